@@ -8,6 +8,7 @@ import (
 	"github.com/quantmind-br/upkg/internal/cmd"
 	"github.com/quantmind-br/upkg/internal/config"
 	"github.com/quantmind-br/upkg/internal/logging"
+	"github.com/rs/zerolog"
 )
 
 var version = "dev"
@@ -15,24 +16,29 @@ var version = "dev"
 func main() {
 	ctx := context.Background()
 
-	// Load configuration
+	// Config and logging are loaded only by commands that need them, keeping
+	// help, version and shell completion fast
+	rt := cmd.NewRuntime(loadRuntime)
+
+	// Execute root command
+	rootCmd := cmd.NewLazyRootCmd(rt, version)
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		rt.Logger().Error().Err(err).Msg("command failed")
+		os.Exit(cmd.ExitCode(err))
+	}
+}
+
+// loadRuntime loads the configuration and initializes the logger
+func loadRuntime() (*config.Config, *zerolog.Logger, error) {
 	cfg, err := config.Load()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
-		os.Exit(1)
+		return nil, nil, fmt.Errorf("error loading config: %w", err)
 	}
 
-	// Initialize logger
 	log := logging.NewLogger(logging.Config{
 		Level:   cfg.Logging.Level,
 		LogFile: cfg.Paths.LogFile,
 		NoColor: cfg.Logging.Color == "never",
 	})
-
-	// Execute root command
-	rootCmd := cmd.NewRootCmd(cfg, log, version)
-	if err := rootCmd.ExecuteContext(ctx); err != nil {
-		log.Error().Err(err).Msg("command failed")
-		os.Exit(1)
-	}
+	return cfg, log, nil
 }
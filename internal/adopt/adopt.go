@@ -0,0 +1,366 @@
+// Package adopt rebuilds install records for applications installed by hand
+// (an AppImage, an unpacked folder or a desktop entry launching one) so upkg
+// can uninstall and upgrade them. Nothing is moved or rewritten; the record
+// points at the files where they are.
+package adopt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/desktop"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/security"
+	"github.com/quantmind-br/upkg/internal/versions"
+	"github.com/spf13/afero"
+)
+
+// iconExts are the icon formats looked up for an Icon= name
+var iconExts = []string{".png", ".svg", ".xpm"}
+
+// Scanner reconstructs install records from files on disk
+type Scanner struct {
+	fs    afero.Fs
+	paths *paths.Resolver
+}
+
+// NewScanner creates a scanner looking for desktop entries, wrappers and
+// icons in the user directories of resolver
+func NewScanner(fs afero.Fs, resolver *paths.Resolver) *Scanner {
+	return &Scanner{fs: fs, paths: resolver}
+}
+
+// launcher is a desktop entry with the program it finally runs
+type launcher struct {
+	path    string
+	entry   *core.DesktopEntry
+	wrapper string // Script in the bin dir the entry runs, if any
+	program string
+}
+
+// Scan builds the record of the installation at target: an AppImage, an
+// application folder or a desktop entry launching one. name overrides the
+// name derived from the desktop entry or file name.
+func (s *Scanner) Scan(target, name string) (*core.InstallRecord, error) {
+	target, err := filepath.Abs(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+
+	var primary *launcher
+	payload := target
+	if strings.EqualFold(filepath.Ext(target), ".desktop") {
+		primary, err = s.readLauncher(target)
+		if err != nil {
+			return nil, err
+		}
+		if primary.program == "" || !filepath.IsAbs(primary.program) {
+			return nil, fmt.Errorf("%s does not launch an installed file (Exec=%s)", filepath.Base(target), primary.entry.Exec)
+		}
+		payload = primary.program
+	}
+
+	info, err := s.fs.Stat(payload)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", payload, err)
+	}
+
+	var record *core.InstallRecord
+	switch {
+	case info.IsDir():
+		record, err = s.folderRecord(payload)
+	case s.isAppImage(payload):
+		record = &core.InstallRecord{PackageType: core.PackageTypeAppImage, InstallPath: payload}
+	case primary != nil:
+		// A desktop entry running a program inside an unpacked folder
+		record, err = s.folderRecord(appRoot(filepath.Dir(payload)))
+	default:
+		return nil, fmt.Errorf("%s is not an AppImage; pass the application folder or its desktop entry", filepath.Base(payload))
+	}
+	if err != nil {
+		return nil, err
+	}
+	record.InstallDate = info.ModTime()
+	if record.InstallDate.IsZero() {
+		record.InstallDate = time.Now()
+	}
+	record.OriginalFile = target
+
+	launchers := s.findLaunchers(record.InstallPath)
+	if primary != nil {
+		launchers = slices.DeleteFunc(launchers, func(l *launcher) bool { return l.path == primary.path })
+		launchers = append([]*launcher{primary}, launchers...)
+	}
+	s.addIntegration(record, launchers)
+
+	if name == "" {
+		name = derivedName(record, launchers)
+	}
+	if err := security.ValidatePackageName(helpers.NormalizeFilename(name)); err != nil {
+		return nil, fmt.Errorf("invalid package name %q: %w (use --name)", name, err)
+	}
+	record.Name = name
+	record.InstallID = helpers.GenerateInstallID(helpers.NormalizeFilename(name))
+	record.Version = versions.Extract(filepath.Base(record.InstallPath))
+	record.Metadata.InstallMethod = core.InstallMethodLocal
+	record.Metadata.WaylandSupport = string(core.WaylandUnknown)
+	record.Metadata.Adopted = true
+
+	return record, nil
+}
+
+// folderRecord returns the record of an application folder, refusing shared
+// directories whose removal on uninstall would take other files with them
+func (s *Scanner) folderRecord(dir string) (*core.InstallRecord, error) {
+	if reason := s.sharedDir(dir); reason != "" {
+		return nil, fmt.Errorf("refusing to adopt %s: %s", dir, reason)
+	}
+	return &core.InstallRecord{PackageType: core.PackageTypeTarball, InstallPath: dir}, nil
+}
+
+// sharedDir explains why dir cannot be owned by one package, or returns ""
+func (s *Scanner) sharedDir(dir string) string {
+	home := s.paths.HomeDir()
+	switch {
+	case dir == "/" || filepath.Dir(dir) == "/":
+		return "it is a top-level system directory"
+	case dir == "/usr" || strings.HasPrefix(dir, "/usr/") && !strings.HasPrefix(dir, "/usr/local/"):
+		return "files under /usr belong to the system package manager"
+	case home != "" && (dir == home || strings.HasPrefix(home, dir+string(filepath.Separator))):
+		return "it contains the home directory"
+	case slices.Contains([]string{
+		"/usr/local", "/usr/local/bin", "/usr/local/share", "/usr/local/lib",
+		s.paths.GetBinDir(), s.paths.GetAppsDir(), s.paths.GetUpkgAppsDir(),
+		filepath.Join(home, "Applications"), filepath.Join(home, ".local"), filepath.Join(home, ".local", "share"),
+	}, dir):
+		return "it holds files of other applications"
+	}
+	return ""
+}
+
+// appRoot returns the application folder of a program directory, stepping
+// out of a bin directory (/opt/app/bin -> /opt/app)
+func appRoot(dir string) string {
+	if filepath.Base(dir) == "bin" {
+		return filepath.Dir(dir)
+	}
+	return dir
+}
+
+// isAppImage reports whether path is an AppImage, by extension or by the
+// AppImage magic in its ELF header
+func (s *Scanner) isAppImage(path string) bool {
+	if strings.EqualFold(filepath.Ext(path), ".appimage") {
+		return true
+	}
+	file, err := s.fs.Open(path)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = file.Close() }()
+	return helpers.AppImageType(file) != helpers.AppImageTypeUnknown
+}
+
+// readLauncher parses the desktop entry at path and follows a wrapper script
+// in the bin dir to the program it runs
+func (s *Scanner) readLauncher(path string) (*launcher, error) {
+	file, err := s.fs.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", path, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	entry, err := desktop.Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	l := &launcher{path: path, entry: entry, program: desktop.ExecProgram(entry.Exec)}
+	if l.program != "" && !filepath.IsAbs(l.program) {
+		// Bare commands are looked up in the bin dir only; others on PATH
+		// belong to the system
+		if candidate := filepath.Join(s.paths.GetBinDir(), l.program); s.exists(candidate) {
+			l.program = candidate
+		}
+	}
+	if filepath.Dir(l.program) == s.paths.GetBinDir() {
+		if target := s.wrapperTarget(l.program); target != "" {
+			l.wrapper = l.program
+			l.program = target
+		}
+	}
+	return l, nil
+}
+
+// wrapperTarget returns the program a launcher in the bin dir starts: the
+// target of a symlink, or the program of the exec line of a shell script
+func (s *Scanner) wrapperTarget(path string) string {
+	if reader, ok := s.fs.(afero.LinkReader); ok {
+		if target, err := reader.ReadlinkIfPossible(path); err == nil {
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(path), target)
+			}
+			return target
+		}
+	}
+
+	file, err := s.fs.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = file.Close() }()
+
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(file, head); err != nil || !bytes.Equal(head, []byte("#!")) {
+		return ""
+	}
+
+	var target string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if rest, ok := strings.CutPrefix(line, "exec "); ok {
+			target = execTarget(rest)
+		}
+	}
+	return target
+}
+
+// execTarget returns the program of a wrapper's exec line: the first
+// absolute path, or the one after "--" when a sandbox tool runs it
+func execTarget(line string) string {
+	fields := strings.Fields(line)
+	if i := slices.Index(fields, "--"); i >= 0 {
+		fields = fields[i+1:]
+	}
+	for _, field := range fields {
+		field = strings.Trim(field, `"'`)
+		if filepath.IsAbs(field) {
+			return field
+		}
+	}
+	return ""
+}
+
+// findLaunchers returns the user desktop entries that launch installPath or
+// a program inside it
+func (s *Scanner) findLaunchers(installPath string) []*launcher {
+	matches, err := afero.Glob(s.fs, filepath.Join(s.paths.GetAppsDir(), "*.desktop"))
+	if err != nil {
+		return nil
+	}
+	slices.Sort(matches)
+
+	var launchers []*launcher
+	for _, path := range matches {
+		l, err := s.readLauncher(path)
+		if err != nil || !within(l.program, installPath) {
+			continue
+		}
+		launchers = append(launchers, l)
+	}
+	return launchers
+}
+
+// addIntegration records the desktop entries, wrapper, exposed binaries and
+// icons that belong to the installation
+func (s *Scanner) addIntegration(record *core.InstallRecord, launchers []*launcher) {
+	for _, l := range launchers {
+		record.Metadata.DesktopFiles = append(record.Metadata.DesktopFiles, l.path)
+		if l.wrapper != "" && record.Metadata.WrapperScript == "" {
+			record.Metadata.WrapperScript = l.wrapper
+		}
+	}
+	if len(launchers) > 0 {
+		record.DesktopFile = launchers[0].path
+	}
+
+	// Links and scripts in the bin dir starting the installation
+	if entries, err := afero.ReadDir(s.fs, s.paths.GetBinDir()); err == nil {
+		for _, entry := range entries {
+			path := filepath.Join(s.paths.GetBinDir(), entry.Name())
+			if path == record.Metadata.WrapperScript || path == record.InstallPath || entry.IsDir() {
+				continue
+			}
+			if !within(s.wrapperTarget(path), record.InstallPath) {
+				continue
+			}
+			switch {
+			case entry.Mode()&os.ModeSymlink != 0 && record.PackageType == core.PackageTypeTarball:
+				record.Metadata.ExposedBins = append(record.Metadata.ExposedBins, path)
+			case record.Metadata.WrapperScript == "":
+				record.Metadata.WrapperScript = path
+			}
+		}
+	}
+
+	for _, l := range launchers {
+		for _, icon := range s.findIcons(l.entry.Icon, record.InstallPath) {
+			if !slices.Contains(record.Metadata.IconFiles, icon) {
+				record.Metadata.IconFiles = append(record.Metadata.IconFiles, icon)
+			}
+		}
+	}
+}
+
+// findIcons returns the user icon files of an Icon= value. Icons inside the
+// installation are left out since they go with it.
+func (s *Scanner) findIcons(icon, installPath string) []string {
+	if icon == "" {
+		return nil
+	}
+	if filepath.IsAbs(icon) {
+		if within(icon, installPath) {
+			return nil
+		}
+		if within(icon, filepath.Dir(s.paths.GetIconsDir())) {
+			if _, err := s.fs.Stat(icon); err == nil {
+				return []string{icon}
+			}
+		}
+		return nil
+	}
+
+	var icons []string
+	for _, ext := range iconExts {
+		matches, err := afero.Glob(s.fs, filepath.Join(s.paths.GetIconsDir(), "*", "apps", icon+ext))
+		if err == nil {
+			icons = append(icons, matches...)
+		}
+	}
+	slices.Sort(icons)
+	return icons
+}
+
+// derivedName picks the record name: the Name= of the primary desktop
+// entry, or the display form of the cleaned file name
+func derivedName(record *core.InstallRecord, launchers []*launcher) string {
+	if len(launchers) > 0 && strings.TrimSpace(launchers[0].entry.Name) != "" {
+		return strings.TrimSpace(launchers[0].entry.Name)
+	}
+	base := filepath.Base(record.InstallPath)
+	if record.PackageType == core.PackageTypeAppImage {
+		base = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	return helpers.FormatDisplayName(helpers.CleanAppName(base))
+}
+
+// exists reports whether path exists
+func (s *Scanner) exists(path string) bool {
+	_, err := s.fs.Stat(path)
+	return err == nil
+}
+
+// within reports whether path is root or lies under it
+func within(path, root string) bool {
+	return path != "" && root != "" && (path == root || strings.HasPrefix(path, root+string(filepath.Separator)))
+}
@@ -0,0 +1,117 @@
+package adopt
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const home = "/home/u"
+
+func newTestScanner(t *testing.T, files map[string]string) *Scanner {
+	t.Helper()
+	fs := afero.NewMemMapFs()
+	for path, content := range files {
+		require.NoError(t, afero.WriteFile(fs, path, []byte(content), 0755))
+	}
+	return NewScanner(fs, paths.NewResolverWithHome(&config.Config{}, home))
+}
+
+func TestScanAppImage(t *testing.T) {
+	appImage := filepath.Join(home, "Applications", "Obsidian-1.5.3.AppImage")
+	desktopFile := filepath.Join(home, ".local/share/applications/obsidian.desktop")
+	icon := filepath.Join(home, ".local/share/icons/hicolor/256x256/apps/obsidian.png")
+	scanner := newTestScanner(t, map[string]string{
+		appImage:    "payload",
+		desktopFile: "[Desktop Entry]\nType=Application\nName=Obsidian\nExec=" + appImage + " %U\nIcon=obsidian\n",
+		icon:        "png",
+		filepath.Join(home, ".local/share/applications/other.desktop"): "[Desktop Entry]\nType=Application\nName=Other\nExec=/opt/other/other\n",
+	})
+
+	record, err := scanner.Scan(appImage, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Obsidian", record.Name)
+	assert.Equal(t, core.PackageTypeAppImage, record.PackageType)
+	assert.Equal(t, "1.5.3", record.Version)
+	assert.Equal(t, appImage, record.InstallPath)
+	assert.Equal(t, desktopFile, record.DesktopFile)
+	assert.Equal(t, []string{desktopFile}, record.Metadata.DesktopFiles)
+	assert.Equal(t, []string{icon}, record.Metadata.IconFiles)
+	assert.Equal(t, core.InstallMethodLocal, record.Metadata.InstallMethod)
+	assert.True(t, record.Metadata.Adopted)
+	assert.NotEmpty(t, record.InstallID)
+}
+
+func TestScanDesktopEntryThroughWrapper(t *testing.T) {
+	desktopFile := filepath.Join(home, ".local/share/applications/zen.desktop")
+	wrapper := filepath.Join(home, ".local/bin/zen")
+	scanner := newTestScanner(t, map[string]string{
+		desktopFile:    "[Desktop Entry]\nType=Application\nName=Zen Browser\nExec=zen %u\nIcon=/opt/zen/browser/icon.png\n",
+		wrapper:        "#!/bin/sh\nexport MOZ_ENABLE_WAYLAND=1\nexec /opt/zen/zen \"$@\"\n",
+		"/opt/zen/zen": "elf",
+	})
+
+	record, err := scanner.Scan(desktopFile, "zen")
+	require.NoError(t, err)
+
+	assert.Equal(t, "zen", record.Name)
+	assert.Equal(t, core.PackageTypeTarball, record.PackageType)
+	assert.Equal(t, "/opt/zen", record.InstallPath)
+	assert.Equal(t, desktopFile, record.OriginalFile)
+	assert.Equal(t, wrapper, record.Metadata.WrapperScript)
+	assert.Equal(t, []string{desktopFile}, record.Metadata.DesktopFiles)
+	assert.Empty(t, record.Metadata.IconFiles, "icons inside the installation go with it")
+}
+
+func TestScanRejects(t *testing.T) {
+	scanner := newTestScanner(t, map[string]string{
+		"/usr/bin/tool":     "elf",
+		home + "/notes.txt": "text",
+		home + "/.local/share/applications/web.desktop": "[Desktop Entry]\nType=Application\nName=Web\nExec=xdg-open https://example.com\n",
+		home + "/.local/share/applications/sys.desktop": "[Desktop Entry]\nType=Application\nName=Sys\nExec=/usr/bin/tool\n",
+	})
+	require.NoError(t, scanner.fs.MkdirAll(home+"/Applications", 0755))
+
+	tests := []struct {
+		name   string
+		target string
+		errMsg string
+	}{
+		{"missing file", home + "/missing.AppImage", "cannot read"},
+		{"plain file", home + "/notes.txt", "is not an AppImage"},
+		{"system directory", "/usr/bin", "refusing to adopt"},
+		{"home directory", home, "refusing to adopt"},
+		{"shared apps folder", home + "/Applications", "refusing to adopt"},
+		{"entry without installed file", home + "/.local/share/applications/web.desktop", "does not launch an installed file"},
+		{"system program", home + "/.local/share/applications/sys.desktop", "refusing to adopt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := scanner.Scan(tt.target, "")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestExecTarget(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{`/opt/app/app "$@"`, "/opt/app/app"},
+		{`env FOO=1 "/opt/app/app" "$@"`, "/opt/app/app"},
+		{`bwrap --ro-bind / / --bind /home/u/.local/share/upkg/sandbox/app /home/u -- /opt/app/app "$@"`, "/opt/app/app"},
+		{`app "$@"`, ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, execTarget(tt.line), tt.line)
+	}
+}
@@ -0,0 +1,204 @@
+// Package appdata finds the configuration, cache and data directories an app
+// leaves in the user's home. They are matched by the names the app is known
+// by, so only directories that exist are ever reported.
+package appdata
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/desktop"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/spf13/afero"
+)
+
+// minNameLength keeps short names ("go", "qt") from matching unrelated
+// directories
+const minNameLength = 3
+
+// shared are directories under the base directories used by many apps; they
+// are never reported, even for an app named after one
+var shared = map[string]bool{
+	"applications": true, "autostart": true, "bin": true, "cache": true, "config": true,
+	"dbus1": true, "dconf": true, "desktopdirectories": true, "environmentd": true,
+	"flatpak": true, "fontconfig": true, "fonts": true, "gnupg": true, "gtk30": true,
+	"gtk40": true, "icons": true, "keyrings": true, "local": true, "menus": true,
+	"mime": true, "pki": true, "pulse": true, "share": true, "ssh": true, "state": true,
+	"systemd": true, "themes": true, "trash": true, "upkg": true, "var": true,
+}
+
+// Dirs are the base directories searched for an app's directories
+type Dirs struct {
+	Home   string // Legacy ~/.name directories and Flatpak's ~/.var/app
+	Config string // $XDG_CONFIG_HOME
+	Cache  string // $XDG_CACHE_HOME
+	Data   string // $XDG_DATA_HOME
+	State  string // $XDG_STATE_HOME
+}
+
+// DirsFor returns the base directories of home, honoring the XDG_*_HOME
+// variables returned by getenv
+func DirsFor(home string, getenv func(string) string) Dirs {
+	base := func(key string, fallback ...string) string {
+		if dir := getenv(key); filepath.IsAbs(dir) {
+			return dir
+		}
+		return filepath.Join(append([]string{home}, fallback...)...)
+	}
+	return Dirs{
+		Home:   home,
+		Config: base("XDG_CONFIG_HOME", ".config"),
+		Cache:  base("XDG_CACHE_HOME", ".cache"),
+		Data:   base("XDG_DATA_HOME", ".local", "share"),
+		State:  base("XDG_STATE_HOME", ".local", "state"),
+	}
+}
+
+// Find returns the existing directories record's app keeps under dirs, sorted.
+// They are matched against the package name, the Name and StartupWMClass of
+// its desktop entry and the last part of reverse-DNS IDs, ignoring case,
+// spaces, dots, dashes and underscores. Directories in record's DataDirs are
+// kept while they exist, even if the app was renamed since.
+func Find(fs afero.Fs, dirs Dirs, record *core.InstallRecord) []string {
+	keys := make(map[string]bool)
+	for _, name := range names(fs, record) {
+		if key := matchKey(name); len(key) >= minNameLength && !shared[key] {
+			keys[key] = true
+		}
+	}
+
+	var found []string
+	for _, base := range []string{dirs.Config, dirs.Cache, dirs.Data, dirs.State} {
+		found = append(found, matchChildren(fs, base, "", keys)...)
+	}
+	found = append(found, matchChildren(fs, dirs.Home, ".", keys)...)
+	if record.PackageType == core.PackageTypeFlatpak {
+		found = append(found, matchChildren(fs, dirs.flatpakApps(), "", keys)...)
+	}
+	for _, dir := range record.Metadata.DataDirs {
+		if dirs.searched(dir) && isDir(fs, dir) {
+			found = append(found, filepath.Clean(dir))
+		}
+	}
+
+	// Never report the install itself or a directory holding it
+	found = slices.DeleteFunc(found, func(dir string) bool {
+		return record.InstallPath != "" && within(record.InstallPath, dir)
+	})
+	slices.Sort(found)
+	return slices.Compact(found)
+}
+
+// flatpakApps is where Flatpak keeps each app's home
+func (d Dirs) flatpakApps() string {
+	return filepath.Join(d.Home, ".var", "app")
+}
+
+// searched reports whether Find could have reported dir: a directory directly
+// under one of d that is not shared between apps
+func (d Dirs) searched(dir string) bool {
+	if !filepath.IsAbs(dir) {
+		return false
+	}
+	dir = filepath.Clean(dir)
+	parent, name := filepath.Dir(dir), filepath.Base(dir)
+	if parent == d.Home {
+		var hidden bool
+		if name, hidden = strings.CutPrefix(name, "."); !hidden {
+			return false
+		}
+	} else if !slices.Contains([]string{d.Config, d.Cache, d.Data, d.State, d.flatpakApps()}, parent) {
+		return false
+	}
+	key := matchKey(name)
+	return len(key) >= minNameLength && !shared[key]
+}
+
+// isDir reports whether path is a directory, not following symlinks
+func isDir(fs afero.Fs, path string) bool {
+	var info os.FileInfo
+	var err error
+	if lstater, ok := fs.(afero.Lstater); ok {
+		info, _, err = lstater.LstatIfPossible(path)
+	} else {
+		info, err = fs.Stat(path)
+	}
+	return err == nil && info.IsDir()
+}
+
+// names returns the names record's app may call its directories
+func names(fs afero.Fs, record *core.InstallRecord) []string {
+	list := []string{record.Name, helpers.NormalizeFilename(record.Name), record.Metadata.ExtractedMeta.StartupWMClass}
+	for _, path := range record.GetDesktopFiles() {
+		entry, err := readEntry(fs, path)
+		if err != nil {
+			continue
+		}
+		list = append(list, entry.Name, entry.StartupWMClass, strings.TrimSuffix(filepath.Base(path), ".desktop"))
+	}
+	if record.PackageType == core.PackageTypeFlatpak {
+		list = append(list, record.InstallID)
+	}
+
+	// com.example.App is usually just App elsewhere
+	for _, name := range list {
+		if parts := strings.Split(name, "."); len(parts) >= 3 {
+			list = append(list, parts[len(parts)-1])
+		}
+	}
+	return list
+}
+
+// readEntry parses the desktop entry at path
+func readEntry(fs afero.Fs, path string) (*core.DesktopEntry, error) {
+	file, err := fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	return desktop.Parse(file)
+}
+
+// matchChildren returns the directories directly under base whose name, once
+// prefix is trimmed, matches one of keys
+func matchChildren(fs afero.Fs, base, prefix string, keys map[string]bool) []string {
+	if base == "" {
+		return nil
+	}
+	entries, err := afero.ReadDir(fs, base)
+	if err != nil {
+		return nil
+	}
+
+	var matched []string
+	for _, entry := range entries {
+		name, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !ok || !entry.IsDir() || entry.Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+		if key := matchKey(name); keys[key] && !shared[key] {
+			matched = append(matched, filepath.Join(base, entry.Name()))
+		}
+	}
+	return matched
+}
+
+// matchKey folds name for comparison: lowercase without separators
+func matchKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '.', '-', '_':
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+}
+
+// within reports whether path is dir or below it
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && filepath.IsLocal(rel)
+}
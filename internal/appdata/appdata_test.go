@@ -0,0 +1,89 @@
+package appdata
+
+import (
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirsFor(t *testing.T) {
+	env := map[string]string{"XDG_CONFIG_HOME": "/cfg", "XDG_CACHE_HOME": "relative"}
+	dirs := DirsFor("/home/me", func(key string) string { return env[key] })
+
+	assert.Equal(t, Dirs{
+		Home:   "/home/me",
+		Config: "/cfg",
+		Cache:  "/home/me/.cache",
+		Data:   "/home/me/.local/share",
+		State:  "/home/me/.local/state",
+	}, dirs)
+}
+
+func TestFind(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	dirs := DirsFor("/home/me", func(string) string { return "" })
+	for _, dir := range []string{
+		"/home/me/.config/Vendor App",
+		"/home/me/.config/vendor-tool",
+		"/home/me/.config/autostart",
+		"/home/me/.config/other-app",
+		"/home/me/.cache/vendorapp",
+		"/home/me/.local/share/com.vendor.VendorApp",
+		"/home/me/.local/share/upkg/apps/vendor-app",
+		"/home/me/.vendor-app",
+		"/home/me/vendor-app",
+	} {
+		require.NoError(t, fs.MkdirAll(dir, 0755))
+	}
+	require.NoError(t, afero.WriteFile(fs, "/home/me/.config/vendor-app.conf", nil, 0644))
+	require.NoError(t, afero.WriteFile(fs, "/home/me/.local/share/applications/vendor-app.desktop",
+		[]byte("[Desktop Entry]\nType=Application\nName=Vendor App\nExec=vendor-app\nStartupWMClass=vendor-tool\n"), 0644))
+
+	record := &core.InstallRecord{
+		Name:        "vendor-app",
+		PackageType: core.PackageTypeTarball,
+		InstallPath: "/home/me/.local/share/upkg/apps/vendor-app",
+		DesktopFile: "/home/me/.local/share/applications/vendor-app.desktop",
+	}
+
+	assert.Equal(t, []string{
+		"/home/me/.cache/vendorapp",
+		"/home/me/.config/Vendor App",
+		"/home/me/.config/vendor-tool",
+		"/home/me/.vendor-app",
+	}, Find(fs, dirs, record))
+
+	t.Run("reverse DNS and flatpak", func(t *testing.T) {
+		require.NoError(t, fs.MkdirAll("/home/me/.var/app/com.vendor.VendorApp", 0755))
+		flatpak := &core.InstallRecord{InstallID: "com.vendor.VendorApp", Name: "com.vendor.VendorApp", PackageType: core.PackageTypeFlatpak}
+
+		assert.Equal(t, []string{
+			"/home/me/.cache/vendorapp",
+			"/home/me/.config/Vendor App",
+			"/home/me/.local/share/com.vendor.VendorApp",
+			"/home/me/.var/app/com.vendor.VendorApp",
+			"/home/me/.vendor-app",
+		}, Find(fs, dirs, flatpak))
+	})
+
+	t.Run("recorded dirs", func(t *testing.T) {
+		require.NoError(t, fs.MkdirAll("/home/me/.config/OldName", 0755))
+		renamed := &core.InstallRecord{Name: "renamed", Metadata: core.Metadata{DataDirs: []string{
+			"/home/me/.config/OldName",
+			"/home/me/.config/Gone",
+			"/home/me/vendor-app",
+			"/home/me/.config/autostart",
+			"/etc",
+		}}}
+
+		assert.Equal(t, []string{"/home/me/.config/OldName"}, Find(fs, dirs, renamed))
+	})
+
+	t.Run("short and shared names", func(t *testing.T) {
+		assert.Empty(t, Find(fs, dirs, &core.InstallRecord{Name: "go"}))
+		assert.Empty(t, Find(fs, dirs, &core.InstallRecord{Name: "autostart"}))
+	})
+}
@@ -0,0 +1,318 @@
+// Package assets picks the release asset (GitHub release, download page)
+// that matches the local machine: CPU architecture, C library and package
+// format.
+package assets
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// Architectures recognized in asset names
+const (
+	ArchX86_64  = "x86_64"
+	ArchAarch64 = "aarch64"
+	ArchArmv7   = "armv7"
+	ArchArmv6   = "armv6"
+	ArchI686    = "i686"
+)
+
+// C libraries recognized in asset names
+const (
+	LibcGNU  = "gnu"
+	LibcMusl = "musl"
+)
+
+// Asset formats, matching the backend names
+const (
+	FormatAppImage = "appimage"
+	FormatTarball  = "tarball"
+	FormatDeb      = "deb"
+	FormatRpm      = "rpm"
+	FormatBinary   = "binary"
+)
+
+// DefaultFormatPreference orders formats when hints do not set one
+var DefaultFormatPreference = []string{FormatAppImage, FormatTarball, FormatDeb, FormatRpm, FormatBinary}
+
+// ErrNoCompatibleAsset is returned when no asset can run on the platform
+var ErrNoCompatibleAsset = errors.New("no compatible release asset")
+
+// Platform describes the machine an asset must run on
+type Platform struct {
+	Arch string
+	Libc string
+}
+
+// Hints steer selection for a package, e.g. from a catalog or manifest entry
+type Hints struct {
+	Arch    string   `yaml:"arch,omitempty"`    // Overrides the detected architecture
+	Libc    string   `yaml:"libc,omitempty"`    // Overrides the detected C library (gnu, musl)
+	Include []string `yaml:"include,omitempty"` // Glob patterns the asset name must match (any)
+	Exclude []string `yaml:"exclude,omitempty"` // Glob patterns that reject an asset
+	Formats []string `yaml:"formats,omitempty"` // Format preference, most preferred first
+}
+
+// Info is what an asset name reveals about its target
+type Info struct {
+	Name   string
+	Arch   string // Empty when the name does not say
+	Libc   string // Empty when the name does not say
+	Format string // Empty for non-installable files (checksums, other OSes)
+}
+
+var archPatterns = []struct {
+	arch    string
+	pattern *regexp.Regexp
+}{
+	{ArchX86_64, tokenPattern(`x86[_-]64|amd64|x64|linux64`)},
+	{ArchAarch64, tokenPattern(`aarch64|arm64|armv8l?`)},
+	{ArchArmv7, tokenPattern(`armv7l?|armv7hf|armhf`)},
+	{ArchArmv6, tokenPattern(`armv6l?|arm|armel`)},
+	{ArchI686, tokenPattern(`i[3-6]86|386|x86|ia32|linux32`)},
+}
+
+var (
+	muslPattern    = tokenPattern(`musl|musleabihf|musleabi|alpine`)
+	gnuPattern     = tokenPattern(`gnu|glibc|gnueabihf|gnueabi`)
+	foreignPattern = tokenPattern(`windows|win32|win64|darwin|macos|osx|apple|freebsd|netbsd|openbsd|android|ios|illumos|solaris`)
+)
+
+// skippedExtensions mark files that accompany releases but are not packages
+var skippedExtensions = []string{
+	".sha256", ".sha512", ".sha1", ".md5", ".sig", ".asc", ".pem", ".crt", ".sbom",
+	".json", ".txt", ".yml", ".yaml", ".exe", ".msi", ".dmg", ".pkg", ".apk", ".zsync",
+	".sha256sum", ".checksums", ".spdx", ".intoto.jsonl", ".blockmap", ".nupkg", ".snap", ".flatpakref",
+}
+
+// tokenPattern matches alternatives delimited by non-alphanumerics
+func tokenPattern(alternatives string) *regexp.Regexp {
+	return regexp.MustCompile(`(?:^|[^a-z0-9])(?:` + alternatives + `)(?:[^a-z0-9]|$)`)
+}
+
+// Classify extracts architecture, C library and format from an asset name
+func Classify(name string) Info {
+	info := Info{Name: name}
+	lower := strings.ToLower(name)
+
+	for _, candidate := range archPatterns {
+		if candidate.pattern.MatchString(lower) {
+			info.Arch = candidate.arch
+			break
+		}
+	}
+
+	switch {
+	case muslPattern.MatchString(lower):
+		info.Libc = LibcMusl
+	case gnuPattern.MatchString(lower):
+		info.Libc = LibcGNU
+	}
+
+	if !foreignPattern.MatchString(lower) {
+		info.Format = formatOf(lower)
+	}
+	return info
+}
+
+// formatOf maps a lowercase asset name to a format by extension
+func formatOf(lower string) string {
+	for _, ext := range skippedExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return ""
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(lower, ".appimage"):
+		return FormatAppImage
+	case strings.HasSuffix(lower, ".deb"):
+		return FormatDeb
+	case strings.HasSuffix(lower, ".rpm"):
+		return FormatRpm
+	}
+	for _, ext := range []string{".tar.gz", ".tgz", ".tar.xz", ".txz", ".tar.bz2", ".tbz2", ".tar.zst", ".tzst", ".tar.lz4", ".zip", ".7z", ".tar"} {
+		if strings.HasSuffix(lower, ext) {
+			return FormatTarball
+		}
+	}
+
+	// Bare executables have no extension; dots only appear inside versions
+	// ("tool-1.2.0-x86_64") or as version-like suffixes ("tool-1.2")
+	if ext := path.Ext(lower); ext == "" || strings.ContainsAny(ext, "-_") || strings.Trim(ext[1:], "0123456789") == "" {
+		return FormatBinary
+	}
+	return ""
+}
+
+// CurrentPlatform describes the running machine; fs is used to detect musl
+func CurrentPlatform(fs afero.Fs) Platform {
+	return Platform{Arch: archFromGOARCH(runtime.GOARCH), Libc: DetectLibc(fs)}
+}
+
+// archFromGOARCH maps Go architecture names to asset architecture names
+func archFromGOARCH(goarch string) string {
+	switch goarch {
+	case "amd64":
+		return ArchX86_64
+	case "arm64":
+		return ArchAarch64
+	case "arm":
+		return ArchArmv7
+	case "386":
+		return ArchI686
+	default:
+		return goarch
+	}
+}
+
+// DetectLibc reports musl when the musl dynamic loader is present, gnu otherwise
+func DetectLibc(fs afero.Fs) string {
+	for _, dir := range []string{"/lib", "/usr/lib"} {
+		if matches, err := afero.Glob(fs, dir+"/ld-musl-*.so.1"); err == nil && len(matches) > 0 {
+			return LibcMusl
+		}
+	}
+	return LibcGNU
+}
+
+// candidate is a compatible asset with its ranking keys
+type candidate struct {
+	info       Info
+	archScore  int
+	formatRank int
+	libcScore  int
+}
+
+// Rank returns the assets that can run on platform, best first
+func Rank(names []string, platform Platform, hints Hints) []Info {
+	if hints.Arch != "" {
+		platform.Arch = hints.Arch
+	}
+	if hints.Libc != "" {
+		platform.Libc = hints.Libc
+	}
+	formats := hints.Formats
+	if len(formats) == 0 {
+		formats = DefaultFormatPreference
+	}
+
+	var candidates []candidate
+	for _, name := range names {
+		if !matchesHints(name, hints) {
+			continue
+		}
+		info := Classify(name)
+		formatRank := indexOf(formats, info.Format)
+		if info.Format == "" || formatRank < 0 {
+			continue
+		}
+		archScore, ok := archCompatibility(platform.Arch, info.Arch)
+		if !ok {
+			continue
+		}
+		libcScore, ok := libcCompatibility(platform.Libc, info.Libc)
+		if !ok {
+			continue
+		}
+		candidates = append(candidates, candidate{info: info, archScore: archScore, formatRank: formatRank, libcScore: libcScore})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		switch {
+		case a.archScore != b.archScore:
+			return a.archScore > b.archScore
+		case a.formatRank != b.formatRank:
+			return a.formatRank < b.formatRank
+		case a.libcScore != b.libcScore:
+			return a.libcScore > b.libcScore
+		default:
+			return len(a.info.Name) < len(b.info.Name)
+		}
+	})
+
+	ranked := make([]Info, 0, len(candidates))
+	for _, c := range candidates {
+		ranked = append(ranked, c.info)
+	}
+	return ranked
+}
+
+// Select returns the best asset for platform or ErrNoCompatibleAsset
+func Select(names []string, platform Platform, hints Hints) (Info, error) {
+	ranked := Rank(names, platform, hints)
+	if len(ranked) == 0 {
+		return Info{}, fmt.Errorf("%w for %s/%s among %d assets", ErrNoCompatibleAsset, platform.Arch, platform.Libc, len(names))
+	}
+	return ranked[0], nil
+}
+
+// archCompatibility scores an asset architecture against the platform.
+// Unlabeled assets are conventionally x86_64 builds, so elsewhere they only
+// rank below explicitly matching ones.
+func archCompatibility(platformArch, assetArch string) (int, bool) {
+	switch {
+	case assetArch == platformArch:
+		return 3, true
+	case assetArch == "" && platformArch == ArchX86_64:
+		return 3, true
+	case assetArch == "":
+		return 1, true
+	case platformArch == ArchArmv7 && assetArch == ArchArmv6:
+		return 2, true
+	default:
+		return 0, false
+	}
+}
+
+// libcCompatibility scores an asset C library against the platform. Musl
+// builds are usually static and also run on glibc systems; glibc builds do
+// not run on musl systems.
+func libcCompatibility(platformLibc, assetLibc string) (int, bool) {
+	switch {
+	case assetLibc == platformLibc:
+		return 3, true
+	case assetLibc == "":
+		return 2, true
+	case platformLibc == LibcGNU && assetLibc == LibcMusl:
+		return 1, true
+	default:
+		return 0, false
+	}
+}
+
+// matchesHints applies the include/exclude glob patterns to an asset name
+func matchesHints(name string, hints Hints) bool {
+	for _, pattern := range hints.Exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if len(hints.Include) == 0 {
+		return true
+	}
+	for _, pattern := range hints.Include {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// indexOf returns the position of value in list, or -1
+func indexOf(list []string, value string) int {
+	for i, item := range list {
+		if strings.EqualFold(item, value) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,148 @@
+package assets
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ripgrepAssets = []string{
+	"ripgrep-14.1.0-aarch64-unknown-linux-gnu.tar.gz",
+	"ripgrep-14.1.0-aarch64-unknown-linux-gnu.tar.gz.sha256",
+	"ripgrep-14.1.0-armv7-unknown-linux-gnueabihf.tar.gz",
+	"ripgrep-14.1.0-armv7-unknown-linux-musleabihf.tar.gz",
+	"ripgrep-14.1.0-i686-unknown-linux-gnu.tar.gz",
+	"ripgrep-14.1.0-x86_64-apple-darwin.tar.gz",
+	"ripgrep-14.1.0-x86_64-pc-windows-msvc.zip",
+	"ripgrep-14.1.0-x86_64-unknown-linux-musl.tar.gz",
+	"ripgrep_14.1.0-1_amd64.deb",
+}
+
+func TestClassify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want Info
+	}{
+		{"tool-1.0-x86_64.AppImage", Info{Arch: ArchX86_64, Format: FormatAppImage}},
+		{"tool_1.0_amd64.deb", Info{Arch: ArchX86_64, Format: FormatDeb}},
+		{"tool-1.0.aarch64.rpm", Info{Arch: ArchAarch64, Format: FormatRpm}},
+		{"tool-linux-arm64", Info{Arch: ArchAarch64, Format: FormatBinary}},
+		{"tool-1.2.0-armv7-unknown-linux-musleabihf", Info{Arch: ArchArmv7, Libc: LibcMusl, Format: FormatBinary}},
+		{"tool-arm-unknown-linux-gnueabihf.tar.xz", Info{Arch: ArchArmv6, Libc: LibcGNU, Format: FormatTarball}},
+		{"tool-linux-386.tar.gz", Info{Arch: ArchI686, Format: FormatTarball}},
+		{"tool-x86_64-unknown-linux-gnu.zip", Info{Arch: ArchX86_64, Libc: LibcGNU, Format: FormatTarball}},
+		{"tool-x86_64-pc-windows-msvc.zip", Info{Arch: ArchX86_64}},
+		{"tool-1.0-x86_64.AppImage.zsync", Info{Arch: ArchX86_64}},
+		{"SHA256SUMS.txt", Info{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tt.want.Name = tt.name
+			assert.Equal(t, tt.want, Classify(tt.name))
+		})
+	}
+}
+
+func TestSelect_ArchAndLibc(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		platform Platform
+		want     string
+	}{
+		{"x86_64 glibc accepts static musl tarball", Platform{ArchX86_64, LibcGNU}, "ripgrep-14.1.0-x86_64-unknown-linux-musl.tar.gz"},
+		{"aarch64 glibc", Platform{ArchAarch64, LibcGNU}, "ripgrep-14.1.0-aarch64-unknown-linux-gnu.tar.gz"},
+		{"armv7 glibc", Platform{ArchArmv7, LibcGNU}, "ripgrep-14.1.0-armv7-unknown-linux-gnueabihf.tar.gz"},
+		{"armv7 musl", Platform{ArchArmv7, LibcMusl}, "ripgrep-14.1.0-armv7-unknown-linux-musleabihf.tar.gz"},
+		{"i686", Platform{ArchI686, LibcGNU}, "ripgrep-14.1.0-i686-unknown-linux-gnu.tar.gz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Select(ripgrepAssets, tt.platform, Hints{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Name)
+		})
+	}
+}
+
+func TestSelect_RejectsGlibcOnMusl(t *testing.T) {
+	t.Parallel()
+
+	_, err := Select([]string{"tool-aarch64-unknown-linux-gnu.tar.gz"}, Platform{ArchAarch64, LibcMusl}, Hints{})
+	assert.ErrorIs(t, err, ErrNoCompatibleAsset)
+}
+
+func TestSelect_RejectsWrongArch(t *testing.T) {
+	t.Parallel()
+
+	_, err := Select([]string{"tool-x86_64.AppImage", "tool_amd64.deb"}, Platform{ArchAarch64, LibcGNU}, Hints{})
+	assert.ErrorIs(t, err, ErrNoCompatibleAsset)
+}
+
+func TestSelect_UnlabeledAssets(t *testing.T) {
+	t.Parallel()
+
+	names := []string{"Tool-1.0.AppImage", "tool-1.0-arm64.tar.gz"}
+
+	got, err := Select(names, Platform{ArchX86_64, LibcGNU}, Hints{})
+	require.NoError(t, err)
+	assert.Equal(t, "Tool-1.0.AppImage", got.Name)
+
+	got, err = Select(names, Platform{ArchAarch64, LibcGNU}, Hints{})
+	require.NoError(t, err)
+	assert.Equal(t, "tool-1.0-arm64.tar.gz", got.Name, "explicit arch beats unlabeled asset off x86_64")
+}
+
+func TestSelect_FormatPreference(t *testing.T) {
+	t.Parallel()
+
+	names := []string{"tool_1.0_amd64.deb", "tool-1.0-x86_64.tar.gz", "tool-1.0-x86_64.AppImage"}
+
+	got, err := Select(names, Platform{ArchX86_64, LibcGNU}, Hints{})
+	require.NoError(t, err)
+	assert.Equal(t, FormatAppImage, got.Format)
+
+	got, err = Select(names, Platform{ArchX86_64, LibcGNU}, Hints{Formats: []string{FormatDeb, FormatTarball}})
+	require.NoError(t, err)
+	assert.Equal(t, FormatDeb, got.Format)
+
+	_, err = Select(names, Platform{ArchX86_64, LibcGNU}, Hints{Formats: []string{FormatRpm}})
+	assert.ErrorIs(t, err, ErrNoCompatibleAsset)
+}
+
+func TestSelect_Hints(t *testing.T) {
+	t.Parallel()
+
+	got, err := Select(ripgrepAssets, Platform{ArchX86_64, LibcGNU}, Hints{Arch: ArchArmv7, Libc: LibcMusl})
+	require.NoError(t, err)
+	assert.Equal(t, "ripgrep-14.1.0-armv7-unknown-linux-musleabihf.tar.gz", got.Name)
+
+	got, err = Select(ripgrepAssets, Platform{ArchX86_64, LibcGNU}, Hints{Include: []string{"*.deb"}})
+	require.NoError(t, err)
+	assert.Equal(t, "ripgrep_14.1.0-1_amd64.deb", got.Name)
+
+	got, err = Select(ripgrepAssets, Platform{ArchAarch64, LibcGNU}, Hints{Exclude: []string{"*-gnu.*"}})
+	assert.ErrorIs(t, err, ErrNoCompatibleAsset)
+	assert.Empty(t, got.Name)
+}
+
+func TestDetectLibc(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	assert.Equal(t, LibcGNU, DetectLibc(fs))
+
+	require.NoError(t, afero.WriteFile(fs, "/lib/ld-musl-x86_64.so.1", []byte("x"), 0755))
+	assert.Equal(t, LibcMusl, DetectLibc(fs))
+}
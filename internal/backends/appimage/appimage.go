@@ -1,9 +1,11 @@
 package appimage
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -14,9 +16,13 @@ import (
 	"github.com/quantmind-br/upkg/internal/core"
 	"github.com/quantmind-br/upkg/internal/desktop"
 	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/heuristics"
 	"github.com/quantmind-br/upkg/internal/icons"
+	"github.com/quantmind-br/upkg/internal/integration"
 	"github.com/quantmind-br/upkg/internal/security"
+	"github.com/quantmind-br/upkg/internal/squashfs"
 	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/quantmind-br/upkg/internal/ui"
 	"github.com/rs/zerolog"
 	"github.com/spf13/afero"
 )
@@ -66,6 +72,30 @@ func (a *AppImageBackend) Name() string {
 	return "appimage"
 }
 
+// UnsquashfsTool extracts AppImages whose runtime cannot extract itself
+var UnsquashfsTool = core.ToolRequirement{
+	Name:     "unsquashfs",
+	Optional: true,
+	Purpose:  "fallback extraction when --appimage-extract fails (zstd, lz4 and lzo payloads)",
+	Packages: map[string]string{core.DistroArch: "squashfs-tools", core.DistroDebian: "squashfs-tools", core.DistroFedora: "squashfs-tools", core.DistroSUSE: "squashfs"},
+}
+
+// RequiredTools lists the external tools used by the AppImage backend
+func (a *AppImageBackend) RequiredTools() []core.ToolRequirement {
+	return []core.ToolRequirement{
+		UnsquashfsTool,
+		{
+			Name:         "bsdtar",
+			Alternatives: []string{"7z"},
+			Optional:     true,
+			Purpose:      "extract type-1 (ISO9660) AppImages",
+			Packages:     map[string]string{core.DistroArch: "libarchive", core.DistroDebian: "libarchive-tools", core.DistroFedora: "bsdtar", core.DistroSUSE: "bsdtar"},
+		},
+		integration.DesktopValidatorTool,
+		integration.SandboxTool,
+	}
+}
+
 // Detect checks if this backend can handle the package
 func (a *AppImageBackend) Detect(_ context.Context, packagePath string) (bool, error) {
 	// Check if file exists
@@ -110,24 +140,47 @@ func (a *AppImageBackend) Detect(_ context.Context, packagePath string) (bool, e
 // Install installs the AppImage package
 //
 //nolint:gocyclo // install flow is inherently branching (metadata, icons, desktop, tx).
-func (a *AppImageBackend) Install(ctx context.Context, packagePath string, opts core.InstallOptions, tx *transaction.Manager) (*core.InstallRecord, error) {
+func (a *AppImageBackend) Install(ctx context.Context, packagePath string, opts core.InstallOptions, tx *transaction.Manager) (*core.InstallResult, error) {
+	result := core.NewInstallResult()
 	a.Log.Info().
 		Str("package_path", packagePath).
 		Str("custom_name", opts.CustomName).
 		Msg("installing AppImage package")
 
 	// Validate package exists
-	if _, err := a.Fs.Stat(packagePath); err != nil {
+	pkgInfo, err := a.Fs.Stat(packagePath)
+	if err != nil {
 		return nil, fmt.Errorf("package not found: %w", err)
 	}
 
+	// The AppImage itself is the installed payload, so check it against the quota
+	if maxBytes := a.Cfg.Limits.MaxPackageBytes(); maxBytes > 0 && pkgInfo.Size() > maxBytes {
+		return nil, fmt.Errorf("%w: %d bytes (max %d)", helpers.ErrQuotaExceeded, pkgInfo.Size(), maxBytes)
+	}
+	if warnBytes := a.Cfg.Limits.WarnPackageBytes(); warnBytes > 0 && pkgInfo.Size() > warnBytes {
+		a.Log.Warn().
+			Int64("size_bytes", pkgInfo.Size()).
+			Int64("warn_mb", a.Cfg.Limits.WarnPackageSizeMB).
+			Msg("package exceeds size warning threshold")
+		result.Warn("package is %d MB, above the %d MB warning threshold", pkgInfo.Size()>>20, a.Cfg.Limits.WarnPackageSizeMB)
+	}
+
+	progress := a.NewProgress(ctx, []ui.InstallationPhase{
+		{Name: "Extracting AppImage", Weight: 45, Deterministic: false}, // Indeterminate - runs the AppImage or unsquashfs
+		{Name: "Installing AppImage", Weight: 30, Deterministic: true},
+		{Name: "Installing icons", Weight: 10, Deterministic: true},
+		{Name: "Configuring desktop", Weight: 15, Deterministic: true},
+	}, "Installing AppImage")
+	defer progress.Finish()
+
 	// Make AppImage executable first
 	if err := a.Fs.Chmod(packagePath, 0755); err != nil {
 		return nil, fmt.Errorf("failed to make AppImage executable: %w", err)
 	}
 
 	// Create temp directory for extraction
-	tmpDir, err := afero.TempDir(a.Fs, "", "upkg-appimage-*")
+	// Named after the file until the install ID is known
+	tmpDir, err := helpers.CreateTempDir(a.Fs, strings.TrimSuffix(filepath.Base(packagePath), filepath.Ext(packagePath)), "appimage")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temp directory: %w", err)
 	}
@@ -138,7 +191,12 @@ func (a *AppImageBackend) Install(ctx context.Context, packagePath string, opts
 	}()
 
 	// Extract AppImage
-	if extractErr := a.extractAppImage(ctx, packagePath, tmpDir); extractErr != nil {
+	progress.StartPhase(0)
+	tickCtx, stopTicking := context.WithCancel(ctx)
+	backendbase.TickIndeterminate(tickCtx, progress, "Extracting AppImage")
+	extractErr := a.extractAppImage(ctx, packagePath, tmpDir)
+	stopTicking()
+	if extractErr != nil {
 		return nil, fmt.Errorf("failed to extract AppImage: %w", extractErr)
 	}
 
@@ -152,6 +210,7 @@ func (a *AppImageBackend) Install(ctx context.Context, packagePath string, opts
 	metadata, err := a.parseAppImageMetadata(squashfsRoot)
 	if err != nil {
 		a.Log.Warn().Err(err).Msg("failed to parse AppImage metadata, using defaults")
+		result.Warn("could not read AppImage metadata, using defaults: %v", err)
 		metadata = &appImageMetadata{
 			appName: opts.CustomName,
 		}
@@ -177,11 +236,20 @@ func (a *AppImageBackend) Install(ctx context.Context, packagePath string, opts
 	}
 	installID := helpers.GenerateInstallID(binName)
 
+	if renamed, renameErr := helpers.RenameTempDir(a.Fs, tmpDir, installID); renameErr != nil {
+		a.Log.Debug().Err(renameErr).Str("tmp_dir", tmpDir).Msg("failed to label temp dir with install ID")
+	} else {
+		tmpDir = renamed
+		squashfsRoot = filepath.Join(tmpDir, "squashfs-root")
+	}
+	a.Log.Debug().Str("install_id", installID).Str("tmp_dir", tmpDir).Msg("using temp dir")
+
 	if a.Paths.HomeDir() == "" {
 		return nil, fmt.Errorf("failed to get home directory")
 	}
 
 	// Copy AppImage to ~/.local/bin/
+	progress.AdvancePhase()
 	binDir := a.Paths.GetBinDir()
 	if mkdirErr := a.Fs.MkdirAll(binDir, 0755); mkdirErr != nil {
 		return nil, fmt.Errorf("failed to create bin directory: %w", mkdirErr)
@@ -190,19 +258,15 @@ func (a *AppImageBackend) Install(ctx context.Context, packagePath string, opts
 	destPath := filepath.Join(binDir, binName+".appimage")
 	if _, statErr := a.Fs.Stat(destPath); statErr == nil {
 		if !opts.Force {
-			return nil, fmt.Errorf("package already installed at: %s (use --force to reinstall)", destPath)
+			return nil, &core.ErrAlreadyInstalled{Path: destPath}
 		}
 		if removeErr := a.Fs.Remove(destPath); removeErr != nil {
 			return nil, fmt.Errorf("remove existing AppImage: %w", removeErr)
 		}
 	}
 
-	content, err := afero.ReadFile(a.Fs, packagePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read AppImage: %w", err)
-	}
-	if writeErr := afero.WriteFile(a.Fs, destPath, content, 0755); writeErr != nil {
-		return nil, fmt.Errorf("failed to copy AppImage: %w", writeErr)
+	if copyErr := a.copyAppImage(packagePath, destPath, pkgInfo.Size(), backendbase.ByteProgress(progress)); copyErr != nil {
+		return nil, copyErr
 	}
 
 	if chmodErr := a.Fs.Chmod(destPath, 0755); chmodErr != nil {
@@ -217,6 +281,7 @@ func (a *AppImageBackend) Install(ctx context.Context, packagePath string, opts
 		tx.Add("remove appimage binary", func() error {
 			return a.Fs.Remove(path)
 		})
+		tx.TrackPaths(path)
 	}
 
 	a.Log.Debug().
@@ -224,7 +289,35 @@ func (a *AppImageBackend) Install(ctx context.Context, packagePath string, opts
 		Str("dest", destPath).
 		Msg("AppImage copied")
 
+	// Sandboxed AppImages are started by a wrapper; FUSE is unavailable in
+	// the sandbox, so the runtime extracts the payload instead of mounting it.
+	// Wrapper args or env also need one.
+	execPath := destPath
+	var wrapperPath, sandboxTool string
+	if opts.Sandbox || len(opts.WrapperArgs) > 0 || len(opts.WrapperEnv) > 0 {
+		if opts.Sandbox {
+			wrapperPath, sandboxTool, err = a.Integration().CreateSandboxedWrapper(binName, destPath, []string{"APPIMAGE_EXTRACT_AND_RUN=1"}, opts, destPath)
+		} else {
+			wrapperPath, err = a.Integration().CreateWrapper(binName, destPath, opts)
+		}
+		if err != nil {
+			if removeErr := a.Fs.Remove(destPath); removeErr != nil {
+				a.Log.Warn().Err(removeErr).Str("path", destPath).Msg("failed to remove AppImage after wrapper error")
+			}
+			return nil, err
+		}
+		if tx != nil {
+			path := wrapperPath
+			tx.Add("remove appimage wrapper script", func() error {
+				return a.Fs.Remove(path)
+			})
+			tx.TrackPaths(path)
+		}
+		execPath = wrapperPath
+	}
+
 	// Install icons
+	progress.AdvancePhase()
 	discoveredIcons := icons.DiscoverIcons(squashfsRoot)
 	a.Log.Debug().
 		Int("count", len(discoveredIcons)).
@@ -240,6 +333,7 @@ func (a *AppImageBackend) Install(ctx context.Context, packagePath string, opts
 	iconPaths, err := a.installIcons(squashfsRoot, binName, metadata)
 	if err != nil {
 		a.Log.Warn().Err(err).Msg("failed to install icons")
+		result.Warn("icons not installed: %v", err)
 	}
 	if tx != nil && len(iconPaths) > 0 {
 		paths := append([]string(nil), iconPaths...)
@@ -247,9 +341,12 @@ func (a *AppImageBackend) Install(ctx context.Context, packagePath string, opts
 			a.removeIcons(paths)
 			return nil
 		})
+		tx.TrackPaths(paths...)
 	}
 
 	// Create/update desktop file
+	progress.AdvancePhase()
+	framework, wayland := heuristics.DetectApp(a.Fs, squashfsRoot, "")
 	var desktopPath string
 	if !opts.SkipDesktop {
 		if opts.Force {
@@ -259,7 +356,7 @@ func (a *AppImageBackend) Install(ctx context.Context, packagePath string, opts
 				a.Log.Debug().Err(removeErr).Str("desktop_file", oldDesktopPath).Msg("failed to remove existing desktop file")
 			}
 		}
-		desktopPath, err = a.createDesktopFile(squashfsRoot, appName, binName, destPath, metadata, opts)
+		desktopPath, err = a.createDesktopFile(squashfsRoot, appName, binName, execPath, metadata, wayland, opts)
 		if err != nil {
 			// Clean up on failure
 			if removeErr := a.Fs.Remove(destPath); removeErr != nil {
@@ -278,6 +375,7 @@ func (a *AppImageBackend) Install(ctx context.Context, packagePath string, opts
 			tx.Add("remove desktop file", func() error {
 				return a.Fs.Remove(path)
 			})
+			tx.TrackPaths(path)
 		}
 
 		// Update caches
@@ -290,6 +388,23 @@ func (a *AppImageBackend) Install(ctx context.Context, packagePath string, opts
 		if cacheErr := a.cacheManager.UpdateIconCache(iconsDir, a.Log); cacheErr != nil {
 			a.Log.Warn().Err(cacheErr).Str("icons_dir", iconsDir).Msg("failed to update icon cache")
 		}
+	} else {
+		result.Skip("desktop entry", "--skip-desktop")
+	}
+
+	// Install AppStream metainfo for software centers
+	metainfoPaths, err := a.Integration().InstallMetainfo(squashfsRoot, desktopPath)
+	if err != nil {
+		a.Log.Warn().Err(err).Msg("failed to install metainfo")
+		result.Warn("AppStream metainfo not installed: %v", err)
+	}
+	if tx != nil && len(metainfoPaths) > 0 {
+		paths := append([]string(nil), metainfoPaths...)
+		tx.Add("remove metainfo", func() error {
+			a.Integration().RemoveFiles(paths)
+			return nil
+		})
+		tx.TrackPaths(paths...)
 	}
 
 	// Create install record
@@ -304,8 +419,12 @@ func (a *AppImageBackend) Install(ctx context.Context, packagePath string, opts
 		DesktopFile:  desktopPath,
 		Metadata: core.Metadata{
 			IconFiles:      iconPaths,
-			WaylandSupport: string(core.WaylandUnknown),
+			MetainfoFiles:  metainfoPaths,
+			WrapperScript:  wrapperPath,
+			WaylandSupport: string(wayland),
+			Framework:      string(framework),
 			InstallMethod:  core.InstallMethodLocal,
+			Sandbox:        sandboxTool,
 			ExtractedMeta: core.ExtractedMetadata{
 				Categories: metadata.categories,
 				Comment:    metadata.comment,
@@ -319,11 +438,12 @@ func (a *AppImageBackend) Install(ctx context.Context, packagePath string, opts
 		Str("path", destPath).
 		Msg("AppImage package installed successfully")
 
-	return record, nil
+	return result.Finish(record), nil
 }
 
 // Uninstall removes the installed AppImage package
-func (a *AppImageBackend) Uninstall(_ context.Context, record *core.InstallRecord) error {
+func (a *AppImageBackend) Uninstall(_ context.Context, record *core.InstallRecord) (*core.UninstallResult, error) {
+	result := core.NewUninstallResult()
 	a.Log.Info().
 		Str("install_id", record.InstallID).
 		Str("name", record.Name).
@@ -333,6 +453,15 @@ func (a *AppImageBackend) Uninstall(_ context.Context, record *core.InstallRecor
 	if record.InstallPath != "" {
 		if err := a.Fs.Remove(record.InstallPath); err != nil {
 			a.Log.Warn().Err(err).Str("path", record.InstallPath).Msg("failed to remove AppImage")
+			result.Warn("failed to remove %s: %v", record.InstallPath, err)
+		}
+	}
+
+	// Remove the wrapper of sandboxed AppImages
+	if record.Metadata.WrapperScript != "" {
+		if err := a.Fs.Remove(record.Metadata.WrapperScript); err != nil {
+			a.Log.Warn().Err(err).Str("path", record.Metadata.WrapperScript).Msg("failed to remove wrapper script")
+			result.Warn("failed to remove %s: %v", record.Metadata.WrapperScript, err)
 		}
 	}
 
@@ -343,12 +472,16 @@ func (a *AppImageBackend) Uninstall(_ context.Context, record *core.InstallRecor
 		}
 		if err := a.Fs.Remove(desktopPath); err != nil {
 			a.Log.Warn().Err(err).Str("path", desktopPath).Msg("failed to remove desktop file")
+			result.Warn("failed to remove %s: %v", desktopPath, err)
 		}
 	}
 
 	// Remove icons
 	a.removeIcons(record.Metadata.IconFiles)
 
+	// Remove AppStream metainfo
+	a.Integration().RemoveFiles(record.Metadata.MetainfoFiles)
+
 	// Update caches
 	appsDir := a.Paths.GetAppsDir()
 	if cacheErr := a.cacheManager.UpdateDesktopDatabase(appsDir, a.Log); cacheErr != nil {
@@ -364,6 +497,32 @@ func (a *AppImageBackend) Uninstall(_ context.Context, record *core.InstallRecor
 		Str("install_id", record.InstallID).
 		Msg("AppImage package uninstalled successfully")
 
+	return result.Finish(), nil
+}
+
+// copyAppImage streams the AppImage to destPath, reporting the bytes copied.
+// A partial copy is removed.
+func (a *AppImageBackend) copyAppImage(packagePath, destPath string, size int64, onProgress helpers.ProgressFunc) error {
+	src, err := a.Fs.Open(packagePath)
+	if err != nil {
+		return fmt.Errorf("failed to read AppImage: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := a.Fs.OpenFile(destPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to copy AppImage: %w", err)
+	}
+	_, copyErr := io.Copy(dst, helpers.NewProgressReader(src, size, onProgress))
+	if closeErr := dst.Close(); copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr != nil {
+		if removeErr := a.Fs.Remove(destPath); removeErr != nil {
+			a.Log.Debug().Err(removeErr).Str("path", destPath).Msg("failed to remove partial AppImage copy")
+		}
+		return fmt.Errorf("failed to copy AppImage: %w", copyErr)
+	}
 	return nil
 }
 
@@ -379,30 +538,127 @@ func (a *AppImageBackend) extractAppImage(ctx context.Context, appImagePath, des
 		return fmt.Errorf("failed to resolve AppImage path: %w", err)
 	}
 
-	// Try --appimage-extract first (runs in destDir)
 	extractCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer cancel()
 
+	if a.appImageType(absAppImagePath) == helpers.AppImageType1 {
+		return a.extractType1(extractCtx, absAppImagePath, filepath.Join(destDir, "squashfs-root"))
+	}
+
+	// Try --appimage-extract first (runs in destDir)
+
 	_, err = a.Runner.RunCommandInDir(extractCtx, destDir, absAppImagePath, "--appimage-extract")
 	if err == nil {
 		return nil
 	}
 
-	a.Log.Warn().Err(err).Msg("--appimage-extract failed, trying unsquashfs")
+	a.Log.Warn().Err(err).Msg("--appimage-extract failed, trying fallback extraction")
+	attempts := []string{fmt.Sprintf("--appimage-extract: %v", err)}
 
-	// Fallback to unsquashfs
-	if !a.Runner.CommandExists("unsquashfs") {
-		return fmt.Errorf("extraction failed and unsquashfs not found: %w", err)
+	// Drop anything the failed runtime left behind
+	rootDir := filepath.Join(destDir, "squashfs-root")
+	if removeErr := a.Fs.RemoveAll(rootDir); removeErr != nil {
+		return fmt.Errorf("failed to clean extraction directory: %w", removeErr)
 	}
 
-	_, err = a.Runner.RunCommand(extractCtx, "unsquashfs", "-d", "squashfs-root", absAppImagePath)
-	if err != nil {
-		return fmt.Errorf("unsquashfs extraction failed: %w", err)
+	if a.Runner.CommandExists("unsquashfs") {
+		_, err = a.Runner.RunCommandInDir(extractCtx, destDir, "unsquashfs", "-d", "squashfs-root", absAppImagePath)
+		if err == nil {
+			return nil
+		}
+		a.Log.Warn().Err(err).Msg("unsquashfs failed, trying built-in squashfs reader")
+		attempts = append(attempts, fmt.Sprintf("unsquashfs: %v", err))
+		if removeErr := a.Fs.RemoveAll(rootDir); removeErr != nil {
+			return fmt.Errorf("failed to clean extraction directory: %w", removeErr)
+		}
+	} else {
+		attempts = append(attempts, "unsquashfs not found")
 	}
 
+	if err := a.extractBuiltin(absAppImagePath, rootDir); err != nil {
+		return fmt.Errorf("extraction failed (%s): %w", strings.Join(attempts, "; "), err)
+	}
 	return nil
 }
 
+// extractBuiltin unpacks the squashfs payload in-process, for systems where
+// the runtime cannot run and unsquashfs is missing
+func (a *AppImageBackend) extractBuiltin(appImagePath, rootDir string) error {
+	file, err := a.Fs.Open(appImagePath)
+	if err != nil {
+		return fmt.Errorf("failed to open AppImage: %w", err)
+	}
+	defer file.Close()
+
+	offset, err := helpers.AppImageOffset(file)
+	if err != nil {
+		return fmt.Errorf("failed to locate squashfs payload: %w", err)
+	}
+	image, err := squashfs.Open(file, offset)
+	if err != nil {
+		if errors.Is(err, squashfs.ErrUnsupportedCompression) {
+			return fmt.Errorf("%w (install unsquashfs to extract it)", err)
+		}
+		return fmt.Errorf("failed to read squashfs payload: %w", err)
+	}
+
+	a.Log.Info().
+		Str("appimage", appImagePath).
+		Str("compression", image.Compression()).
+		Msg("extracting AppImage with built-in squashfs reader")
+	return image.ExtractTo(a.Fs, rootDir)
+}
+
+// appImageType reads the AppImage format of the file; unreadable files
+// report helpers.AppImageTypeUnknown and take the type-2 path
+func (a *AppImageBackend) appImageType(appImagePath string) int {
+	file, err := a.Fs.Open(appImagePath)
+	if err != nil {
+		return helpers.AppImageTypeUnknown
+	}
+	defer file.Close()
+
+	return helpers.AppImageType(file)
+}
+
+// extractType1 unpacks the ISO9660 payload of a type-1 AppImage, whose
+// runtime has no --appimage-extract. The payload goes to rootDir, named
+// like the type-2 squashfs-root so the rest of the install is shared.
+func (a *AppImageBackend) extractType1(ctx context.Context, appImagePath, rootDir string) error {
+	a.Log.Info().Str("appimage", appImagePath).Msg("extracting type-1 (ISO9660) AppImage")
+
+	if err := a.Fs.MkdirAll(rootDir, 0755); err != nil {
+		return fmt.Errorf("failed to create extraction directory: %w", err)
+	}
+
+	var err error
+	switch {
+	case a.Runner.CommandExists("bsdtar"):
+		_, err = a.Runner.RunCommand(ctx, "bsdtar", "-xf", appImagePath, "-C", rootDir)
+	case a.Runner.CommandExists("7z"):
+		_, err = a.Runner.RunCommand(ctx, "7z", "x", "-y", "-o"+rootDir, appImagePath)
+	default:
+		return fmt.Errorf("type-1 AppImage requires bsdtar or 7z to extract: %w", a.MissingTool("bsdtar", a.RequiredTools()))
+	}
+	if err != nil {
+		return fmt.Errorf("type-1 AppImage extraction failed: %w", err)
+	}
+
+	// ISO9660 directories are read-only; make them writable so the
+	// extraction directory can be removed afterwards
+	return afero.Walk(a.Fs, rootDir, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if info.IsDir() && info.Mode().Perm()&0200 == 0 {
+			if chmodErr := a.Fs.Chmod(path, info.Mode().Perm()|0700); chmodErr != nil {
+				return fmt.Errorf("make %s writable: %w", path, chmodErr)
+			}
+		}
+		return nil
+	})
+}
+
 // parseAppImageMetadata extracts metadata from extracted AppImage
 func (a *AppImageBackend) parseAppImageMetadata(squashfsRoot string) (*appImageMetadata, error) {
 	metadata := &appImageMetadata{}
@@ -463,13 +719,6 @@ func (a *AppImageBackend) parseAppImageMetadata(squashfsRoot string) (*appImageM
 
 // installIcons installs all icon files from the AppImage
 func (a *AppImageBackend) installIcons(squashfsRoot, binName string, metadata *appImageMetadata) ([]string, error) {
-	homeDir := a.Paths.HomeDir()
-	if homeDir == "" {
-		return nil, fmt.Errorf("failed to get home directory")
-	}
-
-	installedIcons := []string{}
-
 	// Discover icons in squashfs-root
 	discoveredIcons := icons.DiscoverIcons(squashfsRoot)
 
@@ -483,152 +732,37 @@ func (a *AppImageBackend) installIcons(squashfsRoot, binName string, metadata *a
 		iconName = binName
 	}
 
-	// Install each icon
-	for _, iconFile := range discoveredIcons {
-		targetPath, err := icons.InstallIcon(iconFile, iconName, homeDir)
-		if err != nil {
-			a.Log.Warn().
-				Err(err).
-				Str("icon", iconFile.Path).
-				Msg("failed to install icon")
-			continue
-		}
-
-		installedIcons = append(installedIcons, targetPath)
-		a.Log.Debug().
-			Str("source", iconFile.Path).
-			Str("target", targetPath).
-			Msg("icon installed")
-	}
-
-	return installedIcons, nil
+	return a.Integration().InstallIcons(discoveredIcons, iconName)
 }
 
 // removeIcons removes installed icons
 func (a *AppImageBackend) removeIcons(iconPaths []string) {
-	for _, iconPath := range iconPaths {
-		if err := a.Fs.Remove(iconPath); err != nil {
-			a.Log.Warn().
-				Err(err).
-				Str("path", iconPath).
-				Msg("failed to remove icon")
-		}
-	}
+	a.Integration().RemoveFiles(iconPaths)
 }
 
 // createDesktopFile creates or updates the .desktop file
 //
 //nolint:gocyclo // desktop generation handles multiple formats and environment cases.
-func (a *AppImageBackend) createDesktopFile(squashfsRoot, appName, binName, execPath string, metadata *appImageMetadata, opts core.InstallOptions) (string, error) {
-	appsDir := a.Paths.GetAppsDir()
-	if err := a.Fs.MkdirAll(appsDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create applications directory: %w", err)
-	}
-
-	desktopFilePath := filepath.Join(appsDir, binName+".desktop")
-
-	var entry *core.DesktopEntry
-
-	// Try to use existing .desktop file from AppImage
-	if metadata.desktopFile != "" {
-		file, err := a.Fs.Open(metadata.desktopFile)
-		if err == nil {
-			defer func() {
-				if closeErr := file.Close(); closeErr != nil {
-					a.Log.Debug().Err(closeErr).Str("desktop_file", metadata.desktopFile).Msg("failed to close desktop file")
-				}
-			}()
-			if parsed, parseErr := desktop.Parse(file); parseErr == nil {
-				entry = parsed
-			} else {
-				a.Log.Debug().Err(parseErr).Str("desktop_file", metadata.desktopFile).Msg("failed to parse desktop file from AppImage")
-			}
-		}
-	}
-
-	// Create default entry if parsing failed
-	if entry == nil {
-		entry = &core.DesktopEntry{
-			Type:    "Application",
-			Version: "1.5",
-			Name:    appName,
-		}
-	}
-
-	// Update Exec to point to installed AppImage
-	entry.Exec = execPath
-
-	// Check for Electron structure to apply sandbox fix if needed
-	isElectron := false
-	if _, err := a.Fs.Stat(filepath.Join(squashfsRoot, "resources", "app.asar")); err == nil {
-		isElectron = true
-	}
-
-	if a.Cfg.Desktop.ElectronDisableSandbox && isElectron {
-		entry.Exec += " --no-sandbox"
-	}
-
-	entry.Exec += " %U"
-
-	// Set icon (use icon name from embedded .desktop file if available, otherwise binName)
-	iconName := metadata.icon
-	if iconName == "" {
-		iconName = binName
-	}
-	entry.Icon = iconName
-
-	// Ensure categories
-	if len(entry.Categories) == 0 {
-		entry.Categories = []string{"Utility"}
-	}
-
-	// Detect Tauri apps (they use WebKitGTK and require specific environment handling)
-	isTauriApp := strings.Contains(strings.ToLower(entry.StartupWMClass), "tauri")
-
-	// Inject Wayland environment variables (skip for Tauri apps or if explicitly disabled)
-	if a.Cfg.Desktop.WaylandEnvVars && !opts.SkipWaylandEnv && !isTauriApp {
-		if err := desktop.InjectWaylandEnvVars(entry, a.Cfg.Desktop.CustomEnvVars); err != nil {
-			a.Log.Warn().
-				Err(err).
-				Str("app", appName).
-				Msg("invalid custom Wayland env vars, injecting defaults only")
-			if fallbackErr := desktop.InjectWaylandEnvVars(entry, nil); fallbackErr != nil {
-				a.Log.Warn().Err(fallbackErr).Str("app", appName).Msg("failed to inject default Wayland env vars")
-			}
-		}
-	} else if isTauriApp {
-		a.Log.Info().
-			Str("app", appName).
-			Str("wm_class", entry.StartupWMClass).
-			Msg("detected Tauri app, skipping Wayland environment injection")
-	} else if opts.SkipWaylandEnv {
-		a.Log.Info().
-			Str("app", appName).
-			Msg("skipping Wayland environment injection per user request")
-	}
-
-	var buf bytes.Buffer
-	if err := desktop.Write(&buf, entry); err != nil {
-		return "", err
-	}
-	if err := afero.WriteFile(a.Fs, desktopFilePath, buf.Bytes(), 0644); err != nil {
-		return "", err
-	}
-
-	// Validate
-	if a.Runner.CommandExists("desktop-file-validate") {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		if _, err := a.Runner.RunCommand(ctx, "desktop-file-validate", desktopFilePath); err != nil {
-			a.Log.Warn().
-				Err(err).
-				Str("desktop_file", desktopFilePath).
-				Msg("desktop file validation failed")
+func (a *AppImageBackend) createDesktopFile(squashfsRoot, appName, binName, execPath string, metadata *appImageMetadata, wayland core.WaylandSupport, opts core.InstallOptions) (string, error) {
+	spec := integration.DesktopSpec{
+		AppName:       appName,
+		FileName:      binName,
+		ExecPath:      execPath,
+		IconName:      metadata.icon,
+		SourceDesktop: metadata.desktopFile,
+		PayloadRoot:   squashfsRoot,
+		Toolkit:       heuristics.DetectFramework(a.Fs, squashfsRoot, ""),
+		Wayland:       wayland,
+	}
+
+	// Electron AppImages need --no-sandbox when the sandbox is disabled by config
+	if a.Cfg.Desktop.ElectronDisableSandbox {
+		if _, err := a.Fs.Stat(filepath.Join(squashfsRoot, "resources", "app.asar")); err == nil {
+			spec.ExecArgs = append(spec.ExecArgs, "--no-sandbox")
 		}
 	}
 
-	return desktopFilePath, nil
+	return a.Integration().WriteDesktopEntry(spec, opts)
 }
 
 // Helper types
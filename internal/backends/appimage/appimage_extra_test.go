@@ -50,7 +50,7 @@ Categories=Utility;`
 	execPath := "/opt/testapp.TestImage"
 	opts := core.InstallOptions{}
 
-	resultPath, err := backend.createDesktopFile(squashfsRoot, appName, binName, execPath, metadata, opts)
+	resultPath, err := backend.createDesktopFile(squashfsRoot, appName, binName, execPath, metadata, core.WaylandUnknown, opts)
 	_ = resultPath
 	_ = err
 }
@@ -80,7 +80,7 @@ func TestAppImageBackend_createDesktopFile_WithoutDesktop(t *testing.T) {
 	execPath := "/opt/testapp.TestImage"
 	opts := core.InstallOptions{}
 
-	resultPath, err := backend.createDesktopFile(squashfsRoot, appName, binName, execPath, metadata, opts)
+	resultPath, err := backend.createDesktopFile(squashfsRoot, appName, binName, execPath, metadata, core.WaylandUnknown, opts)
 	_ = resultPath
 	_ = err
 }
@@ -117,7 +117,7 @@ func TestAppImageBackend_createDesktopFile_WithElectron(t *testing.T) {
 	execPath := "/opt/electronapp.AppImage"
 	opts := core.InstallOptions{}
 
-	resultPath, err := backend.createDesktopFile(squashfsRoot, appName, binName, execPath, metadata, opts)
+	resultPath, err := backend.createDesktopFile(squashfsRoot, appName, binName, execPath, metadata, core.WaylandUnknown, opts)
 	_ = resultPath
 	_ = err
 }
@@ -151,7 +151,7 @@ func TestAppImageBackend_createDesktopFile_WithWaylandEnv(t *testing.T) {
 		SkipWaylandEnv: false,
 	}
 
-	resultPath, err := backend.createDesktopFile(squashfsRoot, appName, binName, execPath, metadata, opts)
+	resultPath, err := backend.createDesktopFile(squashfsRoot, appName, binName, execPath, metadata, core.WaylandUnknown, opts)
 	_ = resultPath
 	_ = err
 }
@@ -185,7 +185,7 @@ func TestAppImageBackend_createDesktopFile_SkipWaylandEnv(t *testing.T) {
 		SkipWaylandEnv: true, // Skip Wayland env
 	}
 
-	resultPath, err := backend.createDesktopFile(squashfsRoot, appName, binName, execPath, metadata, opts)
+	resultPath, err := backend.createDesktopFile(squashfsRoot, appName, binName, execPath, metadata, core.WaylandUnknown, opts)
 	_ = resultPath
 	_ = err
 }
@@ -228,7 +228,7 @@ Exec=tauriapp`
 	execPath := "/opt/tauriapp.AppImage"
 	opts := core.InstallOptions{}
 
-	resultPath, err := backend.createDesktopFile(squashfsRoot, appName, binName, execPath, metadata, opts)
+	resultPath, err := backend.createDesktopFile(squashfsRoot, appName, binName, execPath, metadata, core.WaylandUnknown, opts)
 	_ = resultPath
 	_ = err
 }
@@ -261,7 +261,7 @@ func TestAppImageBackend_createDesktopFile_WithCustomEnvVars(t *testing.T) {
 	execPath := "/opt/testapp.AppImage"
 	opts := core.InstallOptions{}
 
-	resultPath, err := backend.createDesktopFile(squashfsRoot, appName, binName, execPath, metadata, opts)
+	resultPath, err := backend.createDesktopFile(squashfsRoot, appName, binName, execPath, metadata, core.WaylandUnknown, opts)
 	_ = resultPath
 	_ = err
 }
@@ -380,7 +380,7 @@ func TestAppImageBackend_Uninstall(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	err := backend.Uninstall(ctx, record)
+	_, err := backend.Uninstall(ctx, record)
 	_ = err
 }
 
@@ -2,6 +2,7 @@ package appimage
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"os"
@@ -32,13 +33,33 @@ func TestAppImageBackend_Install_PackageNotFound(t *testing.T) {
 	packagePath := "/nonexistent/test.AppImage"
 	tx := transaction.NewManager(&logger)
 
-	record, err := backend.Install(ctx, packagePath, core.InstallOptions{}, tx)
+	result, err := backend.Install(ctx, packagePath, core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "package not found")
 	assert.Nil(t, record)
 }
 
+// TestAppImageBackend_Install_ExceedsQuota tests Install rejects packages over the size quota
+func TestAppImageBackend_Install_ExceedsQuota(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Limits: config.LimitsConfig{MaxPackageSizeMB: 1}}
+	logger := zerolog.New(io.Discard)
+	fs := afero.NewMemMapFs()
+	backend := NewWithDeps(cfg, &logger, fs, &helpers.MockCommandRunner{})
+
+	packagePath := "/tmp/big.AppImage"
+	require.NoError(t, afero.WriteFile(fs, packagePath, make([]byte, 2*1024*1024), 0755))
+
+	result, err := backend.Install(context.Background(), packagePath, core.InstallOptions{}, transaction.NewManager(&logger))
+	record := result.GetRecord()
+
+	assert.ErrorIs(t, err, helpers.ErrQuotaExceeded)
+	assert.Nil(t, record)
+}
+
 // TestAppImageBackend_Install_InvalidFormat tests Install with invalid AppImage
 func TestAppImageBackend_Install_InvalidFormat(t *testing.T) {
 	t.Parallel()
@@ -61,7 +82,8 @@ func TestAppImageBackend_Install_InvalidFormat(t *testing.T) {
 	ctx := context.Background()
 	tx := transaction.NewManager(&logger)
 
-	record, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{}, tx)
+	result, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Nil(t, record)
@@ -104,7 +126,8 @@ func TestAppImageBackend_Install_WithForce(t *testing.T) {
 	tx := transaction.NewManager(&logger)
 
 	// With force, should try to remove existing dir
-	record, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{Force: true}, tx)
+	result, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{Force: true}, tx)
+	record := result.GetRecord()
 
 	// Will fail during extraction but should attempt removal
 	assert.Error(t, err)
@@ -132,7 +155,8 @@ func TestAppImageBackend_Install_SkipDesktop(t *testing.T) {
 	ctx := context.Background()
 	tx := transaction.NewManager(&logger)
 
-	record, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{SkipDesktop: true}, tx)
+	result, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{SkipDesktop: true}, tx)
+	record := result.GetRecord()
 
 	// Should fail during extraction
 	assert.Error(t, err)
@@ -163,7 +187,8 @@ func TestAppImageBackend_Install_SkipWaylandEnv(t *testing.T) {
 	ctx := context.Background()
 	tx := transaction.NewManager(&logger)
 
-	record, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{SkipWaylandEnv: true}, tx)
+	result, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{SkipWaylandEnv: true}, tx)
+	record := result.GetRecord()
 
 	// Should fail during extraction
 	assert.Error(t, err)
@@ -191,7 +216,8 @@ func TestAppImageBackend_Install_WithCustomName(t *testing.T) {
 	ctx := context.Background()
 	tx := transaction.NewManager(&logger)
 
-	record, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{CustomName: "CustomApp"}, tx)
+	result, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{CustomName: "CustomApp"}, tx)
+	record := result.GetRecord()
 
 	// Should fail during extraction
 	assert.Error(t, err)
@@ -220,7 +246,8 @@ func TestAppImageBackend_Install_InvalidCustomName(t *testing.T) {
 	tx := transaction.NewManager(&logger)
 
 	// Custom name with invalid characters
-	record, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{CustomName: "../../etc/passwd"}, tx)
+	result, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{CustomName: "../../etc/passwd"}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Contains(t, strings.ToLower(err.Error()), "invalid")
@@ -253,7 +280,8 @@ func TestAppImageBackend_Install_NoHomeDirectory(t *testing.T) {
 	ctx := context.Background()
 	tx := transaction.NewManager(&logger)
 
-	record, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{}, tx)
+	result, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	// Should fail because HOME is not set
 	assert.Error(t, err)
@@ -285,7 +313,8 @@ func TestAppImageBackend_Install_NilTransaction(t *testing.T) {
 	ctx := context.Background()
 
 	// Pass nil transaction
-	record, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{}, nil)
+	result, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{}, nil)
+	record := result.GetRecord()
 
 	// Should fail during extraction
 	assert.Error(t, err)
@@ -323,7 +352,8 @@ func TestAppImageBackend_Install_MkdirFailure(t *testing.T) {
 	ctx := context.Background()
 	tx := transaction.NewManager(&logger)
 
-	record, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{}, tx)
+	result, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Nil(t, record)
@@ -365,7 +395,8 @@ func TestAppImageBackend_Install_ExtractFailure(t *testing.T) {
 	ctx := context.Background()
 	tx := transaction.NewManager(&logger)
 
-	record, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{}, tx)
+	result, err := backend.Install(ctx, fakeAppImage, core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Nil(t, record)
@@ -438,7 +469,7 @@ func TestAppImageBackend_Uninstall_WithDesktopFile(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	err := backend.Uninstall(ctx, record)
+	_, err := backend.Uninstall(ctx, record)
 
 	// May succeed or fail gracefully
 	_ = err
@@ -476,7 +507,7 @@ func TestAppImageBackend_Uninstall_WithIconFiles(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	err := backend.Uninstall(ctx, record)
+	_, err := backend.Uninstall(ctx, record)
 
 	// Should succeed - icons should be removed
 	assert.NoError(t, err)
@@ -533,6 +564,84 @@ func TestAppImageBackend_extractAppImage_UnsquashfsNotFound(t *testing.T) {
 	assert.Contains(t, err.Error(), "unsquashfs not found")
 }
 
+// fakeAppImage writes an AppImage made of the test executable as runtime and
+// a SquashFS image whose root directory is empty, compressed with compression
+func fakeAppImage(t *testing.T, path string, compression uint16) {
+	t.Helper()
+
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	runtime, err := os.ReadFile(exe)
+	require.NoError(t, err)
+
+	le := binary.LittleEndian
+	image := make([]byte, 96)
+	le.PutUint32(image[0:], 0x73717368) // Magic
+	le.PutUint32(image[4:], 1)          // Inode count
+	le.PutUint32(image[12:], 4096)      // Block size
+	le.PutUint16(image[20:], compression)
+	le.PutUint16(image[22:], 12) // Block log
+	le.PutUint16(image[28:], 4)  // Version 4.0
+	le.PutUint64(image[64:], 96) // Inode table start
+	le.PutUint64(image[72:], 96) // Directory table start (unused: root is empty)
+
+	// One uncompressed metadata block holding the root directory inode
+	inode := make([]byte, 32)
+	le.PutUint16(inode[0:], 1)    // Basic directory
+	le.PutUint16(inode[2:], 0755) // Permissions
+	le.PutUint32(inode[12:], 1)   // Inode number
+	le.PutUint32(inode[20:], 2)   // Link count
+	le.PutUint16(inode[24:], 3)   // Listing size of an empty directory
+	image = le.AppendUint16(image, uint16(len(inode))|0x8000)
+	image = append(image, inode...)
+
+	require.NoError(t, os.WriteFile(path, append(runtime, image...), 0755))
+}
+
+// TestAppImageBackend_extractAppImage_BuiltinFallback tests extraction without
+// a working runtime or unsquashfs
+func TestAppImageBackend_extractAppImage_BuiltinFallback(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.New(io.Discard)
+	mockRunner := &helpers.MockCommandRunner{
+		CommandExistsFunc: func(string) bool { return false },
+		RunCommandInDirFunc: func(_ context.Context, dir, _ string, _ ...string) (string, error) {
+			// A runtime that fails after creating part of the tree
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, "squashfs-root", "partial"), 0755))
+			return "", fmt.Errorf("fuse: failed to exec fusermount")
+		},
+	}
+	backend := NewWithDeps(&config.Config{}, &logger, afero.NewOsFs(), mockRunner)
+
+	t.Run("gzip payload", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		appImage := filepath.Join(tmpDir, "test.AppImage")
+		fakeAppImage(t, appImage, 1)
+
+		outputDir := filepath.Join(tmpDir, "output")
+		require.NoError(t, os.MkdirAll(outputDir, 0755))
+		require.NoError(t, backend.extractAppImage(context.Background(), appImage, outputDir))
+
+		entries, err := os.ReadDir(filepath.Join(outputDir, "squashfs-root"))
+		require.NoError(t, err)
+		assert.Empty(t, entries, "leftovers of the failed runtime are removed")
+	})
+
+	t.Run("zstd payload", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		appImage := filepath.Join(tmpDir, "test.AppImage")
+		fakeAppImage(t, appImage, 6)
+
+		outputDir := filepath.Join(tmpDir, "output")
+		require.NoError(t, os.MkdirAll(outputDir, 0755))
+		err := backend.extractAppImage(context.Background(), appImage, outputDir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "fusermount")
+		assert.Contains(t, err.Error(), "zstd (install unsquashfs to extract it)")
+	})
+}
+
 // TestAppImageBackend_extractAppImage_InvalidOutputDir tests extraction when output dir creation fails
 func TestAppImageBackend_extractAppImage_InvalidOutputDir(t *testing.T) {
 	t.Parallel()
@@ -562,3 +671,72 @@ func TestAppImageBackend_extractAppImage_InvalidOutputDir(t *testing.T) {
 
 	assert.Error(t, err)
 }
+
+// writeType1AppImage writes a stub carrying the type-1 AppImage magic
+func writeType1AppImage(t *testing.T, fs afero.Fs, path string) {
+	t.Helper()
+	header := []byte{0x7F, 'E', 'L', 'F', 2, 1, 1, 0, 'A', 'I', 1}
+	require.NoError(t, afero.WriteFile(fs, path, append(header, make([]byte, 64)...), 0755))
+}
+
+// TestAppImageBackend_extractAppImage_Type1 tests that ISO9660 AppImages are unpacked with bsdtar, or 7z without it
+func TestAppImageBackend_extractAppImage_Type1(t *testing.T) {
+	t.Parallel()
+
+	for _, tool := range []string{"bsdtar", "7z"} {
+		t.Run(tool, func(t *testing.T) {
+			t.Parallel()
+
+			logger := zerolog.New(io.Discard)
+			fs := afero.NewMemMapFs()
+			appImage := "/tmp/legacy.AppImage"
+			writeType1AppImage(t, fs, appImage)
+
+			var calls []string
+			mockRunner := &helpers.MockCommandRunner{
+				CommandExistsFunc: func(name string) bool { return name == tool },
+				RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+					calls = append(calls, name+" "+strings.Join(args, " "))
+					// ISO9660 extractions leave read-only directories
+					root := "/out/squashfs-root"
+					require.NoError(t, afero.WriteFile(fs, filepath.Join(root, "usr", "bin", "legacy"), []byte("bin"), 0755))
+					require.NoError(t, fs.Chmod(filepath.Join(root, "usr"), 0555))
+					return "", nil
+				},
+				RunCommandInDirFunc: func(context.Context, string, string, ...string) (string, error) {
+					t.Fatal("type-1 runtimes have no --appimage-extract")
+					return "", nil
+				},
+			}
+			backend := NewWithDeps(&config.Config{}, &logger, fs, mockRunner)
+
+			require.NoError(t, backend.extractAppImage(context.Background(), appImage, "/out"))
+
+			want := map[string]string{
+				"bsdtar": "bsdtar -xf /tmp/legacy.AppImage -C /out/squashfs-root",
+				"7z":     "7z x -y -o/out/squashfs-root /tmp/legacy.AppImage",
+			}
+			assert.Equal(t, []string{want[tool]}, calls)
+
+			info, err := fs.Stat("/out/squashfs-root/usr")
+			require.NoError(t, err)
+			assert.NotZero(t, info.Mode().Perm()&0200, "extracted directories are made writable for cleanup")
+		})
+	}
+}
+
+// TestAppImageBackend_extractAppImage_Type1NoTools tests the error when neither bsdtar nor 7z is available
+func TestAppImageBackend_extractAppImage_Type1NoTools(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.New(io.Discard)
+	fs := afero.NewMemMapFs()
+	writeType1AppImage(t, fs, "/tmp/legacy.AppImage")
+	mockRunner := &helpers.MockCommandRunner{
+		CommandExistsFunc: func(string) bool { return false },
+	}
+	backend := NewWithDeps(&config.Config{}, &logger, fs, mockRunner)
+
+	err := backend.extractAppImage(context.Background(), "/tmp/legacy.AppImage", "/out")
+	assert.ErrorContains(t, err, "requires bsdtar or 7z")
+}
@@ -131,7 +131,8 @@ func TestInstall_PackageNotFound(t *testing.T) {
 	backend := New(cfg, &logger)
 	tx := transaction.NewManager(&logger)
 
-	record, err := backend.Install(context.Background(), "/nonexistent/app.AppImage", core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), "/nonexistent/app.AppImage", core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "package not found")
@@ -153,9 +154,10 @@ func TestInstall_InvalidCustomName(t *testing.T) {
 	require.NoError(t, os.WriteFile(fakeAppImage, []byte("fake content"), 0755))
 
 	// Try to install - will fail on extraction, not name validation
-	record, err := backend.Install(context.Background(), fakeAppImage, core.InstallOptions{
+	result, err := backend.Install(context.Background(), fakeAppImage, core.InstallOptions{
 		CustomName: "valid-name", // Use valid name to test extraction path
 	}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err) // Fails during extraction
 	assert.Nil(t, record)
@@ -174,7 +176,8 @@ func TestInstall_ExtractionFailure(t *testing.T) {
 	backend := New(cfg, &logger)
 	tx := transaction.NewManager(&logger)
 
-	record, err := backend.Install(context.Background(), appImageFile, core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), appImageFile, core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	// We expect an error because the fake AppImage won't extract properly
 	assert.Error(t, err)
@@ -219,7 +222,7 @@ func TestUninstall(t *testing.T) {
 			},
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.NoError(t, err)
 
 		// Verify files are removed
@@ -246,7 +249,7 @@ func TestUninstall(t *testing.T) {
 			},
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.NoError(t, err)
 	})
 
@@ -263,7 +266,7 @@ func TestUninstall(t *testing.T) {
 			PackageType: core.PackageTypeAppImage,
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.NoError(t, err)
 	})
 }
@@ -383,7 +386,8 @@ func TestInstall_AlreadyInstalled(t *testing.T) {
 	require.NoError(t, os.WriteFile(destPath, []byte("existing"), 0755))
 
 	tx := transaction.NewManager(&logger)
-	record, err := backend.Install(context.Background(), sourceAppImage, core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), sourceAppImage, core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	// Should fail because extracting fails (not real AppImage)
 	// but we're testing that it reaches that point
@@ -829,7 +833,7 @@ Icon=test-icon`
 			icon:    "test-icon",
 		}
 
-		resultPath, err := backend.createDesktopFile(squashfsRoot, "TestApp", "test-app", execPath, metadata, core.InstallOptions{})
+		resultPath, err := backend.createDesktopFile(squashfsRoot, "TestApp", "test-app", execPath, metadata, core.WaylandUnknown, core.InstallOptions{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, resultPath)
 		assert.Contains(t, resultPath, ".desktop")
@@ -846,7 +850,7 @@ Icon=test-icon`
 
 		metadata := &appImageMetadata{}
 
-		resultPath, err := backend.createDesktopFile(squashfsRoot, "TestApp", "test-app", execPath, metadata, core.InstallOptions{})
+		resultPath, err := backend.createDesktopFile(squashfsRoot, "TestApp", "test-app", execPath, metadata, core.WaylandUnknown, core.InstallOptions{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, resultPath)
 		assert.Contains(t, resultPath, ".desktop")
@@ -877,7 +881,7 @@ Icon=test-icon`
 			appName: "TestApp",
 		}
 
-		resultPath, err := backend.createDesktopFile(squashfsRoot, "TestApp", "test-app", execPath, metadata, core.InstallOptions{})
+		resultPath, err := backend.createDesktopFile(squashfsRoot, "TestApp", "test-app", execPath, metadata, core.WaylandUnknown, core.InstallOptions{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, resultPath)
 
@@ -904,7 +908,7 @@ Icon=test-icon`
 			appName: "TestApp",
 		}
 
-		resultPath, err := backend.createDesktopFile(squashfsRoot, "TestApp", "test-app", execPath, metadata, core.InstallOptions{})
+		resultPath, err := backend.createDesktopFile(squashfsRoot, "TestApp", "test-app", execPath, metadata, core.WaylandUnknown, core.InstallOptions{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, resultPath)
 
@@ -1166,7 +1170,7 @@ func TestCreateDesktopFile_ErrorCases(t *testing.T) {
 			appName: "TestApp",
 		}
 
-		_, err := backend.createDesktopFile(nonExistentRoot, "TestApp", "test", execPath, metadata, core.InstallOptions{})
+		_, err := backend.createDesktopFile(nonExistentRoot, "TestApp", "test", execPath, metadata, core.WaylandUnknown, core.InstallOptions{})
 		// The function should handle this gracefully
 		_ = err
 	})
@@ -1181,7 +1185,7 @@ func TestCreateDesktopFile_ErrorCases(t *testing.T) {
 
 		metadata := &appImageMetadata{}
 
-		resultPath, err := backend.createDesktopFile(squashfsRoot, "", "", execPath, metadata, core.InstallOptions{})
+		resultPath, err := backend.createDesktopFile(squashfsRoot, "", "", execPath, metadata, core.WaylandUnknown, core.InstallOptions{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, resultPath)
 	})
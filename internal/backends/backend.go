@@ -3,21 +3,23 @@ package backends
 import (
 	"bytes"
 	"context"
-	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
-	"strings"
+	"sort"
+	"sync"
 
 	"github.com/quantmind-br/upkg/internal/backends/appimage"
+	backendbase "github.com/quantmind-br/upkg/internal/backends/base"
 	"github.com/quantmind-br/upkg/internal/backends/binary"
 	"github.com/quantmind-br/upkg/internal/backends/deb"
 	"github.com/quantmind-br/upkg/internal/backends/flatpak"
+	"github.com/quantmind-br/upkg/internal/backends/plugin"
 	"github.com/quantmind-br/upkg/internal/backends/rpm"
 	"github.com/quantmind-br/upkg/internal/backends/tarball"
 	"github.com/quantmind-br/upkg/internal/config"
 	"github.com/quantmind-br/upkg/internal/core"
 	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/paths"
 	"github.com/quantmind-br/upkg/internal/transaction"
 	"github.com/rs/zerolog"
 	"github.com/spf13/afero"
@@ -37,16 +39,87 @@ type Backend interface {
 	Detect(ctx context.Context, packagePath string) (bool, error)
 
 	// Install installs the package
-	Install(ctx context.Context, packagePath string, opts core.InstallOptions, tx *transaction.Manager) (*core.InstallRecord, error)
+	Install(ctx context.Context, packagePath string, opts core.InstallOptions, tx *transaction.Manager) (*core.InstallResult, error)
 
 	// Uninstall removes the installed package
-	Uninstall(ctx context.Context, record *core.InstallRecord) error
+	Uninstall(ctx context.Context, record *core.InstallRecord) (*core.UninstallResult, error)
+}
+
+// MetadataSyncer is implemented by backends whose installs are owned by a
+// system package manager, so their records can be refreshed from it
+type MetadataSyncer interface {
+	// SyncMetadata updates record from the package manager and reports
+	// whether anything changed
+	SyncMetadata(ctx context.Context, record *core.InstallRecord) (bool, error)
+}
+
+// Factory creates a backend with the registry's shared dependencies
+type Factory func(cfg *config.Config, log *zerolog.Logger, fs afero.Fs, runner helpers.CommandRunner) Backend
+
+// Detection priorities: DetectBackend tries lower values first. The gaps
+// leave room for registered backends between the built-in ones.
+const (
+	PriorityFlatpak  = 100 // App IDs must be detected before file-based formats
+	PriorityPlugin   = 150 // External plugins claim their formats before the built-in ones
+	PriorityDeb      = 200
+	PriorityRpm      = 300
+	PriorityAppImage = 400 // AppImages are also ELF: must precede Binary
+	PriorityBinary   = 500 // Standalone ELF binaries
+	PriorityTarball  = 600 // Generic archive fallback
+)
+
+// registration is a backend made available with Register
+type registration struct {
+	priority int
+	factory  Factory
+}
+
+var (
+	registrationsMu sync.RWMutex
+	registrations   = make(map[string]registration)
+)
+
+func init() {
+	Register("flatpak", PriorityFlatpak, func(cfg *config.Config, log *zerolog.Logger, fs afero.Fs, runner helpers.CommandRunner) Backend {
+		return flatpak.NewWithDeps(cfg, log, fs, runner)
+	})
+	Register("deb", PriorityDeb, func(cfg *config.Config, log *zerolog.Logger, fs afero.Fs, runner helpers.CommandRunner) Backend {
+		return deb.NewWithDeps(cfg, log, fs, runner)
+	})
+	Register("rpm", PriorityRpm, func(cfg *config.Config, log *zerolog.Logger, fs afero.Fs, runner helpers.CommandRunner) Backend {
+		return rpm.NewWithDeps(cfg, log, fs, runner)
+	})
+	Register("appimage", PriorityAppImage, func(cfg *config.Config, log *zerolog.Logger, fs afero.Fs, runner helpers.CommandRunner) Backend {
+		return appimage.NewWithDeps(cfg, log, fs, runner)
+	})
+	Register("binary", PriorityBinary, func(cfg *config.Config, log *zerolog.Logger, fs afero.Fs, runner helpers.CommandRunner) Backend {
+		return binary.NewWithDeps(cfg, log, fs, runner)
+	})
+	Register(packageTypeTarball, PriorityTarball, func(cfg *config.Config, log *zerolog.Logger, fs afero.Fs, runner helpers.CommandRunner) Backend {
+		return tarball.NewWithDeps(cfg, log, fs, runner)
+	})
+}
+
+// Register makes a backend available to every registry created afterwards.
+// Like database/sql.Register, it panics when name is empty or already
+// registered, or factory is nil.
+func Register(name string, priority int, factory Factory) {
+	registrationsMu.Lock()
+	defer registrationsMu.Unlock()
+	if name == "" || factory == nil {
+		panic("backends: Register needs a name and a factory")
+	}
+	if _, dup := registrations[name]; dup {
+		panic("backends: Register called twice for " + name)
+	}
+	registrations[name] = registration{priority: priority, factory: factory}
 }
 
 // Registry manages all available backends
 type Registry struct {
 	backends []Backend
 	logger   *zerolog.Logger
+	runner   helpers.CommandRunner
 }
 
 // NewRegistry creates a backend registry with all backends
@@ -54,31 +127,53 @@ func NewRegistry(cfg *config.Config, log *zerolog.Logger) *Registry {
 	return NewRegistryWithDeps(cfg, log, afero.NewOsFs(), helpers.NewOSCommandRunner())
 }
 
-// NewRegistryWithDeps cria um registry com dependências comuns injetadas.
-// O fs será usado conforme os backends forem migrados para DI.
+// NewRegistryWithDeps creates a registry with the registered backends and
+// the plugins of the plugins directory, ordered by detection priority
 func NewRegistryWithDeps(cfg *config.Config, log *zerolog.Logger, fs afero.Fs, runner helpers.CommandRunner) *Registry {
-	registry := &Registry{
-		backends: make([]Backend, 0),
-		logger:   log,
+	type candidate struct {
+		name     string
+		priority int
+		build    func() Backend
 	}
 
-	// Register backends in priority order
-	// 0. Flatpak (App IDs must be detected before file-based formats)
-	registry.backends = append(registry.backends, flatpak.NewWithDeps(cfg, log, fs, runner))
-
-	// 1. DEB and RPM (specific format detection)
-	registry.backends = append(registry.backends, deb.NewWithDeps(cfg, log, fs, runner))
-	registry.backends = append(registry.backends, rpm.NewWithDeps(cfg, log, fs, runner))
-
-	// 2. AppImage must come before Binary (AppImages are also ELF)
-	registry.backends = append(registry.backends, appimage.NewWithDeps(cfg, log, fs, runner))
-
-	// 3. Binary (catches standalone ELF binaries)
-	registry.backends = append(registry.backends, binary.NewWithDeps(cfg, log, fs, runner))
+	registrationsMu.RLock()
+	candidates := make([]candidate, 0, len(registrations))
+	for name, reg := range registrations {
+		factory := reg.factory
+		candidates = append(candidates, candidate{name, reg.priority, func() Backend {
+			return factory(cfg, log, fs, runner)
+		}})
+	}
+	registrationsMu.RUnlock()
+
+	for _, found := range plugin.Discover(fs, paths.NewResolver(cfg).GetPluginsDir()) {
+		registrationsMu.RLock()
+		_, taken := registrations[found.Name]
+		registrationsMu.RUnlock()
+		if taken {
+			log.Warn().Str("plugin", found.Path).Msg("backend plugin ignored: a built-in backend has the same name")
+			continue
+		}
+		candidates = append(candidates, candidate{found.Name, PriorityPlugin, func() Backend {
+			return plugin.New(backendbase.NewWithDeps(cfg, log, fs, runner), found.Name, found.Path)
+		}})
+	}
 
-	// 4. Tarball/Zip (archive formats)
-	registry.backends = append(registry.backends, tarball.NewWithDeps(cfg, log, fs, runner))
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].priority != candidates[j].priority {
+			return candidates[i].priority < candidates[j].priority
+		}
+		return candidates[i].name < candidates[j].name
+	})
 
+	registry := &Registry{
+		backends: make([]Backend, 0, len(candidates)),
+		logger:   log,
+		runner:   runner,
+	}
+	for _, c := range candidates {
+		registry.backends = append(registry.backends, c.build())
+	}
 	return registry
 }
 
@@ -112,45 +207,15 @@ func (r *Registry) DetectBackend(ctx context.Context, packagePath string) (Backe
 	return nil, r.createDetectionError(packagePath)
 }
 
-// createDetectionError creates a detailed error message for unsupported packages
+// createDetectionError creates the error for unsupported packages, noting
+// what the file looks like so the CLI can suggest an alternative
 func (r *Registry) createDetectionError(packagePath string) error {
-	// Try to detect file type
 	fileType, detectErr := r.detectFileType(packagePath)
 	if detectErr != nil {
 		r.logger.Debug().Err(detectErr).Str("package_path", packagePath).Msg("failed to detect file type")
 		fileType = ""
 	}
-
-	errorMsg := fmt.Sprintf("cannot detect package type for: %s", packagePath)
-
-	if fileType != "" {
-		errorMsg += fmt.Sprintf("\n\nDetected file type: %s", fileType)
-	}
-
-	errorMsg += "\n\nSupported package types:"
-	errorMsg += "\n  • AppImage (.AppImage)"
-	errorMsg += "\n  • DEB (.deb)"
-	errorMsg += "\n  • RPM (.rpm)"
-	errorMsg += "\n  • Tarball (.tar.gz, .tar.xz, .tar.bz2, .tgz)"
-	errorMsg += "\n  • Zip (.zip)"
-	errorMsg += "\n  • ELF Binary (executable files)"
-
-	if fileType == "shell script" || fileType == "text" {
-		errorMsg += "\n\nNote: Shell scripts and text files are not supported as standalone packages."
-		errorMsg += "\nWorkaround: Package your script in a tarball (.tar.gz) with any required assets."
-	}
-
-	ext := strings.ToLower(filepath.Ext(packagePath))
-	switch ext {
-	case ".flatpak", ".flatpakref", ".flatpakrepo":
-		errorMsg += "\n\nNote: This looks like a Flatpak package."
-		errorMsg += "\nUse: flatpak install <file-or-ref>"
-	case ".snap":
-		errorMsg += "\n\nNote: This looks like a Snap package."
-		errorMsg += "\nUse: sudo snap install <file.snap>"
-	}
-
-	return errors.New(errorMsg)
+	return &core.ErrUnsupportedFormat{Path: packagePath, FileType: fileType}
 }
 
 // detectFileType attempts to detect the file type
@@ -211,6 +276,11 @@ func (r *Registry) detectFileType(packagePath string) (string, error) {
 		return packageTypeTarball, nil
 	}
 
+	// Check for zstd (tar.zst) and lz4 (tar.lz4)
+	if n >= 4 && (bytes.Equal(buf[:4], []byte{0x28, 0xB5, 0x2F, 0xFD}) || bytes.Equal(buf[:4], []byte{0x04, 0x22, 0x4D, 0x18})) {
+		return packageTypeTarball, nil
+	}
+
 	return "", fmt.Errorf("unknown file type")
 }
 
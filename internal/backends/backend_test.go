@@ -8,10 +8,14 @@ import (
 	"testing"
 
 	"github.com/quantmind-br/upkg/internal/backends/base"
+	"github.com/quantmind-br/upkg/internal/backends/plugin"
 	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
 	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/transaction"
 	"github.com/rs/zerolog"
 	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -23,6 +27,56 @@ func TestNewRegistry_OrderIsPreserved(t *testing.T) {
 	require.Equal(t, []string{"flatpak", "deb", "rpm", "appimage", "binary", "tarball"}, registry.ListBackends())
 }
 
+// fakeBackend is a registered backend that detects nothing
+type fakeBackend struct{ name string }
+
+func (f fakeBackend) Name() string                                 { return f.name }
+func (f fakeBackend) Detect(context.Context, string) (bool, error) { return false, nil }
+func (f fakeBackend) Install(context.Context, string, core.InstallOptions, *transaction.Manager) (*core.InstallResult, error) {
+	return nil, nil
+}
+func (f fakeBackend) Uninstall(context.Context, *core.InstallRecord) (*core.UninstallResult, error) {
+	return nil, nil
+}
+
+func TestRegister(t *testing.T) {
+	Register("acme", PriorityDeb+50, func(*config.Config, *zerolog.Logger, afero.Fs, helpers.CommandRunner) Backend {
+		return fakeBackend{name: "acme"}
+	})
+	t.Cleanup(func() {
+		registrationsMu.Lock()
+		delete(registrations, "acme")
+		registrationsMu.Unlock()
+	})
+
+	logger := zerolog.New(io.Discard)
+	registry := NewRegistryWithDeps(&config.Config{}, &logger, afero.NewMemMapFs(), &helpers.MockCommandRunner{})
+	assert.Equal(t, []string{"flatpak", "deb", "acme", "rpm", "appimage", "binary", "tarball"}, registry.ListBackends())
+
+	assert.Panics(t, func() {
+		Register("acme", PriorityDeb, func(*config.Config, *zerolog.Logger, afero.Fs, helpers.CommandRunner) Backend { return nil })
+	})
+}
+
+func TestNewRegistry_Plugins(t *testing.T) {
+	t.Parallel()
+	fs := afero.NewMemMapFs()
+	cfg := &config.Config{}
+	cfg.Paths.DataDir = "/data"
+	require.NoError(t, afero.WriteFile(fs, "/data/plugins/upkg-backend-acme", []byte("#!/bin/sh"), 0o755))
+	require.NoError(t, afero.WriteFile(fs, "/data/plugins/upkg-backend-deb", []byte("#!/bin/sh"), 0o755))
+
+	logger := zerolog.New(io.Discard)
+	registry := NewRegistryWithDeps(cfg, &logger, fs, &helpers.MockCommandRunner{})
+
+	// Plugins are tried after Flatpak and before the file formats; one
+	// named like a built-in backend is ignored
+	assert.Equal(t, []string{"flatpak", "acme", "deb", "rpm", "appimage", "binary", "tarball"}, registry.ListBackends())
+	backend, err := registry.GetBackend("acme")
+	require.NoError(t, err)
+	assert.IsType(t, &plugin.Backend{}, backend)
+}
+
 func TestBaseBackend_New(t *testing.T) {
 	t.Parallel()
 	cfg := &config.Config{}
@@ -142,7 +196,10 @@ func TestCreateDetectionError(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "cannot detect package type")
 	require.Contains(t, err.Error(), "test-file.deb")
-	require.Contains(t, err.Error(), "Supported package types")
+
+	var unsupported *core.ErrUnsupportedFormat
+	require.ErrorAs(t, err, &unsupported)
+	assert.Equal(t, "test-file.deb", unsupported.Path)
 }
 
 func TestDetectFileType(t *testing.T) {
@@ -294,34 +351,18 @@ func TestDetectFileType_AdditionalTypes(t *testing.T) {
 	})
 }
 
-func TestCreateDetectionError_SpecialFormats(t *testing.T) {
+func TestCreateDetectionError_FileType(t *testing.T) {
 	t.Parallel()
 	cfg := &config.Config{}
 	logger := zerolog.New(io.Discard)
 	registry := NewRegistry(cfg, &logger)
 
-	t.Run("detects flatpak extension", func(t *testing.T) {
-		err := registry.createDetectionError("test-file.flatpak")
-		require.Error(t, err)
-		require.Contains(t, err.Error(), "Flatpak package")
-		require.Contains(t, err.Error(), "flatpak install")
-	})
+	tmpDir := t.TempDir()
+	scriptPath := filepath.Join(tmpDir, "test.sh")
+	require.NoError(t, os.WriteFile(scriptPath, []byte("#!/bin/bash\necho test"), 0755))
 
-	t.Run("detects snap extension", func(t *testing.T) {
-		err := registry.createDetectionError("test-file.snap")
-		require.Error(t, err)
-		require.Contains(t, err.Error(), "Snap package")
-		require.Contains(t, err.Error(), "snap install")
-	})
-
-	t.Run("includes shell script hint for script files", func(t *testing.T) {
-		tmpDir := t.TempDir()
-		scriptPath := filepath.Join(tmpDir, "test.sh")
-		require.NoError(t, os.WriteFile(scriptPath, []byte("#!/bin/bash\necho test"), 0755))
-
-		err := registry.createDetectionError(scriptPath)
-		require.Error(t, err)
-		require.Contains(t, err.Error(), "Shell scripts and text files are not supported")
-		require.Contains(t, err.Error(), "tarball")
-	})
+	var unsupported *core.ErrUnsupportedFormat
+	require.ErrorAs(t, registry.createDetectionError(scriptPath), &unsupported)
+	assert.Equal(t, "shell script", unsupported.FileType)
+	assert.Contains(t, unsupported.Error(), "detected: shell script")
 }
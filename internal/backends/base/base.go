@@ -1,8 +1,12 @@
 package base
 
 import (
+	"time"
+
 	"github.com/quantmind-br/upkg/internal/config"
 	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/heuristics"
+	"github.com/quantmind-br/upkg/internal/integration"
 	"github.com/quantmind-br/upkg/internal/paths"
 	"github.com/rs/zerolog"
 	"github.com/spf13/afero"
@@ -35,3 +39,29 @@ func NewWithDeps(cfg *config.Config, log *zerolog.Logger, fs afero.Fs, runner he
 		Cfg:    cfg,
 	}
 }
+
+// Integration retorna o engine de integração desktop compartilhado pelos backends locais.
+func (b *BaseBackend) Integration() *integration.Engine {
+	return integration.NewEngine(b.Fs, b.Runner, b.Paths, b.Cfg, b.Log)
+}
+
+// RetryPolicy retorna a política de retentativa configurada para comandos
+// idempotentes que dependem da rede (conversão com debtap, flatpak install).
+func (b *BaseBackend) RetryPolicy() helpers.RetryPolicy {
+	if b.Cfg == nil {
+		return helpers.RetryPolicy{}
+	}
+	return helpers.RetryPolicy{
+		Attempts: b.Cfg.System.CommandRetries + 1,
+		Delay:    b.Cfg.System.CommandRetryDelay(),
+		MaxDelay: time.Minute,
+	}
+}
+
+// ScoringRules retorna as regras [executables] da configuração usadas na escolha do executável principal.
+func ScoringRules(cfg *config.Config) heuristics.ScoringRules {
+	if cfg == nil {
+		return heuristics.ScoringRules{}
+	}
+	return heuristics.ScoringRules{Prefer: cfg.Executables.Prefer, Ignore: cfg.Executables.Ignore}
+}
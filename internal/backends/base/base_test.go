@@ -1,11 +1,15 @@
 package base
 
 import (
+	"context"
+	"errors"
 	"io"
 	"testing"
 
 	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
 	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/ui"
 	"github.com/rs/zerolog"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
@@ -66,3 +70,60 @@ func TestNewWithNilLogger(t *testing.T) {
 	require.NotNil(t, backend.Runner)
 	require.NotNil(t, backend.Paths)
 }
+
+func TestNewProgress(t *testing.T) {
+	phases := []ui.InstallationPhase{{Name: "Extracting", Weight: 100, Deterministic: true}}
+
+	var events []ui.ProgressEvent
+	ctx := ui.WithProgressSink(context.Background(), func(e ui.ProgressEvent) { events = append(events, e) })
+	logger := zerolog.New(io.Discard)
+	progress := NewWithDeps(&config.Config{}, &logger, afero.NewMemMapFs(), &helpers.MockCommandRunner{}).NewProgress(ctx, phases, "Installing")
+	require.True(t, progress.IsEnabled())
+
+	progress.StartPhase(0)
+	onBytes := ByteProgress(progress)
+	onBytes(1, 4000) // below one step: same as the phase start
+	onBytes(1, 4000) // unchanged steps are dropped
+	onBytes(2000, 4000)
+	onBytes(4000, 4000)
+	onBytes(10, 0) // unknown totals are ignored
+
+	var percents []float64
+	for _, event := range events {
+		if event.Type == ui.ProgressPercent {
+			percents = append(percents, event.Percent)
+		}
+	}
+	require.Equal(t, []float64{0, 50, 100}, percents)
+}
+
+func TestRequireCommand(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	fs := afero.NewMemMapFs()
+	runner := &helpers.MockCommandRunner{
+		RequireCommandFunc: func(name string) error {
+			if name == "bsdtar" {
+				return nil
+			}
+			return errors.New("command not found")
+		},
+	}
+	backend := NewWithDeps(&config.Config{}, &logger, fs, runner)
+
+	reqs := []core.ToolRequirement{{
+		Name:     "unsquashfs",
+		Packages: map[string]string{core.DistroDebian: "squashfs-tools"},
+	}}
+	require.NoError(t, backend.RequireCommand("bsdtar", reqs))
+
+	// Without os-release the hint names the package generically
+	var missing *core.ErrMissingTool
+	require.ErrorAs(t, backend.RequireCommand("unsquashfs", reqs), &missing)
+	require.Equal(t, "unsquashfs", missing.Tool)
+	require.Equal(t, "install: unsquashfs", missing.InstallHint)
+
+	require.NoError(t, afero.WriteFile(fs, "/etc/os-release", []byte("ID=ubuntu\nID_LIKE=debian\n"), 0644))
+	require.ErrorAs(t, backend.RequireCommand("unsquashfs", reqs), &missing)
+	require.Equal(t, "sudo apt install squashfs-tools", missing.InstallHint)
+	require.Equal(t, "sudo apt install 7z", backend.MissingTool("7z", reqs).InstallHint, "undeclared tools fall back to their name")
+}
@@ -0,0 +1,20 @@
+package base
+
+import (
+	"github.com/quantmind-br/upkg/internal/helpers"
+)
+
+// ExtractOptions devolve as opções de extração comuns aos backends: a cota
+// por pacote, com aviso no log ao passar do limiar, e os limites contra
+// archive bombs configurados em [limits].
+func (b *BaseBackend) ExtractOptions() []helpers.ExtractOption {
+	limits := b.Cfg.Limits
+	quota := helpers.WithSizeQuota(limits.MaxPackageBytes(), limits.WarnPackageBytes(), func(total int64) {
+		b.Log.Warn().
+			Int64("extracted_bytes", total).
+			Int64("warn_mb", limits.WarnPackageSizeMB).
+			Msg("package exceeds size warning threshold")
+	})
+	bombLimits := helpers.WithLimits(limits.MaxExtractedBytes(), limits.MaxExtractedFiles, limits.MaxCompressionRatio)
+	return []helpers.ExtractOption{quota, bombLimits}
+}
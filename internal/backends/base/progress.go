@@ -0,0 +1,56 @@
+package base
+
+import (
+	"context"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+)
+
+// byteProgressSteps é a resolução com que ByteProgress repassa bytes ao progresso
+const byteProgressSteps = 1000
+
+// NewProgress cria o progresso por fases de uma instalação. Com um sink no
+// contexto os eventos vão para ele; senão a barra é desenhada no terminal,
+// exceto quando o nível de log esconde mensagens informativas.
+func (b *BaseBackend) NewProgress(ctx context.Context, phases []ui.InstallationPhase, description string) ui.Progress {
+	enabled := b.Log.GetLevel() != zerolog.Disabled && b.Log.GetLevel() <= zerolog.InfoLevel
+	return ui.NewProgress(ctx, phases, description, enabled)
+}
+
+// ByteProgress adapta contagens de bytes (extração, cópia) à fase atual de
+// progress, repassando apenas mudanças visíveis para não inundar os sinks.
+func ByteProgress(progress ui.Progress) helpers.ProgressFunc {
+	last := -1
+	return func(done, total int64) {
+		if total <= 0 {
+			return
+		}
+		step := int(min(done, total) * byteProgressSteps / total)
+		if step == last {
+			return
+		}
+		last = step
+		progress.SetProgress(step, byteProgressSteps)
+	}
+}
+
+// TickIndeterminate atualiza uma fase indeterminada com o tempo decorrido a
+// cada segundo, até ctx terminar.
+func TickIndeterminate(ctx context.Context, progress ui.Progress, message string) {
+	go func() {
+		ticker := time.NewTicker(1 * time.Second)
+		defer ticker.Stop()
+		start := time.Now()
+		for {
+			select {
+			case <-ticker.C:
+				progress.UpdateIndeterminateWithElapsed(message, time.Since(start))
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,29 @@
+package base
+
+import (
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/syspkg"
+)
+
+// MissingTool cria o erro de ferramenta ausente com o comando que a instala
+// na distro do host, a partir da requisição declarada em reqs (normalmente o
+// RequiredTools do backend).
+func (b *BaseBackend) MissingTool(tool string, reqs []core.ToolRequirement) *core.ErrMissingTool {
+	req := core.ToolRequirement{Name: tool}
+	for _, candidate := range reqs {
+		if candidate.Name == tool {
+			req = candidate
+			break
+		}
+	}
+	return &core.ErrMissingTool{Tool: tool, InstallHint: syspkg.InstallHint(syspkg.DetectDistroFamily(b.Fs), req)}
+}
+
+// RequireCommand verifica tool com o runner e, quando ele não está no PATH,
+// retorna um ErrMissingTool com a dica de instalação.
+func (b *BaseBackend) RequireCommand(tool string, reqs []core.ToolRequirement) error {
+	if err := b.Runner.RequireCommand(tool); err != nil {
+		return b.MissingTool(tool, reqs)
+	}
+	return nil
+}
@@ -15,6 +15,8 @@ import (
 	"github.com/quantmind-br/upkg/internal/core"
 	"github.com/quantmind-br/upkg/internal/desktop"
 	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/heuristics"
+	"github.com/quantmind-br/upkg/internal/integration"
 	"github.com/quantmind-br/upkg/internal/security"
 	"github.com/quantmind-br/upkg/internal/transaction"
 	"github.com/rs/zerolog"
@@ -60,6 +62,11 @@ func (b *BinaryBackend) Name() string {
 	return "binary"
 }
 
+// RequiredTools lists the external tools used by the binary backend
+func (b *BinaryBackend) RequiredTools() []core.ToolRequirement {
+	return []core.ToolRequirement{integration.DesktopValidatorTool}
+}
+
 // Detect checks if this backend can handle the package
 func (b *BinaryBackend) Detect(_ context.Context, packagePath string) (bool, error) {
 	// Check if file exists
@@ -79,7 +86,8 @@ func (b *BinaryBackend) Detect(_ context.Context, packagePath string) (bool, err
 // Install installs the binary package
 //
 //nolint:gocyclo // install flow includes optional desktop integration and rollback hooks.
-func (b *BinaryBackend) Install(_ context.Context, packagePath string, opts core.InstallOptions, tx *transaction.Manager) (*core.InstallRecord, error) {
+func (b *BinaryBackend) Install(_ context.Context, packagePath string, opts core.InstallOptions, tx *transaction.Manager) (*core.InstallResult, error) {
+	result := core.NewInstallResult()
 	b.Log.Info().
 		Str("package_path", packagePath).
 		Str("custom_name", opts.CustomName).
@@ -115,7 +123,7 @@ func (b *BinaryBackend) Install(_ context.Context, packagePath string, opts core
 	destPath := filepath.Join(binDir, binName)
 	if _, err := b.Fs.Stat(destPath); err == nil {
 		if !opts.Force {
-			return nil, fmt.Errorf("package already installed at: %s (use --force to reinstall)", destPath)
+			return nil, &core.ErrAlreadyInstalled{Path: destPath}
 		}
 		if err := b.Fs.Remove(destPath); err != nil {
 			return nil, fmt.Errorf("remove existing binary: %w", err)
@@ -136,6 +144,7 @@ func (b *BinaryBackend) Install(_ context.Context, packagePath string, opts core
 		tx.Add("remove binary", func() error {
 			return b.Fs.Remove(path)
 		})
+		tx.TrackPaths(path)
 	}
 
 	b.Log.Debug().
@@ -148,15 +157,18 @@ func (b *BinaryBackend) Install(_ context.Context, packagePath string, opts core
 		desktopPath string
 		err         error
 	)
-	if !opts.SkipDesktop {
-		if opts.Force {
-			appsDir := b.Paths.GetAppsDir()
-			oldDesktopPath := filepath.Join(appsDir, binName+".desktop")
-			if removeErr := b.Fs.Remove(oldDesktopPath); removeErr != nil {
-				b.Log.Debug().Err(removeErr).Str("desktop_file", oldDesktopPath).Msg("failed to remove existing desktop file")
-			}
+	if opts.Force {
+		appsDir := b.Paths.GetAppsDir()
+		oldDesktopPath := filepath.Join(appsDir, binName+".desktop")
+		if removeErr := b.Fs.Remove(oldDesktopPath); removeErr != nil {
+			b.Log.Debug().Err(removeErr).Str("desktop_file", oldDesktopPath).Msg("failed to remove existing desktop file")
 		}
-		desktopPath, err = b.createDesktopFile(appName, binName, destPath, opts)
+	}
+	framework, wayland := heuristics.DetectApp(b.Fs, "", destPath)
+
+	// Most standalone binaries are command-line tools, so the menu entry is opt-in
+	if opts.Desktop && !opts.SkipDesktop {
+		desktopPath, err = b.createDesktopFile(appName, binName, destPath, wayland, opts)
 		if err != nil {
 			// Clean up binary on desktop file creation failure
 			if removeErr := b.Fs.Remove(destPath); removeErr != nil {
@@ -174,6 +186,7 @@ func (b *BinaryBackend) Install(_ context.Context, packagePath string, opts core
 			tx.Add("remove desktop file", func() error {
 				return b.Fs.Remove(path)
 			})
+			tx.TrackPaths(path)
 		}
 
 		// Update desktop database
@@ -181,6 +194,12 @@ func (b *BinaryBackend) Install(_ context.Context, packagePath string, opts core
 		if cacheErr := b.cacheManager.UpdateDesktopDatabase(appsDir, b.Log); cacheErr != nil {
 			b.Log.Warn().Err(cacheErr).Str("apps_dir", appsDir).Msg("failed to update desktop database")
 		}
+	} else {
+		reason := "not requested (use --desktop)"
+		if opts.SkipDesktop {
+			reason = "--skip-desktop"
+		}
+		result.Skip("desktop entry", reason)
 	}
 
 	// Create install record
@@ -193,7 +212,8 @@ func (b *BinaryBackend) Install(_ context.Context, packagePath string, opts core
 		InstallPath:  destPath,
 		DesktopFile:  desktopPath,
 		Metadata: core.Metadata{
-			WaylandSupport: string(core.WaylandUnknown),
+			WaylandSupport: string(wayland),
+			Framework:      string(framework),
 			InstallMethod:  core.InstallMethodLocal,
 		},
 	}
@@ -204,7 +224,7 @@ func (b *BinaryBackend) Install(_ context.Context, packagePath string, opts core
 		Str("path", destPath).
 		Msg("binary package installed successfully")
 
-	return record, nil
+	return result.Finish(record), nil
 }
 
 func (b *BinaryBackend) copyBinary(srcPath, destPath string) error {
@@ -236,7 +256,8 @@ func (b *BinaryBackend) copyBinary(srcPath, destPath string) error {
 }
 
 // Uninstall removes the installed binary package
-func (b *BinaryBackend) Uninstall(_ context.Context, record *core.InstallRecord) error {
+func (b *BinaryBackend) Uninstall(_ context.Context, record *core.InstallRecord) (*core.UninstallResult, error) {
+	result := core.NewUninstallResult()
 	b.Log.Info().
 		Str("install_id", record.InstallID).
 		Str("name", record.Name).
@@ -249,6 +270,7 @@ func (b *BinaryBackend) Uninstall(_ context.Context, record *core.InstallRecord)
 				Err(err).
 				Str("path", record.InstallPath).
 				Msg("failed to remove binary")
+			result.Warn("failed to remove %s: %v", record.InstallPath, err)
 		} else {
 			b.Log.Debug().
 				Str("path", record.InstallPath).
@@ -266,6 +288,7 @@ func (b *BinaryBackend) Uninstall(_ context.Context, record *core.InstallRecord)
 				Err(err).
 				Str("path", desktopPath).
 				Msg("failed to remove desktop file")
+			result.Warn("failed to remove %s: %v", desktopPath, err)
 		} else {
 			b.Log.Debug().
 				Str("path", desktopPath).
@@ -283,11 +306,11 @@ func (b *BinaryBackend) Uninstall(_ context.Context, record *core.InstallRecord)
 		Str("install_id", record.InstallID).
 		Msg("binary package uninstalled successfully")
 
-	return nil
+	return result.Finish(), nil
 }
 
 // createDesktopFile creates a .desktop file for the binary
-func (b *BinaryBackend) createDesktopFile(appName, binName, execPath string, opts core.InstallOptions) (string, error) {
+func (b *BinaryBackend) createDesktopFile(appName, binName, execPath string, wayland core.WaylandSupport, opts core.InstallOptions) (string, error) {
 	appsDir := b.Paths.GetAppsDir()
 	if err := b.Fs.MkdirAll(appsDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create applications directory: %w", err)
@@ -305,22 +328,24 @@ func (b *BinaryBackend) createDesktopFile(appName, binName, execPath string, opt
 		Comment:     fmt.Sprintf("%s application", displayName),
 		Icon:        "application-x-executable", // Generic icon
 		Exec:        execPath,
+		TryExec:     execPath,
 		Terminal:    false,
 		Categories:  []string{"Utility"},
 		Keywords:    []string{appName},
 	}
 
-	// Inject Wayland environment variables if enabled
-	if b.Cfg.Desktop.WaylandEnvVars && !opts.SkipWaylandEnv {
-		if err := desktop.InjectWaylandEnvVars(entry, b.Cfg.Desktop.CustomEnvVars); err != nil {
-			b.Log.Warn().
-				Err(err).
-				Str("app", appName).
-				Msg("invalid custom Wayland env vars, injecting defaults only")
-			if fallbackErr := desktop.InjectWaylandEnvVars(entry, nil); fallbackErr != nil {
-				b.Log.Warn().Err(fallbackErr).Str("app", appName).Msg("failed to inject default Wayland env vars")
-			}
-		}
+	if b.Cfg.Desktop.DescriptionFields {
+		integration.FillDescription(entry, opts.Description, entry.Comment)
+	}
+
+	// Inject the Wayland and HiDPI rules for the binary's toolkit
+	toolkit := heuristics.DetectFramework(b.Fs, "", execPath)
+	scale := b.Integration().ScaleFor(binName, opts)
+	if err := integration.ApplyLauncherRules(entry, toolkit, wayland, scale, opts, b.Cfg.Desktop); err != nil {
+		b.Log.Warn().
+			Err(err).
+			Str("app", appName).
+			Msg("invalid custom Wayland env vars, injecting defaults only")
 	}
 
 	var buf bytes.Buffer
@@ -117,7 +117,8 @@ func TestInstall_PackageNotFound(t *testing.T) {
 	backend := New(cfg, &logger)
 	tx := transaction.NewManager(&logger)
 
-	record, err := backend.Install(context.Background(), "/nonexistent/binary", core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), "/nonexistent/binary", core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "package not found")
@@ -134,9 +135,10 @@ func TestInstall_InvalidPackageName(t *testing.T) {
 	fakeBin := filepath.Join(tmpDir, "test-binary")
 	require.NoError(t, os.WriteFile(fakeBin, []byte("fake binary"), 0755))
 
-	record, err := backend.Install(context.Background(), fakeBin, core.InstallOptions{
+	result, err := backend.Install(context.Background(), fakeBin, core.InstallOptions{
 		CustomName: "///",
 	}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid")
@@ -164,7 +166,8 @@ func TestInstall_AlreadyInstalled(t *testing.T) {
 	require.NoError(t, os.WriteFile(destPath, []byte("existing"), 0755))
 
 	tx := transaction.NewManager(&logger)
-	record, err := backend.Install(context.Background(), fakeBin, core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), fakeBin, core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "already installed")
@@ -195,7 +198,8 @@ func TestInstall_ForceReinstall(t *testing.T) {
 	require.NoError(t, os.WriteFile(destPath, []byte("existing"), 0755))
 
 	tx := transaction.NewManager(&logger)
-	record, err := backend.Install(context.Background(), fakeBin, core.InstallOptions{Force: true}, tx)
+	result, err := backend.Install(context.Background(), fakeBin, core.InstallOptions{Force: true}, tx)
+	record := result.GetRecord()
 
 	require.NoError(t, err)
 	assert.NotNil(t, record)
@@ -224,7 +228,8 @@ func TestInstall_WithCustomName(t *testing.T) {
 	require.NoError(t, os.WriteFile(fakeBin, []byte("binary content"), 0755))
 
 	tx := transaction.NewManager(&logger)
-	record, err := backend.Install(context.Background(), fakeBin, core.InstallOptions{CustomName: "CustomApp"}, tx)
+	result, err := backend.Install(context.Background(), fakeBin, core.InstallOptions{CustomName: "CustomApp"}, tx)
+	record := result.GetRecord()
 
 	require.NoError(t, err)
 	assert.NotNil(t, record)
@@ -250,7 +255,8 @@ func TestInstall_SkipDesktop(t *testing.T) {
 	require.NoError(t, os.WriteFile(fakeBin, []byte("binary content"), 0755))
 
 	tx := transaction.NewManager(&logger)
-	record, err := backend.Install(context.Background(), fakeBin, core.InstallOptions{SkipDesktop: true}, tx)
+	result, err := backend.Install(context.Background(), fakeBin, core.InstallOptions{SkipDesktop: true, Desktop: true}, tx)
+	record := result.GetRecord()
 
 	require.NoError(t, err)
 	assert.NotNil(t, record)
@@ -260,6 +266,28 @@ func TestInstall_SkipDesktop(t *testing.T) {
 	assert.NoFileExists(t, desktopPath)
 }
 
+func TestInstall_NoDesktopByDefault(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	tmpDir, restore := setTempHome(t)
+	defer restore()
+
+	mockRunner := &helpers.MockCommandRunner{
+		CommandExistsFunc: func(name string) bool { return name == desktopValidateCmd },
+	}
+	backend := NewWithDeps(&config.Config{}, &logger, afero.NewOsFs(), mockRunner)
+
+	fakeBin := filepath.Join(tmpDir, "cli-tool")
+	require.NoError(t, os.WriteFile(fakeBin, []byte("binary content"), 0755))
+
+	result, err := backend.Install(context.Background(), fakeBin, core.InstallOptions{}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.Record.DesktopFile)
+	assert.Equal(t, []string{"desktop entry: not requested (use --desktop)"}, result.Skipped)
+	assert.Equal(t, []string{filepath.Join(tmpDir, ".local", "bin", "cli-tool")}, result.CreatedFiles)
+	assert.FileExists(t, filepath.Join(tmpDir, ".local", "bin", "cli-tool"))
+	assert.NoFileExists(t, filepath.Join(tmpDir, ".local", "share", "applications", "cli-tool.desktop"))
+}
+
 func TestInstall_WithTransaction(t *testing.T) {
 	logger := zerolog.New(io.Discard)
 	tmpDir, restore := setTempHome(t)
@@ -279,7 +307,8 @@ func TestInstall_WithTransaction(t *testing.T) {
 	require.NoError(t, os.WriteFile(fakeBin, []byte("binary content"), 0755))
 
 	tx := transaction.NewManager(&logger)
-	record, err := backend.Install(context.Background(), fakeBin, core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), fakeBin, core.InstallOptions{Desktop: true}, tx)
+	record := result.GetRecord()
 
 	require.NoError(t, err)
 	assert.NotNil(t, record)
@@ -324,7 +353,7 @@ func TestUninstall(t *testing.T) {
 			DesktopFile: desktopPath,
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.NoError(t, err)
 		assert.NoFileExists(t, binPath)
 		assert.NoFileExists(t, desktopPath)
@@ -344,7 +373,7 @@ func TestUninstall(t *testing.T) {
 			DesktopFile: "/nonexistent/desktop.desktop",
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.NoError(t, err)
 	})
 }
@@ -368,7 +397,7 @@ func TestCreateDesktopFile(t *testing.T) {
 		}
 		backend := NewWithDeps(cfg, &logger, afero.NewOsFs(), mockRunner)
 
-		desktopPath, err := backend.createDesktopFile("Test App", "test-app", "/usr/bin/test-app", core.InstallOptions{})
+		desktopPath, err := backend.createDesktopFile("Test App", "test-app", "/usr/bin/test-app", core.WaylandUnknown, core.InstallOptions{})
 		require.NoError(t, err)
 		assert.NotEmpty(t, desktopPath)
 
@@ -393,7 +422,7 @@ func TestCreateDesktopFile(t *testing.T) {
 		}
 		backend := NewWithDeps(cfg, &logger, afero.NewOsFs(), mockRunner)
 
-		desktopPath, err := backend.createDesktopFile("Test App", "test-app", "/usr/bin/test-app", core.InstallOptions{})
+		desktopPath, err := backend.createDesktopFile("Test App", "test-app", "/usr/bin/test-app", core.WaylandUnknown, core.InstallOptions{})
 		require.NoError(t, err)
 
 		content, err := os.ReadFile(desktopPath)
@@ -410,7 +439,7 @@ func TestCreateDesktopFile(t *testing.T) {
 		}
 		backend := NewWithDeps(cfg, &logger, afero.NewOsFs(), mockRunner)
 
-		desktopPath, err := backend.createDesktopFile("Test App", "test-app", "/usr/bin/test-app", core.InstallOptions{SkipWaylandEnv: true})
+		desktopPath, err := backend.createDesktopFile("Test App", "test-app", "/usr/bin/test-app", core.WaylandUnknown, core.InstallOptions{SkipWaylandEnv: true})
 		require.NoError(t, err)
 
 		content, err := os.ReadFile(desktopPath)
@@ -438,7 +467,8 @@ func TestInstallRecord(t *testing.T) {
 	require.NoError(t, os.WriteFile(fakeBin, []byte("binary content"), 0755))
 
 	tx := transaction.NewManager(&logger)
-	record, err := backend.Install(context.Background(), fakeBin, core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), fakeBin, core.InstallOptions{Desktop: true}, tx)
+	record := result.GetRecord()
 
 	require.NoError(t, err)
 	require.NotNil(t, record)
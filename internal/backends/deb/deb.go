@@ -14,20 +14,24 @@ import (
 	"github.com/quantmind-br/upkg/internal/core"
 	"github.com/quantmind-br/upkg/internal/desktop"
 	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/integration"
 	"github.com/quantmind-br/upkg/internal/syspkg"
 	"github.com/quantmind-br/upkg/internal/syspkg/arch"
+	"github.com/quantmind-br/upkg/internal/syspkg/debian"
 	"github.com/quantmind-br/upkg/internal/transaction"
 	"github.com/quantmind-br/upkg/internal/ui"
 	"github.com/rs/zerolog"
 	"github.com/spf13/afero"
 )
 
-// DebBackend handles DEB package installations via debtap
+// DebBackend handles DEB package installations with apt/dpkg on Debian-based
+// systems, via debtap and pacman on Arch, or by extraction elsewhere
 //
 //nolint:revive // exported backend names are kept for consistency across packages.
 type DebBackend struct {
 	*backendbase.BaseBackend
-	sys          syspkg.Provider
+	sys          syspkg.Provider // pacman, for packages converted with debtap
+	dpkg         syspkg.Provider // apt/dpkg on Debian-based hosts
 	cacheManager *cache.CacheManager
 }
 
@@ -36,7 +40,8 @@ func New(cfg *config.Config, log *zerolog.Logger) *DebBackend {
 	base := backendbase.New(cfg, log)
 	return &DebBackend{
 		BaseBackend:  base,
-		sys:          arch.NewPacmanProvider(),
+		sys:          newPacmanProvider(cfg),
+		dpkg:         newDpkgProvider(cfg),
 		cacheManager: cache.NewCacheManagerWithRunner(base.Runner),
 	}
 }
@@ -51,7 +56,8 @@ func NewWithDeps(cfg *config.Config, log *zerolog.Logger, fs afero.Fs, runner he
 	base := backendbase.NewWithDeps(cfg, log, fs, runner)
 	return &DebBackend{
 		BaseBackend:  base,
-		sys:          arch.NewPacmanProvider(),
+		sys:          arch.NewPacmanProviderWithRunner(runner).WithLockRetry(cfg.System.LockRetries, cfg.System.LockRetryDelay()),
+		dpkg:         debian.NewDpkgProviderWithRunner(runner).WithLockRetry(cfg.System.LockRetries, cfg.System.LockRetryDelay()),
 		cacheManager: cache.NewCacheManagerWithRunner(runner),
 	}
 }
@@ -61,16 +67,73 @@ func NewWithCacheManager(cfg *config.Config, log *zerolog.Logger, cacheManager *
 	base := backendbase.New(cfg, log)
 	return &DebBackend{
 		BaseBackend:  base,
-		sys:          arch.NewPacmanProvider(),
+		sys:          newPacmanProvider(cfg),
+		dpkg:         newDpkgProvider(cfg),
 		cacheManager: cacheManager,
 	}
 }
 
+// newPacmanProvider creates the pacman provider with the configured lock retry policy
+func newPacmanProvider(cfg *config.Config) *arch.PacmanProvider {
+	return arch.NewPacmanProvider().WithLockRetry(cfg.System.LockRetries, cfg.System.LockRetryDelay())
+}
+
+// newDpkgProvider creates the dpkg provider with the configured lock retry policy
+func newDpkgProvider(cfg *config.Config) *debian.DpkgProvider {
+	return debian.NewDpkgProvider().WithLockRetry(cfg.System.LockRetries, cfg.System.LockRetryDelay())
+}
+
+// provider returns the system package manager that owns installs recorded
+// with method
+func (d *DebBackend) provider(method string) syspkg.Provider {
+	if method == core.InstallMethodDpkg {
+		return d.dpkg
+	}
+	return d.sys
+}
+
 // Name returns the backend name
 func (d *DebBackend) Name() string {
 	return "deb"
 }
 
+// RequiredTools lists the external tools used by the DEB backend
+func (d *DebBackend) RequiredTools() []core.ToolRequirement {
+	return []core.ToolRequirement{
+		{
+			Name:     "debtap",
+			Optional: true,
+			Purpose:  "convert DEB packages to Arch packages; DEBs are extracted without it",
+			Packages: map[string]string{core.DistroArch: "debtap"},
+			AUR:      true,
+		},
+		{
+			Name:     "pacman",
+			Optional: true,
+			Purpose:  "install the converted package on Arch Linux; DEBs are extracted without it",
+		},
+		{
+			Name:         "dpkg",
+			Alternatives: []string{"apt-get"},
+			Optional:     true,
+			Purpose:      "install DEB packages natively on Debian and Ubuntu",
+			Packages:     map[string]string{core.DistroDebian: "dpkg"},
+		},
+		{
+			Name:     "bsdtar",
+			Optional: true,
+			Purpose:  "repack converted packages when fixing dependencies, extract zstd-compressed DEBs",
+			Packages: map[string]string{core.DistroArch: "libarchive", core.DistroDebian: "libarchive-tools", core.DistroFedora: "bsdtar", core.DistroSUSE: "bsdtar"},
+		},
+		{
+			Name:     "dpkg-deb",
+			Optional: true,
+			Purpose:  "read DEB metadata",
+			Packages: map[string]string{core.DistroArch: "dpkg", core.DistroDebian: "dpkg", core.DistroFedora: "dpkg", core.DistroSUSE: "dpkg"},
+		},
+	}
+}
+
 // Detect checks if this backend can handle the package
 func (d *DebBackend) Detect(_ context.Context, packagePath string) (bool, error) {
 	// Check if file exists
@@ -87,15 +150,51 @@ func (d *DebBackend) Detect(_ context.Context, packagePath string) (bool, error)
 	return fileType == helpers.FileTypeDEB, nil
 }
 
-// Install installs the DEB package using debtap
+// installMethod resolves the method a DEB is installed with. Without an
+// explicit one, hosts whose native package manager is dpkg (per
+// /etc/os-release or syspkg.provider) use it, hosts with both pacman and
+// debtap convert the package, and anything else extracts it.
+func (d *DebBackend) installMethod(method string) string {
+	switch method {
+	case core.MethodExtract, core.MethodPacman, core.MethodDpkg:
+		return method
+	}
+	if syspkg.HostProvider(d.Fs, d.Cfg.Syspkg.Provider) == syspkg.ProviderDpkg && d.Runner.CommandExists("dpkg") {
+		return core.MethodDpkg
+	}
+	if d.Runner.CommandExists("pacman") && d.Runner.CommandExists("debtap") {
+		return core.MethodPacman
+	}
+	return core.MethodExtract
+}
+
+// Install installs the DEB package with dpkg, by converting it with debtap
+// and installing it with pacman, or by extraction (see installMethod)
 //
 //nolint:gocyclo // multi-step install with progress, conversion, pacman and desktop integration.
-func (d *DebBackend) Install(ctx context.Context, packagePath string, opts core.InstallOptions, tx *transaction.Manager) (*core.InstallRecord, error) {
+func (d *DebBackend) Install(ctx context.Context, packagePath string, opts core.InstallOptions, tx *transaction.Manager) (*core.InstallResult, error) {
+	result := core.NewInstallResult()
 	d.Log.Info().
 		Str("package_path", packagePath).
 		Str("custom_name", opts.CustomName).
+		Str("method", opts.Method).
 		Msg("installing DEB package")
 
+	switch d.installMethod(opts.Method) {
+	case core.MethodExtract:
+		record, err := d.installWithExtract(ctx, packagePath, opts, tx, result)
+		if err != nil {
+			return nil, err
+		}
+		return result.Finish(record), nil
+	case core.MethodDpkg:
+		record, err := d.installWithDpkg(ctx, packagePath, opts, tx, result)
+		if err != nil {
+			return nil, err
+		}
+		return result.Finish(record), nil
+	}
+
 	// Define installation phases with weights
 	phases := []ui.InstallationPhase{
 		{Name: "Validating package", Weight: 5, Deterministic: true},
@@ -107,26 +206,25 @@ func (d *DebBackend) Install(ctx context.Context, packagePath string, opts core.
 	}
 
 	// Create progress tracker (enabled unless in quiet mode)
-	progressEnabled := d.Log.GetLevel() != zerolog.Disabled && d.Log.GetLevel() <= zerolog.InfoLevel
-	progress := ui.NewProgressTracker(phases, "Installing DEB", progressEnabled)
+	progress := d.NewProgress(ctx, phases, "Installing DEB")
 	defer progress.Finish()
 
 	// Phase 1: Validation
 	progress.StartPhase(0)
 
 	// Check if debtap is installed
-	if err := d.Runner.RequireCommand("debtap"); err != nil {
-		return nil, fmt.Errorf("debtap is required for DEB installation: %w\nInstall with: yay -S debtap", err)
+	if err := d.RequireCommand("debtap", d.RequiredTools()); err != nil {
+		return nil, fmt.Errorf("debtap is required for DEB installation: %w", err)
 	}
 
 	// Check if pacman is available (we're on Arch)
-	if err := d.Runner.RequireCommand("pacman"); err != nil {
-		return nil, fmt.Errorf("pacman not found - DEB backend requires Arch Linux")
+	if err := d.RequireCommand("pacman", d.RequiredTools()); err != nil {
+		return nil, fmt.Errorf("pacman not found - DEB backend requires Arch Linux: %w", err)
 	}
 
 	// Check if debtap is initialized
 	if !isDebtapInitialized() {
-		return nil, fmt.Errorf("debtap is not initialized\nRun the following command to initialize:\n  sudo debtap -u")
+		return nil, fmt.Errorf("debtap is not initialized (run: sudo debtap -u)")
 	}
 
 	// Validate package exists
@@ -168,7 +266,8 @@ func (d *DebBackend) Install(ctx context.Context, packagePath string, opts core.
 		Msg("package name determined")
 
 	// Create temp directory for conversion
-	tmpDir, err := afero.TempDir(d.Fs, "", "upkg-deb-*")
+	// Named after the package until the install ID is known
+	tmpDir, err := helpers.CreateTempDir(d.Fs, normalizedName, "deb")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temp directory: %w", err)
 	}
@@ -198,8 +297,9 @@ func (d *DebBackend) Install(ctx context.Context, packagePath string, opts core.
 	progress.StartPhase(3)
 
 	d.Log.Info().Msg("checking and fixing malformed dependencies...")
-	if fixErr := fixMalformedDependencies(archPkgPath, d.Log); fixErr != nil {
+	if fixErr := fixMalformedDependencies(archPkgPath, normalizedName, d.Log); fixErr != nil {
 		d.Log.Warn().Err(fixErr).Msg("failed to fix malformed dependencies, proceeding anyway")
+		result.Warn("could not fix malformed dependencies: %v", fixErr)
 	}
 
 	// Read package metadata to determine actual pacman package name
@@ -216,6 +316,16 @@ func (d *DebBackend) Install(ctx context.Context, packagePath string, opts core.
 
 	installID := helpers.GenerateInstallID(pacmanPkgName)
 
+	if renamed, renameErr := helpers.RenameTempDir(d.Fs, tmpDir, installID); renameErr != nil {
+		d.Log.Debug().Err(renameErr).Str("tmp_dir", tmpDir).Msg("failed to label temp dir with install ID")
+	} else {
+		if rel, relErr := filepath.Rel(tmpDir, archPkgPath); relErr == nil && !strings.HasPrefix(rel, "..") {
+			archPkgPath = filepath.Join(renamed, rel)
+		}
+		tmpDir = renamed
+	}
+	d.Log.Debug().Str("install_id", installID).Str("tmp_dir", tmpDir).Msg("using temp dir")
+
 	progress.AdvancePhase()
 
 	// Phase 5: Install with pacman (indeterminate phase)
@@ -226,19 +336,7 @@ func (d *DebBackend) Install(ctx context.Context, packagePath string, opts core.
 	defer cancel()
 
 	// Update progress during pacman installation
-	go func() {
-		ticker := time.NewTicker(1 * time.Second)
-		defer ticker.Stop()
-		start := time.Now()
-		for {
-			select {
-			case <-ticker.C:
-				progress.UpdateIndeterminateWithElapsed("Installing with pacman", time.Since(start))
-			case <-installCtx.Done():
-				return
-			}
-		}
-	}()
+	backendbase.TickIndeterminate(installCtx, progress, "Installing with pacman")
 
 	err = d.sys.Install(installCtx, archPkgPath, &syspkg.InstallOptions{Overwrite: opts.Overwrite})
 	if err != nil {
@@ -261,11 +359,12 @@ func (d *DebBackend) Install(ctx context.Context, packagePath string, opts core.
 	progress.StartPhase(5)
 
 	// Get package info from pacman
-	pkgInfo, err := d.getPackageInfo(ctx, pacmanPkgName)
+	pkgInfo, err := d.getPackageInfo(ctx, d.sys, pacmanPkgName)
 	if err != nil {
 		d.Log.Warn().Err(err).
 			Str("package", pacmanPkgName).
 			Msg("failed to get package info from pacman")
+		result.Warn("could not query pacman for %s, version may be inaccurate: %v", pacmanPkgName, err)
 		fallbackVersion := "unknown"
 		if pkgMeta != nil && pkgMeta.version != "" {
 			fallbackVersion = pkgMeta.version
@@ -280,63 +379,12 @@ func (d *DebBackend) Install(ctx context.Context, packagePath string, opts core.
 		pkgInfo.version = pkgMeta.version
 	}
 
-	// Find installed files
-	installedFiles, err := d.findInstalledFiles(ctx, pacmanPkgName)
-	if err != nil {
-		d.Log.Warn().Err(err).Msg("failed to list installed files")
-	}
-
-	// Find desktop files
-	desktopFiles := d.findDesktopFiles(installedFiles)
-
-	// Update desktop files with Wayland env vars if needed
-	var primaryDesktopFile string
-	if len(desktopFiles) > 0 {
-		primaryDesktopFile = desktopFiles[0]
+	desktopFiles, iconFiles, primaryDesktopFile := d.integrateSystemPackage(ctx, d.sys, pacmanPkgName, result)
 
-		if d.Cfg.Desktop.WaylandEnvVars {
-			for _, desktopFile := range desktopFiles {
-				if err := d.updateDesktopFileWayland(desktopFile); err != nil {
-					d.Log.Warn().
-						Err(err).
-						Str("desktop_file", desktopFile).
-						Msg("failed to update desktop file with Wayland vars")
-				}
-			}
-		}
-	}
-
-	// Find icon files
-	iconFiles := d.findIconFiles(installedFiles)
-	fallbackIcons, fallbackErr := d.installUserIconFallback(iconFiles, primaryDesktopFile)
-	if fallbackErr != nil {
-		d.Log.Warn().Err(fallbackErr).Msg("failed to install fallback icons")
-	} else if len(fallbackIcons) > 0 {
-		iconFiles = append(iconFiles, fallbackIcons...)
-		iconsDir := d.Paths.GetIconsDir()
-		if cacheErr := d.cacheManager.UpdateIconCache(iconsDir, d.Log); cacheErr != nil {
-			d.Log.Warn().Err(cacheErr).Str("icons_dir", iconsDir).Msg("failed to update user icon cache")
-		}
-	}
-
-	// Update caches
-	if len(desktopFiles) > 0 {
-		appsDir := filepath.Dir(desktopFiles[0])
-		if cacheErr := d.cacheManager.UpdateDesktopDatabase(appsDir, d.Log); cacheErr != nil {
-			d.Log.Warn().Err(cacheErr).Str("apps_dir", appsDir).Msg("failed to update desktop database")
-		}
-	}
-
-	if len(iconFiles) > 0 {
-		// Find hicolor icon directory
-		for _, iconFile := range iconFiles {
-			if strings.Contains(iconFile, "hicolor") {
-				hicolorDir := filepath.Dir(filepath.Dir(filepath.Dir(iconFile)))
-				if cacheErr := d.cacheManager.UpdateIconCache(hicolorDir, d.Log); cacheErr != nil {
-					d.Log.Warn().Err(cacheErr).Str("icons_dir", hicolorDir).Msg("failed to update icon cache")
-				}
-				break
-			}
+	comment := "Installed via debtap/pacman"
+	if d.Cfg.Desktop.DescriptionFields {
+		if synopsis, _ := integration.DescriptionFields(d.queryDescription(ctx, packagePath)); synopsis != "" {
+			comment = synopsis
 		}
 	}
 
@@ -355,8 +403,9 @@ func (d *DebBackend) Install(ctx context.Context, packagePath string, opts core.
 			WaylandSupport: string(core.WaylandUnknown),
 			InstallMethod:  core.InstallMethodPacman,
 			DesktopFiles:   desktopFiles,
+			InstalledSize:  pkgInfo.size,
 			ExtractedMeta: core.ExtractedMetadata{
-				Comment: "Installed via debtap/pacman",
+				Comment: comment,
 			},
 		},
 	}
@@ -367,16 +416,23 @@ func (d *DebBackend) Install(ctx context.Context, packagePath string, opts core.
 		Str("version", pkgInfo.version).
 		Msg("DEB package installed successfully")
 
-	return record, nil
+	return result.Finish(record), nil
 }
 
-// Uninstall removes the installed DEB package via pacman
-func (d *DebBackend) Uninstall(ctx context.Context, record *core.InstallRecord) error {
+// Uninstall removes the installed DEB package via pacman or dpkg, or the
+// extracted files of one installed by extraction
+func (d *DebBackend) Uninstall(ctx context.Context, record *core.InstallRecord) (*core.UninstallResult, error) {
+	result := core.NewUninstallResult()
 	d.Log.Info().
 		Str("install_id", record.InstallID).
 		Str("name", record.Name).
 		Msg("uninstalling DEB package")
 
+	if record.Metadata.InstallMethod == core.InstallMethodLocal {
+		d.uninstallExtracted(record, result)
+		return result.Finish(), nil
+	}
+
 	// Extract package name from InstallPath metadata
 	pkgName := record.Name
 	normalizedName := helpers.NormalizeFilename(pkgName)
@@ -385,23 +441,24 @@ func (d *DebBackend) Uninstall(ctx context.Context, record *core.InstallRecord)
 	checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	installed, err := d.sys.IsInstalled(checkCtx, normalizedName)
+	sys := d.provider(record.Metadata.InstallMethod)
+	installed, err := sys.IsInstalled(checkCtx, normalizedName)
 	if err != nil || !installed {
 		d.Log.Warn().
 			Str("package", normalizedName).
-			Msg("package not found in pacman database")
-		return nil // Already uninstalled
+			Msgf("package not found in %s database", sys.Name())
+		result.Skip(sys.Name()+" removal", fmt.Sprintf("package not found in %s database", sys.Name()))
+		return result.Finish(), nil // Already uninstalled
 	}
 
-	// Uninstall with pacman
-	d.Log.Info().Msg("removing package with pacman...")
+	d.Log.Info().Msgf("removing package with %s...", sys.Name())
 
 	uninstallCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer cancel()
 
-	err = d.sys.Remove(uninstallCtx, normalizedName)
+	err = sys.Remove(uninstallCtx, normalizedName)
 	if err != nil {
-		return fmt.Errorf("pacman removal failed: %w", err)
+		return nil, fmt.Errorf("%s removal failed: %w", sys.Name(), err)
 	}
 
 	// Update caches
@@ -423,15 +480,84 @@ func (d *DebBackend) Uninstall(ctx context.Context, record *core.InstallRecord)
 		Str("install_id", record.InstallID).
 		Msg("DEB package uninstalled successfully")
 
-	return nil
+	return result.Finish(), nil
 }
 
-// getPackageInfo gets package info from pacman
-func (d *DebBackend) getPackageInfo(ctx context.Context, pkgName string) (*packageInfo, error) {
+// integrateSystemPackage finds the desktop entries and icons a system package
+// installed, injects the Wayland variables, copies fallback icons into the
+// user's icon theme and refreshes the caches
+func (d *DebBackend) integrateSystemPackage(ctx context.Context, sys syspkg.Provider, pkgName string, result *core.InstallResult) (desktopFiles, iconFiles []string, primaryDesktopFile string) {
+	// Find installed files
+	installedFiles, err := d.findInstalledFiles(ctx, sys, pkgName)
+	if err != nil {
+		d.Log.Warn().Err(err).Msg("failed to list installed files")
+		result.Warn("could not list installed files, desktop entries and icons are not tracked: %v", err)
+	}
+
+	// Find desktop files
+	desktopFiles = d.findDesktopFiles(installedFiles)
+
+	// Update desktop files with Wayland env vars if needed
+	if len(desktopFiles) > 0 {
+		primaryDesktopFile = desktopFiles[0]
+
+		if d.Cfg.Desktop.WaylandEnvVars {
+			for _, desktopFile := range desktopFiles {
+				if err := d.updateDesktopFileWayland(desktopFile); err != nil {
+					d.Log.Warn().
+						Err(err).
+						Str("desktop_file", desktopFile).
+						Msg("failed to update desktop file with Wayland vars")
+					result.Warn("Wayland variables not added to %s: %v", desktopFile, err)
+				}
+			}
+		}
+	}
+
+	// Find icon files
+	iconFiles = d.findIconFiles(installedFiles)
+	fallbackIcons, fallbackErr := d.installUserIconFallback(iconFiles, primaryDesktopFile)
+	if fallbackErr != nil {
+		d.Log.Warn().Err(fallbackErr).Msg("failed to install fallback icons")
+		result.Warn("fallback icons not installed: %v", fallbackErr)
+	} else if len(fallbackIcons) > 0 {
+		iconFiles = append(iconFiles, fallbackIcons...)
+		iconsDir := d.Paths.GetIconsDir()
+		if cacheErr := d.cacheManager.UpdateIconCache(iconsDir, d.Log); cacheErr != nil {
+			d.Log.Warn().Err(cacheErr).Str("icons_dir", iconsDir).Msg("failed to update user icon cache")
+		}
+	}
+
+	// Update caches
+	if len(desktopFiles) > 0 {
+		appsDir := filepath.Dir(desktopFiles[0])
+		if cacheErr := d.cacheManager.UpdateDesktopDatabase(appsDir, d.Log); cacheErr != nil {
+			d.Log.Warn().Err(cacheErr).Str("apps_dir", appsDir).Msg("failed to update desktop database")
+		}
+	}
+
+	if len(iconFiles) > 0 {
+		// Find hicolor icon directory
+		for _, iconFile := range iconFiles {
+			if strings.Contains(iconFile, "hicolor") {
+				hicolorDir := filepath.Dir(filepath.Dir(filepath.Dir(iconFile)))
+				if cacheErr := d.cacheManager.UpdateIconCache(hicolorDir, d.Log); cacheErr != nil {
+					d.Log.Warn().Err(cacheErr).Str("icons_dir", hicolorDir).Msg("failed to update icon cache")
+				}
+				break
+			}
+		}
+	}
+
+	return desktopFiles, iconFiles, primaryDesktopFile
+}
+
+// getPackageInfo gets package info from the system package manager
+func (d *DebBackend) getPackageInfo(ctx context.Context, sys syspkg.Provider, pkgName string) (*packageInfo, error) {
 	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	info, err := d.sys.GetInfo(queryCtx, pkgName)
+	info, err := sys.GetInfo(queryCtx, pkgName)
 	if err != nil {
 		return nil, err
 	}
@@ -439,15 +565,16 @@ func (d *DebBackend) getPackageInfo(ctx context.Context, pkgName string) (*packa
 	return &packageInfo{
 		name:    info.Name,
 		version: info.Version,
+		size:    info.InstalledSize,
 	}, nil
 }
 
 // findInstalledFiles lists all files installed by the package
-func (d *DebBackend) findInstalledFiles(ctx context.Context, pkgName string) ([]string, error) {
+func (d *DebBackend) findInstalledFiles(ctx context.Context, sys syspkg.Provider, pkgName string) ([]string, error) {
 	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	return d.sys.ListFiles(queryCtx, pkgName)
+	return sys.ListFiles(queryCtx, pkgName)
 }
 
 // findDesktopFiles filters for .desktop files
@@ -498,6 +625,8 @@ func (d *DebBackend) updateDesktopFileWayland(desktopPath string) error {
 		return fmt.Errorf("invalid desktop entry: %w", valErr)
 	}
 
+	entry.Categories = desktop.NormalizeCategories(entry.Categories)
+
 	// Inject Wayland vars
 	if injectErr := desktop.InjectWaylandEnvVars(entry, d.Cfg.Desktop.CustomEnvVars); injectErr != nil {
 		d.Log.Warn().
@@ -553,6 +682,30 @@ func (d *DebBackend) updateDesktopFileWayland(desktopPath string) error {
 type packageInfo struct {
 	name    string
 	version string
+	size    int64 // Installed size in bytes; 0 when unknown
+}
+
+// queryDescription returns the Description field of the DEB control file
+// (synopsis line first), or "" when dpkg-deb is unavailable
+func (d *DebBackend) queryDescription(ctx context.Context, packagePath string) string {
+	if !d.Runner.CommandExists("dpkg-deb") {
+		return ""
+	}
+
+	absPath, err := filepath.Abs(packagePath)
+	if err != nil {
+		return ""
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	output, err := d.Runner.RunCommand(queryCtx, "dpkg-deb", "--field", absPath, "Description")
+	if err != nil {
+		d.Log.Debug().Err(err).Msg("failed to query DEB description")
+		return ""
+	}
+	return strings.TrimSpace(output)
 }
 
 // queryDebName extracts the official package name from DEB metadata using dpkg-deb
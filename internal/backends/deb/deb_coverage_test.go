@@ -477,7 +477,7 @@ func TestInstallWithMockedSyspkg(t *testing.T) {
 		debPath := filepath.Join(tmpDir, "test.deb")
 		require.NoError(t, os.WriteFile(debPath, []byte("!<arch>\ndebian-binary"), 0644))
 
-		_, err := backend.Install(context.Background(), debPath, core.InstallOptions{}, tx)
+		_, err := backend.Install(context.Background(), debPath, core.InstallOptions{Method: core.MethodPacman}, tx)
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "debtap is required")
@@ -500,7 +500,7 @@ func TestInstallWithMockedSyspkg(t *testing.T) {
 		debPath := filepath.Join(tmpDir, "test.deb")
 		require.NoError(t, os.WriteFile(debPath, []byte("!<arch>\ndebian-binary"), 0644))
 
-		_, err := backend.Install(context.Background(), debPath, core.InstallOptions{}, tx)
+		_, err := backend.Install(context.Background(), debPath, core.InstallOptions{Method: core.MethodPacman}, tx)
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "pacman not found")
@@ -537,7 +537,7 @@ func TestUninstallWithMockedSyspkg(t *testing.T) {
 			},
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.Error(t, err)
 	})
 
@@ -576,7 +576,7 @@ func TestUninstallWithMockedSyspkg(t *testing.T) {
 			},
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.NoError(t, err)
 		assert.NoFileExists(t, iconPath)
 	})
@@ -760,7 +760,7 @@ func TestFixMalformedDependenciesFull(t *testing.T) {
 	logger := zerolog.New(io.Discard)
 
 	t.Run("handles missing file gracefully", func(t *testing.T) {
-		err := fixMalformedDependencies("/nonexistent/package.deb", &logger)
+		err := fixMalformedDependencies("/nonexistent/package.deb", "test", &logger)
 		assert.Error(t, err)
 	})
 
@@ -770,7 +770,7 @@ func TestFixMalformedDependenciesFull(t *testing.T) {
 
 		require.NoError(t, os.WriteFile(invalidPath, []byte("not an archive"), 0644))
 
-		err := fixMalformedDependencies(invalidPath, &logger)
+		err := fixMalformedDependencies(invalidPath, "test", &logger)
 		assert.Error(t, err)
 	})
 }
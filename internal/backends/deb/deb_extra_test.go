@@ -45,7 +45,8 @@ func TestDebBackendInstallValidation(t *testing.T) {
 		debPath := filepath.Join(tmpDir, "test.deb")
 		require.NoError(t, os.WriteFile(debPath, []byte("!<arch>\ndebian-binary"), 0644))
 
-		record, err := backend.Install(context.Background(), debPath, core.InstallOptions{}, tx)
+		result, err := backend.Install(context.Background(), debPath, core.InstallOptions{Method: core.MethodPacman}, tx)
+		record := result.GetRecord()
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "debtap is required")
@@ -75,7 +76,8 @@ func TestDebBackendInstallValidation(t *testing.T) {
 		debPath := filepath.Join(tmpDir, "test.deb")
 		require.NoError(t, os.WriteFile(debPath, []byte("!<arch>\ndebian-binary"), 0644))
 
-		record, err := backend.Install(context.Background(), debPath, core.InstallOptions{}, tx)
+		result, err := backend.Install(context.Background(), debPath, core.InstallOptions{Method: core.MethodPacman}, tx)
+		record := result.GetRecord()
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "pacman not found")
@@ -88,7 +90,8 @@ func TestDebBackendInstallValidation(t *testing.T) {
 		backend := New(cfg, &logger)
 		tx := transaction.NewManager(&logger)
 
-		record, err := backend.Install(context.Background(), "/nonexistent.deb", core.InstallOptions{}, tx)
+		result, err := backend.Install(context.Background(), "/nonexistent.deb", core.InstallOptions{}, tx)
+		record := result.GetRecord()
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "package not found")
@@ -119,7 +122,7 @@ func TestDebBackendUninstall(t *testing.T) {
 
 		// This will fail because we can't mock the actual pacman uninstall
 		// but we can verify the flow
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 
 		// Should fail due to missing pacman, but the flow is tested
 		_ = err
@@ -149,7 +152,7 @@ func TestDebBackendUninstall(t *testing.T) {
 			PackageType: core.PackageTypeDeb,
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 
 		// Should succeed (already uninstalled)
 		assert.NoError(t, err)
@@ -229,7 +229,7 @@ func TestDebBackend_Uninstall_PackageNotInstalled(t *testing.T) {
 		InstallPath: "/nonexistent/path",
 	}
 
-	err := backend.Uninstall(ctx, install)
+	_, err := backend.Uninstall(ctx, install)
 
 	// Should handle gracefully
 	_ = err
@@ -257,7 +257,7 @@ func TestDebBackend_Uninstall_NormalizedName(t *testing.T) {
 		InstallPath: tmpDir,
 	}
 
-	err := backend.Uninstall(ctx, install)
+	_, err := backend.Uninstall(ctx, install)
 
 	// Should handle gracefully
 	_ = err
@@ -301,10 +301,12 @@ func TestInstall_MissingDebtap(t *testing.T) {
 	require.NoError(t, os.WriteFile(fakeDeb, []byte("fake deb content"), 0644))
 
 	tx := transaction.NewManager(&logger)
-	record, err := backend.Install(context.Background(), fakeDeb, core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), fakeDeb, core.InstallOptions{Method: core.MethodPacman}, tx)
+	record := result.GetRecord()
 
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "debtap")
+	var missing *core.ErrMissingTool
+	require.ErrorAs(t, err, &missing)
+	assert.Equal(t, "debtap", missing.Tool)
 	assert.Nil(t, record)
 }
 
@@ -319,7 +321,8 @@ func TestInstall_PackageNotFound(t *testing.T) {
 	backend := NewWithDeps(cfg, &logger, afero.NewOsFs(), mockRunner)
 
 	tx := transaction.NewManager(&logger)
-	record, err := backend.Install(context.Background(), "/nonexistent/package.deb", core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), "/nonexistent/package.deb", core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "package not found")
@@ -354,7 +357,7 @@ func TestUninstall_PackageNotInPacman(t *testing.T) {
 		},
 	}
 
-	err := backend.Uninstall(context.Background(), record)
+	_, err := backend.Uninstall(context.Background(), record)
 	assert.NoError(t, err) // Should not error if package not found
 }
 
@@ -386,7 +389,7 @@ func TestUninstall_Success(t *testing.T) {
 		},
 	}
 
-	err := backend.Uninstall(context.Background(), record)
+	_, err := backend.Uninstall(context.Background(), record)
 	assert.NoError(t, err)
 	assert.True(t, mockProvider.removeCalled)
 }
@@ -557,9 +560,10 @@ func TestVersionConstraintPreservation(t *testing.T) {
 
 // mockSyspkgProvider is a mock implementation of syspkg.Provider for testing
 type mockSyspkgProvider struct {
-	isInstalled  bool
-	removeCalled bool
-	removeErr    error
+	isInstalled    bool
+	isInstalledErr error
+	removeCalled   bool
+	removeErr      error
 
 	// Function fields for testing
 	GetInfoFunc   func(context.Context, string) (*syspkg.PackageInfo, error)
@@ -580,7 +584,7 @@ func (m *mockSyspkgProvider) Remove(_ context.Context, _ string) error {
 }
 
 func (m *mockSyspkgProvider) IsInstalled(_ context.Context, _ string) (bool, error) {
-	return m.isInstalled, nil
+	return m.isInstalled, m.isInstalledErr
 }
 
 func (m *mockSyspkgProvider) GetInfo(_ context.Context, packageName string) (*syspkg.PackageInfo, error) {
@@ -615,7 +619,7 @@ func TestGetPackageInfo(t *testing.T) {
 		backend := NewWithDeps(cfg, &logger, afero.NewOsFs(), &helpers.MockCommandRunner{})
 		backend.sys = mockProvider
 
-		info, err := backend.getPackageInfo(context.Background(), "test-package")
+		info, err := backend.getPackageInfo(context.Background(), mockProvider, "test-package")
 		assert.NoError(t, err)
 		assert.NotNil(t, info)
 		assert.Equal(t, "test-package", info.name)
@@ -632,7 +636,7 @@ func TestGetPackageInfo(t *testing.T) {
 		backend := NewWithDeps(cfg, &logger, afero.NewOsFs(), &helpers.MockCommandRunner{})
 		backend.sys = mockProvider
 
-		info, err := backend.getPackageInfo(context.Background(), "nonexistent")
+		info, err := backend.getPackageInfo(context.Background(), mockProvider, "nonexistent")
 		assert.Error(t, err)
 		assert.Nil(t, info)
 	})
@@ -657,7 +661,7 @@ func TestFindInstalledFiles(t *testing.T) {
 		backend := NewWithDeps(cfg, &logger, afero.NewOsFs(), &helpers.MockCommandRunner{})
 		backend.sys = mockProvider
 
-		files, err := backend.findInstalledFiles(context.Background(), "test-package")
+		files, err := backend.findInstalledFiles(context.Background(), mockProvider, "test-package")
 		assert.NoError(t, err)
 		assert.Len(t, files, 3)
 		assert.Contains(t, files, "/usr/bin/test-app")
@@ -673,7 +677,7 @@ func TestFindInstalledFiles(t *testing.T) {
 		backend := NewWithDeps(cfg, &logger, afero.NewOsFs(), &helpers.MockCommandRunner{})
 		backend.sys = mockProvider
 
-		files, err := backend.findInstalledFiles(context.Background(), "nonexistent")
+		files, err := backend.findInstalledFiles(context.Background(), mockProvider, "nonexistent")
 		assert.Error(t, err)
 		assert.Empty(t, files)
 	})
@@ -853,7 +857,7 @@ depend = anaconda
 		cmd := exec.Command("bsdtar", "--zstd", "-cf", pkgPath, "-C", pkgDir, ".PKGINFO")
 		require.NoError(t, cmd.Run())
 
-		err := fixMalformedDependencies(pkgPath, &logger)
+		err := fixMalformedDependencies(pkgPath, "test", &logger)
 		assert.NoError(t, err)
 
 		// Verify the package was fixed by reading it back
@@ -863,7 +867,7 @@ depend = anaconda
 	})
 
 	t.Run("handles non-existent file", func(t *testing.T) {
-		err := fixMalformedDependencies("/nonexistent/package.pkg.tar.zst", &logger)
+		err := fixMalformedDependencies("/nonexistent/package.pkg.tar.zst", "test", &logger)
 		assert.Error(t, err)
 	})
 
@@ -874,7 +878,7 @@ depend = anaconda
 		// Create invalid package that will fail extraction
 		require.NoError(t, os.WriteFile(pkgPath, []byte("invalid package content"), 0644))
 
-		err := fixMalformedDependencies(pkgPath, &logger)
+		err := fixMalformedDependencies(pkgPath, "test", &logger)
 		assert.Error(t, err)
 	})
 }
@@ -18,7 +18,7 @@ import (
 // convertWithDebtapProgress converts a DEB package to Arch package with progress tracking
 //
 //nolint:gocyclo // debtap conversion involves multiple IO streams and search fallbacks.
-func (d *DebBackend) convertWithDebtapProgress(ctx context.Context, debPath, outputDir, expectedPkgName string, progress *ui.ProgressTracker) (string, error) {
+func (d *DebBackend) convertWithDebtapProgress(ctx context.Context, debPath, outputDir, expectedPkgName string, progress ui.Progress) (string, error) {
 	// Run debtap with quiet mode (-q) and skip interactive prompts (-Q)
 	convertCtx, cancel := context.WithTimeout(ctx, 30*time.Minute)
 	defer cancel()
@@ -34,92 +34,20 @@ func (d *DebBackend) convertWithDebtapProgress(ctx context.Context, debPath, out
 		Str("output_dir", outputDir).
 		Msg("running debtap conversion")
 
-	// Execute debtap with explicit working directory
-	// Using -Q for fully automated conversion, then fix dependencies afterwards
-	cmd := exec.CommandContext(convertCtx, "debtap", "-q", "-Q", absDebPath)
-	cmd.Dir = outputDir // Set working directory so debtap creates package here
-
-	stdoutPipe, err := cmd.StdoutPipe()
-	if err != nil {
-		return "", fmt.Errorf("failed to capture debtap stdout: %w", err)
+	// debtap downloads package metadata, so network hiccups are retried
+	label := "Converting DEB to Arch"
+	notify := func(attempt, total int, err error) {
+		d.Log.Warn().Err(err).Int("attempt", attempt).Int("attempts", total).Msg("debtap conversion failed transiently, retrying")
+		label = fmt.Sprintf("Converting DEB to Arch (attempt %d/%d)", attempt, total)
+		progress.UpdateIndeterminate(label)
 	}
-
-	stderrPipe, err := cmd.StderrPipe()
+	err = d.RetryPolicy().Do(convertCtx, notify, func(ctx context.Context) error {
+		return d.runDebtap(ctx, absDebPath, outputDir, progress, label)
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to capture debtap stderr: %w", err)
-	}
-
-	startErr := cmd.Start()
-	if startErr != nil {
-		return "", fmt.Errorf("failed to start debtap: %w", startErr)
+		return "", err
 	}
 
-	var stdoutBuf, stderrBuf bytes.Buffer
-
-	stdoutDone := make(chan struct{})
-	go func() {
-		defer close(stdoutDone)
-		reader := io.TeeReader(stdoutPipe, &stdoutBuf)
-		scanner := bufio.NewScanner(reader)
-		for scanner.Scan() {
-			d.Log.Debug().
-				Str("line", scanner.Text()).
-				Msg("debtap stdout")
-		}
-		if scanErr := scanner.Err(); scanErr != nil {
-			d.Log.Warn().Err(scanErr).Msg("failed to read debtap stdout")
-		}
-	}()
-
-	stderrDone := make(chan struct{})
-	go func() {
-		defer close(stderrDone)
-		reader := io.TeeReader(stderrPipe, &stderrBuf)
-		scanner := bufio.NewScanner(reader)
-		for scanner.Scan() {
-			d.Log.Debug().
-				Str("line", scanner.Text()).
-				Msg("debtap stderr")
-		}
-		if scanErr := scanner.Err(); scanErr != nil {
-			d.Log.Warn().Err(scanErr).Msg("failed to read debtap stderr")
-		}
-	}()
-
-	start := time.Now()
-	progressDone := make(chan struct{})
-	go func() {
-		ticker := time.NewTicker(1 * time.Second)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				progress.UpdateIndeterminateWithElapsed("Converting DEB to Arch", time.Since(start))
-			case <-progressDone:
-				return
-			}
-		}
-	}()
-
-	err = cmd.Wait()
-	close(progressDone)
-	<-stdoutDone
-	<-stderrDone
-
-	if err != nil {
-		d.Log.Error().
-			Err(err).
-			Str("stdout", stdoutBuf.String()).
-			Str("stderr", stderrBuf.String()).
-			Msg("debtap command failed")
-		return "", fmt.Errorf("debtap conversion failed: %w\nStderr: %s", err, stderrBuf.String())
-	}
-
-	d.Log.Debug().
-		Str("stdout", stdoutBuf.String()).
-		Str("stderr", stderrBuf.String()).
-		Msg("debtap conversion completed")
-
 	// Find generated .pkg.tar.* file
 	// Debtap creates package in current working directory, not in temp dir!
 
@@ -285,3 +213,94 @@ func extractPackageInfoFromArchive(pkgPath string) (*packageInfo, error) {
 
 	return info, nil
 }
+
+// runDebtap runs one debtap conversion attempt in outputDir, reporting
+// elapsed time under label
+func (d *DebBackend) runDebtap(ctx context.Context, absDebPath, outputDir string, progress ui.Progress, label string) error {
+	// Execute debtap with explicit working directory
+	// Using -Q for fully automated conversion, then fix dependencies afterwards
+	cmd := exec.CommandContext(ctx, "debtap", "-q", "-Q", absDebPath)
+	cmd.Dir = outputDir // Set working directory so debtap creates package here
+
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to capture debtap stdout: %w", err)
+	}
+
+	stderrPipe, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to capture debtap stderr: %w", err)
+	}
+
+	startErr := cmd.Start()
+	if startErr != nil {
+		return fmt.Errorf("failed to start debtap: %w", startErr)
+	}
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+
+	stdoutDone := make(chan struct{})
+	go func() {
+		defer close(stdoutDone)
+		reader := io.TeeReader(stdoutPipe, &stdoutBuf)
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			d.Log.Debug().
+				Str("line", scanner.Text()).
+				Msg("debtap stdout")
+		}
+		if scanErr := scanner.Err(); scanErr != nil {
+			d.Log.Warn().Err(scanErr).Msg("failed to read debtap stdout")
+		}
+	}()
+
+	stderrDone := make(chan struct{})
+	go func() {
+		defer close(stderrDone)
+		reader := io.TeeReader(stderrPipe, &stderrBuf)
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			d.Log.Debug().
+				Str("line", scanner.Text()).
+				Msg("debtap stderr")
+		}
+		if scanErr := scanner.Err(); scanErr != nil {
+			d.Log.Warn().Err(scanErr).Msg("failed to read debtap stderr")
+		}
+	}()
+
+	start := time.Now()
+	progressDone := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(1 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				progress.UpdateIndeterminateWithElapsed(label, time.Since(start))
+			case <-progressDone:
+				return
+			}
+		}
+	}()
+
+	err = cmd.Wait()
+	close(progressDone)
+	<-stdoutDone
+	<-stderrDone
+
+	if err != nil {
+		d.Log.Error().
+			Err(err).
+			Str("stdout", stdoutBuf.String()).
+			Str("stderr", stderrBuf.String()).
+			Msg("debtap command failed")
+		return fmt.Errorf("debtap conversion failed: %w\nStderr: %s", err, stderrBuf.String())
+	}
+
+	d.Log.Debug().
+		Str("stdout", stdoutBuf.String()).
+		Str("stderr", stderrBuf.String()).
+		Msg("debtap conversion completed")
+	return nil
+}
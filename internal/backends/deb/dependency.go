@@ -7,16 +7,18 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/quantmind-br/upkg/internal/helpers"
 	"github.com/rs/zerolog"
 	"github.com/spf13/afero"
 )
 
 // fixMalformedDependencies corrects common dependency name issues from debtap conversion
-// This addresses issues where epoch versions (like 2:1.4.99.1) cause name mangling
-func fixMalformedDependencies(pkgPath string, logger *zerolog.Logger) error {
+// This addresses issues where epoch versions (like 2:1.4.99.1) cause name mangling.
+// label names the temp directory after the install.
+func fixMalformedDependencies(pkgPath, label string, logger *zerolog.Logger) error {
 	// Extract the package to a temp directory
 	fs := afero.NewOsFs()
-	tmpDir, err := afero.TempDir(fs, "", "upkg-fix-deps-*")
+	tmpDir, err := helpers.CreateTempDir(fs, label, "fix-deps")
 	if err != nil {
 		return fmt.Errorf("failed to create temp directory: %w", err)
 	}
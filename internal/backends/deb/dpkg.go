@@ -0,0 +1,146 @@
+package deb
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	backendbase "github.com/quantmind-br/upkg/internal/backends/base"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/integration"
+	"github.com/quantmind-br/upkg/internal/security"
+	"github.com/quantmind-br/upkg/internal/syspkg"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/quantmind-br/upkg/internal/ui"
+)
+
+// installWithDpkg installs a DEB natively with apt (or dpkg -i) on
+// Debian-based systems. The package is owned by dpkg; upkg only records it
+// and adds the Wayland variables and user icon fallbacks.
+func (d *DebBackend) installWithDpkg(ctx context.Context, packagePath string, opts core.InstallOptions, tx *transaction.Manager, result *core.InstallResult) (*core.InstallRecord, error) {
+	phases := []ui.InstallationPhase{
+		{Name: "Validating package", Weight: 5, Deterministic: true},
+		{Name: "Installing with dpkg", Weight: 85, Deterministic: false}, // Indeterminate - uses spinner
+		{Name: "Configuring desktop", Weight: 10, Deterministic: true},
+	}
+	progress := d.NewProgress(ctx, phases, "Installing DEB")
+	defer progress.Finish()
+
+	// Phase 1: Validation
+	progress.StartPhase(0)
+
+	if !d.Runner.CommandExists("dpkg") && !d.Runner.CommandExists("apt-get") {
+		return nil, fmt.Errorf("dpkg not found - the dpkg method requires a Debian-based system")
+	}
+	if _, err := d.Fs.Stat(packagePath); err != nil {
+		return nil, fmt.Errorf("package not found: %w", err)
+	}
+
+	// apt only treats the argument as a file when it is a path
+	absPackagePath, err := filepath.Abs(packagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	control, err := helpers.ReadDebControl(absPackagePath)
+	if err != nil {
+		d.Log.Debug().Err(err).Msg("failed to read DEB control file")
+		control = map[string]string{}
+	}
+
+	// dpkg knows the package by its control name, so --name cannot rename it
+	pkgName := control["Package"]
+	if pkgName == "" {
+		if name, queryErr := d.queryDebName(ctx, absPackagePath); queryErr == nil {
+			pkgName = name
+		}
+	}
+	if pkgName == "" {
+		return nil, fmt.Errorf("cannot read the package name from %s", filepath.Base(packagePath))
+	}
+	if opts.CustomName != "" && opts.CustomName != pkgName {
+		result.Warn("--name is ignored for packages installed with dpkg (installed as %s)", pkgName)
+	}
+	if err := security.ValidatePackageName(pkgName); err != nil {
+		return nil, fmt.Errorf("invalid package name %q: %w", pkgName, err)
+	}
+
+	installID := helpers.GenerateInstallID(pkgName)
+	progress.AdvancePhase()
+
+	// Phase 2: Install with apt/dpkg (indeterminate phase)
+	progress.StartPhase(1)
+
+	installCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	backendbase.TickIndeterminate(installCtx, progress, "Installing with dpkg")
+
+	if err := d.dpkg.Install(installCtx, absPackagePath, &syspkg.InstallOptions{Overwrite: opts.Overwrite}); err != nil {
+		return nil, err
+	}
+	if tx != nil {
+		tx.Add("remove dpkg package", func() error {
+			removeCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+			defer cancel()
+			return d.dpkg.Remove(removeCtx, pkgName)
+		})
+	}
+
+	d.Log.Info().Msg("package installed successfully via dpkg")
+	progress.AdvancePhase()
+
+	// Phase 3: Desktop integration
+	progress.StartPhase(2)
+
+	version := control["Version"]
+	var installedSize int64
+	if info, infoErr := d.getPackageInfo(ctx, d.dpkg, pkgName); infoErr != nil {
+		d.Log.Warn().Err(infoErr).Str("package", pkgName).Msg("failed to get package info from dpkg")
+	} else {
+		if info.version != "" {
+			version = info.version
+		}
+		installedSize = info.size
+	}
+
+	desktopFiles, iconFiles, primaryDesktopFile := d.integrateSystemPackage(ctx, d.dpkg, pkgName, result)
+
+	comment := "Installed via dpkg"
+	if d.Cfg.Desktop.DescriptionFields {
+		if synopsis, _ := integration.DescriptionFields(control["Description"]); synopsis != "" {
+			comment = synopsis
+		}
+	}
+
+	record := &core.InstallRecord{
+		InstallID:    installID,
+		PackageType:  core.PackageTypeDeb,
+		Name:         pkgName,
+		Version:      strings.TrimSpace(version),
+		InstallDate:  time.Now(),
+		OriginalFile: packagePath,
+		DesktopFile:  primaryDesktopFile,
+		Metadata: core.Metadata{
+			IconFiles:      iconFiles,
+			WaylandSupport: string(core.WaylandUnknown),
+			InstallMethod:  core.InstallMethodDpkg,
+			InstalledSize:  installedSize,
+			DesktopFiles:   desktopFiles,
+			ExtractedMeta: core.ExtractedMetadata{
+				Comment: comment,
+			},
+		},
+	}
+
+	d.Log.Info().
+		Str("install_id", installID).
+		Str("name", pkgName).
+		Str("version", record.Version).
+		Msg("DEB package installed successfully (dpkg)")
+
+	return record, nil
+}
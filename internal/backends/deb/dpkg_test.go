@@ -0,0 +1,99 @@
+package deb
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstall_DpkgMethod(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	logger := zerolog.New(io.Discard)
+
+	var commands []string
+	runner := &helpers.MockCommandRunner{
+		CommandExistsFunc: func(name string) bool { return name == "dpkg" || name == "apt-get" },
+		RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+			command := strings.Join(append([]string{name}, args...), " ")
+			commands = append(commands, command)
+			switch {
+			case strings.HasPrefix(command, "dpkg-query -W -f=${Package}"):
+				return "vendor-app\t2.1.0-1", nil
+			case strings.HasPrefix(command, "dpkg-query -L"):
+				return "/.\n/opt/Vendor App/vendor-app\n/usr/share/applications/vendor-app.desktop\n", nil
+			}
+			return "", nil
+		},
+	}
+	cfg := &config.Config{}
+	cfg.Desktop.DescriptionFields = true
+	backend := NewWithDeps(cfg, &logger, afero.NewOsFs(), runner)
+
+	debPath := filepath.Join(t.TempDir(), "vendor-app_2.1.0_amd64.deb")
+	writeTestDeb(t, debPath)
+
+	tx := transaction.NewManager(&logger)
+	result, err := backend.Install(context.Background(), debPath, core.InstallOptions{Method: core.MethodDpkg, CustomName: "other"}, tx)
+	require.NoError(t, err)
+	tx.Commit()
+
+	record := result.Record
+	assert.Equal(t, "vendor-app", record.Name)
+	assert.Equal(t, "2.1.0-1", record.Version)
+	assert.Equal(t, core.InstallMethodDpkg, record.Metadata.InstallMethod)
+	assert.Empty(t, record.InstallPath)
+	assert.Equal(t, "/usr/share/applications/vendor-app.desktop", record.DesktopFile)
+	assert.Equal(t, "Vendor application", record.Metadata.ExtractedMeta.Comment)
+	assert.Contains(t, commands, "sudo apt-get install -y "+debPath)
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0], "--name is ignored")
+
+	commands = nil
+	runner.RunCommandFunc = func(_ context.Context, name string, args ...string) (string, error) {
+		commands = append(commands, strings.Join(append([]string{name}, args...), " "))
+		if name == "dpkg-query" {
+			return "installed", nil
+		}
+		return "", nil
+	}
+	_, err = backend.Uninstall(context.Background(), record)
+	require.NoError(t, err)
+	assert.Contains(t, commands, "sudo apt-get remove -y vendor-app")
+}
+
+func TestInstall_DpkgMethodRollsBack(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	logger := zerolog.New(io.Discard)
+
+	var removed bool
+	runner := &helpers.MockCommandRunner{
+		CommandExistsFunc: func(name string) bool { return name == "dpkg" },
+		RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+			if name == "sudo" && args[0] == "dpkg" && args[1] == "-r" {
+				removed = true
+			}
+			return "", nil
+		},
+	}
+	backend := NewWithDeps(&config.Config{}, &logger, afero.NewOsFs(), runner)
+
+	debPath := filepath.Join(t.TempDir(), "vendor-app_2.1.0_amd64.deb")
+	writeTestDeb(t, debPath)
+
+	tx := transaction.NewManager(&logger)
+	_, err := backend.Install(context.Background(), debPath, core.InstallOptions{Method: core.MethodDpkg}, tx)
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+	assert.True(t, removed)
+}
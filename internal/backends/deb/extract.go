@@ -0,0 +1,356 @@
+package deb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	backendbase "github.com/quantmind-br/upkg/internal/backends/base"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/heuristics"
+	"github.com/quantmind-br/upkg/internal/icons"
+	"github.com/quantmind-br/upkg/internal/integration"
+	"github.com/quantmind-br/upkg/internal/security"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/quantmind-br/upkg/internal/ui"
+)
+
+// installWithExtract installs a DEB by unpacking its data.tar into the apps
+// directory and creating a wrapper, desktop entry and icons, like the RPM
+// backend does. No system package manager is involved.
+//
+//nolint:gocyclo // extraction install handles multiple fallbacks and integrations.
+func (d *DebBackend) installWithExtract(ctx context.Context, packagePath string, opts core.InstallOptions, tx *transaction.Manager, result *core.InstallResult) (*core.InstallRecord, error) {
+	if _, err := d.Fs.Stat(packagePath); err != nil {
+		return nil, fmt.Errorf("package not found: %w", err)
+	}
+
+	absPackagePath, err := filepath.Abs(packagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	control, err := helpers.ReadDebControl(absPackagePath)
+	if err != nil {
+		d.Log.Debug().Err(err).Msg("failed to read DEB control file")
+		control = map[string]string{}
+	}
+
+	pkgName := opts.CustomName
+	if pkgName == "" {
+		pkgName = control["Package"]
+	}
+	if pkgName == "" {
+		pkgName = strings.TrimSuffix(filepath.Base(packagePath), filepath.Ext(packagePath))
+		d.Log.Debug().
+			Str("name", pkgName).
+			Msg("extracted package name from filename (no control file)")
+	}
+
+	normalizedName := helpers.NormalizeFilename(pkgName)
+	if err := security.ValidatePackageName(normalizedName); err != nil {
+		return nil, fmt.Errorf("invalid normalized name %q: %w", normalizedName, err)
+	}
+	installID := helpers.GenerateInstallID(normalizedName)
+
+	d.Log.Info().Msg("extracting DEB package...")
+
+	tmpDir, err := helpers.CreateTempDir(d.Fs, installID, "deb")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	d.Log.Debug().Str("install_id", installID).Str("tmp_dir", tmpDir).Msg("using temp dir")
+	defer func() {
+		if removeErr := d.Fs.RemoveAll(tmpDir); removeErr != nil {
+			d.Log.Debug().Err(removeErr).Str("tmp_dir", tmpDir).Msg("failed to remove temp dir")
+		}
+	}()
+
+	progress := d.NewProgress(ctx, []ui.InstallationPhase{
+		{Name: "Extracting DEB", Weight: 60, Deterministic: true},
+		{Name: "Creating launcher", Weight: 20, Deterministic: true},
+		{Name: "Installing icons", Weight: 10, Deterministic: true},
+		{Name: "Configuring desktop", Weight: 10, Deterministic: true},
+	}, "Installing DEB")
+	defer progress.Finish()
+
+	progress.StartPhase(0)
+	rootDir := filepath.Join(tmpDir, "root")
+	if err := d.Fs.MkdirAll(rootDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create extraction directory: %w", err)
+	}
+	if err := d.extractData(ctx, absPackagePath, tmpDir, rootDir, backendbase.ByteProgress(progress)); err != nil {
+		return nil, err
+	}
+	progress.AdvancePhase()
+
+	installDir := filepath.Join(d.Paths.GetUpkgAppsDir(), normalizedName)
+	if _, statErr := d.Fs.Stat(installDir); statErr == nil {
+		if !opts.Force {
+			return nil, &core.ErrAlreadyInstalled{Path: installDir}
+		}
+		if removeErr := d.Fs.RemoveAll(installDir); removeErr != nil {
+			return nil, fmt.Errorf("remove existing installation directory: %w", removeErr)
+		}
+		// Best-effort cleanup of expected wrapper/desktop paths
+		oldWrapper := filepath.Join(d.Paths.GetBinDir(), normalizedName)
+		if removeErr := d.Fs.Remove(oldWrapper); removeErr != nil {
+			d.Log.Debug().Err(removeErr).Str("path", oldWrapper).Msg("failed to remove existing wrapper")
+		}
+		oldDesktop := filepath.Join(d.Paths.GetAppsDir(), normalizedName+".desktop")
+		if removeErr := d.Fs.Remove(oldDesktop); removeErr != nil {
+			d.Log.Debug().Err(removeErr).Str("desktop_file", oldDesktop).Msg("failed to remove existing desktop file")
+		}
+	}
+
+	if mkdirErr := d.Fs.MkdirAll(installDir, 0755); mkdirErr != nil {
+		return nil, fmt.Errorf("failed to create installation directory: %w", mkdirErr)
+	}
+	if tx != nil {
+		dir := installDir
+		tx.Add("remove deb installation directory", func() error {
+			return d.Fs.RemoveAll(dir)
+		})
+		tx.TrackPaths(dir)
+	}
+
+	// DEB payloads are rooted at /, with applications under usr/ or opt/
+	for _, dir := range []string{"usr", "opt", "etc"} {
+		srcDir := filepath.Join(rootDir, dir)
+		if _, statErr := d.Fs.Stat(srcDir); statErr != nil {
+			continue
+		}
+		// Falls back to copy+verify+remove when tmp and data dir are on different filesystems
+		moveErr := helpers.MoveDir(d.Fs, srcDir, filepath.Join(installDir, dir), func(copied, total int64) {
+			d.Log.Debug().
+				Str("dir", dir).
+				Int64("copied", copied).
+				Int64("total", total).
+				Msg("copying across filesystems")
+		})
+		if moveErr != nil {
+			d.cleanupInstallDir(installDir, "move error")
+			return nil, fmt.Errorf("failed to move extracted %s directory: %w", dir, moveErr)
+		}
+	}
+
+	executables, err := heuristics.FindExecutables(installDir)
+	if err != nil || len(executables) == 0 {
+		d.cleanupInstallDir(installDir, "no executables")
+		return nil, fmt.Errorf("no executables found in DEB")
+	}
+	d.Log.Debug().
+		Strs("executables", executables).
+		Msg("found executables")
+
+	ranked := heuristics.NewScorer(d.Log, backendbase.ScoringRules(d.Cfg)).Rank(executables, normalizedName, installDir)
+	primaryExec := ranked[0].Path
+	result.Candidates = heuristics.Candidates(ranked, primaryExec)
+
+	wrapperPath, sandboxTool, err := d.Integration().CreateLauncher(normalizedName, primaryExec, opts, installDir)
+	if err != nil {
+		d.cleanupInstallDir(installDir, "wrapper error")
+		return nil, err
+	}
+	if tx != nil {
+		path := wrapperPath
+		tx.Add("remove deb wrapper script", func() error {
+			return d.Fs.Remove(path)
+		})
+		tx.TrackPaths(path)
+	}
+
+	progress.AdvancePhase()
+	iconPaths, err := d.installExtractedIcons(installDir, normalizedName)
+	if err != nil {
+		d.Log.Warn().Err(err).Msg("failed to install icons")
+		result.Warn("icons not installed: %v", err)
+	}
+	if tx != nil && len(iconPaths) > 0 {
+		paths := append([]string(nil), iconPaths...)
+		tx.Add("remove deb icons", func() error {
+			d.Integration().RemoveFiles(paths)
+			return nil
+		})
+		tx.TrackPaths(paths...)
+	}
+
+	progress.AdvancePhase()
+	framework, wayland := heuristics.DetectApp(d.Fs, installDir, primaryExec)
+	var desktopPath string
+	if !opts.SkipDesktop {
+		if description := control["Description"]; description != "" && d.Cfg.Desktop.DescriptionFields {
+			opts.Description = description
+		}
+		desktopPath, err = d.createExtractedDesktopFile(installDir, normalizedName, wrapperPath, wayland, opts)
+		if err != nil {
+			d.cleanupInstallDir(installDir, "desktop error")
+			if removeErr := d.Fs.Remove(wrapperPath); removeErr != nil {
+				d.Log.Debug().Err(removeErr).Str("path", wrapperPath).Msg("failed to cleanup wrapper after desktop error")
+			}
+			d.Integration().RemoveFiles(iconPaths)
+			return nil, fmt.Errorf("failed to create desktop file: %w", err)
+		}
+		if tx != nil && desktopPath != "" {
+			path := desktopPath
+			tx.Add("remove deb desktop file", func() error {
+				return d.Fs.Remove(path)
+			})
+			tx.TrackPaths(path)
+		}
+		d.updateUserCaches()
+	} else {
+		result.Skip("desktop entry", "--skip-desktop")
+	}
+
+	record := &core.InstallRecord{
+		InstallID:    installID,
+		PackageType:  core.PackageTypeDeb,
+		Name:         normalizedName,
+		Version:      control["Version"],
+		InstallDate:  time.Now(),
+		OriginalFile: packagePath,
+		InstallPath:  installDir,
+		DesktopFile:  desktopPath,
+		Metadata: core.Metadata{
+			IconFiles:      iconPaths,
+			WrapperScript:  wrapperPath,
+			WaylandSupport: string(wayland),
+			Framework:      string(framework),
+			InstallMethod:  core.InstallMethodLocal,
+			Sandbox:        sandboxTool,
+		},
+	}
+
+	d.Log.Info().
+		Str("install_id", installID).
+		Str("name", normalizedName).
+		Str("path", installDir).
+		Msg("DEB package installed successfully (extracted)")
+
+	return record, nil
+}
+
+// extractData unpacks the payload of a DEB into rootDir. zstd payloads,
+// which the Go reader cannot decode, are handed to bsdtar when installed.
+// onProgress follows the Go reader only.
+func (d *DebBackend) extractData(ctx context.Context, packagePath, tmpDir, rootDir string, onProgress helpers.ProgressFunc) error {
+	extractOpts := append(d.ExtractOptions(), helpers.WithProgress(onProgress))
+	err := helpers.ExtractDebData(packagePath, rootDir, extractOpts...)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, helpers.ErrUnsupportedDebCompression) {
+		return fmt.Errorf("failed to extract DEB payload: %w", err)
+	}
+	if !d.Runner.CommandExists("bsdtar") {
+		return fmt.Errorf("failed to extract DEB payload: %w: %w", err, d.MissingTool("bsdtar", d.RequiredTools()))
+	}
+
+	member, memberErr := helpers.DebDataMember(packagePath)
+	if memberErr != nil {
+		return fmt.Errorf("failed to extract DEB payload: %w", memberErr)
+	}
+
+	extractCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	if _, runErr := d.Runner.RunCommandInDir(extractCtx, tmpDir, "bsdtar", "-xf", packagePath, member); runErr != nil {
+		return fmt.Errorf("bsdtar failed: %w", runErr)
+	}
+	if _, runErr := d.Runner.RunCommandInDir(extractCtx, rootDir, "bsdtar", "-xf", filepath.Join(tmpDir, member)); runErr != nil {
+		return fmt.Errorf("bsdtar failed: %w", runErr)
+	}
+	return nil
+}
+
+// uninstallExtracted removes a DEB installed by extraction
+func (d *DebBackend) uninstallExtracted(record *core.InstallRecord, result *core.UninstallResult) {
+	if record.InstallPath != "" {
+		if err := d.Fs.RemoveAll(record.InstallPath); err != nil {
+			d.Log.Warn().Err(err).Msg("failed to remove installation directory")
+			result.Warn("failed to remove %s: %v", record.InstallPath, err)
+		}
+	}
+
+	if record.Metadata.WrapperScript != "" {
+		if err := d.Fs.Remove(record.Metadata.WrapperScript); err != nil {
+			d.Log.Warn().Err(err).Msg("failed to remove wrapper script")
+			result.Warn("failed to remove %s: %v", record.Metadata.WrapperScript, err)
+		}
+	}
+
+	for _, desktopPath := range record.GetDesktopFiles() {
+		if desktopPath == "" {
+			continue
+		}
+		if err := d.Fs.Remove(desktopPath); err != nil {
+			d.Log.Warn().Err(err).Str("path", desktopPath).Msg("failed to remove desktop file")
+			result.Warn("failed to remove %s: %v", desktopPath, err)
+		}
+	}
+
+	d.Integration().RemoveFiles(record.Metadata.IconFiles)
+	d.updateUserCaches()
+}
+
+// installExtractedIcons installs the icons found in an extracted payload
+func (d *DebBackend) installExtractedIcons(installDir, normalizedName string) ([]string, error) {
+	discoveredIcons, err := icons.NewManager(d.Fs, "").DiscoverIcons(installDir)
+	if err != nil {
+		return nil, err
+	}
+	return d.Integration().InstallIcons(discoveredIcons, normalizedName)
+}
+
+// createExtractedDesktopFile writes the desktop entry of an extracted DEB,
+// based on the one it ships when there is one
+func (d *DebBackend) createExtractedDesktopFile(installDir, normalizedName, wrapperPath string, wayland core.WaylandSupport, opts core.InstallOptions) (string, error) {
+	engine := d.Integration()
+	sourceDesktop := engine.FindDesktopFile(
+		filepath.Join(installDir, "usr", "share", "applications", "*.desktop"),
+		filepath.Join(installDir, "usr", "local", "share", "applications", "*.desktop"),
+		filepath.Join(installDir, "opt", "*", "share", "applications", "*.desktop"),
+		filepath.Join(installDir, "opt", "*", "*.desktop"),
+	)
+	if sourceDesktop == "" {
+		d.Log.Debug().Msg("no desktop file found in DEB, creating default")
+	}
+
+	spec := integration.DesktopSpec{
+		AppName:       helpers.FormatDisplayName(normalizedName),
+		FileName:      normalizedName,
+		ExecPath:      wrapperPath,
+		IconName:      normalizedName,
+		SourceDesktop: sourceDesktop,
+		PayloadRoot:   installDir,
+		Toolkit:       heuristics.DetectFramework(d.Fs, installDir, ""),
+		Wayland:       wayland,
+	}
+
+	return engine.WriteDesktopEntry(spec, opts)
+}
+
+// updateUserCaches refreshes the desktop database and icon cache of the
+// user's data directory
+func (d *DebBackend) updateUserCaches() {
+	appsDir := d.Paths.GetAppsDir()
+	if cacheErr := d.cacheManager.UpdateDesktopDatabase(appsDir, d.Log); cacheErr != nil {
+		d.Log.Warn().Err(cacheErr).Str("apps_dir", appsDir).Msg("failed to update desktop database")
+	}
+	iconsDir := d.Paths.GetIconsDir()
+	if cacheErr := d.cacheManager.UpdateIconCache(iconsDir, d.Log); cacheErr != nil {
+		d.Log.Warn().Err(cacheErr).Str("icons_dir", iconsDir).Msg("failed to update icon cache")
+	}
+}
+
+// cleanupInstallDir removes a partially populated install directory
+func (d *DebBackend) cleanupInstallDir(installDir, reason string) {
+	if removeErr := d.Fs.RemoveAll(installDir); removeErr != nil {
+		d.Log.Debug().Err(removeErr).Str("install_dir", installDir).Msgf("failed to cleanup install dir after %s", reason)
+	}
+}
@@ -0,0 +1,188 @@
+package deb
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestDeb writes a DEB whose payload holds a real ELF launcher under
+// opt/ and a desktop entry, with uncompressed control and data members
+func writeTestDeb(t *testing.T, path string) {
+	t.Helper()
+
+	self, err := os.Executable()
+	require.NoError(t, err)
+	elf, err := os.ReadFile(self)
+	require.NoError(t, err)
+
+	tarOf := func(files []struct {
+		name string
+		mode int64
+		data []byte
+	}) []byte {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for _, f := range files {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: f.name, Mode: f.mode, Size: int64(len(f.data)), Typeflag: tar.TypeReg}))
+			_, err := tw.Write(f.data)
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+		return buf.Bytes()
+	}
+
+	control := tarOf([]struct {
+		name string
+		mode int64
+		data []byte
+	}{
+		{"./control", 0644, []byte("Package: vendor-app\nVersion: 2.1.0\nDescription: Vendor application\n")},
+	})
+	data := tarOf([]struct {
+		name string
+		mode int64
+		data []byte
+	}{
+		{"./opt/Vendor App/vendor-app", 0755, elf},
+		{"./usr/share/applications/vendor-app.desktop", 0644, []byte("[Desktop Entry]\nType=Application\nName=Vendor App\nExec=\"/opt/Vendor App/vendor-app\" %U\nIcon=vendor-app\n")},
+		{"./usr/share/icons/hicolor/scalable/apps/vendor-app.svg", 0644, []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`)},
+	})
+
+	var deb bytes.Buffer
+	deb.WriteString("!<arch>\n")
+	for _, m := range []struct {
+		name string
+		data []byte
+	}{{"debian-binary", []byte("2.0\n")}, {"control.tar", control}, {"data.tar", data}} {
+		fmt.Fprintf(&deb, "%-16s%-12d%-6d%-6d%-8s%-10d`\n", m.name, 0, 0, 0, "100644", len(m.data))
+		deb.Write(m.data)
+		if len(m.data)%2 == 1 {
+			deb.WriteByte('\n')
+		}
+	}
+	require.NoError(t, os.WriteFile(path, deb.Bytes(), 0644))
+}
+
+func newExtractTestBackend(t *testing.T, exists func(string) bool) (*DebBackend, string) {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	logger := zerolog.New(io.Discard)
+	runner := &helpers.MockCommandRunner{CommandExistsFunc: exists}
+	return NewWithDeps(&config.Config{}, &logger, afero.NewOsFs(), runner), home
+}
+
+func TestDebBackend_installMethod(t *testing.T) {
+	all := func(string) bool { return true }
+	noPacman := func(name string) bool { return name != cmdPacman && name != cmdDebtap }
+	noDebtap := func(name string) bool { return name != cmdDebtap }
+	noDpkg := func(name string) bool { return name != "dpkg" }
+
+	tests := []struct {
+		name      string
+		osRelease string
+		exists    func(string) bool
+		method    string
+		provider  string
+		want      string
+	}{
+		{"auto on Arch", "ID=arch\n", all, "", "", core.MethodPacman},
+		{"auto spelled out", "ID=arch\n", all, core.MethodAuto, "", core.MethodPacman},
+		{"auto on Debian", "ID=debian\n", all, "", "", core.MethodDpkg},
+		{"auto on Ubuntu derivative", "ID=pop\nID_LIKE=\"ubuntu debian\"\n", noPacman, "", "", core.MethodDpkg},
+		{"auto on Debian without dpkg", "ID=debian\n", noDpkg, "", "", core.MethodPacman},
+		{"auto elsewhere without pacman", "ID=fedora\n", noPacman, "", "", core.MethodExtract},
+		{"auto without debtap", "ID=arch\n", noDebtap, "", "", core.MethodExtract},
+		{"auto without os-release", "", noPacman, "", "", core.MethodExtract},
+		{"forced extract on Arch", "ID=arch\n", all, core.MethodExtract, "", core.MethodExtract},
+		{"forced pacman on Debian", "ID=debian\n", all, core.MethodPacman, "", core.MethodPacman},
+		{"forced dpkg on Arch", "ID=arch\n", all, core.MethodDpkg, "", core.MethodDpkg},
+		{"configured dpkg on Arch", "ID=arch\n", all, "", "dpkg", core.MethodDpkg},
+		{"configured pacman on Debian", "ID=debian\n", all, "", "pacman", core.MethodPacman},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if tt.osRelease != "" {
+				require.NoError(t, afero.WriteFile(fs, "/etc/os-release", []byte(tt.osRelease), 0644))
+			}
+			logger := zerolog.New(io.Discard)
+			cfg := &config.Config{Syspkg: config.SyspkgConfig{Provider: tt.provider}}
+			backend := NewWithDeps(cfg, &logger, fs, &helpers.MockCommandRunner{CommandExistsFunc: tt.exists})
+			assert.Equal(t, tt.want, backend.installMethod(tt.method))
+		})
+	}
+}
+
+func TestInstall_ExtractMethod(t *testing.T) {
+	backend, home := newExtractTestBackend(t, func(string) bool { return false })
+	debPath := filepath.Join(t.TempDir(), "vendor-app_2.1.0_amd64.deb")
+	writeTestDeb(t, debPath)
+
+	tx := transaction.NewManager(backend.Log)
+	result, err := backend.Install(context.Background(), debPath, core.InstallOptions{}, tx)
+	require.NoError(t, err)
+	tx.Commit()
+	record := result.Record
+
+	installDir := filepath.Join(home, ".local", "share", "upkg", "apps", "vendor-app")
+	assert.Equal(t, "vendor-app", record.Name)
+	assert.Equal(t, "2.1.0", record.Version)
+	assert.Equal(t, core.PackageTypeDeb, record.PackageType)
+	assert.Equal(t, core.InstallMethodLocal, record.Metadata.InstallMethod)
+	assert.Equal(t, installDir, record.InstallPath)
+
+	wrapper, err := os.ReadFile(record.Metadata.WrapperScript)
+	require.NoError(t, err)
+	assert.Contains(t, string(wrapper), filepath.Join(installDir, "opt", "Vendor App", "vendor-app"))
+
+	require.NotEmpty(t, record.DesktopFile)
+	entry, err := os.ReadFile(record.DesktopFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(entry), record.Metadata.WrapperScript)
+	assert.NotEmpty(t, record.Metadata.IconFiles)
+
+	_, err = backend.Install(context.Background(), debPath, core.InstallOptions{}, nil)
+	assert.ErrorContains(t, err, "already installed")
+
+	uninstall, err := backend.Uninstall(context.Background(), record)
+	require.NoError(t, err)
+	assert.Empty(t, uninstall.Warnings)
+	assert.NoDirExists(t, installDir)
+	assert.NoFileExists(t, record.Metadata.WrapperScript)
+	assert.NoFileExists(t, record.DesktopFile)
+	for _, icon := range record.Metadata.IconFiles {
+		assert.NoFileExists(t, icon)
+	}
+}
+
+func TestInstall_ExtractMethodZstdNeedsBsdtar(t *testing.T) {
+	backend, _ := newExtractTestBackend(t, func(string) bool { return false })
+	debPath := filepath.Join(t.TempDir(), "zstd.deb")
+	var deb bytes.Buffer
+	deb.WriteString("!<arch>\n")
+	fmt.Fprintf(&deb, "%-16s%-12d%-6d%-6d%-8s%-10d`\n", "data.tar.zst", 0, 0, 0, "100644", 4)
+	deb.WriteString("zstd")
+	require.NoError(t, os.WriteFile(debPath, deb.Bytes(), 0644))
+
+	_, err := backend.Install(context.Background(), debPath, core.InstallOptions{Method: core.MethodExtract}, nil)
+	var missing *core.ErrMissingTool
+	require.ErrorAs(t, err, &missing)
+	assert.Equal(t, "bsdtar", missing.Tool)
+}
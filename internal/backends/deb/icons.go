@@ -151,8 +151,7 @@ func (d *DebBackend) installUserIconFallback(iconFiles []string, desktopFile str
 	}
 
 	iconSize := icons.DetectIconSize(source)
-	iconDir := filepath.Join(homeDir, ".local", "share", "icons")
-	manager := icons.NewManager(d.Fs, iconDir)
+	manager := icons.NewManager(d.Fs, d.Paths.GetIconsBaseDir())
 
 	installedPath, err := manager.InstallIcon(source, iconName, iconSize)
 	if err != nil {
@@ -204,7 +203,7 @@ func (d *DebBackend) iconNameFromDesktopFile(desktopPath string) (string, error)
 }
 
 func (d *DebBackend) removeUserIcons(iconPaths []string) bool {
-	homeDir := d.Paths.HomeDir()
+	homeDir := d.fallbackIconRoot()
 	if homeDir == "" {
 		return false
 	}
@@ -234,3 +233,12 @@ func (d *DebBackend) removeUserIcons(iconPaths []string) bool {
 
 	return removedAny
 }
+
+// fallbackIconRoot returns the directory fallback icons are copied under: the
+// home directory, or the system icon themes for system-wide installs
+func (d *DebBackend) fallbackIconRoot() string {
+	if d.Paths.IsSystem() {
+		return d.Paths.GetIconsBaseDir()
+	}
+	return d.Paths.HomeDir()
+}
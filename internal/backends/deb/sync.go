@@ -0,0 +1,80 @@
+package deb
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+)
+
+// SyncMetadata re-reads the version and owned files of a pacman- or
+// dpkg-managed install, picking up reinstalls and upgrades done outside upkg
+func (d *DebBackend) SyncMetadata(ctx context.Context, record *core.InstallRecord) (bool, error) {
+	pkgName := helpers.NormalizeFilename(record.Name)
+	sys := d.provider(record.Metadata.InstallMethod)
+
+	installed, err := sys.IsInstalled(ctx, pkgName)
+	if err != nil {
+		return false, fmt.Errorf("query %s for %s: %w", sys.Name(), pkgName, err)
+	}
+	if !installed {
+		return false, fmt.Errorf("package %s is no longer installed in %s", pkgName, sys.Name())
+	}
+
+	info, err := d.getPackageInfo(ctx, sys, pkgName)
+	if err != nil {
+		return false, fmt.Errorf("query %s for %s: %w", sys.Name(), pkgName, err)
+	}
+	files, err := d.findInstalledFiles(ctx, sys, pkgName)
+	if err != nil {
+		return false, fmt.Errorf("list files of %s: %w", pkgName, err)
+	}
+
+	changed := false
+	if info.version != "" && info.version != record.Version {
+		record.Version = info.version
+		changed = true
+	}
+	if info.size > 0 && info.size != record.Metadata.InstalledSize {
+		record.Metadata.InstalledSize = info.size
+		changed = true
+	}
+
+	desktopFiles := d.findDesktopFiles(files)
+	if !slices.Equal(desktopFiles, record.Metadata.DesktopFiles) {
+		record.Metadata.DesktopFiles = desktopFiles
+		record.DesktopFile = ""
+		if len(desktopFiles) > 0 {
+			record.DesktopFile = desktopFiles[0]
+		}
+		changed = true
+	}
+
+	// Fallback icons copied into the home directory are not owned by the package manager
+	iconFiles := d.findIconFiles(files)
+	for _, icon := range record.Metadata.IconFiles {
+		if d.isUserIcon(icon) {
+			iconFiles = append(iconFiles, icon)
+		}
+	}
+	if !slices.Equal(iconFiles, record.Metadata.IconFiles) {
+		record.Metadata.IconFiles = iconFiles
+		changed = true
+	}
+
+	return changed, nil
+}
+
+// isUserIcon reports whether path is a fallback icon upkg copied (see
+// fallbackIconRoot)
+func (d *DebBackend) isUserIcon(path string) bool {
+	homeDir := d.fallbackIconRoot()
+	if homeDir == "" {
+		return false
+	}
+	return strings.HasPrefix(filepath.Clean(path), filepath.Clean(homeDir)+string(filepath.Separator))
+}
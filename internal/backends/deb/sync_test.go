@@ -0,0 +1,90 @@
+package deb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/syspkg"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncMetadata(t *testing.T) {
+	t.Parallel()
+	logger := zerolog.New(io.Discard)
+
+	backend := NewWithDeps(&config.Config{}, &logger, afero.NewMemMapFs(), &helpers.MockCommandRunner{})
+	backend.sys = &mockSyspkgProvider{
+		isInstalled: true,
+		GetInfoFunc: func(_ context.Context, name string) (*syspkg.PackageInfo, error) {
+			return &syspkg.PackageInfo{Name: name, Version: "2.0-1", InstalledSize: 4096}, nil
+		},
+		ListFilesFunc: func(_ context.Context, _ string) ([]string, error) {
+			return []string{
+				"/usr/share/applications/",
+				"/usr/share/applications/tool.desktop",
+				"/usr/share/icons/hicolor/256x256/apps/tool.png",
+				"/usr/bin/tool",
+			}, nil
+		},
+	}
+
+	userIcon := filepath.Join(backend.Paths.GetIconsDir(), "256x256", "apps", "tool.png")
+	record := &core.InstallRecord{
+		Name:        "tool",
+		Version:     "1.0-1",
+		DesktopFile: "/usr/share/applications/old-tool.desktop",
+		Metadata: core.Metadata{
+			InstallMethod: core.InstallMethodPacman,
+			DesktopFiles:  []string{"/usr/share/applications/old-tool.desktop"},
+			IconFiles:     []string{"/usr/share/pixmaps/old.png", userIcon},
+		},
+	}
+
+	changed, err := backend.SyncMetadata(context.Background(), record)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "2.0-1", record.Version)
+	assert.Equal(t, int64(4096), record.Metadata.InstalledSize)
+	assert.Equal(t, "/usr/share/applications/tool.desktop", record.DesktopFile)
+	assert.Equal(t, []string{"/usr/share/applications/tool.desktop"}, record.Metadata.DesktopFiles)
+	assert.Equal(t, []string{"/usr/share/icons/hicolor/256x256/apps/tool.png", userIcon}, record.Metadata.IconFiles)
+
+	changed, err = backend.SyncMetadata(context.Background(), record)
+	require.NoError(t, err)
+	assert.False(t, changed, "second sync is a no-op")
+}
+
+func TestSyncMetadata_NotInstalled(t *testing.T) {
+	t.Parallel()
+	logger := zerolog.New(io.Discard)
+
+	backend := NewWithDeps(&config.Config{}, &logger, afero.NewMemMapFs(), &helpers.MockCommandRunner{})
+	backend.sys = &mockSyspkgProvider{isInstalled: false}
+
+	_, err := backend.SyncMetadata(context.Background(), &core.InstallRecord{Name: "gone"})
+	assert.ErrorContains(t, err, "no longer installed")
+}
+
+func TestSyncMetadata_QueryFailure(t *testing.T) {
+	t.Parallel()
+	logger := zerolog.New(io.Discard)
+
+	backend := NewWithDeps(&config.Config{}, &logger, afero.NewMemMapFs(), &helpers.MockCommandRunner{})
+	backend.sys = &mockSyspkgProvider{isInstalledErr: errors.New("database is locked")}
+
+	record := &core.InstallRecord{Name: "tool", Version: "1.0-1"}
+	changed, err := backend.SyncMetadata(context.Background(), record)
+	require.ErrorContains(t, err, "database is locked")
+	assert.NotContains(t, err.Error(), "no longer installed")
+	assert.False(t, changed)
+	assert.Equal(t, "1.0-1", record.Version)
+}
@@ -40,13 +40,25 @@ func (f *FlatpakBackend) Name() string {
 	return "flatpak"
 }
 
+// RequiredTools lists the external tools used by the Flatpak backend
+func (f *FlatpakBackend) RequiredTools() []core.ToolRequirement {
+	return []core.ToolRequirement{
+		{
+			Name:     "flatpak",
+			Purpose:  "install and remove Flatpak applications",
+			Packages: map[string]string{core.DistroArch: "flatpak", core.DistroDebian: "flatpak", core.DistroFedora: "flatpak", core.DistroSUSE: "flatpak"},
+		},
+	}
+}
+
 // Detect checks if the input is a Flatpak package
 func (f *FlatpakBackend) Detect(ctx context.Context, input string) (bool, error) {
 	return Detect(ctx, f.Fs, input)
 }
 
-func (f *FlatpakBackend) Install(ctx context.Context, input string, opts core.InstallOptions, tx *transaction.Manager) (*core.InstallRecord, error) {
-	if err := f.Runner.RequireCommand("flatpak"); err != nil {
+func (f *FlatpakBackend) Install(ctx context.Context, input string, opts core.InstallOptions, tx *transaction.Manager) (*core.InstallResult, error) {
+	result := core.NewInstallResult()
+	if err := f.RequireCommand("flatpak", f.RequiredTools()); err != nil {
 		return nil, err
 	}
 
@@ -81,7 +93,12 @@ func (f *FlatpakBackend) Install(ctx context.Context, input string, opts core.In
 		Strs("args", args).
 		Msg("Installing flatpak package")
 
-	output, err := f.Runner.RunCommand(ctx, "flatpak", args...)
+	// --or-update makes the install idempotent, so transient network
+	// failures are retried
+	runner := helpers.NewRetryingRunner(f.Runner, f.RetryPolicy(), func(attempt, total int, err error) {
+		f.Log.Warn().Err(err).Int("attempt", attempt).Int("attempts", total).Msg("flatpak install failed transiently, retrying")
+	})
+	output, err := runner.RunCommand(ctx, "flatpak", args...)
 	if err != nil {
 		return nil, fmt.Errorf("flatpak install failed: %w", err)
 	}
@@ -96,6 +113,7 @@ func (f *FlatpakBackend) Install(ctx context.Context, input string, opts core.In
 		}
 		if appID == "" {
 			appID = input
+			result.Warn("could not determine the installed application ID, recorded as %q", input)
 		}
 	}
 
@@ -109,7 +127,7 @@ func (f *FlatpakBackend) Install(ctx context.Context, input string, opts core.In
 		Metadata:     core.Metadata{},
 	}
 
-	return record, nil
+	return result.Finish(record), nil
 }
 
 func (f *FlatpakBackend) getInstalledAppIDs(ctx context.Context) map[string]bool {
@@ -153,9 +171,10 @@ func extractAppIDFromOutput(output string) string {
 	return ""
 }
 
-func (f *FlatpakBackend) Uninstall(ctx context.Context, record *core.InstallRecord) error {
-	if err := f.Runner.RequireCommand("flatpak"); err != nil {
-		return err
+func (f *FlatpakBackend) Uninstall(ctx context.Context, record *core.InstallRecord) (*core.UninstallResult, error) {
+	result := core.NewUninstallResult()
+	if err := f.RequireCommand("flatpak", f.RequiredTools()); err != nil {
+		return nil, err
 	}
 
 	args := []string{"uninstall", "--user", "--noninteractive", "-y"}
@@ -173,10 +192,10 @@ func (f *FlatpakBackend) Uninstall(ctx context.Context, record *core.InstallReco
 
 	output, err := f.Runner.RunCommand(ctx, "flatpak", args...)
 	if err != nil {
-		return fmt.Errorf("flatpak uninstall failed: %w", err)
+		return nil, fmt.Errorf("flatpak uninstall failed: %w", err)
 	}
 
 	f.Log.Debug().Str("output", output).Msg("Flatpak uninstall output")
 
-	return nil
+	return result.Finish(), nil
 }
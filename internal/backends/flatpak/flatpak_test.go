@@ -190,7 +190,7 @@ func TestFlatpakBackend_Install(t *testing.T) {
 			},
 			setupFS:       func(fs afero.Fs) {},
 			expectError:   true,
-			errorContains: "command not found",
+			errorContains: `required command "flatpak" not found`,
 		},
 		{
 			name:  "error: remote not configured",
@@ -230,7 +230,8 @@ func TestFlatpakBackend_Install(t *testing.T) {
 			backend := NewWithDeps(cfg, &logger, fs, mockRunner)
 			tx := transaction.NewManager(&logger)
 
-			record, err := backend.Install(context.Background(), tt.input, tt.opts, tx)
+			result, err := backend.Install(context.Background(), tt.input, tt.opts, tx)
+			record := result.GetRecord()
 
 			if tt.expectError {
 				require.Error(t, err)
@@ -323,7 +324,7 @@ func TestFlatpakBackend_Uninstall(t *testing.T) {
 				}
 			},
 			expectError:   true,
-			errorContains: "command not found",
+			errorContains: `required command "flatpak" not found`,
 		},
 		{
 			name: "error: app not installed",
@@ -360,7 +361,7 @@ func TestFlatpakBackend_Uninstall(t *testing.T) {
 
 			backend := NewWithDeps(cfg, &logger, fs, mockRunner)
 
-			err := backend.Uninstall(context.Background(), tt.record)
+			_, err := backend.Uninstall(context.Background(), tt.record)
 
 			if tt.expectError {
 				require.Error(t, err)
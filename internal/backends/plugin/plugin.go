@@ -0,0 +1,259 @@
+// Package plugin runs out-of-tree backends: executables named
+// upkg-backend-<name> in the plugins directory that speak a JSON-over-stdio
+// protocol. upkg writes one Request to the plugin's stdin and reads one
+// Response from its stdout; stderr is kept for error messages. A non-zero
+// exit or a non-empty Response.Error fails the action.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	backendbase "github.com/quantmind-br/upkg/internal/backends/base"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/security"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/spf13/afero"
+)
+
+// Prefix is the file name prefix of plugin executables
+const Prefix = "upkg-backend-"
+
+// ProtocolVersion is sent with every request; bump it on incompatible changes
+const ProtocolVersion = 1
+
+// detectTimeout bounds a detect call, which runs for every install
+const detectTimeout = 10 * time.Second
+
+// Actions of the protocol
+const (
+	ActionDetect    = "detect"
+	ActionInstall   = "install"
+	ActionUninstall = "uninstall"
+)
+
+// Request is written to the plugin's stdin
+type Request struct {
+	Protocol int             `json:"protocol"`
+	Action   string          `json:"action"`
+	Package  string          `json:"package,omitempty"` // detect, install: absolute path or input as typed
+	Options  *InstallOptions `json:"options,omitempty"` // install
+	Record   *Record         `json:"record,omitempty"`  // uninstall: what install returned
+	Dirs     Dirs            `json:"dirs"`
+}
+
+// InstallOptions are the install flags a plugin may honor
+type InstallOptions struct {
+	Name        string `json:"name,omitempty"` // --name
+	Force       bool   `json:"force,omitempty"`
+	SkipDesktop bool   `json:"skip_desktop,omitempty"`
+}
+
+// Dirs are the locations upkg manages, so plugin installs land where the
+// built-in backends put theirs
+type Dirs struct {
+	Bin          string `json:"bin"`          // Launchers (on PATH)
+	Apps         string `json:"apps"`         // Application payloads
+	Applications string `json:"applications"` // .desktop entries
+	Icons        string `json:"icons"`        // hicolor icon theme root
+}
+
+// Record describes an installed package
+type Record struct {
+	Name        string   `json:"name"`
+	Version     string   `json:"version,omitempty"`
+	InstallPath string   `json:"install_path,omitempty"`
+	Launcher    string   `json:"launcher,omitempty"`
+	DesktopFile string   `json:"desktop_file,omitempty"`
+	Icons       []string `json:"icons,omitempty"`
+}
+
+// Response is read from the plugin's stdout
+type Response struct {
+	Detected bool     `json:"detected,omitempty"` // detect
+	Record   *Record  `json:"record,omitempty"`   // install
+	Warnings []string `json:"warnings,omitempty"` // install, uninstall
+	Error    string   `json:"error,omitempty"`
+}
+
+// Found is a plugin executable discovered on disk
+type Found struct {
+	Name string // Backend and package type name
+	Path string
+}
+
+// Discover lists the plugin executables in dir, sorted by name. A missing
+// directory has no plugins.
+func Discover(fs afero.Fs, dir string) []Found {
+	entries, err := afero.ReadDir(fs, dir)
+	if err != nil {
+		return nil
+	}
+
+	var found []Found
+	for _, entry := range entries {
+		name := strings.TrimPrefix(entry.Name(), Prefix)
+		if entry.IsDir() || name == entry.Name() || name == "" || entry.Mode().Perm()&0o111 == 0 {
+			continue
+		}
+		found = append(found, Found{Name: name, Path: filepath.Join(dir, entry.Name())})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+	return found
+}
+
+// Backend adapts a plugin executable to the backend interface
+type Backend struct {
+	*backendbase.BaseBackend
+	name string
+	path string
+}
+
+// New creates the backend for the plugin at path
+func New(base *backendbase.BaseBackend, name, path string) *Backend {
+	return &Backend{BaseBackend: base, name: name, path: path}
+}
+
+// Name returns the plugin name, which is also the package type of its installs
+func (b *Backend) Name() string {
+	return b.name
+}
+
+// Path returns the plugin executable
+func (b *Backend) Path() string {
+	return b.path
+}
+
+// Detect asks the plugin whether it handles input
+func (b *Backend) Detect(ctx context.Context, input string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, detectTimeout)
+	defer cancel()
+
+	resp, err := b.call(ctx, Request{Action: ActionDetect, Package: absolute(input)})
+	if err != nil {
+		return false, err
+	}
+	return resp.Detected, nil
+}
+
+// Install has the plugin install input and records what it reports
+func (b *Backend) Install(ctx context.Context, input string, opts core.InstallOptions, tx *transaction.Manager) (*core.InstallResult, error) {
+	result := core.NewInstallResult()
+
+	resp, err := b.call(ctx, Request{
+		Action:  ActionInstall,
+		Package: absolute(input),
+		Options: &InstallOptions{Name: opts.CustomName, Force: opts.Force, SkipDesktop: opts.SkipDesktop},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Record == nil || resp.Record.Name == "" {
+		return nil, fmt.Errorf("plugin %s returned no record", b.name)
+	}
+	for _, warning := range resp.Warnings {
+		result.Warn("%s", warning)
+	}
+
+	record := &core.InstallRecord{
+		InstallID:    helpers.GenerateInstallID(helpers.NormalizeFilename(resp.Record.Name)),
+		PackageType:  core.PackageType(b.name),
+		Name:         resp.Record.Name,
+		Version:      resp.Record.Version,
+		InstallDate:  time.Now(),
+		OriginalFile: input,
+		InstallPath:  resp.Record.InstallPath,
+		DesktopFile:  resp.Record.DesktopFile,
+		Metadata: core.Metadata{
+			WrapperScript: resp.Record.Launcher,
+			IconFiles:     resp.Record.Icons,
+			InstallMethod: core.InstallMethodLocal,
+		},
+	}
+
+	// The plugin installed everything in one step: undo it as a whole if
+	// a later step of the install fails
+	if tx != nil {
+		tx.Add("plugin_uninstall", func() error {
+			_, uninstallErr := b.Uninstall(context.Background(), record)
+			return uninstallErr
+		})
+		tx.TrackPaths(record.ManagedPaths()...)
+	}
+
+	return result.Finish(record), nil
+}
+
+// Uninstall has the plugin remove an install it made
+func (b *Backend) Uninstall(ctx context.Context, record *core.InstallRecord) (*core.UninstallResult, error) {
+	result := core.NewUninstallResult()
+
+	resp, err := b.call(ctx, Request{Action: ActionUninstall, Record: &Record{
+		Name:        record.Name,
+		Version:     record.Version,
+		InstallPath: record.InstallPath,
+		Launcher:    record.Metadata.WrapperScript,
+		DesktopFile: record.DesktopFile,
+		Icons:       record.Metadata.IconFiles,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range resp.Warnings {
+		result.Warn("%s", warning)
+	}
+	return result.Finish(), nil
+}
+
+// call runs the plugin with req on stdin and decodes its response
+func (b *Backend) call(ctx context.Context, req Request) (*Response, error) {
+	req.Protocol = ProtocolVersion
+	req.Dirs = Dirs{
+		Bin:          b.Paths.GetBinDir(),
+		Apps:         b.Paths.GetUpkgAppsDir(),
+		Applications: b.Paths.GetAppsDir(),
+		Icons:        b.Paths.GetIconsBaseDir(),
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("encode plugin request: %w", err)
+	}
+
+	b.Log.Debug().Str("plugin", b.name).Str("action", req.Action).Str("package", req.Package).Msg("calling backend plugin")
+
+	var stdout, stderr bytes.Buffer
+	cmd := b.Runner.PrepareCommand(ctx, b.path)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if runErr := cmd.Run(); runErr != nil {
+		return nil, fmt.Errorf("plugin %s %s failed: %w\nstderr: %s", b.name, req.Action, runErr, security.Redact(strings.TrimSpace(stderr.String())))
+	}
+
+	var resp Response
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("plugin %s %s: invalid response: %w", b.name, req.Action, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %s %s failed: %s", b.name, req.Action, resp.Error)
+	}
+	return &resp, nil
+}
+
+// absolute resolves input when it names a file, so plugins need not know
+// upkg's working directory; other inputs (IDs, URLs) pass through
+func absolute(input string) string {
+	if strings.Contains(input, "://") {
+		return input
+	}
+	if abs, err := filepath.Abs(input); err == nil {
+		return abs
+	}
+	return input
+}
@@ -0,0 +1,122 @@
+package plugin
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	backendbase "github.com/quantmind-br/upkg/internal/backends/base"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// script is a plugin that logs every request to $dir/requests and answers
+// detect for *.acme files
+const script = `#!/bin/sh
+req=$(cat)
+printf '%s\n' "$req" >> "$(dirname "$0")/requests"
+case "$req" in
+*'"action":"detect"'*)
+	case "$req" in *'.acme"'*) echo '{"detected":true}' ;; *) echo '{}' ;; esac ;;
+*'"action":"install"'*)
+	echo '{"record":{"name":"Acme Tool","version":"2.1","install_path":"/opt/acme","launcher":"/bin/acme","icons":["/icons/acme.png"]},"warnings":["no desktop entry"]}' ;;
+*'"action":"uninstall"'*)
+	echo '{}' ;;
+*)
+	echo '{"error":"unknown action"}' ;;
+esac
+`
+
+func newTestBackend(t *testing.T, body string) (*Backend, string) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, Prefix+"acme")
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o755))
+
+	logger := zerolog.New(io.Discard)
+	cfg := &config.Config{}
+	cfg.Paths.DataDir = filepath.Join(dir, "data")
+	base := backendbase.NewWithDeps(cfg, &logger, afero.NewOsFs(), helpers.NewOSCommandRunner())
+	return New(base, "acme", path), dir
+}
+
+func TestDiscover(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/plugins/upkg-backend-zeta", []byte("#!/bin/sh"), 0o755))
+	require.NoError(t, afero.WriteFile(fs, "/plugins/upkg-backend-acme", []byte("#!/bin/sh"), 0o755))
+	require.NoError(t, afero.WriteFile(fs, "/plugins/upkg-backend-noexec", []byte("#!/bin/sh"), 0o644))
+	require.NoError(t, afero.WriteFile(fs, "/plugins/README", []byte("docs"), 0o755))
+
+	assert.Equal(t, []Found{
+		{Name: "acme", Path: "/plugins/upkg-backend-acme"},
+		{Name: "zeta", Path: "/plugins/upkg-backend-zeta"},
+	}, Discover(fs, "/plugins"))
+	assert.Empty(t, Discover(fs, "/missing"))
+}
+
+func TestBackend_Detect(t *testing.T) {
+	backend, _ := newTestBackend(t, script)
+
+	ok, err := backend.Detect(context.Background(), "/tmp/tool.acme")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = backend.Detect(context.Background(), "/tmp/tool.deb")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestBackend_InstallAndRollback(t *testing.T) {
+	backend, dir := newTestBackend(t, script)
+	logger := zerolog.New(io.Discard)
+	tx := transaction.NewManager(&logger)
+
+	result, err := backend.Install(context.Background(), "/tmp/tool.acme", core.InstallOptions{CustomName: "acme"}, tx)
+	require.NoError(t, err)
+
+	record := result.Record
+	assert.Equal(t, core.PackageType("acme"), record.PackageType)
+	assert.Equal(t, "Acme Tool", record.Name)
+	assert.Equal(t, "2.1", record.Version)
+	assert.Equal(t, "/opt/acme", record.InstallPath)
+	assert.Equal(t, "/bin/acme", record.Metadata.WrapperScript)
+	assert.Equal(t, []string{"/icons/acme.png"}, record.Metadata.IconFiles)
+	assert.Equal(t, []string{"no desktop entry"}, result.Warnings)
+
+	// A failure after the install has the plugin undo it
+	require.NoError(t, tx.Rollback())
+	requests, err := os.ReadFile(filepath.Join(dir, "requests"))
+	require.NoError(t, err)
+	assert.Contains(t, string(requests), `"action":"install"`)
+	assert.Contains(t, string(requests), `"name":"acme"`)
+	assert.Contains(t, string(requests), `"action":"uninstall"`)
+	assert.Contains(t, string(requests), `"apps":"`+filepath.Join(dir, "data", "apps")+`"`)
+}
+
+func TestBackend_Errors(t *testing.T) {
+	t.Run("error response", func(t *testing.T) {
+		backend, _ := newTestBackend(t, "#!/bin/sh\ncat >/dev/null\necho '{\"error\":\"license server unreachable\"}'\n")
+		_, err := backend.Install(context.Background(), "/tmp/tool.acme", core.InstallOptions{}, nil)
+		assert.ErrorContains(t, err, "plugin acme install failed: license server unreachable")
+	})
+
+	t.Run("non-zero exit", func(t *testing.T) {
+		backend, _ := newTestBackend(t, "#!/bin/sh\ncat >/dev/null\necho boom >&2\nexit 3\n")
+		_, err := backend.Uninstall(context.Background(), &core.InstallRecord{Name: "Acme Tool"})
+		assert.ErrorContains(t, err, "stderr: boom")
+	})
+
+	t.Run("missing record", func(t *testing.T) {
+		backend, _ := newTestBackend(t, "#!/bin/sh\ncat >/dev/null\necho '{}'\n")
+		_, err := backend.Install(context.Background(), "/tmp/tool.acme", core.InstallOptions{}, nil)
+		assert.ErrorContains(t, err, "returned no record")
+	})
+}
@@ -0,0 +1,163 @@
+package backends
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/syspkg"
+	"github.com/quantmind-br/upkg/internal/versions"
+	"github.com/spf13/afero"
+)
+
+// ToolDeclarer is implemented by backends that depend on external tools
+type ToolDeclarer interface {
+	// RequiredTools lists the external tools the backend needs
+	RequiredTools() []core.ToolRequirement
+}
+
+var versionRegex = regexp.MustCompile(`\d+(?:\.\d+)+`)
+
+// ToolStatus is the preflight result for one requirement
+type ToolStatus struct {
+	Requirement core.ToolRequirement
+	Found       string // Executable that satisfied the requirement
+	Version     string // Detected version (when MinVersion is set)
+}
+
+// PreflightReport groups tool statuses for a backend
+type PreflightReport struct {
+	Backend         string
+	Missing         []ToolStatus
+	Outdated        []ToolStatus
+	OptionalMissing []ToolStatus
+}
+
+// OK reports whether every required tool is present and recent enough
+func (r *PreflightReport) OK() bool {
+	return len(r.Missing) == 0 && len(r.Outdated) == 0
+}
+
+// Err returns one consolidated, actionable error for the distro family, or nil
+func (r *PreflightReport) Err(distro string) error {
+	if r.OK() {
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s backend is missing required tools:", r.Backend)
+	for _, status := range r.Missing {
+		fmt.Fprintf(&b, "\n  • %s", toolLabel(status.Requirement))
+	}
+	for _, status := range r.Outdated {
+		fmt.Fprintf(&b, "\n  • %s %s is older than required %s",
+			status.Found, status.Version, status.Requirement.MinVersion)
+	}
+
+	var failed []ToolStatus
+	failed = append(failed, r.Missing...)
+	failed = append(failed, r.Outdated...)
+	if hint := syspkg.InstallHint(distro, Requirements(failed)...); hint != "" {
+		fmt.Fprintf(&b, "\n\nInstall with:\n  %s", hint)
+	}
+
+	return fmt.Errorf("%s", b.String())
+}
+
+// Preflight checks the tools declared by backend. Backends that declare no
+// tools always pass.
+func (r *Registry) Preflight(ctx context.Context, backend Backend) *PreflightReport {
+	report := &PreflightReport{Backend: backend.Name()}
+	declarer, ok := backend.(ToolDeclarer)
+	if !ok {
+		return report
+	}
+	return CheckTools(ctx, r.runner, backend.Name(), declarer.RequiredTools())
+}
+
+// CheckTools verifies reqs using runner
+func CheckTools(ctx context.Context, runner helpers.CommandRunner, backendName string, reqs []core.ToolRequirement) *PreflightReport {
+	report := &PreflightReport{Backend: backendName}
+
+	for _, req := range reqs {
+		status := ToolStatus{Requirement: req}
+		for _, candidate := range append([]string{req.Name}, req.Alternatives...) {
+			if runner.CommandExists(candidate) {
+				status.Found = candidate
+				break
+			}
+		}
+
+		switch {
+		case status.Found == "" && req.Optional:
+			report.OptionalMissing = append(report.OptionalMissing, status)
+		case status.Found == "":
+			report.Missing = append(report.Missing, status)
+		case req.MinVersion != "":
+			status.Version = toolVersion(ctx, runner, status.Found, req.VersionArgs)
+			if status.Version != "" && compareVersions(status.Version, req.MinVersion) < 0 {
+				if req.Optional {
+					report.OptionalMissing = append(report.OptionalMissing, status)
+				} else {
+					report.Outdated = append(report.Outdated, status)
+				}
+			}
+		}
+	}
+
+	return report
+}
+
+// Requirements returns the requirements of statuses
+func Requirements(statuses []ToolStatus) []core.ToolRequirement {
+	reqs := make([]core.ToolRequirement, 0, len(statuses))
+	for _, status := range statuses {
+		reqs = append(reqs, status.Requirement)
+	}
+	return reqs
+}
+
+// DetectDistroFamily maps /etc/os-release ID and ID_LIKE to a distro family
+func DetectDistroFamily(fs afero.Fs) string {
+	return syspkg.DetectDistroFamily(fs)
+}
+
+// toolLabel describes a requirement for error output
+func toolLabel(req core.ToolRequirement) string {
+	label := req.Name
+	if len(req.Alternatives) > 0 {
+		label += " (or " + strings.Join(req.Alternatives, ", ") + ")"
+	}
+	if req.MinVersion != "" {
+		label += " >= " + req.MinVersion
+	}
+	if req.Purpose != "" {
+		label += " - " + req.Purpose
+	}
+	return label
+}
+
+// toolVersion runs the tool and extracts the first dotted version number
+func toolVersion(ctx context.Context, runner helpers.CommandRunner, tool string, args []string) string {
+	if len(args) == 0 {
+		args = []string{"--version"}
+	}
+
+	versionCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	stdout, stderr, err := runner.RunCommandWithOutput(versionCtx, tool, args...)
+	if err != nil && stdout == "" && stderr == "" {
+		return ""
+	}
+	return versionRegex.FindString(stdout + "\n" + stderr)
+}
+
+// compareVersions compares dotted numeric versions (-1, 0, 1)
+func compareVersions(a, b string) int {
+	return versions.Compare(a, b)
+}
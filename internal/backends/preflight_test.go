@@ -0,0 +1,113 @@
+package backends
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTools(t *testing.T) {
+	t.Parallel()
+
+	runner := &helpers.MockCommandRunner{
+		CommandExistsFunc: func(name string) bool {
+			return name == "bsdtar" || name == "old-tool"
+		},
+		RunCommandWithOutputFunc: func(_ context.Context, name string, _ ...string) (string, string, error) {
+			return name + " version 1.2.3\n", "", nil
+		},
+	}
+
+	reqs := []core.ToolRequirement{
+		{Name: "rpmextract.sh", Alternatives: []string{"bsdtar"}},
+		{Name: "debtap", Packages: map[string]string{core.DistroArch: "debtap"}},
+		{Name: "old-tool", MinVersion: "2.0", Packages: map[string]string{core.DistroArch: "old-tool-pkg"}},
+		{Name: "npx", Optional: true},
+	}
+
+	report := CheckTools(context.Background(), runner, "test", reqs)
+
+	require.Len(t, report.Missing, 1)
+	assert.Equal(t, "debtap", report.Missing[0].Requirement.Name)
+	require.Len(t, report.Outdated, 1)
+	assert.Equal(t, "1.2.3", report.Outdated[0].Version)
+	require.Len(t, report.OptionalMissing, 1)
+	assert.False(t, report.OK())
+
+	err := report.Err(core.DistroArch)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "debtap")
+	assert.Contains(t, err.Error(), "old-tool 1.2.3 is older than required 2.0")
+	assert.Contains(t, err.Error(), "sudo pacman -S --needed debtap old-tool-pkg")
+}
+
+func TestRegistry_Preflight(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.New(io.Discard)
+	runner := &helpers.MockCommandRunner{CommandExistsFunc: func(string) bool { return false }}
+	registry := NewRegistryWithDeps(&config.Config{}, &logger, afero.NewMemMapFs(), runner)
+
+	flatpakBackend, err := registry.GetBackend("flatpak")
+	require.NoError(t, err)
+	assert.False(t, registry.Preflight(context.Background(), flatpakBackend).OK())
+
+	debBackend, err := registry.GetBackend("deb")
+	require.NoError(t, err)
+	debReport := registry.Preflight(context.Background(), debBackend)
+	assert.True(t, debReport.OK(), "bsdtar only backs the extraction fallback and must stay optional")
+	assert.Contains(t, toolNames(debReport.OptionalMissing), "bsdtar")
+
+	binaryBackend, err := registry.GetBackend("binary")
+	require.NoError(t, err)
+	report := registry.Preflight(context.Background(), binaryBackend)
+	assert.True(t, report.OK())
+	assert.NoError(t, report.Err(""))
+}
+
+func toolNames(statuses []ToolStatus) []string {
+	names := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		names = append(names, status.Requirement.Name)
+	}
+	return names
+}
+
+func TestDetectDistroFamily(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"ID=arch\n", core.DistroArch},
+		{"ID=pop\nID_LIKE=\"ubuntu debian\"\n", core.DistroDebian},
+		{"ID=\"fedora\"\n", core.DistroFedora},
+		{"ID=opensuse-tumbleweed\nID_LIKE=\"opensuse suse\"\n", core.DistroSUSE},
+		{"ID=gentoo\n", ""},
+	}
+
+	for _, tt := range tests {
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/etc/os-release", []byte(tt.content), 0644))
+		assert.Equal(t, tt.want, DetectDistroFamily(fs), tt.content)
+	}
+
+	assert.Empty(t, DetectDistroFamily(afero.NewMemMapFs()))
+}
+
+func TestCompareVersions(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0, compareVersions("1.2", "1.2.0"))
+	assert.Equal(t, -1, compareVersions("1.9", "1.10"))
+	assert.Equal(t, 1, compareVersions("2.0.1", "2.0"))
+}
@@ -0,0 +1,156 @@
+package rpm
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	backendbase "github.com/quantmind-br/upkg/internal/backends/base"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/rpmfile"
+	"github.com/quantmind-br/upkg/internal/security"
+	"github.com/quantmind-br/upkg/internal/syspkg"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/quantmind-br/upkg/internal/ui"
+)
+
+// installWithDnf installs an RPM natively with dnf, zypper or rpm on Fedora
+// and openSUSE. The package is owned by the rpm database; upkg only records
+// it and its desktop entries and icons, which stay as the package ships them.
+func (r *RpmBackend) installWithDnf(ctx context.Context, packagePath string, opts core.InstallOptions, tx *transaction.Manager, result *core.InstallResult) (*core.InstallRecord, error) {
+	phases := []ui.InstallationPhase{
+		{Name: "Validating package", Weight: 5, Deterministic: true},
+		{Name: "Installing with dnf", Weight: 85, Deterministic: false}, // Indeterminate - uses spinner
+		{Name: "Configuring desktop", Weight: 10, Deterministic: true},
+	}
+	progress := r.NewProgress(ctx, phases, "Installing RPM")
+	defer progress.Finish()
+
+	// Phase 1: Validation
+	progress.StartPhase(0)
+
+	if !r.Runner.CommandExists("rpm") {
+		return nil, fmt.Errorf("rpm not found - the dnf method requires an RPM-based system")
+	}
+
+	// dnf and zypper only treat the argument as a file when it is a path
+	absPackagePath, err := filepath.Abs(packagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	pkg, headerErr := rpmfile.Open(absPackagePath)
+	if headerErr != nil {
+		r.Log.Debug().Err(headerErr).Msg("failed to read RPM header")
+		pkg = &rpmfile.Package{}
+	}
+
+	// rpm knows the package by its NAME tag, so --name cannot rename it
+	pkgName := pkg.Name
+	if pkgName == "" {
+		if name, queryErr := r.queryRpmName(ctx, absPackagePath); queryErr == nil {
+			pkgName = name
+		}
+	}
+	if pkgName == "" {
+		return nil, fmt.Errorf("cannot read the package name from %s", filepath.Base(packagePath))
+	}
+	if opts.CustomName != "" && opts.CustomName != pkgName {
+		result.Warn("--name is ignored for packages installed with dnf (installed as %s)", pkgName)
+	}
+	if err := security.ValidatePackageName(pkgName); err != nil {
+		return nil, fmt.Errorf("invalid package name %q: %w", pkgName, err)
+	}
+
+	installID := helpers.GenerateInstallID(pkgName)
+	progress.AdvancePhase()
+
+	// Phase 2: Install with dnf/zypper/rpm (indeterminate phase)
+	progress.StartPhase(1)
+
+	installCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	backendbase.TickIndeterminate(installCtx, progress, "Installing with dnf")
+
+	if err := r.dnf.Install(installCtx, absPackagePath, &syspkg.InstallOptions{Overwrite: opts.Overwrite}); err != nil {
+		return nil, err
+	}
+	if tx != nil {
+		tx.Add("remove dnf package", func() error {
+			removeCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+			defer cancel()
+			return r.dnf.Remove(removeCtx, pkgName)
+		})
+	}
+
+	r.Log.Info().Msg("package installed successfully via dnf")
+	progress.AdvancePhase()
+
+	// Phase 3: Desktop integration
+	progress.StartPhase(2)
+
+	version := pkg.FullVersion()
+	var installedSize int64
+	if info, infoErr := r.getPackageInfo(ctx, r.dnf, pkgName); infoErr != nil {
+		r.Log.Warn().Err(infoErr).Str("package", pkgName).Msg("failed to get package info from rpm")
+	} else {
+		if info.version != "" {
+			version = info.version
+		}
+		installedSize = info.size
+	}
+
+	installedFiles, err := r.findInstalledFiles(ctx, r.dnf, pkgName)
+	if err != nil {
+		r.Log.Warn().Err(err).Msg("failed to list installed files")
+		result.Warn("could not list installed files, desktop entries and icons are not tracked: %v", err)
+	}
+	desktopFiles := r.findDesktopFiles(installedFiles)
+	iconFiles := r.findIconFiles(installedFiles)
+
+	var primaryDesktopFile string
+	if len(desktopFiles) > 0 {
+		primaryDesktopFile = desktopFiles[0]
+		appsDir := filepath.Dir(primaryDesktopFile)
+		if cacheErr := r.cacheManager.UpdateDesktopDatabase(appsDir, r.Log); cacheErr != nil {
+			r.Log.Warn().Err(cacheErr).Str("apps_dir", appsDir).Msg("failed to update desktop database")
+		}
+	}
+
+	comment := "Installed via dnf"
+	if r.Cfg.Desktop.DescriptionFields && strings.TrimSpace(pkg.Summary) != "" {
+		comment = strings.TrimSpace(pkg.Summary)
+	}
+
+	record := &core.InstallRecord{
+		InstallID:    installID,
+		PackageType:  core.PackageTypeRpm,
+		Name:         pkgName,
+		Version:      strings.TrimSpace(version),
+		InstallDate:  time.Now(),
+		OriginalFile: packagePath,
+		DesktopFile:  primaryDesktopFile,
+		Metadata: core.Metadata{
+			IconFiles:      iconFiles,
+			WaylandSupport: string(core.WaylandUnknown),
+			InstallMethod:  core.InstallMethodDnf,
+			InstalledSize:  installedSize,
+			DesktopFiles:   desktopFiles,
+			ExtractedMeta: core.ExtractedMetadata{
+				Comment: comment,
+			},
+		},
+	}
+
+	r.Log.Info().
+		Str("install_id", installID).
+		Str("name", pkgName).
+		Str("version", record.Version).
+		Msg("RPM package installed successfully (dnf)")
+
+	return record, nil
+}
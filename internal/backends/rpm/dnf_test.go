@@ -0,0 +1,130 @@
+package rpm
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRpmBackend_installMethod(t *testing.T) {
+	all := func(string) bool { return true }
+	noRpm := func(name string) bool { return name != "rpm" }
+
+	tests := []struct {
+		name      string
+		osRelease string
+		exists    func(string) bool
+		method    string
+		provider  string
+		want      string
+	}{
+		{"auto on Fedora", "ID=fedora\n", all, "", "", core.MethodDnf},
+		{"auto on openSUSE", "ID=\"opensuse-leap\"\nID_LIKE=\"suse opensuse\"\n", all, core.MethodAuto, "", core.MethodDnf},
+		{"auto on Fedora without rpm", "ID=fedora\n", noRpm, "", "", core.MethodExtract},
+		{"auto on Arch", "ID=arch\n", all, "", "", core.MethodExtract},
+		{"auto without os-release", "", all, "", "", core.MethodExtract},
+		{"forced extract on Fedora", "ID=fedora\n", all, core.MethodExtract, "", core.MethodExtract},
+		{"forced dnf on Arch", "ID=arch\n", all, core.MethodDnf, "", core.MethodDnf},
+		{"DEB-only method picks automatically", "ID=fedora\n", all, core.MethodPacman, "", core.MethodDnf},
+		{"configured dnf on Debian", "ID=debian\n", all, "", "dnf", core.MethodDnf},
+		{"configured pacman on Fedora", "ID=fedora\n", all, "", "pacman", core.MethodExtract},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			if tt.osRelease != "" {
+				require.NoError(t, afero.WriteFile(fs, "/etc/os-release", []byte(tt.osRelease), 0644))
+			}
+			logger := zerolog.New(io.Discard)
+			cfg := &config.Config{Syspkg: config.SyspkgConfig{Provider: tt.provider}}
+			backend := NewWithDeps(cfg, &logger, fs, &helpers.MockCommandRunner{CommandExistsFunc: tt.exists})
+			assert.Equal(t, tt.want, backend.installMethod(tt.method))
+		})
+	}
+}
+
+func TestInstall_DnfMethod(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	logger := zerolog.New(io.Discard)
+
+	var commands []string
+	runner := &helpers.MockCommandRunner{
+		CommandExistsFunc: func(name string) bool { return name == "rpm" || name == "dnf" },
+		RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+			command := strings.Join(append([]string{name}, args...), " ")
+			commands = append(commands, command)
+			switch {
+			case strings.HasPrefix(command, "rpm -q --qf"):
+				return "notes\t2.4.1-3.fc40", nil
+			case strings.HasPrefix(command, "rpm -ql"):
+				return "/usr/bin/notes\n/usr/share/applications/notes.desktop\n/usr/share/icons/hicolor/scalable/apps/notes.svg\n", nil
+			}
+			return "", nil
+		},
+	}
+	cfg := &config.Config{Syspkg: config.SyspkgConfig{Provider: "dnf"}}
+	cfg.Desktop.DescriptionFields = true
+	backend := NewWithDeps(cfg, &logger, afero.NewOsFs(), runner)
+
+	rpmPath := filepath.Join(t.TempDir(), "Notes-2.4.1-3.x86_64.rpm")
+	writeTestRpm(t, rpmPath)
+
+	tx := transaction.NewManager(&logger)
+	result, err := backend.Install(context.Background(), rpmPath, core.InstallOptions{CustomName: "other"}, tx)
+	require.NoError(t, err)
+	tx.Commit()
+
+	record := result.Record
+	assert.Equal(t, "notes", record.Name)
+	assert.Equal(t, "2.4.1-3.fc40", record.Version)
+	assert.Equal(t, core.InstallMethodDnf, record.Metadata.InstallMethod)
+	assert.Empty(t, record.InstallPath)
+	assert.Equal(t, "/usr/share/applications/notes.desktop", record.DesktopFile)
+	assert.Equal(t, []string{"/usr/share/icons/hicolor/scalable/apps/notes.svg"}, record.Metadata.IconFiles)
+	assert.Equal(t, "Markdown note-taking app", record.Metadata.ExtractedMeta.Comment)
+	assert.Contains(t, commands, "sudo dnf install -y "+rpmPath)
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0], "--name is ignored")
+
+	commands = nil
+	_, err = backend.Uninstall(context.Background(), record)
+	require.NoError(t, err)
+	assert.Contains(t, commands, "sudo dnf remove -y notes")
+}
+
+func TestInstall_DnfMethodRollsBack(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	logger := zerolog.New(io.Discard)
+
+	var removed bool
+	runner := &helpers.MockCommandRunner{
+		CommandExistsFunc: func(name string) bool { return name == "rpm" },
+		RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+			if name == "sudo" && args[0] == "rpm" && args[1] == "-e" {
+				removed = true
+			}
+			return "", nil
+		},
+	}
+	backend := NewWithDeps(&config.Config{}, &logger, afero.NewOsFs(), runner)
+
+	rpmPath := filepath.Join(t.TempDir(), "Notes-2.4.1-3.x86_64.rpm")
+	writeTestRpm(t, rpmPath)
+
+	tx := transaction.NewManager(&logger)
+	_, err := backend.Install(context.Background(), rpmPath, core.InstallOptions{Method: core.MethodDnf}, tx)
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+	assert.True(t, removed)
+}
@@ -2,9 +2,10 @@ package rpm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"io/fs"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -13,25 +14,30 @@ import (
 	"github.com/quantmind-br/upkg/internal/cache"
 	"github.com/quantmind-br/upkg/internal/config"
 	"github.com/quantmind-br/upkg/internal/core"
-	"github.com/quantmind-br/upkg/internal/desktop"
 	"github.com/quantmind-br/upkg/internal/helpers"
 	"github.com/quantmind-br/upkg/internal/heuristics"
 	"github.com/quantmind-br/upkg/internal/icons"
+	"github.com/quantmind-br/upkg/internal/integration"
+	"github.com/quantmind-br/upkg/internal/rpmfile"
 	"github.com/quantmind-br/upkg/internal/security"
 	"github.com/quantmind-br/upkg/internal/syspkg"
 	"github.com/quantmind-br/upkg/internal/syspkg/arch"
+	"github.com/quantmind-br/upkg/internal/syspkg/fedora"
 	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/quantmind-br/upkg/internal/ui"
 	"github.com/rs/zerolog"
 	"github.com/spf13/afero"
 )
 
-// RpmBackend handles RPM package installations
+// RpmBackend handles RPM package installations with dnf, zypper or rpm on
+// Fedora and openSUSE, and by extraction elsewhere
 //
 //nolint:revive // exported backend names are kept for consistency across packages.
 type RpmBackend struct {
 	*backendbase.BaseBackend
 	scorer       heuristics.Scorer
-	sys          syspkg.Provider
+	sys          syspkg.Provider // pacman, for packages converted by earlier releases
+	dnf          syspkg.Provider // dnf/zypper/rpm on Fedora and openSUSE
 	cacheManager *cache.CacheManager
 }
 
@@ -57,17 +63,55 @@ func NewWithDeps(cfg *config.Config, log *zerolog.Logger, fs afero.Fs, runner he
 	base := backendbase.NewWithDeps(cfg, log, fs, runner)
 	return &RpmBackend{
 		BaseBackend:  base,
-		scorer:       heuristics.NewScorer(log),
+		scorer:       heuristics.NewScorer(log, backendbase.ScoringRules(cfg)),
 		sys:          arch.NewPacmanProviderWithRunner(runner),
+		dnf:          fedora.NewDnfProviderWithRunner(runner).WithLockRetry(cfg.System.LockRetries, cfg.System.LockRetryDelay()),
 		cacheManager: cache.NewCacheManagerWithRunner(runner),
 	}
 }
 
+// provider returns the system package manager that owns installs recorded
+// with method
+func (r *RpmBackend) provider(method string) syspkg.Provider {
+	if method == core.InstallMethodDnf {
+		return r.dnf
+	}
+	return r.sys
+}
+
 // Name returns the backend name
 func (r *RpmBackend) Name() string {
 	return "rpm"
 }
 
+// RequiredTools lists the external tools used by the RPM backend
+func (r *RpmBackend) RequiredTools() []core.ToolRequirement {
+	return []core.ToolRequirement{
+		{
+			Name:         "rpmextract.sh",
+			Alternatives: []string{"bsdtar"},
+			Optional:     true,
+			Purpose:      "extract zstd-compressed RPM payloads (gzip, bzip2, xz and lzma are extracted natively)",
+			Packages:     map[string]string{core.DistroArch: "rpmextract", core.DistroDebian: "libarchive-tools", core.DistroFedora: "bsdtar", core.DistroSUSE: "bsdtar"},
+		},
+		{
+			Name:     "rpm",
+			Optional: true,
+			Purpose:  "read package names when the built-in header reader fails",
+			Packages: map[string]string{core.DistroArch: "rpm-tools", core.DistroDebian: "rpm", core.DistroFedora: "rpm", core.DistroSUSE: "rpm"},
+		},
+		{
+			Name:         "dnf",
+			Alternatives: []string{"zypper"},
+			Optional:     true,
+			Purpose:      "install RPM packages natively on Fedora and openSUSE",
+			Packages:     map[string]string{core.DistroFedora: "dnf", core.DistroSUSE: "zypper"},
+		},
+		integration.DesktopValidatorTool,
+		integration.SandboxTool,
+	}
+}
+
 // Detect checks if this backend can handle the package
 func (r *RpmBackend) Detect(_ context.Context, packagePath string) (bool, error) {
 	// Check if file exists
@@ -84,8 +128,25 @@ func (r *RpmBackend) Detect(_ context.Context, packagePath string) (bool, error)
 	return fileType == helpers.FileTypeRPM, nil
 }
 
-// Install installs the RPM package
-func (r *RpmBackend) Install(ctx context.Context, packagePath string, opts core.InstallOptions, tx *transaction.Manager) (*core.InstallRecord, error) {
+// installMethod resolves the method an RPM is installed with. Without an
+// explicit one, hosts whose native package manager is dnf (per
+// /etc/os-release or syspkg.provider) install it with dnf, zypper or rpm,
+// and anything else extracts it. The DEB-only methods pick automatically.
+func (r *RpmBackend) installMethod(method string) string {
+	switch method {
+	case core.MethodExtract, core.MethodDnf:
+		return method
+	}
+	if syspkg.HostProvider(r.Fs, r.Cfg.Syspkg.Provider) == syspkg.ProviderDnf && r.Runner.CommandExists("rpm") {
+		return core.MethodDnf
+	}
+	return core.MethodExtract
+}
+
+// Install installs the RPM package with the native package manager or by
+// extraction (see installMethod)
+func (r *RpmBackend) Install(ctx context.Context, packagePath string, opts core.InstallOptions, tx *transaction.Manager) (*core.InstallResult, error) {
+	result := core.NewInstallResult()
 	r.Log.Info().
 		Str("package_path", packagePath).
 		Str("custom_name", opts.CustomName).
@@ -96,11 +157,24 @@ func (r *RpmBackend) Install(ctx context.Context, packagePath string, opts core.
 		return nil, fmt.Errorf("package not found: %w", err)
 	}
 
+	if r.installMethod(opts.Method) == core.MethodDnf {
+		record, err := r.installWithDnf(ctx, packagePath, opts, tx, result)
+		if err != nil {
+			return nil, err
+		}
+		return result.Finish(record), nil
+	}
+
 	// Determine package name
 	pkgName := opts.CustomName
 	if pkgName == "" {
-		// Try to get official package name from RPM metadata (best practice)
-		if name, err := r.queryRpmName(ctx, packagePath); err == nil && name != "" {
+		// Prefer the official NAME tag of the RPM header over the filename
+		if pkg, err := rpmfile.Open(packagePath); err == nil && pkg.Name != "" {
+			pkgName = pkg.Name
+			r.Log.Debug().
+				Str("name", pkgName).
+				Msg("extracted package name from RPM header")
+		} else if name, err := r.queryRpmName(ctx, packagePath); err == nil && name != "" {
 			pkgName = name
 			r.Log.Debug().
 				Str("name", name).
@@ -120,18 +194,17 @@ func (r *RpmBackend) Install(ctx context.Context, packagePath string, opts core.
 	}
 	installID := helpers.GenerateInstallID(normalizedName)
 
-	// Check if rpmextract.sh or bsdtar is available
-	if r.Runner.CommandExists("rpmextract.sh") || r.Runner.CommandExists("bsdtar") {
-		return r.installWithExtract(ctx, packagePath, normalizedName, installID, opts, tx)
+	record, err := r.installWithExtract(ctx, packagePath, normalizedName, installID, opts, tx, result)
+	if err != nil {
+		return nil, err
 	}
-
-	return nil, fmt.Errorf("no suitable RPM extraction tool found\nInstall 'rpmextract' or 'bsdtar'")
+	return result.Finish(record), nil
 }
 
 // installWithExtract installs RPM by extracting and manually placing files
 //
 //nolint:gocyclo // extraction install handles multiple fallbacks and integrations.
-func (r *RpmBackend) installWithExtract(ctx context.Context, packagePath, normalizedName, installID string, opts core.InstallOptions, tx *transaction.Manager) (*core.InstallRecord, error) {
+func (r *RpmBackend) installWithExtract(ctx context.Context, packagePath, normalizedName, installID string, opts core.InstallOptions, tx *transaction.Manager, result *core.InstallResult) (*core.InstallRecord, error) {
 	r.Log.Info().Msg("extracting RPM package...")
 
 	homeDir := r.Paths.HomeDir()
@@ -146,31 +219,38 @@ func (r *RpmBackend) installWithExtract(ctx context.Context, packagePath, normal
 	}
 
 	// Create temp directory for extraction
-	tmpDir, err := afero.TempDir(r.Fs, "", "upkg-rpm-*")
+	tmpDir, err := helpers.CreateTempDir(r.Fs, installID, "rpm")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temp directory: %w", err)
 	}
+	r.Log.Debug().Str("install_id", installID).Str("tmp_dir", tmpDir).Msg("using temp dir")
 	defer func() {
 		if removeErr := r.Fs.RemoveAll(tmpDir); removeErr != nil {
 			r.Log.Debug().Err(removeErr).Str("tmp_dir", tmpDir).Msg("failed to remove temp dir")
 		}
 	}()
 
-	// Extract RPM (in temp directory) using absolute path
-	extractCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
-	defer cancel()
-
-	// Use rpmextract.sh if available, otherwise bsdtar
-	cmd := "rpmextract.sh"
-	args := []string{absPackagePath}
-	if !r.Runner.CommandExists("rpmextract.sh") {
-		cmd = "bsdtar"
-		args = []string{"-xf", absPackagePath}
+	// Header fields feed the record; they are empty when the header cannot
+	// be read and the payload is extracted by an external tool
+	pkg, headerErr := rpmfile.Open(absPackagePath)
+	if headerErr != nil {
+		r.Log.Debug().Err(headerErr).Msg("failed to read RPM header")
 	}
 
-	_, err = r.Runner.RunCommandInDir(extractCtx, tmpDir, cmd, args...)
-	if err != nil {
-		return nil, fmt.Errorf("%s failed: %w", cmd, err)
+	progress := r.NewProgress(ctx, []ui.InstallationPhase{
+		{Name: "Extracting RPM", Weight: 60, Deterministic: true},
+		{Name: "Creating launcher", Weight: 20, Deterministic: true},
+		{Name: "Installing icons", Weight: 10, Deterministic: true},
+		{Name: "Configuring desktop", Weight: 10, Deterministic: true},
+	}, "Installing RPM")
+	defer progress.Finish()
+
+	progress.StartPhase(0)
+	if err := r.extractPayload(ctx, pkg, absPackagePath, tmpDir, backendbase.ByteProgress(progress)); err != nil {
+		return nil, err
+	}
+	if pkg == nil {
+		pkg = &rpmfile.Package{}
 	}
 
 	r.Log.Debug().Msg("RPM extracted successfully")
@@ -181,7 +261,7 @@ func (r *RpmBackend) installWithExtract(ctx context.Context, packagePath, normal
 
 	if _, statErr := r.Fs.Stat(installDir); statErr == nil {
 		if !opts.Force {
-			return nil, fmt.Errorf("package already installed at: %s (use --force to reinstall)", installDir)
+			return nil, &core.ErrAlreadyInstalled{Path: installDir}
 		}
 		if removeErr := r.Fs.RemoveAll(installDir); removeErr != nil {
 			return nil, fmt.Errorf("remove existing installation directory: %w", removeErr)
@@ -207,8 +287,11 @@ func (r *RpmBackend) installWithExtract(ctx context.Context, packagePath, normal
 		tx.Add("remove rpm installation directory", func() error {
 			return r.Fs.RemoveAll(dir)
 		})
+		tx.TrackPaths(dir)
 	}
 
+	progress.AdvancePhase()
+
 	// Move extracted content to installation directory
 	// RPMs typically extract to usr/, opt/, etc.
 	extractedDirs := []string{"usr", "opt", "etc"}
@@ -216,14 +299,19 @@ func (r *RpmBackend) installWithExtract(ctx context.Context, packagePath, normal
 		srcDir := filepath.Join(tmpDir, dir)
 		if _, statErr := r.Fs.Stat(srcDir); statErr == nil {
 			dstDir := filepath.Join(installDir, dir)
-			if renameErr := r.Fs.Rename(srcDir, dstDir); renameErr != nil {
-				// Try copying if rename fails
-				if copyErr := r.copyDir(srcDir, dstDir); copyErr != nil {
-					r.Log.Warn().
-						Err(copyErr).
-						Str("dir", dir).
-						Msg("failed to move directory")
+			// Falls back to copy+verify+remove when tmp and data dir are on different filesystems
+			moveErr := helpers.MoveDir(r.Fs, srcDir, dstDir, func(copied, total int64) {
+				r.Log.Debug().
+					Str("dir", dir).
+					Int64("copied", copied).
+					Int64("total", total).
+					Msg("copying across filesystems")
+			})
+			if moveErr != nil {
+				if removeErr := r.Fs.RemoveAll(installDir); removeErr != nil {
+					r.Log.Debug().Err(removeErr).Str("install_dir", installDir).Msg("failed to cleanup install dir after move error")
 				}
+				return nil, fmt.Errorf("failed to move extracted %s directory: %w", dir, moveErr)
 			}
 		}
 	}
@@ -242,40 +330,33 @@ func (r *RpmBackend) installWithExtract(ctx context.Context, packagePath, normal
 		Msg("found executables")
 
 	// Choose primary executable using scoring heuristic (same as tarball backend)
-	primaryExec := r.scorer.ChooseBest(executables, normalizedName, installDir)
+	ranked := r.scorer.Rank(executables, normalizedName, installDir)
+	primaryExec := ranked[0].Path
+	result.Candidates = heuristics.Candidates(ranked, primaryExec)
 
 	// Create wrapper script
-	binDir := r.Paths.GetBinDir()
-	if mkdirErr := r.Fs.MkdirAll(binDir, 0755); mkdirErr != nil {
-		if removeErr := r.Fs.RemoveAll(installDir); removeErr != nil {
-			r.Log.Debug().Err(removeErr).Str("install_dir", installDir).Msg("failed to cleanup install dir after mkdir error")
-		}
-		return nil, fmt.Errorf("failed to create bin directory: %w", mkdirErr)
-	}
-
-	wrapperPath := filepath.Join(binDir, normalizedName)
-	wrapperCfg := helpers.WrapperConfig{
-		WrapperPath:    wrapperPath,
-		ExecPath:       primaryExec,
-		DisableSandbox: r.Cfg.Desktop.ElectronDisableSandbox,
-	}
-	if wrapperErr := helpers.CreateWrapper(r.Fs, wrapperCfg); wrapperErr != nil {
+	wrapperPath, sandboxTool, wrapperErr := r.Integration().CreateLauncher(normalizedName, primaryExec, opts, installDir)
+	if wrapperErr != nil {
 		if removeErr := r.Fs.RemoveAll(installDir); removeErr != nil {
 			r.Log.Debug().Err(removeErr).Str("install_dir", installDir).Msg("failed to cleanup install dir after wrapper error")
 		}
-		return nil, fmt.Errorf("failed to create wrapper script: %w", wrapperErr)
+		return nil, wrapperErr
 	}
 	if tx != nil {
 		path := wrapperPath
 		tx.Add("remove rpm wrapper script", func() error {
 			return r.Fs.Remove(path)
 		})
+		tx.TrackPaths(path)
 	}
 
+	progress.AdvancePhase()
+
 	// Install icons
 	iconPaths, err := r.installIcons(installDir, normalizedName)
 	if err != nil {
 		r.Log.Warn().Err(err).Msg("failed to install icons")
+		result.Warn("icons not installed: %v", err)
 	}
 	if tx != nil && len(iconPaths) > 0 {
 		paths := append([]string(nil), iconPaths...)
@@ -283,12 +364,26 @@ func (r *RpmBackend) installWithExtract(ctx context.Context, packagePath, normal
 			r.removeIcons(paths)
 			return nil
 		})
+		tx.TrackPaths(paths...)
 	}
 
+	progress.AdvancePhase()
+
+	framework, wayland := heuristics.DetectApp(r.Fs, installDir, primaryExec)
+
 	// Create .desktop file
 	var desktopPath string
 	if !opts.SkipDesktop {
-		desktopPath, err = r.createDesktopFile(installDir, normalizedName, wrapperPath, opts)
+		if r.Cfg.Desktop.DescriptionFields {
+			summary := pkg.Summary
+			if summary == "" {
+				summary = r.querySummary(ctx, absPackagePath)
+			}
+			if summary != "" {
+				opts.Description = summary
+			}
+		}
+		desktopPath, err = r.createDesktopFile(installDir, normalizedName, wrapperPath, wayland, opts)
 		if err != nil {
 			// Clean up on failure
 			if removeErr := r.Fs.RemoveAll(installDir); removeErr != nil {
@@ -306,6 +401,7 @@ func (r *RpmBackend) installWithExtract(ctx context.Context, packagePath, normal
 			tx.Add("remove rpm desktop file", func() error {
 				return r.Fs.Remove(path)
 			})
+			tx.TrackPaths(path)
 		}
 
 		// Update caches
@@ -318,6 +414,23 @@ func (r *RpmBackend) installWithExtract(ctx context.Context, packagePath, normal
 		if cacheErr := r.cacheManager.UpdateIconCache(iconsDir, r.Log); cacheErr != nil {
 			r.Log.Warn().Err(cacheErr).Str("icons_dir", iconsDir).Msg("failed to update icon cache")
 		}
+	} else {
+		result.Skip("desktop entry", "--skip-desktop")
+	}
+
+	// Install AppStream metainfo for software centers
+	metainfoPaths, err := r.Integration().InstallMetainfo(installDir, desktopPath)
+	if err != nil {
+		r.Log.Warn().Err(err).Msg("failed to install metainfo")
+		result.Warn("AppStream metainfo not installed: %v", err)
+	}
+	if tx != nil && len(metainfoPaths) > 0 {
+		paths := append([]string(nil), metainfoPaths...)
+		tx.Add("remove metainfo", func() error {
+			r.Integration().RemoveFiles(paths)
+			return nil
+		})
+		tx.TrackPaths(paths...)
 	}
 
 	// Create install record
@@ -325,15 +438,19 @@ func (r *RpmBackend) installWithExtract(ctx context.Context, packagePath, normal
 		InstallID:    installID,
 		PackageType:  core.PackageTypeRpm,
 		Name:         normalizedName,
+		Version:      pkg.FullVersion(),
 		InstallDate:  time.Now(),
 		OriginalFile: packagePath,
 		InstallPath:  installDir,
 		DesktopFile:  desktopPath,
 		Metadata: core.Metadata{
 			IconFiles:      iconPaths,
+			MetainfoFiles:  metainfoPaths,
 			WrapperScript:  wrapperPath,
-			WaylandSupport: string(core.WaylandUnknown),
+			WaylandSupport: string(wayland),
+			Framework:      string(framework),
 			InstallMethod:  core.InstallMethodLocal,
+			Sandbox:        sandboxTool,
 		},
 	}
 
@@ -346,6 +463,57 @@ func (r *RpmBackend) installWithExtract(ctx context.Context, packagePath, normal
 	return record, nil
 }
 
+// extractPayload unpacks the cpio payload of an RPM into destDir. pkg is
+// nil when the header could not be read; such packages and payloads the
+// built-in reader cannot decode (zstd) are handed to rpmextract.sh or bsdtar.
+// onProgress follows the built-in reader only.
+func (r *RpmBackend) extractPayload(ctx context.Context, pkg *rpmfile.Package, packagePath, destDir string, onProgress helpers.ProgressFunc) error {
+	reason := "unreadable RPM header"
+	if pkg != nil {
+		payload, err := pkg.PayloadWithProgress(onProgress)
+		if err == nil {
+			defer payload.Close()
+			return r.extractCpio(payload, packagePath, destDir)
+		}
+		if !errors.Is(err, rpmfile.ErrUnsupportedCompression) {
+			return fmt.Errorf("failed to read RPM payload: %w", err)
+		}
+		reason = err.Error()
+	}
+	r.Log.Debug().Str("reason", reason).Msg("built-in RPM extraction unavailable, trying external tools")
+
+	// Use rpmextract.sh if available, otherwise bsdtar
+	cmd := "rpmextract.sh"
+	args := []string{packagePath}
+	if !r.Runner.CommandExists("rpmextract.sh") {
+		if !r.Runner.CommandExists("bsdtar") {
+			return fmt.Errorf("no suitable RPM extraction tool found (%s): %w", reason, r.MissingTool("rpmextract.sh", r.RequiredTools()))
+		}
+		cmd = "bsdtar"
+		args = []string{"-xf", packagePath}
+	}
+
+	extractCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	if _, err := r.Runner.RunCommandInDir(extractCtx, destDir, cmd, args...); err != nil {
+		return fmt.Errorf("%s failed: %w", cmd, err)
+	}
+	return nil
+}
+
+// extractCpio extracts a decompressed payload, enforcing the configured size quota and limits
+func (r *RpmBackend) extractCpio(payload io.Reader, packagePath, destDir string) error {
+	info, err := os.Stat(packagePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat package: %w", err)
+	}
+	if err := helpers.ExtractCpio(payload, destDir, info.Size(), r.ExtractOptions()...); err != nil {
+		return fmt.Errorf("failed to extract RPM payload: %w", err)
+	}
+	return nil
+}
+
 // installWithDebtap installs RPM by converting to Arch package via debtap
 //
 //nolint:gocyclo // pacman-based RPM install has multiple fallbacks and integrations.
@@ -354,44 +522,52 @@ func (r *RpmBackend) installWithDebtap(ctx context.Context, packagePath, normali
 }
 
 // Uninstall removes the installed RPM package
-func (r *RpmBackend) Uninstall(ctx context.Context, record *core.InstallRecord) error {
+func (r *RpmBackend) Uninstall(ctx context.Context, record *core.InstallRecord) (*core.UninstallResult, error) {
+	result := core.NewUninstallResult()
 	r.Log.Info().
 		Str("install_id", record.InstallID).
 		Str("name", record.Name).
 		Msg("uninstalling RPM package")
 
-	// Check if it was installed via pacman or extracted
-	if record.Metadata.InstallMethod == core.InstallMethodPacman ||
+	// Check if it was installed via the system package manager or extracted
+	if core.IsSystemManaged(record.Metadata.InstallMethod) ||
 		strings.Contains(record.InstallPath, "pacman") { // backward compatibility
-		// Installed via pacman
-		return r.uninstallPacman(ctx, record)
+		if err := r.uninstallSystem(ctx, record, result); err != nil {
+			return nil, err
+		}
+		return result.Finish(), nil
 	}
 
 	// Installed via extraction
-	return r.uninstallExtracted(ctx, record)
+	if err := r.uninstallExtracted(ctx, record, result); err != nil {
+		return nil, err
+	}
+	return result.Finish(), nil
 }
 
-// uninstallPacman removes RPM installed via pacman
-func (r *RpmBackend) uninstallPacman(ctx context.Context, record *core.InstallRecord) error {
-	normalizedName := helpers.NormalizeFilename(record.Name)
+// uninstallSystem removes an RPM installed via pacman or dnf
+func (r *RpmBackend) uninstallSystem(ctx context.Context, record *core.InstallRecord, result *core.UninstallResult) error {
+	sys := r.provider(record.Metadata.InstallMethod)
+	pkgName := systemPackageName(record)
 
 	// Check if still installed
 	checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	installed, err := r.sys.IsInstalled(checkCtx, normalizedName)
+	installed, err := sys.IsInstalled(checkCtx, pkgName)
 	if err != nil || !installed {
-		r.Log.Warn().Msg("package not found in pacman database")
+		r.Log.Warn().Msgf("package not found in %s database", sys.Name())
+		result.Skip(sys.Name()+" removal", fmt.Sprintf("package not found in %s database", sys.Name()))
 		return nil
 	}
 
-	// Uninstall with pacman
+	// Uninstall with the system package manager
 	uninstallCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer cancel()
 
-	err = r.sys.Remove(uninstallCtx, normalizedName)
+	err = sys.Remove(uninstallCtx, pkgName)
 	if err != nil {
-		return fmt.Errorf("pacman removal failed: %w", err)
+		return fmt.Errorf("%s removal failed: %w", sys.Name(), err)
 	}
 
 	// Update caches
@@ -405,12 +581,23 @@ func (r *RpmBackend) uninstallPacman(ctx context.Context, record *core.InstallRe
 	return nil
 }
 
+// systemPackageName returns the name the system package manager knows a
+// record by. Packages converted for pacman were named after the normalized
+// name, dnf installs keep the RPM NAME tag.
+func systemPackageName(record *core.InstallRecord) string {
+	if record.Metadata.InstallMethod == core.InstallMethodDnf {
+		return record.Name
+	}
+	return helpers.NormalizeFilename(record.Name)
+}
+
 // uninstallExtracted removes RPM installed via extraction
-func (r *RpmBackend) uninstallExtracted(_ context.Context, record *core.InstallRecord) error {
+func (r *RpmBackend) uninstallExtracted(_ context.Context, record *core.InstallRecord, result *core.UninstallResult) error {
 	// Remove installation directory
 	if record.InstallPath != "" {
 		if err := r.Fs.RemoveAll(record.InstallPath); err != nil {
 			r.Log.Warn().Err(err).Msg("failed to remove installation directory")
+			result.Warn("failed to remove %s: %v", record.InstallPath, err)
 		}
 	}
 
@@ -418,6 +605,7 @@ func (r *RpmBackend) uninstallExtracted(_ context.Context, record *core.InstallR
 	if record.Metadata.WrapperScript != "" {
 		if err := r.Fs.Remove(record.Metadata.WrapperScript); err != nil {
 			r.Log.Warn().Err(err).Msg("failed to remove wrapper script")
+			result.Warn("failed to remove %s: %v", record.Metadata.WrapperScript, err)
 		}
 	}
 
@@ -428,12 +616,16 @@ func (r *RpmBackend) uninstallExtracted(_ context.Context, record *core.InstallR
 		}
 		if err := r.Fs.Remove(desktopPath); err != nil {
 			r.Log.Warn().Err(err).Str("path", desktopPath).Msg("failed to remove desktop file")
+			result.Warn("failed to remove %s: %v", desktopPath, err)
 		}
 	}
 
 	// Remove icons
 	r.removeIcons(record.Metadata.IconFiles)
 
+	// Remove AppStream metainfo
+	r.Integration().RemoveFiles(record.Metadata.MetainfoFiles)
+
 	// Update caches
 	appsDir := r.Paths.GetAppsDir()
 	if cacheErr := r.cacheManager.UpdateDesktopDatabase(appsDir, r.Log); cacheErr != nil {
@@ -451,133 +643,56 @@ func (r *RpmBackend) uninstallExtracted(_ context.Context, record *core.InstallR
 // Helper functions
 
 func (r *RpmBackend) installIcons(installDir, normalizedName string) ([]string, error) {
-	homeDir := r.Paths.HomeDir()
-	if homeDir == "" {
-		return nil, fmt.Errorf("failed to get home directory")
-	}
-
-	iconBaseDir := filepath.Join(homeDir, ".local", "share", "icons")
-	iconManager := icons.NewManager(r.Fs, iconBaseDir)
+	iconManager := icons.NewManager(r.Fs, "")
 
 	discoveredIcons, err := iconManager.DiscoverIcons(installDir)
 	if err != nil {
 		return nil, err
 	}
 
-	var installedIcons []string
-
-	for _, iconFile := range discoveredIcons {
-		targetPath, err := iconManager.InstallIcon(iconFile.Path, normalizedName, iconFile.Size)
-		if err != nil {
-			continue
-		}
-		installedIcons = append(installedIcons, targetPath)
-	}
-
-	return installedIcons, nil
+	return r.Integration().InstallIcons(discoveredIcons, normalizedName)
 }
 
 func (r *RpmBackend) removeIcons(iconPaths []string) {
-	for _, iconPath := range iconPaths {
-		if removeErr := r.Fs.Remove(iconPath); removeErr != nil {
-			r.Log.Debug().Err(removeErr).Str("path", iconPath).Msg("failed to remove icon")
-		}
-	}
+	r.Integration().RemoveFiles(iconPaths)
 }
 
-func (r *RpmBackend) createDesktopFile(installDir, normalizedName, wrapperPath string, opts core.InstallOptions) (string, error) {
-	homeDir := r.Paths.HomeDir()
-	if homeDir == "" {
+func (r *RpmBackend) createDesktopFile(installDir, normalizedName, wrapperPath string, wayland core.WaylandSupport, opts core.InstallOptions) (string, error) {
+	if r.Paths.HomeDir() == "" {
 		return "", fmt.Errorf("failed to get home directory")
 	}
 
-	appsDir := r.Paths.GetAppsDir()
-	if mkdirErr := r.Fs.MkdirAll(appsDir, 0755); mkdirErr != nil {
-		return "", fmt.Errorf("failed to create applications directory: %w", mkdirErr)
-	}
-
-	desktopFilePath := filepath.Join(appsDir, normalizedName+".desktop")
-
-	// Try to find existing .desktop file in extracted RPM (similar to tarball backend)
-	var entry *core.DesktopEntry
-
 	// Common locations for .desktop files in RPMs
-	desktopSearchPaths := []string{
-		filepath.Join(installDir, "usr", "share", "applications"),
-		filepath.Join(installDir, "usr", "local", "share", "applications"),
-		filepath.Join(installDir, "opt", "*", "share", "applications"),
-	}
-
-	for _, searchPath := range desktopSearchPaths {
-		matches, globErr := afero.Glob(r.Fs, filepath.Join(searchPath, "*.desktop"))
-		if globErr != nil {
-			continue
-		}
-		if len(matches) > 0 {
-			// Found desktop file(s), parse the first one
-			file, err := r.Fs.Open(matches[0])
-			if err == nil {
-				defer func() {
-					if closeErr := file.Close(); closeErr != nil {
-						r.Log.Debug().Err(closeErr).Str("desktop_file", matches[0]).Msg("failed to close desktop file")
-					}
-				}()
-				entry, err = desktop.Parse(file)
-				if err == nil {
-					r.Log.Debug().
-						Str("desktop_file", matches[0]).
-						Str("name", entry.Name).
-						Msg("using desktop file from RPM package")
-					break
-				}
-			}
-		}
-	}
-
-	// Create default entry if not found in RPM
-	if entry == nil {
+	engine := r.Integration()
+	sourceDesktop := engine.FindDesktopFile(
+		filepath.Join(installDir, "usr", "share", "applications", "*.desktop"),
+		filepath.Join(installDir, "usr", "local", "share", "applications", "*.desktop"),
+		filepath.Join(installDir, "opt", "*", "share", "applications", "*.desktop"),
+	)
+	if sourceDesktop == "" {
 		r.Log.Debug().Msg("no desktop file found in RPM, creating default")
+	}
 
-		// Try to create a better display name from the original package name
+	spec := integration.DesktopSpec{
 		// Example: "git-butler-nightly" -> "Git Butler Nightly"
-		displayName := helpers.FormatDisplayName(normalizedName)
-
-		entry = &core.DesktopEntry{
-			Type:    "Application",
-			Version: "1.5",
-			Name:    displayName,
-			Icon:    normalizedName,
-			Exec:    wrapperPath + " %U",
-		}
-	} else {
-		// Update Exec to point to our wrapper
-		entry.Exec = wrapperPath + " %U"
-
-		// Ensure icon uses normalized name for consistency
-		entry.Icon = normalizedName
-	}
-
-	// Inject Wayland vars
-	if r.Cfg.Desktop.WaylandEnvVars && !opts.SkipWaylandEnv {
-		if err := desktop.InjectWaylandEnvVars(entry, r.Cfg.Desktop.CustomEnvVars); err != nil {
-			r.Log.Warn().
-				Err(err).
-				Str("app", normalizedName).
-				Msg("invalid custom Wayland env vars, injecting defaults only")
-			if fallbackErr := desktop.InjectWaylandEnvVars(entry, nil); fallbackErr != nil {
-				r.Log.Warn().Err(fallbackErr).Str("app", normalizedName).Msg("failed to inject default Wayland env vars")
-			}
-		}
+		AppName:       helpers.FormatDisplayName(normalizedName),
+		FileName:      normalizedName,
+		ExecPath:      wrapperPath,
+		IconName:      normalizedName,
+		SourceDesktop: sourceDesktop,
+		PayloadRoot:   installDir,
+		Toolkit:       heuristics.DetectFramework(r.Fs, installDir, ""),
+		Wayland:       wayland,
 	}
 
-	return desktopFilePath, desktop.WriteDesktopFile(desktopFilePath, entry)
+	return engine.WriteDesktopEntry(spec, opts)
 }
 
-func (r *RpmBackend) getPackageInfo(ctx context.Context, pkgName string) (*packageInfo, error) {
+func (r *RpmBackend) getPackageInfo(ctx context.Context, sys syspkg.Provider, pkgName string) (*packageInfo, error) {
 	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	info, err := r.sys.GetInfo(queryCtx, pkgName)
+	info, err := sys.GetInfo(queryCtx, pkgName)
 	if err != nil {
 		return nil, err
 	}
@@ -585,14 +700,15 @@ func (r *RpmBackend) getPackageInfo(ctx context.Context, pkgName string) (*packa
 	return &packageInfo{
 		name:    info.Name,
 		version: info.Version,
+		size:    info.InstalledSize,
 	}, nil
 }
 
-func (r *RpmBackend) findInstalledFiles(ctx context.Context, pkgName string) ([]string, error) {
+func (r *RpmBackend) findInstalledFiles(ctx context.Context, sys syspkg.Provider, pkgName string) ([]string, error) {
 	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	return r.sys.ListFiles(queryCtx, pkgName)
+	return sys.ListFiles(queryCtx, pkgName)
 }
 
 func (r *RpmBackend) findDesktopFiles(files []string) []string {
@@ -621,6 +737,7 @@ func (r *RpmBackend) findIconFiles(files []string) []string {
 type packageInfo struct {
 	name    string
 	version string
+	size    int64 // Installed size in bytes; 0 when unknown
 }
 
 // queryRpmName extracts the official package name from RPM metadata using rpm -qp
@@ -655,6 +772,24 @@ func (r *RpmBackend) queryRpmName(ctx context.Context, packagePath string) (stri
 	return name, nil
 }
 
+// querySummary returns the one-line SUMMARY from the RPM header, or "" when
+// rpm is unavailable
+func (r *RpmBackend) querySummary(ctx context.Context, packagePath string) string {
+	if !r.Runner.CommandExists("rpm") {
+		return ""
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	output, err := r.Runner.RunCommand(queryCtx, "rpm", "-qp", "--queryformat", "%{SUMMARY}", packagePath)
+	if err != nil {
+		r.Log.Debug().Err(err).Msg("failed to query RPM summary")
+		return ""
+	}
+	return strings.TrimSpace(output)
+}
+
 // extractRpmBaseName extracts the base package name from an RPM filename
 // Examples:
 //   - GitButler_Nightly-0.5.1650-1.x86_64.rpm -> GitButler_Nightly
@@ -692,94 +827,3 @@ func extractRpmBaseName(filename string) string {
 }
 
 // No local helper functions - using shared helpers from internal/helpers/common.go
-
-//nolint:gocyclo // safe recursive copy with symlink handling is inherently branching.
-func (r *RpmBackend) copyDir(src, dst string) error {
-	return afero.Walk(r.Fs, src, func(path string, info fs.FileInfo, walkErr error) error {
-		if walkErr != nil {
-			return walkErr
-		}
-
-		relPath, relErr := filepath.Rel(src, path)
-		if relErr != nil {
-			return relErr
-		}
-
-		dstPath := filepath.Join(dst, relPath)
-
-		// Handle directories
-		if info.IsDir() {
-			if validateErr := security.ValidateExtractPath(dst, relPath); validateErr != nil {
-				return nil
-			}
-			return r.Fs.MkdirAll(dstPath, info.Mode())
-		}
-
-		// Handle symlinks
-		if info.Mode()&fs.ModeSymlink != 0 {
-			linkReader, ok := r.Fs.(afero.LinkReader)
-			if !ok {
-				return nil
-			}
-			linkTarget, readlinkErr := linkReader.ReadlinkIfPossible(path)
-			if readlinkErr != nil {
-				// Skip broken symlinks
-				return nil
-			}
-
-			if validateErr := security.ValidateSymlink(dst, dstPath, linkTarget); validateErr != nil {
-				return nil
-			}
-			// Create symlink at destination
-			if mkdirErr := r.Fs.MkdirAll(filepath.Dir(dstPath), 0755); mkdirErr != nil {
-				return nil
-			}
-			linker, ok := r.Fs.(afero.Linker)
-			if !ok {
-				return nil
-			}
-			return linker.SymlinkIfPossible(linkTarget, dstPath)
-		}
-
-		// Handle regular files using streaming to avoid loading entire file in memory
-		if validateErr := security.ValidateExtractPath(dst, relPath); validateErr != nil {
-			return nil
-		}
-
-		srcFile, openErr := r.Fs.Open(path)
-		if openErr != nil {
-			// Skip files that can't be read
-			return nil
-		}
-		defer func() {
-			if closeErr := srcFile.Close(); closeErr != nil {
-				r.Log.Debug().Err(closeErr).Str("path", path).Msg("failed to close source file")
-			}
-		}()
-
-		if mkdirErr := r.Fs.MkdirAll(filepath.Dir(dstPath), 0755); mkdirErr != nil {
-			return nil
-		}
-		dstFile, createErr := r.Fs.Create(dstPath)
-		if createErr != nil {
-			return fmt.Errorf("failed to create destination file: %w", createErr)
-		}
-		defer func() {
-			if closeErr := dstFile.Close(); closeErr != nil {
-				r.Log.Debug().Err(closeErr).Str("path", dstPath).Msg("failed to close destination file")
-			}
-		}()
-
-		// Use io.Copy for efficient streaming copy
-		if _, copyErr := io.Copy(dstFile, srcFile); copyErr != nil {
-			return fmt.Errorf("failed to copy file data: %w", copyErr)
-		}
-
-		// Preserve original permissions
-		if chmodErr := r.Fs.Chmod(dstPath, info.Mode()); chmodErr != nil {
-			r.Log.Debug().Err(chmodErr).Str("path", dstPath).Msg("failed to preserve file permissions")
-		}
-
-		return nil
-	})
-}
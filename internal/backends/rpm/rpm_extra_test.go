@@ -59,7 +59,7 @@ func TestRPMBackend_Uninstall_Simple(t *testing.T) {
 		InstallPath: tmpDir,
 	}
 
-	err := backend.Uninstall(ctx, install)
+	_, err := backend.Uninstall(ctx, install)
 	_ = err
 }
 
@@ -84,7 +84,7 @@ func TestRPMBackend_CreateDesktopFileCoverage(t *testing.T) {
 	// Create a simple wrapper for testing
 	os.WriteFile(wrapperPath, []byte("#!/bin/sh\necho test"), 0755)
 
-	desktopPath, err := backend.createDesktopFile(installDir, normalizedName, wrapperPath, opts)
+	desktopPath, err := backend.createDesktopFile(installDir, normalizedName, wrapperPath, core.WaylandUnknown, opts)
 	// We're just testing the function gets called
 	_ = desktopPath
 	_ = err
@@ -153,7 +153,7 @@ Icon=testapp
 
 	opts := core.InstallOptions{}
 
-	resultPath, err := backend.createDesktopFile(installDir, normalizedName, wrapperPath, opts)
+	resultPath, err := backend.createDesktopFile(installDir, normalizedName, wrapperPath, core.WaylandUnknown, opts)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, resultPath)
 }
@@ -183,7 +183,7 @@ func TestRPMBackend_createDesktopFile_WithWaylandEnvVars(t *testing.T) {
 		SkipWaylandEnv: false,
 	}
 
-	resultPath, err := backend.createDesktopFile(installDir, normalizedName, wrapperPath, opts)
+	resultPath, err := backend.createDesktopFile(installDir, normalizedName, wrapperPath, core.WaylandUnknown, opts)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, resultPath)
 }
@@ -214,7 +214,7 @@ func TestRPMBackend_createDesktopFile_WithCustomEnvVars(t *testing.T) {
 		SkipWaylandEnv: false,
 	}
 
-	resultPath, err := backend.createDesktopFile(installDir, normalizedName, wrapperPath, opts)
+	resultPath, err := backend.createDesktopFile(installDir, normalizedName, wrapperPath, core.WaylandUnknown, opts)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, resultPath)
 }
@@ -246,7 +246,7 @@ func TestRPMBackend_createDesktopFile_WithInvalidCustomEnvVars(t *testing.T) {
 		SkipWaylandEnv: false,
 	}
 
-	resultPath, err := backend.createDesktopFile(installDir, normalizedName, wrapperPath, opts)
+	resultPath, err := backend.createDesktopFile(installDir, normalizedName, wrapperPath, core.WaylandUnknown, opts)
 	// Should fallback to default injection and not error
 	assert.NoError(t, err)
 	assert.NotEmpty(t, resultPath)
@@ -277,7 +277,7 @@ func TestRPMBackend_createDesktopFile_SkipWaylandEnv(t *testing.T) {
 		SkipWaylandEnv: true, // Skip Wayland env injection
 	}
 
-	resultPath, err := backend.createDesktopFile(installDir, normalizedName, wrapperPath, opts)
+	resultPath, err := backend.createDesktopFile(installDir, normalizedName, wrapperPath, core.WaylandUnknown, opts)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, resultPath)
 }
@@ -320,7 +320,7 @@ func TestRPMBackend_uninstallExtracted_FullRemoval(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	err := backend.uninstallExtracted(ctx, record)
+	err := backend.uninstallExtracted(ctx, record, core.NewUninstallResult())
 	_ = err
 }
 
@@ -350,7 +350,7 @@ func TestRPMBackend_uninstallExtracted_EmptyPaths(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	err := backend.uninstallExtracted(ctx, record)
+	err := backend.uninstallExtracted(ctx, record, core.NewUninstallResult())
 	_ = err
 }
 
@@ -385,7 +385,7 @@ func TestRPMBackend_uninstallExtracted_WithIcons(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	err := backend.uninstallExtracted(ctx, record)
+	err := backend.uninstallExtracted(ctx, record, core.NewUninstallResult())
 	_ = err
 }
 
@@ -408,65 +408,6 @@ func TestRPMBackend_installIcons_EdgeCases(t *testing.T) {
 	_ = err
 }
 
-func TestRPMBackend_copyDir(t *testing.T) {
-	t.Parallel()
-
-	tmpDir := t.TempDir()
-	cfg := &config.Config{
-		Paths: config.PathsConfig{
-			DataDir: tmpDir,
-		},
-	}
-	log := zerolog.Nop()
-	backend := New(cfg, &log)
-
-	t.Run("copies directory successfully", func(t *testing.T) {
-		srcDir := filepath.Join(tmpDir, "src")
-		dstDir := filepath.Join(tmpDir, "dst")
-
-		// Create source structure
-		require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "subdir"), 0755))
-		require.NoError(t, os.WriteFile(filepath.Join(srcDir, "file1.txt"), []byte("content1"), 0644))
-		require.NoError(t, os.WriteFile(filepath.Join(srcDir, "subdir", "file2.txt"), []byte("content2"), 0644))
-
-		err := backend.copyDir(srcDir, dstDir)
-		assert.NoError(t, err)
-
-		// Verify files were copied
-		assert.FileExists(t, filepath.Join(dstDir, "file1.txt"))
-		assert.FileExists(t, filepath.Join(dstDir, "subdir", "file2.txt"))
-	})
-
-	t.Run("handles nonexistent source gracefully", func(t *testing.T) {
-		srcDir := filepath.Join(tmpDir, "nonexistent")
-		dstDir := filepath.Join(tmpDir, "dst")
-
-		// copyDir uses afero.Walk which returns error for nonexistent paths
-		err := backend.copyDir(srcDir, dstDir)
-		assert.Error(t, err)
-	})
-
-	t.Run("copies with symlinks", func(t *testing.T) {
-		srcDir := filepath.Join(tmpDir, "src_symlink")
-		dstDir := filepath.Join(tmpDir, "dst_symlink")
-
-		require.NoError(t, os.MkdirAll(srcDir, 0755))
-		targetFile := filepath.Join(srcDir, "target.txt")
-		require.NoError(t, os.WriteFile(targetFile, []byte("target"), 0644))
-
-		// Create a symlink
-		linkPath := filepath.Join(srcDir, "link.txt")
-		err := os.Symlink("target.txt", linkPath)
-		if err != nil {
-			t.Skip("Symlink creation not supported")
-		}
-
-		err = backend.copyDir(srcDir, dstDir)
-		// On some systems afero doesn't support symlinks properly
-		_ = err
-	})
-}
-
 func TestRPMBackend_queryRpmName(t *testing.T) {
 	t.Parallel()
 
@@ -551,3 +492,22 @@ func TestRPMBackend_queryRpmName(t *testing.T) {
 		assert.Empty(t, name)
 	})
 }
+
+func TestRPMBackend_QuerySummary(t *testing.T) {
+	log := zerolog.Nop()
+	var queried []string
+	runner := &helpers.MockCommandRunner{
+		CommandExistsFunc: func(name string) bool { return name == "rpm" },
+		RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+			queried = append(queried, args[2])
+			return "Markdown note-taking app\n", nil
+		},
+	}
+	backend := NewWithRunner(&config.Config{}, &log, runner)
+
+	assert.Equal(t, "Markdown note-taking app", backend.querySummary(context.Background(), "/tmp/notes.rpm"))
+	assert.Equal(t, []string{"%{SUMMARY}"}, queried)
+
+	runner.CommandExistsFunc = func(string) bool { return false }
+	assert.Empty(t, backend.querySummary(context.Background(), "/tmp/notes.rpm"))
+}
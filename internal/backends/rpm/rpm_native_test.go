@@ -0,0 +1,117 @@
+package rpm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestRpm writes an RPM with a gzip cpio payload holding a real ELF
+// launcher, a desktop entry and an icon
+func writeTestRpm(t *testing.T, path string) {
+	t.Helper()
+
+	self, err := os.Executable()
+	require.NoError(t, err)
+	elf, err := os.ReadFile(self)
+	require.NoError(t, err)
+
+	var cpio bytes.Buffer
+	entry := func(name string, mode uint32, data []byte) {
+		fmt.Fprintf(&cpio, "070701%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+			0, mode, 0, 0, 1, 0, len(data), 0, 0, 0, 0, len(name)+1, 0)
+		cpio.WriteString(name + "\x00")
+		for cpio.Len()%4 != 0 {
+			cpio.WriteByte(0)
+		}
+		cpio.Write(data)
+		for cpio.Len()%4 != 0 {
+			cpio.WriteByte(0)
+		}
+	}
+	entry("./usr/bin/notes", 0100755, elf)
+	entry("./usr/share/applications/notes.desktop", 0100644, []byte("[Desktop Entry]\nType=Application\nName=Notes\nExec=/usr/bin/notes %U\nIcon=notes\n"))
+	entry("./usr/share/icons/hicolor/scalable/apps/notes.svg", 0100644, []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`))
+	entry("TRAILER!!!", 0, nil)
+
+	var payload bytes.Buffer
+	gw := gzip.NewWriter(&payload)
+	_, err = gw.Write(cpio.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	type tag struct {
+		id    uint32
+		value string
+	}
+	header := func(tags ...tag) []byte {
+		var index, store bytes.Buffer
+		for _, tag := range tags {
+			// Type 6 is a NUL-terminated string
+			require.NoError(t, binary.Write(&index, binary.BigEndian, []uint32{tag.id, 6, uint32(store.Len()), 1}))
+			store.WriteString(tag.value + "\x00")
+		}
+		var out bytes.Buffer
+		out.Write([]byte{0x8e, 0xad, 0xe8, 0x01, 0, 0, 0, 0})
+		require.NoError(t, binary.Write(&out, binary.BigEndian, []uint32{uint32(len(tags)), uint32(store.Len())}))
+		out.Write(index.Bytes())
+		out.Write(store.Bytes())
+		return out.Bytes()
+	}
+
+	var rpm bytes.Buffer
+	lead := make([]byte, 96)
+	copy(lead, []byte{0xed, 0xab, 0xee, 0xdb})
+	rpm.Write(lead)
+	rpm.Write(header())
+	rpm.Write(header(tag{1000, "notes"}, tag{1001, "2.4.1"}, tag{1002, "3"}, tag{1004, "Markdown note-taking app"}, tag{1124, "cpio"}, tag{1125, "gzip"}))
+	rpm.Write(payload.Bytes())
+	require.NoError(t, os.WriteFile(path, rpm.Bytes(), 0644))
+}
+
+func TestInstall_NativeExtraction(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	logger := zerolog.New(io.Discard)
+	runner := &helpers.MockCommandRunner{
+		// Neither rpm, rpmextract.sh nor bsdtar are installed
+		CommandExistsFunc: func(string) bool { return false },
+	}
+	backend := NewWithDeps(&config.Config{}, &logger, afero.NewOsFs(), runner)
+
+	rpmPath := filepath.Join(t.TempDir(), "Notes-2.4.1-3.x86_64.rpm")
+	writeTestRpm(t, rpmPath)
+
+	tx := transaction.NewManager(&logger)
+	result, err := backend.Install(context.Background(), rpmPath, core.InstallOptions{}, tx)
+	require.NoError(t, err)
+	tx.Commit()
+	record := result.Record
+
+	installDir := filepath.Join(home, ".local", "share", "upkg", "apps", "notes")
+	assert.Equal(t, "notes", record.Name)
+	assert.Equal(t, "2.4.1-3", record.Version)
+	assert.Equal(t, installDir, record.InstallPath)
+	assert.FileExists(t, filepath.Join(installDir, "usr", "bin", "notes"))
+
+	wrapper, err := os.ReadFile(record.Metadata.WrapperScript)
+	require.NoError(t, err)
+	assert.Contains(t, string(wrapper), filepath.Join(installDir, "usr", "bin", "notes"))
+	require.NotEmpty(t, record.DesktopFile)
+	assert.NotEmpty(t, record.Metadata.IconFiles)
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/quantmind-br/upkg/internal/paths"
 	"github.com/quantmind-br/upkg/internal/syspkg"
 	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/quantmind-br/upkg/internal/wrapper"
 	"github.com/rs/zerolog"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
@@ -164,7 +165,8 @@ func TestInstall_PackageNotFound(t *testing.T) {
 	backend := New(cfg, &logger)
 	tx := transaction.NewManager(&logger)
 
-	record, err := backend.Install(context.Background(), "/nonexistent/package.rpm", core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), "/nonexistent/package.rpm", core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "package not found")
@@ -183,9 +185,10 @@ func TestInstall_InvalidPackageName(t *testing.T) {
 
 	// Try to install with an empty custom name after normalization
 	// Using a name that normalizes to empty string (all invalid chars)
-	record, err := backend.Install(context.Background(), fakeRpm, core.InstallOptions{
+	result, err := backend.Install(context.Background(), fakeRpm, core.InstallOptions{
 		CustomName: "///",
 	}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid")
@@ -210,7 +213,8 @@ func TestInstall_NoInstallationMethod(t *testing.T) {
 	require.NoError(t, os.WriteFile(fakeRpm, []byte{0xED, 0xAB, 0xEE, 0xDB}, 0644))
 
 	tx := transaction.NewManager(&logger)
-	record, err := backend.Install(context.Background(), fakeRpm, core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), fakeRpm, core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "no suitable RPM extraction tool found")
@@ -316,12 +320,12 @@ func TestCreateWrapper(t *testing.T) {
 		wrapperPath := filepath.Join(tmpDir, "wrapper")
 		execPath := "/path/to/executable"
 
-		wrapperCfg := helpers.WrapperConfig{
+		wrapperCfg := wrapper.Config{
 			WrapperPath:    wrapperPath,
 			ExecPath:       execPath,
 			DisableSandbox: false,
 		}
-		err := helpers.CreateWrapper(backend.Fs, wrapperCfg)
+		err := wrapper.Create(backend.Fs, wrapperCfg)
 		require.NoError(t, err)
 
 		content, err := os.ReadFile(wrapperPath)
@@ -411,7 +415,7 @@ func TestUninstall_ExtractedMethod(t *testing.T) {
 			},
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.NoError(t, err)
 
 		// Verify all files removed
@@ -443,7 +447,7 @@ func TestUninstall_ExtractedMethod(t *testing.T) {
 			},
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.NoError(t, err)
 	})
 }
@@ -474,7 +478,7 @@ func TestUninstall_PacmanMethod(t *testing.T) {
 			},
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.NoError(t, err)
 	})
 
@@ -496,7 +500,7 @@ func TestUninstall_PacmanMethod(t *testing.T) {
 			},
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.NoError(t, err)
 		assert.True(t, mockProvider.removeCalled)
 	})
@@ -551,53 +555,6 @@ func TestQueryRpmName(t *testing.T) {
 	})
 }
 
-func TestCopyDir(t *testing.T) {
-	logger := zerolog.New(io.Discard)
-
-	t.Run("copies directory with files", func(t *testing.T) {
-		srcDir := t.TempDir()
-		dstDir := t.TempDir()
-		dstPath := filepath.Join(dstDir, "copied")
-
-		// Create source structure
-		require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "subdir"), 0755))
-		require.NoError(t, os.WriteFile(filepath.Join(srcDir, "file1.txt"), []byte("content1"), 0644))
-		require.NoError(t, os.WriteFile(filepath.Join(srcDir, "subdir", "file2.txt"), []byte("content2"), 0644))
-
-		backend := New(&config.Config{}, &logger)
-		err := backend.copyDir(srcDir, dstPath)
-		require.NoError(t, err)
-
-		// Verify copied files
-		content1, err := os.ReadFile(filepath.Join(dstPath, "file1.txt"))
-		require.NoError(t, err)
-		assert.Equal(t, "content1", string(content1))
-
-		content2, err := os.ReadFile(filepath.Join(dstPath, "subdir", "file2.txt"))
-		require.NoError(t, err)
-		assert.Equal(t, "content2", string(content2))
-	})
-
-	t.Run("handles symlinks safely", func(t *testing.T) {
-		srcDir := t.TempDir()
-		dstDir := t.TempDir()
-		dstPath := filepath.Join(dstDir, "copied")
-
-		// Create source with symlink
-		require.NoError(t, os.WriteFile(filepath.Join(srcDir, "target.txt"), []byte("target content"), 0644))
-		require.NoError(t, os.Symlink("target.txt", filepath.Join(srcDir, "link.txt")))
-
-		backend := New(&config.Config{}, &logger)
-		err := backend.copyDir(srcDir, dstPath)
-		require.NoError(t, err)
-
-		// Verify symlink is copied
-		linkDst, err := os.Readlink(filepath.Join(dstPath, "link.txt"))
-		require.NoError(t, err)
-		assert.Equal(t, "target.txt", linkDst)
-	})
-}
-
 func TestInstallRecord(t *testing.T) {
 	// This test verifies the InstallRecord structure is properly created
 	// without actually running a full install (which would need system commands)
@@ -628,9 +585,10 @@ func TestInstallRecord(t *testing.T) {
 
 // mockSyspkgProvider is a mock implementation of syspkg.Provider for testing
 type mockSyspkgProvider struct {
-	isInstalled  bool
-	removeCalled bool
-	removeErr    error
+	isInstalled    bool
+	isInstalledErr error
+	removeCalled   bool
+	removeErr      error
 
 	// Function fields for testing
 	GetInfoFunc   func(context.Context, string) (*syspkg.PackageInfo, error)
@@ -651,7 +609,7 @@ func (m *mockSyspkgProvider) Remove(_ context.Context, _ string) error {
 }
 
 func (m *mockSyspkgProvider) IsInstalled(_ context.Context, _ string) (bool, error) {
-	return m.isInstalled, nil
+	return m.isInstalled, m.isInstalledErr
 }
 
 func (m *mockSyspkgProvider) GetInfo(_ context.Context, packageName string) (*syspkg.PackageInfo, error) {
@@ -688,7 +646,7 @@ func TestInstallWithExtract(t *testing.T) {
 		backend := NewWithDeps(cfg, &logger, afero.NewOsFs(), mockRunner)
 		tx := transaction.NewManager(&logger)
 
-		record, err := backend.installWithExtract(context.Background(), rpmPath, "test-app", "test-id", core.InstallOptions{}, tx)
+		record, err := backend.installWithExtract(context.Background(), rpmPath, "test-app", "test-id", core.InstallOptions{}, tx, core.NewInstallResult())
 		assert.Error(t, err)
 		assert.Nil(t, record)
 	})
@@ -705,7 +663,7 @@ func TestInstallWithExtract(t *testing.T) {
 		backend := NewWithDeps(cfg, &logger, afero.NewOsFs(), mockRunner)
 		tx := transaction.NewManager(&logger)
 
-		record, err := backend.installWithExtract(context.Background(), rpmPath, "test-app", "test-id", core.InstallOptions{}, tx)
+		record, err := backend.installWithExtract(context.Background(), rpmPath, "test-app", "test-id", core.InstallOptions{}, tx, core.NewInstallResult())
 		assert.Error(t, err)
 		assert.Nil(t, record)
 	})
@@ -777,7 +735,7 @@ func TestGetPackageInfo(t *testing.T) {
 		backend := NewWithDeps(cfg, &logger, afero.NewOsFs(), &helpers.MockCommandRunner{})
 		backend.sys = mockProvider
 
-		info, err := backend.getPackageInfo(context.Background(), "test-package")
+		info, err := backend.getPackageInfo(context.Background(), mockProvider, "test-package")
 		assert.NoError(t, err)
 		assert.NotNil(t, info)
 		assert.Equal(t, "test-package", info.name)
@@ -794,7 +752,7 @@ func TestGetPackageInfo(t *testing.T) {
 		backend := NewWithDeps(cfg, &logger, afero.NewOsFs(), &helpers.MockCommandRunner{})
 		backend.sys = mockProvider
 
-		info, err := backend.getPackageInfo(context.Background(), "nonexistent")
+		info, err := backend.getPackageInfo(context.Background(), mockProvider, "nonexistent")
 		assert.Error(t, err)
 		assert.Nil(t, info)
 	})
@@ -819,7 +777,7 @@ func TestFindInstalledFiles(t *testing.T) {
 		backend := NewWithDeps(cfg, &logger, afero.NewOsFs(), &helpers.MockCommandRunner{})
 		backend.sys = mockProvider
 
-		files, err := backend.findInstalledFiles(context.Background(), "test-package")
+		files, err := backend.findInstalledFiles(context.Background(), mockProvider, "test-package")
 		assert.NoError(t, err)
 		assert.Len(t, files, 3)
 		assert.Contains(t, files, "/usr/bin/test-app")
@@ -835,7 +793,7 @@ func TestFindInstalledFiles(t *testing.T) {
 		backend := NewWithDeps(cfg, &logger, afero.NewOsFs(), &helpers.MockCommandRunner{})
 		backend.sys = mockProvider
 
-		files, err := backend.findInstalledFiles(context.Background(), "nonexistent")
+		files, err := backend.findInstalledFiles(context.Background(), mockProvider, "nonexistent")
 		assert.Error(t, err)
 		assert.Empty(t, files)
 	})
@@ -927,7 +885,7 @@ func TestCreateDesktopFile(t *testing.T) {
 		wrapperPath := filepath.Join(installDir, "test-app")
 		require.NoError(t, os.WriteFile(wrapperPath, []byte("fake binary"), 0755))
 
-		resultPath, err := backend.createDesktopFile(installDir, "test-app", wrapperPath, core.InstallOptions{})
+		resultPath, err := backend.createDesktopFile(installDir, "test-app", wrapperPath, core.WaylandUnknown, core.InstallOptions{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, resultPath)
 		assert.Contains(t, resultPath, ".desktop")
@@ -942,7 +900,7 @@ func TestCreateDesktopFile(t *testing.T) {
 		wrapperPath := filepath.Join(installDir, "test-app")
 		require.NoError(t, os.WriteFile(wrapperPath, []byte("fake binary"), 0755))
 
-		resultPath, err := backend.createDesktopFile(installDir, "test-app", wrapperPath, core.InstallOptions{})
+		resultPath, err := backend.createDesktopFile(installDir, "test-app", wrapperPath, core.WaylandUnknown, core.InstallOptions{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, resultPath)
 
@@ -965,7 +923,7 @@ func TestCreateDesktopFile(t *testing.T) {
 		wrapperPath := filepath.Join(installDir, "test-app")
 		require.NoError(t, os.WriteFile(wrapperPath, []byte("fake binary"), 0755))
 
-		resultPath, err := backend.createDesktopFile(installDir, "test-app", wrapperPath, core.InstallOptions{})
+		resultPath, err := backend.createDesktopFile(installDir, "test-app", wrapperPath, core.WaylandUnknown, core.InstallOptions{})
 		assert.Error(t, err)
 		assert.Empty(t, resultPath)
 	})
@@ -0,0 +1,61 @@
+package rpm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/quantmind-br/upkg/internal/core"
+)
+
+// SyncMetadata re-reads the version and owned files of a dnf- or
+// pacman-managed install, picking up reinstalls and upgrades done outside upkg
+func (r *RpmBackend) SyncMetadata(ctx context.Context, record *core.InstallRecord) (bool, error) {
+	pkgName := systemPackageName(record)
+	sys := r.provider(record.Metadata.InstallMethod)
+
+	installed, err := sys.IsInstalled(ctx, pkgName)
+	if err != nil {
+		return false, fmt.Errorf("query %s for %s: %w", sys.Name(), pkgName, err)
+	}
+	if !installed {
+		return false, fmt.Errorf("package %s is no longer installed in %s", pkgName, sys.Name())
+	}
+
+	info, err := r.getPackageInfo(ctx, sys, pkgName)
+	if err != nil {
+		return false, fmt.Errorf("query %s for %s: %w", sys.Name(), pkgName, err)
+	}
+	files, err := r.findInstalledFiles(ctx, sys, pkgName)
+	if err != nil {
+		return false, fmt.Errorf("list files of %s: %w", pkgName, err)
+	}
+
+	changed := false
+	if info.version != "" && info.version != record.Version {
+		record.Version = info.version
+		changed = true
+	}
+	if info.size > 0 && info.size != record.Metadata.InstalledSize {
+		record.Metadata.InstalledSize = info.size
+		changed = true
+	}
+
+	desktopFiles := r.findDesktopFiles(files)
+	if !slices.Equal(desktopFiles, record.Metadata.DesktopFiles) {
+		record.Metadata.DesktopFiles = desktopFiles
+		record.DesktopFile = ""
+		if len(desktopFiles) > 0 {
+			record.DesktopFile = desktopFiles[0]
+		}
+		changed = true
+	}
+
+	iconFiles := r.findIconFiles(files)
+	if !slices.Equal(iconFiles, record.Metadata.IconFiles) {
+		record.Metadata.IconFiles = iconFiles
+		changed = true
+	}
+
+	return changed, nil
+}
@@ -0,0 +1,84 @@
+package rpm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/syspkg"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncMetadata(t *testing.T) {
+	t.Parallel()
+	logger := zerolog.New(io.Discard)
+
+	var queried string
+	backend := NewWithDeps(&config.Config{}, &logger, afero.NewMemMapFs(), &helpers.MockCommandRunner{})
+	backend.dnf = &mockSyspkgProvider{
+		isInstalled: true,
+		GetInfoFunc: func(_ context.Context, name string) (*syspkg.PackageInfo, error) {
+			queried = name
+			return &syspkg.PackageInfo{Name: name, Version: "2.0-1.fc40"}, nil
+		},
+		ListFilesFunc: func(_ context.Context, _ string) ([]string, error) {
+			return []string{
+				"/usr/bin/Tool",
+				"/usr/share/applications/tool.desktop",
+				"/usr/share/icons/hicolor/256x256/apps/tool.png",
+			}, nil
+		},
+	}
+
+	record := &core.InstallRecord{
+		Name:    "Tool",
+		Version: "1.0-1.fc40",
+		Metadata: core.Metadata{
+			InstallMethod: core.InstallMethodDnf,
+		},
+	}
+
+	changed, err := backend.SyncMetadata(context.Background(), record)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "Tool", queried)
+	assert.Equal(t, "2.0-1.fc40", record.Version)
+	assert.Equal(t, "/usr/share/applications/tool.desktop", record.DesktopFile)
+	assert.Equal(t, []string{"/usr/share/icons/hicolor/256x256/apps/tool.png"}, record.Metadata.IconFiles)
+
+	changed, err = backend.SyncMetadata(context.Background(), record)
+	require.NoError(t, err)
+	assert.False(t, changed)
+}
+
+func TestSyncMetadata_NotInstalled(t *testing.T) {
+	t.Parallel()
+	logger := zerolog.New(io.Discard)
+
+	backend := NewWithDeps(&config.Config{}, &logger, afero.NewMemMapFs(), &helpers.MockCommandRunner{})
+	backend.dnf = &mockSyspkgProvider{isInstalled: false}
+
+	record := &core.InstallRecord{Name: "tool", Metadata: core.Metadata{InstallMethod: core.InstallMethodDnf}}
+	_, err := backend.SyncMetadata(context.Background(), record)
+	assert.ErrorContains(t, err, "no longer installed")
+}
+
+func TestSyncMetadata_QueryFailure(t *testing.T) {
+	t.Parallel()
+	logger := zerolog.New(io.Discard)
+
+	backend := NewWithDeps(&config.Config{}, &logger, afero.NewMemMapFs(), &helpers.MockCommandRunner{})
+	backend.dnf = &mockSyspkgProvider{isInstalledErr: errors.New("rpmdb open failed")}
+
+	record := &core.InstallRecord{Name: "tool", Metadata: core.Metadata{InstallMethod: core.InstallMethodDnf}}
+	_, err := backend.SyncMetadata(context.Background(), record)
+	require.ErrorContains(t, err, "rpmdb open failed")
+	assert.NotContains(t, err.Error(), "no longer installed")
+}
@@ -1,12 +1,13 @@
 package tarball
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"io/fs"
+	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -14,12 +15,15 @@ import (
 	"github.com/quantmind-br/upkg/internal/cache"
 	"github.com/quantmind-br/upkg/internal/config"
 	"github.com/quantmind-br/upkg/internal/core"
-	"github.com/quantmind-br/upkg/internal/desktop"
 	"github.com/quantmind-br/upkg/internal/helpers"
 	"github.com/quantmind-br/upkg/internal/heuristics"
 	"github.com/quantmind-br/upkg/internal/icons"
+	"github.com/quantmind-br/upkg/internal/integration"
+	"github.com/quantmind-br/upkg/internal/layout"
 	"github.com/quantmind-br/upkg/internal/security"
+	"github.com/quantmind-br/upkg/internal/store"
 	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/quantmind-br/upkg/internal/ui"
 	"github.com/rs/zerolog"
 	"github.com/spf13/afero"
 	"layeh.com/asar"
@@ -39,7 +43,7 @@ func New(cfg *config.Config, log *zerolog.Logger) *TarballBackend {
 	base := backendbase.New(cfg, log)
 	return &TarballBackend{
 		BaseBackend:  base,
-		scorer:       heuristics.NewScorer(log),
+		scorer:       heuristics.NewScorer(log, backendbase.ScoringRules(cfg)),
 		cacheManager: cache.NewCacheManagerWithRunner(base.Runner),
 	}
 }
@@ -54,7 +58,7 @@ func NewWithDeps(cfg *config.Config, log *zerolog.Logger, fs afero.Fs, runner he
 	base := backendbase.NewWithDeps(cfg, log, fs, runner)
 	return &TarballBackend{
 		BaseBackend:  base,
-		scorer:       heuristics.NewScorer(log),
+		scorer:       heuristics.NewScorer(log, backendbase.ScoringRules(cfg)),
 		cacheManager: cache.NewCacheManagerWithRunner(runner),
 	}
 }
@@ -64,7 +68,7 @@ func NewWithCacheManager(cfg *config.Config, log *zerolog.Logger, cacheManager *
 	base := backendbase.New(cfg, log)
 	return &TarballBackend{
 		BaseBackend:  base,
-		scorer:       heuristics.NewScorer(log),
+		scorer:       heuristics.NewScorer(log, backendbase.ScoringRules(cfg)),
 		cacheManager: cacheManager,
 	}
 }
@@ -74,6 +78,40 @@ func (t *TarballBackend) Name() string {
 	return "tarball"
 }
 
+// RequiredTools lists the external tools used by the tarball backend
+func (t *TarballBackend) RequiredTools() []core.ToolRequirement {
+	return []core.ToolRequirement{
+		{
+			Name:     "npx",
+			Optional: true,
+			Purpose:  "extract icons from Electron app.asar archives",
+			Packages: map[string]string{core.DistroArch: "npm", core.DistroDebian: "npm", core.DistroFedora: "npm", core.DistroSUSE: "npm"},
+		},
+		{
+			Name:         "zstd",
+			Alternatives: []string{"bsdtar"},
+			Optional:     true,
+			Purpose:      "extract .tar.zst archives",
+			Packages:     map[string]string{core.DistroArch: "zstd", core.DistroDebian: "zstd", core.DistroFedora: "zstd", core.DistroSUSE: "zstd"},
+		},
+		{
+			Name:         "lz4",
+			Alternatives: []string{"bsdtar"},
+			Optional:     true,
+			Purpose:      "extract .tar.lz4 archives",
+			Packages:     map[string]string{core.DistroArch: "lz4", core.DistroDebian: "lz4", core.DistroFedora: "lz4", core.DistroSUSE: "lz4"},
+		},
+		{
+			Name:     "bsdtar",
+			Optional: true,
+			Purpose:  "extract .7z archives",
+			Packages: map[string]string{core.DistroArch: "libarchive", core.DistroDebian: "libarchive-tools", core.DistroFedora: "bsdtar", core.DistroSUSE: "bsdtar"},
+		},
+		integration.DesktopValidatorTool,
+		integration.SandboxTool,
+	}
+}
+
 // Detect checks if this backend can handle the package
 func (t *TarballBackend) Detect(_ context.Context, packagePath string) (bool, error) {
 	// Check if file exists
@@ -87,27 +125,35 @@ func (t *TarballBackend) Detect(_ context.Context, packagePath string) (bool, er
 		return false, err
 	}
 
-	// Accept tar.gz, tar.xz, tar.bz2, tar, zip
+	// Accept tar.gz, tar.xz, tar.bz2, tar.zst, tar.lz4, tar, zip, 7z
 	return fileType == helpers.FileTypeTarGz ||
 		fileType == helpers.FileTypeTarXz ||
 		fileType == helpers.FileTypeTarBz2 ||
+		fileType == helpers.FileTypeTarZst ||
+		fileType == helpers.FileTypeTarLz4 ||
 		fileType == helpers.FileTypeTar ||
-		fileType == helpers.FileTypeZip, nil
+		fileType == helpers.FileTypeZip ||
+		fileType == helpers.FileType7z, nil
 }
 
 // Install installs the tarball/zip package
 //
 //nolint:gocyclo // archive install handles multiple formats, icons, desktop and rollback.
-func (t *TarballBackend) Install(_ context.Context, packagePath string, opts core.InstallOptions, tx *transaction.Manager) (*core.InstallRecord, error) {
+func (t *TarballBackend) Install(ctx context.Context, packagePath string, opts core.InstallOptions, tx *transaction.Manager) (*core.InstallResult, error) {
+	result := core.NewInstallResult()
 	t.Log.Info().
 		Str("package_path", packagePath).
 		Str("custom_name", opts.CustomName).
 		Msg("installing tarball/zip package")
 
 	// Validate package exists
-	if _, err := t.Fs.Stat(packagePath); err != nil {
+	info, err := t.Fs.Stat(packagePath)
+	if err != nil {
 		return nil, fmt.Errorf("package not found: %w", err)
 	}
+	if info.IsDir() {
+		return t.installFromDir(ctx, packagePath, opts, tx, result)
+	}
 
 	// Detect archive type
 	archiveType := helpers.GetArchiveType(packagePath)
@@ -139,28 +185,138 @@ func (t *TarballBackend) Install(_ context.Context, packagePath string, opts cor
 		appName = helpers.FormatDisplayName(appName)
 	}
 
+	normalizedName, installID, installDir, err := t.prepareInstallDir(appName, opts)
+	if err != nil {
+		return nil, err
+	}
+	payload := t.newPayload(installDir, helpers.VersionFromName(filepath.Base(packagePath)), installID, opts)
+
+	hash, err := store.HashFile(t.Fs, packagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash package: %w", err)
+	}
+
+	// Create installation directory
+	if err := t.Fs.MkdirAll(payload.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create installation directory: %w", err)
+	}
+	payloads := t.payloadCache()
+	reused := t.reusePayload(payloads, hash, payload.dir)
+	if tx != nil {
+		dir := payload.created
+		tx.Add("remove installation directory", func() error {
+			if reused {
+				// Keep the payload for the next attempt
+				if putErr := payloads.Put(payload.dir, store.Entry{Hash: hash, Name: appName, Source: packagePath}); putErr != nil {
+					t.Log.Debug().Err(putErr).Str("hash", hash).Msg("failed to return payload to cache")
+				}
+			}
+			return t.Fs.RemoveAll(dir)
+		})
+		tx.TrackPaths(dir)
+	}
+
+	progress := t.NewProgress(ctx, installPhases("Extracting archive"), "Installing archive")
+	defer progress.Finish()
+
+	// Extract archive
+	progress.StartPhase(0)
+	if reused {
+		result.Skip("extraction", "reused the cached payload of an identical archive")
+	} else {
+		t.Log.Debug().
+			Str("archive", packagePath).
+			Str("dest", payload.dir).
+			Msg("extracting archive")
+
+		if extractErr := t.extractArchive(ctx, packagePath, payload.dir, archiveType, helpers.WithProgress(backendbase.ByteProgress(progress))); extractErr != nil {
+			if removeErr := t.Fs.RemoveAll(payload.created); removeErr != nil {
+				t.Log.Debug().Err(removeErr).Str("install_dir", payload.created).Msg("failed to cleanup install dir after extract error")
+			}
+			return nil, fmt.Errorf("failed to extract archive: %w", extractErr)
+		}
+	}
+	if err := t.switchRelease(payload, tx); err != nil {
+		return nil, err
+	}
+
+	record, err := t.integratePayload(packagePath, payload, appName, normalizedName, installID, opts, tx, result, progress)
+	if err != nil {
+		return nil, err
+	}
+	record.Metadata.ContentHash = hash
+	return result.Finish(record), nil
+}
+
+// payloadCache returns the store of uninstalled payloads, or nil when
+// cache.payloads is disabled
+func (t *TarballBackend) payloadCache() *store.Store {
+	if t.Cfg == nil || !t.Cfg.Cache.Payloads {
+		return nil
+	}
+	return store.New(t.Fs, t.Paths.GetPayloadCacheDir())
+}
+
+// reusePayload moves the cached payload of an archive with the same hash
+// into dir, reporting whether there was one
+func (t *TarballBackend) reusePayload(payloads *store.Store, hash, dir string) bool {
+	if payloads == nil || !payloads.Has(hash) {
+		return false
+	}
+	entry, err := payloads.Take(hash, dir)
+	if err != nil {
+		t.Log.Warn().Err(err).Str("hash", hash).Msg("failed to reuse cached payload, extracting instead")
+		if mkdirErr := t.Fs.MkdirAll(dir, 0755); mkdirErr != nil {
+			t.Log.Debug().Err(mkdirErr).Str("dest", dir).Msg("failed to recreate installation directory")
+		}
+		return false
+	}
+	t.Log.Info().
+		Str("hash", hash).
+		Str("cached_from", entry.Name).
+		Str("dest", dir).
+		Msg("reusing cached payload")
+	return true
+}
+
+// installPhases are the progress phases of an install whose payload is
+// produced by the named first phase
+func installPhases(payload string) []ui.InstallationPhase {
+	return []ui.InstallationPhase{
+		{Name: payload, Weight: 70, Deterministic: true},
+		{Name: "Creating launcher", Weight: 10, Deterministic: true},
+		{Name: "Installing icons", Weight: 10, Deterministic: true},
+		{Name: "Configuring desktop", Weight: 10, Deterministic: true},
+	}
+}
+
+// prepareInstallDir names the install and clears a previous install at the
+// same location when forced
+func (t *TarballBackend) prepareInstallDir(appName string, opts core.InstallOptions) (normalizedName, installID, installDir string, err error) {
 	// Normalize name
-	normalizedName := helpers.NormalizeFilename(appName)
+	normalizedName = helpers.NormalizeFilename(appName)
 	if err := security.ValidatePackageName(normalizedName); err != nil {
-		return nil, fmt.Errorf("invalid normalized name %q: %w", normalizedName, err)
+		return "", "", "", fmt.Errorf("invalid normalized name %q: %w", normalizedName, err)
 	}
-	installID := helpers.GenerateInstallID(normalizedName)
+	installID = helpers.GenerateInstallID(normalizedName)
 
 	if t.Paths.HomeDir() == "" {
-		return nil, fmt.Errorf("failed to get home directory")
+		return "", "", "", fmt.Errorf("failed to get home directory")
 	}
 
 	// Create installation directory in ~/.local/share/upkg/apps/
-	appsDir := t.Paths.GetUpkgAppsDir()
-	installDir := filepath.Join(appsDir, normalizedName)
+	installDir = t.Paths.GetAppInstallDir(normalizedName)
 
-	// Check if already exists
-	if _, err := t.Fs.Stat(installDir); err == nil {
+	// Check if already exists (Lstat also catches a link to a removed folder)
+	if t.pathExists(installDir) {
 		if !opts.Force {
-			return nil, fmt.Errorf("package already installed at: %s (use --force to reinstall)", installDir)
+			return "", "", "", &core.ErrAlreadyInstalled{Path: installDir}
 		}
-		if err := t.Fs.RemoveAll(installDir); err != nil {
-			return nil, fmt.Errorf("remove existing installation directory: %w", err)
+		// A versioned install keeps its releases; the new one is added beside them
+		if layout.Current(t.Fs, installDir) == "" {
+			if err := t.Fs.RemoveAll(installDir); err != nil {
+				return "", "", "", fmt.Errorf("remove existing installation directory: %w", err)
+			}
 		}
 		// Best-effort cleanup of expected wrapper/desktop paths
 		binDir := t.Paths.GetBinDir()
@@ -175,45 +331,189 @@ func (t *TarballBackend) Install(_ context.Context, packagePath string, opts cor
 		}
 	}
 
-	// Create installation directory
-	if err := t.Fs.MkdirAll(installDir, 0755); err != nil {
-		return nil, fmt.Errorf("failed to create installation directory: %w", err)
+	return normalizedName, installID, installDir, nil
+}
+
+// installFromDir installs an application that was shipped already unpacked.
+// The folder is copied into the apps dir, or symlinked there with LinkDir so
+// it stays where the user keeps it.
+func (t *TarballBackend) installFromDir(ctx context.Context, sourceDir string, opts core.InstallOptions, tx *transaction.Manager, result *core.InstallResult) (*core.InstallResult, error) {
+	appsDir := t.Paths.GetUpkgAppsDir()
+	if within, _ := security.IsPathWithinDirectory(sourceDir, appsDir); within {
+		return nil, fmt.Errorf("%s is already inside the apps directory %s", sourceDir, appsDir)
+	}
+
+	appName := opts.CustomName
+	if appName == "" {
+		appName = helpers.FormatDisplayName(helpers.CleanAppName(filepath.Base(sourceDir)))
+	}
+
+	normalizedName, installID, installDir, err := t.prepareInstallDir(appName, opts)
+	if err != nil {
+		return nil, err
+	}
+	if mkdirErr := t.Fs.MkdirAll(appsDir, 0755); mkdirErr != nil {
+		return nil, fmt.Errorf("failed to create apps directory: %w", mkdirErr)
+	}
+
+	progress := t.NewProgress(ctx, installPhases("Copying application folder"), "Installing folder")
+	defer progress.Finish()
+	progress.StartPhase(0)
+
+	payload := t.newPayload(installDir, helpers.VersionFromName(filepath.Base(sourceDir)), installID, opts)
+	if opts.LinkDir {
+		linker, ok := t.Fs.(afero.Linker)
+		if !ok {
+			return nil, fmt.Errorf("filesystem does not support symlinks")
+		}
+		if linkErr := linker.SymlinkIfPossible(sourceDir, installDir); linkErr != nil {
+			return nil, fmt.Errorf("failed to link application directory: %w", linkErr)
+		}
+		payload.dir = sourceDir
+	} else {
+		t.Log.Debug().
+			Str("source", sourceDir).
+			Str("dest", payload.dir).
+			Msg("copying application directory")
+		copyErr := helpers.CopyTree(t.Fs, sourceDir, payload.dir, helpers.MoveProgressFunc(backendbase.ByteProgress(progress)))
+		if copyErr != nil {
+			if removeErr := t.Fs.RemoveAll(payload.created); removeErr != nil {
+				t.Log.Debug().Err(removeErr).Str("install_dir", payload.created).Msg("failed to cleanup install dir after copy error")
+			}
+			return nil, fmt.Errorf("failed to copy application directory: %w", copyErr)
+		}
 	}
 	if tx != nil {
-		dir := installDir
+		dir := payload.created
 		tx.Add("remove installation directory", func() error {
 			return t.Fs.RemoveAll(dir)
 		})
+		tx.TrackPaths(dir)
+	}
+	if err := t.switchRelease(payload, tx); err != nil {
+		return nil, err
 	}
 
-	// Extract archive
-	t.Log.Debug().
-		Str("archive", packagePath).
-		Str("dest", installDir).
-		Msg("extracting archive")
+	record, err := t.integratePayload(sourceDir, payload, appName, normalizedName, installID, opts, tx, result, progress)
+	if err != nil {
+		return nil, err
+	}
+	return result.Finish(record), nil
+}
 
-	if extractErr := t.extractArchive(packagePath, installDir, archiveType); extractErr != nil {
-		if removeErr := t.Fs.RemoveAll(installDir); removeErr != nil {
-			t.Log.Debug().Err(removeErr).Str("install_dir", installDir).Msg("failed to cleanup install dir after extract error")
+// pathExists reports whether path exists, without following a final symlink
+func (t *TarballBackend) pathExists(path string) bool {
+	if lstater, ok := t.Fs.(afero.Lstater); ok {
+		_, _, err := lstater.LstatIfPossible(path)
+		return err == nil
+	}
+	_, err := t.Fs.Stat(path)
+	return err == nil
+}
+
+// payloadLayout locates an unpacked payload. Usually every field is the
+// install directory; --link makes dir the linked folder, and the versioned
+// layout (upgrade.versioned_layout) puts dir in a release below root and
+// launches through the current link.
+type payloadLayout struct {
+	root    string // Install directory the record owns
+	dir     string // Unpacked payload, scanned for executables and icons
+	launch  string // Directory wrappers and exposed bins point into
+	release string // Release directory name under root, empty when not versioned
+	created string // Removed when the install fails
+}
+
+// newPayload lays out the payload of an install into root. The versioned
+// layout is used when configured or already in place, except for --link.
+func (t *TarballBackend) newPayload(root, version, installID string, opts core.InstallOptions) payloadLayout {
+	payload := payloadLayout{root: root, dir: root, launch: root, created: root}
+	if opts.LinkDir {
+		return payload
+	}
+	versioned := layout.Current(t.Fs, root) != ""
+	if !versioned && (t.Cfg == nil || !t.Cfg.Upgrade.VersionedLayout) {
+		return payload
+	}
+
+	payload.release = layout.ReleaseName(t.Fs, root, version, installID)
+	payload.dir = filepath.Join(root, payload.release)
+	payload.launch = layout.CurrentDir(root)
+	if versioned {
+		payload.created = payload.dir
+	}
+	return payload
+}
+
+// switchRelease points the current link at a versioned payload, restoring
+// the previous release on rollback
+func (t *TarballBackend) switchRelease(payload payloadLayout, tx *transaction.Manager) error {
+	if payload.release == "" {
+		return nil
+	}
+	restore, err := layout.Switch(t.Fs, payload.root, payload.release)
+	if err != nil {
+		if removeErr := t.Fs.RemoveAll(payload.created); removeErr != nil {
+			t.Log.Debug().Err(removeErr).Str("install_dir", payload.created).Msg("failed to cleanup install dir after switch error")
 		}
-		return nil, fmt.Errorf("failed to extract archive: %w", extractErr)
+		return fmt.Errorf("failed to switch to release %s: %w", payload.release, err)
 	}
+	if tx != nil {
+		tx.Add("restore current release", restore)
+	}
+	t.Log.Debug().
+		Str("install_dir", payload.root).
+		Str("release", payload.release).
+		Msg("switched current release")
+	return nil
+}
+
+// integratePayload wires an unpacked payload into the desktop: a wrapper for
+// the best executable, exposed bins, icons and the desktop entry. progress is
+// in the phase that produced the payload.
+//
+//nolint:gocyclo // integration steps each clean up after themselves on failure.
+func (t *TarballBackend) integratePayload(packagePath string, payload payloadLayout, appName, normalizedName, installID string, opts core.InstallOptions, tx *transaction.Manager, result *core.InstallResult, progress ui.Progress) (*core.InstallRecord, error) {
+	payloadDir, installDir := payload.dir, payload.root
+	progress.AdvancePhase()
 
 	// Find executable(s)
-	executables, err := heuristics.FindExecutables(installDir)
+	executables, err := heuristics.FindExecutables(payloadDir)
 	if err != nil || len(executables) == 0 {
-		if removeErr := t.Fs.RemoveAll(installDir); removeErr != nil {
-			t.Log.Debug().Err(removeErr).Str("install_dir", installDir).Msg("failed to cleanup install dir after no executables")
+		if removeErr := t.Fs.RemoveAll(payload.created); removeErr != nil {
+			t.Log.Debug().Err(removeErr).Str("install_dir", payload.created).Msg("failed to cleanup install dir after no executables")
 		}
-		return nil, fmt.Errorf("no executables found in archive")
+		return nil, fmt.Errorf("no executables found in %s", filepath.Base(packagePath))
 	}
 
 	t.Log.Debug().
 		Strs("executables", executables).
 		Msg("found executables")
 
-	// Choose primary executable using scoring heuristic
-	primaryExec := t.scorer.ChooseBest(executables, normalizedName, installDir)
+	// Choose primary executable using scoring heuristic, unless --bin names it
+	ranked := t.scorer.Rank(executables, normalizedName, payloadDir)
+	launchExecs := []string{ranked[0].Path}
+	if len(opts.Bins) > 0 {
+		launchExecs, err = selectBins(executables, opts.Bins, launchExecs[0])
+		if err != nil {
+			if removeErr := t.Fs.RemoveAll(payload.created); removeErr != nil {
+				t.Log.Debug().Err(removeErr).Str("install_dir", payload.created).Msg("failed to cleanup install dir after bin selection error")
+			}
+			return nil, err
+		}
+	}
+	result.Candidates = heuristics.Candidates(ranked, launchExecs[0])
+	if payload.launch != payloadDir {
+		// Launch through the owned path (or the current link) so the
+		// wrappers follow the record
+		for i, exec := range launchExecs {
+			rel, relErr := filepath.Rel(payloadDir, exec)
+			if relErr != nil {
+				return nil, fmt.Errorf("resolve executable path: %w", relErr)
+			}
+			launchExecs[i] = filepath.Join(payload.launch, rel)
+		}
+	}
+	primaryExec, extraExecs := launchExecs[0], launchExecs[1:]
 
 	t.Log.Debug().
 		Str("primary_executable", primaryExec).
@@ -222,40 +522,65 @@ func (t *TarballBackend) Install(_ context.Context, packagePath string, opts cor
 
 	// Create wrapper script in ~/.local/bin/
 	binDir := t.Paths.GetBinDir()
-	if mkdirErr := t.Fs.MkdirAll(binDir, 0755); mkdirErr != nil {
-		if removeErr := t.Fs.RemoveAll(installDir); removeErr != nil {
-			t.Log.Debug().Err(removeErr).Str("install_dir", installDir).Msg("failed to cleanup install dir after mkdir error")
+	wrapperPath, sandboxTool, wrapperErr := t.Integration().CreateLauncher(normalizedName, primaryExec, opts, installDir)
+	if wrapperErr != nil {
+		if removeErr := t.Fs.RemoveAll(payload.created); removeErr != nil {
+			t.Log.Debug().Err(removeErr).Str("install_dir", payload.created).Msg("failed to cleanup install dir after wrapper error")
 		}
-		return nil, fmt.Errorf("failed to create bin directory: %w", mkdirErr)
-	}
-
-	wrapperPath := filepath.Join(binDir, normalizedName)
-	wrapperCfg := helpers.WrapperConfig{
-		WrapperPath:    wrapperPath,
-		ExecPath:       primaryExec,
-		DisableSandbox: t.Cfg.Desktop.ElectronDisableSandbox,
-	}
-	if wrapperErr := helpers.CreateWrapper(t.Fs, wrapperCfg); wrapperErr != nil {
-		if removeErr := t.Fs.RemoveAll(installDir); removeErr != nil {
-			t.Log.Debug().Err(removeErr).Str("install_dir", installDir).Msg("failed to cleanup install dir after wrapper error")
-		}
-		return nil, fmt.Errorf("failed to create wrapper script: %w", wrapperErr)
+		return nil, wrapperErr
 	}
 	if tx != nil {
 		path := wrapperPath
 		tx.Add("remove wrapper script", func() error {
 			return t.Fs.Remove(path)
 		})
+		tx.TrackPaths(path)
 	}
 
 	t.Log.Debug().
 		Str("wrapper", wrapperPath).
 		Msg("created wrapper script")
 
+	// Launchers for the other executables selected with --bin
+	launchers := t.createExtraLaunchers(extraExecs, wrapperPath, installDir, opts, result)
+	extraWrappers := make([]string, 0, len(launchers))
+	for _, launcher := range launchers {
+		extraWrappers = append(extraWrappers, launcher.wrapper)
+	}
+	if tx != nil && len(extraWrappers) > 0 {
+		wrappers := slices.Clone(extraWrappers)
+		tx.Add("remove extra wrapper scripts", func() error {
+			t.Integration().RemoveFiles(wrappers)
+			return nil
+		})
+		tx.TrackPaths(wrappers...)
+	}
+
+	// Expose every bundled bin/ executable (developer toolchains)
+	var exposedBins []string
+	if opts.ExposeAllBins {
+		exposedBins, err = t.exposeBundledBins(payload.launch, binDir, wrapperPath)
+		if err != nil {
+			t.Log.Warn().Err(err).Msg("failed to expose bundled binaries")
+			result.Warn("bundled binaries not exposed: %v", err)
+		}
+		if tx != nil && len(exposedBins) > 0 {
+			links := append([]string(nil), exposedBins...)
+			tx.Add("remove exposed binaries", func() error {
+				t.removeExposedBins(links, installDir)
+				return nil
+			})
+			tx.TrackPaths(links...)
+		}
+	}
+
+	progress.AdvancePhase()
+
 	// Install icons (if any)
-	iconPaths, err := t.installIcons(installDir, normalizedName)
+	iconPaths, err := t.installIcons(payloadDir, normalizedName)
 	if err != nil {
 		t.Log.Warn().Err(err).Msg("failed to install icons")
+		result.Warn("icons not installed: %v", err)
 	}
 	if tx != nil && len(iconPaths) > 0 {
 		paths := append([]string(nil), iconPaths...)
@@ -263,20 +588,27 @@ func (t *TarballBackend) Install(_ context.Context, packagePath string, opts cor
 			t.removeIcons(paths)
 			return nil
 		})
+		tx.TrackPaths(paths...)
 	}
 
+	progress.AdvancePhase()
+
+	framework, wayland := heuristics.DetectApp(t.Fs, payloadDir, primaryExec)
+
 	// Create .desktop file
 	var desktopPath string
+	var desktopFiles []string
 	if !opts.SkipDesktop {
-		desktopPath, err = t.createDesktopFile(installDir, appName, normalizedName, wrapperPath, opts)
+		desktopPath, err = t.createDesktopFile(payloadDir, appName, normalizedName, wrapperPath, wayland, opts)
 		if err != nil {
 			// Clean up on failure
-			if removeErr := t.Fs.RemoveAll(installDir); removeErr != nil {
-				t.Log.Debug().Err(removeErr).Str("install_dir", installDir).Msg("failed to cleanup install dir after desktop error")
+			if removeErr := t.Fs.RemoveAll(payload.created); removeErr != nil {
+				t.Log.Debug().Err(removeErr).Str("install_dir", payload.created).Msg("failed to cleanup install dir after desktop error")
 			}
 			if removeErr := t.Fs.Remove(wrapperPath); removeErr != nil {
 				t.Log.Debug().Err(removeErr).Str("path", wrapperPath).Msg("failed to cleanup wrapper after desktop error")
 			}
+			t.Integration().RemoveFiles(extraWrappers)
 			t.removeIcons(iconPaths)
 			return nil, fmt.Errorf("failed to create desktop file: %w", err)
 		}
@@ -290,6 +622,22 @@ func (t *TarballBackend) Install(_ context.Context, packagePath string, opts cor
 			tx.Add("remove desktop file", func() error {
 				return t.Fs.Remove(path)
 			})
+			tx.TrackPaths(path)
+		}
+
+		if opts.BinDesktops && len(launchers) > 0 {
+			extraDesktops := t.createExtraDesktopFiles(launchers, appName, normalizedName, opts, result)
+			if tx != nil && len(extraDesktops) > 0 {
+				paths := slices.Clone(extraDesktops)
+				tx.Add("remove extra desktop files", func() error {
+					t.Integration().RemoveFiles(paths)
+					return nil
+				})
+				tx.TrackPaths(paths...)
+			}
+			if len(extraDesktops) > 0 {
+				desktopFiles = append([]string{desktopPath}, extraDesktops...)
+			}
 		}
 
 		// Update caches
@@ -302,6 +650,43 @@ func (t *TarballBackend) Install(_ context.Context, packagePath string, opts cor
 		if cacheErr := t.cacheManager.UpdateIconCache(iconsDir, t.Log); cacheErr != nil {
 			t.Log.Warn().Err(cacheErr).Str("icons_dir", iconsDir).Msg("failed to update icon cache")
 		}
+	} else {
+		result.Skip("desktop entry", "--skip-desktop")
+	}
+
+	// Install AppStream metainfo for software centers
+	metainfoPaths, err := t.Integration().InstallMetainfo(payloadDir, desktopPath)
+	if err != nil {
+		t.Log.Warn().Err(err).Msg("failed to install metainfo")
+		result.Warn("AppStream metainfo not installed: %v", err)
+	}
+	if tx != nil && len(metainfoPaths) > 0 {
+		paths := append([]string(nil), metainfoPaths...)
+		tx.Add("remove metainfo", func() error {
+			t.Integration().RemoveFiles(paths)
+			return nil
+		})
+		tx.TrackPaths(paths...)
+	}
+
+	// Install the man pages and shell completions of command-line tools
+	manPages, err := t.Integration().InstallManPages(payloadDir)
+	if err != nil {
+		t.Log.Warn().Err(err).Msg("failed to install man pages")
+		result.Warn("man pages not installed: %v", err)
+	}
+	completions, err := t.Integration().InstallCompletions(payloadDir)
+	if err != nil {
+		t.Log.Warn().Err(err).Msg("failed to install shell completions")
+		result.Warn("shell completions not installed: %v", err)
+	}
+	if tx != nil && len(manPages)+len(completions) > 0 {
+		paths := append(slices.Clone(manPages), completions...)
+		tx.Add("remove man pages and completions", func() error {
+			t.Integration().RemoveFiles(paths)
+			return nil
+		})
+		tx.TrackPaths(paths...)
 	}
 
 	// Create install record
@@ -315,9 +700,20 @@ func (t *TarballBackend) Install(_ context.Context, packagePath string, opts cor
 		DesktopFile:  desktopPath,
 		Metadata: core.Metadata{
 			IconFiles:      iconPaths,
+			MetainfoFiles:  metainfoPaths,
+			ManPages:       manPages,
+			Completions:    completions,
 			WrapperScript:  wrapperPath,
-			WaylandSupport: string(core.WaylandUnknown),
+			WaylandSupport: string(wayland),
+			Framework:      string(framework),
 			InstallMethod:  core.InstallMethodLocal,
+			ExposedBins:    exposedBins,
+			Sandbox:        sandboxTool,
+			DesktopFiles:   desktopFiles,
+			Bins:           opts.Bins,
+			BinDesktops:    opts.BinDesktops,
+			ExtraWrappers:  extraWrappers,
+			Release:        payload.release,
 		},
 	}
 
@@ -331,7 +727,8 @@ func (t *TarballBackend) Install(_ context.Context, packagePath string, opts cor
 }
 
 // Uninstall removes the installed tarball/zip package
-func (t *TarballBackend) Uninstall(_ context.Context, record *core.InstallRecord) error {
+func (t *TarballBackend) Uninstall(_ context.Context, record *core.InstallRecord) (*core.UninstallResult, error) {
+	result := core.NewUninstallResult()
 	t.Log.Info().
 		Str("install_id", record.InstallID).
 		Str("name", record.Name).
@@ -341,6 +738,7 @@ func (t *TarballBackend) Uninstall(_ context.Context, record *core.InstallRecord
 	if record.InstallPath != "" {
 		if err := t.Fs.RemoveAll(record.InstallPath); err != nil {
 			t.Log.Warn().Err(err).Str("path", record.InstallPath).Msg("failed to remove installation directory")
+			result.Warn("failed to remove %s: %v", record.InstallPath, err)
 		}
 	}
 
@@ -348,9 +746,21 @@ func (t *TarballBackend) Uninstall(_ context.Context, record *core.InstallRecord
 	if record.Metadata.WrapperScript != "" {
 		if err := t.Fs.Remove(record.Metadata.WrapperScript); err != nil {
 			t.Log.Warn().Err(err).Str("path", record.Metadata.WrapperScript).Msg("failed to remove wrapper script")
+			result.Warn("failed to remove %s: %v", record.Metadata.WrapperScript, err)
 		}
 	}
 
+	// Remove the launchers of the other --bin executables
+	for _, wrapper := range record.Metadata.ExtraWrappers {
+		if err := t.Fs.Remove(wrapper); err != nil && !os.IsNotExist(err) {
+			t.Log.Warn().Err(err).Str("path", wrapper).Msg("failed to remove wrapper script")
+			result.Warn("failed to remove %s: %v", wrapper, err)
+		}
+	}
+
+	// Remove exposed bin symlinks
+	t.removeExposedBins(record.Metadata.ExposedBins, record.InstallPath)
+
 	// Remove .desktop file(s)
 	for _, desktopPath := range record.GetDesktopFiles() {
 		if desktopPath == "" {
@@ -358,12 +768,18 @@ func (t *TarballBackend) Uninstall(_ context.Context, record *core.InstallRecord
 		}
 		if err := t.Fs.Remove(desktopPath); err != nil {
 			t.Log.Warn().Err(err).Str("path", desktopPath).Msg("failed to remove desktop file")
+			result.Warn("failed to remove %s: %v", desktopPath, err)
 		}
 	}
 
 	// Remove icons
 	t.removeIcons(record.Metadata.IconFiles)
 
+	// Remove AppStream metainfo, man pages and shell completions
+	t.Integration().RemoveFiles(record.Metadata.MetainfoFiles)
+	t.Integration().RemoveFiles(record.Metadata.ManPages)
+	t.Integration().RemoveFiles(record.Metadata.Completions)
+
 	// Update caches
 	appsDir := t.Paths.GetAppsDir()
 	if cacheErr := t.cacheManager.UpdateDesktopDatabase(appsDir, t.Log); cacheErr != nil {
@@ -379,25 +795,287 @@ func (t *TarballBackend) Uninstall(_ context.Context, record *core.InstallRecord
 		Str("install_id", record.InstallID).
 		Msg("tarball/zip package uninstalled successfully")
 
-	return nil
+	return result.Finish(), nil
+}
+
+// streamTools lists, for the archive types Go cannot decode, the commands
+// that write the archive as a tar stream to stdout, in order of preference.
+// The archive is read from stdin, except by bsdtar for 7z, which must seek.
+var streamTools = map[string][][]string{
+	"tar.zst": {{"zstd", "-dcq"}, {"bsdtar", "-cf", "-", "--format", "pax", "@-"}},
+	"tar.lz4": {{"lz4", "-dcq"}, {"bsdtar", "-cf", "-", "--format", "pax", "@-"}},
+	"7z":      {{"bsdtar", "-cf", "-", "--format", "pax"}},
 }
 
-// extractArchive extracts an archive to a directory
-func (t *TarballBackend) extractArchive(archivePath, destDir, archiveType string) error {
+// extractArchive extracts an archive to a directory, enforcing the configured size quota and limits
+func (t *TarballBackend) extractArchive(ctx context.Context, archivePath, destDir, archiveType string, extra ...helpers.ExtractOption) error {
+	extractOpts := append(t.ExtractOptions(), extra...)
+
+	var err error
 	switch archiveType {
 	case "tar.gz":
-		return helpers.ExtractTarGz(archivePath, destDir)
+		err = helpers.ExtractTarGz(archivePath, destDir, extractOpts...)
 	case "tar.xz":
-		return helpers.ExtractTarXz(archivePath, destDir)
+		err = helpers.ExtractTarXz(archivePath, destDir, extractOpts...)
 	case "tar.bz2":
-		return helpers.ExtractTarBz2(archivePath, destDir)
+		err = helpers.ExtractTarBz2(archivePath, destDir, extractOpts...)
 	case "tar":
-		return helpers.ExtractTar(archivePath, destDir)
+		err = helpers.ExtractTar(archivePath, destDir, extractOpts...)
 	case "zip":
-		return helpers.ExtractZip(archivePath, destDir)
+		err = helpers.ExtractZip(archivePath, destDir, extractOpts...)
+	case "tar.zst", "tar.lz4", "7z":
+		err = t.extractWithTool(ctx, archivePath, destDir, archiveType, extractOpts)
 	default:
 		return fmt.Errorf("unsupported archive type: %s", archiveType)
 	}
+
+	return helpers.ClassifyExtractError(archivePath, err)
+}
+
+// extractWithTool extracts a zstd, lz4 or 7z archive through the first
+// installed tool of streamTools
+func (t *TarballBackend) extractWithTool(ctx context.Context, archivePath, destDir, archiveType string, opts []helpers.ExtractOption) error {
+	var names []string
+	for _, tool := range streamTools[archiveType] {
+		names = append(names, tool[0])
+		if !t.Runner.CommandExists(tool[0]) {
+			continue
+		}
+
+		args := tool[1:]
+		if archiveType == "7z" {
+			args = append(append([]string{}, args...), "@"+archivePath)
+		}
+		t.Log.Debug().Str("tool", tool[0]).Str("archive", archivePath).Msg("extracting archive with external tool")
+		cmd := t.Runner.PrepareCommand(ctx, tool[0], args...)
+		if archiveType == "7z" {
+			// bsdtar reads the archive by path; nothing is fed on stdin
+			cmd.Stdin = strings.NewReader("")
+		}
+		return helpers.ExtractTarCommand(cmd, archivePath, destDir, opts...)
+	}
+	return fmt.Errorf("extracting %s archives requires %s: %w", archiveType, strings.Join(names, " or "), t.MissingTool(names[0], t.RequiredTools()))
+}
+
+// exposeBundledBins symlinks every executable found in the payload's bin/
+// directories into binDir. Existing files in binDir are never overwritten.
+func (t *TarballBackend) exposeBundledBins(installDir, binDir, wrapperPath string) ([]string, error) {
+	linker, ok := t.Fs.(afero.Linker)
+	if !ok {
+		return nil, fmt.Errorf("filesystem does not support symlinks")
+	}
+
+	binDirs := t.findBundledBinDirs(installDir)
+	if len(binDirs) == 0 {
+		return nil, fmt.Errorf("no bin/ directory found in %s", installDir)
+	}
+
+	var created []string
+	seen := make(map[string]bool)
+	for _, dir := range binDirs {
+		entries, err := afero.ReadDir(t.Fs, dir)
+		if err != nil {
+			t.Log.Debug().Err(err).Str("dir", dir).Msg("failed to read bin directory")
+			continue
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			src := filepath.Join(dir, name)
+
+			// Stat follows symlinks so launcher links (e.g. npm -> ../lib/...) are included
+			info, err := t.Fs.Stat(src)
+			if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+				continue
+			}
+
+			dst := filepath.Join(binDir, name)
+			if seen[name] || dst == wrapperPath {
+				t.Log.Debug().Str("name", name).Msg("skipping duplicate bundled binary")
+				continue
+			}
+			if _, err := t.Fs.Stat(dst); err == nil {
+				t.Log.Warn().Str("path", dst).Msg("skipping bundled binary: target already exists")
+				continue
+			}
+			if lstater, ok := t.Fs.(afero.Lstater); ok {
+				if _, _, err := lstater.LstatIfPossible(dst); err == nil {
+					t.Log.Warn().Str("path", dst).Msg("skipping bundled binary: dangling link already exists")
+					continue
+				}
+			}
+
+			if err := linker.SymlinkIfPossible(src, dst); err != nil {
+				return created, fmt.Errorf("failed to link %s: %w", name, err)
+			}
+			seen[name] = true
+			created = append(created, dst)
+		}
+	}
+
+	t.Log.Debug().
+		Strs("links", created).
+		Msg("exposed bundled binaries")
+
+	return created, nil
+}
+
+// extraLauncher is the wrapper of an executable selected with --bin besides
+// the primary one
+type extraLauncher struct {
+	name    string // Wrapper file name
+	exec    string
+	wrapper string
+}
+
+// selectBins resolves a --bin selection against the executables found in the
+// payload. BinsAll keeps every executable (one per file name) after primary;
+// otherwise each name must match an executable's file name and the first one
+// becomes the primary executable.
+func selectBins(executables, bins []string, primary string) ([]string, error) {
+	if slices.Contains(bins, core.BinsAll) {
+		if len(bins) > 1 {
+			return nil, fmt.Errorf("--bin %s cannot be combined with executable names", core.BinsAll)
+		}
+		selected := []string{primary}
+		seen := map[string]bool{filepath.Base(primary): true}
+		for _, exec := range executables {
+			if name := filepath.Base(exec); !seen[name] {
+				seen[name] = true
+				selected = append(selected, exec)
+			}
+		}
+		return selected, nil
+	}
+
+	var selected []string
+	for _, name := range bins {
+		exec := primary
+		if filepath.Base(primary) != name {
+			i := slices.IndexFunc(executables, func(path string) bool { return filepath.Base(path) == name })
+			if i < 0 {
+				names := make([]string, 0, len(executables))
+				for _, path := range executables {
+					names = append(names, filepath.Base(path))
+				}
+				return nil, fmt.Errorf("executable %q not found in package (available: %s)", name, strings.Join(names, ", "))
+			}
+			exec = executables[i]
+		}
+		if !slices.Contains(selected, exec) {
+			selected = append(selected, exec)
+		}
+	}
+	return selected, nil
+}
+
+// createExtraLaunchers writes a wrapper named after each of execs. Names
+// already taken in the bin directory are only replaced with --force.
+func (t *TarballBackend) createExtraLaunchers(execs []string, primaryWrapper, installDir string, opts core.InstallOptions, result *core.InstallResult) []extraLauncher {
+	// Wrapper args and env are meant for the main app
+	opts.WrapperArgs, opts.WrapperEnv = nil, nil
+
+	var launchers []extraLauncher
+	for _, exec := range execs {
+		name := helpers.NormalizeFilename(filepath.Base(exec))
+		if err := security.ValidatePackageName(name); err != nil {
+			result.Warn("launcher for %s not created: %v", filepath.Base(exec), err)
+			continue
+		}
+		wrapperPath := filepath.Join(t.Paths.GetBinDir(), name)
+		if wrapperPath == primaryWrapper {
+			continue
+		}
+		if !opts.Force && t.pathExists(wrapperPath) {
+			t.Log.Warn().Str("path", wrapperPath).Msg("skipping launcher: target already exists")
+			result.Warn("launcher %s not created: %s already exists (use --force to replace it)", name, wrapperPath)
+			continue
+		}
+
+		wrapper, _, err := t.Integration().CreateLauncher(name, exec, opts, installDir)
+		if err != nil {
+			t.Log.Warn().Err(err).Str("executable", exec).Msg("failed to create launcher")
+			result.Warn("launcher %s not created: %v", name, err)
+			continue
+		}
+		launchers = append(launchers, extraLauncher{name: name, exec: exec, wrapper: wrapper})
+	}
+
+	t.Log.Debug().
+		Int("count", len(launchers)).
+		Msg("created extra launchers")
+
+	return launchers
+}
+
+// createExtraDesktopFiles writes a desktop entry for each extra launcher
+func (t *TarballBackend) createExtraDesktopFiles(launchers []extraLauncher, appName, normalizedName string, opts core.InstallOptions, result *core.InstallResult) []string {
+	engine := t.Integration()
+	var paths []string
+	for _, launcher := range launchers {
+		toolkit, wayland := heuristics.DetectApp(t.Fs, "", launcher.exec)
+		path, err := engine.WriteDesktopEntry(integration.DesktopSpec{
+			AppName:        fmt.Sprintf("%s (%s)", appName, launcher.name),
+			FileName:       normalizedName + "-" + launcher.name,
+			ExecPath:       launcher.wrapper,
+			IconName:       normalizedName,
+			DefaultComment: fmt.Sprintf("%s from %s", launcher.name, appName),
+			Toolkit:        toolkit,
+			Wayland:        wayland,
+		}, opts)
+		if err != nil {
+			t.Log.Warn().Err(err).Str("launcher", launcher.name).Msg("failed to create desktop file")
+			result.Warn("desktop entry for %s not created: %v", launcher.name, err)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// findBundledBinDirs returns bin/ directories at the top level or one level below installDir
+func (t *TarballBackend) findBundledBinDirs(installDir string) []string {
+	var dirs []string
+	candidates := []string{filepath.Join(installDir, "bin")}
+
+	entries, err := afero.ReadDir(t.Fs, installDir)
+	if err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				candidates = append(candidates, filepath.Join(installDir, entry.Name(), "bin"))
+			}
+		}
+	}
+
+	for _, dir := range candidates {
+		if info, err := t.Fs.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// removeExposedBins removes symlinks created by exposeBundledBins that still point into installDir
+func (t *TarballBackend) removeExposedBins(links []string, installDir string) {
+	reader, ok := t.Fs.(afero.LinkReader)
+	for _, link := range links {
+		if link == "" {
+			continue
+		}
+		if ok && installDir != "" {
+			target, err := reader.ReadlinkIfPossible(link)
+			if err != nil {
+				continue
+			}
+			if within, _ := security.IsPathWithinDirectory(target, installDir); !within {
+				t.Log.Warn().Str("path", link).Msg("not removing exposed binary: link was replaced")
+				continue
+			}
+		}
+		if err := t.Fs.Remove(link); err != nil {
+			t.Log.Warn().Err(err).Str("path", link).Msg("failed to remove exposed binary")
+		}
+	}
 }
 
 // cleanAppName removes version numbers, architecture, and platform suffixes
@@ -408,13 +1086,6 @@ func (t *TarballBackend) extractArchive(archivePath, destDir, archiveType string
 
 // installIcons installs icons from the extracted directory
 func (t *TarballBackend) installIcons(installDir, normalizedName string) ([]string, error) {
-	homeDir := t.Paths.HomeDir()
-	if homeDir == "" {
-		return nil, fmt.Errorf("failed to get home directory")
-	}
-
-	installedIcons := []string{}
-
 	// Discover icons from regular filesystem
 	discoveredIcons := icons.DiscoverIcons(installDir)
 
@@ -433,21 +1104,7 @@ func (t *TarballBackend) installIcons(installDir, normalizedName string) ([]stri
 		discoveredIcons = append(discoveredIcons, asarIcons...)
 	}
 
-	// Install each icon
-	for _, iconFile := range discoveredIcons {
-		targetPath, err := icons.InstallIcon(iconFile, normalizedName, homeDir)
-		if err != nil {
-			t.Log.Warn().
-				Err(err).
-				Str("icon", iconFile.Path).
-				Msg("failed to install icon")
-			continue
-		}
-
-		installedIcons = append(installedIcons, targetPath)
-	}
-
-	return installedIcons, nil
+	return t.Integration().InstallIcons(discoveredIcons, normalizedName)
 }
 
 // extractIconsFromAsarNative extracts icons using native Go ASAR library
@@ -455,7 +1112,7 @@ func (t *TarballBackend) installIcons(installDir, normalizedName string) ([]stri
 // Returns extracted icons and any error encountered
 //
 //nolint:gocyclo // ASAR extraction handles multiple filesystem and naming cases.
-func (t *TarballBackend) extractIconsFromAsarNative(asarPath, installDir, _ string) ([]core.IconFile, error) {
+func (t *TarballBackend) extractIconsFromAsarNative(asarPath, installDir, normalizedName string) ([]core.IconFile, error) {
 	t.Log.Debug().
 		Str("asar", asarPath).
 		Msg("extracting icons using native Go ASAR library")
@@ -478,7 +1135,7 @@ func (t *TarballBackend) extractIconsFromAsarNative(asarPath, installDir, _ stri
 	}
 
 	// Create temporary directory for extracted icons
-	tempDir, err := afero.TempDir(t.Fs, "", "upkg-asar-icons-*")
+	tempDir, err := helpers.CreateTempDir(t.Fs, normalizedName, "asar-icons")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temp dir: %w", err)
 	}
@@ -671,7 +1328,7 @@ func (t *TarballBackend) extractIconsFromAsar(installDir, normalizedName string)
 			Msg("attempting to extract icons using npx fallback")
 
 		// Create temporary directory for extraction
-		tempDir, err := afero.TempDir(t.Fs, "", "upkg-asar-*")
+		tempDir, err := helpers.CreateTempDir(t.Fs, normalizedName, "asar")
 		if err != nil {
 			t.Log.Warn().Err(err).Msg("failed to create temp dir for asar extraction")
 			continue
@@ -770,106 +1427,27 @@ func (t *TarballBackend) copyFile(src, dst string) (err error) {
 
 // removeIcons removes installed icons
 func (t *TarballBackend) removeIcons(iconPaths []string) {
-	for _, iconPath := range iconPaths {
-		if err := t.Fs.Remove(iconPath); err != nil {
-			t.Log.Warn().
-				Err(err).
-				Str("path", iconPath).
-				Msg("failed to remove icon")
-		}
-	}
+	t.Integration().RemoveFiles(iconPaths)
 }
 
 // createDesktopFile creates a .desktop file
 //
 //nolint:gocyclo // desktop generation handles multiple discovery and environment cases.
-func (t *TarballBackend) createDesktopFile(installDir, appName, normalizedName, execPath string, opts core.InstallOptions) (string, error) {
-	appsDir := t.Paths.GetAppsDir()
-	if err := t.Fs.MkdirAll(appsDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create applications directory: %w", err)
-	}
-
-	desktopFilePath := filepath.Join(appsDir, normalizedName+".desktop")
-
-	// Try to find existing .desktop file in installDir
-	var entry *core.DesktopEntry
-	desktopFiles, globErr := afero.Glob(t.Fs, filepath.Join(installDir, "*.desktop"))
-	if globErr != nil {
-		t.Log.Debug().Err(globErr).Str("dir", installDir).Msg("failed to glob desktop files")
-	}
-	if len(desktopFiles) > 0 {
-		file, err := t.Fs.Open(desktopFiles[0])
-		if err == nil {
-			defer func() {
-				if closeErr := file.Close(); closeErr != nil {
-					t.Log.Debug().Err(closeErr).Str("desktop_file", desktopFiles[0]).Msg("failed to close desktop file")
-				}
-			}()
-			if parsed, parseErr := desktop.Parse(file); parseErr == nil {
-				entry = parsed
-			} else {
-				t.Log.Debug().Err(parseErr).Str("desktop_file", desktopFiles[0]).Msg("failed to parse desktop file")
-			}
-		}
-	}
-
-	// Create default entry if not found
-	if entry == nil {
-		entry = &core.DesktopEntry{
-			Type:    "Application",
-			Version: "1.5",
-			Name:    appName,
-			Comment: fmt.Sprintf("%s application", appName),
-			Icon:    normalizedName,
-		}
-	}
-
-	// Update Exec to point to wrapper
-	entry.Exec = execPath + " %U"
-
-	// Set icon
-	entry.Icon = normalizedName
-
-	// Ensure categories
-	if len(entry.Categories) == 0 {
-		entry.Categories = []string{"Utility"}
-	}
-
-	// Inject Wayland environment variables
-	if t.Cfg.Desktop.WaylandEnvVars && !opts.SkipWaylandEnv {
-		if err := desktop.InjectWaylandEnvVars(entry, t.Cfg.Desktop.CustomEnvVars); err != nil {
-			t.Log.Warn().
-				Err(err).
-				Str("app", appName).
-				Msg("invalid custom Wayland env vars, injecting defaults only")
-			if fallbackErr := desktop.InjectWaylandEnvVars(entry, nil); fallbackErr != nil {
-				t.Log.Warn().Err(fallbackErr).Str("app", appName).Msg("failed to inject default Wayland env vars")
-			}
-		}
-	}
-
-	var buf bytes.Buffer
-	if err := desktop.Write(&buf, entry); err != nil {
-		return "", err
-	}
-	if err := afero.WriteFile(t.Fs, desktopFilePath, buf.Bytes(), 0644); err != nil {
-		return "", err
-	}
-
-	// Validate
-	if t.Runner.CommandExists("desktop-file-validate") {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		if _, err := t.Runner.RunCommand(ctx, "desktop-file-validate", desktopFilePath); err != nil {
-			t.Log.Warn().
-				Err(err).
-				Str("desktop_file", desktopFilePath).
-				Msg("desktop file validation failed")
-		}
+func (t *TarballBackend) createDesktopFile(installDir, appName, normalizedName, execPath string, wayland core.WaylandSupport, opts core.InstallOptions) (string, error) {
+	engine := t.Integration()
+	spec := integration.DesktopSpec{
+		AppName:        appName,
+		FileName:       normalizedName,
+		ExecPath:       execPath,
+		IconName:       normalizedName,
+		SourceDesktop:  engine.FindDesktopFile(filepath.Join(installDir, "*.desktop")),
+		DefaultComment: fmt.Sprintf("%s application", appName),
+		PayloadRoot:    installDir,
+		Toolkit:        heuristics.DetectFramework(t.Fs, installDir, execPath),
+		Wayland:        wayland,
 	}
 
-	return desktopFilePath, nil
+	return engine.WriteDesktopEntry(spec, opts)
 }
 
 // No local helper functions - using shared helpers from internal/helpers/common.go
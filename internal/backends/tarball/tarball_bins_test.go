@@ -0,0 +1,119 @@
+package tarball
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createMultiBinFolder lays out an app shipping a GUI and a CLI executable
+func createMultiBinFolder(t *testing.T, dir string) {
+	t.Helper()
+
+	createAppFolder(t, dir)
+	elf, err := os.ReadFile(filepath.Join(dir, "vendor-app"))
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bin", "vendor-cli"), elf, 0755))
+}
+
+func TestSelectBins(t *testing.T) {
+	t.Parallel()
+
+	executables := []string{"/app/bin/cli", "/app/gui", "/app/helpers/cli", "/app/helpers/crash-reporter"}
+
+	tests := []struct {
+		name    string
+		bins    []string
+		want    []string
+		wantErr string
+	}{
+		{"all keeps primary first", []string{core.BinsAll}, []string{"/app/gui", "/app/bin/cli", "/app/helpers/crash-reporter"}, ""},
+		{"first name becomes primary", []string{"cli", "gui"}, []string{"/app/bin/cli", "/app/gui"}, ""},
+		{"duplicates ignored", []string{"gui", "gui"}, []string{"/app/gui"}, ""},
+		{"unknown name", []string{"gui", "server"}, nil, `executable "server" not found`},
+		{"all with names", []string{core.BinsAll, "cli"}, nil, "cannot be combined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := selectBins(executables, tt.bins, "/app/gui")
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestInstall_BinsCreatesLaunchers(t *testing.T) {
+	backend, home := newDirTestBackend(t)
+	source := filepath.Join(t.TempDir(), "Vendor-App-2.1.0")
+	createMultiBinFolder(t, source)
+
+	opts := core.InstallOptions{Bins: []string{"vendor-app", "vendor-cli"}, BinDesktops: true}
+	result, err := backend.Install(context.Background(), source, opts, nil)
+	require.NoError(t, err)
+	record := result.Record
+
+	binDir := filepath.Join(home, ".local", "bin")
+	installDir := filepath.Join(home, ".local", "share", "upkg", "apps", "vendor-app")
+	assert.Equal(t, filepath.Join(binDir, "vendor-app"), record.Metadata.WrapperScript)
+	require.Equal(t, []string{filepath.Join(binDir, "vendor-cli")}, record.Metadata.ExtraWrappers)
+	wrapper, err := os.ReadFile(record.Metadata.ExtraWrappers[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(wrapper), filepath.Join(installDir, "bin", "vendor-cli"))
+
+	require.Len(t, record.Metadata.DesktopFiles, 2)
+	assert.Equal(t, record.DesktopFile, record.Metadata.DesktopFiles[0])
+	entry, err := os.ReadFile(record.Metadata.DesktopFiles[1])
+	require.NoError(t, err)
+	assert.Contains(t, string(entry), "Name=Vendor App (vendor-cli)")
+	assert.Equal(t, opts.Bins, record.Metadata.Bins)
+
+	_, err = backend.Uninstall(context.Background(), record)
+	require.NoError(t, err)
+	for _, path := range append(record.Metadata.ExtraWrappers, record.Metadata.DesktopFiles...) {
+		assert.NoFileExists(t, path)
+	}
+}
+
+func TestInstall_BinsKeepsExistingCommands(t *testing.T) {
+	backend, home := newDirTestBackend(t)
+	source := filepath.Join(t.TempDir(), "Vendor-App-2.1.0")
+	createMultiBinFolder(t, source)
+
+	taken := filepath.Join(home, ".local", "bin", "vendor-cli")
+	require.NoError(t, os.MkdirAll(filepath.Dir(taken), 0755))
+	require.NoError(t, os.WriteFile(taken, []byte("#!/bin/sh\n"), 0755))
+
+	result, err := backend.Install(context.Background(), source, core.InstallOptions{Bins: []string{core.BinsAll}}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.Record.Metadata.ExtraWrappers)
+	assert.NotEmpty(t, result.Warnings)
+
+	content, err := os.ReadFile(taken)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\n", string(content))
+}
+
+func TestInstall_BinsUnknownName(t *testing.T) {
+	backend, home := newDirTestBackend(t)
+	source := filepath.Join(t.TempDir(), "Vendor-App-2.1.0")
+	createMultiBinFolder(t, source)
+
+	_, err := backend.Install(context.Background(), source, core.InstallOptions{Bins: []string{"server"}}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "vendor-cli")
+	assert.NoDirExists(t, filepath.Join(home, ".local", "share", "upkg", "apps", "vendor-app"))
+}
@@ -0,0 +1,65 @@
+package tarball
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/store"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstall_ReusesCachedPayload(t *testing.T) {
+	backend, home := newDirTestBackend(t)
+	backend.Cfg.Cache.Payloads = true
+
+	// Not a valid archive: the install only succeeds without extracting it
+	archive := filepath.Join(t.TempDir(), "vendor-app-2.1.0.tar.gz")
+	require.NoError(t, os.WriteFile(archive, []byte("archive bytes"), 0644))
+	hash, err := store.HashFile(afero.NewOsFs(), archive)
+	require.NoError(t, err)
+
+	payloads := store.New(afero.NewOsFs(), backend.Paths.GetPayloadCacheDir())
+	staged := filepath.Join(t.TempDir(), "payload")
+	createAppFolder(t, staged)
+	require.NoError(t, payloads.Put(staged, store.Entry{Hash: hash, Name: "Vendor App"}))
+
+	result, err := backend.Install(context.Background(), archive, core.InstallOptions{CustomName: "Renamed App"}, nil)
+	require.NoError(t, err)
+	record := result.Record
+
+	installDir := filepath.Join(home, ".local", "share", "upkg", "apps", "renamed-app")
+	assert.Equal(t, installDir, record.InstallPath)
+	assert.Equal(t, hash, record.Metadata.ContentHash)
+	assert.FileExists(t, filepath.Join(installDir, "resources", "data.bin"))
+	assert.Contains(t, result.Skipped, "extraction: reused the cached payload of an identical archive")
+	assert.False(t, payloads.Has(hash), "the payload moved out of the cache")
+}
+
+func TestInstall_ReturnsCachedPayloadOnRollback(t *testing.T) {
+	backend, _ := newDirTestBackend(t)
+	backend.Cfg.Cache.Payloads = true
+
+	archive := filepath.Join(t.TempDir(), "vendor-app.tar.gz")
+	require.NoError(t, os.WriteFile(archive, []byte("archive bytes"), 0644))
+	hash, err := store.HashFile(afero.NewOsFs(), archive)
+	require.NoError(t, err)
+
+	payloads := store.New(afero.NewOsFs(), backend.Paths.GetPayloadCacheDir())
+	staged := filepath.Join(t.TempDir(), "payload")
+	createAppFolder(t, staged)
+	require.NoError(t, payloads.Put(staged, store.Entry{Hash: hash}))
+
+	tx := transaction.NewManager(backend.Log)
+	result, err := backend.Install(context.Background(), archive, core.InstallOptions{}, tx)
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+
+	assert.NoDirExists(t, result.Record.InstallPath)
+	assert.True(t, payloads.Has(hash), "a rolled back install keeps the payload for the next attempt")
+}
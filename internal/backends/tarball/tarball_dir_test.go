@@ -0,0 +1,186 @@
+package tarball
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createAppFolder lays out a vendor-style unpacked application with a real
+// ELF launcher and an icon
+func createAppFolder(t *testing.T, dir string) {
+	t.Helper()
+
+	self, err := os.Executable()
+	require.NoError(t, err)
+	elf, err := os.ReadFile(self)
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "resources"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "vendor-app"), elf, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "resources", "data.bin"), []byte("data"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "vendor-app.svg"), []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), 0644))
+}
+
+func newDirTestBackend(t *testing.T) (*TarballBackend, string) {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	logger := zerolog.New(io.Discard)
+	runner := &helpers.MockCommandRunner{
+		CommandExistsFunc: func(string) bool { return false },
+	}
+	return NewWithDeps(&config.Config{}, &logger, afero.NewOsFs(), runner), home
+}
+
+func TestInstall_FromDirCopies(t *testing.T) {
+	backend, home := newDirTestBackend(t)
+	source := filepath.Join(t.TempDir(), "Vendor-App-2.1.0")
+	createAppFolder(t, source)
+
+	result, err := backend.Install(context.Background(), source, core.InstallOptions{}, nil)
+	require.NoError(t, err)
+	record := result.Record
+
+	installDir := filepath.Join(home, ".local", "share", "upkg", "apps", "vendor-app")
+	assert.Equal(t, installDir, record.InstallPath)
+	assert.Equal(t, source, record.OriginalFile)
+
+	info, err := os.Lstat(installDir)
+	require.NoError(t, err)
+	assert.True(t, info.IsDir(), "the folder is copied by default")
+	assert.FileExists(t, filepath.Join(installDir, "resources", "data.bin"))
+	assert.FileExists(t, record.Metadata.WrapperScript)
+	assert.NotEmpty(t, record.DesktopFile)
+
+	wrapper, err := os.ReadFile(record.Metadata.WrapperScript)
+	require.NoError(t, err)
+	assert.Contains(t, string(wrapper), filepath.Join(installDir, "vendor-app"))
+}
+
+func TestInstall_FromDirReportsProgress(t *testing.T) {
+	backend, _ := newDirTestBackend(t)
+	source := filepath.Join(t.TempDir(), "Vendor-App-2.1.0")
+	createAppFolder(t, source)
+
+	var events []ui.ProgressEvent
+	ctx := ui.WithProgressSink(context.Background(), func(e ui.ProgressEvent) { events = append(events, e) })
+	_, err := backend.Install(ctx, source, core.InstallOptions{}, nil)
+	require.NoError(t, err)
+
+	var started []string
+	var copied bool
+	for _, event := range events {
+		switch event.Type {
+		case ui.ProgressPhaseStarted:
+			started = append(started, event.Phase)
+		case ui.ProgressPercent:
+			copied = copied || event.Phase == "Copying application folder"
+		}
+	}
+	assert.Equal(t, []string{"Copying application folder", "Creating launcher", "Installing icons", "Configuring desktop"}, started)
+	assert.True(t, copied, "the copy reports byte progress")
+	assert.Equal(t, ui.ProgressFinished, events[len(events)-1].Type)
+}
+
+func TestInstall_FromDirLink(t *testing.T) {
+	backend, home := newDirTestBackend(t)
+	source := filepath.Join(t.TempDir(), "vendor-app")
+	createAppFolder(t, source)
+
+	tx := transaction.NewManager(backend.Log)
+	result, err := backend.Install(context.Background(), source, core.InstallOptions{LinkDir: true}, tx)
+	require.NoError(t, err)
+	tx.Commit()
+
+	installDir := filepath.Join(home, ".local", "share", "upkg", "apps", "vendor-app")
+	target, err := os.Readlink(installDir)
+	require.NoError(t, err)
+	assert.Equal(t, source, target)
+
+	wrapper, err := os.ReadFile(result.Record.Metadata.WrapperScript)
+	require.NoError(t, err)
+	assert.Contains(t, string(wrapper), filepath.Join(installDir, "vendor-app"), "the wrapper launches through the owned link")
+
+	// Uninstalling drops the link and leaves the user's folder alone
+	_, err = backend.Uninstall(context.Background(), result.Record)
+	require.NoError(t, err)
+	_, err = os.Lstat(installDir)
+	assert.True(t, os.IsNotExist(err))
+	assert.FileExists(t, filepath.Join(source, "vendor-app"))
+}
+
+func TestInstall_FromDirRejectsAppsDir(t *testing.T) {
+	backend, home := newDirTestBackend(t)
+	source := filepath.Join(home, ".local", "share", "upkg", "apps", "vendor-app")
+	createAppFolder(t, source)
+
+	_, err := backend.Install(context.Background(), source, core.InstallOptions{Force: true}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already inside the apps directory")
+	assert.FileExists(t, filepath.Join(source, "vendor-app"))
+}
+
+func TestInstall_FromDirNoExecutables(t *testing.T) {
+	backend, _ := newDirTestBackend(t)
+	source := filepath.Join(t.TempDir(), "docs")
+	require.NoError(t, os.MkdirAll(source, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(source, "README"), []byte("docs"), 0644))
+
+	_, err := backend.Install(context.Background(), source, core.InstallOptions{LinkDir: true}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no executables found in docs")
+	assert.FileExists(t, filepath.Join(source, "README"))
+}
+
+func TestInstall_FromDirVersionedLayout(t *testing.T) {
+	backend, home := newDirTestBackend(t)
+	backend.Cfg.Upgrade.VersionedLayout = true
+	parent := t.TempDir()
+	source := filepath.Join(parent, "Vendor-App-2.1.0")
+	createAppFolder(t, source)
+
+	result, err := backend.Install(context.Background(), source, core.InstallOptions{}, nil)
+	require.NoError(t, err)
+
+	installDir := filepath.Join(home, ".local", "share", "upkg", "apps", "vendor-app")
+	assert.Equal(t, installDir, result.Record.InstallPath)
+	assert.Equal(t, "2.1.0", result.Record.Metadata.Release)
+	target, err := os.Readlink(filepath.Join(installDir, "current"))
+	require.NoError(t, err)
+	assert.Equal(t, "2.1.0", target)
+	wrapper, err := os.ReadFile(result.Record.Metadata.WrapperScript)
+	require.NoError(t, err)
+	assert.Contains(t, string(wrapper), filepath.Join(installDir, "current", "vendor-app"), "the wrapper launches through the current link")
+
+	// A new release is added beside the old one; rolling back switches back
+	newSource := filepath.Join(parent, "Vendor-App-2.2.0")
+	createAppFolder(t, newSource)
+	tx := transaction.NewManager(backend.Log)
+	result, err = backend.Install(context.Background(), newSource, core.InstallOptions{Force: true, CustomName: "Vendor App"}, tx)
+	require.NoError(t, err)
+	assert.Equal(t, "2.2.0", result.Record.Metadata.Release)
+	target, err = os.Readlink(filepath.Join(installDir, "current"))
+	require.NoError(t, err)
+	assert.Equal(t, "2.2.0", target)
+	assert.FileExists(t, filepath.Join(installDir, "2.1.0", "vendor-app"))
+
+	require.NoError(t, tx.Rollback())
+	target, err = os.Readlink(filepath.Join(installDir, "current"))
+	require.NoError(t, err)
+	assert.Equal(t, "2.1.0", target)
+	assert.NoDirExists(t, filepath.Join(installDir, "2.2.0"))
+}
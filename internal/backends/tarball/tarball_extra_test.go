@@ -12,6 +12,7 @@ import (
 	"github.com/quantmind-br/upkg/internal/helpers"
 	"github.com/quantmind-br/upkg/internal/paths"
 	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/quantmind-br/upkg/internal/wrapper"
 	"github.com/rs/zerolog"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
@@ -25,7 +26,8 @@ func TestTarballBackend_Install_PackageNotFound(t *testing.T) {
 	backend := New(cfg, &logger)
 	tx := transaction.NewManager(&logger)
 
-	record, err := backend.Install(context.Background(), "/nonexistent.tar.gz", core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), "/nonexistent.tar.gz", core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "package not found")
@@ -43,7 +45,8 @@ func TestTarballBackend_Install_InvalidArchive(t *testing.T) {
 	archivePath := filepath.Join(tmpDir, "test.tar.gz")
 	require.NoError(t, os.WriteFile(archivePath, []byte("invalid archive"), 0644))
 
-	record, err := backend.Install(context.Background(), archivePath, core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), archivePath, core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to extract archive")
@@ -118,7 +121,7 @@ func TestTarballBackend_Uninstall(t *testing.T) {
 			},
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.NoError(t, err)
 
 		// Verify files are removed
@@ -145,7 +148,7 @@ func TestTarballBackend_Uninstall(t *testing.T) {
 			},
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.NoError(t, err)
 	})
 
@@ -160,7 +163,7 @@ func TestTarballBackend_Uninstall(t *testing.T) {
 			PackageType: core.PackageTypeTarball,
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.NoError(t, err)
 	})
 }
@@ -291,12 +294,12 @@ func TestTarballBackend_CreateWrapper(t *testing.T) {
 		wrapperPath := filepath.Join(tmpDir, "test-wrapper")
 		execPath := "/path/to/executable"
 
-		wrapperCfg := helpers.WrapperConfig{
+		wrapperCfg := wrapper.Config{
 			WrapperPath:    wrapperPath,
 			ExecPath:       execPath,
 			DisableSandbox: false,
 		}
-		err := helpers.CreateWrapper(backend.Fs, wrapperCfg)
+		err := wrapper.Create(backend.Fs, wrapperCfg)
 		assert.NoError(t, err)
 
 		// Verify wrapper was created
@@ -328,12 +331,12 @@ func TestTarballBackend_CreateWrapper(t *testing.T) {
 		execPath := filepath.Join(execDir, "app")
 		wrapperPath := filepath.Join(tmpDir, "wrapper")
 
-		wrapperCfg := helpers.WrapperConfig{
+		wrapperCfg := wrapper.Config{
 			WrapperPath:    wrapperPath,
 			ExecPath:       execPath,
 			DisableSandbox: true,
 		}
-		err := helpers.CreateWrapper(backend.Fs, wrapperCfg)
+		err := wrapper.Create(backend.Fs, wrapperCfg)
 		assert.NoError(t, err)
 
 		content, err := os.ReadFile(wrapperPath)
@@ -360,7 +363,7 @@ func TestTarballBackend_IsElectronApp(t *testing.T) {
 
 		execPath := filepath.Join(execDir, "app")
 
-		isElectron := helpers.IsElectronApp(backend.Fs, execPath)
+		isElectron := wrapper.IsElectronApp(backend.Fs, execPath)
 		assert.True(t, isElectron)
 	})
 
@@ -373,7 +376,7 @@ func TestTarballBackend_IsElectronApp(t *testing.T) {
 		execPath := filepath.Join(tmpDir, "app")
 		require.NoError(t, os.WriteFile(execPath, []byte("#!/bin/bash"), 0755))
 
-		isElectron := helpers.IsElectronApp(backend.Fs, execPath)
+		isElectron := wrapper.IsElectronApp(backend.Fs, execPath)
 		assert.False(t, isElectron)
 	})
 
@@ -392,7 +395,7 @@ func TestTarballBackend_IsElectronApp(t *testing.T) {
 
 		execPath := filepath.Join(execDir, "app")
 
-		isElectron := helpers.IsElectronApp(backend.Fs, execPath)
+		isElectron := wrapper.IsElectronApp(backend.Fs, execPath)
 		assert.True(t, isElectron)
 	})
 }
@@ -405,7 +408,7 @@ func TestTarballBackend_ExtractArchive(t *testing.T) {
 		cfg := &config.Config{}
 		backend := New(cfg, &logger)
 
-		err := backend.extractArchive("/path/to/file", "/tmp/dest", "unsupported")
+		err := backend.extractArchive(context.Background(), "/path/to/file", "/tmp/dest", "unsupported")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "unsupported archive type")
 	})
@@ -498,7 +501,7 @@ func TestTarballBackend_CreateDesktopFile(t *testing.T) {
 		execPath := filepath.Join(installDir, "app")
 		require.NoError(t, os.WriteFile(execPath, []byte("#!/bin/bash"), 0755))
 
-		desktopPath, err := backend.createDesktopFile(installDir, "TestApp", "test-app", execPath, core.InstallOptions{})
+		desktopPath, err := backend.createDesktopFile(installDir, "TestApp", "test-app", execPath, core.WaylandUnknown, core.InstallOptions{})
 
 		assert.NoError(t, err)
 		assert.NotEmpty(t, desktopPath)
@@ -528,7 +531,7 @@ func TestTarballBackend_CreateDesktopFile(t *testing.T) {
 		execPath := filepath.Join(installDir, "app")
 		require.NoError(t, os.WriteFile(execPath, []byte("#!/bin/bash"), 0755))
 
-		desktopPath, err := backend.createDesktopFile(installDir, "TestApp", "test-app", execPath, core.InstallOptions{})
+		desktopPath, err := backend.createDesktopFile(installDir, "TestApp", "test-app", execPath, core.WaylandUnknown, core.InstallOptions{})
 
 		assert.NoError(t, err)
 		assert.FileExists(t, desktopPath)
@@ -724,12 +727,12 @@ func TestTarballBackend_CreateDesktopFile_Additional(t *testing.T) {
 
 		// First create wrapper to check it has --no-sandbox
 		wrapperPath := filepath.Join(tmpDir, "wrapper")
-		wrapperCfg := helpers.WrapperConfig{
+		wrapperCfg := wrapper.Config{
 			WrapperPath:    wrapperPath,
 			ExecPath:       execPath,
 			DisableSandbox: true,
 		}
-		err := helpers.CreateWrapper(backend.Fs, wrapperCfg)
+		err := wrapper.Create(backend.Fs, wrapperCfg)
 		assert.NoError(t, err)
 
 		content, err := os.ReadFile(wrapperPath)
@@ -737,7 +740,7 @@ func TestTarballBackend_CreateDesktopFile_Additional(t *testing.T) {
 		assert.Contains(t, string(content), "--no-sandbox")
 
 		// Then test desktop file creation
-		desktopPath, err := backend.createDesktopFile(installDir, "TestApp", "test-app", execPath, core.InstallOptions{})
+		desktopPath, err := backend.createDesktopFile(installDir, "TestApp", "test-app", execPath, core.WaylandUnknown, core.InstallOptions{})
 
 		assert.NoError(t, err)
 		assert.FileExists(t, desktopPath)
@@ -769,7 +772,7 @@ Exec=app`
 		desktopFile := filepath.Join(installDir, "TestApp.desktop")
 		require.NoError(t, os.WriteFile(desktopFile, []byte(desktopContent), 0644))
 
-		desktopPath, err := backend.createDesktopFile(installDir, "TestApp", "test-app", execPath, core.InstallOptions{})
+		desktopPath, err := backend.createDesktopFile(installDir, "TestApp", "test-app", execPath, core.WaylandUnknown, core.InstallOptions{})
 
 		assert.NoError(t, err)
 		assert.NotEmpty(t, desktopPath)
@@ -1004,7 +1007,7 @@ func TestTarballBackend_createDesktopFile_EdgeCases(t *testing.T) {
 		appsDir := filepath.Join(tmpDir, ".local", "share", "applications")
 		require.NoError(t, os.MkdirAll(appsDir, 0755))
 
-		desktopPath, err := backend.createDesktopFile(installDir, appName, normalizedName, execPath, opts)
+		desktopPath, err := backend.createDesktopFile(installDir, appName, normalizedName, execPath, core.WaylandUnknown, opts)
 		// Should succeed or fail gracefully
 		_ = desktopPath
 		_ = err
@@ -1025,7 +1028,7 @@ func TestTarballBackend_createDesktopFile_EdgeCases(t *testing.T) {
 		}
 		backendElectron := New(cfgElectron, &logger)
 
-		desktopPath, err := backendElectron.createDesktopFile(installDir, appName, normalizedName, execPath, opts)
+		desktopPath, err := backendElectron.createDesktopFile(installDir, appName, normalizedName, execPath, core.WaylandUnknown, opts)
 		// Desktop file should be created successfully
 		_ = desktopPath
 		_ = err
@@ -1041,7 +1044,7 @@ func TestTarballBackend_extractArchive_EdgeCases(t *testing.T) {
 
 	t.Run("nonexistent archive", func(t *testing.T) {
 		destDir := t.TempDir()
-		err := backend.extractArchive("/nonexistent/archive.tar.gz", destDir, "tar.gz")
+		err := backend.extractArchive(context.Background(), "/nonexistent/archive.tar.gz", destDir, "tar.gz")
 		assert.Error(t, err)
 	})
 
@@ -1051,7 +1054,7 @@ func TestTarballBackend_extractArchive_EdgeCases(t *testing.T) {
 		destFile := filepath.Join(tmpDir, "not-a-directory")
 		require.NoError(t, os.WriteFile(destFile, []byte("test"), 0644))
 
-		err := backend.extractArchive("/some/path.tar.gz", destFile, "tar.gz")
+		err := backend.extractArchive(context.Background(), "/some/path.tar.gz", destFile, "tar.gz")
 		assert.Error(t, err)
 	})
 
@@ -1061,7 +1064,7 @@ func TestTarballBackend_extractArchive_EdgeCases(t *testing.T) {
 		require.NoError(t, os.WriteFile(archivePath, []byte("fake"), 0644))
 		destDir := t.TempDir()
 
-		err := backend.extractArchive(archivePath, destDir, "zip")
+		err := backend.extractArchive(context.Background(), archivePath, destDir, "zip")
 		// Should error for unsupported type or try to extract
 		_ = err
 	})
@@ -1158,7 +1161,7 @@ func TestTarballBackend_extractArchive(t *testing.T) {
 		require.NoError(t, os.WriteFile(archivePath, []byte("fake"), 0644))
 
 		destDir := filepath.Join(tmpDir, "dest")
-		err := backend.extractArchive(archivePath, destDir, "unknown")
+		err := backend.extractArchive(context.Background(), archivePath, destDir, "unknown")
 		assert.Error(t, err)
 	})
 
@@ -1169,7 +1172,7 @@ func TestTarballBackend_extractArchive(t *testing.T) {
 		require.NoError(t, os.WriteFile(archivePath, []byte{0x1F, 0x8B, 0x08, 0x00}, 0644))
 
 		destDir := filepath.Join(tmpPath, "dest")
-		err := backend.extractArchive(archivePath, destDir, "tar.gz")
+		err := backend.extractArchive(context.Background(), archivePath, destDir, "tar.gz")
 		// May fail due to incomplete tar, but should attempt extraction
 		_ = err
 	})
@@ -1181,7 +1184,7 @@ func TestTarballBackend_extractArchive(t *testing.T) {
 		require.NoError(t, os.WriteFile(archivePath, []byte{0x50, 0x4B, 0x03, 0x04}, 0644))
 
 		destDir := filepath.Join(tmpPath, "dest")
-		err := backend.extractArchive(archivePath, destDir, "zip")
+		err := backend.extractArchive(context.Background(), archivePath, destDir, "zip")
 		// May fail due to incomplete zip, but should attempt extraction
 		_ = err
 	})
@@ -1193,7 +1196,7 @@ func TestTarballBackend_extractArchive(t *testing.T) {
 		require.NoError(t, os.WriteFile(archivePath, []byte{0xFD, 0x37, 0x7A, 0x58, 0x5A, 0x00}, 0644))
 
 		destDir := filepath.Join(tmpPath, "dest")
-		err := backend.extractArchive(archivePath, destDir, "tar.xz")
+		err := backend.extractArchive(context.Background(), archivePath, destDir, "tar.xz")
 		// May fail due to incomplete tar.xz, but should attempt extraction
 		_ = err
 	})
@@ -1205,7 +1208,7 @@ func TestTarballBackend_extractArchive(t *testing.T) {
 		require.NoError(t, os.WriteFile(archivePath, []byte{0x42, 0x5A, 0x68}, 0644))
 
 		destDir := filepath.Join(tmpPath, "dest")
-		err := backend.extractArchive(archivePath, destDir, "tar.bz2")
+		err := backend.extractArchive(context.Background(), archivePath, destDir, "tar.bz2")
 		// May fail due to incomplete tar.bz2, but should attempt extraction
 		_ = err
 	})
@@ -1217,7 +1220,7 @@ func TestTarballBackend_extractArchive(t *testing.T) {
 
 		destDir := filepath.Join(tmpPath, "newdir", "dest")
 		// Don't create destDir - let extractArchive create it
-		err := backend.extractArchive(archivePath, destDir, "tar.gz")
+		err := backend.extractArchive(context.Background(), archivePath, destDir, "tar.gz")
 		_ = err
 		// Verify directory was created or not based on implementation
 	})
@@ -1321,7 +1324,8 @@ func TestTarballBackend_Install_MorePaths(t *testing.T) {
 		require.NoError(t, os.WriteFile(archivePath, []byte("fake archive"), 0644))
 
 		tx := transaction.NewManager(&logger)
-		record, err := backend.Install(context.Background(), archivePath, core.InstallOptions{}, tx)
+		result, err := backend.Install(context.Background(), archivePath, core.InstallOptions{}, tx)
+		record := result.GetRecord()
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "already installed")
@@ -1348,7 +1352,8 @@ func TestTarballBackend_Install_MorePaths(t *testing.T) {
 		require.NoError(t, os.WriteFile(archivePath, []byte("fake archive"), 0644))
 
 		tx := transaction.NewManager(&logger)
-		record, err := backend.Install(context.Background(), archivePath, core.InstallOptions{Force: true}, tx)
+		result, err := backend.Install(context.Background(), archivePath, core.InstallOptions{Force: true}, tx)
+		record := result.GetRecord()
 
 		// Should fail during extraction, but existing dir should be removed
 		assert.Error(t, err)
@@ -1392,7 +1397,8 @@ func TestTarballBackend_Install_MorePaths(t *testing.T) {
 		require.NoError(t, os.WriteFile(archivePath, []byte("fake archive"), 0644))
 
 		// Pass nil transaction manager
-		record, err := backend.Install(context.Background(), archivePath, core.InstallOptions{}, nil)
+		result, err := backend.Install(context.Background(), archivePath, core.InstallOptions{}, nil)
+		record := result.GetRecord()
 
 		assert.Error(t, err)
 		assert.Nil(t, record)
@@ -1523,7 +1529,7 @@ func TestTarballBackend_Uninstall_MoreCoverage(t *testing.T) {
 		// Add desktop file to record
 		record.DesktopFile = desktopPath
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.NoError(t, err)
 
 		// Verify removal
@@ -1543,7 +1549,7 @@ func TestTarballBackend_Uninstall_MoreCoverage(t *testing.T) {
 			InstallPath: "",
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.NoError(t, err)
 	})
 }
@@ -1573,7 +1579,7 @@ func TestTarballBackend_createDesktopFile(t *testing.T) {
 			SkipWaylandEnv: false,
 		}
 
-		desktopPath, err := backend.createDesktopFile(installDir, appName, normalizedName, execPath, opts)
+		desktopPath, err := backend.createDesktopFile(installDir, appName, normalizedName, execPath, core.WaylandUnknown, opts)
 		_ = desktopPath
 		_ = err
 	})
@@ -1583,7 +1589,7 @@ func TestTarballBackend_createDesktopFile(t *testing.T) {
 			SkipWaylandEnv: true,
 		}
 
-		desktopPath, err := backend.createDesktopFile(installDir, appName, normalizedName, execPath, opts)
+		desktopPath, err := backend.createDesktopFile(installDir, appName, normalizedName, execPath, core.WaylandUnknown, opts)
 		_ = desktopPath
 		_ = err
 	})
@@ -1604,7 +1610,7 @@ func TestTarballBackend_createDesktopFile(t *testing.T) {
 			SkipWaylandEnv: false,
 		}
 
-		desktopPath, err := backendCustom.createDesktopFile(installDir, appName, normalizedName, execPath, opts)
+		desktopPath, err := backendCustom.createDesktopFile(installDir, appName, normalizedName, execPath, core.WaylandUnknown, opts)
 		_ = desktopPath
 		_ = err
 	})
@@ -1627,12 +1633,12 @@ func TestTarballBackend_createWrapper(t *testing.T) {
 	wrapperPath := filepath.Join(binDir, "testapp")
 	execPath := "/opt/testapp/bin/testapp"
 
-	wrapperCfg := helpers.WrapperConfig{
+	wrapperCfg := wrapper.Config{
 		WrapperPath:    wrapperPath,
 		ExecPath:       execPath,
 		DisableSandbox: false,
 	}
-	err := helpers.CreateWrapper(backend.Fs, wrapperCfg)
+	err := wrapper.Create(backend.Fs, wrapperCfg)
 	assert.NoError(t, err)
 }
 
@@ -1648,7 +1654,7 @@ func TestTarballBackend_isElectronApp(t *testing.T) {
 		require.NoError(t, os.MkdirAll(filepath.Join(installDir, "..", "resources"), 0755))
 		require.NoError(t, os.WriteFile(filepath.Join(installDir, "..", "resources", "app.asar"), []byte("fake"), 0644))
 
-		isElectron := helpers.IsElectronApp(backend.Fs, installDir)
+		isElectron := wrapper.IsElectronApp(backend.Fs, installDir)
 		assert.True(t, isElectron)
 	})
 
@@ -1662,7 +1668,7 @@ func TestTarballBackend_isElectronApp(t *testing.T) {
 		installDir := filepath.Join(tmpDir, "app", "bin")
 		require.NoError(t, os.MkdirAll(installDir, 0755))
 
-		isElectron := helpers.IsElectronApp(backend.Fs, installDir)
+		isElectron := wrapper.IsElectronApp(backend.Fs, installDir)
 		assert.False(t, isElectron)
 	})
 
@@ -1677,7 +1683,7 @@ func TestTarballBackend_isElectronApp(t *testing.T) {
 		installDir := filepath.Join(tmpDir, "app", "bin")
 		require.NoError(t, os.MkdirAll(installDir, 0755))
 
-		isElectron := helpers.IsElectronApp(backend.Fs, installDir)
+		isElectron := wrapper.IsElectronApp(backend.Fs, installDir)
 		assert.False(t, isElectron)
 	})
 }
@@ -1704,3 +1710,59 @@ func TestTarballBackend_copyFile(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestTarballBackend_ExposeBundledBins(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.New(io.Discard)
+	fs := afero.NewOsFs()
+	backend := NewWithDeps(&config.Config{}, &logger, fs, helpers.NewOSCommandRunner())
+
+	tmpDir := t.TempDir()
+	installDir := filepath.Join(tmpDir, "apps", "node")
+	payloadBin := filepath.Join(installDir, "node-v20-linux-x64", "bin")
+	binDir := filepath.Join(tmpDir, "bin")
+	require.NoError(t, fs.MkdirAll(payloadBin, 0755))
+	require.NoError(t, fs.MkdirAll(binDir, 0755))
+
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(payloadBin, "node"), []byte("elf"), 0755))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(payloadBin, "npx"), []byte("#!/bin/sh"), 0755))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(payloadBin, "README"), []byte("docs"), 0644))
+	// Pre-existing file in the wrapper dir must not be overwritten
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(binDir, "npx"), []byte("system npx"), 0755))
+
+	wrapperPath := filepath.Join(binDir, "node")
+	created, err := backend.exposeBundledBins(installDir, binDir, wrapperPath)
+	require.NoError(t, err)
+
+	// node collides with the wrapper and npx with an existing file; README is not executable
+	assert.Empty(t, created)
+
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(payloadBin, "corepack"), []byte("#!/bin/sh"), 0755))
+	created, err = backend.exposeBundledBins(installDir, binDir, wrapperPath)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(binDir, "corepack")}, created)
+
+	target, err := os.Readlink(created[0])
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(payloadBin, "corepack"), target)
+
+	content, err := os.ReadFile(filepath.Join(binDir, "npx"))
+	require.NoError(t, err)
+	assert.Equal(t, "system npx", string(content))
+
+	backend.removeExposedBins(created, installDir)
+	_, err = os.Lstat(created[0])
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestTarballBackend_ExposeBundledBins_NoBinDir(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.New(io.Discard)
+	backend := NewWithDeps(&config.Config{}, &logger, afero.NewOsFs(), helpers.NewOSCommandRunner())
+
+	tmpDir := t.TempDir()
+	_, err := backend.exposeBundledBins(tmpDir, filepath.Join(tmpDir, "bin-out"), "")
+	assert.Error(t, err)
+}
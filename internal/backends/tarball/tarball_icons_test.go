@@ -2,12 +2,13 @@ package tarball
 
 import (
 	"bytes"
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/quantmind-br/upkg/internal/config"
-	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/wrapper"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -195,7 +196,7 @@ func TestTarballBackend_ExtractArchive_Unsupported(t *testing.T) {
 	destDir := filepath.Join(tmpDir, "dest")
 	require.NoError(t, os.MkdirAll(destDir, 0755))
 
-	err := backend.extractArchive(archivePath, destDir, "unknown")
+	err := backend.extractArchive(context.Background(), archivePath, destDir, "unknown")
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unsupported")
@@ -226,12 +227,12 @@ func TestTarballBackend_CreateWrapper_Electron(t *testing.T) {
 	require.NoError(t, os.WriteFile(asarPath, []byte("fake asar"), 0644))
 
 	wrapperPath := filepath.Join(tmpDir, "wrapper")
-	wrapperCfg := helpers.WrapperConfig{
+	wrapperCfg := wrapper.Config{
 		WrapperPath:    wrapperPath,
 		ExecPath:       execPath,
 		DisableSandbox: false,
 	}
-	err := helpers.CreateWrapper(backend.Fs, wrapperCfg)
+	err := wrapper.Create(backend.Fs, wrapperCfg)
 
 	assert.NoError(t, err)
 	assert.NotEmpty(t, wrapperPath)
@@ -260,7 +261,7 @@ func TestTarballBackend_IsElectronApp_AsarFound(t *testing.T) {
 	execPath := filepath.Join(tmpDir, "app")
 	require.NoError(t, os.WriteFile(execPath, []byte("#!/bin/sh"), 0755))
 
-	isElectron := helpers.IsElectronApp(backend.Fs, execPath)
+	isElectron := wrapper.IsElectronApp(backend.Fs, execPath)
 
 	assert.True(t, isElectron)
 }
@@ -280,7 +281,7 @@ func TestTarballBackend_IsElectronApp_NoAsar(t *testing.T) {
 	execPath := filepath.Join(tmpDir, "app")
 	require.NoError(t, os.WriteFile(execPath, []byte("#!/bin/sh"), 0755))
 
-	isElectron := helpers.IsElectronApp(backend.Fs, execPath)
+	isElectron := wrapper.IsElectronApp(backend.Fs, execPath)
 
 	assert.False(t, isElectron)
 }
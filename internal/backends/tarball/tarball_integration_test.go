@@ -40,7 +40,8 @@ func TestTarballBackend_Install_InvalidPackageName_Full(t *testing.T) {
 
 	tx := transaction.NewManager(&logger)
 	// Use custom name with invalid characters
-	record, err := backend.Install(context.Background(), archivePath, core.InstallOptions{CustomName: "!!!invalid!!!"}, tx)
+	result, err := backend.Install(context.Background(), archivePath, core.InstallOptions{CustomName: "!!!invalid!!!"}, tx)
+	record := result.GetRecord()
 
 	// Should fail - either validation or extraction
 	assert.Error(t, err)
@@ -67,7 +68,8 @@ func TestTarballBackend_Install_NoHomeDir(t *testing.T) {
 	require.NoError(t, os.WriteFile(archivePath, []byte{0x1F, 0x8B}, 0644))
 
 	tx := transaction.NewManager(&logger)
-	record, err := backend.Install(context.Background(), archivePath, core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), archivePath, core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	// Should fail because HOME is not set
 	assert.Error(t, err)
@@ -93,7 +95,8 @@ func TestTarballBackend_Install_EmptyArchive(t *testing.T) {
 	require.NoError(t, os.WriteFile(archivePath, []byte{0x1F, 0x8B}, 0644))
 
 	tx := transaction.NewManager(&logger)
-	record, err := backend.Install(context.Background(), archivePath, core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), archivePath, core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	// Should fail during extraction
 	assert.Error(t, err)
@@ -128,7 +131,8 @@ func TestTarballBackend_Install_MkdirFailure(t *testing.T) {
 	require.NoError(t, afero.WriteFile(fs, archivePath, []byte{0x1F, 0x8B}, 0644))
 
 	tx := transaction.NewManager(&logger)
-	record, err := backend.Install(context.Background(), archivePath, core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), archivePath, core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Nil(t, record)
@@ -248,7 +252,7 @@ func TestTarballBackend_createDesktopFile_MissingAppsDir(t *testing.T) {
 	// Update the backend's paths resolver to use the new home
 	backend.Paths = paths.NewResolverWithHome(cfg, tmpDir)
 
-	_, err := backend.createDesktopFile(installDir, "TestApp", "testapp", execPath, core.InstallOptions{})
+	_, err := backend.createDesktopFile(installDir, "TestApp", "testapp", execPath, core.WaylandUnknown, core.InstallOptions{})
 	// May succeed if it creates the directory, or fail if it can't
 	_ = err
 }
@@ -415,7 +419,8 @@ func TestTarballBackend_Install_SkipWaylandEnv_Full(t *testing.T) {
 	require.NoError(t, os.WriteFile(archivePath, []byte{0x1F, 0x8B}, 0644))
 
 	tx := transaction.NewManager(&logger)
-	record, err := backend.Install(context.Background(), archivePath, core.InstallOptions{SkipWaylandEnv: true}, tx)
+	result, err := backend.Install(context.Background(), archivePath, core.InstallOptions{SkipWaylandEnv: true}, tx)
+	record := result.GetRecord()
 
 	_ = record
 	_ = err
@@ -486,7 +491,8 @@ func TestTarballBackend_Install_WithRealTar(t *testing.T) {
 	backend := New(cfg, &logger)
 
 	tx := transaction.NewManager(&logger)
-	record, err := backend.Install(context.Background(), archivePath, core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), archivePath, core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	// Should succeed or fail gracefully
 	_ = record
@@ -60,7 +60,7 @@ func TestTarballBackend_Uninstall_Simple(t *testing.T) {
 		InstallPath: tmpDir,
 	}
 
-	err := backend.Uninstall(ctx, install)
+	_, err := backend.Uninstall(ctx, install)
 
 	// Will fail but tests the flow
 	_ = err
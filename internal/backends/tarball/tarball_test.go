@@ -8,6 +8,7 @@ import (
 	"context"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/quantmind-br/upkg/internal/helpers"
 	"github.com/quantmind-br/upkg/internal/paths"
 	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/quantmind-br/upkg/internal/wrapper"
 	"github.com/rs/zerolog"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
@@ -149,7 +151,7 @@ func TestExtractArchive(t *testing.T) {
 
 	t.Run("unsupported archive type", func(t *testing.T) {
 		tmpDir := t.TempDir()
-		err := backend.extractArchive("/some/path", tmpDir, "unsupported")
+		err := backend.extractArchive(context.Background(), "/some/path", tmpDir, "unsupported")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "unsupported archive type")
 	})
@@ -176,7 +178,7 @@ func TestExtractArchive(t *testing.T) {
 
 		require.NoError(t, os.WriteFile(tarPath, buf.Bytes(), 0644))
 
-		err := backend.extractArchive(tarPath, destDir, "tar.gz")
+		err := backend.extractArchive(context.Background(), tarPath, destDir, "tar.gz")
 		assert.NoError(t, err)
 
 		// Verify file was extracted
@@ -204,7 +206,7 @@ func TestExtractArchive(t *testing.T) {
 
 		require.NoError(t, os.WriteFile(zipPath, buf.Bytes(), 0644))
 
-		err = backend.extractArchive(zipPath, destDir, "zip")
+		err = backend.extractArchive(context.Background(), zipPath, destDir, "zip")
 		assert.NoError(t, err)
 
 		// Verify file was extracted
@@ -217,11 +219,52 @@ func TestExtractArchive(t *testing.T) {
 
 	t.Run("non-existent archive file", func(t *testing.T) {
 		tmpDir := t.TempDir()
-		err := backend.extractArchive("/non/existent/file.tar.gz", tmpDir, "tar.gz")
+		err := backend.extractArchive(context.Background(), "/non/existent/file.tar.gz", tmpDir, "tar.gz")
 		assert.Error(t, err)
 	})
 }
 
+func TestExtractArchive_ExternalTools(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	cfg := &config.Config{}
+
+	t.Run("missing tool", func(t *testing.T) {
+		backend := NewWithRunner(cfg, &logger, &helpers.MockCommandRunner{})
+		archivePath := filepath.Join(t.TempDir(), "app.tar.zst")
+		require.NoError(t, os.WriteFile(archivePath, []byte{0x28, 0xB5, 0x2F, 0xFD}, 0644))
+
+		err := backend.extractArchive(context.Background(), archivePath, t.TempDir(), "tar.zst")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "requires zstd or bsdtar")
+	})
+
+	t.Run("tar.zst extraction", func(t *testing.T) {
+		zstdPath, err := exec.LookPath("zstd")
+		if err != nil {
+			t.Skip("zstd not available")
+		}
+		tmpDir := t.TempDir()
+		content := []byte("test content")
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "test.txt", Size: int64(len(content)), Mode: 0644, Typeflag: tar.TypeReg}))
+		_, err = tw.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+		tarPath := filepath.Join(tmpDir, "test.tar")
+		require.NoError(t, os.WriteFile(tarPath, buf.Bytes(), 0644))
+		require.NoError(t, exec.Command(zstdPath, "-q", tarPath).Run())
+
+		destDir := filepath.Join(tmpDir, "dest")
+		backend := New(cfg, &logger)
+		require.NoError(t, backend.extractArchive(context.Background(), tarPath+".zst", destDir, "tar.zst"))
+
+		extractedContent, err := os.ReadFile(filepath.Join(destDir, "test.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, content, extractedContent)
+	})
+}
+
 func TestInstall_Validation(t *testing.T) {
 	logger := zerolog.New(io.Discard)
 	cfg := &config.Config{}
@@ -235,7 +278,8 @@ func TestInstall_Validation(t *testing.T) {
 
 		// Try to install non-existent package
 		tx := transaction.NewManager(&logger)
-		record, err := backend.Install(context.Background(), "/nonexistent/package.tar.gz", core.InstallOptions{}, tx)
+		result, err := backend.Install(context.Background(), "/nonexistent/package.tar.gz", core.InstallOptions{}, tx)
+		record := result.GetRecord()
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "package not found")
@@ -253,7 +297,8 @@ func TestInstall_Validation(t *testing.T) {
 		require.NoError(t, os.WriteFile(archivePath, []byte("fake"), 0644))
 
 		tx := transaction.NewManager(&logger)
-		record, err := backend.Install(context.Background(), archivePath, core.InstallOptions{}, tx)
+		result, err := backend.Install(context.Background(), archivePath, core.InstallOptions{}, tx)
+		record := result.GetRecord()
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "unsupported archive type")
@@ -288,7 +333,8 @@ func TestInstall_Validation(t *testing.T) {
 		require.NoError(t, os.WriteFile(archivePath, buf.Bytes(), 0644))
 
 		tx := transaction.NewManager(&logger)
-		record, err := backend.Install(context.Background(), archivePath, core.InstallOptions{}, tx)
+		result, err := backend.Install(context.Background(), archivePath, core.InstallOptions{}, tx)
+		record := result.GetRecord()
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "invalid path in archive")
@@ -326,7 +372,8 @@ func TestInstall_Validation(t *testing.T) {
 		backendWithNoHome.Paths = paths.NewResolverWithHome(cfg, "")
 
 		tx := transaction.NewManager(&logger)
-		record, err := backendWithNoHome.Install(context.Background(), archivePath, core.InstallOptions{}, tx)
+		result, err := backendWithNoHome.Install(context.Background(), archivePath, core.InstallOptions{}, tx)
+		record := result.GetRecord()
 
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to get home directory")
@@ -344,12 +391,12 @@ func TestCreateWrapper(t *testing.T) {
 		wrapperPath := filepath.Join(tmpDir, "test-wrapper")
 		execPath := "/path/to/executable"
 
-		wrapperCfg := helpers.WrapperConfig{
+		wrapperCfg := wrapper.Config{
 			WrapperPath:    wrapperPath,
 			ExecPath:       execPath,
 			DisableSandbox: false,
 		}
-		err := helpers.CreateWrapper(backend.Fs, wrapperCfg)
+		err := wrapper.Create(backend.Fs, wrapperCfg)
 		require.NoError(t, err)
 
 		// Verify wrapper was created
@@ -378,12 +425,12 @@ func TestCreateWrapper(t *testing.T) {
 		require.NoError(t, os.WriteFile(execPath, []byte("fake exec"), 0755))
 
 		wrapperPath := filepath.Join(tmpDir, "wrapper")
-		wrapperCfg := helpers.WrapperConfig{
+		wrapperCfg := wrapper.Config{
 			WrapperPath:    wrapperPath,
 			ExecPath:       execPath,
 			DisableSandbox: false,
 		}
-		err := helpers.CreateWrapper(backend.Fs, wrapperCfg)
+		err := wrapper.Create(backend.Fs, wrapperCfg)
 		require.NoError(t, err)
 
 		content, err := os.ReadFile(wrapperPath)
@@ -413,12 +460,12 @@ func TestCreateWrapper(t *testing.T) {
 		backendWithSandbox := New(cfgWithSandbox, &logger)
 
 		wrapperPath := filepath.Join(tmpDir, "wrapper")
-		wrapperCfg := helpers.WrapperConfig{
+		wrapperCfg := wrapper.Config{
 			WrapperPath:    wrapperPath,
 			ExecPath:       execPath,
 			DisableSandbox: true,
 		}
-		err := helpers.CreateWrapper(backendWithSandbox.Fs, wrapperCfg)
+		err := wrapper.Create(backendWithSandbox.Fs, wrapperCfg)
 		require.NoError(t, err)
 
 		content, err := os.ReadFile(wrapperPath)
@@ -438,7 +485,7 @@ func TestIsElectronApp(t *testing.T) {
 		execPath := filepath.Join(tmpDir, "standard-binary")
 		require.NoError(t, os.WriteFile(execPath, []byte("fake exec"), 0755))
 
-		isElectron := helpers.IsElectronApp(backend.Fs, execPath)
+		isElectron := wrapper.IsElectronApp(backend.Fs, execPath)
 		assert.False(t, isElectron)
 	})
 
@@ -453,7 +500,7 @@ func TestIsElectronApp(t *testing.T) {
 		execPath := filepath.Join(tmpDir, "electron-app")
 		require.NoError(t, os.WriteFile(execPath, []byte("fake exec"), 0755))
 
-		isElectron := helpers.IsElectronApp(backend.Fs, execPath)
+		isElectron := wrapper.IsElectronApp(backend.Fs, execPath)
 		assert.True(t, isElectron)
 	})
 
@@ -468,7 +515,7 @@ func TestIsElectronApp(t *testing.T) {
 		execPath := filepath.Join(binDir, "electron-app")
 		require.NoError(t, os.WriteFile(execPath, []byte("fake exec"), 0755))
 
-		isElectron := helpers.IsElectronApp(backend.Fs, execPath)
+		isElectron := wrapper.IsElectronApp(backend.Fs, execPath)
 		assert.True(t, isElectron)
 	})
 }
@@ -512,7 +559,8 @@ func TestInstall_PackageNotFound(t *testing.T) {
 	backend := New(cfg, &logger)
 	tx := transaction.NewManager(&logger)
 
-	record, err := backend.Install(context.Background(), "/nonexistent/package.tar.gz", core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), "/nonexistent/package.tar.gz", core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "package not found")
@@ -529,7 +577,8 @@ func TestInstall_UnsupportedArchiveType(t *testing.T) {
 	fakePkg := filepath.Join(tmpDir, "test.unknown")
 	require.NoError(t, os.WriteFile(fakePkg, []byte("fake content"), 0644))
 
-	record, err := backend.Install(context.Background(), fakePkg, core.InstallOptions{}, tx)
+	result, err := backend.Install(context.Background(), fakePkg, core.InstallOptions{}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unsupported archive type")
@@ -549,9 +598,10 @@ func TestInstall_InvalidPackageName(t *testing.T) {
 
 	// Try to install with an empty custom name after normalization
 	// Using a name that normalizes to empty string (all invalid chars)
-	record, err := backend.Install(context.Background(), fakePkg, core.InstallOptions{
+	result, err := backend.Install(context.Background(), fakePkg, core.InstallOptions{
 		CustomName: "///",
 	}, tx)
+	record := result.GetRecord()
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid")
@@ -597,7 +647,7 @@ func TestUninstall(t *testing.T) {
 			},
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.NoError(t, err)
 
 		// Verify all files removed
@@ -620,7 +670,7 @@ func TestUninstall(t *testing.T) {
 			},
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.NoError(t, err) // Should not error on missing files
 	})
 
@@ -631,7 +681,7 @@ func TestUninstall(t *testing.T) {
 			PackageType: core.PackageTypeTarball,
 		}
 
-		err := backend.Uninstall(context.Background(), record)
+		_, err := backend.Uninstall(context.Background(), record)
 		assert.NoError(t, err)
 	})
 }
@@ -709,7 +759,7 @@ func TestCreateDesktopFile(t *testing.T) {
 		installDir := filepath.Join(tmpDir, "install")
 		require.NoError(t, os.MkdirAll(installDir, 0755))
 
-		desktopPath, err := backend.createDesktopFile(installDir, "Test App", "test-app", "/usr/bin/test-app", core.InstallOptions{})
+		desktopPath, err := backend.createDesktopFile(installDir, "Test App", "test-app", "/usr/bin/test-app", core.WaylandUnknown, core.InstallOptions{})
 		require.NoError(t, err)
 		assert.NotEmpty(t, desktopPath)
 
@@ -754,7 +804,7 @@ Categories=Development;IDE;
 `
 		require.NoError(t, os.WriteFile(filepath.Join(installDir, "app.desktop"), []byte(existingDesktop), 0644))
 
-		desktopPath, err := backend.createDesktopFile(installDir, "Test App", "test-app", "/usr/bin/test-app", core.InstallOptions{})
+		desktopPath, err := backend.createDesktopFile(installDir, "Test App", "test-app", "/usr/bin/test-app", core.WaylandUnknown, core.InstallOptions{})
 		require.NoError(t, err)
 
 		content, err := os.ReadFile(desktopPath)
@@ -786,7 +836,7 @@ Categories=Development;IDE;
 		installDir := filepath.Join(tmpDir, "install")
 		require.NoError(t, os.MkdirAll(installDir, 0755))
 
-		desktopPath, err := backend.createDesktopFile(installDir, "Test App", "test-app", "/usr/bin/test-app", core.InstallOptions{})
+		desktopPath, err := backend.createDesktopFile(installDir, "Test App", "test-app", "/usr/bin/test-app", core.WaylandUnknown, core.InstallOptions{})
 		require.NoError(t, err)
 
 		content, err := os.ReadFile(desktopPath)
@@ -816,7 +866,7 @@ Categories=Development;IDE;
 		installDir := filepath.Join(tmpDir, "install")
 		require.NoError(t, os.MkdirAll(installDir, 0755))
 
-		desktopPath, err := backend.createDesktopFile(installDir, "Test App", "test-app", "/usr/bin/test-app", core.InstallOptions{
+		desktopPath, err := backend.createDesktopFile(installDir, "Test App", "test-app", "/usr/bin/test-app", core.WaylandUnknown, core.InstallOptions{
 			SkipWaylandEnv: true,
 		})
 		require.NoError(t, err)
@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+)
+
+// Pending lists the directories whose caches still need updating
+type Pending struct {
+	DesktopDirs []string `json:"desktop_dirs,omitempty"`
+	IconDirs    []string `json:"icon_dirs,omitempty"`
+}
+
+// Empty reports whether no update is pending
+func (p Pending) Empty() bool {
+	return len(p.DesktopDirs) == 0 && len(p.IconDirs) == 0
+}
+
+// Merge adds the directories of other that p does not list yet
+func (p *Pending) Merge(other Pending) {
+	for _, dir := range other.DesktopDirs {
+		p.addDesktopDir(dir)
+	}
+	for _, dir := range other.IconDirs {
+		p.addIconDir(dir)
+	}
+}
+
+func (p *Pending) addDesktopDir(dir string) {
+	if !slices.Contains(p.DesktopDirs, dir) {
+		p.DesktopDirs = append(p.DesktopDirs, dir)
+	}
+}
+
+func (p *Pending) addIconDir(dir string) {
+	if !slices.Contains(p.IconDirs, dir) {
+		p.IconDirs = append(p.IconDirs, dir)
+	}
+}
+
+// Batch defers the cache updates of every CacheManager while it is active,
+// so an operation on several packages refreshes each directory once
+type Batch struct {
+	depth   int
+	pending Pending
+}
+
+var (
+	batchMu sync.Mutex
+	active  *Batch
+)
+
+// BeginBatch starts deferring cache updates. A batch begun while another
+// is active joins it; only the outermost End returns the updates.
+func BeginBatch() *Batch {
+	batchMu.Lock()
+	defer batchMu.Unlock()
+
+	if active == nil {
+		active = &Batch{}
+	}
+	active.depth++
+	return active
+}
+
+// End stops the batch and returns the updates deferred while it was active
+func (b *Batch) End() Pending {
+	batchMu.Lock()
+	defer batchMu.Unlock()
+
+	if b.depth == 0 {
+		return Pending{}
+	}
+	b.depth--
+	if b.depth > 0 {
+		return Pending{}
+	}
+	if active == b {
+		active = nil
+	}
+	pending := b.pending
+	b.pending = Pending{}
+	return pending
+}
+
+// deferUpdate records an update in the active batch, reporting false when
+// there is none and the update must run now
+func deferUpdate(add func(*Pending)) bool {
+	batchMu.Lock()
+	defer batchMu.Unlock()
+
+	if active == nil {
+		return false
+	}
+	add(&active.pending)
+	return true
+}
+
+// Run performs the pending updates once per directory
+func (c *CacheManager) Run(pending Pending, log *zerolog.Logger) {
+	for _, dir := range pending.DesktopDirs {
+		if err := c.UpdateDesktopDatabase(dir, log); err != nil {
+			log.Warn().Err(err).Str("apps_dir", dir).Msg("failed to update desktop database")
+		}
+	}
+	for _, dir := range pending.IconDirs {
+		if err := c.UpdateIconCache(dir, log); err != nil {
+			log.Warn().Err(err).Str("icon_dir", dir).Msg("failed to update icon cache")
+		}
+	}
+}
+
+// LoadPending reads the updates saved in path; a missing file means none
+func LoadPending(fs afero.Fs, path string) (Pending, error) {
+	var pending Pending
+	data, err := afero.ReadFile(fs, path)
+	if errors.Is(err, os.ErrNotExist) {
+		return pending, nil
+	}
+	if err != nil {
+		return pending, fmt.Errorf("read pending cache updates: %w", err)
+	}
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return pending, fmt.Errorf("parse pending cache updates: %w", err)
+	}
+	return pending, nil
+}
+
+// SavePending adds pending to the updates saved in path
+func SavePending(fs afero.Fs, path string, pending Pending) error {
+	saved, err := LoadPending(fs, path)
+	if err != nil {
+		return err
+	}
+	saved.Merge(pending)
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode pending cache updates: %w", err)
+	}
+	if err := fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create pending cache updates dir: %w", err)
+	}
+	if err := afero.WriteFile(fs, path, data, 0644); err != nil {
+		return fmt.Errorf("write pending cache updates: %w", err)
+	}
+	return nil
+}
+
+// ClearPending removes the updates saved in path
+func ClearPending(fs afero.Fs, path string) error {
+	if err := fs.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("remove pending cache updates: %w", err)
+	}
+	return nil
+}
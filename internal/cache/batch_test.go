@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls [][]string
+	)
+	runner := &helpers.MockCommandRunner{
+		CommandExistsFunc: func(name string) bool { return name != "gtk4-update-icon-cache" },
+		RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, append([]string{name}, args...))
+			return "", nil
+		},
+	}
+	cm := NewCacheManagerWithRunner(runner)
+	log := zerolog.Nop()
+
+	batch := BeginBatch()
+	nested := BeginBatch()
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, cm.UpdateDesktopDatabase("/tmp/apps", &log))
+			assert.NoError(t, cm.UpdateIconCache("/tmp/icons", &log))
+		}()
+	}
+	wg.Wait()
+	assert.Empty(t, calls, "updates wait for the batch to end")
+
+	assert.True(t, nested.End().Empty(), "a nested batch leaves the updates to the outer one")
+	require.NoError(t, cm.UpdateDesktopDatabase("/tmp/other", &log))
+
+	pending := batch.End()
+	assert.Equal(t, Pending{DesktopDirs: []string{"/tmp/apps", "/tmp/other"}, IconDirs: []string{"/tmp/icons"}}, pending)
+	assert.True(t, batch.End().Empty(), "ending twice is fine")
+
+	cm.Run(pending, &log)
+	assert.Equal(t, [][]string{
+		{"update-desktop-database", "/tmp/apps"},
+		{"update-desktop-database", "/tmp/other"},
+		{gtkUpdateIconCacheCmd, "-f", "-t", "/tmp/icons"},
+	}, calls)
+
+	// Without a batch updates run right away
+	require.NoError(t, cm.UpdateIconCache("/tmp/icons", &log))
+	assert.Len(t, calls, 4)
+}
+
+func TestPendingFile(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	path := "/data/pending-caches.json"
+
+	pending, err := LoadPending(fs, path)
+	require.NoError(t, err)
+	assert.True(t, pending.Empty())
+
+	require.NoError(t, SavePending(fs, path, Pending{DesktopDirs: []string{"/apps"}}))
+	require.NoError(t, SavePending(fs, path, Pending{DesktopDirs: []string{"/apps"}, IconDirs: []string{"/icons"}}))
+	pending, err = LoadPending(fs, path)
+	require.NoError(t, err)
+	assert.Equal(t, Pending{DesktopDirs: []string{"/apps"}, IconDirs: []string{"/icons"}}, pending)
+
+	require.NoError(t, ClearPending(fs, path))
+	require.NoError(t, ClearPending(fs, path), "clearing twice is fine")
+
+	require.NoError(t, afero.WriteFile(fs, path, []byte("{"), 0644))
+	_, err = LoadPending(fs, path)
+	assert.Error(t, err)
+}
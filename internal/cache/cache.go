@@ -31,8 +31,13 @@ func NewCacheManagerWithRunner(runner helpers.CommandRunner) *CacheManager {
 	}
 }
 
-// UpdateIconCache updates the icon cache using gtk-update-icon-cache
+// UpdateIconCache updates the icon cache using gtk-update-icon-cache, deferred while a Batch is active
 func (c *CacheManager) UpdateIconCache(iconDir string, log *zerolog.Logger) error {
+	if deferUpdate(func(p *Pending) { p.addIconDir(iconDir) }) {
+		log.Debug().Str("icon_dir", iconDir).Msg("icon cache update deferred")
+		return nil
+	}
+
 	cmdName := c.detectIconCacheCommand()
 	if cmdName == "" {
 		log.Warn().Msg("gtk-update-icon-cache not found, skipping icon cache update")
@@ -58,8 +63,13 @@ func (c *CacheManager) UpdateIconCache(iconDir string, log *zerolog.Logger) erro
 	return nil
 }
 
-// UpdateDesktopDatabase updates the desktop database using update-desktop-database
+// UpdateDesktopDatabase updates the desktop database using update-desktop-database, deferred while a Batch is active
 func (c *CacheManager) UpdateDesktopDatabase(appsDir string, log *zerolog.Logger) error {
+	if deferUpdate(func(p *Pending) { p.addDesktopDir(appsDir) }) {
+		log.Debug().Str("apps_dir", appsDir).Msg("desktop database update deferred")
+		return nil
+	}
+
 	if !c.runner.CommandExists("update-desktop-database") {
 		log.Warn().Msg("update-desktop-database not found, skipping desktop database update")
 		return nil
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/adopt"
+	"github.com/quantmind-br/upkg/internal/appdata"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// adoptOptions holds the flags of the adopt command
+type adoptOptions struct {
+	name   string
+	dryRun bool
+}
+
+// NewAdoptCmd creates the adopt command
+func NewAdoptCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &adoptOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "adopt <path or desktop-file>",
+		Short: "Take over an application installed by hand",
+		Long: `Record an application installed without upkg so it can be uninstalled
+and upgraded with upkg from now on.
+
+The target is an AppImage (e.g. in ~/Applications), an unpacked application
+folder, or a desktop entry launching one. Desktop entries, launchers in
+~/.local/bin and icons that belong to the application are found and recorded
+with it. Nothing is moved or rewritten.`,
+		Example: `  upkg adopt ~/Applications/Obsidian-1.5.3.AppImage
+  upkg adopt ~/.local/share/applications/cursor.desktop
+  upkg adopt /opt/zen --name zen-browser`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runAdoptCmd(afero.NewOsFs(), paths.NewResolver(cfg), cfg, log, opts, args[0])
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.name, "name", "", "package name (default: from the desktop entry or file name)")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "show what would be recorded without saving it")
+
+	return cmd
+}
+
+func runAdoptCmd(fs afero.Fs, resolver *paths.Resolver, cfg *config.Config, log *zerolog.Logger, opts *adoptOptions, target string) error {
+	record, err := adopt.NewScanner(fs, resolver).Scan(target, opts.name)
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+
+	ctx := context.Background()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	installs, err := database.List(ctx)
+	if err != nil {
+		ui.PrintError("failed to query database: %v", err)
+		return fmt.Errorf("list installs: %w", err)
+	}
+	for _, install := range installs {
+		switch {
+		case install.InstallPath == record.InstallPath:
+			ui.PrintError("%s is already managed as %s", record.InstallPath, install.Name)
+			return fmt.Errorf("%s is already installed", install.Name)
+		case strings.EqualFold(install.Name, record.Name):
+			ui.PrintError("a package named %s is already installed; pick another with --name", install.Name)
+			return fmt.Errorf("%s is already installed", install.Name)
+		}
+	}
+
+	printAdoptedRecord(record)
+	if opts.dryRun {
+		ui.PrintInfo("Dry run: nothing was recorded")
+		return nil
+	}
+
+	recordInstalledSize(fs, record)
+	recordDataDirs(fs, appdata.DirsFor(resolver.HomeDir(), os.Getenv), record)
+	if err := database.Create(ctx, db.FromInstallRecord(record)); err != nil {
+		ui.PrintError("failed to save %s: %v", record.Name, err)
+		return fmt.Errorf("create install: %w", err)
+	}
+	recordIntegrity(fs, cfg, log, record)
+
+	log.Info().
+		Str("name", record.Name).
+		Str("install_id", record.InstallID).
+		Str("install_path", record.InstallPath).
+		Msg("package adopted")
+	ui.PrintSuccess("Adopted %s; manage it with upkg uninstall and upkg upgrade", record.Name)
+	return nil
+}
+
+// printAdoptedRecord shows what adopt found for the installation
+func printAdoptedRecord(record *core.InstallRecord) {
+	ui.PrintKeyValue("Name", record.Name)
+	ui.PrintKeyValue("Type", string(record.PackageType))
+	if record.Version != "" {
+		ui.PrintKeyValue("Version", record.Version)
+	}
+	ui.PrintKeyValue("Install path", record.InstallPath)
+	for _, file := range record.Metadata.DesktopFiles {
+		ui.PrintKeyValue("Desktop file", file)
+	}
+	if record.Metadata.WrapperScript != "" {
+		ui.PrintKeyValue("Launcher", record.Metadata.WrapperScript)
+	}
+	for _, bin := range record.Metadata.ExposedBins {
+		ui.PrintKeyValue("Binary", bin)
+	}
+	for _, icon := range record.Metadata.IconFiles {
+		ui.PrintKeyValue("Icon", icon)
+	}
+}
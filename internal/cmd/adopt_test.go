@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunAdoptCmd(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: filepath.Join(tmpDir, "test.db")}}
+	log := zerolog.New(io.Discard)
+	resolver := paths.NewResolverWithHome(cfg, "/home/u")
+
+	appImage := "/home/u/Applications/Obsidian-1.5.3.AppImage"
+	desktopFile := "/home/u/.local/share/applications/obsidian.desktop"
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, appImage, []byte("payload"), 0755))
+	require.NoError(t, afero.WriteFile(fs, desktopFile, []byte("[Desktop Entry]\nType=Application\nName=Obsidian\nExec="+appImage+" %U\n"), 0644))
+
+	// A dry run records nothing
+	require.NoError(t, runAdoptCmd(fs, resolver, cfg, &log, &adoptOptions{dryRun: true}, appImage))
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	installs, err := database.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, installs)
+	require.NoError(t, database.Close())
+
+	require.NoError(t, runAdoptCmd(fs, resolver, cfg, &log, &adoptOptions{}, appImage))
+
+	database, err = db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	installs, err = database.List(ctx)
+	require.NoError(t, err)
+	require.NoError(t, database.Close())
+	require.Len(t, installs, 1)
+	record := db.ToInstallRecord(&installs[0])
+	assert.Equal(t, "Obsidian", record.Name)
+	assert.Equal(t, appImage, record.InstallPath)
+	assert.Equal(t, desktopFile, record.DesktopFile)
+	assert.True(t, record.Metadata.Adopted)
+
+	// The same installation cannot be adopted twice, under any name
+	err = runAdoptCmd(fs, resolver, cfg, &log, &adoptOptions{name: "notes"}, desktopFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already installed")
+}
@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/quantmind-br/upkg/internal/backends"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/fetch"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/history"
+	"github.com/quantmind-br/upkg/internal/hooks"
+	"github.com/quantmind-br/upkg/internal/manifest"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// applyOptions holds the flags of the apply command
+type applyOptions struct {
+	prune       bool
+	dryRun      bool
+	timeoutSecs int
+}
+
+// NewApplyCmd creates the apply command
+func NewApplyCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &applyOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "apply <manifest.yaml>",
+		Short: "Install, upgrade and remove packages to match a manifest",
+		Long: `Reconcile the installed packages with a YAML manifest.
+
+Packages listed in the manifest but not installed are installed, and
+installed ones whose source changed (another file, URL, repository or
+pinned tag) are upgraded from the new source. Packages are matched by name.
+With --prune, packages installed by an earlier apply (or claimed by one,
+when a listed name was already installed) that are no longer listed are
+uninstalled; other packages are never touched.
+
+  kind: manifest
+  schemaVersion: 1
+  packages:
+    - name: obsidian
+      source: gh:obsidianmd/obsidian-releases
+    - name: cursor
+      source: https://downloader.cursor.sh/linux/appImage/x64
+      sandbox: true
+    - name: lens
+      source: ~/Downloads/lens.deb
+      method: extract
+
+The source is a local path (relative to the manifest), an http(s) URL,
+gh:owner/repo[@tag] or a Flatpak app ID. Per-package options mirror the
+install flags: sha256, method, sandbox, hidpi, desktop, skipDesktop,
+skipWaylandEnv, exposeAllBins, bins, binDesktops and selfUpdating. GitHub
+sources without a tag are not upgraded by apply; use check-updates for them.`,
+		Example: `  upkg apply ~/dotfiles/upkg.yaml --dry-run
+  upkg apply ~/dotfiles/upkg.yaml --prune`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			fs := afero.NewOsFs()
+			defer startCacheBatch(fs, helpers.NewOSCommandRunner(), cfg, log, false)()
+			return runApplyCmd(fs, cfg, log, opts, args[0])
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.prune, "prune", false, "uninstall packages installed by apply that are no longer listed")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "show the changes without applying them")
+	cmd.Flags().IntVar(&opts.timeoutSecs, "timeout", 600, "timeout of each install or upgrade in seconds")
+
+	return cmd
+}
+
+func runApplyCmd(fs afero.Fs, cfg *config.Config, log *zerolog.Logger, opts *applyOptions, manifestPath string) error {
+	m, err := manifest.Load(fs, manifestPath)
+	if err != nil {
+		color.Red("Error: %v", err)
+		return err
+	}
+	for _, pkg := range m.Packages {
+		if err := validateInstallMethod(pkg.Method); err != nil {
+			color.Red("Error: package %s: %v", pkg.Name, err)
+			return fmt.Errorf("package %s: %w", pkg.Name, err)
+		}
+	}
+
+	ctx := context.Background()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		color.Red("Error: failed to open database: %v", err)
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	installs, err := database.List(ctx)
+	if err != nil {
+		color.Red("Error: failed to query database: %v", err)
+		return fmt.Errorf("failed to query database: %w", err)
+	}
+	records := make([]*core.InstallRecord, 0, len(installs))
+	for i := range installs {
+		records = append(records, db.ToInstallRecord(&installs[i]))
+	}
+
+	changes := manifest.Plan(m, records, opts.prune)
+	pending := printApplyPlan(changes)
+	if opts.dryRun {
+		color.Yellow("Dry run: no changes were made")
+		return nil
+	}
+
+	// Listed packages that were already installed become managed by apply
+	for _, change := range changes {
+		if change.Action != manifest.ActionKeep || change.Record.Metadata.Manifest {
+			continue
+		}
+		if err := claimRecord(ctx, database, change.Record); err != nil {
+			log.Warn().Err(err).Str("name", change.Record.Name).Msg("failed to mark package as managed by apply")
+		}
+	}
+	if pending == 0 {
+		color.Green("✓ Everything matches %s", manifestPath)
+		return nil
+	}
+
+	registry := backends.NewRegistry(cfg, log)
+	tracker := startStatus(paths.NewResolver(cfg).GetStatusFile(), log, "apply", pending)
+	defer tracker.Finish()
+
+	var failed []string
+	step := 0
+	for _, change := range changes {
+		if change.Action == manifest.ActionKeep || change.Action == manifest.ActionSkip {
+			continue
+		}
+		step++
+		fmt.Println()
+		color.Cyan("[%d/%d] %s %s", step, pending, change.Action, change.Name())
+
+		tracker.Begin(change.Name())
+		err := applyChange(ctx, cfg, log, registry, database, opts, change)
+		tracker.Done(change.Name(), err)
+		if err != nil {
+			log.Warn().Err(err).Str("name", change.Name()).Str("action", string(change.Action)).Msg("apply step failed")
+			failed = append(failed, change.Name())
+		}
+	}
+
+	fmt.Println()
+	if len(failed) > 0 {
+		color.Red("✗ %d of %d changes failed: %s", len(failed), pending, strings.Join(failed, ", "))
+		return fmt.Errorf("%d of %d changes failed", len(failed), pending)
+	}
+	color.Green("✓ Applied %s (%d changes)", manifestPath, pending)
+	return nil
+}
+
+// printApplyPlan lists the changes of a plan and returns how many need work
+func printApplyPlan(changes []manifest.Change) int {
+	counts := make(map[manifest.Action]int)
+	for _, change := range changes {
+		counts[change.Action]++
+		switch change.Action {
+		case manifest.ActionInstall:
+			color.Green("  + %s (%s)", change.Name(), change.Package.Source)
+		case manifest.ActionUpgrade:
+			color.Yellow("  ~ %s (%s)", change.Name(), change.Reason)
+		case manifest.ActionRemove:
+			color.Red("  - %s", change.Name())
+		case manifest.ActionSkip:
+			color.Yellow("  = %s (%s, skipped)", change.Name(), change.Reason)
+		}
+	}
+	fmt.Printf("📋 %d to install, %d to upgrade, %d to remove, %d unchanged, %d skipped\n",
+		counts[manifest.ActionInstall], counts[manifest.ActionUpgrade], counts[manifest.ActionRemove], counts[manifest.ActionKeep], counts[manifest.ActionSkip])
+	return len(changes) - counts[manifest.ActionKeep] - counts[manifest.ActionSkip]
+}
+
+// applyChange performs one install, upgrade or removal of a plan
+func applyChange(ctx context.Context, cfg *config.Config, log *zerolog.Logger, registry *backends.Registry, database *db.DB, opts *applyOptions, change manifest.Change) error {
+	switch change.Action {
+	case manifest.ActionInstall:
+		_, err := runInstallCmd(cfg, log, manifestInstallOptions(change.Package, opts.timeoutSecs), change.Package.Source)
+		return err
+	case manifest.ActionUpgrade:
+		return upgradeFromManifest(cfg, log, opts, change)
+	case manifest.ActionRemove:
+		started := time.Now()
+		err := performUninstall(ctx, registry, database, log, hooks.NewRunner(cfg.Hooks, log), newPayloadStash(afero.NewOsFs(), cfg), change.Record)
+		recordHistory(afero.NewOsFs(), paths.NewResolver(cfg).GetHistoryFile(), log, historyRecordEntry(history.OpUninstall, change.Record), started, err)
+		if err == nil {
+			forgetIntegrity(afero.NewOsFs(), paths.NewResolver(cfg).GetIntegrityDir(), log, change.Record.InstallID)
+		}
+		return err
+	}
+	return nil
+}
+
+// manifestInstallOptions maps the options of a manifest package to install flags
+func manifestInstallOptions(pkg *manifest.Package, timeoutSecs int) *installOptions {
+	return &installOptions{
+		customName:     pkg.Name,
+		sha256:         pkg.SHA256,
+		method:         pkg.Method,
+		sandbox:        pkg.Sandbox,
+		hiDPI:          pkg.HiDPI,
+		desktop:        pkg.Desktop,
+		skipDesktop:    pkg.SkipDesktop,
+		skipWaylandEnv: pkg.SkipWaylandEnv,
+		exposeAllBins:  pkg.ExposeAllBins,
+		bins:           pkg.Bins,
+		binDesktops:    pkg.BinDesktops,
+		selfUpdating:   pkg.SelfUpdating,
+		timeoutSecs:    timeoutSecs,
+		manifest:       true,
+	}
+}
+
+// upgradeFromManifest fetches the new source of a package and upgrades the
+// installation to it
+func upgradeFromManifest(cfg *config.Config, log *zerolog.Logger, opts *applyOptions, change manifest.Change) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(opts.timeoutSecs)*time.Second)
+	defer cancel()
+
+	pkg := change.Package
+	upgradeOpts := &upgradeOptions{
+		timeoutSecs:    opts.timeoutSecs,
+		skipDesktop:    pkg.SkipDesktop,
+		skipWaylandEnv: pkg.SkipWaylandEnv,
+		hiDPI:          pkg.HiDPI,
+		manifest:       true,
+	}
+
+	packagePath, expectedSHA256 := pkg.Source, pkg.SHA256
+	if fetch.IsGitHubSpec(packagePath) {
+		resolved, err := resolveGitHubSource(ctx, cfg, log, packagePath)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return err
+		}
+		packagePath = resolved.asset.DownloadURL
+		upgradeOpts.sourceRepo = resolved.spec.Repository()
+		upgradeOpts.sourceTag = resolved.release.TagName
+		if expectedSHA256 == "" {
+			expectedSHA256 = resolved.asset.SHA256()
+		}
+	}
+	if fetch.IsURL(packagePath) {
+		upgradeOpts.sourceURL = packagePath
+		localPath, err := downloadPackage(ctx, cfg, log, packagePath, expectedSHA256, false)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return err
+		}
+		packagePath = localPath
+	} else if expectedSHA256 != "" {
+		if err := verifyPackageSHA256(packagePath, expectedSHA256); err != nil {
+			color.Red("Error: %v", err)
+			return err
+		}
+	}
+
+	return runUpgradeCmd(cfg, log, upgradeOpts, change.Record.InstallID, packagePath)
+}
+
+// claimRecord marks an installed package as managed by apply
+func claimRecord(ctx context.Context, database *db.DB, record *core.InstallRecord) error {
+	stored, err := database.Get(ctx, record.InstallID)
+	if err != nil {
+		return err
+	}
+	record.Metadata.Manifest = true
+	dbRecord := db.FromInstallRecord(record)
+	dbRecord.Metadata = mergeMetadata(stored.Metadata, dbRecord.Metadata)
+	return database.Update(ctx, dbRecord)
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunApplyCmd(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{
+		DBFile:  filepath.Join(tmpDir, "test.db"),
+		DataDir: tmpDir,
+	}}
+	log := zerolog.New(io.Discard)
+
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	require.NoError(t, database.Create(ctx, &db.Install{
+		InstallID:    "editor-id",
+		PackageType:  "appimage",
+		Name:         "editor",
+		InstallDate:  time.Now(),
+		OriginalFile: "/pkgs/editor.AppImage",
+		InstallPath:  "/apps/editor.AppImage",
+		Metadata:     map[string]interface{}{"wrapper_script": "/bin/editor"},
+	}))
+	require.NoError(t, database.Close())
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/pkgs/upkg.yaml", []byte("packages:\n  - name: editor\n    source: editor.AppImage\n"), 0644))
+
+	getEditor := func() *db.Install {
+		database, err := db.New(ctx, cfg.Paths.DBFile)
+		require.NoError(t, err)
+		defer func() { _ = database.Close() }()
+		install, err := database.Get(ctx, "editor-id")
+		require.NoError(t, err)
+		return install
+	}
+
+	// A dry run leaves the record alone
+	require.NoError(t, runApplyCmd(fs, cfg, &log, &applyOptions{dryRun: true, timeoutSecs: 60}, "/pkgs/upkg.yaml"))
+	assert.False(t, db.ToInstallRecord(getEditor()).Metadata.Manifest)
+
+	// An unchanged listed package is claimed without reinstalling it
+	require.NoError(t, runApplyCmd(fs, cfg, &log, &applyOptions{timeoutSecs: 60}, "/pkgs/upkg.yaml"))
+	install := getEditor()
+	record := db.ToInstallRecord(install)
+	assert.True(t, record.Metadata.Manifest)
+	assert.Equal(t, "/bin/editor", record.Metadata.WrapperScript)
+
+	// Pinned packages are neither upgraded nor pruned
+	database, err = db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	install.Metadata["pinned"] = true
+	require.NoError(t, database.Update(ctx, install))
+	require.NoError(t, database.Create(ctx, &db.Install{
+		InstallID:   "held-id",
+		PackageType: "appimage",
+		Name:        "held",
+		InstallDate: time.Now(),
+		InstallPath: "/apps/held.AppImage",
+		Metadata:    map[string]interface{}{"manifest": true, "pinned": true},
+	}))
+	require.NoError(t, database.Close())
+	require.NoError(t, afero.WriteFile(fs, "/pkgs/moved.yaml", []byte("packages:\n  - name: editor\n    source: editor-2.AppImage\n"), 0644))
+	require.NoError(t, runApplyCmd(fs, cfg, &log, &applyOptions{prune: true, timeoutSecs: 60}, "/pkgs/moved.yaml"))
+	assert.Equal(t, "/pkgs/editor.AppImage", getEditor().OriginalFile)
+	database, err = db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	_, err = database.Get(ctx, "held-id")
+	require.NoError(t, err, "the pinned package is still installed")
+	require.NoError(t, database.Close())
+
+	// Invalid per-package options are rejected before anything runs
+	require.NoError(t, afero.WriteFile(fs, "/pkgs/bad.yaml", []byte("packages:\n  - name: editor\n    source: editor.AppImage\n    method: zypper\n"), 0644))
+	err = runApplyCmd(fs, cfg, &log, &applyOptions{timeoutSecs: 60}, "/pkgs/bad.yaml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid --method")
+}
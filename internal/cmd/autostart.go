@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/desktop"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// NewAutostartCmd creates the autostart command
+func NewAutostartCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "autostart",
+		Short: "Start installed apps when you log in",
+		Long: `Manage the FreeDesktop autostart entries of installed packages. An enabled
+package gets a desktop file in ~/.config/autostart that starts it through
+its wrapper (or the program of its desktop entry) at login. The entry is
+kept across upgrades and renames and removed on uninstall.`,
+	}
+
+	cmd.AddCommand(newAutostartToggleCmd(cfg, log, true))
+	cmd.AddCommand(newAutostartToggleCmd(cfg, log, false))
+
+	return cmd
+}
+
+func newAutostartToggleCmd(cfg *config.Config, log *zerolog.Logger, enable bool) *cobra.Command {
+	use, short := "disable", "Stop starting a package at login"
+	if enable {
+		use, short = "enable", "Start a package at login"
+	}
+
+	return &cobra.Command{
+		Use:               use + " [package-name or install-id]",
+		Short:             short,
+		Example:           fmt.Sprintf("  upkg autostart %s slack", use),
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeInstalledPackages(cfg, 1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runAutostartCmd(afero.NewOsFs(), cfg, log, args[0], enable)
+		},
+	}
+}
+
+func runAutostartCmd(fs afero.Fs, cfg *config.Config, log *zerolog.Logger, identifier string, enable bool) error {
+	ctx := context.Background()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	record, err := lookupPackage(ctx, database, log, identifier)
+	if err != nil {
+		return err
+	}
+
+	previous := record.Metadata.Autostart
+	if enable {
+		if err := enableAutostart(fs, cfg, record); err != nil {
+			ui.PrintError("%v", err)
+			return err
+		}
+	} else {
+		if previous == "" {
+			ui.PrintInfo("%s does not start at login", record.Name)
+			return nil
+		}
+		if err := fs.Remove(previous); err != nil && !os.IsNotExist(err) {
+			ui.PrintError("failed to remove autostart entry: %v", err)
+			return fmt.Errorf("remove autostart entry: %w", err)
+		}
+		record.Metadata.Autostart = ""
+	}
+
+	dbRecord := db.FromInstallRecord(record)
+	if stored, getErr := database.Get(ctx, record.InstallID); getErr == nil {
+		dbRecord.Metadata = mergeMetadata(stored.Metadata, dbRecord.Metadata)
+	}
+	if err := database.Update(ctx, dbRecord); err != nil {
+		ui.PrintError("failed to save autostart setting: %v", err)
+		return fmt.Errorf("update database: %w", err)
+	}
+
+	if enable {
+		log.Info().Str("name", record.Name).Str("path", record.Metadata.Autostart).Msg("autostart enabled")
+		ui.PrintSuccess("%s starts at login", record.Name)
+		ui.PrintKeyValue("Autostart entry", record.Metadata.Autostart)
+	} else {
+		log.Info().Str("name", record.Name).Str("path", previous).Msg("autostart disabled")
+		ui.PrintSuccess("%s no longer starts at login", record.Name)
+	}
+	return nil
+}
+
+// enableAutostart writes the autostart entry of record and stores its path
+// in the metadata, replacing an entry written under another name
+func enableAutostart(fs afero.Fs, cfg *config.Config, record *core.InstallRecord) error {
+	entry, err := autostartEntry(fs, record)
+	if err != nil {
+		return err
+	}
+
+	name := helpers.NormalizeFilename(record.Name) + ".desktop"
+	if record.DesktopFile != "" {
+		name = filepath.Base(record.DesktopFile)
+	}
+	path := filepath.Join(paths.NewResolver(cfg).GetAutostartDir(), name)
+
+	var buf bytes.Buffer
+	if err := desktop.Write(&buf, entry); err != nil {
+		return fmt.Errorf("render autostart entry: %w", err)
+	}
+	if err := fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create autostart dir: %w", err)
+	}
+	if err := afero.WriteFile(fs, path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("write autostart entry: %w", err)
+	}
+
+	if previous := record.Metadata.Autostart; previous != "" && previous != path {
+		if err := fs.Remove(previous); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove previous autostart entry: %w", err)
+		}
+	}
+	record.Metadata.Autostart = path
+	return nil
+}
+
+// autostartEntry derives the autostart entry of record from its desktop
+// entry, launching the wrapper when there is one
+func autostartEntry(fs afero.Fs, record *core.InstallRecord) (*core.DesktopEntry, error) {
+	entry := &core.DesktopEntry{Type: "Application", Name: record.Name}
+	if record.DesktopFile != "" {
+		if file, err := fs.Open(record.DesktopFile); err == nil {
+			parsed, parseErr := desktop.Parse(file)
+			_ = file.Close()
+			if parseErr == nil {
+				entry = parsed
+			}
+		}
+	}
+
+	// The desktop entry may already run the wrapper with edited env and args
+	wrapper := record.Metadata.WrapperScript
+	switch {
+	case wrapper != "" && desktop.ExecProgram(entry.Exec) != wrapper:
+		entry.Exec, entry.TryExec = wrapper, wrapper
+	case entry.Exec != "":
+	case record.PackageType == core.PackageTypeAppImage || record.PackageType == core.PackageTypeBinary:
+		entry.Exec, entry.TryExec = record.InstallPath, record.InstallPath
+	default:
+		return nil, fmt.Errorf("%s has no wrapper or desktop entry to start", record.Name)
+	}
+
+	entry.NoDisplay = false
+	if err := desktop.Validate(entry); err != nil {
+		return nil, fmt.Errorf("invalid autostart entry: %w", err)
+	}
+	return entry, nil
+}
+
+// removeAutostart deletes the autostart entry of an uninstalled package
+func removeAutostart(fs afero.Fs, log *zerolog.Logger, record *core.InstallRecord) {
+	if record.Metadata.Autostart == "" {
+		return
+	}
+	if err := fs.Remove(record.Metadata.Autostart); err != nil && !os.IsNotExist(err) {
+		log.Warn().Err(err).Str("path", record.Metadata.Autostart).Msg("failed to remove autostart entry")
+	}
+}
+
+// carryAutostart rewrites the autostart entry of an upgraded or renamed
+// package so it starts the new files. It returns a warning when it could
+// not be rewritten.
+func carryAutostart(fs afero.Fs, cfg *config.Config, oldRecord, newRecord *core.InstallRecord) string {
+	if oldRecord.Metadata.Autostart == "" {
+		return ""
+	}
+	newRecord.Metadata.Autostart = oldRecord.Metadata.Autostart
+	if err := enableAutostart(fs, cfg, newRecord); err != nil {
+		return fmt.Sprintf("autostart entry was not updated: %v", err)
+	}
+	return ""
+}
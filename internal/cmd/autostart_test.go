@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunAutostartCmd(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: filepath.Join(tmpDir, "test.db")}}
+	log := zerolog.New(io.Discard)
+	fs := afero.NewMemMapFs()
+
+	const (
+		wrapper = "/home/u/.local/bin/tray-app"
+		entry   = "/home/u/.local/share/applications/tray-app.desktop"
+	)
+	record := &core.InstallRecord{
+		InstallID: "tray-id", PackageType: core.PackageTypeTarball, Name: "Tray App", InstallDate: time.Now(),
+		DesktopFile: entry,
+		Metadata:    core.Metadata{WrapperScript: wrapper},
+	}
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	require.NoError(t, database.Create(ctx, db.FromInstallRecord(record)))
+	require.NoError(t, database.Close())
+	require.NoError(t, afero.WriteFile(fs, entry, []byte("[Desktop Entry]\nType=Application\nName=Tray App\nExec=env GDK_SCALE=2 "+wrapper+" %U\nIcon=tray-app\nNoDisplay=true\n"), 0644))
+
+	stored := func() *core.InstallRecord {
+		database, err := db.New(ctx, cfg.Paths.DBFile)
+		require.NoError(t, err)
+		defer func() { _ = database.Close() }()
+		install, err := database.Get(ctx, "tray-id")
+		require.NoError(t, err)
+		return db.ToInstallRecord(install)
+	}
+
+	require.NoError(t, runAutostartCmd(fs, cfg, &log, "Tray App", true))
+	autostart := filepath.Join(paths.NewResolver(cfg).GetAutostartDir(), "tray-app.desktop")
+	assert.Equal(t, autostart, stored().Metadata.Autostart)
+	content, err := afero.ReadFile(fs, autostart)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Exec=env GDK_SCALE=2 "+wrapper+" %U", "desktop edits are kept")
+	assert.Contains(t, string(content), "Icon=tray-app")
+	assert.NotContains(t, string(content), "NoDisplay")
+
+	require.NoError(t, runAutostartCmd(fs, cfg, &log, "tray-id", false))
+	assert.Empty(t, stored().Metadata.Autostart)
+	exists, err := afero.Exists(fs, autostart)
+	require.NoError(t, err)
+	assert.False(t, exists)
+	require.NoError(t, runAutostartCmd(fs, cfg, &log, "tray-id", false), "disabling twice is fine")
+
+	assert.Error(t, runAutostartCmd(fs, cfg, &log, "missing", true))
+}
+
+func TestAutostartEntry(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	// Without a desktop entry the wrapper is started under the package name
+	entry, err := autostartEntry(fs, &core.InstallRecord{Name: "tool", Metadata: core.Metadata{WrapperScript: "/bin/tool"}})
+	require.NoError(t, err)
+	assert.Equal(t, "/bin/tool", entry.Exec)
+	assert.Equal(t, "tool", entry.Name)
+
+	entry, err = autostartEntry(fs, &core.InstallRecord{Name: "app", PackageType: core.PackageTypeAppImage, InstallPath: "/apps/app.AppImage"})
+	require.NoError(t, err)
+	assert.Equal(t, "/apps/app.AppImage", entry.Exec)
+
+	_, err = autostartEntry(fs, &core.InstallRecord{Name: "lib", PackageType: core.PackageTypeDeb})
+	assert.Error(t, err)
+
+	// Upgrades and renames move the entry with the package
+	cfg := &config.Config{}
+	oldRecord := &core.InstallRecord{Name: "tool", Metadata: core.Metadata{WrapperScript: "/bin/tool"}}
+	require.NoError(t, enableAutostart(fs, cfg, oldRecord))
+	newRecord := &core.InstallRecord{Name: "editor", Metadata: core.Metadata{WrapperScript: "/bin/editor"}}
+	assert.Empty(t, carryAutostart(fs, cfg, oldRecord, newRecord))
+	assert.Equal(t, "editor.desktop", filepath.Base(newRecord.Metadata.Autostart))
+	exists, err := afero.Exists(fs, oldRecord.Metadata.Autostart)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/tw"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/fetch"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+)
+
+// defaultInstallJobs is how many packages a batch install handles at once
+const defaultInstallJobs = 4
+
+// serializedBackends drive a system package manager with a global lock, so
+// batch installs run them one at a time
+var serializedBackends = map[string]bool{
+	string(core.PackageTypeDeb):     true,
+	string(core.PackageTypeRpm):     true,
+	string(core.PackageTypeFlatpak): true,
+}
+
+// batchResult is the outcome of one package of a batch install
+type batchResult struct {
+	label  string
+	result *core.InstallResult
+	err    error
+}
+
+// runBatchInstall installs several packages concurrently behind a combined
+// progress display and prints a summary table
+func runBatchInstall(out io.Writer, cfg *config.Config, log *zerolog.Logger, opts *installOptions, packages []string) error {
+	for _, pkg := range packages {
+		if strings.HasPrefix(pkg, groupPrefix) {
+			color.Red("Error: %s cannot be combined with other packages", pkg)
+			return fmt.Errorf("group %s cannot be combined with other packages", pkg)
+		}
+	}
+	if opts.customName != "" || opts.sha256 != "" {
+		color.Red("Error: --name and --sha256 cannot be used when installing several packages")
+		return fmt.Errorf("--name and --sha256 cannot be used when installing several packages")
+	}
+
+	warnInterruptedOperations(cfg, log)
+	if err := checkTargetDirs(cfg, log); err != nil {
+		color.Red("Error: %v", err)
+		return err
+	}
+
+	jobs := min(max(opts.jobs, 1), len(packages))
+	color.Cyan("📦 Installing %d packages (%d at a time)", len(packages), jobs)
+
+	labels := make([]string, len(packages))
+	for i, pkg := range packages {
+		labels[i] = batchLabel(pkg)
+	}
+
+	// Per-package messages would interleave; the progress rows and the
+	// summary replace them
+	restoreOutput := silenceColorOutput()
+	bars := ui.NewMultiProgress(out, labels, isInteractive())
+
+	tracker := startStatus(paths.NewResolver(cfg).GetStatusFile(), log, "install", len(packages))
+	defer tracker.Finish()
+
+	var (
+		wg         sync.WaitGroup
+		systemLock sync.Mutex
+		slots      = make(chan struct{}, jobs)
+		results    = make([]batchResult, len(packages))
+	)
+	for i, pkg := range packages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			memberOpts := *opts
+			memberOpts.batch = true
+			memberOpts.progress = bars.Sink(i)
+			memberOpts.systemLock = &systemLock
+
+			bars.Start(i)
+			tracker.Begin(labels[i])
+			result, err := reportInstall(cfg, log, &memberOpts, pkg)
+			tracker.Done(labels[i], err)
+			if err != nil {
+				log.Warn().Err(err).Str("package", pkg).Msg("batch member install failed")
+			}
+			results[i] = batchResult{label: labels[i], result: result, err: err}
+			bars.Done(i, err)
+		}()
+	}
+	wg.Wait()
+	bars.Stop()
+	restoreOutput()
+
+	return printBatchSummary(out, results)
+}
+
+// printBatchSummary renders the per-package outcome of a batch install
+func printBatchSummary(out io.Writer, results []batchResult) error {
+	_, _ = fmt.Fprintln(out)
+	table := tablewriter.NewTable(out,
+		tablewriter.WithHeader([]string{"Package", "Status", "Type", "Details"}),
+		tablewriter.WithAlignment(tw.MakeAlign(4, tw.AlignLeft)),
+		tablewriter.WithSymbols(tw.NewSymbols(tw.StyleLight)),
+	)
+
+	var failed int
+	for _, result := range results {
+		row := []string{result.label, ui.SprintSuccess("installed"), "-", ""}
+		switch {
+		case result.err != nil:
+			failed++
+			row[1] = ui.SprintError("failed")
+			row[3] = result.err.Error()
+		case result.result.GetRecord() != nil:
+			record := result.result.Record
+			row[2] = ui.ColorizePackageType(string(record.PackageType))
+			row[3] = record.Name
+			if record.Version != "" {
+				row[3] += " " + record.Version
+			}
+			if n := len(result.result.Warnings); n > 0 {
+				row[3] += fmt.Sprintf(" (%d warnings)", n)
+			}
+		}
+		if err := table.Append(row); err != nil {
+			return fmt.Errorf("append table row: %w", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("render table: %w", err)
+	}
+
+	if failed > 0 {
+		color.Red("✗ %d of %d packages failed to install", failed, len(results))
+		return fmt.Errorf("%d of %d packages failed to install", failed, len(results))
+	}
+	color.Green("✓ Installed %d packages", len(results))
+	return nil
+}
+
+// batchLabel shortens a package argument for the progress rows
+func batchLabel(pkg string) string {
+	switch {
+	case fetch.IsGitHubSpec(pkg):
+		return pkg
+	case fetch.IsURL(pkg):
+		if parsed, err := url.Parse(pkg); err == nil && path.Base(parsed.Path) != "/" && path.Base(parsed.Path) != "." {
+			return path.Base(parsed.Path)
+		}
+		return pkg
+	default:
+		return filepath.Base(pkg)
+	}
+}
+
+// silenceColorOutput discards fatih/color output until the returned function
+// is called
+func silenceColorOutput() func() {
+	output, errOutput := color.Output, color.Error
+	color.Output, color.Error = io.Discard, io.Discard
+	return func() {
+		color.Output, color.Error = output, errOutput
+	}
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"io"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunBatchInstall_RejectsUnsupported(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{}
+	log := zerolog.New(io.Discard)
+
+	err := runBatchInstall(io.Discard, cfg, &log, &installOptions{}, []string{"@dev-tools", "app.AppImage"})
+	assert.ErrorContains(t, err, "cannot be combined with other packages")
+
+	err = runBatchInstall(io.Discard, cfg, &log, &installOptions{customName: "app"}, []string{"a.AppImage", "b.AppImage"})
+	assert.ErrorContains(t, err, "cannot be used when installing several packages")
+}
+
+func TestBatchLabel(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "app.AppImage", batchLabel("/home/user/Downloads/app.AppImage"))
+	assert.Equal(t, "tool.tar.gz", batchLabel("https://example.com/releases/tool.tar.gz?token=x"))
+	assert.Equal(t, "https://example.com", batchLabel("https://example.com"))
+	assert.Equal(t, "gh:owner/repo@v1", batchLabel("gh:owner/repo@v1"))
+}
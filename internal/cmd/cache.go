@@ -0,0 +1,368 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/tw"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/fetch"
+	"github.com/quantmind-br/upkg/internal/layout"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/store"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// cacheListOptions holds the flags of the cache list command
+type cacheListOptions struct {
+	jsonOutput bool
+}
+
+// cacheCleanOptions holds the flags of the cache clean command
+type cacheCleanOptions struct {
+	olderThan string // Only entries unused for this long (30d, 12h); empty for all
+}
+
+// cacheReport is the JSON form of cache list
+type cacheReport struct {
+	Payloads  []store.Entry      `json:"payloads"`
+	Downloads []fetch.CachedFile `json:"downloads"`
+}
+
+// NewCacheCmd creates the cache command
+func NewCacheCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect or clean the download and payload caches",
+		Long: `upkg keeps two caches so reinstalls avoid repeating expensive work:
+
+Downloads of URL and gh: installs are kept in paths.cache_dir and reused
+while the server reports the same ETag (or Last-Modified) for the URL.
+The least recently used are evicted beyond cache.downloads_max_size_mb;
+install --no-cache downloads again.
+
+Uninstalled tarball and zip payloads are kept in <data_dir>/payloads, keyed
+by the SHA256 of the archive they came from. Installing the same archive
+again, under any name, moves the payload back instead of extracting it.
+The oldest are evicted beyond cache.max_size_mb; set cache.payloads = false
+to delete payloads on uninstall.`,
+	}
+
+	cmd.AddCommand(newCacheListCmd(cfg))
+	cmd.AddCommand(newCacheCleanCmd(cfg, log))
+
+	return cmd
+}
+
+func newCacheListCmd(cfg *config.Config) *cobra.Command {
+	opts := &cacheListOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the cached downloads and payloads",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runCacheListCmd(cmd.OutOrStdout(), afero.NewOsFs(), cfg, opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "output the cache contents in JSON format")
+
+	return cmd
+}
+
+func newCacheCleanCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &cacheCleanOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "clean [payload-hash-prefix...]",
+		Short: "Remove cached downloads and payloads",
+		Long: `Remove every cached download and payload, or only those unused for the
+--older-than age. Given hash prefixes, only the matching payloads are
+removed.
+
+Examples:
+  upkg cache clean                    # Empty both caches
+  upkg cache clean --older-than 30d   # Drop what was not used for a month
+  upkg cache clean 3fa9c1             # Drop one payload`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCacheCleanCmd(cmd.OutOrStdout(), afero.NewOsFs(), cfg, log, opts, args)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.olderThan, "older-than", "", "only remove entries unused for this long, e.g. 30d or 12h")
+
+	return cmd
+}
+
+// payloadStore opens the payload cache of cfg
+func payloadStore(fs afero.Fs, cfg *config.Config) *store.Store {
+	return store.New(fs, paths.NewResolver(cfg).GetPayloadCacheDir())
+}
+
+func runCacheListCmd(out io.Writer, fs afero.Fs, cfg *config.Config, opts *cacheListOptions) error {
+	report := cacheReport{Payloads: []store.Entry{}, Downloads: []fetch.CachedFile{}}
+
+	payloads, err := payloadStore(fs, cfg).List()
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	downloads, err := fetch.NewDownloader(downloadCacheDir(cfg)).Cached()
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	report.Payloads = append(report.Payloads, payloads...)
+	report.Downloads = append(report.Downloads, downloads...)
+
+	if opts.jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("encode cache list: %w", err)
+		}
+		return nil
+	}
+
+	if err := printCachedDownloads(out, cfg, report.Downloads); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(out)
+	return printCachedPayloads(out, cfg, report.Payloads)
+}
+
+func printCachedDownloads(out io.Writer, cfg *config.Config, files []fetch.CachedFile) error {
+	ui.PrintHeader("Downloads")
+	if len(files) == 0 {
+		ui.PrintInfo("No cached downloads")
+		return nil
+	}
+
+	table := tablewriter.NewTable(out,
+		tablewriter.WithHeader([]string{"File", "Size", "Last Used", "URL"}),
+		tablewriter.WithAlignment(tw.MakeAlign(4, tw.AlignLeft)),
+		tablewriter.WithSymbols(tw.NewSymbols(tw.StyleNone)),
+	)
+	var total int64
+	for _, file := range files {
+		total += file.Size
+		name, url := filepath.Base(file.Path), file.URL
+		if file.Partial {
+			name += " " + ui.SprintWarning("(partial)")
+		}
+		if url == "" {
+			url = "-"
+		}
+		if err := table.Append(name, formatBytes(file.Size), file.UsedAt.Format("2006-01-02 15:04"), url); err != nil {
+			return fmt.Errorf("append table row: %w", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("render table: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(out, "\n%d download(s), %s (limit %s)\n", len(files), formatBytes(total), formatBytes(cfg.Cache.DownloadsMaxSize()))
+	return nil
+}
+
+func printCachedPayloads(out io.Writer, cfg *config.Config, entries []store.Entry) error {
+	ui.PrintHeader("Payloads")
+	if len(entries) == 0 {
+		ui.PrintInfo("No cached payloads")
+		return nil
+	}
+
+	table := tablewriter.NewTable(out,
+		tablewriter.WithHeader([]string{"Hash", "Name", "Version", "Size", "Cached"}),
+		tablewriter.WithAlignment(tw.MakeAlign(5, tw.AlignLeft)),
+		tablewriter.WithSymbols(tw.NewSymbols(tw.StyleNone)),
+	)
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+		name, version := entry.Name, entry.Version
+		if name == "" {
+			name = "-"
+		}
+		if version == "" {
+			version = "-"
+		}
+		if err := table.Append(shortHash(entry.Hash), name, version, formatBytes(entry.Size), entry.CachedAt.Format("2006-01-02 15:04")); err != nil {
+			return fmt.Errorf("append table row: %w", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("render table: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(out, "\n%d payload(s), %s (limit %s)\n", len(entries), formatBytes(total), formatBytes(cfg.Cache.MaxSize()))
+	return nil
+}
+
+func runCacheCleanCmd(out io.Writer, fs afero.Fs, cfg *config.Config, log *zerolog.Logger, opts *cacheCleanOptions, prefixes []string) error {
+	// Entries last used before cutoff are removed, by default all of them
+	cutoff := time.Now()
+	if opts.olderThan != "" {
+		var err error
+		if cutoff, err = parseHistoryTime(opts.olderThan, time.Now()); err != nil {
+			ui.PrintError("invalid --older-than: %v", err)
+			return fmt.Errorf("invalid --older-than: %w", err)
+		}
+	}
+
+	payloads := payloadStore(fs, cfg)
+	var entries []store.Entry
+	if len(prefixes) == 0 {
+		all, err := payloads.List()
+		if err != nil {
+			ui.PrintError("%v", err)
+			return err
+		}
+		entries = all
+	}
+	for _, prefix := range prefixes {
+		matched, err := payloads.Match(prefix)
+		if err != nil {
+			ui.PrintError("%v", err)
+			return err
+		}
+		if len(matched) == 0 {
+			ui.PrintError("no cached payload matches %q", prefix)
+			return fmt.Errorf("no cached payload matches %q", prefix)
+		}
+		entries = append(entries, matched...)
+	}
+
+	var reclaimed int64
+	removed := map[string]bool{}
+	for _, entry := range entries {
+		if removed[entry.Hash] || !entry.CachedAt.Before(cutoff) {
+			continue
+		}
+		if err := payloads.Remove(entry.Hash); err != nil {
+			ui.PrintError("%v", err)
+			return err
+		}
+		removed[entry.Hash] = true
+		reclaimed += entry.Size
+		log.Debug().Str("hash", entry.Hash).Str("name", entry.Name).Msg("removed cached payload")
+	}
+
+	var downloads int
+	if len(prefixes) == 0 {
+		downloader := fetch.NewDownloader(downloadCacheDir(cfg))
+		files, err := downloader.Cached()
+		if err != nil {
+			ui.PrintError("%v", err)
+			return err
+		}
+		for _, file := range files {
+			if !file.UsedAt.Before(cutoff) {
+				continue
+			}
+			if err := downloader.Remove(file); err != nil {
+				ui.PrintError("%v", err)
+				return err
+			}
+			downloads++
+			reclaimed += file.Size
+			log.Debug().Str("path", file.Path).Str("url", file.URL).Msg("removed cached download")
+		}
+	}
+
+	if len(removed) == 0 && downloads == 0 {
+		ui.PrintInfo("Nothing to clean")
+		return nil
+	}
+	_, _ = fmt.Fprintf(out, "Removed %d cached download(s) and %d payload(s), reclaimed %s\n", downloads, len(removed), formatBytes(reclaimed))
+	return nil
+}
+
+// payloadStash moves the payloads of uninstalled archives into the payload
+// cache instead of letting the backend delete them
+type payloadStash struct {
+	fs      afero.Fs
+	store   *store.Store
+	maxSize int64
+}
+
+// newPayloadStash returns the stash of cfg, or nil when cache.payloads is
+// disabled
+func newPayloadStash(fs afero.Fs, cfg *config.Config) *payloadStash {
+	if !cfg.Cache.Payloads {
+		return nil
+	}
+	return &payloadStash{fs: fs, store: payloadStore(fs, cfg), maxSize: cfg.Cache.MaxSize()}
+}
+
+// keep moves the payload of record into the cache and returns the function
+// that moves it back, or nil when nothing was cached. Only archive installs
+// record a content hash; self-updating apps change their payload, so it no
+// longer matches the archive.
+func (s *payloadStash) keep(log *zerolog.Logger, record *core.InstallRecord) func() {
+	hash := record.Metadata.ContentHash
+	if s == nil || hash == "" || record.PackageType != core.PackageTypeTarball || record.Metadata.SelfUpdating || s.store.Has(hash) {
+		return nil
+	}
+
+	dir := record.InstallPath
+	if release := record.Metadata.Release; release != "" && layout.Current(s.fs, dir) == release {
+		dir = filepath.Join(dir, release)
+	}
+	if !s.isDir(dir) {
+		return nil
+	}
+
+	entry := store.Entry{Hash: hash, Name: record.Name, Version: record.Version, Source: record.OriginalFile}
+	if err := s.store.Put(dir, entry); err != nil {
+		log.Warn().Err(err).Str("name", record.Name).Msg("failed to cache payload")
+		return nil
+	}
+	log.Debug().Str("name", record.Name).Str("hash", hash).Msg("cached payload")
+
+	return func() {
+		if _, err := s.store.Take(hash, dir); err != nil {
+			log.Warn().Err(err).Str("name", record.Name).Str("path", dir).Msg("failed to restore payload from cache")
+		}
+	}
+}
+
+// isDir reports whether path is a directory and not a link to one, which
+// belongs to someone else (install --from-dir --link)
+func (s *payloadStash) isDir(path string) bool {
+	if lstater, ok := s.fs.(afero.Lstater); ok {
+		info, _, err := lstater.LstatIfPossible(path)
+		return err == nil && info.Mode()&os.ModeSymlink == 0 && info.IsDir()
+	}
+	info, err := s.fs.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// prune evicts the oldest payloads beyond cache.max_size_mb
+func (s *payloadStash) prune(log *zerolog.Logger) {
+	if s == nil {
+		return
+	}
+	evicted, err := s.store.Prune(s.maxSize)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to prune payload cache")
+	}
+	for _, entry := range evicted {
+		log.Debug().Str("hash", entry.Hash).Str("name", entry.Name).Msg("evicted cached payload")
+	}
+}
+
+// shortHash abbreviates a content hash for display
+func shortHash(hash string) string {
+	return hash[:min(len(hash), 12)]
+}
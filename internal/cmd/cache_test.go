@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/store"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCacheTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+	return &config.Config{
+		Paths: config.PathsConfig{DataDir: "/data", CacheDir: t.TempDir()},
+		Cache: config.CacheConfig{Payloads: true, MaxSizeMB: 1},
+	}
+}
+
+func TestNewCacheCmd(t *testing.T) {
+	log := zerolog.Nop()
+	cmd := NewCacheCmd(&config.Config{}, &log)
+
+	names := []string{}
+	for _, sub := range cmd.Commands() {
+		names = append(names, sub.Name())
+	}
+	assert.ElementsMatch(t, []string{"list", "clean"}, names)
+}
+
+func TestRunCacheListAndClean(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cfg := newCacheTestConfig(t)
+	log := zerolog.Nop()
+	payloads := payloadStore(fs, cfg)
+
+	for _, c := range []string{"a", "b"} {
+		require.NoError(t, afero.WriteFile(fs, "/staged/"+c+"/app", []byte("payload"), 0755))
+		require.NoError(t, payloads.Put("/staged/"+c, store.Entry{Hash: strings.Repeat(c, 64), Name: "app-" + c}))
+	}
+
+	// Downloads live on the real disk: one recent, one unused for 40 days
+	recent := filepath.Join(cfg.Paths.CacheDir, "0123456789abcdef", "new.AppImage")
+	stale := filepath.Join(cfg.Paths.CacheDir, "fedcba9876543210", "old.AppImage")
+	for _, path := range []string{recent, stale} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("appimage"), 0644))
+	}
+	old := time.Now().AddDate(0, 0, -40)
+	require.NoError(t, os.Chtimes(stale, old, old))
+
+	var out bytes.Buffer
+	require.NoError(t, runCacheListCmd(&out, fs, cfg, &cacheListOptions{jsonOutput: true}))
+	var report cacheReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Len(t, report.Payloads, 2)
+	require.Len(t, report.Downloads, 2)
+	assert.Equal(t, stale, report.Downloads[0].Path, "least recently used first")
+
+	out.Reset()
+	require.NoError(t, runCacheCleanCmd(&out, fs, cfg, &log, &cacheCleanOptions{olderThan: "30d"}, nil))
+	assert.Contains(t, out.String(), "Removed 1 cached download(s) and 0 payload(s)")
+	assert.NoFileExists(t, stale)
+	assert.FileExists(t, recent)
+
+	out.Reset()
+	require.NoError(t, runCacheCleanCmd(&out, fs, cfg, &log, &cacheCleanOptions{}, []string{"aaaa"}))
+	assert.Contains(t, out.String(), "Removed 0 cached download(s) and 1 payload(s)")
+	assert.False(t, payloads.Has(strings.Repeat("a", 64)))
+	assert.True(t, payloads.Has(strings.Repeat("b", 64)))
+	assert.FileExists(t, recent, "prefixes only select payloads")
+
+	assert.Error(t, runCacheCleanCmd(&out, fs, cfg, &log, &cacheCleanOptions{}, []string{"ffff"}), "an unknown prefix fails")
+	assert.Error(t, runCacheCleanCmd(&out, fs, cfg, &log, &cacheCleanOptions{olderThan: "soon"}, nil))
+
+	out.Reset()
+	require.NoError(t, runCacheCleanCmd(&out, fs, cfg, &log, &cacheCleanOptions{}, nil))
+	assert.Contains(t, out.String(), "Removed 1 cached download(s) and 1 payload(s)")
+	assert.NoFileExists(t, recent)
+}
+
+func TestPayloadStash(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cfg := newCacheTestConfig(t)
+	log := zerolog.Nop()
+	hash := strings.Repeat("c", 64)
+
+	record := &core.InstallRecord{
+		Name:        "app",
+		PackageType: core.PackageTypeTarball,
+		InstallPath: "/data/apps/app",
+		Metadata:    core.Metadata{ContentHash: hash},
+	}
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(record.InstallPath, "app"), []byte("payload"), 0755))
+
+	assert.Nil(t, newPayloadStash(fs, &config.Config{}), "cache.payloads = false keeps nothing")
+
+	stash := newPayloadStash(fs, cfg)
+	restore := stash.keep(&log, record)
+	require.NotNil(t, restore)
+	assert.NoDirExists(t, record.InstallPath)
+	assert.True(t, stash.store.Has(hash))
+	assert.Nil(t, stash.keep(&log, record), "a cached hash is not stashed again")
+
+	restore()
+	exists, err := afero.Exists(fs, filepath.Join(record.InstallPath, "app"))
+	require.NoError(t, err)
+	assert.True(t, exists, "a failed uninstall gets its payload back")
+
+	record.Metadata.SelfUpdating = true
+	assert.Nil(t, stash.keep(&log, record), "self-updated payloads no longer match the archive")
+}
@@ -0,0 +1,491 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/tw"
+	"github.com/quantmind-br/upkg/internal/assets"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/fetch"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/quantmind-br/upkg/internal/versions"
+	"github.com/quantmind-br/upkg/internal/zsync"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// Update sources reported by check-updates
+const (
+	updateSourceGitHub = "github"
+	updateSourceURL    = "url"
+)
+
+// checkUpdatesOptions holds the flags of the check-updates command
+type checkUpdatesOptions struct {
+	jsonOutput  bool
+	install     bool
+	timeoutSecs int
+}
+
+// availableUpdate is an installed package with a newer upstream release
+type availableUpdate struct {
+	Name        string `json:"name"`
+	InstallID   string `json:"install_id"`
+	Source      string `json:"source"`
+	Installed   string `json:"installed"`
+	Latest      string `json:"latest"`
+	DownloadURL string `json:"download_url"`
+	// The app updates itself; --install leaves it to the app
+	SelfUpdating bool `json:"self_updating,omitempty"`
+
+	sha256     string
+	sourceRepo string
+	sourceTag  string
+	zsyncURL   string // Control file for a delta update, if published
+	seed       string // Installed AppImage the delta update starts from
+}
+
+// updateChecker queries upstream sources for newer releases
+type updateChecker struct {
+	cfg        *config.Config
+	github     *fetch.GitHubClient
+	downloader *fetch.Downloader
+}
+
+// NewCheckUpdatesCmd creates the check-updates command
+func NewCheckUpdatesCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &checkUpdatesOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "check-updates [name|install-id...]",
+		Short: "Check URL and GitHub installs for newer upstream releases",
+		Long: `Check packages installed from a URL or gh:owner/repo for newer upstream
+versions and list the outdated ones.
+
+GitHub installs compare the installed release tag with the latest release.
+URL installs are outdated when the server reports a modification time newer
+than the install date. Use --install to upgrade every outdated package.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCheckUpdatesCmd(cmd.OutOrStdout(), cfg, log, opts, args)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "output outdated packages in JSON format")
+	cmd.Flags().BoolVar(&opts.install, "install", false, "upgrade outdated packages")
+	cmd.Flags().IntVar(&opts.timeoutSecs, "timeout", 600, "timeout in seconds for each check and upgrade")
+	cmd.MarkFlagsMutuallyExclusive("json", "install")
+
+	return cmd
+}
+
+func runCheckUpdatesCmd(out io.Writer, cfg *config.Config, log *zerolog.Logger, opts *checkUpdatesOptions, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(opts.timeoutSecs)*time.Second)
+	defer cancel()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	installs, err := database.List(ctx)
+	if err != nil {
+		ui.PrintError("failed to list packages: %v", err)
+		return fmt.Errorf("list installs: %w", err)
+	}
+
+	records, err := updatableRecords(installs, args)
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	refreshAppVersions(ctx, database, helpers.NewOSCommandRunner(), installs, records, log)
+
+	checker := &updateChecker{
+		cfg:        cfg,
+		github:     newGitHubClient(cfg),
+		downloader: fetch.NewDownloader(cfg.Paths.CacheDir),
+	}
+
+	updates := make([]availableUpdate, 0)
+	var failed int
+	for _, record := range records {
+		update, checkErr := checker.check(ctx, record)
+		if checkErr != nil {
+			failed++
+			ui.PrintWarning("%s: %v", record.Name, checkErr)
+			log.Warn().Err(checkErr).Str("name", record.Name).Msg("update check failed")
+			continue
+		}
+		if update != nil {
+			updates = append(updates, *update)
+		}
+	}
+
+	// Failed checks are reported after the updates that were found
+	var checkErr error
+	if failed > 0 {
+		checkErr = withExitCode(ExitFailure, fmt.Errorf("%d of %d update checks failed", failed, len(records)))
+	}
+
+	if opts.jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(updates); err != nil {
+			return err
+		}
+		return checkErr
+	}
+
+	switch {
+	case len(records) == 0:
+		ui.PrintInfo("No packages installed from a URL or GitHub release")
+		return nil
+	case len(updates) == 0:
+		ui.PrintSuccess("%d of %d packages are up to date", len(records)-failed, len(records))
+		return checkErr
+	}
+
+	if err := printUpdatesTable(out, updates); err != nil {
+		return err
+	}
+	if !opts.install {
+		ui.PrintInfo("Run 'upkg check-updates --install' to upgrade them")
+		return checkErr
+	}
+	if err := installUpdates(cfg, log, opts, updates); err != nil {
+		return err
+	}
+	return checkErr
+}
+
+// updatableRecords returns the installs that have an upstream source,
+// restricted to the given names or install IDs when any are set
+func updatableRecords(installs []db.Install, identifiers []string) ([]*core.InstallRecord, error) {
+	return selectRecords(installs, identifiers, func(record *core.InstallRecord) bool {
+		return record.Metadata.SourceRepo != "" || record.Metadata.SourceURL != ""
+	})
+}
+
+// selectRecords returns the installs accepted by keep, sorted by name and
+// restricted to the given names or install IDs when any are set. Unknown
+// identifiers are an error.
+func selectRecords(installs []db.Install, identifiers []string, keep func(*core.InstallRecord) bool) ([]*core.InstallRecord, error) {
+	var records []*core.InstallRecord
+	matched := make(map[string]bool, len(identifiers))
+
+	for i := range installs {
+		record := db.ToInstallRecord(&installs[i])
+		if len(identifiers) > 0 {
+			selected := false
+			for _, identifier := range identifiers {
+				if identifier == record.InstallID || strings.EqualFold(identifier, record.Name) {
+					matched[identifier] = true
+					selected = true
+				}
+			}
+			if !selected {
+				continue
+			}
+		}
+		if keep(record) {
+			records = append(records, record)
+		}
+	}
+
+	for _, identifier := range identifiers {
+		if !matched[identifier] {
+			return nil, withExitCode(ExitNotFound, fmt.Errorf("package not found: %s", identifier))
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return strings.ToLower(records[i].Name) < strings.ToLower(records[j].Name)
+	})
+	return records, nil
+}
+
+// check returns the newer release of record, or nil when it is up to date
+func (c *updateChecker) check(ctx context.Context, record *core.InstallRecord) (*availableUpdate, error) {
+	if record.Metadata.SourceRepo != "" {
+		return c.checkGitHub(ctx, record)
+	}
+	return c.checkURL(ctx, record)
+}
+
+// checkGitHub compares the installed release tag with the latest release
+func (c *updateChecker) checkGitHub(ctx context.Context, record *core.InstallRecord) (*availableUpdate, error) {
+	spec, err := fetch.ParseGitHubSpec(fetch.GitHubPrefix + record.Metadata.SourceRepo)
+	if err != nil {
+		return nil, err
+	}
+	release, err := c.github.Release(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// A self-updating app may be past the release upkg installed
+	installed := record.Metadata.SourceTag
+	if record.Metadata.SelfUpdating && record.Metadata.AppVersion != "" {
+		installed = record.Metadata.AppVersion
+	}
+	if installed == "" {
+		installed = record.Version
+	}
+	if !versions.Newer(release.TagName, installed) {
+		return nil, nil
+	}
+
+	asset, _, err := selectGitHubAsset(release, spec, updateHints(record.PackageType, c.cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	update := &availableUpdate{
+		Name:         record.Name,
+		InstallID:    record.InstallID,
+		Source:       updateSourceGitHub,
+		Installed:    displayVersion(installed),
+		Latest:       release.TagName,
+		DownloadURL:  asset.DownloadURL,
+		SelfUpdating: record.Metadata.SelfUpdating,
+		sha256:       asset.SHA256(),
+		sourceRepo:   spec.Repository(),
+		sourceTag:    release.TagName,
+	}
+	// AppImage releases often publish a .zsync control file beside the asset
+	if control, ok := release.Asset(asset.Name + ".zsync"); ok && record.PackageType == core.PackageTypeAppImage {
+		update.zsyncURL = control.DownloadURL
+		update.seed = record.InstallPath
+	}
+	return update, nil
+}
+
+// checkURL treats a URL install as outdated when the remote file changed
+// after it was installed
+func (c *updateChecker) checkURL(ctx context.Context, record *core.InstallRecord) (*availableUpdate, error) {
+	info, err := c.downloader.Probe(ctx, record.Metadata.SourceURL)
+	if err != nil {
+		return nil, err
+	}
+	if info.LastModified.IsZero() {
+		return nil, fmt.Errorf("server does not report a modification time for %s", record.Metadata.SourceURL)
+	}
+	if !info.LastModified.After(record.InstallDate) {
+		return nil, nil
+	}
+
+	installed := record.Version
+	if installed == "" {
+		installed = record.InstallDate.Format("2006-01-02")
+	}
+	update := &availableUpdate{
+		Name:         record.Name,
+		InstallID:    record.InstallID,
+		Source:       updateSourceURL,
+		Installed:    installed,
+		Latest:       "modified " + info.LastModified.Local().Format("2006-01-02"),
+		DownloadURL:  record.Metadata.SourceURL,
+		SelfUpdating: record.Metadata.SelfUpdating,
+	}
+	// The AppImage may name its own control file in its update information
+	if record.PackageType == core.PackageTypeAppImage {
+		if controlURL := zsync.ControlURL(zsync.ReadUpdateInfo(record.InstallPath)); controlURL != "" {
+			update.zsyncURL = controlURL
+			update.seed = record.InstallPath
+		}
+	}
+	return update, nil
+}
+
+// refreshAppVersions probes the version self-updating apps report and saves
+// it, so update checks compare against what is actually installed
+func refreshAppVersions(ctx context.Context, database *db.DB, runner helpers.CommandRunner, installs []db.Install, records []*core.InstallRecord, log *zerolog.Logger) {
+	stored := make(map[string]map[string]interface{}, len(installs))
+	for i := range installs {
+		stored[installs[i].InstallID] = installs[i].Metadata
+	}
+
+	for _, record := range records {
+		if !record.Metadata.SelfUpdating {
+			continue
+		}
+		version := probeAppVersion(ctx, runner, record)
+		if version == "" || version == record.Metadata.AppVersion {
+			continue
+		}
+		record.Metadata.AppVersion = version
+		dbRecord := db.FromInstallRecord(record)
+		dbRecord.Metadata = mergeMetadata(stored[record.InstallID], dbRecord.Metadata)
+		if err := database.Update(ctx, dbRecord); err != nil {
+			log.Warn().Err(err).Str("name", record.Name).Msg("failed to save probed app version")
+		}
+	}
+}
+
+// updateHints restricts asset selection to the installed package type, since
+// an upgrade cannot change it
+func updateHints(packageType core.PackageType, cfg *config.Config) assets.Hints {
+	switch packageType {
+	case core.PackageTypeAppImage:
+		return assets.Hints{Formats: []string{assets.FormatAppImage}}
+	case core.PackageTypeTarball:
+		return assets.Hints{Formats: []string{assets.FormatTarball}, Exclude: []string{"*.zip"}}
+	case core.PackageTypeZip:
+		return assets.Hints{Formats: []string{assets.FormatTarball}, Include: []string{"*.zip"}}
+	case core.PackageTypeDeb:
+		return assets.Hints{Formats: []string{assets.FormatDeb}}
+	case core.PackageTypeRpm:
+		return assets.Hints{Formats: []string{assets.FormatRpm}}
+	case core.PackageTypeBinary:
+		return assets.Hints{Formats: []string{assets.FormatBinary}}
+	default:
+		return assets.Hints{Formats: cfg.Sources.FormatPreference}
+	}
+}
+
+// printUpdatesTable lists outdated packages
+func printUpdatesTable(out io.Writer, updates []availableUpdate) error {
+	table := tablewriter.NewTable(out,
+		tablewriter.WithHeader([]string{"Name", "Installed", "Latest", "Source"}),
+		tablewriter.WithAlignment(tw.MakeAlign(4, tw.AlignLeft)),
+		tablewriter.WithSymbols(tw.NewSymbols(tw.StyleNone)),
+	)
+	for _, update := range updates {
+		name := update.Name
+		if update.SelfUpdating {
+			name += " " + ui.SprintWarning("(self-updating)")
+		}
+		if err := table.Append(name, update.Installed, update.Latest, update.Source); err != nil {
+			return fmt.Errorf("append table row: %w", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("render table: %w", err)
+	}
+	return nil
+}
+
+// installUpdates downloads and upgrades each outdated package in turn
+func installUpdates(cfg *config.Config, log *zerolog.Logger, opts *checkUpdatesOptions, updates []availableUpdate) error {
+	defer startCacheBatch(afero.NewOsFs(), helpers.NewOSCommandRunner(), cfg, log, false)()
+
+	var failed []string
+	var skipped int
+	for i, update := range updates {
+		fmt.Println()
+		if update.SelfUpdating {
+			skipped++
+			ui.PrintWarning("[%d/%d] %s updates itself; skipped (run 'upkg upgrade %s <file>' to replace it anyway)",
+				i+1, len(updates), update.Name, update.Name)
+			continue
+		}
+		color.Cyan("[%d/%d] %s %s → %s", i+1, len(updates), update.Name, update.Installed, update.Latest)
+		if err := installUpdate(cfg, log, opts, update); err != nil {
+			log.Warn().Err(err).Str("name", update.Name).Msg("update failed")
+			failed = append(failed, update.Name)
+		}
+	}
+
+	fmt.Println()
+	if len(failed) > 0 {
+		color.Red("✗ %d of %d updates failed: %s", len(failed), len(updates), strings.Join(failed, ", "))
+		return fmt.Errorf("%d of %d updates failed", len(failed), len(updates))
+	}
+	color.Green("✓ %d packages updated", len(updates)-skipped)
+	return nil
+}
+
+// installUpdate downloads the new release and upgrades the installation to it
+func installUpdate(cfg *config.Config, log *zerolog.Logger, opts *checkUpdatesOptions, update availableUpdate) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(opts.timeoutSecs)*time.Second)
+	defer cancel()
+
+	localPath, err := deltaUpdate(ctx, cfg, log, update)
+	if err != nil {
+		if update.zsyncURL != "" {
+			ui.PrintWarning("Delta update failed (%v); downloading the full release", err)
+		}
+		localPath, err = downloadPackage(ctx, cfg, log, update.DownloadURL, update.sha256, false)
+	}
+	if err != nil {
+		color.Red("Error: %v", err)
+		return err
+	}
+
+	return runUpgradeCmd(cfg, log, &upgradeOptions{
+		timeoutSecs: opts.timeoutSecs,
+		sourceURL:   update.DownloadURL,
+		sourceRepo:  update.sourceRepo,
+		sourceTag:   update.sourceTag,
+	}, update.InstallID, localPath)
+}
+
+// errNoDelta means the update publishes no zsync control file
+var errNoDelta = errors.New("no delta update available")
+
+// deltaUpdate builds the new release from the installed AppImage and the
+// blocks that changed, writing it where the full download would be cached
+func deltaUpdate(ctx context.Context, cfg *config.Config, log *zerolog.Logger, update availableUpdate) (string, error) {
+	if update.zsyncURL == "" || update.seed == "" {
+		return "", errNoDelta
+	}
+	if _, err := os.Stat(update.seed); err != nil {
+		return "", fmt.Errorf("installed AppImage: %w", err)
+	}
+
+	dest := fetch.NewDownloader(downloadCacheDir(cfg)).Path(update.DownloadURL)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", fmt.Errorf("create download cache: %w", err)
+	}
+
+	color.Cyan("→ Downloading changed blocks of %s...", update.DownloadURL)
+	log.Info().Str("control", update.zsyncURL).Str("seed", update.seed).Msg("starting delta update")
+
+	progress := ui.NewProgress(ctx,
+		[]ui.InstallationPhase{{Name: "Downloading", Weight: 100, Deterministic: true}},
+		"Downloading changed blocks",
+		isInteractive(),
+	)
+	progress.StartPhase(0)
+	stats, err := zsync.Sync(ctx, update.zsyncURL, update.seed, dest, zsync.Options{
+		Progress: func(done, total int64) {
+			if total > 0 {
+				progress.SetProgress(int(done*1000/total), 1000)
+			}
+		},
+	})
+	if err != nil {
+		progress.Clear()
+		return "", err
+	}
+	progress.Finish()
+
+	if update.sha256 != "" {
+		if err := verifyPackageSHA256(dest, update.sha256); err != nil {
+			_ = os.Remove(dest)
+			return "", err
+		}
+	}
+
+	color.Green("✓ Delta update: downloaded %s of %s (%.0f%% saved)",
+		formatBytes(stats.Downloaded), formatBytes(stats.Length), stats.Saved()*100)
+	log.Info().Int64("downloaded", stats.Downloaded).Int64("reused", stats.Reused).Int64("length", stats.Length).Msg("delta update finished")
+	return dest, nil
+}
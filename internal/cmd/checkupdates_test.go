@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/assets"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/fetch"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const updateReleaseJSON = `{"tag_name":"v1.4.0","assets":[
+	{"name":"app-1.4.0.tar.gz","browser_download_url":"https://dl/tarball"},
+	{"name":"app-1.4.0.AppImage","browser_download_url":"https://dl/appimage","digest":"sha256:aa"},
+	{"name":"app-1.4.0.AppImage.zsync","browser_download_url":"https://dl/appimage.zsync"}
+]}`
+
+// newUpdateServer serves a GitHub latest release and a HEAD-able download
+func newUpdateServer(t *testing.T, modified time.Time) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/app/releases/latest":
+			_, _ = w.Write([]byte(updateReleaseJSON))
+		case "/download/tool.AppImage":
+			http.ServeContent(w, r, "tool.AppImage", modified, bytes.NewReader([]byte("payload")))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestUpdateChecker_GitHub(t *testing.T) {
+	t.Parallel()
+
+	server := newUpdateServer(t, time.Time{})
+	cfg := &config.Config{Sources: config.SourcesConfig{GitHubAPIURL: server.URL}}
+	checker := &updateChecker{cfg: cfg, github: newGitHubClient(cfg)}
+
+	record := &core.InstallRecord{
+		Name:        "app",
+		InstallID:   "id-1",
+		PackageType: core.PackageTypeTarball,
+		Metadata:    core.Metadata{SourceRepo: "owner/app", SourceTag: "v1.3.0"},
+	}
+	update, err := checker.check(context.Background(), record)
+	require.NoError(t, err)
+	require.NotNil(t, update)
+	assert.Equal(t, "v1.3.0", update.Installed)
+	assert.Equal(t, "v1.4.0", update.Latest)
+	assert.Equal(t, "https://dl/tarball", update.DownloadURL, "keeps the installed package type")
+	assert.Equal(t, "owner/app", update.sourceRepo)
+	assert.Empty(t, update.zsyncURL, "only AppImages are delta updated")
+
+	// An AppImage with a published control file is updated from its blocks
+	record.PackageType = core.PackageTypeAppImage
+	record.InstallPath = "/apps/app.AppImage"
+	update, err = checker.check(context.Background(), record)
+	require.NoError(t, err)
+	require.NotNil(t, update)
+	assert.Equal(t, "https://dl/appimage.zsync", update.zsyncURL)
+	assert.Equal(t, record.InstallPath, update.seed)
+
+	_, err = deltaUpdate(context.Background(), cfg, nil, availableUpdate{DownloadURL: "https://dl/tarball"})
+	assert.ErrorIs(t, err, errNoDelta)
+
+	record.Metadata.SourceTag = "v1.4.0"
+	update, err = checker.check(context.Background(), record)
+	require.NoError(t, err)
+	assert.Nil(t, update)
+
+	// A self-updating app is compared by the version it reports
+	record.Metadata.SourceTag = "v1.0.0"
+	record.Metadata.SelfUpdating = true
+	record.Metadata.AppVersion = "1.4.0"
+	update, err = checker.check(context.Background(), record)
+	require.NoError(t, err)
+	assert.Nil(t, update)
+
+	record.Metadata.AppVersion = "1.3.5"
+	update, err = checker.check(context.Background(), record)
+	require.NoError(t, err)
+	require.NotNil(t, update)
+	assert.Equal(t, "1.3.5", update.Installed)
+	assert.True(t, update.SelfUpdating)
+}
+
+func TestUpdateChecker_URL(t *testing.T) {
+	t.Parallel()
+
+	modified := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	server := newUpdateServer(t, modified)
+	checker := &updateChecker{cfg: &config.Config{}, downloader: fetch.NewDownloader(t.TempDir())}
+
+	record := &core.InstallRecord{
+		Name:        "tool",
+		InstallDate: modified.Add(-24 * time.Hour),
+		Metadata:    core.Metadata{SourceURL: server.URL + "/download/tool.AppImage"},
+	}
+	update, err := checker.check(context.Background(), record)
+	require.NoError(t, err)
+	require.NotNil(t, update)
+	assert.Equal(t, updateSourceURL, update.Source)
+	assert.Equal(t, record.Metadata.SourceURL, update.DownloadURL)
+
+	record.InstallDate = modified.Add(time.Hour)
+	update, err = checker.check(context.Background(), record)
+	require.NoError(t, err)
+	assert.Nil(t, update)
+
+	record.Metadata.SourceURL = server.URL + "/download/missing.AppImage"
+	_, err = checker.check(context.Background(), record)
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestUpdatableRecords(t *testing.T) {
+	t.Parallel()
+
+	installs := []db.Install{
+		{InstallID: "1", Name: "Zed", Metadata: map[string]interface{}{"source_repo": "zed/zed"}},
+		{InstallID: "2", Name: "local", Metadata: map[string]interface{}{}},
+		{InstallID: "3", Name: "alpha", Metadata: map[string]interface{}{"source_url": "https://example.com/a.AppImage"}},
+	}
+
+	records, err := updatableRecords(installs, nil)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "alpha", records[0].Name)
+	assert.Equal(t, "Zed", records[1].Name)
+
+	records, err = updatableRecords(installs, []string{"zed", "2"})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "Zed", records[0].Name)
+
+	_, err = updatableRecords(installs, []string{"missing"})
+	assert.ErrorContains(t, err, "package not found: missing")
+}
+
+func TestUpdateHints(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Sources: config.SourcesConfig{FormatPreference: []string{"deb"}}}
+	assert.Equal(t, []string{assets.FormatAppImage}, updateHints(core.PackageTypeAppImage, cfg).Formats)
+	assert.Equal(t, []string{"*.zip"}, updateHints(core.PackageTypeZip, cfg).Include)
+	assert.Equal(t, []string{"*.zip"}, updateHints(core.PackageTypeTarball, cfg).Exclude)
+	assert.Equal(t, []string{"deb"}, updateHints(core.PackageTypeFlatpak, cfg).Formats)
+}
+
+func TestRunCheckUpdatesCmd_JSON(t *testing.T) {
+	t.Parallel()
+
+	server := newUpdateServer(t, time.Time{})
+	cfg := &config.Config{
+		Paths:   config.PathsConfig{DBFile: filepath.Join(t.TempDir(), "upkg.db"), CacheDir: t.TempDir()},
+		Sources: config.SourcesConfig{GitHubAPIURL: server.URL},
+	}
+
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	require.NoError(t, database.Create(ctx, db.FromInstallRecord(&core.InstallRecord{
+		InstallID:   "id-1",
+		Name:        "app",
+		PackageType: core.PackageTypeAppImage,
+		InstallDate: time.Now(),
+		InstallPath: "/tmp/app",
+		Metadata:    core.Metadata{SourceRepo: "owner/app", SourceTag: "v1.0.0"},
+	})))
+	require.NoError(t, database.Close())
+
+	var out bytes.Buffer
+	log := zerolog.New(io.Discard)
+	require.NoError(t, runCheckUpdatesCmd(&out, cfg, &log, &checkUpdatesOptions{jsonOutput: true, timeoutSecs: 30}, nil))
+
+	var updates []availableUpdate
+	require.NoError(t, json.Unmarshal(out.Bytes(), &updates))
+	require.Len(t, updates, 1)
+	assert.Equal(t, "app", updates[0].Name)
+	assert.Equal(t, "v1.4.0", updates[0].Latest)
+	assert.Equal(t, "https://dl/appimage", updates[0].DownloadURL)
+
+	// A failed check still lists the other updates but fails the command
+	database, err = db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	require.NoError(t, database.Create(ctx, db.FromInstallRecord(&core.InstallRecord{
+		InstallID:   "id-2",
+		Name:        "gone",
+		PackageType: core.PackageTypeAppImage,
+		InstallDate: time.Now(),
+		InstallPath: "/tmp/gone",
+		Metadata:    core.Metadata{SourceRepo: "owner/gone", SourceTag: "v1.0.0"},
+	})))
+	require.NoError(t, database.Close())
+
+	out.Reset()
+	err = runCheckUpdatesCmd(&out, cfg, &log, &checkUpdatesOptions{jsonOutput: true, timeoutSecs: 30}, nil)
+	require.ErrorContains(t, err, "1 of 2 update checks failed")
+	assert.Equal(t, ExitFailure, ExitCode(err))
+	require.NoError(t, json.Unmarshal(out.Bytes(), &updates))
+	assert.Len(t, updates, 1)
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// defaultLegacyTempAge is how old a temp directory without an owner PID must
+// be before clean-temp removes it
+const defaultLegacyTempAge = time.Hour
+
+// cleanTempOptions holds the flags of the clean-temp command
+type cleanTempOptions struct {
+	dryRun    bool
+	olderThan time.Duration
+}
+
+// NewCleanTempCmd creates the clean-temp command
+func NewCleanTempCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &cleanTempOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "clean-temp",
+		Short: "Remove temp directories left behind by interrupted upkg runs",
+		Long: `Remove the scratch directories upkg creates while installing, named after
+the install ID they belong to, when the process that created them is gone.
+
+Only upkg's own directories are considered: those in the per-user upkg temp
+root, and the upkg-* directories of older versions in the system temp dir.
+Directories still used by a running upkg, or holding files that
+'upkg recover' needs to restore, are kept.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runCleanTempCmd(cmd.OutOrStdout(), afero.NewOsFs(), cfg, log, opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "show what would be removed")
+	cmd.Flags().DurationVar(&opts.olderThan, "older-than", defaultLegacyTempAge, "minimum age of directories from older upkg versions, which carry no owner PID")
+
+	return cmd
+}
+
+func runCleanTempCmd(out io.Writer, fs afero.Fs, cfg *config.Config, log *zerolog.Logger, opts *cleanTempOptions) error {
+	dirs, err := helpers.ListTempDirs(fs)
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+
+	// Upgrades keep backups in temp dirs; a pending journal still needs them
+	entries, err := transaction.Pending(fs, paths.NewResolver(cfg).GetJournalDir())
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	var needed []string
+	for _, entry := range entries {
+		for _, step := range entry.Steps {
+			if step.From != "" {
+				needed = append(needed, step.From)
+			}
+		}
+	}
+
+	now := time.Now()
+	var stale []helpers.TempDir
+	var kept int
+	for _, dir := range dirs {
+		if dir.InUse(now, opts.olderThan) || referencesPath(dir.Path, needed) {
+			kept++
+			continue
+		}
+		stale = append(stale, dir)
+	}
+
+	if len(stale) == 0 {
+		ui.PrintSuccess("No leftover temp directories found")
+		if kept > 0 {
+			ui.PrintInfo("%d temp directories are still in use", kept)
+		}
+		return nil
+	}
+
+	var total int64
+	for _, dir := range stale {
+		size, _ := core.PathSize(fs, dir.Path)
+		total += size
+		owner := dir.Label
+		if owner == "" {
+			owner = "older upkg"
+		}
+		_, _ = fmt.Fprintf(out, "   • %s (%s %s, %s, %s)\n", dir.Path, owner, dir.Kind, formatBytes(size), dir.ModTime.Format("2006-01-02 15:04"))
+	}
+
+	if opts.dryRun {
+		ui.PrintInfo("[DRY-RUN] Would remove %d temp directories (%s)", len(stale), formatBytes(total))
+		return nil
+	}
+
+	var failed int
+	for _, dir := range stale {
+		if removeErr := removeTempDir(fs, dir.Path); removeErr != nil {
+			failed++
+			log.Warn().Err(removeErr).Str("path", dir.Path).Msg("failed to remove temp dir")
+			ui.PrintError("%s: %v", dir.Path, removeErr)
+			continue
+		}
+		log.Info().Str("install_id", dir.Label).Str("tmp_dir", dir.Path).Msg("removed leftover temp dir")
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to remove %d of %d temp directories", failed, len(stale))
+	}
+	ui.PrintSuccess("Removed %d temp directories (%s)", len(stale), formatBytes(total))
+	if kept > 0 {
+		ui.PrintInfo("%d temp directories are still in use", kept)
+	}
+	return nil
+}
+
+// referencesPath reports whether any of targets lies inside dir
+func referencesPath(dir string, targets []string) bool {
+	for _, path := range targets {
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeTempDir removes a temp tree, first making read-only directories
+// (left by extracted ISO9660 or squashfs payloads) writable
+func removeTempDir(fs afero.Fs, dir string) error {
+	if err := fs.RemoveAll(dir); err == nil {
+		return nil
+	}
+	_ = afero.Walk(fs, dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.IsDir() && info.Mode().Perm()&0200 == 0 {
+			_ = fs.Chmod(path, info.Mode().Perm()|0700)
+		}
+		return nil
+	})
+	return fs.RemoveAll(dir)
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCleanTempCmd(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	dataDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{DataDir: dataDir}}
+	log := zerolog.Nop()
+	fs := afero.NewOsFs()
+
+	// A directory of this (running) process is kept
+	active, err := helpers.CreateTempDir(fs, "app-1", "rpm")
+	require.NoError(t, err)
+
+	// Leftovers of a process that no longer exists (PIDs never exceed 2^22)
+	const deadPID = 1 << 23
+	stale := filepath.Join(helpers.TempRoot(), "app-2.appimage.8388608.1")
+	require.NoError(t, os.MkdirAll(filepath.Join(stale, "squashfs-root", "usr"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(stale, "squashfs-root", "AppRun"), []byte("#!/bin/sh\n"), 0755))
+	// Read-only directories as left by an ISO9660 extraction
+	require.NoError(t, os.Chmod(filepath.Join(stale, "squashfs-root", "usr"), 0555))
+
+	// An upgrade backup that a pending journal still needs
+	backup := filepath.Join(helpers.TempRoot(), "app-3.upgrade.8388608.2")
+	require.NoError(t, os.MkdirAll(backup, 0700))
+	journal, err := transaction.OpenJournal(fs, filepath.Join(dataDir, "journal"), "upgrade", "app-3")
+	require.NoError(t, err)
+	data, err := json.Marshal(transaction.Entry{
+		Operation: "upgrade",
+		Target:    "app-3",
+		PID:       deadPID,
+		Steps:     []transaction.Step{{Action: transaction.ActionCopy, Path: "/home/u/.local/bin/app-3", From: filepath.Join(backup, "0-app-3")}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(journal.Path(), data, 0644))
+
+	// A legacy directory, old enough to be removed
+	legacy := filepath.Join(tmp, "upkg-deb-123")
+	require.NoError(t, os.MkdirAll(legacy, 0755))
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(legacy, old, old))
+
+	// Not upkg's
+	other := filepath.Join(tmp, "upkg-downloads")
+	require.NoError(t, os.MkdirAll(other, 0755))
+
+	var out bytes.Buffer
+	opts := &cleanTempOptions{dryRun: true, olderThan: time.Hour}
+	require.NoError(t, runCleanTempCmd(&out, fs, cfg, &log, opts))
+	assert.Contains(t, out.String(), stale+" (app-2 appimage")
+	assert.Contains(t, out.String(), legacy+" (older upkg deb")
+	assert.NotContains(t, out.String(), active)
+	assert.NotContains(t, out.String(), backup)
+	assert.DirExists(t, stale)
+
+	opts.dryRun = false
+	require.NoError(t, runCleanTempCmd(&out, fs, cfg, &log, opts))
+	assert.NoDirExists(t, stale)
+	assert.NoDirExists(t, legacy)
+	assert.DirExists(t, active)
+	assert.DirExists(t, backup)
+	assert.DirExists(t, other)
+}
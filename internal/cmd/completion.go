@@ -1,9 +1,17 @@
 package cmd
 
 import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/db"
 	"github.com/quantmind-br/upkg/internal/ui"
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
@@ -44,6 +52,14 @@ Fish:
   # To load completions for each session, execute once:
   $ upkg completion fish > ~/.config/fish/completions/upkg.fish
 
+Commands that take an installed package (uninstall, info, upgrade,
+sync-metadata, icons list, desktop edit) complete the names in the
+install database.
+
+Or install into the per-user completion directory of your shell:
+  $ upkg completion install zsh
+  $ upkg completion remove zsh
+
 PowerShell:
   PS> upkg completion powershell | Out-String | Invoke-Expression
 
@@ -52,32 +68,17 @@ PowerShell:
   # and source this file from your PowerShell profile.
 `,
 		DisableFlagsInUseLine: true,
-		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
-		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		Annotations: map[string]string{
+			skipSetupAnnotation: "true",
+		},
+		ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			shell := args[0]
 
-			switch shell {
-			case "bash":
-				if err := cmd.Root().GenBashCompletion(os.Stdout); err != nil {
-					ui.PrintError("Failed to generate bash completion: %v", err)
-					return err
-				}
-			case "zsh":
-				if err := cmd.Root().GenZshCompletion(os.Stdout); err != nil {
-					ui.PrintError("Failed to generate zsh completion: %v", err)
-					return err
-				}
-			case "fish":
-				if err := cmd.Root().GenFishCompletion(os.Stdout, true); err != nil {
-					ui.PrintError("Failed to generate fish completion: %v", err)
-					return err
-				}
-			case "powershell":
-				if err := cmd.Root().GenPowerShellCompletionWithDesc(os.Stdout); err != nil {
-					ui.PrintError("Failed to generate powershell completion: %v", err)
-					return err
-				}
+			if err := generateCompletion(cmd.Root(), shell, os.Stdout); err != nil {
+				ui.PrintError("Failed to generate %s completion: %v", shell, err)
+				return err
 			}
 
 			log.Info().Str("shell", shell).Msg("generated shell completion")
@@ -85,5 +86,207 @@ PowerShell:
 		},
 	}
 
+	cmd.AddCommand(newCompletionInstallCmd(log))
+	cmd.AddCommand(newCompletionRemoveCmd(log))
+
 	return cmd
 }
+
+// installableShells are the shells supported by completion install/remove
+var installableShells = []string{"bash", "zsh", "fish"}
+
+// newCompletionInstallCmd creates the completion install subcommand
+func newCompletionInstallCmd(log *zerolog.Logger) *cobra.Command {
+	return &cobra.Command{
+		Use:       "install [bash|zsh|fish]",
+		Short:     "Install the completion script into the user completion directory",
+		Long:      `Install the completion script for the given shell (default: $SHELL) into its per-user completion directory.`,
+		ValidArgs: installableShells,
+		Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			shell, target, err := resolveCompletionTarget(args)
+			if err != nil {
+				ui.PrintError("%v", err)
+				return err
+			}
+
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("failed to create completion directory: %w", err)
+			}
+
+			f, err := os.Create(target)
+			if err != nil {
+				return fmt.Errorf("failed to create completion file: %w", err)
+			}
+			defer f.Close()
+
+			if err := generateCompletion(cmd.Root(), shell, f); err != nil {
+				ui.PrintError("Failed to generate %s completion: %v", shell, err)
+				return err
+			}
+
+			ui.PrintSuccess("Installed %s completion to %s", shell, target)
+			if shell == "zsh" && !pathContains(os.Getenv("FPATH"), filepath.Dir(target)) {
+				ui.PrintInfo("Add this to ~/.zshrc before compinit: fpath=(%s $fpath)", filepath.Dir(target))
+			}
+			ui.PrintInfo("Start a new shell for completions to take effect")
+
+			log.Info().Str("shell", shell).Str("path", target).Msg("installed shell completion")
+			return nil
+		},
+	}
+}
+
+// newCompletionRemoveCmd creates the completion remove subcommand
+func newCompletionRemoveCmd(log *zerolog.Logger) *cobra.Command {
+	return &cobra.Command{
+		Use:       "remove [bash|zsh|fish]",
+		Short:     "Remove an installed completion script",
+		ValidArgs: installableShells,
+		Args:      cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+		RunE: func(_ *cobra.Command, args []string) error {
+			shell, target, err := resolveCompletionTarget(args)
+			if err != nil {
+				ui.PrintError("%v", err)
+				return err
+			}
+
+			if err := os.Remove(target); err != nil {
+				if os.IsNotExist(err) {
+					ui.PrintInfo("No %s completion installed at %s", shell, target)
+					return nil
+				}
+				return fmt.Errorf("failed to remove completion file: %w", err)
+			}
+
+			ui.PrintSuccess("Removed %s completion from %s", shell, target)
+			log.Info().Str("shell", shell).Str("path", target).Msg("removed shell completion")
+			return nil
+		},
+	}
+}
+
+// generateCompletion writes the completion script for shell to w
+func generateCompletion(root *cobra.Command, shell string, w io.Writer) error {
+	switch shell {
+	case "bash":
+		return root.GenBashCompletion(w)
+	case "zsh":
+		return root.GenZshCompletion(w)
+	case "fish":
+		return root.GenFishCompletion(w, true)
+	case "powershell":
+		return root.GenPowerShellCompletionWithDesc(w)
+	default:
+		return fmt.Errorf("unsupported shell: %s", shell)
+	}
+}
+
+// resolveCompletionTarget picks the shell (argument or $SHELL) and its completion file path
+func resolveCompletionTarget(args []string) (string, string, error) {
+	shell := filepath.Base(os.Getenv("SHELL"))
+	if len(args) > 0 {
+		shell = args[0]
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	target, err := completionInstallPath(shell, homeDir, os.Getenv)
+	if err != nil {
+		return "", "", err
+	}
+	return shell, target, nil
+}
+
+// completionInstallPath returns the per-user completion file for shell
+func completionInstallPath(shell, homeDir string, getenv func(string) string) (string, error) {
+	dataHome := getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	configHome := getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(homeDir, ".config")
+	}
+
+	switch shell {
+	case "bash":
+		return filepath.Join(dataHome, "bash-completion", "completions", "upkg"), nil
+	case "zsh":
+		// Prefer a user-owned directory already on fpath
+		for _, dir := range filepath.SplitList(getenv("FPATH")) {
+			if dir != "" && strings.HasPrefix(filepath.Clean(dir), filepath.Clean(homeDir)+string(filepath.Separator)) {
+				return filepath.Join(dir, "_upkg"), nil
+			}
+		}
+		return filepath.Join(dataHome, "zsh", "site-functions", "_upkg"), nil
+	case "fish":
+		return filepath.Join(configHome, "fish", "completions", "upkg.fish"), nil
+	case "":
+		return "", fmt.Errorf("cannot detect shell; pass one of: %s", strings.Join(installableShells, ", "))
+	default:
+		return "", fmt.Errorf("completion install is not supported for %s (supported: %s)", shell, strings.Join(installableShells, ", "))
+	}
+}
+
+// completeInstalledPackages completes the names of installed packages from
+// the install database, skipping names already on the command line. No
+// names are offered once maxArgs arguments are given (0 = unlimited).
+func completeInstalledPackages(cfg *config.Config, maxArgs int) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if maxArgs > 0 && len(args) >= maxArgs {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		installs, err := listForCompletion(ctx, cfg)
+		if err != nil {
+			cobra.CompDebugln(fmt.Sprintf("package completion failed: %v", err), true)
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		slices.SortFunc(installs, func(a, b db.Install) int { return cmp.Compare(a.Name, b.Name) })
+		var completions []cobra.Completion
+		for _, install := range installs {
+			if !strings.HasPrefix(strings.ToLower(install.Name), strings.ToLower(toComplete)) || slices.Contains(args, install.Name) {
+				continue
+			}
+			description := install.PackageType
+			if install.Version != "" {
+				description = install.Version + " (" + install.PackageType + ")"
+			}
+			completions = append(completions, cobra.CompletionWithDesc(install.Name, description))
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// listForCompletion reads the installed packages for shell completion.
+// Completion requests run without the config loaded, so it is loaded here
+// when cfg has no database path; a missing database completes nothing.
+func listForCompletion(ctx context.Context, cfg *config.Config) ([]db.Install, error) {
+	dbFile := cfg.Paths.DBFile
+	if dbFile == "" {
+		loaded, err := config.Load()
+		if err != nil {
+			return nil, err
+		}
+		dbFile = loaded.Paths.DBFile
+	}
+	if _, err := os.Stat(dbFile); err != nil {
+		return nil, nil
+	}
+
+	database, err := db.New(ctx, dbFile)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = database.Close() }()
+	return database.List(ctx)
+}
@@ -2,12 +2,20 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/db"
 	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewCompletionCmd(t *testing.T) {
@@ -233,3 +241,117 @@ func TestCompletionCmd_UseLine(t *testing.T) {
 	assert.Contains(t, useLine, "completion")
 	assert.Contains(t, useLine, "[bash|zsh|fish|powershell]")
 }
+
+func TestCompletionInstallPath(t *testing.T) {
+	t.Parallel()
+
+	env := func(vals map[string]string) func(string) string {
+		return func(key string) string { return vals[key] }
+	}
+
+	tests := []struct {
+		name    string
+		shell   string
+		env     map[string]string
+		want    string
+		wantErr bool
+	}{
+		{"bash default", "bash", nil, "/home/u/.local/share/bash-completion/completions/upkg", false},
+		{"bash xdg", "bash", map[string]string{"XDG_DATA_HOME": "/data"}, "/data/bash-completion/completions/upkg", false},
+		{"zsh default", "zsh", nil, "/home/u/.local/share/zsh/site-functions/_upkg", false},
+		{"zsh user fpath", "zsh", map[string]string{"FPATH": "/usr/share/zsh/functions:/home/u/.zfunc"}, "/home/u/.zfunc/_upkg", false},
+		{"fish default", "fish", nil, "/home/u/.config/fish/completions/upkg.fish", false},
+		{"fish xdg", "fish", map[string]string{"XDG_CONFIG_HOME": "/cfg"}, "/cfg/fish/completions/upkg.fish", false},
+		{"powershell unsupported", "powershell", nil, "", true},
+		{"unknown shell", "", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := completionInstallPath(tt.shell, "/home/u", env(tt.env))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCompletionCmd_InstallAndRemove(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	logger := zerolog.New(io.Discard)
+	cmd := NewCompletionCmd(&config.Config{}, &logger)
+
+	cmd.SetArgs([]string{"install", "fish"})
+	assert.NoError(t, cmd.Execute())
+
+	target := filepath.Join(home, ".config", "fish", "completions", "upkg.fish")
+	content, err := os.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "complete")
+
+	cmd.SetArgs([]string{"remove", "fish"})
+	assert.NoError(t, cmd.Execute())
+	assert.NoFileExists(t, target)
+}
+
+// completeArgs runs a shell completion request for args and returns the
+// offered lines, including the trailing ":<directive>" line
+func completeArgs(t *testing.T, cfg *config.Config, args ...string) []string {
+	t.Helper()
+	logger := zerolog.New(io.Discard)
+	root := NewRootCmd(cfg, &logger, "test")
+
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(io.Discard)
+	root.SetArgs(append([]string{cobra.ShellCompRequestCmd}, args...))
+	require.NoError(t, root.Execute())
+	return strings.Split(strings.TrimSpace(out.String()), "\n")
+}
+
+func TestCompleteInstalledPackages(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: filepath.Join(t.TempDir(), "test.db")}}
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	for _, install := range []db.Install{
+		{InstallID: "obsidian-id", PackageType: "appimage", Name: "obsidian", Version: "1.6.7"},
+		{InstallID: "cursor-id", PackageType: "deb", Name: "cursor"},
+		{InstallID: "octave-id", PackageType: "tarball", Name: "octave", Version: "9.2"},
+	} {
+		install.InstallDate = time.Now()
+		require.NoError(t, database.Create(ctx, &install))
+	}
+	require.NoError(t, database.Close())
+
+	noFiles := ":4"
+	assert.Equal(t, []string{"obsidian\t1.6.7 (appimage)", "octave\t9.2 (tarball)", noFiles}, completeArgs(t, cfg, "info", "o"))
+	assert.Equal(t, []string{"cursor\tdeb", "octave\t9.2 (tarball)", noFiles}, completeArgs(t, cfg, "uninstall", "obsidian", ""))
+	assert.Equal(t, []string{noFiles}, completeArgs(t, cfg, "info", "obsidian", ""))
+	assert.Equal(t, []string{"cursor\tdeb", noFiles}, completeArgs(t, cfg, "desktop", "edit", "c"))
+	assert.Equal(t, []string{"cursor\tdeb", noFiles}, completeArgs(t, cfg, "icons", "list", "CU"))
+
+	// upgrade takes the package file as its second argument
+	assert.Equal(t, []string{"cursor\tdeb", noFiles}, completeArgs(t, cfg, "upgrade", "c"))
+	assert.Equal(t, []string{":0"}, completeArgs(t, cfg, "upgrade", "cursor", ""))
+}
+
+func TestCompleteInstalledPackages_MissingDatabase(t *testing.T) {
+	t.Parallel()
+
+	dbFile := filepath.Join(t.TempDir(), "missing.db")
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: dbFile}}
+	assert.Equal(t, []string{":4"}, completeArgs(t, cfg, "uninstall", ""))
+
+	_, err := os.Stat(dbFile)
+	assert.True(t, os.IsNotExist(err), "completion must not create the database")
+}
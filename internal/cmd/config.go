@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// secretConfigKeys are masked by config list
+var secretConfigKeys = map[string]bool{"sources.github_token": true}
+
+// configOptions holds the flags of the config subcommands
+type configOptions struct {
+	jsonOutput bool
+	path       string // Config file; defaults to config.ActiveFilePath()
+	editor     string // Editor command for edit; defaults to $VISUAL, $EDITOR, then vi
+}
+
+// configListReport is the JSON form of config list
+type configListReport struct {
+	File       string           `json:"file"`
+	FileExists bool             `json:"file_exists"`
+	Settings   []config.Setting `json:"settings"`
+}
+
+// NewConfigCmd creates the config command
+func NewConfigCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Show and change configuration settings",
+		Long: `Show the effective configuration, change single settings and edit the
+config file. Keys use dotted TOML paths (desktop.wayland_env_vars); list
+values are comma-separated and table members are addressed by name
+(groups.work).`,
+	}
+
+	cmd.AddCommand(newConfigListCmd(cfg))
+	cmd.AddCommand(newConfigGetCmd(cfg))
+	cmd.AddCommand(newConfigSetCmd(log))
+	cmd.AddCommand(newConfigEditCmd(cfg, log))
+
+	return cmd
+}
+
+func newConfigListCmd(cfg *config.Config) *cobra.Command {
+	opts := &configOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List every setting with its effective value and origin",
+		Long: `List every config key with its effective value and where the value comes
+from: default, file (the config file) or env (an UPKG_* variable).`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runConfigListCmd(cmd.OutOrStdout(), cfg, opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "output the settings in JSON format")
+
+	return cmd
+}
+
+func newConfigGetCmd(cfg *config.Config) *cobra.Command {
+	return &cobra.Command{
+		Use:               "get <key>",
+		Short:             "Print the effective value of a setting",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeConfigKeys,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigGetCmd(cmd.OutOrStdout(), cfg, args[0])
+		},
+	}
+}
+
+func newConfigSetCmd(log *zerolog.Logger) *cobra.Command {
+	opts := &configOptions{}
+
+	return &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Change a setting in the config file",
+		Long: `Validate value against the type and allowed values of key and write it to
+the config file, keeping its other settings. Lists are comma-separated
+("upkg config set sandbox.devices gpu,audio").`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeConfigKeys,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runConfigSetCmd(log, opts, args[0], args[1])
+		},
+	}
+}
+
+func newConfigEditCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &configOptions{}
+
+	return &cobra.Command{
+		Use:   "edit",
+		Short: "Open the config file in $EDITOR",
+		Long: `Open the config file in $VISUAL or $EDITOR (vi when neither is set),
+creating it from the current settings first if needed, and validate it
+once the editor exits.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runConfigEditCmd(cfg, log, opts)
+		},
+	}
+}
+
+func runConfigListCmd(out io.Writer, cfg *config.Config, opts *configOptions) error {
+	path, err := configFilePath(opts)
+	if err != nil {
+		return err
+	}
+	settings, err := config.Settings(cfg, path)
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	for i, setting := range settings {
+		if secretConfigKeys[setting.Key] && config.FormatValue(setting.Value) != "" {
+			settings[i].Value = "********"
+		}
+	}
+	_, statErr := os.Stat(path)
+
+	if opts.jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(configListReport{File: path, FileExists: statErr == nil, Settings: settings}); err != nil {
+			return fmt.Errorf("encode settings: %w", err)
+		}
+		return nil
+	}
+
+	if statErr == nil {
+		_, _ = fmt.Fprintf(out, "# Config file: %s\n", path)
+	} else {
+		_, _ = fmt.Fprintf(out, "# Config file: %s (not found, using defaults)\n", path)
+	}
+	width := 0
+	for _, setting := range settings {
+		width = max(width, len(setting.Key))
+	}
+	for _, setting := range settings {
+		line := fmt.Sprintf("%-*s = %s", width, setting.Key, config.FormatValue(setting.Value))
+		_, _ = fmt.Fprintf(out, "%s  # %s\n", line, setting.Origin)
+	}
+	return nil
+}
+
+func runConfigGetCmd(out io.Writer, cfg *config.Config, key string) error {
+	value, err := config.Get(cfg, key)
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	_, _ = fmt.Fprintln(out, config.FormatValue(value))
+	return nil
+}
+
+func runConfigSetCmd(log *zerolog.Logger, opts *configOptions, key, value string) error {
+	path, err := configFilePath(opts)
+	if err != nil {
+		return err
+	}
+	if err := config.Set(path, key, value); err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+
+	log.Info().Str("key", key).Str("file", path).Msg("config value set")
+	ui.PrintSuccess("Set %s = %s in %s", strings.ToLower(key), value, path)
+	if _, overridden := os.LookupEnv(config.EnvVarName(key)); overridden {
+		ui.PrintWarning("%s is set and overrides this value", config.EnvVarName(key))
+	}
+	return nil
+}
+
+func runConfigEditCmd(cfg *config.Config, log *zerolog.Logger, opts *configOptions) error {
+	path, err := configFilePath(opts)
+	if err != nil {
+		return err
+	}
+	if _, statErr := os.Stat(path); errors.Is(statErr, os.ErrNotExist) {
+		if err := config.Save(cfg, path); err != nil {
+			ui.PrintError("%v", err)
+			return err
+		}
+	}
+
+	editor := cmp.Or(opts.editor, os.Getenv("VISUAL"), os.Getenv("EDITOR"), "vi")
+
+	// The editor may carry arguments (code --wait), so it runs through the shell
+	// #nosec G204 -- the editor is the user's own $VISUAL/$EDITOR
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		ui.PrintError("editor failed: %v", err)
+		return fmt.Errorf("run editor %q: %w", editor, err)
+	}
+
+	if err := config.CheckFile(path); err != nil {
+		log.Warn().Err(err).Str("file", path).Msg("config file has invalid settings")
+		ui.PrintError("%s has invalid settings:\n%v", path, err)
+		return fmt.Errorf("invalid config file: %w", err)
+	}
+	ui.PrintSuccess("%s is valid", path)
+	return nil
+}
+
+// configFilePath returns the config file the config subcommands work on
+func configFilePath(opts *configOptions) (string, error) {
+	if opts.path != "" {
+		return opts.path, nil
+	}
+	return config.ActiveFilePath()
+}
+
+// completeConfigKeys completes the config keys for the first argument
+func completeConfigKeys(_ *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return config.Keys(), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunConfigListCmd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte("[logging]\nlevel = \"debug\"\n"), 0644))
+	cfg := &config.Config{
+		Logging: config.LoggingConfig{Level: "debug", Color: "auto"},
+		Sources: config.SourcesConfig{GitHubToken: "secret"},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, runConfigListCmd(&out, cfg, &configOptions{path: path}))
+	assert.Contains(t, out.String(), "# Config file: "+path+"\n")
+	assert.Regexp(t, `logging\.level += debug  # file`, out.String())
+	assert.Regexp(t, `logging\.color += auto  # default`, out.String())
+	assert.NotContains(t, out.String(), "secret")
+
+	out.Reset()
+	require.NoError(t, runConfigListCmd(&out, cfg, &configOptions{path: path, jsonOutput: true}))
+	var report configListReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.True(t, report.FileExists)
+	assert.Len(t, report.Settings, len(config.Keys()))
+}
+
+func TestRunConfigGetCmd(t *testing.T) {
+	cfg := &config.Config{Sandbox: config.SandboxConfig{Devices: []string{"gpu", "audio"}}}
+
+	var out bytes.Buffer
+	require.NoError(t, runConfigGetCmd(&out, cfg, "sandbox.devices"))
+	assert.Equal(t, "gpu,audio\n", out.String())
+	assert.Error(t, runConfigGetCmd(&out, cfg, "sandbox.nope"))
+}
+
+func TestRunConfigSetCmd(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	path := filepath.Join(t.TempDir(), "upkg", "config.toml")
+	opts := &configOptions{path: path}
+
+	require.NoError(t, runConfigSetCmd(&logger, opts, "upgrade.keep_versions", "2"))
+	assert.Error(t, runConfigSetCmd(&logger, opts, "upgrade.keep_versions", "two"))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "keep_versions = 2")
+}
+
+func TestRunConfigEditCmd(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	path := filepath.Join(t.TempDir(), "config.toml")
+	cfg := &config.Config{
+		Logging:    config.LoggingConfig{Level: "info", Color: "auto"},
+		Syspkg:     config.SyspkgConfig{Provider: "auto"},
+		Sandbox:    config.SandboxConfig{Tool: "auto"},
+		SelfUpdate: config.SelfUpdateConfig{Channel: "stable"},
+	}
+
+	// The editor is given the file path as its last argument
+	require.NoError(t, runConfigEditCmd(cfg, &logger, &configOptions{path: path, editor: "true"}))
+	_, err := os.Stat(path)
+	require.NoError(t, err, "edit creates a missing config file")
+
+	breaking := `sh -c 'printf "[logging]\nlevel = \"loud\"\n" > "$1"' sh`
+	assert.ErrorContains(t, runConfigEditCmd(cfg, &logger, &configOptions{path: path, editor: breaking}), "invalid config file")
+}
@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/preview"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// dbOptions holds the flags of the db subcommands
+type dbOptions struct {
+	jsonOutput bool
+	yes        bool
+}
+
+// recordProblem is an install record referring to files that are gone
+type recordProblem struct {
+	InstallID string   `json:"install_id"`
+	Name      string   `json:"name"`
+	Dangling  bool     `json:"dangling"` // The payload itself is gone
+	Missing   []string `json:"missing"`
+}
+
+// dbVerifyReport is the JSON form of db verify
+type dbVerifyReport struct {
+	SchemaVersion   int             `json:"schema_version"`
+	IntegrityErrors []string        `json:"integrity_errors"`
+	Records         []recordProblem `json:"records"`
+}
+
+// NewDBCmd creates the db command
+func NewDBCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Maintain the install database",
+		Long: `Maintain the install database. The schema is migrated automatically when
+upkg opens it (after a backup next to the database file); these commands
+compact it, check it and fix records whose files are gone.`,
+	}
+
+	cmd.AddCommand(newDBVacuumCmd(cfg, log))
+	cmd.AddCommand(newDBVerifyCmd(cfg, log))
+	cmd.AddCommand(newDBRepairCmd(cfg, log))
+
+	return cmd
+}
+
+func newDBVacuumCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	return &cobra.Command{
+		Use:   "vacuum",
+		Short: "Compact the database file",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runDBVacuumCmd(cfg, log)
+		},
+	}
+}
+
+func newDBVerifyCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &dbOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check the database and the files of every record",
+		Long: `Run SQLite's integrity check and check that the files of every install
+record (payload, desktop entries, icons, wrapper and exposed binaries) still
+exist. Records of packages managed by pacman, dpkg, dnf or Flatpak are
+skipped. Exits with an error when problems are found.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runDBVerifyCmd(cmd.OutOrStdout(), afero.NewOsFs(), cfg, log, opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "output the report in JSON format")
+
+	return cmd
+}
+
+func newDBRepairCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &dbOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "repair",
+		Short: "Drop dangling records and forget missing files",
+		Long: `Fix the records db verify reports: records whose payload is gone are
+dropped, and missing desktop entries, icons, wrappers and exposed binaries
+are removed from the other records. Files left by dropped records can be
+cleaned up with 'upkg gc' afterwards.
+
+Nothing is changed without --yes.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runDBRepairCmd(cmd.OutOrStdout(), afero.NewOsFs(), cfg, log, opts)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.yes, "yes", "y", false, "apply the repairs")
+
+	return cmd
+}
+
+func runDBVacuumCmd(cfg *config.Config, log *zerolog.Logger) error {
+	ctx := context.Background()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	before := fileSize(cfg.Paths.DBFile) + fileSize(cfg.Paths.DBFile+"-wal")
+	if err := database.Vacuum(ctx); err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	after := fileSize(cfg.Paths.DBFile) + fileSize(cfg.Paths.DBFile+"-wal")
+
+	log.Info().Int64("before", before).Int64("after", after).Msg("vacuumed database")
+	ui.PrintSuccess("Database compacted: %s -> %s", formatBytes(before), formatBytes(after))
+	return nil
+}
+
+func runDBVerifyCmd(out io.Writer, fs afero.Fs, cfg *config.Config, log *zerolog.Logger, opts *dbOptions) error {
+	ctx := context.Background()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	report := dbVerifyReport{IntegrityErrors: []string{}, Records: []recordProblem{}}
+	if report.SchemaVersion, err = database.SchemaVersion(ctx); err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	integrity, err := database.IntegrityCheck(ctx)
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	report.IntegrityErrors = append(report.IntegrityErrors, integrity...)
+
+	installs, err := database.List(ctx)
+	if err != nil {
+		ui.PrintError("failed to query database: %v", err)
+		return fmt.Errorf("list installs: %w", err)
+	}
+	report.Records = append(report.Records, verifyRecords(fs, installs)...)
+
+	log.Info().
+		Int("integrity_errors", len(report.IntegrityErrors)).
+		Int("broken_records", len(report.Records)).
+		Msg("verified database")
+
+	if opts.jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("encode report: %w", err)
+		}
+	} else {
+		ui.PrintInfo("Schema version %d, %d records", report.SchemaVersion, len(installs))
+		for _, problem := range report.IntegrityErrors {
+			ui.PrintError("integrity: %s", problem)
+		}
+		printRecordProblems(out, report.Records)
+	}
+
+	problems := len(report.IntegrityErrors) + len(report.Records)
+	if problems > 0 {
+		if !opts.jsonOutput && len(report.Records) > 0 {
+			ui.PrintInfo("Run 'upkg db repair' to fix the records")
+		}
+		return withExitCode(ExitVerificationFailed, fmt.Errorf("database check found %d problem(s)", problems))
+	}
+	if !opts.jsonOutput {
+		ui.PrintSuccess("Database and recorded files are intact")
+	}
+	return nil
+}
+
+func runDBRepairCmd(out io.Writer, fs afero.Fs, cfg *config.Config, log *zerolog.Logger, opts *dbOptions) error {
+	// Records of a running or interrupted transaction are still being written
+	journals, err := transaction.List(fs, paths.NewResolver(cfg).GetJournalDir())
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	if len(journals) > 0 {
+		ui.PrintError("an install or upgrade is running or was interrupted; wait for it or run 'upkg recover' first")
+		return fmt.Errorf("%d operations in progress or interrupted", len(journals))
+	}
+
+	ctx := context.Background()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	installs, err := database.List(ctx)
+	if err != nil {
+		ui.PrintError("failed to query database: %v", err)
+		return fmt.Errorf("list installs: %w", err)
+	}
+
+	problems := verifyRecords(fs, installs)
+	if len(problems) == 0 {
+		ui.PrintSuccess("No records to repair")
+		return nil
+	}
+	printRecordProblems(out, problems)
+
+	if !opts.yes {
+		ui.PrintInfo("Found %d records to repair; run 'upkg db repair --yes' to fix them", len(problems))
+		return nil
+	}
+
+	stored := make(map[string]*db.Install, len(installs))
+	for i := range installs {
+		stored[installs[i].InstallID] = &installs[i]
+	}
+
+	var failed, dropped int
+	for _, problem := range problems {
+		install := stored[problem.InstallID]
+		if problem.Dangling {
+			err = database.Delete(ctx, problem.InstallID)
+			dropped++
+		} else {
+			record := db.ToInstallRecord(install)
+			forgetMissingFiles(record, problem.Missing)
+			dbRecord := db.FromInstallRecord(record)
+			dbRecord.Metadata = mergeMetadata(install.Metadata, dbRecord.Metadata)
+			err = database.Update(ctx, dbRecord)
+		}
+		if err != nil {
+			failed++
+			log.Warn().Err(err).Str("install_id", problem.InstallID).Msg("failed to repair record")
+			ui.PrintError("%s: %v", problem.Name, err)
+			continue
+		}
+		log.Info().Str("install_id", problem.InstallID).Bool("dropped", problem.Dangling).Msg("repaired record")
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to repair %d of %d records", failed, len(problems))
+	}
+	ui.PrintSuccess("Repaired %d records", len(problems))
+	if dropped > 0 {
+		ui.PrintInfo("Run 'upkg gc' to remove files left by the %d dropped records", dropped)
+	}
+	return nil
+}
+
+// verifyRecords lists the records whose files are missing, skipping those a
+// system package manager owns
+func verifyRecords(fs afero.Fs, installs []db.Install) []recordProblem {
+	var problems []recordProblem
+	for i := range installs {
+		record := db.ToInstallRecord(&installs[i])
+		report := preview.Preview(fs, record)
+		if len(report.ExternalPackages) > 0 {
+			continue
+		}
+
+		problem := recordProblem{InstallID: record.InstallID, Name: record.Name}
+		for _, file := range report.Files {
+			if file.Exists {
+				continue
+			}
+			problem.Missing = append(problem.Missing, file.Path)
+			problem.Dangling = problem.Dangling || file.Kind == preview.KindPayload
+		}
+		if len(problem.Missing) > 0 {
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
+
+// forgetMissingFiles removes missing paths from record
+func forgetMissingFiles(record *core.InstallRecord, missing []string) {
+	gone := func(path string) bool { return slices.Contains(missing, path) }
+
+	record.Metadata.IconFiles = slices.DeleteFunc(record.Metadata.IconFiles, gone)
+	record.Metadata.MetainfoFiles = slices.DeleteFunc(record.Metadata.MetainfoFiles, gone)
+	record.Metadata.ManPages = slices.DeleteFunc(record.Metadata.ManPages, gone)
+	record.Metadata.Completions = slices.DeleteFunc(record.Metadata.Completions, gone)
+	record.Metadata.DesktopFiles = slices.DeleteFunc(record.Metadata.DesktopFiles, gone)
+	record.Metadata.ExposedBins = slices.DeleteFunc(record.Metadata.ExposedBins, gone)
+	record.Metadata.ExtraWrappers = slices.DeleteFunc(record.Metadata.ExtraWrappers, gone)
+	if gone(record.Metadata.WrapperScript) {
+		record.Metadata.WrapperScript = ""
+	}
+	if gone(record.DesktopFile) {
+		record.DesktopFile = ""
+		if len(record.Metadata.DesktopFiles) > 0 {
+			record.DesktopFile = record.Metadata.DesktopFiles[0]
+		}
+	}
+}
+
+// printRecordProblems lists broken records and their missing files
+func printRecordProblems(out io.Writer, problems []recordProblem) {
+	for _, problem := range problems {
+		action := "missing files"
+		if problem.Dangling {
+			action = "payload gone, repair drops the record"
+		}
+		_, _ = fmt.Fprintf(out, "   • %s (%s): %s\n", problem.Name, problem.InstallID, action)
+		_, _ = fmt.Fprintf(out, "       %s\n", strings.Join(problem.Missing, "\n       "))
+	}
+}
+
+// fileSize returns the size of path, 0 when it does not exist
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupDBMaintenance records an intact package, one missing its icon and one
+// whose payload is gone
+func setupDBMaintenance(t *testing.T) (*config.Config, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{
+		DataDir: filepath.Join(tmpDir, "data"),
+		DBFile:  filepath.Join(tmpDir, "installed.db"),
+	}}
+
+	intact := filepath.Join(tmpDir, "intact.AppImage")
+	partial := filepath.Join(tmpDir, "partial")
+	icon := filepath.Join(tmpDir, "partial.png")
+	require.NoError(t, os.WriteFile(intact, []byte("app"), 0755))
+	require.NoError(t, os.MkdirAll(partial, 0755))
+
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	defer func() { _ = database.Close() }()
+
+	for _, record := range []*core.InstallRecord{
+		{InstallID: "intact-1", Name: "intact", PackageType: core.PackageTypeAppImage, InstallPath: intact},
+		{InstallID: "partial-1", Name: "partial", PackageType: core.PackageTypeTarball, InstallPath: partial,
+			Metadata: core.Metadata{IconFiles: []string{icon}, InstallMethod: core.InstallMethodLocal}},
+		{InstallID: "gone-1", Name: "gone", PackageType: core.PackageTypeTarball, InstallPath: filepath.Join(tmpDir, "gone")},
+		{InstallID: "deb-1", Name: "system", PackageType: core.PackageTypeDeb, InstallPath: "/var/lib/pacman",
+			Metadata: core.Metadata{InstallMethod: core.InstallMethodPacman}},
+	} {
+		record.InstallDate = time.Now()
+		require.NoError(t, database.Create(ctx, db.FromInstallRecord(record)))
+	}
+	return cfg, icon
+}
+
+func TestRunDBVerifyCmd(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	cfg, icon := setupDBMaintenance(t)
+
+	var out bytes.Buffer
+	err := runDBVerifyCmd(&out, afero.NewOsFs(), cfg, &logger, &dbOptions{jsonOutput: true})
+	assert.ErrorContains(t, err, "2 problem(s)")
+
+	var report dbVerifyReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, db.LatestSchemaVersion(), report.SchemaVersion)
+	assert.Empty(t, report.IntegrityErrors)
+	assert.ElementsMatch(t, []recordProblem{
+		{InstallID: "partial-1", Name: "partial", Missing: []string{icon}},
+		{InstallID: "gone-1", Name: "gone", Dangling: true, Missing: []string{filepath.Join(filepath.Dir(icon), "gone")}},
+	}, report.Records)
+}
+
+func TestRunDBRepairCmd(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	cfg, _ := setupDBMaintenance(t)
+	ctx := context.Background()
+
+	// Without --yes nothing changes
+	require.NoError(t, runDBRepairCmd(io.Discard, afero.NewOsFs(), cfg, &logger, &dbOptions{}))
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	installs, err := database.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, installs, 4)
+	require.NoError(t, database.Close())
+
+	require.NoError(t, runDBRepairCmd(io.Discard, afero.NewOsFs(), cfg, &logger, &dbOptions{yes: true}))
+	require.NoError(t, runDBVerifyCmd(io.Discard, afero.NewOsFs(), cfg, &logger, &dbOptions{}))
+
+	database, err = db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	defer func() { _ = database.Close() }()
+	_, err = database.Get(ctx, "gone-1")
+	assert.Error(t, err, "dangling record is dropped")
+	partial, err := database.Get(ctx, "partial-1")
+	require.NoError(t, err)
+	assert.Empty(t, db.ToInstallRecord(partial).Metadata.IconFiles)
+}
+
+func TestRunDBVacuumCmd(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	cfg, _ := setupDBMaintenance(t)
+	require.NoError(t, runDBVacuumCmd(cfg, &logger))
+}
@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/cache"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/desktop"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// desktopEditOptions holds the flags of the desktop edit command
+type desktopEditOptions struct {
+	addEnv     []string
+	categories []string
+	name       string
+	execArgs   string
+	hidden     bool
+	hiddenSet  bool // --hidden was passed, possibly as --hidden=false
+}
+
+// NewDesktopCmd creates the desktop command
+func NewDesktopCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "desktop",
+		Short: "Customize the desktop entry of a package",
+	}
+
+	cmd.AddCommand(newDesktopEditCmd(cfg, log))
+
+	return cmd
+}
+
+func newDesktopEditCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &desktopEditOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "edit [package-name or install-id]",
+		Short: "Edit the generated desktop entry of a package",
+		Long: `Edit the desktop entry upkg generated for a package. Edits are stored
+with the package and reapplied when it is upgraded.
+
+  --add-env KEY=VALUE   set an environment variable (repeatable)
+  --categories A,B      replace the categories (normalized to the registered list)
+  --name NAME           change the name shown in menus
+  --exec-args "ARGS"    pass extra arguments, replacing earlier --exec-args
+  --hidden              hide the entry from menus (--hidden=false shows it again)
+
+Only the primary desktop entry is edited. Entries of packages installed
+with pacman, dpkg or dnf belong to the system package and cannot be edited.`,
+		Example: `  upkg desktop edit obsidian --add-env GDK_SCALE=2
+  upkg desktop edit cursor --exec-args "--enable-features=UseOzonePlatform"
+  upkg desktop edit my-tool --name "My Tool" --categories Development,IDE`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeInstalledPackages(cfg, 1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.hiddenSet = cmd.Flags().Changed("hidden")
+			return runDesktopEditCmd(afero.NewOsFs(), helpers.NewOSCommandRunner(), cfg, log, opts, args[0])
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&opts.addEnv, "add-env", nil, "set an environment variable in the Exec line (KEY=VALUE, repeatable)")
+	cmd.Flags().StringSliceVar(&opts.categories, "categories", nil, "replace the desktop entry categories (comma-separated)")
+	cmd.Flags().StringVar(&opts.name, "name", "", "set the name shown in application menus")
+	cmd.Flags().StringVar(&opts.execArgs, "exec-args", "", "extra arguments passed to the app")
+	cmd.Flags().BoolVar(&opts.hidden, "hidden", false, "hide the entry from application menus (NoDisplay)")
+
+	return cmd
+}
+
+func runDesktopEditCmd(fs afero.Fs, runner helpers.CommandRunner, cfg *config.Config, log *zerolog.Logger, opts *desktopEditOptions, identifier string) error {
+	if len(opts.addEnv) == 0 && len(opts.categories) == 0 && opts.name == "" && opts.execArgs == "" && !opts.hiddenSet {
+		ui.PrintError("nothing to edit: pass --add-env, --categories, --name, --exec-args or --hidden")
+		return fmt.Errorf("nothing to edit")
+	}
+
+	ctx := context.Background()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	record, err := lookupPackage(ctx, database, log, identifier)
+	if err != nil {
+		return err
+	}
+	if core.IsSystemManaged(record.Metadata.InstallMethod) {
+		ui.PrintError("%s was installed with %s; its desktop entry belongs to the system package", record.Name, record.Metadata.InstallMethod)
+		return fmt.Errorf("desktop entry of %s is system-managed", record.Name)
+	}
+	if record.DesktopFile == "" {
+		ui.PrintError("%s has no desktop entry", record.Name)
+		return fmt.Errorf("%s has no desktop entry", record.Name)
+	}
+
+	previous := record.Metadata.DesktopOverrides
+	overrides := mergeDesktopOverrides(previous, opts)
+
+	original, err := afero.ReadFile(fs, record.DesktopFile)
+	if err != nil {
+		ui.PrintError("failed to read desktop entry: %v", err)
+		return fmt.Errorf("read desktop entry: %w", err)
+	}
+	var staleArgs []string
+	if previous != nil && opts.execArgs != "" {
+		staleArgs = previous.ExecArgs
+	}
+	if err := applyDesktopOverrides(fs, record.DesktopFile, overrides, staleArgs); err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+
+	record.Metadata.DesktopOverrides = overrides
+	dbRecord := db.FromInstallRecord(record)
+	if stored, getErr := database.Get(ctx, record.InstallID); getErr == nil {
+		dbRecord.Metadata = mergeMetadata(stored.Metadata, dbRecord.Metadata)
+	}
+	if err := database.Update(ctx, dbRecord); err != nil {
+		if restoreErr := afero.WriteFile(fs, record.DesktopFile, original, 0644); restoreErr != nil {
+			log.Error().Err(restoreErr).Str("path", record.DesktopFile).Msg("failed to restore desktop entry")
+		}
+		ui.PrintError("failed to save desktop edits: %v", err)
+		return fmt.Errorf("update database: %w", err)
+	}
+	recordIntegrity(fs, cfg, log, record)
+
+	appsDir := filepath.Dir(record.DesktopFile)
+	if err := cache.NewCacheManagerWithRunner(runner).UpdateDesktopDatabase(appsDir, log); err != nil {
+		log.Warn().Err(err).Str("apps_dir", appsDir).Msg("failed to update desktop database")
+	}
+
+	log.Info().Str("name", record.Name).Str("desktop_file", record.DesktopFile).Msg("desktop entry edited")
+	ui.PrintSuccess("Updated desktop entry of %s", record.Name)
+	ui.PrintKeyValue("Desktop file", record.DesktopFile)
+	return nil
+}
+
+// mergeDesktopOverrides adds the edits in opts to the stored overrides.
+// Environment variables accumulate by name; the other fields are replaced.
+func mergeDesktopOverrides(stored *core.DesktopOverrides, opts *desktopEditOptions) *core.DesktopOverrides {
+	merged := &core.DesktopOverrides{}
+	if stored != nil {
+		*merged = *stored
+		merged.Env = slices.Clone(stored.Env)
+	}
+
+	if opts.name != "" {
+		merged.Name = opts.name
+	}
+	if len(opts.categories) > 0 {
+		merged.Categories = opts.categories
+	}
+	if opts.execArgs != "" {
+		merged.ExecArgs = strings.Fields(opts.execArgs)
+	}
+	for _, env := range opts.addEnv {
+		name, _, _ := strings.Cut(env, "=")
+		merged.Env = slices.DeleteFunc(merged.Env, func(existing string) bool {
+			key, _, _ := strings.Cut(existing, "=")
+			return key == name
+		})
+		merged.Env = append(merged.Env, env)
+	}
+	if opts.hiddenSet {
+		hidden := opts.hidden
+		merged.Hidden = &hidden
+	}
+	return merged
+}
+
+// applyDesktopOverrides rewrites the desktop entry at path with overrides,
+// first dropping staleArgs left on its Exec line by an earlier edit
+func applyDesktopOverrides(fs afero.Fs, path string, overrides *core.DesktopOverrides, staleArgs []string) error {
+	file, err := fs.Open(path)
+	if err != nil {
+		return fmt.Errorf("open desktop entry: %w", err)
+	}
+	entry, err := desktop.Parse(file)
+	_ = file.Close()
+	if err != nil {
+		return fmt.Errorf("parse desktop entry: %w", err)
+	}
+
+	if len(staleArgs) > 0 {
+		entry.Exec = desktop.RemoveExecArgs(entry.Exec, staleArgs)
+	}
+	if err := desktop.ApplyOverrides(entry, overrides); err != nil {
+		return err
+	}
+	if err := desktop.Validate(entry); err != nil {
+		return fmt.Errorf("invalid desktop entry: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := desktop.Write(&buf, entry); err != nil {
+		return fmt.Errorf("render desktop entry: %w", err)
+	}
+	if err := afero.WriteFile(fs, path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("write desktop entry: %w", err)
+	}
+	return nil
+}
+
+// carryDesktopOverrides keeps the desktop edits of oldRecord on the upgraded
+// package and applies them to its regenerated entry. It returns a warning
+// when they could not be applied.
+func carryDesktopOverrides(fs afero.Fs, oldRecord, newRecord *core.InstallRecord) string {
+	overrides := oldRecord.Metadata.DesktopOverrides
+	if overrides == nil {
+		return ""
+	}
+	newRecord.Metadata.DesktopOverrides = overrides
+	if newRecord.DesktopFile == "" || core.IsSystemManaged(newRecord.Metadata.InstallMethod) {
+		return ""
+	}
+	if err := applyDesktopOverrides(fs, newRecord.DesktopFile, overrides, nil); err != nil {
+		return fmt.Sprintf("desktop entry edits were not reapplied: %v", err)
+	}
+	return ""
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDesktopEntry = `[Desktop Entry]
+Type=Application
+Name=Editor
+Exec=env GDK_BACKEND=wayland,x11 /home/u/.local/bin/editor %U
+Icon=editor
+Categories=Development;
+StartupWMClass=editor
+`
+
+func TestRunDesktopEditCmd(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: filepath.Join(tmpDir, "test.db")}}
+	log := zerolog.New(io.Discard)
+	desktopPath := "/home/u/.local/share/applications/editor.desktop"
+
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	require.NoError(t, database.Create(ctx, &db.Install{
+		InstallID:   "editor-id",
+		PackageType: "tarball",
+		Name:        "editor",
+		InstallDate: time.Now(),
+		InstallPath: "/opt/editor",
+		DesktopFile: desktopPath,
+		Metadata:    map[string]interface{}{"original_desktop_file": "/old/editor.desktop"},
+	}))
+	require.NoError(t, database.Close())
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, desktopPath, []byte(testDesktopEntry), 0644))
+
+	var commands [][]string
+	runner := &helpers.MockCommandRunner{
+		CommandExistsFunc: func(string) bool { return true },
+		RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+			commands = append(commands, append([]string{name}, args...))
+			return "", nil
+		},
+	}
+
+	opts := &desktopEditOptions{
+		addEnv:     []string{"GDK_SCALE=2"},
+		categories: []string{"Development", "TextEditor"},
+		name:       "My Editor",
+		execArgs:   "--new-window",
+	}
+	require.NoError(t, runDesktopEditCmd(fs, runner, cfg, &log, opts, "editor"))
+
+	content, err := afero.ReadFile(fs, desktopPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Name=My Editor\n")
+	assert.Contains(t, string(content), "Exec=env GDK_BACKEND=wayland,x11 GDK_SCALE=2 /home/u/.local/bin/editor --new-window %U\n")
+	assert.Contains(t, string(content), "Categories=Development;TextEditor;\n")
+	assert.Contains(t, string(content), "StartupWMClass=editor\n")
+	assert.Contains(t, commands, []string{"update-desktop-database", filepath.Dir(desktopPath)})
+
+	// A second edit replaces the exec args and keeps earlier edits
+	opts = &desktopEditOptions{execArgs: "--safe-mode", hidden: true, hiddenSet: true}
+	require.NoError(t, runDesktopEditCmd(fs, runner, cfg, &log, opts, "editor"))
+
+	content, err = afero.ReadFile(fs, desktopPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Exec=env GDK_BACKEND=wayland,x11 GDK_SCALE=2 /home/u/.local/bin/editor --safe-mode %U\n")
+	assert.Contains(t, string(content), "NoDisplay=true\n")
+
+	database, err = db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	defer func() { _ = database.Close() }()
+	stored, err := database.Get(ctx, "editor-id")
+	require.NoError(t, err)
+	assert.Equal(t, "/old/editor.desktop", stored.Metadata["original_desktop_file"])
+
+	overrides := db.ToInstallRecord(stored).Metadata.DesktopOverrides
+	require.NotNil(t, overrides)
+	assert.Equal(t, "My Editor", overrides.Name)
+	assert.Equal(t, []string{"--safe-mode"}, overrides.ExecArgs)
+	assert.Equal(t, []string{"GDK_SCALE=2"}, overrides.Env)
+	require.NotNil(t, overrides.Hidden)
+	assert.True(t, *overrides.Hidden)
+}
+
+func TestRunDesktopEditCmd_Rejects(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: filepath.Join(tmpDir, "test.db")}}
+	log := zerolog.New(io.Discard)
+
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	require.NoError(t, database.Create(ctx, &db.Install{
+		InstallID: "sys-id", PackageType: "deb", Name: "sysapp", InstallDate: time.Now(),
+		DesktopFile: "/usr/share/applications/sysapp.desktop",
+		Metadata:    map[string]interface{}{"install_method": core.InstallMethodDpkg},
+	}))
+	require.NoError(t, database.Create(ctx, &db.Install{
+		InstallID: "cli-id", PackageType: "binary", Name: "cli", InstallDate: time.Now(),
+	}))
+	require.NoError(t, database.Create(ctx, &db.Install{
+		InstallID: "app-id", PackageType: "tarball", Name: "app", InstallDate: time.Now(),
+		DesktopFile: "/apps/app.desktop",
+	}))
+	require.NoError(t, database.Close())
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/apps/app.desktop", []byte("[Desktop Entry]\nType=Application\nName=App\nExec=app\n"), 0644))
+	runner := &helpers.MockCommandRunner{}
+
+	err = runDesktopEditCmd(fs, runner, cfg, &log, &desktopEditOptions{}, "app")
+	assert.ErrorContains(t, err, "nothing to edit")
+
+	err = runDesktopEditCmd(fs, runner, cfg, &log, &desktopEditOptions{name: "X"}, "sysapp")
+	assert.ErrorContains(t, err, "system-managed")
+
+	err = runDesktopEditCmd(fs, runner, cfg, &log, &desktopEditOptions{name: "X"}, "cli")
+	assert.ErrorContains(t, err, "has no desktop entry")
+
+	err = runDesktopEditCmd(fs, runner, cfg, &log, &desktopEditOptions{addEnv: []string{"BROKEN"}}, "app")
+	assert.ErrorContains(t, err, "want KEY=VALUE")
+	content, err := afero.ReadFile(fs, "/apps/app.desktop")
+	require.NoError(t, err)
+	assert.Equal(t, "[Desktop Entry]\nType=Application\nName=App\nExec=app\n", string(content))
+}
+
+func TestCarryDesktopOverrides(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/apps/editor.desktop", []byte(testDesktopEntry), 0644))
+
+	hidden := true
+	oldRecord := &core.InstallRecord{Metadata: core.Metadata{DesktopOverrides: &core.DesktopOverrides{
+		Name:   "My Editor",
+		Hidden: &hidden,
+	}}}
+	newRecord := &core.InstallRecord{DesktopFile: "/apps/editor.desktop"}
+
+	assert.Empty(t, carryDesktopOverrides(fs, oldRecord, newRecord))
+	assert.Same(t, oldRecord.Metadata.DesktopOverrides, newRecord.Metadata.DesktopOverrides)
+	content, err := afero.ReadFile(fs, "/apps/editor.desktop")
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Name=My Editor\n")
+	assert.Contains(t, string(content), "NoDisplay=true\n")
+
+	missing := &core.InstallRecord{DesktopFile: "/apps/missing.desktop"}
+	assert.Contains(t, carryDesktopOverrides(fs, oldRecord, missing), "not reapplied")
+
+	assert.Empty(t, carryDesktopOverrides(fs, &core.InstallRecord{}, newRecord))
+}
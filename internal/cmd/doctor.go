@@ -7,28 +7,66 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/quantmind-br/upkg/internal/backends"
+	"github.com/quantmind-br/upkg/internal/cache"
 	"github.com/quantmind-br/upkg/internal/config"
 	"github.com/quantmind-br/upkg/internal/core"
 	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/desktop"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/syspkg"
 	"github.com/quantmind-br/upkg/internal/ui"
 	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"golang.org/x/sys/unix"
 )
 
+// dependencyCheck describes an external tool upkg relies on
+type dependencyCheck struct {
+	name    string
+	command string
+	purpose string
+}
+
+// requiredDependencies are tools upkg cannot work without
+var requiredDependencies = []dependencyCheck{
+	{"tar", "tar", "Extract tarball packages"},
+	{"unsquashfs", "unsquashfs", "Extract AppImage packages"},
+}
+
+// optionalDependencies are tools that enable additional features
+var optionalDependencies = []dependencyCheck{
+	{"gtk4-update-icon-cache", "gtk4-update-icon-cache", "Update icon cache"},
+	{"update-desktop-database", "update-desktop-database", "Update desktop database"},
+	{"desktop-file-validate", "desktop-file-validate", "Validate desktop files"},
+}
+
+// doctorReport is the result event of doctor in JSON output mode
+type doctorReport struct {
+	Issues   []string `json:"issues"`
+	Warnings []string `json:"warnings"`
+}
+
 // NewDoctorCmd creates the doctor command
 //
 //nolint:gocyclo // diagnostics command performs many sequential checks.
-func NewDoctorCmd(cfg *config.Config, _ *zerolog.Logger) *cobra.Command {
+func NewDoctorCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
 	var verbose bool
 	var fix bool
 
 	cmd := &cobra.Command{
 		Use:   "doctor",
 		Short: "Check system dependencies and integrity",
-		Long:  `Check system dependencies, configuration, database integrity, and installed packages.`,
-		RunE: func(_ *cobra.Command, _ []string) error {
+		Long: `Check system dependencies, configuration, database integrity, and installed packages.
+
+Reports the tools each backend needs with the command that installs them,
+whether ~/.local/bin is on PATH, stale desktop database and icon caches, and
+files left behind by broken installs. --fix creates missing directories and
+refreshes stale caches.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
 			ui.PrintHeader("System Diagnostics")
 			fmt.Println()
 
@@ -37,16 +75,7 @@ func NewDoctorCmd(cfg *config.Config, _ *zerolog.Logger) *cobra.Command {
 
 			// 1. Check required dependencies
 			ui.PrintSubheader("Required Dependencies")
-			requiredDeps := []struct {
-				name    string
-				command string
-				purpose string
-			}{
-				{"tar", "tar", "Extract tarball packages"},
-				{"unsquashfs", "unsquashfs", "Extract AppImage packages"},
-			}
-
-			for _, dep := range requiredDeps {
+			for _, dep := range requiredDependencies {
 				if checkDependency(dep.command, dep.name, dep.purpose, true) {
 					ui.PrintSuccess("%s: found", dep.name)
 				} else {
@@ -59,19 +88,7 @@ func NewDoctorCmd(cfg *config.Config, _ *zerolog.Logger) *cobra.Command {
 
 			// 2. Check optional dependencies
 			ui.PrintSubheader("Optional Dependencies")
-			optionalDeps := []struct {
-				name    string
-				command string
-				purpose string
-			}{
-				{"debtap", "debtap", "Install DEB packages"},
-				{"rpmextract.sh", "rpmextract.sh", "Install RPM packages"},
-				{"gtk4-update-icon-cache", "gtk4-update-icon-cache", "Update icon cache"},
-				{"update-desktop-database", "update-desktop-database", "Update desktop database"},
-				{"desktop-file-validate", "desktop-file-validate", "Validate desktop files"},
-			}
-
-			for _, dep := range optionalDeps {
+			for _, dep := range optionalDependencies {
 				if checkDependency(dep.command, dep.name, dep.purpose, false) {
 					ui.PrintSuccess("%s: found", dep.name)
 				} else {
@@ -82,7 +99,13 @@ func NewDoctorCmd(cfg *config.Config, _ *zerolog.Logger) *cobra.Command {
 
 			fmt.Println()
 
-			// 3. Check directory structure
+			// 3. Check the tools of every backend
+			ui.PrintSubheader("Backends")
+			warnings = append(warnings, checkBackends(cfg, log)...)
+
+			fmt.Println()
+
+			// 4. Check directory structure
 			ui.PrintSubheader("Directory Structure")
 			dirs := []struct {
 				path string
@@ -102,11 +125,23 @@ func NewDoctorCmd(cfg *config.Config, _ *zerolog.Logger) *cobra.Command {
 				}
 			}
 
+			resolver := paths.NewResolver(cfg)
+			fs := afero.NewOsFs()
+			if !binDirOnPath(resolver.GetBinDir(), os.Getenv("PATH")) {
+				ui.PrintWarning("%s is not on PATH", resolver.GetBinDir())
+				ui.PrintInfo("  Fix: run 'upkg init' or add 'export PATH=\"$HOME/.local/bin:$PATH\"' to your shell rc")
+				warnings = append(warnings, fmt.Sprintf("%s is not on PATH; installed commands will not be found", resolver.GetBinDir()))
+			} else {
+				ui.PrintSuccess("PATH: includes %s", resolver.GetBinDir())
+			}
+
 			fmt.Println()
 
-			// 4. Check database
+			// 5. Check database
 			ui.PrintSubheader("Database")
 			ctx := context.Background()
+			var installs []db.Install
+			installsLoaded := false
 			database, err := db.New(ctx, cfg.Paths.DBFile)
 			if err != nil {
 				ui.PrintError("Database: NOT ACCESSIBLE")
@@ -116,11 +151,13 @@ func NewDoctorCmd(cfg *config.Config, _ *zerolog.Logger) *cobra.Command {
 				defer func() { _ = database.Close() }()
 
 				// Check installed packages
-				installs, err := database.List(ctx)
+				var err error
+				installs, err = database.List(ctx)
 				if err != nil {
 					ui.PrintWarning("Cannot list installed packages: %v", err)
 					warnings = append(warnings, "Cannot list installed packages")
 				} else {
+					installsLoaded = true
 					ui.PrintInfo("Installed packages: %d", len(installs))
 
 					if verbose {
@@ -144,14 +181,30 @@ func NewDoctorCmd(cfg *config.Config, _ *zerolog.Logger) *cobra.Command {
 
 			fmt.Println()
 
-			// 5. Check Flatpak
+			// 6. Check desktop integration caches
+			ui.PrintSubheader("Desktop Caches")
+			warnings = append(warnings, checkDesktopCaches(fs, resolver, log, fix)...)
+
+			fmt.Println()
+
+			// 7. Check for files left behind by broken installs
+			ui.PrintSubheader("Orphaned Files")
+			if installsLoaded {
+				warnings = append(warnings, reportOrphans(findOrphans(fs, resolver, installs))...)
+			} else {
+				ui.PrintInfo("Skipped: installed packages could not be read")
+			}
+
+			fmt.Println()
+
+			// 8. Check Flatpak
 			ui.PrintSubheader("Flatpak")
 			flatpakWarnings := checkFlatpak()
 			warnings = append(warnings, flatpakWarnings...)
 
 			fmt.Println()
 
-			// 6. Check environment
+			// 9. Check environment
 			ui.PrintSubheader("Environment")
 			checkEnvironment()
 
@@ -176,6 +229,10 @@ func NewDoctorCmd(cfg *config.Config, _ *zerolog.Logger) *cobra.Command {
 
 			fmt.Println()
 
+			if events := ui.EventWriterFromContext(cmd.Context()); events != nil {
+				events.Result("", doctorReport{Issues: issues, Warnings: warnings})
+			}
+
 			if len(issues) > 0 {
 				return fmt.Errorf("system check failed with %d issue(s)", len(issues))
 			}
@@ -268,6 +325,15 @@ func checkPackageIntegrity(installs []db.Install) []brokenInstall {
 			}
 			if _, err := os.Stat(desktopPath); os.IsNotExist(err) {
 				missing = append(missing, desktopPath)
+				continue
+			}
+			// TryExec is a cheap check that the launcher behind the entry still exists.
+			// Self-updating apps may move their launcher when they update.
+			if selfUpdating, _ := install.Metadata["self_updating"].(bool); selfUpdating {
+				continue
+			}
+			if tryExecErr := checkDesktopTryExec(desktopPath); tryExecErr != nil {
+				missing = append(missing, fmt.Sprintf("%s (%v)", desktopPath, tryExecErr))
 			}
 		}
 
@@ -308,6 +374,21 @@ func checkPackageIntegrity(installs []db.Install) []brokenInstall {
 	return broken
 }
 
+// checkDesktopTryExec parses a desktop file and validates its TryExec target
+func checkDesktopTryExec(desktopPath string) error {
+	file, err := os.Open(desktopPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	entry, err := desktop.Parse(file)
+	if err != nil {
+		return err
+	}
+	return desktop.CheckTryExec(entry)
+}
+
 func getDesktopFilesFromDB(install db.Install) []string {
 	var desktopFiles []string
 
@@ -333,7 +414,7 @@ func getDesktopFilesFromDB(install db.Install) []string {
 func isSystemManagedInstall(install db.Install) bool {
 	if install.Metadata != nil {
 		if method, ok := install.Metadata["install_method"].(string); ok && method != "" {
-			return method == core.InstallMethodPacman
+			return core.IsSystemManaged(method)
 		}
 	}
 
@@ -437,3 +518,129 @@ func checkEnvironment() {
 		}
 	}
 }
+
+// checkBackends runs the tool preflight of every backend and prints what is
+// missing together with the command that installs it
+func checkBackends(cfg *config.Config, log *zerolog.Logger) []string {
+	var warnings []string
+	registry := backends.NewRegistry(cfg, log)
+	distro := backends.DetectDistroFamily(afero.NewOsFs())
+
+	for _, name := range registry.ListBackends() {
+		backend, err := registry.GetBackend(name)
+		if err != nil {
+			continue
+		}
+		report := registry.Preflight(context.Background(), backend)
+		if !report.OK() {
+			ui.PrintWarning("%s: unavailable", name)
+			for _, line := range strings.Split(report.Err(distro).Error(), "\n")[1:] {
+				if line != "" {
+					fmt.Printf("    %s\n", line)
+				}
+			}
+			warnings = append(warnings, fmt.Sprintf("%s packages cannot be installed until missing tools are installed", name))
+			continue
+		}
+
+		ui.PrintSuccess("%s: ready", name)
+		if len(report.OptionalMissing) > 0 {
+			optional := make([]string, 0, len(report.OptionalMissing))
+			for _, status := range report.OptionalMissing {
+				optional = append(optional, status.Requirement.Name)
+			}
+			ui.PrintInfo("  Optional: %s not found (%s)", strings.Join(optional, ", "), syspkg.InstallHint(distro, backends.Requirements(report.OptionalMissing)...))
+		}
+	}
+	return warnings
+}
+
+// binDirOnPath reports whether binDir is one of the entries of pathEnv
+func binDirOnPath(binDir, pathEnv string) bool {
+	for _, entry := range filepath.SplitList(pathEnv) {
+		if entry != "" && filepath.Clean(entry) == filepath.Clean(binDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheIsStale reports whether cacheFile is older than the newest entry in
+// dir accepted by match. Directories without matching entries never are.
+func cacheIsStale(fs afero.Fs, dir, cacheFile string, match func(path string, info os.FileInfo) bool) bool {
+	var newest time.Time
+	_ = afero.Walk(fs, dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == cacheFile || !match(path, info) {
+			return nil
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		return nil
+	})
+	if newest.IsZero() {
+		return false
+	}
+
+	cacheInfo, err := fs.Stat(cacheFile)
+	if err != nil {
+		return true
+	}
+	return cacheInfo.ModTime().Before(newest)
+}
+
+// checkDesktopCaches verifies that the desktop database and icon cache are
+// newer than the entries they index, refreshing them with fix
+func checkDesktopCaches(fs afero.Fs, resolver *paths.Resolver, log *zerolog.Logger, fix bool) []string {
+	var warnings []string
+	cacheManager := cache.NewCacheManager()
+
+	appsDir := resolver.GetAppsDir()
+	desktopStale := cacheIsStale(fs, appsDir, filepath.Join(appsDir, "mimeinfo.cache"), func(path string, info os.FileInfo) bool {
+		return !info.IsDir() && strings.HasSuffix(path, ".desktop")
+	})
+	switch {
+	case !desktopStale:
+		ui.PrintSuccess("Desktop database: up to date")
+	case fix:
+		_ = cacheManager.UpdateDesktopDatabase(appsDir, log)
+		ui.PrintSuccess("Desktop database: refreshed")
+	default:
+		ui.PrintWarning("Desktop database: older than installed desktop entries")
+		ui.PrintInfo("  Fix: update-desktop-database %s (or upkg doctor --fix)", appsDir)
+		warnings = append(warnings, "Desktop database is stale; new apps may not open their file types")
+	}
+
+	iconsDir := resolver.GetIconsDir()
+	iconStale := cacheIsStale(fs, iconsDir, filepath.Join(iconsDir, "icon-theme.cache"), func(_ string, info os.FileInfo) bool {
+		return info.IsDir()
+	})
+	switch {
+	case !iconStale:
+		ui.PrintSuccess("Icon cache: up to date")
+	case fix:
+		_ = cacheManager.UpdateIconCache(iconsDir, log)
+		ui.PrintSuccess("Icon cache: refreshed")
+	default:
+		ui.PrintWarning("Icon cache: older than installed icons")
+		ui.PrintInfo("  Fix: gtk4-update-icon-cache -f -t %s (or upkg doctor --fix)", iconsDir)
+		warnings = append(warnings, "Icon cache is stale; launchers may show generic icons")
+	}
+
+	return warnings
+}
+
+// reportOrphans prints orphaned files with a removal hint
+func reportOrphans(orphans []orphan) []string {
+	if len(orphans) == 0 {
+		ui.PrintSuccess("No orphaned files")
+		return nil
+	}
+
+	ui.PrintWarning("Found %d orphaned file(s) from removed or broken installs:", len(orphans))
+	for _, o := range orphans {
+		fmt.Printf("  • %s (%s)\n", o.path, o.kind)
+	}
+	ui.PrintInfo("  Fix: upkg gc --yes")
+	return []string{fmt.Sprintf("%d orphaned files from broken installs", len(orphans))}
+}
@@ -5,11 +5,13 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/quantmind-br/upkg/internal/config"
 	"github.com/quantmind-br/upkg/internal/core"
 	"github.com/quantmind-br/upkg/internal/db"
 	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -345,6 +347,31 @@ func TestCheckEnvironment(t *testing.T) {
 	})
 }
 
+func TestCheckPackageIntegrity_StaleTryExec(t *testing.T) {
+	tmpDir := t.TempDir()
+	desktopPath := filepath.Join(tmpDir, "stale.desktop")
+	content := "[Desktop Entry]\nType=Application\nName=Stale\nExec=" + filepath.Join(tmpDir, "gone") + "\nTryExec=" + filepath.Join(tmpDir, "gone") + "\n"
+	require.NoError(t, os.WriteFile(desktopPath, []byte(content), 0644))
+
+	installs := []db.Install{
+		{
+			Name:        "stale-pkg",
+			InstallID:   "stale-123",
+			InstallPath: tmpDir,
+			DesktopFile: desktopPath,
+		},
+	}
+
+	broken := checkPackageIntegrity(installs)
+	require.Len(t, broken, 1)
+	require.Len(t, broken[0].missing, 1)
+	assert.Contains(t, broken[0].missing[0], "TryExec target missing")
+
+	// Self-updating apps may move their launcher, so TryExec is not checked
+	installs[0].Metadata = map[string]interface{}{"self_updating": true}
+	assert.Empty(t, checkPackageIntegrity(installs))
+}
+
 func TestCheckPackageIntegrity_IconFilesAsInterface(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -476,3 +503,34 @@ func TestCountNonEmptyLines(t *testing.T) {
 		assert.Equal(t, 0, count)
 	})
 }
+
+func TestBinDirOnPath(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, binDirOnPath("/home/u/.local/bin", "/usr/bin:/home/u/.local/bin/"))
+	assert.False(t, binDirOnPath("/home/u/.local/bin", "/usr/bin:/bin"))
+	assert.False(t, binDirOnPath("/home/u/.local/bin", ""))
+}
+
+func TestCacheIsStale(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	isDesktop := func(path string, info os.FileInfo) bool {
+		return !info.IsDir() && filepath.Ext(path) == ".desktop"
+	}
+	now := time.Now()
+
+	assert.False(t, cacheIsStale(fs, "/apps", "/apps/mimeinfo.cache", isDesktop), "no entries")
+
+	require.NoError(t, afero.WriteFile(fs, "/apps/tool.desktop", []byte("[Desktop Entry]"), 0644))
+	require.NoError(t, fs.Chtimes("/apps/tool.desktop", now, now))
+	assert.True(t, cacheIsStale(fs, "/apps", "/apps/mimeinfo.cache", isDesktop), "missing cache")
+
+	require.NoError(t, afero.WriteFile(fs, "/apps/mimeinfo.cache", []byte(""), 0644))
+	require.NoError(t, fs.Chtimes("/apps/mimeinfo.cache", now.Add(-time.Hour), now.Add(-time.Hour)))
+	assert.True(t, cacheIsStale(fs, "/apps", "/apps/mimeinfo.cache", isDesktop), "cache older than entry")
+
+	require.NoError(t, fs.Chtimes("/apps/mimeinfo.cache", now.Add(time.Minute), now.Add(time.Minute)))
+	assert.False(t, cacheIsStale(fs, "/apps", "/apps/mimeinfo.cache", isDesktop))
+}
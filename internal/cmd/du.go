@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/tw"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/preview"
+	"github.com/quantmind-br/upkg/internal/syspkg"
+	"github.com/quantmind-br/upkg/internal/syspkg/arch"
+	"github.com/quantmind-br/upkg/internal/syspkg/debian"
+	"github.com/quantmind-br/upkg/internal/syspkg/fedora"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// Where a size reported by du comes from
+const (
+	sizeSourceRecorded = "recorded" // Stored in the install record at install time
+	sizeSourceDisk     = "disk"     // Measured from the files on disk
+)
+
+// duOptions holds the flags of the du command
+type duOptions struct {
+	jsonOutput bool
+	refresh    bool
+}
+
+// packageUsage is the disk usage of one installed package
+type packageUsage struct {
+	InstallID   string           `json:"install_id"`
+	Name        string           `json:"name"`
+	PackageType core.PackageType `json:"package_type"`
+	Size        int64            `json:"size"`
+	// recorded, disk or the package manager queried; empty when unknown
+	SizeSource string `json:"size_source,omitempty"`
+}
+
+// duReport is the JSON form of du
+type duReport struct {
+	Packages  []packageUsage `json:"packages"`
+	TotalSize int64          `json:"total_size"`
+	Unknown   int            `json:"unknown"` // Packages whose size could not be determined
+}
+
+// NewDUCmd creates the du command
+func NewDUCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &duOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "du [name|install-id...]",
+		Short: "Show the disk space used by installed packages",
+		Long: `List installed packages by the disk space they use, largest first, with
+the total.
+
+Sizes are recorded at install time. Packages installed through pacman, dpkg
+or dnf report the installed size of the system package; older records
+without a size are measured on disk or queried from the package manager.
+Use --refresh to measure every package again.`,
+		ValidArgsFunction: completeInstalledPackages(cfg, 0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDUCmd(cmd.OutOrStdout(), afero.NewOsFs(), helpers.NewOSCommandRunner(), cfg, log, opts, args)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "output the sizes in JSON format")
+	cmd.Flags().BoolVar(&opts.refresh, "refresh", false, "measure every package instead of using the recorded sizes")
+
+	return cmd
+}
+
+func runDUCmd(out io.Writer, fs afero.Fs, runner helpers.CommandRunner, cfg *config.Config, log *zerolog.Logger, opts *duOptions, identifiers []string) error {
+	ctx := context.Background()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	var records []*core.InstallRecord
+	if len(identifiers) > 0 {
+		for _, identifier := range identifiers {
+			record, lookupErr := lookupPackage(ctx, database, log, identifier)
+			if lookupErr != nil {
+				return lookupErr
+			}
+			records = append(records, record)
+		}
+	} else {
+		installs, listErr := database.List(ctx)
+		if listErr != nil {
+			ui.PrintError("failed to query database: %v", listErr)
+			return fmt.Errorf("list installs: %w", listErr)
+		}
+		for i := range installs {
+			records = append(records, db.ToInstallRecord(&installs[i]))
+		}
+	}
+
+	providers := map[string]syspkg.Provider{
+		preview.ManagerPacman: arch.NewPacmanProviderWithRunner(runner),
+		preview.ManagerDpkg:   debian.NewDpkgProviderWithRunner(runner),
+		preview.ManagerDnf:    fedora.NewDnfProviderWithRunner(runner),
+	}
+
+	report := duReport{Packages: make([]packageUsage, 0, len(records))}
+	for _, record := range records {
+		usage := measureUsage(ctx, fs, providers, log, record, opts.refresh)
+		report.TotalSize += usage.Size
+		if usage.SizeSource == "" {
+			report.Unknown++
+		}
+		report.Packages = append(report.Packages, usage)
+	}
+	slices.SortStableFunc(report.Packages, func(a, b packageUsage) int {
+		return cmp.Or(cmp.Compare(b.Size, a.Size), cmp.Compare(a.Name, b.Name))
+	})
+
+	if opts.jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("encode report: %w", err)
+		}
+		return nil
+	}
+
+	if len(report.Packages) == 0 {
+		ui.PrintInfo("No packages installed")
+		return nil
+	}
+
+	table := tablewriter.NewTable(out,
+		tablewriter.WithHeader([]string{"Name", "Type", "Size", "Source"}),
+		tablewriter.WithAlignment(tw.Alignment{tw.AlignLeft, tw.AlignLeft, tw.AlignRight, tw.AlignLeft}),
+		tablewriter.WithSymbols(tw.NewSymbols(tw.StyleNone)),
+	)
+	for _, usage := range report.Packages {
+		size, source := formatBytes(usage.Size), usage.SizeSource
+		if source == "" {
+			size, source = "-", "unknown"
+		}
+		if err := table.Append(usage.Name, string(usage.PackageType), size, source); err != nil {
+			return fmt.Errorf("append table row: %w", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("render table: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(out, "\nTotal: %s in %d packages\n", formatBytes(report.TotalSize), len(report.Packages))
+	if report.Unknown > 0 {
+		ui.PrintWarning("%d packages have no known size", report.Unknown)
+	}
+	return nil
+}
+
+// measureUsage returns the disk usage of record: its recorded size, the size
+// the system package manager reports, or the size of its files on disk
+func measureUsage(ctx context.Context, fs afero.Fs, providers map[string]syspkg.Provider, log *zerolog.Logger, record *core.InstallRecord, refresh bool) packageUsage {
+	usage := packageUsage{InstallID: record.InstallID, Name: record.Name, PackageType: record.PackageType}
+	if !refresh && record.Metadata.InstalledSize > 0 {
+		usage.Size, usage.SizeSource = record.Metadata.InstalledSize, sizeSourceRecorded
+		return usage
+	}
+
+	report := preview.Preview(fs, record)
+	if len(report.ExternalPackages) == 0 {
+		usage.Size, usage.SizeSource = report.TotalSize, sizeSourceDisk
+		return usage
+	}
+
+	external := report.ExternalPackages[0]
+	sys, ok := providers[external.Manager]
+	if !ok {
+		return usage // Flatpak sizes are not tracked
+	}
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	info, err := sys.GetInfo(queryCtx, external.Name)
+	if err != nil || info.InstalledSize == 0 {
+		log.Debug().Err(err).Str("package", external.Name).Str("manager", external.Manager).Msg("installed size unavailable")
+		return usage
+	}
+	usage.Size, usage.SizeSource = info.InstalledSize, external.Manager
+	return usage
+}
+
+// recordInstalledSize stores the size of the files an install put on disk in
+// record, unless the system package manager already reported it
+func recordInstalledSize(fs afero.Fs, record *core.InstallRecord) {
+	if record.Metadata.InstalledSize > 0 {
+		return
+	}
+	if report := preview.Preview(fs, record); len(report.ExternalPackages) == 0 {
+		record.Metadata.InstalledSize = report.TotalSize
+	}
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDUCmd(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	tmpDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: filepath.Join(tmpDir, "installed.db")}}
+
+	appImage := filepath.Join(tmpDir, "small.AppImage")
+	require.NoError(t, os.WriteFile(appImage, make([]byte, 1000), 0755))
+
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	for _, record := range []*core.InstallRecord{
+		{InstallID: "small-1", Name: "small", PackageType: core.PackageTypeAppImage, InstallPath: appImage},
+		{InstallID: "big-1", Name: "big", PackageType: core.PackageTypeTarball, InstallPath: filepath.Join(tmpDir, "big"),
+			Metadata: core.Metadata{InstalledSize: 1 << 30}},
+		{InstallID: "sys-1", Name: "system-app", PackageType: core.PackageTypeDeb,
+			Metadata: core.Metadata{InstallMethod: core.InstallMethodPacman}},
+		{InstallID: "flat-1", Name: "org.example.App", PackageType: core.PackageTypeFlatpak},
+	} {
+		record.InstallDate = time.Now()
+		require.NoError(t, database.Create(ctx, db.FromInstallRecord(record)))
+	}
+	require.NoError(t, database.Close())
+
+	runner := &helpers.MockCommandRunner{
+		RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+			if name == "pacman" && args[0] == "-Qi" && args[1] == "system-app" {
+				return "Name            : system-app\nVersion         : 1.0-1\nInstalled Size  : 2.00 MiB\n", nil
+			}
+			return "", errors.New("unexpected command")
+		},
+	}
+
+	t.Run("json sorted by size", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, runDUCmd(&out, afero.NewOsFs(), runner, cfg, &logger, &duOptions{jsonOutput: true}, nil))
+
+		var report duReport
+		require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+		require.Len(t, report.Packages, 4)
+		assert.Equal(t, []string{"big", "system-app", "small", "org.example.App"},
+			[]string{report.Packages[0].Name, report.Packages[1].Name, report.Packages[2].Name, report.Packages[3].Name})
+		assert.Equal(t, sizeSourceRecorded, report.Packages[0].SizeSource)
+		assert.Equal(t, int64(2<<20), report.Packages[1].Size)
+		assert.Equal(t, "pacman", report.Packages[1].SizeSource)
+		assert.Equal(t, int64(1000), report.Packages[2].Size)
+		assert.Equal(t, sizeSourceDisk, report.Packages[2].SizeSource)
+		assert.Empty(t, report.Packages[3].SizeSource)
+		assert.Equal(t, int64(1<<30+2<<20+1000), report.TotalSize)
+		assert.Equal(t, 1, report.Unknown)
+	})
+
+	t.Run("refresh measures recorded packages", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, runDUCmd(&out, afero.NewOsFs(), runner, cfg, &logger, &duOptions{jsonOutput: true, refresh: true}, []string{"big"}))
+
+		var report duReport
+		require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+		require.Len(t, report.Packages, 1)
+		assert.Zero(t, report.Packages[0].Size)
+		assert.Equal(t, sizeSourceDisk, report.Packages[0].SizeSource)
+	})
+
+	t.Run("table", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, runDUCmd(&out, afero.NewOsFs(), runner, cfg, &logger, &duOptions{}, nil))
+		assert.Contains(t, out.String(), "1.0 GB")
+		assert.Contains(t, out.String(), "unknown")
+		assert.Contains(t, out.String(), "Total: 1.0 GB in 4 packages")
+	})
+}
+
+func TestRecordInstalledSize(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/apps/tool/bin/tool", make([]byte, 300), 0755))
+	require.NoError(t, afero.WriteFile(fs, "/share/applications/tool.desktop", make([]byte, 20), 0644))
+
+	record := &core.InstallRecord{InstallPath: "/apps/tool", DesktopFile: "/share/applications/tool.desktop", PackageType: core.PackageTypeTarball}
+	recordInstalledSize(fs, record)
+	assert.Equal(t, int64(320), record.Metadata.InstalledSize)
+
+	system := &core.InstallRecord{PackageType: core.PackageTypeDeb, Metadata: core.Metadata{InstallMethod: core.InstallMethodDpkg}}
+	recordInstalledSize(fs, system)
+	assert.Zero(t, system.Metadata.InstalledSize)
+}
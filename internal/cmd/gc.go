@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/cache"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/desktop"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// Kinds of orphaned files
+const (
+	orphanPayload = "payload directory"
+	orphanWrapper = "wrapper script"
+	orphanSymlink = "launcher symlink"
+	orphanDesktop = "desktop entry"
+	orphanIcon    = "icon"
+)
+
+// orphanIconExts are the icon formats upkg installs
+var orphanIconExts = []string{".png", ".svg", ".xpm"}
+
+// gcOptions holds the flags of the gc command
+type gcOptions struct {
+	yes bool
+}
+
+// NewGCCmd creates the gc command
+func NewGCCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &gcOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Remove files left behind by failed or removed installs",
+		Long: `Cross-reference ~/.local/bin, ~/.local/share/applications, the icon theme
+and the upkg apps directory against the install database and list the files
+no installed package owns: payload directories, wrappers, launcher symlinks,
+desktop entries and their icons.
+
+Only files carrying an upkg marker are considered: the wrapper script
+header, the X-Upkg-Managed desktop key, or a place under the upkg apps
+directory. Nothing is removed without --yes.
+gc does not run while an install or upgrade is in progress or was
+interrupted; run 'upkg recover' first in that case.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runGCCmd(cmd.OutOrStdout(), afero.NewOsFs(), helpers.NewOSCommandRunner(), paths.NewResolver(cfg), cfg, log, opts)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.yes, "yes", "y", false, "remove the orphaned files")
+
+	return cmd
+}
+
+func runGCCmd(out io.Writer, fs afero.Fs, runner helpers.CommandRunner, resolver *paths.Resolver, cfg *config.Config, log *zerolog.Logger, opts *gcOptions) error {
+	// Files of a running or interrupted transaction are not recorded yet
+	journals, err := transaction.List(fs, resolver.GetJournalDir())
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	if len(journals) > 0 {
+		ui.PrintError("an install or upgrade is running or was interrupted; wait for it or run 'upkg recover' first")
+		return fmt.Errorf("%d operations in progress or interrupted", len(journals))
+	}
+
+	ctx := context.Background()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	installs, err := database.List(ctx)
+	if err != nil {
+		ui.PrintError("failed to query database: %v", err)
+		return fmt.Errorf("list installs: %w", err)
+	}
+
+	orphans, pinned := skipPinnedOrphans(findOrphans(fs, resolver, installs), installs)
+	if pinned > 0 {
+		ui.PrintInfo("Keeping %d files named after pinned packages", pinned)
+	}
+	if len(orphans) == 0 {
+		ui.PrintSuccess("No orphaned files")
+		return nil
+	}
+
+	var total int64
+	for _, o := range orphans {
+		size, _ := core.PathSize(fs, o.path)
+		total += size
+		_, _ = fmt.Fprintf(out, "   • %s (%s, %s)\n", o.path, o.kind, formatBytes(size))
+	}
+
+	if !opts.yes {
+		ui.PrintInfo("Found %d orphaned files (%s); run 'upkg gc --yes' to remove them", len(orphans), formatBytes(total))
+		return nil
+	}
+
+	var failed int
+	var desktopRemoved, iconsRemoved bool
+	for _, o := range orphans {
+		if removeErr := fs.RemoveAll(o.path); removeErr != nil {
+			failed++
+			log.Warn().Err(removeErr).Str("path", o.path).Msg("failed to remove orphaned file")
+			ui.PrintError("%s: %v", o.path, removeErr)
+			continue
+		}
+		log.Info().Str("path", o.path).Str("kind", o.kind).Msg("removed orphaned file")
+		desktopRemoved = desktopRemoved || o.kind == orphanDesktop
+		iconsRemoved = iconsRemoved || o.kind == orphanIcon
+	}
+
+	cacheManager := cache.NewCacheManagerWithRunner(runner)
+	if desktopRemoved {
+		if cacheErr := cacheManager.UpdateDesktopDatabase(resolver.GetAppsDir(), log); cacheErr != nil {
+			log.Warn().Err(cacheErr).Msg("failed to update desktop database")
+		}
+	}
+	if iconsRemoved {
+		if cacheErr := cacheManager.UpdateIconCache(resolver.GetIconsDir(), log); cacheErr != nil {
+			log.Warn().Err(cacheErr).Msg("failed to update icon cache")
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to remove %d of %d orphaned files", failed, len(orphans))
+	}
+	ui.PrintSuccess("Removed %d orphaned files (%s)", len(orphans), formatBytes(total))
+	return nil
+}
+
+// orphan is a file upkg created that no install record refers to
+type orphan struct {
+	path string
+	kind string
+}
+
+// findOrphans lists payload directories, wrappers, launcher symlinks,
+// desktop entries and icons that belong to no installed package. Files
+// without an upkg marker are never listed, whatever they point at.
+//
+//nolint:gocyclo // collects references from every record before scanning four locations.
+func findOrphans(fs afero.Fs, resolver *paths.Resolver, installs []db.Install) []orphan {
+	referenced := make(map[string]bool)
+	for i := range installs {
+		record := db.ToInstallRecord(&installs[i])
+		for _, path := range []string{record.InstallPath, record.Metadata.WrapperScript, record.DesktopFile, record.Metadata.OriginalDesktopFile} {
+			if path != "" {
+				referenced[filepath.Clean(path)] = true
+			}
+		}
+		for _, list := range [][]string{record.Metadata.ExtraWrappers, record.Metadata.ExposedBins, record.Metadata.DesktopFiles, record.Metadata.IconFiles, record.Metadata.MetainfoFiles, record.Metadata.ManPages, record.Metadata.Completions} {
+			for _, path := range list {
+				referenced[filepath.Clean(path)] = true
+			}
+		}
+	}
+
+	var orphans []orphan
+	appsDir := resolver.GetUpkgAppsDir()
+	if entries, err := afero.ReadDir(fs, appsDir); err == nil {
+		for _, entry := range entries {
+			path := filepath.Join(appsDir, entry.Name())
+			if !referenced[path] {
+				orphans = append(orphans, orphan{path: path, kind: orphanPayload})
+			}
+		}
+	}
+
+	binDir := resolver.GetBinDir()
+	if entries, err := afero.ReadDir(fs, binDir); err == nil {
+		for _, entry := range entries {
+			path := filepath.Join(binDir, entry.Name())
+			if referenced[path] {
+				continue
+			}
+			if target, ok := readLink(fs, path); ok {
+				if isInsideDir(target, appsDir) {
+					orphans = append(orphans, orphan{path: path, kind: orphanSymlink})
+				}
+				continue
+			}
+			if entry.Mode().IsRegular() && isUpkgWrapper(fs, path) {
+				orphans = append(orphans, orphan{path: path, kind: orphanWrapper})
+			}
+		}
+	}
+
+	// Desktop entries upkg wrote that no record refers to
+	iconNames := make(map[string]bool)
+	for _, o := range orphans {
+		iconNames[filepath.Base(o.path)] = true
+	}
+	usedIcons := make(map[string]bool)
+	desktopDir := resolver.GetAppsDir()
+	matches, _ := afero.Glob(fs, filepath.Join(desktopDir, "*.desktop"))
+	for _, path := range matches {
+		entry, err := readDesktopEntry(fs, path)
+		if err != nil {
+			continue
+		}
+		if referenced[path] || !entry.Managed {
+			usedIcons[entry.Icon] = true
+			continue
+		}
+		orphans = append(orphans, orphan{path: path, kind: orphanDesktop})
+		iconNames[strings.TrimSuffix(filepath.Base(path), ".desktop")] = true
+		if entry.Icon != "" && !filepath.IsAbs(entry.Icon) {
+			iconNames[entry.Icon] = true
+		}
+	}
+
+	// Icons named after an orphan that no remaining desktop entry uses
+	var icons []string
+	for name := range iconNames {
+		if usedIcons[name] {
+			continue
+		}
+		for _, ext := range orphanIconExts {
+			found, _ := afero.Glob(fs, filepath.Join(resolver.GetIconsDir(), "*", "apps", name+ext))
+			for _, path := range found {
+				if !referenced[path] {
+					icons = append(icons, path)
+				}
+			}
+		}
+	}
+	slices.Sort(icons)
+	for _, path := range icons {
+		orphans = append(orphans, orphan{path: path, kind: orphanIcon})
+	}
+	return orphans
+}
+
+// readDesktopEntry parses the desktop entry at path
+func readDesktopEntry(fs afero.Fs, path string) (*core.DesktopEntry, error) {
+	file, err := fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	return desktop.Parse(file)
+}
+
+// readLink returns the target of path when it is a symlink
+func readLink(fs afero.Fs, path string) (string, bool) {
+	reader, ok := fs.(afero.LinkReader)
+	if !ok {
+		return "", false
+	}
+	target, err := reader.ReadlinkIfPossible(path)
+	if err != nil {
+		return "", false
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	return filepath.Clean(target), true
+}
+
+// isInsideDir reports whether path is inside dir
+func isInsideDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// isUpkgWrapper reports whether path is a wrapper script generated by upkg
+func isUpkgWrapper(fs afero.Fs, path string) bool {
+	file, err := fs.Open(path)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = file.Close() }()
+
+	head := make([]byte, 128)
+	n, _ := io.ReadFull(file, head)
+	return strings.Contains(string(head[:n]), "# upkg wrapper script")
+}
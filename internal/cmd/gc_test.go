@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindOrphans(t *testing.T) {
+	t.Parallel()
+
+	home := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{DataDir: filepath.Join(home, "data")}}
+	resolver := paths.NewResolverWithHome(cfg, home)
+	appsDir := resolver.GetUpkgAppsDir()
+	binDir := resolver.GetBinDir()
+	desktopDir := resolver.GetAppsDir()
+	iconDir := filepath.Join(resolver.GetIconsDir(), "256x256", "apps")
+
+	for _, dir := range []string{filepath.Join(appsDir, "kept"), filepath.Join(appsDir, "leftover"), binDir, desktopDir, iconDir} {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+	}
+	wrapper := "#!/bin/bash\n# upkg wrapper script\nexec \"x\" \"$@\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "kept"), []byte(wrapper), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "leftover"), []byte(wrapper), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "user-script"), []byte("#!/bin/sh\necho hi\n"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "old.appimage"), []byte("payload"), 0755))
+	require.NoError(t, os.Symlink(filepath.Join(appsDir, "leftover", "tool"), filepath.Join(binDir, "leftover-tool")))
+	require.NoError(t, os.Symlink("/usr/bin/true", filepath.Join(binDir, "unrelated")))
+
+	entry := func(exec, icon string, managed bool) []byte {
+		content := "[Desktop Entry]\nType=Application\nName=App\nExec=" + exec + "\nIcon=" + icon + "\n"
+		if managed {
+			content += "X-Upkg-Managed=true\n"
+		}
+		return []byte(content)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(desktopDir, "kept.desktop"), entry(filepath.Join(binDir, "kept")+" %U", "kept", true), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(desktopDir, "leftover.desktop"), entry("leftover %U", "leftover-icon", true), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(desktopDir, "gone.desktop"), entry(filepath.Join(binDir, "gone"), "gone", true), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(desktopDir, "firefox.desktop"), entry("firefox %u", "firefox", false), 0644))
+	// Files the user made look like upkg ones but carry no marker
+	require.NoError(t, os.WriteFile(filepath.Join(desktopDir, "old.desktop"), entry(filepath.Join(binDir, "old.appimage"), "old", false), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(desktopDir, "missing.desktop"), entry(filepath.Join(binDir, "missing"), "missing", false), 0644))
+	for _, icon := range []string{"kept.png", "leftover-icon.png", "gone.svg", "old.png", "firefox.png", "missing.png"} {
+		require.NoError(t, os.WriteFile(filepath.Join(iconDir, icon), []byte("icon"), 0644))
+	}
+
+	installs := []db.Install{{
+		Name:        "kept",
+		InstallPath: filepath.Join(appsDir, "kept"),
+		DesktopFile: filepath.Join(desktopDir, "kept.desktop"),
+		Metadata: map[string]interface{}{
+			"wrapper_script": filepath.Join(binDir, "kept"),
+			"icon_files":     []string{filepath.Join(iconDir, "kept.png")},
+		},
+	}}
+
+	assert.ElementsMatch(t, []orphan{
+		{path: filepath.Join(appsDir, "leftover"), kind: "payload directory"},
+		{path: filepath.Join(binDir, "leftover"), kind: "wrapper script"},
+		{path: filepath.Join(binDir, "leftover-tool"), kind: "launcher symlink"},
+		{path: filepath.Join(desktopDir, "leftover.desktop"), kind: "desktop entry"},
+		{path: filepath.Join(desktopDir, "gone.desktop"), kind: "desktop entry"},
+		{path: filepath.Join(iconDir, "leftover-icon.png"), kind: "icon"},
+		{path: filepath.Join(iconDir, "gone.svg"), kind: "icon"},
+	}, findOrphans(afero.NewOsFs(), resolver, installs), "the user's AppImage, its desktop entry and icons are kept")
+}
+
+func TestRunGCCmd(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{
+		DBFile:  filepath.Join(tmpDir, "test.db"),
+		DataDir: "/data",
+	}}
+	resolver := paths.NewResolverWithHome(cfg, "/home/u")
+	log := zerolog.New(io.Discard)
+
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	require.NoError(t, database.Create(ctx, &db.Install{
+		InstallID:   "kept-id",
+		PackageType: "tarball",
+		Name:        "kept",
+		InstallDate: time.Now(),
+		InstallPath: "/data/apps/kept",
+	}))
+	require.NoError(t, database.Close())
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, fs.MkdirAll("/data/apps/kept", 0755))
+	require.NoError(t, afero.WriteFile(fs, "/data/apps/leftover/bin/tool", []byte("elf"), 0755))
+	desktopFile := "/home/u/.local/share/applications/leftover.desktop"
+	require.NoError(t, afero.WriteFile(fs, desktopFile, []byte("[Desktop Entry]\nType=Application\nName=Leftover\nExec=/data/apps/leftover/bin/tool\nX-Upkg-Managed=true\n"), 0644))
+
+	var commands [][]string
+	runner := &helpers.MockCommandRunner{
+		CommandExistsFunc: func(string) bool { return true },
+		RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+			commands = append(commands, append([]string{name}, args...))
+			return "", nil
+		},
+	}
+
+	// Without --yes the orphans are only listed
+	var out bytes.Buffer
+	require.NoError(t, runGCCmd(&out, fs, runner, resolver, cfg, &log, &gcOptions{}))
+	assert.Contains(t, out.String(), "/data/apps/leftover (payload directory")
+	assert.Contains(t, out.String(), desktopFile+" (desktop entry")
+	exists, _ := afero.Exists(fs, "/data/apps/leftover")
+	assert.True(t, exists)
+
+	// Journals of running or interrupted operations block gc
+	journal, err := transaction.OpenJournal(fs, resolver.GetJournalDir(), "install", "/tmp/app.tar.gz")
+	require.NoError(t, err)
+	err = runGCCmd(&out, fs, runner, resolver, cfg, &log, &gcOptions{yes: true})
+	require.Error(t, err)
+	require.NoError(t, journal.Close())
+
+	out.Reset()
+	require.NoError(t, runGCCmd(&out, fs, runner, resolver, cfg, &log, &gcOptions{yes: true}))
+	for _, path := range []string{"/data/apps/leftover", desktopFile} {
+		exists, _ := afero.Exists(fs, path)
+		assert.False(t, exists, path)
+	}
+	exists, _ = afero.Exists(fs, "/data/apps/kept")
+	assert.True(t, exists)
+	assert.Contains(t, commands, []string{"update-desktop-database", "/home/u/.local/share/applications"})
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/rs/zerolog"
+)
+
+// groupPrefix marks a group name on the command line (upkg install @dev-tools)
+const groupPrefix = "@"
+
+// runGroupInstall installs every member of a configured group, tagging each
+// record with the group so it can be uninstalled as a set
+func runGroupInstall(cfg *config.Config, log *zerolog.Logger, opts *installOptions, name string) error {
+	members, err := groupMembers(cfg, name)
+	if err != nil {
+		color.Red("Error: %v", err)
+		return err
+	}
+	if opts.customName != "" || opts.sha256 != "" {
+		color.Red("Error: --name and --sha256 cannot be used when installing a group")
+		return fmt.Errorf("--name and --sha256 cannot be used when installing a group")
+	}
+
+	color.Cyan("📦 Installing group %s%s (%d packages)", groupPrefix, name, len(members))
+
+	memberOpts := *opts
+	memberOpts.group = strings.ToLower(name)
+
+	tracker := startStatus(paths.NewResolver(cfg).GetStatusFile(), log, "install", len(members))
+	defer tracker.Finish()
+
+	var failed []string
+	for i, member := range members {
+		fmt.Println()
+		color.Cyan("[%d/%d] %s", i+1, len(members), member)
+		tracker.Begin(member)
+		_, err := reportInstall(cfg, log, &memberOpts, member)
+		tracker.Done(member, err)
+		if err != nil {
+			log.Warn().Err(err).Str("group", name).Str("member", member).Msg("group member install failed")
+			failed = append(failed, member)
+		}
+	}
+
+	fmt.Println()
+	if len(failed) > 0 {
+		color.Red("✗ %d of %d packages in %s%s failed to install:", len(failed), len(members), groupPrefix, name)
+		for _, member := range failed {
+			fmt.Printf("   • %s\n", member)
+		}
+		return fmt.Errorf("%d of %d group members failed to install", len(failed), len(members))
+	}
+
+	color.Green("✓ Group %s%s installed (%d packages)", groupPrefix, name, len(members))
+	return nil
+}
+
+// groupMembers returns the members of a configured group
+func groupMembers(cfg *config.Config, name string) ([]string, error) {
+	// Viper lower-cases map keys read from the config file
+	members, ok := cfg.Groups[strings.ToLower(name)]
+	if !ok {
+		if len(cfg.Groups) == 0 {
+			return nil, fmt.Errorf("unknown group %q: no groups are defined in the config file", name)
+		}
+		names := make([]string, 0, len(cfg.Groups))
+		for groupName := range cfg.Groups {
+			names = append(names, groupName)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown group %q (defined: %s)", name, strings.Join(names, ", "))
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("group %q has no members", name)
+	}
+	return members, nil
+}
+
+// groupEdit drops a package that stays installed from the group being
+// uninstalled
+type groupEdit struct {
+	installID string
+	name      string
+	group     string
+}
+
+// expandGroupArgs replaces @group arguments with the install IDs of the
+// packages installed through that group. Packages that also belong to another
+// group are kept; the returned edits remove them from the group once the
+// uninstall went through. Pinned packages are skipped.
+func expandGroupArgs(ctx context.Context, database *db.DB, args []string) ([]string, []groupEdit, error) {
+	var installs []db.Install
+	var edits []groupEdit
+	expanded := make([]string, 0, len(args))
+
+	for _, arg := range args {
+		name, ok := strings.CutPrefix(arg, groupPrefix)
+		if !ok {
+			expanded = append(expanded, arg)
+			continue
+		}
+		name = strings.ToLower(name)
+
+		if installs == nil {
+			var err error
+			if installs, err = database.List(ctx); err != nil {
+				return nil, nil, fmt.Errorf("failed to query database: %w", err)
+			}
+		}
+
+		found := false
+		for i := range installs {
+			record := db.ToInstallRecord(&installs[i])
+			if !slices.Contains(record.Metadata.Groups, name) {
+				continue
+			}
+			found = true
+
+			if record.Metadata.Pinned {
+				color.Yellow("  Skipping %s (pinned)", record.Name)
+				continue
+			}
+			others := removeGroup(record.Metadata.Groups, name)
+			if len(others) == 0 {
+				expanded = append(expanded, record.InstallID)
+				continue
+			}
+
+			color.Yellow("  Keeping %s (still in %s%s)", record.Name, groupPrefix, strings.Join(others, ", "+groupPrefix))
+			edits = append(edits, groupEdit{installID: record.InstallID, name: record.Name, group: name})
+		}
+
+		if !found {
+			return nil, nil, fmt.Errorf("no installed packages belong to group %s%s", groupPrefix, name)
+		}
+	}
+
+	return expanded, edits, nil
+}
+
+// applyGroupEdits removes the kept packages from the uninstalled groups
+func applyGroupEdits(ctx context.Context, database *db.DB, log *zerolog.Logger, edits []groupEdit) error {
+	for _, edit := range edits {
+		stored, err := database.Get(ctx, edit.installID)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", edit.name, err)
+		}
+		record := db.ToInstallRecord(stored)
+		record.Metadata.Groups = removeGroup(record.Metadata.Groups, edit.group)
+		dbRecord := db.FromInstallRecord(record)
+		dbRecord.Metadata = mergeMetadata(stored.Metadata, dbRecord.Metadata)
+		if err := database.Update(ctx, dbRecord); err != nil {
+			return fmt.Errorf("failed to update group membership of %s: %w", edit.name, err)
+		}
+		log.Info().Str("name", edit.name).Str("group", edit.group).Msg("removed group membership")
+	}
+	return nil
+}
+
+// mergeMetadata overlays updated keys onto the stored metadata so fields the
+// typed conversion does not carry are preserved
+func mergeMetadata(stored, updated map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(stored)+len(updated))
+	for key, value := range stored {
+		merged[key] = value
+	}
+	for key, value := range updated {
+		merged[key] = value
+	}
+	return merged
+}
+
+// addGroup adds a group to a membership list once
+func addGroup(groups []string, name string) []string {
+	if slices.Contains(groups, name) {
+		return groups
+	}
+	return append(groups, name)
+}
+
+// removeGroup returns the membership list without name
+func removeGroup(groups []string, name string) []string {
+	result := make([]string, 0, len(groups))
+	for _, group := range groups {
+		if group != name {
+			result = append(result, group)
+		}
+	}
+	return result
+}
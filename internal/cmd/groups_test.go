@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupMembers(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Groups: map[string][]string{
+		"dev-tools": {"/pkgs/code.deb", "/pkgs/lens.AppImage"},
+		"empty":     {},
+	}}
+
+	members, err := groupMembers(cfg, "Dev-Tools")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/pkgs/code.deb", "/pkgs/lens.AppImage"}, members)
+
+	_, err = groupMembers(cfg, "empty")
+	assert.ErrorContains(t, err, "no members")
+
+	_, err = groupMembers(cfg, "missing")
+	assert.ErrorContains(t, err, "defined: dev-tools, empty")
+
+	_, err = groupMembers(&config.Config{}, "missing")
+	assert.ErrorContains(t, err, "no groups are defined")
+}
+
+func TestInstallCmd_GroupWithCustomName(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Groups: map[string][]string{"dev-tools": {"/pkgs/code.deb"}}}
+	log := zerolog.New(io.Discard)
+
+	cmd := NewInstallCmd(cfg, &log)
+	cmd.SetArgs([]string{"@dev-tools", "--name", "custom"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+
+	assert.ErrorContains(t, cmd.Execute(), "cannot be used when installing a group")
+}
+
+func TestInstallCmd_GroupReportsFailedMembers(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	cfg := &config.Config{
+		Paths:  config.PathsConfig{DBFile: filepath.Join(tmpDir, "test.db"), DataDir: tmpDir},
+		Groups: map[string][]string{"dev-tools": {filepath.Join(tmpDir, "missing.deb"), filepath.Join(tmpDir, "missing.AppImage")}},
+	}
+	log := zerolog.New(io.Discard)
+
+	cmd := NewInstallCmd(cfg, &log)
+	cmd.SetArgs([]string{"@dev-tools"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+
+	assert.ErrorContains(t, cmd.Execute(), "2 of 2 group members failed to install")
+}
+
+func TestAddRemoveGroup(t *testing.T) {
+	t.Parallel()
+
+	groups := addGroup(nil, "dev")
+	groups = addGroup(groups, "dev")
+	groups = addGroup(groups, "media")
+	assert.Equal(t, []string{"dev", "media"}, groups)
+	assert.Equal(t, []string{"media"}, removeGroup(groups, "dev"))
+	assert.Empty(t, removeGroup([]string{"dev"}, "dev"))
+}
+
+func TestExpandGroupArgs(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	database, err := db.New(ctx, filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = database.Close() }()
+
+	for _, install := range []db.Install{
+		{InstallID: "id-code", Name: "code", Metadata: map[string]interface{}{"groups": []string{"dev-tools"}}},
+		{InstallID: "id-lens", Name: "lens", Metadata: map[string]interface{}{"groups": []string{"dev-tools", "k8s"}, "wrapper_script": "/w"}},
+		{InstallID: "id-vlc", Name: "vlc", Metadata: map[string]interface{}{}},
+		{InstallID: "id-kept", Name: "kept", Metadata: map[string]interface{}{"groups": []string{"dev-tools"}, "pinned": true}},
+	} {
+		install.PackageType = "appimage"
+		install.InstallDate = time.Now()
+		install.OriginalFile = "/pkgs/" + install.Name
+		install.InstallPath = "/opt/" + install.Name
+		require.NoError(t, database.Create(ctx, &install))
+	}
+
+	log := zerolog.New(io.Discard)
+
+	// Expanding only plans the membership changes
+	args, edits, err := expandGroupArgs(ctx, database, []string{"@DEV-TOOLS", "vlc"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"id-code", "vlc"}, args)
+	assert.Equal(t, []groupEdit{{installID: "id-lens", name: "lens", group: "dev-tools"}}, edits)
+
+	lens, err := database.Get(ctx, "id-lens")
+	require.NoError(t, err)
+	assert.Len(t, lens.Metadata["groups"], 2)
+
+	// Applied, they drop only the removed group from shared members
+	require.NoError(t, applyGroupEdits(ctx, database, &log, edits))
+	lens, err = database.Get(ctx, "id-lens")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"k8s"}, lens.Metadata["groups"])
+	assert.Equal(t, "/w", lens.Metadata["wrapper_script"])
+
+	_, _, err = expandGroupArgs(ctx, database, []string{"@media"})
+	assert.ErrorContains(t, err, "no installed packages belong to group @media")
+}
+
+func TestUninstallGroupKeepsMembershipUntilRemoved(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "test.db")
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: dbFile, DataDir: t.TempDir()}}
+	log := zerolog.New(io.Discard)
+
+	ctx := context.Background()
+	database, err := db.New(ctx, dbFile)
+	require.NoError(t, err)
+	for _, install := range []db.Install{
+		{InstallID: "id-code", Name: "code", Metadata: map[string]interface{}{"groups": []string{"dev-tools"}}},
+		{InstallID: "id-lens", Name: "lens", Metadata: map[string]interface{}{"groups": []string{"dev-tools", "k8s"}}},
+	} {
+		install.PackageType = "appimage"
+		install.InstallDate = time.Now()
+		install.InstallPath = "/opt/" + install.Name
+		require.NoError(t, database.Create(ctx, &install))
+	}
+	require.NoError(t, database.Close())
+
+	groupsOf := func(installID string) interface{} {
+		database, err := db.New(ctx, dbFile)
+		require.NoError(t, err)
+		defer func() { _ = database.Close() }()
+		install, err := database.Get(ctx, installID)
+		require.NoError(t, err)
+		return install.Metadata["groups"]
+	}
+
+	// The user declines
+	asked := false
+	opts := &uninstallOptions{timeoutSec: 60, noCache: true, confirm: func(string) (bool, error) {
+		asked = true
+		return false, nil
+	}}
+	require.NoError(t, runUninstallCmd(io.Discard, cfg, &log, opts, []string{"@dev-tools"}))
+	assert.True(t, asked)
+	assert.Len(t, groupsOf("id-lens"), 2, "a declined uninstall keeps the membership")
+	assert.NotNil(t, groupsOf("id-code"))
+
+	// The JSON preview changes nothing either
+	opts = &uninstallOptions{timeoutSec: 60, noCache: true, dryRun: true, jsonOutput: true}
+	require.NoError(t, runUninstallCmd(io.Discard, cfg, &log, opts, []string{"@dev-tools"}))
+	assert.Len(t, groupsOf("id-lens"), 2, "a preview keeps the membership")
+}
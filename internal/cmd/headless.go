@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/fetch"
+	"github.com/quantmind-br/upkg/internal/sandbox"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// nonInteractiveFlag is the global flag guaranteeing upkg never waits for input
+const nonInteractiveFlag = "non-interactive"
+
+// Exit codes of upkg. They are a stable contract for scripts: keep the
+// values and the README table in sync.
+const (
+	ExitOK                 = 0 // Success
+	ExitFailure            = 1 // Any other failure
+	ExitNotFound           = 2 // The package, file or installed package named was not found
+	ExitDependencyMissing  = 3 // A tool the operation needs is not installed
+	ExitVerificationFailed = 4 // A checksum or integrity check failed
+	ExitRolledBack         = 5 // The operation failed and its changes were rolled back
+)
+
+// addNonInteractiveFlag registers --non-interactive on the root command
+func addNonInteractiveFlag(root *cobra.Command) {
+	root.PersistentFlags().Bool(nonInteractiveFlag, false,
+		"never prompt (prompts fail instead; pass --yes where supported) and draw no progress bars")
+}
+
+// setupInteractivity applies --non-interactive
+func setupInteractivity(cmd *cobra.Command) {
+	if on, err := cmd.Flags().GetBool(nonInteractiveFlag); err == nil && on {
+		ui.SetNonInteractive(true)
+	}
+}
+
+// isInteractive reports whether upkg may prompt: stdin is a terminal and
+// --non-interactive is not set
+func isInteractive() bool {
+	return !ui.NonInteractive() && term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// exitError tags an error with the exit code it maps to
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode tags err with code; nil stays nil
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// ExitCode returns the process exit code for the error a command returned.
+// The outermost tag wins, so a rollback reports ExitRolledBack whatever
+// made the operation fail.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var tagged *exitError
+	var missing *core.ErrMissingTool
+	switch {
+	case errors.As(err, &tagged):
+		return tagged.code
+	case errors.Is(err, fetch.ErrChecksumMismatch):
+		return ExitVerificationFailed
+	case errors.As(err, &missing), errors.Is(err, sandbox.ErrUnavailable):
+		return ExitDependencyMissing
+	default:
+		return ExitFailure
+	}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/fetch"
+	"github.com/quantmind-br/upkg/internal/sandbox"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitCode(t *testing.T) {
+	notFound := withExitCode(ExitNotFound, fmt.Errorf("package not found: tool"))
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, ExitOK},
+		{"other failure", fmt.Errorf("boom"), ExitFailure},
+		{"not found", fmt.Errorf("uninstall: %w", notFound), ExitNotFound},
+		{"dependency", withExitCode(ExitDependencyMissing, fmt.Errorf("preflight failed")), ExitDependencyMissing},
+		{"missing sandbox tool", fmt.Errorf("wrapper: %w", sandbox.ErrUnavailable), ExitDependencyMissing},
+		{"missing tool", fmt.Errorf("installation failed: %w", &core.ErrMissingTool{Tool: "bsdtar"}), ExitDependencyMissing},
+		{"checksum", fmt.Errorf("%w: expected a, got b", fetch.ErrChecksumMismatch), ExitVerificationFailed},
+		{"rollback wins", withExitCode(ExitRolledBack, fmt.Errorf("%w: expected a, got b", fetch.ErrChecksumMismatch)), ExitRolledBack},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ExitCode(tt.err), tt.name)
+	}
+	assert.NoError(t, withExitCode(ExitNotFound, nil))
+}
+
+func TestSetupInteractivity(t *testing.T) {
+	defer ui.SetNonInteractive(false)
+
+	cmd := &cobra.Command{Use: "install"}
+	addNonInteractiveFlag(cmd)
+	require.NoError(t, cmd.ParseFlags([]string{"--non-interactive"}))
+	setupInteractivity(cmd)
+
+	assert.True(t, ui.NonInteractive())
+	assert.False(t, isInteractive())
+}
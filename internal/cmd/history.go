@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/tw"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/history"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// historyOptions holds the flags of the history command
+type historyOptions struct {
+	jsonOutput bool
+	operation  string
+	since      string
+	until      string
+	limit      int
+}
+
+// NewHistoryCmd creates the history command
+func NewHistoryCmd(cfg *config.Config, _ *zerolog.Logger) *cobra.Command {
+	opts := &historyOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "history [package]",
+		Short: "Show the log of installs, upgrades, rollbacks and uninstalls",
+		Long: `Show the operations upkg performed, oldest first, with their versions,
+options and outcome. Every install, upgrade, rollback and uninstall is
+appended to history.jsonl in the data directory, including failed ones.
+
+Give a package name (or install ID, or part of the package file name) to
+only show its operations. --since and --until take a date (2006-01-02),
+a timestamp (RFC 3339) or an age such as 36h or 7d.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeInstalledPackages(cfg, 1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pkg := ""
+			if len(args) > 0 {
+				pkg = args[0]
+			}
+			return runHistoryCmd(cmd.OutOrStdout(), afero.NewOsFs(), paths.NewResolver(cfg).GetHistoryFile(), opts, pkg, time.Now())
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "output the entries as JSON lines")
+	cmd.Flags().StringVar(&opts.operation, "operation", "", "only show install, upgrade, rollback or uninstall operations")
+	cmd.Flags().StringVar(&opts.since, "since", "", "only show operations at or after this date, timestamp or age (e.g. 7d)")
+	cmd.Flags().StringVar(&opts.until, "until", "", "only show operations before this date, timestamp or age")
+	cmd.Flags().IntVarP(&opts.limit, "limit", "l", 0, "only show the most recent n operations (0 = all)")
+
+	return cmd
+}
+
+func runHistoryCmd(out io.Writer, fs afero.Fs, historyFile string, opts *historyOptions, pkg string, now time.Time) error {
+	filter := history.Filter{Package: pkg, Operation: opts.operation}
+	switch opts.operation {
+	case "", history.OpInstall, history.OpUpgrade, history.OpRollback, history.OpUninstall:
+	default:
+		ui.PrintError("invalid operation %q (expected install, upgrade, rollback or uninstall)", opts.operation)
+		return fmt.Errorf("invalid operation %q", opts.operation)
+	}
+
+	var err error
+	if filter.Since, err = parseHistoryTime(opts.since, now); err != nil {
+		ui.PrintError("invalid --since: %v", err)
+		return err
+	}
+	if filter.Until, err = parseHistoryTime(opts.until, now); err != nil {
+		ui.PrintError("invalid --until: %v", err)
+		return err
+	}
+
+	entries, err := history.Read(fs, historyFile, filter)
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	if opts.limit > 0 && len(entries) > opts.limit {
+		entries = entries[len(entries)-opts.limit:]
+	}
+
+	if opts.jsonOutput {
+		enc := json.NewEncoder(out)
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return fmt.Errorf("encode history entry: %w", err)
+			}
+		}
+		return nil
+	}
+
+	if len(entries) == 0 {
+		ui.PrintInfo("No operations recorded")
+		return nil
+	}
+
+	table := tablewriter.NewTable(out,
+		tablewriter.WithHeader([]string{"Date", "Operation", "Name", "Version", "Outcome", "Options"}),
+		tablewriter.WithAlignment(tw.MakeAlign(6, tw.AlignLeft)),
+		tablewriter.WithSymbols(tw.NewSymbols(tw.StyleNone)),
+	)
+	for _, entry := range entries {
+		name := entry.Name
+		if name == "" {
+			name = entry.Source
+		}
+		version := displayVersion(entry.Version)
+		if entry.FromVersion != "" || entry.Operation == history.OpUpgrade || entry.Operation == history.OpRollback {
+			version = displayVersion(entry.FromVersion) + " → " + version
+		}
+		outcome := entry.Outcome
+		if entry.Error != "" {
+			outcome += ": " + entry.Error
+		}
+		if err := table.Append(entry.Time.Local().Format("2006-01-02 15:04"), entry.Operation, name, version, outcome, strings.Join(entry.Options, " ")); err != nil {
+			return fmt.Errorf("append table row: %w", err)
+		}
+	}
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("render table: %w", err)
+	}
+	return nil
+}
+
+// parseHistoryTime parses a --since/--until value relative to now; empty
+// means no bound
+func parseHistoryTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if age, err := time.ParseDuration(value); err == nil && age >= 0 {
+		return now.Add(-age), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date (2006-01-02), timestamp (RFC 3339) or age (7d, 36h)", value)
+}
+
+// recordHistory appends a finished operation to the history log. The log is
+// best effort: failing to write it never fails the operation.
+func recordHistory(fs afero.Fs, historyFile string, log *zerolog.Logger, entry history.Entry, started time.Time, err error) {
+	if historyFile == "" {
+		return
+	}
+	entry.Time = started.UTC()
+	entry.DurationMS = time.Since(started).Milliseconds()
+	entry.Outcome = history.OutcomeSuccess
+	if err != nil {
+		entry.Outcome = history.OutcomeFailed
+		entry.Error = err.Error()
+	}
+	if appendErr := history.Append(fs, historyFile, entry); appendErr != nil {
+		log.Debug().Err(appendErr).Msg("history log unavailable")
+	}
+}
+
+// historyRecordEntry describes record in a history entry
+func historyRecordEntry(operation string, record *core.InstallRecord) history.Entry {
+	return history.Entry{
+		Operation:   operation,
+		Name:        record.Name,
+		InstallID:   record.InstallID,
+		PackageType: string(record.PackageType),
+		Version:     record.Version,
+	}
+}
+
+// installHistoryOptions lists the install flags that changed the defaults
+func installHistoryOptions(opts *installOptions) []string {
+	var options []string
+	flag := func(set bool, name string) {
+		if set {
+			options = append(options, "--"+name)
+		}
+	}
+	value := func(v, name string) {
+		if v != "" {
+			options = append(options, "--"+name+"="+v)
+		}
+	}
+
+	flag(opts.force, "force")
+	flag(opts.overwrite, "overwrite")
+	flag(opts.skipDesktop, "skip-desktop")
+	flag(opts.skipWaylandEnv, "skip-wayland-env")
+	flag(opts.exposeAllBins, "expose-all-bins")
+	flag(opts.hiDPI, "hidpi")
+	flag(opts.desktop, "desktop")
+	flag(opts.linkDir, "link")
+	flag(opts.selfUpdating, "self-updating")
+	flag(opts.sandbox, "sandbox")
+	value(opts.customName, "name")
+	if opts.method != core.MethodAuto {
+		value(opts.method, "method")
+	}
+	value(opts.icon, "icon")
+	value(strings.Join(opts.bins, ","), "bin")
+	flag(opts.binDesktops, "bin-desktops")
+	if opts.group != "" {
+		options = append(options, groupPrefix+opts.group)
+	}
+	return options
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/history"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testHistoryFile = "/data/history.jsonl"
+
+func TestRecordHistory(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	fs := afero.NewMemMapFs()
+	record := &core.InstallRecord{InstallID: "app-1", Name: "app", PackageType: core.PackageTypeAppImage, Version: "2.0"}
+
+	recordHistory(fs, testHistoryFile, &logger, historyRecordEntry(history.OpInstall, record), time.Now(), nil)
+	recordHistory(fs, testHistoryFile, &logger, history.Entry{Operation: history.OpInstall, Source: "/tmp/bad.deb"}, time.Now(), errors.New("boom"))
+	recordHistory(fs, "", &logger, history.Entry{Operation: history.OpInstall}, time.Now(), nil)
+
+	entries, err := history.Read(fs, testHistoryFile, history.Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "app", entries[0].Name)
+	assert.Equal(t, "appimage", entries[0].PackageType)
+	assert.Equal(t, history.OutcomeSuccess, entries[0].Outcome)
+	assert.False(t, entries[0].Time.IsZero())
+	assert.Equal(t, history.OutcomeFailed, entries[1].Outcome)
+	assert.Equal(t, "boom", entries[1].Error)
+}
+
+func TestInstallHistoryOptions(t *testing.T) {
+	assert.Empty(t, installHistoryOptions(&installOptions{method: core.MethodAuto}))
+	assert.Equal(t,
+		[]string{"--force", "--sandbox", "--name=Editor", "--method=extract", "@work"},
+		installHistoryOptions(&installOptions{force: true, sandbox: true, customName: "Editor", method: core.MethodExtract, group: "work"}))
+}
+
+func TestRunHistoryCmd(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	for _, entry := range []history.Entry{
+		{Time: now.AddDate(0, 0, -9), Operation: history.OpInstall, Name: "editor", Version: "1.0", Outcome: history.OutcomeSuccess},
+		{Time: now.AddDate(0, 0, -2), Operation: history.OpUpgrade, Name: "editor", FromVersion: "1.0", Version: "1.1", Outcome: history.OutcomeSuccess},
+		{Time: now.AddDate(0, 0, -1), Operation: history.OpUninstall, Name: "player", Outcome: history.OutcomeFailed, Error: "busy"},
+	} {
+		require.NoError(t, history.Append(fs, testHistoryFile, entry))
+	}
+
+	t.Run("table", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, runHistoryCmd(&out, fs, testHistoryFile, &historyOptions{}, "editor", now))
+		assert.Contains(t, out.String(), "1.0 → 1.1")
+		assert.NotContains(t, out.String(), "player")
+	})
+
+	t.Run("json since", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, runHistoryCmd(&out, fs, testHistoryFile, &historyOptions{jsonOutput: true, since: "7d"}, "", now))
+		lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+		require.Len(t, lines, 2)
+		var entry history.Entry
+		require.NoError(t, json.Unmarshal(lines[1], &entry))
+		assert.Equal(t, "busy", entry.Error)
+	})
+
+	t.Run("limit and operation", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, runHistoryCmd(&out, fs, testHistoryFile, &historyOptions{jsonOutput: true, operation: history.OpUpgrade, limit: 1}, "", now))
+		assert.Contains(t, out.String(), `"version":"1.1"`)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.Error(t, runHistoryCmd(io.Discard, fs, testHistoryFile, &historyOptions{operation: "remove"}, "", now))
+		assert.Error(t, runHistoryCmd(io.Discard, fs, testHistoryFile, &historyOptions{until: "yesterday"}, "", now))
+	})
+}
+
+func TestParseHistoryTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"", time.Time{}},
+		{"7d", now.AddDate(0, 0, -7)},
+		{"36h", now.Add(-36 * time.Hour)},
+		{"2026-03-01", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"2026-03-01T08:30:00Z", time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseHistoryTime(tt.value, now)
+		require.NoError(t, err, tt.value)
+		assert.True(t, tt.want.Equal(got), "%s: got %v", tt.value, got)
+	}
+
+	_, err := parseHistoryTime("-3d", now)
+	assert.Error(t, err)
+}
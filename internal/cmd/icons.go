@@ -0,0 +1,512 @@
+package cmd
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/gif"  // Register decoders for icon dimensions
+	_ "image/jpeg" // Register decoders for icon dimensions
+	_ "image/png"  // Register decoders for icon dimensions
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/olekukonko/tablewriter/tw"
+	"github.com/quantmind-br/upkg/internal/cache"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/desktop"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/icons"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// iconsListOptions holds the flags of the icons list command
+type iconsListOptions struct {
+	jsonOutput bool
+	open       bool
+}
+
+// iconFileInfo describes one installed icon and where it sits in the theme
+type iconFileInfo struct {
+	Path       string `json:"path"`
+	Theme      string `json:"theme,omitempty"`      // e.g. hicolor, or "pixmaps"
+	SizeDir    string `json:"size_dir,omitempty"`   // Theme size directory, e.g. 256x256 or scalable
+	Context    string `json:"context,omitempty"`    // Theme context directory, e.g. apps
+	Dimensions string `json:"dimensions,omitempty"` // Pixel size read from the image
+	Bytes      int64  `json:"bytes"`
+	Exists     bool   `json:"exists"`
+}
+
+// iconsReport is the JSON form of icons list
+type iconsReport struct {
+	Name     string         `json:"name"`
+	IconName string         `json:"icon_name,omitempty"` // Icon= of the desktop entry
+	Icons    []iconFileInfo `json:"icons"`
+}
+
+// customIconExtensions are the formats the hicolor theme accepts
+var customIconExtensions = []string{".png", ".svg", ".xpm"}
+
+// NewIconsCmd creates the icons command
+func NewIconsCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "icons",
+		Aliases: []string{"icon"},
+		Short:   "Inspect or replace the icons installed for a package",
+	}
+
+	cmd.AddCommand(newIconsListCmd(cfg, log))
+	cmd.AddCommand(newIconsSetCmd(cfg, log))
+
+	return cmd
+}
+
+func newIconsListCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &iconsListOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "list [package-name or install-id]",
+		Short: "List the icon files of a package",
+		Long: `List every icon file installed for a package with its theme, size
+directory, pixel dimensions and file size, plus the Icon= name of its
+desktop entry. Missing files and icons whose pixels do not match their
+size directory are flagged.
+
+--open previews the largest icon with xdg-open.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeInstalledPackages(cfg, 1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runIconsListCmd(cmd.OutOrStdout(), afero.NewOsFs(), helpers.NewOSCommandRunner(), cfg, log, opts, args[0])
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "output the icon list in JSON format")
+	cmd.Flags().BoolVar(&opts.open, "open", false, "preview the largest icon with xdg-open")
+
+	return cmd
+}
+
+func runIconsListCmd(out io.Writer, fs afero.Fs, runner helpers.CommandRunner, cfg *config.Config, log *zerolog.Logger, opts *iconsListOptions, identifier string) error {
+	ctx := context.Background()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	record, err := lookupPackage(ctx, database, log, identifier)
+	if err != nil {
+		return err
+	}
+
+	report := listIcons(fs, record)
+
+	if opts.jsonOutput {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("encode icon list: %w", err)
+		}
+	} else if err := printIcons(out, report); err != nil {
+		return err
+	}
+
+	if opts.open {
+		return openIcon(ctx, runner, report)
+	}
+	return nil
+}
+
+// listIcons inspects the record's icon files on disk
+func listIcons(fs afero.Fs, record *core.InstallRecord) iconsReport {
+	report := iconsReport{Name: record.Name, Icons: []iconFileInfo{}}
+
+	if record.DesktopFile != "" {
+		if file, err := fs.Open(record.DesktopFile); err == nil {
+			if entry, parseErr := desktop.Parse(file); parseErr == nil {
+				report.IconName = entry.Icon
+			}
+			_ = file.Close()
+		}
+	}
+
+	iconPaths := record.Metadata.IconFiles
+	if custom := record.Metadata.CustomIcon; custom != "" && !slices.Contains(iconPaths, custom) {
+		iconPaths = append(slices.Clone(iconPaths), custom)
+	}
+	for _, path := range iconPaths {
+		icon := iconFileInfo{Path: path}
+		icon.Theme, icon.SizeDir, icon.Context = iconLocation(path)
+		if info, err := fs.Stat(path); err == nil {
+			icon.Exists = true
+			icon.Bytes = info.Size()
+			icon.Dimensions = iconDimensions(fs, path)
+		}
+		report.Icons = append(report.Icons, icon)
+	}
+	return report
+}
+
+// iconLocation splits an icon path into theme, size directory and context:
+// .../icons/<theme>/<size>/<context>/<file>, or "pixmaps" for .../pixmaps/<file>
+func iconLocation(path string) (theme, sizeDir, context string) {
+	parts := strings.Split(filepath.ToSlash(filepath.Dir(path)), "/")
+	for i := len(parts) - 1; i >= 0; i-- {
+		switch parts[i] {
+		case "pixmaps":
+			return "pixmaps", "", ""
+		case "icons":
+			rest := parts[i+1:]
+			if len(rest) >= 3 {
+				return rest[0], rest[1], rest[2]
+			}
+			if len(rest) > 0 {
+				return rest[0], "", ""
+			}
+			return "", "", ""
+		}
+	}
+	return "", "", ""
+}
+
+// iconDimensions reads the pixel size of a raster icon; SVGs report "scalable"
+func iconDimensions(fs afero.Fs, path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".svg") {
+		return "scalable"
+	}
+	file, err := fs.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = file.Close() }()
+
+	cfg, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%dx%d", cfg.Width, cfg.Height)
+}
+
+// sizeMismatch reports whether an icon's pixels disagree with its size directory
+func (i iconFileInfo) sizeMismatch() bool {
+	if i.Dimensions == "" || i.SizeDir == "" || i.Dimensions == "scalable" {
+		return false
+	}
+	// Size directories may carry a scale suffix, e.g. 48x48@2
+	base, scale, _ := strings.Cut(i.SizeDir, "@")
+	size, _, ok := strings.Cut(base, "x")
+	if !ok {
+		return false
+	}
+	want, err := strconv.Atoi(size)
+	if err != nil {
+		return false
+	}
+	if factor, err := strconv.Atoi(scale); err == nil {
+		want *= factor
+	}
+	return i.Dimensions != fmt.Sprintf("%dx%d", want, want)
+}
+
+// pixelArea ranks icons for preview; scalable icons rank first
+func (i iconFileInfo) pixelArea() int {
+	if i.Dimensions == "scalable" {
+		return math.MaxInt
+	}
+	w, h, ok := strings.Cut(i.Dimensions, "x")
+	if !ok {
+		return 0
+	}
+	width, _ := strconv.Atoi(w)  //nolint:errcheck // unparsable sizes rank last
+	height, _ := strconv.Atoi(h) //nolint:errcheck // unparsable sizes rank last
+	return width * height
+}
+
+func printIcons(out io.Writer, report iconsReport) error {
+	ui.PrintHeader(fmt.Sprintf("Icons: %s", report.Name))
+	iconName := report.IconName
+	if iconName == "" {
+		iconName = "(no desktop entry)"
+	}
+	ui.PrintKeyValue("Icon Name", iconName)
+	fmt.Println()
+
+	if len(report.Icons) == 0 {
+		ui.PrintInfo("No icon files recorded for %s", report.Name)
+		return nil
+	}
+
+	table := tablewriter.NewTable(out,
+		tablewriter.WithHeader([]string{"Theme", "Size", "Pixels", "Bytes", "Path"}),
+		tablewriter.WithAlignment(tw.MakeAlign(5, tw.AlignLeft)),
+		tablewriter.WithSymbols(tw.NewSymbols(tw.StyleNone)),
+	)
+
+	for _, icon := range report.Icons {
+		theme, sizeDir := icon.Theme, icon.SizeDir
+		if theme == "" {
+			theme = "-"
+		}
+		if sizeDir == "" {
+			sizeDir = "-"
+		}
+
+		pixels, bytes := icon.Dimensions, formatBytes(icon.Bytes)
+		switch {
+		case !icon.Exists:
+			pixels, bytes = ui.SprintWarning("(missing)"), "-"
+		case pixels == "":
+			pixels = "?"
+		case icon.sizeMismatch():
+			pixels += " " + ui.SprintWarning("(size mismatch)")
+		}
+
+		if err := table.Append(theme, sizeDir, pixels, bytes, icon.Path); err != nil {
+			return fmt.Errorf("append table row: %w", err)
+		}
+	}
+
+	if err := table.Render(); err != nil {
+		return fmt.Errorf("render table: %w", err)
+	}
+	return nil
+}
+
+// openIcon previews the largest existing icon with xdg-open
+func openIcon(ctx context.Context, runner helpers.CommandRunner, report iconsReport) error {
+	existing := slices.DeleteFunc(slices.Clone(report.Icons), func(icon iconFileInfo) bool { return !icon.Exists })
+	if len(existing) == 0 {
+		ui.PrintError("no icon files to preview for %s", report.Name)
+		return fmt.Errorf("no icon files to preview for %s", report.Name)
+	}
+	largest := slices.MaxFunc(existing, func(a, b iconFileInfo) int { return cmp.Compare(a.pixelArea(), b.pixelArea()) })
+
+	if err := runner.RequireCommand("xdg-open"); err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	if _, err := runner.RunCommand(ctx, "xdg-open", largest.Path); err != nil {
+		ui.PrintError("failed to open %s: %v", largest.Path, err)
+		return fmt.Errorf("open icon: %w", err)
+	}
+	ui.PrintInfo("Opened %s", largest.Path)
+	return nil
+}
+
+func newIconsSetCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set [package-name or install-id] [icon file]",
+		Short: "Replace the icon of an installed package",
+		Long: `Install an icon of your own for a package whose shipped icon is missing or
+poor. The PNG, SVG or XPM file is installed into the user hicolor theme
+under the icon name of the package's desktop entry, replacing the icons
+upkg installed under that name. It is removed on uninstall and reapplied
+when the package is upgraded.
+
+For packages installed with pacman, dpkg or dnf the icon overrides the
+system one of the same name in the user theme.`,
+		Example:           `  upkg icons set obsidian ~/Pictures/obsidian.svg`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeInstalledPackages(cfg, 1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runIconsSetCmd(afero.NewOsFs(), helpers.NewOSCommandRunner(), cfg, log, args[0], args[1])
+		},
+	}
+}
+
+func runIconsSetCmd(fs afero.Fs, runner helpers.CommandRunner, cfg *config.Config, log *zerolog.Logger, identifier, iconPath string) error {
+	iconPath, err := validateCustomIcon(fs, iconPath)
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+
+	ctx := context.Background()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	record, err := lookupPackage(ctx, database, log, identifier)
+	if err != nil {
+		return err
+	}
+
+	installed, err := setCustomIcon(fs, cfg, record, iconPath)
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+
+	dbRecord := db.FromInstallRecord(record)
+	if stored, getErr := database.Get(ctx, record.InstallID); getErr == nil {
+		dbRecord.Metadata = mergeMetadata(stored.Metadata, dbRecord.Metadata)
+	}
+	if err := database.Update(ctx, dbRecord); err != nil {
+		ui.PrintError("failed to save the icon of %s: %v", record.Name, err)
+		return fmt.Errorf("update database: %w", err)
+	}
+	recordIntegrity(fs, cfg, log, record)
+
+	refreshIconCaches(runner, cfg, log, record)
+
+	log.Info().Str("name", record.Name).Str("icon", installed).Msg("custom icon installed")
+	ui.PrintSuccess("Set the icon of %s", record.Name)
+	ui.PrintKeyValue("Icon file", installed)
+	return nil
+}
+
+// validateCustomIcon checks that path is an icon file the theme accepts and
+// returns it absolute
+func validateCustomIcon(fs afero.Fs, path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid icon path: %w", err)
+	}
+	if !slices.Contains(customIconExtensions, strings.ToLower(filepath.Ext(absPath))) {
+		return "", fmt.Errorf("unsupported icon format %q (expected %s)", filepath.Ext(absPath), strings.Join(customIconExtensions, ", "))
+	}
+	info, err := fs.Stat(absPath)
+	if err != nil {
+		return "", fmt.Errorf("icon file not found: %s", absPath)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("icon path is a directory: %s", absPath)
+	}
+	return absPath, nil
+}
+
+// setCustomIcon installs the icon at iconPath into the user hicolor theme
+// under the icon name of record's desktop entry and records it in
+// record.Metadata.CustomIcon. Icons upkg installed for the package under
+// that name are removed so the theme cannot pick them instead, and an entry
+// that references its icon by path is pointed at the name.
+func setCustomIcon(fs afero.Fs, cfg *config.Config, record *core.InstallRecord, iconPath string) (string, error) {
+	iconsDir := filepath.Dir(paths.NewResolver(cfg).GetIconsDir())
+	systemManaged := core.IsSystemManaged(record.Metadata.InstallMethod)
+
+	var entry *core.DesktopEntry
+	if record.DesktopFile != "" {
+		file, err := fs.Open(record.DesktopFile)
+		if err != nil {
+			return "", fmt.Errorf("open desktop entry: %w", err)
+		}
+		entry, err = desktop.Parse(file)
+		_ = file.Close()
+		if err != nil {
+			return "", fmt.Errorf("parse desktop entry: %w", err)
+		}
+	}
+
+	iconName := icons.NormalizeIconName(record.Name)
+	if entry != nil && entry.Icon != "" && !strings.Contains(entry.Icon, "/") {
+		iconName = entry.Icon
+	} else if entry != nil && systemManaged {
+		return "", fmt.Errorf("the desktop entry of %s belongs to the system package and references its icon by path", record.Name)
+	}
+
+	installed, err := icons.NewManager(fs, iconsDir).InstallIcon(iconPath, iconName, icons.DetectIconSize(iconPath))
+	if err != nil {
+		return "", fmt.Errorf("install icon: %w", err)
+	}
+
+	if previous := record.Metadata.CustomIcon; previous != "" && previous != installed {
+		if err := fs.Remove(previous); err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("remove previous icon: %w", err)
+		}
+	}
+	if !systemManaged {
+		kept := record.Metadata.IconFiles[:0]
+		for _, path := range record.Metadata.IconFiles {
+			name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			if name == iconName && path != installed && strings.HasPrefix(path, iconsDir+string(filepath.Separator)) {
+				if err := fs.Remove(path); err != nil && !os.IsNotExist(err) {
+					return "", fmt.Errorf("remove shipped icon: %w", err)
+				}
+				continue
+			}
+			kept = append(kept, path)
+		}
+		record.Metadata.IconFiles = slices.DeleteFunc(kept, func(path string) bool { return path == installed })
+	}
+	record.Metadata.CustomIcon = installed
+
+	if entry != nil && entry.Icon != iconName {
+		entry.Icon = iconName
+		var buf bytes.Buffer
+		if err := desktop.Write(&buf, entry); err != nil {
+			return "", fmt.Errorf("render desktop entry: %w", err)
+		}
+		if err := afero.WriteFile(fs, record.DesktopFile, buf.Bytes(), 0644); err != nil {
+			return "", fmt.Errorf("write desktop entry: %w", err)
+		}
+	}
+	return installed, nil
+}
+
+// carryCustomIcon reinstalls the custom icon of oldRecord, whose content was
+// read before the upgrade, for the upgraded package. It returns a warning
+// when the icon could not be reinstalled.
+func carryCustomIcon(fs afero.Fs, cfg *config.Config, oldRecord, newRecord *core.InstallRecord, content []byte) string {
+	if oldRecord.Metadata.CustomIcon == "" || content == nil {
+		return ""
+	}
+	tmpDir, err := afero.TempDir(fs, "", "upkg-icon-")
+	if err != nil {
+		return fmt.Sprintf("custom icon was not reinstalled: %v", err)
+	}
+	defer func() { _ = fs.RemoveAll(tmpDir) }()
+
+	iconPath := filepath.Join(tmpDir, filepath.Base(oldRecord.Metadata.CustomIcon))
+	if err := afero.WriteFile(fs, iconPath, content, 0644); err != nil {
+		return fmt.Sprintf("custom icon was not reinstalled: %v", err)
+	}
+	if _, err := setCustomIcon(fs, cfg, newRecord, iconPath); err != nil {
+		return fmt.Sprintf("custom icon was not reinstalled: %v", err)
+	}
+	return ""
+}
+
+// removeCustomIcon deletes the custom icon of an uninstalled package
+func removeCustomIcon(fs afero.Fs, log *zerolog.Logger, record *core.InstallRecord) {
+	if record.Metadata.CustomIcon == "" {
+		return
+	}
+	if err := fs.Remove(record.Metadata.CustomIcon); err != nil && !os.IsNotExist(err) {
+		log.Warn().Err(err).Str("path", record.Metadata.CustomIcon).Msg("failed to remove custom icon")
+	}
+}
+
+// refreshIconCaches updates the icon cache and, for entries upkg owns, the
+// desktop database after an icon change
+func refreshIconCaches(runner helpers.CommandRunner, cfg *config.Config, log *zerolog.Logger, record *core.InstallRecord) {
+	cacheManager := cache.NewCacheManagerWithRunner(runner)
+	if err := cacheManager.UpdateIconCache(paths.NewResolver(cfg).GetIconsDir(), log); err != nil {
+		log.Warn().Err(err).Msg("failed to update icon cache")
+	}
+	if record.DesktopFile != "" && !core.IsSystemManaged(record.Metadata.InstallMethod) {
+		appsDir := filepath.Dir(record.DesktopFile)
+		if err := cacheManager.UpdateDesktopDatabase(appsDir, log); err != nil {
+			log.Warn().Err(err).Str("apps_dir", appsDir).Msg("failed to update desktop database")
+		}
+	}
+}
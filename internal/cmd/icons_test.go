@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestPNG(t *testing.T, fs afero.Fs, path string, size int) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, size, size))))
+	require.NoError(t, afero.WriteFile(fs, path, buf.Bytes(), 0644))
+}
+
+func TestIconLocation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path                    string
+		theme, sizeDir, context string
+	}{
+		{"/home/u/.local/share/icons/hicolor/256x256/apps/app.png", "hicolor", "256x256", "apps"},
+		{"/usr/share/icons/hicolor/scalable/apps/app.svg", "hicolor", "scalable", "apps"},
+		{"/usr/share/pixmaps/app.png", "pixmaps", "", ""},
+		{"/usr/share/icons/app.png", "", "", ""},
+		{"/opt/app/icon.png", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		theme, sizeDir, context := iconLocation(tt.path)
+		assert.Equal(t, tt.theme, theme, tt.path)
+		assert.Equal(t, tt.sizeDir, sizeDir, tt.path)
+		assert.Equal(t, tt.context, context, tt.path)
+	}
+}
+
+func TestListIcons(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	iconsDir := "/home/u/.local/share/icons/hicolor"
+	good := filepath.Join(iconsDir, "48x48", "apps", "app.png")
+	wrong := filepath.Join(iconsDir, "256x256", "apps", "app.png")
+	svg := filepath.Join(iconsDir, "scalable", "apps", "app.svg")
+	missing := filepath.Join(iconsDir, "128x128", "apps", "app.png")
+	writeTestPNG(t, fs, good, 48)
+	writeTestPNG(t, fs, wrong, 64)
+	require.NoError(t, afero.WriteFile(fs, svg, []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/apps/app.desktop", []byte("[Desktop Entry]\nName=App\nExec=app\nIcon=app\n"), 0644))
+
+	record := &core.InstallRecord{
+		Name:        "App",
+		DesktopFile: "/apps/app.desktop",
+		Metadata:    core.Metadata{IconFiles: []string{good, wrong, svg, missing}},
+	}
+
+	report := listIcons(fs, record)
+	assert.Equal(t, "app", report.IconName)
+	require.Len(t, report.Icons, 4)
+
+	assert.Equal(t, "48x48", report.Icons[0].Dimensions)
+	assert.False(t, report.Icons[0].sizeMismatch())
+	assert.Positive(t, report.Icons[0].Bytes)
+
+	assert.Equal(t, "64x64", report.Icons[1].Dimensions)
+	assert.True(t, report.Icons[1].sizeMismatch())
+
+	assert.Equal(t, "scalable", report.Icons[2].Dimensions)
+	assert.Equal(t, "scalable", report.Icons[2].SizeDir)
+
+	assert.False(t, report.Icons[3].Exists)
+	assert.Equal(t, "128x128", report.Icons[3].SizeDir)
+}
+
+func TestSizeMismatch_ScaledDirectory(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, iconFileInfo{SizeDir: "48x48@2", Dimensions: "96x96"}.sizeMismatch())
+	assert.True(t, iconFileInfo{SizeDir: "48x48@2", Dimensions: "48x48"}.sizeMismatch())
+}
+
+func TestOpenIcon(t *testing.T) {
+	t.Parallel()
+
+	report := iconsReport{Name: "App", Icons: []iconFileInfo{
+		{Path: "/icons/48.png", Dimensions: "48x48", Exists: true},
+		{Path: "/icons/512.png", Dimensions: "512x512", Exists: true},
+		{Path: "/icons/app.svg", Dimensions: "scalable"},
+		{Path: "/icons/256.png", Dimensions: "256x256", Exists: true},
+	}}
+
+	var opened []string
+	runner := &helpers.MockCommandRunner{
+		RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+			opened = append(opened, name+" "+args[0])
+			return "", nil
+		},
+	}
+
+	require.NoError(t, openIcon(context.Background(), runner, report))
+	assert.Equal(t, []string{"xdg-open /icons/512.png"}, opened, "the missing SVG is skipped")
+
+	err := openIcon(context.Background(), runner, iconsReport{Name: "App"})
+	assert.ErrorContains(t, err, "no icon files to preview")
+
+	runner.RequireCommandFunc = func(string) error { return errors.New("xdg-open not found") }
+	assert.ErrorContains(t, openIcon(context.Background(), runner, report), "xdg-open not found")
+}
+
+func TestRunIconsListCmd_JSON(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: filepath.Join(tmpDir, "test.db")}}
+	log := zerolog.New(io.Discard)
+
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	require.NoError(t, database.Create(ctx, &db.Install{
+		InstallID:   "icons-id",
+		PackageType: "tarball",
+		Name:        "IconApp",
+		InstallDate: time.Now(),
+		InstallPath: "/opt/iconapp",
+		Metadata:    map[string]interface{}{"icon_files": []string{"/icons/hicolor/48x48/apps/iconapp.png"}},
+	}))
+	require.NoError(t, database.Close())
+
+	fs := afero.NewMemMapFs()
+	writeTestPNG(t, fs, "/icons/hicolor/48x48/apps/iconapp.png", 48)
+
+	var out bytes.Buffer
+	require.NoError(t, runIconsListCmd(&out, fs, &helpers.MockCommandRunner{}, cfg, &log, &iconsListOptions{jsonOutput: true}, "iconapp"))
+
+	var report iconsReport
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, "IconApp", report.Name)
+	require.Len(t, report.Icons, 1)
+	assert.Equal(t, "hicolor", report.Icons[0].Theme)
+	assert.Equal(t, "48x48", report.Icons[0].Dimensions)
+	assert.True(t, report.Icons[0].Exists)
+}
+
+func TestSetCustomIcon(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	fs := afero.NewOsFs()
+	cfg := &config.Config{}
+	iconsDir := filepath.Join(home, ".local", "share", "icons", "hicolor")
+
+	desktopFile := filepath.Join(home, ".local", "share", "applications", "myapp.desktop")
+	require.NoError(t, fs.MkdirAll(filepath.Dir(desktopFile), 0755))
+	require.NoError(t, afero.WriteFile(fs, desktopFile,
+		[]byte("[Desktop Entry]\nType=Application\nName=MyApp\nExec=/opt/myapp/myapp\nIcon=/opt/myapp/icon.png\n"), 0644))
+	shipped := filepath.Join(iconsDir, "48x48", "apps", "myapp.png")
+	require.NoError(t, fs.MkdirAll(filepath.Dir(shipped), 0755))
+	writeTestPNG(t, fs, shipped, 48)
+
+	record := &core.InstallRecord{
+		Name:        "MyApp",
+		PackageType: core.PackageTypeTarball,
+		DesktopFile: desktopFile,
+		Metadata:    core.Metadata{IconFiles: []string{shipped, "/usr/share/pixmaps/other.png"}},
+	}
+
+	custom := filepath.Join(t.TempDir(), "custom.png")
+	writeTestPNG(t, fs, custom, 256)
+	installed, err := setCustomIcon(fs, cfg, record, custom)
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(iconsDir, "256x256", "apps", "myapp.png"), installed)
+	assert.FileExists(t, installed)
+	assert.NoFileExists(t, shipped)
+	assert.Equal(t, installed, record.Metadata.CustomIcon)
+	assert.Equal(t, []string{"/usr/share/pixmaps/other.png"}, record.Metadata.IconFiles)
+	content, err := afero.ReadFile(fs, desktopFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Icon=myapp\n")
+
+	// A new icon replaces the previous one
+	svg := filepath.Join(t.TempDir(), "custom.svg")
+	require.NoError(t, afero.WriteFile(fs, svg, []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 16 16"/>`), 0644))
+	replaced, err := setCustomIcon(fs, cfg, record, svg)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(iconsDir, "scalable", "apps", "myapp.svg"), replaced)
+	assert.NoFileExists(t, installed)
+
+	log := zerolog.New(io.Discard)
+	removeCustomIcon(fs, &log, record)
+	assert.NoFileExists(t, replaced)
+}
+
+func TestSetCustomIcon_SystemEntryWithIconPath(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	fs := afero.NewOsFs()
+
+	desktopFile := filepath.Join(t.TempDir(), "app.desktop")
+	require.NoError(t, afero.WriteFile(fs, desktopFile,
+		[]byte("[Desktop Entry]\nType=Application\nName=App\nExec=app\nIcon=/usr/share/app/icon.png\n"), 0644))
+	record := &core.InstallRecord{
+		Name:        "app",
+		PackageType: core.PackageTypeDeb,
+		DesktopFile: desktopFile,
+		Metadata:    core.Metadata{InstallMethod: core.InstallMethodPacman},
+	}
+
+	custom := filepath.Join(t.TempDir(), "custom.png")
+	writeTestPNG(t, fs, custom, 64)
+	_, err := setCustomIcon(fs, &config.Config{}, record, custom)
+	assert.ErrorContains(t, err, "references its icon by path")
+}
+
+func TestValidateCustomIcon(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/icons/app.ico", []byte("ico"), 0644))
+	require.NoError(t, fs.MkdirAll("/icons/dir.png", 0755))
+	writeTestPNG(t, fs, "/icons/app.png", 32)
+
+	_, err := validateCustomIcon(fs, "/icons/app.ico")
+	assert.ErrorContains(t, err, "unsupported icon format")
+	_, err = validateCustomIcon(fs, "/icons/missing.png")
+	assert.ErrorContains(t, err, "icon file not found")
+	_, err = validateCustomIcon(fs, "/icons/dir.png")
+	assert.ErrorContains(t, err, "is a directory")
+	path, err := validateCustomIcon(fs, "/icons/app.png")
+	require.NoError(t, err)
+	assert.Equal(t, "/icons/app.png", path)
+}
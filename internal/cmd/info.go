@@ -2,91 +2,167 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/quantmind-br/upkg/internal/config"
 	"github.com/quantmind-br/upkg/internal/core"
 	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/fetch"
+	"github.com/quantmind-br/upkg/internal/preview"
 	"github.com/quantmind-br/upkg/internal/ui"
 	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 )
 
+// infoOptions holds the flags of the info command
+type infoOptions struct {
+	jsonOutput bool
+
+	events *ui.EventWriter // Set in JSON output mode; receives the package info
+}
+
+// packageInfo is the JSON form of info: the install record plus what is on disk
+type packageInfo struct {
+	*core.InstallRecord
+	OriginalFileExists bool           `json:"original_file_exists"`
+	DiskUsage          int64          `json:"disk_usage"`
+	Files              []preview.File `json:"files"`
+}
+
 // NewInfoCmd creates the info command
 func NewInfoCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &infoOptions{}
+
 	cmd := &cobra.Command{
 		Use:   "info [package-name or install-id]",
 		Short: "Show package information",
-		Long:  `Show detailed information about an installed package.`,
-		Args:  cobra.ExactArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
-			identifier := args[0]
-			ctx := context.Background()
-
-			// Open database
-			database, err := db.New(ctx, cfg.Paths.DBFile)
-			if err != nil {
-				ui.PrintError("failed to open database: %v", err)
-				return fmt.Errorf("open database: %w", err)
-			}
-			defer func() { _ = database.Close() }()
-
-			// Try to find install record (by ID or name)
-			var dbRecord *db.Install
-
-			// Try as install ID first
-			dbRecord, err = database.Get(ctx, identifier)
-			if err != nil {
-				// Try finding by name
-				log.Debug().
-					Str("identifier", identifier).
-					Msg("not found by ID, trying by name")
-
-				// List all and find by name
-				allInstalls, err := database.List(ctx)
-				if err != nil {
-					ui.PrintError("failed to query database: %v", err)
-					return fmt.Errorf("failed to query database: %w", err)
-				}
-
-				// Find by name (case-insensitive)
-				lowerIdentifier := strings.ToLower(identifier)
-				for _, install := range allInstalls {
-					if strings.ToLower(install.Name) == lowerIdentifier {
-						installCopy := install
-						dbRecord = &installCopy
-						break
-					}
-				}
-
-				if dbRecord == nil {
-					ui.PrintError("package not found: %s", identifier)
-					ui.PrintInfo("Use 'upkg list' to see installed packages")
-					return fmt.Errorf("package not found")
-				}
-			}
+		Long: `Show detailed information about an installed package: its paths,
+desktop integration, install method, disk usage and whether each
+installed file still exists.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeInstalledPackages(cfg, 1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.events = ui.EventWriterFromContext(cmd.Context())
+			return runInfoCmd(cmd.OutOrStdout(), cfg, log, opts, args[0])
+		},
+	}
 
-			// Convert to core.InstallRecord
-			record := db.ToInstallRecord(dbRecord)
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "output package information in JSON format")
 
-			// Display package information
-			printPackageInfo(record)
+	return cmd
+}
 
-			log.Info().
-				Str("install_id", record.InstallID).
-				Str("name", record.Name).
-				Msg("displayed package info")
+func runInfoCmd(out io.Writer, cfg *config.Config, log *zerolog.Logger, opts *infoOptions, identifier string) error {
+	ctx := context.Background()
 
-			return nil
-		},
+	// Open database
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
 	}
+	defer func() { _ = database.Close() }()
 
-	return cmd
+	// Try to find install record (by ID or name)
+	var dbRecord *db.Install
+
+	// Try as install ID first
+	dbRecord, err = database.Get(ctx, identifier)
+	if err != nil {
+		// Try finding by name
+		log.Debug().
+			Str("identifier", identifier).
+			Msg("not found by ID, trying by name")
+
+		// List all and find by name
+		allInstalls, err := database.List(ctx)
+		if err != nil {
+			ui.PrintError("failed to query database: %v", err)
+			return fmt.Errorf("failed to query database: %w", err)
+		}
+
+		// Find by name (case-insensitive)
+		lowerIdentifier := strings.ToLower(identifier)
+		for _, install := range allInstalls {
+			if strings.ToLower(install.Name) == lowerIdentifier {
+				installCopy := install
+				dbRecord = &installCopy
+				break
+			}
+		}
+
+		if dbRecord == nil {
+			ui.PrintError("package not found: %s", identifier)
+			ui.PrintInfo("Use 'upkg list' to see installed packages")
+			return withExitCode(ExitNotFound, fmt.Errorf("package not found"))
+		}
+	}
+
+	// Convert to core.InstallRecord
+	record := db.ToInstallRecord(dbRecord)
+	info := inspectPackage(afero.NewOsFs(), record)
+
+	switch {
+	case opts.events != nil:
+		opts.events.Result(record.Name, info)
+	case opts.jsonOutput:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(info); err != nil {
+			return fmt.Errorf("encode package info: %w", err)
+		}
+	default:
+		printPackageInfo(info)
+	}
+
+	log.Info().
+		Str("install_id", record.InstallID).
+		Str("name", record.Name).
+		Msg("displayed package info")
+
+	return nil
+}
+
+// inspectPackage checks which of the record's files are still on disk
+func inspectPackage(fs afero.Fs, record *core.InstallRecord) packageInfo {
+	report := preview.Preview(fs, record)
+	info := packageInfo{
+		InstallRecord: record,
+		DiskUsage:     report.TotalSize,
+		Files:         report.Files,
+	}
+	if record.OriginalFile != "" {
+		_, err := fs.Stat(record.OriginalFile)
+		info.OriginalFileExists = err == nil
+	}
+	return info
 }
 
 // printPackageInfo displays detailed package information
-func printPackageInfo(record *core.InstallRecord) {
+func printPackageInfo(info packageInfo) {
+	record := info.InstallRecord
+	exists := make(map[string]bool, len(info.Files))
+	for _, file := range info.Files {
+		exists[file.Path] = file.Exists
+	}
+	onDisk := func(path string) string {
+		if path == "" || exists[path] {
+			return path
+		}
+		return path + " " + ui.SprintWarning("(missing)")
+	}
+	onDiskList := func(paths []string) []string {
+		marked := make([]string, 0, len(paths))
+		for _, path := range paths {
+			marked = append(marked, onDisk(path))
+		}
+		return marked
+	}
+
 	ui.PrintHeader(fmt.Sprintf("Package Information: %s", record.Name))
 	fmt.Println()
 
@@ -99,18 +175,31 @@ func printPackageInfo(record *core.InstallRecord) {
 		version = "(not specified)"
 	}
 	ui.PrintKeyValue("Version", version)
+	if record.Metadata.SelfUpdating {
+		appVersion := record.Metadata.AppVersion
+		if appVersion == "" {
+			appVersion = "unknown"
+		}
+		ui.PrintKeyValue("Self-Updating", fmt.Sprintf("yes (app reports %s)", appVersion))
+	}
 
 	ui.PrintKeyValue("Install ID", record.InstallID)
 	ui.PrintKeyValue("Install Date", record.InstallDate.Format("2006-01-02 15:04:05"))
+	ui.PrintKeyValue("Disk Usage", formatBytes(info.DiskUsage))
 
 	fmt.Println()
 	ui.PrintSubheader("Paths")
 
-	ui.PrintKeyValue("Install Path", record.InstallPath)
-	ui.PrintKeyValue("Original File", record.OriginalFile)
+	ui.PrintKeyValue("Install Path", onDisk(record.InstallPath))
+
+	originalFile := record.OriginalFile
+	if originalFile != "" && !info.OriginalFileExists {
+		originalFile += " " + ui.SprintWarning("(missing)")
+	}
+	ui.PrintKeyValue("Original File", originalFile)
 
 	if record.DesktopFile != "" {
-		ui.PrintKeyValue("Desktop File", record.DesktopFile)
+		ui.PrintKeyValue("Desktop File", onDisk(record.DesktopFile))
 	} else {
 		ui.PrintKeyValue("Desktop File", "(none)")
 	}
@@ -122,15 +211,38 @@ func printPackageInfo(record *core.InstallRecord) {
 	// Icon files
 	if len(record.Metadata.IconFiles) > 0 {
 		ui.PrintKeyValue("Icon Files", "")
-		ui.PrintList(record.Metadata.IconFiles)
+		ui.PrintList(onDiskList(record.Metadata.IconFiles))
+	}
+
+	// AppStream metainfo
+	if len(record.Metadata.MetainfoFiles) > 0 {
+		ui.PrintKeyValue("Metainfo Files", "")
+		ui.PrintList(onDiskList(record.Metadata.MetainfoFiles))
+	}
+
+	// Man pages and shell completions
+	if len(record.Metadata.ManPages) > 0 {
+		ui.PrintKeyValue("Man Pages", "")
+		ui.PrintList(onDiskList(record.Metadata.ManPages))
+	}
+	if len(record.Metadata.Completions) > 0 {
+		ui.PrintKeyValue("Completions", "")
+		ui.PrintList(onDiskList(record.Metadata.Completions))
 	}
 
 	// Wrapper script
 	if record.Metadata.WrapperScript != "" {
-		ui.PrintKeyValue("Wrapper Script", record.Metadata.WrapperScript)
+		ui.PrintKeyValue("Wrapper Script", onDisk(record.Metadata.WrapperScript))
+	}
+	if len(record.Metadata.ExtraWrappers) > 0 {
+		ui.PrintKeyValue("Other Launchers", "")
+		ui.PrintList(onDiskList(record.Metadata.ExtraWrappers))
 	}
 
 	// Wayland support
+	if record.Metadata.Framework != "" {
+		ui.PrintKeyValue("Framework", record.Metadata.Framework)
+	}
 	if record.Metadata.WaylandSupport != "" {
 		ui.PrintKeyValue("Wayland Support", record.Metadata.WaylandSupport)
 	}
@@ -138,7 +250,13 @@ func printPackageInfo(record *core.InstallRecord) {
 	// Desktop files
 	if len(record.Metadata.DesktopFiles) > 0 {
 		ui.PrintKeyValue("Desktop Files", "")
-		ui.PrintList(record.Metadata.DesktopFiles)
+		ui.PrintList(onDiskList(record.Metadata.DesktopFiles))
+	}
+
+	// Exposed binaries
+	if len(record.Metadata.ExposedBins) > 0 {
+		ui.PrintKeyValue("Exposed Binaries", "")
+		ui.PrintList(onDiskList(record.Metadata.ExposedBins))
 	}
 
 	// Original desktop file
@@ -151,5 +269,29 @@ func printPackageInfo(record *core.InstallRecord) {
 		ui.PrintKeyValue("Install Method", record.Metadata.InstallMethod)
 	}
 
+	if record.Metadata.Scope == config.ScopeSystem {
+		ui.PrintKeyValue("Scope", "system (shared by all users)")
+	}
+	if record.Metadata.Pinned {
+		ui.PrintKeyValue("Pinned", "yes (skipped by bulk operations)")
+	}
+
+	// Groups and upstream source
+	if len(record.Metadata.Groups) > 0 {
+		ui.PrintKeyValue("Groups", strings.Join(record.Metadata.Groups, ", "))
+	}
+	if record.Metadata.SourceRepo != "" {
+		source := fetch.GitHubPrefix + record.Metadata.SourceRepo
+		if record.Metadata.SourceTag != "" {
+			source += "@" + record.Metadata.SourceTag
+		}
+		ui.PrintKeyValue("Source", source)
+	} else if record.Metadata.SourceURL != "" {
+		ui.PrintKeyValue("Source", record.Metadata.SourceURL)
+	}
+	if record.Metadata.ContentHash != "" {
+		ui.PrintKeyValue("Content Hash", record.Metadata.ContentHash)
+	}
+
 	fmt.Println()
 }
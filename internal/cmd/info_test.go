@@ -3,14 +3,18 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
 	"github.com/quantmind-br/upkg/internal/db"
 	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -588,3 +592,67 @@ func TestInfoCmd_SearchByNameCaseInsensitive(t *testing.T) {
 	err = cmd.Execute()
 	assert.NoError(t, err)
 }
+
+func TestInfoCmd_JSON(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.New(io.Discard)
+	tmpDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: filepath.Join(tmpDir, "test.db")}}
+
+	installPath := filepath.Join(tmpDir, "app.AppImage")
+	require.NoError(t, os.WriteFile(installPath, []byte("1234"), 0755))
+
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	require.NoError(t, database.Create(ctx, &db.Install{
+		InstallID:    "json-id",
+		PackageType:  "appimage",
+		Name:         "JSONApp",
+		InstallDate:  time.Now(),
+		OriginalFile: filepath.Join(tmpDir, "gone.AppImage"),
+		InstallPath:  installPath,
+		DesktopFile:  filepath.Join(tmpDir, "jsonapp.desktop"),
+		Metadata:     map[string]interface{}{"wayland_support": "native", "install_method": "local"},
+	}))
+	require.NoError(t, database.Close())
+
+	cmd := NewInfoCmd(cfg, &logger)
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{"jsonapp", "--json"})
+	require.NoError(t, cmd.Execute())
+
+	var info map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &info))
+	assert.Equal(t, "json-id", info["install_id"])
+	assert.Equal(t, installPath, info["install_path"])
+	assert.Equal(t, false, info["original_file_exists"])
+	assert.Equal(t, float64(4), info["disk_usage"])
+	assert.Equal(t, "native", info["metadata"].(map[string]interface{})["wayland_support"])
+
+	files := info["files"].([]interface{})
+	require.Len(t, files, 2)
+	assert.Equal(t, true, files[0].(map[string]interface{})["exists"])
+	assert.Equal(t, false, files[1].(map[string]interface{})["exists"], "desktop file is missing")
+}
+
+func TestInspectPackage(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/apps/tool/tool", []byte("12345"), 0755))
+	require.NoError(t, afero.WriteFile(fs, "/downloads/tool.tar.gz", []byte("x"), 0644))
+
+	info := inspectPackage(fs, &core.InstallRecord{
+		InstallPath:  "/apps/tool",
+		OriginalFile: "/downloads/tool.tar.gz",
+		Metadata:     core.Metadata{WrapperScript: "/bin/tool"},
+	})
+	assert.True(t, info.OriginalFileExists)
+	assert.Equal(t, int64(5), info.DiskUsage)
+	require.Len(t, info.Files, 2)
+	assert.True(t, info.Files[0].Exists)
+	assert.False(t, info.Files[1].Exists)
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/cache"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// initOptions holds the flags of the init command
+type initOptions struct {
+	yes   bool
+	force bool
+}
+
+// NewInitCmd creates the init command
+func NewInitCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &initOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Interactive first-run setup",
+		Long: `Walk through the main upkg settings, write the config file and verify
+that the external tools upkg relies on are available.
+
+Use --yes to accept the current values without prompting.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runInit(cfg, log, opts)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.yes, "yes", "y", false, "accept defaults without prompting")
+	cmd.Flags().BoolVar(&opts.force, "force", false, "overwrite an existing config file")
+
+	return cmd
+}
+
+//nolint:gocyclo // wizard walks through a fixed sequence of questions.
+func runInit(cfg *config.Config, log *zerolog.Logger, opts *initOptions) error {
+	if !opts.yes && !isInteractive() {
+		return fmt.Errorf("non-interactive mode requires --yes flag")
+	}
+
+	configPath, err := config.FilePath()
+	if err != nil {
+		return err
+	}
+
+	ui.PrintHeader("upkg setup")
+
+	if _, statErr := os.Stat(configPath); statErr == nil && !opts.force {
+		if opts.yes {
+			return fmt.Errorf("config file already exists: %s (use --force to overwrite)", configPath)
+		}
+		overwrite, promptErr := ui.ConfirmWithDefault(fmt.Sprintf("%s already exists. Overwrite", configPath), false)
+		if promptErr != nil {
+			return promptErr
+		}
+		if !overwrite {
+			ui.PrintInfo("Keeping existing configuration")
+			return nil
+		}
+	}
+
+	newCfg := *cfg
+	ask := func(label string, def bool) (bool, error) {
+		if opts.yes {
+			return def, nil
+		}
+		return ui.ConfirmWithDefault(label, def)
+	}
+
+	if newCfg.Desktop.WaylandEnvVars, err = ask("Inject Wayland environment variables into desktop entries", cfg.Desktop.WaylandEnvVars); err != nil {
+		return err
+	}
+	if newCfg.Desktop.ElectronDisableSandbox, err = ask("Disable the Electron sandbox (--no-sandbox) for Electron apps", cfg.Desktop.ElectronDisableSandbox); err != nil {
+		return err
+	}
+
+	resolver := paths.NewResolver(cfg)
+	binDir := resolver.GetBinDir()
+	addToPath := false
+	rcFile := shellRCFile(os.Getenv("SHELL"), resolver.HomeDir())
+	if !pathContains(os.Getenv("PATH"), binDir) && rcFile != "" {
+		if addToPath, err = ask(fmt.Sprintf("Add %s to PATH in %s", binDir, rcFile), true); err != nil {
+			return err
+		}
+	}
+
+	refreshDesktopDB, err := ask("Enable file-manager integration (refresh the desktop database now)", true)
+	if err != nil {
+		return err
+	}
+
+	if err := config.Save(&newCfg, configPath); err != nil {
+		return err
+	}
+	ui.PrintSuccess("Configuration written to %s", configPath)
+
+	if addToPath {
+		added, pathErr := ensurePathExport(rcFile, binDir)
+		if pathErr != nil {
+			ui.PrintWarning("could not update %s: %v", rcFile, pathErr)
+		} else if added {
+			ui.PrintSuccess("Added %s to PATH in %s (restart your shell to apply)", binDir, rcFile)
+		}
+	}
+
+	if refreshDesktopDB {
+		appsDir := resolver.GetAppsDir()
+		if err := os.MkdirAll(appsDir, 0755); err != nil {
+			ui.PrintWarning("could not create %s: %v", appsDir, err)
+		} else if err := cache.NewCacheManager().UpdateDesktopDatabase(appsDir, log); err == nil {
+			ui.PrintSuccess("Desktop database refreshed")
+		}
+	}
+
+	ui.PrintSubheader("External Tools")
+	missingRequired := 0
+	for _, dep := range requiredDependencies {
+		if checkDependency(dep.command, dep.name, dep.purpose, true) {
+			ui.PrintSuccess("%s: found", dep.name)
+		} else {
+			ui.PrintError("%s: NOT FOUND (%s)", dep.name, dep.purpose)
+			missingRequired++
+		}
+	}
+	for _, dep := range optionalDependencies {
+		if checkDependency(dep.command, dep.name, dep.purpose, false) {
+			ui.PrintSuccess("%s: found", dep.name)
+		} else {
+			ui.PrintWarning("%s: not found (optional - %s)", dep.name, dep.purpose)
+		}
+	}
+
+	fmt.Println()
+	if missingRequired > 0 {
+		ui.PrintWarning("%d required tool(s) missing; run 'upkg doctor' for details", missingRequired)
+	} else {
+		ui.PrintSuccess("upkg is ready to use")
+	}
+
+	return nil
+}
+
+// pathContains reports whether dir is an entry of a PATH-style list
+func pathContains(pathEnv, dir string) bool {
+	cleanDir := filepath.Clean(dir)
+	for _, entry := range filepath.SplitList(pathEnv) {
+		if entry != "" && filepath.Clean(entry) == cleanDir {
+			return true
+		}
+	}
+	return false
+}
+
+// shellRCFile returns the startup file of the given login shell
+func shellRCFile(shell, homeDir string) string {
+	switch filepath.Base(shell) {
+	case "bash":
+		return filepath.Join(homeDir, ".bashrc")
+	case "zsh":
+		return filepath.Join(homeDir, ".zshrc")
+	case "fish":
+		return filepath.Join(homeDir, ".config", "fish", "config.fish")
+	default:
+		return ""
+	}
+}
+
+// ensurePathExport appends a PATH export for dir to rcFile unless already present
+func ensurePathExport(rcFile, dir string) (bool, error) {
+	existing, err := os.ReadFile(rcFile)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("read %s: %w", rcFile, err)
+	}
+	if strings.Contains(string(existing), dir) {
+		return false, nil
+	}
+
+	line := fmt.Sprintf("export PATH=\"%s:$PATH\"", dir)
+	if strings.HasSuffix(rcFile, ".fish") {
+		line = fmt.Sprintf("fish_add_path %s", dir)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(rcFile), 0755); err != nil {
+		return false, fmt.Errorf("create directory for %s: %w", rcFile, err)
+	}
+
+	f, err := os.OpenFile(rcFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return false, fmt.Errorf("open %s: %w", rcFile, err)
+	}
+	defer f.Close()
+
+	if _, err := fmt.Fprintf(f, "\n# Added by upkg init\n%s\n", line); err != nil {
+		return false, fmt.Errorf("write %s: %w", rcFile, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInitCmd(t *testing.T) {
+	t.Parallel()
+	logger := zerolog.New(io.Discard)
+
+	cmd := NewInitCmd(&config.Config{}, &logger)
+
+	assert.Equal(t, "init", cmd.Use)
+	assert.NotNil(t, cmd.Flags().Lookup("yes"))
+	assert.NotNil(t, cmd.Flags().Lookup("force"))
+}
+
+func TestPathContains(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		pathEnv string
+		dir     string
+		want    bool
+	}{
+		{"present", "/usr/bin:/home/u/.local/bin", "/home/u/.local/bin", true},
+		{"trailing slash", "/usr/bin:/home/u/.local/bin/", "/home/u/.local/bin", true},
+		{"absent", "/usr/bin:/bin", "/home/u/.local/bin", false},
+		{"empty", "", "/home/u/.local/bin", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, pathContains(tt.pathEnv, tt.dir))
+		})
+	}
+}
+
+func TestShellRCFile(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "/home/u/.bashrc", shellRCFile("/bin/bash", "/home/u"))
+	assert.Equal(t, "/home/u/.zshrc", shellRCFile("/usr/bin/zsh", "/home/u"))
+	assert.Equal(t, "/home/u/.config/fish/config.fish", shellRCFile("/usr/bin/fish", "/home/u"))
+	assert.Empty(t, shellRCFile("/bin/tcsh", "/home/u"))
+}
+
+func TestEnsurePathExport(t *testing.T) {
+	t.Parallel()
+
+	rcFile := filepath.Join(t.TempDir(), ".bashrc")
+	require.NoError(t, os.WriteFile(rcFile, []byte("alias ll='ls -l'\n"), 0644))
+
+	added, err := ensurePathExport(rcFile, "/home/u/.local/bin")
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	// Second call must be idempotent
+	added, err = ensurePathExport(rcFile, "/home/u/.local/bin")
+	require.NoError(t, err)
+	assert.False(t, added)
+
+	content, err := os.ReadFile(rcFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `export PATH="/home/u/.local/bin:$PATH"`)
+	assert.Contains(t, string(content), "alias ll='ls -l'")
+}
+
+func TestEnsurePathExport_Fish(t *testing.T) {
+	t.Parallel()
+
+	rcFile := filepath.Join(t.TempDir(), "fish", "config.fish")
+
+	added, err := ensurePathExport(rcFile, "/home/u/.local/bin")
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	content, err := os.ReadFile(rcFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "fish_add_path /home/u/.local/bin")
+}
@@ -2,220 +2,883 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/quantmind-br/upkg/internal/appdata"
 	"github.com/quantmind-br/upkg/internal/backends"
 	"github.com/quantmind-br/upkg/internal/backends/flatpak"
 	"github.com/quantmind-br/upkg/internal/config"
 	"github.com/quantmind-br/upkg/internal/core"
 	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/fetch"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/history"
+	"github.com/quantmind-br/upkg/internal/hooks"
 	"github.com/quantmind-br/upkg/internal/hyprland"
+	"github.com/quantmind-br/upkg/internal/inhibit"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/portal"
+	"github.com/quantmind-br/upkg/internal/remediation"
 	"github.com/quantmind-br/upkg/internal/security"
 	"github.com/quantmind-br/upkg/internal/transaction"
 	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/quantmind-br/upkg/internal/wrapper"
 	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 )
 
+// installOptions holds command flags
+type installOptions struct {
+	force          bool
+	skipDesktop    bool
+	customName     string
+	timeoutSecs    int
+	skipWaylandEnv bool
+	skipIconFix    bool
+	overwrite      bool
+	exposeAllBins  bool
+	bins           []string // Executables to create launchers for (tarball): "all" or file names
+	binDesktops    bool     // Give every --bin launcher a desktop entry
+	explainChoice  bool     // Print how the main executable was chosen
+	hiDPI          bool
+	desktop        bool     // Create a desktop entry for a standalone binary
+	sha256         string   // Expected SHA256 of the package file (verified for URLs and local files)
+	noCache        bool     // Download URLs again instead of reusing the cached copy
+	noCacheUpdate  bool     // Leave the desktop database and icon caches for 'upkg refresh-caches'
+	group          string   // Group the package is installed as part of (set for @group installs)
+	jobs           int      // Packages installed concurrently by a batch install
+	fromDir        string   // Already unpacked application folder to install
+	linkDir        bool     // Symlink fromDir into the apps dir instead of copying it
+	selfUpdating   bool     // The app updates itself in place; track its own version
+	autostart      bool     // Start the app at login from ~/.config/autostart
+	method         string   // DEB/RPM install method: auto, pacman, dpkg, dnf or extract
+	pick           bool     // Choose the package in the desktop's file chooser
+	sandbox        bool     // Launch the app inside bwrap/firejail
+	manifest       bool     // Installed by upkg apply; tracked for apply --prune
+	icon           string   // Icon file installed instead of the shipped icon
+	wrapperArgs    []string // Arguments the wrapper passes to the app
+	wrapperEnv     []string // KEY=VALUE pairs the wrapper exports
+
+	// Hook commands run after the config's hooks.pre_install and hooks.post_install
+	preInstall  []string
+	postInstall []string
+
+	events *ui.EventWriter // Set in JSON output mode; receives progress and results
+
+	// Set for members of a batch install
+	batch      bool            // The caller ran the one-time checks and owns the terminal
+	progress   ui.ProgressSink // Receives progress instead of the terminal
+	systemLock sync.Locker     // Serializes backends that drive a system package manager
+}
+
 // NewInstallCmd creates the install command
-//
-//nolint:gocyclo // command wiring includes validation and multiple optional flows.
 func NewInstallCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
-	var (
-		force          bool
-		skipDesktop    bool
-		customName     string
-		timeoutSecs    int
-		skipWaylandEnv bool
-		skipIconFix    bool
-		overwrite      bool
-	)
+	opts := &installOptions{}
 
 	cmd := &cobra.Command{
-		Use:   "install [package]",
+		Use:   "install [package|url|gh:owner/repo[@tag]|@group]...",
 		Short: "Install a package",
-		Long:  `Install a package from the specified file (AppImage, DEB, RPM, Tarball, or Binary).`,
-		Args:  cobra.ExactArgs(1),
-		RunE: func(_ *cobra.Command, args []string) error {
-			packagePath := args[0]
-
-			isFlatpakAppID := flatpak.IsFlatpakAppID(packagePath) || flatpak.IsFlatpakRemoteRef(packagePath)
-
-			if !isFlatpakAppID {
-				absPath, err := filepath.Abs(packagePath)
+		Long: `Install a package from the specified file (AppImage, DEB, RPM, Tarball, or Binary).
+
+The package may also be an http(s) URL. It is downloaded into the cache
+directory (paths.cache_dir); interrupted downloads resume on the next attempt.
+
+gh:owner/repo[@tag] installs from GitHub Releases (latest release without a
+tag), choosing the asset for this CPU and C library in the order given by
+sources.format_preference.
+
+Use @name to install every member of a group defined in the [groups] table of
+the config file; members are tracked so 'upkg uninstall @name' removes the set.
+
+A standalone executable (a single static binary) is copied to ~/.local/bin;
+pass --desktop to also add it to the application menu.
+
+Several packages may be given at once. They are installed concurrently
+(--jobs at a time); DEB, RPM and Flatpak installs, which drive the system
+package manager and flatpak, run one at a time. A summary of successes and
+failures is printed at the end.
+
+--from-dir installs an application a vendor ships as an unpacked folder:
+the launcher executable is picked like for archives, icons, a wrapper and a
+desktop entry are created, and the folder is copied into the apps directory
+(or symlinked there with --link, so it stays in place).
+
+DEB packages are installed with apt/dpkg on Debian and Ubuntu, and converted
+with debtap and installed with pacman on Arch Linux. Elsewhere, or with
+--method extract, their payload is unpacked into the apps directory and
+given a wrapper, icons and a desktop entry instead.
+
+RPM packages are installed with dnf (zypper on openSUSE) on Fedora and
+openSUSE, and extracted elsewhere or with --method extract. The native
+package manager is detected from /etc/os-release; set syspkg.provider in the
+config file to override it.
+
+--sandbox launches the app through a wrapper that runs it inside bwrap (or
+firejail) with the profile of the [sandbox] config section: a private home,
+network access and the devices passed through. It applies to AppImages and
+to extracted tarballs, DEBs and RPMs.
+
+--pick asks for the package in the desktop's file chooser through
+xdg-desktop-portal instead of taking it as an argument.
+
+--icon installs a PNG, SVG or XPM file of your own as the app icon, in
+place of a missing or poor shipped one (see also 'upkg icons set').
+
+--pre-install and --post-install add shell commands to the hooks of the
+[hooks] config section. Hooks get UPKG_NAME, UPKG_INSTALL_PATH,
+UPKG_DESKTOP_FILE, UPKG_PACKAGE_TYPE, UPKG_VERSION, UPKG_INSTALL_ID and
+UPKG_PACKAGE in their environment. A failing pre-install hook aborts the
+install; a failing post-install hook rolls it back (or only warns with
+hooks.abort_on_failure = false).`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if opts.fromDir != "" || opts.pick {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateInstallMethod(opts.method); err != nil {
+				return err
+			}
+			if err := wrapper.ValidateEnv(opts.wrapperEnv); err != nil {
+				return fmt.Errorf("invalid --wrapper-env: %w", err)
+			}
+			opts.events = ui.EventWriterFromContext(cmd.Context())
+			defer startCacheBatch(afero.NewOsFs(), helpers.NewOSCommandRunner(), cfg, log, opts.noCacheUpdate)()
+			if opts.fromDir != "" {
+				return trackStatus(cfg, log, "install", opts.fromDir, func() error {
+					_, err := reportInstall(cfg, log, opts, opts.fromDir)
+					return err
+				})
+			}
+			if opts.linkDir {
+				return fmt.Errorf("--link requires --from-dir")
+			}
+			if opts.pick {
+				packagePath, err := pickPackage(cmd.Context(), portal.OpenFile)
 				if err != nil {
-					color.Red("Error: invalid package path: %v", err)
-					return fmt.Errorf("invalid package path: %w", err)
+					return err
 				}
-				packagePath = absPath
+				args = []string{packagePath}
+			}
+			if len(args) > 1 {
+				return runBatchInstall(cmd.OutOrStdout(), cfg, log, opts, args)
 			}
+			if group, ok := strings.CutPrefix(args[0], groupPrefix); ok {
+				return runGroupInstall(cfg, log, opts, group)
+			}
+			return trackStatus(cfg, log, "install", args[0], func() error {
+				_, err := reportInstall(cfg, log, opts, args[0])
+				return err
+			})
+		},
+	}
 
-			log.Info().
-				Str("package", packagePath).
-				Bool("force", force).
-				Bool("skip_desktop", skipDesktop).
-				Msg("starting installation")
+	cmd.Flags().BoolVarP(&opts.force, "force", "f", false, "force installation even if already installed")
+	cmd.Flags().BoolVar(&opts.skipDesktop, "skip-desktop", false, "skip desktop integration")
+	cmd.Flags().StringVarP(&opts.customName, "name", "n", "", "custom application name")
+	cmd.Flags().IntVar(&opts.timeoutSecs, "timeout", 600, "installation timeout in seconds")
+	cmd.Flags().BoolVar(&opts.skipWaylandEnv, "skip-wayland-env", false, "skip Wayland environment variable injection (recommended for Tauri apps)")
+	cmd.Flags().BoolVar(&opts.skipIconFix, "skip-icon-fix", false, "skip dock icon fix (Hyprland initialClass detection)")
+	cmd.Flags().BoolVar(&opts.overwrite, "overwrite", false, "overwrite conflicting files from other packages (DEB/RPM only)")
+	cmd.Flags().BoolVar(&opts.hiDPI, "hidpi", false, "inject HiDPI scaling env for toolkits the desktop does not scale (fractional scaling setups)")
+	cmd.Flags().StringVar(&opts.sha256, "sha256", "", "expected SHA256 of the package file; downloads and local files are verified against it")
+	cmd.Flags().BoolVar(&opts.noCache, "no-cache", false, "download URLs again instead of reusing the cached copy")
+	cmd.Flags().BoolVar(&opts.noCacheUpdate, "no-cache-update", false, "skip the desktop database and icon cache updates (run 'upkg refresh-caches' later)")
+	cmd.Flags().BoolVar(&opts.exposeAllBins, "expose-all-bins", false, "symlink every executable in the payload's bin/ directory (tarball only)")
+	cmd.Flags().StringSliceVar(&opts.bins, "bin", nil, "create launchers for these executables (tarball only): all, or file names; the first name is the main launcher")
+	cmd.Flags().BoolVar(&opts.binDesktops, "bin-desktops", false, "with --bin, also create a desktop entry for every other launcher")
+	cmd.Flags().BoolVar(&opts.explainChoice, "explain-choice", false, "print the score of every executable candidate and why the main one was chosen")
+	cmd.Flags().BoolVar(&opts.desktop, "desktop", false, "create a desktop entry for a standalone binary (skipped by default)")
+	cmd.Flags().IntVarP(&opts.jobs, "jobs", "j", defaultInstallJobs, "packages to install concurrently when several are given")
+	cmd.Flags().StringVar(&opts.fromDir, "from-dir", "", "install an already unpacked application folder")
+	cmd.Flags().BoolVar(&opts.linkDir, "link", false, "with --from-dir, symlink the folder into the apps directory instead of copying it")
+	cmd.Flags().StringVar(&opts.method, "method", core.MethodAuto, "how DEB and RPM packages are installed: auto, pacman (DEB via debtap), dpkg (DEB), dnf (RPM) or extract")
+	cmd.Flags().BoolVar(&opts.pick, "pick", false, "choose the package in the desktop file chooser (xdg-desktop-portal)")
+	cmd.Flags().BoolVar(&opts.sandbox, "sandbox", false, "launch the app inside bwrap/firejail with the [sandbox] config profile")
+	cmd.Flags().StringVar(&opts.icon, "icon", "", "icon file (PNG, SVG or XPM) installed instead of the shipped icon")
+	cmd.Flags().StringArrayVar(&opts.wrapperArgs, "wrapper-arg", nil, "argument the wrapper script passes to the app (repeatable)")
+	cmd.Flags().StringArrayVar(&opts.wrapperEnv, "wrapper-env", nil, "KEY=VALUE the wrapper script exports before starting the app (repeatable)")
+	cmd.Flags().StringArrayVar(&opts.preInstall, "pre-install", nil, "shell command run before installing (repeatable)")
+	cmd.Flags().StringArrayVar(&opts.postInstall, "post-install", nil, "shell command run after installing; a failure rolls the install back (repeatable)")
+	cmd.Flags().BoolVar(&opts.autostart, "autostart", false, "start the app when you log in (~/.config/autostart entry)")
+	cmd.Flags().BoolVar(&opts.selfUpdating, "self-updating", false, "mark the app as updating itself in place (relaxes verify checks, tracks the version it reports)")
 
-			if !isFlatpakAppID {
-				if validateErr := security.ValidatePath(packagePath); validateErr != nil {
-					color.Red("Error: invalid package path: %v", validateErr)
-					return fmt.Errorf("invalid package path: %w", validateErr)
-				}
-			}
+	return cmd
+}
 
-			if customName != "" {
-				customName = security.SanitizeString(customName)
-				if validateErr := security.ValidatePackageName(customName); validateErr != nil {
-					color.Red("Error: invalid custom name: %v", validateErr)
-					return fmt.Errorf("invalid custom name: %w", validateErr)
-				}
-			}
+// runInstallCmd installs a single package file or Flatpak ref
+//
+//nolint:gocyclo // install flow includes validation and multiple optional flows.
+func runInstallCmd(cfg *config.Config, log *zerolog.Logger, opts *installOptions, packagePath string) (_ *core.InstallResult, err error) {
+	started := time.Now()
+	result, err := installPackage(cfg, log, opts, packagePath)
+	if err != nil && offerRedownload(opts, packagePath, err) {
+		retry := *opts
+		retry.noCache = true
+		result, err = installPackage(cfg, log, &retry, packagePath)
+	}
 
-			if !isFlatpakAppID {
-				if _, statErr := os.Stat(packagePath); statErr != nil {
-					color.Red("Error: package file not found: %s", packagePath)
-					return fmt.Errorf("package not found: %w", statErr)
-				}
-			}
+	entry := history.Entry{Operation: history.OpInstall}
+	if result != nil && result.Record != nil {
+		entry = historyRecordEntry(history.OpInstall, result.Record)
+	}
+	entry.Source = packagePath
+	entry.Options = installHistoryOptions(opts)
+	recordHistory(afero.NewOsFs(), paths.NewResolver(cfg).GetHistoryFile(), log, entry, started, err)
+	return result, err
+}
 
-			// Create context with timeout
-			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSecs)*time.Second)
-			defer cancel()
+// installPackage installs one package; runInstallCmd records it in the history
+func installPackage(cfg *config.Config, log *zerolog.Logger, opts *installOptions, packagePath string) (*core.InstallResult, error) {
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(opts.timeoutSecs)*time.Second)
+	defer cancel()
+	if opts.progress != nil {
+		ctx = ui.WithProgressSink(ctx, opts.progress)
+	}
 
-			// Initialize database
-			database, err := db.New(ctx, cfg.Paths.DBFile)
-			if err != nil {
-				color.Red("Error: failed to open database: %v", err)
-				return fmt.Errorf("failed to open database: %w", err)
-			}
-			defer func() { _ = database.Close() }()
+	release := holdSleepInhibitor(ctx, cfg.System.InhibitSleep, log, "Installing "+filepath.Base(packagePath))
+	defer release()
 
-			// Create backend registry
-			registry := backends.NewRegistry(cfg, log)
+	if opts.fromDir != "" && opts.sha256 != "" {
+		color.Red("Error: --sha256 cannot be used with --from-dir")
+		return nil, fmt.Errorf("--sha256 cannot be used with --from-dir")
+	}
 
-			// Detect backend
-			color.Cyan("→ Detecting package type...")
-			backend, err := registry.DetectBackend(ctx, packagePath)
-			if err != nil {
-				color.Red("Error: %v", err)
-				return fmt.Errorf("failed to detect package type: %w", err)
-			}
+	expectedSHA256 := opts.sha256
+	var ghSource *githubSource
+	if fetch.IsGitHubSpec(packagePath) {
+		resolved, err := resolveGitHubSource(ctx, cfg, log, packagePath)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return nil, err
+		}
+		ghSource = resolved
+		packagePath = resolved.asset.DownloadURL
+		if expectedSHA256 == "" {
+			expectedSHA256 = resolved.asset.SHA256()
+		}
+	}
 
-			color.Green("✓ Detected package type: %s", backend.Name())
+	var sourceURL string
+	var expectedSize int64
+	if fetch.IsURL(packagePath) {
+		sourceURL = packagePath
+		localPath, err := downloadPackage(ctx, cfg, log, sourceURL, expectedSHA256, opts.noCache)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return nil, err
+		}
+		packagePath = localPath
+		expectedSize = fetch.NewDownloader(downloadCacheDir(cfg)).ExpectedSize(sourceURL)
+		if ghSource != nil && ghSource.asset.Size > 0 {
+			expectedSize = ghSource.asset.Size
+		}
+	}
 
-			// Initialize transaction manager
-			tx := transaction.NewManager(log)
-			defer func() {
-				if rollbackErr := tx.Rollback(); rollbackErr != nil {
-					log.Warn().Err(rollbackErr).Msg("transaction rollback failed")
-					color.Red("Error: rollback failed: %v", rollbackErr)
-				}
-			}()
-
-			// Install package
-			color.Cyan("→ Installing package...")
-			installOpts := core.InstallOptions{
-				Force:          force,
-				SkipDesktop:    skipDesktop,
-				CustomName:     customName,
-				SkipWaylandEnv: skipWaylandEnv,
-				Overwrite:      overwrite,
-			}
+	isFlatpakAppID := flatpak.IsFlatpakAppID(packagePath) || flatpak.IsFlatpakRemoteRef(packagePath)
 
-			record, err := backend.Install(ctx, packagePath, installOpts, tx)
-			if err != nil {
-				color.Red("Error: installation failed: %v", err)
-				return fmt.Errorf("installation failed: %w", err)
-			}
+	if !isFlatpakAppID {
+		// filepath.Abs would turn an empty argument into the working directory
+		if strings.TrimSpace(packagePath) == "" {
+			color.Red("Error: invalid package path: empty")
+			return nil, fmt.Errorf("invalid package path: empty")
+		}
+		absPath, err := filepath.Abs(packagePath)
+		if err != nil {
+			color.Red("Error: invalid package path: %v", err)
+			return nil, fmt.Errorf("invalid package path: %w", err)
+		}
+		packagePath = absPath
+	}
 
-			// Convert to db.Install format
-			dbRecord := &db.Install{
-				InstallID:    record.InstallID,
-				PackageType:  string(record.PackageType),
-				Name:         record.Name,
-				Version:      record.Version,
-				InstallDate:  record.InstallDate,
-				OriginalFile: record.OriginalFile,
-				InstallPath:  record.InstallPath,
-				DesktopFile:  record.DesktopFile,
-				Metadata: map[string]interface{}{
-					"icon_files":      record.Metadata.IconFiles,
-					"wrapper_script":  record.Metadata.WrapperScript,
-					"wayland_support": record.Metadata.WaylandSupport,
-					"install_method":  record.Metadata.InstallMethod,
-					"desktop_files":   record.Metadata.DesktopFiles,
-				},
+	log.Info().
+		Str("package", packagePath).
+		Bool("force", opts.force).
+		Bool("skip_desktop", opts.skipDesktop).
+		Msg("starting installation")
+
+	if !isFlatpakAppID {
+		if validateErr := security.ValidatePath(packagePath); validateErr != nil {
+			color.Red("Error: invalid package path: %v", validateErr)
+			return nil, fmt.Errorf("invalid package path: %w", validateErr)
+		}
+	}
+
+	customName := opts.customName
+	if customName != "" {
+		customName = security.SanitizeString(customName)
+		if validateErr := security.ValidatePackageName(customName); validateErr != nil {
+			color.Red("Error: invalid custom name: %v", validateErr)
+			return nil, fmt.Errorf("invalid custom name: %w", validateErr)
+		}
+	}
+
+	var iconPath string
+	if opts.icon != "" {
+		var iconErr error
+		if iconPath, iconErr = validateCustomIcon(afero.NewOsFs(), opts.icon); iconErr != nil {
+			color.Red("Error: %v", iconErr)
+			return nil, iconErr
+		}
+	}
+
+	if !isFlatpakAppID {
+		info, statErr := os.Stat(packagePath)
+		if statErr != nil {
+			color.Red("Error: package file not found: %s", packagePath)
+			return nil, withExitCode(ExitNotFound, fmt.Errorf("package not found: %w", statErr))
+		}
+		if (opts.fromDir != "") != info.IsDir() {
+			if info.IsDir() {
+				color.Red("Error: %s is a directory; use --from-dir to install an unpacked application", packagePath)
+				return nil, fmt.Errorf("%s is a directory (use --from-dir)", packagePath)
 			}
+			color.Red("Error: --from-dir expects a directory: %s", packagePath)
+			return nil, fmt.Errorf("--from-dir expects a directory: %s", packagePath)
+		}
+	}
 
-			// Save to database
-			if err := database.Create(ctx, dbRecord); err != nil {
-				color.Red("Error: failed to save installation record: %v", err)
-				// Manual cleanup is handled by transaction rollback (deferred)
-				// For legacy/unsupported cleanup, we might still want to try Uninstall
-				// but ideally we trust the transaction.
-				// Since we haven't fully migrated all cleanup to transaction yet,
-				// keeping backend.Uninstall is safer for now as a fallback.
-				if cleanupErr := backend.Uninstall(ctx, record); cleanupErr != nil {
-					log.Warn().
-						Err(cleanupErr).
-						Str("install_path", record.InstallPath).
-						Msg("failed to cleanup after database save failure")
-				}
-				return fmt.Errorf("failed to save installation record: %w", err)
+	if sourceURL == "" && opts.sha256 != "" {
+		if sumErr := verifyPackageSHA256(packagePath, opts.sha256); sumErr != nil {
+			color.Red("Error: %v", sumErr)
+			return nil, sumErr
+		}
+	}
+
+	if !isFlatpakAppID {
+		// Batch installs check the target directories once up front
+		if !opts.batch {
+			if targetErr := checkTargetDirs(cfg, log); targetErr != nil {
+				color.Red("Error: %v", targetErr)
+				return nil, targetErr
+			}
+		}
+		if opts.fromDir == "" {
+			if hashErr := checkPackageHash(ctx, cfg, packagePath, log); hashErr != nil {
+				color.Red("Error: %v", hashErr)
+				return nil, hashErr
 			}
+		}
+	}
+
+	// Initialize database
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		color.Red("Error: failed to open database: %v", err)
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	// Create backend registry
+	registry := backends.NewRegistry(cfg, log)
+
+	// Detect backend; unpacked folders go through the tarball pipeline
+	var backend backends.Backend
+	if opts.fromDir != "" {
+		backend, err = registry.GetBackend(string(core.PackageTypeTarball))
+	} else {
+		color.Cyan("→ Detecting package type...")
+		backend, err = registry.DetectBackend(ctx, packagePath)
+	}
+	if err != nil {
+		color.Red("Error: %v", err)
+		reportRemediation(err)
+		return nil, fmt.Errorf("failed to detect package type: %w", err)
+	}
+
+	color.Green("✓ Detected package type: %s", backend.Name())
+
+	// Verify external tools before any mutation
+	report := registry.Preflight(ctx, backend)
+	for _, status := range report.OptionalMissing {
+		log.Info().
+			Str("tool", status.Requirement.Name).
+			Str("purpose", status.Requirement.Purpose).
+			Msg("optional tool not available")
+	}
+	if preflightErr := report.Err(backends.DetectDistroFamily(afero.NewOsFs())); preflightErr != nil {
+		color.Red("Error: %v", preflightErr)
+		return nil, withExitCode(ExitDependencyMissing, fmt.Errorf("preflight failed: %w", preflightErr))
+	}
 
-			// Commit transaction
-			tx.Commit()
-
-			// Try to fix dock icon if we have a desktop file and Hyprland is running
-			if record.DesktopFile != "" &&
-				!skipIconFix &&
-				hyprland.IsHyprlandRunning() &&
-				record.Metadata.InstallMethod != core.InstallMethodPacman {
-				if newDesktopPath, err := fixDockIcon(ctx, record, dbRecord, database, log); err != nil {
-					log.Warn().Err(err).Msg("dock icon fix failed")
-				} else if newDesktopPath != "" {
-					record.DesktopFile = newDesktopPath
+	if !opts.batch {
+		warnInterruptedOperations(cfg, log)
+	}
+	if opts.systemLock != nil && serializedBackends[backend.Name()] {
+		opts.systemLock.Lock()
+		defer opts.systemLock.Unlock()
+	}
+
+	hookRunner := hooks.NewRunner(cfg.Hooks, log)
+	hookEnv := hooks.Env{Name: customName, PackageType: backend.Name(), Package: packagePath}
+	if hookErr := hookRunner.Run(ctx, hooks.PreInstall, hookEnv, opts.preInstall...); hookErr != nil {
+		color.Red("Error: %v", hookErr)
+		return nil, hookErr
+	}
+
+	// Initialize transaction manager
+	tx := transaction.NewManager(log)
+	attachJournal(tx, cfg, log, "install", packagePath)
+	defer func() {
+		undo := tx.Pending()
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Warn().Err(rollbackErr).Msg("transaction rollback failed")
+			color.Red("Error: rollback failed: %v", rollbackErr)
+		} else if undo && err != nil {
+			err = withExitCode(ExitRolledBack, err)
+		}
+	}()
+
+	// Install package
+	color.Cyan("→ Installing package...")
+	installOpts := core.InstallOptions{
+		Force:          opts.force,
+		SkipDesktop:    opts.skipDesktop,
+		CustomName:     customName,
+		SkipWaylandEnv: opts.skipWaylandEnv,
+		Overwrite:      opts.overwrite,
+		ExposeAllBins:  opts.exposeAllBins,
+		Bins:           opts.bins,
+		BinDesktops:    opts.binDesktops,
+		HiDPI:          opts.hiDPI,
+		Desktop:        opts.desktop,
+		LinkDir:        opts.linkDir,
+		Method:         opts.method,
+		Sandbox:        opts.sandbox,
+		WrapperArgs:    opts.wrapperArgs,
+		WrapperEnv:     opts.wrapperEnv,
+	}
+	if ghSource != nil {
+		installOpts.Description = ghSource.description
+	}
+
+	result, err := backend.Install(ctx, packagePath, installOpts, tx)
+	if err != nil {
+		color.Red("Error: installation failed: %v", err)
+		var corruptErr *helpers.ArchiveCorruptionError
+		if errors.As(err, &corruptErr) {
+			if corruptErr.Path == packagePath {
+				corruptErr.ExpectedSize, corruptErr.ExpectedSHA256 = expectedSize, expectedSHA256
+			}
+			if sourceURL != "" {
+				// The next fetch would otherwise reuse the damaged copy
+				if discardErr := fetch.NewDownloader(downloadCacheDir(cfg)).Discard(sourceURL); discardErr != nil {
+					log.Warn().Err(discardErr).Str("url", sourceURL).Msg("failed to discard corrupted download")
 				}
 			}
+		}
+		reportArchiveCorruption(err)
+		reportRemediation(err)
+		return nil, fmt.Errorf("installation failed: %w", err)
+	}
+	record := result.Record
+	if opts.sandbox && record.Metadata.Sandbox == "" {
+		result.Warn("--sandbox is not supported for %s packages installed this way; the app runs unsandboxed", record.PackageType)
+	}
+	if len(opts.wrapperArgs) > 0 || len(opts.wrapperEnv) > 0 {
+		if record.Metadata.WrapperScript == "" {
+			result.Warn("--wrapper-arg and --wrapper-env need a wrapper script, which %s packages installed this way do not get", record.PackageType)
+		} else {
+			record.Metadata.WrapperArgs = opts.wrapperArgs
+			record.Metadata.WrapperEnv = opts.wrapperEnv
+		}
+	}
+
+	if sourceURL != "" {
+		record.Metadata.SourceURL = sourceURL
+	}
+	if ghSource != nil {
+		record.Metadata.SourceRepo = ghSource.spec.Repository()
+		record.Metadata.SourceTag = ghSource.release.TagName
+	}
+	if opts.group != "" {
+		record.Metadata.Groups = addGroup(record.Metadata.Groups, opts.group)
+	}
+	record.Metadata.Manifest = opts.manifest
+	if opts.selfUpdating {
+		markSelfUpdating(ctx, helpers.NewOSCommandRunner(), record, log)
+	}
 
-			// Success!
-			color.Green("✓ Package installed successfully")
-			color.Green("  Name: %s", record.Name)
-			color.Green("  Type: %s", record.PackageType)
-			color.Green("  Install ID: %s", record.InstallID)
-			if record.InstallPath != "" {
-				color.Cyan("  Path: %s", record.InstallPath)
+	if iconPath != "" {
+		if installed, iconErr := setCustomIcon(afero.NewOsFs(), cfg, record, iconPath); iconErr != nil {
+			result.Warn("custom icon not installed: %v", iconErr)
+		} else {
+			tx.Add("remove custom icon", func() error { return os.Remove(installed) })
+			tx.TrackPaths(installed)
+			refreshIconCaches(helpers.NewOSCommandRunner(), cfg, log, record)
+		}
+	}
+
+	if opts.autostart {
+		if autostartErr := enableAutostart(afero.NewOsFs(), cfg, record); autostartErr != nil {
+			result.Warn("autostart entry not created: %v", autostartErr)
+		} else {
+			installed := record.Metadata.Autostart
+			tx.Add("remove autostart entry", func() error { return os.Remove(installed) })
+			tx.TrackPaths(installed)
+		}
+	}
+
+	// Post-install hooks run before the record is saved so a failure can
+	// still roll the install back
+	hookEnv = hooks.EnvFromRecord(record)
+	hookEnv.Package = packagePath
+	if hookErr := hookRunner.Run(ctx, hooks.PostInstall, hookEnv, opts.postInstall...); hookErr != nil {
+		if hookRunner.AbortOnFailure() {
+			color.Red("Error: %v", hookErr)
+			return nil, hookErr
+		}
+		result.Warn("%v", hookErr)
+	}
+
+	recordInstalledSize(afero.NewOsFs(), record)
+	if cfg.IsSystemScope() {
+		// Per-user app data is not tracked for installs shared by all users
+		record.Metadata.Scope = config.ScopeSystem
+	} else {
+		recordDataDirs(afero.NewOsFs(), appdata.DirsFor(paths.NewResolver(cfg).HomeDir(), os.Getenv), record)
+	}
+
+	// Convert to db.Install format
+	dbRecord := db.FromInstallRecord(record)
+
+	// Save to database
+	if err := database.Create(ctx, dbRecord); err != nil {
+		color.Red("Error: failed to save installation record: %v", err)
+		// Manual cleanup is handled by transaction rollback (deferred)
+		// For legacy/unsupported cleanup, we might still want to try Uninstall
+		// but ideally we trust the transaction.
+		// Since we haven't fully migrated all cleanup to transaction yet,
+		// keeping backend.Uninstall is safer for now as a fallback.
+		if _, cleanupErr := backend.Uninstall(ctx, record); cleanupErr != nil {
+			log.Warn().
+				Err(cleanupErr).
+				Str("install_path", record.InstallPath).
+				Msg("failed to cleanup after database save failure")
+		}
+		return nil, fmt.Errorf("failed to save installation record: %w", err)
+	}
+
+	// Commit transaction
+	tx.Commit()
+
+	// Try to fix dock icon if we have a desktop file and Hyprland is running
+	if record.DesktopFile != "" &&
+		!opts.skipIconFix && !opts.batch && isInteractive() &&
+		hyprland.IsHyprlandRunning() &&
+		!core.IsSystemManaged(record.Metadata.InstallMethod) {
+		if newDesktopPath, err := fixDockIcon(ctx, record, dbRecord, database, log); err != nil {
+			log.Warn().Err(err).Msg("dock icon fix failed")
+		} else if newDesktopPath != "" {
+			record.DesktopFile = newDesktopPath
+		}
+	}
+	recordIntegrity(afero.NewOsFs(), cfg, log, record)
+
+	// Success!
+	color.Green("✓ Package installed successfully")
+	color.Green("  Name: %s", record.Name)
+	color.Green("  Type: %s", record.PackageType)
+	color.Green("  Install ID: %s", record.InstallID)
+	if record.InstallPath != "" {
+		color.Cyan("  Path: %s", record.InstallPath)
+	}
+	if record.DesktopFile != "" {
+		color.Cyan("  Desktop file: %s", record.DesktopFile)
+	}
+	if record.Metadata.Sandbox != "" {
+		color.Cyan("  Sandbox: %s", record.Metadata.Sandbox)
+	}
+	printResultNotes(result.Warnings, result.Skipped)
+	if opts.explainChoice {
+		printCandidates(os.Stdout, result.Candidates, record.InstallPath)
+	}
+
+	log.Info().
+		Str("install_id", record.InstallID).
+		Str("name", record.Name).
+		Str("type", string(record.PackageType)).
+		Strs("warnings", result.Warnings).
+		Strs("skipped", result.Skipped).
+		Dur("duration", result.Duration).
+		Msg("installation completed successfully")
+
+	return result, nil
+}
+
+// validateInstallMethod checks the value of --method
+func validateInstallMethod(method string) error {
+	switch method {
+	case "", core.MethodAuto, core.MethodPacman, core.MethodDpkg, core.MethodDnf, core.MethodExtract:
+		return nil
+	default:
+		return fmt.Errorf("invalid --method %q (want %s, %s, %s, %s or %s)", method, core.MethodAuto, core.MethodPacman, core.MethodDpkg, core.MethodDnf, core.MethodExtract)
+	}
+}
+
+// packageFilters are the file chooser filters offered by --pick
+var packageFilters = []portal.Filter{
+	{Name: "Packages", Patterns: []string{
+		"*.AppImage", "*.appimage", "*.deb", "*.rpm",
+		"*.tar.gz", "*.tgz", "*.tar.xz", "*.txz", "*.tar.bz2", "*.tbz2", "*.tar.zst", "*.tzst", "*.tar.lz4", "*.tar", "*.zip", "*.7z",
+	}},
+	{Name: "All files", Patterns: []string{"*"}},
+}
+
+// pickPackage asks the user for a package file through open, the portal
+// file chooser
+func pickPackage(ctx context.Context, open func(context.Context, portal.OpenFileOptions) ([]string, error)) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	paths, err := open(ctx, portal.OpenFileOptions{
+		Title:       "Choose a package to install",
+		AcceptLabel: "Install",
+		Filters:     packageFilters,
+	})
+	switch {
+	case errors.Is(err, portal.ErrUnavailable):
+		return "", fmt.Errorf("%w; pass the package path as an argument instead", err)
+	case err != nil:
+		return "", err
+	}
+	return paths[0], nil
+}
+
+// downloadCacheDir returns the directory downloads are cached in
+func downloadCacheDir(cfg *config.Config) string {
+	if cfg.Paths.CacheDir != "" {
+		return cfg.Paths.CacheDir
+	}
+	return filepath.Join(os.TempDir(), "upkg-downloads")
+}
+
+// downloadPackage fetches a package URL into the download cache and returns
+// the local path. A current cached copy is reused unless noCache is set;
+// afterwards the cache is pruned to cache.downloads_max_size_mb.
+func downloadPackage(ctx context.Context, cfg *config.Config, log *zerolog.Logger, rawURL, sha256 string, noCache bool) (string, error) {
+	cacheDir := downloadCacheDir(cfg)
+
+	color.Cyan("→ Downloading %s...", rawURL)
+	log.Info().Str("url", rawURL).Str("cache_dir", cacheDir).Bool("no_cache", noCache).Msg("downloading package")
+
+	progress := ui.NewProgress(ctx,
+		[]ui.InstallationPhase{{Name: "Downloading", Weight: 100, Deterministic: true}},
+		"Downloading package",
+		isInteractive(),
+	)
+	progress.StartPhase(0)
+
+	downloader := fetch.NewDownloader(cacheDir)
+	var transferred bool
+	localPath, err := downloader.Fetch(ctx, rawURL, fetch.Options{
+		SHA256:  sha256,
+		NoCache: noCache,
+		Progress: func(done, total int64) {
+			transferred = true
+			if total > 0 {
+				progress.SetProgress(int(done*1000/total), 1000)
 			}
-			if record.DesktopFile != "" {
-				color.Cyan("  Desktop file: %s", record.DesktopFile)
+		},
+	})
+	if err != nil {
+		progress.Clear()
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+	progress.Finish()
+
+	if transferred {
+		color.Green("✓ Downloaded to %s", localPath)
+	} else {
+		color.Green("✓ Using cached download %s", localPath)
+	}
+
+	evicted, err := downloader.Prune(cfg.Cache.DownloadsMaxSize(), localPath)
+	if err != nil {
+		log.Warn().Err(err).Str("cache_dir", cacheDir).Msg("failed to prune download cache")
+	}
+	for _, file := range evicted {
+		log.Debug().Str("path", file.Path).Str("url", file.URL).Msg("evicted cached download")
+	}
+	return localPath, nil
+}
+
+// verifyPackageSHA256 compares a local package file with the expected checksum
+func verifyPackageSHA256(packagePath, expected string) error {
+	sum, err := helpers.FileSHA256(packagePath)
+	if err != nil {
+		return fmt.Errorf("hash package: %w", err)
+	}
+	if !strings.EqualFold(sum, strings.TrimSpace(expected)) {
+		return fmt.Errorf("%w: expected %s, got %s", fetch.ErrChecksumMismatch, expected, sum)
+	}
+	return nil
+}
+
+// holdSleepInhibitor blocks suspend and shutdown while a long operation runs
+// and returns the function that releases the lock. Failing to take the lock
+// never blocks the operation.
+func holdSleepInhibitor(ctx context.Context, enabled bool, log *zerolog.Logger, why string) func() {
+	if !enabled {
+		return func() {}
+	}
+	lock, err := inhibit.Acquire(ctx, why)
+	if err != nil {
+		log.Debug().Err(err).Msg("sleep inhibitor not taken")
+		return func() {}
+	}
+	log.Debug().Str("why", why).Msg("holding sleep inhibitor lock")
+	return lock.Release
+}
+
+// checkTargetDirs refuses to install through bin/apps/icons directories that are
+// symlinks into a package payload, and asks before following symlinks that leave
+// the home directory.
+func checkTargetDirs(cfg *config.Config, log *zerolog.Logger) error {
+	resolver := paths.NewResolver(cfg)
+	if resolver.HomeDir() == "" {
+		return nil
+	}
+
+	allowedRoots := []string{resolver.HomeDir()}
+	if resolver.IsSystem() {
+		allowedRoots = []string{paths.SystemPrefix}
+	}
+	payloadRoots := []string{resolver.GetUpkgAppsDir()}
+
+	for _, dir := range []string{resolver.GetBinDir(), resolver.GetAppsDir(), resolver.GetIconsDir()} {
+		resolved, err := security.ValidateTargetDir(dir, allowedRoots, payloadRoots)
+		switch {
+		case err == nil:
+			continue
+		case errors.Is(err, security.ErrUnexpectedSymlinkTarget):
+			log.Warn().Str("dir", dir).Str("resolved", resolved).Msg("target directory is a symlink outside the home directory")
+			color.Yellow("⚠️  %s is a symlink to %s", dir, resolved)
+			confirmed, confirmErr := ui.ConfirmWithDefault("Install files there anyway?", false)
+			if confirmErr != nil || !confirmed {
+				return fmt.Errorf("refusing to install through symlinked directory: %w", err)
 			}
+		default:
+			return fmt.Errorf("unsafe target directory: %w", err)
+		}
+	}
+
+	return nil
+}
 
-			log.Info().
-				Str("install_id", record.InstallID).
-				Str("name", record.Name).
-				Str("type", string(record.PackageType)).
-				Msg("installation completed successfully")
+// checkPackageHash queries the configured hash-lookup service (opt-in) and
+// asks for confirmation before installing a package flagged as malicious.
+// Lookup failures are logged and never block the install.
+func checkPackageHash(ctx context.Context, cfg *config.Config, packagePath string, log *zerolog.Logger) error {
+	if !cfg.Security.HashLookup || cfg.Security.HashLookupURL == "" {
+		return nil
+	}
 
-			return nil
-		},
+	sum, err := helpers.FileSHA256(packagePath)
+	if err != nil {
+		log.Warn().Err(err).Str("package", packagePath).Msg("failed to hash package, skipping hash lookup")
+		return nil
+	}
+
+	color.Cyan("→ Checking package hash reputation...")
+	timeout := time.Duration(cfg.Security.HashLookupTimeoutSecs) * time.Second
+	result, err := security.NewHashLookupClient(cfg.Security.HashLookupURL, timeout).Lookup(ctx, sum)
+	if err != nil {
+		log.Warn().Err(err).Str("sha256", sum).Msg("hash lookup failed")
+		color.Yellow("  Hash lookup failed, continuing: %v", err)
+		return nil
 	}
 
-	cmd.Flags().BoolVarP(&force, "force", "f", false, "force installation even if already installed")
-	cmd.Flags().BoolVar(&skipDesktop, "skip-desktop", false, "skip desktop integration")
-	cmd.Flags().StringVarP(&customName, "name", "n", "", "custom application name")
-	cmd.Flags().IntVar(&timeoutSecs, "timeout", 600, "installation timeout in seconds")
-	cmd.Flags().BoolVar(&skipWaylandEnv, "skip-wayland-env", false, "skip Wayland environment variable injection (recommended for Tauri apps)")
-	cmd.Flags().BoolVar(&skipIconFix, "skip-icon-fix", false, "skip dock icon fix (Hyprland initialClass detection)")
-	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "overwrite conflicting files from other packages (DEB/RPM only)")
+	log.Info().Str("sha256", sum).Str("verdict", string(result.Verdict)).Msg("hash lookup completed")
 
-	return cmd
+	if result.Verdict != security.VerdictMalicious {
+		color.Green("✓ Hash lookup verdict: %s", result.Verdict)
+		return nil
+	}
+
+	color.Red("✗ Package is flagged as malicious (sha256 %s)", sum)
+	if result.Detail != "" {
+		color.Red("  %s", result.Detail)
+	}
+
+	confirmed, err := ui.ConfirmWithDefault("Install anyway?", false)
+	if err != nil || !confirmed {
+		return fmt.Errorf("installation aborted: package flagged as malicious by hash lookup")
+	}
+
+	log.Warn().Str("sha256", sum).Msg("user installed package flagged as malicious")
+	return nil
+}
+
+// reportArchiveCorruption explains a corrupted-archive failure with the data needed to compare against the source
+func reportArchiveCorruption(err error) {
+	var corruptErr *helpers.ArchiveCorruptionError
+	if !errors.As(err, &corruptErr) {
+		return
+	}
+
+	color.Yellow("\n→ The archive appears to be %s", corruptErr.Kind)
+	color.White("  File:   %s", corruptErr.Path)
+	if corruptErr.ExpectedSize > 0 {
+		color.White("  Size:   %d bytes (expected %d)", corruptErr.ActualSize, corruptErr.ExpectedSize)
+	} else {
+		color.White("  Size:   %d bytes", corruptErr.ActualSize)
+	}
+	if corruptErr.ActualSHA256 != "" {
+		color.White("  SHA256: %s", corruptErr.ActualSHA256)
+	}
+	if corruptErr.ExpectedSHA256 != "" {
+		color.White("  Expected SHA256: %s", corruptErr.ExpectedSHA256)
+	}
+	if corruptErr.Kind == helpers.CorruptionTruncated {
+		color.White("  The download was likely interrupted. Download the file again and retry.")
+	} else {
+		color.White("  Compare the checksum with the one published by the vendor and download the file again.")
+	}
+}
+
+// offerRedownload asks whether to download a corrupted package from a URL
+// or GitHub source again; installPackage already dropped the cached copy
+func offerRedownload(opts *installOptions, source string, err error) bool {
+	if !errors.Is(err, helpers.ErrCorruptArchive) {
+		return false
+	}
+	if !fetch.IsURL(source) && !fetch.IsGitHubSpec(source) {
+		return false
+	}
+	if opts.batch || opts.events != nil || !isInteractive() {
+		color.White("  The cached download was removed; run the install again to download it from scratch.")
+		return false
+	}
+	confirmed, promptErr := ui.ConfirmWithDefault("Download the package again and retry?", true)
+	return promptErr == nil && confirmed
+}
+
+// reportRemediation prints a highlighted "How to fix" block for recognized failures
+func reportRemediation(err error) {
+	hint, ok := remediation.For(err, backends.DetectDistroFamily(afero.NewOsFs()))
+	if !ok {
+		return
+	}
+
+	fmt.Println()
+	ui.Highlight.Println("How to fix:")
+	color.White("  %s", hint.Problem)
+	for i, step := range hint.Steps {
+		color.White("  %d. %s", i+1, step)
+	}
 }
 
 // fixDockIcon prompts user to open app, captures initialClass, and renames .desktop file for dock compatibility.
@@ -3,7 +3,11 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,6 +16,8 @@ import (
 	"github.com/quantmind-br/upkg/internal/config"
 	"github.com/quantmind-br/upkg/internal/core"
 	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/portal"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -128,6 +134,59 @@ func TestInstallCmd_Flags(t *testing.T) {
 	assert.NotNil(t, cmd.Flags().Lookup("skip-wayland-env"))
 	assert.NotNil(t, cmd.Flags().Lookup("skip-icon-fix"))
 	assert.NotNil(t, cmd.Flags().Lookup("overwrite"))
+	assert.NotNil(t, cmd.Flags().Lookup("method"))
+}
+
+func TestInstallCmd_InvalidMethod(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{}
+	log := zerolog.New(io.Discard)
+	cmd := NewInstallCmd(cfg, &log)
+	cmd.SetArgs([]string{"--method", "rpm", "app.deb"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+
+	err := cmd.Execute()
+	assert.ErrorContains(t, err, `invalid --method "rpm"`)
+}
+
+func TestPickPackage(t *testing.T) {
+	t.Parallel()
+
+	var got portal.OpenFileOptions
+	path, err := pickPackage(context.Background(), func(_ context.Context, opts portal.OpenFileOptions) ([]string, error) {
+		got = opts
+		return []string{"/home/me/app.deb"}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "/home/me/app.deb", path)
+	assert.Equal(t, packageFilters, got.Filters)
+	assert.False(t, got.Multiple)
+
+	_, err = pickPackage(context.Background(), func(context.Context, portal.OpenFileOptions) ([]string, error) {
+		return nil, portal.ErrUnavailable
+	})
+	assert.ErrorIs(t, err, portal.ErrUnavailable)
+	assert.ErrorContains(t, err, "pass the package path as an argument")
+
+	_, err = pickPackage(context.Background(), func(context.Context, portal.OpenFileOptions) ([]string, error) {
+		return nil, portal.ErrCancelled
+	})
+	assert.ErrorIs(t, err, portal.ErrCancelled)
+}
+
+func TestInstallCmd_PickRejectsArgs(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{}
+	log := zerolog.New(io.Discard)
+	cmd := NewInstallCmd(cfg, &log)
+	cmd.SetArgs([]string{"--pick", "app.deb"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+
+	assert.Error(t, cmd.Execute())
 }
 
 func TestInstallCmd_Timeout(t *testing.T) {
@@ -355,10 +414,11 @@ func TestInstallCmd_MissingArgs(t *testing.T) {
 	assert.Error(t, err)
 }
 
-func TestInstallCmd_ExtraArgs(t *testing.T) {
-	t.Parallel()
+func TestInstallCmd_MultipleArgsInstallsBatch(t *testing.T) {
+	// Not parallel: batch installs redirect the global color output
 
-	cfg := &config.Config{}
+	tmpDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: filepath.Join(tmpDir, "test.db"), DataDir: tmpDir}}
 	log := zerolog.New(io.Discard)
 	cmd := NewInstallCmd(cfg, &log)
 
@@ -366,13 +426,12 @@ func TestInstallCmd_ExtraArgs(t *testing.T) {
 	cmd.SetOut(&buf)
 	cmd.SetErr(&buf)
 
-	tmpDir := t.TempDir()
-	testFile := filepath.Join(tmpDir, "test.tar.gz")
-	require.NoError(t, os.WriteFile(testFile, []byte("fake"), 0644))
-
-	cmd.SetArgs([]string{testFile, "extra"})
+	cmd.SetArgs([]string{filepath.Join(tmpDir, "missing.tar.gz"), filepath.Join(tmpDir, "missing.AppImage")})
 	err := cmd.Execute()
-	assert.Error(t, err)
+	assert.ErrorContains(t, err, "2 of 2 packages failed to install")
+	assert.Contains(t, buf.String(), "missing.tar.gz")
+	assert.Contains(t, buf.String(), "missing.AppImage")
+	assert.Contains(t, buf.String(), "package not found")
 }
 
 func TestFixDockIcon_SkipNoDesktop(t *testing.T) {
@@ -556,3 +615,152 @@ func TestInstallCmd_WithRelativePath(t *testing.T) {
 	cmd.SetArgs([]string{"./test.tar.gz"})
 	_ = cmd.Execute()
 }
+
+func TestCheckPackageHash(t *testing.T) {
+	log := zerolog.New(io.Discard)
+	pkg := filepath.Join(t.TempDir(), "app.AppImage")
+	require.NoError(t, os.WriteFile(pkg, []byte("payload"), 0755))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/clean/") {
+			_, _ = w.Write([]byte(`{"verdict":"clean"}`))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	t.Run("disabled by default", func(t *testing.T) {
+		cfg := &config.Config{Security: config.SecurityConfig{HashLookupURL: server.URL + "/fail"}}
+		assert.NoError(t, checkPackageHash(context.Background(), cfg, pkg, &log))
+	})
+
+	t.Run("clean verdict", func(t *testing.T) {
+		cfg := &config.Config{Security: config.SecurityConfig{HashLookup: true, HashLookupURL: server.URL + "/clean", HashLookupTimeoutSecs: 5}}
+		assert.NoError(t, checkPackageHash(context.Background(), cfg, pkg, &log))
+	})
+
+	t.Run("lookup failure does not block", func(t *testing.T) {
+		cfg := &config.Config{Security: config.SecurityConfig{HashLookup: true, HashLookupURL: server.URL + "/fail", HashLookupTimeoutSecs: 5}}
+		assert.NoError(t, checkPackageHash(context.Background(), cfg, pkg, &log))
+	})
+}
+
+func TestInstallCmd_URLDownloadFailure(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	cfg := &config.Config{Paths: config.PathsConfig{CacheDir: t.TempDir()}}
+	log := zerolog.New(io.Discard)
+	cmd := NewInstallCmd(cfg, &log)
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+
+	cmd.SetArgs([]string{server.URL + "/app.AppImage"})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "download failed")
+}
+
+func TestInstallCmd_LocalSHA256Mismatch(t *testing.T) {
+	t.Parallel()
+
+	pkg := filepath.Join(t.TempDir(), "app.AppImage")
+	require.NoError(t, os.WriteFile(pkg, []byte("payload"), 0755))
+
+	cfg := &config.Config{}
+	log := zerolog.New(io.Discard)
+	cmd := NewInstallCmd(cfg, &log)
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+
+	cmd.SetArgs([]string{pkg, "--sha256", strings.Repeat("0", 64)})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch")
+}
+
+func TestInstallCmd_FromDirValidation(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	pkgFile := filepath.Join(tmpDir, "app.tar.gz")
+	require.NoError(t, os.WriteFile(pkgFile, []byte("data"), 0644))
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"link without from-dir", []string{"--link", pkgFile}, "--link requires --from-dir"},
+		{"directory without from-dir", []string{tmpDir}, "is a directory (use --from-dir)"},
+		{"from-dir with a file", []string{"--from-dir", pkgFile}, "--from-dir expects a directory"},
+		{"from-dir with positional args", []string{"--from-dir", tmpDir, pkgFile}, "unknown command"},
+		{"from-dir with sha256", []string{"--from-dir", tmpDir, "--sha256", strings.Repeat("a", 64)}, "--sha256 cannot be used with --from-dir"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dataDir := t.TempDir()
+			cfg := &config.Config{Paths: config.PathsConfig{DBFile: filepath.Join(dataDir, "test.db"), DataDir: dataDir}}
+			log := zerolog.New(io.Discard)
+			cmd := NewInstallCmd(cfg, &log)
+
+			var buf bytes.Buffer
+			cmd.SetOut(&buf)
+			cmd.SetErr(&buf)
+
+			cmd.SetArgs(tt.args)
+			err := cmd.Execute()
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestInstallCmd_PreInstallHookAborts(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	appDir := filepath.Join(tmpDir, "app")
+	require.NoError(t, os.MkdirAll(appDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(appDir, "app"), []byte("#!/bin/sh\n"), 0755))
+
+	dataDir := filepath.Join(tmpDir, "data")
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: filepath.Join(dataDir, "test.db"), DataDir: dataDir}}
+	log := zerolog.New(io.Discard)
+	cmd := NewInstallCmd(cfg, &log)
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+
+	marker := filepath.Join(tmpDir, "hook")
+	cmd.SetArgs([]string{"--from-dir", appDir, "--pre-install", `echo "$UPKG_HOOK $UPKG_PACKAGE_TYPE" > ` + marker + `; exit 1`})
+	err := cmd.Execute()
+	assert.ErrorContains(t, err, "pre-install hook")
+
+	content, readErr := os.ReadFile(marker)
+	require.NoError(t, readErr)
+	assert.Equal(t, "pre-install tarball\n", string(content))
+
+	database, err := db.New(context.Background(), cfg.Paths.DBFile)
+	require.NoError(t, err)
+	defer func() { _ = database.Close() }()
+	installs, err := database.List(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, installs)
+}
+
+func TestOfferRedownload(t *testing.T) {
+	t.Parallel()
+
+	corrupt := fmt.Errorf("installation failed: %w", &helpers.ArchiveCorruptionError{Path: "/cache/app.tar.gz", Kind: helpers.CorruptionTruncated, Err: io.ErrUnexpectedEOF})
+	opts := &installOptions{}
+
+	assert.False(t, offerRedownload(opts, "https://example.com/app.tar.gz", errors.New("installation failed")), "only corrupt archives are downloaded again")
+	assert.False(t, offerRedownload(opts, "/home/u/app.tar.gz", corrupt), "local files are not downloaded")
+	assert.False(t, offerRedownload(opts, "gh:owner/repo", corrupt), "without a terminal the user runs the install again")
+	assert.False(t, offerRedownload(&installOptions{batch: true}, "https://example.com/app.tar.gz", corrupt))
+}
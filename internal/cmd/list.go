@@ -104,14 +104,30 @@ func NewListCmd(cfg *config.Config, _ *zerolog.Logger) *cobra.Command {
 			// Apply filters
 			filtered := filterInstalls(installs, filterType, filterName)
 
+			// On-disk sizes (flatpak apps report none)
+			sizes := packageSizes(filtered)
+
 			// Apply sorting
-			sortInstalls(filtered, sortBy)
+			if strings.EqualFold(sortBy, "size") {
+				sortInstallsBySize(filtered, sizes)
+			} else {
+				sortInstalls(filtered, sortBy)
+			}
 
 			// JSON output
-			if jsonOutput {
+			events := ui.EventWriterFromContext(cmd.Context())
+			if jsonOutput || events != nil {
+				entries := make([]listEntry, 0, len(filtered))
+				for _, install := range filtered {
+					entries = append(entries, listEntry{Install: install, Size: sizes[install.InstallPath]})
+				}
+				if events != nil {
+					events.Result("", entries)
+					return nil
+				}
 				enc := json.NewEncoder(cmd.OutOrStdout())
 				enc.SetIndent("", "  ")
-				return enc.Encode(filtered)
+				return enc.Encode(entries)
 			}
 
 			// Check if empty
@@ -129,11 +145,11 @@ func NewListCmd(cfg *config.Config, _ *zerolog.Logger) *cobra.Command {
 
 			// Table output
 			if showDetails {
-				if err := printDetailedTable(cmd, filtered); err != nil {
+				if err := printDetailedTable(cmd, filtered, sizes); err != nil {
 					return err
 				}
 			} else {
-				if err := printCompactTable(cmd, filtered); err != nil {
+				if err := printCompactTable(cmd, filtered, sizes); err != nil {
 					return err
 				}
 			}
@@ -145,12 +161,60 @@ func NewListCmd(cfg *config.Config, _ *zerolog.Logger) *cobra.Command {
 	cmd.Flags().BoolVar(&jsonOutput, "json", false, "output in JSON format")
 	cmd.Flags().StringVar(&filterType, "type", "", "filter by package type (appimage, binary, tarball, deb, rpm)")
 	cmd.Flags().StringVar(&filterName, "name", "", "filter by package name (partial match)")
-	cmd.Flags().StringVar(&sortBy, "sort", "name", "sort by: name, type, date, version")
+	cmd.Flags().StringVar(&sortBy, "sort", "name", "sort by: name, type, date, version, size")
 	cmd.Flags().BoolVarP(&showDetails, "details", "d", false, "show detailed information")
 
 	return cmd
 }
 
+// listEntry is the JSON form of a listed package
+type listEntry struct {
+	db.Install
+	Size int64 // Bytes on disk under InstallPath (0 when unknown)
+}
+
+// packageSizes returns the on-disk size of each install, keyed by install path
+func packageSizes(installs []db.Install) map[string]int64 {
+	sizes := make(map[string]int64, len(installs))
+	for _, install := range installs {
+		if install.InstallPath == "" {
+			continue
+		}
+		if _, ok := sizes[install.InstallPath]; !ok {
+			sizes[install.InstallPath], _ = calculatePackageSize(install.InstallPath)
+		}
+	}
+	return sizes
+}
+
+// sortInstallsBySize sorts installs largest first, then by name
+func sortInstallsBySize(installs []db.Install, sizes map[string]int64) {
+	sort.SliceStable(installs, func(i, j int) bool {
+		si, sj := sizes[installs[i].InstallPath], sizes[installs[j].InstallPath]
+		if si == sj {
+			return strings.ToLower(installs[i].Name) < strings.ToLower(installs[j].Name)
+		}
+		return si > sj
+	})
+}
+
+// listName renders a package name for table output, marking pinned packages
+func listName(install db.Install) string {
+	if pinned, _ := install.Metadata["pinned"].(bool); pinned {
+		return install.Name + " 📌"
+	}
+	return install.Name
+}
+
+// formatListSize renders a package size for table output
+func formatListSize(install db.Install, sizes map[string]int64) string {
+	size, ok := sizes[install.InstallPath]
+	if !ok || install.PackageType == "flatpak" {
+		return "-"
+	}
+	return formatBytes(size)
+}
+
 // filterInstalls filters installs by type and name
 func filterInstalls(installs []db.Install, filterType, filterName string) []db.Install {
 	filtered := make([]db.Install, 0)
@@ -259,10 +323,10 @@ func printSummary(all, filtered []db.Install, filterType, filterName string) {
 }
 
 // printCompactTable prints a compact table view
-func printCompactTable(cmd *cobra.Command, installs []db.Install) error {
+func printCompactTable(cmd *cobra.Command, installs []db.Install, sizes map[string]int64) error {
 	table := tablewriter.NewTable(cmd.OutOrStdout(),
-		tablewriter.WithHeader([]string{"Name", "Type", "Version", "Install Date"}),
-		tablewriter.WithAlignment(tw.MakeAlign(4, tw.AlignLeft)),
+		tablewriter.WithHeader([]string{"Name", "Type", "Version", "Size", "Install Date"}),
+		tablewriter.WithAlignment(tw.MakeAlign(5, tw.AlignLeft)),
 		tablewriter.WithSymbols(tw.NewSymbols(tw.StyleNone)),
 	)
 
@@ -278,9 +342,10 @@ func printCompactTable(cmd *cobra.Command, installs []db.Install) error {
 		}
 
 		if err := table.Append(
-			install.Name,
+			listName(install),
 			ui.ColorizePackageType(install.PackageType),
 			version,
+			formatListSize(install, sizes),
 			installDate,
 		); err != nil {
 			return fmt.Errorf("append table row: %w", err)
@@ -294,10 +359,10 @@ func printCompactTable(cmd *cobra.Command, installs []db.Install) error {
 }
 
 // printDetailedTable prints a detailed table view
-func printDetailedTable(cmd *cobra.Command, installs []db.Install) error {
+func printDetailedTable(cmd *cobra.Command, installs []db.Install, sizes map[string]int64) error {
 	table := tablewriter.NewTable(cmd.OutOrStdout(),
-		tablewriter.WithHeader([]string{"Name", "Type", "Version", "Install Date", "Install ID", "Path"}),
-		tablewriter.WithAlignment(tw.MakeAlign(6, tw.AlignLeft)),
+		tablewriter.WithHeader([]string{"Name", "Type", "Version", "Size", "Install Date", "Install ID", "Path"}),
+		tablewriter.WithAlignment(tw.MakeAlign(7, tw.AlignLeft)),
 		tablewriter.WithSymbols(tw.NewSymbols(tw.StyleLight)),
 	)
 
@@ -327,9 +392,10 @@ func printDetailedTable(cmd *cobra.Command, installs []db.Install) error {
 		}
 
 		if err := table.Append(
-			install.Name,
+			listName(install),
 			ui.ColorizePackageType(install.PackageType),
 			version,
+			formatListSize(install, sizes),
 			installDate,
 			installID,
 			path,
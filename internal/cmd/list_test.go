@@ -3,7 +3,9 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -548,7 +550,7 @@ func TestPrintCompactTable_EmptyVersion(t *testing.T) {
 	fakeCmd := &cobra.Command{}
 	fakeCmd.SetOut(&buf)
 
-	err := printCompactTable(fakeCmd, installs)
+	err := printCompactTable(fakeCmd, installs, packageSizes(installs))
 	assert.NoError(t, err)
 	// Output should contain "-" for empty version
 	_ = buf.String()
@@ -584,7 +586,7 @@ func TestPrintDetailedTable_Truncations(t *testing.T) {
 	fakeCmd := &cobra.Command{}
 	fakeCmd.SetOut(&buf)
 
-	err := printDetailedTable(fakeCmd, installs)
+	err := printDetailedTable(fakeCmd, installs, packageSizes(installs))
 	assert.NoError(t, err)
 	_ = buf.String()
 }
@@ -661,3 +663,50 @@ func TestListCmd_MultiplePackagesWithEmptyVersions(t *testing.T) {
 	err = cmd.Execute()
 	assert.NoError(t, err)
 }
+
+func TestListCmd_SizeColumnAndJSON(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: dbPath}}
+
+	bigDir := filepath.Join(tmpDir, "big")
+	require.NoError(t, os.MkdirAll(bigDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(bigDir, "data"), make([]byte, 4096), 0644))
+	smallFile := filepath.Join(tmpDir, "small.AppImage")
+	require.NoError(t, os.WriteFile(smallFile, make([]byte, 10), 0755))
+
+	ctx := context.Background()
+	database, err := db.New(ctx, dbPath)
+	require.NoError(t, err)
+	for _, install := range []*db.Install{
+		{InstallID: "a", PackageType: "appimage", Name: "Aaa", InstallDate: time.Now(), InstallPath: smallFile, Metadata: map[string]interface{}{}},
+		{InstallID: "b", PackageType: "tarball", Name: "Bbb", InstallDate: time.Now(), InstallPath: bigDir, Metadata: map[string]interface{}{}},
+	} {
+		require.NoError(t, database.Create(ctx, install))
+	}
+	require.NoError(t, database.Close())
+
+	log := zerolog.New(io.Discard)
+	cmd := NewListCmd(cfg, &log)
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetArgs([]string{"--json", "--sort", "size"})
+	require.NoError(t, cmd.Execute())
+
+	var entries []struct {
+		Name string
+		Size int64
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entries))
+	require.Len(t, entries, 2)
+	assert.Equal(t, "Bbb", entries[0].Name)
+	assert.Equal(t, int64(4096), entries[0].Size)
+	assert.Equal(t, int64(10), entries[1].Size)
+
+	buf.Reset()
+	require.NoError(t, printCompactTable(cmd, []db.Install{{Name: "Bbb", PackageType: "tarball", InstallPath: bigDir}, {Name: "fp", PackageType: "flatpak"}}, map[string]int64{bigDir: 4096}))
+	assert.Contains(t, buf.String(), "4.0 KB")
+	assert.Contains(t, buf.String(), "SIZE")
+}
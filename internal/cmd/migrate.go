@@ -0,0 +1,342 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/relocate"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// migrateDataOptions holds the flags of the migrate-data command
+type migrateDataOptions struct {
+	to         string
+	yes        bool
+	dryRun     bool
+	configPath string // Config file to update; defaults to config.FilePath()
+}
+
+// NewMigrateDataCmd creates the migrate-data command
+func NewMigrateDataCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &migrateDataOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "migrate-data --to <path>",
+		Short: "Move the upkg data directory to a new location",
+		Long: `Move the upkg data directory (installed apps and, when stored there, the
+database and log) to a new location such as a bigger disk.
+
+Wrapper scripts, desktop entries, exposed binaries and install records that
+point into the old directory are rewritten, the config file is updated and
+the result is validated. Any failure before completion restores the
+previous state.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runMigrateDataCmd(afero.NewOsFs(), cfg, log, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.to, "to", "", "new data directory (must not exist or be empty)")
+	cmd.Flags().BoolVarP(&opts.yes, "yes", "y", false, "skip the confirmation prompt")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "show what would be moved and rewritten")
+	_ = cmd.MarkFlagRequired("to")
+
+	return cmd
+}
+
+//nolint:gocyclo // sequential migration steps, each with its own rollback.
+func runMigrateDataCmd(fs afero.Fs, cfg *config.Config, log *zerolog.Logger, opts *migrateDataOptions) error {
+	from := filepath.Clean(cfg.Paths.DataDir)
+	to, err := filepath.Abs(opts.to)
+	if err != nil {
+		return fmt.Errorf("resolve target directory: %w", err)
+	}
+	if err := validateMigrationTarget(fs, from, to); err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
+	}
+	installs, err := database.List(ctx)
+	// The database may live inside the directory being moved
+	_ = database.Close()
+	if err != nil {
+		ui.PrintError("failed to list packages: %v", err)
+		return fmt.Errorf("list installs: %w", err)
+	}
+
+	newPaths := cfg.Paths
+	newPaths.DataDir = to
+	newPaths.DBFile, _ = relocate.Rebase(cfg.Paths.DBFile, from, to)
+	newPaths.LogFile, _ = relocate.Rebase(cfg.Paths.LogFile, from, to)
+
+	records := make([]*core.InstallRecord, 0, len(installs))
+	var integrationFiles int
+	for i := range installs {
+		record := db.ToInstallRecord(&installs[i])
+		records = append(records, record)
+		integrationFiles += len(relocate.IntegrationFiles(record))
+	}
+
+	ui.PrintKeyValue("From", from)
+	ui.PrintKeyValue("To", to)
+	ui.PrintKeyValue("Database", newPaths.DBFile)
+	ui.PrintKeyValue("Packages", fmt.Sprintf("%d (%d integration files to check)", len(records), integrationFiles))
+
+	if opts.dryRun {
+		ui.PrintInfo("[DRY-RUN] No changes were made.")
+		return nil
+	}
+	if !opts.yes {
+		if !isInteractive() {
+			return fmt.Errorf("non-interactive mode requires --yes flag")
+		}
+		confirmed, promptErr := ui.ConfirmPrompt(fmt.Sprintf("Move %s to %s?", from, to))
+		if promptErr != nil || !confirmed {
+			ui.PrintWarning("Migration cancelled.")
+			return nil
+		}
+	}
+
+	release := holdSleepInhibitor(ctx, cfg.System.InhibitSleep, log, "Moving the upkg data directory")
+	defer release()
+
+	tx := transaction.NewManager(log)
+	fail := func(err error) error {
+		ui.PrintError("%v", err)
+		undo := tx.Pending()
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			ui.PrintError("rollback failed: %v", rollbackErr)
+			return fmt.Errorf("%w (rollback failed: %v)", err, rollbackErr)
+		}
+		ui.PrintWarning("Restored the previous data directory.")
+		if !undo {
+			return err
+		}
+		return withExitCode(ExitRolledBack, err)
+	}
+
+	// Move the data directory
+	if err := prepareMigrationTarget(fs, to); err != nil {
+		return fail(err)
+	}
+	tx.Add("move data directory back", func() error {
+		return helpers.MoveDir(fs, to, from, nil)
+	})
+	ui.PrintInfo("Moving %s...", from)
+	if err := helpers.MoveDir(fs, from, to, nil); err != nil {
+		return fail(fmt.Errorf("move data directory: %w", err))
+	}
+
+	// Rewrite wrappers, desktop entries and exposed binaries
+	var rewritten int
+	for _, record := range records {
+		for _, file := range relocate.IntegrationFiles(record) {
+			restore, rewriteErr := relocate.RewriteFile(fs, file, from, to)
+			if rewriteErr != nil {
+				return fail(rewriteErr)
+			}
+			if restore != nil {
+				tx.Add("restore "+file, restore)
+				rewritten++
+			}
+		}
+	}
+
+	// Update install records in the (possibly moved) database
+	if err := updateMigratedRecords(ctx, tx, installs, records, newPaths.DBFile, from, to); err != nil {
+		return fail(err)
+	}
+	retained, err := updateMigratedVersions(ctx, fs, tx, newPaths.DBFile, from, to)
+	if err != nil {
+		return fail(err)
+	}
+	rewritten += retained
+
+	// Point the config at the new location
+	if err := saveMigratedConfig(fs, tx, cfg, newPaths, opts.configPath); err != nil {
+		return fail(err)
+	}
+	cfg.Paths = newPaths
+
+	log.Info().Str("from", from).Str("to", to).Int("rewritten", rewritten).Msg("data directory migrated")
+	ui.PrintSuccess("Moved data directory to %s (%d files rewritten)", to, rewritten)
+
+	problems := relocate.Validate(fs, records, from, to)
+	if len(problems) == 0 {
+		ui.PrintSuccess("Validated %d packages", len(records))
+		return nil
+	}
+	ui.PrintWarning("%d problems found after migration:", len(problems))
+	for _, problem := range problems {
+		fmt.Printf("   • %s: %s (%s)\n", problem.Name, problem.Path, problem.Reason)
+	}
+	return fmt.Errorf("migration completed with %d problems", len(problems))
+}
+
+// validateMigrationTarget rejects targets that overlap the data directory or
+// already hold files
+func validateMigrationTarget(fs afero.Fs, from, to string) error {
+	if _, inside := relocate.Rebase(to, from, from); inside {
+		return fmt.Errorf("target %s is inside the current data directory", to)
+	}
+	if _, inside := relocate.Rebase(from, to, to); inside {
+		return fmt.Errorf("current data directory %s is inside the target", from)
+	}
+	if _, err := fs.Stat(from); err != nil {
+		return fmt.Errorf("data directory %s not found: %w", from, err)
+	}
+	if _, err := fs.Stat(to); err == nil {
+		empty, emptyErr := afero.IsEmpty(fs, to)
+		if emptyErr != nil {
+			return fmt.Errorf("check target directory: %w", emptyErr)
+		}
+		if !empty {
+			return fmt.Errorf("target %s already exists and is not empty", to)
+		}
+	}
+	return nil
+}
+
+// prepareMigrationTarget creates the parent of to and removes an empty to so
+// the data directory can be renamed onto it
+func prepareMigrationTarget(fs afero.Fs, to string) error {
+	if err := fs.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return fmt.Errorf("create target parent directory: %w", err)
+	}
+	if err := fs.Remove(to); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("prepare target directory: %w", err)
+	}
+	return nil
+}
+
+// updateMigratedRecords rebases the stored install records
+func updateMigratedRecords(ctx context.Context, tx *transaction.Manager, installs []db.Install, records []*core.InstallRecord, dbFile, from, to string) error {
+	database, err := db.New(ctx, dbFile)
+	if err != nil {
+		return fmt.Errorf("open migrated database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	for i, record := range records {
+		if !relocate.Record(record, from, to) {
+			continue
+		}
+		dbRecord := db.FromInstallRecord(record)
+		dbRecord.Metadata = mergeMetadata(installs[i].Metadata, dbRecord.Metadata)
+
+		original := installs[i]
+		tx.Add("restore record "+record.Name, func() error {
+			restoreDB, openErr := db.New(context.Background(), dbFile)
+			if openErr != nil {
+				return openErr
+			}
+			defer func() { _ = restoreDB.Close() }()
+			return restoreDB.Update(context.Background(), &original)
+		})
+		if err := database.Update(ctx, dbRecord); err != nil {
+			return fmt.Errorf("update record %s: %w", record.Name, err)
+		}
+	}
+	return nil
+}
+
+// updateMigratedVersions rebases the versions retained for rollback, which
+// moved with the data directory, and rewrites their retained integration
+// files. It returns the number of files rewritten.
+func updateMigratedVersions(ctx context.Context, fs afero.Fs, tx *transaction.Manager, dbFile, from, to string) (int, error) {
+	database, err := db.New(ctx, dbFile)
+	if err != nil {
+		return 0, fmt.Errorf("open migrated database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	versions, err := database.ListVersions(ctx, "")
+	if err != nil {
+		return 0, fmt.Errorf("list retained versions: %w", err)
+	}
+
+	var rewritten int
+	for i := range versions {
+		original := versions[i]
+		version := versions[i]
+		version.Dir, _ = relocate.Rebase(version.Dir, from, to)
+		version.Payload, _ = relocate.Rebase(version.Payload, from, to)
+		version.Files = make(map[string]string, len(original.Files))
+		for path, copyPath := range original.Files {
+			path, _ = relocate.Rebase(path, from, to)
+			copyPath, _ = relocate.Rebase(copyPath, from, to)
+			version.Files[path] = copyPath
+
+			restore, rewriteErr := relocate.RewriteFile(fs, copyPath, from, to)
+			if rewriteErr != nil {
+				return rewritten, rewriteErr
+			}
+			if restore != nil {
+				tx.Add("restore "+copyPath, restore)
+				rewritten++
+			}
+		}
+		record := db.ToInstallRecord(&original.Record)
+		relocate.Record(record, from, to)
+		rebased := db.FromInstallRecord(record)
+		rebased.Metadata = mergeMetadata(original.Record.Metadata, rebased.Metadata)
+		version.Record = *rebased
+
+		tx.Add(fmt.Sprintf("restore retained version %s %s", original.Name, original.Version), func() error {
+			restoreDB, openErr := db.New(context.Background(), dbFile)
+			if openErr != nil {
+				return openErr
+			}
+			defer func() { _ = restoreDB.Close() }()
+			return restoreDB.UpdateVersion(context.Background(), &original)
+		})
+		if err := database.UpdateVersion(ctx, &version); err != nil {
+			return rewritten, fmt.Errorf("update retained version of %s: %w", version.Name, err)
+		}
+	}
+	return rewritten, nil
+}
+
+// saveMigratedConfig writes the new paths to the config file
+func saveMigratedConfig(fs afero.Fs, tx *transaction.Manager, cfg *config.Config, newPaths config.PathsConfig, configPath string) error {
+	if configPath == "" {
+		var err error
+		if configPath, err = config.FilePath(); err != nil {
+			return err
+		}
+	}
+
+	previous, readErr := afero.ReadFile(fs, configPath)
+	tx.Add("restore config file", func() error {
+		if readErr != nil {
+			return fs.Remove(configPath)
+		}
+		return afero.WriteFile(fs, configPath, previous, 0644)
+	})
+
+	newCfg := *cfg
+	newCfg.Paths = newPaths
+	if err := config.Save(&newCfg, configPath); err != nil {
+		return fmt.Errorf("update config: %w", err)
+	}
+	return nil
+}
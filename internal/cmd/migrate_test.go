@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// migrationFixture installs one tarball-like package into a temporary data directory
+func migrationFixture(t *testing.T) (*config.Config, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	dataDir := filepath.Join(root, "data")
+	binDir := filepath.Join(root, "bin")
+	appDir := filepath.Join(dataDir, "apps", "tool")
+	require.NoError(t, os.MkdirAll(filepath.Join(appDir, "bin"), 0755))
+	require.NoError(t, os.MkdirAll(binDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(appDir, "bin", "tool"), []byte("#!/bin/sh\n"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(appDir, "bin", "helper"), []byte("#!/bin/sh\n"), 0755))
+
+	wrapper := filepath.Join(binDir, "tool")
+	require.NoError(t, os.WriteFile(wrapper, []byte("#!/bin/bash\nexec \""+appDir+"/bin/tool\" \"$@\"\n"), 0755))
+	desktopFile := filepath.Join(root, "tool.desktop")
+	require.NoError(t, os.WriteFile(desktopFile, []byte("[Desktop Entry]\nExec="+wrapper+"\nIcon="+appDir+"/icon.png\n"), 0644))
+	exposed := filepath.Join(binDir, "helper")
+	require.NoError(t, os.Symlink(filepath.Join(appDir, "bin", "helper"), exposed))
+
+	cfg := &config.Config{Paths: config.PathsConfig{
+		DataDir: dataDir,
+		DBFile:  filepath.Join(dataDir, "installed.db"),
+		LogFile: filepath.Join(root, "upkg.log"),
+	}}
+
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	require.NoError(t, database.Create(ctx, &db.Install{
+		InstallID:   "tool-1",
+		PackageType: "tarball",
+		Name:        "tool",
+		InstallDate: time.Now(),
+		InstallPath: appDir,
+		DesktopFile: desktopFile,
+		Metadata: map[string]interface{}{
+			"wrapper_script": wrapper,
+			"exposed_bins":   []string{exposed},
+			"custom_key":     "kept",
+		},
+	}))
+
+	// A previous version retained for rollback
+	versionDir := filepath.Join(dataDir, "versions", "tool", "tool-0")
+	require.NoError(t, os.MkdirAll(filepath.Join(versionDir, "payload", "tool"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(versionDir, "files"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(versionDir, "files", "0-tool"), []byte("#!/bin/bash\nexec \""+appDir+"/bin/tool\" \"$@\"\n"), 0755))
+	require.NoError(t, database.AddVersion(ctx, &db.Version{
+		Name:       "tool",
+		Version:    "0.9",
+		RetainedAt: time.Now(),
+		Dir:        versionDir,
+		Payload:    filepath.Join(versionDir, "payload", "tool"),
+		Files:      map[string]string{wrapper: filepath.Join(versionDir, "files", "0-tool")},
+		Record: db.Install{
+			InstallID:   "tool-0",
+			PackageType: "tarball",
+			Name:        "tool",
+			InstallPath: appDir,
+			Metadata:    map[string]interface{}{"wrapper_script": wrapper},
+		},
+	}))
+	require.NoError(t, database.Close())
+
+	return cfg, root
+}
+
+func TestRunMigrateDataCmd(t *testing.T) {
+	t.Parallel()
+
+	cfg, root := migrationFixture(t)
+	oldDataDir := cfg.Paths.DataDir
+	target := filepath.Join(root, "bigdisk", "upkg")
+	configPath := filepath.Join(root, "config.toml")
+
+	log := zerolog.New(io.Discard)
+	opts := &migrateDataOptions{to: target, yes: true, configPath: configPath}
+	require.NoError(t, runMigrateDataCmd(afero.NewOsFs(), cfg, &log, opts))
+
+	newAppDir := filepath.Join(target, "apps", "tool")
+	assert.NoDirExists(t, oldDataDir)
+	assert.FileExists(t, filepath.Join(newAppDir, "bin", "tool"))
+	assert.Equal(t, target, cfg.Paths.DataDir)
+	assert.Equal(t, filepath.Join(target, "installed.db"), cfg.Paths.DBFile)
+	assert.Equal(t, filepath.Join(root, "upkg.log"), cfg.Paths.LogFile, "paths outside the data dir are kept")
+
+	wrapper, err := os.ReadFile(filepath.Join(root, "bin", "tool"))
+	require.NoError(t, err)
+	assert.Contains(t, string(wrapper), newAppDir+"/bin/tool")
+
+	desktopEntry, err := os.ReadFile(filepath.Join(root, "tool.desktop"))
+	require.NoError(t, err)
+	assert.Contains(t, string(desktopEntry), "Icon="+newAppDir+"/icon.png")
+
+	link, err := os.Readlink(filepath.Join(root, "bin", "helper"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(newAppDir, "bin", "helper"), link)
+
+	database, err := db.New(context.Background(), cfg.Paths.DBFile)
+	require.NoError(t, err)
+	defer func() { _ = database.Close() }()
+	install, err := database.Get(context.Background(), "tool-1")
+	require.NoError(t, err)
+	assert.Equal(t, newAppDir, install.InstallPath)
+	assert.Equal(t, "kept", install.Metadata["custom_key"])
+
+	versions, err := database.ListVersions(context.Background(), "tool")
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	versionDir := filepath.Join(target, "versions", "tool", "tool-0")
+	assert.Equal(t, filepath.Join(versionDir, "payload", "tool"), versions[0].Payload)
+	assert.Equal(t, newAppDir, versions[0].Record.InstallPath)
+	retainedWrapper, err := os.ReadFile(versions[0].Files[filepath.Join(root, "bin", "tool")])
+	require.NoError(t, err)
+	assert.Contains(t, string(retainedWrapper), newAppDir+"/bin/tool")
+
+	savedConfig, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(savedConfig), target)
+}
+
+func TestRunMigrateDataCmd_DryRun(t *testing.T) {
+	t.Parallel()
+
+	cfg, root := migrationFixture(t)
+	log := zerolog.New(io.Discard)
+	opts := &migrateDataOptions{to: filepath.Join(root, "new"), dryRun: true, configPath: filepath.Join(root, "config.toml")}
+	require.NoError(t, runMigrateDataCmd(afero.NewOsFs(), cfg, &log, opts))
+
+	assert.DirExists(t, cfg.Paths.DataDir)
+	assert.NoDirExists(t, filepath.Join(root, "new"))
+	assert.NoFileExists(t, opts.configPath)
+}
+
+func TestValidateMigrationTarget(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, fs.MkdirAll("/data/apps", 0755))
+	require.NoError(t, fs.MkdirAll("/empty", 0755))
+	require.NoError(t, afero.WriteFile(fs, "/full/file", []byte("x"), 0644))
+
+	assert.NoError(t, validateMigrationTarget(fs, "/data", "/new"))
+	assert.NoError(t, validateMigrationTarget(fs, "/data", "/empty"))
+	assert.ErrorContains(t, validateMigrationTarget(fs, "/data", "/full"), "not empty")
+	assert.ErrorContains(t, validateMigrationTarget(fs, "/data", "/data/sub"), "inside the current data directory")
+	assert.ErrorContains(t, validateMigrationTarget(fs, "/data", "/"), "inside the target")
+	assert.ErrorContains(t, validateMigrationTarget(fs, "/missing", "/new"), "not found")
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// outputFlag is the global flag choosing between human text and JSON events
+const outputFlag = "output"
+
+// addOutputFlag registers --output on the root command
+func addOutputFlag(root *cobra.Command) {
+	root.PersistentFlags().String(outputFlag, ui.OutputText,
+		"output format: text, or json for one JSON event per line on stdout (progress, phases, messages, results)")
+}
+
+// isJSONOutput validates an --output value and reports whether it selects
+// JSON events
+func isJSONOutput(format string) (bool, error) {
+	switch format {
+	case ui.OutputText:
+		return false, nil
+	case ui.OutputJSON:
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid output format %q (expected %s or %s)", format, ui.OutputText, ui.OutputJSON)
+	}
+}
+
+// setupOutput switches the command to JSON output when --output json is
+// given: events go to stdout, and the text printed for humans to stderr
+func setupOutput(cmd *cobra.Command) error {
+	format, err := cmd.Flags().GetString(outputFlag)
+	if err != nil {
+		// Commands built outside the root command have no --output flag
+		return nil
+	}
+	jsonOutput, err := isJSONOutput(format)
+	if err != nil || !jsonOutput {
+		return err
+	}
+
+	events := ui.NewEventWriter(ui.RedirectStdout(), cmd.Name())
+	ui.SetMessageEvents(events)
+	cmd.SetContext(ui.WithEventWriter(cmd.Context(), events))
+	return nil
+}
+
+// reportInstall runs runInstallCmd and, in JSON output mode, streams the
+// progress of pkg and writes its outcome as events
+func reportInstall(cfg *config.Config, log *zerolog.Logger, opts *installOptions, pkg string) (*core.InstallResult, error) {
+	if opts.events == nil {
+		return runInstallCmd(cfg, log, opts, pkg)
+	}
+
+	label := batchLabel(pkg)
+	memberOpts := *opts
+	memberOpts.progress = opts.events.ProgressSink(label)
+	result, err := runInstallCmd(cfg, log, &memberOpts, pkg)
+	if err != nil {
+		opts.events.Error(label, err)
+	} else {
+		opts.events.Result(label, result)
+	}
+	return result, err
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsJSONOutput(t *testing.T) {
+	t.Parallel()
+
+	jsonOutput, err := isJSONOutput(ui.OutputText)
+	require.NoError(t, err)
+	assert.False(t, jsonOutput)
+
+	jsonOutput, err = isJSONOutput(ui.OutputJSON)
+	require.NoError(t, err)
+	assert.True(t, jsonOutput)
+
+	_, err = isJSONOutput("yaml")
+	assert.ErrorContains(t, err, "invalid output format")
+}
+
+func TestNewRootCmd_InvalidOutput(t *testing.T) {
+	t.Parallel()
+	logger := zerolog.New(io.Discard)
+
+	cmd := NewRootCmd(&config.Config{}, &logger, "1.0.0")
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs([]string{"--output", "yaml", "info", "app"})
+
+	assert.ErrorContains(t, cmd.Execute(), `invalid output format "yaml"`)
+}
+
+func TestRunInfoCmd_OutputEvents(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.New(io.Discard)
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: filepath.Join(t.TempDir(), "test.db")}}
+
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	require.NoError(t, database.Create(ctx, &db.Install{
+		InstallID:   "app-id",
+		PackageType: "appimage",
+		Name:        "App",
+		InstallDate: time.Now(),
+	}))
+	require.NoError(t, database.Close())
+
+	var events bytes.Buffer
+	var out bytes.Buffer
+	opts := &infoOptions{events: ui.NewEventWriter(&events, "info")}
+	require.NoError(t, runInfoCmd(&out, cfg, &logger, opts, "app"))
+	assert.Empty(t, out.String(), "no text is printed in JSON output mode")
+
+	var event struct {
+		Type    string                 `json:"type"`
+		Command string                 `json:"command"`
+		Package string                 `json:"package"`
+		Data    map[string]interface{} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(events.Bytes(), &event))
+	assert.Equal(t, "result", event.Type)
+	assert.Equal(t, "info", event.Command)
+	assert.Equal(t, "App", event.Package)
+	assert.Equal(t, "app-id", event.Data["install_id"])
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// NewPinCmd creates the pin command
+func NewPinCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	return &cobra.Command{
+		Use:   "pin <name|install-id...>",
+		Short: "Protect packages from bulk operations",
+		Long: `Pin packages so bulk operations leave them alone: uninstall --all and
+uninstall @group skip them, apply neither upgrades nor prunes them and gc
+keeps files named after them. Naming a pinned package explicitly still
+works. List marks pinned packages with 📌.`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeInstalledPackages(cfg, 0),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runPinCmd(cfg, log, args, true)
+		},
+	}
+}
+
+// NewUnpinCmd creates the unpin command
+func NewUnpinCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	return &cobra.Command{
+		Use:               "unpin <name|install-id...>",
+		Short:             "Let bulk operations act on pinned packages again",
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeInstalledPackages(cfg, 0),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runPinCmd(cfg, log, args, false)
+		},
+	}
+}
+
+// runPinCmd sets the pinned flag of each identified package
+func runPinCmd(cfg *config.Config, log *zerolog.Logger, identifiers []string, pinned bool) error {
+	ctx := context.Background()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	state := "pinned"
+	if !pinned {
+		state = "unpinned"
+	}
+
+	var failed int
+	for _, identifier := range identifiers {
+		record, lookupErr := lookupPackage(ctx, database, log, identifier)
+		if lookupErr != nil {
+			failed++
+			continue
+		}
+		stored, getErr := database.Get(ctx, record.InstallID)
+		if getErr != nil {
+			// Flatpak apps found through flatpak itself have no record
+			ui.PrintError("%s is not tracked by upkg and cannot be %s", record.Name, state)
+			failed++
+			continue
+		}
+		if record.Metadata.Pinned == pinned {
+			ui.PrintInfo("%s is already %s", record.Name, state)
+			continue
+		}
+
+		record.Metadata.Pinned = pinned
+		dbRecord := db.FromInstallRecord(record)
+		dbRecord.Metadata = mergeMetadata(stored.Metadata, dbRecord.Metadata)
+		if err := database.Update(ctx, dbRecord); err != nil {
+			ui.PrintError("failed to update %s: %v", record.Name, err)
+			failed++
+			continue
+		}
+		log.Info().Str("install_id", record.InstallID).Str("name", record.Name).Bool("pinned", pinned).Msg("pin state changed")
+		ui.PrintSuccess("%s %s", record.Name, state)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to update %d of %d packages", failed, len(identifiers))
+	}
+	return nil
+}
+
+// skipPinned drops the pinned packages from the records of a bulk operation,
+// reporting each one
+func skipPinned(records []*core.InstallRecord) []*core.InstallRecord {
+	kept := make([]*core.InstallRecord, 0, len(records))
+	for _, record := range records {
+		if record.Metadata.Pinned {
+			color.Yellow("  Skipping %s (pinned)", record.Name)
+			continue
+		}
+		kept = append(kept, record)
+	}
+	return kept
+}
+
+// skipPinnedOrphans drops the orphans named after a pinned package, which
+// may be files its record lost track of, and returns how many were kept
+func skipPinnedOrphans(orphans []orphan, installs []db.Install) ([]orphan, int) {
+	var slugs []string
+	for i := range installs {
+		if pinned, _ := installs[i].Metadata["pinned"].(bool); pinned {
+			slugs = append(slugs, helpers.NormalizeFilename(installs[i].Name))
+		}
+	}
+	if len(slugs) == 0 {
+		return orphans, 0
+	}
+
+	remaining := make([]orphan, 0, len(orphans))
+	for _, o := range orphans {
+		if !namedAfterAny(filepath.Base(o.path), slugs) {
+			remaining = append(remaining, o)
+		}
+	}
+	return remaining, len(orphans) - len(remaining)
+}
+
+// namedAfterAny reports whether a file name starts with one of slugs the way
+// upkg names package files (tool, tool.png, tool-cli.desktop)
+func namedAfterAny(base string, slugs []string) bool {
+	for _, slug := range slugs {
+		if _, ok := renameBase(base, slug, slug); ok {
+			return true
+		}
+	}
+	return false
+}
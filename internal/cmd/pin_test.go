@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunPinCmd(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: filepath.Join(tmpDir, "test.db")}}
+	log := zerolog.New(io.Discard)
+
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	require.NoError(t, database.Create(ctx, &db.Install{
+		InstallID: "editor-id", PackageType: "appimage", Name: "editor", InstallDate: time.Now(),
+		Metadata: map[string]interface{}{"wrapper_script": "/bin/editor"},
+	}))
+	require.NoError(t, database.Close())
+
+	pinned := func() bool {
+		database, err := db.New(ctx, cfg.Paths.DBFile)
+		require.NoError(t, err)
+		defer func() { _ = database.Close() }()
+		stored, err := database.Get(ctx, "editor-id")
+		require.NoError(t, err)
+		assert.Equal(t, "/bin/editor", stored.Metadata["wrapper_script"])
+		return db.ToInstallRecord(stored).Metadata.Pinned
+	}
+
+	require.NoError(t, runPinCmd(cfg, &log, []string{"editor"}, true))
+	assert.True(t, pinned())
+	require.NoError(t, runPinCmd(cfg, &log, []string{"editor-id"}, true))
+	assert.True(t, pinned())
+
+	require.NoError(t, runPinCmd(cfg, &log, []string{"editor"}, false))
+	assert.False(t, pinned())
+
+	assert.Error(t, runPinCmd(cfg, &log, []string{"missing"}, true))
+}
+
+func TestSkipPinned(t *testing.T) {
+	records := []*core.InstallRecord{
+		{Name: "editor", Metadata: core.Metadata{Pinned: true}},
+		{Name: "player"},
+	}
+	kept := skipPinned(records)
+	require.Len(t, kept, 1)
+	assert.Equal(t, "player", kept[0].Name)
+}
+
+func TestSkipPinnedOrphans(t *testing.T) {
+	installs := []db.Install{
+		{Name: "Vendor App", Metadata: map[string]interface{}{"pinned": true}},
+		{Name: "player", Metadata: map[string]interface{}{}},
+	}
+	orphans := []orphan{
+		{path: "/data/apps/vendor-app", kind: orphanPayload},
+		{path: "/share/icons/hicolor/64x64/apps/vendor-app.png", kind: orphanIcon},
+		{path: "/share/applications/vendor-app-cli.desktop", kind: orphanDesktop},
+		{path: "/data/apps/vendor-application", kind: orphanPayload},
+		{path: "/data/apps/player", kind: orphanPayload},
+	}
+
+	remaining, kept := skipPinnedOrphans(orphans, installs)
+	assert.Equal(t, 3, kept)
+	assert.Equal(t, []orphan{orphans[3], orphans[4]}, remaining)
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/quantmind-br/upkg/internal/appdata"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+)
+
+// dataDir is an app directory removed by uninstall --purge
+type dataDir struct {
+	Path string
+	Size int64
+}
+
+// recordDataDirs stores the app directories already present in the home in
+// record, so a later purge finds them even if the app is renamed
+func recordDataDirs(fs afero.Fs, dirs appdata.Dirs, record *core.InstallRecord) {
+	record.Metadata.DataDirs = appdata.Find(fs, dirs, record)
+}
+
+// findDataDirs returns the directories uninstall --purge removes for each of
+// records, keyed by install ID, and lists them
+func findDataDirs(fs afero.Fs, dirs appdata.Dirs, records []*core.InstallRecord) map[string][]dataDir {
+	found := make(map[string][]dataDir, len(records))
+	var total int64
+	for _, record := range records {
+		for _, path := range appdata.Find(fs, dirs, record) {
+			size, _ := core.PathSize(fs, path)
+			found[record.InstallID] = append(found[record.InstallID], dataDir{Path: path, Size: size})
+			total += size
+		}
+	}
+
+	if len(found) == 0 {
+		color.Cyan("🧹 No config, cache or data directories found to purge\n")
+		return found
+	}
+	color.Cyan("🧹 App data to purge (%s):", formatBytes(total))
+	for _, record := range records {
+		for _, dir := range found[record.InstallID] {
+			fmt.Printf("   • %s (%s) - %s\n", dir.Path, record.Name, formatBytes(dir.Size))
+		}
+	}
+	fmt.Println()
+	return found
+}
+
+// removeDataDirs deletes dirs and returns the bytes freed; directories that
+// cannot be removed are reported and skipped
+func removeDataDirs(fs afero.Fs, log *zerolog.Logger, dirs []dataDir) int64 {
+	var freed int64
+	removed := 0
+	for _, dir := range dirs {
+		if err := fs.RemoveAll(dir.Path); err != nil {
+			log.Warn().Err(err).Str("path", dir.Path).Msg("failed to purge app data")
+			color.Yellow("Warning: failed to remove %s: %v", dir.Path, err)
+			continue
+		}
+		freed += dir.Size
+		removed++
+	}
+	if removed > 0 {
+		color.Green("✓ Purged %d app data directories (%s)", removed, formatBytes(freed))
+	}
+	return freed
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"io"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/appdata"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataDirs(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	fs := afero.NewMemMapFs()
+	dirs := appdata.DirsFor("/home/me", func(string) string { return "" })
+	require.NoError(t, afero.WriteFile(fs, "/home/me/.config/editor/settings.json", make([]byte, 100), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/home/me/.cache/editor/blob", make([]byte, 50), 0644))
+
+	record := &core.InstallRecord{InstallID: "editor-1", Name: "editor", PackageType: core.PackageTypeAppImage}
+	recordDataDirs(fs, dirs, record)
+	assert.Equal(t, []string{"/home/me/.cache/editor", "/home/me/.config/editor"}, record.Metadata.DataDirs)
+
+	other := &core.InstallRecord{InstallID: "player-1", Name: "player", PackageType: core.PackageTypeAppImage}
+	found := findDataDirs(fs, dirs, []*core.InstallRecord{record, other})
+	require.Len(t, found["editor-1"], 2)
+	assert.Empty(t, found["player-1"])
+	assert.Equal(t, dataDir{Path: "/home/me/.config/editor", Size: 100}, found["editor-1"][1])
+
+	assert.Equal(t, int64(150), removeDataDirs(fs, &logger, found["editor-1"]))
+	purged, err := afero.DirExists(fs, "/home/me/.config/editor")
+	require.NoError(t, err)
+	assert.False(t, purged)
+	kept, err := afero.DirExists(fs, "/home/me/.config")
+	require.NoError(t, err)
+	assert.True(t, kept)
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/fatih/color"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// recoverOptions holds the flags of the recover command
+type recoverOptions struct {
+	yes    bool
+	dryRun bool
+}
+
+// NewRecoverCmd creates the recover command
+func NewRecoverCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &recoverOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "recover",
+		Short: "Clean up after an interrupted install or upgrade",
+		Long: `Undo installs and upgrades that were interrupted (crash, kill, power loss)
+before they completed.
+
+Every install and upgrade keeps a journal of the files it creates in the
+data directory. Journals left behind by upkg processes that are no longer
+running are replayed in reverse: new files, desktop entries and icons are
+removed and files set aside by an upgrade are restored.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runRecoverCmd(cmd.OutOrStdout(), afero.NewOsFs(), cfg, log, opts)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.yes, "yes", "y", false, "skip the confirmation prompt")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "show what would be undone")
+
+	return cmd
+}
+
+func runRecoverCmd(out io.Writer, fs afero.Fs, cfg *config.Config, log *zerolog.Logger, opts *recoverOptions) error {
+	entries, err := transaction.Pending(fs, paths.NewResolver(cfg).GetJournalDir())
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	if len(entries) == 0 {
+		ui.PrintSuccess("No interrupted operations found")
+		return nil
+	}
+
+	for _, entry := range entries {
+		_, _ = fmt.Fprintf(out, "%s %s (started %s, pid %d)\n",
+			color.CyanString(entry.Operation), entry.Target, entry.StartedAt.Format("2006-01-02 15:04:05"), entry.PID)
+		for _, step := range entry.Steps {
+			switch step.Action {
+			case transaction.ActionRemove:
+				_, _ = fmt.Fprintf(out, "   • remove %s\n", step.Path)
+			default:
+				_, _ = fmt.Fprintf(out, "   • restore %s\n", step.Path)
+			}
+		}
+	}
+
+	if opts.dryRun {
+		ui.PrintInfo("[DRY-RUN] No changes were made.")
+		return nil
+	}
+	if !opts.yes {
+		if !isInteractive() {
+			return fmt.Errorf("non-interactive mode requires --yes flag")
+		}
+		confirmed, promptErr := ui.ConfirmPrompt(fmt.Sprintf("Undo %d interrupted operations?", len(entries)))
+		if promptErr != nil || !confirmed {
+			ui.PrintWarning("Recovery cancelled.")
+			return nil
+		}
+	}
+
+	var failed int
+	for _, entry := range entries {
+		if recoverErr := transaction.Recover(fs, entry); recoverErr != nil {
+			failed++
+			log.Warn().Err(recoverErr).Str("journal", entry.File).Msg("recovery failed")
+			ui.PrintError("%s %s: %v", entry.Operation, entry.Target, recoverErr)
+			continue
+		}
+		log.Info().Str("operation", entry.Operation).Str("target", entry.Target).Msg("interrupted operation undone")
+		ui.PrintSuccess("Undid %s %s", entry.Operation, entry.Target)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to recover %d of %d operations", failed, len(entries))
+	}
+	return nil
+}
+
+// attachJournal persists the steps of tx so 'upkg recover' can undo them if
+// upkg dies mid-operation. Without a journal the in-process rollback still works.
+func attachJournal(tx *transaction.Manager, cfg *config.Config, log *zerolog.Logger, operation, target string) {
+	journal, err := transaction.OpenJournal(afero.NewOsFs(), paths.NewResolver(cfg).GetJournalDir(), operation, target)
+	if err != nil {
+		log.Warn().Err(err).Msg("transaction journal unavailable")
+		return
+	}
+	tx.SetJournal(journal)
+}
+
+// warnInterruptedOperations points at 'upkg recover' when earlier runs left
+// journals behind
+func warnInterruptedOperations(cfg *config.Config, log *zerolog.Logger) {
+	entries, err := transaction.Pending(afero.NewOsFs(), paths.NewResolver(cfg).GetJournalDir())
+	if err != nil {
+		log.Debug().Err(err).Msg("failed to check transaction journals")
+		return
+	}
+	if len(entries) > 0 {
+		ui.PrintWarning("%d interrupted operations left files behind; run 'upkg recover' to clean up", len(entries))
+	}
+}
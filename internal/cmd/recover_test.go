@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunRecoverCmd(t *testing.T) {
+	dataDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{DataDir: dataDir}}
+	log := zerolog.Nop()
+	fs := afero.NewOsFs()
+
+	leftover := filepath.Join(dataDir, "apps", "tool")
+	require.NoError(t, os.MkdirAll(leftover, 0755))
+
+	// Journal of an install whose process is gone
+	journalDir := filepath.Join(dataDir, "journal")
+	journal, err := transaction.OpenJournal(fs, journalDir, "install", "/tmp/tool.tar.gz")
+	require.NoError(t, err)
+	data, err := json.Marshal(transaction.Entry{
+		Operation: "install",
+		Target:    "/tmp/tool.tar.gz",
+		Steps:     []transaction.Step{{Action: transaction.ActionRemove, Path: leftover}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(journal.Path(), data, 0644))
+
+	var out bytes.Buffer
+	require.NoError(t, runRecoverCmd(&out, fs, cfg, &log, &recoverOptions{dryRun: true}))
+	assert.Contains(t, out.String(), "install /tmp/tool.tar.gz")
+	assert.Contains(t, out.String(), "remove "+leftover)
+	assert.DirExists(t, leftover)
+
+	require.NoError(t, runRecoverCmd(&out, fs, cfg, &log, &recoverOptions{yes: true}))
+	assert.NoDirExists(t, leftover)
+	assert.NoFileExists(t, journal.Path())
+
+	// Nothing left to do
+	require.NoError(t, runRecoverCmd(&out, fs, cfg, &log, &recoverOptions{yes: true}))
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"github.com/quantmind-br/upkg/internal/cache"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// NewRefreshCachesCmd creates the refresh-caches command
+func NewRefreshCachesCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	return &cobra.Command{
+		Use:   "refresh-caches",
+		Short: "Update the desktop database and icon caches",
+		Long: `Run update-desktop-database and gtk-update-icon-cache on the directories
+installs and uninstalls skipped with --no-cache-update. Without skipped
+updates, the user applications directory and icon theme are refreshed.`,
+		Args: cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return runRefreshCachesCmd(afero.NewOsFs(), helpers.NewOSCommandRunner(), paths.NewResolver(cfg), log)
+		},
+	}
+}
+
+func runRefreshCachesCmd(fs afero.Fs, runner helpers.CommandRunner, resolver *paths.Resolver, log *zerolog.Logger) error {
+	pendingFile := resolver.GetPendingCachesFile()
+	pending, err := cache.LoadPending(fs, pendingFile)
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	if pending.Empty() {
+		pending = cache.Pending{DesktopDirs: []string{resolver.GetAppsDir()}, IconDirs: []string{resolver.GetIconsDir()}}
+	}
+
+	cache.NewCacheManagerWithRunner(runner).Run(pending, log)
+	if err := cache.ClearPending(fs, pendingFile); err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	ui.PrintSuccess("Refreshed %d desktop databases and %d icon caches", len(pending.DesktopDirs), len(pending.IconDirs))
+	return nil
+}
+
+// startCacheBatch defers the desktop database and icon cache updates of a
+// command on several packages. The returned func runs each of them once,
+// or saves them for 'upkg refresh-caches' when skip is set.
+func startCacheBatch(fs afero.Fs, runner helpers.CommandRunner, cfg *config.Config, log *zerolog.Logger, skip bool) func() {
+	batch := cache.BeginBatch()
+	return func() {
+		pending := batch.End()
+		if pending.Empty() {
+			return
+		}
+		if !skip {
+			cache.NewCacheManagerWithRunner(runner).Run(pending, log)
+			return
+		}
+		if err := cache.SavePending(fs, paths.NewResolver(cfg).GetPendingCachesFile(), pending); err != nil {
+			log.Warn().Err(err).Msg("failed to save skipped cache updates")
+		}
+		ui.PrintInfo("Skipped %d cache updates; run 'upkg refresh-caches' to apply them",
+			len(pending.DesktopDirs)+len(pending.IconDirs))
+	}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/cache"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshCaches(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	cfg := &config.Config{Paths: config.PathsConfig{DataDir: "/data"}}
+	resolver := paths.NewResolverWithHome(cfg, "/home/user")
+	fs := afero.NewMemMapFs()
+
+	var calls []string
+	runner := &helpers.MockCommandRunner{
+		CommandExistsFunc: func(name string) bool { return name == "update-desktop-database" },
+		RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+			calls = append(calls, name+" "+filepath.Join(args...))
+			return "", nil
+		},
+	}
+	cacheManager := cache.NewCacheManagerWithRunner(runner)
+
+	// --no-cache-update saves the updates of the whole operation
+	finish := startCacheBatch(fs, runner, cfg, &logger, true)
+	require.NoError(t, cacheManager.UpdateDesktopDatabase("/home/user/a", &logger))
+	require.NoError(t, cacheManager.UpdateDesktopDatabase("/home/user/a", &logger))
+	finish()
+	assert.Empty(t, calls)
+
+	require.NoError(t, runRefreshCachesCmd(fs, runner, resolver, &logger))
+	assert.Equal(t, []string{"update-desktop-database /home/user/a"}, calls)
+	exists, err := afero.Exists(fs, resolver.GetPendingCachesFile())
+	require.NoError(t, err)
+	assert.False(t, exists, "the applied updates are cleared")
+
+	// Without skipped updates the user directories are refreshed
+	calls = nil
+	require.NoError(t, runRefreshCachesCmd(fs, runner, resolver, &logger))
+	assert.Equal(t, []string{"update-desktop-database " + resolver.GetAppsDir()}, calls)
+
+	// A batch runs each update once when it ends
+	calls = nil
+	finish = startCacheBatch(fs, runner, cfg, &logger, false)
+	require.NoError(t, cacheManager.UpdateDesktopDatabase("/home/user/b", &logger))
+	require.NoError(t, cacheManager.UpdateDesktopDatabase("/home/user/b", &logger))
+	assert.Empty(t, calls)
+	finish()
+	assert.Equal(t, []string{"update-desktop-database /home/user/b"}, calls)
+}
@@ -0,0 +1,334 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/desktop"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/relocate"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// renameOptions holds the flags of the rename command
+type renameOptions struct {
+	dryRun bool
+}
+
+// pathMove is a file or directory renamed with its package
+type pathMove struct {
+	from string
+	to   string
+}
+
+// NewRenameCmd creates the rename command
+func NewRenameCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &renameOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "rename <name|install-id> <new-name>",
+		Short: "Rename an installed package",
+		Long: `Rename a tracked package without reinstalling it.
+
+The install directory, wrapper script, desktop entries, icons and private
+sandbox home named after the package are renamed, the files pointing at them
+are rewritten, the desktop entry gets the new name and the install record is
+updated. Any failure restores the previous state. Versions retained for
+rollback are discarded.
+
+Packages installed through pacman, dpkg, dnf or Flatpak cannot be renamed.`,
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeInstalledPackages(cfg, 1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runRenameCmd(afero.NewOsFs(), helpers.NewOSCommandRunner(), cfg, log, opts, args[0], args[1])
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "show what would be renamed without changing anything")
+
+	return cmd
+}
+
+//nolint:gocyclo // sequential rename steps, each with its own rollback.
+func runRenameCmd(fs afero.Fs, runner helpers.CommandRunner, cfg *config.Config, log *zerolog.Logger, opts *renameOptions, identifier, newName string) error {
+	newName = strings.TrimSpace(newName)
+	if helpers.NormalizeFilename(newName) == "" {
+		ui.PrintError("invalid package name %q", newName)
+		return fmt.Errorf("invalid package name %q", newName)
+	}
+
+	ctx := context.Background()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	record, err := lookupPackage(ctx, database, log, identifier)
+	if err != nil {
+		return err
+	}
+	switch {
+	case record.PackageType == core.PackageTypeFlatpak:
+		ui.PrintError("%s is a Flatpak app and cannot be renamed", record.Name)
+		return fmt.Errorf("%s is managed by flatpak", record.Name)
+	case core.IsSystemManaged(record.Metadata.InstallMethod):
+		ui.PrintError("%s was installed with %s and cannot be renamed", record.Name, record.Metadata.InstallMethod)
+		return fmt.Errorf("%s is system-managed", record.Name)
+	case record.Name == newName:
+		ui.PrintInfo("%s already has that name", record.Name)
+		return nil
+	}
+
+	installs, err := database.List(ctx)
+	if err != nil {
+		ui.PrintError("failed to list packages: %v", err)
+		return fmt.Errorf("list installs: %w", err)
+	}
+	for _, install := range installs {
+		if install.InstallID != record.InstallID && helpers.NormalizeFilename(install.Name) == helpers.NormalizeFilename(newName) {
+			ui.PrintError("a package named %s is already installed", install.Name)
+			return fmt.Errorf("%s is already installed", install.Name)
+		}
+	}
+
+	renamed, moves := planRename(record, newName)
+	if record.Metadata.Sandbox != "" {
+		sandboxDir := paths.NewResolver(cfg).GetSandboxDir()
+		home := filepath.Join(sandboxDir, helpers.NormalizeFilename(record.Name))
+		newHome := filepath.Join(sandboxDir, helpers.NormalizeFilename(newName))
+		if _, statErr := fs.Stat(home); statErr == nil && newHome != home {
+			moves = append(moves, pathMove{from: home, to: newHome})
+		}
+	}
+	for _, move := range moves {
+		if _, statErr := lstatUpgrade(fs, move.to); statErr == nil {
+			ui.PrintError("%s already exists", move.to)
+			return fmt.Errorf("rename target %s already exists", move.to)
+		}
+	}
+
+	versions, err := database.ListVersions(ctx, record.Name)
+	if err != nil {
+		log.Warn().Err(err).Str("name", record.Name).Msg("failed to list retained versions")
+	}
+
+	ui.PrintKeyValue("Package", fmt.Sprintf("%s → %s", record.Name, newName))
+	for _, move := range moves {
+		fmt.Printf("   • %s → %s\n", move.from, move.to)
+	}
+	if len(versions) > 0 {
+		ui.PrintWarning("%d versions retained for rollback will be discarded", len(versions))
+	}
+	if record.Metadata.Manifest {
+		ui.PrintWarning("%s is managed by upkg apply; rename it in the manifest too", record.Name)
+	}
+	if opts.dryRun {
+		ui.PrintInfo("[DRY-RUN] No changes were made.")
+		return nil
+	}
+
+	tx := transaction.NewManager(log)
+	fail := func(err error) error {
+		ui.PrintError("%v", err)
+		undo := tx.Pending()
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			ui.PrintError("rollback failed: %v", rollbackErr)
+			return fmt.Errorf("%w (rollback failed: %v)", err, rollbackErr)
+		}
+		ui.PrintWarning("Restored %s.", record.Name)
+		if !undo {
+			return err
+		}
+		return withExitCode(ExitRolledBack, err)
+	}
+
+	if err := movePackageFiles(fs, tx, moves); err != nil {
+		return fail(err)
+	}
+	if err := rewriteRenamedFiles(fs, tx, record, renamed, moves); err != nil {
+		return fail(err)
+	}
+	if previous := record.Metadata.Autostart; previous != "" {
+		original, readErr := afero.ReadFile(fs, previous)
+		if warning := carryAutostart(fs, cfg, record, renamed); warning != "" {
+			ui.PrintWarning("%s", warning)
+		} else if readErr == nil {
+			written := renamed.Metadata.Autostart
+			tx.Add("restore "+previous, func() error {
+				if written != previous {
+					_ = fs.Remove(written)
+				}
+				return afero.WriteFile(fs, previous, original, 0644)
+			})
+		}
+	}
+
+	dbRecord := db.FromInstallRecord(renamed)
+	if stored, getErr := database.Get(ctx, record.InstallID); getErr == nil {
+		dbRecord.Metadata = mergeMetadata(stored.Metadata, dbRecord.Metadata)
+	}
+	if err := database.Update(ctx, dbRecord); err != nil {
+		return fail(fmt.Errorf("update record: %w", err))
+	}
+	tx.Commit()
+	recordIntegrity(fs, cfg, log, renamed)
+
+	pruneVersions(ctx, fs, database, log, record.Name, 0)
+	refreshIconCaches(runner, cfg, log, renamed)
+
+	log.Info().Str("install_id", record.InstallID).Str("from", record.Name).Str("to", newName).Msg("package renamed")
+	ui.PrintSuccess("Renamed %s to %s", record.Name, newName)
+	return nil
+}
+
+// planRename returns record under newName and the files to move: those whose
+// name starts with the package's normalized name. A desktop entry renamed to
+// match the app's window class keeps its name so docks still match it.
+func planRename(record *core.InstallRecord, newName string) (*core.InstallRecord, []pathMove) {
+	oldSlug, newSlug := helpers.NormalizeFilename(record.Name), helpers.NormalizeFilename(newName)
+
+	renamed := *record
+	renamed.Name = newName
+	renamed.Metadata.DesktopFiles = slices.Clone(record.Metadata.DesktopFiles)
+	renamed.Metadata.IconFiles = slices.Clone(record.Metadata.IconFiles)
+
+	var moves []pathMove
+	planned := make(map[string]string)
+	rename := func(path *string) {
+		if *path == "" {
+			return
+		}
+		if to, ok := planned[*path]; ok {
+			*path = to
+			return
+		}
+		base, ok := renameBase(filepath.Base(*path), oldSlug, newSlug)
+		if !ok {
+			return
+		}
+		to := filepath.Join(filepath.Dir(*path), base)
+		if to == *path {
+			return
+		}
+		planned[*path] = to
+		moves = append(moves, pathMove{from: *path, to: to})
+		*path = to
+	}
+
+	rename(&renamed.InstallPath)
+	rename(&renamed.Metadata.WrapperScript)
+	if record.Metadata.OriginalDesktopFile == "" {
+		rename(&renamed.DesktopFile)
+		for i := range renamed.Metadata.DesktopFiles {
+			rename(&renamed.Metadata.DesktopFiles[i])
+		}
+	}
+	for i := range renamed.Metadata.IconFiles {
+		rename(&renamed.Metadata.IconFiles[i])
+	}
+	rename(&renamed.Metadata.CustomIcon)
+	return &renamed, moves
+}
+
+// renameBase replaces oldSlug at the start of a file name (tool, tool.png,
+// tool-cli.desktop) with newSlug
+func renameBase(base, oldSlug, newSlug string) (string, bool) {
+	if base == oldSlug {
+		return newSlug, true
+	}
+	for _, sep := range []string{".", "-"} {
+		if rest, ok := strings.CutPrefix(base, oldSlug+sep); ok {
+			return newSlug + sep + rest, true
+		}
+	}
+	return base, false
+}
+
+// movePackageFiles renames each file of moves, undoing the renames on rollback
+func movePackageFiles(fs afero.Fs, tx *transaction.Manager, moves []pathMove) error {
+	for _, move := range moves {
+		if _, err := lstatUpgrade(fs, move.from); os.IsNotExist(err) {
+			continue
+		}
+		if err := fs.Rename(move.from, move.to); err != nil {
+			return fmt.Errorf("rename %s: %w", move.from, err)
+		}
+		tx.Add("rename "+move.to+" back", func() error {
+			return fs.Rename(move.to, move.from)
+		})
+	}
+	return nil
+}
+
+// rewriteRenamedFiles points the wrappers, desktop entries and exposed
+// binaries of renamed at the moved files and gives the desktop entries the
+// new name and icon
+func rewriteRenamedFiles(fs afero.Fs, tx *transaction.Manager, record, renamed *core.InstallRecord, moves []pathMove) error {
+	for _, file := range relocate.IntegrationFiles(renamed) {
+		for _, move := range moves {
+			restore, err := relocate.RewriteFile(fs, file, move.from, move.to)
+			if err != nil {
+				return err
+			}
+			if restore != nil {
+				tx.Add("restore "+file, restore)
+			}
+		}
+	}
+
+	oldSlug, newSlug := helpers.NormalizeFilename(record.Name), helpers.NormalizeFilename(renamed.Name)
+	for _, file := range renamed.GetDesktopFiles() {
+		original, err := afero.ReadFile(fs, file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("read desktop entry: %w", err)
+		}
+		entry, err := desktop.Parse(bytes.NewReader(original))
+		if err != nil {
+			return fmt.Errorf("parse desktop entry %s: %w", file, err)
+		}
+
+		// Launchers added with --bin are named "App (bin)"
+		if record.Metadata.DesktopOverrides == nil || record.Metadata.DesktopOverrides.Name == "" {
+			if rest, ok := strings.CutPrefix(entry.Name, record.Name); ok && (rest == "" || strings.HasPrefix(rest, " (")) {
+				entry.Name, entry.LocalizedName = renamed.Name+rest, nil
+			}
+		}
+		if entry.Icon == oldSlug {
+			entry.Icon = newSlug
+		}
+
+		var buf bytes.Buffer
+		if err := desktop.Write(&buf, entry); err != nil {
+			return fmt.Errorf("render desktop entry: %w", err)
+		}
+		if bytes.Equal(buf.Bytes(), original) {
+			continue
+		}
+		if err := afero.WriteFile(fs, file, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("write desktop entry: %w", err)
+		}
+		tx.Add("restore "+file, func() error {
+			return afero.WriteFile(fs, file, original, 0644)
+		})
+	}
+	return nil
+}
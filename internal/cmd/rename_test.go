@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenameBase(t *testing.T) {
+	tests := []struct {
+		base   string
+		want   string
+		wantOK bool
+	}{
+		{"vendor-app", "editor", true},
+		{"vendor-app.png", "editor.png", true},
+		{"vendor-app-cli.desktop", "editor-cli.desktop", true},
+		{"vendor-application", "vendor-application", false},
+		{"other.desktop", "other.desktop", false},
+	}
+	for _, tt := range tests {
+		got, ok := renameBase(tt.base, "vendor-app", "editor")
+		assert.Equal(t, tt.want, got, tt.base)
+		assert.Equal(t, tt.wantOK, ok, tt.base)
+	}
+}
+
+func TestRunRenameCmd(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: filepath.Join(tmpDir, "test.db")}}
+	log := zerolog.New(io.Discard)
+	runner := &helpers.MockCommandRunner{
+		CommandExistsFunc: func(string) bool { return false },
+	}
+
+	const (
+		installDir = "/data/apps/vendor-app"
+		wrapper    = "/home/u/.local/bin/vendor-app"
+		cli        = "/home/u/.local/bin/vendor-app-cli"
+		entry      = "/home/u/.local/share/applications/vendor-app.desktop"
+		icon       = "/home/u/.local/share/icons/hicolor/256x256/apps/vendor-app.png"
+	)
+	record := &core.InstallRecord{
+		InstallID:   "vendor-id",
+		PackageType: core.PackageTypeTarball,
+		Name:        "Vendor App",
+		InstallDate: time.Now(),
+		InstallPath: installDir,
+		DesktopFile: entry,
+		Metadata: core.Metadata{
+			WrapperScript: wrapper,
+			ExtraWrappers: []string{cli},
+			IconFiles:     []string{icon},
+			InstallMethod: core.InstallMethodLocal,
+		},
+	}
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	require.NoError(t, database.Create(ctx, db.FromInstallRecord(record)))
+	require.NoError(t, database.Create(ctx, &db.Install{InstallID: "other-id", PackageType: "appimage", Name: "Other", InstallDate: time.Now()}))
+	require.NoError(t, database.Close())
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, installDir+"/vendor-app", []byte("ELF"), 0755))
+	require.NoError(t, afero.WriteFile(fs, wrapper, []byte("#!/bin/sh\nexec \""+installDir+"/vendor-app\" \"$@\"\n"), 0755))
+	require.NoError(t, afero.WriteFile(fs, cli, []byte("#!/bin/sh\nexec \""+installDir+"/bin/cli\" \"$@\"\n"), 0755))
+	require.NoError(t, afero.WriteFile(fs, icon, []byte("PNG"), 0644))
+	require.NoError(t, afero.WriteFile(fs, entry, []byte("[Desktop Entry]\nType=Application\nName=Vendor App\nExec="+wrapper+" %U\nIcon=vendor-app\n"), 0644))
+
+	t.Run("rejects taken names", func(t *testing.T) {
+		assert.Error(t, runRenameCmd(fs, runner, cfg, &log, &renameOptions{}, "vendor-id", "other"))
+		assert.Error(t, runRenameCmd(fs, runner, cfg, &log, &renameOptions{}, "vendor-id", " "))
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		require.NoError(t, runRenameCmd(fs, runner, cfg, &log, &renameOptions{dryRun: true}, "Vendor App", "Editor"))
+		exists, err := afero.Exists(fs, wrapper)
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("renames files and record", func(t *testing.T) {
+		require.NoError(t, runRenameCmd(fs, runner, cfg, &log, &renameOptions{}, "Vendor App", "Editor"))
+
+		for _, path := range []string{"/data/apps/editor/vendor-app", "/home/u/.local/share/icons/hicolor/256x256/apps/editor.png"} {
+			exists, err := afero.Exists(fs, path)
+			require.NoError(t, err)
+			assert.True(t, exists, path)
+		}
+		for _, path := range []string{installDir, wrapper, entry, icon} {
+			exists, err := afero.Exists(fs, path)
+			require.NoError(t, err)
+			assert.False(t, exists, path)
+		}
+
+		content, err := afero.ReadFile(fs, "/home/u/.local/bin/editor")
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `exec "/data/apps/editor/vendor-app"`)
+		content, err = afero.ReadFile(fs, cli)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), `exec "/data/apps/editor/bin/cli"`)
+		content, err = afero.ReadFile(fs, "/home/u/.local/share/applications/editor.desktop")
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "Name=Editor\n")
+		assert.Contains(t, string(content), "Exec=/home/u/.local/bin/editor %U\n")
+		assert.Contains(t, string(content), "Icon=editor\n")
+
+		database, err := db.New(ctx, cfg.Paths.DBFile)
+		require.NoError(t, err)
+		defer func() { _ = database.Close() }()
+		stored, err := database.Get(ctx, "vendor-id")
+		require.NoError(t, err)
+		renamed := db.ToInstallRecord(stored)
+		assert.Equal(t, "Editor", renamed.Name)
+		assert.Equal(t, "/data/apps/editor", renamed.InstallPath)
+		assert.Equal(t, "/home/u/.local/share/applications/editor.desktop", renamed.DesktopFile)
+		assert.Equal(t, "/home/u/.local/bin/editor", renamed.Metadata.WrapperScript)
+		assert.Equal(t, []string{cli}, renamed.Metadata.ExtraWrappers)
+		assert.Equal(t, []string{"/home/u/.local/share/icons/hicolor/256x256/apps/editor.png"}, renamed.Metadata.IconFiles)
+	})
+}
+
+func TestRunRenameCmd_SystemManaged(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: filepath.Join(tmpDir, "test.db")}}
+	log := zerolog.New(io.Discard)
+
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	require.NoError(t, database.Create(ctx, &db.Install{
+		InstallID: "sys-id", PackageType: "deb", Name: "sysapp", InstallDate: time.Now(),
+		Metadata: map[string]interface{}{"install_method": core.InstallMethodPacman},
+	}))
+	require.NoError(t, database.Close())
+
+	err = runRenameCmd(afero.NewMemMapFs(), &helpers.MockCommandRunner{}, cfg, &log, &renameOptions{}, "sysapp", "renamed")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "system-managed")
+}
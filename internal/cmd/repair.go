@@ -0,0 +1,478 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	backendbase "github.com/quantmind-br/upkg/internal/backends/base"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/desktop"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/heuristics"
+	"github.com/quantmind-br/upkg/internal/icons"
+	"github.com/quantmind-br/upkg/internal/integration"
+	"github.com/quantmind-br/upkg/internal/integrity"
+	"github.com/quantmind-br/upkg/internal/layout"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// How repair fixed a file
+const (
+	repairRestored    = "restored"    // Written back from the integrity manifest
+	repairRegenerated = "regenerated" // Built again from the record and the install dir
+)
+
+// repairOptions holds the flags of the repair command
+type repairOptions struct {
+	all    bool
+	dryRun bool
+}
+
+// repairFix is a wrapper, desktop entry or icon that was missing or broken
+type repairFix struct {
+	Kind   string // integrity.KindWrapper, KindDesktop or KindIcon
+	Path   string
+	Action string // repairRestored, repairRegenerated, or empty when it could not be fixed
+	Err    error
+}
+
+// NewRepairCmd creates the repair command
+func NewRepairCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &repairOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "repair [name|install-id...]",
+		Short: "Re-create missing or broken wrappers, desktop entries and icons",
+		Long: `Re-create the wrapper scripts, desktop entries and icons of installed
+packages when they are missing or broken, then refresh the desktop database
+and icon cache. Use it when a desktop environment update wiped
+~/.local/share/applications instead of reinstalling.
+
+Files are written back from the content recorded at install time (see
+upkg verify). Packages installed before that are regenerated from the
+install record and the files still in the install directory: the wrapper
+launches the best executable of the payload, the desktop entry is rebuilt
+with its edits (upkg desktop edit) and the icons are installed again.
+
+Packages installed with pacman, dpkg, dnf or Flatpak are integrated by the
+system and are skipped.`,
+		Example: `  upkg repair obsidian
+  upkg repair --all
+  upkg repair --all --dry-run`,
+		ValidArgsFunction: completeInstalledPackages(cfg, 0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.all == (len(args) > 0) {
+				return fmt.Errorf("pass package names or --all")
+			}
+			return runRepairCmd(afero.NewOsFs(), helpers.NewOSCommandRunner(), paths.NewResolver(cfg), cfg, log, opts, args)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.all, "all", false, "repair every installed package")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "only list what would be repaired")
+
+	return cmd
+}
+
+func runRepairCmd(fs afero.Fs, runner helpers.CommandRunner, resolver *paths.Resolver, cfg *config.Config, log *zerolog.Logger, opts *repairOptions, identifiers []string) error {
+	ctx := context.Background()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	var records []*core.InstallRecord
+	if opts.all {
+		installs, listErr := database.List(ctx)
+		if listErr != nil {
+			ui.PrintError("failed to query database: %v", listErr)
+			return fmt.Errorf("list installs: %w", listErr)
+		}
+		for i := range installs {
+			records = append(records, db.ToInstallRecord(&installs[i]))
+		}
+	} else {
+		for _, identifier := range identifiers {
+			record, lookupErr := lookupPackage(ctx, database, log, identifier)
+			if lookupErr != nil {
+				return lookupErr
+			}
+			records = append(records, record)
+		}
+	}
+
+	repairer := &integrationRepairer{
+		fs:        fs,
+		engine:    integration.NewEngine(fs, runner, resolver, cfg, log),
+		manifests: integrity.NewStore(fs, resolver.GetIntegrityDir()),
+		scorer:    heuristics.NewScorer(log, backendbase.ScoringRules(cfg)),
+		log:       log,
+		dryRun:    opts.dryRun,
+	}
+
+	var failed, repaired int
+	for _, record := range records {
+		if record.PackageType == core.PackageTypeFlatpak || core.IsSystemManaged(record.Metadata.InstallMethod) {
+			if !opts.all {
+				ui.PrintWarning("%s is integrated by the system package manager; skipped", record.Name)
+			}
+			continue
+		}
+
+		before := *record
+		fixes := repairer.repair(record)
+		if len(fixes) == 0 {
+			if !opts.all {
+				ui.PrintSuccess("%s: desktop integration is intact", record.Name)
+			}
+			continue
+		}
+		incomplete := printRepairFixes(record.Name, fixes, opts.dryRun)
+		if incomplete {
+			failed++
+		}
+		if opts.dryRun {
+			continue
+		}
+		repaired++
+
+		if !slices.Equal(before.Metadata.IconFiles, record.Metadata.IconFiles) ||
+			before.DesktopFile != record.DesktopFile || before.Metadata.WrapperScript != record.Metadata.WrapperScript {
+			dbRecord := db.FromInstallRecord(record)
+			if stored, getErr := database.Get(ctx, record.InstallID); getErr == nil {
+				dbRecord.Metadata = mergeMetadata(stored.Metadata, dbRecord.Metadata)
+			}
+			if updateErr := database.Update(ctx, dbRecord); updateErr != nil {
+				log.Warn().Err(updateErr).Str("name", record.Name).Msg("failed to save repaired record")
+			}
+		}
+		if !incomplete {
+			// Record the regenerated files so the next repair restores them;
+			// a partial repair keeps the manifest of the lost ones
+			recordIntegrity(fs, cfg, log, record)
+		}
+		refreshIconCaches(runner, cfg, log, record)
+		log.Info().Str("name", record.Name).Int("files", len(fixes)).Msg("desktop integration repaired")
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d packages could not be fully repaired", failed)
+	}
+	if opts.all && repaired == 0 && !opts.dryRun {
+		ui.PrintSuccess("Desktop integration of every package is intact")
+	}
+	return nil
+}
+
+// printRepairFixes lists the fixes of a package and reports whether any failed
+func printRepairFixes(name string, fixes []repairFix, dryRun bool) bool {
+	failed := false
+	if dryRun {
+		ui.PrintInfo("%s: %d files to repair", name, len(fixes))
+	} else {
+		ui.PrintSuccess("Repaired %s", name)
+	}
+	for _, fix := range fixes {
+		switch {
+		case fix.Err != nil:
+			failed = true
+			ui.PrintKeyValue(fmt.Sprintf("  %s (failed)", fix.Kind), fmt.Sprintf("%s: %v", fix.Path, fix.Err))
+		case dryRun:
+			ui.PrintKeyValue(fmt.Sprintf("  %s", fix.Kind), fix.Path)
+		default:
+			ui.PrintKeyValue(fmt.Sprintf("  %s (%s)", fix.Kind, fix.Action), fix.Path)
+		}
+	}
+	if failed {
+		ui.PrintInfo("Reinstall %s to restore the rest", name)
+	}
+	return failed
+}
+
+// integrationRepairer re-creates the desktop integration of packages
+type integrationRepairer struct {
+	fs        afero.Fs
+	engine    *integration.Engine
+	manifests *integrity.Store
+	scorer    *heuristics.DefaultScorer
+	log       *zerolog.Logger
+	dryRun    bool
+}
+
+// repair fixes the missing or broken wrappers, desktop entries and icons of
+// record, updating its paths when a file had to be regenerated elsewhere
+func (r *integrationRepairer) repair(record *core.InstallRecord) []repairFix {
+	recorded := map[string]integrity.File{}
+	if manifest, err := r.manifests.Load(record.InstallID); err == nil {
+		for _, file := range manifest.Files {
+			recorded[file.Path] = file
+		}
+	} else if !errors.Is(err, integrity.ErrNoManifest) {
+		r.log.Warn().Err(err).Str("name", record.Name).Msg("failed to read integrity manifest")
+	}
+
+	// restore writes path back from the manifest; ok is false without content
+	restore := func(kind, path string) (repairFix, bool) {
+		fix := repairFix{Kind: kind, Path: path, Action: repairRestored}
+		file, ok := recorded[path]
+		if !ok || !file.Restorable() {
+			return fix, false
+		}
+		if !r.dryRun {
+			fix.Err = integrity.Restore(r.fs, file)
+		}
+		return fix, true
+	}
+
+	var fixes []repairFix
+	for i, path := range append([]string{record.Metadata.WrapperScript}, record.Metadata.ExtraWrappers...) {
+		if path == "" || !r.brokenWrapper(path) {
+			continue
+		}
+		fix, ok := restore(integrity.KindWrapper, path)
+		if !ok {
+			fix.Action = repairRegenerated
+			switch {
+			case i > 0:
+				fix.Err = fmt.Errorf("launchers selected with --bin are only restored from recorded content")
+			case !r.dryRun:
+				fix.Err = r.regenerateWrapper(record, path)
+			}
+		}
+		fixes = append(fixes, fix)
+	}
+
+	desktopFiles := append([]string{record.DesktopFile}, record.Metadata.DesktopFiles...)
+	for i, path := range desktopFiles {
+		if path == "" || (i > 0 && path == record.DesktopFile) || !r.brokenDesktopEntry(path) {
+			continue
+		}
+		fix, ok := restore(integrity.KindDesktop, path)
+		if !ok {
+			fix.Action = repairRegenerated
+			switch {
+			case i > 0:
+				fix.Err = fmt.Errorf("extra desktop entries are only restored from recorded content")
+			case !r.dryRun:
+				fix.Err = r.regenerateDesktopEntry(record, path)
+			}
+		}
+		fixes = append(fixes, fix)
+	}
+
+	var lostIcons []string
+	for _, path := range record.Metadata.IconFiles {
+		if exists, _ := afero.Exists(r.fs, path); exists {
+			continue
+		}
+		if fix, ok := restore(integrity.KindIcon, path); ok {
+			fixes = append(fixes, fix)
+		} else {
+			lostIcons = append(lostIcons, path)
+		}
+	}
+	if len(lostIcons) > 0 {
+		fixes = append(fixes, r.regenerateIcons(record, lostIcons)...)
+	}
+	return fixes
+}
+
+// brokenWrapper reports whether the wrapper at path is missing, empty or
+// not executable
+func (r *integrationRepairer) brokenWrapper(path string) bool {
+	info, err := r.fs.Stat(path)
+	return err != nil || info.Size() == 0 || info.Mode()&0111 == 0
+}
+
+// brokenDesktopEntry reports whether the desktop entry at path is missing
+// or cannot be parsed
+func (r *integrationRepairer) brokenDesktopEntry(path string) bool {
+	file, err := r.fs.Open(path)
+	if err != nil {
+		return true
+	}
+	defer func() { _ = file.Close() }()
+	entry, err := desktop.Parse(file)
+	return err != nil || entry.Exec == ""
+}
+
+// regenerateWrapper writes the primary wrapper of record again at path
+func (r *integrationRepairer) regenerateWrapper(record *core.InstallRecord, path string) error {
+	execPath := r.launchTarget(record)
+	if execPath == "" {
+		return fmt.Errorf("no executable left in %s", record.InstallPath)
+	}
+	written, _, err := r.engine.CreateLauncher(filepath.Base(path), execPath, repairInstallOptions(record), record.InstallPath)
+	if err != nil {
+		return err
+	}
+	if written != path {
+		record.Metadata.WrapperScript = written
+	}
+	return nil
+}
+
+// regenerateDesktopEntry builds the primary desktop entry of record again,
+// reapplying its desktop edits
+func (r *integrationRepairer) regenerateDesktopEntry(record *core.InstallRecord, path string) error {
+	execPath := record.Metadata.WrapperScript
+	if execPath == "" {
+		execPath = r.launchTarget(record)
+	}
+	if execPath == "" {
+		return fmt.Errorf("no launcher to point the desktop entry at")
+	}
+
+	spec := integration.DesktopSpec{
+		AppName:        record.Name,
+		FileName:       strings.TrimSuffix(filepath.Base(path), ".desktop"),
+		ExecPath:       execPath,
+		IconName:       repairIconName(record),
+		PayloadRoot:    record.InstallPath,
+		DefaultComment: fmt.Sprintf("%s application", record.Name),
+		Toolkit:        heuristics.Framework(record.Metadata.Framework),
+		Wayland:        core.WaylandSupport(record.Metadata.WaylandSupport),
+	}
+	if info, err := r.fs.Stat(record.InstallPath); err == nil && info.IsDir() {
+		spec.SourceDesktop = r.engine.FindDesktopFile(
+			filepath.Join(record.InstallPath, "*.desktop"),
+			filepath.Join(record.InstallPath, "usr", "share", "applications", "*.desktop"),
+		)
+	}
+
+	written, err := r.engine.WriteDesktopEntry(spec, repairInstallOptions(record))
+	if err != nil {
+		return err
+	}
+	if record.Metadata.DesktopOverrides != nil {
+		if err := applyDesktopOverrides(r.fs, written, record.Metadata.DesktopOverrides, nil); err != nil {
+			return err
+		}
+	}
+	if written != path {
+		record.DesktopFile = written
+	}
+	return nil
+}
+
+// regenerateIcons installs the icons found in the install directory again
+// under the name of the lost ones
+func (r *integrationRepairer) regenerateIcons(record *core.InstallRecord, lost []string) []repairFix {
+	fixes := make([]repairFix, 0, len(lost))
+	fail := func(err error) []repairFix {
+		for _, path := range lost {
+			fixes = append(fixes, repairFix{Kind: integrity.KindIcon, Path: path, Err: err})
+		}
+		return fixes
+	}
+
+	info, err := r.fs.Stat(record.InstallPath)
+	if err != nil || !info.IsDir() {
+		return fail(fmt.Errorf("the icons of %s are only restored from recorded content", record.PackageType))
+	}
+	discovered, err := icons.NewManager(r.fs, "").DiscoverIcons(record.InstallPath)
+	if err != nil || len(discovered) == 0 {
+		return fail(fmt.Errorf("no icons left in %s", record.InstallPath))
+	}
+	if r.dryRun {
+		for _, path := range lost {
+			fixes = append(fixes, repairFix{Kind: integrity.KindIcon, Path: path, Action: repairRegenerated})
+		}
+		return fixes
+	}
+
+	installed, err := r.engine.InstallIcons(discovered, repairIconName(record))
+	if err == nil && len(installed) == 0 {
+		err = fmt.Errorf("no icon could be installed")
+	}
+	if err != nil {
+		return fail(err)
+	}
+	kept := slices.DeleteFunc(slices.Clone(record.Metadata.IconFiles), func(path string) bool {
+		return slices.Contains(lost, path)
+	})
+	for _, path := range installed {
+		if !slices.Contains(kept, path) {
+			kept = append(kept, path)
+		}
+		fixes = append(fixes, repairFix{Kind: integrity.KindIcon, Path: path, Action: repairRegenerated})
+	}
+	record.Metadata.IconFiles = kept
+	return fixes
+}
+
+// launchTarget returns the program the primary wrapper of record starts:
+// the installed file itself, or the best executable of the payload
+func (r *integrationRepairer) launchTarget(record *core.InstallRecord) string {
+	info, err := r.fs.Stat(record.InstallPath)
+	if err != nil {
+		return ""
+	}
+	if !info.IsDir() {
+		return record.InstallPath
+	}
+
+	// Versioned installs launch through the current link
+	scanDir, launchDir := record.InstallPath, record.InstallPath
+	if release := layout.Current(r.fs, record.InstallPath); release != "" {
+		scanDir = filepath.Join(record.InstallPath, release)
+		launchDir = layout.CurrentDir(record.InstallPath)
+	}
+	executables, err := heuristics.FindExecutables(scanDir)
+	if err != nil || len(executables) == 0 {
+		return ""
+	}
+
+	chosen := ""
+	if len(record.Metadata.Bins) > 0 && record.Metadata.Bins[0] != core.BinsAll {
+		for _, exec := range executables {
+			if filepath.Base(exec) == record.Metadata.Bins[0] {
+				chosen = exec
+				break
+			}
+		}
+	}
+	if chosen == "" {
+		chosen = r.scorer.ChooseBest(executables, helpers.NormalizeFilename(record.Name), scanDir)
+	}
+	rel, err := filepath.Rel(scanDir, chosen)
+	if err != nil {
+		return chosen
+	}
+	return filepath.Join(launchDir, rel)
+}
+
+// repairInstallOptions are the install options record was integrated with
+func repairInstallOptions(record *core.InstallRecord) core.InstallOptions {
+	return core.InstallOptions{
+		CustomName:  record.Name,
+		Desktop:     true,
+		Sandbox:     record.Metadata.Sandbox != "",
+		Bins:        record.Metadata.Bins,
+		WrapperArgs: record.Metadata.WrapperArgs,
+		WrapperEnv:  record.Metadata.WrapperEnv,
+	}
+}
+
+// repairIconName returns the theme name the icons of record were installed as
+func repairIconName(record *core.InstallRecord) string {
+	for _, path := range record.Metadata.IconFiles {
+		if path != record.Metadata.CustomIcon {
+			return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+	}
+	return helpers.NormalizeFilename(record.Name)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunRepairCmd(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	home := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{
+		DBFile:  filepath.Join(home, "installed.db"),
+		DataDir: filepath.Join(home, ".local", "share", "upkg"),
+	}}
+	resolver := paths.NewResolverWithHome(cfg, home)
+	fs := afero.NewOsFs()
+	runner := &helpers.MockCommandRunner{}
+
+	// A directory payload with a real ELF launcher and an icon
+	self, err := os.Executable()
+	require.NoError(t, err)
+	elf, err := os.ReadFile(self)
+	require.NoError(t, err)
+	installDir := resolver.GetAppInstallDir("tool")
+	require.NoError(t, os.MkdirAll(installDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(installDir, "tool"), elf, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(installDir, "tool.svg"), []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), 0644))
+
+	wrapper := filepath.Join(resolver.GetBinDir(), "tool")
+	desktopFile := filepath.Join(resolver.GetAppsDir(), "tool.desktop")
+	icon := filepath.Join(resolver.GetIconsDir(), "scalable", "apps", "tool.svg")
+	record := &core.InstallRecord{
+		InstallID: "tool-1", Name: "tool", PackageType: core.PackageTypeTarball, InstallDate: time.Now(),
+		InstallPath: installDir, DesktopFile: desktopFile,
+		Metadata: core.Metadata{
+			WrapperScript:    wrapper,
+			IconFiles:        []string{icon},
+			DesktopOverrides: &core.DesktopOverrides{Name: "Tool Pro"},
+		},
+	}
+
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	require.NoError(t, database.Create(ctx, db.FromInstallRecord(record)))
+	require.NoError(t, database.Close())
+
+	// Nothing was recorded and everything is gone: regenerate from the payload
+	require.NoError(t, runRepairCmd(fs, runner, resolver, cfg, &logger, &repairOptions{dryRun: true}, []string{"tool"}))
+	assert.NoFileExists(t, wrapper, "a dry run changes nothing")
+
+	require.NoError(t, runRepairCmd(fs, runner, resolver, cfg, &logger, &repairOptions{}, []string{"tool"}))
+	content, err := os.ReadFile(wrapper)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), filepath.Join(installDir, "tool"))
+	content, err = os.ReadFile(desktopFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Exec="+wrapper)
+	assert.Contains(t, string(content), "Name=Tool Pro", "desktop edits are reapplied")
+	assert.FileExists(t, icon)
+
+	// The regenerated files were recorded; a wiped applications dir comes back as it was
+	repaired, err := os.ReadFile(desktopFile)
+	require.NoError(t, err)
+	require.NoError(t, os.RemoveAll(resolver.GetAppsDir()))
+	require.NoError(t, os.Chmod(wrapper, 0644))
+	require.NoError(t, runRepairCmd(fs, runner, resolver, cfg, &logger, &repairOptions{all: true}, nil))
+	content, err = os.ReadFile(desktopFile)
+	require.NoError(t, err)
+	assert.Equal(t, repaired, content)
+	info, err := os.Stat(wrapper)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&0111, "a wrapper that lost its exec bit is restored")
+
+	// Without an executable or an icon left there is nothing to rebuild from
+	require.NoError(t, os.RemoveAll(installDir))
+	require.NoError(t, os.RemoveAll(filepath.Join(cfg.Paths.DataDir, "integrity")))
+	require.NoError(t, os.Remove(wrapper))
+	assert.Error(t, runRepairCmd(fs, runner, resolver, cfg, &logger, &repairOptions{}, []string{"tool"}))
+}
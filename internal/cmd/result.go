@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/ui"
+)
+
+// printResultNotes lists the warnings and skipped steps a backend reported
+// for an install, upgrade or uninstall
+func printResultNotes(warnings, skipped []string) {
+	for _, warning := range warnings {
+		color.Yellow("  ⚠ %s", warning)
+	}
+	for _, step := range skipped {
+		color.Cyan("  - Skipped %s", step)
+	}
+}
+
+// printCandidates explains how the main executable of an install was chosen
+// (install --explain-choice). Paths under installDir are shown relative to it.
+func printCandidates(out io.Writer, candidates []core.ExecutableCandidate, installDir string) {
+	if len(candidates) == 0 {
+		ui.PrintInfo("No executable was chosen by heuristics for this package")
+		return
+	}
+
+	_, _ = fmt.Fprintln(out, "\nExecutable candidates (best first):")
+	for _, candidate := range candidates {
+		path := candidate.Path
+		if rel, err := filepath.Rel(installDir, path); err == nil && filepath.IsLocal(rel) {
+			path = rel
+		}
+		marker := " "
+		switch {
+		case candidate.Chosen:
+			marker = "✓"
+		case candidate.Ignored:
+			marker = "✗"
+		}
+		_, _ = fmt.Fprintf(out, "  %s %6d  %s\n", marker, candidate.Score, path)
+		for _, reason := range candidate.Reasons {
+			_, _ = fmt.Fprintf(out, "             %s\n", reason)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintCandidates(t *testing.T) {
+	var out bytes.Buffer
+	printCandidates(&out, []core.ExecutableCandidate{
+		{Path: "/apps/tool/bin/tool", Score: 210, Reasons: []string{"+40 path depth 2", `+120 name matches "tool"`}, Chosen: true},
+		{Path: "/apps/tool/tool-helper", Score: -140, Reasons: []string{`ignore rule "*-helper"`}, Ignored: true},
+	}, "/apps/tool")
+
+	assert.Contains(t, out.String(), "✓    210  bin/tool")
+	assert.Contains(t, out.String(), `+120 name matches "tool"`)
+	assert.Contains(t, out.String(), "✗   -140  tool-helper")
+}
@@ -0,0 +1,363 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/cache"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/history"
+	"github.com/quantmind-br/upkg/internal/layout"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// rollbackOptions holds the flags of the rollback command
+type rollbackOptions struct {
+	to   string
+	list bool
+}
+
+// NewRollbackCmd creates the rollback command
+func NewRollbackCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &rollbackOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "rollback <name|install-id>",
+		Short: "Switch a package back to a version retained by upgrade",
+		Long: `Restore a previous version of a package kept by 'upkg upgrade'.
+
+Upgrades retain the replaced installation (payload, launcher, desktop
+entries and icons) under <data_dir>/versions when upgrade.keep_versions is
+set or --keep-previous is passed. rollback switches back to the newest
+retained version, or the one given with --to, transactionally: if anything
+fails the current version stays in place. The version rolled back from is
+retained in turn, so a second rollback returns to it.`,
+		Example: `  upkg rollback obsidian --list
+  upkg rollback obsidian
+  upkg rollback obsidian --to 1.5.3`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeInstalledPackages(cfg, 0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.list {
+				return runRollbackCmd(cmd.OutOrStdout(), afero.NewOsFs(), helpers.NewOSCommandRunner(), paths.NewResolver(cfg), cfg, log, opts, args[0])
+			}
+			return trackStatus(cfg, log, "rollback", args[0], func() error {
+				return runRollbackCmd(cmd.OutOrStdout(), afero.NewOsFs(), helpers.NewOSCommandRunner(), paths.NewResolver(cfg), cfg, log, opts, args[0])
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.to, "to", "", "version to roll back to (default: the newest retained)")
+	cmd.Flags().BoolVar(&opts.list, "list", false, "list the retained versions")
+
+	return cmd
+}
+
+//nolint:gocyclo // rollback swaps payload, integration files and record, each with its own undo step.
+func runRollbackCmd(out io.Writer, fs afero.Fs, runner helpers.CommandRunner, resolver *paths.Resolver, cfg *config.Config, log *zerolog.Logger, opts *rollbackOptions, identifier string) (err error) {
+	ctx := context.Background()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	current, err := lookupPackage(ctx, database, log, identifier)
+	if err != nil {
+		return err
+	}
+	versions, err := database.ListVersions(ctx, current.Name)
+	if err != nil {
+		ui.PrintError("failed to list retained versions: %v", err)
+		return fmt.Errorf("list versions: %w", err)
+	}
+
+	if opts.list {
+		if len(versions) == 0 {
+			ui.PrintInfo("No previous versions of %s are retained", current.Name)
+			return nil
+		}
+		for _, version := range versions {
+			_, _ = fmt.Fprintf(out, "   • %s (retained %s)\n", displayVersion(version.Version), version.RetainedAt.Local().Format("2006-01-02 15:04"))
+		}
+		return nil
+	}
+
+	started := time.Now()
+	entry := historyRecordEntry(history.OpRollback, current)
+	entry.FromVersion, entry.Version = current.Version, opts.to
+	defer func() { recordHistory(fs, resolver.GetHistoryFile(), log, entry, started, err) }()
+
+	if len(versions) == 0 {
+		ui.PrintError("no previous versions of %s are retained; set upgrade.keep_versions or upgrade with --keep-previous", current.Name)
+		return fmt.Errorf("no retained versions of %s", current.Name)
+	}
+	target := &versions[0]
+	if opts.to != "" {
+		target = nil
+		for i := range versions {
+			if versions[i].Version == opts.to {
+				target = &versions[i]
+				break
+			}
+		}
+		if target == nil {
+			ui.PrintError("version %s of %s is not retained; see 'upkg rollback %s --list'", opts.to, current.Name, current.Name)
+			return fmt.Errorf("version %s not retained", opts.to)
+		}
+	}
+	previous := db.ToInstallRecord(&target.Record)
+	entry.InstallID, entry.Version = previous.InstallID, previous.Version
+
+	release := holdSleepInhibitor(ctx, cfg.System.InhibitSleep, log, "Rolling back "+current.Name)
+	defer release()
+
+	tx := transaction.NewManager(log)
+	attachJournal(tx, cfg, log, "rollback", current.Name)
+	defer func() {
+		undo := tx.Pending()
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Warn().Err(rollbackErr).Msg("transaction rollback failed")
+			ui.PrintError("restoring %s failed: %v", current.Name, rollbackErr)
+		} else if undo && err != nil {
+			err = withExitCode(ExitRolledBack, err)
+		}
+	}()
+
+	// Set the current version aside, as an upgrade does. Between two releases
+	// of a versioned install only the current link is switched.
+	backups, err := backupInstallation(fs, current, tx, previous.Metadata.Release != "" && target.Payload == "")
+	if err != nil {
+		ui.PrintError("failed to back up current installation: %v", err)
+		return fmt.Errorf("backup current installation: %w", err)
+	}
+
+	ui.PrintInfo("Rolling back %s %s → %s...", current.Name, displayVersion(current.Version), displayVersion(previous.Version))
+	if target.Payload != "" {
+		if _, statErr := fs.Stat(previous.InstallPath); statErr == nil {
+			ui.PrintError("%s already exists", previous.InstallPath)
+			return fmt.Errorf("restore payload: %s already exists", previous.InstallPath)
+		}
+		if err := fs.MkdirAll(filepath.Dir(previous.InstallPath), 0755); err != nil {
+			ui.PrintError("failed to restore payload: %v", err)
+			return fmt.Errorf("restore payload: %w", err)
+		}
+		tx.Track(transaction.Step{Action: transaction.ActionRename, Path: target.Payload, From: previous.InstallPath})
+		if err := helpers.MoveDir(fs, target.Payload, previous.InstallPath, nil); err != nil {
+			ui.PrintError("failed to restore payload: %v", err)
+			return fmt.Errorf("restore payload: %w", err)
+		}
+		tx.Add("return retained payload", func() error {
+			return helpers.MoveDir(fs, previous.InstallPath, target.Payload, nil)
+		})
+	}
+
+	if previous.Metadata.Release != "" {
+		restore, switchErr := layout.Switch(fs, previous.InstallPath, previous.Metadata.Release)
+		if switchErr != nil {
+			ui.PrintError("failed to switch to release %s: %v", previous.Metadata.Release, switchErr)
+			return fmt.Errorf("switch release: %w", switchErr)
+		}
+		tx.Add("restore current release", restore)
+	}
+
+	for original, copyPath := range target.Files {
+		info, statErr := lstatUpgrade(fs, copyPath)
+		if statErr != nil {
+			ui.PrintError("retained copy of %s is missing: %v", original, statErr)
+			return fmt.Errorf("restore %s: %w", original, statErr)
+		}
+		if _, existsErr := lstatUpgrade(fs, original); existsErr != nil {
+			tx.Track(transaction.Step{Action: transaction.ActionRemove, Path: original})
+			tx.Add("remove restored "+original, func() error {
+				return fs.Remove(original)
+			})
+		}
+		if err := fs.MkdirAll(filepath.Dir(original), 0755); err != nil {
+			ui.PrintError("failed to restore %s: %v", original, err)
+			return fmt.Errorf("restore %s: %w", original, err)
+		}
+		if err := copyUpgradeFile(fs, copyPath, original, info); err != nil {
+			ui.PrintError("failed to restore %s: %v", original, err)
+			return fmt.Errorf("restore %s: %w", original, err)
+		}
+	}
+
+	if err := database.Create(ctx, &target.Record); err != nil {
+		ui.PrintError("failed to save installation record: %v", err)
+		return fmt.Errorf("save installation record: %w", err)
+	}
+	if err := database.Delete(ctx, current.InstallID); err != nil {
+		if cleanupErr := database.Delete(ctx, previous.InstallID); cleanupErr != nil {
+			log.Warn().Err(cleanupErr).Str("install_id", previous.InstallID).Msg("failed to remove restored record after rollback failure")
+		}
+		ui.PrintError("failed to replace installation record: %v", err)
+		return fmt.Errorf("replace installation record: %w", err)
+	}
+
+	tx.Commit()
+
+	// The version rolled back from takes the place of the restored one
+	if err := database.DeleteVersion(ctx, target.ID); err != nil {
+		log.Warn().Err(err).Int64("version_id", target.ID).Msg("failed to remove restored version")
+	}
+	if err := fs.RemoveAll(target.Dir); err != nil {
+		log.Warn().Err(err).Str("path", target.Dir).Msg("failed to remove restored version directory")
+	}
+	if err := retainVersion(ctx, fs, database, resolver.GetVersionsDir(), current, backups); err != nil {
+		log.Warn().Err(err).Str("name", current.Name).Msg("failed to retain rolled back version")
+		ui.PrintWarning("%s %s could not be retained: %v", current.Name, displayVersion(current.Version), err)
+		backups.discard(fs, log)
+	}
+	for _, path := range staleUpgradeFiles(current, previous, resolver.HomeDir()) {
+		if removeErr := fs.RemoveAll(path); removeErr != nil && !os.IsNotExist(removeErr) {
+			log.Warn().Err(removeErr).Str("path", path).Msg("failed to remove file of rolled back version")
+		}
+	}
+	recordIntegrity(fs, cfg, log, previous)
+	forgetIntegrity(fs, resolver.GetIntegrityDir(), log, current.InstallID)
+
+	cacheManager := cache.NewCacheManagerWithRunner(runner)
+	if cacheErr := cacheManager.UpdateDesktopDatabase(resolver.GetAppsDir(), log); cacheErr != nil {
+		log.Warn().Err(cacheErr).Msg("failed to update desktop database")
+	}
+	if cacheErr := cacheManager.UpdateIconCache(resolver.GetIconsDir(), log); cacheErr != nil {
+		log.Warn().Err(cacheErr).Msg("failed to update icon cache")
+	}
+
+	ui.PrintSuccess("Rolled back %s %s → %s", previous.Name, displayVersion(current.Version), displayVersion(previous.Version))
+	log.Info().
+		Str("name", previous.Name).
+		Str("from_version", current.Version).
+		Str("to_version", previous.Version).
+		Str("install_id", previous.InstallID).
+		Msg("rollback completed successfully")
+
+	return nil
+}
+
+// keepVersions returns how many previous versions an upgrade retains
+func keepVersions(cfg *config.Config, opts *upgradeOptions) int {
+	keep := cfg.Upgrade.KeepVersions
+	if opts.keepPrevious && keep < 1 {
+		keep = 1
+	}
+	return keep
+}
+
+// retainVersion moves the payload and integration files an upgrade set
+// aside into <versionsDir>/<name>/<install-id> and records them as a version
+// of record that rollback can restore
+func retainVersion(ctx context.Context, fs afero.Fs, database *db.DB, versionsDir string, record *core.InstallRecord, backup *upgradeBackup) error {
+	dir := filepath.Join(versionsDir, helpers.NormalizeFilename(record.Name), record.InstallID)
+	version := &db.Version{
+		Name:       record.Name,
+		Version:    record.Version,
+		RetainedAt: time.Now(),
+		Dir:        dir,
+		Files:      make(map[string]string, len(backup.fileCopies)),
+		Record:     *db.FromInstallRecord(record),
+	}
+
+	fail := func(err error) error {
+		_ = fs.RemoveAll(dir)
+		return err
+	}
+	if err := fs.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create version directory: %w", err)
+	}
+
+	if backup.payload != "" {
+		payload := filepath.Join(dir, "payload", filepath.Base(record.InstallPath))
+		if err := fs.MkdirAll(filepath.Dir(payload), 0755); err != nil {
+			return fail(fmt.Errorf("create version directory: %w", err))
+		}
+		if err := helpers.MoveDir(fs, backup.payload, payload, nil); err != nil {
+			return fail(err)
+		}
+		version.Payload = payload
+		backup.payload = ""
+	}
+
+	// A release of a versioned install stays beside the current one
+	backup.release = ""
+
+	if backup.filesDir != "" {
+		filesDir := filepath.Join(dir, "files")
+		if err := helpers.MoveDir(fs, backup.filesDir, filesDir, nil); err != nil {
+			return fail(err)
+		}
+		for original, copyPath := range backup.fileCopies {
+			version.Files[original] = filepath.Join(filesDir, filepath.Base(copyPath))
+		}
+		backup.filesDir = ""
+	}
+
+	if err := database.AddVersion(ctx, version); err != nil {
+		return fail(err)
+	}
+	return nil
+}
+
+// pruneVersions removes the retained versions of name beyond the newest keep
+func pruneVersions(ctx context.Context, fs afero.Fs, database *db.DB, log *zerolog.Logger, name string, keep int) {
+	versions, err := database.ListVersions(ctx, name)
+	if err != nil {
+		log.Warn().Err(err).Str("name", name).Msg("failed to list retained versions")
+		return
+	}
+	if keep < 0 {
+		keep = 0
+	}
+	for i := keep; i < len(versions); i++ {
+		if release := retainedRelease(fs, &versions[i]); release != "" {
+			if err := fs.RemoveAll(release); err != nil {
+				log.Warn().Err(err).Str("path", release).Msg("failed to remove retained release")
+				continue
+			}
+		}
+		if err := fs.RemoveAll(versions[i].Dir); err != nil {
+			log.Warn().Err(err).Str("path", versions[i].Dir).Msg("failed to remove retained version")
+			continue
+		}
+		if err := database.DeleteVersion(ctx, versions[i].ID); err != nil {
+			log.Warn().Err(err).Int64("version_id", versions[i].ID).Msg("failed to remove retained version record")
+			continue
+		}
+	}
+
+	// Drop the package directory once its last version is gone
+	if keep == 0 && len(versions) > 0 {
+		dir := filepath.Dir(versions[0].Dir)
+		if empty, err := afero.IsEmpty(fs, dir); err == nil && empty {
+			_ = fs.Remove(dir)
+		}
+	}
+}
+
+// retainedRelease returns the release directory a retained version left in
+// its versioned install, or "" when it has none or it is the current one
+func retainedRelease(fs afero.Fs, version *db.Version) string {
+	record := db.ToInstallRecord(&version.Record)
+	if record.Metadata.Release == "" || version.Payload != "" || record.InstallPath == "" {
+		return ""
+	}
+	if layout.Current(fs, record.InstallPath) == record.Metadata.Release {
+		return ""
+	}
+	return filepath.Join(record.InstallPath, record.Metadata.Release)
+}
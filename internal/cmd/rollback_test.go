@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunRollbackCmd(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{
+		DBFile:  filepath.Join(tmpDir, "test.db"),
+		DataDir: filepath.Join(tmpDir, "data"),
+	}}
+	resolver := paths.NewResolverWithHome(cfg, "/home/u")
+	log := zerolog.New(io.Discard)
+	runner := &helpers.MockCommandRunner{CommandExistsFunc: func(string) bool { return false }}
+
+	appDir := filepath.Join(resolver.GetUpkgAppsDir(), "app")
+	wrapper := "/home/u/.local/bin/app"
+	desktopFile := "/home/u/.local/share/applications/app.desktop"
+	versionDir := filepath.Join(resolver.GetVersionsDir(), "app", "app-1")
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(appDir, "bin", "app"), []byte("v2"), 0755))
+	require.NoError(t, afero.WriteFile(fs, wrapper, []byte("wrapper v2"), 0755))
+	require.NoError(t, afero.WriteFile(fs, desktopFile, []byte("[Desktop Entry]\n"), 0644))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(versionDir, "payload", "app", "bin", "app"), []byte("v1"), 0755))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(versionDir, "files", "0-app"), []byte("wrapper v1"), 0755))
+
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	require.NoError(t, database.Create(ctx, &db.Install{
+		InstallID:   "app-2",
+		PackageType: "tarball",
+		Name:        "app",
+		Version:     "2.0",
+		InstallDate: time.Now(),
+		InstallPath: appDir,
+		DesktopFile: desktopFile,
+		Metadata:    map[string]interface{}{"wrapper_script": wrapper},
+	}))
+	require.NoError(t, database.AddVersion(ctx, &db.Version{
+		Name:       "app",
+		Version:    "1.0",
+		RetainedAt: time.Now(),
+		Dir:        versionDir,
+		Payload:    filepath.Join(versionDir, "payload", "app"),
+		Files:      map[string]string{wrapper: filepath.Join(versionDir, "files", "0-app")},
+		Record: db.Install{
+			InstallID:   "app-1",
+			PackageType: "tarball",
+			Name:        "app",
+			Version:     "1.0",
+			InstallDate: time.Now(),
+			InstallPath: appDir,
+			Metadata:    map[string]interface{}{"wrapper_script": wrapper},
+		},
+	}))
+	require.NoError(t, database.Close())
+
+	read := func(path string) string {
+		data, readErr := afero.ReadFile(fs, path)
+		require.NoError(t, readErr, path)
+		return string(data)
+	}
+	installedIDs := func() []string {
+		database, openErr := db.New(ctx, cfg.Paths.DBFile)
+		require.NoError(t, openErr)
+		defer func() { _ = database.Close() }()
+		installs, listErr := database.List(ctx)
+		require.NoError(t, listErr)
+		var ids []string
+		for _, install := range installs {
+			ids = append(ids, install.InstallID)
+		}
+		return ids
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, runRollbackCmd(&out, fs, runner, resolver, cfg, &log, &rollbackOptions{list: true}, "app"))
+	assert.Contains(t, out.String(), "1.0 (retained")
+
+	// Unknown versions are rejected before anything changes
+	require.Error(t, runRollbackCmd(&out, fs, runner, resolver, cfg, &log, &rollbackOptions{to: "0.1"}, "app"))
+	assert.Equal(t, "v2", read(filepath.Join(appDir, "bin", "app")))
+
+	require.NoError(t, runRollbackCmd(&out, fs, runner, resolver, cfg, &log, &rollbackOptions{}, "app"))
+	assert.Equal(t, "v1", read(filepath.Join(appDir, "bin", "app")))
+	assert.Equal(t, "wrapper v1", read(wrapper))
+	exists, _ := afero.Exists(fs, desktopFile)
+	assert.False(t, exists, "files the restored version does not use are removed")
+	exists, _ = afero.Exists(fs, versionDir)
+	assert.False(t, exists)
+	assert.Equal(t, []string{"app-1"}, installedIDs())
+
+	// The version rolled back from is retained, so rolling back again returns to it
+	require.NoError(t, runRollbackCmd(&out, fs, runner, resolver, cfg, &log, &rollbackOptions{to: "2.0"}, "app"))
+	assert.Equal(t, "v2", read(filepath.Join(appDir, "bin", "app")))
+	assert.Equal(t, "wrapper v2", read(wrapper))
+	assert.Equal(t, "[Desktop Entry]\n", read(desktopFile))
+	assert.Equal(t, []string{"app-2"}, installedIDs())
+}
+
+func TestPruneVersions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	database, err := db.New(ctx, filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = database.Close() }()
+	log := zerolog.New(io.Discard)
+
+	fs := afero.NewMemMapFs()
+	now := time.Now()
+	for i, version := range []string{"1.0", "1.1", "1.2"} {
+		dir := "/data/versions/app/" + version
+		require.NoError(t, afero.WriteFile(fs, dir+"/payload/app", []byte(version), 0755))
+		require.NoError(t, database.AddVersion(ctx, &db.Version{Name: "app", Version: version, Dir: dir, RetainedAt: now.Add(time.Duration(i) * time.Minute)}))
+	}
+
+	pruneVersions(ctx, fs, database, &log, "app", 1)
+	versions, err := database.ListVersions(ctx, "app")
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, "1.2", versions[0].Version)
+	exists, _ := afero.Exists(fs, "/data/versions/app/1.0")
+	assert.False(t, exists)
+
+	pruneVersions(ctx, fs, database, &log, "app", 0)
+	exists, _ = afero.Exists(fs, "/data/versions/app")
+	assert.False(t, exists)
+}
+
+func TestKeepVersions(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0, keepVersions(&config.Config{}, &upgradeOptions{}))
+	assert.Equal(t, 1, keepVersions(&config.Config{}, &upgradeOptions{keepPrevious: true}))
+	assert.Equal(t, 3, keepVersions(&config.Config{Upgrade: config.UpgradeConfig{KeepVersions: 3}}, &upgradeOptions{keepPrevious: true}))
+}
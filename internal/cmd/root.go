@@ -1,28 +1,99 @@
 package cmd
 
 import (
+	"os"
+
 	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/helpers"
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
 )
 
+// commandAliases maps subcommands to the short forms users of apt/pacman expect
+var commandAliases = map[string][]string{
+	"install":   {"in", "add"},
+	"uninstall": {"remove", "rm"},
+	"list":      {"ls"},
+	"pin":       {"hold"},
+	"unpin":     {"unhold"},
+}
+
+// commandSuggestions maps subcommands to words that should trigger a "did you mean" hint
+var commandSuggestions = map[string][]string{
+	"uninstall": {"delete", "erase", "purge"},
+	"info":      {"show", "inspect"},
+}
+
 // NewRootCmd creates the root command
 func NewRootCmd(cfg *config.Config, log *zerolog.Logger, version string) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:          "upkg",
-		Short:        "Package control utility",
-		Long:         `A modern package manager for Linux supporting AppImage, DEB, RPM, Tarball, and Binary packages.`,
-		SilenceUsage: true,
+		Use:                        "upkg",
+		Short:                      "Package control utility",
+		Long:                       `A modern package manager for Linux supporting AppImage, DEB, RPM, Tarball, and Binary packages.`,
+		SilenceUsage:               true,
+		SuggestionsMinimumDistance: 2,
+		Version:                    version,
+	}
+	cmd.SetVersionTemplate("upkg version {{.Version}}\n")
+	addOutputFlag(cmd)
+	addSystemFlag(cmd)
+	addNonInteractiveFlag(cmd)
+	cmd.PersistentPreRunE = func(cmd *cobra.Command, _ []string) error {
+		if skipsSetup(cmd) {
+			return nil
+		}
+		setupInteractivity(cmd)
+		if delegated, err := setupScope(cmd, cfg, helpers.NewOSCommandRunner(), os.Geteuid()); delegated || err != nil {
+			return err
+		}
+		return setupOutput(cmd)
 	}
 
 	// Add subcommands
+	cmd.AddCommand(NewInitCmd(cfg, log))
+	cmd.AddCommand(NewConfigCmd(cfg, log))
 	cmd.AddCommand(NewInstallCmd(cfg, log))
+	cmd.AddCommand(NewAdoptCmd(cfg, log))
+	cmd.AddCommand(NewApplyCmd(cfg, log))
 	cmd.AddCommand(NewUninstallCmd(cfg, log))
+	cmd.AddCommand(NewUpgradeCmd(cfg, log))
+	cmd.AddCommand(NewRollbackCmd(cfg, log))
+	cmd.AddCommand(NewCheckUpdatesCmd(cfg, log))
+	cmd.AddCommand(NewSyncMetadataCmd(cfg, log))
 	cmd.AddCommand(NewListCmd(cfg, log))
+	cmd.AddCommand(NewDUCmd(cfg, log))
 	cmd.AddCommand(NewInfoCmd(cfg, log))
+	cmd.AddCommand(NewHistoryCmd(cfg, log))
+	cmd.AddCommand(NewIconsCmd(cfg, log))
+	cmd.AddCommand(NewDesktopCmd(cfg, log))
+	cmd.AddCommand(NewAutostartCmd(cfg, log))
+	cmd.AddCommand(NewRenameCmd(cfg, log))
+	cmd.AddCommand(NewPinCmd(cfg, log))
+	cmd.AddCommand(NewUnpinCmd(cfg, log))
 	cmd.AddCommand(NewDoctorCmd(cfg, log))
+	cmd.AddCommand(NewVerifyCmd(cfg, log))
+	cmd.AddCommand(NewRepairCmd(cfg, log))
+	cmd.AddCommand(NewMigrateDataCmd(cfg, log))
+	cmd.AddCommand(NewDBCmd(cfg, log))
+	cmd.AddCommand(NewRecoverCmd(cfg, log))
+	cmd.AddCommand(NewCleanTempCmd(cfg, log))
+	cmd.AddCommand(NewCacheCmd(cfg, log))
+	cmd.AddCommand(NewGCCmd(cfg, log))
+	cmd.AddCommand(NewRefreshCachesCmd(cfg, log))
+	cmd.AddCommand(NewStatusCmd(cfg, log))
 	cmd.AddCommand(NewCompletionCmd(cfg, log))
+	cmd.AddCommand(NewSelfUpdateCmd(cfg, log, version))
 	cmd.AddCommand(NewVersionCmd(version))
 
+	applyCommandAliases(cmd)
+
 	return cmd
 }
+
+// applyCommandAliases attaches the central alias and suggestion tables to the subcommands
+func applyCommandAliases(root *cobra.Command) {
+	for _, sub := range root.Commands() {
+		sub.Aliases = append(sub.Aliases, commandAliases[sub.Name()]...)
+		sub.SuggestFor = append(sub.SuggestFor, commandSuggestions[sub.Name()]...)
+	}
+}
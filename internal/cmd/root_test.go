@@ -19,3 +19,26 @@ func TestNewRootCmd(t *testing.T) {
 	assert.NotNil(t, cmd)
 	assert.Equal(t, "upkg", cmd.Use)
 }
+
+func TestNewRootCmd_Aliases(t *testing.T) {
+	t.Parallel()
+	logger := zerolog.New(io.Discard)
+
+	cmd := NewRootCmd(&config.Config{}, &logger, "1.0.0")
+
+	for alias, want := range map[string]string{"in": "install", "add": "install", "rm": "uninstall", "remove": "uninstall", "ls": "list"} {
+		found, _, err := cmd.Find([]string{alias})
+		assert.NoError(t, err)
+		assert.Equal(t, want, found.Name(), "alias %s", alias)
+	}
+}
+
+func TestNewRootCmd_Suggestions(t *testing.T) {
+	t.Parallel()
+	logger := zerolog.New(io.Discard)
+
+	cmd := NewRootCmd(&config.Config{}, &logger, "1.0.0")
+
+	assert.Contains(t, cmd.SuggestionsFor("instal"), "install")
+	assert.Contains(t, cmd.SuggestionsFor("delete"), "uninstall")
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// systemFlag is the global flag switching commands to system-wide installs
+const systemFlag = "system"
+
+// addSystemFlag registers --system on the root command
+func addSystemFlag(root *cobra.Command) {
+	root.PersistentFlags().Bool(systemFlag, false,
+		"work on system-wide installs in "+config.SystemDataDir+" and /usr/local (runs upkg with sudo)")
+}
+
+// setupScope applies --system. Without root privileges the whole command
+// line is run again through sudo and delegated is true: the command itself
+// has nothing left to do.
+func setupScope(cmd *cobra.Command, cfg *config.Config, runner helpers.CommandRunner, euid int) (delegated bool, err error) {
+	system, flagErr := cmd.Flags().GetBool(systemFlag)
+	if flagErr != nil || !system {
+		// Commands built outside the root command have no --system flag
+		return false, nil
+	}
+	if euid == 0 {
+		cfg.UseSystemScope()
+		return false, nil
+	}
+
+	if !runner.CommandExists("sudo") {
+		return false, fmt.Errorf("--system needs root privileges: run upkg as root or install sudo")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return false, fmt.Errorf("locate upkg executable: %w", err)
+	}
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	sudoArgs := []string{"--", exe}
+	if ui.NonInteractive() {
+		// Fail instead of asking for a password
+		sudoArgs = []string{"-n", "--", exe}
+	}
+	sudo := runner.PrepareCommand(ctx, "sudo", append(sudoArgs, os.Args[1:]...)...)
+	sudo.Stdin, sudo.Stdout, sudo.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Run = nil
+	cmd.RunE = func(*cobra.Command, []string) error { return nil }
+	err = sudo.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// The elevated upkg already reported its error; only pass its
+		// exit code on
+		cmd.Root().SilenceErrors = true
+		return true, withExitCode(exitErr.ExitCode(), fmt.Errorf("system-wide %s failed: %w", cmd.Name(), err))
+	}
+	if err != nil {
+		return true, fmt.Errorf("system-wide %s failed: %w", cmd.Name(), err)
+	}
+	return true, nil
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newScopeTestCmd(t *testing.T, args ...string) *cobra.Command {
+	t.Helper()
+	cmd := &cobra.Command{Use: "install", RunE: func(*cobra.Command, []string) error { return assert.AnError }}
+	addSystemFlag(cmd)
+	require.NoError(t, cmd.ParseFlags(args))
+	return cmd
+}
+
+func TestSetupScope(t *testing.T) {
+	t.Run("user scope by default", func(t *testing.T) {
+		cfg := &config.Config{}
+		delegated, err := setupScope(newScopeTestCmd(t), cfg, &helpers.MockCommandRunner{}, 1000)
+		require.NoError(t, err)
+		assert.False(t, delegated)
+		assert.False(t, cfg.IsSystemScope())
+	})
+
+	t.Run("root switches to the system scope", func(t *testing.T) {
+		cfg := &config.Config{}
+		delegated, err := setupScope(newScopeTestCmd(t, "--system"), cfg, &helpers.MockCommandRunner{}, 0)
+		require.NoError(t, err)
+		assert.False(t, delegated)
+		assert.True(t, cfg.IsSystemScope())
+		assert.Equal(t, "/opt/upkg/installed.db", cfg.Paths.DBFile)
+	})
+
+	t.Run("other users run again through sudo", func(t *testing.T) {
+		var ran []string
+		runner := &helpers.MockCommandRunner{
+			CommandExistsFunc: func(name string) bool { return name == "sudo" },
+			PrepareCommandFunc: func(ctx context.Context, name string, args ...string) *exec.Cmd {
+				ran = append([]string{name}, args...)
+				return exec.CommandContext(ctx, "true")
+			},
+		}
+		cfg := &config.Config{}
+		cmd := newScopeTestCmd(t, "--system")
+		delegated, err := setupScope(cmd, cfg, runner, 1000)
+		require.NoError(t, err)
+		assert.True(t, delegated)
+		assert.False(t, cfg.IsSystemScope(), "only the elevated process works on system installs")
+		assert.Equal(t, []string{"sudo", "--"}, ran[:2])
+		assert.NoError(t, cmd.RunE(cmd, nil), "the command itself does nothing more")
+	})
+
+	t.Run("the elevated exit code is kept", func(t *testing.T) {
+		runner := &helpers.MockCommandRunner{
+			CommandExistsFunc: func(name string) bool { return name == "sudo" },
+			PrepareCommandFunc: func(ctx context.Context, _ string, _ ...string) *exec.Cmd {
+				return exec.CommandContext(ctx, "sh", "-c", "exit 4")
+			},
+		}
+		cmd := newScopeTestCmd(t, "--system")
+		delegated, err := setupScope(cmd, &config.Config{}, runner, 1000)
+		assert.True(t, delegated)
+		require.Error(t, err)
+		assert.Equal(t, ExitVerificationFailed, ExitCode(err))
+		assert.True(t, cmd.SilenceErrors, "the elevated process already printed the error")
+	})
+
+	t.Run("without sudo", func(t *testing.T) {
+		_, err := setupScope(newScopeTestCmd(t, "--system"), &config.Config{}, &helpers.MockCommandRunner{}, 1000)
+		assert.ErrorContains(t, err, "root privileges")
+	})
+}
@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/assets"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/fetch"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/quantmind-br/upkg/internal/versions"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// Release channels of self-update
+const (
+	selfUpdateStable  = "stable"
+	selfUpdateNightly = "nightly"
+)
+
+// selfUpdateProbeTimeout bounds how long the downloaded binary may take to
+// print its version
+const selfUpdateProbeTimeout = 10 * time.Second
+
+// selfUpdateExtractors unpack the release archives upkg may be shipped in
+var selfUpdateExtractors = map[string]func(string, string, ...helpers.ExtractOption) error{
+	"tar.gz":  helpers.ExtractTarGz,
+	"tar.xz":  helpers.ExtractTarXz,
+	"tar.bz2": helpers.ExtractTarBz2,
+	"tar":     helpers.ExtractTar,
+	"zip":     helpers.ExtractZip,
+}
+
+// selfUpdateOptions holds the flags of the self-update command
+type selfUpdateOptions struct {
+	channel     string
+	check       bool
+	force       bool
+	timeoutSecs int
+
+	executable string // Binary to replace; empty resolves the running executable
+	events     *ui.EventWriter
+}
+
+// selfUpdateResult is the outcome of self-update in JSON output mode
+type selfUpdateResult struct {
+	Channel string `json:"channel"`
+	Current string `json:"current"`
+	Latest  string `json:"latest"`
+	Updated bool   `json:"updated"`
+}
+
+// NewSelfUpdateCmd creates the self-update command
+func NewSelfUpdateCmd(cfg *config.Config, log *zerolog.Logger, version string) *cobra.Command {
+	opts := &selfUpdateOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "self-update",
+		Short: "Update upkg to its latest release",
+		Long: `Replace the running upkg binary with the latest GitHub release.
+
+The release is taken from self_update.repository. On the stable channel it is
+the latest release and replaces upkg only when its version is newer; on the
+nightly channel it is the newest release, prereleases included, and replaces
+upkg whenever it differs from the running version. The asset matching this
+machine is downloaded, verified against the checksum published with the
+release (the GitHub digest or a checksums file) and swapped in atomically.
+Development builds are only replaced with --force.`,
+		Example: `  upkg self-update --check
+  upkg self-update
+  upkg self-update --channel nightly`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			opts.events = ui.EventWriterFromContext(cmd.Context())
+			return runSelfUpdateCmd(cmd.Context(), cfg, log, opts, version)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.channel, "channel", "", "release channel: stable or nightly (default: self_update.channel)")
+	cmd.Flags().BoolVar(&opts.check, "check", false, "only report whether an update is available")
+	cmd.Flags().BoolVar(&opts.force, "force", false, "install the release even when it is not newer or upkg is a development build")
+	cmd.Flags().IntVar(&opts.timeoutSecs, "timeout", 600, "timeout in seconds")
+
+	return cmd
+}
+
+//nolint:gocyclo // self-update resolves, verifies and swaps the binary, reporting each outcome.
+func runSelfUpdateCmd(ctx context.Context, cfg *config.Config, log *zerolog.Logger, opts *selfUpdateOptions, version string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(opts.timeoutSecs)*time.Second)
+	defer cancel()
+
+	channel := opts.channel
+	if channel == "" {
+		channel = cfg.SelfUpdate.Channel
+	}
+	if channel == "" {
+		channel = selfUpdateStable
+	}
+	if channel != selfUpdateStable && channel != selfUpdateNightly {
+		ui.PrintError("invalid channel %q (expected %s or %s)", channel, selfUpdateStable, selfUpdateNightly)
+		return fmt.Errorf("invalid channel %q", channel)
+	}
+
+	repository := cfg.SelfUpdate.Repository
+	if repository == "" {
+		repository = "quantmind-br/upkg"
+	}
+	spec, err := fetch.ParseGitHubSpec(fetch.GitHubPrefix + repository)
+	if err != nil {
+		ui.PrintError("invalid self_update.repository: %v", err)
+		return err
+	}
+
+	release, err := latestSelfRelease(ctx, newGitHubClient(cfg), spec, channel)
+	if err != nil {
+		ui.PrintError("failed to check for updates: %v", err)
+		return err
+	}
+	log.Info().Str("channel", channel).Str("current", version).Str("latest", release.TagName).Msg("checked for upkg release")
+
+	result := selfUpdateResult{Channel: channel, Current: version, Latest: release.TagName}
+	report := func() {
+		if opts.events != nil {
+			opts.events.Result("", result)
+		}
+	}
+
+	_, released := versions.Parse(version)
+	switch {
+	case opts.force:
+	case !released:
+		ui.PrintInfo("upkg %s is a development build; the %s release is %s (use --force to install it)", version, channel, release.TagName)
+		report()
+		return nil
+	case !selfUpdateNeeded(channel, release.TagName, version):
+		ui.PrintSuccess("upkg %s is up to date (%s channel)", version, channel)
+		report()
+		return nil
+	}
+	if opts.check {
+		ui.PrintInfo("upkg %s is available (installed: %s); run 'upkg self-update' to install it", release.TagName, version)
+		report()
+		return nil
+	}
+
+	exe := opts.executable
+	if exe == "" {
+		if exe, err = currentExecutable(); err != nil {
+			ui.PrintError("cannot locate the upkg binary: %v", err)
+			return err
+		}
+	}
+
+	chosen, err := assets.Select(release.AssetNames(), assets.CurrentPlatform(afero.NewOsFs()),
+		assets.Hints{Formats: []string{assets.FormatBinary, assets.FormatTarball}})
+	if err != nil {
+		ui.PrintError("release %s has no build for this machine: %v", release.TagName, err)
+		return err
+	}
+	asset, _ := release.Asset(chosen.Name)
+
+	downloader := fetch.NewDownloader(downloadCacheDir(cfg))
+	sum, err := publishedChecksum(ctx, downloader, release, asset)
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	downloaded, err := downloadPackage(ctx, cfg, log, asset.DownloadURL, sum, false)
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+
+	binary := downloaded
+	if archiveType := helpers.GetArchiveType(asset.Name); archiveType != "" {
+		dir, dirErr := helpers.CreateTempDir(afero.NewOsFs(), "upkg", "self-update")
+		if dirErr != nil {
+			ui.PrintError("%v", dirErr)
+			return dirErr
+		}
+		defer func() { _ = os.RemoveAll(dir) }()
+
+		if binary, err = extractSelfBinary(downloaded, archiveType, dir); err != nil {
+			ui.PrintError("%v", err)
+			return err
+		}
+	}
+
+	if err := replaceExecutable(ctx, exe, binary); err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	log.Info().Str("executable", exe).Str("version", release.TagName).Msg("upkg updated")
+	ui.PrintSuccess("upkg updated to %s", release.TagName)
+	result.Updated = true
+	report()
+	return nil
+}
+
+// latestSelfRelease returns the release channel points at: the latest
+// release on stable, the newest non-draft release on nightly
+func latestSelfRelease(ctx context.Context, client *fetch.GitHubClient, spec fetch.GitHubSpec, channel string) (*fetch.GitHubRelease, error) {
+	if channel == selfUpdateStable {
+		return client.Release(ctx, spec)
+	}
+
+	releases, err := client.Releases(ctx, spec, 10)
+	if err != nil {
+		return nil, err
+	}
+	for i := range releases {
+		if !releases[i].Draft {
+			return &releases[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no releases published in %s", spec.Repository())
+}
+
+// selfUpdateNeeded reports whether the release tag should replace the
+// running version: a newer version on stable, any other build on nightly
+func selfUpdateNeeded(channel, tag, current string) bool {
+	if channel == selfUpdateNightly {
+		return strings.TrimPrefix(tag, "v") != strings.TrimPrefix(current, "v")
+	}
+	return versions.Newer(tag, current)
+}
+
+// publishedChecksum returns the SHA256 of asset: the digest GitHub computed,
+// else the entry of a checksums file of the release. Releases without either
+// are refused, since the binary could not be verified.
+func publishedChecksum(ctx context.Context, downloader *fetch.Downloader, release *fetch.GitHubRelease, asset fetch.GitHubAsset) (string, error) {
+	if sum := asset.SHA256(); sum != "" {
+		return sum, nil
+	}
+
+	for _, candidate := range release.Assets {
+		name := strings.ToLower(candidate.Name)
+		ownFile := candidate.Name == asset.Name+".sha256"
+		if !ownFile && !strings.HasSuffix(name, "checksums.txt") && !strings.HasPrefix(name, "sha256sums") {
+			continue
+		}
+
+		path, err := downloader.Fetch(ctx, candidate.DownloadURL, fetch.Options{})
+		if err != nil {
+			return "", fmt.Errorf("download %s: %w", candidate.Name, err)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read %s: %w", candidate.Name, err)
+		}
+
+		sum := fetch.ChecksumFor(string(content), asset.Name)
+		if fields := strings.Fields(string(content)); sum == "" && ownFile && len(fields) == 1 {
+			sum = strings.ToLower(fields[0])
+		}
+		if sum != "" {
+			return sum, nil
+		}
+	}
+	return "", withExitCode(ExitVerificationFailed, fmt.Errorf("release %s publishes no checksum for %s; refusing to install an unverified binary", release.TagName, asset.Name))
+}
+
+// extractSelfBinary unpacks a release archive into dir and returns the path
+// of the upkg executable inside it
+func extractSelfBinary(archivePath, archiveType, dir string) (string, error) {
+	extract, ok := selfUpdateExtractors[archiveType]
+	if !ok {
+		return "", fmt.Errorf("unsupported release archive: %s", archiveType)
+	}
+	if err := extract(archivePath, dir); err != nil {
+		return "", fmt.Errorf("extract %s: %w", filepath.Base(archivePath), err)
+	}
+
+	var binary string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type().IsRegular() && entry.Name() == "upkg" {
+			binary = path
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("search %s: %w", filepath.Base(archivePath), err)
+	}
+	if binary == "" {
+		return "", fmt.Errorf("no upkg binary in %s", filepath.Base(archivePath))
+	}
+	return binary, nil
+}
+
+// currentExecutable returns the resolved path of the running binary
+func currentExecutable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+// replaceExecutable swaps exe for binary once the copy proves to run. The
+// copy is written next to exe and renamed over it, so exe is never left
+// half-written; it keeps the permissions of exe.
+func replaceExecutable(ctx context.Context, exe, binary string) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", exe, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".upkg-update-*")
+	if err != nil {
+		return fmt.Errorf("cannot write to %s (run with sudo, or update upkg with the tool that installed it): %w", filepath.Dir(exe), err)
+	}
+	tmpPath := tmp.Name()
+	defer func() { _ = os.Remove(tmpPath) }()
+
+	src, err := os.Open(binary)
+	if err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("open %s: %w", binary, err)
+	}
+	_, err = io.Copy(tmp, src)
+	_ = src.Close()
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("write %s: %w", tmpPath, err)
+	}
+	if err := os.Chmod(tmpPath, info.Mode().Perm()|0o111); err != nil {
+		return fmt.Errorf("chmod %s: %w", tmpPath, err)
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, selfUpdateProbeTimeout)
+	defer cancel()
+	stdout, _, err := helpers.NewOSCommandRunner().RunCommandWithOutput(probeCtx, tmpPath, "version")
+	if err != nil {
+		return fmt.Errorf("downloaded binary does not run on this machine: %w", err)
+	}
+	if !strings.Contains(stdout, "upkg version") {
+		return fmt.Errorf("downloaded binary is not upkg: %q", strings.TrimSpace(stdout))
+	}
+
+	if err := os.Rename(tmpPath, exe); err != nil {
+		return fmt.Errorf("replace %s: %w", exe, err)
+	}
+	return nil
+}
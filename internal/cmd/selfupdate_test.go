@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUpkg is a stand-in binary printing the given version
+func fakeUpkg(version string) []byte {
+	return []byte("#!/bin/sh\necho upkg version " + version + "\n")
+}
+
+// selfUpdateServer serves a GitHub API with a stable release and a nightly
+// prerelease; files maps asset names to their content
+type selfUpdateServer struct {
+	stable  string
+	nightly string
+	files   map[string][]byte
+}
+
+func (s *selfUpdateServer) start(t *testing.T) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	release := func(tag string, prerelease bool) string {
+		var assetsJSON []string
+		for name := range s.files {
+			assetsJSON = append(assetsJSON, fmt.Sprintf(`{"name":%q,"browser_download_url":%q}`, name, server.URL+"/download/"+name))
+		}
+		return fmt.Sprintf(`{"tag_name":%q,"prerelease":%t,"assets":[%s]}`, tag, prerelease, strings.Join(assetsJSON, ","))
+	}
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/quantmind-br/upkg/releases/latest":
+			_, _ = io.WriteString(w, release(s.stable, false))
+		case "/repos/quantmind-br/upkg/releases":
+			_, _ = io.WriteString(w, "["+release(s.nightly, true)+","+release(s.stable, false)+"]")
+		default:
+			if content, ok := s.files[filepath.Base(r.URL.Path)]; ok {
+				_, _ = w.Write(content)
+				return
+			}
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// setupSelfUpdate returns a config pointing at server and an installed fake
+// upkg binary
+func setupSelfUpdate(t *testing.T, server *httptest.Server) (*config.Config, string) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	cfg := &config.Config{
+		Paths:      config.PathsConfig{CacheDir: filepath.Join(tmpDir, "cache")},
+		Sources:    config.SourcesConfig{GitHubAPIURL: server.URL},
+		SelfUpdate: config.SelfUpdateConfig{Channel: selfUpdateStable, Repository: "quantmind-br/upkg"},
+	}
+	exe := filepath.Join(tmpDir, "bin", "upkg")
+	require.NoError(t, os.MkdirAll(filepath.Dir(exe), 0755))
+	require.NoError(t, os.WriteFile(exe, fakeUpkg("v1.0.0"), 0755))
+	return cfg, exe
+}
+
+func TestSelfUpdateNeeded(t *testing.T) {
+	assert.True(t, selfUpdateNeeded(selfUpdateStable, "v1.1.0", "v1.0.0"))
+	assert.False(t, selfUpdateNeeded(selfUpdateStable, "v1.0.0", "1.0.0"))
+	assert.False(t, selfUpdateNeeded(selfUpdateStable, "v0.9.0", "v1.0.0"))
+	assert.True(t, selfUpdateNeeded(selfUpdateNightly, "v1.1.0-nightly.20261014", "v1.1.0-nightly.20261013"))
+	assert.False(t, selfUpdateNeeded(selfUpdateNightly, "v1.1.0-nightly.1", "1.1.0-nightly.1"))
+}
+
+func TestRunSelfUpdateCmd(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	assetName := "upkg_linux_" + runtime.GOARCH
+	newBinary := fakeUpkg("v1.1.0")
+
+	t.Run("replaces the binary with a verified release", func(t *testing.T) {
+		server := (&selfUpdateServer{stable: "v1.1.0", nightly: "v1.2.0-rc.1", files: map[string][]byte{
+			assetName:       newBinary,
+			"checksums.txt": []byte(sha256Hex(newBinary) + "  " + assetName + "\n"),
+		}}).start(t)
+		cfg, exe := setupSelfUpdate(t, server)
+
+		opts := &selfUpdateOptions{timeoutSecs: 30, executable: exe}
+		require.NoError(t, runSelfUpdateCmd(context.Background(), cfg, &logger, opts, "v1.0.0"))
+
+		content, err := os.ReadFile(exe)
+		require.NoError(t, err)
+		assert.Equal(t, newBinary, content)
+		leftovers, err := filepath.Glob(filepath.Join(filepath.Dir(exe), ".upkg-update-*"))
+		require.NoError(t, err)
+		assert.Empty(t, leftovers)
+	})
+
+	t.Run("extracts the binary from a tarball", func(t *testing.T) {
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gw)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "upkg_1.1.0/upkg", Mode: 0755, Size: int64(len(newBinary)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write(newBinary)
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+		require.NoError(t, gw.Close())
+
+		archive := "upkg_1.1.0_linux_" + runtime.GOARCH + ".tar.gz"
+		server := (&selfUpdateServer{stable: "v1.1.0", files: map[string][]byte{
+			archive:             buf.Bytes(),
+			archive + ".sha256": []byte(sha256Hex(buf.Bytes()) + "\n"),
+		}}).start(t)
+		cfg, exe := setupSelfUpdate(t, server)
+
+		opts := &selfUpdateOptions{timeoutSecs: 30, executable: exe}
+		require.NoError(t, runSelfUpdateCmd(context.Background(), cfg, &logger, opts, "v1.0.0"))
+
+		content, err := os.ReadFile(exe)
+		require.NoError(t, err)
+		assert.Equal(t, newBinary, content)
+	})
+
+	t.Run("nightly channel takes prereleases", func(t *testing.T) {
+		nightly := fakeUpkg("v1.2.0-rc.1")
+		server := (&selfUpdateServer{stable: "v1.1.0", nightly: "v1.2.0-rc.1", files: map[string][]byte{
+			assetName:       nightly,
+			"checksums.txt": []byte(sha256Hex(nightly) + "  " + assetName + "\n"),
+		}}).start(t)
+		cfg, exe := setupSelfUpdate(t, server)
+
+		opts := &selfUpdateOptions{channel: selfUpdateNightly, timeoutSecs: 30, executable: exe}
+		require.NoError(t, runSelfUpdateCmd(context.Background(), cfg, &logger, opts, "v1.1.0"))
+
+		content, err := os.ReadFile(exe)
+		require.NoError(t, err)
+		assert.Equal(t, nightly, content)
+	})
+
+	t.Run("leaves the binary alone", func(t *testing.T) {
+		server := (&selfUpdateServer{stable: "v1.1.0", files: map[string][]byte{
+			assetName:       newBinary,
+			"checksums.txt": []byte(sha256Hex(newBinary) + "  " + assetName + "\n"),
+		}}).start(t)
+
+		for name, tc := range map[string]struct {
+			version string
+			opts    selfUpdateOptions
+		}{
+			"up to date":        {version: "v1.1.0"},
+			"check only":        {version: "v1.0.0", opts: selfUpdateOptions{check: true}},
+			"development build": {version: "dev"},
+		} {
+			cfg, exe := setupSelfUpdate(t, server)
+			opts := tc.opts
+			opts.timeoutSecs, opts.executable = 30, exe
+			require.NoError(t, runSelfUpdateCmd(context.Background(), cfg, &logger, &opts, tc.version), name)
+
+			content, err := os.ReadFile(exe)
+			require.NoError(t, err)
+			assert.Equal(t, fakeUpkg("v1.0.0"), content, name)
+		}
+	})
+
+	t.Run("refuses unverified or mismatching binaries", func(t *testing.T) {
+		for name, files := range map[string]map[string][]byte{
+			"no checksum":       {assetName: newBinary},
+			"checksum mismatch": {assetName: newBinary, "checksums.txt": []byte(sha256Hex([]byte("other")) + "  " + assetName + "\n")},
+		} {
+			server := (&selfUpdateServer{stable: "v1.1.0", files: files}).start(t)
+			cfg, exe := setupSelfUpdate(t, server)
+
+			opts := &selfUpdateOptions{timeoutSecs: 30, executable: exe}
+			assert.Error(t, runSelfUpdateCmd(context.Background(), cfg, &logger, opts, "v1.0.0"), name)
+
+			content, err := os.ReadFile(exe)
+			require.NoError(t, err)
+			assert.Equal(t, fakeUpkg("v1.0.0"), content, name)
+		}
+	})
+
+	t.Run("rejects unknown channel", func(t *testing.T) {
+		cfg := &config.Config{}
+		opts := &selfUpdateOptions{channel: "beta", timeoutSecs: 30}
+		assert.ErrorContains(t, runSelfUpdateCmd(context.Background(), cfg, &logger, opts, "v1.0.0"), "invalid channel")
+	})
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/quantmind-br/upkg/internal/versions"
+	"github.com/rs/zerolog"
+)
+
+// appVersionProbeTimeout bounds how long a self-updating app may take to
+// answer --version
+const appVersionProbeTimeout = 5 * time.Second
+
+// selfUpdatingSupported reports whether upkg owns the files of record, which a
+// self-updating app would then rewrite. Pacman, dpkg, dnf and flatpak manage their own.
+func selfUpdatingSupported(record *core.InstallRecord) bool {
+	return !core.IsSystemManaged(record.Metadata.InstallMethod) &&
+		record.PackageType != core.PackageTypeFlatpak
+}
+
+// appExecutable returns the program to probe for a record's app-managed
+// version: the wrapper script when there is one, else a file install path
+func appExecutable(record *core.InstallRecord) string {
+	if record.Metadata.WrapperScript != "" {
+		return record.Metadata.WrapperScript
+	}
+	switch record.PackageType {
+	case core.PackageTypeBinary, core.PackageTypeAppImage:
+		return record.InstallPath
+	}
+	return ""
+}
+
+// probeAppVersion runs the record's executable with --version and returns the
+// version it reports, or "" when it cannot be determined
+func probeAppVersion(ctx context.Context, runner helpers.CommandRunner, record *core.InstallRecord) string {
+	exe := appExecutable(record)
+	if exe == "" {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, appVersionProbeTimeout)
+	defer cancel()
+
+	stdout, stderr, err := runner.RunCommandWithOutput(ctx, exe, "--version")
+	if err != nil && stdout == "" {
+		return ""
+	}
+	if version := versions.Extract(stdout); version != "" {
+		return version
+	}
+	return versions.Extract(stderr)
+}
+
+// markSelfUpdating flags record as self-updating and records the version its
+// binary reports. Installs whose files upkg does not own are left unchanged.
+func markSelfUpdating(ctx context.Context, runner helpers.CommandRunner, record *core.InstallRecord, log *zerolog.Logger) {
+	if !selfUpdatingSupported(record) {
+		ui.PrintWarning("--self-updating ignored: %s is managed by its own package manager", record.Name)
+		return
+	}
+	record.Metadata.SelfUpdating = true
+	record.Metadata.AppVersion = probeAppVersion(ctx, runner, record)
+	log.Debug().
+		Str("name", record.Name).
+		Str("app_version", record.Metadata.AppVersion).
+		Msg("marked package as self-updating")
+}
+
+// carrySelfUpdating keeps the self-updating mode across an upgrade and returns
+// a warning when the new package is older than the version the app had
+// already updated itself to
+func carrySelfUpdating(ctx context.Context, runner helpers.CommandRunner, oldRecord, newRecord *core.InstallRecord) string {
+	if !oldRecord.Metadata.SelfUpdating {
+		return ""
+	}
+	newRecord.Metadata.SelfUpdating = true
+	newRecord.Metadata.AppVersion = probeAppVersion(ctx, runner, newRecord)
+
+	installed := newRecord.Metadata.AppVersion
+	if installed == "" {
+		installed = versions.Extract(newRecord.Version)
+	}
+	previous := oldRecord.Metadata.AppVersion
+	if previous == "" || installed == "" || !versions.Newer(previous, installed) {
+		return ""
+	}
+	return fmt.Sprintf("%s had updated itself to %s; the upgrade installed the older %s", newRecord.Name, previous, installed)
+}
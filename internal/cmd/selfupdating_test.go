@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func versionRunner(stdout, stderr string, err error) *helpers.MockCommandRunner {
+	return &helpers.MockCommandRunner{
+		RunCommandWithOutputFunc: func(_ context.Context, _ string, args ...string) (string, string, error) {
+			if len(args) != 1 || args[0] != "--version" {
+				return "", "", errors.New("unexpected arguments")
+			}
+			return stdout, stderr, err
+		},
+	}
+}
+
+func TestProbeAppVersion(t *testing.T) {
+	t.Parallel()
+
+	wrapped := &core.InstallRecord{
+		PackageType: core.PackageTypeTarball,
+		InstallPath: "/apps/nvim",
+		Metadata:    core.Metadata{WrapperScript: "/bin/nvim"},
+	}
+	assert.Equal(t, "0.10.2", probeAppVersion(context.Background(), versionRunner("NVIM v0.10.2\n", "", nil), wrapped))
+	assert.Equal(t, "1.2.0", probeAppVersion(context.Background(), versionRunner("", "tool 1.2.0", nil), wrapped),
+		"falls back to stderr")
+	assert.Empty(t, probeAppVersion(context.Background(), versionRunner("", "", errors.New("exit 1")), wrapped))
+
+	unwrapped := &core.InstallRecord{PackageType: core.PackageTypeTarball, InstallPath: "/apps/nvim"}
+	assert.Empty(t, probeAppVersion(context.Background(), versionRunner("1.0.0", "", nil), unwrapped),
+		"a directory install has nothing to run")
+
+	appImage := &core.InstallRecord{PackageType: core.PackageTypeAppImage, InstallPath: "/apps/tool.AppImage"}
+	assert.Equal(t, "1.0.0", probeAppVersion(context.Background(), versionRunner("1.0.0", "", nil), appImage))
+}
+
+func TestMarkSelfUpdating(t *testing.T) {
+	t.Parallel()
+
+	log := zerolog.Nop()
+	record := &core.InstallRecord{PackageType: core.PackageTypeBinary, InstallPath: "/bin/tool"}
+	markSelfUpdating(context.Background(), versionRunner("tool 3.1.4", "", nil), record, &log)
+	assert.True(t, record.Metadata.SelfUpdating)
+	assert.Equal(t, "3.1.4", record.Metadata.AppVersion)
+
+	pacman := &core.InstallRecord{PackageType: core.PackageTypeDeb, Metadata: core.Metadata{InstallMethod: core.InstallMethodPacman}}
+	markSelfUpdating(context.Background(), versionRunner("1.0.0", "", nil), pacman, &log)
+	assert.False(t, pacman.Metadata.SelfUpdating, "pacman owns the files")
+}
+
+func TestCarrySelfUpdating(t *testing.T) {
+	t.Parallel()
+
+	oldRecord := &core.InstallRecord{
+		Name:     "nvim",
+		Metadata: core.Metadata{SelfUpdating: true, AppVersion: "0.11.0"},
+	}
+	newRecord := &core.InstallRecord{
+		Name:        "nvim",
+		PackageType: core.PackageTypeBinary,
+		InstallPath: "/bin/nvim",
+	}
+
+	warning := carrySelfUpdating(context.Background(), versionRunner("NVIM v0.10.2", "", nil), oldRecord, newRecord)
+	assert.True(t, newRecord.Metadata.SelfUpdating)
+	assert.Equal(t, "0.10.2", newRecord.Metadata.AppVersion)
+	assert.Contains(t, warning, "older 0.10.2")
+
+	warning = carrySelfUpdating(context.Background(), versionRunner("NVIM v0.12.0", "", nil), oldRecord, newRecord)
+	assert.Empty(t, warning)
+
+	plain := &core.InstallRecord{Name: "plain"}
+	fresh := &core.InstallRecord{Name: "plain"}
+	assert.Empty(t, carrySelfUpdating(context.Background(), versionRunner("1.0.0", "", nil), plain, fresh))
+	assert.False(t, fresh.Metadata.SelfUpdating)
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"sync"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// skipSetupAnnotation marks commands that run without loading config or logging
+const skipSetupAnnotation = "upkg:skip-setup"
+
+// Loader loads the configuration and builds the logger for it
+type Loader func() (*config.Config, *zerolog.Logger, error)
+
+// Runtime holds the config and logger shared by all commands. They start as
+// zero values and are filled in place by Ensure, so commands built before
+// loading see the loaded values through the same pointers.
+type Runtime struct {
+	cfg  *config.Config
+	log  *zerolog.Logger
+	load Loader
+	once sync.Once
+	err  error
+}
+
+// NewRuntime creates a runtime that calls load on first use
+func NewRuntime(load Loader) *Runtime {
+	log := zerolog.Nop()
+	return &Runtime{cfg: &config.Config{}, log: &log, load: load}
+}
+
+// Ensure loads the config and logger once
+func (r *Runtime) Ensure() error {
+	r.once.Do(func() {
+		cfg, log, err := r.load()
+		if err != nil {
+			r.err = err
+			return
+		}
+		*r.cfg = *cfg
+		*r.log = *log
+	})
+	return r.err
+}
+
+// Logger returns the shared logger (a no-op logger until Ensure succeeds)
+func (r *Runtime) Logger() *zerolog.Logger {
+	return r.log
+}
+
+// NewLazyRootCmd creates the root command with config loading and logging
+// deferred until a command that needs them runs. Help, version, completion
+// script generation and shell completion requests start without them.
+func NewLazyRootCmd(rt *Runtime, version string) *cobra.Command {
+	root := NewRootCmd(rt.cfg, rt.log, version)
+	preRun := root.PersistentPreRunE
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if skipsSetup(cmd) {
+			return nil
+		}
+		if err := rt.Ensure(); err != nil {
+			return err
+		}
+		return preRun(cmd, args)
+	}
+	return root
+}
+
+// skipsSetup reports whether cmd can run without config and logging
+func skipsSetup(cmd *cobra.Command) bool {
+	switch cmd.Name() {
+	case "help", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+	_, ok := cmd.Annotations[skipSetupAnnotation]
+	return ok
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLazyRootCmd_SkipsSetup(t *testing.T) {
+	t.Parallel()
+
+	for _, args := range [][]string{
+		{"version"},
+		{"--version"},
+		{"--help"},
+		{"help", "install"},
+		{"completion", "bash"},
+		{cobra.ShellCompRequestCmd, ""},
+	} {
+		calls := 0
+		rt := NewRuntime(func() (*config.Config, *zerolog.Logger, error) {
+			calls++
+			return nil, nil, errors.New("should not load")
+		})
+		root := NewLazyRootCmd(rt, "1.0.0")
+		root.SetOut(io.Discard)
+		root.SetErr(io.Discard)
+		root.SetArgs(args)
+
+		assert.NoError(t, root.Execute(), "args %v", args)
+		assert.Zero(t, calls, "args %v", args)
+	}
+}
+
+func TestNewLazyRootCmd_LoadsOnce(t *testing.T) {
+	t.Parallel()
+
+	dbFile := filepath.Join(t.TempDir(), "upkg.db")
+	calls := 0
+	rt := NewRuntime(func() (*config.Config, *zerolog.Logger, error) {
+		calls++
+		logger := zerolog.New(io.Discard)
+		return &config.Config{Paths: config.PathsConfig{DBFile: dbFile}}, &logger, nil
+	})
+
+	for range 2 {
+		root := NewLazyRootCmd(rt, "1.0.0")
+		root.SetArgs([]string{"list"})
+		require.NoError(t, root.Execute())
+	}
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, dbFile, rt.cfg.Paths.DBFile)
+}
+
+func TestNewLazyRootCmd_LoadError(t *testing.T) {
+	t.Parallel()
+
+	rt := NewRuntime(func() (*config.Config, *zerolog.Logger, error) {
+		return nil, nil, errors.New("bad config")
+	})
+	root := NewLazyRootCmd(rt, "1.0.0")
+	root.SetOut(io.Discard)
+	root.SetErr(io.Discard)
+	root.SetArgs([]string{"list"})
+
+	err := root.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad config")
+	assert.NotNil(t, rt.Logger())
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/quantmind-br/upkg/internal/assets"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/fetch"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+)
+
+// githubSource is a release asset resolved from a gh:owner/repo[@tag] spec
+type githubSource struct {
+	spec    fetch.GitHubSpec
+	release *fetch.GitHubRelease
+	asset   fetch.GitHubAsset
+	// Repository description, for desktop entries of packages without one
+	description string
+}
+
+// resolveGitHubSource picks the release asset best suited to this machine
+func resolveGitHubSource(ctx context.Context, cfg *config.Config, log *zerolog.Logger, rawSpec string) (*githubSource, error) {
+	spec, err := fetch.ParseGitHubSpec(rawSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	client := newGitHubClient(cfg)
+
+	color.Cyan("→ Resolving %s...", spec)
+	release, err := client.Release(ctx, spec)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", spec, err)
+	}
+
+	asset, platform, err := selectGitHubAsset(release, spec, assets.Hints{Formats: cfg.Sources.FormatPreference})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("repo", spec.Repository()).
+		Str("tag", release.TagName).
+		Str("asset", asset.Name).
+		Str("arch", platform.Arch).
+		Str("libc", platform.Libc).
+		Msg("selected release asset")
+	color.Green("✓ Selected %s from release %s", asset.Name, release.TagName)
+
+	source := &githubSource{spec: spec, release: release, asset: asset}
+	if cfg.Desktop.DescriptionFields {
+		// Best effort: the description only improves the desktop entry
+		if repo, repoErr := client.Repository(ctx, spec); repoErr == nil {
+			source.description = repo.Description
+		} else {
+			log.Debug().Err(repoErr).Str("repo", spec.Repository()).Msg("failed to fetch repository description")
+		}
+	}
+	return source, nil
+}
+
+// newGitHubClient creates a client from the sources config, falling back to GITHUB_TOKEN
+func newGitHubClient(cfg *config.Config) *fetch.GitHubClient {
+	token := cfg.Sources.GitHubToken
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	client := fetch.NewGitHubClient(token)
+	if cfg.Sources.GitHubAPIURL != "" {
+		client.BaseURL = cfg.Sources.GitHubAPIURL
+	}
+	return client
+}
+
+// selectGitHubAsset picks the release asset that runs on this machine
+func selectGitHubAsset(release *fetch.GitHubRelease, spec fetch.GitHubSpec, hints assets.Hints) (fetch.GitHubAsset, assets.Platform, error) {
+	platform := assets.CurrentPlatform(afero.NewOsFs())
+	chosen, err := assets.Select(release.AssetNames(), platform, hints)
+	if err != nil {
+		return fetch.GitHubAsset{}, platform, fmt.Errorf("release %s of %s: %w", release.TagName, spec.Repository(), err)
+	}
+	asset, _ := release.Asset(chosen.Name)
+	return asset, platform, nil
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReleaseServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/app/releases/latest" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestResolveGitHubSource(t *testing.T) {
+	t.Parallel()
+
+	server := newReleaseServer(t, `{"tag_name":"v1.4.0","assets":[
+		{"name":"app-1.4.0.tar.gz.sha256","browser_download_url":"https://dl/sum"},
+		{"name":"app-1.4.0-windows.zip","browser_download_url":"https://dl/win"},
+		{"name":"app_1.4.0.deb","browser_download_url":"https://dl/deb"},
+		{"name":"app-1.4.0.AppImage","browser_download_url":"https://dl/appimage","digest":"sha256:aa"}
+	]}`)
+
+	cfg := &config.Config{Sources: config.SourcesConfig{GitHubAPIURL: server.URL}}
+	log := zerolog.New(io.Discard)
+
+	source, err := resolveGitHubSource(context.Background(), cfg, &log, "gh:owner/app")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.4.0", source.release.TagName)
+	assert.Equal(t, "app-1.4.0.AppImage", source.asset.Name)
+	assert.Equal(t, "aa", source.asset.SHA256())
+
+	cfg.Sources.FormatPreference = []string{"deb"}
+	source, err = resolveGitHubSource(context.Background(), cfg, &log, "gh:owner/app")
+	require.NoError(t, err)
+	assert.Equal(t, "https://dl/deb", source.asset.DownloadURL)
+}
+
+func TestResolveGitHubSource_NoCompatibleAsset(t *testing.T) {
+	t.Parallel()
+
+	server := newReleaseServer(t, `{"tag_name":"v1.0.0","assets":[{"name":"app-macos.dmg"},{"name":"app-windows.exe"}]}`)
+
+	cfg := &config.Config{Sources: config.SourcesConfig{GitHubAPIURL: server.URL}}
+	log := zerolog.New(io.Discard)
+
+	_, err := resolveGitHubSource(context.Background(), cfg, &log, "gh:owner/app")
+	assert.ErrorContains(t, err, "no compatible release asset")
+
+	_, err = resolveGitHubSource(context.Background(), cfg, &log, "gh:owner")
+	assert.ErrorContains(t, err, "invalid GitHub spec")
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/status"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// statusOptions holds the flags of the status command
+type statusOptions struct {
+	jsonOutput bool
+	waybar     bool
+}
+
+// waybarOutput is the JSON object a waybar custom module expects
+type waybarOutput struct {
+	Text    string `json:"text"`
+	Tooltip string `json:"tooltip"`
+	Class   string `json:"class"`
+	Alt     string `json:"alt"`
+}
+
+// NewStatusCmd creates the status command
+func NewStatusCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &statusOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show what upkg is doing, for status bars and scripts",
+		Long: `Show the state of the current or last install, upgrade or uninstall.
+
+Running operations keep a status file in the data directory with the
+active packages, the queue depth of batch installs and the error count.
+Reading it does not touch the database, so it is cheap enough to poll.
+
+Use --waybar in a waybar custom module ("return-type": "json"); polybar
+and other bars can read the --json output or the status file directly.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runStatusCmd(cmd.OutOrStdout(), afero.NewOsFs(), cfg, opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "print the status snapshot as JSON")
+	cmd.Flags().BoolVar(&opts.waybar, "waybar", false, "print a waybar custom module object")
+
+	return cmd
+}
+
+func runStatusCmd(out io.Writer, fs afero.Fs, cfg *config.Config, opts *statusOptions) error {
+	snap, err := status.Read(fs, paths.NewResolver(cfg).GetStatusFile())
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+
+	switch {
+	case opts.waybar:
+		return json.NewEncoder(out).Encode(waybarStatus(snap))
+	case opts.jsonOutput:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(snap)
+	}
+
+	_, _ = fmt.Fprintln(out, statusSummary(snap))
+	if len(snap.Active) > 0 {
+		_, _ = fmt.Fprintf(out, "Active:        %s\n", strings.Join(snap.Active, ", "))
+	}
+	if snap.State == status.StateRunning {
+		_, _ = fmt.Fprintf(out, "Queued:        %d\n", snap.QueueDepth)
+	}
+	if snap.Operation != "" {
+		_, _ = fmt.Fprintf(out, "Completed:     %d\n", snap.Completed)
+		_, _ = fmt.Fprintf(out, "Errors:        %d\n", snap.Errors)
+	}
+	if snap.LastError != "" {
+		_, _ = fmt.Fprintf(out, "Last error:    %s\n", snap.LastError)
+	}
+	if !snap.LastActivity.IsZero() {
+		_, _ = fmt.Fprintf(out, "Last activity: %s (%s ago)\n",
+			snap.LastActivity.Format(time.DateTime), time.Since(snap.LastActivity).Round(time.Second))
+	}
+	return nil
+}
+
+// statusSummary is the one-line description of a snapshot
+func statusSummary(snap *status.Snapshot) string {
+	switch snap.State {
+	case status.StateRunning:
+		return fmt.Sprintf("upkg is running %s (%d of %d done)", snap.Operation, snap.Completed+snap.Errors, statusTotal(snap))
+	case status.StateInterrupted:
+		return fmt.Sprintf("upkg %s was interrupted; run 'upkg recover' to clean up", snap.Operation)
+	case status.StateIdle:
+		if snap.Operation != "" {
+			return fmt.Sprintf("upkg is idle (last %s: %d done, %d failed)", snap.Operation, snap.Completed, snap.Errors)
+		}
+	}
+	return "upkg is idle"
+}
+
+// waybarStatus maps a snapshot to a waybar module; the text is empty when
+// there is nothing to report so the module hides itself
+func waybarStatus(snap *status.Snapshot) waybarOutput {
+	output := waybarOutput{Tooltip: statusSummary(snap), Class: snap.State, Alt: snap.State}
+	switch {
+	case snap.State == status.StateRunning:
+		output.Text = fmt.Sprintf("%s %d/%d", snap.Operation, snap.Completed+snap.Errors, statusTotal(snap))
+	case snap.State == status.StateInterrupted:
+		output.Text = "interrupted"
+	case snap.Errors > 0:
+		output.Text = fmt.Sprintf("%d failed", snap.Errors)
+		output.Class = "error"
+	}
+	if snap.LastError != "" {
+		output.Tooltip += "\n" + snap.LastError
+	}
+	return output
+}
+
+func statusTotal(snap *status.Snapshot) int {
+	return snap.Completed + snap.Errors + len(snap.Active) + snap.QueueDepth
+}
+
+// startStatus publishes a running operation with queued targets in the
+// status file; the returned tracker is nil when the file cannot be written
+func startStatus(statusFile string, log *zerolog.Logger, operation string, queued int) *status.Tracker {
+	tracker, err := status.Start(afero.NewOsFs(), statusFile, operation, queued)
+	if err != nil {
+		log.Debug().Err(err).Msg("status file unavailable")
+		return nil
+	}
+	return tracker
+}
+
+// trackStatus runs a single-target operation behind the status file
+func trackStatus(cfg *config.Config, log *zerolog.Logger, operation, target string, fn func() error) error {
+	tracker := startStatus(paths.NewResolver(cfg).GetStatusFile(), log, operation, 1)
+	defer tracker.Finish()
+
+	tracker.Begin(target)
+	err := fn()
+	tracker.Done(target, err)
+	return err
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/status"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunStatusCmd(t *testing.T) {
+	dataDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{DataDir: dataDir}}
+	log := zerolog.Nop()
+	fs := afero.NewOsFs()
+
+	var out bytes.Buffer
+	require.NoError(t, runStatusCmd(&out, fs, cfg, &statusOptions{}))
+	assert.Contains(t, out.String(), "upkg is idle")
+
+	tracker := startStatus(filepath.Join(dataDir, "status.json"), &log, "install", 2)
+	require.NotNil(t, tracker)
+	tracker.Begin("tool.tar.gz")
+
+	out.Reset()
+	require.NoError(t, runStatusCmd(&out, fs, cfg, &statusOptions{}))
+	assert.Contains(t, out.String(), "upkg is running install (0 of 2 done)")
+	assert.Contains(t, out.String(), "Active:        tool.tar.gz")
+	assert.Contains(t, out.String(), "Queued:        1")
+
+	out.Reset()
+	require.NoError(t, runStatusCmd(&out, fs, cfg, &statusOptions{waybar: true}))
+	var module waybarOutput
+	require.NoError(t, json.Unmarshal(out.Bytes(), &module))
+	assert.Equal(t, "install 0/2", module.Text)
+	assert.Equal(t, status.StateRunning, module.Class)
+
+	tracker.Done("tool.tar.gz", errors.New("boom"))
+	tracker.Finish()
+
+	out.Reset()
+	require.NoError(t, runStatusCmd(&out, fs, cfg, &statusOptions{jsonOutput: true}))
+	var snap status.Snapshot
+	require.NoError(t, json.Unmarshal(out.Bytes(), &snap))
+	assert.Equal(t, status.StateIdle, snap.State)
+	assert.Equal(t, 1, snap.Errors)
+
+	out.Reset()
+	require.NoError(t, runStatusCmd(&out, fs, cfg, &statusOptions{waybar: true}))
+	require.NoError(t, json.Unmarshal(out.Bytes(), &module))
+	assert.Equal(t, "1 failed", module.Text)
+	assert.Equal(t, "error", module.Class)
+	assert.Contains(t, module.Tooltip, "tool.tar.gz: boom")
+}
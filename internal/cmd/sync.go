@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/backends"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+)
+
+// syncMetadataOptions holds the flags of the sync-metadata command
+type syncMetadataOptions struct {
+	dryRun      bool
+	timeoutSecs int
+}
+
+// NewSyncMetadataCmd creates the sync-metadata command
+func NewSyncMetadataCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &syncMetadataOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "sync-metadata [name|install-id...]",
+		Short: "Refresh records of system-managed installs from the package manager",
+		Long: `Re-query pacman, dpkg or rpm for packages installed through them (DEB
+and RPM installs) and refresh the stored version, desktop files and icons
+without reinstalling.
+
+Use it after a package was upgraded or reinstalled outside upkg (for
+example with pacman -U, apt or dnf) so 'upkg list' shows the right version.`,
+		ValidArgsFunction: completeInstalledPackages(cfg, 0),
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runSyncMetadataCmd(backends.NewRegistry(cfg, log), cfg, log, opts, args)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "show what would change without updating the database")
+	cmd.Flags().IntVar(&opts.timeoutSecs, "timeout", 300, "timeout in seconds")
+
+	return cmd
+}
+
+//nolint:gocyclo // per-record query, compare and update with separate reporting.
+func runSyncMetadataCmd(registry *backends.Registry, cfg *config.Config, log *zerolog.Logger, opts *syncMetadataOptions, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(opts.timeoutSecs)*time.Second)
+	defer cancel()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	installs, err := database.List(ctx)
+	if err != nil {
+		ui.PrintError("failed to list packages: %v", err)
+		return fmt.Errorf("list installs: %w", err)
+	}
+	stored := make(map[string]map[string]interface{}, len(installs))
+	for _, install := range installs {
+		stored[install.InstallID] = install.Metadata
+	}
+
+	records, err := selectRecords(installs, args, func(record *core.InstallRecord) bool {
+		return core.IsSystemManaged(record.Metadata.InstallMethod) ||
+			(record.PackageType == core.PackageTypeDeb && record.Metadata.InstallMethod != core.InstallMethodLocal)
+	})
+	if err != nil {
+		ui.PrintError("%v", err)
+		return err
+	}
+	if len(records) == 0 {
+		ui.PrintInfo("No system-managed packages installed")
+		return nil
+	}
+
+	var refreshed, failed int
+	for _, record := range records {
+		backend, backendErr := registry.GetBackend(string(record.PackageType))
+		syncer, ok := backend.(backends.MetadataSyncer)
+		if backendErr != nil || !ok {
+			ui.PrintWarning("%s: metadata sync is not supported for %s packages", record.Name, record.PackageType)
+			continue
+		}
+
+		oldVersion := record.Version
+		changed, syncErr := syncer.SyncMetadata(ctx, record)
+		if syncErr != nil {
+			failed++
+			ui.PrintWarning("%s: %v", record.Name, syncErr)
+			log.Warn().Err(syncErr).Str("name", record.Name).Msg("metadata sync failed")
+			continue
+		}
+		if !changed {
+			log.Debug().Str("name", record.Name).Msg("metadata up to date")
+			continue
+		}
+
+		refreshed++
+		change := "files updated"
+		if record.Version != oldVersion {
+			change = fmt.Sprintf("%s → %s", displayVersion(oldVersion), record.Version)
+		}
+		if opts.dryRun {
+			ui.PrintInfo("[DRY-RUN] %s: %s", record.Name, change)
+			continue
+		}
+
+		dbRecord := db.FromInstallRecord(record)
+		dbRecord.Metadata = mergeMetadata(stored[record.InstallID], dbRecord.Metadata)
+		if updateErr := database.Update(ctx, dbRecord); updateErr != nil {
+			ui.PrintError("failed to update %s: %v", record.Name, updateErr)
+			return fmt.Errorf("update record %s: %w", record.Name, updateErr)
+		}
+		log.Info().Str("name", record.Name).Str("old_version", oldVersion).Str("new_version", record.Version).Msg("metadata refreshed")
+		ui.PrintSuccess("%s: %s", record.Name, change)
+	}
+
+	switch {
+	case refreshed == 0 && failed == 0:
+		ui.PrintSuccess("All %d system-managed packages are up to date", len(records))
+	case opts.dryRun:
+		ui.PrintInfo("[DRY-RUN] %d of %d packages would be refreshed", refreshed, len(records))
+	default:
+		ui.PrintSuccess("Refreshed %d of %d packages", refreshed, len(records))
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to sync %d of %d packages", failed, len(records))
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/backends"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunSyncMetadataCmd(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: filepath.Join(tmpDir, "test.db"), DataDir: tmpDir}}
+	log := zerolog.New(io.Discard)
+
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	for _, install := range []db.Install{
+		{InstallID: "tool-1", PackageType: "deb", Name: "tool", Version: "1.0-1", InstallDate: time.Now(),
+			Metadata: map[string]interface{}{"install_method": "pacman", "groups": []string{"dev"}}},
+		{InstallID: "app-1", PackageType: "appimage", Name: "app", Version: "3.0", InstallDate: time.Now()},
+	} {
+		require.NoError(t, database.Create(ctx, &install))
+	}
+	require.NoError(t, database.Close())
+
+	runner := &helpers.MockCommandRunner{
+		RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+			if name != "pacman" || len(args) != 2 || args[1] != "tool" {
+				return "", errors.New("unexpected command " + name + " " + strings.Join(args, " "))
+			}
+			switch args[0] {
+			case "-Qi":
+				return "Name            : tool\nVersion         : 1.2-1\n", nil
+			case "-Ql":
+				return "tool /usr/bin/tool\ntool /usr/share/applications/tool.desktop\n", nil
+			}
+			return "", errors.New("unexpected pacman flag")
+		},
+	}
+	registry := backends.NewRegistryWithDeps(cfg, &log, afero.NewMemMapFs(), runner)
+
+	// Dry run leaves the record alone
+	require.NoError(t, runSyncMetadataCmd(registry, cfg, &log, &syncMetadataOptions{dryRun: true, timeoutSecs: 10}, nil))
+	assert.Equal(t, "1.0-1", storedInstall(t, cfg, "tool-1").Version)
+
+	require.NoError(t, runSyncMetadataCmd(registry, cfg, &log, &syncMetadataOptions{timeoutSecs: 10}, []string{"tool"}))
+	install := storedInstall(t, cfg, "tool-1")
+	assert.Equal(t, "1.2-1", install.Version)
+	assert.Equal(t, "/usr/share/applications/tool.desktop", install.DesktopFile)
+	assert.Contains(t, install.Metadata, "groups", "unrelated metadata kept")
+
+	err = runSyncMetadataCmd(registry, cfg, &log, &syncMetadataOptions{timeoutSecs: 10}, []string{"missing"})
+	assert.ErrorContains(t, err, "package not found: missing")
+}
+
+// storedInstall reads one install record back from the database
+func storedInstall(t *testing.T, cfg *config.Config, installID string) *db.Install {
+	t.Helper()
+	database, err := db.New(context.Background(), cfg.Paths.DBFile)
+	require.NoError(t, err)
+	defer func() { _ = database.Close() }()
+	install, err := database.Get(context.Background(), installID)
+	require.NoError(t, err)
+	return install
+}
@@ -2,37 +2,63 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/quantmind-br/upkg/internal/appdata"
 	"github.com/quantmind-br/upkg/internal/backends"
 	"github.com/quantmind-br/upkg/internal/config"
 	"github.com/quantmind-br/upkg/internal/core"
 	"github.com/quantmind-br/upkg/internal/db"
 	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/history"
+	"github.com/quantmind-br/upkg/internal/hooks"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/preview"
 	"github.com/quantmind-br/upkg/internal/ui"
 	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
-	"golang.org/x/term"
 )
 
 // uninstallOptions holds command flags
 type uninstallOptions struct {
 	yes        bool
 	dryRun     bool
+	jsonOutput bool
 	all        bool
+	purge      bool
+	noCache    bool
 	timeoutSec int
+
+	noCacheUpdate bool // Leave the desktop database and icon caches for 'upkg refresh-caches'
+
+	inhibitSleep bool   // Hold a sleep inhibitor lock while removing (system.inhibit_sleep)
+	statusFile   string // Status file updated while removing
+	historyFile  string // Operation log each removal is appended to
+	integrityDir string // Recorded file hashes of removed packages are deleted from it
+	hooks        *hooks.Runner
+	dataDirs     appdata.Dirs  // Searched for the app directories --purge removes
+	payloads     *payloadStash // Receives the payloads of archive installs; nil with --no-cache
+
+	events *ui.EventWriter // Set in JSON output mode; receives progress and results
+
+	groupEdits []groupEdit                         // Memberships dropped once the packages are removed
+	confirm    func(question string) (bool, error) // Asks before removing; ui.ConfirmPrompt when nil
 }
 
 // UninstallResult tracks the outcome of a single uninstall operation
 type UninstallResult struct {
-	Name    string
-	Success bool
-	Error   error
+	Name      string `json:"name"`
+	Success   bool   `json:"success"`
+	Error     error  `json:"-"`
+	Reclaimed int64  `json:"reclaimed_bytes"` // Bytes freed on disk (0 on failure)
 }
 
 // NewUninstallCmd creates the uninstall command
@@ -40,35 +66,52 @@ func NewUninstallCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
 	opts := &uninstallOptions{}
 
 	cmd := &cobra.Command{
-		Use:   "uninstall [package-name...] [flags]",
+		Use:   "uninstall [package-name|@group...] [flags]",
 		Short: "Uninstall one or more packages",
 		Long: `Uninstall previously installed packages by name or install ID.
 
 Examples:
   upkg uninstall firefox              # Uninstall single package
   upkg uninstall pkg1 pkg2 pkg3       # Uninstall multiple packages
+  upkg uninstall @dev-tools           # Uninstall packages installed with a group
   upkg uninstall pkg1 --yes           # Skip confirmation prompt
+  upkg uninstall pkg1 --purge         # Also delete its config, cache and data
   upkg uninstall pkg1 --dry-run       # Preview without removing
+  upkg uninstall pkg1 --dry-run --json  # Preview as JSON
   upkg uninstall --all --yes          # Uninstall all packages
   upkg uninstall                      # Interactive mode (select from list)`,
-		Args: cobra.ArbitraryArgs,
-		RunE: func(_ *cobra.Command, args []string) error {
-			return runUninstallCmd(cfg, log, opts, args)
+		Args:              cobra.ArbitraryArgs,
+		ValidArgsFunction: completeInstalledPackages(cfg, 0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.events = ui.EventWriterFromContext(cmd.Context())
+			defer startCacheBatch(afero.NewOsFs(), helpers.NewOSCommandRunner(), cfg, log, opts.noCacheUpdate)()
+			return runUninstallCmd(cmd.OutOrStdout(), cfg, log, opts, args)
 		},
 	}
 
 	cmd.Flags().BoolVarP(&opts.yes, "yes", "y", false, "skip confirmation prompts (required for non-interactive environments)")
 	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "preview what would be uninstalled without making changes")
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "print the dry-run preview in JSON format (requires --dry-run)")
 	cmd.Flags().BoolVar(&opts.all, "all", false, "uninstall all tracked packages")
+	cmd.Flags().BoolVar(&opts.purge, "purge", false, "also remove the apps' config, cache and data directories from your home")
+	cmd.Flags().BoolVar(&opts.noCache, "no-cache", false, "delete archive payloads instead of keeping them in the payload cache for reinstalls")
 	cmd.Flags().IntVar(&opts.timeoutSec, "timeout", 600, "uninstallation timeout in seconds")
+	cmd.Flags().BoolVar(&opts.noCacheUpdate, "no-cache-update", false, "skip the desktop database and icon cache updates (run 'upkg refresh-caches' later)")
 
 	return cmd
 }
 
-func runUninstallCmd(cfg *config.Config, log *zerolog.Logger, opts *uninstallOptions, args []string) error {
+func runUninstallCmd(out io.Writer, cfg *config.Config, log *zerolog.Logger, opts *uninstallOptions, args []string) error {
+	if opts.jsonOutput && !opts.dryRun {
+		return fmt.Errorf("--json requires --dry-run")
+	}
+
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(opts.timeoutSec)*time.Second)
 	defer cancel()
+	if opts.events != nil {
+		ctx = ui.WithProgressSink(ctx, opts.events.ProgressSink(""))
+	}
 
 	// Initialize database
 	database, err := db.New(ctx, cfg.Paths.DBFile)
@@ -79,9 +122,31 @@ func runUninstallCmd(cfg *config.Config, log *zerolog.Logger, opts *uninstallOpt
 	defer func() { _ = database.Close() }()
 
 	registry := backends.NewRegistry(cfg, log)
+	opts.inhibitSleep = cfg.System.InhibitSleep
+	opts.statusFile = paths.NewResolver(cfg).GetStatusFile()
+	opts.historyFile = paths.NewResolver(cfg).GetHistoryFile()
+	opts.integrityDir = paths.NewResolver(cfg).GetIntegrityDir()
+	opts.hooks = hooks.NewRunner(cfg.Hooks, log)
+	opts.dataDirs = appdata.DirsFor(paths.NewResolver(cfg).HomeDir(), os.Getenv)
+	if !opts.noCache {
+		opts.payloads = newPayloadStash(afero.NewOsFs(), cfg)
+	}
+
+	if len(args) > 0 {
+		if args, opts.groupEdits, err = expandGroupArgs(ctx, database, args); err != nil {
+			color.Red("Error: %v", err)
+			return err
+		}
+		if len(args) == 0 {
+			color.Yellow("Every package in the group is pinned or still used by another group. Nothing to uninstall.")
+			return leaveGroups(ctx, database, log, opts)
+		}
+	}
 
 	// Determine the mode of operation
 	switch {
+	case opts.jsonOutput:
+		return runUninstallPreviewJSON(ctx, out, database, log, opts, args)
 	case opts.all:
 		return runUninstallAll(ctx, database, registry, log, opts)
 	case len(args) == 0:
@@ -93,14 +158,9 @@ func runUninstallCmd(cfg *config.Config, log *zerolog.Logger, opts *uninstallOpt
 	}
 }
 
-// isInteractive checks if stdin is a terminal
-func isInteractive() bool {
-	return term.IsTerminal(int(os.Stdin.Fd()))
-}
-
 // requireInteractiveOrYes ensures we're either in a TTY or have --yes flag
 func requireInteractiveOrYes(opts *uninstallOptions) error {
-	if !isInteractive() && !opts.yes {
+	if !isInteractive() && !opts.yes && opts.confirm == nil {
 		return fmt.Errorf("non-interactive mode requires --yes flag")
 	}
 	return nil
@@ -129,6 +189,10 @@ func runUninstallAll(ctx context.Context, database *db.DB, registry *backends.Re
 	for i := range installs {
 		records = append(records, db.ToInstallRecord(&installs[i]))
 	}
+	if records = skipPinned(records); len(records) == 0 {
+		color.Yellow("Every package is pinned. Nothing to uninstall.")
+		return nil
+	}
 
 	color.Yellow("⚠️  WARNING: This will uninstall ALL %d packages!", len(records))
 
@@ -310,7 +374,7 @@ func lookupPackage(ctx context.Context, database *db.DB, log *zerolog.Logger, id
 
 	color.Red("Error: package not found: %s", identifier)
 	color.Yellow("  Use 'upkg list' to see installed packages")
-	return nil, fmt.Errorf("package not found: %s", identifier)
+	return nil, withExitCode(ExitNotFound, fmt.Errorf("package not found: %s", identifier))
 }
 
 // executeUninstall is the unified execution path for all uninstall modes
@@ -323,34 +387,39 @@ func executeUninstall(ctx context.Context, registry *backends.Registry, database
 		color.Cyan("📏 Calculating sizes...")
 	}
 
-	var totalSize int64
+	summary := preview.PreviewAll(afero.NewOsFs(), records)
+	totalSize, totalFiles := summary.TotalSize, summary.TotalFiles
 	sizes := make(map[string]int64, len(records))
 
-	for _, record := range records {
-		size := int64(0)
-		if record.InstallPath != "" {
-			size, _ = calculatePackageSize(record.InstallPath)
-		}
-		sizes[record.InstallID] = size
-		totalSize += size
-	}
-
 	fmt.Println()
-	for _, record := range records {
-		size := sizes[record.InstallID]
-		fmt.Printf("   • %s (%s) - %s\n", record.Name, record.PackageType, formatBytes(size))
+	for _, report := range summary.Packages {
+		sizes[report.InstallID] = report.TotalSize
+		fmt.Printf("   • %s (%s) - %s\n", report.Name, report.PackageType, formatBytes(report.TotalSize))
 	}
 	fmt.Printf("\n💾 Total space to free: %s\n\n", formatBytes(totalSize))
 
+	// Leftover app data is listed before anything is confirmed
+	var purge map[string][]dataDir
+	if opts.purge {
+		purge = findDataDirs(afero.NewOsFs(), opts.dataDirs, records)
+	}
+
 	// Dry-run mode: show detailed breakdown and exit
 	if opts.dryRun {
-		return showDryRunDetails(records, sizes)
+		if opts.events != nil {
+			opts.events.Result("", summary)
+		}
+		return showDryRunDetails(summary)
 	}
 
 	// Confirmation (skip if --yes)
 	if !opts.yes {
 		color.Yellow("⚠️  This action cannot be undone!")
-		confirmed, err := ui.ConfirmPrompt("Are you sure you want to uninstall these packages?")
+		question := "Are you sure you want to uninstall these packages?"
+		if len(purge) > 0 {
+			question = "Are you sure you want to uninstall these packages and delete their app data?"
+		}
+		confirmed, err := opts.askConfirm(question)
 		if err != nil {
 			color.Yellow("Confirmation cancelled. No packages were uninstalled.")
 			return nil
@@ -365,8 +434,23 @@ func executeUninstall(ctx context.Context, registry *backends.Registry, database
 	fmt.Println()
 	color.Cyan("🚀 Starting uninstallation...\n")
 
+	release := holdSleepInhibitor(ctx, opts.inhibitSleep, log, fmt.Sprintf("Uninstalling %d packages", len(records)))
+	defer release()
+
+	tracker := startStatus(opts.statusFile, log, "uninstall", len(records))
+	defer tracker.Finish()
+
 	results := make([]UninstallResult, 0, len(records))
 
+	// Bulk removals get a progress bar driven by the precomputed sizes
+	progress := ui.NewProgress(ctx,
+		[]ui.InstallationPhase{{Name: "Removing packages", Weight: 100, Deterministic: true}},
+		fmt.Sprintf("Uninstalling %d packages (%d files)", len(records), totalFiles),
+		len(records) > 1 && isInteractive(),
+	)
+	progress.StartPhase(0)
+
+	var reclaimed, processed int64
 	for i, record := range records {
 		fmt.Printf("[%d/%d] ", i+1, len(records))
 
@@ -375,43 +459,105 @@ func executeUninstall(ctx context.Context, registry *backends.Registry, database
 			Str("name", record.Name).
 			Msg("starting uninstallation")
 
-		err := performUninstall(ctx, registry, database, log, record)
-		results = append(results, UninstallResult{
+		tracker.Begin(record.Name)
+		started := time.Now()
+		err := performUninstall(ctx, registry, database, log, opts.hooks, opts.payloads, record)
+		tracker.Done(record.Name, err)
+		recordHistory(afero.NewOsFs(), opts.historyFile, log, historyRecordEntry(history.OpUninstall, record), started, err)
+		result := UninstallResult{
 			Name:    record.Name,
 			Success: err == nil,
 			Error:   err,
-		})
+		}
+		if err == nil {
+			forgetIntegrity(afero.NewOsFs(), opts.integrityDir, log, record.InstallID)
+			result.Reclaimed = sizes[record.InstallID] + removeDataDirs(afero.NewOsFs(), log, purge[record.InstallID])
+			reclaimed += result.Reclaimed
+			if len(records) > 1 {
+				fmt.Printf("   💾 Freed %s (%s of %s reclaimed)\n",
+					formatBytes(result.Reclaimed), formatBytes(reclaimed), formatBytes(totalSize))
+			}
+		}
+		if opts.events != nil {
+			if err != nil {
+				opts.events.Error(record.Name, err)
+			} else {
+				opts.events.Result(record.Name, result)
+			}
+		}
+		results = append(results, result)
+
+		processed += sizes[record.InstallID]
+		if totalSize > 0 {
+			progress.SetProgress(int(processed*100/totalSize), 100)
+		} else {
+			progress.SetProgress(i+1, len(records))
+		}
+	}
+	progress.Finish()
+
+	// Packages kept by another group leave the uninstalled one only when
+	// all of its other members are gone
+	failed := slices.ContainsFunc(results, func(r UninstallResult) bool { return !r.Success })
+	if !failed && len(opts.groupEdits) > 0 {
+		if err := applyGroupEdits(ctx, database, log, opts.groupEdits); err != nil {
+			color.Yellow("Warning: %v", err)
+		}
 	}
 
 	// Summary
 	return printUninstallSummary(results)
 }
 
+// askConfirm asks question with opts.confirm, or the terminal prompt
+func (opts *uninstallOptions) askConfirm(question string) (bool, error) {
+	if opts.confirm != nil {
+		return opts.confirm(question)
+	}
+	return ui.ConfirmPrompt(question)
+}
+
+// leaveGroups drops the group memberships of an uninstall that removes no
+// package, after confirmation; a dry run only reports them
+func leaveGroups(ctx context.Context, database *db.DB, log *zerolog.Logger, opts *uninstallOptions) error {
+	if len(opts.groupEdits) == 0 || opts.dryRun {
+		return nil
+	}
+	if err := requireInteractiveOrYes(opts); err != nil {
+		color.Red("Error: %v", err)
+		return err
+	}
+	if !opts.yes {
+		confirmed, err := opts.askConfirm(fmt.Sprintf("Remove %d packages from the group?", len(opts.groupEdits)))
+		if err != nil || !confirmed {
+			color.Yellow("Cancelled. Group memberships were not changed.")
+			return nil
+		}
+	}
+	if err := applyGroupEdits(ctx, database, log, opts.groupEdits); err != nil {
+		color.Red("Error: %v", err)
+		return err
+	}
+	color.Green("✓ Removed %d packages from the group", len(opts.groupEdits))
+	return nil
+}
+
 // showDryRunDetails displays what would be removed without actually removing
-func showDryRunDetails(records []*core.InstallRecord, sizes map[string]int64) error {
+func showDryRunDetails(summary preview.Summary) error {
 	color.Cyan("🔍 [DRY-RUN] The following would be removed:\n")
 
-	for _, record := range records {
-		size := sizes[record.InstallID]
-		fmt.Printf("📦 %s (%s) - %s\n", record.Name, record.PackageType, formatBytes(size))
+	for _, report := range summary.Packages {
+		fmt.Printf("📦 %s (%s) - %s\n", report.Name, report.PackageType, formatBytes(report.TotalSize))
 
-		if record.InstallPath != "" {
-			fmt.Printf("   📁 Install path: %s\n", record.InstallPath)
-		}
-		if record.DesktopFile != "" {
-			fmt.Printf("   🖥️  Desktop file: %s\n", record.DesktopFile)
-		}
-		if len(record.Metadata.IconFiles) > 0 {
-			fmt.Printf("   🎨 Icon files: %d file(s)\n", len(record.Metadata.IconFiles))
-			for _, icon := range record.Metadata.IconFiles {
-				fmt.Printf("      • %s\n", icon)
+		for _, file := range report.Files {
+			missing := ""
+			if !file.Exists {
+				missing = " (missing)"
 			}
+			fmt.Printf("   %s %s: %s%s\n", previewKindIcon(file.Kind), previewKindLabel(file.Kind), file.Path, missing)
 		}
-		if record.Metadata.WrapperScript != "" {
-			fmt.Printf("   📜 Wrapper script: %s\n", record.Metadata.WrapperScript)
-		}
-		if len(record.Metadata.DesktopFiles) > 0 {
-			fmt.Printf("   🖥️  Additional desktop files: %d\n", len(record.Metadata.DesktopFiles))
+		for _, pkg := range report.ExternalPackages {
+			fmt.Printf("   📦 %s package: %s\n", pkg.Manager, pkg.Name)
 		}
 		fmt.Println()
 	}
@@ -420,12 +566,78 @@ func showDryRunDetails(records []*core.InstallRecord, sizes map[string]int64) er
 	return nil
 }
 
+// previewKindIcon returns the dry-run marker of a preview file kind
+func previewKindIcon(kind string) string {
+	switch kind {
+	case preview.KindPayload:
+		return "📁"
+	case preview.KindDesktop:
+		return "🖥️ "
+	case preview.KindIcon:
+		return "🎨"
+	case preview.KindWrapper:
+		return "📜"
+	default:
+		return "🔗"
+	}
+}
+
+// previewKindLabel returns the dry-run label of a preview file kind
+func previewKindLabel(kind string) string {
+	switch kind {
+	case preview.KindPayload:
+		return "Install path"
+	case preview.KindDesktop:
+		return "Desktop file"
+	case preview.KindIcon:
+		return "Icon file"
+	case preview.KindWrapper:
+		return "Wrapper script"
+	case preview.KindExposedBin:
+		return "Exposed binary"
+	default:
+		return kind
+	}
+}
+
+// runUninstallPreviewJSON prints the uninstall preview of the selected
+// packages as JSON without removing anything
+func runUninstallPreviewJSON(ctx context.Context, out io.Writer, database *db.DB, log *zerolog.Logger, opts *uninstallOptions, identifiers []string) error {
+	var records []*core.InstallRecord
+	if opts.all {
+		installs, err := database.List(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to query database: %w", err)
+		}
+		for i := range installs {
+			records = append(records, db.ToInstallRecord(&installs[i]))
+		}
+	} else {
+		if len(identifiers) == 0 {
+			return fmt.Errorf("--json requires package names or --all")
+		}
+		for _, identifier := range identifiers {
+			record, err := lookupPackage(ctx, database, log, identifier)
+			if err != nil {
+				return err
+			}
+			records = append(records, record)
+		}
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(preview.PreviewAll(afero.NewOsFs(), records))
+}
+
 // printUninstallSummary prints the final summary of the uninstall operation
 func printUninstallSummary(results []UninstallResult) error {
 	var successCount, failureCount int
+	var reclaimed int64
 	for _, r := range results {
 		if r.Success {
 			successCount++
+			reclaimed += r.Reclaimed
 		} else {
 			failureCount++
 		}
@@ -436,6 +648,9 @@ func printUninstallSummary(results []UninstallResult) error {
 		color.Yellow("⚠️  Uninstallation completed with errors:")
 		color.Green("   ✓ Successful: %d", successCount)
 		color.Red("   ✗ Failed: %d", failureCount)
+		if reclaimed > 0 {
+			fmt.Printf("   💾 Reclaimed: %s\n", formatBytes(reclaimed))
+		}
 
 		// Show failed packages
 		fmt.Println()
@@ -449,10 +664,16 @@ func printUninstallSummary(results []UninstallResult) error {
 	}
 
 	color.Green("✓ Successfully uninstalled all %d package(s)!", successCount)
+	if reclaimed > 0 {
+		fmt.Printf("💾 Reclaimed %s\n", formatBytes(reclaimed))
+	}
 	return nil
 }
 
-func performUninstall(ctx context.Context, registry *backends.Registry, database *db.DB, log *zerolog.Logger, record *core.InstallRecord) error {
+// performUninstall removes record, running the pre- and post-uninstall hooks
+// of hookRunner (which may be nil) around it. The payload of an archive
+// install moves into payloads unless it is nil.
+func performUninstall(ctx context.Context, registry *backends.Registry, database *db.DB, log *zerolog.Logger, hookRunner *hooks.Runner, payloads *payloadStash, record *core.InstallRecord) error {
 	backend, err := registry.GetBackend(string(record.PackageType))
 	if err != nil {
 		color.Red("Error: backend not found for type %s", record.PackageType)
@@ -461,22 +682,49 @@ func performUninstall(ctx context.Context, registry *backends.Registry, database
 
 	color.Cyan("→ Uninstalling %s (%s)...", record.Name, record.PackageType)
 
-	if err := backend.Uninstall(ctx, record); err != nil {
+	hookEnv := hooks.EnvFromRecord(record)
+	if err := hookRunner.Run(ctx, hooks.PreUninstall, hookEnv); err != nil {
+		color.Red("Error: %v", err)
+		return err
+	}
+
+	restorePayload := payloads.keep(log, record)
+	result, err := backend.Uninstall(ctx, record)
+	if err != nil {
+		if restorePayload != nil {
+			restorePayload()
+		}
 		color.Red("Error: uninstallation failed for %s: %v", record.Name, err)
 		return fmt.Errorf("uninstallation failed: %w", err)
 	}
+	if restorePayload != nil {
+		result.Skip("payload removal", "kept in the payload cache for reinstalls (upkg cache clean frees it)")
+		payloads.prune(log)
+	}
+	removeCustomIcon(afero.NewOsFs(), log, record)
+	removeAutostart(afero.NewOsFs(), log, record)
+	// The package is gone; a failing post-uninstall hook can only be reported
+	if err := hookRunner.Run(ctx, hooks.PostUninstall, hookEnv); err != nil {
+		result.Warn("%v", err)
+	}
 
 	if record.PackageType == core.PackageTypeFlatpak {
 		color.Green("✓ Package uninstalled: %s", record.Name)
 	} else if err := database.Delete(ctx, record.InstallID); err != nil {
 		color.Yellow("Warning: failed to remove %s from database: %v", record.Name, err)
 	} else {
+		// Versions retained for rollback go with the package
+		pruneVersions(ctx, afero.NewOsFs(), database, log, record.Name, 0)
 		color.Green("✓ Package uninstalled: %s", record.Name)
 	}
+	printResultNotes(result.Warnings, result.Skipped)
 
 	log.Info().
 		Str("install_id", record.InstallID).
 		Str("name", record.Name).
+		Strs("warnings", result.Warnings).
+		Strs("skipped", result.Skipped).
+		Dur("duration", result.Duration).
 		Msg("uninstallation completed successfully")
 
 	return nil
@@ -498,34 +746,5 @@ func formatBytes(bytes int64) string {
 
 // calculatePackageSize calculates the total size and file count of a package
 func calculatePackageSize(installPath string) (int64, int) {
-	var totalSize int64
-	var fileCount int
-
-	// Check if path exists
-	info, err := os.Stat(installPath)
-	if err != nil {
-		return 0, 0
-	}
-
-	// If it's a single file
-	if !info.IsDir() {
-		return info.Size(), 1
-	}
-
-	// If it's a directory, walk through it
-	if walkErr := filepath.Walk(installPath, func(_ string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // Skip errors
-		}
-		if !info.IsDir() {
-			totalSize += info.Size()
-			fileCount++
-		}
-		return nil
-	}); walkErr != nil {
-		// Best-effort size calculation; ignore walk errors.
-		_ = walkErr
-	}
-
-	return totalSize, fileCount
+	return core.PathSize(afero.NewOsFs(), installPath)
 }
@@ -3,6 +3,7 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -14,7 +15,9 @@ import (
 	"github.com/quantmind-br/upkg/internal/config"
 	"github.com/quantmind-br/upkg/internal/core"
 	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/preview"
 	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -53,6 +56,11 @@ func TestNewUninstallCmd_HasExpectedFlags(t *testing.T) {
 	allFlag := cmd.Flags().Lookup("all")
 	require.NotNil(t, allFlag)
 
+	// Check --purge flag
+	purgeFlag := cmd.Flags().Lookup("purge")
+	require.NotNil(t, purgeFlag)
+	assert.Equal(t, "false", purgeFlag.DefValue)
+
 	// Check --timeout flag
 	timeoutFlag := cmd.Flags().Lookup("timeout")
 	require.NotNil(t, timeoutFlag)
@@ -596,22 +604,14 @@ func TestShowDryRunDetails(t *testing.T) {
 		},
 	}
 
-	sizes := map[string]int64{
-		"id1": 1024 * 1024,      // 1 MB
-		"id2": 1024 * 1024 * 10, // 10 MB
-	}
-
-	err := showDryRunDetails(records, sizes)
+	err := showDryRunDetails(preview.PreviewAll(afero.NewMemMapFs(), records))
 	assert.NoError(t, err)
 }
 
 func TestShowDryRunDetails_EmptyRecords(t *testing.T) {
 	t.Parallel()
 
-	records := []*core.InstallRecord{}
-	sizes := map[string]int64{}
-
-	err := showDryRunDetails(records, sizes)
+	err := showDryRunDetails(preview.Summary{})
 	assert.NoError(t, err)
 }
 
@@ -1175,6 +1175,61 @@ func TestExecuteUninstall_WithMetadata(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestUninstall_DryRunJSON(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: filepath.Join(tmpDir, "test.db"), DataDir: tmpDir}}
+
+	appDir := filepath.Join(tmpDir, "jsonapp")
+	require.NoError(t, os.MkdirAll(appDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(appDir, "jsonapp"), []byte("123456"), 0755))
+
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	require.NoError(t, database.Create(ctx, &db.Install{
+		InstallID:   "json-test",
+		PackageType: "tarball",
+		Name:        "JSONApp",
+		InstallDate: time.Now(),
+		InstallPath: appDir,
+		Metadata:    map[string]interface{}{"wrapper_script": filepath.Join(tmpDir, "missing-wrapper")},
+	}))
+	require.NoError(t, database.Close())
+
+	log := zerolog.New(io.Discard)
+	cmd := NewUninstallCmd(cfg, &log)
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(io.Discard)
+	cmd.SetArgs([]string{"--dry-run", "--json", "JSONApp"})
+	require.NoError(t, cmd.Execute())
+
+	var summary preview.Summary
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &summary))
+	require.Len(t, summary.Packages, 1)
+	assert.Equal(t, int64(6), summary.TotalSize)
+	report := summary.Packages[0]
+	assert.Equal(t, "json-test", report.InstallID)
+	require.Len(t, report.Files, 2)
+	assert.Equal(t, preview.KindPayload, report.Files[0].Kind)
+	assert.True(t, report.Files[0].Exists)
+	assert.Equal(t, preview.KindWrapper, report.Files[1].Kind)
+	assert.False(t, report.Files[1].Exists)
+
+	// Still on disk and in the database
+	assert.DirExists(t, appDir)
+}
+
+func TestUninstall_JSONRequiresDryRun(t *testing.T) {
+	t.Parallel()
+
+	log := zerolog.New(io.Discard)
+	err := runUninstallCmd(io.Discard, &config.Config{}, &log, &uninstallOptions{jsonOutput: true}, []string{"app"})
+	assert.ErrorContains(t, err, "--json requires --dry-run")
+}
+
 func TestExecuteUninstall_EmptyInstallPath(t *testing.T) {
 	t.Parallel()
 
@@ -1247,7 +1302,7 @@ func TestPerformUninstall_BackendNotFound(t *testing.T) {
 		InstallDate: time.Now(),
 	}
 
-	err = performUninstall(ctx, registry, database, &log, record)
+	err = performUninstall(ctx, registry, database, &log, nil, nil, record)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "backend not found")
 }
@@ -1283,8 +1338,20 @@ func TestPerformUninstall_DatabaseDeleteError(t *testing.T) {
 	}
 
 	// This should fail during database delete
-	err = performUninstall(ctx, registry, database, &log, record)
+	err = performUninstall(ctx, registry, database, &log, nil, nil, record)
 	// Backend uninstall will succeed (no files to remove), but database delete may fail
 	// Just verify the function completes without panicking
 	_ = err
 }
+
+func TestPrintUninstallSummary_Reclaimed(t *testing.T) {
+	t.Parallel()
+
+	results := []UninstallResult{
+		{Name: "pkg1", Success: true, Reclaimed: 2048},
+		{Name: "pkg2", Success: false, Error: fmt.Errorf("error"), Reclaimed: 0},
+	}
+
+	err := printUninstallSummary(results)
+	assert.Error(t, err)
+}
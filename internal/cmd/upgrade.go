@@ -0,0 +1,545 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/quantmind-br/upkg/internal/appdata"
+	"github.com/quantmind-br/upkg/internal/backends"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/history"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/security"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// upgradeBackupSuffix marks payloads set aside while an upgrade runs
+const upgradeBackupSuffix = ".upkg-upgrade"
+
+// upgradeOptions holds the flags of the upgrade command
+type upgradeOptions struct {
+	timeoutSecs    int
+	skipDesktop    bool
+	skipWaylandEnv bool
+	hiDPI          bool
+	keepPrevious   bool // Retain the replaced version for rollback even if upgrade.keep_versions is 0
+
+	// Source of the new package file, recorded for check-updates
+	sourceURL  string
+	sourceRepo string
+	sourceTag  string
+
+	manifest bool // Run by upkg apply; tracked for apply --prune
+}
+
+// NewUpgradeCmd creates the upgrade command
+func NewUpgradeCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &upgradeOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "upgrade <name|install-id> <package-file>",
+		Short: "Upgrade an installed package from a newer package file",
+		Long: `Install a newer package file over an existing installation.
+
+The new version is installed transactionally: the previous installation is
+kept aside and restored if anything fails, and only removed once the new
+version is installed and recorded.
+
+With upgrade.keep_versions set (or --keep-previous), the previous version is
+retained under <data_dir>/versions instead, and 'upkg rollback' switches
+back to it. Tarballs installed with upgrade.versioned_layout keep each
+version in <name>/<version> and launch through <name>/current, so upgrades
+and rollbacks only replace that link.`,
+		Args: cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			if len(args) == 1 {
+				return nil, cobra.ShellCompDirectiveDefault // The package file
+			}
+			return completeInstalledPackages(cfg, 1)(cmd, args, toComplete)
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			return trackStatus(cfg, log, "upgrade", args[0], func() error {
+				return runUpgradeCmd(cfg, log, opts, args[0], args[1])
+			})
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.timeoutSecs, "timeout", 600, "upgrade timeout in seconds")
+	cmd.Flags().BoolVar(&opts.skipDesktop, "skip-desktop", false, "skip desktop integration")
+	cmd.Flags().BoolVar(&opts.skipWaylandEnv, "skip-wayland-env", false, "skip Wayland environment variable injection")
+	cmd.Flags().BoolVar(&opts.hiDPI, "hidpi", false, "inject HiDPI scaling env for toolkits the desktop does not scale")
+	cmd.Flags().BoolVar(&opts.keepPrevious, "keep-previous", false, "retain the previous version for 'upkg rollback'")
+
+	return cmd
+}
+
+//nolint:gocyclo // upgrade orchestrates validation, backup, install and record swap.
+func runUpgradeCmd(cfg *config.Config, log *zerolog.Logger, opts *upgradeOptions, identifier, packagePath string) (err error) {
+	started := time.Now()
+	entry := history.Entry{
+		Operation: history.OpUpgrade,
+		Name:      identifier,
+		Source:    cmp.Or(opts.sourceURL, packagePath),
+		Options:   upgradeHistoryOptions(opts),
+	}
+	defer func() {
+		recordHistory(afero.NewOsFs(), paths.NewResolver(cfg).GetHistoryFile(), log, entry, started, err)
+	}()
+
+	absPath, err := filepath.Abs(packagePath)
+	if err != nil {
+		color.Red("Error: invalid package path: %v", err)
+		return fmt.Errorf("invalid package path: %w", err)
+	}
+	packagePath = absPath
+
+	if validateErr := security.ValidatePath(packagePath); validateErr != nil {
+		color.Red("Error: invalid package path: %v", validateErr)
+		return fmt.Errorf("invalid package path: %w", validateErr)
+	}
+	if _, statErr := os.Stat(packagePath); statErr != nil {
+		color.Red("Error: package file not found: %s", packagePath)
+		return withExitCode(ExitNotFound, fmt.Errorf("package not found: %w", statErr))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(opts.timeoutSecs)*time.Second)
+	defer cancel()
+
+	release := holdSleepInhibitor(ctx, cfg.System.InhibitSleep, log, "Upgrading "+identifier)
+	defer release()
+
+	if targetErr := checkTargetDirs(cfg, log); targetErr != nil {
+		color.Red("Error: %v", targetErr)
+		return targetErr
+	}
+	if hashErr := checkPackageHash(ctx, cfg, packagePath, log); hashErr != nil {
+		color.Red("Error: %v", hashErr)
+		return hashErr
+	}
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		color.Red("Error: failed to open database: %v", err)
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	oldRecord, err := lookupPackage(ctx, database, log, identifier)
+	if err != nil {
+		return err
+	}
+	entry.Name, entry.InstallID, entry.PackageType = oldRecord.Name, oldRecord.InstallID, string(oldRecord.PackageType)
+	entry.FromVersion = oldRecord.Version
+	if oldRecord.PackageType == core.PackageTypeFlatpak {
+		color.Yellow("Flatpak apps are upgraded with 'flatpak update %s'", oldRecord.Name)
+		return fmt.Errorf("flatpak packages cannot be upgraded from a file")
+	}
+
+	registry := backends.NewRegistry(cfg, log)
+
+	color.Cyan("→ Detecting package type...")
+	backend, err := registry.DetectBackend(ctx, packagePath)
+	if err != nil {
+		color.Red("Error: %v", err)
+		reportRemediation(err)
+		return fmt.Errorf("failed to detect package type: %w", err)
+	}
+	if err := checkUpgradeBackend(oldRecord, backend.Name()); err != nil {
+		color.Red("Error: %v", err)
+		return err
+	}
+
+	if preflightErr := registry.Preflight(ctx, backend).Err(backends.DetectDistroFamily(afero.NewOsFs())); preflightErr != nil {
+		color.Red("Error: %v", preflightErr)
+		return withExitCode(ExitDependencyMissing, fmt.Errorf("preflight failed: %w", preflightErr))
+	}
+
+	warnInterruptedOperations(cfg, log)
+
+	tx := transaction.NewManager(log)
+	attachJournal(tx, cfg, log, "upgrade", oldRecord.Name)
+	defer func() {
+		undo := tx.Pending()
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Warn().Err(rollbackErr).Msg("transaction rollback failed")
+			color.Red("Error: rollback failed: %v", rollbackErr)
+		} else if undo && err != nil {
+			err = withExitCode(ExitRolledBack, err)
+		}
+	}()
+
+	if oldRecord.Metadata.SelfUpdating {
+		color.Yellow("⚠ %s updates itself; upgrading replaces any update it applied in place", oldRecord.Name)
+	}
+
+	// Keep the current installation restorable until the new one is recorded.
+	// System-managed packages are upgraded in place by pacman, dpkg or dnf.
+	fs := afero.NewOsFs()
+	var backups *upgradeBackup
+	if !core.IsSystemManaged(oldRecord.Metadata.InstallMethod) {
+		// The versioned layout installs the new release beside the old one
+		backups, err = backupInstallation(fs, oldRecord, tx, oldRecord.Metadata.Release != "")
+		if err != nil {
+			color.Red("Error: failed to back up current installation: %v", err)
+			return fmt.Errorf("backup current installation: %w", err)
+		}
+	}
+
+	color.Cyan("→ Upgrading %s...", oldRecord.Name)
+	installOpts := core.InstallOptions{
+		Force:          true,
+		SkipDesktop:    opts.skipDesktop,
+		CustomName:     oldRecord.Name,
+		SkipWaylandEnv: opts.skipWaylandEnv,
+		HiDPI:          opts.hiDPI,
+		Desktop:        oldRecord.DesktopFile != "",
+		Sandbox:        oldRecord.Metadata.Sandbox != "",
+		Bins:           oldRecord.Metadata.Bins,
+		BinDesktops:    oldRecord.Metadata.BinDesktops,
+		WrapperArgs:    oldRecord.Metadata.WrapperArgs,
+		WrapperEnv:     oldRecord.Metadata.WrapperEnv,
+	}
+	if oldRecord.PackageType == core.PackageTypeDeb {
+		// Keep the method the package was installed with
+		switch oldRecord.Metadata.InstallMethod {
+		case core.InstallMethodLocal:
+			installOpts.Method = core.MethodExtract
+		case core.InstallMethodDpkg:
+			installOpts.Method = core.MethodDpkg
+		default:
+			installOpts.Method = core.MethodPacman
+		}
+	}
+	if oldRecord.PackageType == core.PackageTypeRpm {
+		installOpts.Method = core.MethodExtract
+		if oldRecord.Metadata.InstallMethod == core.InstallMethodDnf {
+			installOpts.Method = core.MethodDnf
+		}
+	}
+
+	// The new version may install its own icon over the custom one
+	var customIcon []byte
+	if oldRecord.Metadata.CustomIcon != "" {
+		customIcon, _ = afero.ReadFile(fs, oldRecord.Metadata.CustomIcon) //nolint:errcheck // a missing icon is not carried
+	}
+
+	result, err := backend.Install(ctx, packagePath, installOpts, tx)
+	if err != nil {
+		color.Red("Error: upgrade failed: %v", err)
+		reportArchiveCorruption(err)
+		reportRemediation(err)
+		return fmt.Errorf("upgrade failed: %w", err)
+	}
+	newRecord := result.Record
+	entry.InstallID, entry.Version = newRecord.InstallID, newRecord.Version
+
+	newRecord.Metadata.Groups = oldRecord.Metadata.Groups
+	newRecord.Metadata.Manifest = oldRecord.Metadata.Manifest || opts.manifest
+	newRecord.Metadata.Pinned = oldRecord.Metadata.Pinned
+	newRecord.Metadata.Scope = oldRecord.Metadata.Scope
+	if newRecord.Metadata.WrapperScript != "" {
+		newRecord.Metadata.WrapperArgs = oldRecord.Metadata.WrapperArgs
+		newRecord.Metadata.WrapperEnv = oldRecord.Metadata.WrapperEnv
+	}
+	carrySource(oldRecord, newRecord, opts)
+	if warning := carrySelfUpdating(ctx, helpers.NewOSCommandRunner(), oldRecord, newRecord); warning != "" {
+		result.Warn("%s", warning)
+	}
+	if warning := carryDesktopOverrides(fs, oldRecord, newRecord); warning != "" {
+		result.Warn("%s", warning)
+	}
+	if warning := carryCustomIcon(fs, cfg, oldRecord, newRecord, customIcon); warning != "" {
+		result.Warn("%s", warning)
+	}
+	if warning := carryAutostart(fs, cfg, oldRecord, newRecord); warning != "" {
+		result.Warn("%s", warning)
+	}
+
+	recordInstalledSize(fs, newRecord)
+	newRecord.Metadata.DataDirs = oldRecord.Metadata.DataDirs
+	recordDataDirs(fs, appdata.DirsFor(paths.NewResolver(cfg).HomeDir(), os.Getenv), newRecord)
+	if err := database.Create(ctx, db.FromInstallRecord(newRecord)); err != nil {
+		color.Red("Error: failed to save installation record: %v", err)
+		return fmt.Errorf("failed to save installation record: %w", err)
+	}
+	if err := database.Delete(ctx, oldRecord.InstallID); err != nil {
+		if cleanupErr := database.Delete(ctx, newRecord.InstallID); cleanupErr != nil {
+			log.Warn().Err(cleanupErr).Str("install_id", newRecord.InstallID).Msg("failed to remove new record after upgrade failure")
+		}
+		color.Red("Error: failed to replace installation record: %v", err)
+		return fmt.Errorf("failed to replace installation record: %w", err)
+	}
+
+	tx.Commit()
+	recordIntegrity(fs, cfg, log, newRecord)
+	forgetIntegrity(fs, paths.NewResolver(cfg).GetIntegrityDir(), log, oldRecord.InstallID)
+
+	// The new version is in place; retain or drop the old copy, and remove
+	// files the new one no longer uses
+	retained := false
+	if keep := keepVersions(cfg, opts); keep > 0 && backups != nil {
+		if retainErr := retainVersion(ctx, fs, database, paths.NewResolver(cfg).GetVersionsDir(), oldRecord, backups); retainErr != nil {
+			log.Warn().Err(retainErr).Str("name", oldRecord.Name).Msg("failed to retain previous version")
+			result.Warn("previous version could not be retained for rollback: %v", retainErr)
+		} else {
+			pruneVersions(ctx, fs, database, log, oldRecord.Name, keep)
+			retained = true
+		}
+	}
+	backups.discard(fs, log)
+	homeDir, _ := os.UserHomeDir()
+	for _, path := range staleUpgradeFiles(oldRecord, newRecord, homeDir) {
+		if removeErr := fs.RemoveAll(path); removeErr != nil && !os.IsNotExist(removeErr) {
+			log.Warn().Err(removeErr).Str("path", path).Msg("failed to remove file from previous version")
+		}
+	}
+
+	color.Green("✓ Upgraded %s %s → %s", newRecord.Name, displayVersion(oldRecord.Version), displayVersion(newRecord.Version))
+	color.Green("  Install ID: %s", newRecord.InstallID)
+	if newRecord.DesktopFile != "" {
+		color.Cyan("  Desktop file: %s", newRecord.DesktopFile)
+	}
+	if retained {
+		color.Cyan("  Previous version retained; 'upkg rollback %s' restores it", newRecord.Name)
+	}
+	printResultNotes(result.Warnings, result.Skipped)
+
+	log.Info().
+		Str("name", newRecord.Name).
+		Str("old_version", oldRecord.Version).
+		Str("new_version", newRecord.Version).
+		Str("install_id", newRecord.InstallID).
+		Strs("warnings", result.Warnings).
+		Strs("skipped", result.Skipped).
+		Dur("duration", result.Duration).
+		Msg("upgrade completed successfully")
+
+	return nil
+}
+
+// upgradeHistoryOptions lists the upgrade flags that changed the defaults
+func upgradeHistoryOptions(opts *upgradeOptions) []string {
+	var options []string
+	for _, flag := range []struct {
+		set  bool
+		name string
+	}{
+		{opts.skipDesktop, "--skip-desktop"},
+		{opts.skipWaylandEnv, "--skip-wayland-env"},
+		{opts.hiDPI, "--hidpi"},
+		{opts.keepPrevious, "--keep-previous"},
+	} {
+		if flag.set {
+			options = append(options, flag.name)
+		}
+	}
+	return options
+}
+
+// checkUpgradeBackend rejects upgrades that would change the package type
+func checkUpgradeBackend(record *core.InstallRecord, backendName string) error {
+	if string(record.PackageType) != backendName {
+		return fmt.Errorf("package type mismatch: %s is installed as %s but the new file is %s", record.Name, record.PackageType, backendName)
+	}
+	return nil
+}
+
+// displayVersion renders an optional version for messages
+func displayVersion(version string) string {
+	if version == "" {
+		return "(unknown)"
+	}
+	return version
+}
+
+// upgradeBackup tracks what backupInstallation set aside
+type upgradeBackup struct {
+	payload    string // Renamed payload (InstallPath + suffix), if any
+	release    string // Release directory left in place in a versioned layout, if any
+	filesDir   string // Temporary directory holding copies of integration files
+	fileCopies map[string]string
+}
+
+// backupInstallation sets the payload aside (rename) and copies the
+// integration files, so the launcher and menu entry stay in place while the
+// new version installs. Rollback steps restore everything. With keepRelease
+// the release of a versioned install stays where it is: the new version is
+// installed beside it and only the current link moves.
+func backupInstallation(fs afero.Fs, record *core.InstallRecord, tx *transaction.Manager, keepRelease bool) (*upgradeBackup, error) {
+	backup := &upgradeBackup{fileCopies: make(map[string]string)}
+
+	if keepRelease && record.Metadata.Release != "" {
+		backup.release = filepath.Join(record.InstallPath, record.Metadata.Release)
+	} else if record.InstallPath != "" {
+		if _, err := fs.Stat(record.InstallPath); err == nil {
+			aside := record.InstallPath + upgradeBackupSuffix
+			if err := fs.RemoveAll(aside); err != nil {
+				return nil, fmt.Errorf("remove stale backup: %w", err)
+			}
+			tx.Track(transaction.Step{Action: transaction.ActionRename, Path: record.InstallPath, From: aside})
+			if err := fs.Rename(record.InstallPath, aside); err != nil {
+				return nil, fmt.Errorf("set aside %s: %w", record.InstallPath, err)
+			}
+			backup.payload = aside
+			tx.Add("restore previous payload", func() error {
+				if err := fs.RemoveAll(record.InstallPath); err != nil {
+					return err
+				}
+				return fs.Rename(aside, record.InstallPath)
+			})
+		}
+	}
+
+	filesDir, err := helpers.CreateTempDir(fs, record.InstallID, "upgrade")
+	if err != nil {
+		return nil, fmt.Errorf("create backup directory: %w", err)
+	}
+	backup.filesDir = filesDir
+
+	for i, path := range integrationFiles(record) {
+		info, statErr := lstatUpgrade(fs, path)
+		if statErr != nil || info.IsDir() {
+			continue
+		}
+		copyPath := filepath.Join(filesDir, fmt.Sprintf("%d-%s", i, filepath.Base(path)))
+		if err := copyUpgradeFile(fs, path, copyPath, info); err != nil {
+			return nil, fmt.Errorf("back up %s: %w", path, err)
+		}
+		backup.fileCopies[path] = copyPath
+		tx.Track(transaction.Step{Action: transaction.ActionCopy, Path: path, From: copyPath})
+	}
+
+	tx.Add("restore previous integration files", func() error {
+		defer func() { _ = fs.RemoveAll(filesDir) }()
+		for original, copyPath := range backup.fileCopies {
+			info, err := lstatUpgrade(fs, copyPath)
+			if err != nil {
+				return err
+			}
+			if err := copyUpgradeFile(fs, copyPath, original, info); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return backup, nil
+}
+
+// discard removes the backups after a successful upgrade
+func (b *upgradeBackup) discard(fs afero.Fs, log *zerolog.Logger) {
+	if b == nil {
+		return
+	}
+	for _, path := range []string{b.payload, b.release, b.filesDir} {
+		if path == "" {
+			continue
+		}
+		if err := fs.RemoveAll(path); err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("failed to remove upgrade backup")
+		}
+	}
+}
+
+// lstatUpgrade stats path without following a final symlink when fs supports it
+func lstatUpgrade(fs afero.Fs, path string) (os.FileInfo, error) {
+	if lstater, ok := fs.(afero.Lstater); ok {
+		info, _, err := lstater.LstatIfPossible(path)
+		return info, err
+	}
+	return fs.Stat(path)
+}
+
+// copyUpgradeFile copies a regular file or symlink, preserving its mode
+func copyUpgradeFile(fs afero.Fs, src, dst string, info os.FileInfo) error {
+	if info.Mode()&os.ModeSymlink != 0 {
+		reader, ok := fs.(afero.LinkReader)
+		linker, linkOK := fs.(afero.Linker)
+		if !ok || !linkOK {
+			return fmt.Errorf("filesystem does not support symlinks")
+		}
+		target, err := reader.ReadlinkIfPossible(src)
+		if err != nil {
+			return err
+		}
+		if err := fs.Remove(dst); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return linker.SymlinkIfPossible(target, dst)
+	}
+
+	data, err := afero.ReadFile(fs, src)
+	if err != nil {
+		return err
+	}
+	return afero.WriteFile(fs, dst, data, info.Mode().Perm())
+}
+
+// integrationFiles lists the desktop integration files owned by record
+func integrationFiles(record *core.InstallRecord) []string {
+	var files []string
+	add := func(paths ...string) {
+		for _, path := range paths {
+			if path != "" && path != record.InstallPath {
+				files = append(files, path)
+			}
+		}
+	}
+
+	add(record.DesktopFile, record.Metadata.WrapperScript)
+	add(record.Metadata.ExtraWrappers...)
+	add(record.Metadata.DesktopFiles...)
+	add(record.Metadata.IconFiles...)
+	add(record.Metadata.MetainfoFiles...)
+	add(record.Metadata.ManPages...)
+	add(record.Metadata.Completions...)
+	add(record.Metadata.ExposedBins...)
+	return files
+}
+
+// staleUpgradeFiles returns files of the old installation under homeDir that
+// the new one no longer uses
+func staleUpgradeFiles(oldRecord, newRecord *core.InstallRecord, homeDir string) []string {
+	if core.IsSystemManaged(oldRecord.Metadata.InstallMethod) {
+		return nil
+	}
+
+	current := make(map[string]bool)
+	for _, path := range append(integrationFiles(newRecord), newRecord.InstallPath) {
+		current[path] = true
+	}
+
+	var stale []string
+	for _, path := range append(integrationFiles(oldRecord), oldRecord.InstallPath) {
+		if path != "" && !current[path] && homeDir != "" && strings.HasPrefix(path, homeDir+string(filepath.Separator)) {
+			stale = append(stale, path)
+		}
+	}
+	return stale
+}
+
+// carrySource records the upstream of the upgrade. An upgrade from a local
+// file keeps the old upstream, so check-updates still follows the package.
+func carrySource(oldRecord, newRecord *core.InstallRecord, opts *upgradeOptions) {
+	if opts.sourceURL == "" && opts.sourceRepo == "" {
+		newRecord.Metadata.SourceURL = oldRecord.Metadata.SourceURL
+		newRecord.Metadata.SourceRepo = oldRecord.Metadata.SourceRepo
+		newRecord.Metadata.SourceTag = oldRecord.Metadata.SourceTag
+		return
+	}
+	newRecord.Metadata.SourceURL = opts.sourceURL
+	newRecord.Metadata.SourceRepo = opts.sourceRepo
+	newRecord.Metadata.SourceTag = opts.sourceTag
+}
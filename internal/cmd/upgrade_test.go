@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/transaction"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUpgradeCmd(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{}
+	log := zerolog.New(io.Discard)
+	cmd := NewUpgradeCmd(cfg, &log)
+
+	assert.Contains(t, cmd.Use, "upgrade")
+	assert.NotNil(t, cmd.Flags().Lookup("timeout"))
+	assert.NotNil(t, cmd.Flags().Lookup("skip-desktop"))
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+	cmd.SetArgs([]string{"app"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestUpgradeCmd_PackageNotFound(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Paths: config.PathsConfig{DBFile: filepath.Join(t.TempDir(), "test.db")}}
+	log := zerolog.New(io.Discard)
+	cmd := NewUpgradeCmd(cfg, &log)
+
+	var buf bytes.Buffer
+	cmd.SetOut(&buf)
+	cmd.SetErr(&buf)
+	cmd.SetArgs([]string{"app", "/nonexistent/app-2.0.AppImage"})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "package not found")
+}
+
+func TestCheckUpgradeBackend(t *testing.T) {
+	t.Parallel()
+
+	record := &core.InstallRecord{Name: "app", PackageType: core.PackageTypeAppImage}
+	assert.NoError(t, checkUpgradeBackend(record, "appimage"))
+
+	err := checkUpgradeBackend(record, "tarball")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "installed as appimage but the new file is tarball")
+}
+
+func newUpgradeFixture(t *testing.T) (afero.Fs, *core.InstallRecord) {
+	t.Helper()
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/home/u/.local/share/upkg/apps/app/app", []byte("v1"), 0755))
+	require.NoError(t, afero.WriteFile(fs, "/home/u/.local/share/applications/app.desktop", []byte("[Desktop Entry]\nName=v1\n"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/home/u/.local/bin/app", []byte("#!/bin/sh v1"), 0755))
+
+	return fs, &core.InstallRecord{
+		Name:        "app",
+		PackageType: core.PackageTypeTarball,
+		InstallPath: "/home/u/.local/share/upkg/apps/app",
+		DesktopFile: "/home/u/.local/share/applications/app.desktop",
+		Metadata:    core.Metadata{WrapperScript: "/home/u/.local/bin/app"},
+	}
+}
+
+func TestBackupInstallation_RollbackRestores(t *testing.T) {
+	t.Parallel()
+
+	fs, record := newUpgradeFixture(t)
+	log := zerolog.New(io.Discard)
+	tx := transaction.NewManager(&log)
+
+	_, err := backupInstallation(fs, record, tx, false)
+	require.NoError(t, err)
+
+	// Payload is set aside, integration files stay in place
+	exists, _ := afero.Exists(fs, record.InstallPath)
+	assert.False(t, exists)
+	exists, _ = afero.Exists(fs, record.DesktopFile)
+	assert.True(t, exists)
+
+	// Simulate a partially written new version
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(record.InstallPath, "app"), []byte("v2"), 0755))
+	require.NoError(t, afero.WriteFile(fs, record.DesktopFile, []byte("[Desktop Entry]\nName=v2\n"), 0644))
+
+	require.NoError(t, tx.Rollback())
+
+	data, err := afero.ReadFile(fs, filepath.Join(record.InstallPath, "app"))
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(data))
+	data, err = afero.ReadFile(fs, record.DesktopFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Name=v1")
+	exists, _ = afero.Exists(fs, record.InstallPath+upgradeBackupSuffix)
+	assert.False(t, exists)
+}
+
+func TestBackupInstallation_DiscardAfterCommit(t *testing.T) {
+	t.Parallel()
+
+	fs, record := newUpgradeFixture(t)
+	log := zerolog.New(io.Discard)
+	tx := transaction.NewManager(&log)
+
+	backup, err := backupInstallation(fs, record, tx, false)
+	require.NoError(t, err)
+	tx.Commit()
+	backup.discard(fs, &log)
+
+	exists, _ := afero.Exists(fs, record.InstallPath+upgradeBackupSuffix)
+	assert.False(t, exists)
+	exists, _ = afero.Exists(fs, backup.filesDir)
+	assert.False(t, exists)
+}
+
+func TestBackupInstallation_KeepsRelease(t *testing.T) {
+	t.Parallel()
+
+	fs, record := newUpgradeFixture(t)
+	record.Metadata.Release = "1.0.0"
+	release := filepath.Join(record.InstallPath, "1.0.0")
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(release, "app"), []byte("v1"), 0755))
+	log := zerolog.New(io.Discard)
+	tx := transaction.NewManager(&log)
+
+	backup, err := backupInstallation(fs, record, tx, true)
+	require.NoError(t, err)
+	exists, _ := afero.Exists(fs, filepath.Join(release, "app"))
+	assert.True(t, exists, "the release stays in place")
+	assert.Empty(t, backup.payload)
+
+	tx.Commit()
+	backup.discard(fs, &log)
+	exists, _ = afero.Exists(fs, release)
+	assert.False(t, exists, "discarding drops the replaced release")
+	exists, _ = afero.Exists(fs, record.InstallPath)
+	assert.True(t, exists)
+}
+
+func TestStaleUpgradeFiles(t *testing.T) {
+	t.Parallel()
+
+	oldRecord := &core.InstallRecord{
+		InstallPath: "/home/u/.local/share/upkg/apps/app",
+		DesktopFile: "/home/u/.local/share/applications/app.desktop",
+		Metadata: core.Metadata{
+			IconFiles: []string{"/home/u/.local/share/icons/hicolor/48x48/apps/app.png", "/usr/share/icons/app.png"},
+		},
+	}
+	newRecord := &core.InstallRecord{
+		InstallPath: "/home/u/.local/share/upkg/apps/app",
+		DesktopFile: "/home/u/.local/share/applications/app.desktop",
+	}
+
+	assert.Equal(t, []string{"/home/u/.local/share/icons/hicolor/48x48/apps/app.png"}, staleUpgradeFiles(oldRecord, newRecord, "/home/u"))
+
+	oldRecord.Metadata.InstallMethod = core.InstallMethodPacman
+	assert.Empty(t, staleUpgradeFiles(oldRecord, newRecord, "/home/u"))
+}
+
+func TestCarrySource(t *testing.T) {
+	t.Parallel()
+
+	oldRecord := &core.InstallRecord{Metadata: core.Metadata{SourceRepo: "owner/app", SourceTag: "v1.0.0", SourceURL: "https://dl/app-1.0.0"}}
+
+	// A local file keeps the upstream
+	newRecord := &core.InstallRecord{}
+	carrySource(oldRecord, newRecord, &upgradeOptions{})
+	assert.Equal(t, oldRecord.Metadata, newRecord.Metadata)
+
+	// A new release replaces it
+	newRecord = &core.InstallRecord{}
+	carrySource(oldRecord, newRecord, &upgradeOptions{sourceURL: "https://dl/app-2.0.0", sourceRepo: "owner/app", sourceTag: "v2.0.0"})
+	assert.Equal(t, "v2.0.0", newRecord.Metadata.SourceTag)
+	assert.Equal(t, "https://dl/app-2.0.0", newRecord.Metadata.SourceURL)
+}
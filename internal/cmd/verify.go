@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/quantmind-br/upkg/internal/cache"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/integrity"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/ui"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+// verifyOptions holds the flags of the verify command
+type verifyOptions struct {
+	jsonOutput bool
+	restore    bool
+	record     bool
+}
+
+// verifyProblem is a file of a package that failed verification
+type verifyProblem struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`
+	Status string `json:"status"` // modified or missing
+	// The recorded content can be written back with --restore
+	Restorable bool `json:"restorable"`
+	Restored   bool `json:"restored,omitempty"`
+}
+
+// verifyReport is the verification result of one package
+type verifyReport struct {
+	Name      string          `json:"name"`
+	InstallID string          `json:"install_id"`
+	Files     int             `json:"files"`
+	Problems  []verifyProblem `json:"problems,omitempty"`
+	// The package was installed before upkg recorded file hashes
+	NoManifest bool `json:"no_manifest,omitempty"`
+}
+
+// unresolved counts the problems verify could not restore
+func (r verifyReport) unresolved() int {
+	n := 0
+	for _, problem := range r.Problems {
+		if !problem.Restored {
+			n++
+		}
+	}
+	return n
+}
+
+// NewVerifyCmd creates the verify command
+func NewVerifyCmd(cfg *config.Config, log *zerolog.Logger) *cobra.Command {
+	opts := &verifyOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "verify [name|install-id...]",
+		Short: "Check installed files against the hashes recorded at install time",
+		Long: `Check the files of installed packages against the SHA256 hashes recorded
+when they were installed, and report the ones that were modified or removed.
+Without arguments every package is verified.
+
+The content of desktop entries, wrappers and icons is recorded too, so
+--restore writes damaged ones back. A damaged payload, metainfo, man page or
+completion file needs the package to be reinstalled or upgraded.
+
+Packages installed before hashes were recorded have no manifest; --record
+hashes their current files (also useful after editing them on purpose).
+Payloads of self-updating apps and of pacman, dpkg or dnf packages are not
+recorded.`,
+		Example: `  upkg verify
+  upkg verify obsidian --restore
+  upkg verify --record my-tool`,
+		ValidArgsFunction: completeInstalledPackages(cfg, 0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVerifyCmd(cmd.OutOrStdout(), afero.NewOsFs(), helpers.NewOSCommandRunner(), cfg, log, opts, args)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.jsonOutput, "json", false, "output the results in JSON format")
+	cmd.Flags().BoolVar(&opts.restore, "restore", false, "write back modified or missing desktop entries, wrappers and icons")
+	cmd.Flags().BoolVar(&opts.record, "record", false, "record the hashes of the current files instead of verifying them")
+	cmd.MarkFlagsMutuallyExclusive("restore", "record")
+
+	return cmd
+}
+
+func runVerifyCmd(out io.Writer, fs afero.Fs, runner helpers.CommandRunner, cfg *config.Config, log *zerolog.Logger, opts *verifyOptions, identifiers []string) error {
+	ctx := context.Background()
+
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	if err != nil {
+		ui.PrintError("failed to open database: %v", err)
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() { _ = database.Close() }()
+
+	var records []*core.InstallRecord
+	if len(identifiers) > 0 {
+		for _, identifier := range identifiers {
+			record, lookupErr := lookupPackage(ctx, database, log, identifier)
+			if lookupErr != nil {
+				return lookupErr
+			}
+			records = append(records, record)
+		}
+	} else {
+		installs, listErr := database.List(ctx)
+		if listErr != nil {
+			ui.PrintError("failed to query database: %v", listErr)
+			return fmt.Errorf("list installs: %w", listErr)
+		}
+		for i := range installs {
+			records = append(records, db.ToInstallRecord(&installs[i]))
+		}
+	}
+
+	manifests := integrity.NewStore(fs, paths.NewResolver(cfg).GetIntegrityDir())
+
+	if opts.record {
+		for _, record := range records {
+			manifest, recordErr := integrity.Build(fs, record)
+			if recordErr == nil {
+				recordErr = manifests.Save(manifest)
+			}
+			if recordErr != nil {
+				ui.PrintError("failed to record %s: %v", record.Name, recordErr)
+				return fmt.Errorf("record %s: %w", record.Name, recordErr)
+			}
+			ui.PrintSuccess("Recorded %d files of %s", len(manifest.Files), record.Name)
+		}
+		return nil
+	}
+
+	reports := make([]verifyReport, 0, len(records))
+	restored := map[string]string{}
+	for _, record := range records {
+		report, verifyErr := verifyPackage(fs, manifests, log, record, opts.restore, restored)
+		if verifyErr != nil {
+			ui.PrintError("failed to verify %s: %v", record.Name, verifyErr)
+			return fmt.Errorf("verify %s: %w", record.Name, verifyErr)
+		}
+		reports = append(reports, report)
+	}
+
+	// Menus pick up restored desktop entries and icons once the caches are
+	// refreshed
+	cacheManager := cache.NewCacheManagerWithRunner(runner)
+	for dir, kind := range restored {
+		var cacheErr error
+		if kind == integrity.KindIcon {
+			cacheErr = cacheManager.UpdateIconCache(dir, log)
+		} else {
+			cacheErr = cacheManager.UpdateDesktopDatabase(dir, log)
+		}
+		if cacheErr != nil {
+			log.Warn().Err(cacheErr).Str("dir", dir).Msg("failed to refresh cache")
+		}
+	}
+
+	failed := 0
+	for _, report := range reports {
+		if report.unresolved() > 0 {
+			failed++
+		}
+	}
+
+	if opts.jsonOutput {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(reports); err != nil {
+			return fmt.Errorf("encode JSON: %w", err)
+		}
+	} else {
+		printVerifyReports(reports)
+	}
+
+	if failed > 0 {
+		return withExitCode(ExitVerificationFailed, fmt.Errorf("%d of %d packages failed verification", failed, len(reports)))
+	}
+	return nil
+}
+
+// verifyPackage checks the files of record against its manifest, restoring
+// the files with recorded content when restore is set. The caches to
+// refresh are added to restored: the applications directory of restored
+// desktop entries and the theme of restored icons, with the kind.
+func verifyPackage(fs afero.Fs, manifests *integrity.Store, log *zerolog.Logger, record *core.InstallRecord, restore bool, restored map[string]string) (verifyReport, error) {
+	report := verifyReport{Name: record.Name, InstallID: record.InstallID}
+
+	manifest, err := manifests.Load(record.InstallID)
+	if errors.Is(err, integrity.ErrNoManifest) {
+		report.NoManifest = true
+		return report, nil
+	}
+	if err != nil {
+		return report, err
+	}
+	report.Files = len(manifest.Files)
+
+	problems, err := manifest.Verify(fs)
+	if err != nil {
+		return report, err
+	}
+	for _, problem := range problems {
+		entry := verifyProblem{Path: problem.File.Path, Kind: problem.File.Kind, Status: problem.Status, Restorable: problem.File.Restorable()}
+		if restore && problem.File.Restorable() {
+			if restoreErr := integrity.Restore(fs, problem.File); restoreErr != nil {
+				log.Warn().Err(restoreErr).Str("path", problem.File.Path).Msg("failed to restore file")
+			} else {
+				entry.Restored = true
+				switch problem.File.Kind {
+				case integrity.KindDesktop:
+					restored[filepath.Dir(problem.File.Path)] = integrity.KindDesktop
+				case integrity.KindIcon:
+					restored[iconThemeDir(problem.File.Path)] = integrity.KindIcon
+				}
+				log.Info().Str("name", record.Name).Str("path", problem.File.Path).Msg("restored file")
+			}
+		}
+		report.Problems = append(report.Problems, entry)
+	}
+	return report, nil
+}
+
+// printVerifyReports shows the result of each package and how to repair it
+func printVerifyReports(reports []verifyReport) {
+	for _, report := range reports {
+		switch {
+		case report.NoManifest:
+			ui.PrintWarning("%s: no recorded hashes (run 'upkg verify --record %s')", report.Name, report.Name)
+		case len(report.Problems) == 0:
+			ui.PrintSuccess("%s: %d files intact", report.Name, report.Files)
+		default:
+			unresolved := report.unresolved()
+			if unresolved == 0 {
+				ui.PrintSuccess("%s: restored %d files", report.Name, len(report.Problems))
+			} else {
+				ui.PrintError("%s: %d of %d files failed verification", report.Name, unresolved, report.Files)
+			}
+			restorable := false
+			for _, problem := range report.Problems {
+				status := problem.Status
+				if problem.Restored {
+					status += ", restored"
+				} else if problem.Restorable {
+					restorable = true
+				}
+				ui.PrintKeyValue(fmt.Sprintf("  %s (%s)", problem.Kind, status), problem.Path)
+			}
+			if restorable {
+				ui.PrintInfo("Run 'upkg verify %s --restore' to write back its desktop entries, wrappers and icons", report.Name)
+			}
+			if unresolved > 0 && !restorable {
+				ui.PrintInfo("Reinstall or upgrade %s to repair it", report.Name)
+			}
+		}
+	}
+}
+
+// recordIntegrity saves the hashes of the files of record for upkg verify;
+// a failure only loses the check, so it is logged
+func recordIntegrity(fs afero.Fs, cfg *config.Config, log *zerolog.Logger, record *core.InstallRecord) {
+	manifest, err := integrity.Build(fs, record)
+	if err == nil {
+		err = integrity.NewStore(fs, paths.NewResolver(cfg).GetIntegrityDir()).Save(manifest)
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("name", record.Name).Msg("failed to record file hashes")
+	}
+}
+
+// forgetIntegrity removes the recorded hashes of an install that is gone
+// from the manifests in dir
+func forgetIntegrity(fs afero.Fs, dir string, log *zerolog.Logger, installID string) {
+	if err := integrity.NewStore(fs, dir).Remove(installID); err != nil {
+		log.Warn().Err(err).Str("install_id", installID).Msg("failed to remove recorded file hashes")
+	}
+}
+
+// iconThemeDir returns the theme directory of an icon installed as
+// <theme>/<size>/<context>/<name>
+func iconThemeDir(path string) string {
+	return filepath.Dir(filepath.Dir(filepath.Dir(path)))
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/integrity"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunVerifyCmd(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	tmpDir := t.TempDir()
+	cfg := &config.Config{Paths: config.PathsConfig{
+		DBFile:  filepath.Join(tmpDir, "installed.db"),
+		DataDir: filepath.Join(tmpDir, "data"),
+	}}
+	fs := afero.NewOsFs()
+	runner := &helpers.MockCommandRunner{}
+
+	appImage := filepath.Join(tmpDir, "app.AppImage")
+	desktopFile := filepath.Join(tmpDir, "applications", "app.desktop")
+	require.NoError(t, os.WriteFile(appImage, []byte("elf"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Dir(desktopFile), 0755))
+	require.NoError(t, os.WriteFile(desktopFile, []byte("[Desktop Entry]\nExec="+appImage+"\n"), 0644))
+
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	record := &core.InstallRecord{
+		InstallID: "app-1", Name: "app", PackageType: core.PackageTypeAppImage,
+		InstallPath: appImage, DesktopFile: desktopFile, InstallDate: time.Now(),
+	}
+	legacy := &core.InstallRecord{InstallID: "old-1", Name: "old", PackageType: core.PackageTypeBinary, InstallDate: time.Now()}
+	require.NoError(t, database.Create(ctx, db.FromInstallRecord(record)))
+	require.NoError(t, database.Create(ctx, db.FromInstallRecord(legacy)))
+	require.NoError(t, database.Close())
+
+	recordIntegrity(fs, cfg, &logger, record)
+
+	verify := func(opts *verifyOptions, args ...string) ([]verifyReport, error) {
+		var out bytes.Buffer
+		opts.jsonOutput = true
+		runErr := runVerifyCmd(&out, fs, runner, cfg, &logger, opts, args)
+		var reports []verifyReport
+		require.NoError(t, json.Unmarshal(out.Bytes(), &reports))
+		return reports, runErr
+	}
+
+	reports, err := verify(&verifyOptions{})
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	byName := map[string]verifyReport{}
+	for _, report := range reports {
+		byName[report.Name] = report
+	}
+	assert.Equal(t, 2, byName["app"].Files)
+	assert.Empty(t, byName["app"].Problems)
+	assert.True(t, byName["old"].NoManifest, "legacy installs are reported, not failed")
+
+	require.NoError(t, os.WriteFile(desktopFile, []byte("[Desktop Entry]\nExec=/tmp/evil\n"), 0644))
+	reports, err = verify(&verifyOptions{}, "app")
+	assert.ErrorContains(t, err, "1 of 1 packages failed verification")
+	assert.Equal(t, ExitVerificationFailed, ExitCode(err))
+	require.Len(t, reports[0].Problems, 1)
+	assert.Equal(t, verifyProblem{Path: desktopFile, Kind: integrity.KindDesktop, Status: integrity.StatusModified, Restorable: true}, reports[0].Problems[0])
+
+	reports, err = verify(&verifyOptions{restore: true}, "app")
+	require.NoError(t, err)
+	assert.True(t, reports[0].Problems[0].Restored)
+	data, err := os.ReadFile(desktopFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Exec="+appImage)
+
+	// A damaged payload cannot be restored
+	require.NoError(t, os.WriteFile(appImage, []byte("corrupt"), 0755))
+	_, err = verify(&verifyOptions{restore: true}, "app")
+	assert.Error(t, err)
+
+	// --record accepts the current files
+	require.NoError(t, runVerifyCmd(io.Discard, fs, runner, cfg, &logger, &verifyOptions{record: true}, []string{"app"}))
+	_, err = verify(&verifyOptions{}, "app")
+	require.NoError(t, err)
+
+	forgetIntegrity(fs, filepath.Join(cfg.Paths.DataDir, "integrity"), &logger, "app-1")
+	reports, err = verify(&verifyOptions{}, "app")
+	require.NoError(t, err)
+	assert.True(t, reports[0].NoManifest)
+}
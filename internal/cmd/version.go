@@ -11,6 +11,9 @@ func NewVersionCmd(version string) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "version",
 		Short: "Show version information",
+		Annotations: map[string]string{
+			skipSetupAnnotation: "true",
+		},
 		Run: func(_ *cobra.Command, _ []string) {
 			fmt.Printf("upkg version %s\n", version)
 		},
@@ -5,15 +5,58 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 // Config represents the application configuration
 type Config struct {
-	Paths   PathsConfig   `mapstructure:"paths"`
-	Desktop DesktopConfig `mapstructure:"desktop"`
-	Logging LoggingConfig `mapstructure:"logging"`
+	Paths    PathsConfig    `mapstructure:"paths"`
+	Desktop  DesktopConfig  `mapstructure:"desktop"`
+	Logging  LoggingConfig  `mapstructure:"logging"`
+	Limits   LimitsConfig   `mapstructure:"limits"`
+	Security SecurityConfig `mapstructure:"security"`
+	System   SystemConfig   `mapstructure:"system"`
+	Syspkg   SyspkgConfig   `mapstructure:"syspkg"`
+	Sandbox  SandboxConfig  `mapstructure:"sandbox"`
+	Sources  SourcesConfig  `mapstructure:"sources"`
+	Upgrade  UpgradeConfig  `mapstructure:"upgrade"`
+	Cache    CacheConfig    `mapstructure:"cache"`
+	// Updates of upkg itself
+	SelfUpdate SelfUpdateConfig `mapstructure:"self_update"`
+	Hooks      HooksConfig      `mapstructure:"hooks"`
+	// Choice of the main executable of tarball, DEB and RPM payloads
+	Executables ExecutablesConfig `mapstructure:"executables"`
+	// Named package sets installable with "upkg install @name"; members are
+	// package files, URLs or Flatpak app IDs
+	Groups map[string][]string `mapstructure:"groups"`
+	// Installs commands work on: ScopeUser, or ScopeSystem with --system.
+	// Not a setting; see UseSystemScope.
+	Scope string `mapstructure:"-"`
+}
+
+// Install scopes
+const (
+	ScopeUser   = "user"
+	ScopeSystem = "system"
+)
+
+// SystemDataDir holds the payloads and database of system-wide installs
+const SystemDataDir = "/opt/upkg"
+
+// UseSystemScope points cfg at the system-wide installs: payloads and the
+// database move to SystemDataDir, and paths.Resolver switches the launcher,
+// desktop entry and icon directories to /usr/local
+func (c *Config) UseSystemScope() {
+	c.Scope = ScopeSystem
+	c.Paths.DataDir = SystemDataDir
+	c.Paths.DBFile = filepath.Join(SystemDataDir, "installed.db")
+}
+
+// IsSystemScope reports whether cfg works on system-wide installs
+func (c *Config) IsSystemScope() bool {
+	return c != nil && c.Scope == ScopeSystem
 }
 
 // PathsConfig contains path-related configuration
@@ -21,6 +64,8 @@ type PathsConfig struct {
 	DataDir string `mapstructure:"data_dir"`
 	DBFile  string `mapstructure:"db_file"`
 	LogFile string `mapstructure:"log_file"`
+	// Downloads for URL installs; partial files here are resumed
+	CacheDir string `mapstructure:"cache_dir"`
 }
 
 // DesktopConfig contains desktop integration configuration
@@ -28,6 +73,16 @@ type DesktopConfig struct {
 	WaylandEnvVars         bool     `mapstructure:"wayland_env_vars"`
 	CustomEnvVars          []string `mapstructure:"custom_env_vars"`
 	ElectronDisableSandbox bool     `mapstructure:"electron_disable_sandbox"`
+	// Per-toolkit additions to the built-in Wayland rules, keyed by toolkit
+	// (electron, tauri, flutter, qt, gtk, default)
+	ToolkitEnvVars map[string][]string `mapstructure:"toolkit_env_vars"`
+	ToolkitArgs    map[string][]string `mapstructure:"toolkit_args"`
+	// Opt-in HiDPI assistance, globally or for the listed package names
+	HiDPI         bool     `mapstructure:"hidpi"`
+	HiDPIPackages []string `mapstructure:"hidpi_packages"`
+	HiDPIScale    float64  `mapstructure:"hidpi_scale"` // Overrides the detected scale (0 = detect)
+	// Fill Comment/GenericName of generated entries from package descriptions
+	DescriptionFields bool `mapstructure:"description_fields"`
 }
 
 // LoggingConfig contains logging configuration
@@ -36,7 +91,143 @@ type LoggingConfig struct {
 	Color string `mapstructure:"color"`
 }
 
-// Load loads configuration from file and environment
+// EnvPrefix is the prefix used for environment variable overrides
+const EnvPrefix = "UPKG"
+
+// LimitsConfig contains per-package disk usage limits (0 disables a limit)
+// and the archive bomb limits applied while extracting (0 keeps the default)
+type LimitsConfig struct {
+	MaxPackageSizeMB  int64 `mapstructure:"max_package_size_mb"`
+	WarnPackageSizeMB int64 `mapstructure:"warn_package_size_mb"`
+
+	MaxExtractedSizeMB  int64 `mapstructure:"max_extracted_size_mb"`
+	MaxExtractedFiles   int   `mapstructure:"max_extracted_files"`
+	MaxCompressionRatio int64 `mapstructure:"max_compression_ratio"`
+}
+
+// MaxPackageBytes returns the per-package quota in bytes
+func (l LimitsConfig) MaxPackageBytes() int64 {
+	return l.MaxPackageSizeMB * 1024 * 1024
+}
+
+// WarnPackageBytes returns the per-package warning threshold in bytes
+func (l LimitsConfig) WarnPackageBytes() int64 {
+	return l.WarnPackageSizeMB * 1024 * 1024
+}
+
+// MaxExtractedBytes returns the archive bomb size limit in bytes
+func (l LimitsConfig) MaxExtractedBytes() int64 {
+	return l.MaxExtractedSizeMB * 1024 * 1024
+}
+
+// SecurityConfig contains opt-in security checks performed before install
+type SecurityConfig struct {
+	HashLookup            bool   `mapstructure:"hash_lookup"`              // Query HashLookupURL with the package SHA256 (file is never uploaded)
+	HashLookupURL         string `mapstructure:"hash_lookup_url"`          // Endpoint; "{sha256}" is replaced, otherwise the hash is appended as a path segment
+	HashLookupTimeoutSecs int    `mapstructure:"hash_lookup_timeout_secs"` // Request timeout
+}
+
+// SystemConfig contains settings for the system package manager (pacman)
+// and other system services
+type SystemConfig struct {
+	LockRetries           int  `mapstructure:"lock_retries"`             // Retries when the package database is locked
+	LockRetryDelaySecs    int  `mapstructure:"lock_retry_delay_secs"`    // Initial backoff, doubled after each retry
+	CommandRetries        int  `mapstructure:"command_retries"`          // Retries of network-bound commands (debtap, flatpak) after a transient failure
+	CommandRetryDelaySecs int  `mapstructure:"command_retry_delay_secs"` // Initial backoff, doubled after each retry
+	InhibitSleep          bool `mapstructure:"inhibit_sleep"`            // Block suspend/shutdown via systemd-inhibit during installs
+}
+
+// SyspkgConfig selects the native package manager DEB and RPM packages are
+// installed with
+type SyspkgConfig struct {
+	Provider string `mapstructure:"provider"` // auto (from /etc/os-release), pacman, dpkg or dnf
+}
+
+// SandboxConfig is the profile of wrappers generated with install --sandbox
+type SandboxConfig struct {
+	Tool        string   `mapstructure:"tool"`         // auto (bwrap, then firejail), bwrap or firejail
+	IsolateHome bool     `mapstructure:"isolate_home"` // Give each app a private home in <data_dir>/sandbox/<name>
+	Network     bool     `mapstructure:"network"`      // Allow network access
+	Devices     []string `mapstructure:"devices"`      // Device classes passed through: gpu, audio, camera, input or all
+}
+
+// SourcesConfig contains settings for remote package sources (gh:owner/repo)
+type SourcesConfig struct {
+	FormatPreference []string `mapstructure:"format_preference"` // Release asset formats, most preferred first
+	GitHubToken      string   `mapstructure:"github_token"`      // Optional; GITHUB_TOKEN is used when empty
+	GitHubAPIURL     string   `mapstructure:"github_api_url"`    // GitHub (Enterprise) REST API base URL
+}
+
+// UpgradeConfig contains settings for upgrades and rollbacks
+type UpgradeConfig struct {
+	KeepVersions    int  `mapstructure:"keep_versions"`    // Previous versions retained per package for rollback (0 = none)
+	VersionedLayout bool `mapstructure:"versioned_layout"` // Install tarballs as <name>/<version> with a <name>/current link wrappers launch through
+}
+
+// CacheConfig contains settings for the payload cache, which keeps tarball
+// payloads after uninstall keyed by the SHA256 of their archive so
+// reinstalling the same file skips the extraction, and for the download
+// cache in paths.cache_dir
+type CacheConfig struct {
+	Payloads           bool `mapstructure:"payloads"`              // Keep uninstalled payloads for reuse
+	MaxSizeMB          int  `mapstructure:"max_size_mb"`           // Oldest payloads are evicted beyond this size (0 = keep none)
+	DownloadsMaxSizeMB int  `mapstructure:"downloads_max_size_mb"` // Least recently used downloads are evicted beyond this size (0 = keep only the latest)
+}
+
+// SelfUpdateConfig contains settings for "upkg self-update"
+type SelfUpdateConfig struct {
+	Channel    string `mapstructure:"channel"`    // stable (latest release) or nightly (newest release, prereleases included)
+	Repository string `mapstructure:"repository"` // GitHub owner/repo upkg releases are published in
+}
+
+// HooksConfig lists shell commands (run with sh -c) executed around installs
+// and uninstalls. A failing pre-install or pre-uninstall hook aborts the
+// operation.
+type HooksConfig struct {
+	PreInstall     []string `mapstructure:"pre_install"`
+	PostInstall    []string `mapstructure:"post_install"`
+	PreUninstall   []string `mapstructure:"pre_uninstall"`
+	PostUninstall  []string `mapstructure:"post_uninstall"`
+	AbortOnFailure bool     `mapstructure:"abort_on_failure"` // A failing post-install hook rolls the install back instead of warning
+	TimeoutSecs    int      `mapstructure:"timeout_secs"`     // Limit for each hook command (0 = none)
+}
+
+// ExecutablesConfig holds glob patterns matched against the file name and the
+// path inside the package of each executable candidate, e.g. "*-cli" or
+// "bin/app*"
+type ExecutablesConfig struct {
+	Prefer []string `mapstructure:"prefer"` // Matching candidates win over the heuristic scores
+	Ignore []string `mapstructure:"ignore"` // Matching candidates are never chosen while another one is left
+}
+
+// LockRetryDelay returns the initial lock retry backoff
+func (s SystemConfig) LockRetryDelay() time.Duration {
+	return time.Duration(s.LockRetryDelaySecs) * time.Second
+}
+
+// MaxSize returns the payload cache limit in bytes
+func (c CacheConfig) MaxSize() int64 {
+	return int64(c.MaxSizeMB) * 1024 * 1024
+}
+
+// DownloadsMaxSize returns the download cache limit in bytes
+func (c CacheConfig) DownloadsMaxSize() int64 {
+	return int64(c.DownloadsMaxSizeMB) * 1024 * 1024
+}
+
+// CommandRetryDelay returns the initial backoff between command retries
+func (s SystemConfig) CommandRetryDelay() time.Duration {
+	return time.Duration(s.CommandRetryDelaySecs) * time.Second
+}
+
+// Load loads configuration from file and environment.
+//
+// Values are resolved with the following precedence (highest first):
+// command-line flags, UPKG_* environment variables, the config file and
+// built-in defaults. Every config key can be overridden from the
+// environment by upper-casing it and replacing "." with "_", e.g.
+// desktop.wayland_env_vars -> UPKG_DESKTOP_WAYLAND_ENV_VARS. List values
+// such as desktop.custom_env_vars are comma-separated.
 func Load() (*Config, error) {
 	// Set config name and paths
 	viper.SetConfigName("config")
@@ -53,10 +244,18 @@ func Load() (*Config, error) {
 	setDefaults()
 
 	// Environment variable overrides
-	viper.SetEnvPrefix("UPKG")
+	viper.SetEnvPrefix(EnvPrefix)
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
 
+	// Bind every known key explicitly so Unmarshal picks up env values
+	// even for keys that are not present in the config file
+	for _, key := range viper.AllKeys() {
+		if err := viper.BindEnv(key, EnvVarName(key)); err != nil {
+			return nil, fmt.Errorf("bind env %s: %w", key, err)
+		}
+	}
+
 	// Read config file
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
@@ -74,6 +273,12 @@ func Load() (*Config, error) {
 	cfg.Paths.DataDir = expandPath(cfg.Paths.DataDir)
 	cfg.Paths.DBFile = expandPath(cfg.Paths.DBFile)
 	cfg.Paths.LogFile = expandPath(cfg.Paths.LogFile)
+	cfg.Paths.CacheDir = expandPath(cfg.Paths.CacheDir)
+	for _, members := range cfg.Groups {
+		for i, member := range members {
+			members[i] = expandPath(member)
+		}
+	}
 
 	return &cfg, nil
 }
@@ -91,13 +296,105 @@ func setDefaults() {
 	viper.SetDefault("paths.data_dir", filepath.Join(homeDir, ".local", "share", "upkg"))
 	viper.SetDefault("paths.db_file", filepath.Join(homeDir, ".local", "share", "upkg", "installed.db"))
 	viper.SetDefault("paths.log_file", filepath.Join(homeDir, ".local", "share", "upkg", "upkg.log"))
+	cacheHome := os.Getenv("XDG_CACHE_HOME")
+	if !filepath.IsAbs(cacheHome) {
+		cacheHome = filepath.Join(homeDir, ".cache")
+	}
+	viper.SetDefault("paths.cache_dir", filepath.Join(cacheHome, "upkg", "downloads"))
 
 	viper.SetDefault("desktop.wayland_env_vars", true)
 	viper.SetDefault("desktop.custom_env_vars", []string{})
 	viper.SetDefault("desktop.electron_disable_sandbox", false) // Sandbox enabled by default for security
+	viper.SetDefault("desktop.toolkit_env_vars", map[string][]string{})
+	viper.SetDefault("desktop.toolkit_args", map[string][]string{})
+	viper.SetDefault("desktop.hidpi", false)
+	viper.SetDefault("desktop.hidpi_packages", []string{})
+	viper.SetDefault("desktop.hidpi_scale", 0.0)
+	viper.SetDefault("desktop.description_fields", true)
 
 	viper.SetDefault("logging.level", "info")
 	viper.SetDefault("logging.color", "auto")
+
+	viper.SetDefault("limits.max_package_size_mb", 0)
+	viper.SetDefault("limits.warn_package_size_mb", 2048)
+	viper.SetDefault("limits.max_extracted_size_mb", 0)
+	viper.SetDefault("limits.max_extracted_files", 0)
+	viper.SetDefault("limits.max_compression_ratio", 0)
+
+	viper.SetDefault("security.hash_lookup", false) // Privacy: never contact a remote service unless enabled
+	viper.SetDefault("security.hash_lookup_url", "")
+	viper.SetDefault("security.hash_lookup_timeout_secs", 10)
+
+	viper.SetDefault("system.lock_retries", 5)
+	viper.SetDefault("system.lock_retry_delay_secs", 5)
+	viper.SetDefault("system.command_retries", 2)
+	viper.SetDefault("system.command_retry_delay_secs", 5)
+	viper.SetDefault("system.inhibit_sleep", true)
+
+	viper.SetDefault("syspkg.provider", "auto")
+
+	viper.SetDefault("sandbox.tool", "auto")
+	viper.SetDefault("sandbox.isolate_home", true)
+	viper.SetDefault("sandbox.network", true)
+	viper.SetDefault("sandbox.devices", []string{"gpu", "audio"})
+
+	viper.SetDefault("sources.format_preference", []string{"appimage", "tarball", "deb", "rpm", "binary"})
+	viper.SetDefault("sources.github_token", "")
+	viper.SetDefault("sources.github_api_url", "https://api.github.com")
+
+	viper.SetDefault("upgrade.keep_versions", 0)
+	viper.SetDefault("upgrade.versioned_layout", false)
+
+	viper.SetDefault("cache.payloads", true)
+	viper.SetDefault("cache.max_size_mb", 10240)
+	viper.SetDefault("cache.downloads_max_size_mb", 4096)
+
+	viper.SetDefault("self_update.channel", "stable")
+	viper.SetDefault("self_update.repository", "quantmind-br/upkg")
+
+	viper.SetDefault("hooks.pre_install", []string{})
+	viper.SetDefault("hooks.post_install", []string{})
+	viper.SetDefault("hooks.pre_uninstall", []string{})
+	viper.SetDefault("hooks.post_uninstall", []string{})
+	viper.SetDefault("hooks.abort_on_failure", true)
+	viper.SetDefault("hooks.timeout_secs", 300)
+
+	viper.SetDefault("executables.prefer", []string{})
+	viper.SetDefault("executables.ignore", []string{})
+
+	viper.SetDefault("groups", map[string][]string{})
+}
+
+// Save writes the configuration to path as TOML, creating parent directories
+func Save(cfg *Config, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create config directory: %w", err)
+	}
+
+	v := viper.New()
+	v.SetConfigType("toml")
+	for key, value := range values(cfg) {
+		v.Set(key, value)
+	}
+
+	if err := v.WriteConfigAs(path); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	return nil
+}
+
+// FilePath returns the path of the user config file (~/.config/upkg/config.toml)
+func FilePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", "upkg", "config.toml"), nil
+}
+
+// EnvVarName returns the environment variable that overrides the given config key
+func EnvVarName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
 }
 
 // expandPath expands ~ and environment variables in paths
@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/spf13/viper"
 )
 
 func TestLoad(t *testing.T) {
@@ -25,6 +27,10 @@ func TestLoad(t *testing.T) {
 	if cfg.Paths.DataDir == "" {
 		t.Error("expected default data_dir, got empty")
 	}
+
+	if cfg.Syspkg.Provider != "auto" {
+		t.Errorf("Syspkg.Provider = %q, want auto", cfg.Syspkg.Provider)
+	}
 }
 
 func TestExpandPath(t *testing.T) {
@@ -68,3 +74,85 @@ func TestSetDefaults(_ *testing.T) {
 	// Verify defaults were set (via viper)
 	// This is tested indirectly through Load()
 }
+
+func TestEnvVarName(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "paths.data_dir", want: "UPKG_PATHS_DATA_DIR"},
+		{key: "desktop.wayland_env_vars", want: "UPKG_DESKTOP_WAYLAND_ENV_VARS"},
+		{key: "logging.level", want: "UPKG_LOGGING_LEVEL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := EnvVarName(tt.key); got != tt.want {
+				t.Errorf("EnvVarName(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_EnvOverrides(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("UPKG_PATHS_DATA_DIR", dataDir)
+	t.Setenv("UPKG_DESKTOP_WAYLAND_ENV_VARS", "false")
+	t.Setenv("UPKG_DESKTOP_ELECTRON_DISABLE_SANDBOX", "true")
+	t.Setenv("UPKG_DESKTOP_CUSTOM_ENV_VARS", "FOO=1,BAR=2")
+	t.Setenv("UPKG_LOGGING_LEVEL", "debug")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Paths.DataDir != dataDir {
+		t.Errorf("DataDir = %q, want %q", cfg.Paths.DataDir, dataDir)
+	}
+	if cfg.Desktop.WaylandEnvVars {
+		t.Error("expected WaylandEnvVars to be overridden to false")
+	}
+	if !cfg.Desktop.ElectronDisableSandbox {
+		t.Error("expected ElectronDisableSandbox to be overridden to true")
+	}
+	if len(cfg.Desktop.CustomEnvVars) != 2 || cfg.Desktop.CustomEnvVars[0] != "FOO=1" || cfg.Desktop.CustomEnvVars[1] != "BAR=2" {
+		t.Errorf("CustomEnvVars = %v, want [FOO=1 BAR=2]", cfg.Desktop.CustomEnvVars)
+	}
+	if cfg.Logging.Level != "debug" {
+		t.Errorf("Logging.Level = %q, want debug", cfg.Logging.Level)
+	}
+}
+
+func TestSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "upkg", "config.toml")
+	cfg := &Config{
+		Paths:   PathsConfig{DataDir: "/data", DBFile: "/data/installed.db", LogFile: "/data/upkg.log"},
+		Desktop: DesktopConfig{WaylandEnvVars: false, CustomEnvVars: []string{"FOO=1"}, ElectronDisableSandbox: true},
+		Logging: LoggingConfig{Level: "debug", Color: "never"},
+	}
+
+	if err := Save(cfg, path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatalf("ReadInConfig() error = %v", err)
+	}
+
+	var got Config
+	if err := v.Unmarshal(&got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got.Paths.DataDir != "/data" || got.Desktop.WaylandEnvVars || !got.Desktop.ElectronDisableSandbox {
+		t.Errorf("unexpected round-trip config: %+v", got)
+	}
+	if len(got.Desktop.CustomEnvVars) != 1 || got.Desktop.CustomEnvVars[0] != "FOO=1" {
+		t.Errorf("CustomEnvVars = %v, want [FOO=1]", got.Desktop.CustomEnvVars)
+	}
+	if got.Logging.Level != "debug" {
+		t.Errorf("Logging.Level = %q, want debug", got.Logging.Level)
+	}
+}
@@ -0,0 +1,345 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Origin is where an effective config value comes from
+type Origin string
+
+const (
+	OriginDefault Origin = "default"
+	OriginFile    Origin = "file"
+	OriginEnv     Origin = "env"
+)
+
+// Setting is the effective value of one config key
+type Setting struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Origin Origin `json:"origin"`
+}
+
+// allowedValues restricts keys (or the items of list keys) to fixed choices
+var allowedValues = map[string][]string{
+	"logging.level":             {"trace", "debug", "info", "warn", "warning", "error", "fatal", "panic"},
+	"logging.color":             {"auto", "always", "never"},
+	"syspkg.provider":           {"auto", "pacman", "dpkg", "dnf"},
+	"sandbox.tool":              {"auto", "bwrap", "firejail"},
+	"sandbox.devices":           {"gpu", "audio", "camera", "input", "all"},
+	"sources.format_preference": {"appimage", "tarball", "deb", "rpm", "binary"},
+	"self_update.channel":       {"stable", "nightly"},
+}
+
+// globKeys are list keys whose items are filepath.Match patterns
+var globKeys = []string{"executables.prefer", "executables.ignore"}
+
+// values maps every config key to its value in cfg
+func values(cfg *Config) map[string]any {
+	return map[string]any{
+		"paths.data_dir":                    cfg.Paths.DataDir,
+		"paths.db_file":                     cfg.Paths.DBFile,
+		"paths.log_file":                    cfg.Paths.LogFile,
+		"paths.cache_dir":                   cfg.Paths.CacheDir,
+		"desktop.wayland_env_vars":          cfg.Desktop.WaylandEnvVars,
+		"desktop.custom_env_vars":           cfg.Desktop.CustomEnvVars,
+		"desktop.electron_disable_sandbox":  cfg.Desktop.ElectronDisableSandbox,
+		"desktop.toolkit_env_vars":          cfg.Desktop.ToolkitEnvVars,
+		"desktop.toolkit_args":              cfg.Desktop.ToolkitArgs,
+		"desktop.hidpi":                     cfg.Desktop.HiDPI,
+		"desktop.hidpi_packages":            cfg.Desktop.HiDPIPackages,
+		"desktop.hidpi_scale":               cfg.Desktop.HiDPIScale,
+		"desktop.description_fields":        cfg.Desktop.DescriptionFields,
+		"logging.level":                     cfg.Logging.Level,
+		"logging.color":                     cfg.Logging.Color,
+		"limits.max_package_size_mb":        cfg.Limits.MaxPackageSizeMB,
+		"limits.warn_package_size_mb":       cfg.Limits.WarnPackageSizeMB,
+		"limits.max_extracted_size_mb":      cfg.Limits.MaxExtractedSizeMB,
+		"limits.max_extracted_files":        cfg.Limits.MaxExtractedFiles,
+		"limits.max_compression_ratio":      cfg.Limits.MaxCompressionRatio,
+		"security.hash_lookup":              cfg.Security.HashLookup,
+		"security.hash_lookup_url":          cfg.Security.HashLookupURL,
+		"security.hash_lookup_timeout_secs": cfg.Security.HashLookupTimeoutSecs,
+		"system.lock_retries":               cfg.System.LockRetries,
+		"system.lock_retry_delay_secs":      cfg.System.LockRetryDelaySecs,
+		"system.command_retries":            cfg.System.CommandRetries,
+		"system.command_retry_delay_secs":   cfg.System.CommandRetryDelaySecs,
+		"system.inhibit_sleep":              cfg.System.InhibitSleep,
+		"syspkg.provider":                   cfg.Syspkg.Provider,
+		"sandbox.tool":                      cfg.Sandbox.Tool,
+		"sandbox.isolate_home":              cfg.Sandbox.IsolateHome,
+		"sandbox.network":                   cfg.Sandbox.Network,
+		"sandbox.devices":                   cfg.Sandbox.Devices,
+		"sources.format_preference":         cfg.Sources.FormatPreference,
+		"sources.github_token":              cfg.Sources.GitHubToken,
+		"sources.github_api_url":            cfg.Sources.GitHubAPIURL,
+		"upgrade.keep_versions":             cfg.Upgrade.KeepVersions,
+		"upgrade.versioned_layout":          cfg.Upgrade.VersionedLayout,
+		"cache.payloads":                    cfg.Cache.Payloads,
+		"cache.max_size_mb":                 cfg.Cache.MaxSizeMB,
+		"cache.downloads_max_size_mb":       cfg.Cache.DownloadsMaxSizeMB,
+		"self_update.channel":               cfg.SelfUpdate.Channel,
+		"self_update.repository":            cfg.SelfUpdate.Repository,
+		"hooks.pre_install":                 cfg.Hooks.PreInstall,
+		"hooks.post_install":                cfg.Hooks.PostInstall,
+		"hooks.pre_uninstall":               cfg.Hooks.PreUninstall,
+		"hooks.post_uninstall":              cfg.Hooks.PostUninstall,
+		"hooks.abort_on_failure":            cfg.Hooks.AbortOnFailure,
+		"hooks.timeout_secs":                cfg.Hooks.TimeoutSecs,
+		"executables.prefer":                cfg.Executables.Prefer,
+		"executables.ignore":                cfg.Executables.Ignore,
+		"groups":                            cfg.Groups,
+	}
+}
+
+// Keys returns every config key, sorted
+func Keys() []string {
+	return slices.Sorted(maps.Keys(values(&Config{})))
+}
+
+// ActiveFilePath returns the config file Load read, or FilePath when none
+// was found
+func ActiveFilePath() (string, error) {
+	if used := viper.ConfigFileUsed(); used != "" {
+		if _, err := os.Stat(used); err == nil {
+			return used, nil
+		}
+	}
+	return FilePath()
+}
+
+// Settings returns the effective value of every key of cfg, sorted by key.
+// Members of table keys (groups, desktop.toolkit_env_vars, ...) are listed
+// individually; the config file at path (which may not exist) tells file
+// values from defaults.
+func Settings(cfg *Config, path string) ([]Setting, error) {
+	file, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	origin := func(key string) Origin {
+		if _, ok := os.LookupEnv(EnvVarName(key)); ok {
+			return OriginEnv
+		}
+		if file.InConfig(key) {
+			return OriginFile
+		}
+		return OriginDefault
+	}
+
+	all := values(cfg)
+	var settings []Setting
+	for _, key := range Keys() {
+		table, ok := all[key].(map[string][]string)
+		if !ok || len(table) == 0 {
+			settings = append(settings, Setting{Key: key, Value: all[key], Origin: origin(key)})
+			continue
+		}
+		for _, member := range slices.Sorted(maps.Keys(table)) {
+			settings = append(settings, Setting{Key: key + "." + member, Value: table[member], Origin: origin(key)})
+		}
+	}
+	return settings, nil
+}
+
+// Get returns the effective value of key in cfg; members of table keys are
+// addressed as <key>.<member>, e.g. groups.work
+func Get(cfg *Config, key string) (any, error) {
+	key = strings.ToLower(key)
+	all := values(cfg)
+	if value, ok := all[key]; ok {
+		return value, nil
+	}
+	table, member, err := splitTableKey(key)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := all[table].(map[string][]string)[member]
+	if !ok {
+		return nil, fmt.Errorf("%s has no member %q", table, member)
+	}
+	return value, nil
+}
+
+// Set validates raw against the type of key and writes it to the config file
+// at path, keeping the values already there. Lists are comma-separated and
+// table keys are set per member (groups.work = a,b).
+func Set(path, key, raw string) error {
+	key = strings.ToLower(key)
+	value, err := ParseValue(key, raw)
+	if err != nil {
+		return err
+	}
+
+	file, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	file.Set(key, value)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create config directory: %w", err)
+	}
+	if err := file.WriteConfigAs(path); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	return nil
+}
+
+// ParseValue converts raw to the type of key, rejecting unknown keys and
+// values outside the allowed choices
+func ParseValue(key, raw string) (any, error) {
+	key = strings.ToLower(key)
+	zero, ok := values(&Config{})[key]
+	if !ok {
+		table, _, err := splitTableKey(key)
+		if err != nil {
+			return nil, err
+		}
+		key, zero = table+".*", []string(nil)
+	}
+
+	var value any
+	var err error
+	switch zero.(type) {
+	case bool:
+		value, err = strconv.ParseBool(raw)
+	case int:
+		value, err = parseCount(raw, strconv.Atoi)
+	case int64:
+		value, err = parseCount(raw, func(s string) (int64, error) { return strconv.ParseInt(s, 10, 64) })
+	case float64:
+		value, err = parseCount(raw, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+	case string:
+		value = raw
+	case []string:
+		value = splitList(raw)
+	case map[string][]string:
+		return nil, fmt.Errorf("%s is a table: set its members instead, e.g. %s.<name>", key, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q for %s: %w", raw, key, err)
+	}
+
+	if allowed, ok := allowedValues[key]; ok {
+		items, isList := value.([]string)
+		if !isList {
+			items = []string{raw}
+		}
+		for _, item := range items {
+			if !slices.Contains(allowed, item) {
+				return nil, fmt.Errorf("invalid value %q for %s (expected one of: %s)", item, key, strings.Join(allowed, ", "))
+			}
+		}
+	}
+	if slices.Contains(globKeys, key) {
+		for _, pattern := range value.([]string) {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q for %s: %w", pattern, key, err)
+			}
+		}
+	}
+	return value, nil
+}
+
+// CheckFile validates every key of the config file at path against the
+// config schema
+func CheckFile(path string) error {
+	file, err := readFile(path)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, key := range file.AllKeys() {
+		raw := file.Get(key)
+		if _, err := ParseValue(key, formatRaw(raw)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FormatValue renders a config value the way Set accepts it
+func FormatValue(value any) string {
+	switch v := value.(type) {
+	case []string:
+		return strings.Join(v, ",")
+	case map[string][]string:
+		if len(v) == 0 {
+			return ""
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// readFile reads the config file at path into a fresh viper instance; a
+// missing file reads as empty
+func readFile(path string) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigType("toml")
+	v.SetConfigFile(path)
+	if _, err := os.Stat(path); err != nil {
+		return v, nil
+	}
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	return v, nil
+}
+
+// splitTableKey splits <table>.<member> for the table keys
+func splitTableKey(key string) (table, member string, err error) {
+	all := values(&Config{})
+	for candidate, zero := range all {
+		if _, ok := zero.(map[string][]string); !ok {
+			continue
+		}
+		if member, found := strings.CutPrefix(key, candidate+"."); found && member != "" && !strings.Contains(member, ".") {
+			return candidate, member, nil
+		}
+	}
+	return "", "", fmt.Errorf("unknown config key %q (see \"upkg config list\")", key)
+}
+
+// parseCount parses a number that must not be negative
+func parseCount[T int | int64 | float64](raw string, parse func(string) (T, error)) (T, error) {
+	n, err := parse(strings.TrimSpace(raw))
+	if err == nil && n < 0 {
+		err = errors.New("must not be negative")
+	}
+	return n, err
+}
+
+// splitList parses a comma-separated list, dropping empty items
+func splitList(raw string) []string {
+	items := []string{}
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// formatRaw renders a value read from a config file as Set input
+func formatRaw(value any) string {
+	if list, ok := value.([]any); ok {
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		key     string
+		raw     string
+		want    any
+		wantErr string
+	}{
+		{key: "desktop.wayland_env_vars", raw: "false", want: false},
+		{key: "limits.max_package_size_mb", raw: "512", want: int64(512)},
+		{key: "upgrade.keep_versions", raw: "3", want: 3},
+		{key: "desktop.hidpi_scale", raw: "1.5", want: 1.5},
+		{key: "sandbox.devices", raw: "gpu, audio", want: []string{"gpu", "audio"}},
+		{key: "groups.work", raw: "a.deb,b.rpm", want: []string{"a.deb", "b.rpm"}},
+		{key: "Logging.Level", raw: "debug", want: "debug"},
+		{key: "desktop.hidpi", raw: "maybe", wantErr: "invalid value"},
+		{key: "upgrade.keep_versions", raw: "-1", wantErr: "must not be negative"},
+		{key: "logging.level", raw: "loud", wantErr: "expected one of"},
+		{key: "sandbox.devices", raw: "gpu,printer", wantErr: "expected one of"},
+		{key: "groups", raw: "a", wantErr: "is a table"},
+		{key: "executables.prefer", raw: "bin/*-cli", want: []string{"bin/*-cli"}},
+		{key: "executables.ignore", raw: "*-helper,[", wantErr: "invalid pattern"},
+		{key: "desktop.nope", raw: "1", wantErr: "unknown config key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.raw, func(t *testing.T) {
+			got, err := ParseValue(tt.key, tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseValue() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseValue() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetKeepsOtherValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "upkg", "config.toml")
+	if err := Set(path, "logging.level", "debug"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := Set(path, "groups.work", "a.deb,b.rpm"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := Set(path, "logging.level", "loud"); err == nil {
+		t.Fatal("expected invalid value to be rejected")
+	}
+
+	file, err := readFile(path)
+	if err != nil {
+		t.Fatalf("readFile() error = %v", err)
+	}
+	if got := file.GetString("logging.level"); got != "debug" {
+		t.Errorf("logging.level = %q, want debug", got)
+	}
+	if got := file.GetStringSlice("groups.work"); !reflect.DeepEqual(got, []string{"a.deb", "b.rpm"}) {
+		t.Errorf("groups.work = %v, want [a.deb b.rpm]", got)
+	}
+	if file.InConfig("desktop.wayland_env_vars") {
+		t.Error("expected unset keys to stay out of the file")
+	}
+	if err := CheckFile(path); err != nil {
+		t.Errorf("CheckFile() error = %v", err)
+	}
+}
+
+func TestSettingsOrigins(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("[logging]\nlevel = \"debug\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("UPKG_SANDBOX_NETWORK", "false")
+
+	cfg := &Config{Logging: LoggingConfig{Level: "debug"}, Groups: map[string][]string{"work": {"a.deb"}}}
+	settings, err := Settings(cfg, path)
+	if err != nil {
+		t.Fatalf("Settings() error = %v", err)
+	}
+
+	origins := map[string]Origin{}
+	for _, setting := range settings {
+		origins[setting.Key] = setting.Origin
+	}
+	want := map[string]Origin{
+		"logging.level":   OriginFile,
+		"sandbox.network": OriginEnv,
+		"logging.color":   OriginDefault,
+		"groups.work":     OriginDefault,
+	}
+	for key, origin := range want {
+		if origins[key] != origin {
+			t.Errorf("origin of %s = %q, want %q", key, origins[key], origin)
+		}
+	}
+	if _, ok := origins["groups"]; ok {
+		t.Error("expected groups to be listed per member")
+	}
+}
+
+func TestCheckFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := "[desktop]\nhidpi = \"sometimes\"\n[sandbox]\ntool = \"docker\"\n[unknown]\nkey = 1\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := CheckFile(path)
+	if err == nil {
+		t.Fatal("expected CheckFile() to fail")
+	}
+	for _, want := range []string{"desktop.hidpi", "docker", "unknown.key"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("CheckFile() error = %v, want mention of %s", err, want)
+		}
+	}
+}
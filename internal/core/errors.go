@@ -0,0 +1,38 @@
+package core
+
+import "fmt"
+
+// Typed errors returned by the backends. They carry the details the CLI
+// needs to suggest a fix; match them with errors.As.
+
+// ErrMissingTool reports an external command the operation needs that is not in PATH
+type ErrMissingTool struct {
+	Tool        string // Executable looked up in PATH
+	InstallHint string // Command that installs it (empty when unknown)
+}
+
+func (e *ErrMissingTool) Error() string {
+	return fmt.Sprintf("required command %q not found in PATH", e.Tool)
+}
+
+// ErrAlreadyInstalled reports an install whose destination already exists
+type ErrAlreadyInstalled struct {
+	Path string // Existing installation
+}
+
+func (e *ErrAlreadyInstalled) Error() string {
+	return fmt.Sprintf("package already installed at: %s", e.Path)
+}
+
+// ErrUnsupportedFormat reports an input no backend knows how to install
+type ErrUnsupportedFormat struct {
+	Path     string // Input that was rejected
+	FileType string // What the file looks like, e.g. "shell script" (empty when unknown)
+}
+
+func (e *ErrUnsupportedFormat) Error() string {
+	if e.FileType == "" {
+		return fmt.Sprintf("cannot detect package type for: %s", e.Path)
+	}
+	return fmt.Sprintf("cannot detect package type for: %s (detected: %s)", e.Path, e.FileType)
+}
@@ -0,0 +1,30 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestTypedErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&ErrMissingTool{Tool: "debtap"}, `required command "debtap" not found in PATH`},
+		{&ErrAlreadyInstalled{Path: "/opt/app"}, "package already installed at: /opt/app"},
+		{&ErrUnsupportedFormat{Path: "a.pdf"}, "cannot detect package type for: a.pdf"},
+		{&ErrUnsupportedFormat{Path: "a.sh", FileType: "shell script"}, "cannot detect package type for: a.sh (detected: shell script)"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+
+	var missing *ErrMissingTool
+	wrapped := fmt.Errorf("installation failed: %w", &ErrMissingTool{Tool: "bsdtar", InstallHint: "sudo pacman -S libarchive"})
+	if !errors.As(wrapped, &missing) || missing.InstallHint != "sudo pacman -S libarchive" {
+		t.Errorf("errors.As did not find the wrapped ErrMissingTool")
+	}
+}
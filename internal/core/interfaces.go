@@ -2,9 +2,32 @@ package core
 
 // InstallOptions contains options for package installation
 type InstallOptions struct {
-	Force          bool   // Force installation even if already installed
-	SkipDesktop    bool   // Skip desktop integration
-	CustomName     string // Custom application name
-	SkipWaylandEnv bool   // Skip Wayland environment variable injection
-	Overwrite      bool   // Overwrite conflicting files from other packages (pacman --overwrite)
+	Force          bool     // Force installation even if already installed
+	SkipDesktop    bool     // Skip desktop integration
+	CustomName     string   // Custom application name
+	SkipWaylandEnv bool     // Skip Wayland environment variable injection
+	Overwrite      bool     // Overwrite conflicting files from other packages (pacman --overwrite)
+	ExposeAllBins  bool     // Symlink every executable in the payload's bin/ directories (tarball only)
+	Bins           []string // Executables to create launchers for (tarball only): BinsAll, or file names whose first entry becomes the primary launcher
+	BinDesktops    bool     // Also create a desktop entry for every launcher selected with Bins besides the primary one
+	HiDPI          bool     // Inject HiDPI scaling env into the generated launcher
+	Desktop        bool     // Create a desktop entry where it is opt-in (standalone binaries)
+	LinkDir        bool     // Symlink an unpacked app directory into the apps dir instead of copying it (tarball only)
+	Description    string   // Upstream description (e.g. of the GitHub repo), used when the package has none
+	Sandbox        bool     // Launch the app inside bwrap/firejail with the [sandbox] profile (wrapper-based installs)
+	WrapperArgs    []string // Arguments the primary wrapper passes to the app before the user's
+	WrapperEnv     []string // KEY=VALUE pairs the primary wrapper exports before starting the app
+	Method         string   // DEB/RPM install method (MethodPacman, MethodDpkg, MethodDnf or MethodExtract); empty or MethodAuto picks one for the system
 }
+
+// BinsAll selects every executable of the payload in InstallOptions.Bins
+const BinsAll = "all"
+
+// DEB and RPM install methods selectable with InstallOptions.Method
+const (
+	MethodAuto    = "auto"
+	MethodPacman  = "pacman"
+	MethodDpkg    = "dpkg"
+	MethodDnf     = "dnf"
+	MethodExtract = "extract"
+)
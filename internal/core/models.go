@@ -46,12 +46,50 @@ func (r *InstallRecord) GetDesktopFiles() []string {
 // Metadata contains additional package-specific metadata
 type Metadata struct {
 	IconFiles           []string          `json:"icon_files,omitempty"`
+	MetainfoFiles       []string          `json:"metainfo_files,omitempty"` // AppStream metainfo installed into ~/.local/share/metainfo
+	ManPages            []string          `json:"man_pages,omitempty"`      // Man pages shipped in the archive, installed into ~/.local/share/man
+	Completions         []string          `json:"completions,omitempty"`    // Bash, zsh and fish completions shipped in the archive
 	WrapperScript       string            `json:"wrapper_script,omitempty"`
 	WaylandSupport      string            `json:"wayland_support,omitempty"`
+	Framework           string            `json:"framework,omitempty"` // UI toolkit detected in the payload (electron, tauri, flutter, qt, gtk)
 	InstallMethod       string            `json:"install_method,omitempty"`
 	ExtractedMeta       ExtractedMetadata `json:"extracted_metadata,omitempty"`
 	OriginalDesktopFile string            `json:"original_desktop_file,omitempty"` // Original .desktop path before rename for dock compatibility
 	DesktopFiles        []string          `json:"desktop_files,omitempty"`
+	ExposedBins         []string          `json:"exposed_bins,omitempty"`  // Symlinks created by --expose-all-bins
+	Groups              []string          `json:"groups,omitempty"`        // Groups (upkg install @group) the package was installed with
+	SourceURL           string            `json:"source_url,omitempty"`    // URL the package file was downloaded from
+	SourceRepo          string            `json:"source_repo,omitempty"`   // GitHub owner/repo for gh: installs
+	SourceTag           string            `json:"source_tag,omitempty"`    // Release tag installed from SourceRepo
+	SelfUpdating        bool              `json:"self_updating,omitempty"` // The app updates its own files in place
+	AppVersion          string            `json:"app_version,omitempty"`   // Version last reported by a self-updating app's binary
+	Sandbox             string            `json:"sandbox,omitempty"`       // Sandbox tool the wrapper launches the app with (bwrap or firejail)
+	DesktopOverrides    *DesktopOverrides `json:"desktop_overrides,omitempty"`
+	Adopted             bool              `json:"adopted,omitempty"`        // Installed by hand and taken over with upkg adopt
+	Manifest            bool              `json:"manifest,omitempty"`       // Managed by upkg apply; apply --prune removes it once unlisted
+	CustomIcon          string            `json:"custom_icon,omitempty"`    // Icon installed with install --icon or upkg icons set
+	InstalledSize       int64             `json:"installed_size,omitempty"` // Bytes on disk when installed; reported by the package manager for system-managed installs
+	Bins                []string          `json:"bins,omitempty"`           // --bin selection, reapplied on upgrade
+	BinDesktops         bool              `json:"bin_desktops,omitempty"`   // The --bin launchers got desktop entries of their own
+	ExtraWrappers       []string          `json:"extra_wrappers,omitempty"` // Launchers of the executables selected with --bin besides WrapperScript
+	DataDirs            []string          `json:"data_dirs,omitempty"`      // Config, cache and data directories of the app found in the home; removed by uninstall --purge
+	Pinned              bool              `json:"pinned,omitempty"`         // Held with upkg pin; skipped by bulk operations
+	WrapperArgs         []string          `json:"wrapper_args,omitempty"`   // install --wrapper-arg values, reapplied on upgrade
+	WrapperEnv          []string          `json:"wrapper_env,omitempty"`    // install --wrapper-env values, reapplied on upgrade
+	Release             string            `json:"release,omitempty"`        // Release directory under InstallPath the current link points at (versioned layout)
+	Scope               string            `json:"scope,omitempty"`          // "system" for installs made with --system; empty for the user's own
+	ContentHash         string            `json:"content_hash,omitempty"`   // SHA256 of the package file; keys its payload in the payload cache
+	Autostart           string            `json:"autostart,omitempty"`      // Entry in ~/.config/autostart made with install --autostart or upkg autostart enable
+}
+
+// DesktopOverrides are the edits made with upkg desktop edit. They are
+// reapplied to the regenerated desktop entry when the package is upgraded.
+type DesktopOverrides struct {
+	Name       string   `json:"name,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+	ExecArgs   []string `json:"exec_args,omitempty"` // Inserted before the Exec field code
+	Env        []string `json:"env,omitempty"`       // KEY=VALUE pairs added to the Exec env prefix
+	Hidden     *bool    `json:"hidden,omitempty"`    // NoDisplay; nil keeps the generated value
 }
 
 // UnmarshalJSON implements custom JSON unmarshaling to handle legacy formats
@@ -60,6 +98,8 @@ func (m *Metadata) UnmarshalJSON(data []byte) error {
 	aux := &struct {
 		IconFiles    interface{} `json:"icon_files,omitempty"`
 		DesktopFiles interface{} `json:"desktop_files,omitempty"`
+		ExposedBins  interface{} `json:"exposed_bins,omitempty"`
+		Groups       interface{} `json:"groups,omitempty"`
 		*Alias
 	}{
 		Alias: (*Alias)(m),
@@ -71,6 +111,8 @@ func (m *Metadata) UnmarshalJSON(data []byte) error {
 
 	m.IconFiles = convertToStringSlice(aux.IconFiles)
 	m.DesktopFiles = convertToStringSlice(aux.DesktopFiles)
+	m.ExposedBins = convertToStringSlice(aux.ExposedBins)
+	m.Groups = convertToStringSlice(aux.Groups)
 
 	return nil
 }
@@ -100,8 +142,16 @@ func convertToStringSlice(val interface{}) []string {
 const (
 	InstallMethodLocal  = "local"
 	InstallMethodPacman = "pacman"
+	InstallMethodDpkg   = "dpkg"
+	InstallMethodDnf    = "dnf"
 )
 
+// IsSystemManaged reports whether installs recorded with method are owned by
+// the system package manager (pacman, dpkg or dnf) rather than by upkg
+func IsSystemManaged(method string) bool {
+	return method == InstallMethodPacman || method == InstallMethodDpkg || method == InstallMethodDnf
+}
+
 // ExtractedMetadata contains metadata extracted from the package
 type ExtractedMetadata struct {
 	Categories     []string `json:"categories,omitempty"`
@@ -118,6 +168,7 @@ type DesktopEntry struct {
 	Comment        string   `ini:"Comment,omitempty"`
 	Icon           string   `ini:"Icon,omitempty"`
 	Exec           string   `ini:"Exec"`
+	TryExec        string   `ini:"TryExec,omitempty"`
 	Path           string   `ini:"Path,omitempty"`
 	Terminal       bool     `ini:"Terminal,omitempty"`
 	Categories     []string `ini:"Categories,omitempty"`
@@ -126,6 +177,16 @@ type DesktopEntry struct {
 	NoDisplay      bool     `ini:"NoDisplay,omitempty"`
 	Keywords       []string `ini:"Keywords,omitempty"`
 	StartupNotify  bool     `ini:"StartupNotify,omitempty"`
+	// SingleMainWindow tells launchers not to offer "New Window" (Desktop Entry 1.5)
+	SingleMainWindow bool `ini:"SingleMainWindow,omitempty"`
+	// Managed is read from the X-Upkg-Managed key that Write stamps on every
+	// entry, so gc only ever removes entries upkg created
+	Managed bool `ini:"X-Upkg-Managed,omitempty"`
+	// Translations keyed by locale (pt_BR for Name[pt_BR]=...)
+	LocalizedName        map[string]string   `ini:"-"`
+	LocalizedGenericName map[string]string   `ini:"-"`
+	LocalizedComment     map[string]string   `ini:"-"`
+	LocalizedKeywords    map[string][]string `ini:"-"`
 }
 
 // IconFile represents an icon discovered during installation
@@ -135,6 +196,26 @@ type IconFile struct {
 	Ext  string // "png", "svg", "ico", "xpm"
 }
 
+// ToolRequirement declares an external tool a backend depends on
+type ToolRequirement struct {
+	Name         string            // Executable looked up in PATH
+	Alternatives []string          // Other executables that also satisfy the requirement
+	Optional     bool              // Missing optional tools only degrade functionality
+	MinVersion   string            // Minimum version, e.g. "3.2" (empty = any)
+	VersionArgs  []string          // Arguments that print the version (default: --version)
+	Purpose      string            // Why the tool is needed
+	Packages     map[string]string // Distro family -> package providing the tool
+	AUR          bool              // The Arch package is only in the AUR
+}
+
+// Distro families used as ToolRequirement.Packages keys
+const (
+	DistroArch   = "arch"
+	DistroDebian = "debian"
+	DistroFedora = "fedora"
+	DistroSUSE   = "suse"
+)
+
 // Exit codes (align with CLI.md section 12)
 const (
 	ExitSuccess         = 0
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// InstallResult is what a backend reports after a successful install
+type InstallResult struct {
+	Record       *InstallRecord `json:"record"`
+	CreatedFiles []string       `json:"created_files,omitempty"`
+	Warnings     []string       `json:"warnings,omitempty"`
+	Skipped      []string       `json:"skipped,omitempty"` // Optional steps that did not run, with the reason
+	// Executables the main one was chosen from, best first (tarball, DEB and RPM payloads)
+	Candidates []ExecutableCandidate `json:"candidates,omitempty"`
+	Duration   time.Duration         `json:"duration_ns"`
+
+	started time.Time
+}
+
+// ExecutableCandidate is an executable considered as the main one of a
+// package, with the heuristics and rules that scored it
+type ExecutableCandidate struct {
+	Path    string   `json:"path"`
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons,omitempty"` // e.g. "+20 in a bin/ directory"
+	Ignored bool     `json:"ignored,omitempty"` // Matched an executables.ignore rule
+	Chosen  bool     `json:"chosen,omitempty"`
+}
+
+// UninstallResult is what a backend reports after a successful uninstall
+type UninstallResult struct {
+	Warnings []string      `json:"warnings,omitempty"`
+	Skipped  []string      `json:"skipped,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+
+	started time.Time
+}
+
+// NewInstallResult starts timing an install
+func NewInstallResult() *InstallResult {
+	return &InstallResult{started: time.Now()}
+}
+
+// Warn records a problem that did not stop the install
+func (r *InstallResult) Warn(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Skip records an optional step that did not run
+func (r *InstallResult) Skip(step, reason string) {
+	r.Skipped = append(r.Skipped, step+": "+reason)
+}
+
+// Finish attaches the record, lists the files it owns and stops the clock
+func (r *InstallResult) Finish(record *InstallRecord) *InstallResult {
+	r.Record = record
+	r.CreatedFiles = record.ManagedPaths()
+	r.Duration = time.Since(r.started)
+	return r
+}
+
+// GetRecord returns the installed record; it is nil-safe
+func (r *InstallResult) GetRecord() *InstallRecord {
+	if r == nil {
+		return nil
+	}
+	return r.Record
+}
+
+// NewUninstallResult starts timing an uninstall
+func NewUninstallResult() *UninstallResult {
+	return &UninstallResult{started: time.Now()}
+}
+
+// Warn records a problem that did not stop the uninstall
+func (r *UninstallResult) Warn(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Skip records a step that did not run
+func (r *UninstallResult) Skip(step, reason string) {
+	r.Skipped = append(r.Skipped, step+": "+reason)
+}
+
+// Finish stops the clock
+func (r *UninstallResult) Finish() *UninstallResult {
+	r.Duration = time.Since(r.started)
+	return r
+}
+
+// ManagedPaths lists the files and directories upkg created for the
+// install: payload, launcher, exposed binaries, desktop entries, icons and
+// AppStream metainfo
+func (r *InstallRecord) ManagedPaths() []string {
+	if r == nil {
+		return nil
+	}
+	candidates := []string{r.InstallPath, r.Metadata.WrapperScript}
+	candidates = append(candidates, r.Metadata.ExtraWrappers...)
+	candidates = append(candidates, r.Metadata.ExposedBins...)
+	candidates = append(candidates, r.GetDesktopFiles()...)
+	candidates = append(candidates, r.Metadata.IconFiles...)
+	candidates = append(candidates, r.Metadata.MetainfoFiles...)
+	candidates = append(candidates, r.Metadata.ManPages...)
+	candidates = append(candidates, r.Metadata.Completions...)
+
+	seen := make(map[string]bool, len(candidates))
+	paths := make([]string, 0, len(candidates))
+	for _, path := range candidates {
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	return paths
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInstallResult_Finish(t *testing.T) {
+	result := NewInstallResult()
+	result.Warn("icons not installed: %s", "no icons")
+	result.Skip("desktop entry", "--skip-desktop")
+
+	record := &InstallRecord{
+		InstallPath: "/apps/tool",
+		DesktopFile: "/share/applications/tool.desktop",
+		Metadata: Metadata{
+			WrapperScript: "/bin/tool",
+			ExposedBins:   []string{"/bin/tool", "/bin/tool-cli"},
+			IconFiles:     []string{"/icons/tool.png"},
+		},
+	}
+	got := result.Finish(record)
+
+	if got.Record != record {
+		t.Fatalf("Finish() did not attach the record")
+	}
+	wantFiles := []string{"/apps/tool", "/bin/tool", "/bin/tool-cli", "/share/applications/tool.desktop", "/icons/tool.png"}
+	if !reflect.DeepEqual(got.CreatedFiles, wantFiles) {
+		t.Errorf("CreatedFiles = %v, want %v", got.CreatedFiles, wantFiles)
+	}
+	if !reflect.DeepEqual(got.Warnings, []string{"icons not installed: no icons"}) {
+		t.Errorf("Warnings = %v", got.Warnings)
+	}
+	if !reflect.DeepEqual(got.Skipped, []string{"desktop entry: --skip-desktop"}) {
+		t.Errorf("Skipped = %v", got.Skipped)
+	}
+	if got.Duration < 0 {
+		t.Errorf("Duration = %v, want >= 0", got.Duration)
+	}
+}
+
+func TestInstallResult_GetRecordNil(t *testing.T) {
+	var result *InstallResult
+	if result.GetRecord() != nil {
+		t.Errorf("GetRecord() on nil result should be nil")
+	}
+}
+
+func TestInstallRecord_ManagedPathsNil(t *testing.T) {
+	var record *InstallRecord
+	if paths := record.ManagedPaths(); paths != nil {
+		t.Errorf("ManagedPaths() = %v, want nil", paths)
+	}
+}
+
+func TestUninstallResult_Finish(t *testing.T) {
+	result := NewUninstallResult()
+	result.Warn("failed to remove %s", "/bin/tool")
+	result.Skip("pacman removal", "package not found")
+	result.Finish()
+
+	if len(result.Warnings) != 1 || len(result.Skipped) != 1 {
+		t.Errorf("unexpected notes: warnings=%v skipped=%v", result.Warnings, result.Skipped)
+	}
+}
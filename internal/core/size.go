@@ -0,0 +1,33 @@
+package core
+
+import (
+	"os"
+
+	"github.com/spf13/afero"
+)
+
+// PathSize returns the size and file count of a file or directory tree
+func PathSize(fs afero.Fs, path string) (int64, int) {
+	info, err := fs.Stat(path)
+	if err != nil {
+		return 0, 0
+	}
+	if !info.IsDir() {
+		return info.Size(), 1
+	}
+
+	var size int64
+	var files int
+	// Best-effort: unreadable entries are skipped
+	_ = afero.Walk(fs, path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() {
+			size += info.Size()
+			files++
+		}
+		return nil
+	})
+	return size, files
+}
@@ -0,0 +1,29 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestPathSize(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	if size, files := PathSize(fs, "/missing"); size != 0 || files != 0 {
+		t.Errorf("PathSize(/missing) = %d, %d; want 0, 0", size, files)
+	}
+
+	if err := afero.WriteFile(fs, "/dir/one", []byte("1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := afero.WriteFile(fs, "/dir/sub/two", []byte("22"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if size, files := PathSize(fs, "/dir"); size != 3 || files != 2 {
+		t.Errorf("PathSize(/dir) = %d, %d; want 3, 2", size, files)
+	}
+	if size, files := PathSize(fs, "/dir/sub/two"); size != 2 || files != 1 {
+		t.Errorf("PathSize(/dir/sub/two) = %d, %d; want 2, 1", size, files)
+	}
+}
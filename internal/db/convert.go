@@ -40,3 +40,52 @@ func ToInstallRecord(dbRecord *Install) *core.InstallRecord {
 
 	return record
 }
+
+// FromInstallRecord converts a core.InstallRecord to the db.Install stored in the database
+func FromInstallRecord(record *core.InstallRecord) *Install {
+	return &Install{
+		InstallID:    record.InstallID,
+		PackageType:  string(record.PackageType),
+		Name:         record.Name,
+		Version:      record.Version,
+		InstallDate:  record.InstallDate,
+		OriginalFile: record.OriginalFile,
+		InstallPath:  record.InstallPath,
+		DesktopFile:  record.DesktopFile,
+		Metadata: map[string]interface{}{
+			"icon_files":        record.Metadata.IconFiles,
+			"metainfo_files":    record.Metadata.MetainfoFiles,
+			"man_pages":         record.Metadata.ManPages,
+			"completions":       record.Metadata.Completions,
+			"wrapper_script":    record.Metadata.WrapperScript,
+			"wayland_support":   record.Metadata.WaylandSupport,
+			"framework":         record.Metadata.Framework,
+			"install_method":    record.Metadata.InstallMethod,
+			"desktop_files":     record.Metadata.DesktopFiles,
+			"exposed_bins":      record.Metadata.ExposedBins,
+			"groups":            record.Metadata.Groups,
+			"source_url":        record.Metadata.SourceURL,
+			"source_repo":       record.Metadata.SourceRepo,
+			"source_tag":        record.Metadata.SourceTag,
+			"self_updating":     record.Metadata.SelfUpdating,
+			"app_version":       record.Metadata.AppVersion,
+			"sandbox":           record.Metadata.Sandbox,
+			"desktop_overrides": record.Metadata.DesktopOverrides,
+			"adopted":           record.Metadata.Adopted,
+			"manifest":          record.Metadata.Manifest,
+			"custom_icon":       record.Metadata.CustomIcon,
+			"installed_size":    record.Metadata.InstalledSize,
+			"bins":              record.Metadata.Bins,
+			"bin_desktops":      record.Metadata.BinDesktops,
+			"wrapper_args":      record.Metadata.WrapperArgs,
+			"wrapper_env":       record.Metadata.WrapperEnv,
+			"extra_wrappers":    record.Metadata.ExtraWrappers,
+			"data_dirs":         record.Metadata.DataDirs,
+			"pinned":            record.Metadata.Pinned,
+			"release":           record.Metadata.Release,
+			"scope":             record.Metadata.Scope,
+			"content_hash":      record.Metadata.ContentHash,
+			"autostart":         record.Metadata.Autostart,
+		},
+	}
+}
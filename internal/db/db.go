@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"os"
 	"time"
 
 	_ "modernc.org/sqlite" // sqlite driver
@@ -105,32 +106,178 @@ CREATE TABLE IF NOT EXISTS schema_migrations (
 	return nil
 }
 
-const currentSchemaVersion = 1
+const currentSchemaVersion = 2
+
+// migrations upgrade the schema one version at a time; the initial schema
+// is created by initSchema
+var migrations = []struct {
+	version     int
+	description string
+	statements  string
+}{
+	{1, "initial schema", ""},
+	{2, "retained versions", `
+CREATE TABLE IF NOT EXISTS versions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    version TEXT,
+    retained_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    dir TEXT NOT NULL,
+    payload TEXT,
+    files TEXT,
+    record TEXT NOT NULL
+);
 
-// applyMigrations records/applies schema migrations.
-// For now we only stamp the initial schema version if none exists.
+CREATE INDEX IF NOT EXISTS idx_versions_name ON versions(name);
+`},
+}
+
+// applyMigrations applies the migrations newer than the stamped schema
+// version. An existing database is backed up before it is migrated, and one
+// stamped by a newer upkg is refused rather than used with a schema this
+// build does not know.
 func (db *DB) applyMigrations(ctx context.Context) error {
+	current, err := db.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkSchemaVersion(current); err != nil || current == currentSchemaVersion {
+		return err
+	}
+	return db.migrate(ctx)
+}
+
+// migrate runs the pending migrations in one transaction holding the write
+// lock. Concurrent openers (parallel installs, another upkg process) wait
+// for it and then find the schema already migrated, so a migration is never
+// backed up or applied twice.
+func (db *DB) migrate(ctx context.Context) error {
+	conn, err := db.write.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("open migration connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err := conn.ExecContext(ctx, `BEGIN IMMEDIATE`); err != nil {
+		return fmt.Errorf("lock database for migration: %w", err)
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_, _ = conn.ExecContext(context.Background(), `ROLLBACK`)
+		}
+	}()
+
+	// Re-read under the lock: another opener may have migrated meanwhile
 	var current int
-	if err := db.write.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+	if err := conn.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
 		return fmt.Errorf("read migrations version: %w", err)
 	}
+	if err := checkSchemaVersion(current); err != nil || current == currentSchemaVersion {
+		return err
+	}
 
-	if current >= currentSchemaVersion {
-		return nil
+	if current > 0 {
+		// The read pool sees the committed, unmigrated database
+		if err := db.backup(ctx, fmt.Sprintf("%s.schema-v%d.bak", db.path, current)); err != nil {
+			return fmt.Errorf("back up before migrating: %w", err)
+		}
 	}
 
-	_, err := db.write.ExecContext(ctx,
-		`INSERT INTO schema_migrations (version, description) VALUES (?, ?)`,
-		currentSchemaVersion,
-		"initial schema",
-	)
-	if err != nil {
-		return fmt.Errorf("insert migration version: %w", err)
+	for _, migration := range migrations {
+		if migration.version <= current {
+			continue
+		}
+		if migration.statements != "" {
+			if _, err := conn.ExecContext(ctx, migration.statements); err != nil {
+				return fmt.Errorf("migrate to version %d: %w", migration.version, err)
+			}
+		}
+		if _, err := conn.ExecContext(ctx,
+			`INSERT INTO schema_migrations (version, description) VALUES (?, ?)`,
+			migration.version,
+			migration.description,
+		); err != nil {
+			return fmt.Errorf("insert migration version %d: %w", migration.version, err)
+		}
+	}
+
+	if _, err := conn.ExecContext(ctx, `COMMIT`); err != nil {
+		return fmt.Errorf("commit migrations: %w", err)
+	}
+	committed = true
+	return nil
+}
+
+// checkSchemaVersion refuses databases stamped by a newer upkg
+func checkSchemaVersion(version int) error {
+	if version > currentSchemaVersion {
+		return fmt.Errorf("database schema version %d is newer than the %d supported by this upkg; upgrade upkg", version, currentSchemaVersion)
+	}
+	return nil
+}
+
+// backup writes a consistent copy of the database to path, replacing it
+func (db *DB) backup(ctx context.Context, path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if _, err := db.read.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		return err
+	}
+	return nil
+}
+
+// SchemaVersion returns the newest migration applied to the database
+func (db *DB) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	if err := db.write.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("read migrations version: %w", err)
 	}
+	return version, nil
+}
 
+// LatestSchemaVersion returns the schema version this build migrates to
+func LatestSchemaVersion() int {
+	return currentSchemaVersion
+}
+
+// Vacuum rebuilds the database file, reclaiming free pages
+func (db *DB) Vacuum(ctx context.Context) error {
+	if _, err := db.write.ExecContext(ctx, `VACUUM`); err != nil {
+		return fmt.Errorf("vacuum: %w", err)
+	}
+	if _, err := db.write.ExecContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
 	return nil
 }
 
+// IntegrityCheck runs SQLite's integrity check and returns the problems it
+// reports, none for a sound database
+func (db *DB) IntegrityCheck(ctx context.Context) ([]string, error) {
+	rows, err := db.read.QueryContext(ctx, `PRAGMA integrity_check`)
+	if err != nil {
+		return nil, fmt.Errorf("integrity check: %w", err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, fmt.Errorf("scan integrity check: %w", err)
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return problems, nil
+}
+
 // Install holds install record methods
 type Install struct {
 	InstallID    string
@@ -2,6 +2,9 @@ package db
 
 import (
 	"context"
+	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -105,8 +108,183 @@ func TestApplyMigrations(t *testing.T) {
 		t.Fatalf("Failed to query schema_migrations: %v", err)
 	}
 
-	if count != 1 {
-		t.Errorf("schema_migrations count = %d, want 1", count)
+	if count != currentSchemaVersion {
+		t.Errorf("schema_migrations count = %d, want %d", count, currentSchemaVersion)
+	}
+
+	// Reopening does not apply migrations twice
+	db.Close()
+	db, err = New(ctx, tmpfile)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	if err := db.read.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations").Scan(&count); err != nil {
+		t.Fatalf("Failed to query schema_migrations: %v", err)
+	}
+	if count != currentSchemaVersion {
+		t.Errorf("schema_migrations count after reopen = %d, want %d", count, currentSchemaVersion)
+	}
+}
+
+func TestApplyMigrations_UpgradeAndNewerSchema(t *testing.T) {
+	ctx := context.Background()
+	tmpfile := t.TempDir() + "/test_upgrade.db"
+	db, err := New(ctx, tmpfile)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+
+	// Roll the database back to schema version 1
+	if _, err := db.write.ExecContext(ctx, "DROP TABLE versions; DELETE FROM schema_migrations WHERE version > 1"); err != nil {
+		t.Fatalf("Failed to downgrade schema: %v", err)
+	}
+	db.Close()
+
+	db, err = New(ctx, tmpfile)
+	if err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	if version, err := db.SchemaVersion(ctx); err != nil || version != LatestSchemaVersion() {
+		t.Errorf("SchemaVersion() = %d, %v, want %d", version, err, LatestSchemaVersion())
+	}
+	if _, err := os.Stat(tmpfile + ".schema-v1.bak"); err != nil {
+		t.Errorf("expected a backup of the version 1 database: %v", err)
+	}
+
+	// A database migrated by a newer upkg is refused
+	if _, err := db.write.ExecContext(ctx, "INSERT INTO schema_migrations (version, description) VALUES (?, 'future')", LatestSchemaVersion()+1); err != nil {
+		t.Fatalf("Failed to stamp newer version: %v", err)
+	}
+	db.Close()
+	if _, err := New(ctx, tmpfile); err == nil || !strings.Contains(err.Error(), "newer than") {
+		t.Errorf("New() error = %v, want newer schema error", err)
+	}
+}
+
+func TestApplyMigrations_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	tmpfile := t.TempDir() + "/test_concurrent.db"
+	db, err := New(ctx, tmpfile)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	if _, err := db.write.ExecContext(ctx, "DROP TABLE versions; DELETE FROM schema_migrations WHERE version > 1"); err != nil {
+		t.Fatalf("Failed to downgrade schema: %v", err)
+	}
+	db.Close()
+
+	// Parallel openers of a version 1 database migrate it exactly once
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			opened, err := New(ctx, tmpfile)
+			if err != nil {
+				errs <- err
+				return
+			}
+			_ = opened.Close()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("New() error = %v", err)
+	}
+
+	db, err = New(ctx, tmpfile)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer db.Close()
+	var count int
+	if err := db.read.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations").Scan(&count); err != nil {
+		t.Fatalf("Failed to query schema_migrations: %v", err)
+	}
+	if count != currentSchemaVersion {
+		t.Errorf("schema_migrations count = %d, want %d", count, currentSchemaVersion)
+	}
+}
+
+func TestVacuumAndIntegrityCheck(t *testing.T) {
+	ctx := context.Background()
+	db, err := New(ctx, t.TempDir()+"/test_vacuum.db")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Vacuum(ctx); err != nil {
+		t.Errorf("Vacuum() error = %v", err)
+	}
+	problems, err := db.IntegrityCheck(ctx)
+	if err != nil || len(problems) != 0 {
+		t.Errorf("IntegrityCheck() = %v, %v, want no problems", problems, err)
+	}
+}
+
+func TestVersions(t *testing.T) {
+	ctx := context.Background()
+	db, err := New(ctx, t.TempDir()+"/test_versions.db")
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	for i, v := range []string{"1.0", "1.1"} {
+		version := &Version{
+			Name:       "app",
+			Version:    v,
+			RetainedAt: now.Add(time.Duration(i) * time.Minute),
+			Dir:        "/data/versions/app/" + v,
+			Payload:    "/data/versions/app/" + v + "/payload/app",
+			Files:      map[string]string{"/bin/app": "/data/versions/app/" + v + "/files/0-app"},
+			Record:     Install{InstallID: "id-" + v, Name: "app", Version: v, Metadata: map[string]interface{}{"wrapper_script": "/bin/app"}},
+		}
+		if err := db.AddVersion(ctx, version); err != nil {
+			t.Fatalf("AddVersion() error = %v", err)
+		}
+		if version.ID == 0 {
+			t.Error("AddVersion() did not set the ID")
+		}
+	}
+	if err := db.AddVersion(ctx, &Version{Name: "other", Dir: "/data/versions/other/1", RetainedAt: now}); err != nil {
+		t.Fatalf("AddVersion() error = %v", err)
+	}
+
+	versions, err := db.ListVersions(ctx, "app")
+	if err != nil {
+		t.Fatalf("ListVersions() error = %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version != "1.1" {
+		t.Fatalf("ListVersions() = %+v, want 1.1 then 1.0", versions)
+	}
+	if versions[0].Record.InstallID != "id-1.1" || versions[0].Files["/bin/app"] != "/data/versions/app/1.1/files/0-app" {
+		t.Errorf("ListVersions() record = %+v", versions[0])
+	}
+
+	all, err := db.ListVersions(ctx, "")
+	if err != nil || len(all) != 3 {
+		t.Errorf("ListVersions(\"\") = %d versions, %v; want 3", len(all), err)
+	}
+
+	versions[1].Dir = "/mnt/versions/app/1.0"
+	if err := db.UpdateVersion(ctx, &versions[1]); err != nil {
+		t.Fatalf("UpdateVersion() error = %v", err)
+	}
+	if err := db.DeleteVersion(ctx, versions[0].ID); err != nil {
+		t.Fatalf("DeleteVersion() error = %v", err)
+	}
+	if err := db.DeleteVersion(ctx, versions[0].ID); err == nil {
+		t.Error("DeleteVersion() of a missing version should fail")
+	}
+
+	versions, err = db.ListVersions(ctx, "app")
+	if err != nil || len(versions) != 1 || versions[0].Dir != "/mnt/versions/app/1.0" {
+		t.Errorf("ListVersions() after update = %+v, %v", versions, err)
 	}
 }
 
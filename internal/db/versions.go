@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Version is a previous installation retained by upgrade for rollback
+type Version struct {
+	ID         int64
+	Name       string // Name of the package the version belongs to
+	Version    string
+	RetainedAt time.Time
+	Dir        string            // Directory holding the retained payload and files
+	Payload    string            // Retained payload, moved back to Record.InstallPath on rollback
+	Files      map[string]string // Integration file path → retained copy
+	Record     Install           // Install record of the version
+}
+
+// AddVersion stores a retained version and sets its ID
+func (db *DB) AddVersion(ctx context.Context, version *Version) error {
+	filesJSON, recordJSON, err := marshalVersion(version)
+	if err != nil {
+		return err
+	}
+
+	query := `
+INSERT INTO versions (name, version, retained_at, dir, payload, files, record)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := db.write.ExecContext(ctx, query,
+		version.Name,
+		version.Version,
+		version.RetainedAt,
+		version.Dir,
+		version.Payload,
+		filesJSON,
+		recordJSON,
+	)
+	if err != nil {
+		return fmt.Errorf("insert version: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("read version id: %w", err)
+	}
+	version.ID = id
+	return nil
+}
+
+// ListVersions retrieves the retained versions of a package, newest first.
+// An empty name lists the versions of every package.
+func (db *DB) ListVersions(ctx context.Context, name string) ([]Version, error) {
+	query := `
+SELECT id, name, version, retained_at, dir, payload, files, record
+FROM versions WHERE ? = '' OR name = ? ORDER BY retained_at DESC, id DESC
+	`
+
+	rows, err := db.read.QueryContext(ctx, query, name, name)
+	if err != nil {
+		return nil, fmt.Errorf("query versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []Version
+	for rows.Next() {
+		var version Version
+		var filesJSON, recordJSON string
+
+		err := rows.Scan(
+			&version.ID,
+			&version.Name,
+			&version.Version,
+			&version.RetainedAt,
+			&version.Dir,
+			&version.Payload,
+			&filesJSON,
+			&recordJSON,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan version: %w", err)
+		}
+
+		if err := json.Unmarshal([]byte(filesJSON), &version.Files); err != nil {
+			return nil, fmt.Errorf("unmarshal version files: %w", err)
+		}
+		if err := json.Unmarshal([]byte(recordJSON), &version.Record); err != nil {
+			return nil, fmt.Errorf("unmarshal version record: %w", err)
+		}
+
+		versions = append(versions, version)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return versions, nil
+}
+
+// UpdateVersion updates the paths and record of a retained version
+func (db *DB) UpdateVersion(ctx context.Context, version *Version) error {
+	filesJSON, recordJSON, err := marshalVersion(version)
+	if err != nil {
+		return err
+	}
+
+	query := `
+UPDATE versions SET
+    dir = ?,
+    payload = ?,
+    files = ?,
+    record = ?
+WHERE id = ?
+	`
+
+	result, err := db.write.ExecContext(ctx, query,
+		version.Dir,
+		version.Payload,
+		filesJSON,
+		recordJSON,
+		version.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("update version: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("version not found: %d", version.ID)
+	}
+
+	return nil
+}
+
+// DeleteVersion removes a retained version
+func (db *DB) DeleteVersion(ctx context.Context, id int64) error {
+	result, err := db.write.ExecContext(ctx, "DELETE FROM versions WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("delete version: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("version not found: %d", id)
+	}
+
+	return nil
+}
+
+// marshalVersion encodes the JSON columns of a version
+func marshalVersion(version *Version) (filesJSON, recordJSON string, err error) {
+	files, err := json.Marshal(version.Files)
+	if err != nil {
+		return "", "", fmt.Errorf("marshal version files: %w", err)
+	}
+	record, err := json.Marshal(version.Record)
+	if err != nil {
+		return "", "", fmt.Errorf("marshal version record: %w", err)
+	}
+	return string(files), string(record), nil
+}
@@ -0,0 +1,274 @@
+package desktop
+
+import "strings"
+
+// DefaultMainCategory is used when an entry has no main category to derive
+const DefaultMainCategory = "Utility"
+
+// mainCategories are the registered main categories of the Desktop Menu
+// Specification; every entry should carry at least one
+var mainCategories = map[string]bool{
+	"AudioVideo":  true,
+	"Audio":       true,
+	"Video":       true,
+	"Development": true,
+	"Education":   true,
+	"Game":        true,
+	"Graphics":    true,
+	"Network":     true,
+	"Office":      true,
+	"Science":     true,
+	"Settings":    true,
+	"System":      true,
+	"Utility":     true,
+}
+
+// additionalCategories are the registered additional categories, mapped to
+// the main category the specification relates them to ("" when any fits)
+var additionalCategories = map[string]string{
+	"Building":               "Development",
+	"Debugger":               "Development",
+	"IDE":                    "Development",
+	"GUIDesigner":            "Development",
+	"Profiling":              "Development",
+	"RevisionControl":        "Development",
+	"Translation":            "Development",
+	"Calendar":               "Office",
+	"ContactManagement":      "Office",
+	"Database":               "Office",
+	"Dictionary":             "Office",
+	"Chart":                  "Office",
+	"Email":                  "Network",
+	"Finance":                "Office",
+	"FlowChart":              "Office",
+	"PDA":                    "Office",
+	"ProjectManagement":      "Office",
+	"Presentation":           "Office",
+	"Spreadsheet":            "Office",
+	"WordProcessor":          "Office",
+	"2DGraphics":             "Graphics",
+	"VectorGraphics":         "Graphics",
+	"RasterGraphics":         "Graphics",
+	"3DGraphics":             "Graphics",
+	"Scanning":               "Graphics",
+	"OCR":                    "Graphics",
+	"Photography":            "Graphics",
+	"Publishing":             "Graphics",
+	"Viewer":                 "Graphics",
+	"TextTools":              "Utility",
+	"DesktopSettings":        "Settings",
+	"HardwareSettings":       "Settings",
+	"Printing":               "Settings",
+	"PackageManager":         "Settings",
+	"Dialup":                 "Network",
+	"InstantMessaging":       "Network",
+	"Chat":                   "Network",
+	"IRCClient":              "Network",
+	"Feed":                   "Network",
+	"FileTransfer":           "Network",
+	"HamRadio":               "Network",
+	"News":                   "Network",
+	"P2P":                    "Network",
+	"RemoteAccess":           "Network",
+	"Telephony":              "Network",
+	"TelephonyTools":         "Utility",
+	"VideoConference":        "Network",
+	"WebBrowser":             "Network",
+	"WebDevelopment":         "Development",
+	"Midi":                   "Audio",
+	"Mixer":                  "Audio",
+	"Sequencer":              "Audio",
+	"Tuner":                  "Audio",
+	"TV":                     "Video",
+	"AudioVideoEditing":      "AudioVideo",
+	"Player":                 "AudioVideo",
+	"Recorder":               "AudioVideo",
+	"DiscBurning":            "AudioVideo",
+	"ActionGame":             "Game",
+	"AdventureGame":          "Game",
+	"ArcadeGame":             "Game",
+	"BoardGame":              "Game",
+	"BlocksGame":             "Game",
+	"CardGame":               "Game",
+	"KidsGame":               "Game",
+	"LogicGame":              "Game",
+	"RolePlaying":            "Game",
+	"Shooter":                "Game",
+	"Simulation":             "Game",
+	"SportsGame":             "Game",
+	"StrategyGame":           "Game",
+	"Art":                    "Education",
+	"Construction":           "Education",
+	"Music":                  "AudioVideo",
+	"Languages":              "Education",
+	"ArtificialIntelligence": "Science",
+	"Astronomy":              "Science",
+	"Biology":                "Science",
+	"Chemistry":              "Science",
+	"ComputerScience":        "Science",
+	"DataVisualization":      "Science",
+	"Economy":                "Education",
+	"Electricity":            "Science",
+	"Geography":              "Education",
+	"Geology":                "Science",
+	"Geoscience":             "Science",
+	"History":                "Education",
+	"Humanities":             "Education",
+	"ImageProcessing":        "Science",
+	"Literature":             "Education",
+	"Maps":                   "Utility",
+	"Math":                   "Science",
+	"NumericalAnalysis":      "Science",
+	"MedicalSoftware":        "Science",
+	"Physics":                "Science",
+	"Robotics":               "Science",
+	"Spirituality":           "Education",
+	"Sports":                 "Education",
+	"ParallelComputing":      "Science",
+	"Amusement":              "",
+	"Archiving":              "Utility",
+	"Compression":            "Utility",
+	"Electronics":            "",
+	"Emulator":               "System",
+	"Engineering":            "",
+	"FileTools":              "Utility",
+	"FileManager":            "System",
+	"TerminalEmulator":       "System",
+	"Filesystem":             "System",
+	"Monitor":                "System",
+	"Security":               "System",
+	"Accessibility":          "Utility",
+	"Calculator":             "Utility",
+	"Clock":                  "Utility",
+	"TextEditor":             "Utility",
+	"Documentation":          "",
+	"Adult":                  "",
+	"Core":                   "",
+	"KDE":                    "",
+	"GNOME":                  "",
+	"XFCE":                   "",
+	"DDE":                    "",
+	"GTK":                    "",
+	"Qt":                     "",
+	"Motif":                  "",
+	"Java":                   "",
+	"ConsoleOnly":            "",
+}
+
+// categoryAliases maps common unregistered or deprecated names, lowercased,
+// to registered categories; an empty value drops the category
+var categoryAliases = map[string]string{
+	"application":    "", // Deprecated by the specification
+	"applications":   "",
+	"accessories":    "Utility",
+	"accessory":      "Utility",
+	"utilities":      "Utility",
+	"tools":          "Utility",
+	"internet":       "Network",
+	"web":            "Network",
+	"browser":        "WebBrowser",
+	"multimedia":     "AudioVideo",
+	"media":          "AudioVideo",
+	"sound":          "Audio",
+	"games":          "Game",
+	"programming":    "Development",
+	"developer":      "Development",
+	"developertools": "Development",
+	"devtools":       "Development",
+	"productivity":   "Office",
+	"systemtools":    "System",
+	"system-tools":   "System",
+	"preferences":    "Settings",
+	"editor":         "TextEditor",
+	"messaging":      "InstantMessaging",
+	"im":             "InstantMessaging",
+	"mail":           "Email",
+	"terminal":       "TerminalEmulator",
+	"photo":          "Photography",
+}
+
+// canonicalCategories maps lowercased registered names to their spelling
+var canonicalCategories = func() map[string]string {
+	m := make(map[string]string, len(mainCategories)+len(additionalCategories))
+	for name := range mainCategories {
+		m[strings.ToLower(name)] = name
+	}
+	for name := range additionalCategories {
+		m[strings.ToLower(name)] = name
+	}
+	return m
+}()
+
+// IsRegisteredCategory reports whether name is a registered main or
+// additional category, or an X- vendor extension
+func IsRegisteredCategory(name string) bool {
+	if strings.HasPrefix(name, "X-") {
+		return true
+	}
+	_, ok := additionalCategories[name]
+	return ok || mainCategories[name]
+}
+
+// InvalidCategories returns the entries of categories that are neither
+// registered nor X- extensions
+func InvalidCategories(categories []string) []string {
+	var invalid []string
+	for _, name := range categories {
+		if !IsRegisteredCategory(name) {
+			invalid = append(invalid, name)
+		}
+	}
+	return invalid
+}
+
+// NormalizeCategories maps categories onto the registered list: spelling is
+// fixed, known aliases are translated, unknown names are dropped and a main
+// category is added when none is present (derived from the additional
+// categories, DefaultMainCategory otherwise). Audio and Video also get
+// AudioVideo, as the specification requires.
+func NormalizeCategories(categories []string) []string {
+	result := make([]string, 0, len(categories)+1)
+	seen := make(map[string]bool, len(categories)+1)
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+
+	for _, raw := range categories {
+		name := strings.TrimSpace(raw)
+		if strings.HasPrefix(name, "X-") {
+			add(name)
+			continue
+		}
+		key := strings.ToLower(name)
+		if canonical, ok := canonicalCategories[key]; ok {
+			add(canonical)
+		} else if alias, ok := categoryAliases[strings.ReplaceAll(key, " ", "")]; ok {
+			add(alias)
+		}
+	}
+
+	hasMain := false
+	for _, name := range result {
+		hasMain = hasMain || mainCategories[name]
+	}
+	if !hasMain {
+		for _, name := range append([]string(nil), result...) {
+			if main := additionalCategories[name]; main != "" {
+				add(main)
+				hasMain = true
+				break
+			}
+		}
+	}
+	if !hasMain {
+		add(DefaultMainCategory)
+	}
+	if seen["Audio"] || seen["Video"] {
+		add("AudioVideo")
+	}
+
+	return result
+}
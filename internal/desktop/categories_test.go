@@ -0,0 +1,77 @@
+package desktop
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizeCategories(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []string
+		want  []string
+	}{
+		{"empty", nil, []string{"Utility"}},
+		{"valid unchanged", []string{"Network", "WebBrowser"}, []string{"Network", "WebBrowser"}},
+		{"spelling fixed", []string{"network", "WEBBROWSER"}, []string{"Network", "WebBrowser"}},
+		{"aliases", []string{"Internet", "Multimedia"}, []string{"Network", "AudioVideo"}},
+		{"alias with space", []string{"Developer Tools"}, []string{"Development"}},
+		{"deprecated dropped", []string{"Application", "Office"}, []string{"Office"}},
+		{"unknown dropped", []string{"Electron", "Chromium", "Graphics"}, []string{"Graphics"}},
+		{"main derived from additional", []string{"IDE", "Debugger"}, []string{"IDE", "Debugger", "Development"}},
+		{"first related main wins", []string{"WebBrowser", "TextEditor"}, []string{"WebBrowser", "TextEditor", "Network"}},
+		{"no related main", []string{"Amusement"}, []string{"Amusement", "Utility"}},
+		{"audio implies AudioVideo", []string{"Audio", "Player"}, []string{"Audio", "Player", "AudioVideo"}},
+		{"additional implying audio", []string{"Midi"}, []string{"Midi", "Audio", "AudioVideo"}},
+		{"vendor extension kept", []string{"X-Custom", "Game"}, []string{"X-Custom", "Game"}},
+		{"duplicates removed", []string{"Game", "game", "Games"}, []string{"Game"}},
+		{"only unknown", []string{"Foo"}, []string{"Utility"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeCategories(tt.input)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("NormalizeCategories(%v) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeCategories_RegisteredList(t *testing.T) {
+	for name := range mainCategories {
+		got := NormalizeCategories([]string{name})
+		if len(got) == 0 || got[0] != name {
+			t.Errorf("main category %s normalized to %v", name, got)
+		}
+	}
+
+	for name, main := range additionalCategories {
+		got := NormalizeCategories([]string{name})
+		if len(got) < 2 || got[0] != name {
+			t.Errorf("additional category %s normalized to %v", name, got)
+			continue
+		}
+		if main != "" && got[1] != main {
+			t.Errorf("additional category %s got main %s, want %s", name, got[1], main)
+		}
+		if InvalidCategories(got) != nil {
+			t.Errorf("normalized %s has invalid categories %v", name, InvalidCategories(got))
+		}
+	}
+
+	// Aliases must point at registered names
+	for alias, target := range categoryAliases {
+		if target != "" && !IsRegisteredCategory(target) {
+			t.Errorf("alias %q maps to unregistered %q", alias, target)
+		}
+	}
+}
+
+func TestInvalidCategories(t *testing.T) {
+	got := InvalidCategories([]string{"Network", "Internet", "X-Vendor", "utility", "TextEditor"})
+	want := []string{"Internet", "utility"}
+	if !slices.Equal(got, want) {
+		t.Errorf("InvalidCategories() = %v, want %v", got, want)
+	}
+}
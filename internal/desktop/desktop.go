@@ -4,13 +4,20 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/quantmind-br/upkg/internal/core"
 	"github.com/quantmind-br/upkg/internal/security"
 )
 
+// ManagedKey marks the desktop entries written by upkg
+const ManagedKey = "X-Upkg-Managed"
+
 // Parse parses a .desktop file from a reader
 //
 //nolint:gocyclo // parser handles many key variants and validations.
@@ -43,6 +50,11 @@ func Parse(r io.Reader) (*core.DesktopEntry, error) {
 			key := strings.TrimSpace(parts[0])
 			value := strings.TrimSpace(parts[1])
 
+			if base, locale, ok := splitLocaleKey(key); ok {
+				parseLocalized(de, base, locale, value)
+				continue
+			}
+
 			switch key {
 			case "Type":
 				de.Type = value
@@ -50,16 +62,28 @@ func Parse(r io.Reader) (*core.DesktopEntry, error) {
 				de.Name = value
 			case "Exec":
 				de.Exec = value
+			case "TryExec":
+				de.TryExec = value
 			case "Icon":
 				de.Icon = value
+			case "GenericName":
+				de.GenericName = value
 			case "Comment":
 				de.Comment = value
 			case "Categories":
 				de.Categories = parseSemicolonList(value)
+			case "Keywords":
+				de.Keywords = parseSemicolonList(value)
 			case "Terminal":
 				de.Terminal = value == "true"
 			case "StartupWMClass":
 				de.StartupWMClass = value
+			case "SingleMainWindow":
+				de.SingleMainWindow = value == "true"
+			case "NoDisplay":
+				de.NoDisplay = value == "true"
+			case ManagedKey:
+				de.Managed = value == "true"
 			}
 		}
 	}
@@ -71,28 +95,52 @@ func Parse(r io.Reader) (*core.DesktopEntry, error) {
 	return de, nil
 }
 
-// Write writes a .desktop file to a writer
+// Write writes a .desktop file to a writer, marking it as written by upkg
 func Write(w io.Writer, de *core.DesktopEntry) error {
 	fmt.Fprintln(w, "[Desktop Entry]")
 	fmt.Fprintf(w, "Type=%s\n", de.Type)
 	fmt.Fprintf(w, "Name=%s\n", de.Name)
+	writeLocalized(w, "Name", de.LocalizedName)
 	fmt.Fprintf(w, "Exec=%s\n", de.Exec)
 
+	if de.TryExec != "" {
+		fmt.Fprintf(w, "TryExec=%s\n", de.TryExec)
+	}
 	if de.Icon != "" {
 		fmt.Fprintf(w, "Icon=%s\n", de.Icon)
 	}
+	if de.GenericName != "" {
+		fmt.Fprintf(w, "GenericName=%s\n", de.GenericName)
+	}
+	writeLocalized(w, "GenericName", de.LocalizedGenericName)
 	if de.Comment != "" {
 		fmt.Fprintf(w, "Comment=%s\n", de.Comment)
 	}
+	writeLocalized(w, "Comment", de.LocalizedComment)
 	if len(de.Categories) > 0 {
 		fmt.Fprintf(w, "Categories=%s\n", strings.Join(de.Categories, ";")+";")
 	}
+	if len(de.Keywords) > 0 {
+		fmt.Fprintf(w, "Keywords=%s\n", strings.Join(de.Keywords, ";")+";")
+	}
+	for _, locale := range slices.Sorted(maps.Keys(de.LocalizedKeywords)) {
+		if keywords := de.LocalizedKeywords[locale]; len(keywords) > 0 {
+			fmt.Fprintf(w, "Keywords[%s]=%s\n", locale, strings.Join(keywords, ";")+";")
+		}
+	}
 	if de.Terminal {
 		fmt.Fprintln(w, "Terminal=true")
 	}
 	if de.StartupWMClass != "" {
 		fmt.Fprintf(w, "StartupWMClass=%s\n", de.StartupWMClass)
 	}
+	if de.SingleMainWindow {
+		fmt.Fprintln(w, "SingleMainWindow=true")
+	}
+	if de.NoDisplay {
+		fmt.Fprintln(w, "NoDisplay=true")
+	}
+	fmt.Fprintf(w, "%s=true\n", ManagedKey)
 
 	return nil
 }
@@ -111,14 +159,47 @@ func Validate(de *core.DesktopEntry) error {
 	return nil
 }
 
+// CheckTryExec verifies that the TryExec target of an entry exists and is executable.
+// Entries without TryExec are considered valid.
+func CheckTryExec(de *core.DesktopEntry) error {
+	if de.TryExec == "" {
+		return nil
+	}
+
+	if !filepath.IsAbs(de.TryExec) {
+		if _, err := exec.LookPath(de.TryExec); err != nil {
+			return fmt.Errorf("TryExec %s not found in PATH", de.TryExec)
+		}
+		return nil
+	}
+
+	info, err := os.Stat(de.TryExec)
+	if err != nil {
+		return fmt.Errorf("TryExec target missing: %s", de.TryExec)
+	}
+	if info.IsDir() || info.Mode()&0111 == 0 {
+		return fmt.Errorf("TryExec target not executable: %s", de.TryExec)
+	}
+	return nil
+}
+
+// DefaultWaylandEnvVars are injected when an app's toolkit is unknown
+var DefaultWaylandEnvVars = []string{
+	"GDK_BACKEND=wayland,x11",
+	"QT_QPA_PLATFORM=wayland:xcb",
+	"MOZ_ENABLE_WAYLAND=1",
+	"ELECTRON_OZONE_PLATFORM_HINT=auto",
+}
+
 // InjectWaylandEnvVars injects Wayland environment variables into the Exec line
 func InjectWaylandEnvVars(de *core.DesktopEntry, customVars []string) error {
-	envVars := []string{
-		"GDK_BACKEND=wayland,x11",
-		"QT_QPA_PLATFORM=wayland:xcb",
-		"MOZ_ENABLE_WAYLAND=1",
-		"ELECTRON_OZONE_PLATFORM_HINT=auto",
-	}
+	return InjectEnvVars(de, DefaultWaylandEnvVars, customVars)
+}
+
+// InjectEnvVars prefixes the Exec line with "env" and baseVars plus the
+// validated customVars. Nothing is injected when both lists are empty.
+func InjectEnvVars(de *core.DesktopEntry, baseVars, customVars []string) error {
+	envVars := append([]string(nil), baseVars...)
 	validCustom := make([]string, 0, len(customVars))
 	var invalid []string
 	for _, raw := range customVars {
@@ -139,6 +220,9 @@ func InjectWaylandEnvVars(de *core.DesktopEntry, customVars []string) error {
 		return fmt.Errorf("invalid custom env vars: %v", invalid)
 	}
 	envVars = append(envVars, validCustom...)
+	if len(envVars) == 0 {
+		return nil
+	}
 
 	for i, val := range envVars {
 		envVars[i] = escapeExecToken(val)
@@ -173,6 +257,51 @@ func WriteDesktopFile(filePath string, de *core.DesktopEntry) error {
 	return Write(file, de)
 }
 
+// splitLocaleKey splits a localized key such as Name[pt_BR] into its base key
+// and locale
+func splitLocaleKey(key string) (base, locale string, ok bool) {
+	base, rest, found := strings.Cut(key, "[")
+	locale, found2 := strings.CutSuffix(rest, "]")
+	if !found || !found2 || base == "" || locale == "" {
+		return "", "", false
+	}
+	return base, locale, true
+}
+
+// parseLocalized stores the translation of a Name, GenericName, Comment or
+// Keywords key; other localized keys are dropped
+func parseLocalized(de *core.DesktopEntry, base, locale, value string) {
+	set := func(m *map[string]string) {
+		if *m == nil {
+			*m = make(map[string]string)
+		}
+		(*m)[locale] = value
+	}
+
+	switch base {
+	case "Name":
+		set(&de.LocalizedName)
+	case "GenericName":
+		set(&de.LocalizedGenericName)
+	case "Comment":
+		set(&de.LocalizedComment)
+	case "Keywords":
+		if de.LocalizedKeywords == nil {
+			de.LocalizedKeywords = make(map[string][]string)
+		}
+		de.LocalizedKeywords[locale] = parseSemicolonList(value)
+	}
+}
+
+// writeLocalized writes the translations of key sorted by locale
+func writeLocalized(w io.Writer, key string, values map[string]string) {
+	for _, locale := range slices.Sorted(maps.Keys(values)) {
+		if values[locale] != "" {
+			fmt.Fprintf(w, "%s[%s]=%s\n", key, locale, values[locale])
+		}
+	}
+}
+
 // parseSemicolonList parses semicolon-separated list
 func parseSemicolonList(value string) []string {
 	value = strings.TrimSuffix(value, ";")
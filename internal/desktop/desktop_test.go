@@ -2,6 +2,7 @@ package desktop
 
 import (
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -23,7 +24,8 @@ Type=Application
 Name=Firefox
 Exec=firefox %u
 Icon=firefox
-Comment=Web Browser
+GenericName=Web Browser
+Comment=Browse the World Wide Web
 Categories=Network;WebBrowser;
 Terminal=false
 StartupWMClass=firefox`,
@@ -32,13 +34,31 @@ StartupWMClass=firefox`,
 				Name:           "Firefox",
 				Exec:           "firefox %u",
 				Icon:           "firefox",
-				Comment:        "Web Browser",
+				GenericName:    "Web Browser",
+				Comment:        "Browse the World Wide Web",
 				Categories:     []string{"Network", "WebBrowser"},
 				Terminal:       false,
 				StartupWMClass: "firefox",
 			},
 			wantErr: false,
 		},
+		{
+			name: "single main window",
+			input: `[Desktop Entry]
+Type=Application
+Name=Obsidian
+Exec=obsidian %U
+StartupWMClass=obsidian
+SingleMainWindow=true`,
+			wantEntry: &core.DesktopEntry{
+				Type:             "Application",
+				Name:             "Obsidian",
+				Exec:             "obsidian %U",
+				StartupWMClass:   "obsidian",
+				SingleMainWindow: true,
+			},
+			wantErr: false,
+		},
 		{
 			name: "minimal desktop entry",
 			input: `[Desktop Entry]
@@ -133,13 +153,24 @@ func TestWrite(t *testing.T) {
 				Name:           "Firefox",
 				Exec:           "firefox %u",
 				Icon:           "firefox",
-				Comment:        "Web Browser",
+				GenericName:    "Web Browser",
+				Comment:        "Browse the World Wide Web",
 				Categories:     []string{"Network", "WebBrowser"},
 				Terminal:       false,
 				StartupWMClass: "firefox",
 			},
 			wantErr: false,
 		},
+		{
+			name: "single main window",
+			entry: &core.DesktopEntry{
+				Type:             "Application",
+				Name:             "Obsidian",
+				Exec:             "obsidian %U",
+				SingleMainWindow: true,
+			},
+			wantErr: false,
+		},
 		{
 			name: "minimal desktop entry",
 			entry: &core.DesktopEntry{
@@ -187,11 +218,78 @@ func TestWrite(t *testing.T) {
 				if parsedEntry.Exec != tt.entry.Exec {
 					t.Errorf("Write() Exec mismatch: got %v, want %v", parsedEntry.Exec, tt.entry.Exec)
 				}
+				if parsedEntry.GenericName != tt.entry.GenericName {
+					t.Errorf("Write() GenericName mismatch: got %v, want %v", parsedEntry.GenericName, tt.entry.GenericName)
+				}
+				if parsedEntry.SingleMainWindow != tt.entry.SingleMainWindow {
+					t.Errorf("Write() SingleMainWindow mismatch: got %v, want %v", parsedEntry.SingleMainWindow, tt.entry.SingleMainWindow)
+				}
 			}
 		})
 	}
 }
 
+func TestLocalizedFieldsRoundTrip(t *testing.T) {
+	input := `[Desktop Entry]
+Type=Application
+Name=Files
+Name[pt_BR]=Arquivos
+Name[de]=Dateien
+GenericName=File Manager
+GenericName[de]=Dateiverwaltung
+Comment=Browse files
+Comment[pt_BR]=Navegue pelos arquivos
+Keywords=folder;manager;
+Keywords[de]=Ordner;Verwaltung;
+Exec=files %U
+X-Custom[de]=ignored
+`
+	entry, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := entry.LocalizedName["pt_BR"]; got != "Arquivos" {
+		t.Errorf("Name[pt_BR] = %q", got)
+	}
+	if want := []string{"folder", "manager"}; !reflect.DeepEqual(entry.Keywords, want) {
+		t.Errorf("Keywords = %v, want %v", entry.Keywords, want)
+	}
+
+	var buf strings.Builder
+	if err := Write(&buf, entry); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	want := `[Desktop Entry]
+Type=Application
+Name=Files
+Name[de]=Dateien
+Name[pt_BR]=Arquivos
+Exec=files %U
+GenericName=File Manager
+GenericName[de]=Dateiverwaltung
+Comment=Browse files
+Comment[pt_BR]=Navegue pelos arquivos
+Keywords=folder;manager;
+Keywords[de]=Ordner;Verwaltung;
+X-Upkg-Managed=true
+`
+	if buf.String() != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	// Only entries upkg wrote carry the marker gc relies on
+	if entry.Managed {
+		t.Error("Managed set for an entry upkg did not write")
+	}
+	written, err := Parse(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !written.Managed {
+		t.Error("Managed not set for an entry written by Write")
+	}
+}
+
 func TestValidate(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -586,3 +684,57 @@ func compareStringSlices(a, b []string) bool {
 	}
 	return true
 }
+
+func TestTryExecRoundTrip(t *testing.T) {
+	entry := &core.DesktopEntry{Type: "Application", Name: "App", Exec: "/opt/app %U", TryExec: "/opt/app"}
+
+	var buf strings.Builder
+	if err := Write(&buf, entry); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "TryExec=/opt/app\n") {
+		t.Errorf("Write() output missing TryExec: %q", buf.String())
+	}
+
+	parsed, err := Parse(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if parsed.TryExec != "/opt/app" {
+		t.Errorf("Parse() TryExec = %q, want /opt/app", parsed.TryExec)
+	}
+}
+
+func TestCheckTryExec(t *testing.T) {
+	tmpDir := t.TempDir()
+	executable := tmpDir + "/app"
+	nonExecutable := tmpDir + "/data"
+	if err := os.WriteFile(executable, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(nonExecutable, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		tryExec string
+		wantErr bool
+	}{
+		{"empty", "", false},
+		{"existing executable", executable, false},
+		{"missing file", tmpDir + "/missing", true},
+		{"not executable", nonExecutable, true},
+		{"directory", tmpDir, true},
+		{"not in PATH", "upkg-definitely-not-a-command", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckTryExec(&core.DesktopEntry{TryExec: tt.tryExec})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckTryExec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package desktop
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/security"
+)
+
+// ApplyOverrides applies the edits in o to de. Categories are normalized,
+// exec args go before the trailing field code and env vars are merged into
+// the Exec env prefix, replacing variables of the same name.
+func ApplyOverrides(de *core.DesktopEntry, o *core.DesktopOverrides) error {
+	if o == nil {
+		return nil
+	}
+	if o.Name != "" {
+		// Translations of the upstream name would hide the new one
+		de.Name, de.LocalizedName = o.Name, nil
+	}
+	if len(o.Categories) > 0 {
+		de.Categories = NormalizeCategories(o.Categories)
+	}
+	if len(o.ExecArgs) > 0 {
+		de.Exec = InsertExecArgs(de.Exec, o.ExecArgs)
+	}
+	if len(o.Env) > 0 {
+		if err := SetExecEnv(de, o.Env); err != nil {
+			return err
+		}
+	}
+	if o.Hidden != nil {
+		de.NoDisplay = *o.Hidden
+	}
+	return nil
+}
+
+// InsertExecArgs adds args missing from execLine before its trailing field code (%U, %f, ...)
+func InsertExecArgs(execLine string, args []string) string {
+	fields := strings.Fields(execLine)
+	var missing []string
+	for _, arg := range args {
+		if !slices.Contains(fields, arg) && !slices.Contains(missing, arg) {
+			missing = append(missing, arg)
+		}
+	}
+	if len(missing) == 0 {
+		return execLine
+	}
+
+	insert := strings.Join(missing, " ")
+	if n := len(fields); n > 1 && strings.HasPrefix(fields[n-1], "%") {
+		idx := strings.LastIndex(execLine, fields[n-1])
+		return execLine[:idx] + insert + " " + execLine[idx:]
+	}
+	return execLine + " " + insert
+}
+
+// RemoveExecArgs drops the fields of execLine equal to one of args. The
+// program itself is never removed.
+func RemoveExecArgs(execLine string, args []string) string {
+	fields := strings.Fields(execLine)
+	if len(fields) < 2 {
+		return execLine
+	}
+	kept := append([]string{fields[0]}, slices.DeleteFunc(fields[1:], func(field string) bool {
+		return slices.Contains(args, field)
+	})...)
+	return strings.Join(kept, " ")
+}
+
+// SetExecEnv sets the KEY=VALUE pairs in vars through the "env" prefix of the
+// Exec line, adding the prefix when the line has none
+func SetExecEnv(de *core.DesktopEntry, vars []string) error {
+	assignments, rest := splitEnvPrefix(de.Exec)
+	for _, raw := range vars {
+		name, value, ok := strings.Cut(raw, "=")
+		if !ok {
+			return fmt.Errorf("invalid env var %q: want KEY=VALUE", raw)
+		}
+		if err := security.ValidateEnvironmentVariable(name, value); err != nil {
+			return fmt.Errorf("invalid env var %q: %w", raw, err)
+		}
+
+		token := escapeExecToken(raw)
+		idx := slices.IndexFunc(assignments, func(existing string) bool {
+			key, _, _ := strings.Cut(existing, "=")
+			return key == name
+		})
+		if idx >= 0 {
+			assignments[idx] = token
+		} else {
+			assignments = append(assignments, token)
+		}
+	}
+
+	if len(assignments) > 0 {
+		de.Exec = "env " + strings.Join(assignments, " ") + " " + rest
+	}
+	return nil
+}
+
+// splitEnvPrefix splits the assignments of an "env K=V ..." prefix off an
+// Exec line and returns them with the rest of the line
+func splitEnvPrefix(execLine string) ([]string, string) {
+	if !strings.HasPrefix(execLine, "env ") {
+		return nil, execLine
+	}
+
+	var assignments []string
+	rest := strings.TrimLeft(strings.TrimPrefix(execLine, "env "), " ")
+	for rest != "" {
+		token := nextExecToken(rest)
+		if key, _, ok := strings.Cut(token, "="); !ok || key == "" {
+			break
+		}
+		assignments = append(assignments, token)
+		rest = strings.TrimLeft(rest[len(token):], " ")
+	}
+	return assignments, rest
+}
+
+// ExecProgram returns the program an Exec line runs, skipping an "env"
+// prefix and unquoting it
+func ExecProgram(execLine string) string {
+	_, rest := splitEnvPrefix(strings.TrimSpace(execLine))
+	program := nextExecToken(rest)
+	if unquoted, ok := strings.CutPrefix(program, `"`); ok {
+		program = strings.TrimSuffix(unquoted, `"`)
+		program = strings.ReplaceAll(program, `\"`, `"`)
+		program = strings.ReplaceAll(program, `\\`, `\`)
+	}
+	return program
+}
+
+// nextExecToken returns the first space-separated token of s, keeping quoted
+// sections and backslash escapes intact
+func nextExecToken(s string) string {
+	inQuote := false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			inQuote = !inQuote
+		case ' ':
+			if !inQuote {
+				return s[:i]
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,180 @@
+package desktop
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/core"
+)
+
+func TestInsertExecArgs(t *testing.T) {
+	tests := []struct {
+		execLine string
+		args     []string
+		want     string
+	}{
+		{"/bin/a %U", []string{"--x"}, "/bin/a --x %U"},
+		{"/bin/a", []string{"--x"}, "/bin/a --x"},
+		{"/bin/a --x %U", []string{"--x"}, "/bin/a --x %U"},
+		{"/bin/a %F", []string{"--x", "--y", "--x"}, "/bin/a --x --y %F"},
+	}
+	for _, tt := range tests {
+		if got := InsertExecArgs(tt.execLine, tt.args); got != tt.want {
+			t.Errorf("InsertExecArgs(%q, %v) = %q, want %q", tt.execLine, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestRemoveExecArgs(t *testing.T) {
+	tests := []struct {
+		execLine string
+		args     []string
+		want     string
+	}{
+		{"/bin/a --x --y %U", []string{"--x"}, "/bin/a --y %U"},
+		{"/bin/a %U", []string{"--x"}, "/bin/a %U"},
+		{"--x --x", []string{"--x"}, "--x"},
+		{"/bin/a", []string{"/bin/a"}, "/bin/a"},
+	}
+	for _, tt := range tests {
+		if got := RemoveExecArgs(tt.execLine, tt.args); got != tt.want {
+			t.Errorf("RemoveExecArgs(%q, %v) = %q, want %q", tt.execLine, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestSetExecEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		exec    string
+		vars    []string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "adds env prefix",
+			exec: "/bin/app %U",
+			vars: []string{"FOO=1"},
+			want: "env FOO=1 /bin/app %U",
+		},
+		{
+			name: "replaces existing variable",
+			exec: `env GDK_BACKEND=wayland,x11 TITLE="a b" /bin/app %U`,
+			vars: []string{"GDK_BACKEND=x11", "NEW=2"},
+			want: `env GDK_BACKEND=x11 TITLE="a b" NEW=2 /bin/app %U`,
+		},
+		{
+			name: "quotes values with spaces",
+			exec: "env A=1 /bin/app",
+			vars: []string{"TITLE=my app"},
+			want: `env A=1 TITLE="my app" /bin/app`,
+		},
+		{
+			name:    "rejects missing value",
+			exec:    "/bin/app",
+			vars:    []string{"FOO"},
+			wantErr: "want KEY=VALUE",
+		},
+		{
+			name:    "rejects invalid name",
+			exec:    "/bin/app",
+			vars:    []string{"1FOO=bar"},
+			wantErr: "invalid env var",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			de := &core.DesktopEntry{Exec: tt.exec}
+			err := SetExecEnv(de, tt.vars)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("SetExecEnv() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetExecEnv() error = %v", err)
+			}
+			if de.Exec != tt.want {
+				t.Errorf("Exec = %q, want %q", de.Exec, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecProgram(t *testing.T) {
+	tests := []struct {
+		execLine string
+		want     string
+	}{
+		{"/opt/app/app %U", "/opt/app/app"},
+		{`env GDK_BACKEND=wayland,x11 TITLE="a b" /opt/app/app --x %U`, "/opt/app/app"},
+		{`"/home/u/My Apps/app" %F`, "/home/u/My Apps/app"},
+		{"app", "app"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := ExecProgram(tt.execLine); got != tt.want {
+			t.Errorf("ExecProgram(%q) = %q, want %q", tt.execLine, got, tt.want)
+		}
+	}
+}
+
+func TestApplyOverrides(t *testing.T) {
+	hidden := true
+	de := &core.DesktopEntry{
+		Type:          "Application",
+		Name:          "Editor",
+		Exec:          "env GDK_BACKEND=wayland,x11 /home/test/.local/bin/editor %U",
+		Categories:    []string{"Development"},
+		LocalizedName: map[string]string{"de": "Editor (de)"},
+	}
+	err := ApplyOverrides(de, &core.DesktopOverrides{
+		Name:       "My Editor",
+		Categories: []string{"Utilities", "TextEditor"},
+		ExecArgs:   []string{"--new-window"},
+		Env:        []string{"EDITOR_THEME=dark"},
+		Hidden:     &hidden,
+	})
+	if err != nil {
+		t.Fatalf("ApplyOverrides() error = %v", err)
+	}
+
+	if de.Name != "My Editor" {
+		t.Errorf("Name = %q", de.Name)
+	}
+	if de.LocalizedName != nil {
+		t.Errorf("LocalizedName = %v, want the translations dropped", de.LocalizedName)
+	}
+	if want := []string{"Utility", "TextEditor"}; !reflect.DeepEqual(de.Categories, want) {
+		t.Errorf("Categories = %v, want %v", de.Categories, want)
+	}
+	if want := "env GDK_BACKEND=wayland,x11 EDITOR_THEME=dark /home/test/.local/bin/editor --new-window %U"; de.Exec != want {
+		t.Errorf("Exec = %q, want %q", de.Exec, want)
+	}
+	if !de.NoDisplay {
+		t.Error("NoDisplay = false, want true")
+	}
+
+	if err := ApplyOverrides(de, nil); err != nil {
+		t.Errorf("ApplyOverrides(nil) error = %v", err)
+	}
+}
+
+func TestNoDisplayRoundTrip(t *testing.T) {
+	var buf strings.Builder
+	if err := Write(&buf, &core.DesktopEntry{Type: "Application", Name: "App", Exec: "app", NoDisplay: true}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "NoDisplay=true\n") {
+		t.Fatalf("Write() output missing NoDisplay:\n%s", buf.String())
+	}
+	de, err := Parse(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !de.NoDisplay {
+		t.Error("NoDisplay lost in round trip")
+	}
+}
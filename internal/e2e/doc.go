@@ -0,0 +1,10 @@
+// Package e2e holds the end-to-end suite that installs and uninstalls small
+// real packages through the CLI. The tests are opt-in:
+//
+//	go test -tags integration ./internal/e2e/...
+//
+// Fixtures are built while the suite runs: the executable from testdata/hello,
+// a tar.gz around it, an AppImage using it as runtime and, when dpkg-deb is
+// available, a .deb. Backends whose external tools are missing are skipped, and
+// the system-wide (pacman) DEB flow only runs with UPKG_E2E_SYSTEM=1.
+package e2e
@@ -0,0 +1,327 @@
+//go:build integration
+
+package e2e
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/backends"
+	"github.com/quantmind-br/upkg/internal/cmd"
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/db"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// greeting is printed by the fixture executable
+const greeting = "hello from upkg fixture"
+
+var (
+	cfg      *config.Config
+	homeDir  string
+	workDir  string
+	helloBin string
+)
+
+// TestMain isolates the suite in a temporary HOME and builds the fixture executable
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	var err error
+	if homeDir, err = os.MkdirTemp("", "upkg-e2e-home-"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer os.RemoveAll(homeDir)
+	if workDir, err = os.MkdirTemp("", "upkg-e2e-work-"); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer os.RemoveAll(workDir)
+
+	// Build before switching HOME so the Go build cache is reused
+	helloBin = filepath.Join(workDir, "hello")
+	build := exec.Command("go", "build", "-o", helloBin, "./testdata/hello")
+	build.Env = append(os.Environ(), "CGO_ENABLED=0")
+	if output, buildErr := build.CombinedOutput(); buildErr != nil {
+		fmt.Fprintf(os.Stderr, "build fixture executable: %v\n%s", buildErr, output)
+		return 1
+	}
+
+	_ = os.Setenv("HOME", homeDir)
+	for _, key := range []string{"XDG_CONFIG_HOME", "XDG_DATA_HOME", "XDG_CACHE_HOME", "XDG_STATE_HOME", "HYPRLAND_INSTANCE_SIGNATURE"} {
+		_ = os.Unsetenv(key)
+	}
+
+	if cfg, err = config.Load(); err != nil {
+		fmt.Fprintf(os.Stderr, "load config: %v\n", err)
+		return 1
+	}
+	// The CLI's logger creates the data directory that holds the database
+	if err = os.MkdirAll(filepath.Dir(cfg.Paths.DBFile), 0755); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return m.Run()
+}
+
+// upkg runs the CLI with args
+func upkg(t *testing.T, args ...string) error {
+	t.Helper()
+	log := zerolog.New(io.Discard)
+	root := cmd.NewRootCmd(cfg, &log, "e2e")
+	root.SetArgs(args)
+	root.SetOut(io.Discard)
+	root.SetErr(io.Discard)
+	return root.ExecuteContext(context.Background())
+}
+
+// installedRecord returns the database record of name, or nil
+func installedRecord(t *testing.T, name string) *core.InstallRecord {
+	t.Helper()
+	ctx := context.Background()
+	database, err := db.New(ctx, cfg.Paths.DBFile)
+	require.NoError(t, err)
+	defer func() { _ = database.Close() }()
+
+	installs, err := database.List(ctx)
+	require.NoError(t, err)
+	for i := range installs {
+		if installs[i].Name == name {
+			return db.ToInstallRecord(&installs[i])
+		}
+	}
+	return nil
+}
+
+// requireBackend skips the test when the backend's required tools are missing
+func requireBackend(t *testing.T, name string) {
+	t.Helper()
+	log := zerolog.New(io.Discard)
+	registry := backends.NewRegistry(cfg, &log)
+	backend, err := registry.GetBackend(name)
+	require.NoError(t, err)
+	if preflightErr := registry.Preflight(context.Background(), backend).Err(backends.DetectDistroFamily(afero.NewOsFs())); preflightErr != nil {
+		t.Skipf("%s backend unavailable: %v", name, preflightErr)
+	}
+}
+
+// installAndUninstall runs the full flow for a package and checks the
+// launcher, desktop entry and record, then that uninstall removes them
+func installAndUninstall(t *testing.T, packagePath, name, launcher string, wantDesktop bool, extraArgs ...string) {
+	t.Helper()
+
+	args := append([]string{"install", packagePath, "--name", name, "--skip-icon-fix", "--timeout", "120"}, extraArgs...)
+	require.NoError(t, upkg(t, args...))
+
+	record := installedRecord(t, name)
+	require.NotNil(t, record, "install record for %s", name)
+	assert.FileExists(t, launcher)
+	if record.InstallPath != "" {
+		assert.True(t, pathExists(record.InstallPath), "install path %s", record.InstallPath)
+	}
+	if wantDesktop {
+		require.NotEmpty(t, record.DesktopFile)
+		content, err := os.ReadFile(record.DesktopFile)
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "Exec=")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, launcher).CombinedOutput()
+	require.NoError(t, err, "run launcher: %s", output)
+	assert.Contains(t, string(output), greeting)
+
+	require.NoError(t, upkg(t, "uninstall", name, "--yes", "--timeout", "120"))
+
+	assert.Nil(t, installedRecord(t, name))
+	assert.NoFileExists(t, launcher)
+	if record.InstallPath != "" {
+		assert.False(t, pathExists(record.InstallPath), "install path %s left behind", record.InstallPath)
+	}
+	for _, desktopFile := range record.GetDesktopFiles() {
+		assert.NoFileExists(t, desktopFile)
+	}
+	for _, icon := range record.Metadata.IconFiles {
+		assert.NoFileExists(t, icon)
+	}
+}
+
+// pathExists reports whether path exists (file, directory or symlink)
+func pathExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// fixtureFile is one file of a fixture payload
+type fixtureFile struct {
+	name    string
+	content []byte
+	mode    int64
+}
+
+// payloadFiles returns the files shipped by every fixture under prefix
+func payloadFiles(t *testing.T, prefix, execName string) []fixtureFile {
+	t.Helper()
+	hello, err := os.ReadFile(helloBin)
+	require.NoError(t, err)
+
+	desktop := fmt.Sprintf("[Desktop Entry]\nType=Application\nName=Hello Fixture\nExec=%s %%U\nIcon=hello\nCategories=Utility;\n", execName)
+	return []fixtureFile{
+		{name: prefix + execName, content: hello, mode: 0755},
+		{name: prefix + "hello.desktop", content: []byte(desktop), mode: 0644},
+		{name: prefix + "hello.png", content: pngIcon(t), mode: 0644},
+	}
+}
+
+// pngIcon renders a 64x64 PNG icon
+func pngIcon(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 64; y++ {
+			img.Set(x, y, color.RGBA{R: 40, G: 120, B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// writeTar writes files as a tar stream
+func writeTar(t *testing.T, w io.Writer, files []fixtureFile) {
+	t.Helper()
+	tw := tar.NewWriter(w)
+	for _, file := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     file.name,
+			Mode:     file.mode,
+			Size:     int64(len(file.content)),
+			Typeflag: tar.TypeReg,
+			ModTime:  time.Now(),
+		}))
+		_, err := tw.Write(file.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+}
+
+func TestTarball_InstallUninstall(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "hello-1.0-linux-x86_64.tar.gz")
+	f, err := os.Create(archive)
+	require.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	writeTar(t, gz, payloadFiles(t, "hello-1.0/", "hello"))
+	require.NoError(t, gz.Close())
+	require.NoError(t, f.Close())
+
+	installAndUninstall(t, archive, "e2e-tarball", filepath.Join(homeDir, ".local", "bin", "e2e-tarball"), true)
+}
+
+func TestAppImage_InstallUninstall(t *testing.T) {
+	var payload bytes.Buffer
+	files := payloadFiles(t, "", "AppRun")
+	writeTar(t, &payload, files)
+
+	hello, err := os.ReadFile(helloBin)
+	require.NoError(t, err)
+	var trailer [8]byte
+	binary.LittleEndian.PutUint64(trailer[:], uint64(payload.Len()))
+
+	appImage := filepath.Join(t.TempDir(), "Hello-1.0-x86_64.AppImage")
+	content := append(append(hello, payload.Bytes()...), trailer[:]...)
+	require.NoError(t, os.WriteFile(appImage, content, 0755))
+
+	installAndUninstall(t, appImage, "e2e-appimage", filepath.Join(homeDir, ".local", "bin", "e2e-appimage.appimage"), true)
+}
+
+func TestBinary_InstallUninstall(t *testing.T) {
+	binaryPath := filepath.Join(t.TempDir(), "hello")
+	hello, err := os.ReadFile(helloBin)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(binaryPath, hello, 0755))
+
+	installAndUninstall(t, binaryPath, "e2e-binary", filepath.Join(homeDir, ".local", "bin", "e2e-binary"), false)
+}
+
+func TestDeb_InstallUninstall(t *testing.T) {
+	// DEB packages are installed system-wide through pacman
+	if os.Getenv("UPKG_E2E_SYSTEM") != "1" {
+		t.Skip("set UPKG_E2E_SYSTEM=1 to run tests that modify the system")
+	}
+	requireBackend(t, "deb")
+
+	debPath := buildDebFixture(t)
+	installAndUninstall(t, debPath, "e2e-deb", "/usr/bin/e2e-deb", false, "--method", "pacman")
+}
+
+func TestDebExtract_InstallUninstall(t *testing.T) {
+	debPath := buildDebFixture(t)
+	installAndUninstall(t, debPath, "e2e-deb", filepath.Join(homeDir, ".local", "bin", "e2e-deb"), true, "--method", "extract")
+}
+
+// buildDebFixture packs the hello payload into a .deb with dpkg-deb
+func buildDebFixture(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("dpkg-deb"); err != nil {
+		t.Skip("dpkg-deb not available to build the fixture")
+	}
+
+	root := filepath.Join(t.TempDir(), "pkg")
+	files := payloadFiles(t, "", "e2e-deb")
+	layout := map[string]string{
+		files[0].name: "usr/bin",
+		files[1].name: "usr/share/applications",
+		files[2].name: "usr/share/icons/hicolor/64x64/apps",
+	}
+	for _, file := range files {
+		dir := filepath.Join(root, layout[file.name])
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file.name), file.content, os.FileMode(file.mode)))
+	}
+	control := "Package: e2e-deb\nVersion: 1.0\nArchitecture: amd64\nMaintainer: upkg <upkg@example.com>\nDescription: upkg integration fixture\n"
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "DEBIAN"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "DEBIAN", "control"), []byte(control), 0644))
+
+	debPath := filepath.Join(t.TempDir(), "e2e-deb_1.0_amd64.deb")
+	output, err := exec.Command("dpkg-deb", "--root-owner-group", "--build", root, debPath).CombinedOutput()
+	require.NoError(t, err, "dpkg-deb: %s", output)
+	return debPath
+}
+
+func TestInstall_CorruptTarball(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "broken-1.0.tar.gz")
+	require.NoError(t, os.WriteFile(archive, []byte("\x1f\x8b\x08\x00not really gzip"), 0644))
+
+	err := upkg(t, "install", archive, "--name", "e2e-broken", "--timeout", "60")
+	require.Error(t, err)
+	assert.Nil(t, installedRecord(t, "e2e-broken"))
+
+	entries, readErr := os.ReadDir(filepath.Join(homeDir, ".local", "share", "upkg", "apps"))
+	if readErr == nil {
+		for _, entry := range entries {
+			assert.False(t, strings.HasPrefix(entry.Name(), "e2e-broken"), "leftover payload %s", entry.Name())
+		}
+	}
+}
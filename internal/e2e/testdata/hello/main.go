@@ -0,0 +1,95 @@
+// Command hello is the executable packaged into integration test fixtures.
+//
+// It doubles as a minimal AppImage runtime: when a tar payload is appended to
+// the executable, followed by the payload length as an 8-byte little-endian
+// trailer, "--appimage-extract" unpacks it into ./squashfs-root just like the
+// real runtime does.
+package main
+
+import (
+	"archive/tar"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "--appimage-extract" {
+		if err := extract(); err != nil {
+			fmt.Fprintln(os.Stderr, "extract:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Println("hello from upkg fixture")
+}
+
+func extract() error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(self)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	var trailer [8]byte
+	if _, err := f.ReadAt(trailer[:], info.Size()-8); err != nil {
+		return err
+	}
+	size := int64(binary.LittleEndian.Uint64(trailer[:]))
+	if size <= 0 || size > info.Size()-8 {
+		return errors.New("no payload appended")
+	}
+
+	tr := tar.NewReader(io.NewSectionReader(f, info.Size()-8-size, size))
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join("squashfs-root", filepath.Clean(hdr.Name))
+		if !strings.HasPrefix(target, "squashfs-root") {
+			return fmt.Errorf("invalid entry %q", hdr.Name)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		default:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(hdr.Mode))
+			if err != nil {
+				return err
+			}
+			_, copyErr := io.Copy(out, tr)
+			closeErr := out.Close()
+			if copyErr != nil {
+				return copyErr
+			}
+			if closeErr != nil {
+				return closeErr
+			}
+		}
+	}
+}
@@ -0,0 +1,521 @@
+// Package fetch downloads remote packages into a local cache with resume
+// support and size/checksum verification.
+package fetch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/helpers"
+)
+
+// partSuffix marks an incomplete download that can be resumed
+const partSuffix = ".part"
+
+// entrySuffix names the cache entry stored beside a complete download
+const entrySuffix = ".json"
+
+// errNotModified reports that the server confirmed the cached copy is current
+var errNotModified = errors.New("not modified")
+
+// ErrChecksumMismatch is returned when a download does not match the expected SHA256
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+var (
+	sha256Regex       = regexp.MustCompile(`^[a-f0-9]{64}$`)
+	unsafeNameChars   = regexp.MustCompile(`[^A-Za-z0-9._+-]`)
+	contentRangeRegex = regexp.MustCompile(`^bytes (\d+)-\d+/(\d+|\*)$`)
+	cacheKeyRegex     = regexp.MustCompile(`^[a-f0-9]{16}$`)
+)
+
+// Options controls a single download
+type Options struct {
+	SHA256   string                  // Expected hex SHA256; empty skips verification
+	NoCache  bool                    // Download again even when the cached copy is current
+	Progress func(done, total int64) // Called as bytes arrive; total is -1 when unknown
+}
+
+// cacheEntry is stored beside a cached download. Its validators let the
+// next fetch of the URL ask the server whether the cached copy is current.
+// A partial download has one too, so a resume only appends to it while the
+// server still serves the same file.
+type cacheEntry struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Size         int64  `json:"size,omitempty"` // Content length announced by the server
+}
+
+// Downloader fetches URLs into CacheDir
+type Downloader struct {
+	CacheDir string
+	client   *http.Client
+}
+
+// NewDownloader creates a downloader caching into cacheDir. Requests are
+// bounded by the caller's context rather than a client timeout, since
+// package downloads can be large.
+func NewDownloader(cacheDir string) *Downloader {
+	return &Downloader{CacheDir: cacheDir, client: &http.Client{}}
+}
+
+// IsURL reports whether s is an http(s) URL
+func IsURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Fetch downloads rawURL and returns the path of the complete local file.
+// An interrupted download is resumed from its .part file on the next call.
+// A complete cached file is reused when it matches opts.SHA256 or, without
+// a checksum, when the server reports that its ETag or Last-Modified still
+// match; opts.NoCache always downloads again.
+func (d *Downloader) Fetch(ctx context.Context, rawURL string, opts Options) (string, error) {
+	if !IsURL(rawURL) {
+		return "", fmt.Errorf("unsupported url: %q", rawURL)
+	}
+	expected := strings.ToLower(strings.TrimSpace(opts.SHA256))
+	if expected != "" && !sha256Regex.MatchString(expected) {
+		return "", fmt.Errorf("invalid sha256: %q", opts.SHA256)
+	}
+
+	dest := d.cachePath(rawURL)
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", fmt.Errorf("create download cache: %w", err)
+	}
+
+	part := dest + partSuffix
+	var cached *cacheEntry
+	switch {
+	case opts.NoCache:
+		removePart(part)
+	case expected != "":
+		if sum, err := helpers.FileSHA256(dest); err == nil && sum == expected {
+			touch(dest)
+			return dest, nil
+		}
+	default:
+		cached = readEntry(dest)
+	}
+
+	entry, err := d.download(ctx, rawURL, part, cached, opts.Progress)
+	if errors.Is(err, errNotModified) {
+		touch(dest)
+		return dest, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if expected != "" {
+		sum, err := helpers.FileSHA256(part)
+		if err != nil {
+			return "", fmt.Errorf("hash download: %w", err)
+		}
+		if sum != expected {
+			removePart(part)
+			return "", fmt.Errorf("%w: expected %s, got %s", ErrChecksumMismatch, expected, sum)
+		}
+	}
+
+	if err := os.Rename(part, dest); err != nil {
+		return "", fmt.Errorf("finalize download: %w", err)
+	}
+	_ = os.Remove(part + entrySuffix)
+	writeEntry(dest, entry)
+	return dest, nil
+}
+
+// readEntry loads the cache entry of path when path exists and the server
+// gave it a validator
+func readEntry(path string) *cacheEntry {
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path + entrySuffix)
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if json.Unmarshal(data, &entry) != nil || (entry.ETag == "" && entry.LastModified == "") {
+		return nil
+	}
+	return &entry
+}
+
+// ifRange returns the If-Range validator of entry: its strong ETag, else
+// its Last-Modified date
+func ifRange(entry *cacheEntry) string {
+	switch {
+	case entry == nil:
+		return ""
+	case entry.ETag != "" && !strings.HasPrefix(entry.ETag, "W/"):
+		return entry.ETag
+	default:
+		return entry.LastModified
+	}
+}
+
+// removePart deletes a partial download with its cache entry
+func removePart(part string) {
+	_ = os.Remove(part)
+	_ = os.Remove(part + entrySuffix)
+}
+
+// writeEntry stores entry beside dest; without it the next fetch downloads
+// again, so failing to write it is not an error
+func writeEntry(dest string, entry cacheEntry) {
+	if data, err := json.Marshal(entry); err == nil {
+		_ = os.WriteFile(dest+entrySuffix, data, 0644)
+	}
+}
+
+// touch marks a cached file as used, which keeps it from eviction
+func touch(path string) {
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+}
+
+// ChecksumFor returns the SHA256 listed for name in a sha256sum-style
+// checksum file ("<hex>  <name>" per line), or "" when it is not listed
+func ChecksumFor(list, name string) string {
+	for _, line := range strings.Split(list, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		sum := strings.ToLower(fields[0])
+		listed := strings.TrimPrefix(fields[1], "*") // Binary mode marker
+		if path.Base(listed) == name && sha256Regex.MatchString(sum) {
+			return sum
+		}
+	}
+	return ""
+}
+
+// RemoteInfo describes a remote file as reported by a HEAD request
+type RemoteInfo struct {
+	LastModified time.Time // Zero when the server does not report it
+	ETag         string
+	Size         int64 // -1 when unknown
+}
+
+// Probe reads the metadata of rawURL without downloading it
+func (d *Downloader) Probe(ctx context.Context, rawURL string) (RemoteInfo, error) {
+	if !IsURL(rawURL) {
+		return RemoteInfo{}, fmt.Errorf("unsupported url: %q", rawURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return RemoteInfo{}, fmt.Errorf("create probe request: %w", err)
+	}
+	req.Header.Set("User-Agent", "upkg")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return RemoteInfo{}, fmt.Errorf("probe %s: %w", rawURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return RemoteInfo{}, fmt.Errorf("probe %s: server returned %s", rawURL, resp.Status)
+	}
+
+	info := RemoteInfo{ETag: resp.Header.Get("ETag"), Size: resp.ContentLength}
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.LastModified = modified
+	}
+	return info, nil
+}
+
+// download fetches rawURL into part, resuming from its current size. A
+// fresh download sends the validators of cached, if any, and returns
+// errNotModified when the server answers that the cached copy is current.
+//
+//nolint:gocyclo // resume handling covers several server responses.
+func (d *Downloader) download(ctx context.Context, rawURL, part string, cached *cacheEntry, progress func(done, total int64)) (cacheEntry, error) {
+	entry := cacheEntry{URL: rawURL}
+	var offset int64
+	validator := ifRange(readEntry(part))
+	if info, err := os.Stat(part); err == nil && info.Size() > 0 {
+		if validator == "" {
+			// Nothing tells whether the server still has the same file
+			removePart(part)
+		} else {
+			offset = info.Size()
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return entry, fmt.Errorf("create download request: %w", err)
+	}
+	req.Header.Set("User-Agent", "upkg")
+	switch {
+	case offset > 0:
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", validator)
+	case cached != nil && cached.ETag != "":
+		req.Header.Set("If-None-Match", cached.ETag)
+	case cached != nil:
+		req.Header.Set("If-Modified-Since", cached.LastModified)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return entry, fmt.Errorf("download %s: %w", rawURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	entry.ETag = resp.Header.Get("ETag")
+	entry.LastModified = resp.Header.Get("Last-Modified")
+
+	flags := os.O_CREATE | os.O_WRONLY
+	total := int64(-1)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// Full body: the server ignored the range or the file changed
+		offset = 0
+		flags |= os.O_TRUNC
+		if resp.ContentLength >= 0 {
+			total = resp.ContentLength
+		}
+	case http.StatusPartialContent:
+		match := contentRangeRegex.FindStringSubmatch(resp.Header.Get("Content-Range"))
+		if match == nil || match[1] != strconv.FormatInt(offset, 10) {
+			if offset == 0 {
+				return entry, fmt.Errorf("download %s: unexpected range %q", rawURL, resp.Header.Get("Content-Range"))
+			}
+			// Not the continuation of the partial file; start over
+			removePart(part)
+			return d.download(ctx, rawURL, part, cached, progress)
+		}
+		flags |= os.O_APPEND
+		if match[2] != "*" {
+			total, _ = strconv.ParseInt(match[2], 10, 64)
+		} else if resp.ContentLength >= 0 {
+			total = offset + resp.ContentLength
+		}
+	case http.StatusNotModified:
+		if cached == nil {
+			return entry, fmt.Errorf("download %s: server returned %s", rawURL, resp.Status)
+		}
+		return entry, errNotModified
+	case http.StatusRequestedRangeNotSatisfiable:
+		if offset == 0 {
+			return entry, fmt.Errorf("download %s: server returned %s", rawURL, resp.Status)
+		}
+		// The partial file is stale or already complete; start over
+		removePart(part)
+		return d.download(ctx, rawURL, part, cached, progress)
+	default:
+		return entry, fmt.Errorf("download %s: server returned %s", rawURL, resp.Status)
+	}
+
+	file, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return entry, fmt.Errorf("open download file: %w", err)
+	}
+	if offset == 0 {
+		writeEntry(part, entry)
+	}
+
+	written, copyErr := io.Copy(file, &progressReader{r: resp.Body, done: offset, total: total, report: progress})
+	closeErr := file.Close()
+	if copyErr != nil {
+		// Keep the partial file so the next attempt can resume
+		return entry, fmt.Errorf("download %s: %w", rawURL, copyErr)
+	}
+	if closeErr != nil {
+		return entry, fmt.Errorf("write download file: %w", closeErr)
+	}
+
+	if size := offset + written; total >= 0 && size != total {
+		return entry, fmt.Errorf("download %s incomplete: got %d of %d bytes", rawURL, size, total)
+	}
+	if total >= 0 {
+		entry.Size = total
+	}
+	return entry, nil
+}
+
+// CachedFile is a download kept in the cache
+type CachedFile struct {
+	Path    string    `json:"path"`
+	URL     string    `json:"url,omitempty"` // Empty for files cached by older versions
+	Size    int64     `json:"size"`
+	UsedAt  time.Time `json:"used_at"`           // Last download or reuse
+	Partial bool      `json:"partial,omitempty"` // Interrupted download awaiting resume
+}
+
+// Cached lists the downloads in the cache, least recently used first
+func (d *Downloader) Cached() ([]CachedFile, error) {
+	dirs, err := os.ReadDir(d.CacheDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read download cache: %w", err)
+	}
+
+	var files []CachedFile
+	for _, dir := range dirs {
+		// Only the per-URL directories of cachePath; leave anything else alone
+		if !dir.IsDir() || !cacheKeyRegex.MatchString(dir.Name()) {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(d.CacheDir, dir.Name()))
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			info, err := e.Info()
+			if err != nil || !info.Mode().IsRegular() || strings.HasSuffix(e.Name(), entrySuffix) {
+				continue
+			}
+			file := CachedFile{
+				Path:    filepath.Join(d.CacheDir, dir.Name(), e.Name()),
+				Size:    info.Size(),
+				UsedAt:  info.ModTime(),
+				Partial: strings.HasSuffix(e.Name(), partSuffix),
+			}
+			if data, err := os.ReadFile(file.Path + entrySuffix); err == nil {
+				var entry cacheEntry
+				if json.Unmarshal(data, &entry) == nil {
+					file.URL = entry.URL
+				}
+			}
+			files = append(files, file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].UsedAt.Before(files[j].UsedAt) })
+	return files, nil
+}
+
+// Remove deletes a cached download with its cache entry
+func (d *Downloader) Remove(file CachedFile) error {
+	if err := os.Remove(file.Path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove cached download: %w", err)
+	}
+	_ = os.Remove(file.Path + entrySuffix)
+	_ = os.Remove(filepath.Dir(file.Path)) // Only succeeds once the directory is empty
+	return nil
+}
+
+// ExpectedSize returns the size the server announced for the cached
+// download of rawURL, or 0 when it is unknown
+func (d *Downloader) ExpectedSize(rawURL string) int64 {
+	data, err := os.ReadFile(d.cachePath(rawURL) + entrySuffix)
+	if err != nil {
+		return 0
+	}
+	var entry cacheEntry
+	if json.Unmarshal(data, &entry) != nil {
+		return 0
+	}
+	return entry.Size
+}
+
+// Discard deletes the cached download of rawURL with its cache entry and
+// partial file, so the next Fetch downloads it from scratch
+func (d *Downloader) Discard(rawURL string) error {
+	dest := d.cachePath(rawURL)
+	for _, path := range []string{dest, dest + entrySuffix, dest + partSuffix, dest + partSuffix + entrySuffix} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove cached download: %w", err)
+		}
+	}
+	_ = os.Remove(filepath.Dir(dest)) // Only succeeds once the directory is empty
+	return nil
+}
+
+// Prune evicts the least recently used downloads until the cache holds at
+// most maxSize bytes and returns the evicted files. keep (the file being
+// installed) and partial downloads, which may still be written to, are
+// never evicted. maxSize <= 0 keeps nothing else.
+func (d *Downloader) Prune(maxSize int64, keep string) ([]CachedFile, error) {
+	files, err := d.Cached()
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, file := range files {
+		total += file.Size
+	}
+
+	var evicted []CachedFile
+	for _, file := range files {
+		if total <= maxSize && maxSize > 0 {
+			break
+		}
+		if file.Path == keep || file.Partial {
+			continue
+		}
+		if err := d.Remove(file); err != nil {
+			return evicted, err
+		}
+		total -= file.Size
+		evicted = append(evicted, file)
+	}
+	return evicted, nil
+}
+
+// Path returns where Fetch stores rawURL, for files built by other means
+// such as a delta update
+func (d *Downloader) Path(rawURL string) string {
+	return d.cachePath(rawURL)
+}
+
+// cachePath maps a URL to a stable file in the cache, keeping the remote
+// file name so backends can still detect the format from the extension
+func (d *Downloader) cachePath(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(d.CacheDir, hex.EncodeToString(sum[:])[:16], fileName(rawURL))
+}
+
+// fileName derives a safe local file name from the URL path
+func fileName(rawURL string) string {
+	name := "download"
+	if u, err := url.Parse(rawURL); err == nil {
+		if base, err := url.PathUnescape(path.Base(u.Path)); err == nil {
+			name = base
+		}
+	}
+	name = unsafeNameChars.ReplaceAllString(name, "_")
+	if strings.Trim(name, "._") == "" {
+		return "download"
+	}
+	return name
+}
+
+// progressReader reports cumulative bytes read
+type progressReader struct {
+	r      io.Reader
+	done   int64
+	total  int64
+	report func(done, total int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 && p.report != nil {
+		p.done += int64(n)
+		p.report(p.done, p.total)
+	}
+	return n, err
+}
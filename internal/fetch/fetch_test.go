@@ -0,0 +1,362 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var payload = bytes.Repeat([]byte("upkg-payload-"), 4096)
+
+func payloadSum() string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// newPayloadServer serves payload with Range support, an ETag and counts requests
+func newPayloadServer(t *testing.T, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/missing.AppImage" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "app.AppImage", time.Time{}, bytes.NewReader(payload))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestIsURL(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsURL("https://example.com/app.AppImage"))
+	assert.True(t, IsURL("http://example.com/app.tar.gz"))
+	assert.False(t, IsURL("/tmp/app.AppImage"))
+	assert.False(t, IsURL("ftp://example.com/app"))
+	assert.False(t, IsURL("org.mozilla.firefox"))
+	assert.False(t, IsURL("flathub:org.mozilla.firefox"))
+}
+
+func TestFileName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "app-1.0.AppImage", fileName("https://example.com/dl/app-1.0.AppImage?token=x"))
+	assert.Equal(t, "My_App.deb", fileName("https://example.com/My%20App.deb"))
+	assert.Equal(t, "download", fileName("https://example.com/"))
+}
+
+func TestFetch_DownloadsAndVerifies(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := newPayloadServer(t, &requests)
+	d := NewDownloader(t.TempDir())
+
+	var lastDone, lastTotal int64
+	path, err := d.Fetch(context.Background(), server.URL+"/app.AppImage", Options{
+		SHA256:   payloadSum(),
+		Progress: func(done, total int64) { lastDone, lastTotal = done, total },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "app.AppImage", filepath.Base(path))
+	assert.Equal(t, int64(len(payload)), lastDone)
+	assert.Equal(t, int64(len(payload)), lastTotal)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, payload, data)
+	assert.NoFileExists(t, path+partSuffix)
+
+	// A verified cached file is reused without another request
+	_, err = d.Fetch(context.Background(), server.URL+"/app.AppImage", Options{SHA256: payloadSum()})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestFetch_RevalidatesCachedFile(t *testing.T) {
+	t.Parallel()
+
+	var requests, bodies atomic.Int32
+	etag := `"v1"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		bodies.Add(1)
+		_, _ = w.Write(payload)
+	}))
+	t.Cleanup(server.Close)
+
+	d := NewDownloader(t.TempDir())
+	rawURL := server.URL + "/app.AppImage"
+
+	path, err := d.Fetch(context.Background(), rawURL, Options{})
+	require.NoError(t, err)
+
+	// The server confirms the ETag: the cached copy is reused
+	again, err := d.Fetch(context.Background(), rawURL, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, path, again)
+	assert.Equal(t, int32(2), requests.Load())
+	assert.Equal(t, int32(1), bodies.Load())
+
+	// --no-cache downloads again regardless
+	_, err = d.Fetch(context.Background(), rawURL, Options{NoCache: true})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), bodies.Load())
+
+	files, err := d.Cached()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, path, files[0].Path)
+	assert.Equal(t, rawURL, files[0].URL)
+	assert.Equal(t, int64(len(payload)), files[0].Size)
+}
+
+func TestDownloader_Prune(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := newPayloadServer(t, &requests)
+	d := NewDownloader(t.TempDir())
+	require.NoError(t, os.WriteFile(filepath.Join(d.CacheDir, "unrelated"), []byte("x"), 0644))
+
+	var paths []string
+	for i, name := range []string{"a", "b", "c"} {
+		path, err := d.Fetch(context.Background(), server.URL+"/"+name+".AppImage", Options{})
+		require.NoError(t, err)
+		used := time.Now().Add(time.Duration(i-3) * time.Hour)
+		require.NoError(t, os.Chtimes(path, used, used))
+		paths = append(paths, path)
+	}
+
+	// Room for one file: the oldest go first, but never the kept one
+	evicted, err := d.Prune(int64(len(payload)), paths[0])
+	require.NoError(t, err)
+	require.Len(t, evicted, 2)
+	assert.Equal(t, paths[1], evicted[0].Path)
+	assert.Equal(t, paths[2], evicted[1].Path)
+	assert.FileExists(t, paths[0])
+	assert.NoFileExists(t, paths[1]+entrySuffix)
+	assert.NoDirExists(t, filepath.Dir(paths[1]))
+	assert.FileExists(t, filepath.Join(d.CacheDir, "unrelated"))
+}
+
+func TestDownloader_Discard(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := newPayloadServer(t, &requests)
+	d := NewDownloader(t.TempDir())
+	rawURL := server.URL + "/app.AppImage"
+
+	assert.Zero(t, d.ExpectedSize(rawURL))
+	path, err := d.Fetch(context.Background(), rawURL, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(payload)), d.ExpectedSize(rawURL), "the announced size is kept")
+	require.NoError(t, os.WriteFile(path+partSuffix, payload[:10], 0644))
+
+	require.NoError(t, d.Discard(rawURL))
+	assert.NoFileExists(t, path)
+	assert.NoFileExists(t, path+partSuffix)
+	assert.NoDirExists(t, filepath.Dir(path))
+	assert.Zero(t, d.ExpectedSize(rawURL))
+	require.NoError(t, d.Discard(rawURL), "discarding twice is fine")
+
+	_, err = d.Fetch(context.Background(), rawURL, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), requests.Load(), "the discarded file is downloaded again")
+}
+
+func TestFetch_ResumesPartialDownload(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := newPayloadServer(t, &requests)
+	d := NewDownloader(t.TempDir())
+	rawURL := server.URL + "/app.AppImage"
+
+	part := d.cachePath(rawURL) + partSuffix
+	require.NoError(t, os.MkdirAll(filepath.Dir(part), 0755))
+	require.NoError(t, os.WriteFile(part, payload[:1000], 0644))
+	writeEntry(part, cacheEntry{URL: rawURL, ETag: `"v1"`})
+
+	var firstDone int64 = -1
+	path, err := d.Fetch(context.Background(), rawURL, Options{
+		SHA256: payloadSum(),
+		Progress: func(done, _ int64) {
+			if firstDone < 0 {
+				firstDone = done
+			}
+		},
+	})
+	require.NoError(t, err)
+	assert.Greater(t, firstDone, int64(1000), "progress continues from the partial size")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, payload, data)
+	assert.NoFileExists(t, part+entrySuffix)
+}
+
+func TestFetch_RestartsChangedPartialDownload(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := newPayloadServer(t, &requests)
+	d := NewDownloader(t.TempDir())
+	rawURL := server.URL + "/app.AppImage"
+	part := d.cachePath(rawURL) + partSuffix
+
+	stale := bytes.Repeat([]byte("x"), 1000)
+	for _, entry := range []*cacheEntry{
+		{URL: rawURL, ETag: `"v0"`}, // Upstream changed: If-Range fails, the server sends it all
+		nil,                         // No validator: the partial file cannot be trusted
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(part), 0755))
+		require.NoError(t, os.WriteFile(part, stale, 0644))
+		if entry != nil {
+			writeEntry(part, *entry)
+		}
+
+		path, err := d.Fetch(context.Background(), rawURL, Options{})
+		require.NoError(t, err)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, payload, data)
+		require.NoError(t, d.Discard(rawURL))
+	}
+
+	// A range that does not continue the partial file is not appended
+	misplaced := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("Range") != "" {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(payload)-1, len(payload)))
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write(payload)
+			return
+		}
+		_, _ = w.Write(payload)
+	}))
+	t.Cleanup(misplaced.Close)
+	rawURL = misplaced.URL + "/app.AppImage"
+	part = d.cachePath(rawURL) + partSuffix
+	require.NoError(t, os.MkdirAll(filepath.Dir(part), 0755))
+	require.NoError(t, os.WriteFile(part, stale, 0644))
+	writeEntry(part, cacheEntry{URL: rawURL, ETag: `"v1"`})
+
+	path, err := d.Fetch(context.Background(), rawURL, Options{})
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, payload, data)
+}
+
+func TestFetch_ChecksumMismatch(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := newPayloadServer(t, &requests)
+	d := NewDownloader(t.TempDir())
+	rawURL := server.URL + "/app.AppImage"
+
+	wrong := payloadSum()[:63] + "0"
+	if wrong == payloadSum() {
+		wrong = payloadSum()[:63] + "1"
+	}
+	_, err := d.Fetch(context.Background(), rawURL, Options{SHA256: wrong})
+	require.ErrorIs(t, err, ErrChecksumMismatch)
+	assert.NoFileExists(t, d.cachePath(rawURL))
+	assert.NoFileExists(t, d.cachePath(rawURL)+partSuffix)
+
+	_, err = d.Fetch(context.Background(), rawURL, Options{SHA256: "not-a-hash"})
+	assert.ErrorContains(t, err, "invalid sha256")
+}
+
+func TestFetch_ShortBodyIsIncomplete(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+		_, _ = w.Write(payload[:100])
+	}))
+	t.Cleanup(server.Close)
+
+	d := NewDownloader(t.TempDir())
+	rawURL := server.URL + "/app.AppImage"
+
+	_, err := d.Fetch(context.Background(), rawURL, Options{})
+	require.Error(t, err)
+	assert.FileExists(t, d.cachePath(rawURL)+partSuffix, "partial file is kept for resume")
+}
+
+func TestFetch_HTTPError(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	server := newPayloadServer(t, &requests)
+
+	_, err := NewDownloader(t.TempDir()).Fetch(context.Background(), server.URL+"/missing.AppImage", Options{})
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestProbe(t *testing.T) {
+	t.Parallel()
+
+	modified := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		if r.URL.Path == "/missing.AppImage" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", `"abc"`)
+		http.ServeContent(w, r, "app.AppImage", modified, bytes.NewReader(payload))
+	}))
+	t.Cleanup(server.Close)
+
+	d := NewDownloader(t.TempDir())
+	info, err := d.Probe(context.Background(), server.URL+"/app.AppImage")
+	require.NoError(t, err)
+	assert.True(t, modified.Equal(info.LastModified))
+	assert.Equal(t, `"abc"`, info.ETag)
+	assert.Equal(t, int64(len(payload)), info.Size)
+
+	_, err = d.Probe(context.Background(), server.URL+"/missing.AppImage")
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestChecksumFor(t *testing.T) {
+	t.Parallel()
+
+	sum := strings.Repeat("ab", 32)
+	list := "# generated\n" +
+		strings.Repeat("cd", 32) + "  upkg_linux_arm64\n" +
+		strings.ToUpper(sum) + " *dist/upkg_linux_amd64\n"
+
+	assert.Equal(t, sum, ChecksumFor(list, "upkg_linux_amd64"))
+	assert.Equal(t, strings.Repeat("cd", 32), ChecksumFor(list, "upkg_linux_arm64"))
+	assert.Empty(t, ChecksumFor(list, "upkg_linux_386"))
+	assert.Empty(t, ChecksumFor("nothex  upkg_linux_386", "upkg_linux_386"))
+}
@@ -0,0 +1,200 @@
+package fetch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// GitHubPrefix marks a GitHub Releases source spec (gh:owner/repo[@tag])
+const GitHubPrefix = "gh:"
+
+// DefaultGitHubAPI is the GitHub REST API base URL
+const DefaultGitHubAPI = "https://api.github.com"
+
+var ghNameRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// GitHubSpec identifies a repository release
+type GitHubSpec struct {
+	Owner string
+	Repo  string
+	Tag   string // Empty selects the latest release
+}
+
+// Repository returns owner/repo
+func (s GitHubSpec) Repository() string {
+	return s.Owner + "/" + s.Repo
+}
+
+// String formats the spec as accepted by ParseGitHubSpec
+func (s GitHubSpec) String() string {
+	spec := GitHubPrefix + s.Repository()
+	if s.Tag != "" {
+		spec += "@" + s.Tag
+	}
+	return spec
+}
+
+// IsGitHubSpec reports whether s uses the gh: prefix
+func IsGitHubSpec(s string) bool {
+	return strings.HasPrefix(s, GitHubPrefix)
+}
+
+// ParseGitHubSpec parses gh:owner/repo[@tag]
+func ParseGitHubSpec(s string) (GitHubSpec, error) {
+	rest, ok := strings.CutPrefix(s, GitHubPrefix)
+	if !ok {
+		return GitHubSpec{}, fmt.Errorf("not a GitHub spec: %q", s)
+	}
+
+	var spec GitHubSpec
+	var hasTag bool
+	rest, spec.Tag, hasTag = strings.Cut(rest, "@")
+	owner, repo, ok := strings.Cut(rest, "/")
+	if !ok || !ghNameRegex.MatchString(owner) || !ghNameRegex.MatchString(repo) {
+		return GitHubSpec{}, fmt.Errorf("invalid GitHub spec %q (expected gh:owner/repo[@tag])", s)
+	}
+	if hasTag && (spec.Tag == "" || strings.ContainsAny(spec.Tag, " \t")) {
+		return GitHubSpec{}, fmt.Errorf("invalid release tag %q", spec.Tag)
+	}
+	spec.Owner, spec.Repo = owner, repo
+	return spec, nil
+}
+
+// GitHubRelease is the subset of the Releases API response upkg uses
+type GitHubRelease struct {
+	TagName    string        `json:"tag_name"`
+	Name       string        `json:"name"`
+	Prerelease bool          `json:"prerelease"`
+	Draft      bool          `json:"draft"`
+	Assets     []GitHubAsset `json:"assets"`
+}
+
+// AssetNames lists the release asset file names
+func (r *GitHubRelease) AssetNames() []string {
+	names := make([]string, 0, len(r.Assets))
+	for _, asset := range r.Assets {
+		names = append(names, asset.Name)
+	}
+	return names
+}
+
+// Asset returns the asset with the given file name
+func (r *GitHubRelease) Asset(name string) (GitHubAsset, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
+	}
+	return GitHubAsset{}, false
+}
+
+// GitHubAsset is a downloadable release file
+type GitHubAsset struct {
+	Name        string `json:"name"`
+	DownloadURL string `json:"browser_download_url"`
+	Size        int64  `json:"size"`
+	Digest      string `json:"digest"` // "sha256:<hex>" when GitHub computed one
+}
+
+// SHA256 returns the asset checksum published by GitHub, if any
+func (a GitHubAsset) SHA256() string {
+	if sum, ok := strings.CutPrefix(a.Digest, "sha256:"); ok {
+		return sum
+	}
+	return ""
+}
+
+// GitHubClient queries the GitHub Releases API
+type GitHubClient struct {
+	BaseURL string
+	Token   string // Optional; raises the anonymous rate limit
+	client  *http.Client
+}
+
+// NewGitHubClient creates a client for the public GitHub API
+func NewGitHubClient(token string) *GitHubClient {
+	return &GitHubClient{BaseURL: DefaultGitHubAPI, Token: token, client: &http.Client{}}
+}
+
+// Release fetches the tagged release, or the latest one when spec.Tag is empty
+func (c *GitHubClient) Release(ctx context.Context, spec GitHubSpec) (*GitHubRelease, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/releases/latest", strings.TrimRight(c.BaseURL, "/"), spec.Owner, spec.Repo)
+	if spec.Tag != "" {
+		endpoint = fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", strings.TrimRight(c.BaseURL, "/"), spec.Owner, spec.Repo, url.PathEscape(spec.Tag))
+	}
+
+	var release GitHubRelease
+	if err := c.getJSON(ctx, endpoint, "release", spec.String(), &release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// Releases lists the most recent releases of spec (its tag is ignored),
+// newest first and prereleases included
+func (c *GitHubClient) Releases(ctx context.Context, spec GitHubSpec, perPage int) ([]GitHubRelease, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/releases?per_page=%d", strings.TrimRight(c.BaseURL, "/"), spec.Owner, spec.Repo, perPage)
+
+	var releases []GitHubRelease
+	if err := c.getJSON(ctx, endpoint, "releases", spec.Repository(), &releases); err != nil {
+		return nil, err
+	}
+	return releases, nil
+}
+
+// GitHubRepository is the subset of the Repositories API response upkg uses
+type GitHubRepository struct {
+	FullName    string `json:"full_name"`
+	Description string `json:"description"`
+}
+
+// Repository fetches the repository metadata of spec (its tag is ignored)
+func (c *GitHubClient) Repository(ctx context.Context, spec GitHubSpec) (*GitHubRepository, error) {
+	endpoint := fmt.Sprintf("%s/repos/%s/%s", strings.TrimRight(c.BaseURL, "/"), spec.Owner, spec.Repo)
+
+	var repo GitHubRepository
+	if err := c.getJSON(ctx, endpoint, "repository", spec.Repository(), &repo); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+// getJSON decodes the API response for endpoint into v; kind and subject
+// name the requested resource in errors
+func (c *GitHubClient) getJSON(ctx context.Context, endpoint, kind, subject string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("create GitHub request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "upkg")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("GitHub request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%s not found: %s", kind, subject)
+	case resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0":
+		return fmt.Errorf("GitHub API rate limit exceeded (set sources.github_token or GITHUB_TOKEN)")
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("GitHub API returned %s for %s", resp.Status, subject)
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(v); err != nil {
+		return fmt.Errorf("decode GitHub %s: %w", kind, err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package fetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGitHubSpec(t *testing.T) {
+	t.Parallel()
+
+	spec, err := ParseGitHubSpec("gh:BurntSushi/ripgrep")
+	require.NoError(t, err)
+	assert.Equal(t, GitHubSpec{Owner: "BurntSushi", Repo: "ripgrep"}, spec)
+	assert.Equal(t, "gh:BurntSushi/ripgrep", spec.String())
+
+	spec, err = ParseGitHubSpec("gh:owner/repo.name@v1.2.3")
+	require.NoError(t, err)
+	assert.Equal(t, GitHubSpec{Owner: "owner", Repo: "repo.name", Tag: "v1.2.3"}, spec)
+	assert.Equal(t, "owner/repo.name", spec.Repository())
+
+	for _, invalid := range []string{"owner/repo", "gh:owner", "gh:/repo", "gh:owner/repo/extra", "gh:owner/repo@", "gh:own er/repo"} {
+		_, err := ParseGitHubSpec(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestGitHubAsset_SHA256(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "abc", GitHubAsset{Digest: "sha256:abc"}.SHA256())
+	assert.Empty(t, GitHubAsset{Digest: "sha512:abc"}.SHA256())
+	assert.Empty(t, GitHubAsset{}.SHA256())
+}
+
+func TestGitHubClient_Release(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/app/releases/latest":
+			assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+			_, _ = w.Write([]byte(`{"tag_name":"v2.0.0","assets":[{"name":"app-x86_64.AppImage","browser_download_url":"https://dl/app","size":3,"digest":"sha256:ff"}]}`))
+		case "/repos/owner/app/releases/tags/v1.0.0":
+			_, _ = w.Write([]byte(`{"tag_name":"v1.0.0","assets":[]}`))
+		case "/repos/owner/limited/releases/latest":
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewGitHubClient("secret")
+	client.BaseURL = server.URL
+	ctx := context.Background()
+
+	release, err := client.Release(ctx, GitHubSpec{Owner: "owner", Repo: "app"})
+	require.NoError(t, err)
+	assert.Equal(t, "v2.0.0", release.TagName)
+	assert.Equal(t, []string{"app-x86_64.AppImage"}, release.AssetNames())
+	asset, ok := release.Asset("app-x86_64.AppImage")
+	require.True(t, ok)
+	assert.Equal(t, "https://dl/app", asset.DownloadURL)
+	assert.Equal(t, "ff", asset.SHA256())
+
+	release, err = client.Release(ctx, GitHubSpec{Owner: "owner", Repo: "app", Tag: "v1.0.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0.0", release.TagName)
+
+	_, err = client.Release(ctx, GitHubSpec{Owner: "owner", Repo: "missing"})
+	assert.ErrorContains(t, err, "release not found: gh:owner/missing")
+
+	_, err = client.Release(ctx, GitHubSpec{Owner: "owner", Repo: "limited"})
+	assert.ErrorContains(t, err, "rate limit")
+}
+
+func TestGitHubClient_Repository(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/app":
+			_, _ = w.Write([]byte(`{"full_name":"owner/app","description":"A fast note-taking app"}`))
+		case "/repos/owner/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewGitHubClient("")
+	client.BaseURL = server.URL
+	ctx := context.Background()
+
+	repo, err := client.Repository(ctx, GitHubSpec{Owner: "owner", Repo: "app", Tag: "v1.0.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "A fast note-taking app", repo.Description)
+
+	_, err = client.Repository(ctx, GitHubSpec{Owner: "owner", Repo: "missing"})
+	assert.ErrorContains(t, err, "repository not found: owner/missing")
+
+	_, err = client.Repository(ctx, GitHubSpec{Owner: "owner", Repo: "broken"})
+	assert.ErrorContains(t, err, "GitHub API returned 500 Internal Server Error for owner/broken")
+}
+
+func TestGitHubClient_Releases(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/app/releases" {
+			http.NotFound(w, r)
+			return
+		}
+		assert.Equal(t, "5", r.URL.Query().Get("per_page"))
+		_, _ = w.Write([]byte(`[{"tag_name":"nightly","prerelease":true},{"tag_name":"v1.0.0"}]`))
+	}))
+	defer server.Close()
+
+	client := NewGitHubClient("")
+	client.BaseURL = server.URL
+
+	releases, err := client.Releases(context.Background(), GitHubSpec{Owner: "owner", Repo: "app", Tag: "v1.0.0"}, 5)
+	require.NoError(t, err)
+	require.Len(t, releases, 2)
+	assert.Equal(t, "nightly", releases[0].TagName)
+	assert.True(t, releases[0].Prerelease)
+	assert.False(t, releases[1].Prerelease)
+
+	_, err = client.Releases(context.Background(), GitHubSpec{Owner: "owner", Repo: "missing"}, 5)
+	assert.ErrorContains(t, err, "releases not found: owner/missing")
+}
@@ -3,19 +3,23 @@ package helpers
 import (
 	"archive/tar"
 	"archive/zip"
+	"bytes"
 	"compress/bzip2"
 	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"math"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 
 	"github.com/quantmind-br/upkg/internal/security"
 	"github.com/ulikunitz/xz"
 )
 
-// Extraction limits to prevent archive bombs
+// Default extraction limits to prevent archive bombs; see WithLimits
 const (
 	MaxExtractedSize      = 10 * 1024 * 1024 * 1024 // 10GB
 	MaxFileCount          = 100000                  // 100k files
@@ -23,45 +27,142 @@ const (
 	MaxIndividualFileSize = 5 * 1024 * 1024 * 1024  // 5GB per file
 )
 
+// ErrQuotaExceeded is returned when extraction would exceed the package size quota
+var ErrQuotaExceeded = errors.New("package size quota exceeded")
+
+// ExtractOption configures archive extraction
+type ExtractOption func(*extractionLimiter)
+
+// WithSizeQuota aborts extraction once the cumulative extracted size exceeds
+// maxBytes and calls onWarn once when it crosses warnBytes. Zero disables a limit.
+func WithSizeQuota(maxBytes, warnBytes int64, onWarn func(totalBytes int64)) ExtractOption {
+	return func(e *extractionLimiter) {
+		e.quotaBytes = maxBytes
+		e.warnBytes = warnBytes
+		e.onWarn = onWarn
+	}
+}
+
+// WithLimits overrides the archive bomb limits: the total extracted size, the
+// number of entries and the compression ratio, both of the whole archive and
+// of each zip entry. Zero keeps the default limit.
+func WithLimits(maxBytes int64, maxFiles int, maxRatio int64) ExtractOption {
+	return func(e *extractionLimiter) {
+		if maxBytes > 0 {
+			e.maxBytes = maxBytes
+		}
+		if maxFiles > 0 {
+			e.maxFiles = maxFiles
+		}
+		if maxRatio > 0 {
+			e.maxRatio = maxRatio
+		}
+	}
+}
+
+// WithProgress calls onProgress as extraction advances. Tar archives report
+// the archive bytes read, so compressed streams advance evenly; zip archives
+// report uncompressed bytes.
+func WithProgress(onProgress ProgressFunc) ExtractOption {
+	return func(e *extractionLimiter) {
+		e.onProgress = onProgress
+	}
+}
+
 // extractionLimiter tracks extraction metrics to prevent bombs
 type extractionLimiter struct {
 	totalBytes   int64
 	fileCount    int
 	originalSize int64
+
+	maxBytes int64
+	maxFiles int
+	maxRatio int64
+
+	quotaBytes int64
+	warnBytes  int64
+	onWarn     func(totalBytes int64)
+	warned     bool
+
+	onProgress ProgressFunc
 }
 
-func newExtractionLimiter(originalSize int64) *extractionLimiter {
-	return &extractionLimiter{
+func newExtractionLimiter(originalSize int64, opts ...ExtractOption) *extractionLimiter {
+	e := &extractionLimiter{
 		originalSize: originalSize,
+		maxBytes:     MaxExtractedSize,
+		maxFiles:     MaxFileCount,
+		maxRatio:     MaxCompressionRatio,
+	}
+	for _, opt := range opts {
+		opt(e)
 	}
+	return e
+}
+
+// checkEntry counts an entry without data (directory, link) against the file
+// count limit, so archives cannot exhaust inodes with empty entries
+func (e *extractionLimiter) checkEntry() error {
+	e.fileCount++
+	if e.fileCount > e.maxFiles {
+		return fmt.Errorf("file count limit exceeded: %d files (max %d)", e.fileCount, e.maxFiles)
+	}
+	return nil
+}
+
+// checkRatio rejects a single entry whose declared compression ratio is
+// implausible, catching bombs hidden in an otherwise small archive
+func (e *extractionLimiter) checkRatio(compressedSize, uncompressedSize int64) error {
+	if compressedSize <= 0 || uncompressedSize <= compressedSize*e.maxRatio {
+		return nil
+	}
+	return fmt.Errorf("entry compression ratio too high: %d:1 (possible archive bomb, max %d:1)",
+		uncompressedSize/compressedSize, e.maxRatio)
 }
 
 func (e *extractionLimiter) checkLimits(fileSize int64) error {
 	e.totalBytes += fileSize
-	e.fileCount++
+	if err := e.checkEntry(); err != nil {
+		return err
+	}
 
-	if e.totalBytes > MaxExtractedSize {
-		return fmt.Errorf("extraction size limit exceeded: %d bytes (max %d)", e.totalBytes, MaxExtractedSize)
+	if e.quotaBytes > 0 && e.totalBytes > e.quotaBytes {
+		return fmt.Errorf("%w: %d bytes extracted (max %d)", ErrQuotaExceeded, e.totalBytes, e.quotaBytes)
 	}
 
-	if e.fileCount > MaxFileCount {
-		return fmt.Errorf("file count limit exceeded: %d files (max %d)", e.fileCount, MaxFileCount)
+	if e.warnBytes > 0 && !e.warned && e.totalBytes > e.warnBytes {
+		e.warned = true
+		if e.onWarn != nil {
+			e.onWarn(e.totalBytes)
+		}
+	}
+
+	if e.totalBytes > e.maxBytes {
+		return fmt.Errorf("extraction size limit exceeded: %d bytes (max %d)", e.totalBytes, e.maxBytes)
 	}
 
 	if fileSize > MaxIndividualFileSize {
 		return fmt.Errorf("individual file too large: %d bytes (max %d)", fileSize, MaxIndividualFileSize)
 	}
 
-	if e.originalSize > 0 && e.totalBytes > e.originalSize*MaxCompressionRatio {
+	if e.originalSize > 0 && e.totalBytes > e.originalSize*e.maxRatio {
 		return fmt.Errorf("compression ratio too high: %d:1 (possible archive bomb, max %d:1)",
-			e.totalBytes/e.originalSize, MaxCompressionRatio)
+			e.totalBytes/e.originalSize, e.maxRatio)
 	}
 
 	return nil
 }
 
+// limitError wraps a limiter error, keeping quota errors distinct from bomb protection
+func limitError(err error) error {
+	if errors.Is(err, ErrQuotaExceeded) {
+		return err
+	}
+	return fmt.Errorf("archive bomb protection triggered: %w", err)
+}
+
 // ExtractTarGz extracts a .tar.gz archive with security checks
-func ExtractTarGz(archivePath, destDir string) error {
+func ExtractTarGz(archivePath, destDir string, opts ...ExtractOption) error {
 	// Get original file size for compression ratio check
 	info, err := os.Stat(archivePath)
 	if err != nil {
@@ -74,18 +175,18 @@ func ExtractTarGz(archivePath, destDir string) error {
 	}
 	defer file.Close()
 
-	gzr, err := gzip.NewReader(file)
+	limiter := newExtractionLimiter(info.Size(), opts...)
+	gzr, err := gzip.NewReader(NewProgressReader(file, info.Size(), limiter.onProgress))
 	if err != nil {
 		return fmt.Errorf("failed to create gzip reader: %w", err)
 	}
 	defer gzr.Close()
 
-	limiter := newExtractionLimiter(info.Size())
 	return extractTar(gzr, destDir, limiter)
 }
 
 // ExtractTar extracts a .tar archive with security checks
-func ExtractTar(archivePath, destDir string) error {
+func ExtractTar(archivePath, destDir string, opts ...ExtractOption) error {
 	info, err := os.Stat(archivePath)
 	if err != nil {
 		return fmt.Errorf("failed to stat archive: %w", err)
@@ -97,12 +198,12 @@ func ExtractTar(archivePath, destDir string) error {
 	}
 	defer file.Close()
 
-	limiter := newExtractionLimiter(info.Size())
-	return extractTar(file, destDir, limiter)
+	limiter := newExtractionLimiter(info.Size(), opts...)
+	return extractTar(NewProgressReader(file, info.Size(), limiter.onProgress), destDir, limiter)
 }
 
 // ExtractTarXz extracts a .tar.xz archive with security checks
-func ExtractTarXz(archivePath, destDir string) error {
+func ExtractTarXz(archivePath, destDir string, opts ...ExtractOption) error {
 	info, err := os.Stat(archivePath)
 	if err != nil {
 		return fmt.Errorf("failed to stat archive: %w", err)
@@ -114,18 +215,19 @@ func ExtractTarXz(archivePath, destDir string) error {
 	}
 	defer file.Close()
 
+	limiter := newExtractionLimiter(info.Size(), opts...)
+
 	// Use xz decompressor
-	xzr, err := xz.NewReader(file)
+	xzr, err := xz.NewReader(NewProgressReader(file, info.Size(), limiter.onProgress))
 	if err != nil {
 		return fmt.Errorf("failed to create xz reader: %w", err)
 	}
 
-	limiter := newExtractionLimiter(info.Size())
 	return extractTar(xzr, destDir, limiter)
 }
 
 // ExtractTarBz2 extracts a .tar.bz2 archive with security checks
-func ExtractTarBz2(archivePath, destDir string) error {
+func ExtractTarBz2(archivePath, destDir string, opts ...ExtractOption) error {
 	info, err := os.Stat(archivePath)
 	if err != nil {
 		return fmt.Errorf("failed to stat archive: %w", err)
@@ -137,13 +239,63 @@ func ExtractTarBz2(archivePath, destDir string) error {
 	}
 	defer file.Close()
 
+	limiter := newExtractionLimiter(info.Size(), opts...)
+
 	// Use bzip2 decompressor
-	bzr := bzip2.NewReader(file)
+	bzr := bzip2.NewReader(NewProgressReader(file, info.Size(), limiter.onProgress))
 
-	limiter := newExtractionLimiter(info.Size())
 	return extractTar(bzr, destDir, limiter)
 }
 
+// ExtractTarCommand extracts an archive Go cannot decode (zstd, lz4, 7z)
+// through cmd, an external tool that writes it as a tar stream to stdout,
+// with the security checks of ExtractTar. Unless cmd sets its own Stdin,
+// the archive is fed on stdin and read progress is reported.
+func ExtractTarCommand(cmd *exec.Cmd, archivePath, destDir string, opts ...ExtractOption) error {
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat archive: %w", err)
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	limiter := newExtractionLimiter(info.Size(), opts...)
+	if cmd.Stdin == nil {
+		cmd.Stdin = NewProgressReader(file, info.Size(), limiter.onProgress)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", filepath.Base(cmd.Path), err)
+	}
+
+	if err := extractTar(stdout, destDir, limiter); err != nil {
+		// Stop the tool instead of letting it decompress the rest
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w (%s: %s)", err, filepath.Base(cmd.Path), msg)
+		}
+		return err
+	}
+	_, _ = io.Copy(io.Discard, stdout)
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", filepath.Base(cmd.Path), err, strings.TrimSpace(stderr.String()))
+	}
+	if limiter.onProgress != nil {
+		limiter.onProgress(info.Size(), info.Size())
+	}
+	return nil
+}
+
 //nolint:gocyclo // tar extraction handles multiple entry types and security checks.
 func extractTar(r io.Reader, destDir string, limiter *extractionLimiter) error {
 	tr := tar.NewReader(r)
@@ -157,13 +309,22 @@ func extractTar(r io.Reader, destDir string, limiter *extractionLimiter) error {
 			return fmt.Errorf("tar read error: %w", err)
 		}
 
+		name := tarEntryName(header.Name)
+
 		// Security: Validate path to prevent directory traversal
-		if err := security.ValidateExtractPath(destDir, header.Name); err != nil {
+		if err := security.ValidateExtractPath(destDir, name); err != nil {
 			return fmt.Errorf("invalid path in archive: %w", err)
 		}
 
-		//nolint:gosec // G305: header.Name is validated by ValidateExtractPath above.
-		target := filepath.Join(destDir, header.Name)
+		//nolint:gosec // G305: name is validated by ValidateExtractPath above.
+		target := filepath.Join(destDir, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir, tar.TypeSymlink, tar.TypeLink:
+			if err := limiter.checkEntry(); err != nil {
+				return limitError(err)
+			}
+		}
 
 		switch header.Typeflag {
 		case tar.TypeDir:
@@ -174,28 +335,30 @@ func extractTar(r io.Reader, destDir string, limiter *extractionLimiter) error {
 		case tar.TypeReg:
 			// Check extraction limits before extracting file
 			if err := limiter.checkLimits(header.Size); err != nil {
-				return fmt.Errorf("archive bomb protection triggered: %w", err)
+				return limitError(err)
 			}
 
 			if err := extractFile(tr, target, header.FileInfo().Mode()); err != nil {
-				return fmt.Errorf("failed to extract file %s: %w", header.Name, err)
+				return fmt.Errorf("failed to extract file %s: %w", name, err)
 			}
 
 		case tar.TypeSymlink:
+			linkname := tarEntryName(header.Linkname)
 			// Security: Validate symlink target
-			if err := security.ValidateSymlink(destDir, target, header.Linkname); err != nil {
+			if err := security.ValidateSymlink(destDir, target, linkname); err != nil {
 				return fmt.Errorf("invalid symlink: %w", err)
 			}
 
-			if err := os.Symlink(header.Linkname, target); err != nil {
+			if err := os.Symlink(linkname, target); err != nil {
 				return fmt.Errorf("failed to create symlink: %w", err)
 			}
 
 		case tar.TypeLink:
 			// Hard link - validate and create
-			//nolint:gosec // G305: header.Linkname is validated by ValidateExtractPath above.
-			linkTarget := filepath.Join(destDir, header.Linkname)
-			if err := security.ValidateExtractPath(destDir, header.Linkname); err != nil {
+			linkname := tarEntryName(header.Linkname)
+			//nolint:gosec // G305: linkname is validated by ValidateExtractPath above.
+			linkTarget := filepath.Join(destDir, linkname)
+			if err := security.ValidateExtractPath(destDir, linkname); err != nil {
 				return fmt.Errorf("invalid hard link target: %w", err)
 			}
 
@@ -233,7 +396,7 @@ func extractFile(r io.Reader, target string, mode os.FileMode) error {
 }
 
 // ExtractZip extracts a .zip archive with security checks
-func ExtractZip(archivePath, destDir string) error {
+func ExtractZip(archivePath, destDir string, opts ...ExtractOption) error {
 	info, err := os.Stat(archivePath)
 	if err != nil {
 		return fmt.Errorf("failed to stat archive: %w", err)
@@ -245,18 +408,31 @@ func ExtractZip(archivePath, destDir string) error {
 	}
 	defer r.Close()
 
-	limiter := newExtractionLimiter(info.Size())
+	limiter := newExtractionLimiter(info.Size(), opts...)
+
+	var extracted, total int64
+	if limiter.onProgress != nil {
+		for _, f := range r.File {
+			total += int64(min(f.UncompressedSize64, math.MaxInt64)) //nolint:gosec // G115: clamped to MaxInt64.
+		}
+	}
 
 	for _, f := range r.File {
+		// Windows tools often store names in a legacy code page
+		name := zipEntryName(f)
+
 		// Security: Validate path
-		if err := security.ValidateExtractPath(destDir, f.Name); err != nil {
+		if err := security.ValidateExtractPath(destDir, name); err != nil {
 			return fmt.Errorf("invalid path in zip: %w", err)
 		}
 
-		//nolint:gosec // G305: f.Name is validated by ValidateExtractPath above.
-		target := filepath.Join(destDir, f.Name)
+		//nolint:gosec // G305: name is validated by ValidateExtractPath above.
+		target := filepath.Join(destDir, name)
 
 		if f.FileInfo().IsDir() {
+			if err := limiter.checkEntry(); err != nil {
+				return limitError(err)
+			}
 			if err := os.MkdirAll(target, f.Mode()); err != nil {
 				return fmt.Errorf("failed to create directory: %w", err)
 			}
@@ -270,11 +446,19 @@ func ExtractZip(archivePath, destDir string) error {
 
 		// Check extraction limits before extracting file
 		if err := limiter.checkLimits(uncompressedSize); err != nil {
-			return fmt.Errorf("archive bomb protection triggered: %w", err)
+			return limitError(err)
+		}
+		//nolint:gosec // G115: compressed size never exceeds the archive size.
+		if err := limiter.checkRatio(int64(min(f.CompressedSize64, math.MaxInt64)), uncompressedSize); err != nil {
+			return limitError(fmt.Errorf("%s: %w", name, err))
 		}
 
 		if err := extractZipFile(f, target, uncompressedSize); err != nil {
-			return fmt.Errorf("failed to extract %s: %w", f.Name, err)
+			return fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+		if limiter.onProgress != nil {
+			extracted += uncompressedSize
+			limiter.onProgress(extracted, total)
 		}
 	}
 
@@ -0,0 +1,128 @@
+package helpers
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"hash/crc32"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// zipUnicodePathExtraID is the Info-ZIP Unicode Path extra field, which
+// carries a UTF-8 copy of a legacy-encoded entry name
+const zipUnicodePathExtraID = 0x7075
+
+// Hosts in the upper byte of zip.FileHeader.CreatorVersion whose tools write
+// entry names in the OEM code page
+const (
+	zipCreatorFAT  = 0
+	zipCreatorNTFS = 11
+	zipCreatorVFAT = 14
+)
+
+var (
+	// Windows tools (Explorer, older 7-Zip/WinZip) use the OEM code page;
+	// the zip specification defines CP437 as the default
+	zipLegacyEncodings = []encoding.Encoding{charmap.CodePage437, charmap.Windows1252, charmap.CodePage850}
+	// Tar has no encoding flag; legacy Unix locales were mostly Latin-1
+	tarLegacyEncodings = []encoding.Encoding{charmap.Windows1252, charmap.ISO8859_15, charmap.CodePage437}
+)
+
+// zipEntryName returns the name of a zip entry as UTF-8. A UTF-8 copy from
+// the Unicode Path extra field wins; names that are already valid UTF-8 are
+// kept; anything else is decoded from the most plausible legacy code page.
+func zipEntryName(f *zip.File) string {
+	if name, ok := zipUnicodePath(f.Extra, f.Name); ok {
+		return name
+	}
+	if utf8.ValidString(f.Name) {
+		return f.Name
+	}
+
+	candidates := zipLegacyEncodings
+	switch f.CreatorVersion >> 8 {
+	case zipCreatorFAT, zipCreatorNTFS, zipCreatorVFAT:
+	default:
+		// Non-Windows creators rarely use an OEM code page
+		candidates = tarLegacyEncodings
+	}
+	return decodeLegacyName(f.Name, candidates)
+}
+
+// tarEntryName returns a tar header name or link target as UTF-8. PAX
+// headers are UTF-8 already; raw ustar/GNU names from legacy locales are
+// decoded from the most plausible code page.
+func tarEntryName(name string) string {
+	if utf8.ValidString(name) {
+		return name
+	}
+	return decodeLegacyName(name, tarLegacyEncodings)
+}
+
+// zipUnicodePath extracts the Info-ZIP Unicode Path (version 1) for raw
+// from extra. The field is ignored when its CRC no longer matches the
+// legacy name, as the specification requires.
+func zipUnicodePath(extra []byte, raw string) (string, bool) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra[0:2])
+		size := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if len(extra) < 4+size {
+			return "", false
+		}
+		field := extra[4 : 4+size]
+		extra = extra[4+size:]
+
+		if id != zipUnicodePathExtraID || len(field) < 5 || field[0] != 1 {
+			continue
+		}
+		if binary.LittleEndian.Uint32(field[1:5]) != crc32.ChecksumIEEE([]byte(raw)) {
+			continue
+		}
+		name := string(field[5:])
+		if name != "" && utf8.ValidString(name) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// decodeLegacyName decodes raw with each candidate and keeps the result that
+// reads most like a file name. Earlier candidates win ties.
+func decodeLegacyName(raw string, candidates []encoding.Encoding) string {
+	best, bestScore := raw, -1
+	for _, enc := range candidates {
+		decoded, err := enc.NewDecoder().String(raw)
+		if err != nil {
+			continue
+		}
+		if score := nameScore(decoded); score > bestScore {
+			best, bestScore = decoded, score
+		}
+	}
+	if bestScore < 0 {
+		// Keep the path usable even if nothing decodes it
+		return string([]rune(raw))
+	}
+	return best
+}
+
+// nameScore rates how plausible the non-ASCII part of a decoded name is:
+// letters count for, box drawing, symbols and control characters against
+func nameScore(name string) int {
+	score := 0
+	for _, r := range name {
+		switch {
+		case r < utf8.RuneSelf:
+		case unicode.IsLetter(r):
+			score += 2
+		case unicode.IsDigit(r), unicode.IsSpace(r), r == '’', r == '–', r == '—':
+			score++
+		default:
+			score -= 2
+		}
+	}
+	return max(score, 0)
+}
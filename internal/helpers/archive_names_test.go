@@ -0,0 +1,132 @@
+package helpers
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// legacyZipEntry is a zip entry whose name is stored as raw bytes, the way
+// Windows Explorer and older archivers write it
+type legacyZipEntry struct {
+	raw         string // Name bytes as stored in the archive
+	host        uint16 // CreatorVersion host byte
+	unicodePath string // Optional Info-ZIP Unicode Path extra field
+}
+
+func createLegacyZip(t *testing.T, path string, entries []legacyZipEntry) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	defer zw.Close()
+
+	for _, entry := range entries {
+		header := &zip.FileHeader{
+			Name:           entry.raw,
+			NonUTF8:        true,
+			CreatorVersion: entry.host << 8,
+			Method:         zip.Deflate,
+		}
+		if entry.unicodePath != "" {
+			header.Extra = unicodePathExtra(entry.raw, entry.unicodePath)
+		}
+		fw, err := zw.CreateHeader(header)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte("data"))
+		require.NoError(t, err)
+	}
+}
+
+func unicodePathExtra(raw, name string) []byte {
+	var field bytes.Buffer
+	field.WriteByte(1)
+	_ = binary.Write(&field, binary.LittleEndian, crc32.ChecksumIEEE([]byte(raw)))
+	field.WriteString(name)
+
+	var extra bytes.Buffer
+	_ = binary.Write(&extra, binary.LittleEndian, uint16(zipUnicodePathExtraID))
+	_ = binary.Write(&extra, binary.LittleEndian, uint16(field.Len()))
+	extra.Write(field.Bytes())
+	return extra.Bytes()
+}
+
+func TestExtractZip_LegacyEncodedNames(t *testing.T) {
+	tmpDir := t.TempDir()
+	zipPath := filepath.Join(tmpDir, "legacy.zip")
+	createLegacyZip(t, zipPath, []legacyZipEntry{
+		// "Größe/Übersicht.txt" in CP437, as written by Windows Explorer
+		{raw: "Gr\x94\xe1e/\x9abersicht.txt", host: zipCreatorFAT},
+		// "café.png" in Windows-1252 from a Unix-hosted archiver
+		{raw: "res/caf\xe9.png", host: 3},
+		// CP437 name with a UTF-8 copy in the Unicode Path field (7-Zip, Info-ZIP)
+		{raw: "docs/r\x82sum\x82.pdf", host: zipCreatorNTFS, unicodePath: "docs/résumé.pdf"},
+	})
+
+	destDir := filepath.Join(tmpDir, "extract")
+	require.NoError(t, ExtractZip(zipPath, destDir))
+
+	assert.FileExists(t, filepath.Join(destDir, "Größe", "Übersicht.txt"))
+	assert.FileExists(t, filepath.Join(destDir, "res", "café.png"))
+	assert.FileExists(t, filepath.Join(destDir, "docs", "résumé.pdf"))
+}
+
+func TestExtractZip_UTF8NamesUnchanged(t *testing.T) {
+	tmpDir := t.TempDir()
+	zipPath := filepath.Join(tmpDir, "utf8.zip")
+	createTestZip(t, zipPath, map[string]string{"日本語/ファイル.txt": "content"})
+
+	destDir := filepath.Join(tmpDir, "extract")
+	require.NoError(t, ExtractZip(zipPath, destDir))
+	assert.FileExists(t, filepath.Join(destDir, "日本語", "ファイル.txt"))
+}
+
+func TestExtractTar_Latin1Names(t *testing.T) {
+	tmpDir := t.TempDir()
+	tarPath := filepath.Join(tmpDir, "latin1.tar")
+
+	f, err := os.Create(tarPath)
+	require.NoError(t, err)
+	tw := tar.NewWriter(f)
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name:   "share/na\xefve.txt",
+		Mode:   0644,
+		Size:   4,
+		Format: tar.FormatGNU,
+	}))
+	_, err = tw.Write([]byte("data"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, f.Close())
+
+	destDir := filepath.Join(tmpDir, "extract")
+	require.NoError(t, ExtractTar(tarPath, destDir))
+	assert.FileExists(t, filepath.Join(destDir, "share", "naïve.txt"))
+}
+
+func TestZipUnicodePath_StaleCRC(t *testing.T) {
+	extra := unicodePathExtra("old-name", "new-name")
+	_, ok := zipUnicodePath(extra, "renamed")
+	assert.False(t, ok, "a Unicode Path for a different legacy name must be ignored")
+
+	name, ok := zipUnicodePath(extra, "old-name")
+	assert.True(t, ok)
+	assert.Equal(t, "new-name", name)
+}
+
+func TestTarEntryName(t *testing.T) {
+	assert.Equal(t, "plain/ascii", tarEntryName("plain/ascii"))
+	assert.Equal(t, "ünïcode", tarEntryName("ünïcode"))
+	assert.Equal(t, "Ärger", tarEntryName("\xc4rger"))
+}
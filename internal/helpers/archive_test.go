@@ -4,8 +4,11 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"compress/gzip"
+	"math/rand"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -273,7 +276,159 @@ func TestExtractionLimiter(t *testing.T) {
 	})
 }
 
+func TestExtractionLimiter_SizeQuota(t *testing.T) {
+	t.Run("warns once past threshold", func(t *testing.T) {
+		var warnings []int64
+		limiter := newExtractionLimiter(0, WithSizeQuota(0, 150, func(total int64) {
+			warnings = append(warnings, total)
+		}))
+		assert.NoError(t, limiter.checkLimits(100))
+		assert.NoError(t, limiter.checkLimits(100))
+		assert.NoError(t, limiter.checkLimits(100))
+		assert.Equal(t, []int64{200}, warnings)
+	})
+
+	t.Run("exceeds quota", func(t *testing.T) {
+		limiter := newExtractionLimiter(0, WithSizeQuota(250, 0, nil))
+		assert.NoError(t, limiter.checkLimits(200))
+		err := limiter.checkLimits(100)
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+	})
+
+	t.Run("extraction aborts on quota", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		tarGzPath := filepath.Join(tmpDir, "big.tar.gz")
+		createTestTarGz(t, tarGzPath, map[string]string{
+			"file1.txt": "0123456789",
+			"file2.txt": "0123456789",
+		})
+
+		err := ExtractTarGz(tarGzPath, filepath.Join(tmpDir, "out"), WithSizeQuota(15, 0, nil))
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+		assert.NotContains(t, err.Error(), "archive bomb")
+	})
+}
+
+func TestExtractionLimiter_WithLimits(t *testing.T) {
+	t.Run("overrides size and file count", func(t *testing.T) {
+		limiter := newExtractionLimiter(0, WithLimits(150, 0, 0))
+		assert.NoError(t, limiter.checkLimits(100))
+		err := limiter.checkLimits(100)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "extraction size limit exceeded")
+
+		limiter = newExtractionLimiter(0, WithLimits(0, 2, 0))
+		assert.NoError(t, limiter.checkEntry())
+		assert.NoError(t, limiter.checkLimits(1))
+		err = limiter.checkEntry()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "file count limit exceeded")
+	})
+
+	t.Run("zero keeps defaults", func(t *testing.T) {
+		limiter := newExtractionLimiter(0, WithLimits(0, 0, 0))
+		assert.Equal(t, int64(MaxExtractedSize), limiter.maxBytes)
+		assert.Equal(t, MaxFileCount, limiter.maxFiles)
+		assert.Equal(t, int64(MaxCompressionRatio), limiter.maxRatio)
+	})
+
+	t.Run("directories count toward file limit", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		tarPath := filepath.Join(tmpDir, "dirs.tar")
+		f, err := os.Create(tarPath)
+		require.NoError(t, err)
+		tw := tar.NewWriter(f)
+		for _, name := range []string{"a/", "b/", "c/"} {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeDir, Mode: 0755}))
+		}
+		require.NoError(t, tw.Close())
+		require.NoError(t, f.Close())
+
+		err = ExtractTar(tarPath, filepath.Join(tmpDir, "out"), WithLimits(0, 2, 0))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "archive bomb protection triggered")
+	})
+
+	t.Run("rejects highly compressed zip entry", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		zipPath := filepath.Join(tmpDir, "bomb.zip")
+		// Incompressible data keeps the ratio of the whole archive low
+		noise := make([]byte, 64<<10)
+		rand.New(rand.NewSource(1)).Read(noise)
+		createTestZip(t, zipPath, map[string]string{
+			"noise.bin": string(noise),
+			"zeros.bin": strings.Repeat("\x00", 1<<20),
+		})
+
+		err := ExtractZip(zipPath, filepath.Join(tmpDir, "out"), WithLimits(0, 0, 100))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "entry compression ratio too high")
+		assert.Contains(t, err.Error(), "zeros.bin")
+	})
+}
+
+func TestExtract_WithProgress(t *testing.T) {
+	files := map[string]string{"file1.txt": "0123456789", "dir/file2.txt": "abcdef"}
+	tmpDir := t.TempDir()
+	tarGzPath := filepath.Join(tmpDir, "app.tar.gz")
+	zipPath := filepath.Join(tmpDir, "app.zip")
+	createTestTarGz(t, tarGzPath, files)
+	createTestZip(t, zipPath, files)
+
+	for name, extract := range map[string]func(string, string, ...ExtractOption) error{
+		tarGzPath: ExtractTarGz,
+		zipPath:   ExtractZip,
+	} {
+		var calls int
+		var done, total int64
+		err := extract(name, filepath.Join(tmpDir, filepath.Base(name)+".out"), WithProgress(func(d, t int64) {
+			calls++
+			done, total = d, t
+		}))
+		require.NoError(t, err, name)
+		assert.Positive(t, calls, name)
+		assert.Positive(t, total, name)
+		assert.Equal(t, total, done, "%s reports completion", name)
+	}
+}
+
 // Helper functions
+func TestExtractTarCommand(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat not available")
+	}
+	tmpDir := t.TempDir()
+	tarPath := filepath.Join(tmpDir, "app.tar")
+	createTestTar(t, tarPath, map[string]string{"bin/app": "binary", "README": "hello"})
+
+	t.Run("extracts the stream written by the tool", func(t *testing.T) {
+		destDir := filepath.Join(tmpDir, "out")
+		var done, total int64
+		err := ExtractTarCommand(exec.Command("cat"), tarPath, destDir, WithProgress(func(d, t int64) {
+			done, total = d, t
+		}))
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(filepath.Join(destDir, "bin/app"))
+		require.NoError(t, err)
+		assert.Equal(t, "binary", string(content))
+		assert.Positive(t, total)
+		assert.Equal(t, total, done)
+	})
+
+	t.Run("enforces limits", func(t *testing.T) {
+		err := ExtractTarCommand(exec.Command("cat"), tarPath, filepath.Join(tmpDir, "limited"), WithLimits(0, 1, 0))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "archive bomb protection triggered")
+	})
+
+	t.Run("reports tool failure", func(t *testing.T) {
+		err := ExtractTarCommand(exec.Command("sh", "-c", "echo broken >&2; exit 3"), tarPath, filepath.Join(tmpDir, "failed"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "broken")
+	})
+}
+
 func createTestTarGz(t *testing.T, path string, files map[string]string) {
 	t.Helper()
 
@@ -0,0 +1,132 @@
+package helpers
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrCorruptArchive marks extraction failures caused by a damaged or truncated archive
+var ErrCorruptArchive = errors.New("corrupt archive")
+
+// CorruptionKind classifies archive corruption
+type CorruptionKind string
+
+const (
+	CorruptionTruncated CorruptionKind = "truncated" // Archive ends early (partial download)
+	CorruptionChecksum  CorruptionKind = "checksum"  // CRC/checksum mismatch
+	CorruptionFormat    CorruptionKind = "format"    // Invalid header or structure
+)
+
+// ArchiveCorruptionError describes a corrupted archive with the data needed to diagnose it
+type ArchiveCorruptionError struct {
+	Path           string
+	Kind           CorruptionKind
+	ActualSize     int64
+	ActualSHA256   string
+	ExpectedSize   int64  // 0 when unknown
+	ExpectedSHA256 string // empty when unknown
+	Err            error
+}
+
+func (e *ArchiveCorruptionError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s archive (%s): size %d bytes", e.Kind, e.Path, e.ActualSize)
+	if e.ExpectedSize > 0 {
+		fmt.Fprintf(&b, ", expected %d", e.ExpectedSize)
+	}
+	if e.ActualSHA256 != "" {
+		fmt.Fprintf(&b, ", sha256 %s", e.ActualSHA256)
+	}
+	if e.ExpectedSHA256 != "" {
+		fmt.Fprintf(&b, ", expected sha256 %s", e.ExpectedSHA256)
+	}
+	fmt.Fprintf(&b, ": %v", e.Err)
+	return b.String()
+}
+
+// Unwrap exposes both ErrCorruptArchive and the underlying extraction error
+func (e *ArchiveCorruptionError) Unwrap() []error {
+	return []error{ErrCorruptArchive, e.Err}
+}
+
+// ClassifyExtractError inspects an extraction error and returns an
+// *ArchiveCorruptionError when it was caused by a damaged archive.
+// Other errors (quota, permissions, bomb protection) are returned unchanged.
+func ClassifyExtractError(archivePath string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	kind, ok := corruptionKind(err)
+	if !ok {
+		return err
+	}
+
+	corruptErr := &ArchiveCorruptionError{
+		Path: archivePath,
+		Kind: kind,
+		Err:  err,
+	}
+	if info, statErr := os.Stat(archivePath); statErr == nil {
+		corruptErr.ActualSize = info.Size()
+	}
+	if sum, hashErr := FileSHA256(archivePath); hashErr == nil {
+		corruptErr.ActualSHA256 = sum
+	}
+
+	return corruptErr
+}
+
+// corruptionKind maps known decoder errors to a corruption class
+func corruptionKind(err error) (CorruptionKind, bool) {
+	var bzErr bzip2.StructuralError
+
+	switch {
+	case errors.Is(err, ErrQuotaExceeded):
+		return "", false
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return CorruptionTruncated, true
+	case errors.Is(err, gzip.ErrChecksum), errors.Is(err, zip.ErrChecksum):
+		return CorruptionChecksum, true
+	case errors.Is(err, gzip.ErrHeader), errors.Is(err, zip.ErrFormat),
+		errors.Is(err, tar.ErrHeader), errors.As(err, &bzErr):
+		return CorruptionFormat, true
+	}
+
+	// The xz decoder does not export sentinel errors
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "xz: unexpected EOF"), strings.Contains(msg, "unexpected end of"):
+		return CorruptionTruncated, true
+	case strings.Contains(msg, "xz: ") && strings.Contains(msg, "checksum"):
+		return CorruptionChecksum, true
+	case strings.Contains(msg, "xz: "):
+		return CorruptionFormat, true
+	}
+
+	return "", false
+}
+
+// FileSHA256 returns the hex-encoded SHA-256 of a file
+func FileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,79 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyExtractError_TruncatedTarGz(t *testing.T) {
+	tmpDir := t.TempDir()
+	archivePath := filepath.Join(tmpDir, "app.tar.gz")
+	createTestTarGz(t, archivePath, map[string]string{
+		"app/bin/app": strings.Repeat("payload", 4096),
+	})
+
+	data, err := os.ReadFile(archivePath)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(archivePath, data[:len(data)/2], 0644))
+
+	err = ClassifyExtractError(archivePath, ExtractTarGz(archivePath, filepath.Join(tmpDir, "out")))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrCorruptArchive))
+
+	var corruptErr *ArchiveCorruptionError
+	require.True(t, errors.As(err, &corruptErr))
+	assert.Equal(t, CorruptionTruncated, corruptErr.Kind)
+	assert.Equal(t, int64(len(data)/2), corruptErr.ActualSize)
+	assert.Len(t, corruptErr.ActualSHA256, 64)
+}
+
+func TestClassifyExtractError_InvalidZip(t *testing.T) {
+	tmpDir := t.TempDir()
+	archivePath := filepath.Join(tmpDir, "app.zip")
+	require.NoError(t, os.WriteFile(archivePath, []byte("not a zip"), 0644))
+
+	err := ClassifyExtractError(archivePath, ExtractZip(archivePath, filepath.Join(tmpDir, "out")))
+
+	var corruptErr *ArchiveCorruptionError
+	require.True(t, errors.As(err, &corruptErr))
+	assert.Equal(t, CorruptionFormat, corruptErr.Kind)
+}
+
+func TestClassifyExtractError_Passthrough(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, ClassifyExtractError("/missing", nil))
+
+	quotaErr := fmt.Errorf("extract: %w", ErrQuotaExceeded)
+	assert.Equal(t, quotaErr, ClassifyExtractError("/missing", quotaErr))
+
+	otherErr := errors.New("permission denied")
+	assert.Equal(t, otherErr, ClassifyExtractError("/missing", otherErr))
+}
+
+func TestArchiveCorruptionError_Error(t *testing.T) {
+	t.Parallel()
+
+	err := &ArchiveCorruptionError{
+		Path:           "/tmp/app.tar.gz",
+		Kind:           CorruptionChecksum,
+		ActualSize:     10,
+		ExpectedSize:   20,
+		ActualSHA256:   "abc",
+		ExpectedSHA256: "def",
+		Err:            errors.New("gzip: invalid checksum"),
+	}
+
+	msg := err.Error()
+	assert.Contains(t, msg, "checksum archive")
+	assert.Contains(t, msg, "expected 20")
+	assert.Contains(t, msg, "expected sha256 def")
+	assert.Contains(t, msg, "gzip: invalid checksum")
+}
@@ -0,0 +1,264 @@
+package helpers
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/security"
+)
+
+const (
+	cpioHeaderSize = 110
+	cpioTrailer    = "TRAILER!!!"
+
+	cpioTypeMask    = 0170000
+	cpioTypeDir     = 0040000
+	cpioTypeRegular = 0100000
+	cpioTypeSymlink = 0120000
+)
+
+// cpioHeader holds the fields of a "newc" cpio header that extraction needs
+type cpioHeader struct {
+	ino      uint64
+	mode     uint64
+	nlink    uint64
+	fileSize int64
+	name     string
+}
+
+// ExtractCpio extracts a "newc" cpio stream (the payload format of RPM
+// packages) into destDir with the same security checks as ExtractTar.
+// originalSize is the size of the compressed package, for the compression
+// ratio check. Device nodes and FIFOs are skipped.
+//
+//nolint:gocyclo // cpio extraction handles multiple entry types, hard links and security checks.
+func ExtractCpio(r io.Reader, destDir string, originalSize int64, opts ...ExtractOption) error {
+	br := bufio.NewReader(r)
+	limiter := newExtractionLimiter(originalSize, opts...)
+
+	// newc archives store hard links as empty entries followed by one
+	// carrying the data; links are created once that entry is written
+	pendingLinks := make(map[uint64][]string)
+	written := make(map[uint64]string)
+
+	for {
+		header, err := readCpioHeader(br)
+		if err != nil {
+			return err
+		}
+		if header.name == cpioTrailer {
+			break
+		}
+
+		name := strings.TrimPrefix(filepath.Clean("/"+header.name), "/")
+		if name == "" {
+			if err := skipCpioData(br, header.fileSize, header.fileSize); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Security: Validate path to prevent directory traversal
+		if err := security.ValidateExtractPath(destDir, name); err != nil {
+			return fmt.Errorf("invalid path in archive: %w", err)
+		}
+
+		//nolint:gosec // G305: name is validated by ValidateExtractPath above.
+		target := filepath.Join(destDir, name)
+		mode := os.FileMode(header.mode & 0777)
+
+		switch header.mode & cpioTypeMask {
+		case cpioTypeDir:
+			if err := limiter.checkEntry(); err != nil {
+				return limitError(err)
+			}
+			if err := os.MkdirAll(target, mode|0700); err != nil {
+				return fmt.Errorf("failed to create directory: %w", err)
+			}
+			if err := skipCpioData(br, header.fileSize, header.fileSize); err != nil {
+				return err
+			}
+
+		case cpioTypeRegular:
+			if header.nlink > 1 && header.fileSize == 0 {
+				if err := limiter.checkEntry(); err != nil {
+					return limitError(err)
+				}
+				if first, ok := written[header.ino]; ok {
+					if err := linkCpioFile(first, target); err != nil {
+						return err
+					}
+				} else {
+					pendingLinks[header.ino] = append(pendingLinks[header.ino], target)
+				}
+				continue
+			}
+
+			// Check extraction limits before extracting file
+			if err := limiter.checkLimits(header.fileSize); err != nil {
+				return limitError(err)
+			}
+			if err := extractFile(&cpioDataReader{r: br, n: header.fileSize}, target, mode); err != nil {
+				return fmt.Errorf("failed to extract file %s: %w", name, err)
+			}
+			if err := skipCpioData(br, 0, header.fileSize); err != nil {
+				return err
+			}
+
+			if header.nlink > 1 {
+				written[header.ino] = target
+				for _, link := range pendingLinks[header.ino] {
+					if err := linkCpioFile(target, link); err != nil {
+						return err
+					}
+				}
+				delete(pendingLinks, header.ino)
+			}
+
+		case cpioTypeSymlink:
+			if err := limiter.checkEntry(); err != nil {
+				return limitError(err)
+			}
+			if header.fileSize > 4096 {
+				return fmt.Errorf("symlink target too long: %s", name)
+			}
+			buf := make([]byte, header.fileSize)
+			if _, err := io.ReadFull(br, buf); err != nil {
+				return fmt.Errorf("cpio read error: %w", err)
+			}
+			if err := skipCpioData(br, 0, header.fileSize); err != nil {
+				return err
+			}
+			linkname := string(buf)
+
+			// Security: Validate symlink target
+			if err := security.ValidateSymlink(destDir, target, linkname); err != nil {
+				return fmt.Errorf("invalid symlink: %w", err)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("failed to create parent directory: %w", err)
+			}
+			if err := os.Symlink(linkname, target); err != nil {
+				return fmt.Errorf("failed to create symlink: %w", err)
+			}
+
+		default:
+			// Skip unsupported types (block/char devices, FIFOs, sockets)
+			if err := skipCpioData(br, header.fileSize, header.fileSize); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Links whose data entry never came are empty files
+	for _, links := range pendingLinks {
+		for _, link := range links {
+			if err := extractFile(strings.NewReader(""), link, 0644); err != nil {
+				return fmt.Errorf("failed to extract file %s: %w", link, err)
+			}
+		}
+	}
+	return nil
+}
+
+// readCpioHeader reads the next header and name, including name padding
+func readCpioHeader(br *bufio.Reader) (cpioHeader, error) {
+	raw := make([]byte, cpioHeaderSize)
+	if _, err := io.ReadFull(br, raw); err != nil {
+		return cpioHeader{}, fmt.Errorf("cpio read error: %w", err)
+	}
+	magic := string(raw[:6])
+	if magic != "070701" && magic != "070702" {
+		return cpioHeader{}, fmt.Errorf("unsupported cpio format (magic %q)", magic)
+	}
+
+	field := func(i int) (uint64, error) {
+		start := 6 + i*8
+		v, err := strconv.ParseUint(string(raw[start:start+8]), 16, 64)
+		if err != nil {
+			return 0, fmt.Errorf("corrupt cpio header: %w", err)
+		}
+		return v, nil
+	}
+
+	var values [13]uint64
+	for i := range values {
+		v, err := field(i)
+		if err != nil {
+			return cpioHeader{}, err
+		}
+		values[i] = v
+	}
+	nameSize := values[11]
+	if nameSize == 0 || nameSize > 4096 {
+		return cpioHeader{}, fmt.Errorf("corrupt cpio header: name size %d", nameSize)
+	}
+
+	name := make([]byte, nameSize)
+	if _, err := io.ReadFull(br, name); err != nil {
+		return cpioHeader{}, fmt.Errorf("cpio read error: %w", err)
+	}
+	if _, err := br.Discard(cpioPadding(cpioHeaderSize + int64(nameSize))); err != nil {
+		return cpioHeader{}, fmt.Errorf("cpio read error: %w", err)
+	}
+
+	return cpioHeader{
+		ino:      values[0],
+		mode:     values[1],
+		nlink:    values[4],
+		fileSize: int64(values[6]),
+		name:     strings.TrimRight(string(name), "\x00"),
+	}, nil
+}
+
+// skipCpioData discards the unread bytes of an entry's data, followed by
+// the padding after its size bytes of data
+func skipCpioData(br *bufio.Reader, unread, size int64) error {
+	if _, err := io.CopyN(io.Discard, br, unread+int64(cpioPadding(size))); err != nil {
+		return fmt.Errorf("cpio read error: %w", err)
+	}
+	return nil
+}
+
+// cpioDataReader reads the n bytes of an entry's data, failing when the
+// stream ends early
+type cpioDataReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *cpioDataReader) Read(p []byte) (int, error) {
+	if c.n <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > c.n {
+		p = p[:c.n]
+	}
+	n, err := c.r.Read(p)
+	c.n -= int64(n)
+	if err == io.EOF && c.n > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// cpioPadding returns the bytes that align offset to 4
+func cpioPadding(offset int64) int {
+	return int((4 - offset%4) % 4)
+}
+
+// linkCpioFile hard-links target to an already extracted file
+func linkCpioFile(existing, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create parent directory: %w", err)
+	}
+	if err := os.Link(existing, target); err != nil {
+		return fmt.Errorf("failed to create hard link: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package helpers
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cpioEntry is one entry of a generated newc archive
+type cpioEntry struct {
+	name  string
+	mode  uint32
+	ino   uint32
+	nlink uint32
+	data  string
+}
+
+// cpioArchive writes a "newc" cpio archive the way rpmbuild does
+func cpioArchive(entries ...cpioEntry) []byte {
+	var buf bytes.Buffer
+	write := func(e cpioEntry) {
+		nlink := e.nlink
+		if nlink == 0 {
+			nlink = 1
+		}
+		fmt.Fprintf(&buf, "070701%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+			e.ino, e.mode, 0, 0, nlink, 0, len(e.data), 0, 0, 0, 0, len(e.name)+1, 0)
+		buf.WriteString(e.name + "\x00")
+		for buf.Len()%4 != 0 {
+			buf.WriteByte(0)
+		}
+		buf.WriteString(e.data)
+		for buf.Len()%4 != 0 {
+			buf.WriteByte(0)
+		}
+	}
+	for _, e := range entries {
+		write(e)
+	}
+	write(cpioEntry{name: cpioTrailer})
+	return buf.Bytes()
+}
+
+func TestExtractCpio(t *testing.T) {
+	t.Parallel()
+
+	archive := cpioArchive(
+		cpioEntry{name: "./usr", mode: cpioTypeDir | 0755},
+		cpioEntry{name: "./usr/bin/app", mode: cpioTypeRegular | 0755, ino: 1, data: "#!/bin/sh\necho hi\n"},
+		cpioEntry{name: "./usr/bin/app-link", mode: cpioTypeSymlink | 0777, ino: 2, data: "app"},
+		// Hard links: empty entries first, the data on the last one
+		cpioEntry{name: "./usr/lib/a", mode: cpioTypeRegular | 0644, ino: 3, nlink: 2},
+		cpioEntry{name: "./usr/lib/b", mode: cpioTypeRegular | 0644, ino: 3, nlink: 2, data: "shared"},
+		cpioEntry{name: "./dev/null", mode: 0020000 | 0666, ino: 4},
+	)
+
+	dest := t.TempDir()
+	require.NoError(t, ExtractCpio(bytes.NewReader(archive), dest, int64(len(archive))))
+
+	content, err := os.ReadFile(filepath.Join(dest, "usr", "bin", "app"))
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\necho hi\n", string(content))
+	info, err := os.Stat(filepath.Join(dest, "usr", "bin", "app"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	target, err := os.Readlink(filepath.Join(dest, "usr", "bin", "app-link"))
+	require.NoError(t, err)
+	assert.Equal(t, "app", target)
+
+	for _, name := range []string{"a", "b"} {
+		content, err := os.ReadFile(filepath.Join(dest, "usr", "lib", name))
+		require.NoError(t, err)
+		assert.Equal(t, "shared", string(content), name)
+	}
+	assert.NoFileExists(t, filepath.Join(dest, "dev", "null"))
+}
+
+func TestExtractCpio_Errors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("path traversal", func(t *testing.T) {
+		// Leading ../ are resolved against the archive root
+		root := t.TempDir()
+		dest := filepath.Join(root, "dest")
+		archive := cpioArchive(cpioEntry{name: "../../evil", mode: cpioTypeRegular | 0644, data: "x"})
+		require.NoError(t, ExtractCpio(bytes.NewReader(archive), dest, 0))
+		assert.FileExists(t, filepath.Join(dest, "evil"))
+		assert.NoFileExists(t, filepath.Join(root, "evil"))
+	})
+
+	t.Run("symlink escaping", func(t *testing.T) {
+		archive := cpioArchive(cpioEntry{name: "./link", mode: cpioTypeSymlink | 0777, data: "../../etc/passwd"})
+		err := ExtractCpio(bytes.NewReader(archive), t.TempDir(), 0)
+		assert.ErrorContains(t, err, "invalid symlink")
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		archive := cpioArchive(cpioEntry{name: "./file", mode: cpioTypeRegular | 0644, data: "0123456789"})
+		err := ExtractCpio(bytes.NewReader(archive[:cpioHeaderSize+12]), t.TempDir(), 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("bad magic", func(t *testing.T) {
+		archive := cpioArchive()
+		copy(archive, "070707")
+		err := ExtractCpio(bytes.NewReader(archive), t.TempDir(), 0)
+		assert.ErrorContains(t, err, "unsupported cpio format")
+	})
+
+	t.Run("quota", func(t *testing.T) {
+		archive := cpioArchive(cpioEntry{name: "./file", mode: cpioTypeRegular | 0644, data: "0123456789"})
+		err := ExtractCpio(bytes.NewReader(archive), t.TempDir(), 0, WithSizeQuota(5, 0, nil))
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+	})
+}
@@ -0,0 +1,240 @@
+package helpers
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// ErrUnsupportedDebCompression is returned when a DEB member uses a
+// compression format upkg cannot decode without external tools (zstd)
+var ErrUnsupportedDebCompression = errors.New("unsupported DEB member compression")
+
+const (
+	arMagic      = "!<arch>\n"
+	arHeaderSize = 60
+)
+
+// debMember is one entry of the ar archive a DEB package is made of
+type debMember struct {
+	name string
+	size int64
+}
+
+// DebDataMember returns the name of the data.tar.* member of a DEB package
+func DebDataMember(archivePath string) (string, error) {
+	var name string
+	err := walkDeb(archivePath, func(m debMember, _ io.Reader) (bool, error) {
+		if strings.HasPrefix(m.name, "data.tar") {
+			name = m.name
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", fmt.Errorf("no data.tar member in DEB package")
+	}
+	return name, nil
+}
+
+// ExtractDebData extracts the payload (data.tar.*) of a DEB package into
+// destDir with the same security checks as ExtractTar. Members compressed
+// with zstd return ErrUnsupportedDebCompression.
+func ExtractDebData(archivePath, destDir string, opts ...ExtractOption) error {
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat archive: %w", err)
+	}
+
+	found := false
+	err = walkDeb(archivePath, func(m debMember, r io.Reader) (bool, error) {
+		if !strings.HasPrefix(m.name, "data.tar") {
+			return false, nil
+		}
+		found = true
+		limiter := newExtractionLimiter(info.Size(), opts...)
+		dr, err := debMemberReader(m.name, NewProgressReader(r, m.size, limiter.onProgress))
+		if err != nil {
+			return true, err
+		}
+		return true, extractTar(dr, destDir, limiter)
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("no data.tar member in DEB package")
+	}
+	return nil
+}
+
+// ReadDebControl returns the fields of the control file of a DEB package.
+// Continuation lines are joined to their field with newlines, as dpkg-deb
+// --field prints them.
+func ReadDebControl(archivePath string) (map[string]string, error) {
+	var fields map[string]string
+	err := walkDeb(archivePath, func(m debMember, r io.Reader) (bool, error) {
+		if !strings.HasPrefix(m.name, "control.tar") {
+			return false, nil
+		}
+		cr, err := debMemberReader(m.name, r)
+		if err != nil {
+			return true, err
+		}
+		tr := tar.NewReader(cr)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				return true, fmt.Errorf("no control file in DEB package")
+			}
+			if err != nil {
+				return true, fmt.Errorf("tar read error: %w", err)
+			}
+			if path.Clean(header.Name) != "control" {
+				continue
+			}
+			fields, err = parseDebControl(io.LimitReader(tr, 1<<20))
+			return true, err
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, fmt.Errorf("no control.tar member in DEB package")
+	}
+	return fields, nil
+}
+
+// parseDebControl parses a deb822 paragraph
+func parseDebControl(r io.Reader) (map[string]string, error) {
+	fields := make(map[string]string)
+	var last string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			if len(fields) > 0 {
+				break
+			}
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if last != "" {
+				fields[last] += "\n" + strings.TrimSpace(line)
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed control line: %q", line)
+		}
+		last = strings.TrimSpace(key)
+		fields[last] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read control file: %w", err)
+	}
+	return fields, nil
+}
+
+// walkDeb calls fn for each member of the ar archive at archivePath until fn
+// reports it is done
+func walkDeb(archivePath string, fn func(m debMember, r io.Reader) (bool, error)) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer file.Close()
+
+	br := bufio.NewReader(file)
+	magic := make([]byte, len(arMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != arMagic {
+		return fmt.Errorf("not a DEB package: missing ar header")
+	}
+
+	header := make([]byte, arHeaderSize)
+	for {
+		if _, err := io.ReadFull(br, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("truncated DEB package: %w", err)
+		}
+		if !bytes.Equal(header[58:60], []byte("`\n")) {
+			return fmt.Errorf("corrupt ar member header in DEB package")
+		}
+		size, err := strconv.ParseInt(strings.TrimSpace(string(header[48:58])), 10, 64)
+		if err != nil || size < 0 {
+			return fmt.Errorf("corrupt ar member size in DEB package")
+		}
+		member := debMember{
+			// GNU ar terminates names with a slash
+			name: strings.TrimSuffix(strings.TrimSpace(string(header[:16])), "/"),
+			size: size,
+		}
+
+		body := io.LimitReader(br, size)
+		done, err := fn(member, body)
+		if err != nil {
+			return fmt.Errorf("%s: %w", member.name, err)
+		}
+		if done {
+			return nil
+		}
+		// Members are padded to an even offset
+		if _, err := io.Copy(io.Discard, body); err != nil {
+			return fmt.Errorf("truncated DEB package: %w", err)
+		}
+		if size%2 == 1 {
+			if _, err := br.Discard(1); err != nil && err != io.EOF {
+				return fmt.Errorf("truncated DEB package: %w", err)
+			}
+		}
+	}
+}
+
+// debMemberReader decompresses a control.tar.* or data.tar.* member
+func debMemberReader(name string, r io.Reader) (io.Reader, error) {
+	switch {
+	case strings.HasSuffix(name, ".tar"):
+		return r, nil
+	case strings.HasSuffix(name, ".gz"):
+		gzr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		return gzr, nil
+	case strings.HasSuffix(name, ".xz"):
+		xzr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create xz reader: %w", err)
+		}
+		return xzr, nil
+	case strings.HasSuffix(name, ".lzma"):
+		lr, err := lzma.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create lzma reader: %w", err)
+		}
+		return lr, nil
+	case strings.HasSuffix(name, ".bz2"):
+		return bzip2.NewReader(r), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDebCompression, name)
+	}
+}
@@ -0,0 +1,176 @@
+package helpers
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+)
+
+// arMember is one member of a generated ar archive
+type arMember struct {
+	name string
+	data []byte
+}
+
+// writeAr writes an ar archive the way dpkg-deb lays out DEB packages
+func writeAr(t *testing.T, path string, members ...arMember) {
+	t.Helper()
+	var buf bytes.Buffer
+	buf.WriteString(arMagic)
+	for _, m := range members {
+		fmt.Fprintf(&buf, "%-16s%-12d%-6d%-6d%-8s%-10d`\n", m.name+"/", 0, 0, 0, "100644", len(m.data))
+		buf.Write(m.data)
+		if len(m.data)%2 == 1 {
+			buf.WriteByte('\n')
+		}
+	}
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+}
+
+// tarBytes builds a tar stream from name -> content; names ending in / are directories
+func tarBytes(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		header := &tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if name[len(name)-1] == '/' {
+			header.Typeflag, header.Size = tar.TypeDir, 0
+		}
+		require.NoError(t, tw.WriteHeader(header))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err := gw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+func xzBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	xw, err := xz.NewWriter(&buf)
+	require.NoError(t, err)
+	_, err = xw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, xw.Close())
+	return buf.Bytes()
+}
+
+func TestExtractDebData(t *testing.T) {
+	t.Parallel()
+
+	control := tarBytes(t, map[string]string{
+		"./control": "Package: my-app\nVersion: 1.2.3-1\nDescription: A test app\n Longer text\n .\n More\n",
+	})
+	data := tarBytes(t, map[string]string{
+		"./usr/":            "",
+		"./usr/bin/":        "",
+		"./usr/bin/my-app":  "#!/bin/sh\n",
+		"./opt/My App/data": "x",
+	})
+
+	tests := []struct {
+		name    string
+		members []arMember
+	}{
+		{"gzip", []arMember{
+			{"debian-binary", []byte("2.0\n")},
+			{"control.tar.gz", gzipBytes(t, control)},
+			{"data.tar.gz", gzipBytes(t, data)},
+		}},
+		{"xz", []arMember{
+			{"debian-binary", []byte("2.0\n")},
+			{"control.tar.xz", xzBytes(t, control)},
+			{"data.tar.xz", xzBytes(t, data)},
+		}},
+		{"uncompressed", []arMember{
+			{"debian-binary", []byte("2.0\n")},
+			{"control.tar", control},
+			{"data.tar", data},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			debPath := filepath.Join(dir, "pkg.deb")
+			writeAr(t, debPath, tt.members...)
+
+			dest := filepath.Join(dir, "root")
+			require.NoError(t, ExtractDebData(debPath, dest))
+			content, err := os.ReadFile(filepath.Join(dest, "usr", "bin", "my-app"))
+			require.NoError(t, err)
+			assert.Equal(t, "#!/bin/sh\n", string(content))
+			assert.FileExists(t, filepath.Join(dest, "opt", "My App", "data"))
+
+			fields, err := ReadDebControl(debPath)
+			require.NoError(t, err)
+			assert.Equal(t, "my-app", fields["Package"])
+			assert.Equal(t, "1.2.3-1", fields["Version"])
+			assert.Equal(t, "A test app\nLonger text\n.\nMore", fields["Description"])
+
+			member, err := DebDataMember(debPath)
+			require.NoError(t, err)
+			assert.Equal(t, tt.members[2].name, member)
+		})
+	}
+}
+
+func TestExtractDebData_Errors(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	t.Run("zstd payload", func(t *testing.T) {
+		debPath := filepath.Join(dir, "zstd.deb")
+		writeAr(t, debPath,
+			arMember{"debian-binary", []byte("2.0\n")},
+			arMember{"data.tar.zst", []byte("not decoded")},
+		)
+		err := ExtractDebData(debPath, filepath.Join(dir, "zstd"))
+		assert.True(t, errors.Is(err, ErrUnsupportedDebCompression))
+		assert.ErrorContains(t, err, "data.tar.zst")
+	})
+
+	t.Run("not an ar archive", func(t *testing.T) {
+		debPath := filepath.Join(dir, "plain.deb")
+		require.NoError(t, os.WriteFile(debPath, []byte("plain text"), 0644))
+		assert.ErrorContains(t, ExtractDebData(debPath, filepath.Join(dir, "plain")), "missing ar header")
+	})
+
+	t.Run("no payload", func(t *testing.T) {
+		debPath := filepath.Join(dir, "empty.deb")
+		writeAr(t, debPath, arMember{"debian-binary", []byte("2.0\n")})
+		assert.ErrorContains(t, ExtractDebData(debPath, filepath.Join(dir, "empty")), "no data.tar member")
+		_, err := ReadDebControl(debPath)
+		assert.ErrorContains(t, err, "no control.tar member")
+	})
+
+	t.Run("path traversal", func(t *testing.T) {
+		debPath := filepath.Join(dir, "evil.deb")
+		writeAr(t, debPath,
+			arMember{"debian-binary", []byte("2.0\n")},
+			arMember{"data.tar", tarBytes(t, map[string]string{"../../evil": "x"})},
+		)
+		assert.ErrorContains(t, ExtractDebData(debPath, filepath.Join(dir, "evil")), "invalid path")
+	})
+}
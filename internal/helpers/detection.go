@@ -3,6 +3,7 @@ package helpers
 import (
 	"bytes"
 	"debug/elf"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"os"
@@ -22,8 +23,11 @@ const (
 	FileTypeTarGz    FileType = "tar.gz"
 	FileTypeTarXz    FileType = "tar.xz"
 	FileTypeTarBz2   FileType = "tar.bz2"
+	FileTypeTarZst   FileType = "tar.zst"
+	FileTypeTarLz4   FileType = "tar.lz4"
 	FileTypeTar      FileType = "tar"
 	FileTypeZip      FileType = "zip"
+	FileType7z       FileType = "7z"
 	FileTypeUnknown  FileType = "unknown"
 )
 
@@ -41,6 +45,8 @@ func DetectFileType(filePath string) (FileType, error) {
 		return FileTypeRPM, nil
 	case ".zip":
 		return FileTypeZip, nil
+	case ".7z":
+		return FileType7z, nil
 	case ".appimage":
 		// Verify it's actually an AppImage
 		if isAppImage, err := IsAppImage(filePath); err == nil && isAppImage {
@@ -64,6 +70,15 @@ func DetectFileType(filePath string) (FileType, error) {
 		return FileTypeTarBz2, nil
 	}
 
+	if strings.HasSuffix(strings.ToLower(filePath), ".tar.zst") ||
+		strings.HasSuffix(strings.ToLower(filePath), ".tzst") {
+		return FileTypeTarZst, nil
+	}
+
+	if strings.HasSuffix(strings.ToLower(filePath), ".tar.lz4") {
+		return FileTypeTarLz4, nil
+	}
+
 	if ext == ".tar" {
 		return FileTypeTar, nil
 	}
@@ -127,6 +142,21 @@ func DetectFileType(filePath string) (FileType, error) {
 		return FileTypeTarBz2, nil
 	}
 
+	// Zstandard magic: 0x28 0xB5 0x2F 0xFD
+	if len(header) >= 4 && bytes.Equal(header[:4], []byte{0x28, 0xB5, 0x2F, 0xFD}) {
+		return FileTypeTarZst, nil
+	}
+
+	// LZ4 frame magic: 0x04 0x22 0x4D 0x18
+	if len(header) >= 4 && bytes.Equal(header[:4], []byte{0x04, 0x22, 0x4D, 0x18}) {
+		return FileTypeTarLz4, nil
+	}
+
+	// 7z magic: '7' 'z' 0xBC 0xAF 0x27 0x1C
+	if len(header) >= 6 && bytes.Equal(header[:6], []byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C}) {
+		return FileType7z, nil
+	}
+
 	// ZIP magic: "PK"
 	if len(header) >= 2 && bytes.Equal(header[:2], []byte{'P', 'K'}) {
 		return FileTypeZip, nil
@@ -185,7 +215,94 @@ func IsAppImage(filePath string) (bool, error) {
 	}
 	defer func() { _ = f.Close() }()
 
-	return hasSquashFS(f), nil
+	return hasSquashFS(f) || AppImageType(f) == AppImageType1, nil
+}
+
+// AppImage formats defined by the AppImage specification
+const (
+	AppImageTypeUnknown = 0
+	AppImageType1       = 1 // ISO9660 payload
+	AppImageType2       = 2 // squashfs payload
+)
+
+// iso9660Offset is where the primary volume descriptor identifier sits in an
+// ISO9660 image (after the 32 KiB system area holding the AppImage runtime)
+const iso9660Offset = 0x8001
+
+// AppImageType reports the AppImage format of r from the "AI" magic in the
+// ELF identification padding. Old type-1 images written without the magic
+// are recognized by their ISO9660 volume descriptor.
+func AppImageType(r io.ReaderAt) int {
+	header := make([]byte, 11)
+	if n, _ := r.ReadAt(header, 0); n == len(header) && header[8] == 'A' && header[9] == 'I' {
+		switch header[10] {
+		case AppImageType1, AppImageType2:
+			return int(header[10])
+		}
+	}
+
+	magic := make([]byte, 5)
+	if n, _ := r.ReadAt(magic, iso9660Offset); n == len(magic) && string(magic) == "CD001" {
+		return AppImageType1
+	}
+	return AppImageTypeUnknown
+}
+
+// AppImageOffset returns where the filesystem image of an AppImage starts:
+// right after the runtime ELF, i.e. past both its section header table and
+// its last section. This is the offset the runtime prints for --appimage-offset.
+func AppImageOffset(r io.ReaderAt) (int64, error) {
+	ident := make([]byte, elf.EI_NIDENT)
+	if _, err := r.ReadAt(ident, 0); err != nil {
+		return 0, fmt.Errorf("read ELF header: %w", err)
+	}
+	if string(ident[:4]) != elf.ELFMAG {
+		return 0, fmt.Errorf("not an ELF file")
+	}
+
+	var order binary.ByteOrder = binary.LittleEndian
+	if elf.Data(ident[elf.EI_DATA]) == elf.ELFDATA2MSB {
+		order = binary.BigEndian
+	}
+
+	// e_shoff, e_shentsize and e_shnum sit at class-specific offsets
+	var header []byte
+	var shoff int64
+	var shentsize, shnum uint16
+	switch elf.Class(ident[elf.EI_CLASS]) {
+	case elf.ELFCLASS64:
+		header = make([]byte, 64)
+		if _, err := r.ReadAt(header, 0); err != nil {
+			return 0, fmt.Errorf("read ELF header: %w", err)
+		}
+		shoff = int64(order.Uint64(header[0x28:]))
+		shentsize, shnum = order.Uint16(header[0x3A:]), order.Uint16(header[0x3C:])
+	case elf.ELFCLASS32:
+		header = make([]byte, 52)
+		if _, err := r.ReadAt(header, 0); err != nil {
+			return 0, fmt.Errorf("read ELF header: %w", err)
+		}
+		shoff = int64(order.Uint32(header[0x20:]))
+		shentsize, shnum = order.Uint16(header[0x2E:]), order.Uint16(header[0x30:])
+	default:
+		return 0, fmt.Errorf("unknown ELF class %d", ident[elf.EI_CLASS])
+	}
+
+	if shoff <= 0 {
+		return 0, fmt.Errorf("ELF file has no section header table")
+	}
+	end := shoff + int64(shentsize)*int64(shnum)
+
+	file, err := elf.NewFile(r)
+	if err != nil {
+		return 0, fmt.Errorf("parse ELF: %w", err)
+	}
+	for _, section := range file.Sections {
+		if section.Type != elf.SHT_NOBITS {
+			end = max(end, int64(section.Offset+section.FileSize))
+		}
+	}
+	return end, nil
 }
 
 func hasSquashFS(f *os.File) bool {
@@ -246,12 +363,21 @@ func GetArchiveType(filePath string) string {
 	if strings.HasSuffix(lower, ".tar.xz") || strings.HasSuffix(lower, ".txz") {
 		return "tar.xz"
 	}
+	if strings.HasSuffix(lower, ".tar.zst") || strings.HasSuffix(lower, ".tzst") {
+		return "tar.zst"
+	}
+	if strings.HasSuffix(lower, ".tar.lz4") {
+		return "tar.lz4"
+	}
 	if strings.HasSuffix(lower, ".tar") {
 		return "tar"
 	}
 	if strings.HasSuffix(lower, ".zip") {
 		return "zip"
 	}
+	if strings.HasSuffix(lower, ".7z") {
+		return "7z"
+	}
 
 	return ""
 }
@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
@@ -160,6 +161,62 @@ func TestIsAppImage(t *testing.T) {
 	}
 }
 
+func TestAppImageType(t *testing.T) {
+	elfHeader := func(magic ...byte) []byte {
+		header := append([]byte{0x7F, 'E', 'L', 'F', 2, 1, 1, 0}, magic...)
+		return append(header, make([]byte, 64)...)
+	}
+	iso := make([]byte, iso9660Offset+5)
+	copy(iso, elfHeader())
+	copy(iso[iso9660Offset:], "CD001")
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"type-1 magic", elfHeader('A', 'I', 1), AppImageType1},
+		{"type-2 magic", elfHeader('A', 'I', 2), AppImageType2},
+		{"unknown type byte", elfHeader('A', 'I', 3), AppImageTypeUnknown},
+		{"ISO9660 without magic", iso, AppImageType1},
+		{"plain ELF", elfHeader(), AppImageTypeUnknown},
+		{"short file", []byte{0x7F, 'E'}, AppImageTypeUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AppImageType(bytes.NewReader(tt.data)); got != tt.want {
+				t.Errorf("AppImageType() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppImageOffset(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skipf("no test executable: %v", err)
+	}
+	runtime, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The payload is appended to the runtime, so it starts where the ELF ends
+	appImage := append(bytes.Clone(runtime), []byte("hsqs payload")...)
+	offset, err := AppImageOffset(bytes.NewReader(appImage))
+	if err != nil {
+		t.Fatalf("AppImageOffset() error = %v", err)
+	}
+	if offset != int64(len(runtime)) {
+		t.Errorf("AppImageOffset() = %d, want %d", offset, len(runtime))
+	}
+
+	if _, err := AppImageOffset(bytes.NewReader([]byte("#!/bin/sh\necho not elf\n"))); err == nil {
+		t.Error("AppImageOffset() on a script succeeded, want error")
+	}
+}
+
 func TestIsELF(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -251,6 +308,26 @@ func TestGetArchiveType(t *testing.T) {
 			filePath:   "test.zip",
 			wantResult: "zip",
 		},
+		{
+			name:       "tar.zst file",
+			filePath:   "test.tar.zst",
+			wantResult: "tar.zst",
+		},
+		{
+			name:       "tzst file",
+			filePath:   "test.tzst",
+			wantResult: "tar.zst",
+		},
+		{
+			name:       "tar.lz4 file",
+			filePath:   "test.tar.lz4",
+			wantResult: "tar.lz4",
+		},
+		{
+			name:       "7z file",
+			filePath:   "test.7z",
+			wantResult: "7z",
+		},
 		{
 			name:       "unknown file",
 			filePath:   "test.txt",
@@ -344,6 +421,27 @@ func TestDetectFileTypeWithMockFiles(t *testing.T) {
 			wantType: FileTypeZip,
 			wantErr:  false,
 		},
+		{
+			name:     "ZSTD file",
+			filePath: "test.zst",
+			content:  []byte{0x28, 0xB5, 0x2F, 0xFD},
+			wantType: FileTypeTarZst,
+			wantErr:  false,
+		},
+		{
+			name:     "LZ4 file",
+			filePath: "test.lz4",
+			content:  []byte{0x04, 0x22, 0x4D, 0x18},
+			wantType: FileTypeTarLz4,
+			wantErr:  false,
+		},
+		{
+			name:     "7z file",
+			filePath: "test.bin",
+			content:  []byte{'7', 'z', 0xBC, 0xAF, 0x27, 0x1C},
+			wantType: FileType7z,
+			wantErr:  false,
+		},
 		{
 			name:     "unknown file",
 			filePath: "test.unknown",
@@ -9,6 +9,9 @@ import (
 	"os/exec"
 	"sync"
 	"time"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/security"
 )
 
 // CommandRunner defines an interface for executing system commands
@@ -70,7 +73,7 @@ func (r *OSCommandRunner) CommandExists(name string) bool {
 // RequireCommand ensures a command exists or returns error
 func (r *OSCommandRunner) RequireCommand(name string) error {
 	if !r.CommandExists(name) {
-		return fmt.Errorf("required command %q not found in PATH", name)
+		return &core.ErrMissingTool{Tool: name}
 	}
 	return nil
 }
@@ -85,7 +88,7 @@ func (r *OSCommandRunner) RunCommand(ctx context.Context, name string, args ...s
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("command %q failed: %w\nstderr: %s", name, err, stderr.String())
+		return "", fmt.Errorf("command %q failed: %w\nstderr: %s", name, err, security.Redact(stderr.String()))
 	}
 
 	return stdout.String(), nil
@@ -102,7 +105,7 @@ func (r *OSCommandRunner) RunCommandInDir(ctx context.Context, dir, name string,
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("command %q failed: %w\nstderr: %s", name, err, stderr.String())
+		return "", fmt.Errorf("command %q failed: %w\nstderr: %s", name, err, security.Redact(stderr.String()))
 	}
 
 	return stdout.String(), nil
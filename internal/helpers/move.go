@@ -0,0 +1,186 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/quantmind-br/upkg/internal/security"
+	"github.com/spf13/afero"
+)
+
+// MoveProgressFunc reports bytes copied so far out of total during a cross-device move
+type MoveProgressFunc func(copied, total int64)
+
+// MoveDir moves src to dst. It renames when possible and, when the rename
+// fails with EXDEV (src and dst on different filesystems), falls back to
+// copying the tree, verifying the copy and removing src.
+func MoveDir(fsys afero.Fs, src, dst string, onProgress MoveProgressFunc) error {
+	renameErr := fsys.Rename(src, dst)
+	if renameErr == nil {
+		return nil
+	}
+	if !errors.Is(renameErr, syscall.EXDEV) {
+		return fmt.Errorf("failed to move %s: %w", src, renameErr)
+	}
+
+	if err := CopyTree(fsys, src, dst, onProgress); err != nil {
+		if removeErr := fsys.RemoveAll(dst); removeErr != nil {
+			return fmt.Errorf("failed to copy %s: %w (cleanup failed: %v)", src, err, removeErr)
+		}
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+
+	if err := verifyTree(fsys, src, dst); err != nil {
+		if removeErr := fsys.RemoveAll(dst); removeErr != nil {
+			return fmt.Errorf("copy verification failed: %w (cleanup failed: %v)", err, removeErr)
+		}
+		return fmt.Errorf("copy verification failed: %w", err)
+	}
+
+	if err := fsys.RemoveAll(src); err != nil {
+		return fmt.Errorf("failed to remove source after copy: %w", err)
+	}
+	return nil
+}
+
+// CopyTree recursively copies src into dst, preserving modes and symlinks.
+// Entries that would escape dst are skipped.
+//
+//nolint:gocyclo // safe recursive copy with symlink handling is inherently branching.
+func CopyTree(fsys afero.Fs, src, dst string, onProgress MoveProgressFunc) error {
+	var total, copied int64
+	if onProgress != nil {
+		total, _ = treeSize(fsys, src)
+	}
+
+	return afero.Walk(fsys, src, func(path string, info fs.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+
+		relPath, relErr := filepath.Rel(src, path)
+		if relErr != nil {
+			return relErr
+		}
+		dstPath := filepath.Join(dst, relPath)
+
+		if validateErr := security.ValidateExtractPath(dst, relPath); validateErr != nil {
+			return nil
+		}
+
+		switch {
+		case info.IsDir():
+			return fsys.MkdirAll(dstPath, info.Mode().Perm())
+
+		case info.Mode()&fs.ModeSymlink != 0:
+			linkReader, ok := fsys.(afero.LinkReader)
+			if !ok {
+				return nil
+			}
+			linkTarget, readlinkErr := linkReader.ReadlinkIfPossible(path)
+			if readlinkErr != nil {
+				// Skip broken symlinks
+				return nil
+			}
+			if validateErr := security.ValidateSymlink(dst, dstPath, linkTarget); validateErr != nil {
+				return nil
+			}
+			linker, ok := fsys.(afero.Linker)
+			if !ok {
+				return nil
+			}
+			if mkdirErr := fsys.MkdirAll(filepath.Dir(dstPath), 0755); mkdirErr != nil {
+				return mkdirErr
+			}
+			return linker.SymlinkIfPossible(linkTarget, dstPath)
+
+		case info.Mode().IsRegular():
+			if err := copyTreeFile(fsys, path, dstPath, info.Mode().Perm()); err != nil {
+				return err
+			}
+			copied += info.Size()
+			if onProgress != nil {
+				onProgress(copied, total)
+			}
+		}
+
+		return nil
+	})
+}
+
+// copyTreeFile streams a single file, syncing it before close
+func copyTreeFile(fsys afero.Fs, src, dst string, mode fs.FileMode) (err error) {
+	srcFile, err := fsys.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open source file: %w", err)
+	}
+	defer func() { _ = srcFile.Close() }()
+
+	if mkdirErr := fsys.MkdirAll(filepath.Dir(dst), 0755); mkdirErr != nil {
+		return mkdirErr
+	}
+
+	dstFile, err := fsys.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("failed to create destination file: %w", err)
+	}
+	defer func() {
+		if cerr := dstFile.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close destination file: %w", cerr)
+		}
+	}()
+
+	if _, err = io.Copy(dstFile, srcFile); err != nil {
+		return fmt.Errorf("failed to copy file contents: %w", err)
+	}
+	return dstFile.Sync()
+}
+
+// verifyTree checks that every regular file in src exists in dst with the same size
+func verifyTree(fsys afero.Fs, src, dst string) error {
+	return afero.Walk(fsys, src, func(path string, info fs.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, relErr := filepath.Rel(src, path)
+		if relErr != nil {
+			return relErr
+		}
+		if security.ValidateExtractPath(dst, relPath) != nil {
+			return nil
+		}
+
+		dstInfo, statErr := fsys.Stat(filepath.Join(dst, relPath))
+		if statErr != nil {
+			return fmt.Errorf("missing %s: %w", relPath, statErr)
+		}
+		if dstInfo.Size() != info.Size() {
+			return fmt.Errorf("size mismatch for %s: %d != %d", relPath, dstInfo.Size(), info.Size())
+		}
+		return nil
+	})
+}
+
+// treeSize returns the total size of regular files under root
+func treeSize(fsys afero.Fs, root string) (int64, error) {
+	var total int64
+	err := afero.Walk(fsys, root, func(_ string, info fs.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
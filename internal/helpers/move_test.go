@@ -0,0 +1,91 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exdevFs simulates src and dst living on different filesystems
+type exdevFs struct {
+	*afero.OsFs
+}
+
+func (exdevFs) Rename(oldname, newname string) error {
+	return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EXDEV}
+}
+
+func TestMoveDir_Rename(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/src/a.txt", []byte("a"), 0644))
+
+	require.NoError(t, MoveDir(fs, "/src", "/dst", nil))
+
+	content, err := afero.ReadFile(fs, "/dst/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "a", string(content))
+}
+
+func TestMoveDir_CrossDevice(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "src")
+	dst := filepath.Join(tmpDir, "dst")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "bin", "app"), []byte("#!/bin/sh\n"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "data.txt"), []byte("data"), 0644))
+	require.NoError(t, os.Symlink("bin/app", filepath.Join(src, "app")))
+
+	var lastCopied, lastTotal int64
+	err := MoveDir(exdevFs{&afero.OsFs{}}, src, dst, func(copied, total int64) {
+		lastCopied, lastTotal = copied, total
+	})
+	require.NoError(t, err)
+
+	assert.NoDirExists(t, src)
+	info, err := os.Stat(filepath.Join(dst, "bin", "app"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	link, err := os.Readlink(filepath.Join(dst, "app"))
+	require.NoError(t, err)
+	assert.Equal(t, "bin/app", link)
+
+	assert.Equal(t, lastTotal, lastCopied)
+	assert.Equal(t, int64(len("#!/bin/sh\n")+len("data")), lastTotal)
+}
+
+func TestMoveDir_OtherRenameError(t *testing.T) {
+	t.Parallel()
+
+	err := MoveDir(afero.NewOsFs(), filepath.Join(t.TempDir(), "missing"), filepath.Join(t.TempDir(), "dst"), nil)
+	assert.Error(t, err)
+}
+
+func TestCopyTree(t *testing.T) {
+	src := t.TempDir()
+	dst := filepath.Join(t.TempDir(), "copied")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "subdir"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "file1.txt"), []byte("content1"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "subdir", "file2.txt"), []byte("content2"), 0644))
+	require.NoError(t, os.Symlink("file1.txt", filepath.Join(src, "link.txt")))
+
+	require.NoError(t, CopyTree(afero.NewOsFs(), src, dst, nil))
+
+	content, err := os.ReadFile(filepath.Join(dst, "subdir", "file2.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "content2", string(content))
+	link, err := os.Readlink(filepath.Join(dst, "link.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "file1.txt", link, "symlinks are copied as links")
+
+	assert.Error(t, CopyTree(afero.NewOsFs(), filepath.Join(src, "missing"), dst, nil))
+}
@@ -46,6 +46,25 @@ func CleanAppName(baseName string) string {
 	return strings.Join(tokens, "-")
 }
 
+// VersionFromName returns the version in a package file name
+// (app-1.2.3-x86_64.tar.gz -> 1.2.3), or "" when it has none
+func VersionFromName(fileName string) string {
+	for _, token := range strings.Split(fileName, "-") {
+		token = strings.TrimPrefix(strings.ToLower(token), "v")
+		end := strings.IndexFunc(token, func(r rune) bool { return r != '.' && !unicode.IsDigit(r) })
+		if end >= 0 && !strings.HasSuffix(token[:end], ".") {
+			continue
+		}
+		if end >= 0 {
+			token = token[:end]
+		}
+		if version := strings.Trim(token, "."); looksNumeric(version) {
+			return version
+		}
+	}
+	return ""
+}
+
 // GenerateNameVariants produces different normalized variants for matching executable names
 func GenerateNameVariants(baseName string) []string {
 	normalized := strings.Trim(strings.ToLower(baseName), "-_.")
@@ -31,6 +31,30 @@ func TestCleanAppName(t *testing.T) {
 	}
 }
 
+func TestVersionFromName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"app-1.2.3-x86_64.tar.gz", "1.2.3"},
+		{"Vendor-App-2.1.0", "2.1.0"},
+		{"my-app-v1.0.0.zip", "1.0.0"},
+		{"tool-2024.tar.xz", "2024"},
+		{"app-x86_64.tar.gz", ""},
+		{"app-64bit.zip", ""},
+		{"visual-studio-code", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := VersionFromName(tt.input)
+			if got != tt.expected {
+				t.Errorf("VersionFromName(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestFormatDisplayName(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -0,0 +1,32 @@
+package helpers
+
+import "io"
+
+// ProgressFunc reports bytes processed so far out of total
+type ProgressFunc func(done, total int64)
+
+// progressReader counts the bytes read through it
+type progressReader struct {
+	r          io.Reader
+	done       int64
+	total      int64
+	onProgress ProgressFunc
+}
+
+// NewProgressReader returns a reader calling onProgress with the bytes read
+// from r so far out of total. r is returned as is when onProgress is nil.
+func NewProgressReader(r io.Reader, total int64, onProgress ProgressFunc) io.Reader {
+	if onProgress == nil {
+		return r
+	}
+	return &progressReader{r: r, total: total, onProgress: onProgress}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.done += int64(n)
+		p.onProgress(p.done, p.total)
+	}
+	return n, err
+}
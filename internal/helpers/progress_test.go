@@ -0,0 +1,27 @@
+package helpers
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProgressReader(t *testing.T) {
+	t.Parallel()
+
+	src := strings.NewReader("hello world")
+	assert.Same(t, src, NewProgressReader(src, 11, nil))
+
+	var done, total int64
+	r := NewProgressReader(strings.NewReader("hello world"), 11, func(d, t int64) {
+		done, total = d, t
+	})
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(data))
+	assert.Equal(t, int64(11), done)
+	assert.Equal(t, int64(11), total)
+}
@@ -0,0 +1,110 @@
+package helpers
+
+import (
+	"context"
+	"regexp"
+	"time"
+)
+
+// transientPattern matches the network failures worth retrying; anything
+// else (a bad package, a missing file) fails the same way every time
+var transientPattern = regexp.MustCompile(`(?i)could not resolve host|temporary failure in name resolution|connection (?:timed out|reset|refused)|network is unreachable|operation timed out|failed to download|curl: \(\d+\)|tls handshake timeout|unexpected eof`)
+
+// IsTransientError reports whether err looks like a network hiccup that may
+// succeed on a later attempt
+func IsTransientError(err error) bool {
+	return err != nil && transientPattern.MatchString(err.Error())
+}
+
+// RetryPolicy retries idempotent operations that fail transiently, backing
+// off exponentially between attempts
+type RetryPolicy struct {
+	Attempts  int              // Total tries including the first; <= 1 disables retries
+	Delay     time.Duration    // Backoff before the second try, doubled after each retry
+	MaxDelay  time.Duration    // Caps the backoff (0 = uncapped)
+	Retryable func(error) bool // Errors worth retrying (nil = IsTransientError)
+}
+
+// RetryNotify is called before each retry with the attempt about to start
+// (1-based), the total allowed and the error of the previous attempt
+type RetryNotify func(attempt, total int, err error)
+
+// Do runs fn until it succeeds, fails with an error the policy does not
+// retry, ctx ends or the attempts run out. notify may be nil.
+func (p RetryPolicy) Do(ctx context.Context, notify RetryNotify, fn func(ctx context.Context) error) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsTransientError
+	}
+	total := max(p.Attempts, 1)
+
+	delay := p.Delay
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= total || !retryable(err) {
+			return err
+		}
+
+		if notify != nil {
+			notify(attempt+1, total, err)
+		}
+		if sleepErr := sleepContext(ctx, delay); sleepErr != nil {
+			return err
+		}
+		delay *= 2
+		if p.MaxDelay > 0 && delay > p.MaxDelay {
+			delay = p.MaxDelay
+		}
+	}
+}
+
+// sleepContext waits for d or until ctx ends
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// RetryingRunner is a CommandRunner that retries RunCommand and
+// RunCommandInDir under a RetryPolicy. Wrap a runner with it only for
+// idempotent commands: streaming and prepared commands are not retried,
+// since their output may already have been consumed.
+type RetryingRunner struct {
+	CommandRunner
+	Policy RetryPolicy
+	Notify RetryNotify
+}
+
+// NewRetryingRunner wraps runner with policy
+func NewRetryingRunner(runner CommandRunner, policy RetryPolicy, notify RetryNotify) *RetryingRunner {
+	return &RetryingRunner{CommandRunner: runner, Policy: policy, Notify: notify}
+}
+
+// RunCommand runs the command, retrying transient failures
+func (r *RetryingRunner) RunCommand(ctx context.Context, name string, args ...string) (string, error) {
+	var out string
+	err := r.Policy.Do(ctx, r.Notify, func(ctx context.Context) error {
+		var runErr error
+		out, runErr = r.CommandRunner.RunCommand(ctx, name, args...)
+		return runErr
+	})
+	return out, err
+}
+
+// RunCommandInDir runs the command in dir, retrying transient failures
+func (r *RetryingRunner) RunCommandInDir(ctx context.Context, dir, name string, args ...string) (string, error) {
+	var out string
+	err := r.Policy.Do(ctx, r.Notify, func(ctx context.Context) error {
+		var runErr error
+		out, runErr = r.CommandRunner.RunCommandInDir(ctx, dir, name, args...)
+		return runErr
+	})
+	return out, err
+}
+
+var _ CommandRunner = (*RetryingRunner)(nil)
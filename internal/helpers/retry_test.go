@@ -0,0 +1,101 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTransientError(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsTransientError(errors.New("curl: (6) Could not resolve host: ftp.debian.org")))
+	assert.True(t, IsTransientError(errors.New("error: Connection timed out")))
+	assert.False(t, IsTransientError(errors.New("not a debian package")))
+	assert.False(t, IsTransientError(nil))
+}
+
+func TestRetryPolicy_Do(t *testing.T) {
+	t.Parallel()
+
+	transient := errors.New("could not resolve host")
+	policy := RetryPolicy{Attempts: 3, Delay: time.Millisecond}
+
+	t.Run("retries transient failures until success", func(t *testing.T) {
+		t.Parallel()
+		calls := 0
+		var notified []int
+		err := policy.Do(context.Background(), func(attempt, total int, _ error) {
+			notified = append(notified, attempt)
+			assert.Equal(t, 3, total)
+		}, func(context.Context) error {
+			calls++
+			if calls < 3 {
+				return transient
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, []int{2, 3}, notified)
+	})
+
+	t.Run("gives up after the last attempt", func(t *testing.T) {
+		t.Parallel()
+		calls := 0
+		err := policy.Do(context.Background(), nil, func(context.Context) error {
+			calls++
+			return transient
+		})
+		assert.ErrorIs(t, err, transient)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("does not retry permanent failures", func(t *testing.T) {
+		t.Parallel()
+		calls := 0
+		err := policy.Do(context.Background(), nil, func(context.Context) error {
+			calls++
+			return errors.New("invalid package")
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("stops when the context ends", func(t *testing.T) {
+		t.Parallel()
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		err := RetryPolicy{Attempts: 5, Delay: time.Hour}.Do(ctx, func(int, int, error) { cancel() }, func(context.Context) error {
+			calls++
+			return transient
+		})
+		assert.ErrorIs(t, err, transient)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestRetryingRunner(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	mock := &MockCommandRunner{
+		RunCommandFunc: func(_ context.Context, _ string, _ ...string) (string, error) {
+			calls++
+			if calls == 1 {
+				return "", errors.New("connection reset by peer")
+			}
+			return "done", nil
+		},
+	}
+	runner := NewRetryingRunner(mock, RetryPolicy{Attempts: 2, Delay: time.Millisecond}, nil)
+
+	out, err := runner.RunCommand(context.Background(), "flatpak", "install")
+	require.NoError(t, err)
+	assert.Equal(t, "done", out)
+	assert.Equal(t, 2, calls)
+}
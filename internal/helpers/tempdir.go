@@ -0,0 +1,187 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// TempRoot returns the per-user directory holding upkg's temp directories.
+// Keeping them under one directory owned by the user lets clean-temp remove
+// leftovers without touching anything else in the system temp dir.
+func TempRoot() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("upkg-%d", os.Getuid()))
+}
+
+// LegacyTempPatterns match temp directories created by upkg versions that
+// used the system temp dir directly
+var LegacyTempPatterns = []string{
+	"upkg-appimage-*",
+	"upkg-asar-*", // Also matches upkg-asar-icons-*
+	"upkg-deb-*",
+	"upkg-fix-deps-*",
+	"upkg-rpm-*",
+	"upkg-upgrade-*",
+}
+
+// TempDir describes a temp directory created by CreateTempDir
+type TempDir struct {
+	Path    string
+	Label   string // Install ID, or the package name before the ID is assigned
+	Kind    string // What the directory is used for, e.g. "extract"
+	PID     int    // Process that created it; 0 for legacy directories
+	ModTime time.Time
+}
+
+// CreateTempDir creates a unique scratch directory for one step of an
+// install, named <label>.<kind>.<pid>.<random> under TempRoot so leftovers can
+// be traced to their install. label is the install ID, or the package name
+// when the ID is not assigned yet (see RenameTempDir). Concurrent installs,
+// even of the same package, get distinct directories.
+func CreateTempDir(fs afero.Fs, label, kind string) (string, error) {
+	root, err := ensureTempRoot(fs)
+	if err != nil {
+		return "", err
+	}
+	if label = NormalizeFilename(label); label == "" {
+		label = "unnamed"
+	}
+	dir, err := afero.TempDir(fs, root, fmt.Sprintf("%s.%s.%d.", label, kind, os.Getpid()))
+	if err != nil {
+		return "", fmt.Errorf("create temp directory: %w", err)
+	}
+	return dir, nil
+}
+
+// RenameTempDir relabels a directory from CreateTempDir once the install ID
+// is known and returns its new path
+func RenameTempDir(fs afero.Fs, dir, label string) (string, error) {
+	label = NormalizeFilename(label)
+	info, ok := ParseTempDir(filepath.Base(dir))
+	if !ok || label == "" {
+		return "", fmt.Errorf("not an upkg temp directory: %s", dir)
+	}
+	if info.Label == label {
+		return dir, nil
+	}
+	suffix := strings.TrimPrefix(filepath.Base(dir), info.Label)
+	renamed := filepath.Join(filepath.Dir(dir), label+suffix)
+	if err := fs.Rename(dir, renamed); err != nil {
+		return "", fmt.Errorf("rename temp directory: %w", err)
+	}
+	return renamed, nil
+}
+
+// ParseTempDir splits a directory name created by CreateTempDir. The label
+// may itself contain dots, so the name is split from the right.
+func ParseTempDir(name string) (TempDir, bool) {
+	parts := strings.Split(name, ".")
+	if len(parts) < 4 {
+		return TempDir{}, false
+	}
+	n := len(parts)
+	pid, err := strconv.Atoi(parts[n-2])
+	if err != nil || pid <= 0 {
+		return TempDir{}, false
+	}
+	if _, err := strconv.ParseUint(parts[n-1], 10, 64); err != nil {
+		return TempDir{}, false
+	}
+	label := strings.Join(parts[:n-3], ".")
+	if label == "" || parts[n-3] == "" {
+		return TempDir{}, false
+	}
+	return TempDir{Label: label, Kind: parts[n-3], PID: pid}, true
+}
+
+// ListTempDirs returns the temp directories upkg left in TempRoot, plus
+// legacy ones in the system temp dir. Entries owned by other users are
+// skipped.
+func ListTempDirs(fs afero.Fs) ([]TempDir, error) {
+	var dirs []TempDir
+
+	root := TempRoot()
+	entries, err := afero.ReadDir(fs, root)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("read %s: %w", root, err)
+	}
+	for _, entry := range entries {
+		info, ok := ParseTempDir(entry.Name())
+		if !ok || !entry.IsDir() || !ownedByUser(entry) {
+			continue
+		}
+		info.Path = filepath.Join(root, entry.Name())
+		info.ModTime = entry.ModTime()
+		dirs = append(dirs, info)
+	}
+
+	for _, pattern := range LegacyTempPatterns {
+		matches, err := afero.Glob(fs, filepath.Join(os.TempDir(), pattern))
+		if err != nil {
+			return nil, fmt.Errorf("match %s: %w", pattern, err)
+		}
+		for _, path := range matches {
+			entry, err := fs.Stat(path)
+			if err != nil || !entry.IsDir() || !ownedByUser(entry) {
+				continue
+			}
+			kind := strings.TrimSuffix(strings.TrimPrefix(pattern, "upkg-"), "-*")
+			dirs = append(dirs, TempDir{Path: path, Kind: kind, ModTime: entry.ModTime()})
+		}
+	}
+	return dirs, nil
+}
+
+// InUse reports whether the process that created d may still be using it.
+// Legacy directories carry no PID and count as in use while younger than
+// minAge.
+func (d TempDir) InUse(now time.Time, minAge time.Duration) bool {
+	if d.PID == 0 {
+		return now.Sub(d.ModTime) < minAge
+	}
+	return processAlive(d.PID)
+}
+
+// ensureTempRoot creates TempRoot and checks that it is a real directory
+// owned by the user, so a link planted in a shared temp dir is not followed
+func ensureTempRoot(fs afero.Fs) (string, error) {
+	root := TempRoot()
+	if err := fs.MkdirAll(root, 0700); err != nil {
+		return "", fmt.Errorf("create temp root: %w", err)
+	}
+
+	var info os.FileInfo
+	var err error
+	if lstater, ok := fs.(afero.Lstater); ok {
+		info, _, err = lstater.LstatIfPossible(root)
+	} else {
+		info, err = fs.Stat(root)
+	}
+	if err != nil {
+		return "", fmt.Errorf("stat temp root: %w", err)
+	}
+	if !info.IsDir() || !ownedByUser(info) {
+		return "", fmt.Errorf("temp root %s is not a directory owned by the current user", root)
+	}
+	return root, nil
+}
+
+// ownedByUser reports whether info belongs to the current user; filesystems
+// without ownership data (in-memory ones) count as owned
+func ownedByUser(info os.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return !ok || int(stat.Uid) == os.Getuid()
+}
+
+// processAlive reports whether a process with pid exists
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
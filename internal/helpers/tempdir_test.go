@@ -0,0 +1,112 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTempDir(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want TempDir
+		ok   bool
+	}{
+		{"code-1712345678.rpm.4242.987654", TempDir{Label: "code-1712345678", Kind: "rpm", PID: 4242}, true},
+		{"org.app.desktop-1.appimage.7.1", TempDir{Label: "org.app.desktop-1", Kind: "appimage", PID: 7}, true},
+		{"code.rpm.4242", TempDir{}, false},
+		{"code.rpm.pid.1", TempDir{}, false},
+		{".rpm.1.1", TempDir{}, false},
+		{"upkg-deb-123", TempDir{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseTempDir(tt.name)
+		assert.Equal(t, tt.ok, ok, tt.name)
+		assert.Equal(t, tt.want, got, tt.name)
+	}
+}
+
+func TestCreateTempDir(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	fs := afero.NewOsFs()
+
+	first, err := CreateTempDir(fs, "Code Editor", "extract")
+	require.NoError(t, err)
+	second, err := CreateTempDir(fs, "Code Editor", "extract")
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second, "concurrent installs get distinct directories")
+	assert.Equal(t, TempRoot(), filepath.Dir(first))
+
+	info, ok := ParseTempDir(filepath.Base(first))
+	require.True(t, ok)
+	assert.Equal(t, "code-editor", info.Label)
+	assert.Equal(t, "extract", info.Kind)
+	assert.Equal(t, os.Getpid(), info.PID)
+
+	rootInfo, err := os.Stat(TempRoot())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), rootInfo.Mode().Perm())
+
+	renamed, err := RenameTempDir(fs, first, "code-editor-1712345678")
+	require.NoError(t, err)
+	assert.DirExists(t, renamed)
+	assert.NoDirExists(t, first)
+	info, ok = ParseTempDir(filepath.Base(renamed))
+	require.True(t, ok)
+	assert.Equal(t, "code-editor-1712345678", info.Label)
+	assert.Equal(t, "extract", info.Kind)
+
+	_, err = RenameTempDir(fs, t.TempDir(), "id")
+	assert.Error(t, err)
+}
+
+func TestCreateTempDir_RejectsLinkedRoot(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+
+	// A link planted where the root should be is not followed
+	target := t.TempDir()
+	require.NoError(t, os.Symlink(target, TempRoot()))
+
+	_, err := CreateTempDir(afero.NewOsFs(), "app", "extract")
+	assert.ErrorContains(t, err, "not a directory owned by the current user")
+}
+
+func TestListTempDirs(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	fs := afero.NewOsFs()
+
+	current, err := CreateTempDir(fs, "app-1", "rpm")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(TempRoot(), "not-upkg"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tmp, "upkg-deb-123"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tmp, "upkg-downloads"), 0755))
+
+	dirs, err := ListTempDirs(fs)
+	require.NoError(t, err)
+	require.Len(t, dirs, 2)
+
+	byPath := map[string]TempDir{}
+	for _, dir := range dirs {
+		byPath[dir.Path] = dir
+	}
+	require.Contains(t, byPath, current)
+	require.Contains(t, byPath, filepath.Join(tmp, "upkg-deb-123"))
+
+	now := time.Now()
+	assert.True(t, byPath[current].InUse(now, time.Hour), "created by this process")
+
+	legacy := byPath[filepath.Join(tmp, "upkg-deb-123")]
+	assert.Equal(t, "deb", legacy.Kind)
+	assert.True(t, legacy.InUse(now, time.Hour), "too young to tell")
+	assert.False(t, legacy.InUse(now.Add(2*time.Hour), time.Hour))
+}
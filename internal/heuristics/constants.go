@@ -3,13 +3,14 @@ package heuristics
 // Scoring constants for executable heuristics
 const (
 	// Positive scores
-	ScoreExactMatch   = 120 // Filename exactly matches base name variant
-	ScorePartialMatch = 60  // Filename contains base name variant
-	ScoreBonusPattern = 80  // Matches known main executable patterns
-	ScoreDepthBase    = 10  // Base multiplier for depth scoring
-	ScoreLargeFile    = 30  // File size > 10MB
-	ScoreMediumFile   = 10  // File size 1-10MB
-	ScoreBinDirectory = 20  // Executable in /bin/ directory
+	ScoreExactMatch   = 120  // Filename exactly matches base name variant
+	ScorePartialMatch = 60   // Filename contains base name variant
+	ScoreBonusPattern = 80   // Matches known main executable patterns
+	ScoreDepthBase    = 10   // Base multiplier for depth scoring
+	ScoreLargeFile    = 30   // File size > 10MB
+	ScoreMediumFile   = 10   // File size 1-10MB
+	ScoreBinDirectory = 20   // Executable in /bin/ directory
+	ScorePreferRule   = 1000 // Matches an executables.prefer pattern from the config
 
 	// Negative scores (penalties)
 	PenaltyHelper        = -200 // Helper/utility executables
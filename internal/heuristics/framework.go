@@ -0,0 +1,161 @@
+package heuristics
+
+import (
+	"debug/elf"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// Framework identifies the UI toolkit an application is built with
+type Framework string
+
+const (
+	FrameworkUnknown  Framework = ""
+	FrameworkElectron Framework = "electron"
+	FrameworkTauri    Framework = "tauri" // Tauri and other WebKitGTK webview apps
+	FrameworkFlutter  Framework = "flutter"
+	FrameworkQt       Framework = "qt"
+	FrameworkGTK      Framework = "gtk"
+)
+
+// maxFrameworkScanEntries bounds the payload walk in DetectFramework
+const maxFrameworkScanEntries = 20000
+
+// maxLinkageCandidates bounds the payload executables whose libraries are read
+const maxLinkageCandidates = 8
+
+// DetectFramework inspects an extracted payload (root, may be empty) and its
+// main executable (execPath, may be empty) to identify the UI toolkit.
+// Electron wins over bundled Qt/GTK libraries since Chromium ships both;
+// Flutter and Tauri win over GTK, which their Linux embedders are built on.
+// Tauri apps bundle WebKitGTK only in AppImages, so the executables of the
+// payload are checked for the webview linkage as well.
+func DetectFramework(fsys afero.Fs, root, execPath string) Framework {
+	var hasElectron, hasFlutter, hasTauri, hasQt, hasGTK bool
+	var executables []string
+
+	if root != "" {
+		entries := 0
+		// Walk errors only mean the scan stopped early; detection is best-effort
+		_ = afero.Walk(fsys, root, func(path string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			entries++
+			if entries > maxFrameworkScanEntries {
+				return filepath.SkipAll
+			}
+			if info.IsDir() {
+				return nil
+			}
+
+			switch frameworkFromFile(filepath.Base(path)) {
+			case FrameworkElectron:
+				hasElectron = true
+				return filepath.SkipAll
+			case FrameworkFlutter:
+				hasFlutter = true
+			case FrameworkTauri:
+				hasTauri = true
+			case FrameworkQt:
+				hasQt = true
+			case FrameworkGTK:
+				hasGTK = true
+			}
+			if len(executables) < maxLinkageCandidates && info.Mode()&0111 != 0 && !strings.Contains(info.Name(), ".so") {
+				executables = append(executables, path)
+			}
+			return nil
+		})
+	}
+	if hasElectron {
+		return FrameworkElectron
+	}
+
+	if execPath != "" {
+		for _, lib := range importedLibraries(fsys, execPath) {
+			switch frameworkFromFile(lib) {
+			case FrameworkFlutter:
+				hasFlutter = true
+			case FrameworkTauri:
+				hasTauri = true
+			case FrameworkQt:
+				hasQt = true
+			case FrameworkGTK:
+				hasGTK = true
+			}
+		}
+	}
+
+	// Other payload executables only decide between webview runtimes; their
+	// Qt or GTK linkage may belong to helpers rather than the app
+	if !hasFlutter && !hasTauri {
+		for _, path := range executables {
+			for _, lib := range importedLibraries(fsys, path) {
+				switch frameworkFromFile(lib) {
+				case FrameworkFlutter:
+					hasFlutter = true
+				case FrameworkTauri:
+					hasTauri = true
+				}
+			}
+		}
+	}
+
+	switch {
+	case hasFlutter:
+		return FrameworkFlutter
+	case hasTauri:
+		return FrameworkTauri
+	case hasQt:
+		return FrameworkQt
+	case hasGTK:
+		return FrameworkGTK
+	default:
+		return FrameworkUnknown
+	}
+}
+
+// frameworkFromFile maps a payload file or library name to the toolkit it implies
+func frameworkFromFile(name string) Framework {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".asar"), lower == "chrome-sandbox":
+		return FrameworkElectron
+	case strings.HasPrefix(lower, "libflutter_linux_gtk.so"):
+		return FrameworkFlutter
+	case strings.HasPrefix(lower, "libwebkit2gtk-4.0.so"), strings.HasPrefix(lower, "libwebkit2gtk-4.1.so"),
+		strings.HasPrefix(lower, "libwebkitgtk-6.0.so"):
+		return FrameworkTauri
+	case strings.HasPrefix(lower, "libqt5core.so"), strings.HasPrefix(lower, "libqt6core.so"),
+		strings.HasPrefix(lower, "libqt5gui.so"), strings.HasPrefix(lower, "libqt6gui.so"):
+		return FrameworkQt
+	case strings.HasPrefix(lower, "libgtk-3.so"), strings.HasPrefix(lower, "libgtk-4.so"):
+		return FrameworkGTK
+	}
+	return FrameworkUnknown
+}
+
+// importedLibraries returns the DT_NEEDED entries of an ELF executable
+func importedLibraries(fsys afero.Fs, path string) []string {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = file.Close() }()
+
+	elfFile, err := elf.NewFile(file)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = elfFile.Close() }()
+
+	libs, err := elfFile.ImportedLibraries()
+	if err != nil {
+		return nil
+	}
+	return libs
+}
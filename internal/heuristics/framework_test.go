@@ -0,0 +1,140 @@
+package heuristics
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectFramework(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		files []string
+		want  Framework
+	}{
+		{"electron asar", []string{"/app/resources/app.asar", "/app/libQt5Core.so.5"}, FrameworkElectron},
+		{"electron sandbox helper", []string{"/app/chrome-sandbox"}, FrameworkElectron},
+		{"bundled qt", []string{"/app/usr/lib/libQt6Core.so.6", "/app/usr/lib/libgtk-3.so.0"}, FrameworkQt},
+		{"bundled gtk", []string{"/app/usr/lib/libgtk-4.so.1"}, FrameworkGTK},
+		{"flutter engine", []string{"/app/lib/libflutter_linux_gtk.so", "/app/usr/lib/libgtk-3.so.0"}, FrameworkFlutter},
+		{"bundled webkitgtk", []string{"/app/usr/lib/libwebkit2gtk-4.1.so.0", "/app/usr/lib/libgtk-3.so.0"}, FrameworkTauri},
+		{"unknown", []string{"/app/bin/tool"}, FrameworkUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := afero.NewMemMapFs()
+			for _, f := range tt.files {
+				require.NoError(t, afero.WriteFile(fs, f, []byte("x"), 0644))
+			}
+			assert.Equal(t, tt.want, DetectFramework(fs, "/app", "/app/bin/tool"))
+		})
+	}
+}
+
+func TestDetectFramework_NonELFExecutable(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/bin/script", []byte("#!/bin/sh\n"), 0755))
+	assert.Equal(t, FrameworkUnknown, DetectFramework(fs, "", "/bin/script"))
+	assert.Equal(t, FrameworkUnknown, DetectFramework(fs, "", "/missing"))
+}
+
+func TestDetectFramework_Linkage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		files map[string][]string // executable path -> DT_NEEDED entries
+		exec  string
+		want  Framework
+	}{
+		{"tauri main executable", map[string][]string{
+			"/app/usr/bin/notes": {"libwebkit2gtk-4.1.so.0", "libgtk-3.so.0", "libc.so.6"},
+		}, "/app/usr/bin/notes", FrameworkTauri},
+		{"tauri payload executable", map[string][]string{
+			"/app/usr/bin/notes": {"libwebkit2gtk-4.0.so.37", "libgtk-3.so.0"},
+		}, "", FrameworkTauri},
+		{"flutter main executable", map[string][]string{
+			"/app/bundle/todo": {"libflutter_linux_gtk.so", "libgtk-3.so.0"},
+		}, "/app/bundle/todo", FrameworkFlutter},
+		{"qt main executable", map[string][]string{
+			"/app/bin/viewer": {"libQt6Gui.so.6", "libQt6Core.so.6"},
+		}, "/app/bin/viewer", FrameworkQt},
+		{"gtk helper does not decide", map[string][]string{
+			"/app/bin/helper": {"libgtk-3.so.0"},
+		}, "", FrameworkUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := afero.NewMemMapFs()
+			for path, libs := range tt.files {
+				require.NoError(t, afero.WriteFile(fs, path, elfNeeding(t, libs...), 0755))
+			}
+			assert.Equal(t, tt.want, DetectFramework(fs, "/app", tt.exec))
+		})
+	}
+}
+
+// elfNeeding builds a minimal ELF64 shared object whose dynamic section lists
+// libs as DT_NEEDED entries
+func elfNeeding(t *testing.T, libs ...string) []byte {
+	t.Helper()
+
+	dynstr := []byte{0}
+	var dynamic []elf.Dyn64
+	for _, lib := range libs {
+		dynamic = append(dynamic, elf.Dyn64{Tag: int64(elf.DT_NEEDED), Val: uint64(len(dynstr))})
+		dynstr = append(append(dynstr, lib...), 0)
+	}
+	dynamic = append(dynamic, elf.Dyn64{Tag: int64(elf.DT_NULL)})
+	shstrtab := []byte("\x00.dynstr\x00.dynamic\x00.shstrtab\x00")
+
+	const headerSize = 64
+	dynstrOff := uint64(headerSize)
+	dynamicOff := (dynstrOff + uint64(len(dynstr)) + 7) &^ 7
+	dynamicSize := uint64(len(dynamic)) * 16
+	shstrtabOff := dynamicOff + dynamicSize
+	shOff := (shstrtabOff + uint64(len(shstrtab)) + 7) &^ 7
+
+	header := elf.Header64{
+		Ident:     [elf.EI_NIDENT]byte{0x7f, 'E', 'L', 'F', byte(elf.ELFCLASS64), byte(elf.ELFDATA2LSB), byte(elf.EV_CURRENT)},
+		Type:      uint16(elf.ET_DYN),
+		Machine:   uint16(elf.EM_X86_64),
+		Version:   uint32(elf.EV_CURRENT),
+		Shoff:     shOff,
+		Ehsize:    headerSize,
+		Shentsize: 64,
+		Shnum:     4,
+		Shstrndx:  3,
+	}
+	sections := []elf.Section64{
+		{},
+		{Name: 1, Type: uint32(elf.SHT_STRTAB), Off: dynstrOff, Size: uint64(len(dynstr)), Addralign: 1},
+		{Name: 9, Type: uint32(elf.SHT_DYNAMIC), Off: dynamicOff, Size: dynamicSize, Link: 1, Addralign: 8, Entsize: 16},
+		{Name: 18, Type: uint32(elf.SHT_STRTAB), Off: shstrtabOff, Size: uint64(len(shstrtab)), Addralign: 1},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, binary.Write(&buf, binary.LittleEndian, header))
+	buf.Write(dynstr)
+	buf.Write(make([]byte, dynamicOff-uint64(buf.Len())))
+	require.NoError(t, binary.Write(&buf, binary.LittleEndian, dynamic))
+	buf.Write(shstrtab)
+	buf.Write(make([]byte, shOff-uint64(buf.Len())))
+	require.NoError(t, binary.Write(&buf, binary.LittleEndian, sections))
+	return buf.Bytes()
+}
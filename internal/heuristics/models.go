@@ -1,9 +1,63 @@
 package heuristics
 
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/quantmind-br/upkg/internal/core"
+)
+
 // ExecutableScore represents a candidate executable with its calculated score
 type ExecutableScore struct {
-	Path  string
-	Score int
+	Path    string
+	Score   int
+	Reasons []ScoreReason // What the score is made of, in the order applied
+	Ignored bool          // Matched an ignore rule
+}
+
+// ScoreReason is one heuristic or rule that applied to a candidate
+type ScoreReason struct {
+	Rule   string
+	Points int
+}
+
+// ScoringRules are user-defined glob patterns that steer the choice of the
+// main executable. A pattern matches the file name or the path inside the
+// package, e.g. "*-cli" or "bin/app*".
+type ScoringRules struct {
+	Prefer []string // Matching candidates get ScorePreferRule
+	Ignore []string // Matching candidates are only chosen when all others are ignored too
+}
+
+// match returns the first of patterns matching relPath or its file name
+func (r ScoringRules) match(patterns []string, relPath string) (string, bool) {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, relPath); ok {
+			return pattern, true
+		}
+		if ok, _ := filepath.Match(pattern, filepath.Base(relPath)); ok {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// Candidates converts a ranking to the candidates reported in an install
+// result, marking chosen
+func Candidates(ranked []ExecutableScore, chosen string) []core.ExecutableCandidate {
+	candidates := make([]core.ExecutableCandidate, 0, len(ranked))
+	for _, score := range ranked {
+		candidate := core.ExecutableCandidate{Path: score.Path, Score: score.Score, Ignored: score.Ignored, Chosen: score.Path == chosen}
+		for _, reason := range score.Reasons {
+			if reason.Points == 0 {
+				candidate.Reasons = append(candidate.Reasons, reason.Rule)
+				continue
+			}
+			candidate.Reasons = append(candidate.Reasons, fmt.Sprintf("%+d %s", reason.Points, reason.Rule))
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
 }
 
 // Scorer defines the interface for scoring executables
@@ -13,4 +67,7 @@ type Scorer interface {
 
 	// ChooseBest selects the best executable from a list of candidates
 	ChooseBest(candidates []string, baseName, installDir string) string
+
+	// Rank scores every candidate and sorts them best first
+	Rank(candidates []string, baseName, installDir string) []ExecutableScore
 }
@@ -1,6 +1,7 @@
 package heuristics
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -15,12 +16,15 @@ import (
 // DefaultScorer implements the Scorer interface with standard heuristics
 type DefaultScorer struct {
 	Logger *zerolog.Logger
+	Rules  ScoringRules
 }
 
-// NewScorer creates a new DefaultScorer
-func NewScorer(logger *zerolog.Logger) *DefaultScorer {
+// NewScorer creates a new DefaultScorer that applies rules on top of the
+// built-in heuristics
+func NewScorer(logger *zerolog.Logger, rules ScoringRules) *DefaultScorer {
 	return &DefaultScorer{
 		Logger: logger,
+		Rules:  rules,
 	}
 }
 
@@ -33,33 +37,56 @@ func (s *DefaultScorer) ChooseBest(executables []string, baseName, installDir st
 		return executables[0]
 	}
 
-	candidates := make([]ExecutableScore, 0, len(executables))
+	return s.Rank(executables, baseName, installDir)[0].Path
+}
 
+// Rank scores every candidate and sorts them best first. Candidates matching
+// an ignore rule come last, so they are only chosen when nothing else is left.
+func (s *DefaultScorer) Rank(executables []string, baseName, installDir string) []ExecutableScore {
+	candidates := make([]ExecutableScore, 0, len(executables))
 	for _, exe := range executables {
-		score := s.ScoreExecutable(exe, baseName, installDir)
-		candidates = append(candidates, ExecutableScore{Path: exe, Score: score})
+		candidate := s.Explain(exe, baseName, installDir)
+		candidates = append(candidates, candidate)
 
 		if s.Logger != nil {
 			s.Logger.Debug().
 				Str("executable", exe).
-				Int("score", score).
+				Int("score", candidate.Score).
+				Bool("ignored", candidate.Ignored).
 				Msg("scored executable candidate")
 		}
 	}
 
-	// Sort by score descending (highest score first)
-	sort.Slice(candidates, func(i, j int) bool {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Ignored != candidates[j].Ignored {
+			return !candidates[i].Ignored
+		}
 		return candidates[i].Score > candidates[j].Score
 	})
-
-	return candidates[0].Path
+	if len(candidates) > 0 && candidates[0].Ignored && s.Logger != nil {
+		s.Logger.Warn().
+			Str("executable", candidates[0].Path).
+			Msg("every executable candidate matches an ignore rule; choosing the best scored one")
+	}
+	return candidates
 }
 
 // ScoreExecutable assigns a score to an executable based on various heuristics
+func (s *DefaultScorer) ScoreExecutable(execPath, baseName, installDir string) int {
+	return s.Explain(execPath, baseName, installDir).Score
+}
+
+// Explain scores an executable and lists the heuristics and rules that
+// contributed to the score
 //
 //nolint:gocyclo // scoring uses a set of heuristic rules.
-func (s *DefaultScorer) ScoreExecutable(execPath, baseName, installDir string) int {
-	score := 0
+func (s *DefaultScorer) Explain(execPath, baseName, installDir string) ExecutableScore {
+	result := ExecutableScore{Path: execPath}
+	add := func(points int, rule string) {
+		result.Score += points
+		result.Reasons = append(result.Reasons, ScoreReason{Rule: rule, Points: points})
+	}
+
 	filename := strings.ToLower(filepath.Base(execPath))
 	normalizedBase := strings.ToLower(baseName)
 	nameVariants := helpers.GenerateNameVariants(normalizedBase)
@@ -71,32 +98,30 @@ func (s *DefaultScorer) ScoreExecutable(execPath, baseName, installDir string) i
 
 	// Prefer shallow depth (executables in root or first level)
 	// Depth 1: +50, Depth 2: +40, Depth 3: +30, etc.
-	score += (DepthScoreOffset - depth) * ScoreDepthBase
+	add((DepthScoreOffset-depth)*ScoreDepthBase, fmt.Sprintf("path depth %d", depth))
 	if depth > MaxShallowDepth {
-		score += PenaltyDeepPath
+		add(PenaltyDeepPath, "deeply nested path")
 	}
 
 	// Strong match: filename exactly matches any base variant
-exactMatchLoop:
 	for _, variant := range nameVariants {
 		if variant == "" {
 			continue
 		}
 		if filename == variant || filename == variant+".exe" {
-			score += ScoreExactMatch
-			break exactMatchLoop
+			add(ScoreExactMatch, fmt.Sprintf("name matches %q", variant))
+			break
 		}
 	}
 
 	// Partial match: filename contains any of the variants
-partialMatchLoop:
 	for _, variant := range nameVariants {
 		if variant == "" || len(variant) < MinNameVariantLength {
 			continue
 		}
 		if strings.Contains(filename, variant) {
-			score += ScorePartialMatch
-			break partialMatchLoop
+			add(ScorePartialMatch, fmt.Sprintf("name contains %q", variant))
+			break
 		}
 	}
 
@@ -107,24 +132,24 @@ partialMatchLoop:
 			continue
 		}
 		if matched {
-			score += ScoreBonusPattern
+			add(ScoreBonusPattern, "common main executable name")
 		}
 	}
 
 	// Penalize known helper/utility executables
 	for _, pattern := range penaltyPatterns {
 		if strings.Contains(filename, pattern) {
-			score += PenaltyHelper
+			add(PenaltyHelper, fmt.Sprintf("helper name (%s)", pattern))
 		}
 	}
 
 	// Strongly penalize shared libraries and lib-prefixed files that slip through
 	if strings.HasPrefix(filename, "lib") {
-		score += PenaltyLibPrefix
+		add(PenaltyLibPrefix, "lib prefix")
 	}
 	if strings.HasSuffix(filename, ".so") || strings.Contains(filename, ".so.") ||
 		strings.HasSuffix(filename, ".dylib") || strings.HasSuffix(filename, ".dll") {
-		score += PenaltyLibrary
+		add(PenaltyLibrary, "shared library")
 	}
 
 	// Check file size (main executables are usually larger)
@@ -132,29 +157,38 @@ partialMatchLoop:
 		fileSize := info.Size()
 
 		if fileSize > LargeFileSizeBytes {
-			score += ScoreLargeFile
+			add(ScoreLargeFile, "larger than 10 MB")
 		} else if fileSize > MediumFileSizeBytes {
-			score += ScoreMediumFile
+			add(ScoreMediumFile, "larger than 1 MB")
 		} else if fileSize < SmallFileSizeBytes {
-			score += PenaltySmallFile
+			add(PenaltySmallFile, "smaller than 100 KB")
 
 			if fileSize < TinyFileSizeBytes {
-				score += PenaltyTinyFile
+				add(PenaltyTinyFile, "smaller than 1 KB")
 			}
 		}
 	}
 
 	// Bonus for executables in "bin" directory
 	if strings.Contains(strings.ToLower(relPath), "/bin/") {
-		score += ScoreBinDirectory
+		add(ScoreBinDirectory, "in a bin/ directory")
 	}
 
 	// Additional check: penalize if executable is a shell script with invalid references
 	if s.isInvalidWrapperScript(execPath) {
-		score += PenaltyInvalidScript
+		add(PenaltyInvalidScript, "script referencing a build directory")
+	}
+
+	// Rules from the config override the heuristics
+	if pattern, ok := s.Rules.match(s.Rules.Prefer, relPath); ok {
+		add(ScorePreferRule, fmt.Sprintf("prefer rule %q", pattern))
+	}
+	if pattern, ok := s.Rules.match(s.Rules.Ignore, relPath); ok {
+		result.Ignored = true
+		result.Reasons = append(result.Reasons, ScoreReason{Rule: fmt.Sprintf("ignore rule %q", pattern)})
 	}
 
-	return score
+	return result
 }
 
 // isInvalidWrapperScript checks if file is a wrapper script with invalid path references
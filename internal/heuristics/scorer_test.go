@@ -15,7 +15,7 @@ func TestChooseBestExecutablePrefersCoreBinary(t *testing.T) {
 	t.Parallel()
 
 	logger := zerolog.New(io.Discard)
-	scorer := NewScorer(&logger)
+	scorer := NewScorer(&logger, ScoringRules{})
 
 	installDir := filepath.Join(t.TempDir(), "mod-desktop-0.0.12-linux-x86-64")
 	appDir := filepath.Join(installDir, "mod-desktop-0.0.12-linux-x86_64", "mod-desktop")
@@ -40,7 +40,7 @@ func TestScoreExecutablePenalizesSharedLibraries(t *testing.T) {
 	t.Parallel()
 
 	logger := zerolog.New(io.Discard)
-	scorer := NewScorer(&logger)
+	scorer := NewScorer(&logger, ScoringRules{})
 
 	installDir := filepath.Join(t.TempDir(), "mod-desktop-0.0.12-linux-x86-64")
 	appDir := filepath.Join(installDir, "mod-desktop")
@@ -122,7 +122,7 @@ func TestFindExecutables(t *testing.T) {
 
 func TestScoreExecutable(t *testing.T) {
 	logger := zerolog.New(io.Discard)
-	scorer := NewScorer(&logger)
+	scorer := NewScorer(&logger, ScoringRules{})
 
 	t.Run("exact name match gets high score", func(t *testing.T) {
 		tmpDir := t.TempDir()
@@ -261,7 +261,7 @@ func TestScoreExecutable(t *testing.T) {
 
 func TestChooseBestWithMultipleCandidates(t *testing.T) {
 	logger := zerolog.New(io.Discard)
-	scorer := NewScorer(&logger)
+	scorer := NewScorer(&logger, ScoringRules{})
 
 	tmpDir := t.TempDir()
 
@@ -284,7 +284,7 @@ func TestChooseBestWithMultipleCandidates(t *testing.T) {
 
 func TestChooseBestEmpty(t *testing.T) {
 	logger := zerolog.New(io.Discard)
-	scorer := NewScorer(&logger)
+	scorer := NewScorer(&logger, ScoringRules{})
 
 	best := scorer.ChooseBest([]string{}, "myapp", "/tmp")
 	if best != "" {
@@ -294,7 +294,7 @@ func TestChooseBestEmpty(t *testing.T) {
 
 func TestChooseBestSingle(t *testing.T) {
 	logger := zerolog.New(io.Discard)
-	scorer := NewScorer(&logger)
+	scorer := NewScorer(&logger, ScoringRules{})
 
 	tmpDir := t.TempDir()
 	single := filepath.Join(tmpDir, "app")
@@ -308,7 +308,7 @@ func TestChooseBestSingle(t *testing.T) {
 
 func TestDefaultScorerStruct(t *testing.T) {
 	logger := zerolog.New(io.Discard)
-	scorer := NewScorer(&logger)
+	scorer := NewScorer(&logger, ScoringRules{})
 
 	if scorer == nil {
 		t.Fatal("scorer should not be nil")
@@ -321,7 +321,7 @@ func TestDefaultScorerStruct(t *testing.T) {
 
 func TestIsInvalidWrapperScript(t *testing.T) {
 	logger := zerolog.New(io.Discard)
-	scorer := NewScorer(&logger)
+	scorer := NewScorer(&logger, ScoringRules{})
 
 	t.Run("non-existent file", func(t *testing.T) {
 		result := scorer.isInvalidWrapperScript("/nonexistent/file")
@@ -410,3 +410,53 @@ func TestFindExecutablesErrors(t *testing.T) {
 		t.Skip("Requires specific filesystem permissions")
 	})
 }
+
+func TestExplain(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	scorer := NewScorer(&logger, ScoringRules{Prefer: []string{"tools/*-cli"}, Ignore: []string{"*-helper"}})
+
+	tmpDir := t.TempDir()
+	app := filepath.Join(tmpDir, "bin", "myapp")
+	cli := filepath.Join(tmpDir, "tools", "myapp-cli")
+	helper := filepath.Join(tmpDir, "myapp-helper")
+	writeExecutable(t, app, 2*1024*1024)
+	writeExecutable(t, cli, 500)
+	writeExecutable(t, helper, 2*1024*1024)
+
+	explained := scorer.Explain(app, "myapp", tmpDir)
+	sum := 0
+	rules := make([]string, 0, len(explained.Reasons))
+	for _, reason := range explained.Reasons {
+		sum += reason.Points
+		rules = append(rules, reason.Rule)
+	}
+	assert.Equal(t, explained.Score, sum, "reasons add up to the score")
+	assert.Equal(t, explained.Score, scorer.ScoreExecutable(app, "myapp", tmpDir))
+	assert.Contains(t, rules, "path depth 2")
+	assert.Contains(t, rules, `name matches "myapp"`)
+	assert.Contains(t, rules, "larger than 1 MB")
+
+	ranked := scorer.Rank([]string{helper, app, cli}, "myapp", tmpDir)
+	assert.Equal(t, []string{cli, app, helper}, []string{ranked[0].Path, ranked[1].Path, ranked[2].Path})
+	assert.Contains(t, ranked[0].Reasons, ScoreReason{Rule: `prefer rule "tools/*-cli"`, Points: ScorePreferRule})
+	assert.True(t, ranked[2].Ignored)
+	assert.Equal(t, cli, scorer.ChooseBest([]string{helper, app, cli}, "myapp", tmpDir))
+
+	candidates := Candidates(ranked, app)
+	assert.False(t, candidates[0].Chosen)
+	assert.True(t, candidates[1].Chosen)
+	assert.Contains(t, candidates[2].Reasons, `ignore rule "*-helper"`)
+}
+
+func TestChooseBestAllIgnored(t *testing.T) {
+	logger := zerolog.New(io.Discard)
+	scorer := NewScorer(&logger, ScoringRules{Ignore: []string{"*"}})
+
+	tmpDir := t.TempDir()
+	app := filepath.Join(tmpDir, "myapp")
+	helper := filepath.Join(tmpDir, "myapp-helper")
+	writeExecutable(t, app, 1024)
+	writeExecutable(t, helper, 1024)
+
+	assert.Equal(t, app, scorer.ChooseBest([]string{helper, app}, "myapp", tmpDir))
+}
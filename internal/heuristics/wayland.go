@@ -0,0 +1,203 @@
+package heuristics
+
+import (
+	"bytes"
+	"debug/elf"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/spf13/afero"
+)
+
+// ozoneMarker is present in Electron/Chromium builds that can run on Wayland
+// through Ozone (Electron 12 and later)
+var ozoneMarker = []byte("ozone-platform")
+
+// maxElectronCandidates bounds the executables searched for ozoneMarker
+const maxElectronCandidates = 8
+
+// waylandSignals are the libraries an app links or bundles
+type waylandSignals struct {
+	toolkit          Framework
+	waylandClient    bool     // libwayland-client
+	qtWayland        bool     // Qt Wayland platform plugin or QtWaylandClient
+	bundledQt        bool     // Qt ships with the payload, so only its plugins apply
+	gtk2             bool     // GTK 2 has no Wayland backend
+	x11              bool     // libX11 or libxcb
+	electronBinaries []string // Executables next to chrome-sandbox or resources/app.asar
+}
+
+// DetectWaylandSupport classifies how the app in an extracted payload (root,
+// may be empty) with main executable execPath (may be empty) runs on Wayland:
+//   - native: it uses Wayland on its own (GTK 3/4 and Flutter, or links
+//     libwayland-client)
+//   - hybrid: it supports Wayland but needs a hint (Electron with Ozone, Qt
+//     with the Wayland platform plugin, WebKitGTK webviews such as Tauri)
+//   - xwayland: it is X11-only (GTK 2, old Electron, Qt bundled without the
+//     Wayland plugin, plain X11 clients)
+//   - unknown: nothing conclusive was found
+func DetectWaylandSupport(fsys afero.Fs, root, execPath string) core.WaylandSupport {
+	_, wayland := DetectApp(fsys, root, execPath)
+	return wayland
+}
+
+// DetectApp returns both the UI toolkit (see DetectFramework) and the Wayland
+// support (see DetectWaylandSupport) of an app, scanning the payload once
+func DetectApp(fsys afero.Fs, root, execPath string) (Framework, core.WaylandSupport) {
+	signals := scanWaylandSignals(fsys, root, execPath)
+	return signals.toolkit, classifyWayland(fsys, execPath, signals)
+}
+
+// classifyWayland maps the signals of an app to its Wayland support
+func classifyWayland(fsys afero.Fs, execPath string, signals waylandSignals) core.WaylandSupport {
+	switch signals.toolkit {
+	case FrameworkElectron:
+		binaries := signals.electronBinaries
+		if isELF(fsys, execPath) {
+			binaries = []string{execPath}
+		}
+		if len(binaries) == 0 {
+			return core.WaylandUnknown
+		}
+		for _, binary := range binaries {
+			if fileContains(fsys, binary, ozoneMarker) {
+				return core.WaylandHybrid
+			}
+		}
+		return core.WaylandXWayland
+	case FrameworkGTK, FrameworkFlutter:
+		return core.WaylandNative
+	case FrameworkTauri:
+		// WebKitGTK renders blank windows on many Wayland drivers without a hint
+		return core.WaylandHybrid
+	case FrameworkQt:
+		if signals.qtWayland || !signals.bundledQt {
+			return core.WaylandHybrid
+		}
+		return core.WaylandXWayland
+	}
+
+	switch {
+	case signals.waylandClient:
+		return core.WaylandNative
+	case signals.gtk2, signals.x11:
+		return core.WaylandXWayland
+	}
+	return core.WaylandUnknown
+}
+
+// scanWaylandSignals collects the toolkit and display libraries of the
+// payload files and of the executable's DT_NEEDED entries
+func scanWaylandSignals(fsys afero.Fs, root, execPath string) waylandSignals {
+	signals := waylandSignals{toolkit: DetectFramework(fsys, root, execPath)}
+
+	note := func(name string) {
+		lower := strings.ToLower(name)
+		switch {
+		case strings.HasPrefix(lower, "libwayland-client.so"):
+			signals.waylandClient = true
+		case strings.HasPrefix(lower, "libqwayland"), strings.HasPrefix(lower, "libqt5waylandclient.so"),
+			strings.HasPrefix(lower, "libqt6waylandclient.so"):
+			signals.qtWayland = true
+		case strings.HasPrefix(lower, "libgtk-x11-2.0.so"):
+			signals.gtk2 = true
+		case strings.HasPrefix(lower, "libx11.so"), strings.HasPrefix(lower, "libxcb.so"):
+			signals.x11 = true
+		}
+	}
+
+	if root != "" {
+		entries := 0
+		// Walk errors only mean the scan stopped early; detection is best-effort
+		_ = afero.Walk(fsys, root, func(path string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			entries++
+			if entries > maxFrameworkScanEntries {
+				return filepath.SkipAll
+			}
+			if info.IsDir() {
+				return nil
+			}
+			note(info.Name())
+			if frameworkFromFile(info.Name()) == FrameworkQt {
+				signals.bundledQt = true
+			}
+			if signals.toolkit == FrameworkElectron && len(signals.electronBinaries) < maxElectronCandidates &&
+				isElectronBinary(fsys, path, info) {
+				signals.electronBinaries = append(signals.electronBinaries, path)
+			}
+			return nil
+		})
+	}
+
+	if execPath != "" {
+		for _, lib := range importedLibraries(fsys, execPath) {
+			note(lib)
+		}
+	}
+	return signals
+}
+
+// isElectronBinary reports whether path is an ELF executable in an Electron
+// app directory (next to chrome-sandbox or resources/app.asar)
+func isElectronBinary(fsys afero.Fs, path string, info fs.FileInfo) bool {
+	name := info.Name()
+	if info.Mode()&0111 == 0 || name == "chrome-sandbox" || strings.Contains(name, ".so") {
+		return false
+	}
+	dir := filepath.Dir(path)
+	_, sandboxErr := fsys.Stat(filepath.Join(dir, "chrome-sandbox"))
+	_, asarErr := fsys.Stat(filepath.Join(dir, "resources", "app.asar"))
+	return (sandboxErr == nil || asarErr == nil) && isELF(fsys, path)
+}
+
+// isELF reports whether path is an ELF file
+func isELF(fsys afero.Fs, path string) bool {
+	if path == "" {
+		return false
+	}
+	file, err := fsys.Open(path)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = file.Close() }()
+
+	magic := make([]byte, len(elf.ELFMAG))
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return false
+	}
+	return string(magic) == elf.ELFMAG
+}
+
+// fileContains streams path looking for marker
+func fileContains(fsys afero.Fs, path string, marker []byte) bool {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return false
+	}
+	defer func() { _ = file.Close() }()
+
+	// Each read keeps the previous tail so markers split across reads are found
+	overlap := len(marker) - 1
+	buf := make([]byte, overlap+1<<20)
+	kept := 0
+	for {
+		n, err := file.Read(buf[kept:])
+		if bytes.Contains(buf[:kept+n], marker) {
+			return true
+		}
+		if err != nil {
+			return false
+		}
+		if end := kept + n; end > overlap {
+			kept = copy(buf, buf[end-overlap:end])
+		} else {
+			kept = end
+		}
+	}
+}
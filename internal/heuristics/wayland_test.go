@@ -0,0 +1,74 @@
+package heuristics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectWaylandSupport(t *testing.T) {
+	t.Parallel()
+
+	elfWith := func(marker string) string { return "\x7fELF" + marker }
+
+	tests := []struct {
+		name  string
+		files map[string]string
+		exec  string
+		want  core.WaylandSupport
+	}{
+		{"gtk3", map[string]string{"/app/usr/lib/libgtk-3.so.0": "x"}, "", core.WaylandNative},
+		{"gtk2", map[string]string{"/app/usr/lib/libgtk-x11-2.0.so.0": "x"}, "", core.WaylandXWayland},
+		{"flutter", map[string]string{"/app/lib/libflutter_linux_gtk.so": "x"}, "", core.WaylandNative},
+		{"tauri webview", map[string]string{"/app/usr/lib/libwebkit2gtk-4.1.so.0": "x"}, "", core.WaylandHybrid},
+		{"bundled qt without plugin", map[string]string{"/app/usr/lib/libQt5Core.so.5": "x"}, "", core.WaylandXWayland},
+		{"bundled qt with plugin", map[string]string{
+			"/app/usr/lib/libQt5Core.so.5":                      "x",
+			"/app/usr/plugins/platforms/libqwayland-generic.so": "x",
+		}, "", core.WaylandHybrid},
+		{"electron with ozone", map[string]string{
+			"/app/chrome-sandbox": "x",
+			"/app/my-app":         elfWith("--ozone-platform-hint"),
+		}, "", core.WaylandHybrid},
+		{"electron without ozone", map[string]string{
+			"/app/chrome-sandbox": "x",
+			"/app/my-app":         elfWith("x11"),
+		}, "", core.WaylandXWayland},
+		{"electron without binary", map[string]string{"/app/chrome-sandbox": "x"}, "", core.WaylandUnknown},
+		{"electron main executable", map[string]string{
+			"/app/resources/app.asar": "x",
+			"/app/my-app":             elfWith("ozone-platform"),
+		}, "/app/my-app", core.WaylandHybrid},
+		{"wayland client", map[string]string{"/app/lib/libwayland-client.so.0": "x"}, "", core.WaylandNative},
+		{"x11 only", map[string]string{"/app/lib/libX11.so.6": "x"}, "", core.WaylandXWayland},
+		{"nothing", map[string]string{"/app/bin/tool": "x"}, "", core.WaylandUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := afero.NewMemMapFs()
+			for path, content := range tt.files {
+				require.NoError(t, afero.WriteFile(fs, path, []byte(content), 0755))
+			}
+			assert.Equal(t, tt.want, DetectWaylandSupport(fs, "/app", tt.exec))
+		})
+	}
+}
+
+func TestFileContains_AcrossReads(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	content := append(bytes.Repeat([]byte{0}, 1<<20+len(ozoneMarker)-6), ozoneMarker...)
+	require.NoError(t, afero.WriteFile(fs, "/bin/app", content, 0755))
+
+	assert.True(t, fileContains(fs, "/bin/app", ozoneMarker))
+	assert.False(t, fileContains(fs, "/bin/app", []byte("wayland")))
+	assert.False(t, fileContains(fs, "/missing", ozoneMarker))
+}
@@ -0,0 +1,134 @@
+// Package history keeps an append-only log of the installs, upgrades,
+// rollbacks and uninstalls upkg performed, one JSON object per line, so users
+// can look back at what changed and when.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// Operations recorded in the log
+const (
+	OpInstall   = "install"
+	OpUninstall = "uninstall"
+	OpUpgrade   = "upgrade"
+	OpRollback  = "rollback"
+)
+
+// Outcomes of an operation
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailed  = "failed"
+)
+
+// maxLineSize bounds a single log line read back
+const maxLineSize = 1 << 20
+
+// Entry is one operation on one package
+type Entry struct {
+	Time        time.Time `json:"time"`
+	Operation   string    `json:"operation"`
+	Name        string    `json:"name,omitempty"` // Package name; empty when an install failed before it was known
+	InstallID   string    `json:"install_id,omitempty"`
+	PackageType string    `json:"package_type,omitempty"`
+	FromVersion string    `json:"from_version,omitempty"` // Version replaced by an upgrade or rollback
+	Version     string    `json:"version,omitempty"`
+	Source      string    `json:"source,omitempty"`  // Package file, URL or ref the operation was asked for
+	Options     []string  `json:"options,omitempty"` // Flags that changed the defaults, e.g. --method=extract
+	Outcome     string    `json:"outcome"`
+	Error       string    `json:"error,omitempty"`
+	DurationMS  int64     `json:"duration_ms"`
+}
+
+// Filter selects entries; zero fields match everything
+type Filter struct {
+	Package   string // Matches the name, install ID or source, case-insensitively
+	Operation string
+	Since     time.Time
+	Until     time.Time
+}
+
+// Match reports whether entry passes the filter
+func (f Filter) Match(entry Entry) bool {
+	if f.Operation != "" && entry.Operation != f.Operation {
+		return false
+	}
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !entry.Time.Before(f.Until) {
+		return false
+	}
+	if f.Package == "" {
+		return true
+	}
+	query := strings.ToLower(f.Package)
+	return strings.EqualFold(entry.Name, f.Package) ||
+		entry.InstallID == f.Package ||
+		strings.Contains(strings.ToLower(filepath.Base(entry.Source)), query)
+}
+
+// Append adds entry to the log at path, creating it if needed. Each entry is
+// written with a single append so concurrent upkg processes never interleave
+// lines.
+func Append(fs afero.Fs, path string, entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode history entry: %w", err)
+	}
+	if err := fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create history directory: %w", err)
+	}
+
+	file, err := fs.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open history file: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("write history file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("close history file: %w", err)
+	}
+	return nil
+}
+
+// Read returns the entries of the log at path that match filter, oldest
+// first. A missing log reads as empty and unreadable lines are skipped.
+func Read(fs afero.Fs, path string, filter Filter) ([]Entry, error) {
+	file, err := fs.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open history file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		var entry Entry
+		if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+			continue
+		}
+		if filter.Match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read history file: %w", err)
+	}
+	return entries, nil
+}
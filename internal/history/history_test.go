@@ -0,0 +1,88 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testHistoryFile = "/data/history.jsonl"
+
+func TestAppendRead(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	day := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	entries := []Entry{
+		{Time: day, Operation: OpInstall, Name: "Firefox", InstallID: "firefox-1", Version: "120",
+			Source: "/tmp/firefox-120.tar.bz2", Options: []string{"--force"}, Outcome: OutcomeSuccess},
+		{Time: day.Add(24 * time.Hour), Operation: OpInstall, Source: "/tmp/broken.AppImage",
+			Outcome: OutcomeFailed, Error: "not an AppImage"},
+		{Time: day.Add(48 * time.Hour), Operation: OpUpgrade, Name: "Firefox", InstallID: "firefox-2",
+			FromVersion: "120", Version: "121", Outcome: OutcomeSuccess},
+	}
+	for _, entry := range entries {
+		require.NoError(t, Append(fs, testHistoryFile, entry))
+	}
+
+	all, err := Read(fs, testHistoryFile, Filter{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, entries[0], all[0])
+	assert.Equal(t, "not an AppImage", all[1].Error)
+
+	firefox, err := Read(fs, testHistoryFile, Filter{Package: "firefox"})
+	require.NoError(t, err)
+	assert.Len(t, firefox, 2)
+
+	upgrades, err := Read(fs, testHistoryFile, Filter{Operation: OpUpgrade})
+	require.NoError(t, err)
+	require.Len(t, upgrades, 1)
+	assert.Equal(t, "121", upgrades[0].Version)
+
+	window, err := Read(fs, testHistoryFile, Filter{Since: day.Add(time.Hour), Until: day.Add(48 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, window, 1)
+	assert.Equal(t, OutcomeFailed, window[0].Outcome)
+}
+
+func TestRead_MissingAndCorrupt(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	entries, err := Read(fs, testHistoryFile, Filter{})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	require.NoError(t, afero.WriteFile(fs, testHistoryFile, []byte("{truncated\n"), 0644))
+	require.NoError(t, Append(fs, testHistoryFile, Entry{Operation: OpUninstall, Name: "app", Outcome: OutcomeSuccess}))
+
+	entries, err = Read(fs, testHistoryFile, Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "app", entries[0].Name)
+}
+
+func TestFilter_Match(t *testing.T) {
+	entry := Entry{Operation: OpInstall, Name: "Obsidian", InstallID: "obsidian-abc", Source: "/home/u/Downloads/Obsidian-1.5.AppImage"}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"empty", Filter{}, true},
+		{"name case-insensitive", Filter{Package: "obsidian"}, true},
+		{"install id", Filter{Package: "obsidian-abc"}, true},
+		{"source file name", Filter{Package: "1.5.appimage"}, true},
+		{"source directory does not match", Filter{Package: "downloads"}, false},
+		{"other package", Filter{Package: "firefox"}, false},
+		{"other operation", Filter{Operation: OpUpgrade}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Match(entry))
+		})
+	}
+}
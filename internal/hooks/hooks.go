@@ -0,0 +1,155 @@
+// Package hooks runs the shell commands users attach to installs and
+// uninstalls, from the [hooks] config section and install --pre-install /
+// --post-install.
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/rs/zerolog"
+)
+
+// waitDelay bounds how long a timed out hook's output is drained
+const waitDelay = time.Second
+
+// Event is the point of an operation a hook runs at
+type Event string
+
+const (
+	PreInstall    Event = "pre-install"
+	PostInstall   Event = "post-install"
+	PreUninstall  Event = "pre-uninstall"
+	PostUninstall Event = "post-uninstall"
+)
+
+// Env describes the package a hook runs for. Every field is passed to the
+// hook as an UPKG_* environment variable, empty when unknown (the install
+// path and desktop file do not exist yet before an install).
+type Env struct {
+	Name        string // UPKG_NAME
+	InstallID   string // UPKG_INSTALL_ID
+	PackageType string // UPKG_PACKAGE_TYPE
+	Version     string // UPKG_VERSION
+	Package     string // UPKG_PACKAGE: the package file installed from
+	InstallPath string // UPKG_INSTALL_PATH
+	DesktopFile string // UPKG_DESKTOP_FILE
+}
+
+// EnvFromRecord describes an installed package
+func EnvFromRecord(record *core.InstallRecord) Env {
+	return Env{
+		Name:        record.Name,
+		InstallID:   record.InstallID,
+		PackageType: string(record.PackageType),
+		Version:     record.Version,
+		Package:     record.OriginalFile,
+		InstallPath: record.InstallPath,
+		DesktopFile: record.DesktopFile,
+	}
+}
+
+// Vars returns the environment entries of a hook run at event
+func (e Env) Vars(event Event) []string {
+	return []string{
+		"UPKG_HOOK=" + string(event),
+		"UPKG_NAME=" + e.Name,
+		"UPKG_INSTALL_ID=" + e.InstallID,
+		"UPKG_PACKAGE_TYPE=" + e.PackageType,
+		"UPKG_VERSION=" + e.Version,
+		"UPKG_PACKAGE=" + e.Package,
+		"UPKG_INSTALL_PATH=" + e.InstallPath,
+		"UPKG_DESKTOP_FILE=" + e.DesktopFile,
+	}
+}
+
+// Runner runs the configured hooks
+type Runner struct {
+	cfg config.HooksConfig
+	log *zerolog.Logger
+
+	// Receive the hook output; os.Stdout and os.Stderr when nil
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// NewRunner creates a runner for the hooks of cfg
+func NewRunner(cfg config.HooksConfig, log *zerolog.Logger) *Runner {
+	return &Runner{cfg: cfg, log: log}
+}
+
+// AbortOnFailure reports whether a failing post-install hook rolls the
+// install back
+func (r *Runner) AbortOnFailure() bool {
+	return r != nil && r.cfg.AbortOnFailure
+}
+
+// Commands returns the configured hooks of event
+func (r *Runner) Commands(event Event) []string {
+	if r == nil {
+		return nil
+	}
+	switch event {
+	case PreInstall:
+		return r.cfg.PreInstall
+	case PostInstall:
+		return r.cfg.PostInstall
+	case PreUninstall:
+		return r.cfg.PreUninstall
+	case PostUninstall:
+		return r.cfg.PostUninstall
+	}
+	return nil
+}
+
+// Run runs the configured hooks of event followed by extra, in order,
+// stopping at the first failure. It is a no-op on a nil runner.
+func (r *Runner) Run(ctx context.Context, event Event, env Env, extra ...string) error {
+	if r == nil {
+		return nil
+	}
+	commands := append(append([]string{}, r.Commands(event)...), extra...)
+	for _, command := range commands {
+		if err := r.run(ctx, event, env, command); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runner) run(ctx context.Context, event Event, env Env, command string) error {
+	if r.cfg.TimeoutSecs > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(r.cfg.TimeoutSecs)*time.Second)
+		defer cancel()
+	}
+
+	r.log.Info().Str("hook", string(event)).Str("command", command).Str("name", env.Name).Msg("running hook")
+
+	// #nosec G204 -- hooks are commands the user configured to run
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), env.Vars(event)...)
+	// Background children of a killed hook may keep its output open
+	cmd.WaitDelay = waitDelay
+	cmd.Stdout, cmd.Stderr = r.Stdout, r.Stderr
+	if cmd.Stdout == nil {
+		cmd.Stdout = os.Stdout
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = os.Stderr
+	}
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %ds", r.cfg.TimeoutSecs)
+		}
+		return fmt.Errorf("%s hook %q failed: %w", event, command, err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRunner(cfg config.HooksConfig) (*Runner, *bytes.Buffer) {
+	log := zerolog.New(io.Discard)
+	runner := NewRunner(cfg, &log)
+	var out bytes.Buffer
+	runner.Stdout, runner.Stderr = &out, &out
+	return runner, &out
+}
+
+func TestRunPassesEnvironment(t *testing.T) {
+	runner, out := newTestRunner(config.HooksConfig{
+		PostInstall: []string{`echo "$UPKG_HOOK $UPKG_NAME $UPKG_INSTALL_PATH $UPKG_DESKTOP_FILE $UPKG_PACKAGE_TYPE"`},
+	})
+	env := EnvFromRecord(&core.InstallRecord{
+		Name:        "app",
+		PackageType: core.PackageTypeAppImage,
+		InstallPath: "/opt/app",
+		DesktopFile: "/apps/app.desktop",
+	})
+
+	require.NoError(t, runner.Run(context.Background(), PostInstall, env, `echo extra`))
+	assert.Equal(t, "post-install app /opt/app /apps/app.desktop appimage\nextra\n", out.String())
+}
+
+func TestRunStopsAtFirstFailure(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "ran")
+	runner, _ := newTestRunner(config.HooksConfig{
+		PreUninstall: []string{"exit 3", "touch " + marker},
+	})
+
+	err := runner.Run(context.Background(), PreUninstall, Env{Name: "app"})
+	assert.ErrorContains(t, err, `pre-uninstall hook "exit 3" failed`)
+	assert.NoFileExists(t, marker)
+}
+
+func TestRunTimeout(t *testing.T) {
+	runner, _ := newTestRunner(config.HooksConfig{TimeoutSecs: 1})
+
+	err := runner.Run(context.Background(), PreInstall, Env{}, "sleep 10")
+	assert.ErrorContains(t, err, "timed out after 1s")
+}
+
+func TestNilRunner(t *testing.T) {
+	var runner *Runner
+	assert.NoError(t, runner.Run(context.Background(), PostInstall, Env{}, "exit 1"))
+	assert.False(t, runner.AbortOnFailure())
+	assert.Empty(t, runner.Commands(PreInstall))
+}
+
+func TestRunInheritsEnvironment(t *testing.T) {
+	t.Setenv("UPKG_HOOK_TEST", "inherited")
+	runner, out := newTestRunner(config.HooksConfig{})
+
+	require.NoError(t, runner.Run(context.Background(), PreInstall, Env{}, `printf %s "$UPKG_HOOK_TEST"`))
+	assert.Equal(t, "inherited", out.String())
+}
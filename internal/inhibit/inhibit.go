@@ -0,0 +1,103 @@
+// Package inhibit holds a systemd-logind inhibitor lock so the machine does
+// not suspend or shut down in the middle of a long install.
+package inhibit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Command is the systemd tool that takes the lock on our behalf
+const Command = "systemd-inhibit"
+
+// What lists the operations blocked while the lock is held
+const What = "sleep:idle:shutdown"
+
+// readyLine is printed by the helper's child once logind granted the lock;
+// systemd-inhibit exits without running it when the lock is refused
+const readyLine = "upkg-inhibit-ready"
+
+// releaseTimeout bounds how long Release waits before killing the helper
+const releaseTimeout = 2 * time.Second
+
+// Lock is a held inhibitor lock. The lock lives as long as the helper
+// process, which exits when its stdin is closed, so it is also released if
+// upkg is killed.
+type Lock struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	done    chan struct{} // Closed when the helper exits
+	waitErr error
+	once    sync.Once
+}
+
+// Acquire takes an inhibitor lock described by why. The lock is released by
+// Release or when ctx is cancelled.
+func Acquire(ctx context.Context, why string) (*Lock, error) {
+	return acquire(ctx, Command, why)
+}
+
+func acquire(ctx context.Context, command, why string) (*Lock, error) {
+	path, err := exec.LookPath(command)
+	if err != nil {
+		return nil, fmt.Errorf("%s not available: %w", command, err)
+	}
+
+	// #nosec G204 -- fixed arguments; why is passed as a single argv entry
+	cmd := exec.CommandContext(ctx, path,
+		"--what="+What, "--who=upkg", "--why="+why, "--mode=block",
+		"sh", "-c", "echo "+readyLine+" && exec cat")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("create inhibitor pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("create inhibitor pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start %s: %w", command, err)
+	}
+
+	// Blocks until the lock is taken or the helper exits
+	line, readErr := bufio.NewReader(stdout).ReadString('\n')
+
+	lock := &Lock{cmd: cmd, stdin: stdin, done: make(chan struct{})}
+	go func() {
+		lock.waitErr = cmd.Wait()
+		close(lock.done)
+	}()
+
+	if strings.TrimSpace(line) != readyLine {
+		_ = stdin.Close()
+		<-lock.done
+		waitErr := lock.waitErr
+		if waitErr == nil {
+			waitErr = fmt.Errorf("exited without taking the lock: %w", readErr)
+		}
+		return nil, fmt.Errorf("inhibitor lock refused: %w", waitErr)
+	}
+	return lock, nil
+}
+
+// Release drops the lock. It is safe to call on a nil lock and more than once.
+func (l *Lock) Release() {
+	if l == nil {
+		return
+	}
+	l.once.Do(func() {
+		_ = l.stdin.Close()
+		select {
+		case <-l.done:
+		case <-time.After(releaseTimeout):
+			_ = l.cmd.Process.Kill()
+			<-l.done
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package inhibit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeInhibit writes a stand-in for systemd-inhibit that records its
+// arguments and then runs script; "$@" is left with the command to run
+func fakeInhibit(t *testing.T, script string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	command := filepath.Join(dir, "fake-inhibit")
+	content := "#!/bin/sh\necho \"$@\" > " + argsFile + "\nshift 4\n" + script + "\n"
+	require.NoError(t, os.WriteFile(command, []byte(content), 0755))
+	return command, argsFile
+}
+
+func TestAcquireAndRelease(t *testing.T) {
+	t.Parallel()
+
+	command, argsFile := fakeInhibit(t, `exec "$@"`)
+	lock, err := acquire(context.Background(), command, "Installing app")
+	require.NoError(t, err)
+
+	args, err := os.ReadFile(argsFile)
+	require.NoError(t, err)
+	assert.Equal(t, "--what=sleep:idle:shutdown --who=upkg --why=Installing app --mode=block sh -c echo "+readyLine+" && exec cat\n", string(args))
+
+	lock.Release()
+	assert.NotNil(t, lock.cmd.ProcessState, "helper exited")
+	lock.Release()
+}
+
+func TestAcquire_Refused(t *testing.T) {
+	t.Parallel()
+
+	command, _ := fakeInhibit(t, "echo 'Failed to inhibit: Access denied' >&2; exit 1")
+	lock, err := acquire(context.Background(), command, "Installing app")
+	assert.Nil(t, lock)
+	assert.ErrorContains(t, err, "inhibitor lock refused")
+}
+
+func TestAcquire_WaitsForTheLock(t *testing.T) {
+	t.Parallel()
+
+	// logind takes a while to answer; Acquire returns only once it did
+	command, _ := fakeInhibit(t, `sleep 0.3; exec "$@"`)
+	started := time.Now()
+	lock, err := acquire(context.Background(), command, "x")
+	require.NoError(t, err)
+	defer lock.Release()
+	assert.GreaterOrEqual(t, time.Since(started), 300*time.Millisecond)
+
+	select {
+	case <-lock.done:
+		t.Fatal("helper exited while the lock is held")
+	default:
+	}
+}
+
+func TestAcquire_Missing(t *testing.T) {
+	t.Parallel()
+
+	_, err := acquire(context.Background(), "upkg-no-such-inhibit", "x")
+	assert.ErrorContains(t, err, "not available")
+}
+
+func TestAcquire_ReleasedOnCancel(t *testing.T) {
+	t.Parallel()
+
+	command, _ := fakeInhibit(t, `exec "$@"`)
+	ctx, cancel := context.WithCancel(context.Background())
+	lock, err := acquire(ctx, command, "x")
+	require.NoError(t, err)
+
+	cancel()
+	select {
+	case <-lock.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("helper still running after cancel")
+	}
+}
+
+func TestRelease_Nil(t *testing.T) {
+	t.Parallel()
+
+	var lock *Lock
+	assert.NotPanics(t, lock.Release)
+}
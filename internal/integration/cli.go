@@ -0,0 +1,156 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+var (
+	// manPageName matches man page file names: tool.1, tool.1.gz, tool.3x
+	manPageName = regexp.MustCompile(`^[^.].*\.([1-9])[a-z]*(\.(gz|bz2|xz|zst))?$`)
+	// manSectionDir matches the section directories of a man tree
+	manSectionDir = regexp.MustCompile(`^man[1-9]$`)
+)
+
+// shippedFile is a file of the payload and where it is installed
+type shippedFile struct {
+	source string
+	target string
+}
+
+// findManPages returns the man pages shipped under root in a man/manN tree
+// or loose in a doc directory, with their place under manDir
+func findManPages(fs afero.Fs, root, manDir string) []shippedFile {
+	var found []shippedFile
+	walkShipped(fs, root, func(path string, dirs []string) {
+		name := filepath.Base(path)
+		match := manPageName.FindStringSubmatch(name)
+		if match == nil || strings.Contains(name, ".so.") || len(dirs) == 0 {
+			return
+		}
+		parent := dirs[len(dirs)-1]
+		section := "man" + match[1]
+		switch {
+		case manSectionDir.MatchString(parent) && len(dirs) > 1 && dirs[len(dirs)-2] == "man":
+			section = parent
+		case parent == "doc" || parent == "docs" || parent == "man":
+		default:
+			return
+		}
+		found = append(found, shippedFile{source: path, target: filepath.Join(manDir, section, name)})
+	})
+	return found
+}
+
+// findCompletions returns the bash, zsh and fish completions shipped under
+// root in a completion directory, with the directory of their shell
+func findCompletions(fs afero.Fs, root, bashDir, zshDir, fishDir string) []shippedFile {
+	var found []shippedFile
+	walkShipped(fs, root, func(path string, dirs []string) {
+		inCompletions, bashTree := false, false
+		for _, dir := range dirs {
+			lower := strings.ToLower(dir)
+			inCompletions = inCompletions || strings.Contains(lower, "complet") || lower == "site-functions"
+			bashTree = bashTree || strings.Contains(lower, "bash")
+		}
+		if !inCompletions {
+			return
+		}
+
+		name := filepath.Base(path)
+		switch ext := filepath.Ext(name); {
+		case ext == ".fish":
+			found = append(found, shippedFile{source: path, target: filepath.Join(fishDir, name)})
+		case ext == ".zsh":
+			found = append(found, shippedFile{source: path, target: filepath.Join(zshDir, "_"+strings.TrimPrefix(strings.TrimSuffix(name, ext), "_"))})
+		case ext == "" && strings.HasPrefix(name, "_"):
+			found = append(found, shippedFile{source: path, target: filepath.Join(zshDir, name)})
+		case ext == ".bash", ext == "" && bashTree:
+			found = append(found, shippedFile{source: path, target: filepath.Join(bashDir, name)})
+		}
+	})
+	return found
+}
+
+// walkShipped calls visit with every regular file under root and the names
+// of the directories between root and it, skipping bundled node modules
+func walkShipped(fs afero.Fs, root string, visit func(path string, dirs []string)) {
+	if root == "" {
+		return
+	}
+	_ = afero.Walk(fs, root, func(path string, info os.FileInfo, err error) error { //nolint:errcheck // unreadable parts are skipped
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if info.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, relErr := filepath.Rel(root, filepath.Dir(path))
+		if relErr != nil {
+			return nil
+		}
+		var dirs []string
+		if rel != "." {
+			dirs = strings.Split(rel, string(filepath.Separator))
+		}
+		visit(path, dirs)
+		return nil
+	})
+}
+
+// InstallManPages copies the man pages shipped under root into the user man
+// directory so man finds them, and returns the installed paths
+func (e *Engine) InstallManPages(root string) ([]string, error) {
+	return e.installShipped(findManPages(e.fs, root, e.paths.GetManDir()), "man page")
+}
+
+// InstallCompletions copies the shell completions shipped under root into
+// the user completion directories of bash, zsh and fish, and returns the
+// installed paths
+func (e *Engine) InstallCompletions(root string) ([]string, error) {
+	files := findCompletions(e.fs, root, e.paths.GetBashCompletionDir(), e.paths.GetZshCompletionDir(), e.paths.GetFishCompletionDir())
+	return e.installShipped(files, "completion")
+}
+
+// installShipped copies files to their targets; the first file wins when
+// several have the same target
+func (e *Engine) installShipped(files []shippedFile, kind string) ([]string, error) {
+	var installed []string
+	seen := make(map[string]bool)
+	for _, file := range files {
+		if seen[file.target] {
+			continue
+		}
+		seen[file.target] = true
+
+		if err := e.fs.MkdirAll(filepath.Dir(file.target), 0755); err != nil {
+			return installed, fmt.Errorf("failed to create %s directory: %w", kind, err)
+		}
+		data, err := afero.ReadFile(e.fs, file.source)
+		if err != nil {
+			e.log.Warn().Err(err).Str("source", file.source).Msgf("failed to read %s", kind)
+			continue
+		}
+		if err := afero.WriteFile(e.fs, file.target, data, 0644); err != nil {
+			e.log.Warn().Err(err).Str("source", file.source).Msgf("failed to install %s", kind)
+			continue
+		}
+		installed = append(installed, file.target)
+		e.log.Debug().
+			Str("source", file.source).
+			Str("target", file.target).
+			Msgf("%s installed", kind)
+	}
+	return installed, nil
+}
@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_InstallManPages(t *testing.T) {
+	t.Parallel()
+
+	engine, fs, resolver := newTestEngine(t, &config.Config{})
+	for _, path := range []string{
+		"/payload/share/man/man1/tool.1",
+		"/payload/share/man/man5/tool.conf.5.gz",
+		"/payload/doc/tool-extra.1",
+		"/payload/lib/libtool.so.1",
+		"/payload/doc/README.md",
+		"/payload/node_modules/dep/man/man1/dep.1",
+	} {
+		require.NoError(t, afero.WriteFile(fs, path, []byte(".TH TOOL 1"), 0644))
+	}
+
+	installed, err := engine.InstallManPages("/payload")
+	require.NoError(t, err)
+	manDir := resolver.GetManDir()
+	assert.ElementsMatch(t, []string{
+		filepath.Join(manDir, "man1", "tool.1"),
+		filepath.Join(manDir, "man5", "tool.conf.5.gz"),
+		filepath.Join(manDir, "man1", "tool-extra.1"),
+	}, installed)
+
+	content, err := afero.ReadFile(fs, filepath.Join(manDir, "man1", "tool.1"))
+	require.NoError(t, err)
+	assert.Equal(t, ".TH TOOL 1", string(content))
+
+	installed, err = engine.InstallManPages("")
+	require.NoError(t, err)
+	assert.Empty(t, installed)
+}
+
+func TestEngine_InstallCompletions(t *testing.T) {
+	t.Parallel()
+
+	engine, fs, resolver := newTestEngine(t, &config.Config{})
+	for _, path := range []string{
+		"/payload/complete/rg.bash",
+		"/payload/complete/_rg",
+		"/payload/complete/rg.fish",
+		"/payload/completions/tool.zsh",
+		"/payload/share/bash-completion/completions/tool",
+		"/payload/bin/_helper",
+		"/payload/scripts/setup.bash",
+	} {
+		require.NoError(t, afero.WriteFile(fs, path, []byte("complete"), 0644))
+	}
+
+	installed, err := engine.InstallCompletions("/payload")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(resolver.GetBashCompletionDir(), "rg.bash"),
+		filepath.Join(resolver.GetZshCompletionDir(), "_rg"),
+		filepath.Join(resolver.GetFishCompletionDir(), "rg.fish"),
+		filepath.Join(resolver.GetZshCompletionDir(), "_tool"),
+		filepath.Join(resolver.GetBashCompletionDir(), "tool"),
+	}, installed, "only files in completion directories are installed")
+}
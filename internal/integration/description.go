@@ -0,0 +1,139 @@
+package integration
+
+import (
+	"encoding/xml"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/spf13/afero"
+)
+
+// Length limits for generated fields; launchers truncate longer tooltips and
+// show GenericName next to the app name
+const (
+	maxCommentLength     = 100
+	maxGenericNameLength = 40
+)
+
+// appStreamPatterns locate AppStream metainfo relative to a payload root
+var appStreamPatterns = []string{
+	"usr/share/metainfo/*.xml",
+	"usr/share/appdata/*.xml",
+	"share/metainfo/*.xml",
+	"share/appdata/*.xml",
+}
+
+// appStreamComponent is the subset of an AppStream metainfo file used here
+type appStreamComponent struct {
+	Summaries []struct {
+		Lang string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+		Text string `xml:",chardata"`
+	} `xml:"summary"`
+}
+
+// DescriptionFields derives a Comment and GenericName from a package
+// description. The first line, cut at the end of its first sentence, is the
+// Comment; when that summary is short enough it also serves as GenericName.
+func DescriptionFields(description string) (comment, genericName string) {
+	summary := firstSentence(description)
+	if summary == "" {
+		return "", ""
+	}
+	comment = truncateWords(summary, maxCommentLength)
+
+	name := strings.TrimRight(summary, ".!")
+	for _, article := range []string{"a ", "an ", "the "} {
+		if len(name) > len(article) && strings.EqualFold(name[:len(article)], article) {
+			name = name[len(article):]
+			break
+		}
+	}
+	if utf8.RuneCountInString(name) <= maxGenericNameLength && !strings.ContainsAny(name, ".!?:;()") {
+		genericName = capitalize(name)
+	}
+	return comment, genericName
+}
+
+// FillDescription fills entry's Comment and GenericName from description.
+// Only placeholders are replaced: an empty Comment or placeholderComment, and
+// an empty GenericName or one that merely repeats Name.
+func FillDescription(entry *core.DesktopEntry, description, placeholderComment string) {
+	comment, genericName := DescriptionFields(description)
+	if comment != "" && (entry.Comment == "" || entry.Comment == placeholderComment) {
+		entry.Comment = comment
+	}
+	if genericName != "" && !strings.EqualFold(genericName, entry.Name) &&
+		(entry.GenericName == "" || strings.EqualFold(entry.GenericName, entry.Name)) {
+		entry.GenericName = genericName
+	}
+}
+
+// AppStreamSummary returns the untranslated <summary> of the first AppStream
+// metainfo file shipped under root, or "" when there is none
+func AppStreamSummary(fs afero.Fs, root string) string {
+	if root == "" {
+		return ""
+	}
+	for _, pattern := range appStreamPatterns {
+		matches, err := afero.Glob(fs, filepath.Join(root, pattern))
+		if err != nil {
+			continue
+		}
+		for _, match := range matches {
+			data, err := afero.ReadFile(fs, match)
+			if err != nil {
+				continue
+			}
+			var component appStreamComponent
+			if xml.Unmarshal(data, &component) != nil {
+				continue
+			}
+			for _, summary := range component.Summaries {
+				if text := strings.TrimSpace(summary.Text); summary.Lang == "" && text != "" {
+					return text
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// firstSentence returns the first non-empty line of text up to the end of its
+// first sentence, with whitespace collapsed
+func firstSentence(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			continue
+		}
+		if end := strings.Index(line, ". "); end > 0 {
+			line = line[:end+1]
+		}
+		return line
+	}
+	return ""
+}
+
+// truncateWords shortens s to at most limit runes at a word boundary
+func truncateWords(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)[:limit-1]
+	cut := string(runes)
+	if space := strings.LastIndex(cut, " "); space > limit/2 {
+		cut = cut[:space]
+	}
+	return strings.TrimRight(cut, " ,;:-") + "…"
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -0,0 +1,110 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescriptionFields(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name, description, comment, genericName string
+	}{
+		{"debian synopsis", "fast, secure web browser\n Extended description that\n spans lines.", "fast, secure web browser", "Fast, secure web browser"},
+		{"leading article", "A markdown note-taking app.", "A markdown note-taking app.", "Markdown note-taking app"},
+		{"first sentence only", "Modern terminal emulator. Written in Rust with GPU rendering.", "Modern terminal emulator.", "Modern terminal emulator"},
+		{"long summary has no generic name", "Visual Studio Code is a lightweight but powerful source code editor", "Visual Studio Code is a lightweight but powerful source code editor", ""},
+		{"collapsed whitespace", "\n\n  Music   player  \n", "Music player", "Music player"},
+		{"empty", "  \n ", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comment, genericName := DescriptionFields(tt.description)
+			assert.Equal(t, tt.comment, comment)
+			assert.Equal(t, tt.genericName, genericName)
+		})
+	}
+}
+
+func TestDescriptionFields_Truncates(t *testing.T) {
+	t.Parallel()
+
+	description := "An extremely capable and thoroughly over-engineered application for editing, converting and publishing documents in every format"
+	comment, genericName := DescriptionFields(description)
+
+	assert.LessOrEqual(t, len([]rune(comment)), maxCommentLength)
+	assert.Equal(t, "An extremely capable and thoroughly over-engineered application for editing, converting and…", comment)
+	assert.Empty(t, genericName)
+}
+
+func TestFillDescription(t *testing.T) {
+	t.Parallel()
+
+	entry := &core.DesktopEntry{Name: "Notes", GenericName: "Notes", Comment: "Notes application"}
+	FillDescription(entry, "Markdown note-taking app", "Notes application")
+	assert.Equal(t, "Markdown note-taking app", entry.Comment, "placeholder comment is replaced")
+	assert.Equal(t, "Markdown note-taking app", entry.GenericName, "GenericName repeating Name is replaced")
+
+	shipped := &core.DesktopEntry{Name: "Notes", GenericName: "Note Editor", Comment: "Write notes"}
+	FillDescription(shipped, "Markdown note-taking app", "Notes application")
+	assert.Equal(t, "Write notes", shipped.Comment)
+	assert.Equal(t, "Note Editor", shipped.GenericName)
+
+	same := &core.DesktopEntry{Name: "Notes"}
+	FillDescription(same, "notes", "")
+	assert.Equal(t, "notes", same.Comment)
+	assert.Empty(t, same.GenericName, "a GenericName equal to Name adds nothing")
+}
+
+func TestAppStreamSummary(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	assert.Empty(t, AppStreamSummary(fs, "/payload"))
+
+	metainfo := `<?xml version="1.0" encoding="UTF-8"?>
+<component type="desktop-application">
+  <id>org.example.Notes</id>
+  <summary xml:lang="de">Notizen in Markdown</summary>
+  <summary>Markdown note-taking app</summary>
+</component>`
+	require.NoError(t, afero.WriteFile(fs, "/payload/usr/share/metainfo/org.example.Notes.metainfo.xml", []byte(metainfo), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/payload/usr/share/appdata/broken.appdata.xml", []byte("<component"), 0644))
+
+	assert.Equal(t, "Markdown note-taking app", AppStreamSummary(fs, "/payload"))
+}
+
+func TestEngine_BuildDesktopEntry_Description(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Desktop: config.DesktopConfig{DescriptionFields: true}}
+	engine, fs, _ := newTestEngine(t, cfg)
+	spec := DesktopSpec{
+		AppName:        "Notes",
+		FileName:       "notes",
+		ExecPath:       "/home/test/.local/bin/notes",
+		DefaultComment: "Notes application",
+		PayloadRoot:    "/payload",
+	}
+
+	entry := engine.BuildDesktopEntry(spec, core.InstallOptions{Description: "Take notes from GitHub"})
+	assert.Equal(t, "Take notes from GitHub", entry.Comment, "falls back to the upstream description")
+
+	require.NoError(t, afero.WriteFile(fs, "/payload/share/metainfo/notes.appdata.xml",
+		[]byte(`<component><summary>Markdown note-taking app</summary></component>`), 0644))
+	entry = engine.BuildDesktopEntry(spec, core.InstallOptions{Description: "Take notes from GitHub"})
+	assert.Equal(t, "Markdown note-taking app", entry.Comment, "package metadata wins over the upstream description")
+	assert.Equal(t, "Markdown note-taking app", entry.GenericName)
+
+	disabled, _, _ := newTestEngine(t, &config.Config{})
+	entry = disabled.BuildDesktopEntry(spec, core.InstallOptions{Description: "Take notes from GitHub"})
+	assert.Equal(t, "Notes application", entry.Comment)
+	assert.Empty(t, entry.GenericName)
+}
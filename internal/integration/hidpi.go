@@ -0,0 +1,222 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/heuristics"
+	"github.com/spf13/afero"
+)
+
+// Desktop families that natively scale one toolkit
+const (
+	DesktopKDE   = "kde"
+	DesktopGNOME = "gnome"
+	DesktopOther = "other"
+)
+
+// ScaleSetup describes the display scaling of the running session
+type ScaleSetup struct {
+	Desktop string  // kde, gnome or other
+	Factor  float64 // Largest scale factor in use (0 when HiDPI assistance is off)
+}
+
+// Fractional reports whether the scale factor is not a whole number
+func (s ScaleSetup) Fractional() bool {
+	return s.Factor != math.Trunc(s.Factor)
+}
+
+var monitorsXMLScale = regexp.MustCompile(`<scale>\s*([0-9.]+)\s*</scale>`)
+
+// ScaleFor returns the scaling to assist for the named package, or the zero
+// value when HiDPI assistance is not enabled for it
+func (e *Engine) ScaleFor(name string, opts core.InstallOptions) ScaleSetup {
+	if !e.hiDPIEnabled(name, opts) {
+		return ScaleSetup{}
+	}
+
+	setup := DetectScaling(e.fs, e.runner, os.Getenv, e.paths.HomeDir())
+	if e.cfg.Desktop.HiDPIScale > 0 {
+		setup.Factor = e.cfg.Desktop.HiDPIScale
+	}
+
+	e.log.Debug().
+		Str("app", name).
+		Str("desktop", setup.Desktop).
+		Float64("scale", setup.Factor).
+		Msg("HiDPI assistance enabled")
+	if setup.Factor <= 1 {
+		return ScaleSetup{}
+	}
+	return setup
+}
+
+// hiDPIEnabled reports whether HiDPI assistance applies to the named package
+func (e *Engine) hiDPIEnabled(name string, opts core.InstallOptions) bool {
+	if opts.HiDPI || e.cfg.Desktop.HiDPI {
+		return true
+	}
+	for _, pkg := range e.cfg.Desktop.HiDPIPackages {
+		if strings.EqualFold(pkg, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectScaling inspects the session (KDE config, GNOME settings, Hyprland
+// monitors) for the largest scale factor in use
+func DetectScaling(fs afero.Fs, runner helpers.CommandRunner, getenv func(string) string, homeDir string) ScaleSetup {
+	current := strings.ToLower(getenv("XDG_CURRENT_DESKTOP"))
+	setup := ScaleSetup{Desktop: DesktopOther}
+
+	switch {
+	case strings.Contains(current, "kde"):
+		setup.Desktop = DesktopKDE
+		setup.Factor = kdeScale(fs, homeDir)
+	case strings.Contains(current, "gnome"):
+		setup.Desktop = DesktopGNOME
+		setup.Factor = gnomeScale(fs, runner, homeDir)
+	case strings.Contains(current, "hyprland"):
+		setup.Factor = hyprlandScale(runner)
+	}
+	return setup
+}
+
+// HiDPIRule returns the env and argv that make toolkit honor scale on the
+// session's desktop. Toolkits the desktop already scales natively get nothing.
+func HiDPIRule(toolkit heuristics.Framework, scale ScaleSetup) LauncherRule {
+	var rule LauncherRule
+	if scale.Factor <= 1 {
+		return rule
+	}
+
+	qt := (toolkit == heuristics.FrameworkQt || toolkit == heuristics.FrameworkUnknown) && scale.Desktop != DesktopKDE
+	gtk := (toolkit == heuristics.FrameworkGTK || toolkit == heuristics.FrameworkTauri || toolkit == heuristics.FrameworkFlutter || toolkit == heuristics.FrameworkUnknown) && scale.Desktop != DesktopGNOME
+
+	if qt {
+		rule.EnvVars = append(rule.EnvVars, "QT_AUTO_SCREEN_SCALE_FACTOR=1", "QT_ENABLE_HIGHDPI_SCALING=1")
+		if scale.Fractional() {
+			rule.EnvVars = append(rule.EnvVars, "QT_SCALE_FACTOR_ROUNDING_POLICY=PassThrough")
+		}
+	}
+	if gtk {
+		// GTK only scales by integers; GDK_DPI_SCALE shrinks text back to the fractional target
+		whole := math.Ceil(scale.Factor)
+		rule.EnvVars = append(rule.EnvVars, fmt.Sprintf("GDK_SCALE=%d", int(whole)))
+		if scale.Fractional() {
+			rule.EnvVars = append(rule.EnvVars, "GDK_DPI_SCALE="+formatScale(scale.Factor/whole))
+		}
+	}
+	if toolkit == heuristics.FrameworkElectron {
+		rule.Args = append(rule.Args, "--force-device-scale-factor="+formatScale(scale.Factor))
+	}
+	return rule
+}
+
+// formatScale renders a scale factor without trailing zeros
+func formatScale(f float64) string {
+	return strconv.FormatFloat(math.Round(f*1000)/1000, 'f', -1, 64)
+}
+
+// kdeScale reads the Plasma scale from kwinrc ([Xwayland] Scale) or kdeglobals ([KScreen] ScaleFactor)
+func kdeScale(fs afero.Fs, homeDir string) float64 {
+	configDir := filepath.Join(homeDir, ".config")
+	if scale := iniFloat(fs, filepath.Join(configDir, "kwinrc"), "Xwayland", "Scale"); scale > 0 {
+		return scale
+	}
+	return iniFloat(fs, filepath.Join(configDir, "kdeglobals"), "KScreen", "ScaleFactor")
+}
+
+// gnomeScale reads the integer scaling-factor setting, falling back to the
+// per-monitor scales in monitors.xml (used by fractional scaling)
+func gnomeScale(fs afero.Fs, runner helpers.CommandRunner, homeDir string) float64 {
+	if runner != nil && runner.CommandExists("gsettings") {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if out, err := runner.RunCommand(ctx, "gsettings", "get", "org.gnome.desktop.interface", "scaling-factor"); err == nil {
+			fields := strings.Fields(out)
+			if len(fields) > 0 {
+				if scale, parseErr := strconv.ParseFloat(fields[len(fields)-1], 64); parseErr == nil && scale > 1 {
+					return scale
+				}
+			}
+		}
+	}
+
+	data, err := afero.ReadFile(fs, filepath.Join(homeDir, ".config", "monitors.xml"))
+	if err != nil {
+		return 0
+	}
+	var largest float64
+	for _, match := range monitorsXMLScale.FindAllStringSubmatch(string(data), -1) {
+		if scale, parseErr := strconv.ParseFloat(match[1], 64); parseErr == nil && scale > largest {
+			largest = scale
+		}
+	}
+	return largest
+}
+
+// hyprlandScale returns the largest monitor scale reported by hyprctl
+func hyprlandScale(runner helpers.CommandRunner) float64 {
+	if runner == nil || !runner.CommandExists("hyprctl") {
+		return 0
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	out, err := runner.RunCommand(ctx, "hyprctl", "monitors", "-j")
+	if err != nil {
+		return 0
+	}
+	var monitors []struct {
+		Scale float64 `json:"scale"`
+	}
+	if err := json.Unmarshal([]byte(out), &monitors); err != nil {
+		return 0
+	}
+	var largest float64
+	for _, monitor := range monitors {
+		largest = math.Max(largest, monitor.Scale)
+	}
+	return largest
+}
+
+// iniFloat reads a float key from a section of an INI-style file
+func iniFloat(fs afero.Fs, path, section, key string) float64 {
+	file, err := fs.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer func() { _ = file.Close() }()
+
+	inSection := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inSection = line == "["+section+"]"
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, "="); ok && strings.TrimSpace(k) == key {
+			if value, parseErr := strconv.ParseFloat(strings.TrimSpace(v), 64); parseErr == nil {
+				return value
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,107 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/heuristics"
+	"github.com/quantmind-br/upkg/internal/snapshot"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envFunc(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func TestDetectScaling(t *testing.T) {
+	t.Parallel()
+
+	t.Run("kde kwinrc", func(t *testing.T) {
+		t.Parallel()
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/home/u/.config/kwinrc", []byte("[Compositing]\nScale=3\n[Xwayland]\nScale=1.25\n"), 0644))
+		setup := DetectScaling(fs, nil, envFunc(map[string]string{"XDG_CURRENT_DESKTOP": "KDE"}), "/home/u")
+		assert.Equal(t, ScaleSetup{Desktop: DesktopKDE, Factor: 1.25}, setup)
+		assert.True(t, setup.Fractional())
+	})
+
+	t.Run("gnome gsettings", func(t *testing.T) {
+		t.Parallel()
+		runner := &helpers.MockCommandRunner{
+			CommandExistsFunc: func(name string) bool { return name == "gsettings" },
+			RunCommandFunc: func(_ context.Context, _ string, _ ...string) (string, error) {
+				return "uint32 2\n", nil
+			},
+		}
+		setup := DetectScaling(afero.NewMemMapFs(), runner, envFunc(map[string]string{"XDG_CURRENT_DESKTOP": "ubuntu:GNOME"}), "/home/u")
+		assert.Equal(t, ScaleSetup{Desktop: DesktopGNOME, Factor: 2}, setup)
+	})
+
+	t.Run("gnome monitors.xml", func(t *testing.T) {
+		t.Parallel()
+		fs := afero.NewMemMapFs()
+		require.NoError(t, afero.WriteFile(fs, "/home/u/.config/monitors.xml", []byte("<monitors><scale>1</scale><scale>1.5</scale></monitors>"), 0644))
+		setup := DetectScaling(fs, nil, envFunc(map[string]string{"XDG_CURRENT_DESKTOP": "GNOME"}), "/home/u")
+		assert.Equal(t, 1.5, setup.Factor)
+	})
+
+	t.Run("hyprland", func(t *testing.T) {
+		t.Parallel()
+		runner := &helpers.MockCommandRunner{
+			CommandExistsFunc: func(string) bool { return true },
+			RunCommandFunc: func(_ context.Context, _ string, _ ...string) (string, error) {
+				return `[{"name":"eDP-1","scale":1.6},{"name":"HDMI-A-1","scale":1.0}]`, nil
+			},
+		}
+		setup := DetectScaling(afero.NewMemMapFs(), runner, envFunc(map[string]string{"XDG_CURRENT_DESKTOP": "Hyprland"}), "/home/u")
+		assert.Equal(t, ScaleSetup{Desktop: DesktopOther, Factor: 1.6}, setup)
+	})
+}
+
+func TestHiDPIRule(t *testing.T) {
+	t.Parallel()
+
+	kde := ScaleSetup{Desktop: DesktopKDE, Factor: 1.5}
+	gnome := ScaleSetup{Desktop: DesktopGNOME, Factor: 2}
+
+	assert.Equal(t, []string{"GDK_SCALE=2", "GDK_DPI_SCALE=0.75"}, HiDPIRule(heuristics.FrameworkGTK, kde).EnvVars)
+	assert.Empty(t, HiDPIRule(heuristics.FrameworkQt, kde).EnvVars, "Plasma scales Qt natively")
+	assert.Equal(t, []string{"QT_AUTO_SCREEN_SCALE_FACTOR=1", "QT_ENABLE_HIGHDPI_SCALING=1"}, HiDPIRule(heuristics.FrameworkQt, gnome).EnvVars)
+	assert.Empty(t, HiDPIRule(heuristics.FrameworkGTK, gnome).EnvVars, "GNOME scales GTK natively")
+	assert.Equal(t, []string{"--force-device-scale-factor=1.5"}, HiDPIRule(heuristics.FrameworkElectron, kde).Args)
+	assert.Empty(t, HiDPIRule(heuristics.FrameworkQt, ScaleSetup{Desktop: DesktopOther, Factor: 1}).EnvVars)
+}
+
+func TestEngine_ScaleFor(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Desktop: config.DesktopConfig{HiDPIPackages: []string{"my-app"}, HiDPIScale: 1.5}}
+	engine, _, _ := newTestEngine(t, cfg)
+
+	assert.Equal(t, 1.5, engine.ScaleFor("My-App", core.InstallOptions{}).Factor)
+	assert.Zero(t, engine.ScaleFor("other", core.InstallOptions{}).Factor)
+	assert.Equal(t, 1.5, engine.ScaleFor("other", core.InstallOptions{HiDPI: true}).Factor)
+}
+
+func TestComposeDesktopEntry_HiDPISnapshot(t *testing.T) {
+	t.Parallel()
+
+	spec := DesktopSpec{
+		AppName:  "Gtk App",
+		FileName: "gtk-app",
+		ExecPath: "/opt/gtk-app/gtk-app",
+		Toolkit:  heuristics.FrameworkGTK,
+		Scale:    ScaleSetup{Desktop: DesktopKDE, Factor: 1.5},
+	}
+	entry, err := ComposeDesktopEntry(nil, spec, core.InstallOptions{}, config.DesktopConfig{WaylandEnvVars: true})
+	require.NoError(t, err)
+
+	content, err := RenderDesktopEntry(entry)
+	require.NoError(t, err)
+	snapshot.Match(t, "desktop_hidpi_gtk_on_kde", content)
+}
@@ -0,0 +1,465 @@
+// Package integration implements the desktop integration steps shared by the
+// local backends (appimage, tarball, rpm): wrapper scripts (optionally
+// sandboxed), icon installation and .desktop entry generation.
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/desktop"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/heuristics"
+	"github.com/quantmind-br/upkg/internal/icons"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/quantmind-br/upkg/internal/sandbox"
+	"github.com/quantmind-br/upkg/internal/wrapper"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+)
+
+// DesktopValidatorTool is the optional validator run on generated desktop entries
+var DesktopValidatorTool = core.ToolRequirement{
+	Name:     "desktop-file-validate",
+	Optional: true,
+	Purpose:  "validate generated .desktop files",
+	Packages: map[string]string{core.DistroArch: "desktop-file-utils", core.DistroDebian: "desktop-file-utils", core.DistroFedora: "desktop-file-utils", core.DistroSUSE: "desktop-file-utils"},
+}
+
+// SandboxTool runs the apps installed with --sandbox
+var SandboxTool = core.ToolRequirement{
+	Name:         "bwrap",
+	Alternatives: []string{"firejail"},
+	Optional:     true,
+	Purpose:      "run apps installed with --sandbox",
+	Packages:     map[string]string{core.DistroArch: "bubblewrap", core.DistroDebian: "bubblewrap", core.DistroFedora: "bubblewrap", core.DistroSUSE: "bubblewrap"},
+}
+
+// DesktopSpec is the normalized description of a payload used to build its desktop entry
+type DesktopSpec struct {
+	AppName        string               // Name used when the payload ships no desktop entry
+	FileName       string               // Stem of the generated .desktop file (normalized name)
+	ExecPath       string               // Launcher written to Exec and TryExec
+	ExecArgs       []string             // Extra arguments placed between ExecPath and %U
+	IconName       string               // Icon name (defaults to FileName)
+	SourceDesktop  string               // Desktop file shipped with the payload, if any
+	PayloadRoot    string               // Extracted payload, searched when inferring WMClass
+	WMClass        string               // StartupWMClass for entries that lack one (inferred when empty)
+	DefaultComment string               // Comment used for generated entries
+	Description    string               // Package description for Comment/GenericName (AppStream or opts.Description when empty)
+	Toolkit        heuristics.Framework // Detected UI toolkit, selects the Wayland rules
+	Wayland        core.WaylandSupport  // Detected Wayland support, decides whether the Wayland rules apply
+	Scale          ScaleSetup           // Display scaling for HiDPI assistance (zero disables it)
+}
+
+// LauncherRule is the env and argv injected into a launcher's Exec line
+type LauncherRule struct {
+	EnvVars []string
+	Args    []string
+}
+
+// DefaultToolkitKey selects the config rule applied to apps with an unknown toolkit
+const DefaultToolkitKey = "default"
+
+// builtinWaylandRules are the per-toolkit defaults. Electron is switched via
+// argv since ELECTRON_OZONE_PLATFORM_HINT is ignored by recent releases.
+// WebKitGTK (Tauri) breaks with a forced GDK_BACKEND and picks the backend
+// itself; it only needs its DMA-BUF renderer off to avoid blank windows.
+var builtinWaylandRules = map[heuristics.Framework]LauncherRule{
+	heuristics.FrameworkUnknown:  {EnvVars: desktop.DefaultWaylandEnvVars},
+	heuristics.FrameworkElectron: {Args: []string{"--ozone-platform-hint=auto"}},
+	heuristics.FrameworkTauri:    {EnvVars: []string{"WEBKIT_DISABLE_DMABUF_RENDERER=1"}},
+	heuristics.FrameworkFlutter:  {EnvVars: []string{"GDK_BACKEND=wayland,x11"}},
+	heuristics.FrameworkQt:       {EnvVars: []string{"QT_QPA_PLATFORM=wayland:xcb"}},
+	heuristics.FrameworkGTK:      {EnvVars: []string{"GDK_BACKEND=wayland,x11"}},
+}
+
+// Engine performs desktop integration on behalf of a backend
+type Engine struct {
+	fs     afero.Fs
+	runner helpers.CommandRunner
+	paths  *paths.Resolver
+	cfg    *config.Config
+	log    *zerolog.Logger
+}
+
+// NewEngine creates a new integration engine
+func NewEngine(fs afero.Fs, runner helpers.CommandRunner, resolver *paths.Resolver, cfg *config.Config, log *zerolog.Logger) *Engine {
+	return &Engine{
+		fs:     fs,
+		runner: runner,
+		paths:  resolver,
+		cfg:    cfg,
+		log:    log,
+	}
+}
+
+// CreateWrapper writes a launcher script in the user bin directory and
+// returns its path. opts.WrapperArgs and opts.WrapperEnv are added to it.
+func (e *Engine) CreateWrapper(name, execPath string, opts core.InstallOptions) (string, error) {
+	return e.writeWrapper(name, execPath, opts, nil)
+}
+
+// CreateLauncher writes the launcher of name: a plain wrapper, or with
+// opts.Sandbox one that starts execPath inside the configured sandbox (see
+// CreateSandboxedWrapper). It returns the wrapper path and the sandbox tool,
+// empty when the app is not sandboxed.
+func (e *Engine) CreateLauncher(name, execPath string, opts core.InstallOptions, expose ...string) (string, string, error) {
+	if !opts.Sandbox {
+		wrapperPath, err := e.CreateWrapper(name, execPath, opts)
+		return wrapperPath, "", err
+	}
+	return e.CreateSandboxedWrapper(name, execPath, nil, opts, expose...)
+}
+
+// CreateSandboxedWrapper writes a launcher that starts execPath inside bwrap
+// or firejail with the [sandbox] profile, setting env in the sandbox. expose
+// lists the paths the app needs when its home is private (its install dir).
+// It returns the wrapper path and the sandbox tool used.
+func (e *Engine) CreateSandboxedWrapper(name, execPath string, env []string, opts core.InstallOptions, expose ...string) (string, string, error) {
+	tool, err := sandbox.Resolve(e.runner, e.cfg.Sandbox.Tool)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Linked install dirs (--link) must be visible at their target too
+	visible := slices.Clone(expose)
+	for _, path := range expose {
+		if target, linkErr := filepath.EvalSymlinks(path); linkErr == nil && target != path {
+			visible = append(visible, target)
+		}
+	}
+
+	profile := sandbox.ProfileFor(e.cfg.Sandbox, e.paths.GetSandboxDir(), name, visible...)
+	profile.Env = env
+	command, err := sandbox.Command(tool, profile)
+	if err != nil {
+		return "", "", err
+	}
+	if profile.HomeDir != "" {
+		if err := e.fs.MkdirAll(profile.HomeDir, 0700); err != nil {
+			return "", "", fmt.Errorf("failed to create sandbox home: %w", err)
+		}
+	}
+
+	wrapperPath, err := e.writeWrapper(name, execPath, opts, command)
+	if err != nil {
+		return "", "", err
+	}
+
+	e.log.Debug().
+		Str("wrapper", wrapperPath).
+		Str("tool", tool).
+		Str("home", profile.HomeDir).
+		Msg("sandboxed wrapper created")
+
+	return wrapperPath, tool, nil
+}
+
+// writeWrapper renders the wrapper of name with the user template, if any,
+// and writes it to the user bin directory
+func (e *Engine) writeWrapper(name, execPath string, opts core.InstallOptions, sandboxCommand []string) (string, error) {
+	tmpl, err := wrapper.Load(e.fs, e.paths.GetWrapperTemplateFile())
+	if err != nil {
+		return "", err
+	}
+
+	binDir := e.paths.GetBinDir()
+	if err := e.fs.MkdirAll(binDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create bin directory: %w", err)
+	}
+
+	wrapperPath := filepath.Join(binDir, name)
+	wrapperCfg := wrapper.Config{
+		WrapperPath:    wrapperPath,
+		ExecPath:       execPath,
+		DisableSandbox: e.cfg.Desktop.ElectronDisableSandbox,
+		Sandbox:        sandboxCommand,
+		Args:           opts.WrapperArgs,
+		Env:            opts.WrapperEnv,
+		Template:       tmpl,
+	}
+	if err := wrapper.Create(e.fs, wrapperCfg); err != nil {
+		return "", fmt.Errorf("failed to create wrapper script: %w", err)
+	}
+
+	return wrapperPath, nil
+}
+
+// InstallIcons installs discovered icons into the hicolor theme of the scope under iconName
+func (e *Engine) InstallIcons(discovered []core.IconFile, iconName string) ([]string, error) {
+	if e.paths.HomeDir() == "" {
+		return nil, fmt.Errorf("failed to get home directory")
+	}
+
+	manager := icons.NewManager(e.fs, e.paths.GetIconsBaseDir())
+	installed := []string{}
+	for _, iconFile := range discovered {
+		targetPath, err := manager.InstallIcon(iconFile.Path, iconName, iconFile.Size)
+		if err != nil {
+			e.log.Warn().
+				Err(err).
+				Str("icon", iconFile.Path).
+				Msg("failed to install icon")
+			continue
+		}
+
+		installed = append(installed, targetPath)
+		e.log.Debug().
+			Str("source", iconFile.Path).
+			Str("target", targetPath).
+			Msg("icon installed")
+	}
+
+	return installed, nil
+}
+
+// RemoveFiles removes previously installed integration files, logging failures
+func (e *Engine) RemoveFiles(filePaths []string) {
+	for _, path := range filePaths {
+		if path == "" {
+			continue
+		}
+		if err := e.fs.Remove(path); err != nil {
+			e.log.Warn().
+				Err(err).
+				Str("path", path).
+				Msg("failed to remove file")
+		}
+	}
+}
+
+// FindDesktopFile returns the first .desktop file matching any of the glob patterns
+func (e *Engine) FindDesktopFile(patterns ...string) string {
+	for _, pattern := range patterns {
+		matches, err := afero.Glob(e.fs, pattern)
+		if err != nil {
+			e.log.Debug().Err(err).Str("pattern", pattern).Msg("failed to glob desktop files")
+			continue
+		}
+		if len(matches) > 0 {
+			return matches[0]
+		}
+	}
+	return ""
+}
+
+// BuildDesktopEntry builds the desktop entry for a payload, starting from the
+// shipped entry when it can be parsed
+func (e *Engine) BuildDesktopEntry(spec DesktopSpec, opts core.InstallOptions) *core.DesktopEntry {
+	source := e.parseSourceDesktop(spec.SourceDesktop)
+	if spec.Scale.Factor == 0 {
+		spec.Scale = e.ScaleFor(spec.FileName, opts)
+	}
+	if spec.WMClass == "" {
+		spec.WMClass = e.inferWMClass(spec, source)
+	}
+	if spec.Description == "" && e.cfg.Desktop.DescriptionFields {
+		spec.Description = AppStreamSummary(e.fs, spec.PayloadRoot)
+	}
+
+	if reason := waylandSkipReason(source, spec.Wayland, opts); reason != "" {
+		e.log.Info().Str("app", spec.AppName).Msg(reason)
+	}
+
+	entry, err := ComposeDesktopEntry(source, spec, opts, e.cfg.Desktop)
+	if err != nil {
+		e.log.Warn().
+			Err(err).
+			Str("app", spec.AppName).
+			Msg("invalid custom Wayland env vars, injecting defaults only")
+	}
+	return entry
+}
+
+// ComposeDesktopEntry derives the entry written for spec from the shipped
+// source entry (nil when absent). It has no side effects; an error reports
+// rejected custom env vars, in which case the defaults are still injected.
+func ComposeDesktopEntry(source *core.DesktopEntry, spec DesktopSpec, opts core.InstallOptions, cfg config.DesktopConfig) (*core.DesktopEntry, error) {
+	entry := &core.DesktopEntry{
+		Type:    "Application",
+		Version: "1.5",
+		Name:    spec.AppName,
+		Comment: spec.DefaultComment,
+	}
+	if source != nil {
+		copied := *source
+		copied.Categories = append([]string(nil), source.Categories...)
+		entry = &copied
+	}
+
+	execLine := append([]string{spec.ExecPath}, spec.ExecArgs...)
+	entry.Exec = strings.Join(append(execLine, "%U"), " ")
+	entry.TryExec = spec.ExecPath
+
+	entry.Icon = spec.IconName
+	if entry.Icon == "" {
+		entry.Icon = spec.FileName
+	}
+
+	entry.Categories = desktop.NormalizeCategories(entry.Categories)
+	if entry.StartupWMClass == "" {
+		entry.StartupWMClass = spec.WMClass
+	}
+	if cfg.DescriptionFields {
+		description := spec.Description
+		if description == "" {
+			description = opts.Description
+		}
+		FillDescription(entry, description, spec.DefaultComment)
+	}
+
+	return entry, ApplyLauncherRules(entry, spec.Toolkit, spec.Wayland, spec.Scale, opts, cfg)
+}
+
+// ResolveWaylandRule merges the built-in rule for toolkit with the config additions
+func ResolveWaylandRule(toolkit heuristics.Framework, cfg config.DesktopConfig) LauncherRule {
+	builtin := builtinWaylandRules[toolkit]
+	key := string(toolkit)
+	if toolkit == heuristics.FrameworkUnknown {
+		key = DefaultToolkitKey
+	}
+
+	return LauncherRule{
+		EnvVars: append(append([]string(nil), builtin.EnvVars...), cfg.ToolkitEnvVars[key]...),
+		Args:    append(append([]string(nil), builtin.Args...), cfg.ToolkitArgs[key]...),
+	}
+}
+
+// ApplyLauncherRules injects the Wayland rule for toolkit (unless disabled, or
+// skipped for the app's Wayland support) and the HiDPI rule for scale into
+// entry.Exec. Invalid custom env vars are dropped and reported; the rest is
+// still applied.
+func ApplyLauncherRules(entry *core.DesktopEntry, toolkit heuristics.Framework, wayland core.WaylandSupport, scale ScaleSetup, opts core.InstallOptions, cfg config.DesktopConfig) error {
+	toolkit = entryToolkit(entry, toolkit)
+
+	var rule LauncherRule
+	var customVars []string
+	if waylandSkipReason(entry, wayland, opts) == "" && cfg.WaylandEnvVars {
+		rule = ResolveWaylandRule(toolkit, cfg)
+		customVars = cfg.CustomEnvVars
+	}
+
+	hidpi := HiDPIRule(toolkit, scale)
+	rule.EnvVars = append(rule.EnvVars, hidpi.EnvVars...)
+	rule.Args = append(rule.Args, hidpi.Args...)
+
+	entry.Exec = desktop.InsertExecArgs(entry.Exec, rule.Args)
+	if err := desktop.InjectEnvVars(entry, rule.EnvVars, customVars); err != nil {
+		if fallbackErr := desktop.InjectEnvVars(entry, rule.EnvVars, nil); fallbackErr != nil {
+			return fallbackErr
+		}
+		return err
+	}
+	return nil
+}
+
+// RenderDesktopEntry serializes entry in .desktop format
+func RenderDesktopEntry(entry *core.DesktopEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := desktop.Write(&buf, entry); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteDesktopEntry builds the entry for spec, writes it to the applications
+// directory and validates it. It returns the written path.
+func (e *Engine) WriteDesktopEntry(spec DesktopSpec, opts core.InstallOptions) (string, error) {
+	appsDir := e.paths.GetAppsDir()
+	if err := e.fs.MkdirAll(appsDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create applications directory: %w", err)
+	}
+
+	content, err := RenderDesktopEntry(e.BuildDesktopEntry(spec, opts))
+	if err != nil {
+		return "", err
+	}
+
+	desktopFilePath := filepath.Join(appsDir, spec.FileName+".desktop")
+	if err := afero.WriteFile(e.fs, desktopFilePath, content, 0644); err != nil {
+		return "", err
+	}
+
+	e.validateDesktopFile(desktopFilePath)
+
+	return desktopFilePath, nil
+}
+
+// parseSourceDesktop parses the desktop file shipped with the payload
+func (e *Engine) parseSourceDesktop(path string) *core.DesktopEntry {
+	if path == "" {
+		return nil
+	}
+
+	file, err := e.fs.Open(path)
+	if err != nil {
+		e.log.Debug().Err(err).Str("desktop_file", path).Msg("failed to open desktop file")
+		return nil
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			e.log.Debug().Err(closeErr).Str("desktop_file", path).Msg("failed to close desktop file")
+		}
+	}()
+
+	entry, err := desktop.Parse(file)
+	if err != nil {
+		e.log.Debug().Err(err).Str("desktop_file", path).Msg("failed to parse desktop file")
+		return nil
+	}
+
+	e.log.Debug().
+		Str("desktop_file", path).
+		Str("name", entry.Name).
+		Msg("using desktop file from package")
+	return entry
+}
+
+// waylandSkipReason explains why Wayland env injection is skipped for entry, or returns "".
+// Only apps that need a hint (hybrid) or could not be classified get it.
+func waylandSkipReason(entry *core.DesktopEntry, wayland core.WaylandSupport, opts core.InstallOptions) string {
+	switch {
+	case opts.SkipWaylandEnv:
+		return "skipping Wayland environment injection per user request"
+	case wayland == core.WaylandNative:
+		return "app runs natively on Wayland, skipping Wayland environment injection"
+	case wayland == core.WaylandXWayland:
+		// Forcing a Wayland backend on an X11-only app breaks it
+		return "app is X11-only and runs through XWayland, skipping Wayland environment injection"
+	}
+	return ""
+}
+
+// entryToolkit falls back to the StartupWMClass of entry when the payload gave
+// the toolkit away: Tauri apps name their window class after the framework
+func entryToolkit(entry *core.DesktopEntry, toolkit heuristics.Framework) heuristics.Framework {
+	if toolkit == heuristics.FrameworkUnknown && entry != nil && strings.Contains(strings.ToLower(entry.StartupWMClass), "tauri") {
+		return heuristics.FrameworkTauri
+	}
+	return toolkit
+}
+
+// validateDesktopFile runs desktop-file-validate when available (warnings only)
+func (e *Engine) validateDesktopFile(desktopFilePath string) {
+	if e.runner == nil || !e.runner.CommandExists("desktop-file-validate") {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := e.runner.RunCommand(ctx, "desktop-file-validate", desktopFilePath); err != nil {
+		e.log.Warn().
+			Err(err).
+			Str("desktop_file", desktopFilePath).
+			Msg("desktop file validation failed")
+	}
+}
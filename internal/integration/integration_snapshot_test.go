@@ -0,0 +1,217 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/heuristics"
+	"github.com/quantmind-br/upkg/internal/sandbox"
+	"github.com/quantmind-br/upkg/internal/snapshot"
+	"github.com/quantmind-br/upkg/internal/wrapper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComposeDesktopEntry_Snapshots(t *testing.T) {
+	t.Parallel()
+
+	shipped := &core.DesktopEntry{
+		Type:           "Application",
+		Name:           "Shipped App",
+		Comment:        "Comment from the package",
+		Exec:           "AppRun %U",
+		Icon:           "shipped",
+		Categories:     []string{"Development", "IDE"},
+		StartupWMClass: "shipped-app",
+	}
+	tauri := &core.DesktopEntry{Type: "Application", Name: "Tauri App", Exec: "tauri-app", StartupWMClass: "tauri-app"}
+
+	waylandOn := config.DesktopConfig{WaylandEnvVars: true}
+	waylandOff := config.DesktopConfig{}
+
+	tests := []struct {
+		name   string
+		source *core.DesktopEntry
+		spec   DesktopSpec
+		opts   core.InstallOptions
+		cfg    config.DesktopConfig
+	}{
+		{
+			name:   "appimage_shipped_entry",
+			source: shipped,
+			spec:   DesktopSpec{AppName: "Shipped App", FileName: "shipped-app", ExecPath: "/home/test/.local/share/upkg/apps/shipped-app/AppRun", IconName: "shipped-app"},
+			cfg:    waylandOn,
+		},
+		{
+			name:   "appimage_electron_no_sandbox",
+			source: shipped,
+			spec:   DesktopSpec{AppName: "Shipped App", FileName: "shipped-app", ExecPath: "/home/test/.local/bin/shipped-app", ExecArgs: []string{"--no-sandbox"}},
+			cfg:    waylandOn,
+		},
+		{
+			name: "tarball_generated_entry",
+			spec: DesktopSpec{AppName: "My Tool", FileName: "my-tool", ExecPath: "/home/test/.local/share/upkg/apps/my-tool/bin/my-tool", IconName: "my-tool", DefaultComment: "My Tool application"},
+			cfg:  waylandOn,
+		},
+		{
+			name: "rpm_wrapper_entry",
+			spec: DesktopSpec{AppName: "Git Butler Nightly", FileName: "git-butler-nightly", ExecPath: "/home/test/.local/bin/git-butler-nightly", IconName: "git-butler-nightly"},
+			cfg:  waylandOn,
+		},
+		{
+			name: "wayland_disabled",
+			spec: DesktopSpec{AppName: "My Tool", FileName: "my-tool", ExecPath: "/opt/my-tool/my-tool"},
+			cfg:  waylandOff,
+		},
+		{
+			name: "skip_wayland_flag",
+			spec: DesktopSpec{AppName: "My Tool", FileName: "my-tool", ExecPath: "/opt/my-tool/my-tool"},
+			opts: core.InstallOptions{SkipWaylandEnv: true},
+			cfg:  waylandOn,
+		},
+		{
+			name:   "tauri_app",
+			source: tauri,
+			spec:   DesktopSpec{AppName: "Tauri App", FileName: "tauri-app", ExecPath: "/opt/tauri-app/tauri-app"},
+			cfg:    waylandOn,
+		},
+		{
+			name: "toolkit_electron",
+			spec: DesktopSpec{AppName: "Editor", FileName: "editor", ExecPath: "/home/test/.local/bin/editor", ExecArgs: []string{"--no-sandbox"}, Toolkit: heuristics.FrameworkElectron},
+			cfg:  waylandOn,
+		},
+		{
+			name: "toolkit_qt",
+			spec: DesktopSpec{AppName: "Qt App", FileName: "qt-app", ExecPath: "/opt/qt-app/qt-app", Toolkit: heuristics.FrameworkQt},
+			cfg:  waylandOn,
+		},
+		{
+			name: "toolkit_gtk",
+			spec: DesktopSpec{AppName: "Gtk App", FileName: "gtk-app", ExecPath: "/opt/gtk-app/gtk-app", Toolkit: heuristics.FrameworkGTK},
+			cfg:  waylandOn,
+		},
+		{
+			name: "toolkit_flutter",
+			spec: DesktopSpec{AppName: "Todo", FileName: "todo", ExecPath: "/opt/todo/todo", Toolkit: heuristics.FrameworkFlutter},
+			cfg:  waylandOn,
+		},
+		{
+			name: "toolkit_config_rules",
+			spec: DesktopSpec{AppName: "Editor", FileName: "editor", ExecPath: "/opt/editor/editor", Toolkit: heuristics.FrameworkElectron},
+			cfg: config.DesktopConfig{
+				WaylandEnvVars: true,
+				ToolkitEnvVars: map[string][]string{"electron": {"ELECTRON_ENABLE_WAYLAND=1"}},
+				ToolkitArgs:    map[string][]string{"electron": {"--enable-features=WaylandWindowDecorations", "--ozone-platform-hint=auto"}},
+			},
+		},
+		{
+			name: "custom_env_vars",
+			spec: DesktopSpec{AppName: "My Tool", FileName: "my-tool", ExecPath: "/opt/my-tool/my-tool"},
+			cfg:  config.DesktopConfig{WaylandEnvVars: true, CustomEnvVars: []string{"GTK_THEME=Adwaita:dark", "APP_TITLE=My Tool"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			entry, err := ComposeDesktopEntry(tt.source, tt.spec, tt.opts, tt.cfg)
+			require.NoError(t, err)
+
+			content, err := RenderDesktopEntry(entry)
+			require.NoError(t, err)
+			snapshot.Match(t, "desktop_"+tt.name, content)
+		})
+	}
+}
+
+func TestComposeDesktopEntry_InvalidCustomEnvFallsBack(t *testing.T) {
+	t.Parallel()
+
+	spec := DesktopSpec{AppName: "My Tool", FileName: "my-tool", ExecPath: "/opt/my-tool/my-tool"}
+	cfg := config.DesktopConfig{WaylandEnvVars: true, CustomEnvVars: []string{"NOT_AN_ASSIGNMENT"}}
+
+	entry, err := ComposeDesktopEntry(nil, spec, core.InstallOptions{}, cfg)
+	require.Error(t, err)
+
+	content, err := RenderDesktopEntry(entry)
+	require.NoError(t, err)
+	snapshot.Match(t, "desktop_invalid_custom_env_vars", content)
+}
+
+func TestComposeDesktopEntry_DoesNotMutateSource(t *testing.T) {
+	t.Parallel()
+
+	source := &core.DesktopEntry{Type: "Application", Name: "A", Exec: "a"}
+	_, err := ComposeDesktopEntry(source, DesktopSpec{AppName: "A", FileName: "a", ExecPath: "/bin/a"}, core.InstallOptions{}, config.DesktopConfig{WaylandEnvVars: true})
+	require.NoError(t, err)
+	require.Equal(t, "a", source.Exec)
+	require.Empty(t, source.Categories)
+}
+
+func TestResolveWaylandRule_DefaultKey(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.DesktopConfig{ToolkitEnvVars: map[string][]string{DefaultToolkitKey: {"SDL_VIDEODRIVER=wayland"}}}
+	rule := ResolveWaylandRule(heuristics.FrameworkUnknown, cfg)
+	require.Contains(t, rule.EnvVars, "GDK_BACKEND=wayland,x11")
+	require.Contains(t, rule.EnvVars, "SDL_VIDEODRIVER=wayland")
+	require.Empty(t, rule.Args)
+}
+
+func TestRenderWrapper_Snapshots(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		execPath       string
+		isElectron     bool
+		disableSandbox bool
+	}{
+		{"plain", "/home/test/.local/share/upkg/apps/my-tool/bin/my-tool", false, false},
+		{"plain_sandbox_flag_ignored", "/opt/my-tool/my-tool", false, true},
+		{"electron", "/home/test/.local/share/upkg/apps/editor/editor", true, false},
+		{"electron_no_sandbox", "/home/test/.local/share/upkg/apps/editor/editor", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			content, err := wrapper.Render(wrapper.Config{ExecPath: tt.execPath, DisableSandbox: tt.disableSandbox}, tt.isElectron)
+			require.NoError(t, err)
+			snapshot.MatchString(t, "wrapper_"+tt.name, content)
+		})
+	}
+}
+
+func TestRenderSandboxedWrapper_Snapshots(t *testing.T) {
+	t.Parallel()
+
+	profile := sandbox.Profile{
+		HomeDir: "/home/test/.local/share/upkg/sandbox/editor",
+		Expose:  []string{"/home/test/.local/share/upkg/apps/editor"},
+		Network: true,
+		Devices: []string{sandbox.DeviceGPU, sandbox.DeviceAudio},
+	}
+
+	tests := []struct {
+		name       string
+		tool       string
+		isElectron bool
+	}{
+		{"bwrap", sandbox.ToolBwrap, false},
+		{"bwrap_electron", sandbox.ToolBwrap, true},
+		{"firejail", sandbox.ToolFirejail, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			words, err := sandbox.Command(tt.tool, profile)
+			require.NoError(t, err)
+			content, err := wrapper.Render(wrapper.Config{ExecPath: "/home/test/.local/share/upkg/apps/editor/editor", Sandbox: words}, tt.isElectron)
+			require.NoError(t, err)
+			snapshot.MatchString(t, "wrapper_sandbox_"+tt.name, content)
+		})
+	}
+}
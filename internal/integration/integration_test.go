@@ -0,0 +1,209 @@
+package integration
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/paths"
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestEngine(t *testing.T, cfg *config.Config) (*Engine, afero.Fs, *paths.Resolver) {
+	t.Helper()
+	logger := zerolog.New(io.Discard)
+	fs := afero.NewMemMapFs()
+	resolver := paths.NewResolverWithHome(cfg, "/home/test")
+	runner := &helpers.MockCommandRunner{
+		CommandExistsFunc: func(string) bool { return false },
+	}
+	return NewEngine(fs, runner, resolver, cfg, &logger), fs, resolver
+}
+
+func TestEngine_BuildDesktopEntry_Default(t *testing.T) {
+	t.Parallel()
+
+	engine, _, _ := newTestEngine(t, &config.Config{})
+
+	entry := engine.BuildDesktopEntry(DesktopSpec{
+		AppName:        "My App",
+		FileName:       "my-app",
+		ExecPath:       "/home/test/.local/bin/my-app",
+		ExecArgs:       []string{"--no-sandbox"},
+		DefaultComment: "My App application",
+	}, core.InstallOptions{})
+
+	assert.Equal(t, "Application", entry.Type)
+	assert.Equal(t, "My App", entry.Name)
+	assert.Equal(t, "/home/test/.local/bin/my-app --no-sandbox %U", entry.Exec)
+	assert.Equal(t, "/home/test/.local/bin/my-app", entry.TryExec)
+	assert.Equal(t, "my-app", entry.Icon)
+	assert.Equal(t, []string{"Utility"}, entry.Categories)
+	assert.Equal(t, "My App application", entry.Comment)
+}
+
+func TestEngine_BuildDesktopEntry_FromSource(t *testing.T) {
+	t.Parallel()
+
+	engine, fs, _ := newTestEngine(t, &config.Config{Desktop: config.DesktopConfig{WaylandEnvVars: true}})
+	source := "/payload/app.desktop"
+	require.NoError(t, afero.WriteFile(fs, source, []byte("[Desktop Entry]\nType=Application\nName=Shipped\nExec=app\nTryExec=app\nCategories=Development;\n"), 0644))
+
+	entry := engine.BuildDesktopEntry(DesktopSpec{
+		AppName:       "Fallback",
+		FileName:      "app",
+		ExecPath:      "/bin/app",
+		IconName:      "custom-icon",
+		SourceDesktop: source,
+	}, core.InstallOptions{})
+
+	assert.Equal(t, "Shipped", entry.Name)
+	assert.Equal(t, "/bin/app", entry.TryExec)
+	assert.Equal(t, "custom-icon", entry.Icon)
+	assert.Equal(t, []string{"Development"}, entry.Categories)
+	assert.True(t, strings.HasPrefix(entry.Exec, "env "))
+	assert.True(t, strings.HasSuffix(entry.Exec, "/bin/app %U"))
+}
+
+func TestEngine_BuildDesktopEntry_WaylandSkipsAndTauri(t *testing.T) {
+	t.Parallel()
+
+	engine, fs, _ := newTestEngine(t, &config.Config{Desktop: config.DesktopConfig{WaylandEnvVars: true}})
+
+	entry := engine.BuildDesktopEntry(DesktopSpec{AppName: "A", FileName: "a", ExecPath: "/bin/a"}, core.InstallOptions{SkipWaylandEnv: true})
+	assert.Equal(t, "/bin/a %U", entry.Exec)
+
+	source := "/payload/tauri.desktop"
+	require.NoError(t, afero.WriteFile(fs, source, []byte("[Desktop Entry]\nType=Application\nName=T\nExec=t\nStartupWMClass=my-tauri-app\n"), 0644))
+	entry = engine.BuildDesktopEntry(DesktopSpec{AppName: "T", FileName: "t", ExecPath: "/bin/t", SourceDesktop: source}, core.InstallOptions{})
+	assert.Equal(t, "env WEBKIT_DISABLE_DMABUF_RENDERER=1 /bin/t %U", entry.Exec, "Tauri WMClass selects the WebKitGTK rule, not GDK_BACKEND")
+}
+
+func TestEngine_BuildDesktopEntry_WaylandSupport(t *testing.T) {
+	t.Parallel()
+
+	engine, _, _ := newTestEngine(t, &config.Config{Desktop: config.DesktopConfig{WaylandEnvVars: true}})
+
+	for support, injected := range map[core.WaylandSupport]bool{
+		core.WaylandNative:   false,
+		core.WaylandXWayland: false,
+		core.WaylandHybrid:   true,
+		core.WaylandUnknown:  true,
+		"":                   true,
+	} {
+		entry := engine.BuildDesktopEntry(DesktopSpec{AppName: "A", FileName: "a", ExecPath: "/bin/a", Wayland: support}, core.InstallOptions{})
+		assert.Equal(t, injected, strings.HasPrefix(entry.Exec, "env "), string(support))
+	}
+}
+
+func TestEngine_WriteDesktopEntry(t *testing.T) {
+	t.Parallel()
+
+	engine, fs, resolver := newTestEngine(t, &config.Config{})
+
+	path, err := engine.WriteDesktopEntry(DesktopSpec{AppName: "App", FileName: "app", ExecPath: "/bin/app"}, core.InstallOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(resolver.GetAppsDir(), "app.desktop"), path)
+
+	content, err := afero.ReadFile(fs, path)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Exec=/bin/app %U")
+	assert.Contains(t, string(content), "TryExec=/bin/app")
+}
+
+func TestEngine_FindDesktopFile(t *testing.T) {
+	t.Parallel()
+
+	engine, fs, _ := newTestEngine(t, &config.Config{})
+	require.NoError(t, afero.WriteFile(fs, "/payload/usr/share/applications/b.desktop", []byte(""), 0644))
+
+	assert.Equal(t, "/payload/usr/share/applications/b.desktop",
+		engine.FindDesktopFile("/payload/*.desktop", "/payload/usr/share/applications/*.desktop"))
+	assert.Empty(t, engine.FindDesktopFile("/missing/*.desktop"))
+}
+
+func TestEngine_CreateWrapperAndRemove(t *testing.T) {
+	t.Parallel()
+
+	engine, fs, resolver := newTestEngine(t, &config.Config{})
+
+	wrapperPath, err := engine.CreateWrapper("tool", "/opt/tool/bin/tool", core.InstallOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(resolver.GetBinDir(), "tool"), wrapperPath)
+
+	content, err := afero.ReadFile(fs, wrapperPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "/opt/tool/bin/tool")
+
+	engine.RemoveFiles([]string{wrapperPath, ""})
+	exists, err := afero.Exists(fs, wrapperPath)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestEngine_CreateWrapper_ArgsEnvAndUserTemplate(t *testing.T) {
+	t.Parallel()
+
+	engine, fs, resolver := newTestEngine(t, &config.Config{})
+	opts := core.InstallOptions{WrapperArgs: []string{"--profile", "it's"}, WrapperEnv: []string{"FOO=bar baz"}}
+
+	wrapperPath, err := engine.CreateWrapper("tool", "/opt/tool/bin/tool", opts)
+	require.NoError(t, err)
+	content, err := afero.ReadFile(fs, wrapperPath)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/bash\n# upkg wrapper script\nexport 'FOO=bar baz'\nexec \"/opt/tool/bin/tool\" '--profile' 'it'\\''s' \"$@\"\n", string(content))
+
+	require.NoError(t, afero.WriteFile(fs, resolver.GetWrapperTemplateFile(), []byte("#!/bin/sh\nexec nice {{.ExecPath}}{{range .Args}} {{quote .}}{{end}} \"$@\"\n"), 0644))
+	_, err = engine.CreateWrapper("tool", "/opt/tool/bin/tool", opts)
+	require.NoError(t, err)
+	content, err = afero.ReadFile(fs, wrapperPath)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\nexec nice /opt/tool/bin/tool '--profile' 'it'\\''s' \"$@\"\n", string(content))
+
+	require.NoError(t, afero.WriteFile(fs, resolver.GetWrapperTemplateFile(), []byte("{{.Missing"), 0644))
+	_, err = engine.CreateWrapper("tool", "/opt/tool/bin/tool", opts)
+	assert.ErrorContains(t, err, "parse wrapper template")
+}
+
+func TestEngine_CreateLauncher(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{}
+	cfg.Sandbox = config.SandboxConfig{Tool: "auto", IsolateHome: true, Devices: []string{"gpu"}}
+	engine, fs, resolver := newTestEngine(t, cfg)
+
+	wrapperPath, tool, err := engine.CreateLauncher("tool", "/opt/tool/bin/tool", core.InstallOptions{}, "/opt/tool")
+	require.NoError(t, err)
+	assert.Empty(t, tool)
+	content, err := afero.ReadFile(fs, wrapperPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "bwrap")
+
+	_, _, err = engine.CreateLauncher("tool", "/opt/tool/bin/tool", core.InstallOptions{Sandbox: true}, "/opt/tool")
+	assert.ErrorContains(t, err, "no sandbox tool found")
+
+	engine.runner = &helpers.MockCommandRunner{
+		CommandExistsFunc: func(name string) bool { return name == "bwrap" },
+	}
+	wrapperPath, tool, err = engine.CreateLauncher("tool", "/opt/tool/bin/tool", core.InstallOptions{Sandbox: true}, "/opt/tool")
+	require.NoError(t, err)
+	assert.Equal(t, "bwrap", tool)
+
+	content, err = afero.ReadFile(fs, wrapperPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "exec bwrap ")
+	assert.Contains(t, string(content), "--ro-bind '/opt/tool' '/opt/tool'")
+	assert.Contains(t, string(content), "--unshare-net")
+
+	homeDir := filepath.Join(resolver.GetSandboxDir(), "tool")
+	info, err := fs.Stat(homeDir)
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+}
@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// metainfoSuffixes are the file name endings of AppStream metainfo
+var metainfoSuffixes = []string{".metainfo.xml", ".appdata.xml"}
+
+// desktopLaunchable matches the desktop-id launchables of a metainfo file
+var desktopLaunchable = regexp.MustCompile(`(<launchable\s+type="desktop-id"\s*>)[^<]*(</launchable>)`)
+
+// FindMetainfo returns the AppStream metainfo files shipped under root, in
+// the standard directories or at its top level
+func FindMetainfo(fs afero.Fs, root string) []string {
+	if root == "" {
+		return nil
+	}
+
+	var found []string
+	seen := make(map[string]bool)
+	for _, pattern := range append(slices.Clone(appStreamPatterns), "*.xml") {
+		matches, err := afero.Glob(fs, filepath.Join(root, pattern))
+		if err != nil {
+			continue
+		}
+		for _, match := range matches {
+			base := filepath.Base(match)
+			if seen[base] || !slices.ContainsFunc(metainfoSuffixes, func(suffix string) bool { return strings.HasSuffix(base, suffix) }) {
+				continue
+			}
+			seen[base] = true
+			found = append(found, match)
+		}
+	}
+	return found
+}
+
+// InstallMetainfo copies the AppStream metainfo shipped under root into the
+// user metainfo directory so software centers describe the app, and returns
+// the installed paths. Desktop-id launchables are pointed at desktopFile, the
+// entry upkg installed, when there is one.
+func (e *Engine) InstallMetainfo(root, desktopFile string) ([]string, error) {
+	sources := FindMetainfo(e.fs, root)
+	if len(sources) == 0 {
+		return nil, nil
+	}
+
+	metainfoDir := e.paths.GetMetainfoDir()
+	if err := e.fs.MkdirAll(metainfoDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create metainfo directory: %w", err)
+	}
+
+	var installed []string
+	for _, source := range sources {
+		data, err := afero.ReadFile(e.fs, source)
+		if err != nil {
+			e.log.Warn().Err(err).Str("metainfo", source).Msg("failed to read metainfo")
+			continue
+		}
+		if desktopFile != "" {
+			data = desktopLaunchable.ReplaceAll(data, []byte("${1}"+filepath.Base(desktopFile)+"${2}"))
+		}
+
+		target := filepath.Join(metainfoDir, filepath.Base(source))
+		if err := afero.WriteFile(e.fs, target, data, 0644); err != nil {
+			e.log.Warn().Err(err).Str("metainfo", source).Msg("failed to install metainfo")
+			continue
+		}
+		installed = append(installed, target)
+		e.log.Debug().
+			Str("source", source).
+			Str("target", target).
+			Msg("metainfo installed")
+	}
+
+	return installed, nil
+}
@@ -0,0 +1,59 @@
+package integration
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindMetainfo(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	for _, path := range []string{
+		"/payload/usr/share/metainfo/org.example.Editor.metainfo.xml",
+		"/payload/usr/share/appdata/org.example.Editor.metainfo.xml",
+		"/payload/usr/share/appdata/editor.appdata.xml",
+		"/payload/usr/share/metainfo/notes.xml",
+		"/payload/tool.metainfo.xml",
+	} {
+		require.NoError(t, afero.WriteFile(fs, path, []byte("<component/>"), 0644))
+	}
+
+	assert.Equal(t, []string{
+		"/payload/usr/share/metainfo/org.example.Editor.metainfo.xml",
+		"/payload/usr/share/appdata/editor.appdata.xml",
+		"/payload/tool.metainfo.xml",
+	}, FindMetainfo(fs, "/payload"))
+	assert.Empty(t, FindMetainfo(fs, ""))
+}
+
+func TestEngine_InstallMetainfo(t *testing.T) {
+	t.Parallel()
+
+	engine, fs, resolver := newTestEngine(t, &config.Config{})
+	const source = "/payload/usr/share/metainfo/org.example.Editor.metainfo.xml"
+	require.NoError(t, afero.WriteFile(fs, source, []byte(`<component type="desktop-application">
+  <id>org.example.Editor</id>
+  <launchable type="desktop-id">org.example.Editor.desktop</launchable>
+</component>
+`), 0644))
+
+	installed, err := engine.InstallMetainfo("/payload", "/home/test/.local/share/applications/editor.desktop")
+	require.NoError(t, err)
+	target := filepath.Join(resolver.GetMetainfoDir(), "org.example.Editor.metainfo.xml")
+	assert.Equal(t, []string{target}, installed)
+
+	content, err := afero.ReadFile(fs, target)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `<launchable type="desktop-id">editor.desktop</launchable>`)
+	assert.Contains(t, string(content), "<id>org.example.Editor</id>")
+
+	installed, err = engine.InstallMetainfo("/empty", "")
+	require.NoError(t, err)
+	assert.Empty(t, installed)
+}
@@ -0,0 +1,142 @@
+package integration
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/desktop"
+	"github.com/spf13/afero"
+	"layeh.com/asar"
+)
+
+// electronPackage holds the package.json fields that name an Electron app's windows
+type electronPackage struct {
+	Name        string `json:"name"`
+	ProductName string `json:"productName"`
+	DesktopName string `json:"desktopName"`
+}
+
+// inferWMClass guesses the StartupWMClass for a payload whose shipped entry
+// has none, so docks can match windows to the installed entry. It tries, in
+// order: other desktop entries in the payload, the Electron package.json and
+// the name of the launched binary.
+func (e *Engine) inferWMClass(spec DesktopSpec, source *core.DesktopEntry) string {
+	if source != nil && source.StartupWMClass != "" {
+		return source.StartupWMClass
+	}
+
+	if spec.PayloadRoot != "" {
+		if class := e.wmClassFromPayloadEntries(spec.PayloadRoot); class != "" {
+			return class
+		}
+		if pkg, ok := readElectronPackage(e.fs, spec.PayloadRoot); ok {
+			if class := pkg.wmClass(); class != "" {
+				return class
+			}
+		}
+	}
+
+	if source != nil {
+		if class := binaryWMClass(firstExecField(source.Exec)); class != "" {
+			return class
+		}
+	}
+	return binaryWMClass(spec.ExecPath)
+}
+
+// wmClassFromPayloadEntries returns the first StartupWMClass declared by a
+// desktop entry shipped in the payload
+func (e *Engine) wmClassFromPayloadEntries(root string) string {
+	patterns := []string{
+		filepath.Join(root, "*.desktop"),
+		filepath.Join(root, "usr", "share", "applications", "*.desktop"),
+		filepath.Join(root, "share", "applications", "*.desktop"),
+	}
+	for _, pattern := range patterns {
+		matches, err := afero.Glob(e.fs, pattern)
+		if err != nil {
+			continue
+		}
+		for _, match := range matches {
+			file, err := e.fs.Open(match)
+			if err != nil {
+				continue
+			}
+			entry, parseErr := desktop.Parse(file)
+			_ = file.Close()
+			if parseErr == nil && entry.StartupWMClass != "" {
+				return entry.StartupWMClass
+			}
+		}
+	}
+	return ""
+}
+
+// wmClass returns the window class Electron assigns: desktopName when set,
+// otherwise productName, otherwise name
+func (p electronPackage) wmClass() string {
+	if p.DesktopName != "" {
+		return strings.TrimSuffix(p.DesktopName, ".desktop")
+	}
+	if p.ProductName != "" {
+		return p.ProductName
+	}
+	return p.Name
+}
+
+// readElectronPackage reads package.json from resources/app or resources/app.asar
+func readElectronPackage(fs afero.Fs, root string) (electronPackage, bool) {
+	var pkg electronPackage
+
+	resources := filepath.Join(root, "resources")
+	if data, err := afero.ReadFile(fs, filepath.Join(resources, "app", "package.json")); err == nil {
+		return pkg, json.Unmarshal(data, &pkg) == nil
+	}
+
+	file, err := fs.Open(filepath.Join(resources, "app.asar"))
+	if err != nil {
+		return pkg, false
+	}
+	defer func() { _ = file.Close() }()
+
+	archive, err := asar.Decode(file)
+	if err != nil {
+		return pkg, false
+	}
+	entry := archive.Find("package.json")
+	if entry == nil {
+		return pkg, false
+	}
+	return pkg, json.Unmarshal(entry.Bytes(), &pkg) == nil
+}
+
+// firstExecField returns the program of an Exec line, skipping an env prefix
+func firstExecField(execLine string) string {
+	fields := strings.Fields(execLine)
+	for i := 0; i < len(fields); i++ {
+		switch {
+		case fields[i] == "env":
+			continue
+		case strings.Contains(fields[i], "=") && !strings.HasPrefix(fields[i], "/"):
+			continue
+		default:
+			return strings.Trim(fields[i], `"'`)
+		}
+	}
+	return ""
+}
+
+// binaryWMClass derives a window class from an executable path. AppImage
+// launchers (AppRun) and versioned AppImage names carry no usable class.
+func binaryWMClass(execPath string) string {
+	name := filepath.Base(execPath)
+	if execPath == "" || name == "." || name == "/" || strings.EqualFold(name, "AppRun") {
+		return ""
+	}
+	if strings.HasSuffix(strings.ToLower(name), ".appimage") {
+		return ""
+	}
+	return name
+}
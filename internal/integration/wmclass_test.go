@@ -0,0 +1,123 @@
+package integration
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildAsar packs a single package.json into an asar archive
+func buildAsar(t *testing.T, packageJSON string) []byte {
+	t.Helper()
+	header := fmt.Sprintf(`{"files":{"package.json":{"size":%d,"offset":"0"}}}`, len(packageJSON))
+	padded := (len(header) + 3) &^ 3
+
+	buf := make([]byte, 16+padded)
+	binary.LittleEndian.PutUint32(buf[0:4], 4)
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(8+padded))
+	binary.LittleEndian.PutUint32(buf[8:12], uint32(4+padded))
+	binary.LittleEndian.PutUint32(buf[12:16], uint32(len(header)))
+	copy(buf[16:], header)
+	return append(buf, packageJSON...)
+}
+
+func TestEngine_InferWMClass(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		files  map[string]string
+		source *core.DesktopEntry
+		spec   DesktopSpec
+		want   string
+	}{
+		{
+			name:   "shipped entry wins",
+			source: &core.DesktopEntry{StartupWMClass: "Shipped", Exec: "app"},
+			spec:   DesktopSpec{PayloadRoot: "/payload", ExecPath: "/bin/app"},
+			want:   "Shipped",
+		},
+		{
+			name:  "other payload entry",
+			files: map[string]string{"/payload/usr/share/applications/other.desktop": "[Desktop Entry]\nName=Other\nStartupWMClass=OtherClass\n"},
+			spec:  DesktopSpec{PayloadRoot: "/payload", ExecPath: "/bin/app"},
+			want:  "OtherClass",
+		},
+		{
+			name:  "electron unpacked package.json",
+			files: map[string]string{"/payload/resources/app/package.json": `{"name":"slack-desktop","productName":"Slack"}`},
+			spec:  DesktopSpec{PayloadRoot: "/payload", ExecPath: "/bin/slack"},
+			want:  "Slack",
+		},
+		{
+			name:  "electron desktopName",
+			files: map[string]string{"/payload/resources/app/package.json": `{"name":"code-oss","productName":"Code - OSS","desktopName":"code-oss.desktop"}`},
+			spec:  DesktopSpec{PayloadRoot: "/payload", ExecPath: "/bin/code"},
+			want:  "code-oss",
+		},
+		{
+			name:   "source exec binary",
+			source: &core.DesktopEntry{Exec: "env FOO=1 /opt/app/real-app %U"},
+			spec:   DesktopSpec{PayloadRoot: "/payload", ExecPath: "/home/test/.local/bin/wrapper"},
+			want:   "real-app",
+		},
+		{
+			name:   "AppRun falls back to exec path",
+			source: &core.DesktopEntry{Exec: "AppRun %U"},
+			spec:   DesktopSpec{ExecPath: "/home/test/.local/bin/tool"},
+			want:   "tool",
+		},
+		{
+			name: "appimage file yields nothing",
+			spec: DesktopSpec{ExecPath: "/home/test/.local/bin/Tool-1.0-x86_64.AppImage"},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			engine, fs, _ := newTestEngine(t, &config.Config{})
+			for path, content := range tt.files {
+				require.NoError(t, afero.WriteFile(fs, path, []byte(content), 0644))
+			}
+			assert.Equal(t, tt.want, engine.inferWMClass(tt.spec, tt.source))
+		})
+	}
+}
+
+func TestEngine_InferWMClass_Asar(t *testing.T) {
+	t.Parallel()
+
+	engine, fs, _ := newTestEngine(t, &config.Config{})
+	archive := buildAsar(t, `{"name":"obsidian","productName":"Obsidian"}`)
+	require.NoError(t, afero.WriteFile(fs, "/payload/resources/app.asar", archive, 0644))
+
+	assert.Equal(t, "Obsidian", engine.inferWMClass(DesktopSpec{PayloadRoot: "/payload", ExecPath: "/bin/obsidian"}, nil))
+
+	require.NoError(t, afero.WriteFile(fs, "/broken/resources/app.asar", archive[:16], 0644))
+	_, ok := readElectronPackage(fs, "/broken")
+	assert.False(t, ok, "a truncated archive is ignored")
+}
+
+func TestComposeDesktopEntry_WMClass(t *testing.T) {
+	t.Parallel()
+
+	spec := DesktopSpec{AppName: "App", FileName: "app", ExecPath: "/bin/app", WMClass: "Inferred"}
+
+	entry, err := ComposeDesktopEntry(nil, spec, core.InstallOptions{}, config.DesktopConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "Inferred", entry.StartupWMClass)
+
+	source := &core.DesktopEntry{Type: "Application", Name: "App", StartupWMClass: "Shipped", SingleMainWindow: true}
+	entry, err = ComposeDesktopEntry(source, spec, core.InstallOptions{}, config.DesktopConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "Shipped", entry.StartupWMClass)
+	assert.True(t, entry.SingleMainWindow)
+}
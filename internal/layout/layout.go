@@ -0,0 +1,83 @@
+// Package layout manages the versioned install layout: every release of a
+// package is unpacked in <apps>/<name>/<release> and <apps>/<name>/current
+// links to the active one. Wrappers launch through the link, so upgrades and
+// rollbacks switch versions by replacing it atomically.
+package layout
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/spf13/afero"
+)
+
+// CurrentLink is the name of the link to the active release
+const CurrentLink = "current"
+
+// CurrentDir returns the path wrappers launch the package installed at root through
+func CurrentDir(root string) string {
+	return filepath.Join(root, CurrentLink)
+}
+
+// Current returns the release the current link of root points at, or ""
+// when root has no versioned layout
+func Current(fs afero.Fs, root string) string {
+	reader, ok := fs.(afero.LinkReader)
+	if !ok {
+		return ""
+	}
+	target, err := reader.ReadlinkIfPossible(CurrentDir(root))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(target)
+}
+
+// ReleaseName returns the directory name of a new release under root: the
+// version when it is known and not taken, fallback otherwise
+func ReleaseName(fs afero.Fs, root, version, fallback string) string {
+	name := helpers.NormalizeFilename(version)
+	if name == "" || name == CurrentLink {
+		return fallback
+	}
+	if _, err := fs.Stat(filepath.Join(root, name)); err == nil {
+		return fallback
+	}
+	return name
+}
+
+// Switch points the current link of root at release, replacing the link in
+// one rename so a running launcher never sees it missing. It returns a
+// function restoring the previous target, or removing the link when there
+// was none.
+func Switch(fs afero.Fs, root, release string) (func() error, error) {
+	linker, ok := fs.(afero.Linker)
+	if !ok {
+		return nil, fmt.Errorf("filesystem does not support symlinks")
+	}
+	if _, err := fs.Stat(filepath.Join(root, release)); err != nil {
+		return nil, fmt.Errorf("release %s: %w", release, err)
+	}
+
+	previous := Current(fs, root)
+	link := CurrentDir(root)
+	staged := link + ".new"
+	_ = fs.Remove(staged)
+	// Relative, so the layout survives moving the data directory
+	if err := linker.SymlinkIfPossible(release, staged); err != nil {
+		return nil, fmt.Errorf("create current link: %w", err)
+	}
+	if err := fs.Rename(staged, link); err != nil {
+		_ = fs.Remove(staged)
+		return nil, fmt.Errorf("switch current link: %w", err)
+	}
+
+	return func() error {
+		if previous == "" {
+			return fs.Remove(link)
+		}
+		_, err := Switch(fs, root, previous)
+		return err
+	}, nil
+}
@@ -0,0 +1,52 @@
+package layout
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwitch(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewOsFs()
+	root := t.TempDir()
+	for _, release := range []string{"1.0.0", "1.1.0"} {
+		require.NoError(t, fs.MkdirAll(filepath.Join(root, release), 0755))
+	}
+	assert.Empty(t, Current(fs, root))
+
+	restoreFirst, err := Switch(fs, root, "1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", Current(fs, root))
+
+	restore, err := Switch(fs, root, "1.1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", Current(fs, root))
+	target, err := os.Readlink(CurrentDir(root))
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", target, "the link is relative")
+
+	require.NoError(t, restore())
+	assert.Equal(t, "1.0.0", Current(fs, root))
+	require.NoError(t, restoreFirst())
+	assert.Empty(t, Current(fs, root))
+
+	_, err = Switch(fs, root, "2.0.0")
+	assert.Error(t, err, "missing releases are rejected")
+}
+
+func TestReleaseName(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, fs.MkdirAll("/apps/tool/1.0.0", 0755))
+
+	assert.Equal(t, "1.1.0", ReleaseName(fs, "/apps/tool", "1.1.0", "tool-1700000000"))
+	assert.Equal(t, "tool-1700000000", ReleaseName(fs, "/apps/tool", "1.0.0", "tool-1700000000"))
+	assert.Equal(t, "tool-1700000000", ReleaseName(fs, "/apps/tool", "", "tool-1700000000"))
+}
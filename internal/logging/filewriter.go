@@ -0,0 +1,161 @@
+package logging
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// lockedFileWriter appends log entries to a file shared by concurrent upkg
+// processes. Every entry is written with a single O_APPEND write while an
+// advisory lock on a sidecar lock file is held, so entries from different
+// processes never interleave. Rotation happens under the same lock; writers
+// in other processes notice the replaced file and reopen it.
+type lockedFileWriter struct {
+	path       string
+	maxSize    int64 // Rotate once the file would grow beyond this many bytes
+	maxBackups int   // Compressed backups to keep (path.1.gz is the newest)
+
+	mu   sync.Mutex
+	file *os.File
+	lock *os.File
+}
+
+func newLockedFileWriter(path string, maxSize int64, maxBackups int) *lockedFileWriter {
+	return &lockedFileWriter{path: path, maxSize: maxSize, maxBackups: maxBackups}
+}
+
+// Write appends one log entry
+func (w *lockedFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.lock == nil {
+		lock, err := os.OpenFile(w.path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+		if err != nil {
+			return 0, fmt.Errorf("open log lock: %w", err)
+		}
+		w.lock = lock
+	}
+
+	if err := syscall.Flock(int(w.lock.Fd()), syscall.LOCK_EX); err != nil {
+		return 0, fmt.Errorf("lock log file: %w", err)
+	}
+	defer func() { _ = syscall.Flock(int(w.lock.Fd()), syscall.LOCK_UN) }()
+
+	if err := w.openCurrent(); err != nil {
+		return 0, err
+	}
+
+	if w.maxSize > 0 {
+		info, err := w.file.Stat()
+		if err != nil {
+			return 0, fmt.Errorf("stat log file: %w", err)
+		}
+		if info.Size() > 0 && info.Size()+int64(len(p)) > w.maxSize {
+			if err := w.rotate(); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return w.file.Write(p)
+}
+
+// Close releases the log file and lock file handles
+func (w *lockedFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	if w.lock != nil {
+		if lockErr := w.lock.Close(); err == nil {
+			err = lockErr
+		}
+		w.lock = nil
+	}
+	return err
+}
+
+// openCurrent (re)opens the log file when it is not open yet or another
+// process rotated it away. Must be called with the lock held.
+func (w *lockedFileWriter) openCurrent() error {
+	if w.file != nil {
+		current, statErr := os.Stat(w.path)
+		open, fstatErr := w.file.Stat()
+		if statErr == nil && fstatErr == nil && os.SameFile(current, open) {
+			return nil
+		}
+		_ = w.file.Close()
+		w.file = nil
+	}
+
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	w.file = file
+	return nil
+}
+
+// rotate compresses the current file into path.1.gz, shifting older backups,
+// and starts a new file. Must be called with the lock held.
+func (w *lockedFileWriter) rotate() error {
+	_ = w.file.Close()
+	w.file = nil
+
+	for i := w.maxBackups; i > 1; i-- {
+		// Missing backups are expected until the retention window fills up
+		_ = os.Rename(w.backupPath(i-1), w.backupPath(i))
+	}
+
+	if w.maxBackups > 0 {
+		if err := compressFile(w.path, w.backupPath(1)); err != nil {
+			return fmt.Errorf("compress rotated log: %w", err)
+		}
+	}
+	if err := os.Remove(w.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove rotated log: %w", err)
+	}
+
+	return w.openCurrent()
+}
+
+func (w *lockedFileWriter) backupPath(n int) string {
+	return fmt.Sprintf("%s.%d.gz", w.path, n)
+}
+
+// compressFile gzips src into dst, replacing dst atomically
+func compressFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	_, copyErr := io.Copy(gz, in)
+	gzErr := gz.Close()
+	closeErr := out.Close()
+	for _, err := range []error{copyErr, gzErr, closeErr} {
+		if err != nil {
+			_ = os.Remove(tmp)
+			return err
+		}
+	}
+
+	return os.Rename(tmp, dst)
+}
@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockedFileWriter_ConcurrentWritersKeepLinesIntact(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "upkg.log")
+
+	// Separate writers stand in for separate processes sharing the file
+	const writers, entries = 4, 200
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		writer := newLockedFileWriter(logFile, 0, 0)
+		t.Cleanup(func() { _ = writer.Close() })
+
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			padding := strings.Repeat("x", 4096)
+			for i := 0; i < entries; i++ {
+				line := fmt.Sprintf("{\"writer\":%d,\"seq\":%d,\"pad\":%q}\n", id, i, padding)
+				_, err := writer.Write([]byte(line))
+				assert.NoError(t, err)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	file, err := os.Open(logFile)
+	require.NoError(t, err)
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024)
+	count := 0
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry), "corrupted line %d", count)
+		count++
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, writers*entries, count)
+}
+
+func TestLockedFileWriter_RotatesAndOtherWritersReopen(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "upkg.log")
+
+	first := newLockedFileWriter(logFile, 100, 2)
+	second := newLockedFileWriter(logFile, 100, 2)
+	t.Cleanup(func() { _ = first.Close(); _ = second.Close() })
+
+	line := []byte(strings.Repeat("a", 59) + "\n")
+	_, err := second.Write(line)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = first.Write(line)
+		require.NoError(t, err)
+	}
+
+	// first rotated the file second still holds open; second must follow it
+	_, err = second.Write([]byte("second\n"))
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(data), 100)
+	assert.Contains(t, string(data), "second")
+
+	assert.FileExists(t, logFile+".1.gz")
+	assert.FileExists(t, logFile+".2.gz")
+	assert.NoFileExists(t, logFile+".3.gz")
+
+	gzFile, err := os.Open(logFile + ".1.gz")
+	require.NoError(t, err)
+	defer gzFile.Close()
+	gz, err := gzip.NewReader(gzFile)
+	require.NoError(t, err)
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		assert.NotEmpty(t, scanner.Text())
+	}
+	require.NoError(t, scanner.Err())
+}
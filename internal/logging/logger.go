@@ -7,9 +7,15 @@ import (
 	"path/filepath"
 	"sync"
 
+	"github.com/quantmind-br/upkg/internal/security"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/pkgerrors"
-	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Log file rotation limits
+const (
+	maxLogFileSize = 10 * 1024 * 1024
+	maxLogBackups  = 3
 )
 
 // Config holds logger configuration
@@ -35,21 +41,17 @@ func NewLogger(cfg Config) *zerolog.Logger {
 	}
 
 	var writers []io.Writer
-	writers = append(writers, consoleWriter)
+	writers = append(writers, newRedactingWriter(consoleWriter))
 
 	// File logger if path provided
 	if cfg.LogFile != "" {
 		// Ensure directory exists
 		dir := filepath.Dir(cfg.LogFile)
 		if err := os.MkdirAll(dir, 0755); err == nil {
-			fileWriter := &lumberjack.Logger{
-				Filename:   cfg.LogFile,
-				MaxSize:    10, // MB
-				MaxBackups: 3,
-				MaxAge:     28, // days
-				Compress:   true,
-			}
-			writers = append(writers, fileWriter)
+			// Several upkg processes may log at once (batch installs, file
+			// manager actions), so writes and rotation go through a file lock
+			fileWriter := newLockedFileWriter(cfg.LogFile, maxLogFileSize, maxLogBackups)
+			writers = append(writers, newRedactingWriter(fileWriter))
 		}
 	}
 
@@ -104,6 +106,25 @@ func (w *progressSafeWriter) Write(p []byte) (int, error) {
 	return n, err
 }
 
+// redactingWriter masks secrets in each log entry before it reaches the underlying writer.
+// zerolog emits one complete entry per Write call, so patterns never span writes.
+type redactingWriter struct {
+	out io.Writer
+}
+
+func newRedactingWriter(out io.Writer) *redactingWriter {
+	return &redactingWriter{out: out}
+}
+
+func (w *redactingWriter) Write(p []byte) (int, error) {
+	redacted := security.Redact(string(p))
+	if _, err := io.WriteString(w.out, redacted); err != nil {
+		return 0, err
+	}
+	// Report the original length so callers don't treat redaction as a short write
+	return len(p), nil
+}
+
 // parseLevel converts string level to zerolog.Level
 func parseLevel(level string) zerolog.Level {
 	switch level {
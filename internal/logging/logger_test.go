@@ -175,3 +175,24 @@ func TestLoggerLevels(t *testing.T) {
 	assert.Contains(t, output, "warn message")
 	assert.Contains(t, output, "error message")
 }
+
+func TestRedactingWriter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(newRedactingWriter(&buf))
+
+	logger.Info().Str("url", "https://example.com/app.tar.gz?token=abc123").Msg("downloading")
+
+	assert.NotContains(t, buf.String(), "abc123")
+	assert.Contains(t, buf.String(), "token=***")
+}
+
+func TestNewLogger_RedactsFileOutput(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "upkg.log")
+	logger := NewLogger(Config{Level: "info", LogFile: logFile, NoColor: true})
+
+	logger.Info().Str("cmd", "tool --password=hunter2").Msg("running")
+
+	content, err := os.ReadFile(logFile)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), "hunter2")
+}
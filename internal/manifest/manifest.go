@@ -0,0 +1,236 @@
+// Package manifest reads the declarative package lists applied with
+// upkg apply and plans the installs, upgrades and removals that bring the
+// install database in line with one.
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/backends/flatpak"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/fetch"
+	"github.com/quantmind-br/upkg/internal/schema"
+	"github.com/quantmind-br/upkg/internal/security"
+	"github.com/spf13/afero"
+)
+
+// Kind is the document kind of manifests
+const Kind = "manifest"
+
+// docSchema versions manifest documents
+var docSchema = schema.New(Kind, 1)
+
+// Manifest is the desired set of packages
+type Manifest struct {
+	Packages []Package `yaml:"packages"`
+}
+
+// Package is one desired package and the options it is installed with
+type Package struct {
+	Name   string `yaml:"name"`
+	Source string `yaml:"source"` // Local path, http(s) URL, gh:owner/repo[@tag] or Flatpak app ID
+	SHA256 string `yaml:"sha256,omitempty"`
+	Method string `yaml:"method,omitempty"` // DEB/RPM install method, as for install --method
+
+	Sandbox        bool     `yaml:"sandbox,omitempty"`
+	HiDPI          bool     `yaml:"hidpi,omitempty"`
+	Desktop        bool     `yaml:"desktop,omitempty"`
+	SkipDesktop    bool     `yaml:"skipDesktop,omitempty"`
+	SkipWaylandEnv bool     `yaml:"skipWaylandEnv,omitempty"`
+	ExposeAllBins  bool     `yaml:"exposeAllBins,omitempty"`
+	Bins           []string `yaml:"bins,omitempty"` // Executables to create launchers for, like install --bin
+	BinDesktops    bool     `yaml:"binDesktops,omitempty"`
+	SelfUpdating   bool     `yaml:"selfUpdating,omitempty"`
+}
+
+// Load reads and validates the manifest at path. Relative local sources are
+// resolved against the manifest's directory and ~ against the home directory.
+func Load(fs afero.Fs, path string) (*Manifest, error) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := docSchema.Load(data, &m); err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest path: %w", err)
+	}
+	for i := range m.Packages {
+		m.Packages[i].Source = resolveSource(m.Packages[i].Source, filepath.Dir(absPath))
+	}
+	return &m, nil
+}
+
+// Marshal encodes m with the manifest kind and schema version
+func Marshal(m *Manifest) ([]byte, error) {
+	return docSchema.Marshal(m)
+}
+
+// Validate checks that every package has a valid, unique name and a source
+func (m *Manifest) Validate() error {
+	seen := make(map[string]bool, len(m.Packages))
+	for i, pkg := range m.Packages {
+		if pkg.Name == "" {
+			return fmt.Errorf("package %d: name is required", i+1)
+		}
+		if err := security.ValidatePackageName(pkg.Name); err != nil {
+			return fmt.Errorf("package %s: %w", pkg.Name, err)
+		}
+		if strings.TrimSpace(pkg.Source) == "" {
+			return fmt.Errorf("package %s: source is required", pkg.Name)
+		}
+		key := strings.ToLower(pkg.Name)
+		if seen[key] {
+			return fmt.Errorf("package %s is listed twice", pkg.Name)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// IsLocal reports whether source names a file on disk rather than a URL,
+// GitHub release or Flatpak app
+func IsLocal(source string) bool {
+	return !fetch.IsURL(source) && !fetch.IsGitHubSpec(source) &&
+		!flatpak.IsFlatpakAppID(source) && !flatpak.IsFlatpakRemoteRef(source)
+}
+
+// resolveSource makes a local source absolute
+func resolveSource(source, baseDir string) string {
+	if !IsLocal(source) {
+		return source
+	}
+	if rest, ok := strings.CutPrefix(source, "~"); ok && (rest == "" || rest[0] == '/') {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	if !filepath.IsAbs(source) {
+		return filepath.Join(baseDir, source)
+	}
+	return filepath.Clean(source)
+}
+
+// Action is what apply does with one package
+type Action string
+
+const (
+	// ActionInstall installs a listed package that is not installed
+	ActionInstall Action = "install"
+	// ActionUpgrade reinstalls a listed package whose source changed
+	ActionUpgrade Action = "upgrade"
+	// ActionKeep leaves an installed, unchanged package alone
+	ActionKeep Action = "keep"
+	// ActionRemove uninstalls a package no longer listed (--prune)
+	ActionRemove Action = "remove"
+	// ActionSkip leaves a pinned package that would be upgraded or removed
+	ActionSkip Action = "skip"
+)
+
+// Change is one step of a plan
+type Change struct {
+	Action  Action
+	Package *Package            // Desired package; nil for removals
+	Record  *core.InstallRecord // Installed package; nil for installs
+	Reason  string              // Why an upgrade is needed or the package is skipped
+}
+
+// Name returns the package name the change applies to
+func (c Change) Name() string {
+	if c.Package != nil {
+		return c.Package.Name
+	}
+	return c.Record.Name
+}
+
+// Plan compares the manifest with the installed records and returns a change
+// per listed package, in manifest order. With prune, packages an earlier
+// apply installed or claimed that are no longer listed are removed as well.
+// Pinned packages are neither upgraded nor removed: they are skipped.
+func Plan(m *Manifest, records []*core.InstallRecord, prune bool) []Change {
+	byName := make(map[string]*core.InstallRecord, len(records))
+	for _, record := range records {
+		byName[strings.ToLower(record.Name)] = record
+	}
+
+	changes := make([]Change, 0, len(m.Packages))
+	listed := make(map[string]bool, len(m.Packages))
+	for i := range m.Packages {
+		pkg := &m.Packages[i]
+		key := strings.ToLower(pkg.Name)
+		listed[key] = true
+
+		record, ok := byName[key]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Action: ActionInstall, Package: pkg})
+		case sourceChange(pkg.Source, record) == "":
+			changes = append(changes, Change{Action: ActionKeep, Package: pkg, Record: record})
+		case record.Metadata.Pinned:
+			changes = append(changes, Change{Action: ActionSkip, Package: pkg, Record: record, Reason: "pinned"})
+		default:
+			changes = append(changes, Change{Action: ActionUpgrade, Package: pkg, Record: record, Reason: sourceChange(pkg.Source, record)})
+		}
+	}
+
+	if prune {
+		for _, record := range records {
+			if !record.Metadata.Manifest || listed[strings.ToLower(record.Name)] {
+				continue
+			}
+			if record.Metadata.Pinned {
+				changes = append(changes, Change{Action: ActionSkip, Record: record, Reason: "pinned"})
+				continue
+			}
+			changes = append(changes, Change{Action: ActionRemove, Record: record})
+		}
+	}
+	return changes
+}
+
+// sourceChange describes how source differs from the one record was
+// installed from, or returns "" when it is the same. GitHub sources without
+// a tag follow the latest release, which check-updates tracks instead.
+func sourceChange(source string, record *core.InstallRecord) string {
+	switch {
+	case fetch.IsGitHubSpec(source):
+		spec, err := fetch.ParseGitHubSpec(source)
+		if err != nil {
+			return ""
+		}
+		if !strings.EqualFold(spec.Repository(), record.Metadata.SourceRepo) {
+			return fmt.Sprintf("source changed to %s", spec)
+		}
+		if spec.Tag != "" && spec.Tag != record.Metadata.SourceTag {
+			return fmt.Sprintf("tag %s → %s", displayTag(record.Metadata.SourceTag), spec.Tag)
+		}
+	case fetch.IsURL(source):
+		if source != record.Metadata.SourceURL {
+			return fmt.Sprintf("source changed to %s", source)
+		}
+	case IsLocal(source):
+		if record.Metadata.SourceURL != "" || record.Metadata.SourceRepo != "" || source != record.OriginalFile {
+			return fmt.Sprintf("source changed to %s", source)
+		}
+	}
+	return ""
+}
+
+// displayTag shows a missing tag as "-"
+func displayTag(tag string) string {
+	if tag == "" {
+		return "-"
+	}
+	return tag
+}
@@ -0,0 +1,135 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/dots/upkg.yaml", []byte(`kind: manifest
+schemaVersion: 1
+packages:
+  - name: obsidian
+    source: gh:obsidianmd/obsidian-releases
+  - name: lens
+    source: pkgs/lens.deb
+    method: extract
+    sandbox: true
+  - name: firefox
+    source: org.mozilla.firefox
+  - name: tool
+    source: /opt/dl/../tool.tar.gz
+`), 0644))
+
+	m, err := Load(fs, "/dots/upkg.yaml")
+	require.NoError(t, err)
+	require.Len(t, m.Packages, 4)
+	assert.Equal(t, "gh:obsidianmd/obsidian-releases", m.Packages[0].Source)
+	assert.Equal(t, Package{Name: "lens", Source: "/dots/pkgs/lens.deb", Method: "extract", Sandbox: true}, m.Packages[1])
+	assert.Equal(t, "org.mozilla.firefox", m.Packages[2].Source)
+	assert.Equal(t, "/opt/tool.tar.gz", m.Packages[3].Source)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		errMsg  string
+	}{
+		{"missing name", "packages:\n  - source: /a.AppImage\n", "name is required"},
+		{"missing source", "packages:\n  - name: a\n", "source is required"},
+		{"bad name", "packages:\n  - name: a/b\n    source: /a.AppImage\n", "invalid package name"},
+		{"duplicate", "packages:\n  - name: a\n    source: /a.AppImage\n  - name: A\n    source: /b.AppImage\n", "listed twice"},
+		{"other kind", "kind: catalog\npackages: []\n", "kind mismatch"},
+		{"newer version", "kind: manifest\nschemaVersion: 9\n", "unsupported schema version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "/m.yaml", []byte(tt.content), 0644))
+			_, err := Load(fs, "/m.yaml")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	in := &Manifest{Packages: []Package{{Name: "app", Source: "/tmp/app.AppImage", HiDPI: true}}}
+	data, err := Marshal(in)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "kind: manifest\nschemaVersion: 1\n")
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/m.yaml", data, 0644))
+	out, err := Load(fs, "/m.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, in, out)
+}
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+
+	m := &Manifest{Packages: []Package{
+		{Name: "new-app", Source: "/pkgs/new.AppImage"},
+		{Name: "Same", Source: "/pkgs/same.AppImage"},
+		{Name: "moved", Source: "/pkgs/moved-2.0.AppImage"},
+		{Name: "latest", Source: "gh:owner/latest"},
+		{Name: "pinned", Source: "gh:owner/pinned@v2.0.0"},
+		{Name: "web", Source: "https://example.com/web.tar.gz"},
+		{Name: "flat", Source: "org.example.Flat"},
+		{Name: "held", Source: "/pkgs/held-2.0.AppImage"},
+	}}
+	records := []*core.InstallRecord{
+		{Name: "same", OriginalFile: "/pkgs/same.AppImage"},
+		{Name: "moved", OriginalFile: "/pkgs/moved-1.0.AppImage"},
+		{Name: "latest", Metadata: core.Metadata{SourceRepo: "owner/latest", SourceTag: "v9"}},
+		{Name: "pinned", Metadata: core.Metadata{SourceRepo: "owner/pinned", SourceTag: "v1.0.0"}},
+		{Name: "web", Metadata: core.Metadata{SourceURL: "https://example.com/web.tar.gz"}},
+		{Name: "flat", OriginalFile: "org.example.Flat"},
+		{Name: "dropped", Metadata: core.Metadata{Manifest: true}},
+		{Name: "held", OriginalFile: "/pkgs/held-1.0.AppImage", Metadata: core.Metadata{Manifest: true, Pinned: true}},
+		{Name: "held-dropped", Metadata: core.Metadata{Manifest: true, Pinned: true}},
+		{Name: "manual"},
+	}
+
+	actions := func(changes []Change) map[string]Action {
+		out := make(map[string]Action, len(changes))
+		for _, change := range changes {
+			out[change.Name()] = change.Action
+		}
+		return out
+	}
+
+	changes := Plan(m, records, false)
+	assert.Equal(t, map[string]Action{
+		"new-app": ActionInstall,
+		"Same":    ActionKeep,
+		"moved":   ActionUpgrade,
+		"latest":  ActionKeep,
+		"pinned":  ActionUpgrade,
+		"web":     ActionKeep,
+		"flat":    ActionKeep,
+		"held":    ActionSkip,
+	}, actions(changes))
+	assert.Equal(t, "pinned", changes[7].Reason)
+	assert.Equal(t, "tag v1.0.0 → v2.0.0", changes[4].Reason)
+
+	// Only packages managed by apply are pruned
+	changes = Plan(m, records, true)
+	assert.Equal(t, ActionRemove, actions(changes)["dropped"])
+	assert.NotContains(t, actions(changes), "manual")
+	assert.Equal(t, ActionSkip, actions(changes)["held-dropped"])
+}
@@ -7,6 +7,9 @@ import (
 	"github.com/quantmind-br/upkg/internal/config"
 )
 
+// SystemPrefix é a raiz de binários, atalhos e ícones no escopo system.
+const SystemPrefix = "/usr/local"
+
 // Resolver centraliza caminhos padrão do upkg.
 // Ele calcula diretórios base a partir de HOME e da configuração.
 type Resolver struct {
@@ -39,32 +42,143 @@ func (r *Resolver) HomeDir() string {
 	return r.homeDir
 }
 
-// GetBinDir retorna ~/.local/bin.
+// IsSystem indica se o Resolver aponta para instalações system-wide (--system).
+func (r *Resolver) IsSystem() bool {
+	return r.cfg.IsSystemScope()
+}
+
+// prefix retorna ~/.local, ou SystemPrefix no escopo system.
+func (r *Resolver) prefix() string {
+	if r.IsSystem() {
+		return SystemPrefix
+	}
+	return filepath.Join(r.homeDir, ".local")
+}
+
+// GetBinDir retorna ~/.local/bin (/usr/local/bin no escopo system).
 func (r *Resolver) GetBinDir() string {
-	return filepath.Join(r.homeDir, ".local", "bin")
+	return filepath.Join(r.prefix(), "bin")
 }
 
-// GetAppsDir retorna ~/.local/share/applications.
+// GetAppsDir retorna ~/.local/share/applications (/usr/local/share/applications no escopo system).
 func (r *Resolver) GetAppsDir() string {
-	return filepath.Join(r.homeDir, ".local", "share", "applications")
+	return filepath.Join(r.prefix(), "share", "applications")
+}
+
+// GetIconsBaseDir retorna ~/.local/share/icons, raiz dos temas de ícones.
+func (r *Resolver) GetIconsBaseDir() string {
+	return filepath.Join(r.prefix(), "share", "icons")
 }
 
 // GetIconsDir retorna ~/.local/share/icons/hicolor.
 func (r *Resolver) GetIconsDir() string {
-	return filepath.Join(r.homeDir, ".local", "share", "icons", "hicolor")
+	return filepath.Join(r.GetIconsBaseDir(), "hicolor")
+}
+
+// GetMetainfoDir retorna ~/.local/share/metainfo, lido por centrais de software.
+func (r *Resolver) GetMetainfoDir() string {
+	return filepath.Join(r.prefix(), "share", "metainfo")
+}
+
+// GetManDir retorna ~/.local/share/man, onde man procura páginas do usuário.
+func (r *Resolver) GetManDir() string {
+	return filepath.Join(r.prefix(), "share", "man")
+}
+
+// GetBashCompletionDir retorna ~/.local/share/bash-completion/completions, carregado sob demanda pelo bash-completion.
+func (r *Resolver) GetBashCompletionDir() string {
+	return filepath.Join(r.prefix(), "share", "bash-completion", "completions")
+}
+
+// GetZshCompletionDir retorna ~/.local/share/zsh/site-functions (precisa estar no fpath).
+func (r *Resolver) GetZshCompletionDir() string {
+	return filepath.Join(r.prefix(), "share", "zsh", "site-functions")
+}
+
+// GetFishCompletionDir retorna ~/.local/share/fish/vendor_completions.d, lido pelo fish.
+func (r *Resolver) GetFishCompletionDir() string {
+	return filepath.Join(r.prefix(), "share", "fish", "vendor_completions.d")
 }
 
 // GetUpkgAppsDir retorna o diretório de apps gerenciados pelo upkg.
 // Por padrão: ~/.local/share/upkg/apps, respeitando cfg.Paths.DataDir se definido.
 func (r *Resolver) GetUpkgAppsDir() string {
-	base := ""
-	if r.cfg != nil {
-		base = r.cfg.Paths.DataDir
+	return filepath.Join(r.dataDir(), "apps")
+}
+
+// GetAppInstallDir retorna o diretório de instalação de um app extraído.
+// No layout versionado ele contém uma release por versão e o link "current".
+func (r *Resolver) GetAppInstallDir(name string) string {
+	return filepath.Join(r.GetUpkgAppsDir(), name)
+}
+
+// GetJournalDir retorna o diretório dos journals de transações em andamento.
+func (r *Resolver) GetJournalDir() string {
+	return filepath.Join(r.dataDir(), "journal")
+}
+
+// GetStatusFile retorna o arquivo de status lido por barras de status.
+func (r *Resolver) GetStatusFile() string {
+	return filepath.Join(r.dataDir(), "status.json")
+}
+
+// GetHistoryFile retorna o log de operações (JSON lines) lido por "upkg history".
+func (r *Resolver) GetHistoryFile() string {
+	return filepath.Join(r.dataDir(), "history.jsonl")
+}
+
+// GetSandboxDir retorna o diretório das homes privadas de apps em sandbox.
+func (r *Resolver) GetSandboxDir() string {
+	return filepath.Join(r.dataDir(), "sandbox")
+}
+
+// GetVersionsDir retorna o diretório das versões anteriores mantidas para rollback.
+func (r *Resolver) GetVersionsDir() string {
+	return filepath.Join(r.dataDir(), "versions")
+}
+
+// GetPluginsDir retorna o diretório dos backends externos (executáveis upkg-backend-<nome>).
+func (r *Resolver) GetPluginsDir() string {
+	return filepath.Join(r.dataDir(), "plugins")
+}
+
+// GetPayloadCacheDir retorna o cache de payloads desinstalados, indexado pelo hash do pacote.
+func (r *Resolver) GetPayloadCacheDir() string {
+	return filepath.Join(r.dataDir(), "payloads")
+}
+
+// GetIntegrityDir retorna o diretório dos manifestos de integridade (hashes dos arquivos de cada instalação).
+func (r *Resolver) GetIntegrityDir() string {
+	return filepath.Join(r.dataDir(), "integrity")
+}
+
+// GetPendingCachesFile retorna o arquivo dos caches de menu e ícones cuja atualização foi adiada (--no-cache-update).
+func (r *Resolver) GetPendingCachesFile() string {
+	return filepath.Join(r.dataDir(), "pending-caches.json")
+}
+
+// GetAutostartDir retorna ~/.config/autostart (/etc/xdg/autostart no escopo system), lido pelas sessões no login.
+func (r *Resolver) GetAutostartDir() string {
+	if r.IsSystem() {
+		return "/etc/xdg/autostart"
+	}
+	return filepath.Join(r.homeDir, ".config", "autostart")
+}
+
+// GetWrapperTemplateFile retorna ~/.config/upkg/wrapper.tmpl, o template opcional dos wrappers.
+func (r *Resolver) GetWrapperTemplateFile() string {
+	return filepath.Join(r.homeDir, ".config", "upkg", "wrapper.tmpl")
+}
+
+// dataDir retorna cfg.Paths.DataDir ou ~/.local/share/upkg.
+func (r *Resolver) dataDir() string {
+	if r.cfg != nil && r.cfg.Paths.DataDir != "" {
+		return r.cfg.Paths.DataDir
 	}
-	if base == "" {
-		base = filepath.Join(r.homeDir, ".local", "share", "upkg")
+	if r.IsSystem() {
+		return config.SystemDataDir
 	}
-	return filepath.Join(base, "apps")
+	return filepath.Join(r.homeDir, ".local", "share", "upkg")
 }
 
 // GetIconSizeDir retorna ~/.local/share/icons/hicolor/{size}/apps.
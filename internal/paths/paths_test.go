@@ -125,6 +125,46 @@ func TestGetUpkgAppsDir(t *testing.T) {
 	}
 }
 
+func TestGetJournalDir(t *testing.T) {
+	resolver := NewResolverWithHome(&config.Config{}, "/home/user")
+	if got, want := resolver.GetJournalDir(), filepath.Join("/home/user", ".local", "share", "upkg", "journal"); got != want {
+		t.Errorf("GetJournalDir() = %q, want %q", got, want)
+	}
+
+	resolver = NewResolverWithHome(&config.Config{Paths: config.PathsConfig{DataDir: "/custom/data"}}, "/home/user")
+	if got, want := resolver.GetJournalDir(), filepath.Join("/custom/data", "journal"); got != want {
+		t.Errorf("GetJournalDir() = %q, want %q", got, want)
+	}
+}
+
+func TestGetSandboxDir(t *testing.T) {
+	resolver := NewResolverWithHome(&config.Config{Paths: config.PathsConfig{DataDir: "/custom/data"}}, "/home/user")
+	if got, want := resolver.GetSandboxDir(), filepath.Join("/custom/data", "sandbox"); got != want {
+		t.Errorf("GetSandboxDir() = %q, want %q", got, want)
+	}
+}
+
+func TestGetVersionsDir(t *testing.T) {
+	resolver := NewResolverWithHome(&config.Config{Paths: config.PathsConfig{DataDir: "/custom/data"}}, "/home/user")
+	if got, want := resolver.GetVersionsDir(), filepath.Join("/custom/data", "versions"); got != want {
+		t.Errorf("GetVersionsDir() = %q, want %q", got, want)
+	}
+}
+
+func TestGetStatusFile(t *testing.T) {
+	resolver := NewResolverWithHome(&config.Config{Paths: config.PathsConfig{DataDir: "/custom/data"}}, "/home/user")
+	if got, want := resolver.GetStatusFile(), filepath.Join("/custom/data", "status.json"); got != want {
+		t.Errorf("GetStatusFile() = %q, want %q", got, want)
+	}
+}
+
+func TestGetHistoryFile(t *testing.T) {
+	resolver := NewResolverWithHome(&config.Config{Paths: config.PathsConfig{DataDir: "/custom/data"}}, "/home/user")
+	if got, want := resolver.GetHistoryFile(), filepath.Join("/custom/data", "history.jsonl"); got != want {
+		t.Errorf("GetHistoryFile() = %q, want %q", got, want)
+	}
+}
+
 func TestGetIconSizeDir(t *testing.T) {
 	cfg := &config.Config{}
 	resolver := NewResolverWithHome(cfg, "/home/user")
@@ -174,3 +214,66 @@ func TestPathConsistency(t *testing.T) {
 		t.Errorf("GetUpkgAppsDir() should be under home directory (or custom DataDir)")
 	}
 }
+
+func TestSystemScope(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.UseSystemScope()
+	resolver := NewResolverWithHome(cfg, "/root")
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"bin", resolver.GetBinDir(), "/usr/local/bin"},
+		{"apps", resolver.GetAppsDir(), "/usr/local/share/applications"},
+		{"icons", resolver.GetIconsDir(), "/usr/local/share/icons/hicolor"},
+		{"metainfo", resolver.GetMetainfoDir(), "/usr/local/share/metainfo"},
+		{"payloads", resolver.GetUpkgAppsDir(), "/opt/upkg/apps"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s dir = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+	if !resolver.IsSystem() {
+		t.Error("IsSystem() = false in the system scope")
+	}
+	if cfg.Paths.DBFile != "/opt/upkg/installed.db" {
+		t.Errorf("DBFile = %q, want /opt/upkg/installed.db", cfg.Paths.DBFile)
+	}
+}
+
+func TestGetPendingCachesFile(t *testing.T) {
+	resolver := NewResolverWithHome(&config.Config{Paths: config.PathsConfig{DataDir: "/custom/data"}}, "/home/user")
+	if got, want := resolver.GetPendingCachesFile(), filepath.Join("/custom/data", "pending-caches.json"); got != want {
+		t.Errorf("GetPendingCachesFile() = %q, want %q", got, want)
+	}
+}
+
+func TestGetAutostartDir(t *testing.T) {
+	resolver := NewResolverWithHome(&config.Config{}, "/home/user")
+	if got, want := resolver.GetAutostartDir(), filepath.Join("/home/user", ".config", "autostart"); got != want {
+		t.Errorf("GetAutostartDir() = %q, want %q", got, want)
+	}
+	system := NewResolverWithHome(&config.Config{Scope: config.ScopeSystem}, "/home/user")
+	if got, want := system.GetAutostartDir(), "/etc/xdg/autostart"; got != want {
+		t.Errorf("GetAutostartDir() system = %q, want %q", got, want)
+	}
+}
+
+func TestGetManAndCompletionDirs(t *testing.T) {
+	resolver := NewResolverWithHome(&config.Config{}, "/home/user")
+	share := filepath.Join("/home/user", ".local", "share")
+	tests := map[string]struct{ got, want string }{
+		"man":  {resolver.GetManDir(), filepath.Join(share, "man")},
+		"bash": {resolver.GetBashCompletionDir(), filepath.Join(share, "bash-completion", "completions")},
+		"zsh":  {resolver.GetZshCompletionDir(), filepath.Join(share, "zsh", "site-functions")},
+		"fish": {resolver.GetFishCompletionDir(), filepath.Join(share, "fish", "vendor_completions.d")},
+	}
+	for name, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s dir = %q, want %q", name, tt.got, tt.want)
+		}
+	}
+}
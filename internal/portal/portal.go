@@ -0,0 +1,264 @@
+// Package portal lets the user choose files through xdg-desktop-portal, so
+// GUI-initiated installs can ask for a package without upkg needing broad
+// filesystem access (the portal grants access to the chosen file only, which
+// is what a sandboxed upkg gets).
+//
+// The portal is a soft dependency: without a session bus or a portal
+// service OpenFile returns ErrUnavailable and callers fall back to paths
+// given on the command line.
+package portal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+var (
+	// ErrUnavailable is returned when there is no session bus or no
+	// xdg-desktop-portal FileChooser on it
+	ErrUnavailable = errors.New("xdg-desktop-portal file chooser not available")
+	// ErrCancelled is returned when the user dismisses the dialog
+	ErrCancelled = errors.New("file selection cancelled")
+)
+
+const (
+	busName       = "org.freedesktop.portal.Desktop"
+	objectPath    = dbus.ObjectPath("/org/freedesktop/portal/desktop")
+	chooserIface  = "org.freedesktop.portal.FileChooser"
+	requestIface  = "org.freedesktop.portal.Request"
+	requestPrefix = "/org/freedesktop/portal/desktop/request/"
+)
+
+// Response codes of org.freedesktop.portal.Request.Response
+const (
+	responseSuccess   = 0
+	responseCancelled = 1
+)
+
+// Filter is a named set of glob patterns shown in the dialog's file type list
+type Filter struct {
+	Name     string
+	Patterns []string
+}
+
+// OpenFileOptions configures the file chooser dialog
+type OpenFileOptions struct {
+	Title       string
+	AcceptLabel string // Label of the confirm button; the portal picks one when empty
+	Multiple    bool
+	Filters     []Filter
+	// ParentWindow identifies the window the dialog is attached to
+	// ("x11:<xid>" or "wayland:<handle>"); empty for none
+	ParentWindow string
+}
+
+// OpenFile shows the portal's file chooser and returns the local paths of
+// the chosen files. It blocks until the user answers or ctx is cancelled.
+func OpenFile(ctx context.Context, opts OpenFileOptions) ([]string, error) {
+	conn, err := dialSession()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	defer func() { _ = conn.Close() }()
+	return openFile(ctx, conn, opts)
+}
+
+// dialSession opens a private connection to a running session bus; it never
+// launches one
+func dialSession() (*dbus.Conn, error) {
+	conn, err := dbus.SessionBusPrivateNoAutoStartup()
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Auth(nil); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	if err := conn.Hello(); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func openFile(ctx context.Context, conn *dbus.Conn, opts OpenFileOptions) ([]string, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	signals := make(chan *dbus.Signal, 8)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+
+	// Subscribe before calling so a quick Response is not missed
+	handle := requestHandle(conn.Names()[0], token)
+	if err := conn.AddMatchSignalContext(ctx, responseMatch(handle)...); err != nil {
+		return nil, contextErr(ctx, fmt.Errorf("subscribe to portal response: %w", err))
+	}
+
+	var returned dbus.ObjectPath
+	call := conn.Object(busName, objectPath).CallWithContext(ctx, chooserIface+".OpenFile", 0,
+		opts.ParentWindow, opts.Title, openFileOptions(opts, token))
+	if call.Err != nil {
+		return nil, contextErr(ctx, portalError(call.Err))
+	}
+	if err := call.Store(&returned); err != nil {
+		return nil, fmt.Errorf("open file chooser: %w", err)
+	}
+	// Portals before 0.9 ignore handle_token and pick their own path
+	if returned != handle {
+		handle = returned
+		if err := conn.AddMatchSignalContext(ctx, responseMatch(handle)...); err != nil {
+			return nil, contextErr(ctx, fmt.Errorf("subscribe to portal response: %w", err))
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case signal, ok := <-signals:
+			if !ok {
+				return nil, errors.New("session bus connection closed")
+			}
+			if signal.Path == handle && signal.Name == requestIface+".Response" {
+				return parseResponse(signal.Body)
+			}
+		}
+	}
+}
+
+// portalFilter and filterPattern marshal as the (sa(us)) filters of OpenFile
+type portalFilter struct {
+	Name     string
+	Patterns []filterPattern
+}
+
+type filterPattern struct {
+	Kind    uint32 // 0 is a glob pattern, 1 a MIME type
+	Pattern string
+}
+
+// openFileOptions builds the a{sv} options of FileChooser.OpenFile
+func openFileOptions(opts OpenFileOptions, token string) map[string]dbus.Variant {
+	options := map[string]dbus.Variant{
+		"handle_token": dbus.MakeVariant(token),
+		"modal":        dbus.MakeVariant(true),
+		"multiple":     dbus.MakeVariant(opts.Multiple),
+	}
+	if opts.AcceptLabel != "" {
+		options["accept_label"] = dbus.MakeVariant(opts.AcceptLabel)
+	}
+	if len(opts.Filters) > 0 {
+		filters := make([]portalFilter, 0, len(opts.Filters))
+		for _, filter := range opts.Filters {
+			patterns := make([]filterPattern, 0, len(filter.Patterns))
+			for _, pattern := range filter.Patterns {
+				patterns = append(patterns, filterPattern{Kind: 0, Pattern: pattern})
+			}
+			filters = append(filters, portalFilter{Name: filter.Name, Patterns: patterns})
+		}
+		options["filters"] = dbus.MakeVariant(filters)
+	}
+	return options
+}
+
+// parseResponse turns the (ua{sv}) body of a Response signal into paths
+func parseResponse(body []any) ([]string, error) {
+	if len(body) < 2 {
+		return nil, errors.New("malformed portal response")
+	}
+	code, _ := body[0].(uint32)
+	switch code {
+	case responseSuccess:
+	case responseCancelled:
+		return nil, ErrCancelled
+	default:
+		return nil, fmt.Errorf("file chooser failed (response %d)", code)
+	}
+
+	results, _ := body[1].(map[string]dbus.Variant)
+	uris, _ := results["uris"].Value().([]string)
+	paths := make([]string, 0, len(uris))
+	for _, uri := range uris {
+		path, err := uriToPath(uri)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 {
+		return nil, ErrCancelled
+	}
+	return paths, nil
+}
+
+// uriToPath converts a file:// URI returned by the portal to a local path
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", fmt.Errorf("invalid URI %q from portal: %w", uri, err)
+	}
+	if u.Scheme != "file" || (u.Host != "" && u.Host != "localhost") {
+		return "", fmt.Errorf("portal returned a non-local file %q", uri)
+	}
+	return u.Path, nil
+}
+
+// requestHandle predicts the Request object path the portal creates for
+// the caller's unique name and handle token
+func requestHandle(uniqueName, token string) dbus.ObjectPath {
+	sender := strings.ReplaceAll(strings.TrimPrefix(uniqueName, ":"), ".", "_")
+	return dbus.ObjectPath(requestPrefix + sender + "/" + token)
+}
+
+// responseMatch matches the Response signal of the request at handle
+func responseMatch(handle dbus.ObjectPath) []dbus.MatchOption {
+	return []dbus.MatchOption{
+		dbus.WithMatchSender(busName),
+		dbus.WithMatchInterface(requestIface),
+		dbus.WithMatchMember("Response"),
+		dbus.WithMatchObjectPath(handle),
+	}
+}
+
+// newToken returns a handle token unique to this request
+func newToken() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate request token: %w", err)
+	}
+	return "upkg_" + hex.EncodeToString(b), nil
+}
+
+// portalError maps bus errors meaning "no portal here" to ErrUnavailable
+func portalError(err error) error {
+	var busErr dbus.Error
+	if errors.As(err, &busErr) {
+		switch busErr.Name {
+		case "org.freedesktop.DBus.Error.ServiceUnknown",
+			"org.freedesktop.DBus.Error.NameHasNoOwner",
+			"org.freedesktop.DBus.Error.UnknownMethod",
+			"org.freedesktop.DBus.Error.UnknownInterface",
+			"org.freedesktop.DBus.Error.UnknownObject":
+			return fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+	}
+	return fmt.Errorf("open file chooser: %w", err)
+}
+
+// contextErr reports cancellation instead of the error it caused
+func contextErr(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
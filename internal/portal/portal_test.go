@@ -0,0 +1,218 @@
+package portal
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBus plays the bus daemon and the portal on one end of a pipe. answer
+// is called with the OpenFile call and writes the messages sent back to it.
+func fakeBus(t *testing.T, answer func(call *dbus.Message, server net.Conn) error) *dbus.Conn {
+	t.Helper()
+	client, server := net.Pipe()
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+
+	go func() {
+		r := bufio.NewReader(server)
+		for _, reply := range []string{"REJECTED EXTERNAL\r\n", "OK 0123456789abcdef\r\n"} {
+			if _, err := r.ReadString('\n'); err != nil {
+				return
+			}
+			if _, err := server.Write([]byte(reply)); err != nil {
+				return
+			}
+		}
+		if line, err := r.ReadString('\n'); err != nil || line != "BEGIN\r\n" {
+			return
+		}
+		for {
+			msg, err := dbus.DecodeMessage(r)
+			if err != nil {
+				return
+			}
+			switch msg.Headers[dbus.FieldMember].Value() {
+			case "Hello":
+				err = writeReply(server, msg, ":1.42")
+			case "AddMatch":
+				err = writeReply(server, msg)
+			case "OpenFile":
+				err = answer(msg, server)
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	c, err := dbus.NewConn(client)
+	require.NoError(t, err)
+	require.NoError(t, c.Auth([]dbus.Auth{dbus.AuthExternal("1000")}))
+	require.NoError(t, c.Hello())
+	return c
+}
+
+// writeReply answers call with body
+func writeReply(w net.Conn, call *dbus.Message, body ...any) error {
+	reply := &dbus.Message{
+		Type:    dbus.TypeMethodReply,
+		Headers: map[dbus.HeaderField]dbus.Variant{dbus.FieldReplySerial: dbus.MakeVariant(call.Serial())},
+		Body:    body,
+	}
+	if len(body) > 0 {
+		reply.Headers[dbus.FieldSignature] = dbus.MakeVariant(dbus.SignatureOf(body...))
+	}
+	return reply.EncodeTo(w, binary.LittleEndian)
+}
+
+// optionsOf returns the a{sv} options of an OpenFile call
+func optionsOf(call *dbus.Message) map[string]dbus.Variant {
+	return call.Body[2].(map[string]dbus.Variant)
+}
+
+// respond answers an OpenFile call with the request handle and then the
+// Response signal
+func respond(call *dbus.Message, server net.Conn, code uint32, uris ...string) error {
+	token := optionsOf(call)["handle_token"].Value().(string)
+	handle := requestHandle(":1.42", token)
+	if err := writeReply(server, call, handle); err != nil {
+		return err
+	}
+
+	body := []any{code, map[string]dbus.Variant{"uris": dbus.MakeVariant(uris)}}
+	signal := &dbus.Message{
+		Type: dbus.TypeSignal,
+		Headers: map[dbus.HeaderField]dbus.Variant{
+			dbus.FieldPath:      dbus.MakeVariant(handle),
+			dbus.FieldInterface: dbus.MakeVariant(requestIface),
+			dbus.FieldMember:    dbus.MakeVariant("Response"),
+			dbus.FieldSender:    dbus.MakeVariant(busName),
+			dbus.FieldSignature: dbus.MakeVariant(dbus.SignatureOf(body...)),
+		},
+		Body: body,
+	}
+	return signal.EncodeTo(server, binary.LittleEndian)
+}
+
+func TestOpenFile(t *testing.T) {
+	t.Parallel()
+
+	calls := make(chan *dbus.Message, 1)
+	c := fakeBus(t, func(m *dbus.Message, server net.Conn) error {
+		calls <- m
+		return respond(m, server, responseSuccess, "file:///home/me/Downloads/My%20App.AppImage")
+	})
+	assert.Equal(t, ":1.42", c.Names()[0])
+
+	paths, err := openFile(context.Background(), c, OpenFileOptions{
+		Title:   "Choose a package",
+		Filters: []Filter{{Name: "Packages", Patterns: []string{"*.deb", "*.rpm"}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/home/me/Downloads/My App.AppImage"}, paths)
+
+	call := <-calls
+	assert.Equal(t, busName, call.Headers[dbus.FieldDestination].Value())
+	assert.Equal(t, chooserIface, call.Headers[dbus.FieldInterface].Value())
+	assert.Equal(t, "Choose a package", call.Body[1])
+	options := optionsOf(call)
+	assert.Equal(t, true, options["modal"].Value())
+	assert.Equal(t, "a(sa(us))", options["filters"].Signature().String())
+	assert.Equal(t, [][]any{{"Packages", [][]any{
+		{uint32(0), "*.deb"},
+		{uint32(0), "*.rpm"},
+	}}}, options["filters"].Value())
+}
+
+func TestOpenFile_Cancelled(t *testing.T) {
+	t.Parallel()
+
+	c := fakeBus(t, func(m *dbus.Message, server net.Conn) error {
+		return respond(m, server, responseCancelled)
+	})
+	_, err := openFile(context.Background(), c, OpenFileOptions{})
+	assert.ErrorIs(t, err, ErrCancelled)
+}
+
+func TestOpenFile_NoPortal(t *testing.T) {
+	t.Parallel()
+
+	c := fakeBus(t, func(m *dbus.Message, server net.Conn) error {
+		reply := &dbus.Message{
+			Type: dbus.TypeError,
+			Headers: map[dbus.HeaderField]dbus.Variant{
+				dbus.FieldReplySerial: dbus.MakeVariant(m.Serial()),
+				dbus.FieldErrorName:   dbus.MakeVariant("org.freedesktop.DBus.Error.ServiceUnknown"),
+				dbus.FieldSignature:   dbus.MakeVariant(dbus.SignatureOf("")),
+			},
+			Body: []any{"The name is not activatable"},
+		}
+		return reply.EncodeTo(server, binary.LittleEndian)
+	})
+	_, err := openFile(context.Background(), c, OpenFileOptions{})
+	assert.ErrorIs(t, err, ErrUnavailable)
+	assert.ErrorContains(t, err, "not activatable")
+}
+
+func TestOpenFile_ContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	// The portal returns the request but never answers, as while the dialog
+	// is open
+	c := fakeBus(t, func(m *dbus.Message, server net.Conn) error {
+		return writeReply(server, m, requestHandle(":1.42", optionsOf(m)["handle_token"].Value().(string)))
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := openFile(ctx, c, OpenFileOptions{})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestOpenFile_NoSessionBus(t *testing.T) {
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "unix:path="+t.TempDir()+"/missing")
+	_, err := OpenFile(context.Background(), OpenFileOptions{})
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+func TestParseResponse(t *testing.T) {
+	t.Parallel()
+
+	_, err := parseResponse([]any{uint32(0)})
+	assert.ErrorContains(t, err, "malformed")
+
+	_, err = parseResponse([]any{uint32(2), map[string]dbus.Variant{}})
+	assert.ErrorContains(t, err, "response 2")
+
+	_, err = parseResponse([]any{uint32(0), map[string]dbus.Variant{"uris": dbus.MakeVariant([]string{"https://example.com/app.deb"})}})
+	assert.ErrorContains(t, err, "non-local")
+}
+
+func TestRequestHandle(t *testing.T) {
+	t.Parallel()
+
+	handle := requestHandle(":1.42", "upkg_token")
+	assert.Equal(t, dbus.ObjectPath("/org/freedesktop/portal/desktop/request/1_42/upkg_token"), handle)
+	assert.True(t, strings.HasPrefix(string(handle), requestPrefix))
+}
+
+func TestURIToPath(t *testing.T) {
+	t.Parallel()
+
+	path, err := uriToPath("file:///run/user/1000/doc/abc/app.deb")
+	require.NoError(t, err)
+	assert.Equal(t, "/run/user/1000/doc/abc/app.deb", path)
+
+	_, err = uriToPath("https://example.com/app.deb")
+	assert.Error(t, err)
+}
@@ -0,0 +1,166 @@
+// Package preview describes what uninstalling an installation would remove:
+// files with their sizes and the system packages affected. It is shared by the
+// uninstall dry-run and any other front end that needs the same report.
+package preview
+
+import (
+	"os"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/spf13/afero"
+)
+
+// Kinds of files removed with an installation
+const (
+	KindPayload    = "payload"
+	KindDesktop    = "desktop_file"
+	KindIcon       = "icon"
+	KindWrapper    = "wrapper"
+	KindExposedBin = "exposed_bin"
+)
+
+// Package managers that remove system-managed installs
+const (
+	ManagerPacman  = "pacman"
+	ManagerDpkg    = "dpkg"
+	ManagerDnf     = "dnf"
+	ManagerFlatpak = "flatpak"
+)
+
+// File is a path that would be removed
+type File struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`
+	Size   int64  `json:"size"`  // Bytes on disk; directories are summed
+	Files  int    `json:"files"` // Files under Path
+	Exists bool   `json:"exists"`
+}
+
+// ExternalPackage is a package removed through a system package manager
+type ExternalPackage struct {
+	Manager string `json:"manager"`
+	Name    string `json:"name"`
+}
+
+// Report is the preview of uninstalling one installation
+type Report struct {
+	InstallID        string            `json:"install_id"`
+	Name             string            `json:"name"`
+	PackageType      core.PackageType  `json:"package_type"`
+	Files            []File            `json:"files"`
+	ExternalPackages []ExternalPackage `json:"external_packages,omitempty"`
+	TotalSize        int64             `json:"total_size"`
+	TotalFiles       int               `json:"total_files"`
+}
+
+// Summary is the preview of uninstalling several installations
+type Summary struct {
+	Packages   []Report `json:"packages"`
+	TotalSize  int64    `json:"total_size"`
+	TotalFiles int      `json:"total_files"`
+}
+
+// Preview reports what uninstalling record would remove
+func Preview(fs afero.Fs, record *core.InstallRecord) Report {
+	report := Report{
+		InstallID:   record.InstallID,
+		Name:        record.Name,
+		PackageType: record.PackageType,
+		Files:       []File{},
+	}
+
+	seen := make(map[string]bool)
+	add := func(path, kind string) {
+		if path == "" || seen[path] {
+			return
+		}
+		seen[path] = true
+
+		file := File{Path: path, Kind: kind}
+		if _, err := fs.Stat(path); err == nil || isSymlink(fs, path) {
+			file.Exists = true
+			if kind == KindPayload {
+				file.Size, file.Files = core.PathSize(fs, path)
+			} else {
+				file.Size, file.Files = entrySize(fs, path)
+			}
+		}
+		report.TotalSize += file.Size
+		report.TotalFiles += file.Files
+		report.Files = append(report.Files, file)
+	}
+
+	add(record.InstallPath, KindPayload)
+	for _, desktopFile := range record.GetDesktopFiles() {
+		add(desktopFile, KindDesktop)
+	}
+	for _, icon := range record.Metadata.IconFiles {
+		add(icon, KindIcon)
+	}
+	add(record.Metadata.WrapperScript, KindWrapper)
+	for _, wrapper := range record.Metadata.ExtraWrappers {
+		add(wrapper, KindWrapper)
+	}
+	for _, bin := range record.Metadata.ExposedBins {
+		add(bin, KindExposedBin)
+	}
+
+	switch {
+	case record.PackageType == core.PackageTypeFlatpak:
+		report.ExternalPackages = []ExternalPackage{{Manager: ManagerFlatpak, Name: record.Name}}
+	case record.Metadata.InstallMethod == core.InstallMethodDpkg:
+		report.ExternalPackages = []ExternalPackage{{Manager: ManagerDpkg, Name: helpers.NormalizeFilename(record.Name)}}
+	case record.Metadata.InstallMethod == core.InstallMethodDnf:
+		report.ExternalPackages = []ExternalPackage{{Manager: ManagerDnf, Name: record.Name}}
+	case pacmanManaged(record):
+		report.ExternalPackages = []ExternalPackage{{Manager: ManagerPacman, Name: helpers.NormalizeFilename(record.Name)}}
+	}
+
+	return report
+}
+
+// pacmanManaged mirrors the DEB and RPM backends: DEB packages are installed
+// with pacman unless recorded as extracted, RPM packages only when recorded so
+func pacmanManaged(record *core.InstallRecord) bool {
+	switch record.PackageType {
+	case core.PackageTypeDeb:
+		return record.Metadata.InstallMethod != core.InstallMethodLocal
+	case core.PackageTypeRpm:
+		return record.Metadata.InstallMethod == core.InstallMethodPacman ||
+			strings.Contains(record.InstallPath, "pacman")
+	default:
+		return false
+	}
+}
+
+// PreviewAll reports what uninstalling records would remove
+func PreviewAll(fs afero.Fs, records []*core.InstallRecord) Summary {
+	summary := Summary{Packages: make([]Report, 0, len(records))}
+	for _, record := range records {
+		report := Preview(fs, record)
+		summary.TotalSize += report.TotalSize
+		summary.TotalFiles += report.TotalFiles
+		summary.Packages = append(summary.Packages, report)
+	}
+	return summary
+}
+
+// entrySize sizes an integration file; symlinks count as the link itself
+func entrySize(fs afero.Fs, path string) (int64, int) {
+	if isSymlink(fs, path) {
+		return 0, 1
+	}
+	return core.PathSize(fs, path)
+}
+
+// isSymlink reports whether path is a (possibly dangling) symlink
+func isSymlink(fs afero.Fs, path string) bool {
+	lstater, ok := fs.(afero.Lstater)
+	if !ok {
+		return false
+	}
+	info, _, err := lstater.LstatIfPossible(path)
+	return err == nil && info.Mode()&os.ModeSymlink != 0
+}
@@ -0,0 +1,111 @@
+package preview
+
+import (
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreview(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/apps/tool/bin/tool", []byte("12345"), 0755))
+	require.NoError(t, afero.WriteFile(fs, "/apps/tool/lib/libtool.so", []byte("123"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/share/applications/tool.desktop", []byte("[Desktop Entry]"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/share/icons/tool.png", []byte("png"), 0644))
+
+	record := &core.InstallRecord{
+		InstallID:   "id-1",
+		Name:        "Tool",
+		PackageType: core.PackageTypeTarball,
+		InstallPath: "/apps/tool",
+		DesktopFile: "/share/applications/tool.desktop",
+		Metadata: core.Metadata{
+			IconFiles:    []string{"/share/icons/tool.png"},
+			DesktopFiles: []string{"/share/applications/tool.desktop"},
+			ExposedBins:  []string{"/bin/tool-cli"},
+		},
+	}
+
+	report := Preview(fs, record)
+	assert.Equal(t, "id-1", report.InstallID)
+	assert.Equal(t, []File{
+		{Path: "/apps/tool", Kind: KindPayload, Size: 8, Files: 2, Exists: true},
+		{Path: "/share/applications/tool.desktop", Kind: KindDesktop, Size: 15, Files: 1, Exists: true},
+		{Path: "/share/icons/tool.png", Kind: KindIcon, Size: 3, Files: 1, Exists: true},
+		{Path: "/bin/tool-cli", Kind: KindExposedBin},
+	}, report.Files, "desktop files are listed once and missing files are kept")
+	assert.Equal(t, int64(26), report.TotalSize)
+	assert.Equal(t, 4, report.TotalFiles)
+	assert.Empty(t, report.ExternalPackages)
+}
+
+func TestPreview_ExternalPackages(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		record *core.InstallRecord
+		want   []ExternalPackage
+	}{
+		{
+			name:   "pacman",
+			record: &core.InstallRecord{Name: "My App", PackageType: core.PackageTypeDeb},
+			want:   []ExternalPackage{{Manager: ManagerPacman, Name: "my-app"}},
+		},
+		{
+			name:   "extracted deb",
+			record: &core.InstallRecord{Name: "app", PackageType: core.PackageTypeDeb, InstallPath: "/apps/app", Metadata: core.Metadata{InstallMethod: core.InstallMethodLocal}},
+		},
+		{
+			name:   "dpkg deb",
+			record: &core.InstallRecord{Name: "vendor-app", PackageType: core.PackageTypeDeb, Metadata: core.Metadata{InstallMethod: core.InstallMethodDpkg}},
+			want:   []ExternalPackage{{Manager: ManagerDpkg, Name: "vendor-app"}},
+		},
+		{
+			name:   "dnf rpm",
+			record: &core.InstallRecord{Name: "Vendor-App", PackageType: core.PackageTypeRpm, Metadata: core.Metadata{InstallMethod: core.InstallMethodDnf}},
+			want:   []ExternalPackage{{Manager: ManagerDnf, Name: "Vendor-App"}},
+		},
+		{
+			name:   "extracted rpm",
+			record: &core.InstallRecord{Name: "app", PackageType: core.PackageTypeRpm, InstallPath: "/apps/app"},
+		},
+		{
+			name:   "flatpak",
+			record: &core.InstallRecord{Name: "org.example.App", PackageType: core.PackageTypeFlatpak},
+			want:   []ExternalPackage{{Manager: ManagerFlatpak, Name: "org.example.App"}},
+		},
+		{
+			name:   "local",
+			record: &core.InstallRecord{Name: "app", PackageType: core.PackageTypeAppImage},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, Preview(afero.NewMemMapFs(), tt.record).ExternalPackages)
+		})
+	}
+}
+
+func TestPreviewAll(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/a", []byte("aa"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/b", []byte("bbb"), 0644))
+
+	summary := PreviewAll(fs, []*core.InstallRecord{
+		{InstallID: "a", InstallPath: "/a"},
+		{InstallID: "b", InstallPath: "/b"},
+	})
+	require.Len(t, summary.Packages, 2)
+	assert.Equal(t, int64(5), summary.TotalSize)
+	assert.Equal(t, 2, summary.TotalFiles)
+}
@@ -0,0 +1,229 @@
+// Package relocate rewrites the paths that installed packages keep into the
+// upkg data directory after that directory is moved.
+package relocate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/spf13/afero"
+)
+
+// Rebase returns path moved from the from directory to the to directory and
+// whether path was inside from
+func Rebase(path, from, to string) (string, bool) {
+	if path == "" || from == "" {
+		return path, false
+	}
+	rel, err := filepath.Rel(from, path)
+	if err != nil || !filepath.IsAbs(path) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path, false
+	}
+	return filepath.Join(to, rel), true
+}
+
+// Record rebases every path of record that points into from and reports
+// whether anything changed
+func Record(record *core.InstallRecord, from, to string) bool {
+	changed := false
+	rebase := func(path *string) {
+		if rebased, ok := Rebase(*path, from, to); ok {
+			*path = rebased
+			changed = true
+		}
+	}
+	rebaseAll := func(paths []string) {
+		for i := range paths {
+			rebase(&paths[i])
+		}
+	}
+
+	rebase(&record.InstallPath)
+	rebase(&record.OriginalFile)
+	rebase(&record.DesktopFile)
+	rebase(&record.Metadata.WrapperScript)
+	rebase(&record.Metadata.OriginalDesktopFile)
+	rebaseAll(record.Metadata.IconFiles)
+	rebaseAll(record.Metadata.DesktopFiles)
+	rebaseAll(record.Metadata.ExposedBins)
+	rebaseAll(record.Metadata.ExtraWrappers)
+	return changed
+}
+
+// IntegrationFiles returns the wrappers, desktop entries and exposed binaries
+// of record, which live outside the data directory but may point into it
+func IntegrationFiles(record *core.InstallRecord) []string {
+	var files []string
+	if record.Metadata.WrapperScript != "" {
+		files = append(files, record.Metadata.WrapperScript)
+	}
+	files = append(files, record.GetDesktopFiles()...)
+	files = append(files, record.Metadata.ExtraWrappers...)
+	files = append(files, record.Metadata.ExposedBins...)
+	return files
+}
+
+// RewriteFile replaces references to from inside a text file, or retargets
+// a symlink that points into from. It returns a function that restores the
+// previous state, or nil when nothing changed.
+func RewriteFile(fs afero.Fs, path, from, to string) (func() error, error) {
+	if restore, handled, err := relink(fs, path, from, to); handled {
+		return restore, err
+	}
+
+	info, err := fs.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("stat %s: %w", path, err)
+	}
+	content, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	updated := ReplacePath(string(content), from, to)
+	if updated == string(content) {
+		return nil, nil
+	}
+	if err := afero.WriteFile(fs, path, []byte(updated), info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("write %s: %w", path, err)
+	}
+	return func() error {
+		return afero.WriteFile(fs, path, content, info.Mode().Perm())
+	}, nil
+}
+
+// ReplacePath replaces from with to in content wherever from is a whole path
+// or the directory of a longer one. Longer names are left alone: /apps/tool-cli
+// is kept when from is /apps/tool.
+func ReplacePath(content, from, to string) string {
+	if from == "" {
+		return content
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(content, from)
+		if i < 0 {
+			b.WriteString(content)
+			return b.String()
+		}
+		end := i + len(from)
+		b.WriteString(content[:i])
+		if end < len(content) && isNameByte(content[end]) {
+			b.WriteString(from)
+		} else {
+			b.WriteString(to)
+		}
+		content = content[end:]
+	}
+}
+
+// isNameByte reports whether c may continue a file name
+func isNameByte(c byte) bool {
+	return c == '-' || c == '_' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// relink retargets a symlink into from; handled is false when path is not a symlink
+func relink(fs afero.Fs, path, from, to string) (restore func() error, handled bool, err error) {
+	lstater, ok := fs.(afero.Lstater)
+	if !ok {
+		return nil, false, nil
+	}
+	info, _, lstatErr := lstater.LstatIfPossible(path)
+	if lstatErr != nil || info.Mode()&os.ModeSymlink == 0 {
+		return nil, false, nil
+	}
+
+	reader, readerOK := fs.(afero.LinkReader)
+	linker, linkerOK := fs.(afero.Linker)
+	if !readerOK || !linkerOK {
+		return nil, true, nil
+	}
+	target, err := reader.ReadlinkIfPossible(path)
+	if err != nil {
+		return nil, true, fmt.Errorf("read link %s: %w", path, err)
+	}
+	rebased, ok := Rebase(target, from, to)
+	if !ok {
+		return nil, true, nil
+	}
+
+	if err := fs.Remove(path); err != nil {
+		return nil, true, fmt.Errorf("remove link %s: %w", path, err)
+	}
+	if err := linker.SymlinkIfPossible(rebased, path); err != nil {
+		return nil, true, fmt.Errorf("relink %s: %w", path, err)
+	}
+	return func() error {
+		if err := fs.Remove(path); err != nil {
+			return err
+		}
+		return linker.SymlinkIfPossible(target, path)
+	}, true, nil
+}
+
+// Problem is a reference that still points at the old location after a move
+type Problem struct {
+	Name   string
+	Path   string
+	Reason string
+}
+
+// Validate checks that payloads moved to to exist and that no record or
+// integration file still refers to from
+func Validate(fs afero.Fs, records []*core.InstallRecord, from, to string) []Problem {
+	var problems []Problem
+	for _, record := range records {
+		switch {
+		case isUnder(record.InstallPath, from):
+			problems = append(problems, Problem{record.Name, record.InstallPath, "install path still points to the old location"})
+		case isUnder(record.InstallPath, to):
+			if _, err := fs.Stat(record.InstallPath); err != nil {
+				problems = append(problems, Problem{record.Name, record.InstallPath, "install path is missing"})
+			}
+		}
+
+		for _, file := range IntegrationFiles(record) {
+			if reason := staleReference(fs, file, from); reason != "" {
+				problems = append(problems, Problem{record.Name, file, reason})
+			}
+		}
+	}
+	return problems
+}
+
+// staleReference explains why file still refers to from, or returns ""
+func staleReference(fs afero.Fs, file, from string) string {
+	if lstater, ok := fs.(afero.Lstater); ok {
+		if info, _, err := lstater.LstatIfPossible(file); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			if reader, ok := fs.(afero.LinkReader); ok {
+				if target, err := reader.ReadlinkIfPossible(file); err == nil {
+					if isUnder(target, from) {
+						return "symlink still points to the old location"
+					}
+				}
+			}
+			return ""
+		}
+	}
+
+	content, err := afero.ReadFile(fs, file)
+	if err != nil {
+		return ""
+	}
+	if strings.Contains(string(content), from+string(filepath.Separator)) {
+		return "still refers to the old location"
+	}
+	return ""
+}
+
+// isUnder reports whether path is dir or inside it
+func isUnder(path, dir string) bool {
+	_, inside := Rebase(path, dir, dir)
+	return inside
+}
@@ -0,0 +1,138 @@
+package relocate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebase(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path   string
+		want   string
+		inside bool
+	}{
+		{"/data/upkg", "/disk/upkg", true},
+		{"/data/upkg/apps/tool", "/disk/upkg/apps/tool", true},
+		{"/data/upkg2/apps", "/data/upkg2/apps", false},
+		{"/data", "/data", false},
+		{"", "", false},
+		{"relative/path", "relative/path", false},
+	}
+
+	for _, tt := range tests {
+		got, inside := Rebase(tt.path, "/data/upkg", "/disk/upkg")
+		assert.Equal(t, tt.want, got, tt.path)
+		assert.Equal(t, tt.inside, inside, tt.path)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+
+	record := &core.InstallRecord{
+		InstallPath:  "/data/apps/tool",
+		OriginalFile: "/downloads/tool.tar.gz",
+		DesktopFile:  "/home/u/.local/share/applications/tool.desktop",
+		Metadata: core.Metadata{
+			WrapperScript: "/home/u/.local/bin/tool",
+			IconFiles:     []string{"/data/apps/tool/icon.png", "/home/u/.local/share/icons/tool.png"},
+		},
+	}
+
+	assert.True(t, Record(record, "/data", "/disk"))
+	assert.Equal(t, "/disk/apps/tool", record.InstallPath)
+	assert.Equal(t, "/downloads/tool.tar.gz", record.OriginalFile)
+	assert.Equal(t, []string{"/disk/apps/tool/icon.png", "/home/u/.local/share/icons/tool.png"}, record.Metadata.IconFiles)
+
+	assert.False(t, Record(record, "/data", "/disk"), "already rebased")
+}
+
+func TestRewriteFile(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	original := "#!/bin/bash\nexec \"/data/apps/tool/tool\" \"$@\"\n# /data2/apps is unrelated\n"
+	require.NoError(t, afero.WriteFile(fs, "/bin/tool", []byte(original), 0755))
+
+	restore, err := RewriteFile(fs, "/bin/tool", "/data", "/disk")
+	require.NoError(t, err)
+	require.NotNil(t, restore)
+
+	content, err := afero.ReadFile(fs, "/bin/tool")
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/bash\nexec \"/disk/apps/tool/tool\" \"$@\"\n# /data2/apps is unrelated\n", string(content))
+	info, err := fs.Stat("/bin/tool")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	require.NoError(t, restore())
+	content, err = afero.ReadFile(fs, "/bin/tool")
+	require.NoError(t, err)
+	assert.Equal(t, original, string(content))
+
+	restore, err = RewriteFile(fs, "/bin/missing", "/data", "/disk")
+	assert.NoError(t, err)
+	assert.Nil(t, restore)
+}
+
+func TestReplacePath(t *testing.T) {
+	t.Parallel()
+
+	content := "Exec=/bin/tool %U\nTryExec=/bin/tool\n--bind \"/bin/tool\" /bin/tool/x /bin/tool-cli /bin/tool.sh\n"
+	assert.Equal(t,
+		"Exec=/bin/editor %U\nTryExec=/bin/editor\n--bind \"/bin/editor\" /bin/editor/x /bin/tool-cli /bin/tool.sh\n",
+		ReplacePath(content, "/bin/tool", "/bin/editor"))
+	assert.Equal(t, content, ReplacePath(content, "", "/bin/editor"))
+}
+
+func TestRewriteFile_Symlink(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	from, to := filepath.Join(dir, "old"), filepath.Join(dir, "new")
+	link := filepath.Join(dir, "helper")
+	require.NoError(t, os.Symlink(filepath.Join(from, "bin", "helper"), link))
+
+	fs := afero.NewOsFs()
+	restore, err := RewriteFile(fs, link, from, to)
+	require.NoError(t, err)
+	require.NotNil(t, restore)
+
+	target, err := os.Readlink(link)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(to, "bin", "helper"), target)
+
+	require.NoError(t, restore())
+	target, err = os.Readlink(link)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(from, "bin", "helper"), target)
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, fs.MkdirAll("/disk/apps/good", 0755))
+	require.NoError(t, afero.WriteFile(fs, "/bin/good", []byte("exec /disk/apps/good/good"), 0755))
+	require.NoError(t, afero.WriteFile(fs, "/bin/stale", []byte("exec /data/apps/stale/stale"), 0755))
+
+	records := []*core.InstallRecord{
+		{Name: "good", InstallPath: "/disk/apps/good", Metadata: core.Metadata{WrapperScript: "/bin/good"}},
+		{Name: "stale", InstallPath: "/data/apps/stale", Metadata: core.Metadata{WrapperScript: "/bin/stale"}},
+		{Name: "lost", InstallPath: "/disk/apps/lost"},
+	}
+
+	assert.Equal(t, []Problem{
+		{"stale", "/data/apps/stale", "install path still points to the old location"},
+		{"stale", "/bin/stale", "still refers to the old location"},
+		{"lost", "/disk/apps/lost", "install path is missing"},
+	}, Validate(fs, records, "/data", "/disk"))
+}
@@ -0,0 +1,174 @@
+// Package remediation maps common failure signatures to actionable advice.
+package remediation
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/backends/appimage"
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/quantmind-br/upkg/internal/syspkg"
+)
+
+// Hint is actionable advice for a recognized failure
+type Hint struct {
+	Problem string   // One-line diagnosis
+	Steps   []string // Actions or commands, in order
+}
+
+// rule recognizes one failure signature and builds its hint
+type rule struct {
+	pattern *regexp.Regexp
+	build   func(match []string, distro string) Hint
+}
+
+var rules = []rule{
+	{
+		pattern: regexp.MustCompile(`(?i)debtap is not initialized`),
+		build: func(_ []string, _ string) Hint {
+			return Hint{
+				Problem: "debtap needs its package database before it can convert .deb files.",
+				Steps:   []string{"sudo debtap -u", "Re-run the install"},
+			}
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)unsquashfs (?:not found|: command not found)|"unsquashfs": executable file not found`),
+		build: func(_ []string, distro string) Hint {
+			return Hint{
+				Problem: "The AppImage could not extract itself and unsquashfs is not installed.",
+				Steps: []string{
+					syspkg.InstallHint(distro, appimage.UnsquashfsTool),
+					"Re-run the install",
+				},
+			}
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)(/\S+) exists in filesystem`),
+		build: func(match []string, _ string) Hint {
+			return Hint{
+				Problem: "pacman refused to overwrite " + match[1] + ", which another package already owns.",
+				Steps: []string{
+					"pacman -Qo " + match[1] + "  # find the owning package",
+					"If it belongs to an older copy of this app, retry with: upkg install --overwrite <package>",
+					"Otherwise remove the owning package first: sudo pacman -R <owner>",
+				},
+			}
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)no executables found`),
+		build: func(_ []string, _ string) Hint {
+			return Hint{
+				Problem: "The package does not contain a Linux executable upkg can launch.",
+				Steps: []string{
+					"Check that you downloaded the Linux build for this CPU (compare with: uname -m)",
+					"Source or library-only archives cannot be installed as applications",
+					"For scripts, mark the file executable (chmod +x <file>) and install it directly",
+				},
+			}
+		},
+	},
+	{
+		// e.g. "open /home/u/.local/bin/app: permission denied"
+		pattern: regexp.MustCompile(`(?i)(/\S*/\.local/(?:bin|share/applications|share/icons))\S*: permission denied`),
+		build: func(match []string, _ string) Hint {
+			dir := match[1]
+			return Hint{
+				Problem: dir + " is not writable by your user (often created by a sudo install).",
+				Steps: []string{
+					"ls -ld " + dir + "  # check the owner",
+					`sudo chown -R "$USER": ` + dir,
+					"Re-run the command",
+				},
+			}
+		},
+	},
+}
+
+// For returns remediation advice for err on the given distro family
+func For(err error, distro string) (Hint, bool) {
+	if err == nil {
+		return Hint{}, false
+	}
+
+	if hint, ok := forTyped(err); ok {
+		return hint, true
+	}
+
+	msg := err.Error()
+	for _, r := range rules {
+		if match := r.pattern.FindStringSubmatch(msg); match != nil {
+			return r.build(match, distro), true
+		}
+	}
+	return Hint{}, false
+}
+
+// forTyped builds advice from the typed errors returned by the backends
+func forTyped(err error) (Hint, bool) {
+	var missing *core.ErrMissingTool
+	if errors.As(err, &missing) {
+		install := missing.InstallHint
+		if install == "" {
+			install = "Install " + missing.Tool + " with your distribution's package manager"
+		}
+		return Hint{
+			Problem: missing.Tool + " is required but not installed.",
+			Steps:   []string{install, "Re-run the command"},
+		}, true
+	}
+
+	var installed *core.ErrAlreadyInstalled
+	if errors.As(err, &installed) {
+		return Hint{
+			Problem: "A copy of this package is already installed at " + installed.Path + ".",
+			Steps: []string{
+				"Reinstall over it: add --force to the install command",
+				"Or move to the new version keeping your settings: upkg upgrade <name> <package>",
+			},
+		}, true
+	}
+
+	var unsupported *core.ErrUnsupportedFormat
+	if errors.As(err, &unsupported) {
+		return unsupportedHint(unsupported), true
+	}
+
+	return Hint{}, false
+}
+
+// unsupportedHint points at the supported formats, or at the right tool
+// when the file is a Flatpak, a Snap or a bare script
+func unsupportedHint(err *core.ErrUnsupportedFormat) Hint {
+	switch strings.ToLower(filepath.Ext(err.Path)) {
+	case ".flatpak", ".flatpakref", ".flatpakrepo":
+		return Hint{
+			Problem: "This looks like a Flatpak package.",
+			Steps:   []string{"flatpak install " + err.Path},
+		}
+	case ".snap":
+		return Hint{
+			Problem: "This looks like a Snap package.",
+			Steps:   []string{"sudo snap install " + err.Path},
+		}
+	}
+
+	if err.FileType == "shell script" || err.FileType == "text" {
+		return Hint{
+			Problem: "Shell scripts and text files are not supported as standalone packages.",
+			Steps:   []string{"Package the script in a tarball (.tar.gz) with any required assets and install that"},
+		}
+	}
+
+	return Hint{
+		Problem: "upkg does not recognize this file as a package.",
+		Steps: []string{
+			"Supported: AppImage, DEB, RPM, tarballs (.tar.gz, .tar.xz, .tar.bz2, .tar.zst, .tar.lz4, .tgz), .zip, .7z and ELF binaries",
+			"Check that the download completed and is the Linux build (file <package> shows what it is)",
+		},
+	}
+}
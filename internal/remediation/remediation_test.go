@@ -0,0 +1,126 @@
+package remediation
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		err         error
+		distro      string
+		wantProblem string
+		wantStep    string
+	}{
+		{
+			name:        "debtap uninitialized",
+			err:         errors.New("debtap is not initialized (run: sudo debtap -u)"),
+			wantProblem: "debtap",
+			wantStep:    "sudo debtap -u",
+		},
+		{
+			name:        "missing unsquashfs on arch",
+			err:         errors.New("extraction failed and unsquashfs not found: exit status 1"),
+			distro:      core.DistroArch,
+			wantProblem: "unsquashfs",
+			wantStep:    "sudo pacman -S --needed squashfs-tools",
+		},
+		{
+			name:        "missing unsquashfs on suse",
+			err:         errors.New("extraction failed and unsquashfs not found: exit status 1"),
+			distro:      core.DistroSUSE,
+			wantProblem: "unsquashfs",
+			wantStep:    "sudo zypper install squashfs",
+		},
+		{
+			name:        "pacman conflict",
+			err:         errors.New("pacman installation failed: command \"sudo\" failed: exit status 1\nstderr: error: failed to commit transaction (conflicting files)\nfoo: /usr/bin/foo exists in filesystem"),
+			wantProblem: "/usr/bin/foo",
+			wantStep:    "pacman -Qo /usr/bin/foo",
+		},
+		{
+			name:        "no executables",
+			err:         fmt.Errorf("installation failed: %w", errors.New("no executables found in archive")),
+			wantProblem: "Linux executable",
+			wantStep:    "uname -m",
+		},
+		{
+			name:        "local bin not writable",
+			err:         errors.New("create symlink: symlink /opt/app /home/u/.local/bin/app: permission denied"),
+			wantProblem: "/home/u/.local/bin is not writable",
+			wantStep:    `sudo chown -R "$USER": /home/u/.local/bin`,
+		},
+		{
+			name:        "missing tool with hint",
+			err:         fmt.Errorf("installation failed: %w", &core.ErrMissingTool{Tool: "debtap", InstallHint: "yay -S debtap"}),
+			wantProblem: "debtap is required",
+			wantStep:    "yay -S debtap",
+		},
+		{
+			name:        "missing tool without hint",
+			err:         &core.ErrMissingTool{Tool: "bsdtar"},
+			wantProblem: "bsdtar",
+			wantStep:    "Install bsdtar with your distribution's package manager",
+		},
+		{
+			name:        "already installed",
+			err:         fmt.Errorf("installation failed: %w", &core.ErrAlreadyInstalled{Path: "/home/u/.local/share/upkg/apps/foo"}),
+			wantProblem: "/home/u/.local/share/upkg/apps/foo",
+			wantStep:    "--force",
+		},
+		{
+			name:        "flatpak file",
+			err:         &core.ErrUnsupportedFormat{Path: "app.flatpakref"},
+			wantProblem: "Flatpak",
+			wantStep:    "flatpak install app.flatpakref",
+		},
+		{
+			name:        "snap file",
+			err:         &core.ErrUnsupportedFormat{Path: "app.snap"},
+			wantProblem: "Snap",
+			wantStep:    "sudo snap install app.snap",
+		},
+		{
+			name:        "shell script",
+			err:         &core.ErrUnsupportedFormat{Path: "install.sh", FileType: "shell script"},
+			wantProblem: "Shell scripts",
+			wantStep:    "tarball",
+		},
+		{
+			name:        "unknown format",
+			err:         &core.ErrUnsupportedFormat{Path: "notes.pdf"},
+			wantProblem: "does not recognize",
+			wantStep:    "AppImage, DEB, RPM",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			hint, ok := For(tt.err, tt.distro)
+			require.True(t, ok)
+			assert.Contains(t, hint.Problem, tt.wantProblem)
+			assert.Contains(t, strings.Join(hint.Steps, "\n"), tt.wantStep)
+		})
+	}
+}
+
+func TestFor_Unrecognized(t *testing.T) {
+	t.Parallel()
+
+	_, ok := For(errors.New("open /etc/shadow: permission denied"), core.DistroArch)
+	assert.False(t, ok)
+
+	_, ok = For(nil, core.DistroArch)
+	assert.False(t, ok)
+}
@@ -0,0 +1,262 @@
+// Package rpmfile reads RPM packages without the rpm tools: the header tags
+// upkg records (name, version, summary) and the compressed cpio payload.
+//
+// An RPM file is a 96-byte lead, a signature header padded to 8 bytes, the
+// main header and the payload. Both headers share one layout: an 8-byte
+// magic, the index entry count and data size, the index entries and the data
+// store they point into.
+package rpmfile
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+var (
+	// ErrNotRPM is returned for files without the RPM lead magic
+	ErrNotRPM = errors.New("not an RPM package")
+	// ErrCorrupt is returned when a header is truncated or inconsistent
+	ErrCorrupt = errors.New("corrupt RPM package")
+	// ErrUnsupportedCompression is returned for payloads compressed with a
+	// format upkg cannot decode itself (zstd, lzip)
+	ErrUnsupportedCompression = errors.New("unsupported RPM payload compression")
+)
+
+const (
+	leadSize = 96
+
+	// Sanity limits for header tables, far above real packages
+	maxIndexEntries = 1 << 16
+	maxStoreSize    = 256 << 20
+)
+
+var (
+	leadMagic   = []byte{0xed, 0xab, 0xee, 0xdb}
+	headerMagic = []byte{0x8e, 0xad, 0xe8, 0x01}
+)
+
+// Header tags read from the main header
+const (
+	tagName              = 1000
+	tagVersion           = 1001
+	tagRelease           = 1002
+	tagSummary           = 1004
+	tagDescription       = 1005
+	tagPayloadFormat     = 1124
+	tagPayloadCompressor = 1125
+)
+
+// Header entry types holding strings
+const (
+	typeString      = 6
+	typeStringArray = 8
+	typeI18NString  = 9
+)
+
+// Package holds the header fields of an RPM file and where its payload starts
+type Package struct {
+	Name        string
+	Version     string
+	Release     string
+	Summary     string
+	Description string
+	// Compressor is the payload compression ("gzip", "xz", "zstd", ...)
+	Compressor string
+
+	path          string
+	payloadFormat string
+	payloadOffset int64
+}
+
+// Open reads the headers of the RPM file at path
+func Open(path string) (*Package, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open package: %w", err)
+	}
+	defer file.Close()
+
+	pkg, err := readHeaders(bufio.NewReader(file))
+	if err != nil {
+		return nil, err
+	}
+	pkg.path = path
+	return pkg, nil
+}
+
+// FullVersion returns version-release, or the version alone without a release
+func (p *Package) FullVersion() string {
+	if p.Release == "" {
+		return p.Version
+	}
+	return p.Version + "-" + p.Release
+}
+
+// Payload returns the decompressed cpio payload. ErrUnsupportedCompression
+// is returned for compressors upkg cannot decode.
+func (p *Package) Payload() (io.ReadCloser, error) {
+	return p.PayloadWithProgress(nil)
+}
+
+// PayloadWithProgress is Payload, calling onProgress with the compressed
+// payload bytes read so far out of their total
+func (p *Package) PayloadWithProgress(onProgress helpers.ProgressFunc) (io.ReadCloser, error) {
+	if p.payloadFormat != "" && p.payloadFormat != "cpio" {
+		return nil, fmt.Errorf("%w: payload format %q", ErrUnsupportedCompression, p.payloadFormat)
+	}
+
+	file, err := os.Open(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("open package: %w", err)
+	}
+	if _, err := file.Seek(p.payloadOffset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("seek to payload: %w", err)
+	}
+
+	var size int64
+	if info, statErr := file.Stat(); statErr == nil {
+		size = info.Size() - p.payloadOffset
+	}
+	r, err := decompressor(p.Compressor, bufio.NewReader(helpers.NewProgressReader(file, size, onProgress)))
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &payloadReader{Reader: r, file: file}, nil
+}
+
+// payloadReader closes the package file along with the payload stream
+type payloadReader struct {
+	io.Reader
+	file *os.File
+}
+
+func (p *payloadReader) Close() error {
+	return p.file.Close()
+}
+
+func decompressor(name string, r io.Reader) (io.Reader, error) {
+	switch name {
+	case "", "gzip":
+		gzr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: gzip payload: %w", ErrCorrupt, err)
+		}
+		return gzr, nil
+	case "bzip2":
+		return bzip2.NewReader(r), nil
+	case "xz":
+		xzr, err := xz.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: xz payload: %w", ErrCorrupt, err)
+		}
+		return xzr, nil
+	case "lzma":
+		lr, err := lzma.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: lzma payload: %w", ErrCorrupt, err)
+		}
+		return lr, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCompression, name)
+	}
+}
+
+// readHeaders parses the lead, skips the signature header and reads the
+// main header
+func readHeaders(r *bufio.Reader) (*Package, error) {
+	lead := make([]byte, leadSize)
+	if _, err := io.ReadFull(r, lead); err != nil || !bytes.Equal(lead[:4], leadMagic) {
+		return nil, ErrNotRPM
+	}
+	offset := int64(leadSize)
+
+	// Signature header, padded to a multiple of 8 bytes
+	_, size, err := readHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("signature header: %w", err)
+	}
+	offset += size
+	if pad := (8 - size%8) % 8; pad > 0 {
+		if _, err := r.Discard(int(pad)); err != nil {
+			return nil, fmt.Errorf("%w: signature header: %w", ErrCorrupt, err)
+		}
+		offset += pad
+	}
+
+	tags, size, err := readHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("main header: %w", err)
+	}
+	offset += size
+
+	return &Package{
+		Name:          tags[tagName],
+		Version:       tags[tagVersion],
+		Release:       tags[tagRelease],
+		Summary:       tags[tagSummary],
+		Description:   tags[tagDescription],
+		Compressor:    tags[tagPayloadCompressor],
+		payloadFormat: tags[tagPayloadFormat],
+		payloadOffset: offset,
+	}, nil
+}
+
+// readHeader reads one header structure and returns its string tags (the
+// first value of arrays) and its size in bytes
+func readHeader(r io.Reader) (map[int32]string, int64, error) {
+	intro := make([]byte, 16)
+	if _, err := io.ReadFull(r, intro); err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+	if !bytes.Equal(intro[:4], headerMagic) {
+		return nil, 0, fmt.Errorf("%w: bad header magic", ErrCorrupt)
+	}
+	count := binary.BigEndian.Uint32(intro[8:12])
+	storeSize := binary.BigEndian.Uint32(intro[12:16])
+	if count > maxIndexEntries || storeSize > maxStoreSize {
+		return nil, 0, fmt.Errorf("%w: header too large", ErrCorrupt)
+	}
+
+	index := make([]byte, int(count)*16)
+	if _, err := io.ReadFull(r, index); err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+	store := make([]byte, storeSize)
+	if _, err := io.ReadFull(r, store); err != nil {
+		return nil, 0, fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+
+	tags := make(map[int32]string)
+	for i := 0; i < int(count); i++ {
+		entry := index[i*16 : (i+1)*16]
+		tag := int32(binary.BigEndian.Uint32(entry[0:4]))
+		kind := binary.BigEndian.Uint32(entry[4:8])
+		offset := binary.BigEndian.Uint32(entry[8:12])
+		if kind != typeString && kind != typeStringArray && kind != typeI18NString {
+			continue
+		}
+		if offset >= storeSize {
+			return nil, 0, fmt.Errorf("%w: tag %d points outside the header", ErrCorrupt, tag)
+		}
+		value := store[offset:]
+		if end := bytes.IndexByte(value, 0); end >= 0 {
+			value = value[:end]
+		}
+		tags[tag] = string(value)
+	}
+
+	return tags, int64(16 + len(index) + len(store)), nil
+}
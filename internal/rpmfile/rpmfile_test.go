@@ -0,0 +1,153 @@
+package rpmfile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ulikunitz/xz"
+)
+
+// headerBytes encodes string tags as an RPM header structure
+func headerBytes(tags map[int32]string) []byte {
+	keys := make([]int32, 0, len(tags))
+	for tag := range tags {
+		keys = append(keys, tag)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	var index, store bytes.Buffer
+	for _, tag := range keys {
+		kind := uint32(typeString)
+		if tag == tagSummary || tag == tagDescription {
+			kind = typeI18NString
+		}
+		_ = binary.Write(&index, binary.BigEndian, []uint32{uint32(tag), kind, uint32(store.Len()), 1})
+		store.WriteString(tags[tag] + "\x00")
+	}
+
+	var out bytes.Buffer
+	out.Write(headerMagic)
+	out.Write(make([]byte, 4))
+	_ = binary.Write(&out, binary.BigEndian, []uint32{uint32(len(keys)), uint32(store.Len())})
+	out.Write(index.Bytes())
+	out.Write(store.Bytes())
+	return out.Bytes()
+}
+
+// writeRPM writes an RPM file with the given main header tags and payload
+func writeRPM(t *testing.T, tags map[int32]string, payload []byte) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	lead := make([]byte, leadSize)
+	copy(lead, leadMagic)
+	buf.Write(lead)
+
+	// A signature header whose size needs padding to 8 bytes
+	signature := headerBytes(map[int32]string{1000: "abc"})
+	buf.Write(signature)
+	for buf.Len()%8 != 0 {
+		buf.WriteByte(0)
+	}
+	buf.Write(headerBytes(tags))
+	buf.Write(payload)
+
+	path := filepath.Join(t.TempDir(), "test.rpm")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+	return path
+}
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+
+	cpio := []byte("070701 cpio stream")
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, err := gw.Write(cpio)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	var xzBuf bytes.Buffer
+	xw, err := xz.NewWriter(&xzBuf)
+	require.NoError(t, err)
+	_, err = xw.Write(cpio)
+	require.NoError(t, err)
+	require.NoError(t, xw.Close())
+
+	tests := []struct {
+		name       string
+		compressor string
+		payload    []byte
+	}{
+		{"gzip", "gzip", gz.Bytes()},
+		{"default gzip", "", gz.Bytes()},
+		{"xz", "xz", xzBuf.Bytes()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tags := map[int32]string{
+				tagName:          "notes",
+				tagVersion:       "2.4.1",
+				tagRelease:       "3.fc40",
+				tagSummary:       "Markdown note-taking app",
+				tagDescription:   "Notes keeps your notes.",
+				tagPayloadFormat: "cpio",
+			}
+			if tt.compressor != "" {
+				tags[tagPayloadCompressor] = tt.compressor
+			}
+
+			pkg, err := Open(writeRPM(t, tags, tt.payload))
+			require.NoError(t, err)
+			assert.Equal(t, "notes", pkg.Name)
+			assert.Equal(t, "2.4.1-3.fc40", pkg.FullVersion())
+			assert.Equal(t, "Markdown note-taking app", pkg.Summary)
+			assert.Equal(t, "Notes keeps your notes.", pkg.Description)
+
+			payload, err := pkg.Payload()
+			require.NoError(t, err)
+			defer payload.Close()
+			got, err := io.ReadAll(payload)
+			require.NoError(t, err)
+			assert.Equal(t, cpio, got)
+		})
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not an rpm", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "plain.rpm")
+		require.NoError(t, os.WriteFile(path, []byte("plain text"), 0644))
+		_, err := Open(path)
+		assert.ErrorIs(t, err, ErrNotRPM)
+	})
+
+	t.Run("truncated header", func(t *testing.T) {
+		path := writeRPM(t, map[int32]string{tagName: "notes"}, nil)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data[:len(data)-4], 0644))
+		_, err = Open(path)
+		assert.ErrorIs(t, err, ErrCorrupt)
+	})
+
+	t.Run("zstd payload", func(t *testing.T) {
+		pkg, err := Open(writeRPM(t, map[int32]string{tagName: "notes", tagPayloadCompressor: "zstd"}, []byte("zstd")))
+		require.NoError(t, err)
+		assert.Equal(t, "notes", pkg.Name)
+		_, err = pkg.Payload()
+		assert.ErrorIs(t, err, ErrUnsupportedCompression)
+		assert.ErrorContains(t, err, "zstd")
+	})
+}
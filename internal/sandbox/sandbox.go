@@ -0,0 +1,206 @@
+// Package sandbox builds the command lines that run an installed app inside
+// bubblewrap or firejail. Wrapper scripts generated with install --sandbox
+// prefix the app's executable with them.
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/helpers"
+)
+
+// Sandbox tools selectable with sandbox.tool
+const (
+	ToolAuto     = "auto"
+	ToolBwrap    = "bwrap"
+	ToolFirejail = "firejail"
+)
+
+// Device classes selectable with sandbox.devices
+const (
+	DeviceGPU    = "gpu"
+	DeviceAudio  = "audio"
+	DeviceCamera = "camera"
+	DeviceInput  = "input"
+	DeviceAll    = "all" // Share the whole /dev
+)
+
+// ErrUnavailable is returned when no supported sandbox tool is installed
+var ErrUnavailable = errors.New("no sandbox tool found (install bubblewrap or firejail)")
+
+// deviceNodes are the /dev entries bwrap binds for each device class; missing
+// ones are skipped at launch
+var deviceNodes = map[string][]string{
+	DeviceGPU:    {"/dev/dri", "/dev/nvidiactl", "/dev/nvidia0", "/dev/nvidia-modeset", "/dev/nvidia-uvm"},
+	DeviceAudio:  {"/dev/snd"},
+	DeviceCamera: {"/dev/video0", "/dev/video1", "/dev/video2", "/dev/video3"},
+	DeviceInput:  {"/dev/input", "/dev/uinput"},
+}
+
+// firejailDeviceFlags hide a device class from firejail sandboxes
+var firejailDeviceFlags = map[string]string{
+	DeviceGPU:    "--no3d",
+	DeviceAudio:  "--nosound",
+	DeviceCamera: "--novideo",
+	DeviceInput:  "--noinput",
+}
+
+// Profile describes what a sandboxed app may access. Everything outside the
+// home directory is visible read-only.
+type Profile struct {
+	HomeDir string   // Private home directory; empty shares the user's home
+	Expose  []string // Paths kept visible read-only inside a private home (the install dir)
+	Network bool     // Allow network access
+	Devices []string // Device classes passed through (gpu, audio, camera, input or all)
+	Env     []string // KEY=VALUE pairs set inside the sandbox
+}
+
+// ProfileFor builds the profile of the package name from cfg. A private home
+// lives in <homesDir>/<name>.
+func ProfileFor(cfg config.SandboxConfig, homesDir, name string, expose ...string) Profile {
+	profile := Profile{
+		Network: cfg.Network,
+		Devices: cfg.Devices,
+		Expose:  expose,
+	}
+	if cfg.IsolateHome {
+		profile.HomeDir = filepath.Join(homesDir, name)
+	}
+	return profile
+}
+
+// Resolve returns the sandbox tool to use: tool itself when it is installed,
+// or for auto bwrap and then firejail
+func Resolve(runner helpers.CommandRunner, tool string) (string, error) {
+	switch tool {
+	case "", ToolAuto:
+		for _, candidate := range []string{ToolBwrap, ToolFirejail} {
+			if runner.CommandExists(candidate) {
+				return candidate, nil
+			}
+		}
+		return "", ErrUnavailable
+	case ToolBwrap, ToolFirejail:
+		if !runner.CommandExists(tool) {
+			return "", fmt.Errorf("%w: %s is not installed", ErrUnavailable, tool)
+		}
+		return tool, nil
+	default:
+		return "", fmt.Errorf("unknown sandbox tool %q (want %s, %s or %s)", tool, ToolAuto, ToolBwrap, ToolFirejail)
+	}
+}
+
+// Command returns the words that launch a program inside tool's sandbox,
+// ending with "--" so the program and its arguments can follow. Words are
+// quoted for a bash script and may expand $HOME and $XDG_RUNTIME_DIR when
+// the script runs.
+func Command(tool string, profile Profile) ([]string, error) {
+	for _, device := range profile.Devices {
+		if _, ok := deviceNodes[device]; !ok && device != DeviceAll {
+			return nil, fmt.Errorf("unknown sandbox device %q (want %s, %s, %s, %s or %s)",
+				device, DeviceGPU, DeviceAudio, DeviceCamera, DeviceInput, DeviceAll)
+		}
+	}
+	for _, env := range profile.Env {
+		if key, _, ok := strings.Cut(env, "="); !ok || key == "" {
+			return nil, fmt.Errorf("invalid sandbox environment variable %q", env)
+		}
+	}
+
+	switch tool {
+	case ToolBwrap:
+		return bwrapCommand(profile), nil
+	case ToolFirejail:
+		return firejailCommand(profile), nil
+	default:
+		return nil, fmt.Errorf("unknown sandbox tool %q", tool)
+	}
+}
+
+// bwrapCommand mounts the host read-only with fresh /dev, /proc and /tmp,
+// then binds back the home directory (or the private one), the display and
+// session sockets and the allowed devices
+func bwrapCommand(profile Profile) []string {
+	args := []string{
+		"bwrap",
+		"--ro-bind", "/", "/",
+		"--dev", "/dev",
+		"--proc", "/proc",
+		"--tmpfs", "/tmp",
+		"--ro-bind-try", "/tmp/.X11-unix", "/tmp/.X11-unix",
+		"--bind-try", `"${XDG_RUNTIME_DIR:-/run/user/$(id -u)}"`, `"${XDG_RUNTIME_DIR:-/run/user/$(id -u)}"`,
+	}
+
+	if profile.HomeDir == "" {
+		args = append(args, "--bind", `"$HOME"`, `"$HOME"`)
+	} else {
+		args = append(args, "--bind", quote(profile.HomeDir), `"$HOME"`)
+		for _, path := range profile.Expose {
+			args = append(args, "--ro-bind", quote(path), quote(path))
+		}
+	}
+
+	args = append(args, "--unshare-pid", "--die-with-parent")
+	if !profile.Network {
+		args = append(args, "--unshare-net")
+	}
+
+	if slices.Contains(profile.Devices, DeviceAll) {
+		args = append(args, "--dev-bind", "/dev", "/dev")
+	} else {
+		for _, device := range profile.Devices {
+			for _, node := range deviceNodes[device] {
+				args = append(args, "--dev-bind-try", node, node)
+			}
+		}
+	}
+
+	for _, env := range profile.Env {
+		key, value, _ := strings.Cut(env, "=")
+		args = append(args, "--setenv", key, quote(value))
+	}
+
+	return append(args, "--")
+}
+
+// firejailCommand points HOME at the private home and whitelists it, since
+// firejail cannot mount a directory over the home while keeping the install
+// directory visible
+func firejailCommand(profile Profile) []string {
+	args := []string{"firejail", "--quiet"}
+
+	if profile.HomeDir != "" {
+		args = append(args, quote("--whitelist="+profile.HomeDir), quote("--env=HOME="+profile.HomeDir))
+		for _, path := range profile.Expose {
+			args = append(args, quote("--whitelist="+path), quote("--read-only="+path))
+		}
+	}
+
+	if !profile.Network {
+		args = append(args, "--net=none")
+	}
+
+	if !slices.Contains(profile.Devices, DeviceAll) {
+		for _, device := range []string{DeviceGPU, DeviceAudio, DeviceCamera, DeviceInput} {
+			if !slices.Contains(profile.Devices, device) {
+				args = append(args, firejailDeviceFlags[device])
+			}
+		}
+	}
+
+	for _, env := range profile.Env {
+		args = append(args, quote("--env="+env))
+	}
+
+	return append(args, "--")
+}
+
+// quote single-quotes s for a bash script
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
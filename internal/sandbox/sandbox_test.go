@@ -0,0 +1,128 @@
+package sandbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/config"
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name      string
+		installed []string
+		tool      string
+		want      string
+		wantErr   string
+	}{
+		{"auto prefers bwrap", []string{"bwrap", "firejail"}, ToolAuto, ToolBwrap, ""},
+		{"auto falls back to firejail", []string{"firejail"}, "", ToolFirejail, ""},
+		{"auto without tools", nil, ToolAuto, "", "no sandbox tool found"},
+		{"explicit tool", []string{"bwrap", "firejail"}, ToolFirejail, ToolFirejail, ""},
+		{"explicit tool missing", []string{"firejail"}, ToolBwrap, "", "bwrap is not installed"},
+		{"unknown tool", []string{"bwrap"}, "nsjail", "", "unknown sandbox tool"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &helpers.MockCommandRunner{
+				CommandExistsFunc: func(name string) bool {
+					for _, tool := range tt.installed {
+						if tool == name {
+							return true
+						}
+					}
+					return false
+				},
+			}
+			got, err := Resolve(runner, tt.tool)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := Resolve(&helpers.MockCommandRunner{}, ToolBwrap)
+	assert.True(t, errors.Is(err, ErrUnavailable))
+}
+
+func TestProfileFor(t *testing.T) {
+	cfg := config.SandboxConfig{IsolateHome: true, Network: true, Devices: []string{DeviceGPU}}
+	profile := ProfileFor(cfg, "/data/sandbox", "editor", "/apps/editor")
+	assert.Equal(t, "/data/sandbox/editor", profile.HomeDir)
+	assert.Equal(t, []string{"/apps/editor"}, profile.Expose)
+	assert.True(t, profile.Network)
+	assert.Equal(t, []string{DeviceGPU}, profile.Devices)
+
+	cfg.IsolateHome = false
+	assert.Empty(t, ProfileFor(cfg, "/data/sandbox", "editor").HomeDir)
+}
+
+func TestCommand_Bwrap(t *testing.T) {
+	words, err := Command(ToolBwrap, Profile{
+		HomeDir: "/data/sandbox/editor",
+		Expose:  []string{"/apps/my editor"},
+		Devices: []string{DeviceAudio},
+		Env:     []string{"APPIMAGE_EXTRACT_AND_RUN=1"},
+	})
+	require.NoError(t, err)
+	command := strings.Join(words, " ")
+
+	assert.True(t, strings.HasPrefix(command, "bwrap --ro-bind / / --dev /dev --proc /proc --tmpfs /tmp"))
+	assert.Contains(t, command, `--bind '/data/sandbox/editor' "$HOME"`)
+	assert.Contains(t, command, `--ro-bind '/apps/my editor' '/apps/my editor'`)
+	assert.Contains(t, command, "--unshare-net")
+	assert.Contains(t, command, "--dev-bind-try /dev/snd /dev/snd")
+	assert.NotContains(t, command, "/dev/dri")
+	assert.Contains(t, command, "--setenv APPIMAGE_EXTRACT_AND_RUN '1'")
+	assert.Equal(t, "--", words[len(words)-1])
+
+	words, err = Command(ToolBwrap, Profile{Network: true, Devices: []string{DeviceAll}})
+	require.NoError(t, err)
+	command = strings.Join(words, " ")
+	assert.Contains(t, command, `--bind "$HOME" "$HOME"`)
+	assert.Contains(t, command, "--dev-bind /dev /dev")
+	assert.NotContains(t, command, "--unshare-net")
+}
+
+func TestCommand_Firejail(t *testing.T) {
+	words, err := Command(ToolFirejail, Profile{
+		HomeDir: "/data/sandbox/editor",
+		Expose:  []string{"/apps/editor"},
+		Devices: []string{DeviceGPU, DeviceAudio},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"firejail", "--quiet",
+		"'--whitelist=/data/sandbox/editor'", "'--env=HOME=/data/sandbox/editor'",
+		"'--whitelist=/apps/editor'", "'--read-only=/apps/editor'",
+		"--net=none", "--novideo", "--noinput",
+		"--",
+	}, words)
+
+	words, err = Command(ToolFirejail, Profile{Network: true, Devices: []string{DeviceAll}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"firejail", "--quiet", "--"}, words)
+}
+
+func TestCommand_Invalid(t *testing.T) {
+	_, err := Command(ToolBwrap, Profile{Devices: []string{"printer"}})
+	assert.ErrorContains(t, err, `unknown sandbox device "printer"`)
+
+	_, err = Command(ToolBwrap, Profile{Env: []string{"=1"}})
+	assert.ErrorContains(t, err, "invalid sandbox environment variable")
+
+	_, err = Command("nsjail", Profile{})
+	assert.ErrorContains(t, err, "unknown sandbox tool")
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, `'/apps/it'\''s'`, quote("/apps/it's"))
+}
@@ -0,0 +1,172 @@
+// Package schema versions the YAML documents upkg exports and imports
+// (manifests, catalogs, applied state). Every document carries a
+// schemaVersion; loaders validate it and migrate older versions in place
+// before decoding into the current Go types.
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// VersionKey is the top-level YAML key holding the document version
+const VersionKey = "schemaVersion"
+
+// KindKey is the top-level YAML key holding the document kind
+const KindKey = "kind"
+
+var (
+	// ErrUnsupportedVersion is returned for documents written by a newer upkg
+	ErrUnsupportedVersion = errors.New("unsupported schema version")
+	// ErrKindMismatch is returned when a document of another kind is loaded
+	ErrKindMismatch = errors.New("document kind mismatch")
+)
+
+// Migration upgrades a raw document from version N to N+1
+type Migration func(doc map[string]any) error
+
+// Schema describes one versioned document kind
+type Schema struct {
+	kind       string
+	current    int
+	migrations map[int]Migration
+}
+
+// New creates a schema for kind at the given current version
+func New(kind string, current int) *Schema {
+	return &Schema{
+		kind:       kind,
+		current:    current,
+		migrations: make(map[int]Migration),
+	}
+}
+
+// Kind returns the document kind
+func (s *Schema) Kind() string {
+	return s.kind
+}
+
+// Current returns the version written by this build
+func (s *Schema) Current() int {
+	return s.current
+}
+
+// AddMigration registers the migration from version `from` to `from+1`
+func (s *Schema) AddMigration(from int, fn Migration) *Schema {
+	s.migrations[from] = fn
+	return s
+}
+
+// Load validates data, migrates it to the current version and decodes it into out.
+// Documents without a schemaVersion are treated as version 1.
+func (s *Schema) Load(data []byte, out any) error {
+	doc := map[string]any{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parse %s: %w", s.kind, err)
+	}
+
+	if kind, ok := doc[KindKey]; ok && kind != s.kind {
+		return fmt.Errorf("%w: expected %q, got %v", ErrKindMismatch, s.kind, kind)
+	}
+
+	version, err := documentVersion(doc)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", s.kind, err)
+	}
+	if version > s.current {
+		return fmt.Errorf("%w: %s version %d is newer than supported version %d (upgrade upkg)",
+			ErrUnsupportedVersion, s.kind, version, s.current)
+	}
+
+	for v := version; v < s.current; v++ {
+		migrate, ok := s.migrations[v]
+		if !ok {
+			return fmt.Errorf("%w: no migration for %s from version %d", ErrUnsupportedVersion, s.kind, v)
+		}
+		if err := migrate(doc); err != nil {
+			return fmt.Errorf("migrate %s from version %d: %w", s.kind, v, err)
+		}
+	}
+
+	doc[VersionKey] = s.current
+	doc[KindKey] = s.kind
+
+	migrated, err := yaml.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("encode migrated %s: %w", s.kind, err)
+	}
+	if err := yaml.Unmarshal(migrated, out); err != nil {
+		return fmt.Errorf("decode %s: %w", s.kind, err)
+	}
+	return nil
+}
+
+// Marshal encodes v as YAML with the kind and current schemaVersion at the top
+func (s *Schema) Marshal(v any) ([]byte, error) {
+	body, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", s.kind, err)
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(body, &node); err != nil {
+		return nil, fmt.Errorf("encode %s: %w", s.kind, err)
+	}
+	if len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("encode %s: document must be a mapping", s.kind)
+	}
+
+	mapping := node.Content[0]
+	header := []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: KindKey},
+		{Kind: yaml.ScalarNode, Value: s.kind},
+		{Kind: yaml.ScalarNode, Value: VersionKey},
+		{Kind: yaml.ScalarNode, Tag: "!!int", Value: fmt.Sprint(s.current)},
+	}
+	mapping.Content = append(header, withoutKeys(mapping.Content, KindKey, VersionKey)...)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, fmt.Errorf("encode %s: %w", s.kind, err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("encode %s: %w", s.kind, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// documentVersion reads schemaVersion, defaulting to 1 for legacy documents
+func documentVersion(doc map[string]any) (int, error) {
+	raw, ok := doc[VersionKey]
+	if !ok {
+		return 1, nil
+	}
+	version, ok := raw.(int)
+	if !ok || version < 1 {
+		return 0, fmt.Errorf("invalid %s: %v", VersionKey, raw)
+	}
+	return version, nil
+}
+
+// withoutKeys drops the given keys from mapping node content (key/value pairs)
+func withoutKeys(content []*yaml.Node, keys ...string) []*yaml.Node {
+	out := make([]*yaml.Node, 0, len(content))
+	for i := 0; i+1 < len(content); i += 2 {
+		skip := false
+		for _, key := range keys {
+			if content[i].Value == key {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			out = append(out, content[i], content[i+1])
+		}
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testManifest struct {
+	Packages []testPackage `yaml:"packages"`
+}
+
+type testPackage struct {
+	Name   string `yaml:"name"`
+	Source string `yaml:"source"`
+}
+
+// testSchema renames v1 "apps" to v2 "packages" and v2 "file" to v3 "source"
+func testSchema() *Schema {
+	return New("manifest", 3).
+		AddMigration(1, func(doc map[string]any) error {
+			doc["packages"] = doc["apps"]
+			delete(doc, "apps")
+			return nil
+		}).
+		AddMigration(2, func(doc map[string]any) error {
+			pkgs, _ := doc["packages"].([]any)
+			for _, p := range pkgs {
+				if m, ok := p.(map[string]any); ok {
+					m["source"] = m["file"]
+					delete(m, "file")
+				}
+			}
+			return nil
+		})
+}
+
+func TestSchema_MarshalAndLoad(t *testing.T) {
+	t.Parallel()
+
+	s := testSchema()
+	in := testManifest{Packages: []testPackage{{Name: "app", Source: "/tmp/app.AppImage"}}}
+
+	data, err := s.Marshal(in)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "kind: manifest\nschemaVersion: 3\n"))
+
+	var out testManifest
+	require.NoError(t, s.Load(data, &out))
+	assert.Equal(t, in, out)
+}
+
+func TestSchema_LoadMigratesLegacy(t *testing.T) {
+	t.Parallel()
+
+	var out testManifest
+	require.NoError(t, testSchema().Load([]byte("apps:\n  - name: app\n    file: /tmp/app.tar.gz\n"), &out))
+	assert.Equal(t, []testPackage{{Name: "app", Source: "/tmp/app.tar.gz"}}, out.Packages)
+
+	out = testManifest{}
+	require.NoError(t, testSchema().Load([]byte("schemaVersion: 2\npackages:\n  - name: b\n    file: /b\n"), &out))
+	assert.Equal(t, "/b", out.Packages[0].Source)
+}
+
+func TestSchema_LoadErrors(t *testing.T) {
+	t.Parallel()
+
+	var out testManifest
+
+	err := testSchema().Load([]byte("schemaVersion: 9\n"), &out)
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	err = testSchema().Load([]byte("kind: catalog\nschemaVersion: 1\n"), &out)
+	assert.ErrorIs(t, err, ErrKindMismatch)
+
+	err = testSchema().Load([]byte("schemaVersion: abc\n"), &out)
+	assert.Error(t, err)
+
+	err = New("manifest", 2).Load([]byte("schemaVersion: 1\n"), &out)
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	err = testSchema().Load([]byte(":\n  - bad"), &out)
+	assert.Error(t, err)
+}
@@ -0,0 +1,106 @@
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// HashVerdict is the classification returned by a hash-lookup service
+type HashVerdict string
+
+const (
+	VerdictClean     HashVerdict = "clean"
+	VerdictMalicious HashVerdict = "malicious"
+	VerdictUnknown   HashVerdict = "unknown"
+)
+
+var sha256Regex = regexp.MustCompile(`^[a-f0-9]{64}$`)
+
+// HashLookupResult is the response of a hash lookup
+type HashLookupResult struct {
+	Verdict HashVerdict `json:"verdict"`
+	Detail  string      `json:"detail,omitempty"`
+}
+
+// HashLookupClient queries a hash reputation endpoint. Only the SHA256 is
+// sent; the package file itself is never uploaded.
+//
+// The endpoint must answer GET requests with JSON {"verdict": "...", "detail": "..."}.
+// A 404 response means the hash is unknown.
+type HashLookupClient struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewHashLookupClient creates a client for endpoint with the given request timeout
+func NewHashLookupClient(endpoint string, timeout time.Duration) *HashLookupClient {
+	return &HashLookupClient{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+// Lookup queries the endpoint for the given SHA256
+func (c *HashLookupClient) Lookup(ctx context.Context, sha256 string) (*HashLookupResult, error) {
+	sha256 = strings.ToLower(sha256)
+	if !sha256Regex.MatchString(sha256) {
+		return nil, fmt.Errorf("invalid sha256: %q", sha256)
+	}
+
+	lookupURL, err := c.lookupURL(sha256)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, lookupURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create hash lookup request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("hash lookup request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return &HashLookupResult{Verdict: VerdictUnknown}, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("hash lookup returned status %d", resp.StatusCode)
+	}
+
+	var result HashLookupResult
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode hash lookup response: %w", err)
+	}
+
+	switch result.Verdict {
+	case VerdictClean, VerdictMalicious:
+	default:
+		result.Verdict = VerdictUnknown
+	}
+
+	return &result, nil
+}
+
+// lookupURL builds the request URL, replacing {sha256} or appending the hash as a path segment
+func (c *HashLookupClient) lookupURL(sha256 string) (string, error) {
+	if strings.Contains(c.endpoint, "{sha256}") {
+		return strings.ReplaceAll(c.endpoint, "{sha256}", sha256), nil
+	}
+
+	u, err := url.Parse(c.endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("invalid hash lookup url: %q", c.endpoint)
+	}
+	return u.JoinPath(sha256).String(), nil
+}
@@ -0,0 +1,69 @@
+package security
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+func TestHashLookupClient_Lookup(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/"+testHash):
+			_, _ = w.Write([]byte(`{"verdict":"malicious","detail":"trojan"}`))
+		case r.URL.Query().Get("hash") == testHash:
+			_, _ = w.Write([]byte(`{"verdict":"clean"}`))
+		case strings.HasSuffix(r.URL.Path, "/broken"):
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	t.Run("appends hash as path segment", func(t *testing.T) {
+		result, err := NewHashLookupClient(server.URL+"/v1/files", time.Second).Lookup(ctx, strings.ToUpper(testHash))
+		require.NoError(t, err)
+		assert.Equal(t, VerdictMalicious, result.Verdict)
+		assert.Equal(t, "trojan", result.Detail)
+	})
+
+	t.Run("replaces placeholder", func(t *testing.T) {
+		result, err := NewHashLookupClient(server.URL+"/lookup?hash={sha256}", time.Second).Lookup(ctx, testHash)
+		require.NoError(t, err)
+		assert.Equal(t, VerdictClean, result.Verdict)
+	})
+
+	t.Run("not found is unknown", func(t *testing.T) {
+		result, err := NewHashLookupClient(server.URL+"/other?h={sha256}", time.Second).Lookup(ctx, testHash)
+		require.NoError(t, err)
+		assert.Equal(t, VerdictUnknown, result.Verdict)
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		_, err := NewHashLookupClient(server.URL+"/broken?h={sha256}", time.Second).Lookup(ctx, testHash)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid hash", func(t *testing.T) {
+		_, err := NewHashLookupClient(server.URL, time.Second).Lookup(ctx, "not-a-hash")
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid endpoint", func(t *testing.T) {
+		_, err := NewHashLookupClient("not a url", time.Second).Lookup(ctx, testHash)
+		assert.Error(t, err)
+	})
+}
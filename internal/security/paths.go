@@ -1,11 +1,20 @@
 package security
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 )
 
+// ErrUnexpectedSymlinkTarget is returned when a target directory resolves, through
+// symlinks, to a location outside the expected roots
+var ErrUnexpectedSymlinkTarget = errors.New("target directory resolves to an unexpected location")
+
+// ErrSymlinkIntoPayload is returned when a target directory resolves into a package payload
+var ErrSymlinkIntoPayload = errors.New("target directory resolves into a package payload")
+
 // ValidateExtractPath prevents directory traversal attacks (Zip Slip vulnerability)
 // Ensures that the extracted path does not escape the target directory
 func ValidateExtractPath(targetDir, extractedPath string) error {
@@ -108,3 +117,78 @@ func IsPathSafe(basePath, targetPath string) bool {
 	err := ValidateExtractPath(basePath, targetPath)
 	return err == nil
 }
+
+// ResolveExistingPath resolves symlinks in the longest existing prefix of path
+// and appends the remaining (not yet created) components
+func ResolveExistingPath(path string) (string, error) {
+	cleanPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	existing, rest := cleanPath, ""
+	for {
+		if _, statErr := os.Lstat(existing); statErr == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return cleanPath, nil
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve symlinks in %s: %w", existing, err)
+	}
+	return filepath.Join(resolved, rest), nil
+}
+
+// ValidateTargetDir checks where an install target directory (e.g. ~/.local/bin)
+// really points. When symlinks are involved the resolved path must stay inside
+// one of allowedRoots and must never land inside a payloadRoots entry.
+// Both root lists are resolved the same way before comparison.
+func ValidateTargetDir(dir string, allowedRoots, payloadRoots []string) (string, error) {
+	resolved, err := ResolveExistingPath(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for _, root := range payloadRoots {
+		resolvedRoot, rootErr := ResolveExistingPath(root)
+		if rootErr != nil {
+			continue
+		}
+		if isWithin(resolved, resolvedRoot) {
+			return resolved, fmt.Errorf("%w: %s -> %s", ErrSymlinkIntoPayload, dir, resolved)
+		}
+	}
+
+	if cleanDir, absErr := filepath.Abs(dir); absErr == nil && cleanDir == resolved {
+		// No symlinks involved
+		return resolved, nil
+	}
+
+	for _, root := range allowedRoots {
+		resolvedRoot, rootErr := ResolveExistingPath(root)
+		if rootErr != nil {
+			continue
+		}
+		if isWithin(resolved, resolvedRoot) {
+			return resolved, nil
+		}
+	}
+
+	return resolved, fmt.Errorf("%w: %s -> %s", ErrUnexpectedSymlinkTarget, dir, resolved)
+}
+
+// isWithin reports whether path equals root or is located below it
+func isWithin(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
@@ -1,6 +1,9 @@
 package security
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -1072,3 +1075,52 @@ func TestIsPathWithinDirectory_AdditionalCases(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateTargetDir(t *testing.T) {
+	home := t.TempDir()
+	outside := t.TempDir()
+	payload := filepath.Join(home, ".local", "share", "upkg", "apps")
+	mustMkdir(t, filepath.Join(payload, "app", "bin"))
+	mustMkdir(t, filepath.Join(home, "dotfiles", "bin"))
+
+	mustSymlink(t, filepath.Join(home, "dotfiles", "bin"), filepath.Join(home, "bin-dotfiles"))
+	mustSymlink(t, outside, filepath.Join(home, "bin-outside"))
+	mustSymlink(t, filepath.Join(payload, "app", "bin"), filepath.Join(home, "bin-payload"))
+
+	tests := []struct {
+		name    string
+		dir     string
+		wantErr error
+	}{
+		{"plain missing directory", filepath.Join(home, ".local", "bin"), nil},
+		{"symlink inside home", filepath.Join(home, "bin-dotfiles"), nil},
+		{"symlink outside home", filepath.Join(home, "bin-outside", "sub"), ErrUnexpectedSymlinkTarget},
+		{"symlink into payload", filepath.Join(home, "bin-payload"), ErrSymlinkIntoPayload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidateTargetDir(tt.dir, []string{home}, []string{payload})
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("ValidateTargetDir(%q) unexpected error: %v", tt.dir, err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateTargetDir(%q) error = %v, want %v", tt.dir, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func mustMkdir(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustSymlink(t *testing.T, target, link string) {
+	t.Helper()
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+}
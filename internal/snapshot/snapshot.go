@@ -0,0 +1,68 @@
+// Package snapshot provides golden-file assertions for generated artifacts.
+//
+// Snapshots live in testdata/snapshots/<name>.golden next to the test. Run the
+// tests with UPDATE_SNAPSHOTS=1 to (re)write them after an intended change,
+// then review the diff like any other code change.
+package snapshot
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// UpdateEnv is the environment variable that switches Match into update mode
+const UpdateEnv = "UPDATE_SNAPSHOTS"
+
+// Dir is the snapshot directory, relative to the test package
+const Dir = "testdata/snapshots"
+
+// Updating reports whether snapshots should be rewritten instead of compared
+func Updating() bool {
+	switch strings.ToLower(os.Getenv(UpdateEnv)) {
+	case "", "0", "false", "no":
+		return false
+	}
+	return true
+}
+
+// Path returns the golden file path for name
+func Path(name string) string {
+	return filepath.Join(Dir, name+".golden")
+}
+
+// Match compares got with the named snapshot, or rewrites it in update mode
+func Match(t testing.TB, name string, got []byte) {
+	t.Helper()
+
+	path := Path(name)
+	if Updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("create snapshot dir: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("write snapshot %s: %v", path, err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("snapshot %s does not exist; run with %s=1 to create it", path, UpdateEnv)
+	}
+	if err != nil {
+		t.Fatalf("read snapshot %s: %v", path, err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("snapshot %s mismatch (run with %s=1 to update)\n--- want\n%s\n--- got\n%s", path, UpdateEnv, want, got)
+	}
+}
+
+// MatchString is Match for string output
+func MatchString(t testing.TB, name, got string) {
+	t.Helper()
+	Match(t, name, []byte(got))
+}
@@ -0,0 +1,30 @@
+package snapshot
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch_UpdateThenCompare(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	t.Setenv(UpdateEnv, "1")
+	Match(t, "example", []byte("content\n"))
+
+	data, err := os.ReadFile(Path("example"))
+	require.NoError(t, err)
+	assert.Equal(t, "content\n", string(data))
+
+	t.Setenv(UpdateEnv, "")
+	MatchString(t, "example", "content\n")
+}
+
+func TestUpdating(t *testing.T) {
+	for value, want := range map[string]bool{"": false, "0": false, "false": false, "1": true, "true": true} {
+		t.Setenv(UpdateEnv, value)
+		assert.Equal(t, want, Updating(), value)
+	}
+}
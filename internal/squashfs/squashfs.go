@@ -0,0 +1,600 @@
+// Package squashfs extracts SquashFS 4.0 images, such as the payload of a
+// type-2 AppImage, without external tools. Images compressed with gzip, xz
+// or lzma are supported; other compressors need unsquashfs.
+package squashfs
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/ulikunitz/xz"
+	"github.com/ulikunitz/xz/lzma"
+)
+
+// ErrCorrupt marks images whose structures are inconsistent or truncated
+var ErrCorrupt = errors.New("corrupt squashfs image")
+
+// ErrUnsupportedCompression marks images using a compressor this package
+// cannot decode
+var ErrUnsupportedCompression = errors.New("unsupported squashfs compression")
+
+const (
+	magic             = 0x73717368 // "hsqs"
+	metadataBlockSize = 8192
+	fragmentsPerBlock = metadataBlockSize / 16
+	noFragment        = 0xFFFFFFFF
+
+	metadataUncompressed = 0x8000  // Metadata block header flag
+	dataUncompressed     = 1 << 24 // Data block and fragment size flag
+
+	maxDepth      = 256     // Directory nesting accepted before assuming a loop
+	maxTargetSize = 4096    // Longest symlink target (PATH_MAX)
+	maxBlocks     = 1 << 24 // Data blocks per file accepted before assuming corruption
+)
+
+// Compressor IDs stored in the superblock
+const (
+	compressionGzip = 1
+	compressionLZMA = 2
+	compressionLZO  = 3
+	compressionXZ   = 4
+	compressionLZ4  = 5
+	compressionZstd = 6
+)
+
+var compressionNames = map[uint16]string{
+	compressionGzip: "gzip",
+	compressionLZMA: "lzma",
+	compressionLZO:  "lzo",
+	compressionXZ:   "xz",
+	compressionLZ4:  "lz4",
+	compressionZstd: "zstd",
+}
+
+// Inode types
+const (
+	typeDir        = 1
+	typeFile       = 2
+	typeSymlink    = 3
+	typeExtDir     = 8
+	typeExtFile    = 9
+	typeExtSymlink = 10
+)
+
+// superblock is the 96-byte header of a SquashFS 4.0 image. Table offsets
+// are relative to the start of the image.
+type superblock struct {
+	Magic               uint32
+	InodeCount          uint32
+	ModTime             uint32
+	BlockSize           uint32
+	FragmentCount       uint32
+	Compression         uint16
+	BlockLog            uint16
+	Flags               uint16
+	IDCount             uint16
+	VersionMajor        uint16
+	VersionMinor        uint16
+	RootInode           uint64
+	BytesUsed           uint64
+	IDTableStart        uint64
+	XattrTableStart     uint64
+	InodeTableStart     uint64
+	DirectoryTableStart uint64
+	FragmentTableStart  uint64
+	ExportTableStart    uint64
+}
+
+// Image is an open SquashFS image
+type Image struct {
+	r      io.ReaderAt
+	offset int64 // Start of the image within r
+	sb     superblock
+
+	// The most recently used fragment block; files sharing one are
+	// usually stored next to each other
+	fragmentIndex uint32
+	fragment      []byte
+}
+
+// inode is the subset of an inode needed to extract it
+type inode struct {
+	kind uint16
+	perm os.FileMode
+
+	// Directories
+	dirBlock  uint32
+	dirOffset uint16
+	dirSize   uint32
+
+	// Regular files
+	blocksStart    uint64
+	size           uint64
+	fragment       uint32
+	fragmentOffset uint32
+	blockSizes     []uint32
+
+	// Symlinks
+	target string
+}
+
+// dirEntry is one name in a directory listing
+type dirEntry struct {
+	name string
+	ref  uint64 // Inode reference: block << 16 | offset
+}
+
+// Open reads the superblock of the image starting at offset in r
+func Open(r io.ReaderAt, offset int64) (*Image, error) {
+	img := &Image{r: r, offset: offset, fragmentIndex: noFragment}
+
+	buf := make([]byte, binary.Size(img.sb))
+	if _, err := r.ReadAt(buf, offset); err != nil {
+		return nil, fmt.Errorf("read superblock: %w", err)
+	}
+	if err := binary.Read(bytes.NewReader(buf), binary.LittleEndian, &img.sb); err != nil {
+		return nil, fmt.Errorf("decode superblock: %w", err)
+	}
+
+	switch {
+	case img.sb.Magic != magic:
+		return nil, fmt.Errorf("%w: bad magic at offset %d", ErrCorrupt, offset)
+	case img.sb.VersionMajor != 4:
+		return nil, fmt.Errorf("%w: version %d.%d, only 4.x is supported", ErrCorrupt, img.sb.VersionMajor, img.sb.VersionMinor)
+	case img.sb.BlockSize == 0 || img.sb.BlockSize > 1<<20 || uint32(1)<<img.sb.BlockLog != img.sb.BlockSize:
+		return nil, fmt.Errorf("%w: invalid block size %d", ErrCorrupt, img.sb.BlockSize)
+	}
+	switch img.sb.Compression {
+	case compressionGzip, compressionXZ, compressionLZMA:
+	default:
+		name := compressionNames[img.sb.Compression]
+		if name == "" {
+			name = fmt.Sprintf("id %d", img.sb.Compression)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCompression, name)
+	}
+
+	return img, nil
+}
+
+// Compression names the compressor the image was built with
+func (img *Image) Compression() string {
+	return compressionNames[img.sb.Compression]
+}
+
+// ExtractTo writes the contents of the image into dest, which is created if
+// needed. Directories are left writable by the owner so the tree can be
+// removed again; device nodes, FIFOs and sockets are skipped.
+func (img *Image) ExtractTo(fs afero.Fs, dest string) error {
+	root, err := img.inode(img.sb.RootInode)
+	if err != nil {
+		return fmt.Errorf("read root inode: %w", err)
+	}
+	if root.kind != typeDir && root.kind != typeExtDir {
+		return fmt.Errorf("%w: root inode is not a directory", ErrCorrupt)
+	}
+	if err := fs.MkdirAll(dest, 0755); err != nil {
+		return fmt.Errorf("create %s: %w", dest, err)
+	}
+	return img.extractDir(fs, dest, root, 0)
+}
+
+func (img *Image) extractDir(fs afero.Fs, dir string, node *inode, depth int) error {
+	if depth > maxDepth {
+		return fmt.Errorf("%w: directories nested deeper than %d", ErrCorrupt, maxDepth)
+	}
+
+	entries, err := img.readDir(node)
+	if err != nil {
+		return fmt.Errorf("read directory %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.name == "." || entry.name == ".." || strings.ContainsAny(entry.name, "/\x00") {
+			return fmt.Errorf("%w: invalid entry name %q", ErrCorrupt, entry.name)
+		}
+		path := filepath.Join(dir, entry.name)
+
+		child, err := img.inode(entry.ref)
+		if err != nil {
+			return fmt.Errorf("read inode of %s: %w", path, err)
+		}
+
+		switch child.kind {
+		case typeDir, typeExtDir:
+			// Mkdir fails on duplicate names, so nothing is written
+			// through an earlier symlink of the same name
+			if err := fs.Mkdir(path, 0755); err != nil {
+				return fmt.Errorf("create %s: %w", path, err)
+			}
+			if err := img.extractDir(fs, path, child, depth+1); err != nil {
+				return err
+			}
+			if err := fs.Chmod(path, child.perm|0700); err != nil {
+				return fmt.Errorf("chmod %s: %w", path, err)
+			}
+		case typeFile, typeExtFile:
+			if err := img.extractFile(fs, path, child); err != nil {
+				return err
+			}
+		case typeSymlink, typeExtSymlink:
+			linker, ok := fs.(afero.Linker)
+			if !ok {
+				continue
+			}
+			if err := linker.SymlinkIfPossible(child.target, path); err != nil {
+				return fmt.Errorf("create symlink %s: %w", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (img *Image) extractFile(fs afero.Fs, path string, node *inode) error {
+	file, err := fs.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", path, err)
+	}
+	if err := img.writeFile(file, node); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("extract %s: %w", path, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := fs.Chmod(path, node.perm); err != nil {
+		return fmt.Errorf("chmod %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeFile copies the data blocks and fragment tail of a regular file to w
+func (img *Image) writeFile(w io.Writer, node *inode) error {
+	blockSize := uint64(img.sb.BlockSize)
+	remaining := node.size
+	pos := node.blocksStart
+
+	for _, sizeField := range node.blockSizes {
+		n := min(remaining, blockSize)
+		size := sizeField &^ dataUncompressed
+		if size == 0 {
+			// Sparse block
+			if _, err := w.Write(make([]byte, n)); err != nil {
+				return err
+			}
+			remaining -= n
+			continue
+		}
+
+		data, err := img.dataBlock(pos, sizeField)
+		if err != nil {
+			return err
+		}
+		if uint64(len(data)) < n {
+			return fmt.Errorf("%w: short data block", ErrCorrupt)
+		}
+		if _, err := w.Write(data[:n]); err != nil {
+			return err
+		}
+		pos += uint64(size)
+		remaining -= n
+	}
+
+	if remaining == 0 {
+		return nil
+	}
+	if node.fragment == noFragment {
+		return fmt.Errorf("%w: file data ends early", ErrCorrupt)
+	}
+	fragment, err := img.fragmentBlock(node.fragment)
+	if err != nil {
+		return err
+	}
+	start := uint64(node.fragmentOffset)
+	if start+remaining > uint64(len(fragment)) {
+		return fmt.Errorf("%w: fragment range out of bounds", ErrCorrupt)
+	}
+	_, err = w.Write(fragment[start : start+remaining])
+	return err
+}
+
+// inode reads the inode at ref
+func (img *Image) inode(ref uint64) (*inode, error) {
+	m, err := img.metadata(img.sb.InodeTableStart+ref>>16, uint16(ref))
+	if err != nil {
+		return nil, err
+	}
+
+	var header struct {
+		Type, Mode, UID, GID uint16
+		ModTime, Number      uint32
+	}
+	if err := m.decode(&header); err != nil {
+		return nil, err
+	}
+	node := &inode{kind: header.Type, perm: os.FileMode(header.Mode) & os.ModePerm}
+
+	switch header.Type {
+	case typeDir:
+		var dir struct {
+			Block, Links uint32
+			Size, Offset uint16
+			Parent       uint32
+		}
+		if err := m.decode(&dir); err != nil {
+			return nil, err
+		}
+		node.dirBlock, node.dirOffset, node.dirSize = dir.Block, dir.Offset, uint32(dir.Size)
+	case typeExtDir:
+		var dir struct {
+			Links, Size, Block, Parent uint32
+			IndexCount, Offset         uint16
+			Xattr                      uint32
+		}
+		if err := m.decode(&dir); err != nil {
+			return nil, err
+		}
+		node.dirBlock, node.dirOffset, node.dirSize = dir.Block, dir.Offset, dir.Size
+	case typeFile:
+		var file struct {
+			BlocksStart, Fragment, Offset, Size uint32
+		}
+		if err := m.decode(&file); err != nil {
+			return nil, err
+		}
+		node.blocksStart, node.size = uint64(file.BlocksStart), uint64(file.Size)
+		node.fragment, node.fragmentOffset = file.Fragment, file.Offset
+	case typeExtFile:
+		var file struct {
+			BlocksStart, Size, Sparse      uint64
+			Links, Fragment, Offset, Xattr uint32
+		}
+		if err := m.decode(&file); err != nil {
+			return nil, err
+		}
+		node.blocksStart, node.size = file.BlocksStart, file.Size
+		node.fragment, node.fragmentOffset = file.Fragment, file.Offset
+	case typeSymlink, typeExtSymlink:
+		var link struct{ Links, Size uint32 }
+		if err := m.decode(&link); err != nil {
+			return nil, err
+		}
+		if link.Size == 0 || link.Size > maxTargetSize {
+			return nil, fmt.Errorf("%w: symlink target of %d bytes", ErrCorrupt, link.Size)
+		}
+		target := make([]byte, link.Size)
+		if _, err := io.ReadFull(m, target); err != nil {
+			return nil, err
+		}
+		node.target = string(target)
+	}
+
+	if node.kind == typeFile || node.kind == typeExtFile {
+		count := node.size / uint64(img.sb.BlockSize)
+		if node.fragment == noFragment && node.size%uint64(img.sb.BlockSize) != 0 {
+			count++
+		}
+		if count > maxBlocks {
+			return nil, fmt.Errorf("%w: file of %d blocks", ErrCorrupt, count)
+		}
+		node.blockSizes = make([]uint32, count)
+		if err := m.decode(node.blockSizes); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+// readDir lists a directory inode
+func (img *Image) readDir(node *inode) ([]dirEntry, error) {
+	// The recorded size counts the implicit "." and ".." entries
+	if node.dirSize <= 3 {
+		return nil, nil
+	}
+	m, err := img.metadata(img.sb.DirectoryTableStart+uint64(node.dirBlock), node.dirOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []dirEntry
+	remaining := int(node.dirSize) - 3
+	for remaining > 0 {
+		var header struct{ Count, Start, Inode uint32 }
+		if err := m.decode(&header); err != nil {
+			return nil, err
+		}
+		remaining -= binary.Size(header)
+		if header.Count >= 256 {
+			return nil, fmt.Errorf("%w: directory header of %d entries", ErrCorrupt, header.Count+1)
+		}
+
+		for i := uint32(0); i <= header.Count; i++ {
+			var entry struct {
+				Offset     uint16
+				InodeDelta int16
+				Type       uint16
+				NameSize   uint16
+			}
+			if err := m.decode(&entry); err != nil {
+				return nil, err
+			}
+			name := make([]byte, int(entry.NameSize)+1)
+			if _, err := io.ReadFull(m, name); err != nil {
+				return nil, err
+			}
+			remaining -= binary.Size(entry) + len(name)
+			entries = append(entries, dirEntry{
+				name: string(name),
+				ref:  uint64(header.Start)<<16 | uint64(entry.Offset),
+			})
+		}
+	}
+	return entries, nil
+}
+
+// fragmentBlock returns the decompressed fragment block at index
+func (img *Image) fragmentBlock(index uint32) ([]byte, error) {
+	if index == img.fragmentIndex {
+		return img.fragment, nil
+	}
+	if index >= img.sb.FragmentCount {
+		return nil, fmt.Errorf("%w: fragment %d of %d", ErrCorrupt, index, img.sb.FragmentCount)
+	}
+
+	// The fragment table is an array of pointers to metadata blocks that
+	// each hold 512 16-byte entries
+	var location [8]byte
+	if err := img.readAt(location[:], img.sb.FragmentTableStart+uint64(index/fragmentsPerBlock)*8); err != nil {
+		return nil, err
+	}
+	m, err := img.metadata(binary.LittleEndian.Uint64(location[:]), uint16(index%fragmentsPerBlock)*16)
+	if err != nil {
+		return nil, err
+	}
+	var entry struct {
+		Start        uint64
+		Size, Unused uint32
+	}
+	if err := m.decode(&entry); err != nil {
+		return nil, err
+	}
+
+	data, err := img.dataBlock(entry.Start, entry.Size)
+	if err != nil {
+		return nil, err
+	}
+	img.fragmentIndex, img.fragment = index, data
+	return data, nil
+}
+
+// dataBlock reads the data block or fragment at start, whose size field
+// carries the uncompressed flag
+func (img *Image) dataBlock(start uint64, sizeField uint32) ([]byte, error) {
+	size := sizeField &^ dataUncompressed
+	if size > img.sb.BlockSize {
+		return nil, fmt.Errorf("%w: data block of %d bytes", ErrCorrupt, size)
+	}
+	data := make([]byte, size)
+	if err := img.readAt(data, start); err != nil {
+		return nil, err
+	}
+	if sizeField&dataUncompressed != 0 {
+		return data, nil
+	}
+	return img.decompress(data, int(img.sb.BlockSize))
+}
+
+// decompress inflates one block, which may not exceed limit bytes
+func (img *Image) decompress(data []byte, limit int) ([]byte, error) {
+	var r io.Reader
+	var err error
+	switch img.sb.Compression {
+	case compressionGzip:
+		r, err = zlib.NewReader(bytes.NewReader(data))
+	case compressionXZ:
+		r, err = xz.NewReader(bytes.NewReader(data))
+	case compressionLZMA:
+		r, err = lzma.NewReader(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCompression, img.Compression())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+
+	out, err := io.ReadAll(io.LimitReader(r, int64(limit)+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if len(out) > limit {
+		return nil, fmt.Errorf("%w: block inflates past %d bytes", ErrCorrupt, limit)
+	}
+	return out, nil
+}
+
+// readAt reads len(p) bytes at an image-relative position
+func (img *Image) readAt(p []byte, pos uint64) error {
+	if pos > uint64(1<<62) {
+		return fmt.Errorf("%w: offset %d out of range", ErrCorrupt, pos)
+	}
+	if _, err := img.r.ReadAt(p, img.offset+int64(pos)); err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("%w: truncated at offset %d", ErrCorrupt, pos)
+		}
+		return err
+	}
+	return nil
+}
+
+// metadataReader reads a run of metadata blocks as one stream
+type metadataReader struct {
+	img  *Image
+	next uint64 // Image-relative position of the next block
+	buf  []byte
+}
+
+// metadata returns a reader positioned offset bytes into the decompressed
+// metadata block at start
+func (img *Image) metadata(start uint64, offset uint16) (*metadataReader, error) {
+	m := &metadataReader{img: img, next: start}
+	if err := m.fill(); err != nil {
+		return nil, err
+	}
+	if int(offset) > len(m.buf) {
+		return nil, fmt.Errorf("%w: metadata offset %d past block end", ErrCorrupt, offset)
+	}
+	m.buf = m.buf[offset:]
+	return m, nil
+}
+
+func (m *metadataReader) fill() error {
+	var header [2]byte
+	if err := m.img.readAt(header[:], m.next); err != nil {
+		return err
+	}
+	size := binary.LittleEndian.Uint16(header[:])
+	stored := size&metadataUncompressed != 0
+	size &^= metadataUncompressed
+	if size == 0 || size > metadataBlockSize {
+		return fmt.Errorf("%w: metadata block of %d bytes", ErrCorrupt, size)
+	}
+
+	data := make([]byte, size)
+	if err := m.img.readAt(data, m.next+2); err != nil {
+		return err
+	}
+	m.next += 2 + uint64(size)
+
+	if !stored {
+		var err error
+		if data, err = m.img.decompress(data, metadataBlockSize); err != nil {
+			return err
+		}
+	}
+	m.buf = append(m.buf, data...)
+	return nil
+}
+
+func (m *metadataReader) Read(p []byte) (int, error) {
+	if len(m.buf) == 0 {
+		if err := m.fill(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, m.buf)
+	m.buf = m.buf[n:]
+	return n, nil
+}
+
+// decode reads a fixed-size little-endian value
+func (m *metadataReader) decode(v any) error {
+	return binary.Read(m, binary.LittleEndian, v)
+}
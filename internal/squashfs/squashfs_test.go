@@ -0,0 +1,268 @@
+package squashfs
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testBlockSize = 4096
+
+// testFile describes one entry of a generated image; a nil content with an
+// empty target is a directory
+type testFile struct {
+	path    string
+	content []byte
+	target  string
+	mode    uint16
+}
+
+// buildImage writes a gzip-compressed SquashFS 4.0 image holding files. Data
+// blocks are compressed; metadata is stored uncompressed in single blocks.
+func buildImage(t *testing.T, files []testFile, prefix int) []byte {
+	t.Helper()
+
+	var data, fragment, inodes, dirs bytes.Buffer
+	const dataStart = 96
+
+	le := binary.LittleEndian
+	compress := func(b []byte) []byte {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		_, err := w.Write(b)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+
+	type child struct {
+		name string
+		ref  uint32 // Offset in the inode table
+		kind uint16
+	}
+	children := map[string][]child{}
+	number := uint32(1)
+
+	writeHeader := func(kind, mode uint16) uint32 {
+		ref := uint32(inodes.Len())
+		require.NoError(t, binary.Write(&inodes, le, []uint16{kind, mode, 0, 0}))
+		require.NoError(t, binary.Write(&inodes, le, []uint32{0, number}))
+		number++
+		return ref
+	}
+
+	// Files and symlinks first, then directories deepest first and the root
+	// last, so every listing knows the inodes of its children
+	var dirPaths []string
+	for _, f := range files {
+		parent, name := filepath.Dir(f.path), filepath.Base(f.path)
+		switch {
+		case f.target != "":
+			ref := writeHeader(typeSymlink, 0777)
+			require.NoError(t, binary.Write(&inodes, le, []uint32{1, uint32(len(f.target))}))
+			inodes.WriteString(f.target)
+			children[parent] = append(children[parent], child{name, ref, typeSymlink})
+		case f.content != nil:
+			var sizes []uint32
+			blocksStart := uint32(dataStart + data.Len())
+			full := len(f.content) / testBlockSize
+			for i := 0; i < full; i++ {
+				block := compress(f.content[i*testBlockSize : (i+1)*testBlockSize])
+				data.Write(block)
+				sizes = append(sizes, uint32(len(block)))
+			}
+			fragIndex, fragOffset := uint32(noFragment), uint32(0)
+			if tail := f.content[full*testBlockSize:]; len(tail) > 0 {
+				fragIndex, fragOffset = 0, uint32(fragment.Len())
+				fragment.Write(tail)
+			}
+			ref := writeHeader(typeFile, f.mode)
+			require.NoError(t, binary.Write(&inodes, le, []uint32{blocksStart, fragIndex, fragOffset, uint32(len(f.content))}))
+			require.NoError(t, binary.Write(&inodes, le, sizes))
+			children[parent] = append(children[parent], child{name, ref, typeFile})
+		default:
+			dirPaths = append(dirPaths, f.path)
+		}
+	}
+	slices.SortStableFunc(dirPaths, func(a, b string) int {
+		return strings.Count(b, "/") - strings.Count(a, "/")
+	})
+	dirPaths = append(dirPaths, ".")
+
+	var rootRef uint32
+	for _, dir := range dirPaths {
+		start := uint16(dirs.Len())
+		entries := children[dir]
+		if len(entries) > 0 {
+			require.NoError(t, binary.Write(&dirs, le, []uint32{uint32(len(entries) - 1), 0, 1}))
+			for _, e := range entries {
+				require.NoError(t, binary.Write(&dirs, le, []uint16{uint16(e.ref), 0, e.kind, uint16(len(e.name) - 1)}))
+				dirs.WriteString(e.name)
+			}
+		}
+		size := uint16(dirs.Len()) - start + 3
+
+		ref := writeHeader(typeDir, 0755)
+		require.NoError(t, binary.Write(&inodes, le, []uint32{0, 2}))
+		require.NoError(t, binary.Write(&inodes, le, []uint16{size, start}))
+		require.NoError(t, binary.Write(&inodes, le, uint32(0)))
+
+		if dir == "." {
+			rootRef = ref
+			continue
+		}
+		parent := filepath.Dir(dir)
+		children[parent] = append(children[parent], child{filepath.Base(dir), ref, typeDir})
+	}
+
+	metadataBlock := func(b []byte) []byte {
+		require.Less(t, len(b), metadataBlockSize)
+		out := le.AppendUint16(nil, uint16(len(b))|metadataUncompressed)
+		return append(out, b...)
+	}
+
+	image := bytes.NewBuffer(make([]byte, dataStart))
+	image.Write(data.Bytes())
+
+	fragmentStart := uint64(image.Len())
+	compressedFragment := compress(fragment.Bytes())
+	image.Write(compressedFragment)
+
+	inodeTableStart := uint64(image.Len())
+	image.Write(metadataBlock(inodes.Bytes()))
+	directoryTableStart := uint64(image.Len())
+	image.Write(metadataBlock(dirs.Bytes()))
+
+	fragmentEntries := uint64(image.Len())
+	var entry bytes.Buffer
+	require.NoError(t, binary.Write(&entry, le, struct {
+		Start        uint64
+		Size, Unused uint32
+	}{fragmentStart, uint32(len(compressedFragment)), 0}))
+	image.Write(metadataBlock(entry.Bytes()))
+	fragmentTableStart := uint64(image.Len())
+	require.NoError(t, binary.Write(image, le, fragmentEntries))
+
+	sb := superblock{
+		Magic:               magic,
+		InodeCount:          number - 1,
+		BlockSize:           testBlockSize,
+		FragmentCount:       1,
+		Compression:         compressionGzip,
+		BlockLog:            12,
+		VersionMajor:        4,
+		RootInode:           uint64(rootRef),
+		BytesUsed:           uint64(image.Len()),
+		InodeTableStart:     inodeTableStart,
+		DirectoryTableStart: directoryTableStart,
+		FragmentTableStart:  fragmentTableStart,
+	}
+	out := image.Bytes()
+	var header bytes.Buffer
+	require.NoError(t, binary.Write(&header, le, sb))
+	copy(out, header.Bytes())
+
+	return append(bytes.Repeat([]byte{0x7f}, prefix), out...)
+}
+
+func testFiles() []testFile {
+	big := bytes.Repeat([]byte("0123456789abcdef"), 600) // Two blocks plus a fragment tail
+	return []testFile{
+		{path: "AppRun", content: []byte("#!/bin/sh\nexec ./bin/tool\n"), mode: 0755},
+		{path: "bin/tool", content: big, mode: 0755},
+		{path: "tool.desktop", content: []byte("[Desktop Entry]\nName=Tool\n"), mode: 0644},
+		{path: "usr/share/empty"},
+		{path: "usr/share/icon.svg", content: []byte("<svg/>"), mode: 0644},
+		{path: ".DirIcon", target: "usr/share/icon.svg"},
+		{path: "bin"},
+		{path: "usr/share"},
+		{path: "usr"},
+	}
+}
+
+func TestExtractTo(t *testing.T) {
+	t.Parallel()
+
+	files := testFiles()
+	image := buildImage(t, files, 1024)
+	img, err := Open(bytes.NewReader(image), 1024)
+	require.NoError(t, err)
+	assert.Equal(t, "gzip", img.Compression())
+
+	dest := filepath.Join(t.TempDir(), "squashfs-root")
+	require.NoError(t, img.ExtractTo(afero.NewOsFs(), dest))
+
+	for _, f := range files {
+		path := filepath.Join(dest, f.path)
+		switch {
+		case f.target != "":
+			target, err := os.Readlink(path)
+			require.NoError(t, err)
+			assert.Equal(t, f.target, target)
+		case f.content != nil:
+			got, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, f.content, got, f.path)
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(f.mode), info.Mode().Perm(), f.path)
+		default:
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.True(t, info.IsDir(), f.path)
+		}
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	t.Parallel()
+
+	image := buildImage(t, testFiles(), 0)
+
+	_, err := Open(bytes.NewReader(image), 1)
+	assert.ErrorIs(t, err, ErrCorrupt)
+
+	zstd := bytes.Clone(image)
+	binary.LittleEndian.PutUint16(zstd[20:], compressionZstd)
+	_, err = Open(bytes.NewReader(zstd), 0)
+	assert.ErrorIs(t, err, ErrUnsupportedCompression)
+	assert.ErrorContains(t, err, "zstd")
+
+	_, err = Open(bytes.NewReader(image[:40]), 0)
+	assert.Error(t, err)
+}
+
+func TestExtractTo_Corrupt(t *testing.T) {
+	t.Parallel()
+
+	t.Run("truncated", func(t *testing.T) {
+		image := buildImage(t, testFiles(), 0)
+		img, err := Open(bytes.NewReader(image[:len(image)/2]), 0)
+		require.NoError(t, err)
+		err = img.ExtractTo(afero.NewMemMapFs(), "/out")
+		assert.ErrorIs(t, err, ErrCorrupt)
+	})
+
+	t.Run("path traversal", func(t *testing.T) {
+		image := buildImage(t, []testFile{{path: "xx", content: []byte("evil"), mode: 0644}}, 0)
+		// Rename the only entry to ".."
+		at := bytes.LastIndex(image, []byte("xx"))
+		require.Positive(t, at)
+		copy(image[at:], "..")
+
+		img, err := Open(bytes.NewReader(image), 0)
+		require.NoError(t, err)
+		err = img.ExtractTo(afero.NewMemMapFs(), "/out")
+		assert.ErrorIs(t, err, ErrCorrupt)
+		assert.ErrorContains(t, err, `invalid entry name ".."`)
+	})
+}
@@ -0,0 +1,179 @@
+// Package status keeps a small JSON snapshot of what upkg is doing so status
+// bars (waybar, polybar) and scripts can show it without invoking upkg.
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// Snapshot states
+const (
+	StateIdle    = "idle"
+	StateRunning = "running"
+	// StateInterrupted is reported by Read when the process that wrote a
+	// running snapshot no longer exists
+	StateInterrupted = "interrupted"
+)
+
+// Snapshot is the on-disk content of the status file
+type Snapshot struct {
+	State        string    `json:"state"`
+	Operation    string    `json:"operation,omitempty"`
+	Active       []string  `json:"active,omitempty"` // Targets being processed right now
+	QueueDepth   int       `json:"queue_depth"`      // Targets not started yet
+	Completed    int       `json:"completed"`
+	Errors       int       `json:"errors"`
+	LastError    string    `json:"last_error,omitempty"`
+	PID          int       `json:"pid,omitempty"`
+	StartedAt    time.Time `json:"started_at"`
+	LastActivity time.Time `json:"last_activity"`
+}
+
+// Tracker updates the status file as an operation progresses. A nil Tracker
+// is valid and does nothing, so callers need not check whether the file
+// could be written.
+type Tracker struct {
+	fs   afero.Fs
+	path string
+	mu   sync.Mutex
+	snap Snapshot
+}
+
+// Start writes a running snapshot for operation with queued targets pending
+func Start(fs afero.Fs, path, operation string, queued int) (*Tracker, error) {
+	now := time.Now()
+	t := &Tracker{
+		fs:   fs,
+		path: path,
+		snap: Snapshot{
+			State:        StateRunning,
+			Operation:    operation,
+			QueueDepth:   queued,
+			PID:          os.Getpid(),
+			StartedAt:    now,
+			LastActivity: now,
+		},
+	}
+	if err := t.write(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Begin moves target from the queue to the active set
+func (t *Tracker) Begin(target string) {
+	if t == nil {
+		return
+	}
+	t.update(func(snap *Snapshot) {
+		snap.QueueDepth = max(snap.QueueDepth-1, 0)
+		snap.Active = append(snap.Active, target)
+	})
+}
+
+// Done removes target from the active set and counts the outcome
+func (t *Tracker) Done(target string, err error) {
+	if t == nil {
+		return
+	}
+	t.update(func(snap *Snapshot) {
+		if i := slices.Index(snap.Active, target); i >= 0 {
+			snap.Active = slices.Delete(snap.Active, i, i+1)
+		}
+		if err != nil {
+			snap.Errors++
+			snap.LastError = fmt.Sprintf("%s: %v", target, err)
+			return
+		}
+		snap.Completed++
+	})
+}
+
+// Finish marks the operation as over; counts and the last error are kept
+// until the next operation starts
+func (t *Tracker) Finish() {
+	if t == nil {
+		return
+	}
+	t.update(func(snap *Snapshot) {
+		snap.State = StateIdle
+		snap.Active = nil
+		snap.QueueDepth = 0
+		snap.PID = 0
+	})
+}
+
+func (t *Tracker) update(fn func(snap *Snapshot)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fn(&t.snap)
+	t.snap.LastActivity = time.Now()
+	// Best effort: a stale status file must never fail an install
+	_ = t.writeLocked()
+}
+
+func (t *Tracker) write() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.writeLocked()
+}
+
+// writeLocked replaces the status file atomically so readers never see a
+// partial snapshot
+func (t *Tracker) writeLocked() error {
+	data, err := json.MarshalIndent(t.snap, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode status: %w", err)
+	}
+	if err := t.fs.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return fmt.Errorf("create status directory: %w", err)
+	}
+	tmp := t.path + ".tmp"
+	if err := afero.WriteFile(t.fs, tmp, data, 0644); err != nil {
+		return fmt.Errorf("write status file: %w", err)
+	}
+	if err := t.fs.Rename(tmp, t.path); err != nil {
+		return fmt.Errorf("replace status file: %w", err)
+	}
+	return nil
+}
+
+// Read returns the current snapshot. A missing file reads as idle, and a
+// running snapshot whose process is gone reads as interrupted.
+func Read(fs afero.Fs, path string) (*Snapshot, error) {
+	data, err := afero.ReadFile(fs, path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Snapshot{State: StateIdle}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read status file: %w", err)
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("parse status file %s: %w", path, err)
+	}
+	if snap.State == StateRunning && !processAlive(snap.PID) {
+		snap.State = StateInterrupted
+	}
+	return &snap, nil
+}
+
+// processAlive reports whether pid is a running process
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
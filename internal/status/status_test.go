@@ -0,0 +1,75 @@
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testStatusFile = "/data/status.json"
+
+func TestTracker_Lifecycle(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	tracker, err := Start(fs, testStatusFile, "install", 3)
+	require.NoError(t, err)
+
+	tracker.Begin("a.AppImage")
+	tracker.Begin("b.tar.gz")
+
+	snap, err := Read(fs, testStatusFile)
+	require.NoError(t, err)
+	assert.Equal(t, StateRunning, snap.State)
+	assert.Equal(t, "install", snap.Operation)
+	assert.Equal(t, []string{"a.AppImage", "b.tar.gz"}, snap.Active)
+	assert.Equal(t, 1, snap.QueueDepth)
+
+	tracker.Done("a.AppImage", nil)
+	tracker.Done("b.tar.gz", errors.New("no executables found"))
+	tracker.Finish()
+
+	snap, err = Read(fs, testStatusFile)
+	require.NoError(t, err)
+	assert.Equal(t, StateIdle, snap.State)
+	assert.Empty(t, snap.Active)
+	assert.Zero(t, snap.QueueDepth)
+	assert.Equal(t, 1, snap.Completed)
+	assert.Equal(t, 1, snap.Errors)
+	assert.Equal(t, "b.tar.gz: no executables found", snap.LastError)
+	assert.False(t, snap.LastActivity.IsZero())
+}
+
+func TestTracker_NilIsNoop(t *testing.T) {
+	var tracker *Tracker
+	tracker.Begin("a")
+	tracker.Done("a", nil)
+	tracker.Finish()
+}
+
+func TestRead_Missing(t *testing.T) {
+	snap, err := Read(afero.NewMemMapFs(), testStatusFile)
+	require.NoError(t, err)
+	assert.Equal(t, StateIdle, snap.State)
+}
+
+func TestRead_DeadProcessIsInterrupted(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	data, err := json.Marshal(Snapshot{State: StateRunning, Operation: "upgrade", PID: 0})
+	require.NoError(t, err)
+	require.NoError(t, afero.WriteFile(fs, testStatusFile, data, 0644))
+
+	snap, err := Read(fs, testStatusFile)
+	require.NoError(t, err)
+	assert.Equal(t, StateInterrupted, snap.State)
+}
+
+func TestRead_Corrupt(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, testStatusFile, []byte("{"), 0644))
+
+	_, err := Read(fs, testStatusFile)
+	assert.Error(t, err)
+}
@@ -0,0 +1,252 @@
+// Package store keeps the unpacked payloads of uninstalled archives, keyed
+// by the SHA256 of the archive they were extracted from. Reinstalling the
+// same file, under any name, moves the payload back instead of extracting
+// the archive again. Entries move in and out with a rename, so the store
+// lives on the same filesystem as the apps directory.
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/spf13/afero"
+)
+
+// ErrNotCached is returned by Take when no payload is stored for a hash
+var ErrNotCached = errors.New("payload not cached")
+
+// hashPattern matches the keys of the store
+var hashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Entry describes a stored payload
+type Entry struct {
+	Hash     string    `json:"hash"`    // SHA256 of the archive
+	Name     string    `json:"name"`    // Package the payload was installed as
+	Version  string    `json:"version"` // Version of that install
+	Source   string    `json:"source"`  // Archive the payload was extracted from
+	Size     int64     `json:"size"`    // Bytes on disk
+	CachedAt time.Time `json:"cached_at"`
+}
+
+// HashFile returns the hex SHA256 of the file at path
+func HashFile(fs afero.Fs, path string) (string, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Store is a directory of payloads named by hash, each with a
+// <hash>.json entry beside it
+type Store struct {
+	fs  afero.Fs
+	dir string
+}
+
+// New opens the store in dir; it is created on the first Put
+func New(fs afero.Fs, dir string) *Store {
+	return &Store{fs: fs, dir: dir}
+}
+
+// Dir returns the directory of the store
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Path returns where the payload of hash is kept
+func (s *Store) Path(hash string) string {
+	return filepath.Join(s.dir, hash)
+}
+
+func (s *Store) entryFile(hash string) string {
+	return filepath.Join(s.dir, hash+".json")
+}
+
+// Has reports whether a payload is stored for hash
+func (s *Store) Has(hash string) bool {
+	if !hashPattern.MatchString(hash) {
+		return false
+	}
+	info, err := s.fs.Stat(s.Path(hash))
+	return err == nil && info.IsDir()
+}
+
+// Put moves the payload directory into the store under entry.Hash. An
+// existing payload for the hash is kept and payloadDir is left in place.
+func (s *Store) Put(payloadDir string, entry Entry) error {
+	if !hashPattern.MatchString(entry.Hash) {
+		return fmt.Errorf("invalid payload hash %q", entry.Hash)
+	}
+	if s.Has(entry.Hash) {
+		return fmt.Errorf("payload %s is already cached", short(entry.Hash))
+	}
+	if err := s.fs.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("create payload cache: %w", err)
+	}
+
+	if entry.Size == 0 {
+		entry.Size, _ = core.PathSize(s.fs, payloadDir)
+	}
+	if entry.CachedAt.IsZero() {
+		entry.CachedAt = time.Now()
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode cache entry: %w", err)
+	}
+
+	if err := s.fs.Rename(payloadDir, s.Path(entry.Hash)); err != nil {
+		return fmt.Errorf("move payload into cache: %w", err)
+	}
+	if err := afero.WriteFile(s.fs, s.entryFile(entry.Hash), data, 0644); err != nil {
+		// The payload is still usable; List falls back to the directory
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	return nil
+}
+
+// Take moves the payload of hash to dest, which must not exist or be an
+// empty directory, and returns its entry. It returns ErrNotCached when the
+// store has no payload for hash.
+func (s *Store) Take(hash, dest string) (Entry, error) {
+	if !s.Has(hash) {
+		return Entry{}, ErrNotCached
+	}
+	entry := s.readEntry(hash)
+
+	// An empty directory prepared for extraction is replaced
+	if names, err := afero.ReadDir(s.fs, dest); err == nil && len(names) == 0 {
+		if err := s.fs.Remove(dest); err != nil {
+			return Entry{}, fmt.Errorf("replace %s: %w", dest, err)
+		}
+	}
+	if err := s.fs.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return Entry{}, fmt.Errorf("create %s: %w", filepath.Dir(dest), err)
+	}
+	if err := s.fs.Rename(s.Path(hash), dest); err != nil {
+		return Entry{}, fmt.Errorf("move payload out of cache: %w", err)
+	}
+	_ = s.fs.Remove(s.entryFile(hash)) //nolint:errcheck // a stale entry is ignored by List
+	return entry, nil
+}
+
+// readEntry loads the entry of hash, rebuilding what it can from the
+// payload directory when the entry file is missing or unreadable
+func (s *Store) readEntry(hash string) Entry {
+	var entry Entry
+	if data, err := afero.ReadFile(s.fs, s.entryFile(hash)); err == nil {
+		_ = json.Unmarshal(data, &entry) //nolint:errcheck // fall back below
+	}
+	entry.Hash = hash
+	if entry.CachedAt.IsZero() {
+		if info, err := s.fs.Stat(s.Path(hash)); err == nil {
+			entry.CachedAt = info.ModTime()
+		}
+	}
+	if entry.Size == 0 {
+		entry.Size, _ = core.PathSize(s.fs, s.Path(hash))
+	}
+	return entry
+}
+
+// List returns the stored payloads, oldest first
+func (s *Store) List() ([]Entry, error) {
+	infos, err := afero.ReadDir(s.fs, s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read payload cache: %w", err)
+	}
+
+	var entries []Entry
+	for _, info := range infos {
+		if info.IsDir() && hashPattern.MatchString(info.Name()) {
+			entries = append(entries, s.readEntry(info.Name()))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].CachedAt.Before(entries[j].CachedAt) })
+	return entries, nil
+}
+
+// Remove deletes the payload of hash and its entry
+func (s *Store) Remove(hash string) error {
+	if !hashPattern.MatchString(hash) {
+		return fmt.Errorf("invalid payload hash %q", hash)
+	}
+	if err := s.fs.RemoveAll(s.Path(hash)); err != nil {
+		return fmt.Errorf("remove cached payload %s: %w", short(hash), err)
+	}
+	if err := s.fs.Remove(s.entryFile(hash)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove cache entry %s: %w", short(hash), err)
+	}
+	return nil
+}
+
+// Prune evicts the oldest payloads until the store holds at most maxSize
+// bytes, and returns the evicted entries. maxSize <= 0 empties the store.
+func (s *Store) Prune(maxSize int64) ([]Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+	}
+
+	var evicted []Entry
+	for _, entry := range entries {
+		if total <= maxSize && maxSize > 0 {
+			break
+		}
+		if err := s.Remove(entry.Hash); err != nil {
+			return evicted, err
+		}
+		total -= entry.Size
+		evicted = append(evicted, entry)
+	}
+	return evicted, nil
+}
+
+// Match returns the stored entries whose hash starts with prefix
+func (s *Store) Match(prefix string) ([]Entry, error) {
+	entries, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var matched []Entry
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Hash, strings.ToLower(prefix)) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched, nil
+}
+
+// short abbreviates a hash for messages
+func short(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
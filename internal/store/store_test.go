@@ -0,0 +1,104 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hashOf(c string) string {
+	return strings.Repeat(c, 64)
+}
+
+func stagePayload(t *testing.T, fs afero.Fs, dir string, size int) {
+	t.Helper()
+	require.NoError(t, fs.MkdirAll(dir+"/bin", 0755))
+	require.NoError(t, afero.WriteFile(fs, dir+"/bin/app", make([]byte, size), 0755))
+}
+
+func TestHashFile(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/pkg.tar.gz", []byte("hello"), 0644))
+
+	hash, err := HashFile(fs, "/pkg.tar.gz")
+	require.NoError(t, err)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", hash)
+
+	_, err = HashFile(fs, "/missing")
+	assert.Error(t, err)
+}
+
+func TestStore_PutTake(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	s := New(fs, "/data/payloads")
+	stagePayload(t, fs, "/apps/app", 10)
+
+	require.NoError(t, s.Put("/apps/app", Entry{Hash: hashOf("a"), Name: "App", Version: "1.0"}))
+	assert.True(t, s.Has(hashOf("a")))
+	assert.Error(t, s.Put("/apps/app", Entry{Hash: hashOf("a")}), "a hash is cached once")
+	assert.Error(t, s.Put("/apps/app", Entry{Hash: "../escape"}))
+
+	// An empty directory prepared for extraction is replaced
+	require.NoError(t, fs.MkdirAll("/apps/other", 0755))
+	entry, err := s.Take(hashOf("a"), "/apps/other")
+	require.NoError(t, err)
+	assert.Equal(t, "App", entry.Name)
+	assert.Equal(t, int64(10), entry.Size)
+	assert.False(t, s.Has(hashOf("a")))
+
+	data, err := afero.ReadFile(fs, "/apps/other/bin/app")
+	require.NoError(t, err)
+	assert.Len(t, data, 10)
+
+	_, err = s.Take(hashOf("a"), "/apps/again")
+	assert.ErrorIs(t, err, ErrNotCached)
+}
+
+func TestStore_ListPruneMatch(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	s := New(fs, "/payloads")
+
+	entries, err := s.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries, "a missing store is empty")
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, c := range []string{"c", "a", "b"} {
+		stagePayload(t, fs, "/staged/"+c, 100)
+		require.NoError(t, s.Put("/staged/"+c, Entry{Hash: hashOf(c), CachedAt: base.Add(time.Duration(i) * time.Hour)}))
+	}
+
+	entries, err = s.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, hashOf("c"), entries[0].Hash, "oldest first")
+
+	matched, err := s.Match("AAA")
+	require.NoError(t, err)
+	require.Len(t, matched, 1)
+	assert.Equal(t, hashOf("a"), matched[0].Hash)
+
+	evicted, err := s.Prune(150)
+	require.NoError(t, err)
+	require.Len(t, evicted, 2)
+	assert.Equal(t, hashOf("c"), evicted[0].Hash)
+	assert.Equal(t, hashOf("a"), evicted[1].Hash)
+	assert.True(t, s.Has(hashOf("b")))
+
+	evicted, err = s.Prune(0)
+	require.NoError(t, err)
+	assert.Len(t, evicted, 1)
+	entries, err = s.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
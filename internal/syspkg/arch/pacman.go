@@ -3,7 +3,9 @@ package arch
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/quantmind-br/upkg/internal/helpers"
 	"github.com/quantmind-br/upkg/internal/syspkg"
@@ -15,12 +17,14 @@ var _ syspkg.Provider = (*PacmanProvider)(nil)
 // PacmanProvider implements the Provider interface for Arch Linux
 type PacmanProvider struct {
 	runner helpers.CommandRunner
+	lock   syspkg.LockRetry
 }
 
 // NewPacmanProvider creates a new Pacman provider
 func NewPacmanProvider() *PacmanProvider {
 	return &PacmanProvider{
 		runner: helpers.NewOSCommandRunner(),
+		lock:   syspkg.LockRetry{Database: "pacman database", IsLock: IsLockError},
 	}
 }
 
@@ -28,9 +32,27 @@ func NewPacmanProvider() *PacmanProvider {
 func NewPacmanProviderWithRunner(runner helpers.CommandRunner) *PacmanProvider {
 	return &PacmanProvider{
 		runner: runner,
+		lock:   syspkg.LockRetry{Database: "pacman database", IsLock: IsLockError},
 	}
 }
 
+// WithLockRetry retries pacman up to attempts extra times when the database is
+// locked by another pacman process, doubling delay after each attempt
+func (p *PacmanProvider) WithLockRetry(attempts int, delay time.Duration) *PacmanProvider {
+	p.lock.Retries = attempts
+	p.lock.Delay = delay
+	return p
+}
+
+// IsLockError reports whether err comes from pacman failing to lock its database
+func IsLockError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "unable to lock database") || strings.Contains(msg, "db.lck")
+}
+
 func (p *PacmanProvider) Name() string {
 	return "pacman"
 }
@@ -46,8 +68,7 @@ func (p *PacmanProvider) Install(ctx context.Context, pkgPath string, opts *sysp
 
 	args = append(args, pkgPath)
 
-	_, err := p.runner.RunCommand(ctx, "sudo", args...)
-	if err != nil {
+	if err := p.lock.Run(ctx, p.runner, args...); err != nil {
 		return fmt.Errorf("pacman installation failed: %w", err)
 	}
 	return nil
@@ -55,8 +76,7 @@ func (p *PacmanProvider) Install(ctx context.Context, pkgPath string, opts *sysp
 
 // Remove removes a package by name
 func (p *PacmanProvider) Remove(ctx context.Context, pkgName string) error {
-	_, err := p.runner.RunCommand(ctx, "sudo", "pacman", "-R", "--noconfirm", pkgName)
-	if err != nil {
+	if err := p.lock.Run(ctx, p.runner, "pacman", "-R", "--noconfirm", pkgName); err != nil {
 		return fmt.Errorf("pacman removal failed: %w", err)
 	}
 	return nil
@@ -87,11 +107,43 @@ func (p *PacmanProvider) GetInfo(ctx context.Context, pkgName string) (*syspkg.P
 				info.Version = strings.TrimSpace(parts[1])
 			}
 		}
+		if strings.HasPrefix(line, "Installed Size") {
+			if _, value, ok := strings.Cut(line, ":"); ok {
+				info.InstalledSize = parseInstalledSize(value)
+			}
+		}
 	}
 
 	return info, nil
 }
 
+// parseInstalledSize converts pacman's "Installed Size" ("12.34 MiB") to
+// bytes; unparseable values are 0
+func parseInstalledSize(value string) int64 {
+	number, unit, ok := strings.Cut(strings.TrimSpace(value), " ")
+	if !ok {
+		return 0
+	}
+	// Some locales print a decimal comma
+	size, err := strconv.ParseFloat(strings.Replace(number, ",", ".", 1), 64)
+	if err != nil || size < 0 {
+		return 0
+	}
+
+	multipliers := map[string]float64{
+		"B":   1,
+		"KiB": 1 << 10,
+		"MiB": 1 << 20,
+		"GiB": 1 << 30,
+		"TiB": 1 << 40,
+	}
+	multiplier, ok := multipliers[strings.TrimSpace(unit)]
+	if !ok {
+		return 0
+	}
+	return int64(size * multiplier)
+}
+
 // ListFiles lists files owned by the package
 func (p *PacmanProvider) ListFiles(ctx context.Context, pkgName string) ([]string, error) {
 	output, err := p.runner.RunCommand(ctx, "pacman", "-Ql", pkgName)
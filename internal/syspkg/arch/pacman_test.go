@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/quantmind-br/upkg/internal/helpers"
 	"github.com/quantmind-br/upkg/internal/syspkg"
@@ -122,7 +123,7 @@ func TestPacmanProvider_GetInfo(t *testing.T) {
 		mockRunner.RunCommandFunc = func(_ context.Context, name string, args ...string) (string, error) {
 			assert.Equal(t, "pacman", name)
 			assert.Equal(t, []string{"-Qi", "test-package"}, args)
-			return "Name: test-package\nVersion: 1.0.0\nDescription: Test package", nil
+			return "Name: test-package\nVersion: 1.0.0\nDescription: Test package\nInstalled Size  : 1.50 MiB", nil
 		}
 
 		info, err := provider.GetInfo(context.Background(), "test-package")
@@ -130,6 +131,7 @@ func TestPacmanProvider_GetInfo(t *testing.T) {
 		assert.NotNil(t, info)
 		assert.Equal(t, "test-package", info.Name)
 		assert.Equal(t, "1.0.0", info.Version)
+		assert.Equal(t, int64(1572864), info.InstalledSize)
 	})
 
 	// Test case: Package not found
@@ -145,6 +147,23 @@ func TestPacmanProvider_GetInfo(t *testing.T) {
 	})
 }
 
+func TestParseInstalledSize(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+	}{
+		{"512.00 B", 512},
+		{"4.00 KiB", 4096},
+		{" 1.50 MiB", 1572864},
+		{"2,00 GiB", 2 << 30},
+		{"12 parsecs", 0},
+		{"unknown", 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, parseInstalledSize(tt.value), tt.value)
+	}
+}
+
 func TestNewPacmanProvider(t *testing.T) {
 	t.Run("creates provider with OS runner", func(t *testing.T) {
 		provider := NewPacmanProvider()
@@ -196,3 +215,68 @@ func TestPacmanProvider_ListFiles(t *testing.T) {
 		assert.Nil(t, files)
 	})
 }
+
+func TestPacmanProvider_LockRetry(t *testing.T) {
+	lockErr := errors.New("command \"sudo\" failed: exit status 1\nstderr: error: failed to init transaction (unable to lock database)")
+
+	newProvider := func(failures int, calls *int, delays *[]time.Duration) *PacmanProvider {
+		runner := &helpers.MockCommandRunner{
+			RunCommandFunc: func(_ context.Context, _ string, _ ...string) (string, error) {
+				*calls++
+				if *calls <= failures {
+					return "", lockErr
+				}
+				return "", nil
+			},
+		}
+		provider := NewPacmanProviderWithRunner(runner).WithLockRetry(3, time.Second)
+		provider.lock.Sleep = func(_ context.Context, d time.Duration) error {
+			*delays = append(*delays, d)
+			return nil
+		}
+		return provider
+	}
+
+	t.Run("succeeds after lock clears", func(t *testing.T) {
+		var calls int
+		var delays []time.Duration
+		err := newProvider(2, &calls, &delays).Install(context.Background(), "test.pkg.tar.zst", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, delays)
+	})
+
+	t.Run("gives up after configured retries", func(t *testing.T) {
+		var calls int
+		var delays []time.Duration
+		err := newProvider(10, &calls, &delays).Remove(context.Background(), "pkg")
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "still locked after 3 retries")
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("does not retry other errors", func(t *testing.T) {
+		var calls int
+		runner := &helpers.MockCommandRunner{
+			RunCommandFunc: func(_ context.Context, _ string, _ ...string) (string, error) {
+				calls++
+				return "", errors.New("target not found")
+			},
+		}
+		err := NewPacmanProviderWithRunner(runner).WithLockRetry(3, time.Second).Install(context.Background(), "x", nil)
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("stops when context is cancelled", func(t *testing.T) {
+		runner := &helpers.MockCommandRunner{
+			RunCommandFunc: func(_ context.Context, _ string, _ ...string) (string, error) {
+				return "", lockErr
+			},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := NewPacmanProviderWithRunner(runner).WithLockRetry(3, time.Hour).Install(ctx, "x", nil)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,155 @@
+package debian
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/syspkg"
+)
+
+// Ensure DpkgProvider implements Provider interface
+var _ syspkg.Provider = (*DpkgProvider)(nil)
+
+// DpkgProvider implements the Provider interface for Debian and Ubuntu.
+// Packages are installed with apt when available, which also pulls in their
+// dependencies, and with dpkg -i otherwise. Queries use dpkg-query.
+type DpkgProvider struct {
+	runner helpers.CommandRunner
+	lock   syspkg.LockRetry
+}
+
+// NewDpkgProvider creates a new dpkg provider
+func NewDpkgProvider() *DpkgProvider {
+	return &DpkgProvider{
+		runner: helpers.NewOSCommandRunner(),
+		lock:   syspkg.LockRetry{Database: "dpkg database", IsLock: IsLockError},
+	}
+}
+
+// NewDpkgProviderWithRunner creates a new dpkg provider with a custom command runner
+func NewDpkgProviderWithRunner(runner helpers.CommandRunner) *DpkgProvider {
+	return &DpkgProvider{
+		runner: runner,
+		lock:   syspkg.LockRetry{Database: "dpkg database", IsLock: IsLockError},
+	}
+}
+
+// WithLockRetry retries apt/dpkg up to attempts extra times when the dpkg
+// database is locked by another package manager, doubling delay after each attempt
+func (p *DpkgProvider) WithLockRetry(attempts int, delay time.Duration) *DpkgProvider {
+	p.lock.Retries = attempts
+	p.lock.Delay = delay
+	return p
+}
+
+// IsLockError reports whether err comes from apt or dpkg failing to lock the
+// dpkg database
+func IsLockError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "Could not get lock") ||
+		strings.Contains(msg, "dpkg frontend lock") ||
+		strings.Contains(msg, "dpkg status database is locked")
+}
+
+func (p *DpkgProvider) Name() string {
+	return "dpkg"
+}
+
+// Install installs a package from a local path. pkgPath must be absolute or
+// start with ./ for apt to treat it as a file.
+func (p *DpkgProvider) Install(ctx context.Context, pkgPath string, opts *syspkg.InstallOptions) error {
+	overwrite := opts != nil && opts.Overwrite
+
+	var args []string
+	if p.runner.CommandExists("apt-get") {
+		args = []string{"apt-get", "install", "-y"}
+		if overwrite {
+			args = append(args, "-o", "Dpkg::Options::=--force-overwrite")
+		}
+	} else {
+		args = []string{"dpkg", "-i"}
+		if overwrite {
+			args = append(args, "--force-overwrite")
+		}
+	}
+	args = append(args, pkgPath)
+
+	if err := p.lock.Run(ctx, p.runner, args...); err != nil {
+		return fmt.Errorf("dpkg installation failed: %w", err)
+	}
+	return nil
+}
+
+// Remove removes a package by name
+func (p *DpkgProvider) Remove(ctx context.Context, pkgName string) error {
+	args := []string{"dpkg", "-r", pkgName}
+	if p.runner.CommandExists("apt-get") {
+		args = []string{"apt-get", "remove", "-y", pkgName}
+	}
+	if err := p.lock.Run(ctx, p.runner, args...); err != nil {
+		return fmt.Errorf("dpkg removal failed: %w", err)
+	}
+	return nil
+}
+
+// IsInstalled checks if a package is installed. Packages that were removed
+// but keep their configuration files ("config-files") are not installed.
+func (p *DpkgProvider) IsInstalled(ctx context.Context, pkgName string) (bool, error) {
+	output, err := p.runner.RunCommand(ctx, "dpkg-query", "-W", "-f=${db:Status-Status}", pkgName)
+	if err != nil {
+		return false, nil // dpkg-query fails for unknown packages
+	}
+	return strings.TrimSpace(output) == "installed", nil
+}
+
+// GetInfo retrieves package information
+func (p *DpkgProvider) GetInfo(ctx context.Context, pkgName string) (*syspkg.PackageInfo, error) {
+	output, err := p.runner.RunCommand(ctx, "dpkg-query", "-W", "-f=${Package}\t${Version}\t${Installed-Size}\n", pkgName)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &syspkg.PackageInfo{Name: pkgName}
+	// Multi-arch packages may print one line per installed architecture
+	line, _, _ := strings.Cut(strings.TrimSpace(output), "\n")
+	if name, rest, ok := strings.Cut(line, "\t"); ok {
+		if name != "" {
+			info.Name = name
+		}
+		version, size, _ := strings.Cut(rest, "\t")
+		info.Version = strings.TrimSpace(version)
+		// Installed-Size is an estimate in KiB
+		if kib, err := strconv.ParseInt(strings.TrimSpace(size), 10, 64); err == nil {
+			info.InstalledSize = kib * 1024
+		}
+	}
+
+	return info, nil
+}
+
+// ListFiles lists files owned by the package
+func (p *DpkgProvider) ListFiles(ctx context.Context, pkgName string) ([]string, error) {
+	output, err := p.runner.RunCommand(ctx, "dpkg-query", "-L", pkgName)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		// Skip the "/." root entry and diversion notes ("diverted by ...")
+		if !strings.HasPrefix(line, "/") || line == "/." {
+			continue
+		}
+		files = append(files, line)
+	}
+
+	return files, nil
+}
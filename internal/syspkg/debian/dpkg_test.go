@@ -0,0 +1,161 @@
+package debian
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/syspkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDpkgProvider_Install(t *testing.T) {
+	tests := []struct {
+		name     string
+		hasApt   bool
+		opts     *syspkg.InstallOptions
+		wantArgs []string
+	}{
+		{"apt", true, nil, []string{"apt-get", "install", "-y", "/tmp/app.deb"}},
+		{"apt with overwrite", true, &syspkg.InstallOptions{Overwrite: true},
+			[]string{"apt-get", "install", "-y", "-o", "Dpkg::Options::=--force-overwrite", "/tmp/app.deb"}},
+		{"dpkg", false, nil, []string{"dpkg", "-i", "/tmp/app.deb"}},
+		{"dpkg with overwrite", false, &syspkg.InstallOptions{Overwrite: true},
+			[]string{"dpkg", "-i", "--force-overwrite", "/tmp/app.deb"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &helpers.MockCommandRunner{
+				CommandExistsFunc: func(name string) bool { return tt.hasApt && name == "apt-get" },
+				RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+					assert.Equal(t, "sudo", name)
+					assert.Equal(t, tt.wantArgs, args)
+					return "", nil
+				},
+			}
+			assert.NoError(t, NewDpkgProviderWithRunner(runner).Install(context.Background(), "/tmp/app.deb", tt.opts))
+		})
+	}
+
+	t.Run("failed installation", func(t *testing.T) {
+		runner := &helpers.MockCommandRunner{
+			RunCommandFunc: func(_ context.Context, _ string, _ ...string) (string, error) {
+				return "", errors.New("dependency problems")
+			},
+		}
+		err := NewDpkgProviderWithRunner(runner).Install(context.Background(), "/tmp/app.deb", nil)
+		assert.ErrorContains(t, err, "dpkg installation failed")
+	})
+}
+
+func TestDpkgProvider_Remove(t *testing.T) {
+	for _, hasApt := range []bool{true, false} {
+		want := []string{"dpkg", "-r", "app"}
+		if hasApt {
+			want = []string{"apt-get", "remove", "-y", "app"}
+		}
+		runner := &helpers.MockCommandRunner{
+			CommandExistsFunc: func(string) bool { return hasApt },
+			RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+				assert.Equal(t, "sudo", name)
+				assert.Equal(t, want, args)
+				return "", nil
+			},
+		}
+		assert.NoError(t, NewDpkgProviderWithRunner(runner).Remove(context.Background(), "app"))
+	}
+}
+
+func TestDpkgProvider_IsInstalled(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		err    error
+		want   bool
+	}{
+		{"installed", "installed", nil, true},
+		{"config files only", "config-files", nil, false},
+		{"unknown package", "", errors.New("dpkg-query: no packages found matching app"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &helpers.MockCommandRunner{
+				RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+					assert.Equal(t, "dpkg-query", name)
+					assert.Equal(t, []string{"-W", "-f=${db:Status-Status}", "app"}, args)
+					return tt.output, tt.err
+				},
+			}
+			installed, err := NewDpkgProviderWithRunner(runner).IsInstalled(context.Background(), "app")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, installed)
+		})
+	}
+}
+
+func TestDpkgProvider_GetInfo(t *testing.T) {
+	runner := &helpers.MockCommandRunner{
+		RunCommandFunc: func(_ context.Context, _ string, _ ...string) (string, error) {
+			return "app\t1:2.3.4-1ubuntu1\t2048\napp\t1:2.3.4-1ubuntu1\t2048\n", nil
+		},
+	}
+	info, err := NewDpkgProviderWithRunner(runner).GetInfo(context.Background(), "app")
+	require.NoError(t, err)
+	assert.Equal(t, "app", info.Name)
+	assert.Equal(t, "1:2.3.4-1ubuntu1", info.Version)
+	assert.Equal(t, int64(2048*1024), info.InstalledSize)
+
+	runner.RunCommandFunc = func(_ context.Context, _ string, _ ...string) (string, error) {
+		return "", errors.New("no packages found")
+	}
+	_, err = NewDpkgProviderWithRunner(runner).GetInfo(context.Background(), "missing")
+	assert.Error(t, err)
+}
+
+func TestDpkgProvider_ListFiles(t *testing.T) {
+	runner := &helpers.MockCommandRunner{
+		RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+			assert.Equal(t, "dpkg-query", name)
+			assert.Equal(t, []string{"-L", "app"}, args)
+			return "/.\n/usr\n/usr/bin/app\n/usr/share/applications/app.desktop\n" +
+				"diverted by other to: /usr/bin/app.distrib\n", nil
+		},
+	}
+	files, err := NewDpkgProviderWithRunner(runner).ListFiles(context.Background(), "app")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/usr", "/usr/bin/app", "/usr/share/applications/app.desktop"}, files)
+}
+
+func TestDpkgProvider_LockRetry(t *testing.T) {
+	lockErr := errors.New("command \"sudo\" failed: exit status 100\nstderr: E: Could not get lock /var/lib/dpkg/lock-frontend")
+
+	var calls int
+	var delays []time.Duration
+	runner := &helpers.MockCommandRunner{
+		RunCommandFunc: func(_ context.Context, _ string, _ ...string) (string, error) {
+			calls++
+			if calls <= 2 {
+				return "", lockErr
+			}
+			return "", nil
+		},
+	}
+	provider := NewDpkgProviderWithRunner(runner).WithLockRetry(3, time.Second)
+	provider.lock.Sleep = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+
+	require.NoError(t, provider.Install(context.Background(), "/tmp/app.deb", nil))
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, delays)
+
+	assert.True(t, IsLockError(lockErr))
+	assert.False(t, IsLockError(errors.New("dependency problems")))
+	assert.False(t, IsLockError(nil))
+}
@@ -0,0 +1,124 @@
+package syspkg
+
+import (
+	"bufio"
+	"sort"
+	"strings"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/spf13/afero"
+)
+
+// DetectDistroFamily maps /etc/os-release ID and ID_LIKE to a distro family
+// (core.DistroArch, core.DistroDebian, ...); empty when unknown
+func DetectDistroFamily(fs afero.Fs) string {
+	file, err := fs.Open("/etc/os-release")
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = file.Close() }()
+
+	var ids []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok || (key != "ID" && key != "ID_LIKE") {
+			continue
+		}
+		ids = append(ids, strings.Fields(strings.Trim(value, `"'`))...)
+	}
+
+	for _, id := range ids {
+		switch id {
+		case "arch", "manjaro", "endeavouros", "cachyos":
+			return core.DistroArch
+		case "debian", "ubuntu":
+			return core.DistroDebian
+		case "fedora", "rhel", "centos":
+			return core.DistroFedora
+		case "suse", "opensuse", "opensuse-tumbleweed", "opensuse-leap":
+			return core.DistroSUSE
+		}
+	}
+	return ""
+}
+
+// Provider names accepted by the syspkg.provider setting
+const (
+	ProviderAuto   = "auto"
+	ProviderPacman = "pacman"
+	ProviderDpkg   = "dpkg"
+	ProviderDnf    = "dnf"
+)
+
+// HostProvider returns the native package manager for this host: the
+// configured provider when one is set, otherwise the one of the distro
+// family (dnf also covers openSUSE). It is empty when the family is unknown.
+func HostProvider(fs afero.Fs, configured string) string {
+	switch configured {
+	case ProviderPacman, ProviderDpkg, ProviderDnf:
+		return configured
+	}
+
+	switch DetectDistroFamily(fs) {
+	case core.DistroArch:
+		return ProviderPacman
+	case core.DistroDebian:
+		return ProviderDpkg
+	case core.DistroFedora, core.DistroSUSE:
+		return ProviderDnf
+	}
+	return ""
+}
+
+// InstallHint returns the command installing the packages that provide
+// reqs on the distro family. Requirements without a package for the family
+// fall back to the tool name; AUR packages go through an AUR helper.
+func InstallHint(distro string, reqs ...core.ToolRequirement) string {
+	seen := make(map[string]bool)
+	var pkgs, aur []string
+	for _, req := range reqs {
+		pkg := req.Packages[distro]
+		if pkg == "" {
+			pkg = req.Name
+		}
+		if seen[pkg] {
+			continue
+		}
+		seen[pkg] = true
+		if req.AUR && distro == core.DistroArch {
+			aur = append(aur, pkg)
+		} else {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	sort.Strings(pkgs)
+	sort.Strings(aur)
+
+	var cmds []string
+	if len(pkgs) > 0 {
+		cmds = append(cmds, installCommand(distro, pkgs...))
+	}
+	if len(aur) > 0 {
+		cmds = append(cmds, "yay -S --needed "+strings.Join(aur, " "))
+	}
+	return strings.Join(cmds, " && ")
+}
+
+// installCommand returns the command installing pkgs with the package
+// manager of the distro family
+func installCommand(distro string, pkgs ...string) string {
+	list := strings.Join(pkgs, " ")
+	switch distro {
+	case core.DistroArch:
+		return "sudo pacman -S --needed " + list
+	case core.DistroDebian:
+		return "sudo apt install " + list
+	case core.DistroFedora:
+		return "sudo dnf install " + list
+	case core.DistroSUSE:
+		return "sudo zypper install " + list
+	default:
+		return "install: " + list
+	}
+}
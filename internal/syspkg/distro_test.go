@@ -0,0 +1,47 @@
+package syspkg
+
+import (
+	"testing"
+
+	"github.com/quantmind-br/upkg/internal/core"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostProvider(t *testing.T) {
+	tests := []struct {
+		name       string
+		osRelease  string
+		configured string
+		want       string
+	}{
+		{"arch", "ID=arch\n", ProviderAuto, ProviderPacman},
+		{"ubuntu", "ID=ubuntu\nID_LIKE=debian\n", ProviderAuto, ProviderDpkg},
+		{"fedora", "ID=fedora\n", "", ProviderDnf},
+		{"opensuse", "ID=\"opensuse-tumbleweed\"\nID_LIKE=\"opensuse suse\"\n", ProviderAuto, ProviderDnf},
+		{"unknown", "ID=nixos\n", ProviderAuto, ""},
+		{"override", "ID=debian\n", ProviderDnf, ProviderDnf},
+		{"invalid override ignored", "ID=arch\n", "yum", ProviderPacman},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "/etc/os-release", []byte(tt.osRelease), 0644))
+			assert.Equal(t, tt.want, HostProvider(fs, tt.configured))
+		})
+	}
+}
+
+func TestInstallHint(t *testing.T) {
+	bsdtar := core.ToolRequirement{Name: "bsdtar", Packages: map[string]string{core.DistroDebian: "libarchive-tools"}}
+	debtap := core.ToolRequirement{Name: "debtap", Packages: map[string]string{core.DistroArch: "debtap"}, AUR: true}
+
+	assert.Equal(t, "sudo apt install libarchive-tools", InstallHint(core.DistroDebian, bsdtar))
+	assert.Equal(t, "sudo dnf install bsdtar", InstallHint(core.DistroFedora, bsdtar))
+	assert.Equal(t, "install: bsdtar", InstallHint("", bsdtar))
+	assert.Equal(t, "yay -S --needed debtap", InstallHint(core.DistroArch, debtap))
+	assert.Equal(t, "sudo pacman -S --needed bsdtar && yay -S --needed debtap", InstallHint(core.DistroArch, debtap, bsdtar, bsdtar))
+	assert.Empty(t, InstallHint(core.DistroArch))
+}
@@ -0,0 +1,163 @@
+package fedora
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/syspkg"
+)
+
+// Ensure DnfProvider implements Provider interface
+var _ syspkg.Provider = (*DnfProvider)(nil)
+
+// DnfProvider implements the Provider interface for Fedora and openSUSE.
+// Packages are installed with dnf when available, which also pulls in their
+// dependencies, with zypper on openSUSE and with rpm -U otherwise. Queries
+// use the rpm database directly.
+type DnfProvider struct {
+	runner helpers.CommandRunner
+	lock   syspkg.LockRetry
+}
+
+// NewDnfProvider creates a new dnf provider
+func NewDnfProvider() *DnfProvider {
+	return &DnfProvider{
+		runner: helpers.NewOSCommandRunner(),
+		lock:   syspkg.LockRetry{Database: "rpm database", IsLock: IsLockError},
+	}
+}
+
+// NewDnfProviderWithRunner creates a new dnf provider with a custom command runner
+func NewDnfProviderWithRunner(runner helpers.CommandRunner) *DnfProvider {
+	return &DnfProvider{
+		runner: runner,
+		lock:   syspkg.LockRetry{Database: "rpm database", IsLock: IsLockError},
+	}
+}
+
+// WithLockRetry retries dnf/zypper/rpm up to attempts extra times when the
+// rpm database is locked by another package manager, doubling delay after
+// each attempt
+func (p *DnfProvider) WithLockRetry(attempts int, delay time.Duration) *DnfProvider {
+	p.lock.Retries = attempts
+	p.lock.Delay = delay
+	return p
+}
+
+// IsLockError reports whether err comes from dnf, zypper or rpm failing to
+// lock the package database
+func IsLockError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "Failed to obtain the transaction lock") ||
+		strings.Contains(msg, "System management is locked") ||
+		strings.Contains(msg, "can't create transaction lock")
+}
+
+func (p *DnfProvider) Name() string {
+	return "dnf"
+}
+
+// Install installs a package from a local path. dnf cannot replace files
+// owned by other packages, so Overwrite uses rpm --replacefiles unless
+// zypper is available.
+func (p *DnfProvider) Install(ctx context.Context, pkgPath string, opts *syspkg.InstallOptions) error {
+	overwrite := opts != nil && opts.Overwrite
+
+	var args []string
+	switch {
+	case p.runner.CommandExists("dnf") && !overwrite:
+		args = []string{"dnf", "install", "-y", pkgPath}
+	case p.runner.CommandExists("zypper"):
+		args = []string{"zypper", "--non-interactive", "install", "--allow-unsigned-rpm"}
+		if overwrite {
+			args = append(args, "--replacefiles")
+		}
+		args = append(args, pkgPath)
+	default:
+		args = []string{"rpm", "-U"}
+		if overwrite {
+			args = append(args, "--replacefiles")
+		}
+		args = append(args, pkgPath)
+	}
+
+	if err := p.lock.Run(ctx, p.runner, args...); err != nil {
+		return fmt.Errorf("dnf installation failed: %w", err)
+	}
+	return nil
+}
+
+// Remove removes a package by name
+func (p *DnfProvider) Remove(ctx context.Context, pkgName string) error {
+	args := []string{"rpm", "-e", pkgName}
+	switch {
+	case p.runner.CommandExists("dnf"):
+		args = []string{"dnf", "remove", "-y", pkgName}
+	case p.runner.CommandExists("zypper"):
+		args = []string{"zypper", "--non-interactive", "remove", pkgName}
+	}
+	if err := p.lock.Run(ctx, p.runner, args...); err != nil {
+		return fmt.Errorf("dnf removal failed: %w", err)
+	}
+	return nil
+}
+
+// IsInstalled checks if a package is installed
+func (p *DnfProvider) IsInstalled(ctx context.Context, pkgName string) (bool, error) {
+	_, err := p.runner.RunCommand(ctx, "rpm", "-q", pkgName)
+	if err != nil {
+		return false, nil // rpm -q exits non-zero for unknown packages
+	}
+	return true, nil
+}
+
+// GetInfo retrieves package information
+func (p *DnfProvider) GetInfo(ctx context.Context, pkgName string) (*syspkg.PackageInfo, error) {
+	output, err := p.runner.RunCommand(ctx, "rpm", "-q", "--qf", `%{NAME}\t%{VERSION}-%{RELEASE}\t%{SIZE}\n`, pkgName)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &syspkg.PackageInfo{Name: pkgName}
+	// Multilib packages print one line per installed architecture
+	line, _, _ := strings.Cut(strings.TrimSpace(output), "\n")
+	if name, rest, ok := strings.Cut(line, "\t"); ok {
+		if name != "" {
+			info.Name = name
+		}
+		version, size, _ := strings.Cut(rest, "\t")
+		info.Version = strings.TrimSpace(version)
+		if bytes, err := strconv.ParseInt(strings.TrimSpace(size), 10, 64); err == nil {
+			info.InstalledSize = bytes
+		}
+	}
+
+	return info, nil
+}
+
+// ListFiles lists files owned by the package
+func (p *DnfProvider) ListFiles(ctx context.Context, pkgName string) ([]string, error) {
+	output, err := p.runner.RunCommand(ctx, "rpm", "-ql", pkgName)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		// Skip notes such as "(contains no files)"
+		if !strings.HasPrefix(line, "/") {
+			continue
+		}
+		files = append(files, line)
+	}
+
+	return files, nil
+}
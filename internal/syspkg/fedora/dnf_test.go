@@ -0,0 +1,178 @@
+package fedora
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/quantmind-br/upkg/internal/syspkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDnfProvider_Install(t *testing.T) {
+	tests := []struct {
+		name     string
+		tools    []string
+		opts     *syspkg.InstallOptions
+		wantArgs []string
+	}{
+		{"dnf", []string{"dnf", "rpm"}, nil, []string{"dnf", "install", "-y", "/tmp/app.rpm"}},
+		{"dnf with overwrite", []string{"dnf", "rpm"}, &syspkg.InstallOptions{Overwrite: true},
+			[]string{"rpm", "-U", "--replacefiles", "/tmp/app.rpm"}},
+		{"zypper", []string{"zypper", "rpm"}, nil,
+			[]string{"zypper", "--non-interactive", "install", "--allow-unsigned-rpm", "/tmp/app.rpm"}},
+		{"zypper with overwrite", []string{"zypper", "rpm"}, &syspkg.InstallOptions{Overwrite: true},
+			[]string{"zypper", "--non-interactive", "install", "--allow-unsigned-rpm", "--replacefiles", "/tmp/app.rpm"}},
+		{"rpm", []string{"rpm"}, nil, []string{"rpm", "-U", "/tmp/app.rpm"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &helpers.MockCommandRunner{
+				CommandExistsFunc: func(name string) bool {
+					for _, tool := range tt.tools {
+						if tool == name {
+							return true
+						}
+					}
+					return false
+				},
+				RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+					assert.Equal(t, "sudo", name)
+					assert.Equal(t, tt.wantArgs, args)
+					return "", nil
+				},
+			}
+			assert.NoError(t, NewDnfProviderWithRunner(runner).Install(context.Background(), "/tmp/app.rpm", tt.opts))
+		})
+	}
+
+	t.Run("failed installation", func(t *testing.T) {
+		runner := &helpers.MockCommandRunner{
+			RunCommandFunc: func(_ context.Context, _ string, _ ...string) (string, error) {
+				return "", errors.New("nothing provides libfoo.so.1")
+			},
+		}
+		err := NewDnfProviderWithRunner(runner).Install(context.Background(), "/tmp/app.rpm", nil)
+		assert.ErrorContains(t, err, "dnf installation failed")
+	})
+}
+
+func TestDnfProvider_Remove(t *testing.T) {
+	tests := []struct {
+		tool string
+		want []string
+	}{
+		{"dnf", []string{"dnf", "remove", "-y", "app"}},
+		{"zypper", []string{"zypper", "--non-interactive", "remove", "app"}},
+		{"", []string{"rpm", "-e", "app"}},
+	}
+
+	for _, tt := range tests {
+		runner := &helpers.MockCommandRunner{
+			CommandExistsFunc: func(name string) bool { return name == tt.tool },
+			RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+				assert.Equal(t, "sudo", name)
+				assert.Equal(t, tt.want, args)
+				return "", nil
+			},
+		}
+		assert.NoError(t, NewDnfProviderWithRunner(runner).Remove(context.Background(), "app"))
+	}
+}
+
+func TestDnfProvider_IsInstalled(t *testing.T) {
+	runner := &helpers.MockCommandRunner{
+		RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+			assert.Equal(t, "rpm", name)
+			if args[1] == "app" {
+				return "app-1.0-1.x86_64", nil
+			}
+			return "", errors.New("package missing is not installed")
+		},
+	}
+	provider := NewDnfProviderWithRunner(runner)
+
+	installed, err := provider.IsInstalled(context.Background(), "app")
+	require.NoError(t, err)
+	assert.True(t, installed)
+
+	installed, err = provider.IsInstalled(context.Background(), "missing")
+	require.NoError(t, err)
+	assert.False(t, installed)
+}
+
+func TestDnfProvider_GetInfo(t *testing.T) {
+	runner := &helpers.MockCommandRunner{
+		RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+			assert.Equal(t, "rpm", name)
+			assert.Equal(t, []string{"-q", "--qf", `%{NAME}\t%{VERSION}-%{RELEASE}\t%{SIZE}\n`, "app"}, args)
+			return "app\t2.3.4-1.fc40\t5242880\napp\t2.3.4-1.fc40\t5242880\n", nil
+		},
+	}
+	info, err := NewDnfProviderWithRunner(runner).GetInfo(context.Background(), "app")
+	require.NoError(t, err)
+	assert.Equal(t, "app", info.Name)
+	assert.Equal(t, "2.3.4-1.fc40", info.Version)
+	assert.Equal(t, int64(5242880), info.InstalledSize)
+
+	runner.RunCommandFunc = func(_ context.Context, _ string, _ ...string) (string, error) {
+		return "", errors.New("package missing is not installed")
+	}
+	_, err = NewDnfProviderWithRunner(runner).GetInfo(context.Background(), "missing")
+	assert.Error(t, err)
+}
+
+func TestDnfProvider_ListFiles(t *testing.T) {
+	runner := &helpers.MockCommandRunner{
+		RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+			assert.Equal(t, "rpm", name)
+			assert.Equal(t, []string{"-ql", "app"}, args)
+			return "/opt/app\n/opt/app/app\n/usr/share/applications/app.desktop\n", nil
+		},
+	}
+	files, err := NewDnfProviderWithRunner(runner).ListFiles(context.Background(), "app")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/opt/app", "/opt/app/app", "/usr/share/applications/app.desktop"}, files)
+
+	runner.RunCommandFunc = func(_ context.Context, _ string, _ ...string) (string, error) {
+		return "(contains no files)\n", nil
+	}
+	files, err = NewDnfProviderWithRunner(runner).ListFiles(context.Background(), "app")
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestDnfProvider_LockRetry(t *testing.T) {
+	lockErr := errors.New("command \"sudo\" failed: exit status 7\nstderr: System management is locked by the application with pid 1234 (zypper).")
+
+	var calls int
+	var delays []time.Duration
+	runner := &helpers.MockCommandRunner{
+		CommandExistsFunc: func(name string) bool { return name == "zypper" },
+		RunCommandFunc: func(_ context.Context, _ string, _ ...string) (string, error) {
+			calls++
+			if calls <= 2 {
+				return "", lockErr
+			}
+			return "", nil
+		},
+	}
+	provider := NewDnfProviderWithRunner(runner).WithLockRetry(3, time.Second)
+	provider.lock.Sleep = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+
+	require.NoError(t, provider.Install(context.Background(), "/tmp/app.rpm", nil))
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, delays)
+
+	assert.True(t, IsLockError(errors.New("error: can't create transaction lock on /var/lib/rpm/.rpm.lock (Resource temporarily unavailable)")))
+	assert.True(t, IsLockError(errors.New("Error: Failed to obtain the transaction lock (logged in as: root).")))
+	assert.False(t, IsLockError(errors.New("nothing provides libfoo.so.1")))
+	assert.False(t, IsLockError(nil))
+}
@@ -0,0 +1,59 @@
+package syspkg
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/helpers"
+)
+
+// maxLockRetryDelay caps the exponential backoff between lock retries
+const maxLockRetryDelay = time.Minute
+
+// LockRetry runs sudo package manager commands, backing off while another
+// process holds the package database lock
+type LockRetry struct {
+	Retries  int                                              // Extra attempts after the first
+	Delay    time.Duration                                    // Backoff before the first retry, doubled after each one
+	Database string                                           // Locked database named in errors ("pacman database")
+	IsLock   func(error) bool                                 // Reports whether an error is a lock failure
+	Sleep    func(ctx context.Context, d time.Duration) error // Waits between attempts; nil uses a timer
+}
+
+// Run runs sudo with args, retrying up to Retries times while the command
+// fails on the database lock
+func (l LockRetry) Run(ctx context.Context, runner helpers.CommandRunner, args ...string) error {
+	sleep := l.Sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+
+	delay := l.Delay
+	for attempt := 0; ; attempt++ {
+		_, err := runner.RunCommand(ctx, "sudo", args...)
+		if !l.IsLock(err) {
+			return err
+		}
+		if attempt >= l.Retries {
+			return fmt.Errorf("%s still locked after %d retries (is another package manager running?): %w", l.Database, attempt, err)
+		}
+
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			return fmt.Errorf("waiting for %s lock: %w", l.Database, sleepErr)
+		}
+		delay = min(delay*2, maxLockRetryDelay)
+	}
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
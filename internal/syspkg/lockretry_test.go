@@ -0,0 +1,47 @@
+package syspkg
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/quantmind-br/upkg/internal/helpers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockRetry_Run(t *testing.T) {
+	errLocked := errors.New("database is locked")
+
+	var calls [][]string
+	runner := &helpers.MockCommandRunner{
+		RunCommandFunc: func(_ context.Context, name string, args ...string) (string, error) {
+			calls = append(calls, append([]string{name}, args...))
+			return "", errLocked
+		},
+	}
+	var delays []time.Duration
+	lock := LockRetry{
+		Retries:  3,
+		Delay:    40 * time.Second,
+		Database: "test database",
+		IsLock:   func(err error) bool { return errors.Is(err, errLocked) },
+		Sleep: func(_ context.Context, d time.Duration) error {
+			delays = append(delays, d)
+			return nil
+		},
+	}
+
+	err := lock.Run(context.Background(), runner, "tool", "install", "pkg")
+	assert.ErrorIs(t, err, errLocked)
+	assert.Contains(t, err.Error(), "test database still locked after 3 retries")
+	assert.Len(t, calls, 4)
+	assert.Equal(t, []string{"sudo", "tool", "install", "pkg"}, calls[0])
+	assert.Equal(t, []time.Duration{40 * time.Second, time.Minute, time.Minute}, delays, "the backoff is capped")
+
+	// Waiting ends with the context
+	lock.Sleep = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, lock.Run(ctx, runner, "tool"), context.Canceled)
+}
@@ -6,8 +6,9 @@ import (
 
 // PackageInfo contains basic package metadata
 type PackageInfo struct {
-	Name    string
-	Version string
+	Name          string
+	Version       string
+	InstalledSize int64 // Bytes; 0 when the package manager does not report it
 }
 
 // InstallOptions contains options for package installation
@@ -0,0 +1,252 @@
+package transaction
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// Journal step actions
+const (
+	// ActionRemove deletes a path the transaction created
+	ActionRemove = "remove"
+	// ActionRename moves a path that was set aside (From) back to Path
+	ActionRename = "rename"
+	// ActionCopy restores Path from a backup copy (From)
+	ActionCopy = "copy"
+)
+
+// journalExt is the file extension of journal files
+const journalExt = ".json"
+
+// Step is one persisted rollback step
+type Step struct {
+	Action string `json:"action"`
+	Path   string `json:"path"`
+	From   string `json:"from,omitempty"`
+}
+
+// Entry is the on-disk content of a journal
+type Entry struct {
+	Operation string    `json:"operation"`
+	Target    string    `json:"target"`
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+	Steps     []Step    `json:"steps"`
+
+	File string `json:"-"` // Journal file the entry was read from
+}
+
+// Journal persists the rollback steps of a running transaction so an
+// interrupted upkg (crash, SIGKILL, power loss) can be cleaned up later
+type Journal struct {
+	fs    afero.Fs
+	path  string
+	entry Entry
+	mu    sync.Mutex
+}
+
+// OpenJournal creates a journal file for operation on target in dir
+func OpenJournal(fs afero.Fs, dir, operation, target string) (*Journal, error) {
+	if err := fs.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create journal directory: %w", err)
+	}
+	started := time.Now()
+	j := &Journal{
+		fs:   fs,
+		path: filepath.Join(dir, fmt.Sprintf("%d-%d%s", started.UnixNano(), os.Getpid(), journalExt)),
+		entry: Entry{
+			Operation: operation,
+			Target:    target,
+			PID:       os.Getpid(),
+			StartedAt: started,
+			Steps:     []Step{},
+		},
+	}
+	if err := j.write(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Path returns the journal file
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// Record appends steps and flushes the journal to disk
+func (j *Journal) Record(steps ...Step) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entry.Steps = append(j.entry.Steps, steps...)
+	return j.write()
+}
+
+// Close removes the journal file once the transaction is settled
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.fs.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove journal: %w", err)
+	}
+	return nil
+}
+
+// write replaces the journal file atomically
+func (j *Journal) write() error {
+	data, err := json.MarshalIndent(j.entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode journal: %w", err)
+	}
+	tmp := j.path + ".tmp"
+	if err := afero.WriteFile(j.fs, tmp, data, 0644); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+	if err := j.fs.Rename(tmp, j.path); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+	return nil
+}
+
+// Pending returns the journals left behind by upkg processes that are no
+// longer running, oldest first
+func Pending(fs afero.Fs, dir string) ([]Entry, error) {
+	entries, err := List(fs, dir)
+	if err != nil {
+		return nil, err
+	}
+	pending := entries[:0]
+	for _, entry := range entries {
+		if !processAlive(entry.PID) {
+			pending = append(pending, entry)
+		}
+	}
+	return pending, nil
+}
+
+// List returns every journal in dir, of running and interrupted
+// transactions alike, oldest first
+func List(fs afero.Fs, dir string) ([]Entry, error) {
+	files, err := afero.Glob(fs, filepath.Join(dir, "*"+journalExt))
+	if err != nil {
+		return nil, fmt.Errorf("list journals: %w", err)
+	}
+	sort.Strings(files)
+
+	var entries []Entry
+	for _, file := range files {
+		data, readErr := afero.ReadFile(fs, file)
+		if readErr != nil {
+			return nil, fmt.Errorf("read journal %s: %w", file, readErr)
+		}
+		var entry Entry
+		if jsonErr := json.Unmarshal(data, &entry); jsonErr != nil {
+			return nil, fmt.Errorf("parse journal %s: %w", file, jsonErr)
+		}
+		entry.File = file
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Recover undoes the steps of an interrupted transaction in reverse order
+// and removes its journal. Steps that fail are reported and the journal is
+// kept so recovery can be retried.
+func Recover(fs afero.Fs, entry Entry) error {
+	var errs []string
+	for i := len(entry.Steps) - 1; i >= 0; i-- {
+		if err := undoStep(fs, entry.Steps[i]); err != nil {
+			errs = append(errs, fmt.Sprintf("%s %s: %v", entry.Steps[i].Action, entry.Steps[i].Path, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("recovery completed with errors: %s", strings.Join(errs, "; "))
+	}
+	if err := fs.Remove(entry.File); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove journal: %w", err)
+	}
+	return nil
+}
+
+// undoStep reverses a single step; steps whose inputs are already gone are
+// treated as done
+func undoStep(fs afero.Fs, step Step) error {
+	switch step.Action {
+	case ActionRemove:
+		return fs.RemoveAll(step.Path)
+	case ActionRename:
+		if _, err := fs.Stat(step.From); os.IsNotExist(err) {
+			return nil
+		}
+		if err := fs.RemoveAll(step.Path); err != nil {
+			return err
+		}
+		return fs.Rename(step.From, step.Path)
+	case ActionCopy:
+		return restoreCopy(fs, step.From, step.Path)
+	default:
+		return fmt.Errorf("unknown action %q", step.Action)
+	}
+}
+
+// restoreCopy puts a backed-up file or symlink back in place
+func restoreCopy(fs afero.Fs, from, to string) error {
+	if lstater, ok := fs.(afero.Lstater); ok {
+		info, _, err := lstater.LstatIfPossible(from)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			reader, ok := fs.(afero.LinkReader)
+			linker, linkOK := fs.(afero.Linker)
+			if !ok || !linkOK {
+				return errors.New("symlinks not supported")
+			}
+			target, err := reader.ReadlinkIfPossible(from)
+			if err != nil {
+				return err
+			}
+			if err := fs.Remove(to); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			return linker.SymlinkIfPossible(target, to)
+		}
+	}
+
+	info, err := fs.Stat(from)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	data, err := afero.ReadFile(fs, from)
+	if err != nil {
+		return err
+	}
+	if err := fs.Remove(to); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return afero.WriteFile(fs, to, data, info.Mode().Perm())
+}
+
+// processAlive reports whether pid is a running process
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
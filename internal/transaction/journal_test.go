@@ -0,0 +1,126 @@
+package transaction
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// orphanJournal opens a journal and rewrites it as if its process had died
+func orphanJournal(t *testing.T, fs afero.Fs, dir string, steps ...Step) string {
+	t.Helper()
+	j, err := OpenJournal(fs, dir, "install", "/tmp/app.tar.gz")
+	require.NoError(t, err)
+	require.NoError(t, j.Record(steps...))
+	j.entry.PID = 0
+	require.NoError(t, j.write())
+	return j.Path()
+}
+
+func TestJournal_RecordAndClose(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	j, err := OpenJournal(fs, "/data/journal", "install", "/tmp/app.tar.gz")
+	require.NoError(t, err)
+
+	require.NoError(t, j.Record(Step{Action: ActionRemove, Path: "/apps/app"}))
+	data, err := afero.ReadFile(fs, j.Path())
+	require.NoError(t, err)
+	var entry Entry
+	require.NoError(t, json.Unmarshal(data, &entry))
+	assert.Equal(t, "install", entry.Operation)
+	assert.Equal(t, os.Getpid(), entry.PID)
+	assert.Equal(t, []Step{{Action: ActionRemove, Path: "/apps/app"}}, entry.Steps)
+
+	// The running process owns the journal
+	pending, err := Pending(fs, "/data/journal")
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	all, err := List(fs, "/data/journal")
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, j.Path(), all[0].File)
+
+	require.NoError(t, j.Close())
+	exists, _ := afero.Exists(fs, j.Path())
+	assert.False(t, exists)
+}
+
+func TestPendingAndRecover(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, fs.MkdirAll("/apps/app/bin", 0755))
+	require.NoError(t, fs.MkdirAll("/apps/old.upgrade-backup", 0755))
+	require.NoError(t, afero.WriteFile(fs, "/bin/app", []byte("new"), 0755))
+	require.NoError(t, afero.WriteFile(fs, "/tmp/backup/app", []byte("old"), 0755))
+
+	file := orphanJournal(t, fs, "/data/journal",
+		Step{Action: ActionRename, Path: "/apps/old", From: "/apps/old.upgrade-backup"},
+		Step{Action: ActionCopy, Path: "/bin/app", From: "/tmp/backup/app"},
+		Step{Action: ActionRemove, Path: "/apps/app"},
+		Step{Action: ActionRemove, Path: "/share/icons/app.png"},
+	)
+
+	pending, err := Pending(fs, "/data/journal")
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, file, pending[0].File)
+	assert.Equal(t, "/tmp/app.tar.gz", pending[0].Target)
+
+	require.NoError(t, Recover(fs, pending[0]))
+
+	exists, _ := afero.DirExists(fs, "/apps/app")
+	assert.False(t, exists, "created directory removed")
+	exists, _ = afero.DirExists(fs, "/apps/old")
+	assert.True(t, exists, "set-aside payload restored")
+	content, err := afero.ReadFile(fs, "/bin/app")
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(content))
+	exists, _ = afero.Exists(fs, file)
+	assert.False(t, exists, "journal removed")
+}
+
+func TestRecover_UnknownActionKeepsJournal(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	file := orphanJournal(t, fs, "/data/journal", Step{Action: "explode", Path: "/x"})
+
+	pending, err := Pending(fs, "/data/journal")
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+
+	assert.ErrorContains(t, Recover(fs, pending[0]), "unknown action")
+	exists, _ := afero.Exists(fs, file)
+	assert.True(t, exists)
+}
+
+func TestManager_Journal(t *testing.T) {
+	logger := zerolog.Nop()
+	fs := afero.NewMemMapFs()
+
+	// Commit drops the journal
+	j, err := OpenJournal(fs, "/journal", "install", "a")
+	require.NoError(t, err)
+	manager := NewManager(&logger)
+	manager.SetJournal(j)
+	manager.TrackPaths("/apps/a", "")
+	manager.Commit()
+	exists, _ := afero.Exists(fs, j.Path())
+	assert.False(t, exists)
+
+	// A failed rollback keeps it for 'upkg recover'
+	j, err = OpenJournal(fs, "/journal", "install", "b")
+	require.NoError(t, err)
+	manager = NewManager(&logger)
+	manager.SetJournal(j)
+	manager.Add("fail", func() error { return errors.New("boom") })
+	manager.TrackPaths("/apps/b")
+	assert.Error(t, manager.Rollback())
+	data, err := afero.ReadFile(fs, j.Path())
+	require.NoError(t, err)
+	assert.Contains(t, string(data), filepath.Join("/apps", "b"))
+}
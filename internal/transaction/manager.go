@@ -16,8 +16,9 @@ type Manager struct {
 		name string
 		fn   RollbackFunc
 	}
-	mu     sync.Mutex
-	logger *zerolog.Logger
+	mu      sync.Mutex
+	logger  *zerolog.Logger
+	journal *Journal // Optional on-disk copy of the steps for crash recovery
 }
 
 // NewManager creates a new transaction manager
@@ -41,12 +42,56 @@ func (m *Manager) Add(name string, fn RollbackFunc) {
 	}{name, fn})
 }
 
+// SetJournal persists the steps recorded with Track to j. The journal is
+// removed on Commit and after a clean Rollback.
+func (m *Manager) SetJournal(j *Journal) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.journal = j
+}
+
+// Track records steps in the journal, if any, so they can be undone after a
+// crash. Failures are logged: the in-process rollback still works.
+func (m *Manager) Track(steps ...Step) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.journal == nil || len(steps) == 0 {
+		return
+	}
+	if err := m.journal.Record(steps...); err != nil && m.logger != nil {
+		m.logger.Warn().Err(err).Msg("failed to update transaction journal")
+	}
+}
+
+// TrackPaths records paths the transaction created
+func (m *Manager) TrackPaths(paths ...string) {
+	steps := make([]Step, 0, len(paths))
+	for _, path := range paths {
+		if path != "" {
+			steps = append(steps, Step{Action: ActionRemove, Path: path})
+		}
+	}
+	m.Track(steps...)
+}
+
+// closeJournal removes the journal; the caller holds m.mu
+func (m *Manager) closeJournal() {
+	if m.journal == nil {
+		return
+	}
+	if err := m.journal.Close(); err != nil && m.logger != nil {
+		m.logger.Warn().Err(err).Msg("failed to remove transaction journal")
+	}
+	m.journal = nil
+}
+
 // Rollback executes all registered rollback functions in reverse order (LIFO)
 func (m *Manager) Rollback() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if len(m.rollbacks) == 0 {
+		m.closeJournal()
 		return nil
 	}
 
@@ -75,8 +120,11 @@ func (m *Manager) Rollback() error {
 	m.rollbacks = nil
 
 	if len(errs) > 0 {
+		// Keep the journal so 'upkg recover' can retry
+		m.journal = nil
 		return fmt.Errorf("rollback completed with errors: %v", errs)
 	}
+	m.closeJournal()
 	return nil
 }
 
@@ -85,4 +133,12 @@ func (m *Manager) Commit() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.rollbacks = nil
+	m.closeJournal()
+}
+
+// Pending reports whether Rollback would undo anything
+func (m *Manager) Pending() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.rollbacks) > 0
 }
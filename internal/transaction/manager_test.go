@@ -188,3 +188,15 @@ func TestRollbackWithMultipleErrors(t *testing.T) {
 	assert.Contains(t, err.Error(), "rollback completed with errors")
 	assert.Equal(t, 0, len(manager.rollbacks))
 }
+
+func TestPending(t *testing.T) {
+	logger := zerolog.Nop()
+	manager := NewManager(&logger)
+	assert.False(t, manager.Pending())
+
+	manager.Add("op", func() error { return nil })
+	assert.True(t, manager.Pending())
+
+	manager.Commit()
+	assert.False(t, manager.Pending())
+}
@@ -50,21 +50,33 @@ func InitColors() {
 
 // PrintSuccess prints a success message
 func PrintSuccess(format string, args ...interface{}) {
+	if emitMessage(OutputMessage, LevelSuccess, format, args...) {
+		return
+	}
 	Success.Fprintf(os.Stdout, "%s %s\n", CheckMark, fmt.Sprintf(format, args...))
 }
 
 // PrintError prints an error message
 func PrintError(format string, args ...interface{}) {
+	if emitMessage(OutputMessage, LevelError, format, args...) {
+		return
+	}
 	Error.Fprintf(os.Stderr, "%s Error: %s\n", CrossMark, fmt.Sprintf(format, args...))
 }
 
 // PrintWarning prints a warning message
 func PrintWarning(format string, args ...interface{}) {
+	if emitMessage(OutputMessage, LevelWarning, format, args...) {
+		return
+	}
 	Warning.Fprintf(os.Stderr, "Warning: %s\n", fmt.Sprintf(format, args...))
 }
 
 // PrintInfo prints an info message
 func PrintInfo(format string, args ...interface{}) {
+	if emitMessage(OutputMessage, LevelInfo, format, args...) {
+		return
+	}
 	Info.Fprintf(os.Stdout, "%s %s\n", Arrow, fmt.Sprintf(format, args...))
 }
 
@@ -93,6 +105,9 @@ func PrintSeparator() {
 
 // PrintHeader prints a section header
 func PrintHeader(text string) {
+	if emitMessage(OutputPhase, "", "%s", text) {
+		return
+	}
 	fmt.Fprintln(os.Stdout)
 	Bold.Fprintln(os.Stdout, text)
 	Muted.Fprintln(os.Stdout, "────────────────────────────────────────")
@@ -100,6 +115,9 @@ func PrintHeader(text string) {
 
 // PrintSubheader prints a subsection header
 func PrintSubheader(text string) {
+	if emitMessage(OutputPhase, "", "%s", text) {
+		return
+	}
 	fmt.Fprintln(os.Stdout)
 	Highlight.Fprintln(os.Stdout, text)
 }
@@ -0,0 +1,23 @@
+package ui
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrNonInteractive is returned by prompts in non-interactive mode
+var ErrNonInteractive = errors.New("a prompt is required but upkg runs with --non-interactive (pass --yes where supported)")
+
+var nonInteractive atomic.Bool
+
+// SetNonInteractive turns prompts and progress bars off for the rest of the
+// process (--non-interactive): prompts fail with ErrNonInteractive instead of
+// waiting for input
+func SetNonInteractive(on bool) {
+	nonInteractive.Store(on)
+}
+
+// NonInteractive reports whether prompts and progress bars are turned off
+func NonInteractive() bool {
+	return nonInteractive.Load()
+}
@@ -0,0 +1,195 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	multiProgressBarWidth = 20
+	multiProgressRefresh  = 100 * time.Millisecond
+)
+
+// taskState is the lifecycle of one MultiProgress row
+type taskState int
+
+const (
+	taskQueued taskState = iota
+	taskRunning
+	taskDone
+	taskFailed
+)
+
+// progressRow is one task drawn by MultiProgress
+type progressRow struct {
+	label   string
+	state   taskState
+	percent float64
+	message string
+}
+
+// MultiProgress draws one status line per concurrent task. On a terminal
+// the block is redrawn in place; otherwise each state change is printed as
+// a plain line.
+type MultiProgress struct {
+	mu       sync.Mutex
+	out      io.Writer
+	rows     []*progressRow
+	width    int
+	live     bool
+	drawn    int  // Lines drawn by the last render
+	dirty    bool // Rows changed since the last render
+	stop     chan struct{}
+	finished chan struct{}
+}
+
+// NewMultiProgress creates a renderer with one queued row per label
+func NewMultiProgress(out io.Writer, labels []string, live bool) *MultiProgress {
+	m := &MultiProgress{out: out, live: live}
+	for _, label := range labels {
+		m.rows = append(m.rows, &progressRow{label: label})
+		m.width = max(m.width, len(label))
+	}
+	if live {
+		m.stop = make(chan struct{})
+		m.finished = make(chan struct{})
+		m.render()
+		go m.refresh()
+	}
+	return m
+}
+
+// Start marks task i as running
+func (m *MultiProgress) Start(i int) {
+	m.update(i, func(row *progressRow) {
+		row.state = taskRunning
+		row.message = "starting"
+	})
+	if !m.live {
+		m.printLine(i)
+	}
+}
+
+// Done marks task i as finished, failed when err is not nil
+func (m *MultiProgress) Done(i int, err error) {
+	m.update(i, func(row *progressRow) {
+		if err != nil {
+			row.state = taskFailed
+			row.message = err.Error()
+			return
+		}
+		row.state = taskDone
+		row.percent = 100
+		row.message = "done"
+	})
+	if !m.live {
+		m.printLine(i)
+	}
+}
+
+// Sink returns a ProgressSink that feeds progress events into task i
+func (m *MultiProgress) Sink(i int) ProgressSink {
+	return func(event ProgressEvent) {
+		m.update(i, func(row *progressRow) {
+			if row.state != taskRunning {
+				return
+			}
+			row.percent = event.Percent
+			switch {
+			case event.Message != "":
+				row.message = event.Message
+			case event.Phase != "":
+				row.message = event.Phase
+			case event.Operation != "":
+				row.message = event.Operation
+			}
+		})
+	}
+}
+
+// Stop draws the final state and stops the refresh loop
+func (m *MultiProgress) Stop() {
+	if !m.live {
+		return
+	}
+	close(m.stop)
+	<-m.finished
+	m.render()
+}
+
+func (m *MultiProgress) update(i int, fn func(row *progressRow)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if i < 0 || i >= len(m.rows) {
+		return
+	}
+	fn(m.rows[i])
+	m.dirty = true
+}
+
+func (m *MultiProgress) refresh() {
+	ticker := time.NewTicker(multiProgressRefresh)
+	defer ticker.Stop()
+	defer close(m.finished)
+	for {
+		select {
+		case <-ticker.C:
+			m.render()
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// render redraws every row in place
+func (m *MultiProgress) render() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.drawn > 0 && !m.dirty {
+		return
+	}
+
+	var b strings.Builder
+	if m.drawn > 0 {
+		fmt.Fprintf(&b, "\033[%dA", m.drawn)
+	}
+	for _, row := range m.rows {
+		b.WriteString(ansiClearLine)
+		b.WriteString(m.formatRow(row))
+		b.WriteByte('\n')
+	}
+	_, _ = io.WriteString(m.out, b.String())
+	m.drawn = len(m.rows)
+	m.dirty = false
+}
+
+// printLine writes the current state of task i as a single line
+func (m *MultiProgress) printLine(i int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, _ = fmt.Fprintln(m.out, m.formatRow(m.rows[i]))
+}
+
+func (m *MultiProgress) formatRow(row *progressRow) string {
+	label := fmt.Sprintf("%-*s", m.width, row.label)
+	switch row.state {
+	case taskQueued:
+		return fmt.Sprintf("  %s %s", label, Info.Sprint("queued"))
+	case taskDone:
+		return fmt.Sprintf("%s %s %s", Success.Sprint(CheckMark), label, progressBar(100))
+	case taskFailed:
+		return fmt.Sprintf("%s %s %s", Error.Sprint(CrossMark), label, Error.Sprint(row.message))
+	default:
+		return fmt.Sprintf("%s %s %s %3.0f%% %s", Info.Sprint(Arrow), label, progressBar(row.percent), row.percent, row.message)
+	}
+}
+
+// progressBar renders percent as a fixed-width bar
+func progressBar(percent float64) string {
+	filled := int(percent / 100 * multiProgressBarWidth)
+	filled = min(max(filled, 0), multiProgressBarWidth)
+	return "[" + strings.Repeat("=", filled) + strings.Repeat(" ", multiProgressBarWidth-filled) + "]"
+}
@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMultiProgress_Lines(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewMultiProgress(&buf, []string{"app.AppImage", "tool.deb"}, false)
+
+	m.Start(0)
+	m.Sink(0)(ProgressEvent{Type: ProgressPercent, Phase: "Extracting", Percent: 50})
+	m.Done(0, nil)
+	m.Start(1)
+	m.Done(1, errors.New("pacman failed"))
+	m.Stop()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want 4: %q", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "app.AppImage") || !strings.Contains(lines[0], "starting") {
+		t.Errorf("start line = %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "[====================]") {
+		t.Errorf("done line = %q", lines[1])
+	}
+	if !strings.Contains(lines[3], "tool.deb") || !strings.Contains(lines[3], "pacman failed") {
+		t.Errorf("failure line = %q", lines[3])
+	}
+}
+
+func TestMultiProgress_SinkUpdatesRunningRow(t *testing.T) {
+	m := NewMultiProgress(&bytes.Buffer{}, []string{"a"}, false)
+
+	m.Sink(0)(ProgressEvent{Percent: 30, Phase: "Downloading"})
+	if m.rows[0].percent != 0 {
+		t.Errorf("queued row updated: %+v", m.rows[0])
+	}
+
+	m.Start(0)
+	m.Sink(0)(ProgressEvent{Percent: 30, Phase: "Downloading"})
+	m.Sink(0)(ProgressEvent{Percent: 40, Phase: "Downloading", Message: "12 MB"})
+	if m.rows[0].percent != 40 || m.rows[0].message != "12 MB" {
+		t.Errorf("row = %+v", m.rows[0])
+	}
+}
+
+func TestMultiProgress_LiveRedraw(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewMultiProgress(&buf, []string{"a", "b"}, true)
+	m.Start(0)
+	m.Done(0, nil)
+	m.Stop()
+
+	out := buf.String()
+	if !strings.Contains(out, "\033[2A") {
+		t.Errorf("expected cursor-up redraw, got %q", out)
+	}
+	if !strings.Contains(out, "queued") {
+		t.Errorf("expected queued row, got %q", out)
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	if got := progressBar(50); got != "["+strings.Repeat("=", 10)+strings.Repeat(" ", 10)+"]" {
+		t.Errorf("progressBar(50) = %q", got)
+	}
+	if got := progressBar(150); got != "["+strings.Repeat("=", 20)+"]" {
+		t.Errorf("progressBar(150) = %q", got)
+	}
+}
@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Values of the global --output flag
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// OutputEventType identifies the kind of an OutputEvent
+type OutputEventType string
+
+// Types of the events written in JSON output mode
+const (
+	OutputProgress OutputEventType = "progress" // A progress update; see ProgressEvent
+	OutputPhase    OutputEventType = "phase"    // A section of the command started
+	OutputMessage  OutputEventType = "message"  // A status line; Level tells its kind
+	OutputResult   OutputEventType = "result"   // The outcome of the command or of one package
+	OutputError    OutputEventType = "error"    // One package failed
+)
+
+// Levels of message events
+const (
+	LevelSuccess = "success"
+	LevelInfo    = "info"
+	LevelWarning = "warning"
+	LevelError   = "error"
+)
+
+// OutputEvent is one line of JSON output
+type OutputEvent struct {
+	Type     OutputEventType `json:"type"`
+	Command  string          `json:"command,omitempty"`
+	Package  string          `json:"package,omitempty"`
+	Level    string          `json:"level,omitempty"`
+	Message  string          `json:"message,omitempty"`
+	Progress *ProgressEvent  `json:"progress,omitempty"`
+	Data     interface{}     `json:"data,omitempty"`
+	Time     time.Time       `json:"time"`
+}
+
+// EventWriter writes OutputEvents as JSON lines. It is safe for concurrent use.
+type EventWriter struct {
+	mu      sync.Mutex
+	enc     *json.Encoder
+	command string
+}
+
+// NewEventWriter creates a writer of the events of command to w
+func NewEventWriter(w io.Writer, command string) *EventWriter {
+	return &EventWriter{enc: json.NewEncoder(w), command: command}
+}
+
+// Emit writes event, filling in the command and time
+func (e *EventWriter) Emit(event OutputEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if event.Command == "" {
+		event.Command = e.command
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	_ = e.enc.Encode(event)
+}
+
+// ProgressSink returns a sink forwarding the progress of pkg as events
+func (e *EventWriter) ProgressSink(pkg string) ProgressSink {
+	return func(progress ProgressEvent) {
+		e.Emit(OutputEvent{Type: OutputProgress, Package: pkg, Progress: &progress})
+	}
+}
+
+// Phase writes a phase event
+func (e *EventWriter) Phase(name string) {
+	e.Emit(OutputEvent{Type: OutputPhase, Message: name})
+}
+
+// Message writes a message event of the given level
+func (e *EventWriter) Message(level, message string) {
+	e.Emit(OutputEvent{Type: OutputMessage, Level: level, Message: message})
+}
+
+// Result writes the outcome of the command, or of pkg when set
+func (e *EventWriter) Result(pkg string, data interface{}) {
+	e.Emit(OutputEvent{Type: OutputResult, Package: pkg, Data: data})
+}
+
+// Error writes the failure of pkg
+func (e *EventWriter) Error(pkg string, err error) {
+	e.Emit(OutputEvent{Type: OutputError, Package: pkg, Message: err.Error()})
+}
+
+// messageEvents receives the Print* status lines instead of the terminal
+var (
+	messageEventsMu sync.RWMutex
+	messageEvents   *EventWriter
+)
+
+// SetMessageEvents routes PrintSuccess, PrintInfo, PrintWarning, PrintError
+// and the section headers to e as events; nil restores terminal output
+func SetMessageEvents(e *EventWriter) {
+	messageEventsMu.Lock()
+	defer messageEventsMu.Unlock()
+	messageEvents = e
+}
+
+// emitMessage writes a status line as an event when events are enabled
+func emitMessage(eventType OutputEventType, level, format string, args ...interface{}) bool {
+	messageEventsMu.RLock()
+	e := messageEvents
+	messageEventsMu.RUnlock()
+	if e == nil {
+		return false
+	}
+	e.Emit(OutputEvent{Type: eventType, Level: level, Message: fmt.Sprintf(format, args...)})
+	return true
+}
+
+// RedirectStdout points os.Stdout and fatih/color output at stderr, so text
+// printed for humans cannot mix with JSON output, and returns the original
+// stdout
+func RedirectStdout() *os.File {
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	color.Output = os.Stderr
+	return stdout
+}
+
+type eventWriterKey struct{}
+
+// WithEventWriter returns a context carrying the JSON event writer of the
+// running command
+func WithEventWriter(ctx context.Context, e *EventWriter) context.Context {
+	return context.WithValue(ctx, eventWriterKey{}, e)
+}
+
+// EventWriterFromContext returns the event writer attached to ctx, or nil in
+// text output mode
+func EventWriterFromContext(ctx context.Context) *EventWriter {
+	if ctx == nil {
+		return nil
+	}
+	e, _ := ctx.Value(eventWriterKey{}).(*EventWriter)
+	return e
+}
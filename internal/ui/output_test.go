@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func decodeEvents(t *testing.T, buf *bytes.Buffer) []OutputEvent {
+	t.Helper()
+	var events []OutputEvent
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var event OutputEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("line %q is not a JSON event: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	events := NewEventWriter(&buf, "install")
+
+	events.ProgressSink("app.AppImage")(ProgressEvent{Type: ProgressPercent, Phase: "Extracting", Percent: 50})
+	events.Result("app.AppImage", map[string]string{"name": "app"})
+	events.Error("other.deb", errors.New("boom"))
+
+	got := decodeEvents(t, &buf)
+	if len(got) != 3 {
+		t.Fatalf("got %d events, want 3", len(got))
+	}
+	if got[0].Type != OutputProgress || got[0].Progress == nil || got[0].Progress.Percent != 50 {
+		t.Errorf("progress event = %+v", got[0])
+	}
+	if got[1].Type != OutputResult || got[1].Package != "app.AppImage" || got[1].Data.(map[string]interface{})["name"] != "app" {
+		t.Errorf("result event = %+v", got[1])
+	}
+	if got[2].Type != OutputError || got[2].Message != "boom" {
+		t.Errorf("error event = %+v", got[2])
+	}
+	for _, event := range got {
+		if event.Command != "install" || event.Time.IsZero() {
+			t.Errorf("event %+v lacks command or time", event)
+		}
+	}
+}
+
+func TestSetMessageEvents(t *testing.T) {
+	var buf bytes.Buffer
+	SetMessageEvents(NewEventWriter(&buf, "doctor"))
+	defer SetMessageEvents(nil)
+
+	PrintHeader("Summary")
+	PrintSuccess("%s: found", "tar")
+	PrintWarning("cache is stale")
+
+	got := decodeEvents(t, &buf)
+	want := []struct {
+		typ     OutputEventType
+		level   string
+		message string
+	}{
+		{OutputPhase, "", "Summary"},
+		{OutputMessage, LevelSuccess, "tar: found"},
+		{OutputMessage, LevelWarning, "cache is stale"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Type != w.typ || got[i].Level != w.level || got[i].Message != w.message {
+			t.Errorf("event %d = %+v, want %+v", i, got[i], w)
+		}
+	}
+}
@@ -21,6 +21,21 @@ type InstallationPhase struct {
 	Deterministic bool // true = progress bar | false = spinner
 }
 
+// Progress reports installation phases and progress. ProgressTracker renders to
+// the terminal; EventProgress streams ProgressEvents to API clients.
+type Progress interface {
+	StartPhase(phaseIndex int)
+	AdvancePhase()
+	UpdateIndeterminate(message string)
+	UpdateIndeterminateWithElapsed(message string, elapsed time.Duration)
+	SetProgress(current, total int)
+	Finish()
+	Clear()
+	IsEnabled() bool
+}
+
+var _ Progress = (*ProgressTracker)(nil)
+
 // ProgressTracker manages installation progress with hybrid approach
 type ProgressTracker struct {
 	bar            *progressbar.ProgressBar
@@ -39,7 +54,7 @@ type ProgressTracker struct {
 
 // NewProgressTracker creates a new progress tracker with phases
 func NewProgressTracker(phases []InstallationPhase, description string, enabled bool) *ProgressTracker {
-	if !enabled {
+	if !enabled || NonInteractive() {
 		return &ProgressTracker{
 			enabled: false,
 			phases:  phases,
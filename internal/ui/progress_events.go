@@ -0,0 +1,221 @@
+package ui
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ProgressEventType identifies the kind of progress event
+type ProgressEventType string
+
+const (
+	ProgressPhaseStarted   ProgressEventType = "phase_started"
+	ProgressPhaseCompleted ProgressEventType = "phase_completed"
+	ProgressPercent        ProgressEventType = "progress"
+	ProgressMessage        ProgressEventType = "message"
+	ProgressFinished       ProgressEventType = "finished"
+	ProgressCleared        ProgressEventType = "cleared"
+)
+
+// ProgressEvent is a single progress update suitable for serialization
+type ProgressEvent struct {
+	Type        ProgressEventType `json:"type"`
+	Operation   string            `json:"operation,omitempty"`
+	Phase       string            `json:"phase,omitempty"`
+	PhaseIndex  int               `json:"phase_index"`
+	PhaseCount  int               `json:"phase_count"`
+	Percent     float64           `json:"percent"`
+	Message     string            `json:"message,omitempty"`
+	ElapsedSecs float64           `json:"elapsed_secs"`
+	Time        time.Time         `json:"time"`
+}
+
+// ProgressSink receives progress events. It must not block for long.
+type ProgressSink func(ProgressEvent)
+
+// EventProgress implements Progress by emitting events instead of drawing
+type EventProgress struct {
+	mu           sync.Mutex
+	sink         ProgressSink
+	operation    string
+	phases       []InstallationPhase
+	totalWeight  int
+	currentPhase int
+	percent      float64
+	startTime    time.Time
+	finished     bool
+}
+
+var _ Progress = (*EventProgress)(nil)
+
+// NewEventProgress creates a progress reporter that forwards events to sink
+func NewEventProgress(phases []InstallationPhase, operation string, sink ProgressSink) *EventProgress {
+	totalWeight := 0
+	for _, phase := range phases {
+		totalWeight += phase.Weight
+	}
+	return &EventProgress{
+		sink:        sink,
+		operation:   operation,
+		phases:      phases,
+		totalWeight: totalWeight,
+		startTime:   time.Now(),
+	}
+}
+
+// StartPhase emits a phase_started event
+func (p *EventProgress) StartPhase(phaseIndex int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if phaseIndex < 0 || phaseIndex >= len(p.phases) {
+		return
+	}
+	p.currentPhase = phaseIndex
+	p.percent = p.weightPercent(p.completedWeight())
+	p.emit(ProgressPhaseStarted, "")
+}
+
+// AdvancePhase emits phase_completed and starts the next phase, if any
+func (p *EventProgress) AdvancePhase() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.currentPhase < 0 || p.currentPhase >= len(p.phases) {
+		return
+	}
+
+	p.percent = p.weightPercent(p.completedWeight() + p.phases[p.currentPhase].Weight)
+	p.emit(ProgressPhaseCompleted, "")
+
+	p.currentPhase++
+	if p.currentPhase < len(p.phases) {
+		p.emit(ProgressPhaseStarted, "")
+	}
+}
+
+// UpdateIndeterminate emits a message event
+func (p *EventProgress) UpdateIndeterminate(message string) {
+	p.UpdateIndeterminateWithElapsed(message, time.Since(p.startTime))
+}
+
+// UpdateIndeterminateWithElapsed emits a message event with the given elapsed time
+func (p *EventProgress) UpdateIndeterminateWithElapsed(message string, elapsed time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	event := p.event(ProgressMessage, message)
+	event.ElapsedSecs = elapsed.Seconds()
+	p.send(event)
+}
+
+// SetProgress emits a progress event for deterministic phases
+func (p *EventProgress) SetProgress(current, total int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.currentPhase < 0 || p.currentPhase >= len(p.phases) || total <= 0 {
+		return
+	}
+	phase := p.phases[p.currentPhase]
+	if !phase.Deterministic {
+		return
+	}
+
+	p.percent = p.weightPercent(p.completedWeight()) + float64(current)*p.weightPercent(phase.Weight)/float64(total)
+	p.emit(ProgressPercent, "")
+}
+
+// Finish emits the finished event once
+func (p *EventProgress) Finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.finished {
+		return
+	}
+	p.finished = true
+	p.percent = 100
+	p.emit(ProgressFinished, "")
+}
+
+// Clear emits a cleared event
+func (p *EventProgress) Clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.emit(ProgressCleared, "")
+}
+
+// IsEnabled reports whether events are delivered anywhere
+func (p *EventProgress) IsEnabled() bool {
+	return p.sink != nil
+}
+
+func (p *EventProgress) emit(eventType ProgressEventType, message string) {
+	p.send(p.event(eventType, message))
+}
+
+func (p *EventProgress) event(eventType ProgressEventType, message string) ProgressEvent {
+	event := ProgressEvent{
+		Type:        eventType,
+		Operation:   p.operation,
+		PhaseIndex:  p.currentPhase,
+		PhaseCount:  len(p.phases),
+		Percent:     p.percent,
+		Message:     message,
+		ElapsedSecs: time.Since(p.startTime).Seconds(),
+		Time:        time.Now(),
+	}
+	if p.currentPhase >= 0 && p.currentPhase < len(p.phases) {
+		event.Phase = p.phases[p.currentPhase].Name
+	}
+	return event
+}
+
+func (p *EventProgress) send(event ProgressEvent) {
+	if p.sink != nil {
+		p.sink(event)
+	}
+}
+
+func (p *EventProgress) completedWeight() int {
+	total := 0
+	for i := 0; i < p.currentPhase && i < len(p.phases); i++ {
+		total += p.phases[i].Weight
+	}
+	return total
+}
+
+func (p *EventProgress) weightPercent(weight int) float64 {
+	if p.totalWeight == 0 {
+		return 0
+	}
+	return float64(weight) * 100 / float64(p.totalWeight)
+}
+
+type progressSinkKey struct{}
+
+// WithProgressSink returns a context whose progress is streamed to sink
+// instead of being drawn on the terminal (used by API/daemon callers)
+func WithProgressSink(ctx context.Context, sink ProgressSink) context.Context {
+	return context.WithValue(ctx, progressSinkKey{}, sink)
+}
+
+// ProgressSinkFromContext returns the sink attached to ctx, if any
+func ProgressSinkFromContext(ctx context.Context) ProgressSink {
+	if ctx == nil {
+		return nil
+	}
+	sink, _ := ctx.Value(progressSinkKey{}).(ProgressSink)
+	return sink
+}
+
+// NewProgress returns an EventProgress when ctx carries a sink, otherwise a
+// terminal ProgressTracker
+func NewProgress(ctx context.Context, phases []InstallationPhase, description string, enabled bool) Progress {
+	if sink := ProgressSinkFromContext(ctx); sink != nil {
+		return NewEventProgress(phases, description, sink)
+	}
+	return NewProgressTracker(phases, description, enabled)
+}
@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEventProgress(t *testing.T) {
+	phases := []InstallationPhase{
+		{Name: "Extracting", Weight: 40, Deterministic: true},
+		{Name: "Converting", Weight: 60, Deterministic: false},
+	}
+
+	var events []ProgressEvent
+	progress := NewEventProgress(phases, "Installing", func(e ProgressEvent) { events = append(events, e) })
+
+	progress.StartPhase(0)
+	progress.SetProgress(1, 2)
+	progress.AdvancePhase()
+	progress.UpdateIndeterminateWithElapsed("Converting DEB", 3*time.Second)
+	progress.SetProgress(1, 2) // ignored in indeterminate phases
+	progress.Finish()
+	progress.Finish() // only reported once
+
+	want := []struct {
+		typ     ProgressEventType
+		phase   string
+		percent float64
+	}{
+		{ProgressPhaseStarted, "Extracting", 0},
+		{ProgressPercent, "Extracting", 20},
+		{ProgressPhaseCompleted, "Extracting", 40},
+		{ProgressPhaseStarted, "Converting", 40},
+		{ProgressMessage, "Converting", 40},
+		{ProgressFinished, "Converting", 100},
+	}
+
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, w := range want {
+		e := events[i]
+		if e.Type != w.typ || e.Phase != w.phase || e.Percent != w.percent {
+			t.Errorf("event %d = {%s %s %.0f}, want {%s %s %.0f}", i, e.Type, e.Phase, e.Percent, w.typ, w.phase, w.percent)
+		}
+		if e.Operation != "Installing" || e.PhaseCount != 2 {
+			t.Errorf("event %d has operation %q and phase count %d", i, e.Operation, e.PhaseCount)
+		}
+	}
+	if events[4].Message != "Converting DEB" || events[4].ElapsedSecs != 3 {
+		t.Errorf("message event = %+v", events[4])
+	}
+}
+
+func TestNewProgress_UsesContextSink(t *testing.T) {
+	phases := []InstallationPhase{{Name: "Only", Weight: 100, Deterministic: true}}
+
+	if _, ok := NewProgress(context.Background(), phases, "x", false).(*ProgressTracker); !ok {
+		t.Error("NewProgress without sink should return a terminal ProgressTracker")
+	}
+
+	var got int
+	ctx := WithProgressSink(context.Background(), func(ProgressEvent) { got++ })
+	progress := NewProgress(ctx, phases, "x", false)
+	if _, ok := progress.(*EventProgress); !ok {
+		t.Fatal("NewProgress with sink should return an EventProgress")
+	}
+	if !progress.IsEnabled() {
+		t.Error("EventProgress with a sink should be enabled")
+	}
+	progress.StartPhase(0)
+	progress.Finish()
+	if got != 2 {
+		t.Errorf("sink received %d events, want 2", got)
+	}
+}
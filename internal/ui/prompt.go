@@ -12,6 +12,9 @@ import (
 
 // ConfirmPrompt asks a yes/no confirmation question
 func ConfirmPrompt(label string) (bool, error) {
+	if NonInteractive() {
+		return false, ErrNonInteractive
+	}
 	prompt := promptui.Prompt{
 		Label:     label,
 		IsConfirm: true,
@@ -31,6 +34,9 @@ func ConfirmPrompt(label string) (bool, error) {
 
 // SelectPrompt presents a list of options for selection
 func SelectPrompt(label string, items []string) (int, string, error) {
+	if NonInteractive() {
+		return -1, "", ErrNonInteractive
+	}
 	prompt := promptui.Select{
 		Label: label,
 		Items: items,
@@ -49,6 +55,9 @@ func SelectPrompt(label string, items []string) (int, string, error) {
 
 // InputPrompt asks for text input with optional validation
 func InputPrompt(label string, defaultValue string, validate func(string) error) (string, error) {
+	if NonInteractive() {
+		return "", ErrNonInteractive
+	}
 	prompt := promptui.Prompt{
 		Label:    label,
 		Default:  defaultValue,
@@ -75,6 +84,9 @@ type SelectOption struct {
 
 // SelectPromptDetailed presents options with details
 func SelectPromptDetailed(label string, options []SelectOption) (int, SelectOption, error) {
+	if NonInteractive() {
+		return -1, SelectOption{}, ErrNonInteractive
+	}
 	templates := &promptui.SelectTemplates{
 		Label:    "{{ . }}?",
 		Active:   "▸ {{ .Label | cyan }} ({{ .Detail | faint }})",
@@ -105,6 +117,9 @@ func MultiSelectPrompt(label string, items []string) ([]string, error) {
 	if len(items) == 0 {
 		return nil, nil
 	}
+	if NonInteractive() {
+		return nil, ErrNonInteractive
+	}
 
 	var selected []string
 
@@ -131,6 +146,9 @@ func MultiSelectPrompt(label string, items []string) ([]string, error) {
 // MultiSelectPromptLegacy presents a multi-select list using the old sequential selection method
 // Kept for backwards compatibility if needed
 func MultiSelectPromptLegacy(label string, items []string) ([]string, error) {
+	if NonInteractive() {
+		return nil, ErrNonInteractive
+	}
 	selected := make([]string, 0)
 	availableItems := make([]string, len(items)+1)
 	copy(availableItems, items)
@@ -201,6 +219,9 @@ func minInt(a, b int) int {
 
 // PasswordPrompt asks for password input (masked)
 func PasswordPrompt(label string) (string, error) {
+	if NonInteractive() {
+		return "", ErrNonInteractive
+	}
 	prompt := promptui.Prompt{
 		Label: label,
 		Mask:  '*',
@@ -256,6 +277,9 @@ func ConfirmDangerousAction(action string, target string) (bool, error) {
 
 // ConfirmWithDefault asks for confirmation with a default value
 func ConfirmWithDefault(label string, defaultYes bool) (bool, error) {
+	if NonInteractive() {
+		return false, ErrNonInteractive
+	}
 	var defaultStr string
 	if defaultYes {
 		defaultStr = "Y/n"
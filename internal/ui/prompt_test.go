@@ -217,3 +217,21 @@ func TestConfirmPrompt(t *testing.T) {
 	// This test verifies the function exists and has the right signature
 	_ = ConfirmPrompt
 }
+
+func TestPromptsNonInteractive(t *testing.T) {
+	SetNonInteractive(true)
+	defer SetNonInteractive(false)
+
+	if _, err := ConfirmPrompt("Continue"); !errors.Is(err, ErrNonInteractive) {
+		t.Errorf("ConfirmPrompt() error = %v, want ErrNonInteractive", err)
+	}
+	if confirmed, err := ConfirmWithDefault("Continue", true); confirmed || !errors.Is(err, ErrNonInteractive) {
+		t.Errorf("ConfirmWithDefault() = %v, %v, want false, ErrNonInteractive", confirmed, err)
+	}
+	if _, err := MultiSelectPrompt("Pick", []string{"a"}); !errors.Is(err, ErrNonInteractive) {
+		t.Errorf("MultiSelectPrompt() error = %v, want ErrNonInteractive", err)
+	}
+	if tracker := NewProgressTracker([]InstallationPhase{{Name: "work", Weight: 1}}, "", true); tracker.IsEnabled() {
+		t.Error("progress bars are drawn in non-interactive mode")
+	}
+}
@@ -0,0 +1,135 @@
+// Package versions compares package version strings such as release tags.
+package versions
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// coreRegex finds the dotted numeric core of a version, skipping prefixes
+// like "v" or "app-"
+var coreRegex = regexp.MustCompile(`\d+(?:\.\d+)*`)
+
+// dottedRegex finds a version in free text such as "--version" output; at
+// least one dot is required so build numbers and years are skipped
+var dottedRegex = regexp.MustCompile(`\d+(?:\.\d+)+(?:-[0-9A-Za-z.]+)?`)
+
+// Extract returns the first dotted version in text ("NVIM v0.10.2" -> "0.10.2"),
+// or "" when there is none
+func Extract(text string) string {
+	return dottedRegex.FindString(text)
+}
+
+// Version is a parsed version: numeric core plus optional pre-release identifiers
+type Version struct {
+	Core       []int
+	PreRelease []string
+}
+
+// Parse extracts a version from s ("v1.2.3", "app-1.2.3-beta.1", "1.2").
+// ok is false when s contains no number.
+func Parse(s string) (Version, bool) {
+	loc := coreRegex.FindStringIndex(s)
+	if loc == nil {
+		return Version{}, false
+	}
+
+	var v Version
+	for _, part := range strings.Split(s[loc[0]:loc[1]], ".") {
+		n, _ := strconv.Atoi(part)
+		v.Core = append(v.Core, n)
+	}
+
+	rest := s[loc[1]:]
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		rest = rest[:i] // Build metadata does not affect ordering
+	}
+	rest = strings.TrimLeft(rest, "-_.~")
+	if rest != "" {
+		v.PreRelease = strings.FieldsFunc(rest, func(r rune) bool {
+			return r == '.' || r == '-' || r == '_'
+		})
+	}
+	return v, true
+}
+
+// Compare orders two version strings (-1, 0, 1). Missing core components
+// count as zero and a pre-release sorts before its release. Strings without
+// a version number compare lexically after all versioned ones.
+func Compare(a, b string) int {
+	va, okA := Parse(a)
+	vb, okB := Parse(b)
+	switch {
+	case !okA && !okB:
+		return strings.Compare(a, b)
+	case !okA:
+		return 1
+	case !okB:
+		return -1
+	}
+	return va.Compare(vb)
+}
+
+// Compare orders v against other (-1, 0, 1)
+func (v Version) Compare(other Version) int {
+	for i := 0; i < len(v.Core) || i < len(other.Core); i++ {
+		if c := compareInts(at(v.Core, i), at(other.Core, i)); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(v.PreRelease) == 0 && len(other.PreRelease) == 0:
+		return 0
+	case len(v.PreRelease) == 0:
+		return 1
+	case len(other.PreRelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.PreRelease) && i < len(other.PreRelease); i++ {
+		if c := compareIdentifiers(v.PreRelease[i], other.PreRelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(v.PreRelease), len(other.PreRelease))
+}
+
+// Newer reports whether candidate is a higher version than current
+func Newer(candidate, current string) bool {
+	return Compare(candidate, current) > 0
+}
+
+// compareIdentifiers orders pre-release identifiers as semver does: numeric
+// identifiers numerically and before alphanumeric ones
+func compareIdentifiers(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func at(values []int, i int) int {
+	if i < len(values) {
+		return values[i]
+	}
+	return 0
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
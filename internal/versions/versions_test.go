@@ -0,0 +1,73 @@
+package versions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2", "1.2.0", 0},
+		{"1.9", "1.10", -1},
+		{"2.0.1", "2.0", 1},
+		{"v1.2.3", "1.2.3", 0},
+		{"app-v2.0.0", "v1.9.9", 1},
+		{"1.0.0-beta.1", "1.0.0", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.10", -1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0-1", "1.0.0-rc", -1},
+		{"1.0.0-rc.1", "1.0.0-rc.1.1", -1},
+		{"1.0.0+build.5", "1.0.0", 0},
+		{"nightly", "1.0.0", 1},
+		{"", "", 0},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Compare(tt.a, tt.b), "Compare(%q, %q)", tt.a, tt.b)
+		assert.Equal(t, -tt.want, Compare(tt.b, tt.a), "Compare(%q, %q)", tt.b, tt.a)
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	v, ok := Parse("release-v3.14.2-rc.1+abc")
+	assert.True(t, ok)
+	assert.Equal(t, []int{3, 14, 2}, v.Core)
+	assert.Equal(t, []string{"rc", "1"}, v.PreRelease)
+
+	_, ok = Parse("latest")
+	assert.False(t, ok)
+}
+
+func TestNewer(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, Newer("v1.4.0", "v1.3.9"))
+	assert.False(t, Newer("v1.3.9", "v1.3.9"))
+	assert.False(t, Newer("v1.4.0-beta", "1.4.0"))
+}
+
+func TestExtract(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		text, want string
+	}{
+		{"NVIM v0.10.2\nBuild type: Release", "0.10.2"},
+		{"1.95.3\nf1a4fb10\nx64", "1.95.3"},
+		{"tool version 2.1.0-beta.2 (2024)", "2.1.0-beta.2"},
+		{"build 2024 release", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Extract(tt.text), "Extract(%q)", tt.text)
+	}
+}
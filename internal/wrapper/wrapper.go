@@ -0,0 +1,160 @@
+// Package wrapper renders the launcher scripts upkg writes to the user bin
+// directory. Scripts are produced from a text/template: the built-in one, or
+// a template of the user's own (see Load).
+package wrapper
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/quantmind-br/upkg/internal/security"
+	"github.com/spf13/afero"
+)
+
+// DefaultTemplate is the built-in wrapper template. Electron apps are started
+// from their own directory, the rest are exec'd by path.
+const DefaultTemplate = `#!/bin/bash
+# upkg wrapper script{{if .Electron}} for Electron app{{end}}{{with .SandboxTool}} (sandboxed with {{.}}){{end}}
+{{range .Env}}export {{quote .}}
+{{end}}{{if .Electron}}cd "{{.ExecDir}}"
+{{end}}exec {{with .Sandbox}}{{.}} {{end}}"{{if and .Electron (not .Sandbox)}}./{{.ExecName}}{{else}}{{.ExecPath}}{{end}}"{{if and .Electron .NoSandbox}} --no-sandbox{{end}}{{range .Args}} {{quote .}}{{end}} "$@"
+`
+
+// Config contains configuration for creating a wrapper script
+type Config struct {
+	WrapperPath    string // Path where the wrapper script will be created
+	ExecPath       string // Path to the executable to wrap
+	DisableSandbox bool   // Whether to add --no-sandbox flag for Electron apps
+	// Shell words launching the app inside bwrap or firejail, ending with
+	// "--" (see internal/sandbox); empty runs the app directly
+	Sandbox  []string
+	Args     []string           // Extra arguments passed before the user's
+	Env      []string           // KEY=VALUE pairs exported before the app starts
+	Template *template.Template // nil uses DefaultTemplate
+}
+
+// Data is what wrapper templates are executed with
+type Data struct {
+	ExecPath    string
+	ExecDir     string
+	ExecName    string
+	Electron    bool     // The executable is part of an Electron app
+	NoSandbox   bool     // Electron's own sandbox is disabled (desktop.electron_disable_sandbox)
+	Sandbox     string   // bwrap or firejail command line ending with "--", empty when not sandboxed
+	SandboxTool string   // bwrap or firejail, empty when not sandboxed
+	Args        []string // Extra arguments; print them with quote
+	Env         []string // KEY=VALUE pairs; print them with quote
+}
+
+var defaultTemplate = template.Must(Parse("default", DefaultTemplate))
+
+// Parse parses a wrapper template. Templates can use quote to single-quote a
+// value for bash.
+func Parse(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(template.FuncMap{"quote": quote}).Parse(text)
+}
+
+// Load parses the user template at path, returning nil when there is none
+func Load(fs afero.Fs, path string) (*template.Template, error) {
+	text, err := afero.ReadFile(fs, path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read wrapper template: %w", err)
+	}
+	tmpl, err := Parse(filepath.Base(path), string(text))
+	if err != nil {
+		return nil, fmt.Errorf("parse wrapper template %s: %w", path, err)
+	}
+	return tmpl, nil
+}
+
+// Create writes the wrapper script described by cfg
+func Create(fs afero.Fs, cfg Config) error {
+	content, err := Render(cfg, IsElectronApp(fs, cfg.ExecPath))
+	if err != nil {
+		return err
+	}
+	return afero.WriteFile(fs, cfg.WrapperPath, []byte(content), 0755)
+}
+
+// Render returns the wrapper script content for cfg without touching the filesystem
+func Render(cfg Config, isElectron bool) (string, error) {
+	data := Data{
+		ExecPath:  cfg.ExecPath,
+		ExecDir:   filepath.Dir(cfg.ExecPath),
+		ExecName:  filepath.Base(cfg.ExecPath),
+		Electron:  isElectron,
+		NoSandbox: cfg.DisableSandbox,
+		Sandbox:   strings.Join(cfg.Sandbox, " "),
+		Args:      cfg.Args,
+		Env:       cfg.Env,
+	}
+	if len(cfg.Sandbox) > 0 {
+		data.SandboxTool = cfg.Sandbox[0]
+	}
+
+	tmpl := cfg.Template
+	if tmpl == nil {
+		tmpl = defaultTemplate
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render wrapper template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// IsElectronApp checks if the executable is part of an Electron app
+// by looking for .asar files in the executable's directory structure
+func IsElectronApp(fs afero.Fs, execPath string) bool {
+	execDir := filepath.Dir(execPath)
+
+	// Check for resources/app.asar (typical Electron structure)
+	asarPath := filepath.Join(execDir, "resources", "app.asar")
+	if _, err := fs.Stat(asarPath); err == nil {
+		return true
+	}
+
+	// Check for *.asar in parent directory and subdirectories
+	parentDir := filepath.Dir(execDir)
+	var asarFound bool
+	if walkErr := filepath.Walk(parentDir, func(path string, info os.FileInfo, entryErr error) error {
+		if entryErr != nil {
+			return nil // Continue on errors
+		}
+		if !info.IsDir() && strings.HasSuffix(strings.ToLower(path), ".asar") {
+			asarFound = true
+			return filepath.SkipAll // Found one, stop walking
+		}
+		return nil
+	}); walkErr != nil {
+		// Silently ignore walk errors - this is a best-effort detection
+		return false
+	}
+	return asarFound
+}
+
+// ValidateEnv checks that each of vars is a KEY=VALUE pair
+func ValidateEnv(vars []string) error {
+	for _, raw := range vars {
+		name, value, ok := strings.Cut(raw, "=")
+		if !ok {
+			return fmt.Errorf("invalid env var %q: want KEY=VALUE", raw)
+		}
+		if err := security.ValidateEnvironmentVariable(name, value); err != nil {
+			return fmt.Errorf("invalid env var %q: %w", raw, err)
+		}
+	}
+	return nil
+}
+
+// quote single-quotes s for a bash script
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
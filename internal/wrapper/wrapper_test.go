@@ -0,0 +1,75 @@
+package wrapper
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender_ElectronWithArgsAndEnv(t *testing.T) {
+	t.Parallel()
+
+	content, err := Render(Config{
+		ExecPath:       "/apps/editor/editor",
+		DisableSandbox: true,
+		Args:           []string{"--enable-features=UseOzonePlatform"},
+		Env:            []string{"EDITOR_HOME=/data"},
+	}, true)
+	require.NoError(t, err)
+	assert.Equal(t, `#!/bin/bash
+# upkg wrapper script for Electron app
+export 'EDITOR_HOME=/data'
+cd "/apps/editor"
+exec "./editor" --no-sandbox '--enable-features=UseOzonePlatform' "$@"
+`, content)
+}
+
+func TestRender_Sandboxed(t *testing.T) {
+	t.Parallel()
+
+	content, err := Render(Config{
+		ExecPath: "/apps/tool/tool",
+		Sandbox:  []string{"firejail", "--quiet", "--"},
+		Args:     []string{"--verbose"},
+	}, false)
+	require.NoError(t, err)
+	assert.Equal(t, `#!/bin/bash
+# upkg wrapper script (sandboxed with firejail)
+exec firejail --quiet -- "/apps/tool/tool" '--verbose' "$@"
+`, content)
+}
+
+func TestRender_TemplateError(t *testing.T) {
+	t.Parallel()
+
+	tmpl, err := Parse("broken", "{{.Missing}}")
+	require.NoError(t, err)
+	_, err = Render(Config{ExecPath: "/apps/tool/tool", Template: tmpl}, false)
+	assert.ErrorContains(t, err, "render wrapper template")
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	fs := afero.NewMemMapFs()
+	tmpl, err := Load(fs, "/config/wrapper.tmpl")
+	require.NoError(t, err)
+	assert.Nil(t, tmpl)
+
+	require.NoError(t, afero.WriteFile(fs, "/config/wrapper.tmpl", []byte(`exec {{quote .ExecPath}}`), 0644))
+	tmpl, err = Load(fs, "/config/wrapper.tmpl")
+	require.NoError(t, err)
+	content, err := Render(Config{ExecPath: "/apps/tool/tool", Template: tmpl}, false)
+	require.NoError(t, err)
+	assert.Equal(t, "exec '/apps/tool/tool'", content)
+}
+
+func TestValidateEnv(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, ValidateEnv([]string{"FOO=bar", "EMPTY="}))
+	assert.Error(t, ValidateEnv([]string{"FOO"}))
+	assert.Error(t, ValidateEnv([]string{"lower=1"}))
+}
@@ -0,0 +1,563 @@
+// Package zsync is a zsync client: it rebuilds a new release of a file from
+// the blocks an older copy (the seed) already has and downloads only the
+// missing ones with HTTP range requests. AppImages publish a .zsync control
+// file beside each release for this, listing a weak rolling checksum and a
+// truncated MD4 for every block of the new file.
+package zsync
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1" //nolint:gosec // zsync control files identify the file by SHA-1
+	"debug/elf"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// maxControlSize bounds the control file download (a 4 GiB file in 2 KiB
+// blocks needs about 40 MiB of checksums)
+const maxControlSize = 64 << 20
+
+// mergeGap is the largest run of present blocks fetched anyway to join two
+// missing ranges into one request
+const mergeGap = 8
+
+// ErrRangesUnsupported is returned when the server ignores range requests,
+// so a delta update would download the whole file anyway
+var ErrRangesUnsupported = errors.New("server does not support range requests")
+
+// blockSum holds the checksums of one block of the target file
+type blockSum struct {
+	weak   uint32 // Rolling checksum, masked to the stored bytes
+	strong []byte // Leading bytes of the block's MD4
+}
+
+// Control is a parsed .zsync control file
+type Control struct {
+	Filename      string
+	Blocksize     int
+	Length        int64
+	SeqMatches    int      // Consecutive blocks that must match together (1 or 2)
+	RsumBytes     int      // Stored bytes of each weak checksum (1-4)
+	ChecksumBytes int      // Stored bytes of each MD4 (3-16)
+	URLs          []string // Target file URLs, possibly relative to the control file
+	SHA1          string   // Hex SHA-1 of the whole target file
+
+	blocks []blockSum
+}
+
+// ParseControl reads a zsync control file: "Key: value" header lines, a
+// blank line, then the checksums of every block
+func ParseControl(r io.Reader) (*Control, error) {
+	br := bufio.NewReader(r)
+	c := &Control{SeqMatches: 1, RsumBytes: 4, ChecksumBytes: 16}
+	var zURL bool
+
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("read zsync header: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed zsync header line %q", line)
+		}
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "Filename":
+			c.Filename = value
+		case "Blocksize":
+			c.Blocksize, err = strconv.Atoi(value)
+		case "Length":
+			c.Length, err = strconv.ParseInt(value, 10, 64)
+		case "Hash-Lengths":
+			parts := strings.Split(value, ",")
+			if len(parts) != 3 {
+				return nil, fmt.Errorf("malformed Hash-Lengths %q", value)
+			}
+			var n [3]int
+			for i, part := range parts {
+				if n[i], err = strconv.Atoi(strings.TrimSpace(part)); err != nil {
+					break
+				}
+			}
+			c.SeqMatches, c.RsumBytes, c.ChecksumBytes = n[0], n[1], n[2]
+		case "URL":
+			c.URLs = append(c.URLs, value)
+		case "Z-URL":
+			zURL = true
+		case "SHA-1":
+			c.SHA1 = strings.ToLower(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("malformed zsync header %s: %w", key, err)
+		}
+	}
+
+	switch {
+	case c.Blocksize <= 0 || c.Blocksize&(c.Blocksize-1) != 0:
+		return nil, fmt.Errorf("invalid zsync block size %d", c.Blocksize)
+	case c.Length < 0:
+		return nil, fmt.Errorf("invalid zsync length %d", c.Length)
+	case c.SeqMatches < 1 || c.SeqMatches > 2 || c.RsumBytes < 1 || c.RsumBytes > 4 || c.ChecksumBytes < 3 || c.ChecksumBytes > 16:
+		return nil, fmt.Errorf("unsupported zsync hash lengths %d,%d,%d", c.SeqMatches, c.RsumBytes, c.ChecksumBytes)
+	case len(c.URLs) == 0 && zURL:
+		return nil, fmt.Errorf("zsync control file only offers a compressed target (Z-URL)")
+	case len(c.URLs) == 0:
+		return nil, fmt.Errorf("zsync control file has no URL")
+	case len(c.SHA1) != sha1.Size*2:
+		return nil, fmt.Errorf("zsync control file has no SHA-1")
+	}
+
+	count := c.blockCount()
+	c.blocks = make([]blockSum, count)
+	record := make([]byte, c.RsumBytes+c.ChecksumBytes)
+	for i := range c.blocks {
+		if _, err := io.ReadFull(br, record); err != nil {
+			return nil, fmt.Errorf("read checksums of block %d of %d: %w", i, count, err)
+		}
+		var weak [4]byte
+		copy(weak[4-c.RsumBytes:], record[:c.RsumBytes])
+		c.blocks[i] = blockSum{
+			weak:   binary.BigEndian.Uint32(weak[:]),
+			strong: bytes.Clone(record[c.RsumBytes:]),
+		}
+	}
+	return c, nil
+}
+
+// blockCount returns the number of blocks of the target file
+func (c *Control) blockCount() int {
+	return int((c.Length + int64(c.Blocksize) - 1) / int64(c.Blocksize))
+}
+
+// weakMask keeps the bytes of a rolling checksum the control file stores
+func (c *Control) weakMask() uint32 {
+	if c.RsumBytes == 4 {
+		return 0xffffffff
+	}
+	return 1<<(8*c.RsumBytes) - 1
+}
+
+// rsum is zsync's rolling checksum of a block: a is the byte sum and b the
+// sum of the running a values, both modulo 2^16
+type rsum struct {
+	a, b uint16
+}
+
+func newRsum(block []byte) rsum {
+	var r rsum
+	for _, c := range block {
+		r.a += uint16(c)
+		r.b += r.a
+	}
+	return r
+}
+
+// roll moves a window of size bytes one byte forward, dropping out and
+// adding in
+func (r *rsum) roll(out, in byte, size int) {
+	r.a += uint16(in) - uint16(out)
+	r.b += r.a - uint16(size)*uint16(out)
+}
+
+func (r rsum) value() uint32 {
+	return uint32(r.a)<<16 | uint32(r.b)
+}
+
+// Stats reports where the bytes of a synced file came from
+type Stats struct {
+	Length     int64 // Size of the new file
+	Reused     int64 // Bytes copied from the seed
+	Downloaded int64 // Bytes fetched from the server, including merged gaps
+}
+
+// Saved returns the share of the file that did not have to be downloaded
+func (s Stats) Saved() float64 {
+	if s.Length <= 0 {
+		return 0
+	}
+	return 1 - float64(min(s.Downloaded, s.Length))/float64(s.Length)
+}
+
+// Options controls a sync
+type Options struct {
+	Client *http.Client // nil uses http.DefaultClient
+	// Progress is called as the file is assembled with the bytes done and
+	// the length of the file; the reused bytes count at once
+	Progress func(done, total int64)
+}
+
+// Sync builds the file described by the control file at controlURL into
+// dest, copying the blocks seed already has and downloading the rest, and
+// verifies its SHA-1. dest is replaced only once complete.
+func Sync(ctx context.Context, controlURL, seed, dest string, opts Options) (Stats, error) {
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	control, err := fetchControl(ctx, client, controlURL)
+	if err != nil {
+		return Stats{}, err
+	}
+	target, err := control.targetURL(controlURL)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	seedFile, err := os.Open(seed)
+	if err != nil {
+		return Stats{}, fmt.Errorf("open seed: %w", err)
+	}
+	defer func() { _ = seedFile.Close() }()
+
+	found, err := control.match(seedFile)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	part := dest + ".zsync-part"
+	out, err := os.OpenFile(part, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
+	if err != nil {
+		return Stats{}, fmt.Errorf("create %s: %w", part, err)
+	}
+	stats, err := control.assemble(ctx, client, target, seedFile, out, found, opts.Progress)
+	if closeErr := out.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("write %s: %w", part, closeErr)
+	}
+	if err == nil {
+		err = control.verify(part)
+	}
+	if err == nil {
+		err = os.Rename(part, dest)
+	}
+	if err != nil {
+		_ = os.Remove(part)
+		return stats, err
+	}
+	return stats, nil
+}
+
+// fetchControl downloads and parses the control file
+func fetchControl(ctx context.Context, client *http.Client, controlURL string) (*Control, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, controlURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create zsync request: %w", err)
+	}
+	req.Header.Set("User-Agent", "upkg")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", controlURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download %s: server returned %s", controlURL, resp.Status)
+	}
+
+	control, err := ParseControl(io.LimitReader(resp.Body, maxControlSize))
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", controlURL, err)
+	}
+	return control, nil
+}
+
+// targetURL resolves the first URL of the control file against controlURL
+func (c *Control) targetURL(controlURL string) (string, error) {
+	base, err := url.Parse(controlURL)
+	if err != nil {
+		return "", fmt.Errorf("parse %s: %w", controlURL, err)
+	}
+	ref, err := url.Parse(c.URLs[0])
+	if err != nil {
+		return "", fmt.Errorf("parse zsync target URL %q: %w", c.URLs[0], err)
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+// match scans seed for blocks of the target and returns, for each block
+// found, its offset in seed
+func (c *Control) match(seed io.Reader) (map[int]int64, error) {
+	bs := c.Blocksize
+	count := c.blockCount()
+	mask := c.weakMask()
+
+	// With SeqMatches 2 a block is only looked up together with the next
+	// one, which makes the short stored checksums selective enough
+	seq := c.SeqMatches
+	if count < 2 {
+		seq = 1
+	}
+	key := func(weak ...uint32) uint64 {
+		k := uint64(weak[0] & mask)
+		if len(weak) > 1 {
+			k = k<<32 | uint64(weak[1]&mask)
+		}
+		return k
+	}
+	index := make(map[uint64][]int, count)
+	for i := 0; i+seq <= count; i++ {
+		if seq == 2 {
+			index[key(c.blocks[i].weak, c.blocks[i+1].weak)] = append(index[key(c.blocks[i].weak, c.blocks[i+1].weak)], i)
+		} else {
+			index[key(c.blocks[i].weak)] = append(index[key(c.blocks[i].weak)], i)
+		}
+	}
+
+	found := make(map[int]int64)
+	window := seq * bs
+	r := bufio.NewReaderSize(seed, 1<<20)
+	buf := make([]byte, 0, 4<<20)
+	var base int64 // Seed offset of buf[0]
+	eof := false
+
+	// fill makes buf hold at least n bytes from pos on, unless the seed ends
+	fill := func(pos, n int) (int, error) {
+		if pos > len(buf)/2 && pos > 1<<20 {
+			buf = append(buf[:0], buf[pos:]...)
+			base += int64(pos)
+			pos = 0
+		}
+		for !eof && len(buf)-pos < n {
+			if len(buf) == cap(buf) {
+				buf = append(buf, make([]byte, cap(buf))...)[:len(buf)]
+			}
+			read, err := r.Read(buf[len(buf):cap(buf)])
+			buf = buf[:len(buf)+read]
+			if errors.Is(err, io.EOF) {
+				eof = true
+			} else if err != nil {
+				return pos, fmt.Errorf("read seed: %w", err)
+			}
+		}
+		return pos, nil
+	}
+
+	pos := 0
+	var sums [2]rsum
+	fresh := true
+	for {
+		var err error
+		if pos, err = fill(pos, window+1); err != nil {
+			return nil, err
+		}
+		if len(buf)-pos < window {
+			break
+		}
+		if fresh {
+			for i := 0; i < seq; i++ {
+				sums[i] = newRsum(buf[pos+i*bs : pos+(i+1)*bs])
+			}
+			fresh = false
+		}
+
+		var candidates []int
+		if seq == 2 {
+			candidates = index[key(sums[0].value(), sums[1].value())]
+		} else {
+			candidates = index[key(sums[0].value())]
+		}
+		matched := false
+		if len(candidates) > 0 {
+			strong := md4(buf[pos : pos+bs])
+			for _, i := range candidates {
+				if _, ok := found[i]; !ok && bytes.Equal(strong[:c.ChecksumBytes], c.blocks[i].strong) {
+					found[i] = base + int64(pos)
+					matched = true
+				}
+			}
+		}
+
+		if matched {
+			// Continue after the block, like zsync does
+			pos += bs
+			fresh = true
+			continue
+		}
+		if len(buf)-pos <= window {
+			break
+		}
+		for i := 0; i < seq; i++ {
+			start := pos + i*bs
+			sums[i].roll(buf[start], buf[start+bs], bs)
+		}
+		pos++
+	}
+	return found, nil
+}
+
+// assemble writes the target into out from the found seed blocks and range
+// requests for the rest
+func (c *Control) assemble(ctx context.Context, client *http.Client, target string, seed io.ReaderAt, out *os.File, found map[int]int64, progress func(done, total int64)) (Stats, error) {
+	stats := Stats{Length: c.Length}
+	if err := out.Truncate(c.Length); err != nil {
+		return stats, fmt.Errorf("size output: %w", err)
+	}
+
+	bs := int64(c.Blocksize)
+	buf := make([]byte, bs)
+	var missing []int
+	for i := 0; i < c.blockCount(); i++ {
+		offset, ok := found[i]
+		if !ok {
+			missing = append(missing, i)
+			continue
+		}
+		size := min(bs, c.Length-int64(i)*bs)
+		if _, err := seed.ReadAt(buf[:size], offset); err != nil {
+			return stats, fmt.Errorf("read seed block: %w", err)
+		}
+		if _, err := out.WriteAt(buf[:size], int64(i)*bs); err != nil {
+			return stats, fmt.Errorf("write block: %w", err)
+		}
+		stats.Reused += size
+	}
+	report := func() {
+		if progress != nil {
+			progress(stats.Reused+stats.Downloaded, stats.Length)
+		}
+	}
+	report()
+
+	for _, span := range c.ranges(missing) {
+		if err := fetchRange(ctx, client, target, out, span, func(n int64) {
+			stats.Downloaded += n
+			report()
+		}); err != nil {
+			return stats, err
+		}
+	}
+
+	// Blocks merged into a range were downloaded rather than reused
+	stats.Reused = max(stats.Length-stats.Downloaded, 0)
+	return stats, nil
+}
+
+// byteRange is an inclusive range of target bytes
+type byteRange struct {
+	start, end int64
+}
+
+// ranges turns the missing blocks into byte ranges, joining ranges
+// separated by fewer than mergeGap blocks
+func (c *Control) ranges(missing []int) []byteRange {
+	bs := int64(c.Blocksize)
+	var spans []byteRange
+	for _, i := range missing {
+		start := int64(i) * bs
+		end := min(start+bs, c.Length) - 1
+		if n := len(spans); n > 0 && start-spans[n-1].end-1 <= mergeGap*bs {
+			spans[n-1].end = end
+			continue
+		}
+		spans = append(spans, byteRange{start: start, end: end})
+	}
+	return spans
+}
+
+// fetchRange downloads span of target into out
+func fetchRange(ctx context.Context, client *http.Client, target string, out io.WriterAt, span byteRange, onBytes func(int64)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return fmt.Errorf("create range request: %w", err)
+	}
+	req.Header.Set("User-Agent", "upkg")
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", span.start, span.end))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("download %s: %w", target, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		return ErrRangesUnsupported
+	default:
+		return fmt.Errorf("download %s: server returned %s", target, resp.Status)
+	}
+
+	want := span.end - span.start + 1
+	buf := make([]byte, 256<<10)
+	var done int64
+	for done < want {
+		n, readErr := resp.Body.Read(buf[:min(int64(len(buf)), want-done)])
+		if n > 0 {
+			if _, err := out.WriteAt(buf[:n], span.start+done); err != nil {
+				return fmt.Errorf("write range: %w", err)
+			}
+			done += int64(n)
+			onBytes(int64(n))
+		}
+		if readErr != nil {
+			if errors.Is(readErr, io.EOF) && done == want {
+				break
+			}
+			return fmt.Errorf("download %s: got %d of %d bytes of range %d-%d: %w", target, done, want, span.start, span.end, readErr)
+		}
+	}
+	return nil
+}
+
+// verify compares the SHA-1 of the assembled file with the control file
+func (c *Control) verify(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha1.New() //nolint:gosec // see import
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("hash %s: %w", path, err)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != c.SHA1 {
+		return fmt.Errorf("zsync result does not match the control file: expected SHA-1 %s, got %s", c.SHA1, sum)
+	}
+	return nil
+}
+
+// ReadUpdateInfo returns the update information embedded in an AppImage's
+// .upd_info section, e.g. "zsync|https://example.com/App.AppImage.zsync" or
+// "gh-releases-zsync|owner|repo|latest|App-*x86_64.AppImage.zsync", or ""
+// when it has none
+func ReadUpdateInfo(path string) string {
+	f, err := elf.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = f.Close() }()
+
+	section := f.Section(".upd_info")
+	if section == nil {
+		return ""
+	}
+	data, err := section.Data()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(bytes.TrimRight(data, "\x00")))
+}
+
+// ControlURL returns the control file URL of "zsync|<url>" update
+// information, or "" for other kinds
+func ControlURL(updateInfo string) string {
+	if rest, ok := strings.CutPrefix(updateInfo, "zsync|"); ok {
+		return rest
+	}
+	return ""
+}
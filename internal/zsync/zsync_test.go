@@ -0,0 +1,184 @@
+package zsync
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1" //nolint:gosec // matches the control file format
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMD4(t *testing.T) {
+	t.Parallel()
+
+	// RFC 1320 test suite
+	vectors := map[string]string{
+		"":                              "31d6cfe0d16ae931b73c59d7e0c089c0",
+		"a":                             "bde52cb31de33e46245e05fbdbd6fb24",
+		"abc":                           "a448017aaf21d8525fc10ae87aa6729d",
+		"message digest":                "d9130a8164549fe818874806e1c7014b",
+		"abcdefghijklmnopqrstuvwxyz":    "d79e1c308aa5bbcdeea8ed63df412da9",
+		strings.Repeat("1234567890", 8): "e33b4ddc9c38f2199c3e7b164fcc0536",
+	}
+	for input, want := range vectors {
+		sum := md4([]byte(input))
+		assert.Equal(t, want, hex.EncodeToString(sum[:]), "md4(%q)", input)
+	}
+}
+
+// makeControl builds a control file for data the way zsyncmake does
+func makeControl(data []byte, blocksize, seq, rsumBytes, checksumBytes int, target string) []byte {
+	var buf bytes.Buffer
+	sum := sha1.Sum(data) //nolint:gosec // see import
+	fmt.Fprintf(&buf, "zsync: 0.6.2\nFilename: app.AppImage\nMTime: Tue, 01 Sep 2026 00:00:00 +0000\n")
+	fmt.Fprintf(&buf, "Blocksize: %d\nLength: %d\nHash-Lengths: %d,%d,%d\n", blocksize, len(data), seq, rsumBytes, checksumBytes)
+	fmt.Fprintf(&buf, "URL: %s\nSHA-1: %s\n\n", target, hex.EncodeToString(sum[:]))
+
+	for start := 0; start < len(data); start += blocksize {
+		block := make([]byte, blocksize)
+		copy(block, data[start:min(start+blocksize, len(data))])
+		var weak [4]byte
+		binary.BigEndian.PutUint32(weak[:], newRsum(block).value())
+		strong := md4(block)
+		buf.Write(weak[4-rsumBytes:])
+		buf.Write(strong[:checksumBytes])
+	}
+	return buf.Bytes()
+}
+
+func TestParseControl(t *testing.T) {
+	t.Parallel()
+
+	data := bytes.Repeat([]byte("0123456789"), 500)
+	control, err := ParseControl(bytes.NewReader(makeControl(data, 1024, 2, 2, 5, "app.AppImage")))
+	require.NoError(t, err)
+	assert.Equal(t, "app.AppImage", control.Filename)
+	assert.Equal(t, 1024, control.Blocksize)
+	assert.Equal(t, int64(5000), control.Length)
+	assert.Equal(t, []string{"app.AppImage"}, control.URLs)
+	assert.Len(t, control.blocks, 5)
+
+	_, err = ParseControl(strings.NewReader("zsync: 0.6.2\nBlocksize: 1000\nLength: 10\nURL: x\n\n"))
+	assert.ErrorContains(t, err, "block size")
+	_, err = ParseControl(strings.NewReader("zsync: 0.6.2\nBlocksize: 1024\nLength: 10\nZ-URL: x.gz\n\n"))
+	assert.ErrorContains(t, err, "Z-URL")
+	truncated := makeControl(data, 1024, 2, 2, 5, "x")
+	_, err = ParseControl(bytes.NewReader(truncated[:len(truncated)-3]))
+	assert.ErrorContains(t, err, "checksums")
+}
+
+func TestRsumRoll(t *testing.T) {
+	t.Parallel()
+
+	data := make([]byte, 300)
+	rand.New(rand.NewSource(1)).Read(data)
+	sum := newRsum(data[:64])
+	for i := 1; i+64 <= len(data); i++ {
+		sum.roll(data[i-1], data[i+63], 64)
+		require.Equal(t, newRsum(data[i:i+64]), sum, "offset %d", i)
+	}
+}
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+
+	const blocksize = 2048
+	rng := rand.New(rand.NewSource(42))
+	old := make([]byte, 200*blocksize+123)
+	rng.Read(old)
+
+	// The new release shifts everything by an inserted header, changes a
+	// few blocks in the middle and grows at the end
+	header := make([]byte, 777)
+	rng.Read(header)
+	updated := append(header, old...)
+	rng.Read(updated[60*blocksize : 62*blocksize])
+	tail := make([]byte, 5*blocksize)
+	rng.Read(tail)
+	updated = append(updated, tail...)
+
+	var ranges int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app.AppImage.zsync":
+			_, _ = w.Write(makeControl(updated, blocksize, 2, 3, 6, "app.AppImage"))
+		case "/app.AppImage":
+			if r.Header.Get("Range") != "" {
+				ranges++
+			}
+			http.ServeContent(w, r, "app.AppImage", time.Time{}, bytes.NewReader(updated))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	seed := filepath.Join(dir, "old.AppImage")
+	dest := filepath.Join(dir, "new.AppImage")
+	require.NoError(t, os.WriteFile(seed, old, 0755))
+
+	var last int64
+	stats, err := Sync(context.Background(), server.URL+"/app.AppImage.zsync", seed, dest, Options{
+		Progress: func(done, total int64) {
+			assert.GreaterOrEqual(t, done, last)
+			assert.Equal(t, int64(len(updated)), total)
+			last = done
+		},
+	})
+	require.NoError(t, err)
+
+	got, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(updated, got), "the synced file matches the new release")
+	assert.Equal(t, int64(len(updated)), stats.Length)
+	assert.Equal(t, stats.Length, stats.Reused+stats.Downloaded)
+	assert.Less(t, stats.Downloaded, int64(12*blocksize), "only changed blocks are downloaded")
+	assert.Greater(t, stats.Saved(), 0.9)
+	assert.Equal(t, int64(len(updated)), last)
+	assert.Positive(t, ranges)
+	assert.NoFileExists(t, dest+".zsync-part")
+}
+
+func TestSync_RangesUnsupported(t *testing.T) {
+	t.Parallel()
+
+	data := make([]byte, 10*1024)
+	rand.New(rand.NewSource(7)).Read(data)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".zsync") {
+			_, _ = w.Write(makeControl(data, 1024, 1, 4, 16, "/files/app.AppImage"))
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	seed := filepath.Join(dir, "seed")
+	require.NoError(t, os.WriteFile(seed, []byte("unrelated"), 0644))
+
+	_, err := Sync(context.Background(), server.URL+"/app.AppImage.zsync", seed, filepath.Join(dir, "out"), Options{})
+	assert.ErrorIs(t, err, ErrRangesUnsupported)
+	assert.NoFileExists(t, filepath.Join(dir, "out"))
+}
+
+func TestControlURL(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "https://example.com/App.AppImage.zsync", ControlURL("zsync|https://example.com/App.AppImage.zsync"))
+	assert.Empty(t, ControlURL("gh-releases-zsync|owner|repo|latest|App-*.AppImage.zsync"))
+	assert.Empty(t, ReadUpdateInfo("/nonexistent"))
+}